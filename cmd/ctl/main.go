@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// flattenTimeout bounds a flatten run so a hung exchange call can't block the operator forever
+// flattenTimeout 限制一键平仓的运行时间，避免交易所请求卡住时无限等待
+const flattenTimeout = 2 * time.Minute
+
+// Control commands that act on the account outside the trading loop.
+// 在交易循环之外操作账户的控制命令。
+func main() {
+	// --yes and --json may appear anywhere in the arguments
+	// --yes 和 --json 可以出现在参数的任意位置
+	var confirmed, jsonOutput bool
+	var args []string
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--yes":
+			confirmed = true
+		case "--json":
+			jsonOutput = true
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "flatten":
+		reason := "ctl 一键平仓"
+		if len(args) >= 2 {
+			reason = fmt.Sprintf("ctl 一键平仓: %s", strings.Join(args[1:], " "))
+		}
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "flatten closes every position with market orders; re-run with --yes to confirm")
+			os.Exit(1)
+		}
+		os.Exit(handleFlatten(reason, jsonOutput))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// handleFlatten closes every open position of the account and returns the exit code
+// handleFlatten 平掉账户的所有持仓并返回退出码
+func handleFlatten(reason string, jsonOutput bool) int {
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	if cfg.IsAnalysisOnly() {
+		fmt.Fprintln(os.Stderr, "No Binance API key configured: there is no account to flatten")
+		return 1
+	}
+
+	logger.Init(cfg.DebugMode, cfg.LogFormat)
+	log := logger.Global
+
+	db, err := storage.NewStorage(cfg.DatabasePath)
+	if err != nil {
+		log.Error(fmt.Sprintf("初始化数据库失败: %v", err))
+		return 1
+	}
+	defer db.Close()
+
+	executor := executors.NewBinanceExecutor(cfg, log.WithComponent("executor"))
+	if cfg.PaperTrading {
+		executor.EnablePaperTrading(db)
+	}
+	executor.EnableTradeRecording(db)
+	executor.EnableSymbolRegistry(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), flattenTimeout)
+	defer cancel()
+
+	// Register the stored positions so each close is recorded with its P&L
+	// 注册数据库中的持仓，使每次平仓都记录盈亏
+	stopLossManager := executors.NewStopLossManager(cfg, executor, log.WithComponent("stoploss"), db)
+	if _, _, err := stopLossManager.RestoreActivePositions(ctx, true); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  加载活跃持仓失败: %v（仍继续平仓）", err))
+	}
+
+	coordinator := executors.NewTradeCoordinator(cfg, executor, log.WithComponent("coordinator"), stopLossManager)
+	report, flattenErr := coordinator.CloseAllPositions(ctx, reason)
+
+	text := strings.Join(report.Lines(), "\n")
+	if text == "" {
+		text = "暂无持仓"
+	}
+	level := notify.LevelWarning
+	if flattenErr != nil {
+		level = notify.LevelError
+	}
+	if err := notify.NewFromConfig(cfg).Notify(ctx, notify.Message{
+		Title: "一键平仓",
+		Text:  fmt.Sprintf("原因: %s\n\n%s", reason, text),
+		Level: level,
+	}); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+
+	if jsonOutput {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	}
+	if flattenErr != nil {
+		log.Error(fmt.Sprintf("❌ 部分持仓未能平仓: %v", flattenErr))
+		return 1
+	}
+	log.Success(fmt.Sprintf("✅ 一键平仓完成：平仓 %d 个，撤销条件入场单 %d 个、止损/止盈单 %d 个",
+		len(report.Closed), report.CancelledEntries, report.CancelledStops))
+	return 0
+}
+
+func printUsage() {
+	fmt.Println("Usage: ctl <command> [args] [--yes] [--json]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  flatten [REASON]   - Close every open position of the account with market orders,")
+	fmt.Println("                       cancel pending stop entries and stop-loss/take-profit orders")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --yes              - Confirm a command that places orders")
+	fmt.Println("  --json             - Also print the result as JSON")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  ctl flatten --yes")
+	fmt.Println("  ctl flatten exchange incident --yes --json")
+}
@@ -16,7 +16,11 @@ import (
 	"github.com/oak/crypto-trading-bot/internal/constant"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/preflight"
+	"github.com/oak/crypto-trading-bot/internal/risk"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
@@ -29,7 +33,7 @@ func main() {
 	}
 
 	// Initialize logger
-	logger.Init(cfg.DebugMode)
+	logger.Init(cfg.DebugMode, cfg.LogFormat)
 	log := logger.Global
 
 	log.Header("加密货币交易机器人 - Go 版本 (Eino Graph)", '=', 80)
@@ -38,14 +42,28 @@ func main() {
 	log.Info(fmt.Sprintf("回看天数: %d", cfg.CryptoLookbackDays))
 	log.Info(fmt.Sprintf("杠杆倍数: %dx", cfg.BinanceLeverage))
 
-	if cfg.BinanceTestMode {
+	analysisOnly := cfg.IsAnalysisOnly()
+	if analysisOnly {
+		log.Success("🔬 运行模式: 仅分析（未配置币安 API Key，只生成决策，不连接账户、不下单）")
+		if cfg.AutoExecute {
+			log.Warning("⚠️  仅分析模式下忽略 AUTO_EXECUTE=true")
+			cfg.AutoExecute = false
+		}
+	} else if cfg.PaperTrading {
+		log.Success(fmt.Sprintf("📝 运行模式: 模拟盘（初始资金 %.2f USDT，不实际下单）", cfg.PaperInitialBalance))
+	} else if cfg.ShadowMode {
+		log.Success(fmt.Sprintf("👻 运行模式: 影子模式（读取真实账户，只记录意向订单不下单，%d 小时后评估）", cfg.ShadowEvalHours))
+	} else if cfg.BinanceTestMode {
 		log.Success("🟢 运行模式: 测试模式（模拟交易）")
 	} else {
 		log.Warning("🔴 运行模式: 实盘模式（真实交易！）")
 	}
+	if names := plugins.Names(); len(names) > 0 {
+		log.Info(fmt.Sprintf("🔌 已加载插件: %s", strings.Join(names, ", ")))
+	}
 
 	// Initialize executor
-	executor := executors.NewBinanceExecutor(cfg, log)
+	executor := executors.NewBinanceExecutor(cfg, log.WithComponent("executor"))
 
 	// Initialize storage
 	log.Subheader("初始化数据库", '─', 80)
@@ -66,26 +84,77 @@ func main() {
 
 	log.Success(fmt.Sprintf("数据库已连接: %s", cfg.DatabasePath))
 
+	// Compress large report columns and compact rows written before compression was enabled
+	// 压缩大体积报告字段，并压缩启用前写入的历史记录
+	db.SetReportCompression(cfg.ReportCompression)
+	if compacted, err := db.CompressExistingReports(); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  压缩历史报告失败: %v", err))
+	} else if compacted > 0 {
+		log.Info(fmt.Sprintf("已压缩 %d 条历史会话报告", compacted))
+	}
+
+	// Prune sessions beyond the retention policy
+	// 清理超出保留策略的会话
+	if pruned, err := db.PruneSessions(cfg.SessionRetentionCutoff(time.Now()), cfg.SessionRetentionCount); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  清理旧会话失败: %v", err))
+	} else if pruned > 0 {
+		log.Info(fmt.Sprintf("🧹 已清理 %d 条旧会话", pruned))
+	}
+
+	// Notification sinks (Discord / Slack / generic webhook)
+	// 通知渠道（Discord / Slack / 通用 Webhook）
+	notifier := notify.NewFromConfig(cfg)
+	if notifier.Len() > 0 {
+		log.Success(fmt.Sprintf("通知渠道已配置: %d 个", notifier.Len()))
+	}
+
+	// Enable paper trading once storage is available
+	// 数据库就绪后启用模拟盘
+	if cfg.PaperTrading {
+		executor.EnablePaperTrading(db)
+	}
+
+	// Record every live fill in the trades table
+	// 将每笔实盘成交写入 trades 表
+	executor.EnableTradeRecording(db)
+
+	// Persist symbol metadata so restarts reuse the last exchangeInfo refresh
+	// 持久化交易对元数据，重启时复用最近一次 exchangeInfo 刷新结果
+	executor.EnableSymbolRegistry(db)
+
 	// Display statistics for all symbols
 	// 显示所有交易对的统计信息
 	for _, symbol := range cfg.CryptoSymbols {
 		stats, err := db.GetSessionStats(symbol)
 		if err != nil {
 			log.Warning(fmt.Sprintf("获取 %s 历史统计失败: %v", symbol, err))
-		} else if stats["total_sessions"].(int) > 0 {
-			log.Info(fmt.Sprintf("【%s】历史会话: %d, 已执行: %d, 执行率: %.1f%%",
+		} else if stats.TotalSessions > 0 {
+			log.Info(fmt.Sprintf("【%s】历史会话: %d, 已执行: %d, 执行率: %.1f%%, 非 HOLD 决策执行成功率: %.1f%%",
 				symbol,
-				stats["total_sessions"].(int),
-				stats["executed_count"].(int),
-				stats["execution_rate"].(float64)))
+				stats.TotalSessions,
+				stats.ExecutedCount,
+				stats.ExecutionRate,
+				stats.ExecutionSuccessRate))
 		}
 	}
 
 	ctx := context.Background()
 
-	// Initialize and verify LLM service
-	// 初始化并验证 LLM 服务
-	log.Subheader("验证 LLM 服务", '─', 80)
+	// Load order filters (step size, tick size, min notional) from exchangeInfo
+	// 从 exchangeInfo 加载下单过滤规则（数量步长、价格步长、最小订单价值）
+	if err := executor.LoadSymbolFilters(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  加载交易对精度规则失败: %v（将在下单时重试，失败则使用默认精度）", err))
+	} else {
+		for _, symbol := range cfg.CryptoSymbols {
+			f := executor.SymbolFiltersFor(ctx, symbol)
+			log.Info(fmt.Sprintf("【%s】数量步长 %g（最小 %g），价格步长 %g，最小订单价值 %g USDT",
+				symbol, f.StepSize, f.MinQty, f.TickSize, f.MinNotional))
+		}
+	}
+
+	// Initialize LLM service (verified by the pre-flight checks below)
+	// 初始化 LLM 服务（由下方的启动前检查验证）
+	log.Subheader("初始化 LLM 服务", '─', 80)
 
 	llmCfg := &openaiComponent.ChatModelConfig{
 		APIKey:  cfg.APIKey,
@@ -101,43 +170,62 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Test LLM service with a simple call
-	// 使用简单调用测试 LLM 服务
-	log.Info(fmt.Sprintf("🔍 测试 LLM 服务连接..."))
-	log.Info(fmt.Sprintf("   模型: %s", cfg.QuickThinkLLM))
-	log.Info(fmt.Sprintf("   API: %s", cfg.BackendURL))
-
-	testMessages := []*schema.Message{
-		schema.SystemMessage("你是一个测试助手"),
-		schema.UserMessage("请回复：OK"),
+	// Sync the Binance clock offset before the first signed request (the pre-flight check reads the balance)
+	// 在第一次签名请求（启动前检查会读取余额）之前同步币安时钟偏移
+	if !analysisOnly {
+		executors.NewClockMonitor(cfg, executor, notifier, log.WithComponent("clock")).Sync(ctx)
 	}
 
-	testResponse, err := chatModel.Generate(ctx, testMessages)
-	if err != nil {
-		log.Error(fmt.Sprintf("❌ LLM 服务测试失败: %v", err))
-		log.Error(fmt.Sprintf("请检查配置: API=%s, Model=%s", cfg.BackendURL, cfg.QuickThinkLLM))
+	// Pre-flight: check every configured dependency once and fail fast before entering the loop
+	// 启动前检查：统一检查所有已配置的依赖，有问题立即退出，而不是在交易周期中途出错
+	log.Subheader("启动前检查", '─', 80)
+	report := preflight.Run(ctx, []preflight.Check{
+		preflight.LLM(cfg, func(ctx context.Context) error {
+			_, err := chatModel.Generate(ctx, []*schema.Message{
+				schema.SystemMessage("你是一个测试助手"),
+				schema.UserMessage("请回复：OK"),
+			})
+			return err
+		}),
+		preflight.BinanceKeys(cfg, executor.GetBalance),
+		preflight.Symbols(cfg.CryptoSymbols, executor.UnlistedSymbols),
+		preflight.PromptFile(cfg.TraderPromptPath),
+		preflight.Database(cfg.DatabasePath),
+		preflight.Proxy(cfg.BinanceProxy),
+	})
+	log.Info("\n" + report.Table())
+	if !report.Passed() {
+		for _, failure := range report.Failures() {
+			log.Error(fmt.Sprintf("❌ %s: %s", failure.Name, failure.Detail))
+			log.Error(fmt.Sprintf("   💡 %s", failure.Hint))
+		}
 		os.Exit(1)
 	}
-
-	log.Success("✅ LLM 服务可用")
-	if testResponse.ResponseMeta != nil && testResponse.ResponseMeta.Usage != nil {
-		log.Info(fmt.Sprintf("   测试消耗 Token: %d", testResponse.ResponseMeta.Usage.TotalTokens))
-	}
+	log.Success("✅ 启动前检查全部通过")
 
 	// Setup exchange for all symbols
 	// 为所有交易对设置交易所参数
 	log.Subheader("设置交易所参数", '─', 80)
-	for _, symbol := range cfg.CryptoSymbols {
-		if err := executor.SetupExchange(ctx, symbol, cfg.BinanceLeverage); err != nil {
-			log.Error(fmt.Sprintf("设置 %s 交易所失败: %v", symbol, err))
-			os.Exit(1)
+	if analysisOnly {
+		log.Info("🔬 仅分析模式：跳过交易所设置")
+	} else {
+		// Switch the account-wide position mode first, every symbol's setup depends on it
+		// 先切换账户级的持仓模式，各交易对的设置都依赖它
+		if err := executor.ApplyPositionMode(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  切换持仓模式失败: %v（按检测到的持仓模式继续）", err))
+		}
+		for _, symbol := range cfg.CryptoSymbols {
+			if err := executor.SetupExchange(ctx, symbol, cfg.BinanceLeverage); err != nil {
+				log.Error(fmt.Sprintf("设置 %s 交易所失败: %v", symbol, err))
+				os.Exit(1)
+			}
+			log.Success(fmt.Sprintf("✅ %s 交易所设置完成", symbol))
 		}
-		log.Success(fmt.Sprintf("✅ %s 交易所设置完成", symbol))
 	}
 
 	// Check margin type and warn if using isolated margin with dynamic leverage
 	// 检查保证金类型，如果在逐仓模式下使用动态杠杆则发出警告
-	if cfg.BinanceLeverageDynamic && len(cfg.CryptoSymbols) > 0 {
+	if !analysisOnly && cfg.BinanceLeverageDynamic && len(cfg.CryptoSymbols) > 0 {
 		log.Subheader("保证金模式检查", '─', 80)
 		firstSymbol := cfg.CryptoSymbols[0]
 		marginType, err := executor.DetectMarginType(ctx, firstSymbol)
@@ -157,7 +245,7 @@ func main() {
 				log.Warning("   • 这可能导致实际杠杆与 LLM 选择的杠杆不一致")
 				log.Warning("")
 				log.Warning("   💡 建议：")
-				log.Warning("   1. 切换到全仓模式（Binance 网页 → 合约 → 设置 → 保证金模式 → 全仓）")
+				log.Warning("   1. 切换到全仓模式（设置 BINANCE_MARGIN_TYPE=cross，或 Binance 网页 → 合约 → 设置 → 保证金模式 → 全仓）")
 				log.Warning("   2. 或使用固定杠杆（例如 BINANCE_LEVERAGE=10）")
 				log.Warning("")
 			} else {
@@ -178,16 +266,89 @@ func main() {
 
 	// Initialize stop-loss manager (used by trading graph for position info)
 	// 初始化止损管理器（用于交易图的持仓信息）
-	stopLossManager := executors.NewStopLossManager(cfg, executor, log, db)
+	stopLossManager := executors.NewStopLossManager(cfg, executor, log.WithComponent("stoploss"), db)
+
+	// Settle orders whose outcome was lost (e.g. the process died right after sending them)
+	// 确认结果丢失的订单（例如发送后进程立即退出）
+	if report, err := executor.ReconcileOrders(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  订单对账失败: %v", err))
+	} else if report.Checked > 0 {
+		log.Info(fmt.Sprintf("🔄 订单对账: 检查 %d 笔，成交 %d 笔，未送达 %d 笔，仍挂单 %d 笔",
+			report.Checked, len(report.Filled), report.Missing, report.Open))
+		for _, o := range report.Filled {
+			if o.Leg == executors.LegOpen && stopLossManager.GetPosition(o.Symbol) == nil {
+				log.Warning(fmt.Sprintf("🚨 开仓订单 %s（%s %s %.4f）已成交但未被跟踪，请检查持仓和止损",
+					o.ClientOrderID, o.Symbol, o.Side, o.ExecutedQty))
+			}
+		}
+	}
+
+	// Write in-memory price and trade histories to storage before exiting
+	// 退出前将内存中的价格历史和交易结果写入数据库
+	historyFlusher := executors.NewHistoryFlusher(executor, stopLossManager, db, log)
+	defer func() {
+		if err := historyFlusher.Flush(); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  写入历史数据失败: %v", err))
+		}
+	}()
+
+	tradingGraph := agents.NewSimpleTradingGraph(cfg, log.WithComponent("agents"), executor, stopLossManager)
+
+	// Batch ID shared by the sessions of this run, also used to store a late LLM decision and the LLM usage
+	// 本次运行所有会话共享的批次 ID，也用于保存迟到的 LLM 决策和 LLM 用量
+	batchID := fmt.Sprintf("batch-%d", time.Now().Unix())
+	tradingGraph.RecordLateDecisions(db, batchID)
+	tradingGraph.TrackLLMUsage(db, batchID)
+
+	// Overtrading adds "be selective" guidance to the trader prompt
+	// 过度交易时在交易员 Prompt 中加入谨慎开仓提示
+	if freq, err := risk.CheckTradeFrequency(db, cfg, time.Now()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计交易频率失败: %v", err))
+	} else {
+		tradingGraph.SetTradeFrequency(freq)
+	}
+
+	// Intraday loss-streak ladder: entries are halved or halted after consecutive losing trades (LOSS_STREAK_*)
+	// 日内连亏阶梯：连续亏损后开仓减半或停止开仓（LOSS_STREAK_*）
+	var lossStreak *risk.LossStreak
+	if streak, err := risk.CheckLossStreak(db, cfg, time.Now()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计连续亏损失败: %v", err))
+	} else {
+		lossStreak = streak
+		tradingGraph.SetLossStreak(streak)
+	}
+
+	// Circuit breaker: repeated Binance order or LLM failures pause auto-execution (CIRCUIT_BREAKER_*)
+	// 熔断：币安下单或 LLM 连续失败后暂停自动执行（CIRCUIT_BREAKER_*）
+	breaker, err := risk.LoadBreaker(db, cfg)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  读取熔断状态失败: %v", err))
+	}
+
+	// Trading performance for {{.Performance}} in prompt templates
+	// 历史交易表现，供 Prompt 模板中的 {{.Performance}} 使用
+	if stats, err := db.GetTradeStats(""); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计交易表现失败: %v", err))
+	} else {
+		tradingGraph.SetTradeStats(stats)
+	}
 
-	tradingGraph := agents.NewSimpleTradingGraph(cfg, log, executor, stopLossManager)
+	// Recent closed trades are reviewed in the trader prompt (DECISION_MEMORY_TRADES)
+	// 在交易员 Prompt 中复盘近期已平仓交易（DECISION_MEMORY_TRADES）
+	tradingGraph.RecallTrades(db)
+
+	// Candles are cached in the database; only the missing tail is fetched (KLINE_CACHE_ENABLED)
+	// K 线缓存在数据库中，只获取缺失的最新部分（KLINE_CACHE_ENABLED）
+	tradingGraph.CacheKlines(db)
 
 	// ! 启动交易员分析流程
+	cycleStart := time.Now() // 行情快照时间，用于延迟分析 / Market snapshot time for latency analytics
 	result, err := tradingGraph.Run(ctx)
 	if err != nil {
 		log.Error(fmt.Sprintf("工作流执行失败: %v", err))
 		os.Exit(1)
 	}
+	decisionAt := time.Now()
 
 	// Display final results
 	log.Subheader("工作流执行结果", '─', 80)
@@ -201,6 +362,11 @@ func main() {
 
 	// Display agent state
 	state := tradingGraph.GetState()
+	if path := state.GetDecisionPath(); agents.LLMFailed(path) {
+		recordBreakerFailure(ctx, breaker, risk.BreakerLLM, path, notifier, log)
+	} else if err := breaker.RecordSuccess(risk.BreakerLLM); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+	}
 	log.Subheader("分析师报告摘要", '─', 80)
 	for _, symbol := range cfg.CryptoSymbols {
 		reports := state.GetSymbolReports(symbol)
@@ -220,13 +386,21 @@ func main() {
 	// Parse multi-currency decision to extract symbol-specific decisions
 	// 解析多币种决策以提取每个交易对的专属决策
 	symbolDecisions := agents.ParseMultiCurrencyDecision(decision, cfg.CryptoSymbols)
+	decisionSource := agents.DecisionSource(decision, cfg.CryptoSymbols)
 
+	sessionIDs := make(map[string]int64) // 用于将成交关联到会话 / Used to attribute fills to sessions
 	for _, symbol := range cfg.CryptoSymbols {
 		reports := state.GetSymbolReports(symbol)
 		if reports == nil {
 			continue
 		}
 
+		// Check the report facts cited by the decision against the reports it was made from
+		// 将决策引用的报告数据与决策所依据的报告核对
+		if flagged := agents.VerifyCitations(symbolDecisions[symbol], reports); len(flagged) > 0 {
+			log.Warning(fmt.Sprintf("⚠️  %s 决策引用了报告中不存在的数据: %s", symbol, strings.Join(flagged, "；")))
+		}
+
 		// Get symbol-specific decision text
 		// 获取该交易对的专属决策文本
 		symbolDecision := decision // Default to full decision
@@ -246,6 +420,7 @@ func main() {
 		}
 
 		session := &storage.TradingSession{
+			BatchID:         batchID,
 			Symbol:          symbol,
 			Timeframe:       cfg.CryptoTimeframe,
 			CreatedAt:       time.Now(),
@@ -254,8 +429,12 @@ func main() {
 			SentimentReport: reports.SentimentReport,
 			PositionInfo:    reports.PositionInfo,
 			Decision:        symbolDecision, // ✅ Symbol-specific decision instead of full text
+			FullDecision:    decision,       // ✅ Full LLM decision (all symbols)
 			Executed:        false,
 			ExecutionResult: "",
+			SizingPolicy:    executors.SizingPolicyLabel(cfg),
+			DecisionPath:    state.GetDecisionPath(),
+			Structured:      agents.ToStructuredDecision(symbolDecisions[symbol], decisionSource), // ✅ Parsed decision (dual-write)
 		}
 
 		sessionID, err := db.SaveSession(session)
@@ -263,15 +442,84 @@ func main() {
 			log.Error(fmt.Sprintf("保存 %s 会话失败: %v", symbol, err))
 		} else {
 			log.Success(fmt.Sprintf("【%s】会话已保存到数据库 (ID: %d)", symbol, sessionID))
+			sessionIDs[symbol] = sessionID
+
+			// Committee votes behind the decision (ensemble mode only)
+			// 决策对应的委员会投票（仅委员会模式）
+			if votes := state.GetEnsembleVotes(symbol); len(votes) > 0 {
+				if err := db.SaveEnsembleVotes(sessionID, votes); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  保存 %s 委员会投票失败: %v", symbol, err))
+				}
+			}
 		}
 	}
 	log.Info(fmt.Sprintf("数据库路径: %s", cfg.DatabasePath))
 
+	// Verify parsed decisions against the JSON content of the raw output
+	// 校验解析后的决策与原始输出中的 JSON 内容是否一致
+	if checked, diverged, err := agents.NewDecisionVerifier(db, log).Run(50); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  决策校验失败: %v", err))
+	} else if checked > 0 {
+		log.Info(fmt.Sprintf("决策校验: 比对 %d 条，不一致 %d 条", checked, diverged))
+	}
+
+	// Pause order placement while Binance is under maintenance (live trading only, see SystemStatusURL)
+	// 币安系统维护期间暂停下单（仅实盘，见 SystemStatusURL）
+	if cfg.AutoExecute && cfg.MaintenanceCheckInterval > 0 && executors.SystemStatusURL(cfg) != "" {
+		maintenance := executors.NewMaintenanceMonitor(executor, log.WithComponent("maintenance"))
+		if _, err := maintenance.Check(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  检查币安系统状态失败: %v", err))
+		}
+		executor.SetMaintenanceMonitor(maintenance)
+	}
+
+	// Kill switch: the dashboard or the KILL_SWITCH_FILE sentinel halts entries until re-armed
+	// 急停开关：控制台或 KILL_SWITCH_FILE 急停文件停止开仓，直到被解除
+	killSwitch := executors.NewKillSwitch(db, cfg, log.WithComponent("killswitch"))
+	executor.SetKillSwitch(killSwitch)
+
+	// Strategy freeze window: after a prompt / risk change the first cycles only record shadow decisions
+	// 策略冻结观察期：Prompt / 风控配置变更后的前几个周期只记录影子决策
+	var freeze *risk.FreezeStatus
+	if cfg.AutoExecute {
+		status, err := risk.CheckFreeze(db, cfg)
+		if err != nil {
+			// Fail closed: an unchecked configuration may be a new one
+			// 检查失败时按冻结处理：未检查的配置可能是新配置
+			log.Warning(fmt.Sprintf("⚠️  策略版本检查失败，本周期仅以影子模式运行: %v", err))
+			freeze = risk.FailedFreezeCheck(err)
+		} else {
+			freeze = status
+			if freeze.Changed {
+				log.Warning(fmt.Sprintf("🧊 检测到策略配置变更 (%s)，观察期 %d 个周期", freeze.Version.Fingerprint, freeze.Version.FreezeCycles))
+			}
+		}
+	}
+
+	// Shadow mode: score the intended orders whose evaluation horizon has passed
+	// 影子模式：评估已到评估期的意向订单
+	if cfg.ShadowMode && !cfg.IsAnalysisOnly() {
+		evaluateShadowOrders(ctx, executor, db, log)
+	}
+
+	// Link the positions closed since the last cycle back to their opening decision
+	// 将上一周期以来平仓的持仓关联到开仓决策
+	if !cfg.IsAnalysisOnly() {
+		scoreDecisions(db, log)
+	}
+
 	// Auto-execution logic
 	// 自动执行交易逻辑
-	if cfg.AutoExecute {
+	if freeze.Active() {
+		log.Subheader("影子模式", '─', 80)
+		shadowFreezeCycle(ctx, db, cfg, freeze, symbolDecisions, notifier, log)
+	} else if cfg.AutoExecute {
 		log.Subheader("自动执行交易", '─', 80)
 		log.Info("🚀 自动执行模式已启用")
+		if breaker.Open(time.Now()) {
+			log.Error(fmt.Sprintf("⛔ 熔断中，暂停开平仓到 %s: %s（止损单继续有效）",
+				breaker.State.OpenUntil.Local().Format("01-02 15:04"), breaker.State.Reason))
+		}
 
 		// Parse multi-currency decision
 		// 解析多币种决策
@@ -279,7 +527,7 @@ func main() {
 
 		// Initialize portfolio manager
 		// 初始化投资组合管理器
-		portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log)
+		portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log.WithComponent("portfolio"))
 		if err := portfolioMgr.UpdateBalance(ctx); err != nil {
 			log.Error(fmt.Sprintf("获取账户余额失败: %v", err))
 		}
@@ -296,7 +544,44 @@ func main() {
 
 		// Initialize trade coordinator with stop-loss manager
 		// 初始化交易协调器（传入止损管理器）
-		coordinator := executors.NewTradeCoordinator(cfg, executor, log, stopLossManager)
+		coordinator := executors.NewTradeCoordinator(cfg, executor, log.WithComponent("coordinator"), stopLossManager)
+
+		// Kill switch: a newly found sentinel file runs the emergency stop; while engaged only closes execute
+		// 急停开关：新发现急停文件时执行紧急停止；启用期间只执行平仓
+		killState, killEngagedNow, err := killSwitch.Check()
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败，本周期不开仓: %v", err))
+			killState = &executors.KillSwitchState{Engaged: true, Reason: err.Error()}
+		} else if killEngagedNow {
+			coordinator.RespondToKillSwitch(ctx, killState, notifier)
+		}
+		if killState.Engaged {
+			log.Error(fmt.Sprintf("🛑 急停开关已启用: %s，本周期只执行平仓", killState.Reason))
+		}
+
+		// Global risk manager; the daily loss is replayed from today's balance history
+		// 全局风控管理器，单日亏损根据当日余额历史回放计算
+		riskManager := risk.NewManager(risk.LimitsFromConfig(cfg))
+		if history, err := db.GetBalanceHistory(24); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  获取余额历史失败，单日亏损从当前权益开始统计: %v", err))
+		} else {
+			withdrawals, err := db.GetWithdrawalsSince(time.Now().Add(-24 * time.Hour))
+			if err != nil {
+				log.Warning(fmt.Sprintf("⚠️  获取利润提取记录失败，单日亏损可能包含已提取的利润: %v", err))
+			}
+			riskManager.LoadHistory(history, withdrawals)
+		}
+		riskManager.ObserveEquity(portfolioMgr.GetTotalBalance()+portfolioMgr.GetTotalUnrealizedPnL(), time.Now())
+		if riskManager.Halted() {
+			log.Warning(fmt.Sprintf("🛑 当日亏损 %.2f%% 已达上限 %.2f%%，今日停止开仓", riskManager.DailyLossPercent(), cfg.RiskDailyMaxLoss))
+			if err := notifier.Notify(ctx, notify.Message{
+				Title: "风控熔断",
+				Text:  fmt.Sprintf("当日亏损 %.2f%% 已达上限 %.2f%%，今日停止开仓", riskManager.DailyLossPercent(), cfg.RiskDailyMaxLoss),
+				Level: notify.LevelWarning,
+			}); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+			}
+		}
 
 		// Note: Local monitoring disabled - relying on Binance server-side stop-loss orders
 		// 注意：已禁用本地监控 - 完全依赖币安服务器端止损单
@@ -307,13 +592,50 @@ func main() {
 		//   4. 即使本地程序崩溃，币安止损单仍会执行
 		// go stopLossManager.MonitorPositions(10 * time.Second) // 已弃用
 
+		// Pending stop entries: filled ones become managed positions, expired ones are cancelled
+		// 条件入场单：已成交的转为受管持仓，已过期的撤销
+		if filled, err := coordinator.SyncPendingEntries(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  检查条件入场单失败: %v", err))
+		} else {
+			notifyEntryFills(ctx, notifier, filled, log)
+		}
+
 		// Execute trades for each symbol
 		// 为每个交易对执行交易
 		executionResults := make(map[string]string)
+		claimedKlines := make(map[string]time.Time) // 本批次在执行台账中预占的 K 线 / Klines this batch claimed in the execution ledger
+
+		// releaseClaim frees the ledger claim of a failed entry, so the kline can be retried
+		// releaseClaim 释放执行失败的开仓在台账中的预占，使该 K 线可以重试
+		releaseClaim := func(symbol string) {
+			klineOpen, ok := claimedKlines[symbol]
+			if !ok {
+				return
+			}
+			delete(claimedKlines, symbol)
+			if err := db.ReleaseExecution(symbol, cfg.CryptoTimeframe, klineOpen); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  释放 %s 执行台账预占失败: %v", symbol, err))
+			}
+		}
+
+		// Portfolio allocator: entries compete for the free margin instead of executing independently
+		// 组合分配器：开仓决策共同竞争可用保证金，而不是各自独立执行
+		var allocationRejects map[string]string
+		if cfg.AllocatorEnabled {
+			allocationRejects = allocateEntries(ctx, cfg, decisions, portfolioMgr, state, log)
+		}
 
 		for symbol, symbolDecision := range decisions {
+			// Tag this symbol's log entries with its symbol and session (JSON log mode)
+			// 为该交易对的日志附加交易对和会话字段（JSON 日志模式）
+			log := log.WithSymbol(symbol).WithSessionID(sessionIDs[symbol])
+
 			log.Subheader(fmt.Sprintf("处理 %s 交易决策", symbol), '-', 60)
 
+			// Fills of this symbol's orders are attributed to its session
+			// 该交易对订单的成交记录归属到其会话
+			tradeCtx := executors.WithBatchID(executors.WithSessionID(ctx, sessionIDs[symbol]), batchID)
+
 			if !symbolDecision.Valid {
 				log.Warning(fmt.Sprintf("⚠️  %s 决策无效: %s", symbol, symbolDecision.Reason))
 				executionResults[symbol] = fmt.Sprintf("决策无效: %s", symbolDecision.Reason)
@@ -360,6 +682,19 @@ func main() {
 				} else {
 					executionResults[symbol] = "观望，不执行交易"
 				}
+
+				// Partial take-profit requested by LLM (now, or at partial_tp_price)
+				// LLM 要求分批止盈（立即或到达 partial_tp_price 时）
+				if symbolDecision.PartialTPPercent > 0 {
+					tpResult, err := coordinator.ExecutePartialTakeProfit(tradeCtx, symbol,
+						symbolDecision.PartialTPPrice, symbolDecision.PartialTPPercent, symbolDecision.Reason)
+					if err != nil {
+						log.Warning(fmt.Sprintf("⚠️  %s 分批止盈失败: %v", symbol, err))
+						executionResults[symbol] += fmt.Sprintf("；分批止盈失败: %v", err)
+					} else {
+						executionResults[symbol] += "；" + tpResult.Message
+					}
+				}
 				continue
 			}
 
@@ -371,32 +706,234 @@ func main() {
 
 			// Get current position
 			// 获取当前持仓
-			currentPosition, err := executor.GetCurrentPosition(ctx, symbol)
+			positions, err := executor.GetCurrentPositions(ctx, symbol)
 			if err != nil {
 				log.Warning(fmt.Sprintf("⚠️  获取 %s 当前持仓失败: %v", symbol, err))
 			}
 
-			// Validate decision against current position
-			// 验证决策与当前持仓的一致性
-			if err := agents.ValidateDecision(symbolDecision, currentPosition); err != nil {
+			// Execution filters for entries (MIN_DECISION_CONFIDENCE, STOP_OUT_COOLDOWN_MINUTES, MAX_TRADES_PER_DAY);
+			// run before validation so a rejection is saved with the filter that caused it
+			// 开仓执行过滤器（MIN_DECISION_CONFIDENCE、STOP_OUT_COOLDOWN_MINUTES、MAX_TRADES_PER_DAY），
+			// 在验证之前执行，以便被拒绝的决策连同过滤器一起保存
+			if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action) {
+				rejection, err := risk.CheckExecutionFilters(db, cfg, symbol, symbolDecision.Confidence, time.Now())
+				if err != nil {
+					log.Warning(fmt.Sprintf("⚠️  %s 执行过滤检查失败: %v", symbol, err))
+				} else if rejection != nil {
+					log.Warning(fmt.Sprintf("🚫 %s 执行过滤(%s): %s", symbol, rejection.Filter, rejection.Reason))
+					executionResults[symbol] = fmt.Sprintf("🚫 执行过滤(%s): %s", rejection.Filter, rejection.Reason)
+					if sessionID, ok := sessionIDs[symbol]; ok {
+						if err := db.SaveFilterRejection(sessionID, rejection.Filter, rejection.Reason); err != nil {
+							log.Warning(fmt.Sprintf("⚠️  保存 %s 过滤记录失败: %v", symbol, err))
+						}
+					}
+					continue
+				}
+			}
+
+			// Validate decision against current positions (both sides in hedge mode)
+			// 验证决策与当前持仓的一致性（双向持仓模式下包括多空两个方向）
+			if err := agents.ValidateDecision(symbolDecision, positions, agents.ThresholdsFromConfig(cfg)); err != nil {
 				log.Error(fmt.Sprintf("❌ %s 决策验证失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("决策验证失败: %v", err)
 				continue
 			}
 
+			// Latest ATR, used for risk-per-trade sizing and the dynamic trailing stop
+			// 最新 ATR，用于按单笔风险计算仓位和动态追踪止损
+			atrValue := latestATR(state, symbol)
+
+			// Global risk check for opening orders (closing is never refused)
+			// 开仓前进行全局风控检查（平仓不受限制）
+			if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action) {
+				if lossStreak.Halted() {
+					log.Error(fmt.Sprintf("🛑 %s 连亏停止开仓: 本时段已连续亏损 %d 笔", symbol, lossStreak.Longest))
+					executionResults[symbol] = fmt.Sprintf("🛑 连亏停止开仓: 本时段已连续亏损 %d 笔，%s 重置",
+						lossStreak.Longest, lossStreak.SessionEnd().Local().Format("01-02 15:04"))
+					continue
+				}
+				if reason, ok := allocationRejects[symbol]; ok {
+					log.Error(fmt.Sprintf("🛑 %s %s", symbol, reason))
+					executionResults[symbol] = reason
+					continue
+				}
+				if lossStreak.Reduced() && symbolDecision.PositionSizePercent > 0 {
+					halved := symbolDecision.PositionSizePercent * lossStreak.SizeMultiplier()
+					log.Warning(fmt.Sprintf("📉 %s 本时段连续亏损 %d 笔，仓位减半: %.1f%% → %.1f%%",
+						symbol, lossStreak.Current, symbolDecision.PositionSizePercent, halved))
+					symbolDecision.PositionSizePercent = halved
+				}
+
+				openAction := symbolDecision.Action
+				if executors.IsStopEntry(openAction) {
+					openAction = executors.EntryAction(openAction)
+				} else if executors.IsAddOn(openAction) {
+					openAction = executors.AddOnAction(openAction)
+				}
+				order, err := portfolioMgr.ProposedOrder(ctx, symbol, openAction,
+					symbolDecision.PositionSizePercent, symbolDecision.Leverage, symbolDecision.StopLoss, atrValue)
+				if err == nil {
+					err = riskManager.CheckOrder(portfolioMgr.RiskSnapshot(stopLossManager), order, executor.IsHedgeMode(ctx))
+				}
+				if err != nil {
+					log.Error(fmt.Sprintf("🛑 %s 风控拒绝: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("🛑 风控拒绝: %v", err)
+					continue
+				}
+			}
+
+			// Deep-think second-pass review (RISK_REVIEW_ENABLED); the veto reason goes to the session
+			// 深度复核（RISK_REVIEW_ENABLED）；否决理由写入会话执行结果
+			if review := state.GetReview(symbol); review != nil && !review.Approved {
+				log.Warning(fmt.Sprintf("🛑 %s 风控复核否决: %s", symbol, review.Reason))
+				executionResults[symbol] = fmt.Sprintf("🛑 风控复核否决: %s", review.Reason)
+				continue
+			}
+
+			// Pipeline timestamps for latency/slippage analytics, priced at the last close the LLM analyzed
+			// 用于延迟/滑点分析的各阶段时间戳，以 LLM 分析的最后收盘价为基准
+			timing := &executors.ExecutionTiming{SignalAt: cycleStart, DecisionAt: decisionAt}
+			var klineOpen time.Time
+			if reports := state.GetSymbolReports(symbol); reports != nil && len(reports.OHLCVData) > 0 {
+				timing.SignalPrice = reports.OHLCVData[len(reports.OHLCVData)-1].Close
+				klineOpen = reports.OHLCVData[len(reports.OHLCVData)-1].Timestamp
+			}
+
+			// Kill switch: entries are skipped, closes still execute
+			// 急停开关：跳过开仓，平仓照常执行
+			if killState.Engaged && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action)) {
+				log.Warning(fmt.Sprintf("🛑 %s 急停开关已启用，跳过 %s", symbol, symbolDecision.Action))
+				executionResults[symbol] = fmt.Sprintf("🛑 急停开关已启用：未执行 %s", symbolDecision.Action)
+				continue
+			}
+
+			// The breaker may also trip mid-loop, pausing the remaining symbols
+			// 熔断也可能在循环中途触发，暂停剩余交易对
+			if breaker.Open(time.Now()) {
+				log.Warning(fmt.Sprintf("⛔ %s 熔断暂停自动执行，跳过 %s", symbol, symbolDecision.Action))
+				executionResults[symbol] = fmt.Sprintf("⛔ 熔断暂停自动执行到 %s：未执行 %s",
+					breaker.State.OpenUntil.Local().Format("01-02 15:04"), symbolDecision.Action)
+				continue
+			}
+
+			// Claim the analyzed kline in the execution ledger before an entry, so a restart mid-loop can't
+			// open it again. Closes, reductions and add-ons are never blocked.
+			// 开仓前在执行台账中预占所分析的 K 线，避免循环中途重启后再次开仓。平仓、减仓和加仓不受限制。
+			switch {
+			case !executors.IsOpening(symbolDecision.Action): // 非开仓动作不预占 / Only entries are claimed
+			case klineOpen.IsZero():
+				log.Warning(fmt.Sprintf("⚠️  %s 没有 K 线数据，无法检查重复执行", symbol))
+			default:
+				existing, err := db.ClaimExecution(&storage.LedgerEntry{
+					Symbol:        symbol,
+					Timeframe:     cfg.CryptoTimeframe,
+					KlineOpenTime: klineOpen,
+					BatchID:       batchID,
+					Action:        string(symbolDecision.Action),
+				})
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 写入执行台账失败，跳过执行: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("执行台账写入失败，跳过执行: %v", err)
+					continue
+				}
+				if existing != nil {
+					log.Warning(fmt.Sprintf("⏭️  %s %s K 线（%s）已由批次 %s 执行 %s，跳过重复执行",
+						symbol, cfg.CryptoTimeframe, klineOpen.Format("01-02 15:04"), existing.BatchID, existing.Action))
+					executionResults[symbol] = fmt.Sprintf("⏭️ 跳过重复执行: 该 K 线已由批次 %s 执行 %s", existing.BatchID, existing.Action)
+					continue
+				}
+				claimedKlines[symbol] = klineOpen
+			}
+
+			// Stop entries rest on the exchange until the price breaks the trigger
+			// 条件入场单挂在交易所，等待价格突破触发价
+			if executors.IsStopEntry(symbolDecision.Action) {
+				leverageToUse := agents.ValidateLeverage(symbolDecision.Leverage, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax, cfg.BinanceLeverageDynamic)
+				entry, err := coordinator.PlaceStopEntry(tradeCtx, symbol, symbolDecision.Action, symbolDecision.EntryPrice,
+					symbolDecision.Reason, leverageToUse, symbolDecision.PositionSizePercent, symbolDecision.StopLoss, atrValue)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 条件入场单失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("条件入场单失败: %v", err)
+					releaseClaim(symbol)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+				} else {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					if entry.Status == storage.EntryStatusShadow {
+						executionResults[symbol] = fmt.Sprintf("👻 影子模式：已记录条件入场单 %s %.4f @ %.2f，未挂单",
+							symbolDecision.Action, entry.Quantity, entry.TriggerPrice)
+					} else {
+						executionResults[symbol] = fmt.Sprintf("⏳ 已挂条件入场单 %s @ %.2f（%s 过期）",
+							symbolDecision.Action, entry.TriggerPrice, entry.ExpiresAt.Format("01-02 15:04"))
+					}
+				}
+				continue
+			}
+
+			// Reductions trim the managed position and keep the rest protected
+			// 减仓平掉部分受管持仓，剩余部分继续受止损保护
+			if executors.IsReduce(symbolDecision.Action) {
+				result, err := coordinator.ExecuteReduce(tradeCtx, symbol, symbolDecision.Action, symbolDecision.ReducePercent, symbolDecision.Reason)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 减仓失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("减仓失败: %v", err)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+					continue
+				}
+				if result.Success {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					executionResults[symbol] = "✅ " + result.Message
+				} else {
+					executionResults[symbol] = result.Message
+				}
+				continue
+			}
+
+			// Add-ons scale into the managed position and re-place its stop over the blended entry
+			// 加仓为受管持仓追加数量，并按混合入场价重挂止损
+			if executors.IsAddOn(symbolDecision.Action) {
+				result, err := coordinator.ExecuteAddOn(tradeCtx, symbol, symbolDecision.Action, symbolDecision.Reason,
+					symbolDecision.PositionSizePercent, symbolDecision.StopLoss, atrValue)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 加仓失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("加仓失败: %v", err)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+					continue
+				}
+				if result.Success {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					executionResults[symbol] = "✅ " + result.Message
+				} else {
+					executionResults[symbol] = result.Message
+				}
+				continue
+			}
+
 			// Execute the trade using coordinator
 			// 使用协调器执行交易
 			result, err := coordinator.ExecuteDecisionWithParams(
-				ctx,
+				executors.WithExecutionTiming(tradeCtx, timing),
 				symbol,
 				symbolDecision.Action,
 				symbolDecision.Reason,
 				symbolDecision.Leverage,
 				symbolDecision.PositionSizePercent,
+				symbolDecision.StopLoss,
+				atrValue,
 			)
 			if err != nil {
 				log.Error(fmt.Sprintf("❌ %s 交易执行失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("执行失败: %v", err)
+				releaseClaim(symbol)
+				recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
 				continue
 			}
 
@@ -406,6 +943,9 @@ func main() {
 
 			if result.Success {
 				executionResults[symbol] = fmt.Sprintf("✅ 成功执行 %s", result.Action)
+				if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+				}
 
 				// Register position for stop-loss management (only for opening positions)
 				// 注册持仓到止损管理器（仅开仓时）
@@ -428,6 +968,9 @@ func main() {
 					// Calculate initial stop-loss if not provided by LLM
 					// 如果 LLM 未提供止损价格，则计算初始止损
 					initialStopLoss := symbolDecision.StopLoss
+					if result.StopLoss > 0 {
+						initialStopLoss = result.StopLoss // 插件或外部风控调整后的止损 / Adjusted by a plugin or the risk oracle
+					}
 					if initialStopLoss == 0 {
 						// Use 2.5% default stop-loss
 						// 使用 2.5% 默认止损
@@ -439,21 +982,10 @@ func main() {
 						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 2.5%% 止损: %.2f", initialStopLoss))
 					}
 
-					// Get ATR value from indicators for dynamic trailing stop
-					// 从指标中获取 ATR 值用于动态追踪止损
-					var atrValue float64
-					reports := state.GetSymbolReports(symbol)
-					if reports != nil && reports.TechnicalIndicators != nil {
-						indicators := reports.TechnicalIndicators
-						if len(indicators.ATR) > 0 {
-							// Get latest ATR value
-							lastIdx := len(indicators.ATR) - 1
-							if lastIdx >= 0 && !math.IsNaN(indicators.ATR[lastIdx]) {
-								atrValue = indicators.ATR[lastIdx]
-								atrPercent := (atrValue / result.Price) * 100
-								log.Info(fmt.Sprintf("当前 ATR: %.2f (%.2f%% of price)", atrValue, atrPercent))
-							}
-						}
+					// ATR for the dynamic trailing stop
+					// 动态追踪止损使用的 ATR
+					if atrValue > 0 {
+						log.Info(fmt.Sprintf("当前 ATR: %.2f (%.2f%% of price)", atrValue, atrValue/result.Price*100))
 					}
 
 					// Create position
@@ -486,24 +1018,7 @@ func main() {
 
 					// Save position to database
 					// 保存持仓到数据库
-					posRecord := &storage.PositionRecord{
-						ID:              position.ID,
-						Symbol:          position.Symbol,
-						Side:            position.Side,
-						EntryPrice:      position.EntryPrice,
-						EntryTime:       position.EntryTime,
-						Quantity:        position.Quantity,
-						Leverage:        position.Leverage,
-						InitialStopLoss: position.InitialStopLoss,
-						CurrentStopLoss: position.CurrentStopLoss,
-						StopLossType:    position.StopLossType,
-						HighestPrice:    position.HighestPrice,
-						CurrentPrice:    position.CurrentPrice,
-						OpenReason:      position.OpenReason,
-						ATR:             position.ATR,
-						StopLossOrderID: position.StopLossOrderID, // ✅ 保存止损单 ID
-						Closed:          false,
-					}
+					posRecord := position.Record()
 
 					if err := db.SavePosition(posRecord); err != nil {
 						log.Warning(fmt.Sprintf("⚠️  保存 %s 持仓到数据库失败: %v", symbol, err))
@@ -518,9 +1033,21 @@ func main() {
 					} else {
 						log.Success(fmt.Sprintf("✅ 初始止损单已下达: %.2f", initialStopLoss))
 					}
+
+					// Partial take-profit plan from LLM
+					// LLM 给出的分批止盈计划
+					if symbolDecision.PartialTPPrice > 0 && symbolDecision.PartialTPPercent > 0 {
+						if err := stopLossManager.SetPartialTakeProfit(symbol, symbolDecision.PartialTPPrice, symbolDecision.PartialTPPercent); err != nil {
+							log.Warning(fmt.Sprintf("⚠️  设置分批止盈失败: %v", err))
+						}
+					}
 				}
+			} else if result.Skipped {
+				executionResults[symbol] = result.Message
 			} else {
 				executionResults[symbol] = fmt.Sprintf("❌ 执行失败: %s", result.Message)
+				releaseClaim(symbol)
+				recordBreakerFailure(ctx, breaker, risk.BreakerExchange, result.Message, notifier, log)
 			}
 		}
 
@@ -564,6 +1091,45 @@ func main() {
 				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
 			}
 		}
+		for symbol, klineOpen := range claimedKlines {
+			if err := db.CompleteExecution(symbol, cfg.CryptoTimeframe, klineOpen, executionResults[symbol]); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行台账失败: %v", symbol, err))
+			}
+		}
+
+		// Notify execution results (skip runs where every symbol held)
+		// 推送执行结果（所有交易对均观望时不推送）
+		notable := false
+		for _, result := range executionResults {
+			if !strings.HasPrefix(result, "观望") {
+				notable = true
+				break
+			}
+		}
+		if notable {
+			if err := notifier.Notify(ctx, notify.Message{
+				Title: "交易执行结果",
+				Text:  executionResultStr,
+				Level: notify.LevelInfo,
+			}); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+			}
+		}
+
+		// Move a slice of the profits to the spot wallet
+		// 将部分利润划转到现货钱包
+		if cfg.ProfitSweepEnabled {
+			sweeper := executors.NewProfitSweeper(cfg, executor, db, log.WithComponent("sweeper"))
+			if _, err := sweeper.Sweep(ctx); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  利润提取失败: %v", err))
+			}
+		}
+
+		// Act on positions getting too close to their liquidation price
+		// 对距强平价过近的持仓采取动作
+		if cfg.LiquidationGuardDistancePercent > 0 {
+			executors.NewLiquidationGuard(cfg, executor, stopLossManager, notifier, log.WithComponent("liquidation")).Check(ctx)
+		}
 
 		log.Success("✅ 自动执行流程完成")
 	} else {
@@ -571,3 +1137,183 @@ func main() {
 	}
 
 }
+
+// shadowFreezeCycle records the decisions of a frozen cycle without executing them
+// shadowFreezeCycle 记录冻结周期的决策但不执行
+func shadowFreezeCycle(ctx context.Context, db *storage.Storage, cfg *config.Config, freeze *risk.FreezeStatus,
+	decisions map[string]*agents.TradingDecision, notifier notify.Notifier, log *logger.ColorLogger) {
+	label := freeze.Label()
+	log.Warning(fmt.Sprintf("🧊 策略%s：仅记录影子决策，不执行交易", label))
+
+	for _, symbol := range cfg.CryptoSymbols {
+		action := "无有效决策"
+		if d, ok := decisions[symbol]; ok && d.Valid {
+			action = string(d.Action)
+		}
+		result := fmt.Sprintf("🔍 影子模式（%s）：未执行 %s", label, action)
+		if err := db.UpdateLatestSessionExecution(symbol, cfg.CryptoTimeframe, false, result); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
+		}
+	}
+
+	summary, err := risk.CompleteShadowCycle(db, freeze)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新策略观察期失败: %v", err))
+		return
+	}
+	if summary == "" {
+		return
+	}
+	log.Success(summary)
+	if err := notifier.Notify(ctx, notify.Message{
+		Title: "策略观察期结束",
+		Text:  summary,
+		Level: notify.LevelInfo,
+	}); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// evaluateShadowOrders scores the shadow orders whose horizon has passed and logs the running totals
+// evaluateShadowOrders 评估已到期的影子订单并输出累计统计
+func evaluateShadowOrders(ctx context.Context, executor *executors.BinanceExecutor, db *storage.Storage, log *logger.ColorLogger) {
+	evaluated, err := executor.EvaluateShadowOrders(ctx, db, time.Now())
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  评估影子订单失败: %v", err))
+	}
+	if evaluated == 0 {
+		return
+	}
+	stats, err := db.GetShadowStats()
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  汇总影子订单失败: %v", err))
+		return
+	}
+	log.Info(fmt.Sprintf("👻 已评估 %d 个影子订单；累计入场 %d 个，胜率 %.1f%%，平均收益 %+.2f%%，触发止损 %d 次",
+		evaluated, stats.Triggered, stats.WinRate*100, stats.AvgReturnPct, stats.StopHits))
+}
+
+// scoreDecisions scores the newly closed positions against their opening decision and logs the calibration
+// scoreDecisions 根据开仓决策为新平仓的持仓评分，并输出置信度校准统计
+func scoreDecisions(db *storage.Storage, log *logger.ColorLogger) {
+	scored, err := db.ScoreClosedPositions(100)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  评估决策质量失败: %v", err))
+	}
+	if scored == 0 {
+		return
+	}
+	calibration, err := db.GetDecisionCalibration(time.Time{}, 0)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  汇总决策质量失败: %v", err))
+		return
+	}
+	log.Info(fmt.Sprintf("🎯 已评估 %d 个平仓持仓的开仓决策；累计关联 %d 个，Brier 分数 %.3f（越低越好）",
+		scored, calibration.Scored, calibration.BrierScore))
+}
+
+// recordBreakerFailure counts a failure on the circuit breaker and alerts when it trips
+// recordBreakerFailure 在熔断器上记录一次失败，触发熔断时发出告警
+func recordBreakerFailure(ctx context.Context, breaker *risk.Breaker, kind, detail string, notifier notify.Notifier, log *logger.ColorLogger) {
+	tripped, err := breaker.RecordFailure(kind, detail, time.Now())
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+	}
+	if !tripped {
+		return
+	}
+
+	text := fmt.Sprintf("%s\n自动执行暂停到 %s，已有止损单继续有效", breaker.State.Reason,
+		breaker.State.OpenUntil.Local().Format("01-02 15:04"))
+	log.Error("⛔ 熔断触发: " + strings.ReplaceAll(text, "\n", "，"))
+	if err := notifier.Notify(ctx, notify.Message{
+		Title: "熔断暂停自动执行",
+		Text:  text,
+		Level: notify.LevelError,
+	}); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// latestATR returns the symbol's latest ATR from this round's reports (0 if unknown)
+// latestATR 返回本轮报告中该交易对的最新 ATR（未知时为 0）
+func latestATR(state *agents.AgentState, symbol string) float64 {
+	if reports := state.GetSymbolReports(symbol); reports != nil && reports.TechnicalIndicators != nil {
+		if atr := reports.TechnicalIndicators.ATR; len(atr) > 0 && !math.IsNaN(atr[len(atr)-1]) {
+			return atr[len(atr)-1]
+		}
+	}
+	return 0
+}
+
+// allocateEntries ranks the round's opening decisions by confidence × risk/reward and trims or rejects
+// them so their combined margin fits ALLOCATOR_MAX_MARGIN_PERCENT of the available balance
+// allocateEntries 按置信度 × 盈亏比对本轮开仓决策排序，并缩减或拒绝部分决策，使保证金合计不超过可用余额的 ALLOCATOR_MAX_MARGIN_PERCENT
+//
+// Trimmed decisions get a smaller PositionSizePercent; the returned map holds the rejection message per symbol.
+// 被缩减的决策直接调低 PositionSizePercent；返回值为被拒绝交易对的说明。
+func allocateEntries(ctx context.Context, cfg *config.Config, decisions map[string]*agents.TradingDecision,
+	portfolioMgr *portfolio.PortfolioManager, state *agents.AgentState, log *logger.ColorLogger) map[string]string {
+	var candidates []risk.Candidate
+	for symbol, d := range decisions {
+		if !d.Valid || !(d.Action == executors.ActionBuy || d.Action == executors.ActionSell || executors.IsStopEntry(d.Action) ||
+			executors.IsAddOn(d.Action)) {
+			continue
+		}
+		openAction := d.Action
+		if executors.IsStopEntry(openAction) {
+			openAction = executors.EntryAction(openAction)
+		} else if executors.IsAddOn(openAction) {
+			openAction = executors.AddOnAction(openAction)
+		}
+		order, err := portfolioMgr.ProposedOrder(ctx, symbol, openAction, d.PositionSizePercent, d.Leverage, d.StopLoss, latestATR(state, symbol))
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  %s 估算保证金失败，不参与组合分配: %v", symbol, err))
+			continue
+		}
+		candidates = append(candidates, risk.Candidate{
+			Symbol:     symbol,
+			Confidence: d.Confidence,
+			RiskReward: d.RiskRewardRatio,
+			Margin:     order.Margin,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	budget := portfolioMgr.GetAvailableBalance() * cfg.AllocatorMaxMargin / 100
+	log.Info(fmt.Sprintf("📊 组合分配: %d 个开仓决策，可用保证金预算 %.2f USDT", len(candidates), budget))
+
+	rejected := make(map[string]string)
+	for _, a := range risk.Allocate(candidates, budget) {
+		d := decisions[a.Symbol]
+		switch {
+		case !a.Trimmed():
+			log.Info(fmt.Sprintf("  #%d %s 评分 %.2f，保证金 %.2f USDT 全额分配", a.Rank, a.Symbol, a.Score(), a.Margin))
+		case a.Granted <= 0:
+			log.Warning(fmt.Sprintf("  #%d %s 评分 %.2f，可用保证金已分配完，拒绝开仓", a.Rank, a.Symbol, a.Score()))
+			rejected[a.Symbol] = fmt.Sprintf("🛑 组合分配拒绝: 排名 #%d（评分 %.2f），可用保证金已分配给更优的决策", a.Rank, a.Score())
+		default:
+			trimmed := min(d.PositionSizePercent, portfolioMgr.PercentForMargin(a.Granted))
+			log.Warning(fmt.Sprintf("  #%d %s 评分 %.2f，保证金 %.2f → %.2f USDT，仓位缩减: %.1f%% → %.1f%%",
+				a.Rank, a.Symbol, a.Score(), a.Margin, a.Granted, d.PositionSizePercent, trimmed))
+			d.PositionSizePercent = trimmed
+		}
+	}
+	return rejected
+}
+
+// notifyEntryFills announces stop entries that filled and became managed positions
+// notifyEntryFills 通知已成交并转为受管持仓的条件入场单
+func notifyEntryFills(ctx context.Context, notifier notify.Notifier, filled []*storage.PendingEntry, log *logger.ColorLogger) {
+	for _, entry := range filled {
+		if err := notifier.Notify(ctx, notify.Message{
+			Title: "条件入场单成交",
+			Text:  fmt.Sprintf("%s %s %.4f @ %.2f（触发价 %.2f）", entry.Symbol, entry.Side, entry.Quantity, entry.FillPrice, entry.TriggerPrice),
+			Level: notify.LevelInfo,
+		}); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+		}
+	}
+}
@@ -1,16 +1,36 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/constant"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
+// jsonOutput is set by --json: results are printed as JSON instead of formatted text
+// jsonOutput 由 --json 设置：结果以 JSON 输出而不是格式化文本
+var jsonOutput bool
+
 func main() {
+	// --json may appear anywhere in the arguments
+	// --json 可以出现在参数的任意位置
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if arg == "--json" {
+			jsonOutput = true
+		} else {
+			args = append(args, arg)
+		}
+	}
+	os.Args = args
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
@@ -35,7 +55,11 @@ func main() {
 
 	switch command {
 	case "stats":
-		handleStats(db, cfg)
+		symbol := ""
+		if len(os.Args) >= 3 {
+			symbol = os.Args[2]
+		}
+		handleStats(db, cfg, symbol)
 	case "latest":
 		limit := 10
 		if len(os.Args) >= 3 {
@@ -53,6 +77,107 @@ func main() {
 			limit, _ = strconv.Atoi(os.Args[3])
 		}
 		handleSymbol(db, symbol, limit)
+	case "trades":
+		// Optional symbol and limit, in either order
+		// 可选的交易对和数量，顺序不限
+		symbol := ""
+		limit := 20
+		for _, arg := range os.Args[2:] {
+			if n, err := strconv.Atoi(arg); err == nil {
+				limit = n
+			} else {
+				symbol = cfg.GetBinanceSymbolFor(arg)
+			}
+		}
+		handleTrades(db, symbol, limit)
+	case "latency":
+		// Optional symbol and limit, in either order
+		// 可选的交易对和数量，顺序不限
+		symbol := ""
+		limit := 20
+		for _, arg := range os.Args[2:] {
+			if n, err := strconv.Atoi(arg); err == nil {
+				limit = n
+			} else {
+				symbol = cfg.GetBinanceSymbolFor(arg)
+			}
+		}
+		handleLatency(db, symbol, limit)
+	case "sweeps":
+		limit := 20
+		if len(os.Args) >= 3 {
+			limit, _ = strconv.Atoi(os.Args[2])
+		}
+		handleSweeps(db, limit)
+	case "costs":
+		// Default: since the start of this month, the period of LLM_MONTHLY_TOKEN_BUDGET
+		// 默认：从本月月初开始，即 LLM_MONTHLY_TOKEN_BUDGET 的统计周期
+		since := storage.MonthStart(time.Now())
+		if len(os.Args) >= 3 {
+			if days, err := strconv.Atoi(os.Args[2]); err == nil && days > 0 {
+				since = time.Now().AddDate(0, 0, -days)
+			}
+		}
+		handleCosts(db, cfg, since)
+	case "attribution":
+		// Default: all closed positions
+		// 默认：全部已平仓持仓
+		var since time.Time
+		if len(os.Args) >= 3 {
+			if days, err := strconv.Atoi(os.Args[2]); err == nil && days > 0 {
+				since = time.Now().AddDate(0, 0, -days)
+			}
+		}
+		handleAttribution(db, since)
+	case "strategy":
+		limit := 10
+		if len(os.Args) >= 3 {
+			limit, _ = strconv.Atoi(os.Args[2])
+		}
+		handleStrategy(db, limit)
+	case "entries":
+		limit := 20
+		if len(os.Args) >= 3 {
+			limit, _ = strconv.Atoi(os.Args[2])
+		}
+		handleEntries(db, limit)
+	case "shadow":
+		limit := 20
+		if len(os.Args) >= 3 {
+			limit, _ = strconv.Atoi(os.Args[2])
+		}
+		handleShadow(db, limit)
+	case "positions":
+		// Optional status flag, symbol and limit, in any order
+		// 可选的状态参数、交易对和数量，顺序不限
+		filter := storage.PositionFilter{Limit: 20}
+		for _, arg := range os.Args[2:] {
+			switch {
+			case arg == "--open":
+				filter.Open = true
+			case arg == "--closed":
+				filter.Closed = true
+			default:
+				if n, err := strconv.Atoi(arg); err == nil {
+					filter.Limit = n
+				} else {
+					filter.Symbol = cfg.GetBinanceSymbolFor(arg)
+				}
+			}
+		}
+		handlePositions(db, filter)
+	case "balance":
+		hours := 24
+		if len(os.Args) >= 3 {
+			hours, _ = strconv.Atoi(os.Args[2])
+		}
+		handleBalance(db, hours)
+	case "stops":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: query stops <positionID>")
+			os.Exit(1)
+		}
+		handleStops(db, os.Args[2])
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -61,25 +186,84 @@ func main() {
 }
 
 func printUsage() {
-	fmt.Println("Usage: query <command> [args]")
+	fmt.Println("Usage: query <command> [args] [--json]")
 	fmt.Println()
 	fmt.Println("Commands:")
-	fmt.Println("  stats              - Show database statistics")
+	fmt.Println("  stats [SYM]        - Show session statistics by action and timeframe (default: first symbol)")
 	fmt.Println("  latest [N]         - Show latest N sessions (default: 10)")
 	fmt.Println("  symbol <SYM> [N]   - Show latest N sessions for symbol (default: 10)")
+	fmt.Println("  trades [SYM] [N]   - Show latest N fills with win rate, average R and P&L (default: 20)")
+	fmt.Println("  latency [SYM] [N]  - Show decision-to-fill latency and slippage by latency (default: 20)")
+	fmt.Println("  sweeps [N]         - Show latest N profit sweeps to the spot wallet (default: 20)")
+	fmt.Println("  costs [DAYS]       - Show LLM tokens and cost per model and batch (default: this month)")
+	fmt.Println("  attribution [DAYS] - Show PnL, win rate and average R by symbol, action and leverage (default: all)")
+	fmt.Println("  strategy [N]       - Show latest N strategy versions and freeze window summaries (default: 10)")
+	fmt.Println("  entries [N]        - Show latest N stop-entry orders and their outcome (default: 20)")
+	fmt.Println("  shadow [N]         - Show shadow mode results and the latest N intended orders (default: 20)")
+	fmt.Println("  positions [--open|--closed] [SYM] [N]")
+	fmt.Println("                     - Show latest N positions with stop and P&L (default: 20)")
+	fmt.Println("  balance [HOURS]    - Show balance snapshots of the last HOURS hours (default: 24)")
+	fmt.Println("  stops <ID>         - Show a position and its stop-loss changes")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --json             - Print the results as JSON, e.g. for jq or scripts")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  query stats")
+	fmt.Println("  query stats ETH/USDT")
 	fmt.Println("  query latest 5")
 	fmt.Println("  query symbol BTC/USDT 10")
+	fmt.Println("  query trades BTC/USDT 50")
+	fmt.Println("  query latency BTC/USDT")
+	fmt.Println("  query sweeps")
+	fmt.Println("  query costs")
+	fmt.Println("  query costs 7")
+	fmt.Println("  query attribution 30")
+	fmt.Println("  query strategy")
+	fmt.Println("  query entries")
+	fmt.Println("  query shadow 50")
+	fmt.Println("  query positions --open")
+	fmt.Println("  query positions --closed BTC/USDT 50")
+	fmt.Println("  query balance 72")
+	fmt.Println("  query stops BTC/USDT-1731144000")
+	fmt.Println("  query trades BTC/USDT --json | jq '.stats.WinRate'")
 }
 
-func handleStats(db *storage.Storage, cfg *config.Config) {
-	// Use first symbol from config or ask user
-	symbol := cfg.CryptoSymbols[0]
-	if len(cfg.CryptoSymbols) > 1 {
-		fmt.Printf("Multiple symbols configured: %v\n", cfg.CryptoSymbols)
-		fmt.Printf("Showing stats for: %s\n\n", symbol)
+// printJSON prints v as indented JSON on stdout
+// printJSON 以缩进 JSON 格式输出 v 到标准输出
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// sessionNotes returns the operator notes of sessions keyed by session ID
+// sessionNotes 返回会话的操作员备注，按会话 ID 分组
+func sessionNotes(db *storage.Storage, sessions []*storage.TradingSession) map[string][]*storage.Note {
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, strconv.FormatInt(session.ID, 10))
+	}
+	notes, err := db.GetNotesFor(storage.NoteTargetSession, ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get notes: %v\n", err)
+		os.Exit(1)
+	}
+	return notes
+}
+
+func handleStats(db *storage.Storage, cfg *config.Config, symbol string) {
+	// Use the given symbol, or the first symbol from config
+	// 使用指定的交易对，未指定时使用配置中的第一个交易对
+	if symbol == "" {
+		symbol = cfg.CryptoSymbols[0]
+		if len(cfg.CryptoSymbols) > 1 && !jsonOutput {
+			fmt.Printf("Multiple symbols configured: %v\n", cfg.CryptoSymbols)
+			fmt.Printf("Showing stats for: %s\n\n", symbol)
+		}
 	}
 
 	stats, err := db.GetSessionStats(symbol)
@@ -88,15 +272,58 @@ func handleStats(db *storage.Storage, cfg *config.Config) {
 		os.Exit(1)
 	}
 
+	if jsonOutput {
+		printJSON(map[string]interface{}{"symbol": symbol, "stats": stats})
+		return
+	}
+
 	fmt.Println("=== Trading Sessions Statistics ===")
 	fmt.Printf("Symbol:           %s\n", symbol)
-	fmt.Printf("Total Sessions:   %d\n", stats["total_sessions"].(int))
-	fmt.Printf("Executed Trades:  %d\n", stats["executed_count"].(int))
-	fmt.Printf("Execution Rate:   %.1f%%\n", stats["execution_rate"].(float64))
+	fmt.Printf("Total Sessions:   %d\n", stats.TotalSessions)
+	fmt.Printf("Executed Trades:  %d\n", stats.ExecutedCount)
+	fmt.Printf("Execution Rate:   %.1f%%\n", stats.ExecutionRate)
+	fmt.Printf("Success Rate:     %.1f%% (%d non-HOLD decisions)\n", stats.ExecutionSuccessRate, stats.ActionableCount)
+	fmt.Printf("Avg Confidence:   %.2f\n", stats.AvgConfidence)
+
+	if stats.FirstSession != "" {
+		fmt.Printf("First Session:    %s\n", stats.FirstSession)
+		fmt.Printf("Last Session:     %s\n", stats.LastSession)
+	}
+
+	if len(stats.Actions) > 0 {
+		fmt.Println("\nBy action:")
+		printActionCounts(stats.Actions, stats.TotalSessions)
+	}
+
+	if len(stats.Timeframes) > 0 {
+		fmt.Println("\nBy timeframe:")
+		timeframes := make([]string, 0, len(stats.Timeframes))
+		for timeframe := range stats.Timeframes {
+			timeframes = append(timeframes, timeframe)
+		}
+		sort.Strings(timeframes)
+		for _, timeframe := range timeframes {
+			tf := stats.Timeframes[timeframe]
+			fmt.Printf("  %-6s %5d sessions, %5d executed (%.1f%%)\n", timeframe, tf.Sessions, tf.Executed, tf.ExecutionRate)
+		}
+	}
+}
 
-	if stats["first_session"] != nil && stats["first_session"].(string) != "" {
-		fmt.Printf("First Session:    %s\n", stats["first_session"].(string))
-		fmt.Printf("Last Session:     %s\n", stats["last_session"].(string))
+// printActionCounts prints the session count per action, most frequent first
+// printActionCounts 按数量从多到少打印每个动作的会话数
+func printActionCounts(actions map[string]int, total int) {
+	names := make([]string, 0, len(actions))
+	for action := range actions {
+		names = append(names, action)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if actions[names[i]] != actions[names[j]] {
+			return actions[names[i]] > actions[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, action := range names {
+		fmt.Printf("  %-12s %5d (%.1f%%)\n", action, actions[action], float64(actions[action])/float64(total)*100)
 	}
 }
 
@@ -107,6 +334,11 @@ func handleLatest(db *storage.Storage, limit int) {
 		os.Exit(1)
 	}
 
+	if jsonOutput {
+		printJSON(map[string]interface{}{"sessions": sessions, "count": len(sessions), "notes": sessionNotes(db, sessions)})
+		return
+	}
+
 	if len(sessions) == 0 {
 		fmt.Println("No sessions found in database.")
 		return
@@ -120,6 +352,12 @@ func handleLatest(db *storage.Storage, limit int) {
 		fmt.Printf("    Timeframe:   %s\n", session.Timeframe)
 		fmt.Printf("    Created:     %s\n", session.CreatedAt.Format("2006-01-02 15:04:05"))
 		fmt.Printf("    Executed:    %v\n", session.Executed)
+		if session.SizingPolicy != "" {
+			fmt.Printf("    Sizing:      %s\n", session.SizingPolicy)
+		}
+		if session.DecisionPath != "" {
+			fmt.Printf("    Decided by:  %s\n", session.DecisionPath)
+		}
 
 		// Show decision preview (first 100 chars)
 		if len(session.Decision) > 0 {
@@ -133,10 +371,24 @@ func handleLatest(db *storage.Storage, limit int) {
 		if session.Executed && session.ExecutionResult != "" {
 			fmt.Printf("    Result:      %s\n", session.ExecutionResult)
 		}
+		printSessionNotes(db, session.ID)
 		fmt.Println()
 	}
 }
 
+// printSessionNotes prints the operator notes attached to a session
+// printSessionNotes 输出会话的操作员备注
+func printSessionNotes(db *storage.Storage, sessionID int64) {
+	notes, err := db.GetNotes(storage.NoteTargetSession, strconv.FormatInt(sessionID, 10))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get notes: %v\n", err)
+		return
+	}
+	for _, note := range notes {
+		fmt.Printf("    Note:        [%s] %s\n", note.CreatedAt.Format("2006-01-02 15:04"), note.Text)
+	}
+}
+
 func handleSymbol(db *storage.Storage, symbol string, limit int) {
 	sessions, err := db.GetSessionsBySymbol(symbol, limit)
 	if err != nil {
@@ -144,6 +396,11 @@ func handleSymbol(db *storage.Storage, symbol string, limit int) {
 		os.Exit(1)
 	}
 
+	if jsonOutput {
+		printJSON(map[string]interface{}{"symbol": symbol, "sessions": sessions, "count": len(sessions), "notes": sessionNotes(db, sessions)})
+		return
+	}
+
 	if len(sessions) == 0 {
 		fmt.Printf("No sessions found for symbol: %s\n", symbol)
 		return
@@ -156,6 +413,12 @@ func handleSymbol(db *storage.Storage, symbol string, limit int) {
 		fmt.Printf("    Timeframe:   %s\n", session.Timeframe)
 		fmt.Printf("    Created:     %s\n", session.CreatedAt.Format("2006-01-02 15:04:05"))
 		fmt.Printf("    Executed:    %v\n", session.Executed)
+		if session.SizingPolicy != "" {
+			fmt.Printf("    Sizing:      %s\n", session.SizingPolicy)
+		}
+		if session.DecisionPath != "" {
+			fmt.Printf("    Decided by:  %s\n", session.DecisionPath)
+		}
 
 		// Show decision preview
 		if len(session.Decision) > 0 {
@@ -169,6 +432,574 @@ func handleSymbol(db *storage.Storage, symbol string, limit int) {
 		if session.Executed && session.ExecutionResult != "" {
 			fmt.Printf("    Result:      %s\n", session.ExecutionResult)
 		}
+		printSessionNotes(db, session.ID)
 		fmt.Println()
 	}
 }
+
+func handleTrades(db *storage.Storage, symbol string, limit int) {
+	trades, err := db.GetTrades(symbol, limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get trades: %v\n", err)
+		os.Exit(1)
+	}
+	stats, err := db.GetTradeStats(symbol)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get trade stats: %v\n", err)
+		os.Exit(1)
+	}
+	costs, err := db.GetTradingCosts(symbol)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get trading costs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"symbol": symbol, "trades": trades, "count": len(trades), "stats": stats, "costs": costs})
+		return
+	}
+
+	scope := symbol
+	if scope == "" {
+		scope = "all symbols"
+	}
+
+	if len(trades) == 0 {
+		fmt.Printf("No trades found for %s.\n", scope)
+		return
+	}
+
+	// Cumulative P&L is accumulated from the oldest displayed fill
+	// 累计盈亏从显示的最早一笔成交开始累加
+	fmt.Printf("=== Latest %d Trades (%s) ===\n\n", len(trades), scope)
+	fmt.Printf("%-19s  %-10s  %-4s  %-12s  %12s  %12s  %10s  %12s  %12s  %s\n",
+		"Time", "Symbol", "Side", "Type", "Quantity", "Price", "Fee", "Realized", "Cumulative", "Session")
+
+	var cumulative float64
+	for i := len(trades) - 1; i >= 0; i-- {
+		t := trades[i]
+		cumulative += t.RealizedPnL - t.Fee
+
+		session := "-"
+		if t.SessionID > 0 {
+			session = strconv.FormatInt(t.SessionID, 10)
+		}
+		typ := t.Type
+		if t.Paper {
+			typ += "*"
+		}
+		fmt.Printf("%-19s  %-10s  %-4s  %-12s  %12.4f  %12.4f  %10.4f  %+12.2f  %+12.2f  %s\n",
+			t.CreatedAt.Format("2006-01-02 15:04:05"), t.Symbol, t.Side, typ,
+			t.Quantity, t.Price, t.Fee, t.RealizedPnL, cumulative, session)
+	}
+	fmt.Println("(* = paper trade, Cumulative is net of fees)")
+
+	fmt.Println()
+	fmt.Println("=== Trade Statistics ===")
+	fmt.Printf("Total Fills:      %d\n", stats.TotalTrades)
+	fmt.Printf("Closing Fills:    %d (%d wins / %d losses)\n", stats.ClosingTrades, stats.Wins, stats.Losses)
+	fmt.Printf("Win Rate:         %.1f%%\n", stats.WinRate)
+	if stats.RTrades > 0 {
+		fmt.Printf("Average R:        %+.2fR (%d closed positions)\n", stats.AverageR, stats.RTrades)
+	} else {
+		fmt.Println("Average R:        n/a (no closed positions with a stop-loss)")
+	}
+	fmt.Printf("Realized P&L:     %+.2f USDT\n", stats.GrossPnL)
+	fmt.Printf("Fees:             %.2f USDT\n", stats.TotalFees)
+	fmt.Printf("Cumulative P&L:   %+.2f USDT\n", stats.NetPnL)
+	fmt.Printf("Slippage:         %.2f USDT (against the decision price, already in the fill prices)\n", costs.Slippage)
+	fmt.Printf("Funding:          %+.2f USDT (closed positions, positive = paid)\n", costs.Funding)
+}
+
+func handleLatency(db *storage.Storage, symbol string, limit int) {
+	timings, err := db.GetExecutionTimings(symbol, limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get execution timings: %v\n", err)
+		os.Exit(1)
+	}
+	stats, err := db.GetLatencyStats(symbol)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get latency stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"symbol": symbol, "timings": timings, "count": len(timings), "stats": stats})
+		return
+	}
+
+	scope := symbol
+	if scope == "" {
+		scope = "all symbols"
+	}
+
+	if len(timings) == 0 {
+		fmt.Printf("No execution timings found for %s.\n", scope)
+		return
+	}
+
+	// Stage durations: snapshot → decision (LLM), → validated, → sent, → filled
+	// 各阶段耗时：快照 → 决策（LLM）→ 验证通过 → 下单 → 成交
+	fmt.Printf("=== Latest %d Executions (%s) ===\n\n", len(timings), scope)
+	fmt.Printf("%-19s  %-10s  %-11s  %9s  %9s  %9s  %9s  %9s  %12s  %12s  %9s  %10s\n",
+		"Filled", "Symbol", "Action", "LLM", "Validate", "Route", "Fill", "Total", "Signal", "Price", "Slip bps", "Slip USDT")
+	for _, t := range timings {
+		action := t.Action
+		if t.Paper {
+			action += "*"
+		}
+		fmt.Printf("%-19s  %-10s  %-11s  %9s  %9s  %9s  %9s  %9s  %12.4f  %12.4f  %+9.1f  %+10.2f\n",
+			t.FilledAt.Format("2006-01-02 15:04:05"), t.Symbol, action,
+			formatStage(t.SignalAt, t.DecisionAt), formatStage(t.DecisionAt, t.ValidatedAt),
+			formatStage(t.ValidatedAt, t.SentAt), formatStage(t.SentAt, t.FilledAt),
+			formatStage(t.SignalAt, t.FilledAt), t.SignalPrice, t.FillPrice, t.SlippageBps, t.SlippageUSD)
+	}
+	fmt.Println("(* = paper trade, positive slippage is a cost)")
+
+	fmt.Println()
+	fmt.Println("=== Latency Statistics ===")
+	fmt.Printf("Executions:       %d\n", stats.Count)
+	fmt.Printf("Avg LLM cycle:    %s\n", stats.AvgDecision.Round(time.Millisecond))
+	fmt.Printf("Avg validation:   %s\n", stats.AvgValidation.Round(time.Millisecond))
+	fmt.Printf("Avg routing:      %s\n", stats.AvgRouting.Round(time.Millisecond))
+	fmt.Printf("Avg fill:         %s\n", stats.AvgFill.Round(time.Millisecond))
+	fmt.Printf("Avg total:        %s\n", stats.AvgTotal.Round(time.Millisecond))
+	fmt.Printf("Avg slippage:     %+.1f bps\n", stats.AvgSlippageBps)
+	fmt.Printf("Slippage cost:    %+.2f USDT\n", stats.SlippageUSD)
+	fmt.Printf("Correlation:      %+.2f (latency vs slippage)\n", stats.Correlation)
+	fmt.Printf("Cost of latency:  %+.2f bps per minute\n", stats.BpsPerMinute)
+
+	fmt.Println()
+	fmt.Println("=== Slippage by Latency ===")
+	fmt.Printf("%-8s  %6s  %9s  %10s\n", "Latency", "Count", "Slip bps", "Slip USDT")
+	for _, b := range stats.Buckets {
+		fmt.Printf("%-8s  %6d  %+9.1f  %+10.2f\n", b.Label, b.Count, b.AvgSlippageBps, b.SlippageUSD)
+	}
+}
+
+// formatStage formats the duration between two timestamps, "-" when one is missing
+// formatStage 格式化两个时间戳之间的耗时，缺少时间戳时显示 "-"
+func formatStage(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return "-"
+	}
+	d := to.Sub(from)
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+func handleSweeps(db *storage.Storage, limit int) {
+	sweeps, err := db.GetProfitSweeps(limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get profit sweeps: %v\n", err)
+		os.Exit(1)
+	}
+	total, err := db.GetTotalSwept()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get total swept: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"sweeps": sweeps, "count": len(sweeps), "total_swept": total})
+		return
+	}
+
+	if len(sweeps) == 0 {
+		fmt.Println("No profit sweeps found.")
+		return
+	}
+
+	fmt.Printf("=== Latest %d Profit Sweeps ===\n\n", len(sweeps))
+	fmt.Printf("%-19s  %12s  %12s  %14s  %13s  %s\n",
+		"Time", "Amount", "Baseline", "Equity Before", "Equity After", "Transfer")
+	for _, sweep := range sweeps {
+		transfer := "-"
+		if sweep.TransferID > 0 {
+			transfer = strconv.FormatInt(sweep.TransferID, 10)
+		}
+		if sweep.Paper {
+			transfer += "*"
+		}
+		fmt.Printf("%-19s  %12.2f  %12.2f  %14.2f  %13.2f  %s\n",
+			sweep.CreatedAt.Format("2006-01-02 15:04:05"), sweep.Amount, sweep.Baseline,
+			sweep.EquityBefore, sweep.EquityAfter, transfer)
+	}
+	fmt.Println("(* = simulated transfer)")
+
+	fmt.Println()
+	fmt.Printf("Total Swept:      %.2f USDT\n", total)
+}
+
+func handleCosts(db *storage.Storage, cfg *config.Config, since time.Time) {
+	summary, err := db.GetLLMCostSummary(since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get LLM usage: %v\n", err)
+		os.Exit(1)
+	}
+	batches, err := db.GetLLMUsageByBatch(10)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get LLM usage: %v\n", err)
+		os.Exit(1)
+	}
+	monthTokens, err := db.GetLLMTokensSince(storage.MonthStart(time.Now()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get LLM usage: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{
+			"summary":      summary,
+			"batches":      batches,
+			"month_tokens": monthTokens,
+			"token_budget": cfg.LLMMonthlyTokenBudget,
+		})
+		return
+	}
+
+	fmt.Printf("=== LLM Usage since %s ===\n\n", summary.Since.Format("2006-01-02 15:04"))
+	if len(summary.Models) == 0 {
+		fmt.Println("No LLM usage recorded.")
+	} else {
+		fmt.Printf("%-28s  %6s  %12s  %12s  %12s  %10s\n", "Model", "Calls", "Prompt", "Completion", "Total", "Cost")
+		for _, u := range summary.Models {
+			fmt.Printf("%-28s  %6d  %12d  %12d  %12d  %10s\n",
+				u.Model, u.Calls, u.PromptTokens, u.CompletionTokens, u.TotalTokens(), formatCost(cfg, u.Model, u.CostUSD))
+		}
+		fmt.Printf("%-28s  %6d  %12d  %12d  %12d  %10s\n",
+			"Total", summary.Calls, summary.PromptTokens, summary.CompletionTokens, summary.TotalTokens(), fmt.Sprintf("$%.4f", summary.CostUSD))
+		fmt.Println("(- = no price in LLM_PRICES)")
+	}
+
+	if len(batches) > 0 {
+		fmt.Printf("\n=== Latest Batches ===\n\n")
+		fmt.Printf("%-19s  %-18s  %-28s  %6s  %12s  %10s\n", "Time", "Batch", "Model", "Calls", "Tokens", "Cost")
+		for _, u := range batches {
+			fmt.Printf("%-19s  %-18s  %-28s  %6d  %12d  %10s\n",
+				u.CreatedAt.Format("2006-01-02 15:04:05"), u.BatchID, u.Model, u.Calls, u.TotalTokens(), formatCost(cfg, u.Model, u.CostUSD))
+		}
+	}
+
+	fmt.Println()
+	if budget := cfg.LLMMonthlyTokenBudget; budget > 0 {
+		status := ""
+		if monthTokens >= budget {
+			status = " (exhausted, LLM calls paused until next month)"
+		}
+		fmt.Printf("Monthly Budget:   %d / %d tokens (%.1f%%)%s\n",
+			monthTokens, budget, float64(monthTokens)/float64(budget)*100, status)
+	} else {
+		fmt.Printf("Monthly Budget:   unlimited (%d tokens this month)\n", monthTokens)
+	}
+}
+
+// formatCost prints a cost in USD, or "-" for a model without a price
+// formatCost 以美元格式输出费用，未配置价格的模型输出 "-"
+func formatCost(cfg *config.Config, model string, cost float64) string {
+	if _, ok := cfg.LLMPrices[model]; !ok && cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+func handleAttribution(db *storage.Storage, since time.Time) {
+	attribution, err := db.GetPerformanceAttribution(since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get performance attribution: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(attribution)
+		return
+	}
+
+	if since.IsZero() {
+		fmt.Printf("=== Performance Attribution (all closed positions) ===\n")
+	} else {
+		fmt.Printf("=== Performance Attribution since %s ===\n", since.Format("2006-01-02 15:04"))
+	}
+	if len(attribution.BySymbol) == 0 {
+		fmt.Println("\nNo closed positions.")
+		return
+	}
+	printAttribution("Symbol", attribution.BySymbol)
+	printAttribution("Action", attribution.ByAction)
+	printAttribution("Leverage", attribution.ByLeverage)
+	fmt.Println("\n(PnL includes partial closes and is net of fees and funding; symbols and actions are listed worst first)")
+}
+
+// printAttribution prints one breakdown table of the performance attribution
+// printAttribution 输出绩效归因的一张拆分表
+func printAttribution(label string, rows []*storage.AttributionRow) {
+	fmt.Printf("\n%-12s  %6s  %5s  %6s  %8s  %12s  %10s  %8s\n",
+		label, "Trades", "Wins", "Losses", "Win%", "PnL", "Fees", "Avg R")
+	for _, r := range rows {
+		avgR := "-"
+		if r.RTrades > 0 {
+			avgR = fmt.Sprintf("%+.2fR", r.AverageR)
+		}
+		fmt.Printf("%-12s  %6d  %5d  %6d  %7.1f%%  %+12.2f  %10.2f  %8s\n",
+			r.Key, r.Trades, r.Wins, r.Losses, r.WinRate, r.RealizedPnL, r.Fees, avgR)
+	}
+}
+
+func handleStrategy(db *storage.Storage, limit int) {
+	versions, err := db.GetStrategyVersions(limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get strategy versions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"versions": versions, "count": len(versions)})
+		return
+	}
+
+	if len(versions) == 0 {
+		fmt.Println("No strategy versions found.")
+		return
+	}
+
+	fmt.Printf("=== Latest %d Strategy Versions ===\n", len(versions))
+	for _, v := range versions {
+		status := "live"
+		if v.ReleasedAt.IsZero() {
+			status = fmt.Sprintf("shadow %d/%d", v.ShadowCycles, v.FreezeCycles)
+		}
+		fmt.Printf("\n[%d] %s  %s  (%s)\n", v.ID, v.CreatedAt.Format("2006-01-02 15:04:05"), v.Fingerprint, status)
+		fmt.Printf("Config:  %s\n", v.Description)
+		if v.FreezeCycles > 0 && !v.ReleasedAt.IsZero() {
+			fmt.Printf("Released: %s after %d shadow cycles\n", v.ReleasedAt.Format("2006-01-02 15:04:05"), v.ShadowCycles)
+		}
+		if v.Summary != "" {
+			fmt.Println(v.Summary)
+		}
+	}
+}
+
+func handleEntries(db *storage.Storage, limit int) {
+	entries, err := db.GetRecentEntries(limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get stop entries: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"entries": entries, "count": len(entries)})
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No stop entries found.")
+		return
+	}
+
+	fmt.Printf("=== Latest %d Stop Entries ===\n", len(entries))
+	fmt.Printf("%-19s  %-10s  %-5s  %12s  %10s  %-9s  %s\n", "Placed", "Symbol", "Side", "Trigger", "Quantity", "Status", "Outcome")
+	for _, e := range entries {
+		outcome := fmt.Sprintf("expires %s", e.ExpiresAt.Format("01-02 15:04"))
+		switch e.Status {
+		case storage.EntryStatusFilled:
+			outcome = fmt.Sprintf("filled @ %.2f -> %s", e.FillPrice, e.PositionID)
+		case storage.EntryStatusCancelled, storage.EntryStatusExpired:
+			outcome = e.ResolvedAt.Format("01-02 15:04")
+		}
+		fmt.Printf("%-19s  %-10s  %-5s  %12.2f  %10.4f  %-9s  %s\n",
+			e.CreatedAt.Format("2006-01-02 15:04:05"), e.Symbol, e.Side, e.TriggerPrice, e.Quantity, e.Status, outcome)
+	}
+}
+
+func handleShadow(db *storage.Storage, limit int) {
+	orders, err := db.GetShadowOrders(limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get shadow orders: %v\n", err)
+		os.Exit(1)
+	}
+	stats, err := db.GetShadowStats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to summarize shadow orders: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"stats": stats, "orders": orders, "count": len(orders)})
+		return
+	}
+
+	if len(orders) == 0 {
+		fmt.Println("No shadow orders found.")
+		return
+	}
+
+	fmt.Println("=== Shadow Mode Summary ===")
+	fmt.Printf("Orders: %d (evaluated %d, entered %d)\n", stats.Total, stats.Evaluated, stats.Triggered)
+	fmt.Printf("Win rate: %.1f%%  Avg return: %+.2f%%  Stop-loss hits: %d\n", stats.WinRate*100, stats.AvgReturnPct, stats.StopHits)
+	fmt.Println("Returns are price moves in the direction of the order, without leverage or fees.")
+	fmt.Println()
+
+	fmt.Printf("=== Latest %d Shadow Orders ===\n", len(orders))
+	fmt.Printf("%-19s  %-10s  %-11s  %10s  %12s  %12s  %-11s  %8s  %8s  %8s\n",
+		"Decided", "Symbol", "Action", "Quantity", "Price", "Exit", "Outcome", "Return", "MFE", "MAE")
+	for _, o := range orders {
+		price := fmt.Sprintf("%.2f", o.DecisionPrice)
+		if o.TriggerPrice > 0 {
+			price = fmt.Sprintf("@%.2f", o.TriggerPrice)
+		}
+		if o.EvaluatedAt.IsZero() {
+			fmt.Printf("%-19s  %-10s  %-11s  %10.4f  %12s  %12s  %-11s\n",
+				o.CreatedAt.Format("2006-01-02 15:04:05"), o.Symbol, o.Action, o.Quantity, price, "-", "pending")
+			continue
+		}
+		fmt.Printf("%-19s  %-10s  %-11s  %10.4f  %12s  %12.2f  %-11s  %+7.2f%%  %+7.2f%%  %+7.2f%%\n",
+			o.CreatedAt.Format("2006-01-02 15:04:05"), o.Symbol, o.Action, o.Quantity, price, o.ExitPrice, o.Outcome,
+			o.ReturnPct, o.MaxFavorablePct, o.MaxAdversePct)
+	}
+}
+
+func handlePositions(db *storage.Storage, filter storage.PositionFilter) {
+	positions, err := db.GetPositions(filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get positions: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"symbol": filter.Symbol, "positions": positions, "count": len(positions)})
+		return
+	}
+
+	scope := filter.Symbol
+	if scope == "" {
+		scope = "all symbols"
+	}
+	status := "Positions"
+	if filter.Open && !filter.Closed {
+		status = "Open Positions"
+	} else if filter.Closed && !filter.Open {
+		status = "Closed Positions"
+	}
+
+	if len(positions) == 0 {
+		fmt.Printf("No positions found for %s.\n", scope)
+		return
+	}
+
+	fmt.Printf("=== Latest %d %s (%s) ===\n\n", len(positions), status, scope)
+	fmt.Printf("%-19s  %-10s  %-5s  %12s  %10s  %12s  %12s  %-11s  %12s  %s\n",
+		"Entry", "Symbol", "Side", "Entry Price", "Quantity", "Stop", "Close Price", "Status", "P&L", "ID")
+
+	var realized, unrealized float64
+	var closed int
+	for _, p := range positions {
+		state, closePrice, pnl := "open", "-", p.UnrealizedPnL
+		if p.Closed {
+			state, closePrice, pnl = "closed", fmt.Sprintf("%.4f", p.ClosePrice), p.RealizedPnL
+			realized += p.RealizedPnL
+			closed++
+		} else {
+			unrealized += p.UnrealizedPnL
+		}
+		fmt.Printf("%-19s  %-10s  %-5s  %12.4f  %10.4f  %12.4f  %12s  %-11s  %+12.2f  %s\n",
+			p.EntryTime.Format("2006-01-02 15:04:05"), p.Symbol, p.Side, p.EntryPrice, p.Quantity,
+			p.CurrentStopLoss, closePrice, state, pnl, p.ID)
+	}
+	fmt.Println("(P&L is realized for closed positions, last known unrealized for open ones)")
+
+	fmt.Println()
+	fmt.Printf("Open:             %d (unrealized %+.2f USDT)\n", len(positions)-closed, unrealized)
+	fmt.Printf("Closed:           %d (realized %+.2f USDT)\n", closed, realized)
+}
+
+func handleBalance(db *storage.Storage, hours int) {
+	history, err := db.GetBalanceHistory(hours)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get balance history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"hours": hours, "history": history, "count": len(history)})
+		return
+	}
+
+	if len(history) == 0 {
+		fmt.Printf("No balance snapshots in the last %d hours.\n", hours)
+		return
+	}
+
+	fmt.Printf("=== Balance History (last %d hours, %d snapshots) ===\n\n", hours, len(history))
+	fmt.Printf("%-19s  %12s  %12s  %12s  %9s\n", "Time", "Total", "Available", "Unrealized", "Positions")
+
+	high, low := history[0].TotalBalance, history[0].TotalBalance
+	for _, h := range history {
+		high = math.Max(high, h.TotalBalance)
+		low = math.Min(low, h.TotalBalance)
+		fmt.Printf("%-19s  %12.2f  %12.2f  %+12.2f  %9d\n",
+			h.Timestamp.Format("2006-01-02 15:04:05"), h.TotalBalance, h.AvailableBalance, h.UnrealizedPnL, h.Positions)
+	}
+
+	first, last := history[0], history[len(history)-1]
+	change := last.TotalBalance - first.TotalBalance
+	changePct := 0.0
+	if first.TotalBalance > 0 {
+		changePct = change / first.TotalBalance * 100
+	}
+
+	fmt.Println()
+	fmt.Printf("Start:            %.2f USDT\n", first.TotalBalance)
+	fmt.Printf("End:              %.2f USDT\n", last.TotalBalance)
+	fmt.Printf("Change:           %+.2f USDT (%+.2f%%)\n", change, changePct)
+	fmt.Printf("High / Low:       %.2f / %.2f USDT\n", high, low)
+}
+
+func handleStops(db *storage.Storage, positionID string) {
+	pos, err := db.GetPositionByID(positionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get position: %v\n", err)
+		os.Exit(1)
+	}
+	if pos == nil {
+		if jsonOutput {
+			fmt.Fprintf(os.Stderr, "Position not found: %s\n", positionID)
+			os.Exit(1)
+		}
+		fmt.Printf("Position not found: %s\n", positionID)
+		return
+	}
+	events, err := db.GetStopLossEvents(positionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get stop-loss events: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonOutput {
+		printJSON(map[string]interface{}{"position": pos, "events": events})
+		return
+	}
+
+	fmt.Printf("=== Position %s ===\n", pos.ID)
+	fmt.Printf("Symbol:           %s %s\n", pos.Symbol, pos.Side)
+	fmt.Printf("Entry:            %.4f x %.4f @ %s\n", pos.EntryPrice, pos.Quantity, pos.EntryTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Initial Stop:     %.4f\n", pos.InitialStopLoss)
+	fmt.Printf("Current Stop:     %.4f (%s)\n", pos.CurrentStopLoss, pos.StopLossType)
+	if pos.Closed && pos.CloseTime != nil {
+		fmt.Printf("Closed:           %.4f @ %s (%s)\n", pos.ClosePrice, pos.CloseTime.Format("2006-01-02 15:04:05"), pos.CloseReason)
+		fmt.Printf("Realized P&L:     %+.2f USDT\n", pos.RealizedPnL)
+	}
+
+	if len(events) == 0 {
+		fmt.Println("\nNo stop-loss changes recorded.")
+		return
+	}
+
+	fmt.Printf("\n=== %d Stop-Loss Changes ===\n", len(events))
+	fmt.Printf("%-19s  %12s  %12s  %-8s  %s\n", "Time", "Old Stop", "New Stop", "Trigger", "Reason")
+	for _, e := range events {
+		fmt.Printf("%-19s  %12.4f  %12.4f  %-8s  %s\n",
+			e.Timestamp.Format("2006-01-02 15:04:05"), e.OldStop, e.NewStop, e.Trigger, e.Reason)
+	}
+}
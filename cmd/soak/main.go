@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/agents"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+	"github.com/oak/crypto-trading-bot/internal/soak"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Soak test: runs the full trading loop in paper mode against recorded klines at an
+// accelerated interval, sampling goroutines, heap and database size to catch leaks.
+// 浸泡测试：以加速间隔在模拟盘模式下基于录制的 K 线运行完整交易循环，
+// 通过采样协程数、堆内存和数据库大小发现资源泄漏。
+func main() {
+	duration := flag.Duration("duration", 4*time.Hour, "运行时长 / Total run time")
+	interval := flag.Duration("interval", 5*time.Second, "交易循环间隔（每次前进一根 K 线）/ Cycle interval (one recorded bar per cycle)")
+	sampleEvery := flag.Duration("sample", time.Minute, "资源采样间隔 / Resource sampling interval")
+	dataDir := flag.String("data", "data/soak", "录制 K 线目录（<SYMBOL>.json）/ Directory of recorded klines (<SYMBOL>.json)")
+	dbPath := flag.String("db", "data/soak.db", "浸泡测试数据库（启动时清空）/ Soak database (reset on start)")
+	warmup := flag.Int("warmup", 200, "仅作为历史数据的 K 线数 / Bars served only as history")
+	useLLM := flag.Bool("llm", false, "调用真实 LLM（默认使用规则决策）/ Call the real LLM (rule-based decisions by default)")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Force an isolated paper run: no exchange orders, no external data, no notifications
+	// 强制隔离的模拟盘运行：不向交易所下单、不访问外部数据、不发送通知
+	cfg.PaperTrading = true
+	cfg.AutoExecute = true
+	cfg.BinanceTestMode = false
+	cfg.BinanceProxy = ""
+	cfg.EnableSentimentAnalysis = false
+	cfg.EnableMultiTimeframe = false
+	cfg.DatabasePath = *dbPath
+	cfg.NotifyDiscordWebhook, cfg.NotifySlackWebhook, cfg.NotifyWebhookURL = "", "", ""
+	if !*useLLM {
+		cfg.APIKey = ""
+	}
+
+	logger.Init(cfg.DebugMode, cfg.LogFormat)
+	log := logger.Global
+
+	log.Header("浸泡测试 - 模拟盘加速回放", '=', 80)
+
+	replay, err := soak.LoadRecording(*dataDir, *warmup)
+	if err != nil {
+		log.Error(fmt.Sprintf("加载录制数据失败: %v", err))
+		os.Exit(1)
+	}
+	recorded := make(map[string]bool)
+	for _, symbol := range replay.Symbols() {
+		recorded[symbol] = true
+	}
+	for _, symbol := range cfg.CryptoSymbols {
+		if !recorded[cfg.GetBinanceSymbolFor(symbol)] {
+			log.Error(fmt.Sprintf("%s 没有录制数据，请在 %s 中提供 %s.json", symbol, *dataDir, cfg.GetBinanceSymbolFor(symbol)))
+			os.Exit(1)
+		}
+	}
+
+	// Serve the recording on a local port and point the futures client at it
+	// 在本地端口提供录制数据，并让合约客户端指向该地址
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Error(fmt.Sprintf("启动回放服务失败: %v", err))
+		os.Exit(1)
+	}
+	go http.Serve(listener, replay)
+	futures.BaseApiMainUrl = "http://" + listener.Addr().String()
+
+	log.Info(fmt.Sprintf("交易对: %v", cfg.CryptoSymbols))
+	log.Info(fmt.Sprintf("回放服务: %s（录制目录 %s）", futures.BaseApiMainUrl, *dataDir))
+	log.Info(fmt.Sprintf("运行时长: %s，循环间隔: %s，采样间隔: %s", *duration, *interval, *sampleEvery))
+	if *useLLM {
+		log.Warning("⚠️  使用真实 LLM，长时间运行会产生费用")
+	} else {
+		log.Info("决策方式: 规则决策（使用 -llm 调用真实 LLM）")
+	}
+
+	// Fresh database for every run so growth is measured from zero
+	// 每次运行使用全新数据库，从零开始测量增长
+	if err := os.MkdirAll(filepath.Dir(cfg.DatabasePath), 0755); err != nil {
+		log.Error(fmt.Sprintf("创建数据库目录失败: %v", err))
+		os.Exit(1)
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(cfg.DatabasePath + suffix)
+	}
+	db, err := storage.NewStorage(cfg.DatabasePath)
+	if err != nil {
+		log.Error(fmt.Sprintf("初始化数据库失败: %v", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+	db.SetReportCompression(cfg.ReportCompression)
+
+	executor := executors.NewBinanceExecutor(cfg, log)
+	executor.EnablePaperTrading(db)
+
+	ctx := context.Background()
+	for _, symbol := range cfg.CryptoSymbols {
+		if err := executor.SetupExchange(ctx, symbol, cfg.BinanceLeverage); err != nil {
+			log.Error(fmt.Sprintf("设置 %s 交易所失败: %v", symbol, err))
+			os.Exit(1)
+		}
+	}
+
+	stopLossManager := executors.NewStopLossManager(cfg, executor, log, db)
+	monitor := soak.NewMonitor(cfg.DatabasePath)
+	baseline := monitor.Sample(0)
+	log.Success(fmt.Sprintf("基线: 协程 %d，堆内存 %.2f MB", baseline.Goroutines, float64(baseline.HeapAlloc)/1024/1024))
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	deadline := time.After(*duration)
+	cycleTicker := time.NewTicker(*interval)
+	defer cycleTicker.Stop()
+	sampleTicker := time.NewTicker(*sampleEvery)
+	defer sampleTicker.Stop()
+
+	cycles := 0
+loop:
+	for {
+		select {
+		case <-sigChan:
+			log.Warning("收到停止信号，提前结束浸泡测试")
+			break loop
+
+		case <-deadline:
+			break loop
+
+		case <-cycleTicker.C:
+			cycles++
+			if err := runCycle(ctx, cfg, log, executor, stopLossManager, db); err != nil {
+				log.Error(fmt.Sprintf("第 %d 次循环失败: %v", cycles, err))
+			}
+			replay.Advance()
+
+		case <-sampleTicker.C:
+			s := monitor.Sample(cycles)
+			log.Info(fmt.Sprintf("📈 采样: 循环 %d，协程 %d，堆内存 %.2f MB，堆对象 %d，数据库 %.2f MB，回放轮次 %d",
+				s.Cycles, s.Goroutines, float64(s.HeapAlloc)/1024/1024, s.HeapObjects, float64(s.DBSize)/1024/1024, replay.Laps()))
+		}
+	}
+
+	stopLossManager.Stop()
+	monitor.Sample(cycles)
+
+	log.Header("浸泡测试报告", '=', 80)
+	report, err := soak.Analyze(monitor.Samples(), soak.DefaultThresholds())
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  无法生成报告: %v", err))
+		return
+	}
+	for _, line := range strings.Split(report.String(), "\n") {
+		log.Info(line)
+	}
+	if len(report.Leaks) > 0 {
+		log.Error("❌ 发现疑似资源泄漏")
+		os.Exit(1)
+	}
+	log.Success("✅ 未发现资源泄漏")
+}
+
+// runCycle runs one analysis and auto-execution cycle, like the web mode trading loop
+// runCycle 执行一次分析与自动执行循环，与 Web 模式的交易循环一致
+func runCycle(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, stopLossManager *executors.StopLossManager, db *storage.Storage) error {
+	tradingGraph := agents.NewSimpleTradingGraph(cfg, log, executor, stopLossManager)
+	result, err := tradingGraph.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("工作流执行失败: %w", err)
+	}
+
+	decision, _ := result["decision"].(string)
+	state := tradingGraph.GetState()
+	decisions := agents.ParseMultiCurrencyDecision(decision, cfg.CryptoSymbols)
+	decisionSource := agents.DecisionSource(decision, cfg.CryptoSymbols)
+	batchID := fmt.Sprintf("batch-%d", time.Now().UnixNano())
+
+	for _, symbol := range cfg.CryptoSymbols {
+		reports := state.GetSymbolReports(symbol)
+		if reports == nil {
+			continue
+		}
+
+		// Check the report facts cited by the decision against the reports it was made from
+		// 将决策引用的报告数据与决策所依据的报告核对
+		if flagged := agents.VerifyCitations(decisions[symbol], reports); len(flagged) > 0 {
+			log.Warning(fmt.Sprintf("⚠️  %s 决策引用了报告中不存在的数据: %s", symbol, strings.Join(flagged, "；")))
+		}
+
+		session := &storage.TradingSession{
+			BatchID:         batchID,
+			Symbol:          symbol,
+			Timeframe:       cfg.CryptoTimeframe,
+			CreatedAt:       time.Now(),
+			MarketReport:    reports.MarketReport,
+			CryptoReport:    reports.CryptoReport,
+			SentimentReport: reports.SentimentReport,
+			PositionInfo:    reports.PositionInfo,
+			Decision:        decision,
+			FullDecision:    decision,
+			DecisionPath:    state.GetDecisionPath(),
+			Structured:      agents.ToStructuredDecision(decisions[symbol], decisionSource),
+		}
+		sessionID, err := db.SaveSession(session)
+		if err != nil {
+			log.Warning(fmt.Sprintf("保存 %s 会话失败: %v", symbol, err))
+			continue
+		}
+		if votes := state.GetEnsembleVotes(symbol); len(votes) > 0 {
+			if err := db.SaveEnsembleVotes(sessionID, votes); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  保存 %s 委员会投票失败: %v", symbol, err))
+			}
+		}
+	}
+
+	portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log)
+	if err := portfolioMgr.UpdateBalance(ctx); err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	for _, symbol := range cfg.CryptoSymbols {
+		if err := portfolioMgr.UpdatePosition(ctx, symbol); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  获取 %s 持仓信息失败: %v", symbol, err))
+		}
+	}
+
+	coordinator := executors.NewTradeCoordinator(cfg, executor, log, stopLossManager)
+	riskManager := risk.NewManager(risk.LimitsFromConfig(cfg))
+	if history, err := db.GetBalanceHistory(24); err == nil {
+		withdrawals, _ := db.GetWithdrawalsSince(time.Now().Add(-24 * time.Hour))
+		riskManager.LoadHistory(history, withdrawals)
+	}
+	riskManager.ObserveEquity(portfolioMgr.GetTotalBalance()+portfolioMgr.GetTotalUnrealizedPnL(), time.Now())
+
+	executionResults := make(map[string]string)
+	for symbol, symbolDecision := range decisions {
+		if !symbolDecision.Valid || symbolDecision.Action == executors.ActionHold {
+			executionResults[symbol] = "观望，不执行交易"
+			continue
+		}
+
+		positions, err := executor.GetCurrentPositions(ctx, symbol)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  获取 %s 当前持仓失败: %v", symbol, err))
+		}
+		currentPosition := executors.PositionOnSide(positions, executors.ActionSide(symbolDecision.Action))
+		if err := agents.ValidateDecision(symbolDecision, positions, agents.ThresholdsFromConfig(cfg)); err != nil {
+			executionResults[symbol] = fmt.Sprintf("决策验证失败: %v", err)
+			continue
+		}
+
+		opening := symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell
+		if opening || executors.IsAddOn(symbolDecision.Action) {
+			openAction := symbolDecision.Action
+			if executors.IsAddOn(openAction) {
+				openAction = executors.AddOnAction(openAction)
+			}
+			order, err := portfolioMgr.ProposedOrder(ctx, symbol, openAction,
+				symbolDecision.PositionSizePercent, symbolDecision.Leverage, symbolDecision.StopLoss, 0)
+			if err == nil {
+				err = riskManager.CheckOrder(portfolioMgr.RiskSnapshot(stopLossManager), order, executor.IsHedgeMode(ctx))
+			}
+			if err != nil {
+				executionResults[symbol] = fmt.Sprintf("🛑 风控拒绝: %v", err)
+				continue
+			}
+		}
+		if review := state.GetReview(symbol); review != nil && !review.Approved {
+			executionResults[symbol] = fmt.Sprintf("🛑 风控复核否决: %s", review.Reason)
+			continue
+		}
+
+		var tradeResult *executors.TradeResult
+		if executors.IsAddOn(symbolDecision.Action) {
+			tradeResult, err = coordinator.ExecuteAddOn(ctx, symbol, symbolDecision.Action,
+				symbolDecision.Reason, symbolDecision.PositionSizePercent, symbolDecision.StopLoss, 0)
+		} else if executors.IsReduce(symbolDecision.Action) {
+			tradeResult, err = coordinator.ExecuteReduce(ctx, symbol, symbolDecision.Action, symbolDecision.ReducePercent, symbolDecision.Reason)
+		} else {
+			tradeResult, err = coordinator.ExecuteDecisionWithParams(ctx, symbol, symbolDecision.Action,
+				symbolDecision.Reason, symbolDecision.Leverage, symbolDecision.PositionSizePercent, symbolDecision.StopLoss, 0)
+		}
+		if err != nil {
+			executionResults[symbol] = fmt.Sprintf("执行失败: %v", err)
+			continue
+		}
+		if tradeResult.Skipped {
+			executionResults[symbol] = tradeResult.Message
+			continue
+		}
+		if !tradeResult.Success {
+			executionResults[symbol] = fmt.Sprintf("❌ 执行失败: %s", tradeResult.Message)
+			continue
+		}
+		executionResults[symbol] = fmt.Sprintf("✅ 成功执行 %s", tradeResult.Action)
+
+		if symbolDecision.Action == executors.ActionCloseLong || symbolDecision.Action == executors.ActionCloseShort {
+			realizedPnL := 0.0
+			if currentPosition != nil {
+				realizedPnL = currentPosition.RealizedPnLAt(tradeResult.Price, currentPosition.Size)
+			}
+			if err := stopLossManager.ClosePositionSide(ctx, symbol, executors.ActionSide(symbolDecision.Action), tradeResult.Price, "浸泡测试平仓", realizedPnL); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  关闭 %s 持仓失败: %v", symbol, err))
+			}
+			continue
+		}
+		if opening {
+			openPosition(ctx, cfg, log, stopLossManager, db, state, symbol, symbolDecision, tradeResult)
+		}
+	}
+
+	if err := portfolioMgr.UpdateBalance(ctx); err != nil {
+		return fmt.Errorf("获取更新后的余额失败: %w", err)
+	}
+	if err := db.SaveBalanceHistory(portfolioMgr.BalanceSnapshot()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  保存余额历史失败: %v", err))
+	}
+
+	var resultBuilder strings.Builder
+	for symbol, result := range executionResults {
+		resultBuilder.WriteString(fmt.Sprintf("%s: %s\n", symbol, result))
+	}
+	for _, symbol := range cfg.CryptoSymbols {
+		if err := db.UpdateLatestSessionExecution(symbol, cfg.CryptoTimeframe, true, resultBuilder.String()); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
+		}
+	}
+	return nil
+}
+
+// openPosition registers a newly opened position, persists it and places its stop-loss
+// openPosition 注册新开持仓、保存到数据库并下止损单
+func openPosition(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, stopLossManager *executors.StopLossManager, db *storage.Storage,
+	state *agents.AgentState, symbol string, symbolDecision *agents.TradingDecision, tradeResult *executors.TradeResult) {
+	side := "long"
+	initialStopLoss := symbolDecision.StopLoss
+	if tradeResult.StopLoss > 0 {
+		initialStopLoss = tradeResult.StopLoss // 插件或外部风控调整后的止损 / Adjusted by a plugin or the risk oracle
+	}
+	if symbolDecision.Action == executors.ActionSell {
+		side = "short"
+		if initialStopLoss == 0 {
+			initialStopLoss = tradeResult.Price * 1.025
+		}
+	} else if initialStopLoss == 0 {
+		initialStopLoss = tradeResult.Price * 0.975
+	}
+
+	var atrValue float64
+	if reports := state.GetSymbolReports(symbol); reports != nil && reports.TechnicalIndicators != nil {
+		if atr := reports.TechnicalIndicators.ATR; len(atr) > 0 && !math.IsNaN(atr[len(atr)-1]) {
+			atrValue = atr[len(atr)-1]
+		}
+	}
+
+	position := &executors.Position{
+		ID:              fmt.Sprintf("%s-%d", symbol, time.Now().UnixNano()),
+		Symbol:          symbol,
+		Side:            side,
+		EntryPrice:      tradeResult.Price,
+		EntryTime:       time.Now(),
+		Quantity:        tradeResult.Amount,
+		Leverage:        agents.ValidateLeverage(symbolDecision.Leverage, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax, cfg.BinanceLeverageDynamic),
+		InitialStopLoss: initialStopLoss,
+		CurrentStopLoss: initialStopLoss,
+		StopLossType:    "fixed",
+		OpenReason:      symbolDecision.Reason,
+		ATR:             atrValue,
+	}
+	stopLossManager.RegisterPosition(position)
+
+	if err := db.SavePosition(position.Record()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  保存持仓到数据库失败: %v", err))
+	}
+
+	if err := stopLossManager.PlaceInitialStopLoss(ctx, position); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", err))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,9 +17,16 @@ import (
 	"github.com/oak/crypto-trading-bot/internal/agents"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/health"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/preflight"
+	"github.com/oak/crypto-trading-bot/internal/reports"
+	"github.com/oak/crypto-trading-bot/internal/risk"
 	"github.com/oak/crypto-trading-bot/internal/scheduler"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 	"github.com/oak/crypto-trading-bot/internal/web"
@@ -28,6 +36,18 @@ import (
 // 全局止损管理器
 var globalStopLossManager *executors.StopLossManager
 
+// globalDecisionStream carries streamed decision output from every run to the web UI
+// globalDecisionStream 将每次运行的流式决策输出传递到 Web 界面
+var globalDecisionStream = agents.NewDecisionStream()
+
+// globalLiquidations collects the liquidation stream between runs for the crypto report (nil = disabled)
+// globalLiquidations 在两次运行之间收集强平推送，用于加密货币报告（nil 表示未启用）
+var globalLiquidations *dataflows.LiquidationTracker
+
+// globalEvents pushes positions, sessions, balance snapshots and log lines to the live dashboard
+// globalEvents 将持仓、会话、余额快照和日志推送到实时看板
+var globalEvents = web.NewEventHub()
+
 func main() {
 	// Load configuration
 	// 加载配置
@@ -39,7 +59,7 @@ func main() {
 
 	// Initialize logger
 	// 初始化日志
-	logger.Init(cfg.DebugMode)
+	logger.Init(cfg.DebugMode, cfg.LogFormat)
 	log := logger.Global
 
 	log.Header("加密货币交易机器人 - Web 监控模式 (完整版)", '=', 80)
@@ -49,15 +69,29 @@ func main() {
 	log.Info(fmt.Sprintf("杠杆倍数: %dx", cfg.BinanceLeverage))
 	log.Info(fmt.Sprintf("Web 端口: %d", cfg.WebPort))
 
-	if cfg.BinanceTestMode {
+	analysisOnly := cfg.IsAnalysisOnly()
+	if analysisOnly {
+		log.Success("🔬 运行模式: 仅分析（未配置币安 API Key，只生成决策，不连接账户、不下单）")
+		if cfg.AutoExecute {
+			log.Warning("⚠️  仅分析模式下忽略 AUTO_EXECUTE=true")
+			cfg.AutoExecute = false
+		}
+	} else if cfg.PaperTrading {
+		log.Success(fmt.Sprintf("📝 运行模式: 模拟盘（初始资金 %.2f USDT，不实际下单）", cfg.PaperInitialBalance))
+	} else if cfg.ShadowMode {
+		log.Success(fmt.Sprintf("👻 运行模式: 影子模式（读取真实账户，只记录意向订单不下单，%d 小时后评估）", cfg.ShadowEvalHours))
+	} else if cfg.BinanceTestMode {
 		log.Success("🟢 运行模式: 测试模式（模拟交易）")
 	} else {
 		log.Warning("🔴 运行模式: 实盘模式（真实交易！）")
 	}
+	if names := plugins.Names(); len(names) > 0 {
+		log.Info(fmt.Sprintf("🔌 已加载插件: %s", strings.Join(names, ", ")))
+	}
 
 	// Initialize executor
 	// 初始化执行器
-	executor := executors.NewBinanceExecutor(cfg, log)
+	executor := executors.NewBinanceExecutor(cfg, log.WithComponent("executor"))
 
 	// Initialize storage
 	// 初始化数据库
@@ -77,26 +111,82 @@ func main() {
 
 	log.Success(fmt.Sprintf("数据库已连接: %s", cfg.DatabasePath))
 
+	// Compress large report columns and compact rows written before compression was enabled
+	// 压缩大体积报告字段，并压缩启用前写入的历史记录
+	db.SetReportCompression(cfg.ReportCompression)
+	if compacted, err := db.CompressExistingReports(); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  压缩历史报告失败: %v", err))
+	} else if compacted > 0 {
+		log.Info(fmt.Sprintf("已压缩 %d 条历史会话报告", compacted))
+	}
+
+	// Mark the sessions of a batch left unfinished by the previous shutdown or crash
+	// 标记上次关闭或崩溃时未完成批次的会话
+	if batchID, marked, err := db.RecoverInterruptedBatch(); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  恢复中断批次失败: %v", err))
+	} else if batchID != "" {
+		log.Warning(fmt.Sprintf("⚠️  上次运行的批次 %s 未完成，已将 %d 个会话标记为已中断", batchID, marked))
+	}
+
+	// Notification sinks (Discord / Slack / generic webhook)
+	// 通知渠道（Discord / Slack / 通用 Webhook）
+	notifier := notify.NewFromConfig(cfg)
+	if notifier.Len() > 0 {
+		log.Success(fmt.Sprintf("通知渠道已配置: %d 个", notifier.Len()))
+	}
+
+	// Enable paper trading once storage is available
+	// 数据库就绪后启用模拟盘
+	if cfg.PaperTrading {
+		executor.EnablePaperTrading(db)
+	}
+
+	// Record every live fill in the trades table
+	// 将每笔实盘成交写入 trades 表
+	executor.EnableTradeRecording(db)
+
+	// Persist symbol metadata so restarts reuse the last exchangeInfo refresh
+	// 持久化交易对元数据，重启时复用最近一次 exchangeInfo 刷新结果
+	executor.EnableSymbolRegistry(db)
+
 	// Display statistics for all symbols
 	// 显示所有交易对的统计信息
 	for _, symbol := range cfg.CryptoSymbols {
 		stats, err := db.GetSessionStats(symbol)
 		if err != nil {
 			log.Warning(fmt.Sprintf("获取 %s 历史统计失败: %v", symbol, err))
-		} else if stats["total_sessions"].(int) > 0 {
-			log.Info(fmt.Sprintf("【%s】历史会话: %d, 已执行: %d, 执行率: %.1f%%",
+		} else if stats.TotalSessions > 0 {
+			log.Info(fmt.Sprintf("【%s】历史会话: %d, 已执行: %d, 执行率: %.1f%%, 非 HOLD 决策执行成功率: %.1f%%",
 				symbol,
-				stats["total_sessions"].(int),
-				stats["executed_count"].(int),
-				stats["execution_rate"].(float64)))
+				stats.TotalSessions,
+				stats.ExecutedCount,
+				stats.ExecutionRate,
+				stats.ExecutionSuccessRate))
 		}
 	}
 
-	ctx := context.Background()
+	// Root context, cancelled on shutdown to stop the background goroutines
+	// 根 context，关闭时取消以停止后台 goroutine
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Initialize and verify LLM service
-	// 初始化并验证 LLM 服务
-	log.Subheader("验证 LLM 服务", '─', 80)
+	var background sync.WaitGroup
+
+	// Load order filters (step size, tick size, min notional) from exchangeInfo
+	// 从 exchangeInfo 加载下单过滤规则（数量步长、价格步长、最小订单价值）
+	if err := executor.LoadSymbolFilters(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  加载交易对精度规则失败: %v（将在下单时重试，失败则使用默认精度）", err))
+	} else {
+		for _, symbol := range cfg.CryptoSymbols {
+			f := executor.SymbolFiltersFor(ctx, symbol)
+			log.Info(fmt.Sprintf("【%s】数量步长 %g（最小 %g），价格步长 %g，最小订单价值 %g USDT",
+				symbol, f.StepSize, f.MinQty, f.TickSize, f.MinNotional))
+		}
+	}
+
+	// Initialize LLM service (verified by the pre-flight checks below)
+	// 初始化 LLM 服务（由下方的启动前检查验证）
+	log.Subheader("初始化 LLM 服务", '─', 80)
 
 	llmCfg := &openaiComponent.ChatModelConfig{
 		APIKey:  cfg.APIKey,
@@ -112,43 +202,64 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Test LLM service with a simple call
-	// 使用简单调用测试 LLM 服务
-	log.Info(fmt.Sprintf("🔍 测试 LLM 服务连接..."))
-	log.Info(fmt.Sprintf("   模型: %s", cfg.QuickThinkLLM))
-	log.Info(fmt.Sprintf("   API: %s", cfg.BackendURL))
-
-	testMessages := []*schema.Message{
-		schema.SystemMessage("你是一个测试助手"),
-		schema.UserMessage("请回复：OK"),
+	// Sync the Binance clock offset before the first signed request (the pre-flight check reads the balance)
+	// 在第一次签名请求（启动前检查会读取余额）之前同步币安时钟偏移
+	clockMonitor := executors.NewClockMonitor(cfg, executor, notifier, log.WithComponent("clock"))
+	if !analysisOnly {
+		clockMonitor.Sync(ctx)
 	}
 
-	testResponse, err := chatModel.Generate(ctx, testMessages)
-	if err != nil {
-		log.Error(fmt.Sprintf("❌ LLM 服务测试失败: %v", err))
-		log.Error(fmt.Sprintf("请检查配置: API=%s, Model=%s", cfg.BackendURL, cfg.QuickThinkLLM))
+	// Pre-flight: check every configured dependency once and fail fast before entering the loop
+	// 启动前检查：统一检查所有已配置的依赖，有问题立即退出，而不是在交易周期中途出错
+	log.Subheader("启动前检查", '─', 80)
+	report := preflight.Run(ctx, []preflight.Check{
+		preflight.LLM(cfg, func(ctx context.Context) error {
+			_, err := chatModel.Generate(ctx, []*schema.Message{
+				schema.SystemMessage("你是一个测试助手"),
+				schema.UserMessage("请回复：OK"),
+			})
+			return err
+		}),
+		preflight.BinanceKeys(cfg, executor.GetBalance),
+		preflight.Symbols(cfg.CryptoSymbols, executor.UnlistedSymbols),
+		preflight.PromptFile(cfg.TraderPromptPath),
+		preflight.Database(cfg.DatabasePath),
+		preflight.WebPort(cfg.WebPort),
+		preflight.Proxy(cfg.BinanceProxy),
+	})
+	log.Info("\n" + report.Table())
+	if !report.Passed() {
+		for _, failure := range report.Failures() {
+			log.Error(fmt.Sprintf("❌ %s: %s", failure.Name, failure.Detail))
+			log.Error(fmt.Sprintf("   💡 %s", failure.Hint))
+		}
 		os.Exit(1)
 	}
-
-	log.Success("✅ LLM 服务可用")
-	if testResponse.ResponseMeta != nil && testResponse.ResponseMeta.Usage != nil {
-		log.Info(fmt.Sprintf("   测试消耗 Token: %d", testResponse.ResponseMeta.Usage.TotalTokens))
-	}
+	log.Success("✅ 启动前检查全部通过")
 
 	// Setup exchange for all symbols
 	// 为所有交易对设置交易所参数
 	log.Subheader("设置交易所参数", '─', 80)
-	for _, symbol := range cfg.CryptoSymbols {
-		if err := executor.SetupExchange(ctx, symbol, cfg.BinanceLeverage); err != nil {
-			log.Error(fmt.Sprintf("设置 %s 交易所失败: %v", symbol, err))
-			os.Exit(1)
+	if analysisOnly {
+		log.Info("🔬 仅分析模式：跳过交易所设置")
+	} else {
+		// Switch the account-wide position mode first, every symbol's setup depends on it
+		// 先切换账户级的持仓模式，各交易对的设置都依赖它
+		if err := executor.ApplyPositionMode(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  切换持仓模式失败: %v（按检测到的持仓模式继续）", err))
+		}
+		for _, symbol := range cfg.CryptoSymbols {
+			if err := executor.SetupExchange(ctx, symbol, cfg.BinanceLeverage); err != nil {
+				log.Error(fmt.Sprintf("设置 %s 交易所失败: %v", symbol, err))
+				os.Exit(1)
+			}
+			log.Success(fmt.Sprintf("✅ %s 交易所设置完成", symbol))
 		}
-		log.Success(fmt.Sprintf("✅ %s 交易所设置完成", symbol))
 	}
 
 	// Check margin type and warn if using isolated margin with dynamic leverage
 	// 检查保证金类型，如果在逐仓模式下使用动态杠杆则发出警告
-	if cfg.BinanceLeverageDynamic && len(cfg.CryptoSymbols) > 0 {
+	if !analysisOnly && cfg.BinanceLeverageDynamic && len(cfg.CryptoSymbols) > 0 {
 		log.Subheader("保证金模式检查", '─', 80)
 		firstSymbol := cfg.CryptoSymbols[0]
 		marginType, err := executor.DetectMarginType(ctx, firstSymbol)
@@ -168,7 +279,7 @@ func main() {
 				log.Warning("   • 这可能导致实际杠杆与 LLM 选择的杠杆不一致")
 				log.Warning("")
 				log.Warning("   💡 建议：")
-				log.Warning("   1. 切换到全仓模式（Binance 网页 → 合约 → 设置 → 保证金模式 → 全仓）")
+				log.Warning("   1. 切换到全仓模式（设置 BINANCE_MARGIN_TYPE=cross，或 Binance 网页 → 合约 → 设置 → 保证金模式 → 全仓）")
 				log.Warning("   2. 或使用固定杠杆（例如 BINANCE_LEVERAGE=10）")
 				log.Warning("")
 			} else {
@@ -181,83 +292,44 @@ func main() {
 	// Initialize stop-loss manager
 	// 初始化止损管理器
 	log.Subheader("初始化止损管理器", '─', 80)
-	globalStopLossManager = executors.NewStopLossManager(cfg, executor, log, db)
+	globalStopLossManager = executors.NewStopLossManager(cfg, executor, log.WithComponent("stoploss"), db)
 
-	// Load existing active positions from database
-	// 从数据库加载现有活跃持仓
-	activePositions, err := db.GetActivePositions()
+	// Restore active positions from the database, checking each still exists on the exchange
+	// 从数据库恢复活跃持仓，并核对每个持仓在交易所是否仍存在
+	restored, stale, err := globalStopLossManager.RestoreActivePositions(ctx, !analysisOnly)
 	if err != nil {
 		log.Warning(fmt.Sprintf("加载活跃持仓失败: %v", err))
-	} else if len(activePositions) > 0 {
-		log.Info(fmt.Sprintf("发现 %d 个活跃持仓，正在注册到止损管理器...", len(activePositions)))
-
-		// Deduplicate positions by normalized symbol
-		// 按标准化符号去重持仓
-		// This prevents BTC/USDT and BTCUSDT being treated as separate positions
-		// 防止 BTC/USDT 和 BTCUSDT 被当作不同的持仓
-		posMap := make(map[string]*storage.PositionRecord)
-		for _, posRecord := range activePositions {
-			normalizedSymbol := cfg.GetBinanceSymbolFor(posRecord.Symbol)
-
-			// If duplicate found, keep the one with valid entry price
-			// 如果发现重复，保留有效入场价的记录
-			if existing, ok := posMap[normalizedSymbol]; ok {
-				// Prefer record with non-zero entry price
-				// 优先选择入场价非零的记录
-				if posRecord.EntryPrice > 0 && existing.EntryPrice == 0 {
-					log.Warning(fmt.Sprintf("⚠️  发现重复持仓: %s 和 %s，保留入场价非零的记录",
-						existing.Symbol, posRecord.Symbol))
-					posMap[normalizedSymbol] = posRecord
-				} else if posRecord.EntryPrice == 0 && existing.EntryPrice > 0 {
-					log.Warning(fmt.Sprintf("⚠️  发现重复持仓: %s 和 %s，保留入场价非零的记录",
-						posRecord.Symbol, existing.Symbol))
-					// Keep existing
-				} else {
-					log.Warning(fmt.Sprintf("⚠️  发现重复持仓: %s 和 %s，保留第一个",
-						existing.Symbol, posRecord.Symbol))
-				}
-			} else {
-				posMap[normalizedSymbol] = posRecord
-			}
-		}
-
-		// Register deduplicated positions
-		// 注册去重后的持仓
-		for normalizedSymbol, posRecord := range posMap {
-			// Convert PositionRecord to Position
-			// 将 PositionRecord 转换为 Position
-			pos := &executors.Position{
-				ID:               posRecord.ID,
-				Symbol:           normalizedSymbol, // Use normalized symbol / 使用标准化符号
-				Side:             posRecord.Side,
-				EntryPrice:       posRecord.EntryPrice,
-				EntryTime:        posRecord.EntryTime,
-				Quantity:         posRecord.Quantity,
-				InitialStopLoss:  posRecord.InitialStopLoss,
-				CurrentStopLoss:  posRecord.CurrentStopLoss,
-				StopLossType:     posRecord.StopLossType,
-				TrailingDistance: posRecord.TrailingDistance,
-				HighestPrice:     posRecord.HighestPrice,
-				CurrentPrice:     posRecord.CurrentPrice,
-				OpenReason:       posRecord.OpenReason,
-				ATR:              posRecord.ATR,
-				StopLossOrderID:  posRecord.StopLossOrderID, // ✅ 恢复止损单 ID
-			}
-			globalStopLossManager.RegisterPosition(pos)
-			log.Success(fmt.Sprintf("已恢复持仓: %s %s @ $%.2f", normalizedSymbol, posRecord.Side, posRecord.EntryPrice))
-		}
-	} else {
+	} else if restored+stale == 0 {
 		log.Info("暂无活跃持仓")
+	} else {
+		log.Info(fmt.Sprintf("已恢复 %d 个活跃持仓，%d 个过期持仓已标记并对账", restored, stale))
+	}
+
+	// Settle orders whose outcome was lost (e.g. the process died right after sending them)
+	// 确认结果丢失的订单（例如发送后进程立即退出）
+	if report, err := executor.ReconcileOrders(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  订单对账失败: %v", err))
+	} else if report.Checked > 0 {
+		log.Info(fmt.Sprintf("🔄 订单对账: 检查 %d 笔，成交 %d 笔，未送达 %d 笔，仍挂单 %d 笔",
+			report.Checked, len(report.Filled), report.Missing, report.Open))
+		for _, o := range report.Filled {
+			if o.Leg == executors.LegOpen && globalStopLossManager.GetPosition(o.Symbol) == nil {
+				log.Warning(fmt.Sprintf("🚨 开仓订单 %s（%s %s %.4f）已成交但未被跟踪，请检查持仓和止损",
+					o.ClientOrderID, o.Symbol, o.Side, o.ExecutedQty))
+			}
+		}
 	}
 
 	// Initialize portfolio manager for balance tracking
 	// 初始化投资组合管理器用于余额跟踪
-	portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log)
+	portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log.WithComponent("portfolio"))
 
 	// Save initial balance snapshot
 	// 保存初始余额快照
 	log.Subheader("保存初始余额快照", '─', 80)
-	if err := portfolioMgr.UpdateBalance(ctx); err != nil {
+	if analysisOnly {
+		log.Info("🔬 仅分析模式：没有账户余额可记录")
+	} else if err := portfolioMgr.UpdateBalance(ctx); err != nil {
 		log.Warning(fmt.Sprintf("⚠️  获取初始余额失败: %v", err))
 	} else {
 		// Update positions for all symbols
@@ -268,13 +340,7 @@ func main() {
 			}
 		}
 
-		initialBalance := &storage.BalanceHistory{
-			Timestamp:        time.Now(),
-			TotalBalance:     portfolioMgr.GetTotalBalance(),
-			AvailableBalance: portfolioMgr.GetAvailableBalance(),
-			UnrealizedPnL:    portfolioMgr.GetTotalUnrealizedPnL(),
-			Positions:        portfolioMgr.GetPositionCount(),
-		}
+		initialBalance := portfolioMgr.BalanceSnapshot()
 		if err := db.SaveBalanceHistory(initialBalance); err != nil {
 			log.Warning(fmt.Sprintf("⚠️  保存初始余额快照失败: %v", err))
 		} else {
@@ -295,43 +361,69 @@ func main() {
 	// 	globalStopLossManager.MonitorPositions(10 * time.Second)
 	// }()
 
-	// Start balance history recording in background
-	// 在后台启动余额历史记录
-	go func() {
-		log.Success("📊 启动余额历史记录，间隔: 5 分钟")
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			// Update balance
-			if err := portfolioMgr.UpdateBalance(ctx); err != nil {
-				log.Warning(fmt.Sprintf("⚠️  更新余额失败: %v", err))
-				continue
+	// Prune old trading sessions at startup and once a day afterwards
+	// 启动时及此后每天清理一次旧的交易会话
+	if cfg.SessionRetentionDays > 0 || cfg.SessionRetentionCount > 0 {
+		pruneSessions(db, cfg, log)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			ticker := time.NewTicker(24 * time.Hour)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					pruneSessions(db, cfg, log)
+				}
 			}
+		}()
+	}
 
-			// Update positions for all symbols
-			for _, symbol := range cfg.CryptoSymbols {
-				if err := portfolioMgr.UpdatePosition(ctx, symbol); err != nil {
-					log.Warning(fmt.Sprintf("⚠️  获取 %s 持仓信息失败: %v", symbol, err))
+	// Start balance history recording in background (there is no account in analysis-only mode)
+	// 在后台启动余额历史记录（仅分析模式下没有账户）
+	if !analysisOnly {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			log.Success("📊 启动余额历史记录，间隔: 5 分钟")
+			ticker := time.NewTicker(5 * time.Minute)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
 				}
-			}
 
-			// Save balance snapshot
-			balanceHistory := &storage.BalanceHistory{
-				Timestamp:        time.Now(),
-				TotalBalance:     portfolioMgr.GetTotalBalance(),
-				AvailableBalance: portfolioMgr.GetAvailableBalance(),
-				UnrealizedPnL:    portfolioMgr.GetTotalUnrealizedPnL(),
-				Positions:        portfolioMgr.GetPositionCount(),
-			}
-			if err := db.SaveBalanceHistory(balanceHistory); err != nil {
-				log.Warning(fmt.Sprintf("⚠️  保存余额历史失败: %v", err))
-			} else {
-				log.Info(fmt.Sprintf("💾 余额快照已保存: %.2f USDT (持仓: %d)",
-					balanceHistory.TotalBalance, balanceHistory.Positions))
+				// Update balance
+				if err := portfolioMgr.UpdateBalance(ctx); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  更新余额失败: %v", err))
+					continue
+				}
+
+				// Update positions for all symbols
+				for _, symbol := range cfg.CryptoSymbols {
+					if err := portfolioMgr.UpdatePosition(ctx, symbol); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  获取 %s 持仓信息失败: %v", symbol, err))
+					}
+				}
+
+				// Save balance snapshot
+				balanceHistory := portfolioMgr.BalanceSnapshot()
+				publishPortfolio(portfolioMgr, cfg.CryptoSymbols)
+				if err := db.SaveBalanceHistory(balanceHistory); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  保存余额历史失败: %v", err))
+				} else {
+					log.Info(fmt.Sprintf("💾 余额快照已保存: %.2f USDT (持仓: %d)",
+						balanceHistory.TotalBalance, balanceHistory.Positions))
+				}
 			}
-		}
-	}()
+		}()
+	}
 
 	// Initialize scheduler
 	// 初始化调度器（使用 TradingInterval 而不是 CryptoTimeframe）
@@ -344,9 +436,256 @@ func main() {
 
 	log.Success(fmt.Sprintf("调度器已初始化 (运行间隔: %s, K线间隔: %s)", cfg.TradingInterval, cfg.CryptoTimeframe))
 
+	// Review open positions faster (ACTIVE_TRADING_INTERVAL), stay on TRADING_INTERVAL when flat
+	// 有持仓时加快复查频率（ACTIVE_TRADING_INTERVAL），空仓时保持 TRADING_INTERVAL
+	if err := tradingScheduler.SetActiveTimeframe(cfg.ActiveInterval); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  ACTIVE_TRADING_INTERVAL 无效，有持仓时不加速: %v", err))
+	} else if cfg.ActiveInterval != "" {
+		log.Info(fmt.Sprintf("⏩ 有持仓时运行间隔: %s", cfg.ActiveInterval))
+	}
+	syncSchedulerCadence(tradingScheduler, log)
+
+	// Run analysis on a cron expression instead of timeframe boundaries (ANALYSIS_CRON)
+	// 按 cron 表达式而不是周期边界运行分析（ANALYSIS_CRON）
+	if cfg.AnalysisCron != "" {
+		if err := tradingScheduler.SetCron(cfg.AnalysisCron); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  ANALYSIS_CRON 无效，按 TRADING_INTERVAL 运行: %v", err))
+		} else {
+			log.Info(fmt.Sprintf("⏰ 分析任务按 cron 运行: %s (UTC)", cfg.AnalysisCron))
+		}
+	}
+
 	// Start web server (pass scheduler to enable config updates)
 	// 启动 Web 服务器（传递调度器以启用配置更新）
-	webServer := web.NewServer(cfg, log, db, globalStopLossManager, tradingScheduler)
+	webServer := web.NewServer(cfg, log.WithComponent("web"), db, globalStopLossManager, tradingScheduler, notifier)
+
+	// Flush in-memory price and trade histories to storage periodically
+	// 定期将内存中的价格历史和交易结果写入数据库
+	historyFlusher := executors.NewHistoryFlusher(executor, globalStopLossManager, db, log)
+	if cfg.HistoryFlushInterval > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			historyFlusher.Run(ctx, time.Duration(cfg.HistoryFlushInterval)*time.Second)
+		}()
+	}
+	webServer.SetHistoryFlusher(historyFlusher)
+	webServer.SetExecutor(executor)
+	webServer.SetDecisionStream(globalDecisionStream)
+	webServer.SetEventHub(globalEvents)
+
+	// Mirror log lines to the live dashboard
+	// 将日志同步推送到实时看板
+	log.SetSink(func(level, message string) {
+		globalEvents.Publish(web.EventLog, map[string]string{"level": level, "message": message})
+	})
+
+	// Analysis batches get their own context so shutdown can give them a grace period
+	// before cancelling them, independently of the background goroutines
+	// 分析批次使用独立的 context，关闭时先给予宽限时间再取消，与后台 goroutine 互不影响
+	analysisCtx, cancelAnalysis := context.WithCancel(context.Background())
+	defer cancelAnalysis()
+
+	// Scheduled and dashboard-triggered batches share one runner so they never overlap
+	// 定时批次和控制台触发的批次共用一个运行器，保证不会重叠
+	runner := newAnalysisRunner(log, func(batchID string) {
+		if err := runTradingAnalysis(analysisCtx, cfg, log, executor, db, notifier, batchID); err != nil {
+			log.Error(fmt.Sprintf("交易分析失败: %v", err))
+			return
+		}
+		// Reported by /api/health as the last successful run
+		// 作为最近一次成功运行由 /api/health 返回
+		if err := health.RecordSuccessfulRun(db, batchID, time.Now()); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  记录成功运行失败: %v", err))
+		}
+	})
+	webServer.SetRunTrigger(func() (string, bool) {
+		return runner.Start("控制台触发")
+	})
+
+	// Cron jobs between analyses (STOP_REVIEW_CRON, DAILY_REPORT_CRON, WEEKLY_REPORT_CRON); the stop review is skipped while an
+	// analysis runs because the analysis reviews the stops itself
+	// 分析之间的 cron 定时任务（STOP_REVIEW_CRON、DAILY_REPORT_CRON、WEEKLY_REPORT_CRON）；分析进行中时跳过止损复查，因为分析本身会复查止损
+	jobs := scheduler.NewJobs(log.WithComponent("jobs"))
+	jitter := time.Duration(cfg.ScheduleJitter) * time.Second
+	if !analysisOnly && cfg.StopReviewCron != "" {
+		if err := jobs.Add("止损复查", cfg.StopReviewCron, jitter, func(ctx context.Context) error {
+			if runner.Busy() {
+				return nil
+			}
+			return globalStopLossManager.ReviewStops(ctx, cfg.CryptoSymbols)
+		}); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  STOP_REVIEW_CRON 无效，不启用止损复查: %v", err))
+		}
+	}
+	if !analysisOnly && cfg.DailyReportCron != "" {
+		if err := jobs.Add("每日报告", cfg.DailyReportCron, jitter, func(ctx context.Context) error {
+			_, err := reports.Send(ctx, db, notifier, reports.PeriodDaily, time.Now())
+			return err
+		}); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  DAILY_REPORT_CRON 无效，不发送每日报告: %v", err))
+		}
+	}
+	if !analysisOnly && cfg.WeeklyReportCron != "" {
+		if err := jobs.Add("每周报告", cfg.WeeklyReportCron, jitter, func(ctx context.Context) error {
+			_, err := reports.Send(ctx, db, notifier, reports.PeriodWeekly, time.Now())
+			return err
+		}); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  WEEKLY_REPORT_CRON 无效，不发送每周报告: %v", err))
+		}
+	}
+	if statuses := jobs.Status(); len(statuses) > 0 {
+		for _, job := range statuses {
+			log.Info(fmt.Sprintf("⏰ 定时任务 %s: %s (UTC)，下次 %s", job.Name, job.Spec, job.NextRun.Format("2006-01-02 15:04:05")))
+		}
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			jobs.Run(ctx, time.Second)
+		}()
+		webServer.SetJobs(jobs)
+	}
+
+	// Collect liquidations between runs; Binance no longer serves them over REST
+	// 在两次运行之间收集爆仓数据（币安已不再通过 REST 提供）
+	if cfg.LiquidationWindowMinutes > 0 {
+		globalLiquidations = dataflows.NewLiquidationTracker(time.Duration(cfg.LiquidationWindowMinutes) * time.Minute)
+		liquidationLog := log.WithComponent("liquidations")
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			globalLiquidations.Run(ctx, cfg.GetAllBinanceSymbols(), func(symbol string, err error) {
+				liquidationLog.Warning(fmt.Sprintf("⚠️  %s 强平推送异常: %v（稍后重连）", symbol, err))
+			})
+		}()
+	}
+
+	// Move a slice of the profits to the spot wallet periodically
+	// 定期将部分利润划转到现货钱包
+	if !analysisOnly && cfg.ProfitSweepEnabled && cfg.ProfitSweepInterval > 0 {
+		sweeper := executors.NewProfitSweeper(cfg, executor, db, log.WithComponent("sweeper"))
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			sweeper.Run(ctx, time.Duration(cfg.ProfitSweepInterval)*time.Second)
+		}()
+		log.Info(fmt.Sprintf("💰 利润提取已启用：权益超过基准 %.0f%% 时提取超出部分的 %.0f%%", cfg.ProfitSweepThreshold, cfg.ProfitSweepPercent))
+	}
+
+	// Snapshot the whole bot state once a day and report what changed since the previous day
+	// 每天保存一次完整的机器人状态快照，并报告与前一天相比的变化
+	if !analysisOnly && cfg.CheckpointEnabled {
+		checkpointer := executors.NewStateCheckpointer(cfg, executor, db, notifier, log.WithComponent("checkpoint"))
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			checkpointer.Run(ctx, time.Minute)
+		}()
+		log.Info(fmt.Sprintf("📸 日终快照已启用：每天 UTC %02d:00 保存状态并对比前一天", cfg.CheckpointHour))
+	}
+
+	// Pause order placement while Binance is under maintenance, resuming automatically (live trading only, see SystemStatusURL)
+	// 币安系统维护期间暂停下单，维护结束后自动恢复（仅实盘，见 SystemStatusURL）
+	if !analysisOnly && cfg.MaintenanceCheckInterval > 0 && executors.SystemStatusURL(cfg) != "" {
+		maintenance := executors.NewMaintenanceMonitor(executor, log.WithComponent("maintenance"))
+		if _, err := maintenance.Check(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  检查币安系统状态失败: %v", err))
+		}
+		executor.SetMaintenanceMonitor(maintenance)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			maintenance.Run(ctx, time.Duration(cfg.MaintenanceCheckInterval)*time.Second)
+		}()
+	}
+
+	// Act on positions getting too close to their liquidation price
+	// 对距强平价过近的持仓采取动作
+	if !analysisOnly && cfg.LiquidationGuardDistancePercent > 0 {
+		liquidationGuard := executors.NewLiquidationGuard(cfg, executor, globalStopLossManager, notifier, log.WithComponent("liquidation"))
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			liquidationGuard.Run(ctx, time.Duration(cfg.LiquidationGuardInterval)*time.Second)
+		}()
+		log.Info(fmt.Sprintf("🚨 强平距离监控已启用：距强平价低于 %.1f%% 时 %s", cfg.LiquidationGuardDistancePercent, cfg.LiquidationGuardAction))
+	}
+
+	// Keep watching the local clock drift; the offset itself was set once at startup
+	// 持续监测本地时钟偏差并告警；时间偏移只在启动时设置一次
+	if !analysisOnly && cfg.ClockDriftCheckInterval > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			clockMonitor.Run(ctx, time.Duration(cfg.ClockDriftCheckInterval)*time.Second)
+		}()
+	}
+
+	// Real-time fills, position updates, liquidations and margin calls (live trading only)
+	// 实时成交、持仓更新、强平和追加保证金通知（仅实盘）
+	if !analysisOnly && cfg.UserDataStreamEnabled && !cfg.PaperTrading && !cfg.BinanceTestMode {
+		userStream := executors.NewUserDataStream(executor, globalStopLossManager, notifier, log.WithComponent("userstream"))
+		executor.SetUserDataStream(userStream)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			userStream.Run(ctx)
+		}()
+	}
+
+	// Kill switch: the dashboard or the KILL_SWITCH_FILE sentinel halts entries until re-armed
+	// 急停开关：控制台或 KILL_SWITCH_FILE 急停文件停止开仓，直到被解除
+	if !analysisOnly {
+		killSwitch := executors.NewKillSwitch(db, cfg, log.WithComponent("killswitch"))
+		executor.SetKillSwitch(killSwitch)
+		if state, _, err := killSwitch.Check(); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败: %v", err))
+		} else if state.Engaged {
+			log.Error(fmt.Sprintf("🛑 急停开关仍处于启用状态（%s）: %s，解除前不会开仓", state.EngagedAt.Format("01-02 15:04"), state.Reason))
+		}
+
+		killCoordinator := executors.NewTradeCoordinator(cfg, executor, log.WithComponent("killswitch"), globalStopLossManager)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			killSwitch.Run(ctx, func(state *executors.KillSwitchState) {
+				killCoordinator.RespondToKillSwitch(ctx, state, notifier)
+			})
+		}()
+	}
+
+	// Manual trades from the dashboard share the executor and stop-loss manager with the trading loop
+	// 控制台人工交易与交易循环共享执行器和止损管理器
+	if !analysisOnly {
+		webServer.SetTradeCoordinator(executors.NewTradeCoordinator(cfg, executor, log.WithComponent("coordinator"), globalStopLossManager))
+	}
+
+	// Check stop entries between cycles so a fill gets its initial stop-loss right away
+	// 在周期之间检查条件入场单，成交后立即下初始止损单
+	if !analysisOnly && cfg.StopEntryCheckInterval > 0 {
+		entryCoordinator := executors.NewTradeCoordinator(cfg, executor, log.WithComponent("entries"), globalStopLossManager)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			ticker := time.NewTicker(time.Duration(cfg.StopEntryCheckInterval) * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				filled, err := entryCoordinator.SyncPendingEntries(ctx)
+				if err != nil {
+					log.Warning(fmt.Sprintf("⚠️  检查条件入场单失败: %v", err))
+					continue
+				}
+				notifyEntryFills(ctx, notifier, filled, log)
+			}
+		}()
+	}
 	go func() {
 		if err := webServer.Start(); err != nil {
 			log.Error(fmt.Sprintf("Web 服务器启动失败: %v", err))
@@ -365,45 +704,179 @@ func main() {
 
 	// Trading loop
 	// 交易循环
-	runCount := 0
 	ticker := time.NewTicker(1 * time.Minute) // Check every minute
 	defer ticker.Stop()
+	tradingScheduler.Heartbeat()
 
 	for {
 		select {
-		case <-sigChan:
-			log.Warning("\n收到停止信号，正在关闭...")
-			globalStopLossManager.Stop()
-			if err := webServer.Stop(ctx); err != nil {
+		case sig := <-sigChan:
+			log.Warning(fmt.Sprintf("\n收到停止信号 (%s)，正在关闭...", sig))
+
+			// 1. Stop accepting dashboard requests (manual trades, config changes)
+			// 1. 停止接受控制台请求（人工交易、配置修改）
+			stopCtx, stopCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := webServer.Stop(stopCtx); err != nil {
 				log.Warning(fmt.Sprintf("Web 服务器停止失败: %v", err))
 			}
+			stopCancel()
+
+			// 2. Let the in-flight batch finish, cancel it after SHUTDOWN_TIMEOUT
+			// 2. 等待进行中的批次完成，超过 SHUTDOWN_TIMEOUT 后取消
+			if analysisDone := runner.InFlight(); analysisDone != nil {
+				timeout := time.Duration(cfg.ShutdownTimeout) * time.Second
+				log.Info(fmt.Sprintf("⏳ 等待进行中的分析批次完成（最多 %s）...", timeout))
+				select {
+				case <-analysisDone:
+				case <-time.After(timeout):
+					log.Warning("⚠️  等待超时，取消进行中的分析批次")
+					cancelAnalysis()
+					select {
+					case <-analysisDone:
+					case <-time.After(10 * time.Second):
+						log.Warning("⚠️  分析批次未能及时退出，下次启动时将标记为已中断")
+					}
+				}
+			}
+
+			// 3. Stop the background goroutines (balance recorder, history flusher, profit sweeper,
+			//    state checkpointer, maintenance monitor, cron jobs), then flush what is left
+			// 3. 停止后台 goroutine（余额记录、历史写入、利润提取、日终快照、维护监控、定时任务），然后写入剩余数据
+			cancel()
+			background.Wait()
+			if err := historyFlusher.Flush(); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  写入历史数据失败: %v", err))
+			}
+			globalStopLossManager.Stop()
+
+			log.Success("✅ 已安全关闭")
 			return
 
+		case <-runner.Finished():
+			syncSchedulerCadence(tradingScheduler, log)
+
+			// Calculate next run time
+			// 计算下次执行时间
+			nextTime := tradingScheduler.GetNextTimeframeTime()
+			log.Info(fmt.Sprintf("下次执行时间: %s", nextTime.Format("2006-01-02 15:04:05")))
+			log.Header("等待下一次执行", '=', 80)
+
 		case <-ticker.C:
+			// Liveness for /health: the loop is still checking the schedule
+			// /health 的存活信号：交易循环仍在检查调度
+			tradingScheduler.Heartbeat()
+
+			// Positions may have been closed by stops between batches
+			// 批次之间持仓可能已被止损平掉
+			syncSchedulerCadence(tradingScheduler, log)
+
 			// Check if it's time to run
 			// 检查是否到达执行时间
-			if tradingScheduler.IsOnTimeframe() {
-				runCount++
-				log.Header(fmt.Sprintf("第 %d 次执行", runCount), '=', 80)
-				log.Info(fmt.Sprintf("执行时间: %s", time.Now().Format("2006-01-02 15:04:05")))
-
-				// Run trading analysis with auto-execution
-				// 运行交易分析并自动执行
-				if err := runTradingAnalysis(ctx, cfg, log, executor, db); err != nil {
-					log.Error(fmt.Sprintf("交易分析失败: %v", err))
-				}
-
-				// Calculate next run time
-				// 计算下次执行时间
-				nextTime := tradingScheduler.GetNextTimeframeTime()
-				log.Info(fmt.Sprintf("下次执行时间: %s", nextTime.Format("2006-01-02 15:04:05")))
-				log.Header("等待下一次执行", '=', 80)
+			if !tradingScheduler.IsOnTimeframe() {
+				continue
+			}
+			// Run trading analysis with auto-execution in the background so signals are still handled
+			// 在后台运行交易分析并自动执行，以便仍能处理停止信号
+			if batchID, started := runner.Start("定时"); !started {
+				log.Warning(fmt.Sprintf("⚠️  上一个分析批次 %s 仍在进行，跳过本次执行", batchID))
 			}
 		}
 	}
 }
 
-func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage) error {
+// syncSchedulerCadence switches the scheduler to the active timeframe while the stop-loss manager holds positions
+// syncSchedulerCadence 在止损管理器持有仓位时将调度器切换到持仓周期
+func syncSchedulerCadence(tradingScheduler *scheduler.TradingScheduler, log *logger.ColorLogger) {
+	open := len(globalStopLossManager.GetAllPositions()) > 0
+	if !tradingScheduler.SetPositionsOpen(open) {
+		return
+	}
+	if open {
+		log.Info(fmt.Sprintf("⏩ 有持仓，运行间隔切换为 %s", tradingScheduler.CurrentTimeframe()))
+	} else {
+		log.Info(fmt.Sprintf("⏸️  已空仓，运行间隔恢复为 %s", tradingScheduler.CurrentTimeframe()))
+	}
+	log.Info(fmt.Sprintf("下次执行时间: %s", tradingScheduler.GetNextTimeframeTime().Format("2006-01-02 15:04:05")))
+}
+
+// analysisRunner runs analysis batches one at a time, whether scheduled or triggered from the dashboard
+// analysisRunner 逐个运行分析批次，无论是定时触发还是控制台触发
+type analysisRunner struct {
+	mu       sync.Mutex
+	log      *logger.ColorLogger
+	run      func(batchID string)
+	batchID  string        // 进行中的批次（空闲时为 ""）/ In-flight batch ("" when idle)
+	done     chan struct{} // 进行中批次的完成信号 / Done signal of the in-flight batch
+	runCount int
+	finished chan struct{} // 每个批次完成后通知交易循环 / Tells the trading loop a batch finished
+}
+
+// newAnalysisRunner creates a runner that calls run in the background for every started batch
+// newAnalysisRunner 创建运行器，每个启动的批次在后台调用 run
+func newAnalysisRunner(log *logger.ColorLogger, run func(batchID string)) *analysisRunner {
+	return &analysisRunner{log: log, run: run, finished: make(chan struct{}, 1)}
+}
+
+// Start starts a batch unless one is running; returns the batch ID and whether it was started
+// (the in-flight batch's ID when not)
+// Start 在没有批次运行时启动新批次；返回批次 ID 以及是否已启动（未启动时返回进行中批次的 ID）
+func (r *analysisRunner) Start(trigger string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.batchID != "" {
+		return r.batchID, false
+	}
+
+	// All symbols in this run share the same batch_id
+	// 本次运行的所有交易对共享相同的 batch_id
+	r.runCount++
+	r.batchID = fmt.Sprintf("batch-%d", time.Now().Unix())
+	r.done = make(chan struct{})
+	r.log.Header(fmt.Sprintf("第 %d 次执行（%s）", r.runCount, trigger), '=', 80)
+	r.log.Info(fmt.Sprintf("执行时间: %s", time.Now().Format("2006-01-02 15:04:05")))
+
+	go func(batchID string, done chan struct{}) {
+		defer func() {
+			r.mu.Lock()
+			r.batchID, r.done = "", nil
+			r.mu.Unlock()
+			close(done)
+			select {
+			case r.finished <- struct{}{}:
+			default:
+			}
+		}()
+		r.run(batchID)
+	}(r.batchID, r.done)
+	return r.batchID, true
+}
+
+// Busy reports whether a batch is running
+// Busy 返回是否有批次正在运行
+func (r *analysisRunner) Busy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batchID != ""
+}
+
+// InFlight returns the done signal of the running batch, nil when idle
+// InFlight 返回进行中批次的完成信号，空闲时返回 nil
+func (r *analysisRunner) InFlight() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done == nil {
+		return nil
+	}
+	return r.done
+}
+
+// Finished signals after each batch completes
+// Finished 在每个批次完成后发出信号
+func (r *analysisRunner) Finished() <-chan struct{} {
+	return r.finished
+}
+
+func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage, notifier notify.Notifier, batchID string) error {
 	// Create trading graph
 	// 创建交易图工作流
 	log.Subheader("初始化 Eino Graph 工作流", '─', 80)
@@ -414,14 +887,83 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	log.Info("  • 交易员 (Trader)")
 	log.Info("")
 
-	tradingGraph := agents.NewSimpleTradingGraph(cfg, log, executor, globalStopLossManager)
+	tradingGraph := agents.NewSimpleTradingGraph(cfg, log.WithComponent("agents"), executor, globalStopLossManager)
+	tradingGraph.SetDecisionStream(globalDecisionStream)
+	tradingGraph.TrackLiquidations(globalLiquidations)
+
+	// The batch ID comes from the runner so the dashboard can show it right away
+	// 批次 ID 由运行器生成，以便控制台立即显示
+	tradingGraph.RecordLateDecisions(db, batchID)
+	tradingGraph.TrackLLMUsage(db, batchID)
+
+	// Overtrading adds "be selective" guidance to the trader prompt
+	// 过度交易时在交易员 Prompt 中加入谨慎开仓提示
+	if freq, err := risk.CheckTradeFrequency(db, cfg, time.Now()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计交易频率失败: %v", err))
+	} else {
+		tradingGraph.SetTradeFrequency(freq)
+	}
+
+	// Intraday loss-streak ladder: entries are halved or halted after consecutive losing trades (LOSS_STREAK_*)
+	// 日内连亏阶梯：连续亏损后开仓减半或停止开仓（LOSS_STREAK_*）
+	var lossStreak *risk.LossStreak
+	if streak, err := risk.CheckLossStreak(db, cfg, time.Now()); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计连续亏损失败: %v", err))
+	} else {
+		lossStreak = streak
+		tradingGraph.SetLossStreak(streak)
+	}
+
+	// Circuit breaker: repeated Binance order or LLM failures pause auto-execution (CIRCUIT_BREAKER_*)
+	// 熔断：币安下单或 LLM 连续失败后暂停自动执行（CIRCUIT_BREAKER_*）
+	breaker, err := risk.LoadBreaker(db, cfg)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  读取熔断状态失败: %v", err))
+	}
+
+	// Trading performance for {{.Performance}} in prompt templates
+	// 历史交易表现，供 Prompt 模板中的 {{.Performance}} 使用
+	if stats, err := db.GetTradeStats(""); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  统计交易表现失败: %v", err))
+	} else {
+		tradingGraph.SetTradeStats(stats)
+	}
+
+	// Recent closed trades are reviewed in the trader prompt (DECISION_MEMORY_TRADES)
+	// 在交易员 Prompt 中复盘近期已平仓交易（DECISION_MEMORY_TRADES）
+	tradingGraph.RecallTrades(db)
+
+	// Candles are cached in the database; only the missing tail is fetched (KLINE_CACHE_ENABLED)
+	// K 线缓存在数据库中，只获取缺失的最新部分（KLINE_CACHE_ENABLED）
+	tradingGraph.CacheKlines(db)
+
+	// Mark the batch as in progress; if it is cancelled, or the process dies before it completes,
+	// its sessions without an execution result are marked as interrupted
+	// 标记批次进行中；批次被取消或进程在完成前退出时，其尚无执行结果的会话会被标记为已中断
+	if err := db.BeginBatch(batchID); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  记录批次状态失败: %v", err))
+	}
+	defer func() {
+		if ctx.Err() != nil {
+			if marked, err := db.MarkBatchInterrupted(batchID); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  标记中断会话失败: %v", err))
+			} else if marked > 0 {
+				log.Warning(fmt.Sprintf("⚠️  批次 %s 已取消，%d 个会话标记为已中断", batchID, marked))
+			}
+		}
+		if err := db.EndBatch(batchID); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  清除批次状态失败: %v", err))
+		}
+	}()
 
 	// Run the graph workflow
 	// 运行工作流
+	cycleStart := time.Now() // 行情快照时间，用于延迟分析 / Market snapshot time for latency analytics
 	result, err := tradingGraph.Run(ctx)
 	if err != nil {
 		return fmt.Errorf("工作流执行失败: %w", err)
 	}
+	decisionAt := time.Now()
 
 	// Display final results
 	// 显示最终结果
@@ -437,6 +979,11 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	// Get agent state
 	// 获取智能体状态
 	state := tradingGraph.GetState()
+	if path := state.GetDecisionPath(); agents.LLMFailed(path) {
+		recordBreakerFailure(ctx, breaker, risk.BreakerLLM, path, notifier, log)
+	} else if err := breaker.RecordSuccess(risk.BreakerLLM); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+	}
 	log.Subheader("分析师报告摘要", '─', 80)
 	for _, symbol := range cfg.CryptoSymbols {
 		reports := state.GetSymbolReports(symbol)
@@ -453,21 +1000,26 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	// 为每个交易对保存分析结果到数据库，包含该交易对的专属决策
 	log.Subheader("保存分析结果", '─', 80)
 
-	// Generate batch ID for this execution (all symbols in this run share the same batch_id)
-	// 为本次执行生成批次 ID（本次运行的所有交易对共享相同的 batch_id）
-	batchID := fmt.Sprintf("batch-%d", time.Now().Unix())
 	log.Info(fmt.Sprintf("批次 ID: %s", batchID))
 
 	// Parse multi-currency decision to extract symbol-specific decisions
 	// 解析多币种决策以提取每个交易对的专属决策
 	symbolDecisions := agents.ParseMultiCurrencyDecision(decision, cfg.CryptoSymbols)
+	decisionSource := agents.DecisionSource(decision, cfg.CryptoSymbols)
 
+	sessionIDs := make(map[string]int64) // 用于将成交关联到会话 / Used to attribute fills to sessions
 	for _, symbol := range cfg.CryptoSymbols {
 		reports := state.GetSymbolReports(symbol)
 		if reports == nil {
 			continue
 		}
 
+		// Check the report facts cited by the decision against the reports it was made from
+		// 将决策引用的报告数据与决策所依据的报告核对
+		if flagged := agents.VerifyCitations(symbolDecisions[symbol], reports); len(flagged) > 0 {
+			log.Warning(fmt.Sprintf("⚠️  %s 决策引用了报告中不存在的数据: %s", symbol, strings.Join(flagged, "；")))
+		}
+
 		// Get symbol-specific decision text
 		// 获取该交易对的专属决策文本
 		symbolDecision := decision // Default to full decision
@@ -499,6 +1051,9 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			FullDecision:    decision,       // ✅ Full LLM decision (all symbols)
 			Executed:        false,
 			ExecutionResult: "",
+			SizingPolicy:    executors.SizingPolicyLabel(cfg),
+			DecisionPath:    state.GetDecisionPath(),
+			Structured:      agents.ToStructuredDecision(symbolDecisions[symbol], decisionSource), // ✅ Parsed decision (dual-write)
 		}
 
 		sessionID, err := db.SaveSession(session)
@@ -506,15 +1061,74 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			log.Warning(fmt.Sprintf("保存 %s 会话失败: %v", symbol, err))
 		} else {
 			log.Success(fmt.Sprintf("【%s】会话已保存到数据库 (ID: %d)", symbol, sessionID))
+			sessionIDs[symbol] = sessionID
+			event := map[string]any{"id": sessionID, "batch_id": batchID, "symbol": symbol}
+			if parsed, ok := symbolDecisions[symbol]; ok && parsed.Valid {
+				event["action"] = parsed.Action
+			}
+			globalEvents.Publish(web.EventSession, event)
+
+			// Committee votes behind the decision (ensemble mode only)
+			// 决策对应的委员会投票（仅委员会模式）
+			if votes := state.GetEnsembleVotes(symbol); len(votes) > 0 {
+				if err := db.SaveEnsembleVotes(sessionID, votes); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  保存 %s 委员会投票失败: %v", symbol, err))
+				}
+			}
 		}
 	}
 	log.Info(fmt.Sprintf("数据库路径: %s", cfg.DatabasePath))
 
+	// Verify parsed decisions against the JSON content of the raw output
+	// 校验解析后的决策与原始输出中的 JSON 内容是否一致
+	if checked, diverged, err := agents.NewDecisionVerifier(db, log).Run(50); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  决策校验失败: %v", err))
+	} else if checked > 0 {
+		log.Info(fmt.Sprintf("决策校验: 比对 %d 条，不一致 %d 条", checked, diverged))
+	}
+
+	// Strategy freeze window: after a prompt / risk change the first cycles only record shadow decisions
+	// 策略冻结观察期：Prompt / 风控配置变更后的前几个周期只记录影子决策
+	var freeze *risk.FreezeStatus
+	if cfg.AutoExecute {
+		status, err := risk.CheckFreeze(db, cfg)
+		if err != nil {
+			// Fail closed: an unchecked configuration may be a new one
+			// 检查失败时按冻结处理：未检查的配置可能是新配置
+			log.Warning(fmt.Sprintf("⚠️  策略版本检查失败，本周期仅以影子模式运行: %v", err))
+			freeze = risk.FailedFreezeCheck(err)
+		} else {
+			freeze = status
+			if freeze.Changed {
+				log.Warning(fmt.Sprintf("🧊 检测到策略配置变更 (%s)，观察期 %d 个周期", freeze.Version.Fingerprint, freeze.Version.FreezeCycles))
+			}
+		}
+	}
+
+	// Shadow mode: score the intended orders whose evaluation horizon has passed
+	// 影子模式：评估已到评估期的意向订单
+	if cfg.ShadowMode && !cfg.IsAnalysisOnly() {
+		evaluateShadowOrders(ctx, executor, db, log)
+	}
+
+	// Link the positions closed since the last cycle back to their opening decision
+	// 将上一周期以来平仓的持仓关联到开仓决策
+	if !cfg.IsAnalysisOnly() {
+		scoreDecisions(db, log)
+	}
+
 	// Auto-execution logic
 	// 自动执行交易逻辑
-	if cfg.AutoExecute {
+	if freeze.Active() {
+		log.Subheader("影子模式", '─', 80)
+		shadowFreezeCycle(ctx, db, cfg, freeze, symbolDecisions, notifier, log)
+	} else if cfg.AutoExecute {
 		log.Subheader("自动执行交易", '─', 80)
 		log.Info("🚀 自动执行模式已启用")
+		if breaker.Open(time.Now()) {
+			log.Error(fmt.Sprintf("⛔ 熔断中，暂停开平仓到 %s: %s（止损单继续有效）",
+				breaker.State.OpenUntil.Local().Format("01-02 15:04"), breaker.State.Reason))
+		}
 
 		// Parse multi-currency decision
 		// 解析多币种决策
@@ -522,7 +1136,7 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 		// Initialize portfolio manager
 		// 初始化投资组合管理器
-		portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log)
+		portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log.WithComponent("portfolio"))
 		if err := portfolioMgr.UpdateBalance(ctx); err != nil {
 			log.Error(fmt.Sprintf("获取账户余额失败: %v", err))
 		}
@@ -539,15 +1153,90 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 		// Initialize trade coordinator with stop-loss manager
 		// 初始化交易协调器（传入止损管理器）
-		coordinator := executors.NewTradeCoordinator(cfg, executor, log, globalStopLossManager)
+		coordinator := executors.NewTradeCoordinator(cfg, executor, log.WithComponent("coordinator"), globalStopLossManager)
+
+		// Kill switch: a newly found sentinel file runs the emergency stop; while engaged only closes execute
+		// 急停开关：新发现急停文件时执行紧急停止；启用期间只执行平仓
+		killSwitch := executors.NewKillSwitch(db, cfg, log.WithComponent("killswitch"))
+		killState, killEngagedNow, err := killSwitch.Check()
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败，本周期不开仓: %v", err))
+			killState = &executors.KillSwitchState{Engaged: true, Reason: err.Error()}
+		} else if killEngagedNow {
+			coordinator.RespondToKillSwitch(ctx, killState, notifier)
+		}
+		if killState.Engaged {
+			log.Error(fmt.Sprintf("🛑 急停开关已启用: %s，本周期只执行平仓", killState.Reason))
+		}
+
+		// Global risk manager; the daily loss is replayed from today's balance history
+		// 全局风控管理器，单日亏损根据当日余额历史回放计算
+		riskManager := risk.NewManager(risk.LimitsFromConfig(cfg))
+		if history, err := db.GetBalanceHistory(24); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  获取余额历史失败，单日亏损从当前权益开始统计: %v", err))
+		} else {
+			withdrawals, err := db.GetWithdrawalsSince(time.Now().Add(-24 * time.Hour))
+			if err != nil {
+				log.Warning(fmt.Sprintf("⚠️  获取利润提取记录失败，单日亏损可能包含已提取的利润: %v", err))
+			}
+			riskManager.LoadHistory(history, withdrawals)
+		}
+		riskManager.ObserveEquity(portfolioMgr.GetTotalBalance()+portfolioMgr.GetTotalUnrealizedPnL(), time.Now())
+		if riskManager.Halted() {
+			log.Warning(fmt.Sprintf("🛑 当日亏损 %.2f%% 已达上限 %.2f%%，今日停止开仓", riskManager.DailyLossPercent(), cfg.RiskDailyMaxLoss))
+			if err := notifier.Notify(ctx, notify.Message{
+				Title: "风控熔断",
+				Text:  fmt.Sprintf("当日亏损 %.2f%% 已达上限 %.2f%%，今日停止开仓", riskManager.DailyLossPercent(), cfg.RiskDailyMaxLoss),
+				Level: notify.LevelWarning,
+			}); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+			}
+		}
+
+		// Pending stop entries: filled ones become managed positions, expired ones are cancelled
+		// 条件入场单：已成交的转为受管持仓，已过期的撤销
+		if filled, err := coordinator.SyncPendingEntries(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  检查条件入场单失败: %v", err))
+		} else {
+			notifyEntryFills(ctx, notifier, filled, log)
+		}
 
 		// Execute trades for each symbol
 		// 为每个交易对执行交易
 		executionResults := make(map[string]string)
+		claimedKlines := make(map[string]time.Time) // 本批次在执行台账中预占的 K 线 / Klines this batch claimed in the execution ledger
+
+		// releaseClaim frees the ledger claim of a failed entry, so the kline can be retried
+		// releaseClaim 释放执行失败的开仓在台账中的预占，使该 K 线可以重试
+		releaseClaim := func(symbol string) {
+			klineOpen, ok := claimedKlines[symbol]
+			if !ok {
+				return
+			}
+			delete(claimedKlines, symbol)
+			if err := db.ReleaseExecution(symbol, cfg.CryptoTimeframe, klineOpen); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  释放 %s 执行台账预占失败: %v", symbol, err))
+			}
+		}
+
+		// Portfolio allocator: entries compete for the free margin instead of executing independently
+		// 组合分配器：开仓决策共同竞争可用保证金，而不是各自独立执行
+		var allocationRejects map[string]string
+		if cfg.AllocatorEnabled {
+			allocationRejects = allocateEntries(ctx, cfg, decisions, portfolioMgr, state, log)
+		}
 
 		for symbol, symbolDecision := range decisions {
+			// Tag this symbol's log entries with its symbol and session (JSON log mode)
+			// 为该交易对的日志附加交易对和会话字段（JSON 日志模式）
+			log := log.WithSymbol(symbol).WithSessionID(sessionIDs[symbol])
+
 			log.Subheader(fmt.Sprintf("处理 %s 交易决策", symbol), '-', 60)
 
+			// Fills of this symbol's orders are attributed to its session
+			// 该交易对订单的成交记录归属到其会话
+			tradeCtx := executors.WithBatchID(executors.WithSessionID(ctx, sessionIDs[symbol]), batchID)
+
 			if !symbolDecision.Valid {
 				log.Warning(fmt.Sprintf("⚠️  %s 决策无效: %s", symbol, symbolDecision.Reason))
 				executionResults[symbol] = fmt.Sprintf("决策无效: %s", symbolDecision.Reason)
@@ -594,6 +1283,19 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				} else {
 					executionResults[symbol] = "观望，不执行交易"
 				}
+
+				// Partial take-profit requested by LLM (now, or at partial_tp_price)
+				// LLM 要求分批止盈（立即或到达 partial_tp_price 时）
+				if symbolDecision.PartialTPPercent > 0 {
+					tpResult, err := coordinator.ExecutePartialTakeProfit(tradeCtx, symbol,
+						symbolDecision.PartialTPPrice, symbolDecision.PartialTPPercent, symbolDecision.Reason)
+					if err != nil {
+						log.Warning(fmt.Sprintf("⚠️  %s 分批止盈失败: %v", symbol, err))
+						executionResults[symbol] += fmt.Sprintf("；分批止盈失败: %v", err)
+					} else {
+						executionResults[symbol] += "；" + tpResult.Message
+					}
+				}
 				continue
 			}
 
@@ -605,32 +1307,234 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 			// Get current position
 			// 获取当前持仓
-			currentPosition, err := executor.GetCurrentPosition(ctx, symbol)
+			positions, err := executor.GetCurrentPositions(ctx, symbol)
 			if err != nil {
 				log.Warning(fmt.Sprintf("⚠️  获取 %s 当前持仓失败: %v", symbol, err))
 			}
 
-			// Validate decision against current position
-			// 验证决策与当前持仓的一致性
-			if err := agents.ValidateDecision(symbolDecision, currentPosition); err != nil {
+			// Execution filters for entries (MIN_DECISION_CONFIDENCE, STOP_OUT_COOLDOWN_MINUTES, MAX_TRADES_PER_DAY);
+			// run before validation so a rejection is saved with the filter that caused it
+			// 开仓执行过滤器（MIN_DECISION_CONFIDENCE、STOP_OUT_COOLDOWN_MINUTES、MAX_TRADES_PER_DAY），
+			// 在验证之前执行，以便被拒绝的决策连同过滤器一起保存
+			if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action) {
+				rejection, err := risk.CheckExecutionFilters(db, cfg, symbol, symbolDecision.Confidence, time.Now())
+				if err != nil {
+					log.Warning(fmt.Sprintf("⚠️  %s 执行过滤检查失败: %v", symbol, err))
+				} else if rejection != nil {
+					log.Warning(fmt.Sprintf("🚫 %s 执行过滤(%s): %s", symbol, rejection.Filter, rejection.Reason))
+					executionResults[symbol] = fmt.Sprintf("🚫 执行过滤(%s): %s", rejection.Filter, rejection.Reason)
+					if sessionID, ok := sessionIDs[symbol]; ok {
+						if err := db.SaveFilterRejection(sessionID, rejection.Filter, rejection.Reason); err != nil {
+							log.Warning(fmt.Sprintf("⚠️  保存 %s 过滤记录失败: %v", symbol, err))
+						}
+					}
+					continue
+				}
+			}
+
+			// Validate decision against current positions (both sides in hedge mode)
+			// 验证决策与当前持仓的一致性（双向持仓模式下包括多空两个方向）
+			if err := agents.ValidateDecision(symbolDecision, positions, agents.ThresholdsFromConfig(cfg)); err != nil {
 				log.Error(fmt.Sprintf("❌ %s 决策验证失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("决策验证失败: %v", err)
 				continue
 			}
 
+			// Latest ATR, used for risk-per-trade sizing and the dynamic trailing stop
+			// 最新 ATR，用于按单笔风险计算仓位和动态追踪止损
+			atrValue := latestATR(state, symbol)
+
+			// Global risk check for opening orders (closing is never refused)
+			// 开仓前进行全局风控检查（平仓不受限制）
+			if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action) {
+				if lossStreak.Halted() {
+					log.Error(fmt.Sprintf("🛑 %s 连亏停止开仓: 本时段已连续亏损 %d 笔", symbol, lossStreak.Longest))
+					executionResults[symbol] = fmt.Sprintf("🛑 连亏停止开仓: 本时段已连续亏损 %d 笔，%s 重置",
+						lossStreak.Longest, lossStreak.SessionEnd().Local().Format("01-02 15:04"))
+					continue
+				}
+				if reason, ok := allocationRejects[symbol]; ok {
+					log.Error(fmt.Sprintf("🛑 %s %s", symbol, reason))
+					executionResults[symbol] = reason
+					continue
+				}
+				if lossStreak.Reduced() && symbolDecision.PositionSizePercent > 0 {
+					halved := symbolDecision.PositionSizePercent * lossStreak.SizeMultiplier()
+					log.Warning(fmt.Sprintf("📉 %s 本时段连续亏损 %d 笔，仓位减半: %.1f%% → %.1f%%",
+						symbol, lossStreak.Current, symbolDecision.PositionSizePercent, halved))
+					symbolDecision.PositionSizePercent = halved
+				}
+
+				openAction := symbolDecision.Action
+				if executors.IsStopEntry(openAction) {
+					openAction = executors.EntryAction(openAction)
+				} else if executors.IsAddOn(openAction) {
+					openAction = executors.AddOnAction(openAction)
+				}
+				order, err := portfolioMgr.ProposedOrder(ctx, symbol, openAction,
+					symbolDecision.PositionSizePercent, symbolDecision.Leverage, symbolDecision.StopLoss, atrValue)
+				if err == nil {
+					err = riskManager.CheckOrder(portfolioMgr.RiskSnapshot(globalStopLossManager), order, executor.IsHedgeMode(ctx))
+				}
+				if err != nil {
+					log.Error(fmt.Sprintf("🛑 %s 风控拒绝: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("🛑 风控拒绝: %v", err)
+					continue
+				}
+			}
+
+			// Deep-think second-pass review (RISK_REVIEW_ENABLED); the veto reason goes to the session
+			// 深度复核（RISK_REVIEW_ENABLED）；否决理由写入会话执行结果
+			if review := state.GetReview(symbol); review != nil && !review.Approved {
+				log.Warning(fmt.Sprintf("🛑 %s 风控复核否决: %s", symbol, review.Reason))
+				executionResults[symbol] = fmt.Sprintf("🛑 风控复核否决: %s", review.Reason)
+				continue
+			}
+
+			// Pipeline timestamps for latency/slippage analytics, priced at the last close the LLM analyzed
+			// 用于延迟/滑点分析的各阶段时间戳，以 LLM 分析的最后收盘价为基准
+			timing := &executors.ExecutionTiming{SignalAt: cycleStart, DecisionAt: decisionAt}
+			var klineOpen time.Time
+			if reports := state.GetSymbolReports(symbol); reports != nil && len(reports.OHLCVData) > 0 {
+				timing.SignalPrice = reports.OHLCVData[len(reports.OHLCVData)-1].Close
+				klineOpen = reports.OHLCVData[len(reports.OHLCVData)-1].Timestamp
+			}
+
+			// Kill switch: entries are skipped, closes still execute
+			// 急停开关：跳过开仓，平仓照常执行
+			if killState.Engaged && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell ||
+				executors.IsStopEntry(symbolDecision.Action) || executors.IsAddOn(symbolDecision.Action)) {
+				log.Warning(fmt.Sprintf("🛑 %s 急停开关已启用，跳过 %s", symbol, symbolDecision.Action))
+				executionResults[symbol] = fmt.Sprintf("🛑 急停开关已启用：未执行 %s", symbolDecision.Action)
+				continue
+			}
+
+			// The breaker may also trip mid-loop, pausing the remaining symbols
+			// 熔断也可能在循环中途触发，暂停剩余交易对
+			if breaker.Open(time.Now()) {
+				log.Warning(fmt.Sprintf("⛔ %s 熔断暂停自动执行，跳过 %s", symbol, symbolDecision.Action))
+				executionResults[symbol] = fmt.Sprintf("⛔ 熔断暂停自动执行到 %s：未执行 %s",
+					breaker.State.OpenUntil.Local().Format("01-02 15:04"), symbolDecision.Action)
+				continue
+			}
+
+			// Claim the analyzed kline in the execution ledger before an entry, so a restart mid-loop can't
+			// open it again. Closes, reductions and add-ons are never blocked.
+			// 开仓前在执行台账中预占所分析的 K 线，避免循环中途重启后再次开仓。平仓、减仓和加仓不受限制。
+			switch {
+			case !executors.IsOpening(symbolDecision.Action): // 非开仓动作不预占 / Only entries are claimed
+			case klineOpen.IsZero():
+				log.Warning(fmt.Sprintf("⚠️  %s 没有 K 线数据，无法检查重复执行", symbol))
+			default:
+				existing, err := db.ClaimExecution(&storage.LedgerEntry{
+					Symbol:        symbol,
+					Timeframe:     cfg.CryptoTimeframe,
+					KlineOpenTime: klineOpen,
+					BatchID:       batchID,
+					Action:        string(symbolDecision.Action),
+				})
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 写入执行台账失败，跳过执行: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("执行台账写入失败，跳过执行: %v", err)
+					continue
+				}
+				if existing != nil {
+					log.Warning(fmt.Sprintf("⏭️  %s %s K 线（%s）已由批次 %s 执行 %s，跳过重复执行",
+						symbol, cfg.CryptoTimeframe, klineOpen.Format("01-02 15:04"), existing.BatchID, existing.Action))
+					executionResults[symbol] = fmt.Sprintf("⏭️ 跳过重复执行: 该 K 线已由批次 %s 执行 %s", existing.BatchID, existing.Action)
+					continue
+				}
+				claimedKlines[symbol] = klineOpen
+			}
+
+			// Stop entries rest on the exchange until the price breaks the trigger
+			// 条件入场单挂在交易所，等待价格突破触发价
+			if executors.IsStopEntry(symbolDecision.Action) {
+				leverageToUse := agents.ValidateLeverage(symbolDecision.Leverage, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax, cfg.BinanceLeverageDynamic)
+				entry, err := coordinator.PlaceStopEntry(tradeCtx, symbol, symbolDecision.Action, symbolDecision.EntryPrice,
+					symbolDecision.Reason, leverageToUse, symbolDecision.PositionSizePercent, symbolDecision.StopLoss, atrValue)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 条件入场单失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("条件入场单失败: %v", err)
+					releaseClaim(symbol)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+				} else {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					if entry.Status == storage.EntryStatusShadow {
+						executionResults[symbol] = fmt.Sprintf("👻 影子模式：已记录条件入场单 %s %.4f @ %.2f，未挂单",
+							symbolDecision.Action, entry.Quantity, entry.TriggerPrice)
+					} else {
+						executionResults[symbol] = fmt.Sprintf("⏳ 已挂条件入场单 %s @ %.2f（%s 过期）",
+							symbolDecision.Action, entry.TriggerPrice, entry.ExpiresAt.Format("01-02 15:04"))
+					}
+				}
+				continue
+			}
+
+			// Reductions trim the managed position and keep the rest protected
+			// 减仓平掉部分受管持仓，剩余部分继续受止损保护
+			if executors.IsReduce(symbolDecision.Action) {
+				result, err := coordinator.ExecuteReduce(tradeCtx, symbol, symbolDecision.Action, symbolDecision.ReducePercent, symbolDecision.Reason)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 减仓失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("减仓失败: %v", err)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+					continue
+				}
+				if result.Success {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					executionResults[symbol] = "✅ " + result.Message
+				} else {
+					executionResults[symbol] = result.Message
+				}
+				continue
+			}
+
+			// Add-ons scale into the managed position and re-place its stop over the blended entry
+			// 加仓为受管持仓追加数量，并按混合入场价重挂止损
+			if executors.IsAddOn(symbolDecision.Action) {
+				result, err := coordinator.ExecuteAddOn(tradeCtx, symbol, symbolDecision.Action, symbolDecision.Reason,
+					symbolDecision.PositionSizePercent, symbolDecision.StopLoss, atrValue)
+				if err != nil {
+					log.Error(fmt.Sprintf("❌ %s 加仓失败: %v", symbol, err))
+					executionResults[symbol] = fmt.Sprintf("加仓失败: %v", err)
+					recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
+					continue
+				}
+				if result.Success {
+					if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+						log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+					}
+					executionResults[symbol] = "✅ " + result.Message
+				} else {
+					executionResults[symbol] = result.Message
+				}
+				continue
+			}
+
 			// Execute the trade using coordinator
 			// 使用协调器执行交易
 			result, err := coordinator.ExecuteDecisionWithParams(
-				ctx,
+				executors.WithExecutionTiming(tradeCtx, timing),
 				symbol,
 				symbolDecision.Action,
 				symbolDecision.Reason,
 				symbolDecision.Leverage,
 				symbolDecision.PositionSizePercent,
+				symbolDecision.StopLoss,
+				atrValue,
 			)
 			if err != nil {
 				log.Error(fmt.Sprintf("❌ %s 交易执行失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("执行失败: %v", err)
+				releaseClaim(symbol)
+				recordBreakerFailure(ctx, breaker, risk.BreakerExchange, err.Error(), notifier, log)
 				continue
 			}
 
@@ -644,22 +1548,26 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				tradingGraph.IncrementTradeCount()
 
 				executionResults[symbol] = fmt.Sprintf("✅ 成功执行 %s", result.Action)
+				if err := breaker.RecordSuccess(risk.BreakerExchange); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+				}
 
 				// Handle closing positions: cancel stop-loss and update database
 				// 处理平仓：取消止损单并更新数据库
 				if symbolDecision.Action == executors.ActionCloseLong || symbolDecision.Action == executors.ActionCloseShort {
 					// Get close price and calculate realized PnL
 					// 获取平仓价格并计算已实现盈亏
+					closeSide := executors.ActionSide(symbolDecision.Action)
 					closePrice := result.Price
 					realizedPnL := 0.0
-					if currentPosition != nil {
+					if currentPosition := executors.PositionOnSide(positions, closeSide); currentPosition != nil {
 						realizedPnL = currentPosition.UnrealizedPnL
 					}
 
 					// Close position completely (cancel stop-loss, remove from memory, update database)
 					// 完整关闭持仓（取消止损单、从内存移除、更新数据库）
 					closeReason := fmt.Sprintf("LLM决策平仓: %s", symbolDecision.Reason)
-					if err := globalStopLossManager.ClosePosition(ctx, symbol, closePrice, closeReason, realizedPnL); err != nil {
+					if err := globalStopLossManager.ClosePositionSide(ctx, symbol, closeSide, closePrice, closeReason, realizedPnL); err != nil {
 						log.Warning(fmt.Sprintf("⚠️  关闭 %s 持仓失败: %v", symbol, err))
 					}
 				}
@@ -685,6 +1593,9 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 					// Calculate initial stop-loss if not provided by LLM
 					// 如果 LLM 未提供止损价格，则计算初始止损
 					initialStopLoss := symbolDecision.StopLoss
+					if result.StopLoss > 0 {
+						initialStopLoss = result.StopLoss // 插件或外部风控调整后的止损 / Adjusted by a plugin or the risk oracle
+					}
 					if initialStopLoss == 0 {
 						// Use 2.5% default stop-loss
 						// 使用 2.5% 默认止损
@@ -696,22 +1607,10 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 2.5%% 止损: %.2f", initialStopLoss))
 					}
 
-					// Get ATR value from indicators for dynamic trailing stop
-					// 从指标中获取 ATR 值用于动态追踪止损
-					var atrValue float64
-					reports := state.GetSymbolReports(symbol)
-					if reports != nil && reports.TechnicalIndicators != nil {
-						indicators := reports.TechnicalIndicators
-						if len(indicators.ATR) > 0 {
-							// Get latest ATR value
-							// 获取最新 ATR 值
-							lastIdx := len(indicators.ATR) - 1
-							if lastIdx >= 0 && !math.IsNaN(indicators.ATR[lastIdx]) {
-								atrValue = indicators.ATR[lastIdx]
-								atrPercent := (atrValue / result.Price) * 100
-								log.Info(fmt.Sprintf("当前 ATR: %.2f (%.2f%% of price)", atrValue, atrPercent))
-							}
-						}
+					// ATR for the dynamic trailing stop
+					// 动态追踪止损使用的 ATR
+					if atrValue > 0 {
+						log.Info(fmt.Sprintf("当前 ATR: %.2f (%.2f%% of price)", atrValue, atrValue/result.Price*100))
 					}
 
 					// Create position
@@ -744,25 +1643,7 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 					// Save position to database
 					// 保存持仓到数据库
-					posRecord := &storage.PositionRecord{
-						ID:               position.ID,
-						Symbol:           position.Symbol,
-						Side:             position.Side,
-						EntryPrice:       position.EntryPrice,
-						EntryTime:        position.EntryTime,
-						Quantity:         position.Quantity,
-						Leverage:         position.Leverage,
-						InitialStopLoss:  position.InitialStopLoss,
-						CurrentStopLoss:  position.CurrentStopLoss,
-						StopLossType:     position.StopLossType,
-						TrailingDistance: position.TrailingDistance,
-						HighestPrice:     position.EntryPrice,
-						CurrentPrice:     position.EntryPrice,
-						OpenReason:       position.OpenReason,
-						ATR:              position.ATR,
-						StopLossOrderID:  position.StopLossOrderID, // ✅ 保存止损单 ID
-						Closed:           false,
-					}
+					posRecord := position.Record()
 					if err := db.SavePosition(posRecord); err != nil {
 						log.Warning(fmt.Sprintf("⚠️  保存持仓到数据库失败: %v", err))
 					}
@@ -774,9 +1655,21 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 					} else {
 						log.Success(fmt.Sprintf("✅ 初始止损单已下达: %.2f", initialStopLoss))
 					}
+
+					// Partial take-profit plan from LLM
+					// LLM 给出的分批止盈计划
+					if symbolDecision.PartialTPPrice > 0 && symbolDecision.PartialTPPercent > 0 {
+						if err := globalStopLossManager.SetPartialTakeProfit(symbol, symbolDecision.PartialTPPrice, symbolDecision.PartialTPPercent); err != nil {
+							log.Warning(fmt.Sprintf("⚠️  设置分批止盈失败: %v", err))
+						}
+					}
 				}
+			} else if result.Skipped {
+				executionResults[symbol] = result.Message
 			} else {
 				executionResults[symbol] = fmt.Sprintf("❌ 执行失败: %s", result.Message)
+				releaseClaim(symbol)
+				recordBreakerFailure(ctx, breaker, risk.BreakerExchange, result.Message, notifier, log)
 			}
 		}
 
@@ -799,16 +1692,11 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 		// Save balance history to database
 		// 保存余额历史到数据库
-		balanceHistory := &storage.BalanceHistory{
-			Timestamp:        time.Now(),
-			TotalBalance:     portfolioMgr.GetTotalBalance(),
-			AvailableBalance: portfolioMgr.GetAvailableBalance(),
-			UnrealizedPnL:    portfolioMgr.GetTotalUnrealizedPnL(),
-			Positions:        portfolioMgr.GetPositionCount(),
-		}
+		balanceHistory := portfolioMgr.BalanceSnapshot()
 		if err := db.SaveBalanceHistory(balanceHistory); err != nil {
 			log.Warning(fmt.Sprintf("⚠️  保存余额历史失败: %v", err))
 		}
+		publishPortfolio(portfolioMgr, cfg.CryptoSymbols)
 
 		// Display execution summary
 		// 显示执行摘要
@@ -833,6 +1721,30 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
 			}
 		}
+		for symbol, klineOpen := range claimedKlines {
+			if err := db.CompleteExecution(symbol, cfg.CryptoTimeframe, klineOpen, executionResults[symbol]); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行台账失败: %v", symbol, err))
+			}
+		}
+
+		// Notify execution results (skip runs where every symbol held)
+		// 推送执行结果（所有交易对均观望时不推送）
+		notable := false
+		for _, result := range executionResults {
+			if !strings.HasPrefix(result, "观望") {
+				notable = true
+				break
+			}
+		}
+		if notable {
+			if err := notifier.Notify(ctx, notify.Message{
+				Title: "交易执行结果",
+				Text:  executionResultStr,
+				Level: notify.LevelInfo,
+			}); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+			}
+		}
 
 		log.Success("✅ 自动执行流程完成")
 	} else {
@@ -842,3 +1754,233 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	log.Success("✅ 本次执行完成")
 	return nil
 }
+
+// shadowFreezeCycle records the decisions of a frozen cycle without executing them
+// shadowFreezeCycle 记录冻结周期的决策但不执行
+func shadowFreezeCycle(ctx context.Context, db *storage.Storage, cfg *config.Config, freeze *risk.FreezeStatus,
+	decisions map[string]*agents.TradingDecision, notifier notify.Notifier, log *logger.ColorLogger) {
+	label := freeze.Label()
+	log.Warning(fmt.Sprintf("🧊 策略%s：仅记录影子决策，不执行交易", label))
+
+	for _, symbol := range cfg.CryptoSymbols {
+		action := "无有效决策"
+		if d, ok := decisions[symbol]; ok && d.Valid {
+			action = string(d.Action)
+		}
+		result := fmt.Sprintf("🔍 影子模式（%s）：未执行 %s", label, action)
+		if err := db.UpdateLatestSessionExecution(symbol, cfg.CryptoTimeframe, false, result); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
+		}
+	}
+
+	summary, err := risk.CompleteShadowCycle(db, freeze)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新策略观察期失败: %v", err))
+		return
+	}
+	if summary == "" {
+		return
+	}
+	log.Success(summary)
+	if err := notifier.Notify(ctx, notify.Message{
+		Title: "策略观察期结束",
+		Text:  summary,
+		Level: notify.LevelInfo,
+	}); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// evaluateShadowOrders scores the shadow orders whose horizon has passed and logs the running totals
+// evaluateShadowOrders 评估已到期的影子订单并输出累计统计
+func evaluateShadowOrders(ctx context.Context, executor *executors.BinanceExecutor, db *storage.Storage, log *logger.ColorLogger) {
+	evaluated, err := executor.EvaluateShadowOrders(ctx, db, time.Now())
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  评估影子订单失败: %v", err))
+	}
+	if evaluated == 0 {
+		return
+	}
+	stats, err := db.GetShadowStats()
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  汇总影子订单失败: %v", err))
+		return
+	}
+	log.Info(fmt.Sprintf("👻 已评估 %d 个影子订单；累计入场 %d 个，胜率 %.1f%%，平均收益 %+.2f%%，触发止损 %d 次",
+		evaluated, stats.Triggered, stats.WinRate*100, stats.AvgReturnPct, stats.StopHits))
+}
+
+// scoreDecisions scores the newly closed positions against their opening decision and logs the calibration
+// scoreDecisions 根据开仓决策为新平仓的持仓评分，并输出置信度校准统计
+func scoreDecisions(db *storage.Storage, log *logger.ColorLogger) {
+	scored, err := db.ScoreClosedPositions(100)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  评估决策质量失败: %v", err))
+	}
+	if scored == 0 {
+		return
+	}
+	calibration, err := db.GetDecisionCalibration(time.Time{}, 0)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  汇总决策质量失败: %v", err))
+		return
+	}
+	log.Info(fmt.Sprintf("🎯 已评估 %d 个平仓持仓的开仓决策；累计关联 %d 个，Brier 分数 %.3f（越低越好）",
+		scored, calibration.Scored, calibration.BrierScore))
+}
+
+// recordBreakerFailure counts a failure on the circuit breaker and alerts when it trips
+// recordBreakerFailure 在熔断器上记录一次失败，触发熔断时发出告警
+func recordBreakerFailure(ctx context.Context, breaker *risk.Breaker, kind, detail string, notifier notify.Notifier, log *logger.ColorLogger) {
+	tripped, err := breaker.RecordFailure(kind, detail, time.Now())
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新熔断状态失败: %v", err))
+	}
+	if !tripped {
+		return
+	}
+
+	text := fmt.Sprintf("%s\n自动执行暂停到 %s，已有止损单继续有效", breaker.State.Reason,
+		breaker.State.OpenUntil.Local().Format("01-02 15:04"))
+	log.Error("⛔ 熔断触发: " + strings.ReplaceAll(text, "\n", "，"))
+	if err := notifier.Notify(ctx, notify.Message{
+		Title: "熔断暂停自动执行",
+		Text:  text,
+		Level: notify.LevelError,
+	}); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// latestATR returns the symbol's latest ATR from this round's reports (0 if unknown)
+// latestATR 返回本轮报告中该交易对的最新 ATR（未知时为 0）
+func latestATR(state *agents.AgentState, symbol string) float64 {
+	if reports := state.GetSymbolReports(symbol); reports != nil && reports.TechnicalIndicators != nil {
+		if atr := reports.TechnicalIndicators.ATR; len(atr) > 0 && !math.IsNaN(atr[len(atr)-1]) {
+			return atr[len(atr)-1]
+		}
+	}
+	return 0
+}
+
+// allocateEntries ranks the round's opening decisions by confidence × risk/reward and trims or rejects
+// them so their combined margin fits ALLOCATOR_MAX_MARGIN_PERCENT of the available balance
+// allocateEntries 按置信度 × 盈亏比对本轮开仓决策排序，并缩减或拒绝部分决策，使保证金合计不超过可用余额的 ALLOCATOR_MAX_MARGIN_PERCENT
+//
+// Trimmed decisions get a smaller PositionSizePercent; the returned map holds the rejection message per symbol.
+// 被缩减的决策直接调低 PositionSizePercent；返回值为被拒绝交易对的说明。
+func allocateEntries(ctx context.Context, cfg *config.Config, decisions map[string]*agents.TradingDecision,
+	portfolioMgr *portfolio.PortfolioManager, state *agents.AgentState, log *logger.ColorLogger) map[string]string {
+	var candidates []risk.Candidate
+	for symbol, d := range decisions {
+		if !d.Valid || !(d.Action == executors.ActionBuy || d.Action == executors.ActionSell || executors.IsStopEntry(d.Action) ||
+			executors.IsAddOn(d.Action)) {
+			continue
+		}
+		openAction := d.Action
+		if executors.IsStopEntry(openAction) {
+			openAction = executors.EntryAction(openAction)
+		} else if executors.IsAddOn(openAction) {
+			openAction = executors.AddOnAction(openAction)
+		}
+		order, err := portfolioMgr.ProposedOrder(ctx, symbol, openAction, d.PositionSizePercent, d.Leverage, d.StopLoss, latestATR(state, symbol))
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  %s 估算保证金失败，不参与组合分配: %v", symbol, err))
+			continue
+		}
+		candidates = append(candidates, risk.Candidate{
+			Symbol:     symbol,
+			Confidence: d.Confidence,
+			RiskReward: d.RiskRewardRatio,
+			Margin:     order.Margin,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	budget := portfolioMgr.GetAvailableBalance() * cfg.AllocatorMaxMargin / 100
+	log.Info(fmt.Sprintf("📊 组合分配: %d 个开仓决策，可用保证金预算 %.2f USDT", len(candidates), budget))
+
+	rejected := make(map[string]string)
+	for _, a := range risk.Allocate(candidates, budget) {
+		d := decisions[a.Symbol]
+		switch {
+		case !a.Trimmed():
+			log.Info(fmt.Sprintf("  #%d %s 评分 %.2f，保证金 %.2f USDT 全额分配", a.Rank, a.Symbol, a.Score(), a.Margin))
+		case a.Granted <= 0:
+			log.Warning(fmt.Sprintf("  #%d %s 评分 %.2f，可用保证金已分配完，拒绝开仓", a.Rank, a.Symbol, a.Score()))
+			rejected[a.Symbol] = fmt.Sprintf("🛑 组合分配拒绝: 排名 #%d（评分 %.2f），可用保证金已分配给更优的决策", a.Rank, a.Score())
+		default:
+			trimmed := min(d.PositionSizePercent, portfolioMgr.PercentForMargin(a.Granted))
+			log.Warning(fmt.Sprintf("  #%d %s 评分 %.2f，保证金 %.2f → %.2f USDT，仓位缩减: %.1f%% → %.1f%%",
+				a.Rank, a.Symbol, a.Score(), a.Margin, a.Granted, d.PositionSizePercent, trimmed))
+			d.PositionSizePercent = trimmed
+		}
+	}
+	return rejected
+}
+
+// publishPortfolio pushes the current balance and open positions to the live dashboard
+// publishPortfolio 将当前余额和持仓推送到实时看板
+//
+// The balance uses the same fields as /api/balance/current so the dashboard can apply either.
+// 余额字段与 /api/balance/current 一致，看板可用同一方式处理两者。
+func publishPortfolio(pm *portfolio.PortfolioManager, symbols []string) {
+	globalEvents.Publish(web.EventBalance, map[string]any{
+		"timestamp":          time.Now().Format("2006-01-02 15:04:05"),
+		"total_balance":      pm.GetTotalBalance(),
+		"available_balance":  pm.GetAvailableBalance(),
+		"unrealized_pnl":     pm.GetTotalUnrealizedPnL(),
+		"positions":          pm.GetPositionCount(),
+		"margin_balance":     pm.GetAccountRisk().MarginBalance,
+		"maintenance_margin": pm.GetAccountRisk().MaintenanceMargin,
+		"margin_ratio":       pm.GetAccountRisk().MarginRatio,
+		"assets":             pm.GetAccountRisk().Assets,
+	})
+
+	positions := make([]map[string]any, 0, len(symbols))
+	for _, symbol := range symbols {
+		pos := pm.GetPosition(symbol)
+		if pos == nil {
+			continue
+		}
+		positions = append(positions, map[string]any{
+			"symbol":         pos.Symbol,
+			"side":           pos.Side,
+			"size":           pos.Size,
+			"entry_price":    pos.EntryPrice,
+			"current_price":  pos.CurrentPrice,
+			"unrealized_pnl": pos.UnrealizedPnL,
+			"leverage":       pos.Leverage,
+		})
+	}
+	globalEvents.Publish(web.EventPosition, positions)
+}
+
+// notifyEntryFills announces stop entries that filled and became managed positions
+// notifyEntryFills 通知已成交并转为受管持仓的条件入场单
+// pruneSessions applies the session retention policy (SESSION_RETENTION_DAYS / SESSION_RETENTION_COUNT)
+// pruneSessions 执行会话保留策略（SESSION_RETENTION_DAYS / SESSION_RETENTION_COUNT）
+func pruneSessions(db *storage.Storage, cfg *config.Config, log *logger.ColorLogger) {
+	pruned, err := db.PruneSessions(cfg.SessionRetentionCutoff(time.Now()), cfg.SessionRetentionCount)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  清理旧会话失败: %v", err))
+		return
+	}
+	if pruned > 0 {
+		log.Info(fmt.Sprintf("🧹 已清理 %d 条旧会话", pruned))
+	}
+}
+
+func notifyEntryFills(ctx context.Context, notifier notify.Notifier, filled []*storage.PendingEntry, log *logger.ColorLogger) {
+	for _, entry := range filled {
+		if err := notifier.Notify(ctx, notify.Message{
+			Title: "条件入场单成交",
+			Text:  fmt.Sprintf("%s %s %.4f @ %.2f（触发价 %.2f）", entry.Symbol, entry.Side, entry.Quantity, entry.FillPrice, entry.TriggerPrice),
+			Level: notify.LevelInfo,
+		}); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+		}
+	}
+}
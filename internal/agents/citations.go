@@ -0,0 +1,114 @@
+package agents
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Citation is a report fact a decision relied on, e.g. "ADX=32 rising"
+// Citation 是决策依据的报告数据，例如 "ADX=32 上升"
+type Citation = storage.DecisionCitation
+
+// citedNumberRegex matches numbers that are not part of a name such as EMA20, keeping the sign
+// citedNumberRegex 匹配不属于名称（如 EMA20）的数值，保留正负号
+var citedNumberRegex = regexp.MustCompile(`(?:^|[^\p{L}\d.])([-+]?\d(?:[\d,]*\d)?(?:\.\d+)?)`)
+
+// VerifyCitations checks the numbers in each citation of the decision against its symbol's reports and
+// returns the citations that contain a number found nowhere in them
+// VerifyCitations 将决策每条引用中的数值与该交易对的报告核对，返回包含报告中不存在数值的引用
+//
+// A cited number matches a report number when it equals it after rounding to the cited precision
+// (ADX=32 matches 32.4) or lies within 0.5% of it; signs are ignored so "down 2%" matches -2%. The
+// latest indicator values are included as well, so a value the report only shows rounded still matches.
+// 引用数值按其精度四舍五入后与报告数值相等（ADX=32 匹配 32.4），或相差不超过 0.5% 即视为匹配；
+// 忽略正负号，"下跌 2%" 可以匹配 -2%。最新指标值也参与匹配，报告中四舍五入显示的数值同样可以匹配。
+func VerifyCitations(decision *TradingDecision, reports *SymbolReports) []string {
+	if decision == nil || len(decision.Citations) == 0 {
+		return nil
+	}
+	known := reportNumbers(reports)
+
+	var flagged []string
+	for i := range decision.Citations {
+		citation := &decision.Citations[i]
+		citation.Unmatched = nil
+		for _, match := range citedNumberRegex.FindAllStringSubmatch(citation.Text, -1) {
+			if !numberInReports(match[1], known) {
+				citation.Unmatched = append(citation.Unmatched, match[1])
+			}
+		}
+		citation.Verified = len(citation.Unmatched) == 0
+		if !citation.Verified {
+			flagged = append(flagged, citation.Text)
+		}
+	}
+	return flagged
+}
+
+// reportNumbers collects the absolute values of all numbers in the reports and the latest indicators
+// reportNumbers 收集报告和最新指标中所有数值的绝对值
+func reportNumbers(reports *SymbolReports) []float64 {
+	if reports == nil {
+		return nil
+	}
+	var numbers []float64
+	for _, text := range []string{reports.MarketReport, reports.CryptoReport, reports.SentimentReport, reports.PositionInfo, reports.TradeMemory} {
+		for _, match := range citedNumberRegex.FindAllStringSubmatch(text, -1) {
+			if value, ok := parseCitedNumber(match[1]); ok {
+				numbers = append(numbers, math.Abs(value))
+			}
+		}
+	}
+
+	if ind := reports.TechnicalIndicators; ind != nil {
+		for _, series := range [][]float64{
+			ind.RSI, ind.RSI_7, ind.MACD, ind.Signal, ind.BB_Upper, ind.BB_Middle, ind.BB_Lower,
+			ind.SMA_20, ind.SMA_50, ind.SMA_200, ind.EMA_12, ind.EMA_20, ind.EMA_26, ind.ATR, ind.ATR_3,
+			ind.Volume, ind.ADX, ind.DI_Plus, ind.DI_Minus, ind.VolumeRatio,
+		} {
+			if len(series) > 0 && !math.IsNaN(series[len(series)-1]) {
+				numbers = append(numbers, math.Abs(series[len(series)-1]))
+			}
+		}
+	}
+	if n := len(reports.OHLCVData); n > 0 {
+		last := reports.OHLCVData[n-1]
+		numbers = append(numbers, last.Open, last.High, last.Low, last.Close)
+	}
+	return numbers
+}
+
+// numberInReports reports whether a cited number matches one of the report numbers
+// numberInReports 返回引用的数值是否与报告中的某个数值匹配
+func numberInReports(cited string, known []float64) bool {
+	value, ok := parseCitedNumber(cited)
+	if !ok {
+		return true // 无法解析的数值不做判断 / Unparseable numbers are not judged
+	}
+	value = math.Abs(value)
+
+	// Half a unit of the cited precision, e.g. 0.005 for "-0.01"
+	// 引用精度的半个单位，例如 "-0.01" 为 0.005
+	precision := 0.5
+	if dot := strings.IndexByte(cited, '.'); dot >= 0 {
+		precision = 0.5 * math.Pow(10, -float64(len(cited)-dot-1))
+	}
+	for _, number := range known {
+		diff := math.Abs(number - value)
+		if diff <= precision+1e-9 || diff <= number*0.005 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCitedNumber parses a number with optional sign and thousands separators
+// parseCitedNumber 解析可带正负号和千位分隔符的数值
+func parseCitedNumber(text string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+	return value, err == nil
+}
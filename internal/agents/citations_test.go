@@ -0,0 +1,56 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// TestVerifyCitations tests that cited numbers are matched against the reports and indicators
+// TestVerifyCitations 测试引用数值与报告和指标的核对
+func TestVerifyCitations(t *testing.T) {
+	reports := &SymbolReports{
+		MarketReport:        "ADX: 32.41，EMA20: 67,250.5，资金费率: -0.0100%",
+		TechnicalIndicators: &dataflows.TechnicalIndicators{RSI: []float64{55.2, 61.87}},
+	}
+	decision := &TradingDecision{Citations: []Citation{
+		{Text: "ADX=32 上升"},
+		{Text: "EMA20 67,250"},
+		{Text: "funding -0.01%"},
+		{Text: "RSI 61.9"},
+		{Text: "MACD 金叉"},
+		{Text: "ADX=45"},
+		{Text: "资金费率 -0.05%"},
+	}}
+
+	flagged := VerifyCitations(decision, reports)
+	if len(flagged) != 2 || flagged[0] != "ADX=45" || flagged[1] != "资金费率 -0.05%" {
+		t.Errorf("Expected the invented ADX and funding to be flagged, got %v", flagged)
+	}
+	for _, citation := range decision.Citations[:5] {
+		if !citation.Verified {
+			t.Errorf("Expected %q to be verified, unmatched %v", citation.Text, citation.Unmatched)
+		}
+	}
+	if got := decision.Citations[6].Unmatched; len(got) != 1 || got[0] != "-0.05" {
+		t.Errorf("Expected -0.05 to be unmatched, got %v", got)
+	}
+
+	if flagged := VerifyCitations(nil, reports); flagged != nil {
+		t.Errorf("Expected nil for a missing decision, got %v", flagged)
+	}
+}
+
+// TestExtractCitations tests citations in text and JSON decisions
+// TestExtractCitations 测试文本和 JSON 决策中的引用数据
+func TestExtractCitations(t *testing.T) {
+	decision := ParseDecision("**交易方向**: BUY\n**依据数据**: ADX=32 上升；资金费率 -0.01%, 价格 67,250", "BTC/USDT")
+	if len(decision.Citations) != 3 || decision.Citations[2].Text != "价格 67,250" {
+		t.Errorf("Unexpected text citations: %+v", decision.Citations)
+	}
+
+	decisions := ParseMultiCurrencyDecision(`{"symbol": "BTC/USDT", "action": "HOLD", "citations": ["ADX=32", " "]}`, []string{"BTC/USDT"})
+	if got := decisions["BTC/USDT"].Citations; len(got) != 1 || got[0].Text != "ADX=32" {
+		t.Errorf("Unexpected JSON citations: %+v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// errDecisionBudgetExceeded is returned when the LLM doesn't answer within the decision budget
+// errDecisionBudgetExceeded 表示 LLM 未在决策时间预算内返回
+var errDecisionBudgetExceeded = errors.New("LLM decision budget exceeded")
+
+// decisionBudget returns the configured LLM decision budget (0 = unlimited)
+// decisionBudget 返回配置的 LLM 决策时间预算（0 表示不限制）
+func (g *SimpleTradingGraph) decisionBudget() time.Duration {
+	if g.config == nil || g.config.LLMDecisionBudget <= 0 {
+		return 0
+	}
+	return time.Duration(g.config.LLMDecisionBudget) * time.Second
+}
+
+// RecordLateDecisions stores LLM answers that arrive after the decision budget against a batch
+// RecordLateDecisions 将超出决策预算后才返回的 LLM 结果按批次保存
+func (g *SimpleTradingGraph) RecordLateDecisions(db *storage.Storage, batchID string) {
+	g.lateDecision = func(content string, latency time.Duration, err error) {
+		late := &storage.LateDecision{BatchID: batchID, Decision: content, Latency: latency}
+		if err != nil {
+			late.Error = err.Error()
+		}
+		if err := db.SaveLateDecision(late); err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  保存迟到的 LLM 决策失败: %v", err))
+		}
+	}
+}
+
+// generateWithinBudget runs the LLM call and gives up waiting once the budget is spent
+// generateWithinBudget 执行 LLM 调用，超出时间预算后不再等待
+//
+// The call itself keeps running in the background; if it eventually returns, the late result and its
+// latency are logged and, when RecordLateDecisions was called, stored against the batch. It is never executed.
+// 调用本身会在后台继续运行；若最终返回，迟到结果及其耗时会记录到日志，并在调用过 RecordLateDecisions 时按批次保存，
+// 但不会被执行。
+func (g *SimpleTradingGraph) generateWithinBudget(ctx context.Context, budget time.Duration, generate func(context.Context) (*schema.Message, error)) (*schema.Message, error) {
+	if budget <= 0 {
+		return generate(ctx)
+	}
+
+	type generateResult struct {
+		response *schema.Message
+		err      error
+	}
+
+	start := time.Now()
+	resultCh := make(chan generateResult, 1)
+	go func() {
+		response, err := generate(ctx)
+		resultCh <- generateResult{response: response, err: err}
+	}()
+
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+
+	select {
+	case result := <-resultCh:
+		return result.response, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		record := g.lateDecision
+		go func() {
+			result := <-resultCh
+			latency := time.Since(start)
+			var content string
+			if result.err != nil {
+				g.logger.Warning(fmt.Sprintf("⏱️  迟到的 LLM 调用最终失败 (耗时 %s，预算 %s): %v",
+					latency.Round(time.Second), budget, result.err))
+			} else {
+				if result.response != nil {
+					content = result.response.Content
+				}
+				g.logger.Warning(fmt.Sprintf("⏱️  LLM 决策迟到: 耗时 %s（预算 %s），本轮已使用后备决策，迟到结果仅记录不执行",
+					latency.Round(time.Second), budget))
+			}
+			if record != nil {
+				record(content, latency, result.err)
+			}
+		}()
+		return nil, errDecisionBudgetExceeded
+	}
+}
+
+// budgetFallbackDecision returns the pre-computed rule-based decision, annotated with the timeout
+// budgetFallbackDecision 返回预先计算的规则决策，并注明超时原因
+func budgetFallbackDecision(fallback string, budget time.Duration) string {
+	return fmt.Sprintf("⏱️ LLM 未在 %s 决策预算内返回，本轮使用规则后备决策（观望），止损更新不受影响。\n\n%s", budget, fallback)
+}
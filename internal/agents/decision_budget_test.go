@@ -0,0 +1,87 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestGenerateWithinBudget tests that a fast LLM call returns its response
+// TestGenerateWithinBudget 测试快速返回的 LLM 调用能正常返回结果
+func TestGenerateWithinBudget(t *testing.T) {
+	g := &SimpleTradingGraph{logger: logger.NewColorLogger(false)}
+
+	response, err := g.generateWithinBudget(context.Background(), time.Second, func(ctx context.Context) (*schema.Message, error) {
+		return schema.AssistantMessage(`{"action": "HOLD"}`, nil), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected response, got error %v", err)
+	}
+	if response.Content != `{"action": "HOLD"}` {
+		t.Errorf("Unexpected content %q", response.Content)
+	}
+}
+
+// TestGenerateBudgetExceeded tests that a slow LLM call falls back once the budget is spent
+// TestGenerateBudgetExceeded 测试超出时间预算的慢调用会触发后备决策
+func TestGenerateBudgetExceeded(t *testing.T) {
+	g := &SimpleTradingGraph{logger: logger.NewColorLogger(false)}
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	_, err := g.generateWithinBudget(context.Background(), 50*time.Millisecond, func(ctx context.Context) (*schema.Message, error) {
+		<-release
+		return schema.AssistantMessage("late", nil), nil
+	})
+	if !errors.Is(err, errDecisionBudgetExceeded) {
+		t.Fatalf("Expected budget exceeded error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to stop waiting after the budget, waited %s", elapsed)
+	}
+
+	g.state = NewAgentState([]string{"BTC/USDT"}, "1h")
+	fallback := budgetFallbackDecision(g.makeSimpleDecision(), 50*time.Millisecond)
+	if !strings.Contains(fallback, "决策预算") || !strings.Contains(fallback, "**最终决策**: HOLD") {
+		t.Errorf("Expected annotated HOLD fallback, got:\n%s", fallback)
+	}
+}
+
+// TestGenerateBudgetRecordsLateDecision tests that a late LLM answer is handed to the recorder with its latency
+// TestGenerateBudgetRecordsLateDecision 测试迟到的 LLM 结果连同耗时交给记录函数
+func TestGenerateBudgetRecordsLateDecision(t *testing.T) {
+	type late struct {
+		content string
+		latency time.Duration
+	}
+	recorded := make(chan late, 1)
+	g := &SimpleTradingGraph{logger: logger.NewColorLogger(false)}
+	g.lateDecision = func(content string, latency time.Duration, err error) {
+		recorded <- late{content: content, latency: latency}
+	}
+
+	release := make(chan struct{})
+	_, err := g.generateWithinBudget(context.Background(), 20*time.Millisecond, func(ctx context.Context) (*schema.Message, error) {
+		<-release
+		return schema.AssistantMessage(`{"action": "BUY"}`, nil), nil
+	})
+	if !errors.Is(err, errDecisionBudgetExceeded) {
+		t.Fatalf("Expected budget exceeded error, got %v", err)
+	}
+	close(release)
+
+	select {
+	case got := <-recorded:
+		if got.content != `{"action": "BUY"}` || got.latency < 20*time.Millisecond {
+			t.Errorf("Unexpected late decision: %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Late decision was not recorded")
+	}
+}
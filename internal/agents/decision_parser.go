@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 )
 
@@ -21,6 +22,12 @@ type TradingDecision struct {
 	Symbol              string                // 交易对 / Trading pair
 	StopLoss            float64               // 止损价格 / Stop-loss price
 	PositionSizePercent float64               // 仓位百分比 0-100 / Position size percentage (e.g., 40 = 40%)
+	PartialTPPrice      float64               // 分批止盈目标价（0 表示立即）/ Partial take-profit price (0 = immediately)
+	PartialTPPercent    float64               // 分批止盈平仓比例 0-100 / Percent to close for partial take-profit
+	EntryPrice          float64               // 条件入场触发价（仅 BUY_STOP/SELL_STOP）/ Stop-entry trigger price (BUY_STOP/SELL_STOP only)
+	ReducePercent       float64               // 减仓比例 0-100（仅 REDUCE_LONG/REDUCE_SHORT）/ Percent to close (REDUCE_LONG/REDUCE_SHORT only)
+	RiskRewardRatio     float64               // 预期盈亏比（0 表示未给出）/ Expected risk/reward ratio (0 = not stated)
+	Citations           []Citation            // 决策依据的报告数据（由 VerifyCitations 核对）/ Cited report facts (checked by VerifyCitations)
 	Valid               bool                  // 决策是否有效 / Whether decision is valid
 }
 
@@ -68,6 +75,30 @@ func ParseDecision(decisionText string, symbol string) *TradingDecision {
 	// 提取仓位百分比（新功能）
 	decision.PositionSizePercent = extractPositionSizePercent(text)
 
+	// Extract partial take-profit plan (optional)
+	// 提取分批止盈计划（可选）
+	decision.PartialTPPrice, decision.PartialTPPercent = extractPartialTakeProfit(text)
+
+	// Extract the expected risk/reward ratio (optional)
+	// 提取预期盈亏比（可选）
+	decision.RiskRewardRatio = extractRiskReward(text)
+
+	// Extract the report facts the decision relied on (optional)
+	// 提取决策依据的报告数据（可选）
+	decision.Citations = extractCitations(text)
+
+	// Extract the trigger price of a conditional entry (BUY_STOP / SELL_STOP)
+	// 提取条件入场单的触发价格（BUY_STOP / SELL_STOP）
+	if executors.IsStopEntry(decision.Action) {
+		decision.EntryPrice = extractEntryPrice(text)
+	}
+
+	// Extract the percent of a reduction (REDUCE_LONG / REDUCE_SHORT)
+	// 提取减仓比例（REDUCE_LONG / REDUCE_SHORT）
+	if executors.IsReduce(decision.Action) {
+		decision.ReducePercent = extractReducePercent(text)
+	}
+
 	// Extract reason (pass lowercase text for consistency)
 	// 提取理由（传入小写文本以保持一致性）
 	decision.Reason = extractReason(text)
@@ -174,6 +205,18 @@ func mapToTradeAction(action string) executors.TradeAction {
 		return executors.ActionBuy
 	case "sell":
 		return executors.ActionSell
+	case "buy_stop":
+		return executors.ActionBuyStop
+	case "sell_stop":
+		return executors.ActionSellStop
+	case "add_long":
+		return executors.ActionAddLong
+	case "add_short":
+		return executors.ActionAddShort
+	case "reduce_long":
+		return executors.ActionReduceLong
+	case "reduce_short":
+		return executors.ActionReduceShort
 	case "close_long":
 		return executors.ActionCloseLong
 	case "close_short":
@@ -214,6 +257,49 @@ func extractConfidence(text string) float64 {
 	return 0.7
 }
 
+// extractRiskReward extracts the expected risk/reward ratio from text, returning 0 when none is stated
+// extractRiskReward 从文本中提取预期盈亏比，未给出时返回 0
+func extractRiskReward(text string) float64 {
+	// Look for patterns like "**预期盈亏比**: 2.5:1", "盈亏比 ≥ 3:1" or "risk/reward: 2.2"
+	// 查找模式，如 "**预期盈亏比**: 2.5:1"、"盈亏比 ≥ 3:1" 或 "risk/reward: 2.2"
+	patterns := []string{
+		`\*{0,2}(?:预期)?盈亏比\*{0,2}[：:\s≥>约]*([0-9]+(?:\.[0-9]+)?)`,
+		`\*{0,2}risk[/\s_-]?reward(?:\s*ratio)?\*{0,2}[：:\s≥>~]*([0-9]+(?:\.[0-9]+)?)`,
+	}
+
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		matches := re.FindStringSubmatch(text)
+		if len(matches) > 1 {
+			var ratio float64
+			fmt.Sscanf(matches[1], "%f", &ratio)
+			return ratio
+		}
+	}
+	return 0
+}
+
+// extractCitations extracts the cited report facts from a line like "**依据数据**: ADX=32 上升；资金费率 -0.01%"
+// extractCitations 从类似 "**依据数据**: ADX=32 上升；资金费率 -0.01%" 的行中提取引用的报告数据
+//
+// Facts are separated by semicolons, enumeration commas, Chinese commas or ", " (thousands separators have no space).
+// 数据之间用分号、顿号、中文逗号或 ", " 分隔（千位分隔符后没有空格）。
+func extractCitations(text string) []Citation {
+	re := regexp.MustCompile(`\*{0,2}(?:依据数据|引用数据|citations)\*{0,2}[：:]\s*\*{0,2}([^\n]+)`)
+	matches := re.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return nil
+	}
+
+	var citations []Citation
+	for _, part := range regexp.MustCompile(`[;；、，]|,\s`).Split(matches[1], -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			citations = append(citations, Citation{Text: part})
+		}
+	}
+	return citations
+}
+
 // extractLeverage extracts leverage multiplier from text
 // extractLeverage 从文本中提取杠杆倍数
 func extractLeverage(text string) int {
@@ -333,33 +419,93 @@ func extractReason(text string) string {
 	return "未提供明确理由"
 }
 
-// ValidateDecision performs safety checks on the decision
-// ValidateDecision 对决策执行安全检查
-func ValidateDecision(decision *TradingDecision, currentPosition *executors.Position) error {
+// DecisionThresholds are the minimum confidence and risk/reward of an entry (0 disables a check)
+// DecisionThresholds 是开仓决策的最低置信度和盈亏比（0 表示不检查）
+type DecisionThresholds struct {
+	MinConfidence float64 // 最低置信度 0-1 / Minimum confidence 0-1
+	MinRiskReward float64 // 最低预期盈亏比 / Minimum expected risk/reward
+}
+
+// ThresholdsFromConfig returns the decision thresholds configured by MIN_DECISION_CONFIDENCE and MIN_RISK_REWARD
+// ThresholdsFromConfig 返回 MIN_DECISION_CONFIDENCE 和 MIN_RISK_REWARD 配置的决策门槛
+func ThresholdsFromConfig(cfg *config.Config) DecisionThresholds {
+	return DecisionThresholds{MinConfidence: cfg.MinDecisionConfidence, MinRiskReward: cfg.MinRiskReward}
+}
+
+// ValidateDecision performs safety checks on the decision against the thresholds and the current positions of its symbol
+// ValidateDecision 根据决策门槛和交易对当前持仓对决策执行安全检查
+//
+// Each side is checked on its own: in hedge mode a long and a short can coexist, so BUY is
+// valid next to a short and CLOSE_SHORT only needs a short. The thresholds only apply to entries and
+// add-ons; a decision without a risk/reward ratio skips that check.
+// 每个方向单独检查：双向持仓模式下多仓和空仓可以共存，因此持有空仓时可以 BUY，CLOSE_SHORT 只要求有空仓。
+// 门槛只检查开仓和加仓决策；未给出盈亏比的决策不检查盈亏比。
+func ValidateDecision(decision *TradingDecision, positions []*executors.Position, thresholds DecisionThresholds) error {
 	if !decision.Valid {
 		return fmt.Errorf("无效的决策")
 	}
 
+	// Enforce the thresholds the prompt asks for
+	// 强制执行 Prompt 中要求的门槛
+	switch decision.Action {
+	case executors.ActionBuy, executors.ActionSell, executors.ActionBuyStop, executors.ActionSellStop,
+		executors.ActionAddLong, executors.ActionAddShort:
+		if decision.Confidence < thresholds.MinConfidence {
+			return fmt.Errorf("置信度 %.2f 低于开仓门槛 %.2f", decision.Confidence, thresholds.MinConfidence)
+		}
+		if decision.RiskRewardRatio > 0 && decision.RiskRewardRatio < thresholds.MinRiskReward {
+			return fmt.Errorf("预期盈亏比 %.2f:1 低于开仓门槛 %.2f:1", decision.RiskRewardRatio, thresholds.MinRiskReward)
+		}
+	}
+
+	// An add-on or a reduction needs a position on its side
+	// 加仓或减仓要求该方向已有持仓
+	switch decision.Action {
+	case executors.ActionAddLong:
+		if executors.PositionOnSide(positions, "long") == nil {
+			return fmt.Errorf("没有多仓可加仓")
+		}
+	case executors.ActionAddShort:
+		if executors.PositionOnSide(positions, "short") == nil {
+			return fmt.Errorf("没有空仓可加仓")
+		}
+	case executors.ActionReduceLong:
+		if executors.PositionOnSide(positions, "long") == nil {
+			return fmt.Errorf("没有多仓可减仓")
+		}
+	case executors.ActionReduceShort:
+		if executors.PositionOnSide(positions, "short") == nil {
+			return fmt.Errorf("没有空仓可减仓")
+		}
+	}
+	if executors.IsReduce(decision.Action) && (decision.ReducePercent <= 0 || decision.ReducePercent >= 100) {
+		return fmt.Errorf("减仓比例必须在 0-100 之间（不含），当前: %.1f%%", decision.ReducePercent)
+	}
+
 	// Check for conflicting actions
 	// 检查冲突的动作
-	if currentPosition != nil {
+	if len(positions) > 0 {
+		long := executors.PositionOnSide(positions, "long")
+		short := executors.PositionOnSide(positions, "short")
 		switch decision.Action {
 		case executors.ActionBuy:
-			if currentPosition.Side == "long" {
+			if long != nil {
 				return fmt.Errorf("已有多仓，不能重复开多")
 			}
 		case executors.ActionSell:
-			if currentPosition.Side == "short" {
+			if short != nil {
 				return fmt.Errorf("已有空仓，不能重复开空")
 			}
 		case executors.ActionCloseLong:
-			if currentPosition.Side != "long" {
+			if long == nil {
 				return fmt.Errorf("没有多仓可平")
 			}
 		case executors.ActionCloseShort:
-			if currentPosition.Side != "short" {
+			if short == nil {
 				return fmt.Errorf("没有空仓可平")
 			}
+		case executors.ActionBuyStop, executors.ActionSellStop:
+			return fmt.Errorf("已有 %s 持仓，不挂条件入场单", positions[0].Side)
 		}
 	}
 
@@ -533,8 +679,26 @@ func convertTradeDecisionToTradingDecision(td *TradeDecision) *TradingDecision {
 		Reason:              reason,
 		StopLoss:            stopLoss,
 		PositionSizePercent: td.PositionSize,
+		RiskRewardRatio:     td.RiskRewardRatio,
 		Valid:               true,
 	}
+	if td.PartialTPPrice != nil {
+		decision.PartialTPPrice = *td.PartialTPPrice
+	}
+	if td.PartialTPPercent != nil {
+		decision.PartialTPPercent = *td.PartialTPPercent
+	}
+	if td.EntryPrice != nil {
+		decision.EntryPrice = *td.EntryPrice
+	}
+	if td.ReducePercent != nil {
+		decision.ReducePercent = *td.ReducePercent
+	}
+	for _, citation := range td.Citations {
+		if citation = strings.TrimSpace(citation); citation != "" {
+			decision.Citations = append(decision.Citations, Citation{Text: citation})
+		}
+	}
 
 	// If action is unknown, mark as invalid but keep parsed context
 	// 如果动作未知，则标记为无效，但保留已解析的上下文信息
@@ -604,6 +768,85 @@ func extractPositionSizePercent(text string) float64 {
 	return 0
 }
 
+// extractPartialTakeProfit extracts the partial take-profit price and percent from text
+// extractPartialTakeProfit 从文本中提取分批止盈价格和平仓比例
+func extractPartialTakeProfit(text string) (float64, float64) {
+	var price, percent float64
+
+	pricePatterns := []string{
+		`\*{0,2}分批止盈价格?\*{0,2}[：:\s]*\$?\s*([0-9,.]+)`,                      // **分批止盈价格**: $102000
+		`\*{0,2}partial[-_\s]?tp[-_\s]?price\*{0,2}[：:\s]*\$?\s*([0-9,.]+)`, // partial_tp_price: 102000
+	}
+	for _, pattern := range pricePatterns {
+		re := regexp.MustCompile(pattern)
+		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
+			fmt.Sscanf(strings.ReplaceAll(matches[1], ",", ""), "%f", &price)
+			break
+		}
+	}
+
+	percentPatterns := []string{
+		`\*{0,2}分批止盈比例\*{0,2}[：:\s]*([0-9.]+)%?`,                         // **分批止盈比例**: 50%
+		`\*{0,2}partial[-_\s]?tp[-_\s]?percent\*{0,2}[：:\s]*([0-9.]+)%?`, // partial_tp_percent: 50
+	}
+	for _, pattern := range percentPatterns {
+		re := regexp.MustCompile(pattern)
+		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
+			fmt.Sscanf(matches[1], "%f", &percent)
+			break
+		}
+	}
+
+	// Only a percent in (0, 100) makes a valid partial close
+	// 只有 (0, 100) 范围内的比例才是有效的部分平仓
+	if percent <= 0 || percent >= 100 {
+		return 0, 0
+	}
+	return price, percent
+}
+
+// extractReducePercent extracts the percent of a reduction from text (0 when missing or not in (0, 100))
+// extractReducePercent 从文本中提取减仓比例（缺失或不在 (0, 100) 范围内时返回 0）
+func extractReducePercent(text string) float64 {
+	patterns := []string{
+		`\*{0,2}减仓比例\*{0,2}[：:\s]*([0-9.]+)%?`,                 // **减仓比例**: 30%
+		`\*{0,2}reduce[-_\s]?percent\*{0,2}[：:\s]*([0-9.]+)%?`, // reduce_percent: 30
+	}
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
+			var percent float64
+			if _, err := fmt.Sscanf(matches[1], "%f", &percent); err == nil && percent > 0 && percent < 100 {
+				return percent
+			}
+		}
+	}
+	return 0
+}
+
+// extractEntryPrice extracts the trigger price of a stop entry from text
+// extractEntryPrice 从文本中提取条件入场单的触发价格
+func extractEntryPrice(text string) float64 {
+	patterns := []string{
+		`\*{0,2}(?:入场触发价|触发价格|触发价|突破价格?)\*{0,2}[：:\s]*\$?\s*([0-9,.]+)`,      // **触发价格**: $101000
+		`\*{0,2}entry[-_\s]?(?:trigger|price)\*{0,2}[：:\s]*\$?\s*([0-9,.]+)`, // entry_price: 101000
+		`(?:buy|sell)_stop\s*(?:above|below|@|at)?\s*\$?\s*([0-9,.]+)`,       // BUY_STOP above $101000
+	}
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
+			var price float64
+			if _, err := fmt.Sscanf(strings.ReplaceAll(matches[1], ",", ""), "%f", &price); err == nil && price > 0 {
+				return price
+			}
+		}
+	}
+
+	// No trigger price: the stop entry is rejected when placed
+	// 未找到触发价格：挂单时会被拒绝
+	return 0
+}
+
 // ValidateLeverage validates and returns the appropriate leverage to use
 // ValidateLeverage 验证并返回应使用的杠杆倍数
 func ValidateLeverage(llmLeverage int, minLeverage int, maxLeverage int, dynamic bool) int {
@@ -252,6 +252,195 @@ func TestExtractStopLoss(t *testing.T) {
 	}
 }
 
+// TestExtractPartialTakeProfit tests partial take-profit extraction with various formats
+// TestExtractPartialTakeProfit 测试各种格式的分批止盈提取
+func TestExtractPartialTakeProfit(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		expectedPrice   float64
+		expectedPercent float64
+	}{
+		{
+			name:            "Chinese with target price",
+			text:            "**分批止盈价格**: $102,000\n**分批止盈比例**: 50%",
+			expectedPrice:   102000,
+			expectedPercent: 50,
+		},
+		{
+			name:            "English immediate close",
+			text:            "partial_tp_percent: 30",
+			expectedPrice:   0,
+			expectedPercent: 30,
+		},
+		{
+			name:            "Full close is not partial",
+			text:            "分批止盈价格: 3500\n分批止盈比例: 100%",
+			expectedPrice:   0,
+			expectedPercent: 0,
+		},
+		{
+			name:            "Not specified",
+			text:            "止损价: 100.25",
+			expectedPrice:   0,
+			expectedPercent: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, percent := extractPartialTakeProfit(tt.text)
+			if price != tt.expectedPrice || percent != tt.expectedPercent {
+				t.Errorf("extractPartialTakeProfit() = (%v, %v), want (%v, %v)",
+					price, percent, tt.expectedPrice, tt.expectedPercent)
+			}
+		})
+	}
+}
+
+// TestParseMultiCurrencyDecision_JSONPartialTP tests partial take-profit fields in JSON decisions
+// TestParseMultiCurrencyDecision_JSONPartialTP 测试 JSON 决策中的分批止盈字段
+func TestParseMultiCurrencyDecision_JSONPartialTP(t *testing.T) {
+	jsonDecision := `{"symbol": "BTC/USDT", "action": "HOLD", "confidence": 0.8, "partial_tp_price": 105000, "partial_tp_percent": 50}`
+
+	decisions := ParseMultiCurrencyDecision(jsonDecision, []string{"BTC/USDT"})
+	decision := decisions["BTC/USDT"]
+	if decision == nil {
+		t.Fatal("Expected BTC/USDT decision")
+	}
+	if decision.PartialTPPrice != 105000 || decision.PartialTPPercent != 50 {
+		t.Errorf("Expected partial TP 105000 / 50%%, got %.2f / %.1f%%", decision.PartialTPPrice, decision.PartialTPPercent)
+	}
+}
+
+// TestParseStopEntryDecision tests BUY_STOP / SELL_STOP decisions in text and JSON
+// TestParseStopEntryDecision 测试文本和 JSON 格式的 BUY_STOP / SELL_STOP 决策
+func TestParseStopEntryDecision(t *testing.T) {
+	text := "**交易方向**: BUY_STOP\n**触发价格**: $101,000\n**初始止损**: $99500\n**仓位建议**: 10% 资金"
+	decision := ParseDecision(text, "BTC/USDT")
+	if !decision.Valid || decision.Action != executors.ActionBuyStop {
+		t.Fatalf("Expected valid BUY_STOP, got %+v", decision)
+	}
+	if decision.EntryPrice != 101000 || decision.StopLoss != 99500 {
+		t.Errorf("Expected entry 101000 / stop 99500, got %.2f / %.2f", decision.EntryPrice, decision.StopLoss)
+	}
+
+	if price := extractEntryPrice("sell_stop below $3,150.5"); price != 3150.5 {
+		t.Errorf("Expected inline trigger 3150.5, got %.2f", price)
+	}
+
+	jsonDecision := `{"symbol": "ETH/USDT", "action": "SELL_STOP", "confidence": 0.9, "entry_price": 3150, "stop_loss": 3250}`
+	decisions := ParseMultiCurrencyDecision(jsonDecision, []string{"ETH/USDT"})
+	decision = decisions["ETH/USDT"]
+	if decision == nil || decision.Action != executors.ActionSellStop || decision.EntryPrice != 3150 {
+		t.Errorf("Expected SELL_STOP @ 3150, got %+v", decision)
+	}
+
+	if err := ValidateDecision(decision, []*executors.Position{{Side: "long"}}, DecisionThresholds{}); err == nil {
+		t.Error("Expected stop entry to be rejected while a position is open")
+	}
+}
+
+// TestParseReduceDecision tests REDUCE_LONG / REDUCE_SHORT decisions in text and JSON
+// TestParseReduceDecision 测试文本和 JSON 格式的 REDUCE_LONG / REDUCE_SHORT 决策
+func TestParseReduceDecision(t *testing.T) {
+	text := "**交易方向**: REDUCE_LONG\n**减仓比例**: 30%\n**理由**: 临近阻力位，先锁定部分利润"
+	decision := ParseDecision(text, "BTC/USDT")
+	if !decision.Valid || decision.Action != executors.ActionReduceLong || decision.ReducePercent != 30 {
+		t.Fatalf("Expected valid REDUCE_LONG 30%%, got %+v", decision)
+	}
+
+	jsonDecision := `{"symbol": "ETH/USDT", "action": "REDUCE_SHORT", "confidence": 0.7, "reduce_percent": 50}`
+	decision = ParseMultiCurrencyDecision(jsonDecision, []string{"ETH/USDT"})["ETH/USDT"]
+	if decision == nil || decision.Action != executors.ActionReduceShort || decision.ReducePercent != 50 {
+		t.Fatalf("Expected REDUCE_SHORT 50%%, got %+v", decision)
+	}
+
+	short := []*executors.Position{{Side: "short"}}
+	if err := ValidateDecision(decision, short, DecisionThresholds{MinConfidence: 0.9}); err != nil {
+		t.Errorf("Expected reduction to skip the entry thresholds, got %v", err)
+	}
+	if err := ValidateDecision(decision, []*executors.Position{{Side: "long"}}, DecisionThresholds{}); err == nil {
+		t.Error("Expected REDUCE_SHORT to be rejected without a short")
+	}
+	decision.ReducePercent = 100
+	if err := ValidateDecision(decision, short, DecisionThresholds{}); err == nil {
+		t.Error("Expected a 100% reduction to be rejected")
+	}
+}
+
+// TestValidateDecisionHedge tests validation when a long and a short are held on the same symbol
+// TestValidateDecisionHedge 测试同一交易对同时持有多仓和空仓时的决策验证
+func TestValidateDecisionHedge(t *testing.T) {
+	short := []*executors.Position{{Side: "short"}}
+	both := []*executors.Position{{Side: "long"}, {Side: "short"}}
+
+	tests := []struct {
+		name      string
+		action    executors.TradeAction
+		positions []*executors.Position
+		wantErr   bool
+	}{
+		{name: "Buy next to short", action: executors.ActionBuy, positions: short, wantErr: false},
+		{name: "Close long without long", action: executors.ActionCloseLong, positions: short, wantErr: true},
+		{name: "Buy with both sides", action: executors.ActionBuy, positions: both, wantErr: true},
+		{name: "Sell with both sides", action: executors.ActionSell, positions: both, wantErr: true},
+		{name: "Close long with both sides", action: executors.ActionCloseLong, positions: both, wantErr: false},
+		{name: "Close short with both sides", action: executors.ActionCloseShort, positions: both, wantErr: false},
+		{name: "Add short to short", action: executors.ActionAddShort, positions: short, wantErr: false},
+		{name: "Add long without long", action: executors.ActionAddLong, positions: short, wantErr: true},
+		{name: "Add long without positions", action: executors.ActionAddLong, positions: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := &TradingDecision{Valid: true, Action: tt.action}
+			if err := ValidateDecision(decision, tt.positions, DecisionThresholds{}); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateDecisionThresholds tests that entries below the configured thresholds are rejected
+// TestValidateDecisionThresholds 测试低于配置门槛的开仓决策被拒绝
+func TestValidateDecisionThresholds(t *testing.T) {
+	thresholds := DecisionThresholds{MinConfidence: 0.75, MinRiskReward: 2}
+
+	tests := []struct {
+		name       string
+		action     executors.TradeAction
+		confidence float64
+		riskReward float64
+		wantErr    bool
+	}{
+		{name: "Entry above thresholds", action: executors.ActionBuy, confidence: 0.8, riskReward: 2.5, wantErr: false},
+		{name: "Low confidence entry", action: executors.ActionSell, confidence: 0.55, riskReward: 3, wantErr: true},
+		{name: "Low risk/reward entry", action: executors.ActionBuyStop, confidence: 0.9, riskReward: 1.5, wantErr: true},
+		{name: "Entry without risk/reward", action: executors.ActionBuy, confidence: 0.8, wantErr: false},
+		{name: "Low confidence close", action: executors.ActionCloseLong, confidence: 0.5, wantErr: false},
+	}
+
+	positions := []*executors.Position{{Side: "long"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := &TradingDecision{Valid: true, Action: tt.action, Confidence: tt.confidence, RiskRewardRatio: tt.riskReward}
+			held := positions
+			if tt.action != executors.ActionCloseLong {
+				held = nil
+			}
+			if err := ValidateDecision(decision, held, thresholds); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	text := "**交易方向**: BUY\n**置信度**: 0.8\n**预期盈亏比**: 2.5:1"
+	if decision := ParseDecision(text, "BTC/USDT"); decision.RiskRewardRatio != 2.5 {
+		t.Errorf("Expected risk/reward 2.5, got %.2f", decision.RiskRewardRatio)
+	}
+}
+
 // TestExtractReason tests reason extraction with various formats
 // TestExtractReason 测试各种格式的理由提取
 func TestExtractReason(t *testing.T) {
@@ -0,0 +1,166 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Decision parse sources
+// 决策解析来源
+const (
+	DecisionSourceJSON = "json" // 直接解析 JSON / Parsed from JSON
+	DecisionSourceText = "text" // 正则/文本解析 / Parsed from text via regex
+)
+
+var jsonFenceRegex = regexp.MustCompile("(?s)```(?:json)?\\s*(\\{.*?\\})\\s*```")
+
+// DecisionSource reports which path ParseMultiCurrencyDecision takes for the raw output
+// DecisionSource 返回 ParseMultiCurrencyDecision 对原始输出采用的解析路径
+func DecisionSource(decisionText string, symbols []string) string {
+	trimmed := strings.TrimSpace(decisionText)
+	if strings.HasPrefix(trimmed, "{") && parseJSONMultiCurrencyDecision(trimmed, symbols) != nil {
+		return DecisionSourceJSON
+	}
+	return DecisionSourceText
+}
+
+// ToStructuredDecision converts a parsed decision into its storage form
+// ToStructuredDecision 将解析后的决策转换为存储结构
+func ToStructuredDecision(decision *TradingDecision, source string) *storage.StructuredDecision {
+	if decision == nil {
+		return nil
+	}
+
+	return &storage.StructuredDecision{
+		Action:              string(decision.Action),
+		Confidence:          decision.Confidence,
+		Leverage:            decision.Leverage,
+		PositionSizePercent: decision.PositionSizePercent,
+		StopLoss:            decision.StopLoss,
+		RiskReward:          decision.RiskRewardRatio,
+		Reason:              decision.Reason,
+		Valid:               decision.Valid,
+		Source:              source,
+		Citations:           decision.Citations,
+	}
+}
+
+// ExtractDecisionJSON extracts the JSON decision object embedded in raw LLM output
+// ExtractDecisionJSON 从 LLM 原始输出中提取嵌入的 JSON 决策对象
+//
+// Handles bare JSON, ```json fenced blocks and JSON surrounded by prose. Returns "" if none is found.
+// 支持纯 JSON、```json 代码块以及夹在文本中的 JSON，未找到时返回空字符串。
+func ExtractDecisionJSON(rawText string) string {
+	trimmed := strings.TrimSpace(rawText)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return trimmed
+	}
+
+	if matches := jsonFenceRegex.FindStringSubmatch(rawText); len(matches) > 1 && json.Valid([]byte(matches[1])) {
+		return matches[1]
+	}
+
+	start := strings.Index(rawText, "{")
+	end := strings.LastIndex(rawText, "}")
+	if start >= 0 && end > start {
+		candidate := rawText[start : end+1]
+		if json.Valid([]byte(candidate)) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// VerifyDecision compares a stored structured decision against the JSON content of the raw output
+// VerifyDecision 将保存的结构化决策与原始输出中的 JSON 内容进行比对
+//
+// Returns the list of divergences and whether the raw output contained a JSON decision to compare against.
+// 返回不一致项列表，以及原始输出中是否包含可比对的 JSON 决策。
+func VerifyDecision(rawText, symbol string, structured *storage.StructuredDecision) ([]string, bool) {
+	jsonText := ExtractDecisionJSON(rawText)
+	if jsonText == "" || structured == nil {
+		return nil, false
+	}
+
+	decisions := parseJSONMultiCurrencyDecision(jsonText, []string{symbol})
+	if decisions == nil {
+		return nil, false
+	}
+	expected := decisions[symbol]
+
+	var divergences []string
+	if string(expected.Action) != structured.Action {
+		divergences = append(divergences, fmt.Sprintf("action: parsed=%s json=%s", structured.Action, expected.Action))
+	}
+	if math.Abs(expected.Confidence-structured.Confidence) > 0.001 {
+		divergences = append(divergences, fmt.Sprintf("confidence: parsed=%.2f json=%.2f", structured.Confidence, expected.Confidence))
+	}
+	if expected.Leverage != structured.Leverage {
+		divergences = append(divergences, fmt.Sprintf("leverage: parsed=%d json=%d", structured.Leverage, expected.Leverage))
+	}
+	if math.Abs(expected.PositionSizePercent-structured.PositionSizePercent) > 0.01 {
+		divergences = append(divergences, fmt.Sprintf("position_size: parsed=%.2f json=%.2f",
+			structured.PositionSizePercent, expected.PositionSizePercent))
+	}
+	if math.Abs(expected.StopLoss-structured.StopLoss) > 1e-6 {
+		divergences = append(divergences, fmt.Sprintf("stop_loss: parsed=%.4f json=%.4f", structured.StopLoss, expected.StopLoss))
+	}
+	if expected.Valid != structured.Valid {
+		divergences = append(divergences, fmt.Sprintf("valid: parsed=%t json=%t", structured.Valid, expected.Valid))
+	}
+
+	return divergences, true
+}
+
+// DecisionVerifier flags divergences between stored structured decisions and the JSON in the raw output
+// DecisionVerifier 标记已保存的结构化决策与原始输出 JSON 之间的不一致
+type DecisionVerifier struct {
+	storage *storage.Storage
+	logger  *logger.ColorLogger
+}
+
+// NewDecisionVerifier creates a new DecisionVerifier
+// NewDecisionVerifier 创建新的决策校验器
+func NewDecisionVerifier(db *storage.Storage, log *logger.ColorLogger) *DecisionVerifier {
+	return &DecisionVerifier{
+		storage: db,
+		logger:  log,
+	}
+}
+
+// Run verifies up to limit pending sessions and returns how many were checked and how many diverged
+// Run 校验最多 limit 个待校验会话，返回已比对数量和不一致数量
+func (v *DecisionVerifier) Run(limit int) (int, int, error) {
+	pending, err := v.storage.GetUnverifiedDecisions(limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	checked, diverged := 0, 0
+	for _, item := range pending {
+		divergences, ok := VerifyDecision(item.RawOutput, item.Symbol, item.Structured)
+		if ok {
+			checked++
+		}
+
+		divergence := strings.Join(divergences, "; ")
+		if divergence != "" {
+			diverged++
+			v.logger.Warning(fmt.Sprintf("⚠️  【%s】决策解析不一致 (会话 %d, 来源 %s): %s",
+				item.Symbol, item.SessionID, item.Structured.Source, divergence))
+		}
+
+		if err := v.storage.MarkDecisionVerified(item.SessionID, divergence); err != nil {
+			return checked, diverged, err
+		}
+	}
+
+	return checked, diverged, nil
+}
@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExtractDecisionJSON tests extracting JSON decisions from raw LLM output
+// TestExtractDecisionJSON 测试从 LLM 原始输出中提取 JSON 决策
+func TestExtractDecisionJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "Bare JSON",
+			raw:      `  {"BTC/USDT": {"action": "BUY"}}  `,
+			expected: `{"BTC/USDT": {"action": "BUY"}}`,
+		},
+		{
+			name:     "Fenced JSON block",
+			raw:      "分析如下：\n```json\n{\"symbol\": \"BTC/USDT\", \"action\": \"SELL\"}\n```\n以上",
+			expected: `{"symbol": "BTC/USDT", "action": "SELL"}`,
+		},
+		{
+			name:     "JSON surrounded by prose",
+			raw:      `最终决策 {"symbol": "ETH/USDT", "action": "HOLD"} 完毕`,
+			expected: `{"symbol": "ETH/USDT", "action": "HOLD"}`,
+		},
+		{
+			name:     "No JSON",
+			raw:      "【BTC/USDT】\n**交易方向**: BUY",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractDecisionJSON(tt.raw); got != tt.expected {
+				t.Errorf("ExtractDecisionJSON() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestVerifyDecisionConsistent tests that a JSON-parsed decision verifies without divergence
+// TestVerifyDecisionConsistent 测试 JSON 解析的决策校验无不一致
+func TestVerifyDecisionConsistent(t *testing.T) {
+	raw := `{"BTC/USDT": {"symbol": "BTC/USDT", "action": "BUY", "confidence": 0.8, "leverage": 10, "position_size": 30, "stop_loss": 95000}}`
+	symbols := []string{"BTC/USDT"}
+
+	if source := DecisionSource(raw, symbols); source != DecisionSourceJSON {
+		t.Fatalf("Expected json source, got %s", source)
+	}
+
+	decisions := ParseMultiCurrencyDecision(raw, symbols)
+	structured := ToStructuredDecision(decisions["BTC/USDT"], DecisionSourceJSON)
+
+	divergences, checked := VerifyDecision(raw, "BTC/USDT", structured)
+	if !checked {
+		t.Fatal("Expected decision to be checked")
+	}
+	if len(divergences) != 0 {
+		t.Errorf("Expected no divergences, got %v", divergences)
+	}
+}
+
+// TestVerifyDecisionDivergence tests that text parsing of embedded JSON is flagged
+// TestVerifyDecisionDivergence 测试对内嵌 JSON 的文本解析会被标记为不一致
+func TestVerifyDecisionDivergence(t *testing.T) {
+	raw := "最终决策如下：\n```json\n{\"symbol\": \"BTC/USDT\", \"action\": \"SELL\", \"confidence\": 0.9, \"leverage\": 15}\n```"
+	symbols := []string{"BTC/USDT"}
+
+	if source := DecisionSource(raw, symbols); source != DecisionSourceText {
+		t.Fatalf("Expected text source, got %s", source)
+	}
+
+	decisions := ParseMultiCurrencyDecision(raw, symbols)
+	structured := ToStructuredDecision(decisions["BTC/USDT"], DecisionSourceText)
+	structured.Action = "HOLD" // Simulate a regex misread / 模拟正则误读
+
+	divergences, checked := VerifyDecision(raw, "BTC/USDT", structured)
+	if !checked {
+		t.Fatal("Expected decision to be checked")
+	}
+	if len(divergences) == 0 || !strings.Contains(divergences[0], "action: parsed=HOLD json=SELL") {
+		t.Errorf("Expected action divergence, got %v", divergences)
+	}
+}
+
+// TestVerifyDecisionWithoutJSON tests that text-only output is not checked
+// TestVerifyDecisionWithoutJSON 测试纯文本输出不参与比对
+func TestVerifyDecisionWithoutJSON(t *testing.T) {
+	raw := "【BTC/USDT】\n**交易方向**: BUY\n**置信度**: 0.8"
+	decisions := ParseMultiCurrencyDecision(raw, []string{"BTC/USDT"})
+
+	if _, checked := VerifyDecision(raw, "BTC/USDT", ToStructuredDecision(decisions["BTC/USDT"], DecisionSourceText)); checked {
+		t.Error("Text-only output should not be checked")
+	}
+}
@@ -0,0 +1,246 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// maxEnsembleModels caps the committee size, each member is a full LLM call
+// maxEnsembleModels 限制委员会规模，每个成员都是一次完整的 LLM 调用
+const maxEnsembleModels = 3
+
+// EnsembleModels returns the models that vote in ensemble mode (fewer than 2 means ensemble mode is off)
+// EnsembleModels 返回委员会模式下参与投票的模型（少于 2 个表示未启用委员会模式）
+func EnsembleModels(cfg *config.Config) []string {
+	models := cfg.EnsembleModels
+	if len(models) > maxEnsembleModels {
+		models = models[:maxEnsembleModels]
+	}
+	return models
+}
+
+// EnsembleQuorum returns the votes in one direction needed to execute: ENSEMBLE_QUORUM, or a majority when unset
+// EnsembleQuorum 返回执行所需的同向票数：ENSEMBLE_QUORUM，未设置时为过半数
+func EnsembleQuorum(cfg *config.Config, voters int) int {
+	if cfg.EnsembleQuorum > 0 && cfg.EnsembleQuorum <= voters {
+		return cfg.EnsembleQuorum
+	}
+	return voters/2 + 1
+}
+
+// ModelBallot is one model's answer in ensemble mode
+// ModelBallot 是委员会模式下单个模型的回答
+type ModelBallot struct {
+	Model     string                   // 模型名称 / Model name
+	Decisions map[string]TradeDecision // 按交易对索引的决策 / Decisions keyed by symbol
+	Err       error                    // 调用或解析失败的原因 / Why the call or parsing failed
+}
+
+// voteDirection groups actions by direction: a stop entry votes with the market entry of the same side
+// voteDirection 按方向归类动作：条件入场单与同方向的市价开仓视为同一方向
+func voteDirection(action executors.TradeAction) executors.TradeAction {
+	if executors.IsStopEntry(action) {
+		return executors.EntryAction(action)
+	}
+	return action
+}
+
+// TallyEnsemble counts the ballots per symbol and returns the committee decisions and every vote
+// TallyEnsemble 按交易对统计选票，返回委员会决策及所有投票
+//
+// A direction wins when it has the most votes, no other direction ties it and it reaches the quorum;
+// the winner's most confident vote becomes the decision. Without a winner the symbol holds.
+// Returns nil decisions when no ballot could be used.
+// 某方向票数最多、无其他方向持平且达到法定票数时胜出，取该方向置信度最高的投票作为决策；
+// 无胜出方向时该交易对观望。所有选票均不可用时返回 nil 决策。
+func TallyEnsemble(symbols []string, ballots []ModelBallot, quorum int) (map[string]TradeDecision, map[string][]*storage.EnsembleVote) {
+	votes := make(map[string][]*storage.EnsembleVote)
+	usable := 0
+	for _, ballot := range ballots {
+		if ballot.Err == nil {
+			usable++
+		}
+	}
+
+	decisions := make(map[string]TradeDecision)
+	for _, symbol := range symbols {
+		counts := make(map[executors.TradeAction]int)
+		chosen := make(map[executors.TradeAction]TradeDecision)
+		var order []executors.TradeAction
+
+		for _, ballot := range ballots {
+			vote := &storage.EnsembleVote{Symbol: symbol, Model: ballot.Model}
+			votes[symbol] = append(votes[symbol], vote)
+			if ballot.Err != nil {
+				vote.Error = ballot.Err.Error()
+				continue
+			}
+
+			td, ok := ballot.Decisions[symbol]
+			if !ok {
+				// Symbols the model didn't mention hold, as in ParseMultiCurrencyDecision
+				// 模型未提及的交易对视为观望，与 ParseMultiCurrencyDecision 一致
+				td = TradeDecision{Symbol: symbol, Action: string(executors.ActionHold), Reasoning: "未提供该交易对决策，默认观望"}
+			}
+			parsed := convertTradeDecisionToTradingDecision(&td)
+			if !parsed.Valid {
+				vote.Error = parsed.Reason
+				continue
+			}
+
+			vote.Action = string(parsed.Action)
+			vote.Confidence = parsed.Confidence
+			vote.Leverage = parsed.Leverage
+			vote.PositionSizePercent = parsed.PositionSizePercent
+			vote.StopLoss = parsed.StopLoss
+			vote.Reason = parsed.Reason
+
+			direction := voteDirection(parsed.Action)
+			if counts[direction] == 0 {
+				order = append(order, direction)
+			}
+			counts[direction]++
+			if best, ok := chosen[direction]; !ok || td.Confidence > best.Confidence {
+				td.Symbol = symbol
+				chosen[direction] = td
+			}
+		}
+
+		// Find the unique direction with the most votes
+		// 找出票数唯一最多的方向
+		var winner executors.TradeAction
+		top, tied := 0, false
+		for _, direction := range order {
+			switch {
+			case counts[direction] > top:
+				winner, top, tied = direction, counts[direction], false
+			case counts[direction] == top:
+				tied = true
+			}
+		}
+
+		tally := make([]string, 0, len(order))
+		for _, direction := range order {
+			tally = append(tally, fmt.Sprintf("%s×%d", direction, counts[direction]))
+		}
+
+		if top >= quorum && !tied {
+			decision := chosen[winner]
+			decision.Reasoning = fmt.Sprintf("[委员会 %d/%d 票 %s] %s", top, len(ballots), winner, decision.Reasoning)
+			decisions[symbol] = decision
+			for _, vote := range votes[symbol] {
+				vote.Agreed = vote.Action != "" && voteDirection(executors.TradeAction(vote.Action)) == winner
+			}
+			continue
+		}
+
+		summary := strings.Join(tally, ", ")
+		if summary == "" {
+			summary = "无有效投票"
+		}
+		decisions[symbol] = TradeDecision{
+			Symbol:    symbol,
+			Action:    string(executors.ActionHold),
+			Reasoning: fmt.Sprintf("委员会未达成一致（%s，需 %d 票同向），本轮观望", summary, quorum),
+		}
+	}
+
+	if usable == 0 {
+		return nil, votes
+	}
+	return decisions, votes
+}
+
+// castBallot asks one committee model for its decision
+// castBallot 向委员会中的一个模型请求决策
+func (g *SimpleTradingGraph) castBallot(ctx context.Context, model string, messages []*schema.Message, budget time.Duration) ModelBallot {
+	ballot := ModelBallot{Model: model}
+
+	chatModel, modeStr, err := g.newDecisionModel(ctx, model)
+	if err != nil {
+		ballot.Err = fmt.Errorf("LLM 初始化失败: %w", err)
+		return ballot
+	}
+
+	g.logger.Info(fmt.Sprintf("🗳️  正在调用委员会模型 %s (%s 模式)", model, modeStr))
+	response, err := g.generateWithinBudget(ctx, budget, func(ctx context.Context) (*schema.Message, error) {
+		return g.generateDecision(ctx, chatModel, model, messages)
+	})
+	if errors.Is(err, errDecisionBudgetExceeded) {
+		ballot.Err = fmt.Errorf("超出决策预算 %s", budget)
+		return ballot
+	}
+	if err != nil {
+		ballot.Err = fmt.Errorf("LLM 调用失败: %w", err)
+		return ballot
+	}
+
+	if _, err := parseDecisionSample(response.Content); err != nil {
+		ballot.Err = err
+		return ballot
+	}
+	ballot.Decisions, _ = parseModelDecisions(response.Content)
+	return ballot
+}
+
+// makeEnsembleDecision lets every committee model vote and returns the committee decision as JSON
+// makeEnsembleDecision 让委员会中的每个模型投票，并以 JSON 返回委员会决策
+//
+// The models are called in parallel; the votes are kept in the agent state for the sessions.
+// 各模型并行调用；投票结果保存在 AgentState 中，随会话一起保存。
+func (g *SimpleTradingGraph) makeEnsembleDecision(ctx context.Context, models []string, messages []*schema.Message) string {
+	quorum := EnsembleQuorum(g.config, len(models))
+	g.logger.Info(fmt.Sprintf("🗳️  委员会模式: %d 个模型投票 (%s)，需 %d 票同向才执行",
+		len(models), strings.Join(models, ", "), quorum))
+
+	budget := g.decisionBudget()
+	ballots := make([]ModelBallot, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			ballots[i] = g.castBallot(ctx, model, messages, budget)
+		}(i, model)
+	}
+	wg.Wait()
+
+	for _, ballot := range ballots {
+		if ballot.Err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  委员会模型 %s 弃权: %v", ballot.Model, ballot.Err))
+		}
+	}
+
+	decisions, votes := TallyEnsemble(g.state.Symbols, ballots, quorum)
+	g.state.SetEnsembleVotes(votes)
+	if decisions == nil {
+		g.logger.Warning("所有委员会模型均未给出有效决策，使用简单规则决策")
+		g.state.SetDecisionPath(rulesPath("ensemble failed"))
+		return g.makeSimpleDecision()
+	}
+
+	for _, symbol := range g.state.Symbols {
+		decision := decisions[symbol]
+		g.logger.Info(fmt.Sprintf("🗳️  【%s】委员会决策: %s - %s", symbol, decision.Action, decision.Reasoning))
+	}
+
+	content, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("委员会决策序列化失败，使用简单规则决策: %v", err))
+		g.state.SetDecisionPath(rulesPath("ensemble failed"))
+		return g.makeSimpleDecision()
+	}
+	g.state.SetDecisionPath("ensemble " + strings.Join(models, ", "))
+	g.logger.Success("✅ 委员会决策生成完成")
+	return string(content)
+}
@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestEnsembleQuorum tests the configured quorum and the majority default
+// TestEnsembleQuorum 测试配置的法定票数及默认过半数
+func TestEnsembleQuorum(t *testing.T) {
+	tests := []struct {
+		quorum, voters, want int
+	}{
+		{0, 2, 2},
+		{0, 3, 2},
+		{3, 3, 3},
+		{1, 3, 1},
+		{5, 3, 2}, // 超过模型数时回退为过半数 / Falls back to a majority above the model count
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{EnsembleQuorum: tt.quorum}
+		if got := EnsembleQuorum(cfg, tt.voters); got != tt.want {
+			t.Errorf("EnsembleQuorum(%d, %d) = %d, want %d", tt.quorum, tt.voters, got, tt.want)
+		}
+	}
+
+	cfg := &config.Config{EnsembleModels: []string{"a", "b", "c", "d"}}
+	if models := EnsembleModels(cfg); len(models) != 3 {
+		t.Errorf("Expected the committee capped at 3 models, got %v", models)
+	}
+}
+
+// TestTallyEnsemble tests that only a quorum on direction executes and every vote is recorded
+// TestTallyEnsemble 测试只有同向票数达到法定票数才执行，且所有投票均被记录
+func TestTallyEnsemble(t *testing.T) {
+	symbols := []string{"BTC/USDT", "ETH/USDT"}
+	ballots := []ModelBallot{
+		{Model: "quick", Decisions: map[string]TradeDecision{
+			"BTC/USDT": {Symbol: "BTC/USDT", Action: "BUY", Confidence: 0.7, Leverage: 5, PositionSize: 20, StopLoss: 95000, Reasoning: "突破"},
+			"ETH/USDT": {Symbol: "ETH/USDT", Action: "SELL", Confidence: 0.6, Reasoning: "走弱"},
+		}},
+		{Model: "deep", Decisions: map[string]TradeDecision{
+			"BTC/USDT": {Symbol: "BTC/USDT", Action: "BUY_STOP", Confidence: 0.9, Leverage: 3, PositionSize: 10, StopLoss: 96000, Reasoning: "等待突破确认"},
+			"ETH/USDT": {Symbol: "ETH/USDT", Action: "BUY", Confidence: 0.8, Reasoning: "支撑有效"},
+		}},
+		{Model: "slow", Err: errors.New("超出决策预算 2m0s")},
+	}
+
+	decisions, votes := TallyEnsemble(symbols, ballots, 2)
+	if decisions == nil {
+		t.Fatal("Expected committee decisions")
+	}
+
+	// BUY 与 BUY_STOP 同向，取置信度最高的 BUY_STOP
+	btc := decisions["BTC/USDT"]
+	if btc.Action != "BUY_STOP" || btc.Leverage != 3 || !strings.Contains(btc.Reasoning, "2/3") {
+		t.Errorf("Expected the most confident long vote to win, got %+v", btc)
+	}
+
+	// ETH 一票做多一票做空，未达成一致 → 观望
+	if eth := decisions["ETH/USDT"]; eth.Action != "HOLD" || !strings.Contains(eth.Reasoning, "未达成一致") {
+		t.Errorf("Expected ETH to hold without a quorum, got %+v", eth)
+	}
+
+	btcVotes := votes["BTC/USDT"]
+	if len(btcVotes) != 3 {
+		t.Fatalf("Expected 3 BTC votes, got %d", len(btcVotes))
+	}
+	if !btcVotes[0].Agreed || !btcVotes[1].Agreed || btcVotes[2].Agreed {
+		t.Errorf("Unexpected agreement flags: %+v %+v %+v", btcVotes[0], btcVotes[1], btcVotes[2])
+	}
+	if btcVotes[0].Action != "BUY" || btcVotes[0].StopLoss != 95000 || btcVotes[2].Error == "" {
+		t.Errorf("Expected votes to keep each model's decision and error, got %+v %+v", btcVotes[0], btcVotes[2])
+	}
+	for _, vote := range votes["ETH/USDT"] {
+		if vote.Agreed {
+			t.Errorf("Expected no agreed ETH votes without a quorum, got %+v", vote)
+		}
+	}
+
+	// 模型未提及的交易对视为观望
+	decisions, _ = TallyEnsemble([]string{"SOL/USDT"}, ballots[:2], 2)
+	if sol := decisions["SOL/USDT"]; sol.Action != "HOLD" || !strings.Contains(sol.Reasoning, "2/2") {
+		t.Errorf("Expected unmentioned symbols to be unanimous holds, got %+v", sol)
+	}
+
+	// 所有模型都失败时不返回决策，但仍记录投票
+	decisions, votes = TallyEnsemble(symbols, ballots[2:], 1)
+	if decisions != nil || len(votes["BTC/USDT"]) != 1 {
+		t.Errorf("Expected nil decisions with recorded votes when every model failed, got %v, %v", decisions, votes)
+	}
+}
+
+// TestParseModelDecisions tests both response formats of a committee model
+// TestParseModelDecisions 测试委员会模型的两种响应格式
+func TestParseModelDecisions(t *testing.T) {
+	multi, err := parseModelDecisions("```json\n{\"BTC/USDT\": {\"action\": \"BUY\", \"confidence\": 0.8}}\n```")
+	if err != nil || multi["BTC/USDT"].Symbol != "BTC/USDT" || multi["BTC/USDT"].Action != "BUY" {
+		t.Errorf("Expected the map key as symbol, got %+v, %v", multi, err)
+	}
+
+	single, err := parseModelDecisions(`{"symbol": "ETH/USDT", "action": "SELL"}`)
+	if err != nil || single["ETH/USDT"].Action != "SELL" {
+		t.Errorf("Expected the single-object format keyed by symbol, got %+v, %v", single, err)
+	}
+
+	if _, err := parseDecisionSample("not json"); err == nil {
+		t.Error("Expected an error for a non-JSON response")
+	}
+}
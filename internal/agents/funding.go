@@ -0,0 +1,47 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// formatFundingReport renders the upcoming funding settlement for the crypto analyst report
+// formatFundingReport 为加密货币分析报告生成下一次资金费结算的描述
+//
+// Besides the readable line it emits a one-line JSON "funding" field so the trader can weigh the
+// holding cost precisely. maxPercent (0 = disabled) is the FUNDING_RATE_MAX_PERCENT entry guard.
+// 除了可读文本外，还输出一行 JSON 格式的 "funding" 字段，便于交易员精确评估持仓成本。
+// maxPercent（0 表示关闭）对应 FUNDING_RATE_MAX_PERCENT 开仓限制。
+func formatFundingReport(info *dataflows.FundingInfo, now time.Time, maxPercent float64) string {
+	minutes := int(info.NextFundingTime.Sub(now).Minutes())
+	if minutes < 0 {
+		minutes = 0
+	}
+
+	payer := "none"
+	switch {
+	case info.Rate > 0:
+		payer = "long"
+	case info.Rate < 0:
+		payer = "short"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⏰ 下次资金费结算: %s（约 %dh%02dm 后），预测费率 %.4f%%\n",
+		info.NextFundingTime.UTC().Format("2006-01-02 15:04 UTC"), minutes/60, minutes%60, info.Rate*100))
+	switch payer {
+	case "long":
+		sb.WriteString(fmt.Sprintf("   多头支付空头：持有多仓每次结算成本约 %.4f%% 名义价值\n", info.Rate*100))
+	case "short":
+		sb.WriteString(fmt.Sprintf("   空头支付多头：持有空仓每次结算成本约 %.4f%% 名义价值\n", -info.Rate*100))
+	}
+	if maxPercent > 0 {
+		sb.WriteString(fmt.Sprintf("   开仓限制：付费方向的资金费率超过 %.4f%% 时系统会拒绝开仓\n", maxPercent))
+	}
+	sb.WriteString(fmt.Sprintf("📌 funding: {\"predicted_rate_pct\": %.4f, \"next_funding_time\": %q, \"minutes_to_funding\": %d, \"payer\": %q, \"max_entry_rate_pct\": %.4f}\n\n",
+		info.Rate*100, info.NextFundingTime.UTC().Format(time.RFC3339), minutes, payer, maxPercent))
+	return sb.String()
+}
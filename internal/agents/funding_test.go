@@ -0,0 +1,32 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+func TestFormatFundingReport(t *testing.T) {
+	now := time.Date(2026, 1, 2, 13, 47, 0, 0, time.UTC)
+	info := &dataflows.FundingInfo{Rate: 0.0015, MarkPrice: 100000, NextFundingTime: time.Date(2026, 1, 2, 16, 0, 0, 0, time.UTC)}
+
+	report := formatFundingReport(info, now, 0.1)
+	for _, want := range []string{
+		"2026-01-02 16:00 UTC（约 2h13m 后），预测费率 0.1500%",
+		"多头支付空头",
+		"超过 0.1000% 时系统会拒绝开仓",
+		`"minutes_to_funding": 133, "payer": "long", "max_entry_rate_pct": 0.1000`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+
+	info.Rate = -0.0002
+	report = formatFundingReport(info, now, 0)
+	if !strings.Contains(report, "空头支付多头") || !strings.Contains(report, `"payer": "short"`) || strings.Contains(report, "拒绝开仓") {
+		t.Errorf("Unexpected report for negative rate without guard:\n%s", report)
+	}
+}
@@ -2,6 +2,7 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -17,7 +18,10 @@ import (
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/format"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // SymbolReports holds reports for a single symbol
@@ -28,6 +32,7 @@ type SymbolReports struct {
 	CryptoReport        string
 	SentimentReport     string
 	PositionInfo        string
+	TradeMemory         string // 近期已平仓交易复盘 / Review of recent closed trades
 	OHLCVData           []dataflows.OHLCV
 	TechnicalIndicators *dataflows.TechnicalIndicators
 }
@@ -36,7 +41,7 @@ type SymbolReports struct {
 // TradeDecision 表示 LLM 的结构化交易决策（用于 JSON Schema 输出）
 type TradeDecision struct {
 	Symbol            string   `json:"symbol"`                        // 交易对 / Trading pair
-	Action            string   `json:"action"`                        // 交易动作 / Action: BUY|SELL|HOLD|CLOSE_LONG|CLOSE_SHORT
+	Action            string   `json:"action"`                        // 交易动作 / Action: BUY|SELL|BUY_STOP|SELL_STOP|ADD_LONG|ADD_SHORT|REDUCE_LONG|REDUCE_SHORT|HOLD|CLOSE_LONG|CLOSE_SHORT
 	Confidence        float64  `json:"confidence"`                    // 置信度 / Confidence (0.00-1.00)
 	Leverage          int      `json:"leverage"`                      // 杠杆倍数 / Leverage multiplier
 	PositionSize      float64  `json:"position_size"`                 // 建议仓位百分比 / Position size percentage (0-100)
@@ -47,18 +52,28 @@ type TradeDecision struct {
 	CurrentPnlPercent *float64 `json:"current_pnl_percent,omitempty"` // 当前盈亏% (仅HOLD) / Current PnL% (HOLD only)
 	NewStopLoss       *float64 `json:"new_stop_loss,omitempty"`       // 新止损价格 (仅HOLD调整时) / New stop loss (HOLD adjustment only)
 	StopLossReason    *string  `json:"stop_loss_reason,omitempty"`    // 止损调整理由 (仅HOLD调整时) / Stop loss reason (HOLD adjustment only)
+	PartialTPPrice    *float64 `json:"partial_tp_price,omitempty"`    // 分批止盈目标价 / Partial take-profit target price
+	PartialTPPercent  *float64 `json:"partial_tp_percent,omitempty"`  // 分批止盈平仓比例 / Percent to close at partial take-profit
+	EntryPrice        *float64 `json:"entry_price,omitempty"`         // 条件入场触发价 (仅BUY_STOP/SELL_STOP) / Stop-entry trigger (BUY_STOP/SELL_STOP only)
+	ReducePercent     *float64 `json:"reduce_percent,omitempty"`      // 减仓比例 (仅REDUCE_LONG/REDUCE_SHORT) / Percent to close (REDUCE_LONG/REDUCE_SHORT only)
+	Citations         []string `json:"citations,omitempty"`           // 决策依据的报告数据 / Report facts the decision relied on
 }
 
 // AgentState holds the state of all analysts' reports for multiple symbols
 // AgentState 保存所有分析师对多个交易对的报告状态
 type AgentState struct {
-	Symbols       []string                  // 所有交易对 / All trading pairs
-	Timeframe     string                    // 时间周期 / Timeframe
-	Reports       map[string]*SymbolReports // 每个交易对的报告 / Reports for each symbol
-	AccountInfo   string                    // 账户总览信息 / Account overview
-	AllPositions  string                    // 所有持仓汇总 / All positions summary
-	FinalDecision string                    // 最终交易决策 / Final trading decision
-	mu            sync.RWMutex              // 读写锁 / Read-write mutex
+	Symbols       []string                           // 所有交易对 / All trading pairs
+	Timeframe     string                             // 时间周期 / Timeframe
+	Reports       map[string]*SymbolReports          // 每个交易对的报告 / Reports for each symbol
+	AccountInfo   string                             // 账户总览信息 / Account overview
+	AllPositions  string                             // 所有持仓汇总 / All positions summary
+	FinalDecision string                             // 最终交易决策 / Final trading decision
+	Language      string                             // 报告标题语言 zh/en / Report header language zh/en
+	EnsembleVotes map[string][]*storage.EnsembleVote // 委员会模式下各模型的投票 / Per-model votes in ensemble mode
+	Reviews       map[string]*ReviewVerdict          // 开仓决策的深度复核结论 / Deep-think review verdicts on entries
+	DecisionPath  string                             // 决策来源路径 / How the decision was produced
+	PluginContext string                             // 插件追加的补充信息 / Extra context added by plugins
+	mu            sync.RWMutex                       // 读写锁 / Read-write mutex
 }
 
 // NewAgentState creates a new agent state for multiple symbols
@@ -141,6 +156,54 @@ func (s *AgentState) SetFinalDecision(decision string) {
 	s.FinalDecision = decision
 }
 
+// SetEnsembleVotes sets the committee votes of the current decision
+// SetEnsembleVotes 设置本次决策的委员会投票
+func (s *AgentState) SetEnsembleVotes(votes map[string][]*storage.EnsembleVote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.EnsembleVotes = votes
+}
+
+// GetEnsembleVotes returns the committee votes for a symbol (empty outside ensemble mode)
+// GetEnsembleVotes 返回某个交易对的委员会投票（非委员会模式时为空）
+func (s *AgentState) GetEnsembleVotes(symbol string) []*storage.EnsembleVote {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.EnsembleVotes[symbol]
+}
+
+// SetDecisionPath records how the current decision was produced (model, retry, fallback or rules)
+// SetDecisionPath 记录本次决策的来源路径（模型、重试、备用模型或规则）
+func (s *AgentState) SetDecisionPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DecisionPath = path
+}
+
+// GetDecisionPath returns how the current decision was produced
+// GetDecisionPath 返回本次决策的来源路径
+func (s *AgentState) GetDecisionPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.DecisionPath
+}
+
+// SetReviews sets the deep-think review verdicts of the current decision
+// SetReviews 设置本次决策的深度复核结论
+func (s *AgentState) SetReviews(reviews map[string]*ReviewVerdict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Reviews = reviews
+}
+
+// GetReview returns the review verdict for a symbol (nil when the symbol wasn't reviewed)
+// GetReview 返回某个交易对的复核结论（未复核时为 nil）
+func (s *AgentState) GetReview(symbol string) *ReviewVerdict {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Reviews[symbol]
+}
+
 // GetSymbolReports returns reports for a specific symbol
 // GetSymbolReports 返回特定交易对的报告
 func (s *AgentState) GetSymbolReports(symbol string) *SymbolReports {
@@ -152,38 +215,56 @@ func (s *AgentState) GetSymbolReports(symbol string) *SymbolReports {
 // GetAllReports returns all reports as a formatted string
 // GetAllReports 返回所有报告的格式化字符串
 func (s *AgentState) GetAllReports() string {
+	return s.GetReportsFor(s.Symbols)
+}
+
+// GetReportsFor returns the account overview, the positions summary and the reports of the given symbols
+// GetReportsFor 返回账户总览、持仓汇总以及指定交易对的报告
+func (s *AgentState) GetReportsFor(symbols []string) string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var sb strings.Builder
+	labels := labelsFor(s.Language)
 
 	// 首先显示账户总览 / First show account overview
 	if s.AccountInfo != "" {
-		sb.WriteString("\n=== 账户总览 ===\n")
+		sb.WriteString(fmt.Sprintf("\n=== %s ===\n", labels.AccountOverview))
 		sb.WriteString(s.AccountInfo)
 		sb.WriteString("\n")
 	}
 
 	// 然后显示所有持仓汇总 / Then show all positions summary
 	if s.AllPositions != "" {
-		sb.WriteString("=== 持仓汇总 ===\n")
+		sb.WriteString(fmt.Sprintf("=== %s ===\n", labels.PositionsSummary))
 		sb.WriteString(s.AllPositions)
 		sb.WriteString("\n")
 	}
 
 	// 最后为每个交易对生成市场分析报告（不包含持仓信息）/ Finally generate market analysis for each symbol (without position info)
-	for _, symbol := range s.Symbols {
+	for _, symbol := range symbols {
 		reports := s.Reports[symbol]
-		sb.WriteString(fmt.Sprintf("\n================ %s 分析报告 ================\n", symbol))
-		sb.WriteString("\n=== 市场技术分析 ===\n")
+		sb.WriteString(fmt.Sprintf("\n================ %s ================\n", fmt.Sprintf(labels.SymbolReport, symbol)))
+		sb.WriteString(fmt.Sprintf("\n=== %s ===\n", labels.MarketAnalysis))
 		sb.WriteString(reports.MarketReport)
-		sb.WriteString("\n\n=== 加密货币专属分析 ===\n")
+		sb.WriteString(fmt.Sprintf("\n\n=== %s ===\n", labels.CryptoAnalysis))
 		sb.WriteString(reports.CryptoReport)
+		if reports.TradeMemory != "" {
+			sb.WriteString(fmt.Sprintf("\n\n=== %s ===\n", labels.TradeMemory))
+			sb.WriteString(reports.TradeMemory)
+		}
 		//sb.WriteString("\n\n=== 市场情绪分析 ===\n")
 		//sb.WriteString(reports.SentimentReport)
 		sb.WriteString("\n")
 	}
 
+	// 插件追加的补充信息 / Extra context added by plugins
+	if s.PluginContext != "" {
+		sb.WriteString(fmt.Sprintf("\n=== %s ===\n", labels.PluginContext))
+		sb.WriteString(s.PluginContext)
+		sb.WriteString("\n")
+	}
+
 	return sb.String()
 }
 
@@ -272,16 +353,62 @@ type SimpleTradingGraph struct {
 	startTime       time.Time  // 交易开始时间 / Trading start time
 	tradeCount      int        // 已执行的交易次数 / Number of trades executed
 	mu              sync.Mutex // 保护 tradeCount / Protect tradeCount
+
+	// lateDecision receives LLM answers that arrive after the decision budget (nil = log only)
+	// lateDecision 接收超出决策预算后才返回的 LLM 结果（nil 表示只记录日志）
+	lateDecision func(content string, latency time.Duration, err error)
+
+	// tradeFrequency is the recent trade frequency; overtrading adds guidance to the prompt (nil = not checked)
+	// tradeFrequency 是最近的交易频率；过度交易时在 Prompt 中加入提示（nil 表示未检查）
+	tradeFrequency *risk.TradeFrequency
+
+	// lossStreak is the intraday loss-streak ladder; a halving or halt adds guidance to the prompt (nil = not checked)
+	// lossStreak 是日内连亏阶梯；减仓或停止开仓时在 Prompt 中加入提示（nil 表示未检查）
+	lossStreak *risk.LossStreak
+
+	// tradeStats is the trading performance passed to prompt templates (nil = unknown)
+	// tradeStats 是传给 Prompt 模板的历史交易表现（nil 表示未知）
+	tradeStats *storage.TradeStats
+
+	// closedTrades returns a symbol's latest closed positions for the decision memory (nil = not recalled)
+	// closedTrades 返回某个交易对最近的已平仓持仓，用于决策记忆（nil 表示不回顾）
+	closedTrades func(symbol string, limit int) ([]*storage.PositionRecord, error)
+
+	// balance is the available balance read with the account info, used for small-account mode (0 = unknown)
+	// balance 是获取账户信息时读取的可用余额，用于小账户模式（0 表示未知）
+	balance float64
+
+	// stream receives streamed decision output when LLM_STREAMING is enabled (nil = not published)
+	// stream 在启用 LLM_STREAMING 时接收流式决策输出（nil 表示不发布）
+	stream *DecisionStream
+
+	// llmUsage receives the tokens of every LLM call and monthTokens returns this month's total (nil = not tracked)
+	// llmUsage 接收每次 LLM 调用的 token 用量，monthTokens 返回本月合计（nil 表示不统计）
+	llmUsage    func(model string, promptTokens, completionTokens int)
+	monthTokens func() (int, error)
+
+	// liquidations collects the liquidation stream between cycles (nil = no liquidation section)
+	// liquidations 在周期之间收集强平推送（nil 表示报告中不含爆仓统计）
+	liquidations *dataflows.LiquidationTracker
+
+	// klineCache stores fetched candles so each cycle only requests the missing tail (nil = full fetch)
+	// klineCache 保存已获取的 K 线，每个周期只请求缺失部分（nil 表示完整获取）
+	klineCache *storage.Storage
 }
 
 // NewSimpleTradingGraph creates a new simple trading graph
 // NewSimpleTradingGraph 创建新的简单交易图
 func NewSimpleTradingGraph(cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, stopLossManager *executors.StopLossManager) *SimpleTradingGraph {
+	// Match report headers to the model's language to keep prompts single-language
+	// 报告标题语言与模型保持一致，避免中英混杂的 Prompt
+	state := NewAgentState(cfg.CryptoSymbols, cfg.CryptoTimeframe)
+	state.Language = ResolveReportLanguage(cfg.ReportLanguage, cfg.QuickThinkLLM)
+
 	return &SimpleTradingGraph{
 		config:          cfg,
 		logger:          log,
 		executor:        executor,
-		state:           NewAgentState(cfg.CryptoSymbols, cfg.CryptoTimeframe),
+		state:           state,
 		stopLossManager: stopLossManager,
 		startTime:       time.Now(), // 初始化交易开始时间 / Initialize trading start time
 		tradeCount:      0,          // 初始化交易次数为 0 / Initialize trade count to 0
@@ -309,6 +436,21 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	graph := compose.NewGraph[map[string]any, map[string]any]()
 
 	marketData := dataflows.NewMarketData(g.config)
+	if g.klineCache != nil {
+		marketData.UseKlineCache(g.klineCache)
+	}
+	news := dataflows.NewNewsFeed(g.config)
+	mood := dataflows.NewMarketMood(g.config)
+
+	// Indicator set from INDICATORS; unknown names fall back to the default set
+	// INDICATORS 配置的指标集；包含未知名称时退回默认指标集
+	indicatorSet, err := dataflows.ParseIndicatorSet(g.config.Indicators)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  INDICATORS 配置无效，使用默认指标集: %v", err))
+	}
+	// Advanced indicators are reported when enabled or when the set may list them
+	// 启用高级指标或配置了指标集时输出高级指标（未选中的不会输出）
+	reportAdvanced := g.config.AdvancedIndicators || indicatorSet != nil
 
 	// Market Analyst Lambda - Fetches market data and calculates indicators for all symbols
 	// Market Analyst Lambda - 为所有交易对获取市场数据并计算指标
@@ -342,11 +484,14 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 
 				// Calculate indicators for primary timeframe
 				// 计算主时间周期的指标
-				indicators := dataflows.CalculateIndicators(ohlcvData)
+				indicators := dataflows.CalculateIndicatorSet(ohlcvData, indicatorSet)
 
 				// Generate primary timeframe report
 				// 生成主时间周期报告
 				report := dataflows.FormatIndicatorReport(sym, timeframe, ohlcvData, indicators)
+				if reportAdvanced {
+					report += dataflows.FormatAdvancedIndicators(ohlcvData, indicators)
+				}
 
 				// Multi-timeframe analysis (if enabled)
 				// 多时间周期分析（如果启用）
@@ -361,11 +506,14 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					} else {
 						// Calculate indicators for longer timeframe
 						// 计算更长期时间周期的指标
-						longerIndicators := dataflows.CalculateIndicators(longerOHLCV)
+						longerIndicators := dataflows.CalculateIndicatorSet(longerOHLCV, indicatorSet)
 
 						// Generate longer timeframe report
 						// 生成更长期时间周期报告
 						longerReport := dataflows.FormatLongerTimeframeReport(sym, g.config.CryptoLongerTimeframe, longerOHLCV, longerIndicators)
+						if reportAdvanced {
+							longerReport += dataflows.FormatAdvancedIndicators(longerOHLCV, longerIndicators)
+						}
 
 						// Append longer timeframe report to main report
 						// 将更长期时间周期报告追加到主报告
@@ -414,7 +562,7 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				binanceSymbol := g.config.GetBinanceSymbolFor(sym)
 				var reportBuilder strings.Builder
 
-				reportBuilder.WriteString(fmt.Sprintf("=== %s 加密货币数据 ===\n\n", sym))
+				reportBuilder.WriteString(fmt.Sprintf("=== %s ===\n\n", fmt.Sprintf(labelsFor(g.state.Language).CryptoData, sym)))
 
 				// Funding rate
 				fundingRate, err := marketData.GetFundingRate(ctx, binanceSymbol)
@@ -424,16 +572,23 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					reportBuilder.WriteString(fmt.Sprintf("💰 资金费率: %.6f (%.4f%%)\n\n", fundingRate, fundingRate*100))
 				}
 
-				// Order book - use enhanced format
-				//orderBook, err := marketData.GetOrderBook(ctx, binanceSymbol, 50)
-				//if err != nil {
-				//	reportBuilder.WriteString(fmt.Sprintf("订单簿获取失败: %v\n\n", err))
-				//} else {
-				//	// Use the new formatted order book report
-				//	orderBookReport := dataflows.FormatOrderBookReport(orderBook, 20)
-				//	reportBuilder.WriteString(orderBookReport)
-				//	reportBuilder.WriteString("\n")
-				//}
+				// Upcoming funding settlement
+				// 下一次资金费结算
+				if fundingInfo, err := marketData.GetFundingInfo(ctx, binanceSymbol); err != nil {
+					reportBuilder.WriteString(fmt.Sprintf("下次资金费结算获取失败: %v\n\n", err))
+				} else {
+					reportBuilder.WriteString(formatFundingReport(fundingInfo, time.Now(), g.config.FundingRateMaxPercent))
+				}
+
+				// Order book - 1000 levels so the ±2% depth bands are covered on liquid pairs
+				// 订单簿 - 取 1000 档，使流动性好的交易对能覆盖 ±2% 深度范围
+				orderBook, err := marketData.GetOrderBook(ctx, binanceSymbol, 1000)
+				if err != nil {
+					reportBuilder.WriteString(fmt.Sprintf("订单簿获取失败: %v\n\n", err))
+				} else {
+					reportBuilder.WriteString(dataflows.FormatOrderBookReport(orderBook, 1000))
+					reportBuilder.WriteString("\n")
+				}
 
 				// 持仓量统计 - 4h、15m 间隔，显示相对变化率
 				// Open Interest Statistics - 4h window with 15m sampling, showing percentage changes
@@ -472,29 +627,9 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					reportBuilder.WriteString("  数据不足，无法构建 4h 序列\n\n")
 				}
 
-				// 大户多空比 - 2h 15m 间隔，提供序列变化
-				// Top Trader Long/Short Ratio - 2h window with 15m sampling
-				//reportBuilder.WriteString("🐋 大户持仓多空比变化统计2h:\n")
-				//
-				//ratioSeries, err := marketData.GetTopLongShortPositionRatio(ctx, binanceSymbol, "15m", 8)
-				//if err != nil {
-				//	reportBuilder.WriteString(fmt.Sprintf("  数据获取失败: %v\n\n", err))
-				//} else {
-				//	longPct := ratioSeries["long_account"].(float64)
-				//	shortPct := ratioSeries["short_account"].(float64)
-				//	lsRatio := ratioSeries["long_short_ratio"].(float64)
-				//	reportBuilder.WriteString(fmt.Sprintf("  最新: 多空比 %.2f (多头 %.1f%% vs 空头 %.1f%%)\n", lsRatio, longPct, shortPct))
-				//
-				//	if series, ok := ratioSeries["series_ratios"].([]float64); ok && len(series) > 0 {
-				//		chunks := make([]string, 0, len(series))
-				//		for _, val := range series {
-				//			chunks = append(chunks, fmt.Sprintf("%.2f", val))
-				//		}
-				//		reportBuilder.WriteString(fmt.Sprintf("  间隔15分钟: [%s]\n\n", strings.Join(chunks, ", ")))
-				//	} else {
-				//		reportBuilder.WriteString("  数据不足，无法构建 2h 序列\n\n")
-				//	}
-				//}
+				// 多空比和爆仓 - 2h 15m 间隔，提供序列变化
+				// Long/short ratios and liquidations - 2h window with 15m sampling
+				reportBuilder.WriteString(g.positioningReport(ctx, marketData, binanceSymbol))
 
 				// 24h stats
 				stats, err := marketData.Get24HrStats(ctx, binanceSymbol)
@@ -535,7 +670,7 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 # 市场情绪分析（已禁用）
 
 `
-				g.state.SetSentimentReport(symbol, emptyReport)
+				g.state.SetSentimentReport(symbol, emptyReport+g.moodReport(ctx, mood, symbol)+g.newsReport(ctx, news, symbol))
 			}
 			return results, nil
 		}
@@ -562,7 +697,7 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					report := dataflows.FormatSentimentReport(nil)
 					g.state.SetSentimentReport(sym, report)
 				} else {
-					report := dataflows.FormatSentimentReport(sentiment)
+					report := dataflows.FormatSentimentReport(sentiment) + g.moodReport(ctx, mood, sym) + g.newsReport(ctx, news, sym)
 					g.state.SetSentimentReport(sym, report)
 					g.logger.Success(fmt.Sprintf("  ✅ %s 情绪分析完成", sym))
 				}
@@ -578,11 +713,30 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	// Position Info Lambda - Gets current position for all symbols
 	// Position Info Lambda - 获取所有交易对的持仓信息
 	positionInfo := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		// Analysis-only mode has no account: mark the sections unavailable instead of calling the exchange
+		// 仅分析模式没有账户：将相应部分标记为不可用，而不是调用交易所
+		if g.config.IsAnalysisOnly() {
+			unavailable := labelsFor(g.state.Language).AnalysisOnly
+			g.state.SetAccountInfo(unavailable)
+			var allPositions strings.Builder
+			for _, symbol := range g.state.Symbols {
+				allPositions.WriteString(fmt.Sprintf("**%s**: %s", symbol, unavailable))
+			}
+			g.state.SetAllPositions(allPositions.String())
+			g.logger.Info("🔬 仅分析模式：跳过账户和持仓信息")
+			return map[string]any{}, nil
+		}
+
 		g.logger.Info("📊 获取账户总览和持仓信息...")
 
 		// 首先获取账户信息（只调用一次）/ First get account info (call only once)
 		accountSummary := g.executor.GetAccountSummary(ctx)
 		g.state.SetAccountInfo(accountSummary)
+		if g.config.SmallAccountEquity > 0 {
+			if balance, err := g.executor.GetBalance(ctx); err == nil {
+				g.balance = balance
+			}
+		}
 		g.logger.Success("  ✅ 账户信息获取完成")
 
 		// 并行获取所有交易对的持仓 / Get positions for all symbols in parallel
@@ -604,6 +758,18 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					g.logger.Warning(fmt.Sprintf("  ⚠️  更新 %s 价格失败: %v", sym, err))
 				}
 
+				// Take partial profit if the target was reached
+				// 如果到达分批止盈目标则部分平仓
+				if err := g.stopLossManager.CheckPartialTakeProfit(ctx, sym); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  %s 分批止盈失败: %v", sym, err))
+				}
+
+				// Ratchet ATR trailing stop to the new high/low (no-op if disabled)
+				// 根据新的最高/最低价收紧 ATR 追踪止损（未启用时不操作）
+				if err := g.stopLossManager.UpdateTrailingStop(ctx, sym); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  更新 %s 追踪止损失败: %v", sym, err))
+				}
+
 				// Reconcile position (detect if stop-loss was triggered by Binance)
 				// 对账持仓（检测币安是否已自动执行止损）
 				if err := g.stopLossManager.ReconcilePosition(ctx, sym); err != nil {
@@ -616,6 +782,12 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					g.logger.Warning(fmt.Sprintf("  ⚠️  检查 %s 止损单状态失败: %v", sym, err))
 				}
 
+				// Check the take-profit side of the bracket; a fill cancels the stop-loss
+				// 检查括号单的止盈一方；成交后撤销止损单
+				if err := g.stopLossManager.CheckTakeProfitOrderStatus(ctx, sym); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  检查 %s 止盈单状态失败: %v", sym, err))
+				}
+
 				// 获取持仓信息（不包含账户信息）/ Get position info (without account info)
 				posInfo := g.executor.GetPositionOnly(ctx, sym, g.stopLossManager)
 
@@ -647,7 +819,11 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	trader := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
 		g.logger.Info("🤖 交易员：正在制定交易策略...")
 
+		g.recallTradeMemory()
+		g.runReportPlugins(ctx)
 		allReports := g.state.GetAllReports()
+		g.state.SetEnsembleVotes(nil) // 清除上一轮的委员会投票 / Clear the previous round's committee votes
+		g.state.SetDecisionPath("")
 
 		// Try to use LLM for decision, fall back to simple rules if LLM fails
 		var decision string
@@ -660,10 +836,12 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 			if err != nil {
 				g.logger.Warning(fmt.Sprintf("LLM 决策失败: %v", err))
 				decision = g.makeSimpleDecision()
+				g.state.SetDecisionPath(rulesPath("LLM failed"))
 			}
 		} else {
 			g.logger.Info("OpenAI API Key 未配置，使用简单规则决策")
 			decision = g.makeSimpleDecision()
+			g.state.SetDecisionPath(rulesPath("no API key"))
 		}
 
 		g.state.SetFinalDecision(decision)
@@ -676,6 +854,34 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 		}, nil
 	})
 
+	// Risk Reviewer Lambda - DEEP_THINK_LLM approves or vetoes the trader's entries (RISK_REVIEW_ENABLED)
+	// 风控复核员 - 由 DEEP_THINK_LLM 批准或否决交易员的开仓决策（RISK_REVIEW_ENABLED）
+	riskReviewer := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		// Plugins see the decision once the review verdicts are in
+		// 复核结论确定后再通知插件
+		defer g.notifyDecisionPlugins(ctx)
+
+		g.state.SetReviews(nil)
+		if !g.config.RiskReviewEnabled || g.config.APIKey == "" || g.config.APIKey == "your_openai_key" {
+			return input, nil
+		}
+
+		decision, _ := input["decision"].(string)
+		reviews := g.reviewDecision(ctx, decision)
+		for _, symbol := range g.state.Symbols {
+			if review, ok := reviews[symbol]; ok {
+				if review.Approved {
+					g.logger.Success(fmt.Sprintf("✅ 风控复核批准 %s: %s", symbol, review.Reason))
+				} else {
+					g.logger.Warning(fmt.Sprintf("🛑 风控复核否决 %s: %s", symbol, review.Reason))
+				}
+			}
+		}
+		g.state.SetReviews(reviews)
+
+		return input, nil
+	})
+
 	// Add nodes to graph
 	if err := graph.AddLambdaNode("market_analyst", marketAnalyst); err != nil {
 		return nil, err
@@ -692,6 +898,9 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	if err := graph.AddLambdaNode("trader", trader); err != nil {
 		return nil, err
 	}
+	if err := graph.AddLambdaNode("risk_reviewer", riskReviewer); err != nil {
+		return nil, err
+	}
 
 	// Parallel execution: market_analyst and sentiment_analyst run in parallel
 	if err := graph.AddEdge(compose.START, "market_analyst"); err != nil {
@@ -719,8 +928,11 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 		return nil, err
 	}
 
-	// Trader outputs to END
-	if err := graph.AddEdge("trader", compose.END); err != nil {
+	// Trader's decision goes through the risk reviewer to END
+	if err := graph.AddEdge("trader", "risk_reviewer"); err != nil {
+		return nil, err
+	}
+	if err := graph.AddEdge("risk_reviewer", compose.END); err != nil {
 		return nil, err
 	}
 
@@ -793,7 +1005,87 @@ func (g *SimpleTradingGraph) makeSimpleDecision() string {
 
 // makeLLMDecision uses LLM to generate trading decision with JSON structured output
 // makeLLMDecision 使用 LLM 生成交易决策，使用 JSON 结构化输出
+//
+// With ENSEMBLE_MODELS set, several models vote and the committee decision is returned instead.
+// Otherwise entries above HIGH_STAKES_LEVERAGE or HIGH_STAKES_POSITION_SIZE are confirmed by re-queries.
+// 设置 ENSEMBLE_MODELS 时由多个模型投票，返回委员会决策；否则超过 HIGH_STAKES_LEVERAGE 或
+// HIGH_STAKES_POSITION_SIZE 的开仓需重新询问确认。
 func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error) {
+	// A spent monthly token budget pauses LLM calls until the next month
+	// 月度 token 预算用完后暂停 LLM 调用直到下月
+	if g.tokenBudgetExhausted() {
+		g.state.SetDecisionPath(rulesPath("monthly token budget exhausted"))
+		return g.makeSimpleDecision(), nil
+	}
+
+	// PER_SYMBOL_DECISIONS: one smaller call per symbol instead of one combined prompt (not with a committee)
+	// PER_SYMBOL_DECISIONS：每个交易对单独调用一次，代替合并的大 Prompt（委员会模式下不启用）
+	if g.config.PerSymbolDecisions && len(g.state.Symbols) > 1 && len(EnsembleModels(g.config)) <= 1 {
+		return g.makePerSymbolDecision(ctx), nil
+	}
+
+	messages := g.decisionMessages()
+
+	if models := EnsembleModels(g.config); len(models) > 1 {
+		return g.makeEnsembleDecision(ctx, models, messages), nil
+	}
+
+	// Pre-compute the conservative rule-based decision in parallel, used if the LLM exceeds the budget
+	// 并行预先计算保守的规则决策，LLM 超出时间预算时使用
+	budget := g.decisionBudget()
+	fallbackCh := make(chan string, 1)
+	if budget > 0 {
+		go func() { fallbackCh <- g.makeSimpleDecision() }()
+	}
+
+	// Retries, the secondary model and their backoff all share the budget
+	// 重试、备用模型及其等待时间共用决策预算
+	var path string
+	response, err := g.generateWithinBudget(ctx, budget, func(ctx context.Context) (*schema.Message, error) {
+		response, chainPath, err := g.generateWithFallback(ctx, messages)
+		path = chainPath
+		return response, err
+	})
+	if errors.Is(err, errDecisionBudgetExceeded) {
+		g.logger.Warning(fmt.Sprintf("⏱️  LLM 超出决策预算 %s，使用规则后备决策", budget))
+		g.state.SetDecisionPath(rulesPath("budget exceeded"))
+		return budgetFallbackDecision(<-fallbackCh, budget), nil
+	}
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("LLM 后备链全部失败，使用简单规则决策: %v", err))
+		g.state.SetDecisionPath(rulesPath("LLM failed"))
+		return g.makeSimpleDecision(), nil
+	}
+
+	g.logger.Success(fmt.Sprintf("✅ LLM 决策生成完成 (%s)", path))
+	g.state.SetDecisionPath(path)
+
+	// generateWithFallback only returns parseable answers
+	// generateWithFallback 只返回可解析的结果
+	sample, _ := parseDecisionSample(response.Content)
+
+	// Log parsed decision info
+	// 记录解析后的示例决策信息
+	g.logger.Info(fmt.Sprintf("📊 示例决策: Symbol=%s, Action=%s, Confidence=%.2f, Leverage=%d",
+		sample.Symbol, sample.Action, sample.Confidence, sample.Leverage))
+
+	// Return both JSON and formatted text for backward compatibility
+	// 为了向后兼容，返回 JSON 原文（也可以格式化为文本）
+	// TODO: 可以选择格式化为可读文本，或直接返回 JSON 供后续处理
+	// High-stakes entries are only kept when re-queries agree on direction
+	// 高风险开仓只有在重新询问后方向获多数一致时才保留
+	return g.confirmHighStakes(ctx, messages, response.Content, path), nil
+}
+
+// newDecisionModel creates the chat model used for trade decisions, returning the structured output mode it uses
+// newDecisionModel 创建用于交易决策的 ChatModel，并返回其使用的结构化输出模式
+func (g *SimpleTradingGraph) newDecisionModel(ctx context.Context, model string) (*openaiComponent.ChatModel, string, error) {
+	return g.newDecisionModelAt(ctx, g.config.BackendURL, g.config.APIKey, model)
+}
+
+// newDecisionModelAt creates the decision chat model on a specific provider
+// newDecisionModelAt 在指定服务商上创建决策 ChatModel
+func (g *SimpleTradingGraph) newDecisionModelAt(ctx context.Context, baseURL, apiKey, model string) (*openaiComponent.ChatModel, string, error) {
 	// List of backend URLs that only support JSON Object mode (not JSON Schema)
 	// 仅支持 JSON Object 模式（不支持 JSON Schema）的后端 URL 列表
 	jsonObjectModeBackends := []string{
@@ -803,7 +1095,7 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 
 	// Check if backend URL requires JSON Object mode
 	// 检查后端 URL 是否需要 JSON Object 模式
-	backendURL := strings.TrimSpace(g.config.BackendURL)
+	backendURL := strings.TrimSpace(baseURL)
 	backendURL = strings.TrimSuffix(backendURL, "/") // Remove trailing slash / 移除尾部斜杠
 
 	useJSONObjectMode := false
@@ -822,9 +1114,9 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		// 仅支持 JSON Object 模式的后端（无 schema）
 		g.logger.Info(fmt.Sprintf("检测到需要 JSON Object 模式的后端: %s", backendURL))
 		cfg = &openaiComponent.ChatModelConfig{
-			APIKey:  g.config.APIKey,
-			BaseURL: g.config.BackendURL,
-			Model:   g.config.QuickThinkLLM,
+			APIKey:  apiKey,
+			BaseURL: baseURL,
+			Model:   model,
 			// Enable basic JSON mode (compatible with DeepSeek, Qwen, etc.)
 			// 启用基础 JSON 模式（兼容 DeepSeek、Qwen 等）
 			ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
@@ -842,9 +1134,9 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		jsonSchemaObj := jsonschema.Reflect(multiDecision)
 
 		cfg = &openaiComponent.ChatModelConfig{
-			APIKey:  g.config.APIKey,
-			BaseURL: g.config.BackendURL,
-			Model:   g.config.QuickThinkLLM,
+			APIKey:  apiKey,
+			BaseURL: baseURL,
+			Model:   model,
 			// Enable JSON Schema structured output
 			// 启用 JSON Schema 结构化输出
 			ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
@@ -859,146 +1151,158 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		}
 	}
 
+	modeStr := "JSON Schema"
+	if useJSONObjectMode {
+		modeStr = "JSON Object"
+	}
+
 	// Create ChatModel
 	// 创建 ChatModel
 	chatModel, err := openaiComponent.NewChatModel(ctx, cfg)
-	if err != nil {
-		g.logger.Warning(fmt.Sprintf("LLM 初始化失败，使用简单规则决策: %v", err))
-		return g.makeSimpleDecision(), nil
+	return chatModel, modeStr, err
+}
+
+// decisionMessages builds the trader system and user prompts from all reports
+// decisionMessages 根据所有报告构建交易员的系统 Prompt 和用户 Prompt
+func (g *SimpleTradingGraph) decisionMessages() []*schema.Message {
+	return g.decisionMessagesFor(g.state.Symbols, g.decisionSessionContext())
+}
+
+// decisionSessionContext returns the session line of the user prompt with any risk guidance, logging the guidance
+// decisionSessionContext 返回用户 Prompt 中的会话信息及风控提示，并记录加入的提示
+func (g *SimpleTradingGraph) decisionSessionContext() string {
+	// Calculate trading session context
+	// 计算交易会话上下文信息
+	minutesSinceStart := int(time.Since(g.startTime).Minutes())
+	currentTime := time.Now().Format("2006-01-02 15:04:05")
+	tradeCount := g.GetTradeCount()
+
+	// Build session context info
+	// 构建会话上下文信息
+	sessionContext := sessionContextLine(g.state.Language, minutesSinceStart, currentTime, tradeCount)
+	if guidance := overtradingGuidance(g.state.Language, g.tradeFrequency); guidance != "" {
+		g.logger.Warning(fmt.Sprintf("⚠️  过度交易：最近 24 小时开仓 %d 次（上限 %d），Prompt 已加入谨慎开仓提示",
+			g.tradeFrequency.Trades, g.tradeFrequency.Max))
+		sessionContext += guidance
+	}
+	if guidance := lossStreakGuidance(g.state.Language, g.lossStreak); guidance != "" {
+		g.logger.Warning(fmt.Sprintf("📉 连亏阶梯：本时段连续亏损 %d 笔，开仓仓位系数 %.1f，Prompt 已加入提示",
+			g.lossStreak.Current, g.lossStreak.SizeMultiplier()))
+		sessionContext += guidance
+	}
+	if guidance := smallAccountGuidance(g.state.Language, g.config, g.balance); guidance != "" {
+		g.logger.Info(fmt.Sprintf("💰 小账户模式：余额 %.2f USDT < %.2f USDT，Prompt 已限制为单一持仓", g.balance, g.config.SmallAccountEquity))
+		sessionContext += guidance
 	}
+	sessionContext += pyramidingGuidance(g.state.Language, g.config)
+	return sessionContext
+}
 
-	// Prepare the prompt with all reports
-	// 准备包含所有报告的 Prompt
-	allReports := g.state.GetAllReports()
+// decisionMessagesFor builds the trader prompts with the reports (and symbol prompts) of the given symbols only
+// decisionMessagesFor 只使用指定交易对的报告（及专属 Prompt）构建交易员 Prompt
+func (g *SimpleTradingGraph) decisionMessagesFor(symbols []string, sessionContext string) []*schema.Message {
+	// Prepare the prompt with the symbols' reports
+	// 准备包含交易对报告的 Prompt
+	allReports := g.state.GetReportsFor(symbols)
 
-	// Load system prompt from file or use default
-	// 从文件加载系统 Prompt 或使用默认值
-	systemPrompt := loadPromptFromFile(g.config.TraderPromptPath, g.logger)
+	// Load the system prompt template (and per-symbol prompts) from file or use default
+	// 从文件加载系统 Prompt 模板（及交易对专属 Prompt）或使用默认值
+	systemPrompt := g.systemPromptFor(symbols)
 
-	// Build user prompt with leverage range info and K-line interval
-	// 构建包含杠杆范围信息和 K 线间隔的用户 Prompt
+	// Build user prompt with leverage range info and K-line interval (in the report language)
+	// 构建包含杠杆范围信息和 K 线间隔的用户 Prompt（使用报告语言）
+	labels := labelsFor(g.state.Language)
 	leverageInfo := ""
 	if g.config.BinanceLeverageDynamic {
-		leverageInfo = fmt.Sprintf(`
-**动态杠杆范围**: %d-%d 倍
-`, g.config.BinanceLeverageMin, g.config.BinanceLeverageMax)
+		leverageInfo = fmt.Sprintf(labels.DynamicLeverage, g.config.BinanceLeverageMin, g.config.BinanceLeverageMax)
 	} else {
-		leverageInfo = fmt.Sprintf(`
-**固定杠杆**: %d 倍（本次交易将使用固定杠杆）
-`, g.config.BinanceLeverage)
+		leverageInfo = fmt.Sprintf(labels.FixedLeverage, g.config.BinanceLeverage)
 	}
 
 	// Add K-line interval info
 	// 添加 K 线间隔信息
-	klineInfo := fmt.Sprintf(`
-**K 线数据间隔**: %s（市场报告中的技术指标基于此时间周期计算）
-**系统运行间隔**: %s（系统每隔此时间运行一次分析）
-`, g.config.CryptoTimeframe, g.config.TradingInterval)
+	klineInfo := fmt.Sprintf(labels.KlineInfo, g.config.CryptoTimeframe, g.config.TradingInterval)
 
-	// Calculate trading session context
-	// 计算交易会话上下文信息
-	minutesSinceStart := int(time.Since(g.startTime).Minutes())
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
-	tradeCount := g.GetTradeCount()
-
-	// Build session context info
-	// 构建会话上下文信息
-	sessionContext := fmt.Sprintf(`
-- 这是你开始交易的第 %d 分钟,目前的时间是：%s,你已经参与了交易 %d 次，
-`, minutesSinceStart, currentTime, tradeCount)
-
-	userPrompt := fmt.Sprintf(`%s下方我们将为您提供各种市场技术分析、加密货币状态分析，助您发掘超额收益。再下方是您当前的当前持仓信息，包括价值、业绩和持仓情况。请分析以下各种数据并给出交易决策：
+	userPrompt := fmt.Sprintf(`%s%s
 %s
 %s
 %s
 
-请给出你的分析和最终决策。`, sessionContext, leverageInfo, klineInfo, allReports)
+%s`, sessionContext, labels.PromptIntro, leverageInfo, klineInfo, allReports, labels.PromptOutro)
 
 	// Create messages
 	// 创建消息
-	messages := []*schema.Message{
+	return []*schema.Message{
 		schema.SystemMessage(systemPrompt),
 		schema.UserMessage(userPrompt),
 	}
+}
 
-	// Call LLM
-	// 调用 LLM
-	modeStr := "JSON Schema"
-	if useJSONObjectMode {
-		modeStr = "JSON Object"
-	}
-	g.logger.Info(fmt.Sprintf("🤖 正在调用 LLM 生成交易决策 (%s 模式), 使用的模型:%v", modeStr, g.config.QuickThinkLLM))
-	response, err := chatModel.Generate(ctx, messages)
-	if err != nil {
-		g.logger.Warning(fmt.Sprintf("LLM 调用失败，使用简单规则决策: %v", err))
-		return g.makeSimpleDecision(), nil
-	}
-
-	g.logger.Success("✅ LLM 决策生成完成")
-
-	// Log token usage if available
-	// 记录 token 使用情况
+// logTokenUsage logs the token usage of an LLM response if available
+// logTokenUsage 记录 LLM 响应的 token 使用情况（如有）
+func (g *SimpleTradingGraph) logTokenUsage(response *schema.Message) {
 	if response.ResponseMeta != nil && response.ResponseMeta.Usage != nil {
 		g.logger.Info(fmt.Sprintf("Token 使用: %d (输入: %d, 输出: %d)",
 			response.ResponseMeta.Usage.TotalTokens,
 			response.ResponseMeta.Usage.PromptTokens,
 			response.ResponseMeta.Usage.CompletionTokens))
 	}
+}
 
-	// Parse JSON response (support both multi-symbol map and single-object formats)
-	// 解析 JSON 响应（支持多币种映射和单对象两种格式）
-	var sample TradeDecision
-	parsed := false
-
-	cleanContent := extractJSONPayload(response.Content)
-	trimmed := strings.TrimSpace(cleanContent)
+// parseModelDecisions parses an LLM response into decisions keyed by symbol
+// parseModelDecisions 将 LLM 响应解析为按交易对索引的决策
+//
+// Both the multi-symbol map and the single-object format are accepted; a decision without a symbol
+// takes its map key.
+// 支持多币种映射和单对象两种格式；未填写 symbol 的决策使用 map 的键。
+func parseModelDecisions(content string) (map[string]TradeDecision, error) {
+	trimmed := strings.TrimSpace(extractJSONPayload(content))
 
 	// Try multi-symbol format: map[string]TradeDecision
 	// 优先尝试多币种格式：map[string]TradeDecision
 	var multi map[string]TradeDecision
 	if err := sonic.Unmarshal([]byte(trimmed), &multi); err == nil && len(multi) > 0 {
 		for sym, d := range multi {
-			sample = d
 			// If symbol field is empty, use map key as fallback
 			// 如果结构体中未填 symbol，则使用 map 的键作为回退
-			if sample.Symbol == "" {
-				sample.Symbol = sym
+			if d.Symbol == "" {
+				d.Symbol = sym
+				multi[sym] = d
 			}
-			parsed = true
-			break
-		}
-	} else {
-		// Fallback: single-object format
-		// 回退到单对象格式
-		var single TradeDecision
-		if err := sonic.Unmarshal([]byte(trimmed), &single); err == nil {
-			sample = single
-			parsed = true
 		}
+		return multi, nil
 	}
 
-	if !parsed {
-		g.logger.Warning(fmt.Sprintf("JSON 解析失败，原始响应: %s", response.Content))
-		g.logger.Warning("降级到简单规则决策")
-		return g.makeSimpleDecision(), nil
+	// Fallback: single-object format
+	// 回退到单对象格式
+	var single TradeDecision
+	if err := sonic.Unmarshal([]byte(trimmed), &single); err == nil {
+		return map[string]TradeDecision{single.Symbol: single}, nil
+	}
+
+	return nil, fmt.Errorf("JSON 解析失败")
+}
+
+// parseDecisionSample parses an LLM response and returns one decision, checking the required fields
+// parseDecisionSample 解析 LLM 响应并返回其中一个决策，同时检查必填字段
+func parseDecisionSample(content string) (TradeDecision, error) {
+	decisions, err := parseModelDecisions(content)
+	if err != nil {
+		return TradeDecision{}, err
+	}
+
+	var sample TradeDecision
+	for _, d := range decisions {
+		sample = d
+		break
 	}
 
 	// Validate required fields on sample decision
 	// 对示例决策验证必填字段
 	if strings.TrimSpace(sample.Action) == "" || strings.TrimSpace(sample.Symbol) == "" {
-		g.logger.Warning(fmt.Sprintf("LLM 返回的 JSON 缺少必填字段 (action或symbol为空)，示例: %+v", sample))
-		return g.makeSimpleDecision(), nil
+		return sample, fmt.Errorf("LLM 返回的 JSON 缺少必填字段 (action或symbol为空)，示例: %+v", sample)
 	}
-
-	// Log parsed decision info
-	// 记录解析后的示例决策信息
-	g.logger.Info(fmt.Sprintf("📊 示例决策: Symbol=%s, Action=%s, Confidence=%.2f, Leverage=%d",
-		sample.Symbol, sample.Action, sample.Confidence, sample.Leverage))
-
-	// Return both JSON and formatted text for backward compatibility
-	// 为了向后兼容，返回 JSON 原文（也可以格式化为文本）
-	// TODO: 可以选择格式化为可读文本，或直接返回 JSON 供后续处理
-	return response.Content, nil
+	return sample, nil
 }
 
 // Run executes the trading graph
@@ -1049,25 +1353,5 @@ func extractJSONPayload(content string) string {
 // formatLargeNumber formats large numbers into readable format (B/M/K)
 // formatLargeNumber 将大数字格式化为易读格式（B/M/K）
 func formatLargeNumber(value float64) string {
-	absValue := value
-	if absValue < 0 {
-		absValue = -absValue
-	}
-
-	var formatted string
-	if absValue >= 1e9 {
-		// Billions / 十亿
-		formatted = fmt.Sprintf("$%.3fB", value/1e9)
-	} else if absValue >= 1e6 {
-		// Millions / 百万
-		formatted = fmt.Sprintf("$%.3fM", value/1e6)
-	} else if absValue >= 1e3 {
-		// Thousands / 千
-		formatted = fmt.Sprintf("$%.3fK", value/1e3)
-	} else {
-		// Less than 1000 / 小于1000
-		formatted = fmt.Sprintf("$%.3f", value)
-	}
-
-	return formatted
+	return "$" + format.Compact(value, 3)
 }
@@ -0,0 +1,172 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// HighStakesSymbols returns the symbols whose entry exceeds HIGH_STAKES_LEVERAGE or HIGH_STAKES_POSITION_SIZE
+// HighStakesSymbols 返回开仓杠杆超过 HIGH_STAKES_LEVERAGE 或仓位超过 HIGH_STAKES_POSITION_SIZE 的交易对
+func HighStakesSymbols(cfg *config.Config, symbols []string, decisions map[string]TradeDecision) []string {
+	var highStakes []string
+	for _, symbol := range symbols {
+		td, ok := decisions[symbol]
+		if !ok {
+			continue
+		}
+		parsed := convertTradeDecisionToTradingDecision(&td)
+		if direction := voteDirection(parsed.Action); !parsed.Valid ||
+			(direction != executors.ActionBuy && direction != executors.ActionSell) {
+			continue
+		}
+		if (cfg.HighStakesLeverage > 0 && parsed.Leverage > cfg.HighStakesLeverage) ||
+			(cfg.HighStakesPositionSize > 0 && parsed.PositionSizePercent > cfg.HighStakesPositionSize) {
+			highStakes = append(highStakes, symbol)
+		}
+	}
+	return highStakes
+}
+
+// highStakesModels returns the model of each re-query: HIGH_STAKES_SAMPLES-1 calls cycling through
+// HIGH_STAKES_MODELS, or QUICK_THINK_LLM when unset
+// highStakesModels 返回每次重新询问使用的模型：共 HIGH_STAKES_SAMPLES-1 次，依次使用 HIGH_STAKES_MODELS，
+// 未设置时使用 QUICK_THINK_LLM
+func highStakesModels(cfg *config.Config) []string {
+	samples := cfg.HighStakesSamples
+	if samples < 2 {
+		samples = 2
+	}
+	if samples > maxEnsembleModels {
+		samples = maxEnsembleModels
+	}
+	pool := cfg.HighStakesModels
+	if len(pool) == 0 {
+		pool = []string{cfg.QuickThinkLLM}
+	}
+	models := make([]string, samples-1)
+	for i := range models {
+		models[i] = pool[i%len(pool)]
+	}
+	return models
+}
+
+// ConfirmHighStakes keeps each high-stakes decision only when a majority of the ballots agree on its direction
+// ConfirmHighStakes 只有多数选票与高风险决策方向一致时才保留该决策
+//
+// The first ballot is the original decision. A confirmed decision is kept as proposed; otherwise the
+// symbol holds. Returns the updated decisions and the votes of the high-stakes symbols.
+// 第一张选票是原决策。获得确认的决策按原样保留，否则该交易对观望。返回更新后的决策和高风险交易对的投票。
+func ConfirmHighStakes(symbols []string, decisions map[string]TradeDecision, ballots []ModelBallot) (map[string]TradeDecision, map[string][]*storage.EnsembleVote) {
+	quorum := len(ballots)/2 + 1
+	tallied, votes := TallyEnsemble(symbols, ballots, quorum)
+
+	confirmed := make(map[string]TradeDecision, len(decisions))
+	for symbol, td := range decisions {
+		confirmed[symbol] = td
+	}
+	for _, symbol := range symbols {
+		original := decisions[symbol]
+		direction := voteDirection(convertTradeDecisionToTradingDecision(&original).Action)
+
+		agreed := 0
+		counts := make(map[executors.TradeAction]int)
+		var order []executors.TradeAction
+		for _, vote := range votes[symbol] {
+			if vote.Action == "" {
+				continue
+			}
+			voted := voteDirection(executors.TradeAction(vote.Action))
+			if counts[voted] == 0 {
+				order = append(order, voted)
+			}
+			counts[voted]++
+			if voted == direction {
+				agreed++
+			}
+		}
+
+		winner := voteDirection(mapToTradeAction(strings.ToLower(tallied[symbol].Action)))
+		if winner == direction && agreed >= quorum {
+			original.Reasoning = fmt.Sprintf("[自洽确认 %d/%d 票 %s] %s", agreed, len(ballots), direction, original.Reasoning)
+			confirmed[symbol] = original
+			continue
+		}
+
+		tally := make([]string, 0, len(order))
+		for _, voted := range order {
+			tally = append(tally, fmt.Sprintf("%s×%d", voted, counts[voted]))
+		}
+		confirmed[symbol] = TradeDecision{
+			Symbol: symbol,
+			Action: string(executors.ActionHold),
+			Reasoning: fmt.Sprintf("高风险决策 %s（杠杆 %dx，仓位 %.0f%%）未获多数确认（%s，需 %d 票同向），本轮观望",
+				original.Action, original.Leverage, original.PositionSize, strings.Join(tally, ", "), quorum),
+		}
+	}
+	return confirmed, votes
+}
+
+// confirmHighStakes re-queries high-stakes entries and returns the decision JSON with unconfirmed entries on hold
+// confirmHighStakes 对高风险开仓重新询问，返回未获确认的开仓改为观望后的决策 JSON
+//
+// The votes are kept in the agent state and saved with the sessions, as in ensemble mode.
+// 投票结果保存在 AgentState 中，与委员会模式一样随会话一起保存。
+func (g *SimpleTradingGraph) confirmHighStakes(ctx context.Context, messages []*schema.Message, content, path string) string {
+	decisions, err := parseModelDecisions(content)
+	if err != nil {
+		return content
+	}
+	symbols := HighStakesSymbols(g.config, g.state.Symbols, decisions)
+	if len(symbols) == 0 {
+		return content
+	}
+
+	models := highStakesModels(g.config)
+	g.logger.Info(fmt.Sprintf("🗳️  高风险决策 (%s)，重新询问 %d 次 (%s) 确认方向",
+		strings.Join(symbols, ", "), len(models), strings.Join(models, ", ")))
+
+	budget := g.decisionBudget()
+	ballots := make([]ModelBallot, len(models)+1)
+	ballots[0] = ModelBallot{Model: path, Decisions: decisions}
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			ballots[i+1] = g.castBallot(ctx, model, messages, budget)
+		}(i, model)
+	}
+	wg.Wait()
+
+	for _, ballot := range ballots[1:] {
+		if ballot.Err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  确认模型 %s 弃权: %v", ballot.Model, ballot.Err))
+		}
+	}
+
+	confirmed, votes := ConfirmHighStakes(symbols, decisions, ballots)
+	g.state.SetEnsembleVotes(votes)
+	for _, symbol := range symbols {
+		decision := confirmed[symbol]
+		if decision.Action == string(executors.ActionHold) {
+			g.logger.Warning(fmt.Sprintf("🛑 【%s】%s", symbol, decision.Reasoning))
+		} else {
+			g.logger.Success(fmt.Sprintf("✅ 【%s】高风险决策获多数确认: %s", symbol, decision.Action))
+		}
+	}
+
+	confirmedContent, err := json.MarshalIndent(confirmed, "", "  ")
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("确认后的决策序列化失败，高风险开仓改为观望: %v", err))
+		return g.makeSimpleDecision()
+	}
+	return string(confirmedContent)
+}
@@ -0,0 +1,94 @@
+package agents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestHighStakesSymbols tests that only entries above the leverage or position size threshold need confirmation
+// TestHighStakesSymbols 测试只有杠杆或仓位超过阈值的开仓需要确认
+func TestHighStakesSymbols(t *testing.T) {
+	cfg := &config.Config{HighStakesLeverage: 10, HighStakesPositionSize: 30}
+	symbols := []string{"BTC/USDT", "ETH/USDT", "SOL/USDT", "BNB/USDT", "XRP/USDT"}
+	decisions := map[string]TradeDecision{
+		"BTC/USDT": {Symbol: "BTC/USDT", Action: "BUY", Leverage: 15, PositionSize: 10},
+		"ETH/USDT": {Symbol: "ETH/USDT", Action: "SELL_STOP", Leverage: 5, PositionSize: 40},
+		"SOL/USDT": {Symbol: "SOL/USDT", Action: "BUY", Leverage: 10, PositionSize: 30},
+		"BNB/USDT": {Symbol: "BNB/USDT", Action: "CLOSE_LONG", Leverage: 20, PositionSize: 100},
+	}
+
+	got := HighStakesSymbols(cfg, symbols, decisions)
+	if strings.Join(got, ",") != "BTC/USDT,ETH/USDT" {
+		t.Errorf("Expected BTC and ETH to need confirmation, got %v", got)
+	}
+
+	if got := HighStakesSymbols(&config.Config{}, symbols, decisions); len(got) != 0 {
+		t.Errorf("Expected no confirmation without thresholds, got %v", got)
+	}
+}
+
+// TestHighStakesModels tests the re-query count and the model rotation
+// TestHighStakesModels 测试重新询问次数和模型轮换
+func TestHighStakesModels(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+		models  []string
+		want    string
+	}{
+		{"same model", 3, nil, "quick,quick"},
+		{"two samples", 2, nil, "quick"},
+		{"other model", 3, []string{"deep"}, "deep,deep"},
+		{"across models", 3, []string{"deep", "other"}, "deep,other"},
+		{"capped", 5, nil, "quick,quick"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{QuickThinkLLM: "quick", HighStakesSamples: tt.samples, HighStakesModels: tt.models}
+		if got := strings.Join(highStakesModels(cfg), ","); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestConfirmHighStakes tests that a high-stakes entry executes only with a majority on its direction
+// TestConfirmHighStakes 测试高风险开仓只有在同方向获多数票时才执行
+func TestConfirmHighStakes(t *testing.T) {
+	decisions := map[string]TradeDecision{
+		"BTC/USDT": {Symbol: "BTC/USDT", Action: "BUY", Confidence: 0.8, Leverage: 15, PositionSize: 20, Reasoning: "突破"},
+		"ETH/USDT": {Symbol: "ETH/USDT", Action: "SELL", Confidence: 0.8, Leverage: 20, PositionSize: 40, Reasoning: "走弱"},
+		"SOL/USDT": {Symbol: "SOL/USDT", Action: "HOLD", Reasoning: "观望"},
+	}
+	ballots := []ModelBallot{
+		{Model: "quick", Decisions: decisions},
+		{Model: "quick", Decisions: map[string]TradeDecision{
+			"BTC/USDT": {Symbol: "BTC/USDT", Action: "BUY_STOP", Confidence: 0.9, Leverage: 5},
+			"ETH/USDT": {Symbol: "ETH/USDT", Action: "HOLD"},
+		}},
+		{Model: "quick", Err: errors.New("超出决策预算 2m0s")},
+	}
+
+	confirmed, votes := ConfirmHighStakes([]string{"BTC/USDT", "ETH/USDT"}, decisions, ballots)
+
+	// BUY_STOP 与 BUY 同向：按原决策执行
+	btc := confirmed["BTC/USDT"]
+	if btc.Action != "BUY" || btc.Leverage != 15 || !strings.Contains(btc.Reasoning, "2/3") {
+		t.Errorf("Expected the original BTC entry to be confirmed, got %+v", btc)
+	}
+	eth := confirmed["ETH/USDT"]
+	if eth.Action != "HOLD" || !strings.Contains(eth.Reasoning, "未获多数确认") {
+		t.Errorf("Expected the unconfirmed ETH entry to hold, got %+v", eth)
+	}
+	if confirmed["SOL/USDT"].Reasoning != "观望" {
+		t.Errorf("Expected other symbols to be kept, got %+v", confirmed["SOL/USDT"])
+	}
+
+	if len(votes["BTC/USDT"]) != 3 || votes["BTC/USDT"][2].Error == "" {
+		t.Errorf("Expected every vote to be recorded, got %+v", votes["BTC/USDT"])
+	}
+	if _, ok := votes["SOL/USDT"]; ok {
+		t.Error("Expected no votes for symbols that needed no confirmation")
+	}
+}
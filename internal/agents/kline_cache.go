@@ -0,0 +1,13 @@
+package agents
+
+import (
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// CacheKlines serves candles from the database and only fetches the missing tail (KLINE_CACHE_ENABLED)
+// CacheKlines 从数据库读取 K 线，只获取缺失的最新部分（KLINE_CACHE_ENABLED）
+func (g *SimpleTradingGraph) CacheKlines(db *storage.Storage) {
+	if g.config.KlineCacheEnabled {
+		g.klineCache = db
+	}
+}
@@ -0,0 +1,156 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// llmRetryBackoffMax caps the wait between two retries of the same model
+// llmRetryBackoffMax 是同一模型两次重试之间的最长等待时间
+const llmRetryBackoffMax = 30 * time.Second
+
+// llmStep is one model in the fallback chain
+// llmStep 是后备链中的一个模型
+type llmStep struct {
+	Role     string // primary 或 fallback / primary or fallback
+	Model    string // 模型名称 / Model name
+	BaseURL  string // API 地址 / API URL
+	APIKey   string // API Key
+	Attempts int    // 最多尝试次数（含首次）/ Max attempts including the first
+}
+
+// fallbackChain returns the models tried in order before the rule-based decision
+// fallbackChain 返回在规则决策之前依次尝试的模型
+func fallbackChain(cfg *config.Config) []llmStep {
+	retries := cfg.LLMMaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	chain := []llmStep{{
+		Role:     "primary",
+		Model:    cfg.QuickThinkLLM,
+		BaseURL:  cfg.BackendURL,
+		APIKey:   cfg.APIKey,
+		Attempts: retries + 1,
+	}}
+
+	if model := strings.TrimSpace(cfg.LLMFallbackModel); model != "" {
+		step := llmStep{Role: "fallback", Model: model, BaseURL: cfg.LLMFallbackBackendURL, APIKey: cfg.LLMFallbackAPIKey, Attempts: 1}
+		if step.BaseURL == "" {
+			step.BaseURL = cfg.BackendURL
+		}
+		if step.APIKey == "" {
+			step.APIKey = cfg.APIKey
+		}
+		chain = append(chain, step)
+	}
+	return chain
+}
+
+// retryDelay returns the wait before the given retry (1 = first retry), doubling from LLM_RETRY_BACKOFF
+// retryDelay 返回第 retry 次重试前的等待时间（1 为第一次重试），从 LLM_RETRY_BACKOFF 起逐次翻倍
+func retryDelay(cfg *config.Config, retry int) time.Duration {
+	if cfg.LLMRetryBackoff <= 0 || retry <= 0 {
+		return 0
+	}
+	delay := time.Duration(cfg.LLMRetryBackoff) * time.Second
+	for i := 1; i < retry && delay < llmRetryBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > llmRetryBackoffMax {
+		delay = llmRetryBackoffMax
+	}
+	return delay
+}
+
+// stepPath describes the model and attempt that produced a decision, as stored with the session
+// stepPath 描述产生决策的模型和尝试次数，随会话保存
+func stepPath(cfg *config.Config, step llmStep, attempt int) string {
+	path := fmt.Sprintf("%s %s", step.Role, step.Model)
+	if strings.TrimSuffix(step.BaseURL, "/") != strings.TrimSuffix(cfg.BackendURL, "/") {
+		path += " @ " + step.BaseURL
+	}
+	if attempt > 1 {
+		path += fmt.Sprintf(" (retry %d)", attempt-1)
+	}
+	return path
+}
+
+// rulesPath describes a rule-based decision and why the LLM wasn't used
+// rulesPath 描述规则决策及未使用 LLM 的原因
+func rulesPath(reason string) string {
+	return fmt.Sprintf("rules (%s)", reason)
+}
+
+// LLMFailed reports whether a decision path is a rule-based fallback caused by failing LLM calls
+// LLMFailed 返回决策路径是否为 LLM 调用失败导致的规则后备
+//
+// Rules used on purpose (no API key, spent monthly budget) don't count as failures.
+// 主动使用规则（未配置 API Key、月度预算用完）不算失败。
+func LLMFailed(path string) bool {
+	switch path {
+	case rulesPath("LLM failed"), rulesPath("budget exceeded"), rulesPath("ensemble failed"):
+		return true
+	}
+	return false
+}
+
+// generateWithFallback walks the fallback chain and returns the first parseable answer with the path that produced it
+// generateWithFallback 依次尝试后备链中的模型，返回第一个可解析的结果及其来源路径
+//
+// Each model is retried with backoff; an answer that can't be parsed counts as a failed attempt.
+// When every model fails, the last error is returned and the caller falls back to rules.
+// 每个模型按退避时间重试；无法解析的结果视为失败。所有模型都失败时返回最后一个错误，由调用方使用规则决策。
+func (g *SimpleTradingGraph) generateWithFallback(ctx context.Context, messages []*schema.Message) (*schema.Message, string, error) {
+	var lastErr error
+	for _, step := range fallbackChain(g.config) {
+		if step.Role != "primary" {
+			g.logger.Warning(fmt.Sprintf("🔁 主模型失败，切换到备用模型 %s (%s)", step.Model, step.BaseURL))
+		}
+
+		chatModel, modeStr, err := g.newDecisionModelAt(ctx, step.BaseURL, step.APIKey, step.Model)
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  LLM %s 初始化失败: %v", step.Model, err))
+			lastErr = err
+			continue
+		}
+
+		for attempt := 1; attempt <= step.Attempts; attempt++ {
+			if attempt > 1 {
+				delay := retryDelay(g.config, attempt-1)
+				g.logger.Warning(fmt.Sprintf("⚠️  LLM %s 调用失败 (尝试 %d/%d): %v，等待 %s 后重试...",
+					step.Model, attempt-1, step.Attempts, lastErr, delay))
+				select {
+				case <-ctx.Done():
+					return nil, "", ctx.Err()
+				case <-time.After(delay):
+				}
+			}
+
+			g.logger.Info(fmt.Sprintf("🤖 正在调用 LLM 生成交易决策 (%s 模式), 使用的模型:%v", modeStr, step.Model))
+			response, err := g.generateDecision(ctx, chatModel, step.Model, messages)
+			if err == nil {
+				if _, err = parseDecisionSample(response.Content); err != nil {
+					g.logger.Warning(fmt.Sprintf("%v，原始响应: %s", err, response.Content))
+				}
+			}
+			if err == nil {
+				return response, stepPath(g.config, step, attempt), nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, "", ctx.Err()
+			}
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no LLM configured")
+	}
+	return nil, "", lastErr
+}
@@ -0,0 +1,88 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestFallbackChain tests the order of the fallback chain and the secondary model's defaults
+// TestFallbackChain 测试后备链的顺序以及备用模型的默认值
+func TestFallbackChain(t *testing.T) {
+	cfg := &config.Config{QuickThinkLLM: "gpt-4o-mini", BackendURL: "https://api.openai.com/v1", APIKey: "sk-primary", LLMMaxRetries: 2}
+
+	chain := fallbackChain(cfg)
+	if len(chain) != 1 || chain[0].Role != "primary" || chain[0].Attempts != 3 {
+		t.Fatalf("Expected only the primary model with 3 attempts, got %+v", chain)
+	}
+
+	cfg.LLMFallbackModel = "deepseek-chat"
+	chain = fallbackChain(cfg)
+	if len(chain) != 2 || chain[1].BaseURL != cfg.BackendURL || chain[1].APIKey != "sk-primary" || chain[1].Attempts != 1 {
+		t.Fatalf("Expected the secondary model to default to the primary provider, got %+v", chain)
+	}
+	if got := stepPath(cfg, chain[1], 1); got != "fallback deepseek-chat" {
+		t.Errorf("stepPath = %q, want %q", got, "fallback deepseek-chat")
+	}
+
+	cfg.LLMFallbackBackendURL = "https://api.deepseek.com"
+	cfg.LLMFallbackAPIKey = "sk-secondary"
+	chain = fallbackChain(cfg)
+	if chain[1].BaseURL != "https://api.deepseek.com" || chain[1].APIKey != "sk-secondary" {
+		t.Errorf("Expected the secondary provider, got %+v", chain[1])
+	}
+	if got := stepPath(cfg, chain[1], 1); got != "fallback deepseek-chat @ https://api.deepseek.com" {
+		t.Errorf("stepPath = %q", got)
+	}
+	if got := stepPath(cfg, chain[0], 3); got != "primary gpt-4o-mini (retry 2)" {
+		t.Errorf("stepPath = %q, want %q", got, "primary gpt-4o-mini (retry 2)")
+	}
+}
+
+// TestRetryDelay tests that the backoff doubles and is capped
+// TestRetryDelay 测试退避时间逐次翻倍并有上限
+func TestRetryDelay(t *testing.T) {
+	cfg := &config.Config{LLMRetryBackoff: 2}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{10, llmRetryBackoffMax},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(cfg, tt.retry); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.retry, got, tt.want)
+		}
+	}
+
+	if got := retryDelay(&config.Config{}, 1); got != 0 {
+		t.Errorf("Expected no backoff when LLM_RETRY_BACKOFF is 0, got %v", got)
+	}
+}
+
+// TestLLMFailed tests which decision paths count as LLM failures
+// TestLLMFailed 测试哪些决策路径算作 LLM 失败
+func TestLLMFailed(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"primary gpt-4o-mini", false},
+		{"fallback deepseek-chat (retry 1)", false},
+		{rulesPath("LLM failed"), true},
+		{rulesPath("budget exceeded"), true},
+		{rulesPath("ensemble failed"), true},
+		{rulesPath("no API key"), false},
+		{rulesPath("monthly token budget exhausted"), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := LLMFailed(tt.path); got != tt.want {
+			t.Errorf("LLMFailed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package agents
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TrackLLMUsage stores the token usage and cost of every LLM call against a batch,
+// and enforces LLM_MONTHLY_TOKEN_BUDGET from the stored usage
+// TrackLLMUsage 按批次保存每次 LLM 调用的 token 用量和费用，并根据已保存的用量执行 LLM_MONTHLY_TOKEN_BUDGET
+func (g *SimpleTradingGraph) TrackLLMUsage(db *storage.Storage, batchID string) {
+	g.llmUsage = func(model string, promptTokens, completionTokens int) {
+		cost := g.config.LLMCost(model, promptTokens, completionTokens)
+		if err := db.AddLLMUsage(batchID, model, promptTokens, completionTokens, cost); err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  保存 LLM 用量失败: %v", err))
+		}
+	}
+	g.monthTokens = func() (int, error) {
+		return db.GetLLMTokensSince(storage.MonthStart(time.Now()))
+	}
+}
+
+// recordTokenUsage logs the token usage of a response and stores it when usage tracking is enabled
+// recordTokenUsage 记录响应的 token 用量日志，启用用量统计时保存到数据库
+func (g *SimpleTradingGraph) recordTokenUsage(model string, response *schema.Message) {
+	if response == nil || response.ResponseMeta == nil || response.ResponseMeta.Usage == nil {
+		return
+	}
+	g.logTokenUsage(response)
+	if g.llmUsage != nil {
+		usage := response.ResponseMeta.Usage
+		g.llmUsage(model, usage.PromptTokens, usage.CompletionTokens)
+	}
+}
+
+// tokenBudgetExhausted reports whether this month's tokens reached LLM_MONTHLY_TOKEN_BUDGET
+// tokenBudgetExhausted 判断本月 token 用量是否已达到 LLM_MONTHLY_TOKEN_BUDGET
+//
+// The budget isn't enforced when the usage can't be read, so a storage error doesn't stop trading.
+// 无法读取用量时不限制，避免数据库错误导致停止交易。
+func (g *SimpleTradingGraph) tokenBudgetExhausted() bool {
+	if g.config == nil || g.config.LLMMonthlyTokenBudget <= 0 || g.monthTokens == nil {
+		return false
+	}
+	used, err := g.monthTokens()
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  读取本月 LLM 用量失败，本次不检查 token 预算: %v", err))
+		return false
+	}
+	if used < g.config.LLMMonthlyTokenBudget {
+		return false
+	}
+	g.logger.Warning(fmt.Sprintf("💸 本月 LLM token 预算已用完 (%d / %d)，暂停 LLM 调用直到下月",
+		used, g.config.LLMMonthlyTokenBudget))
+	return true
+}
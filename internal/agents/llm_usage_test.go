@@ -0,0 +1,79 @@
+package agents
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestRecordTokenUsage tests that responses with usage are passed on with their model
+// TestRecordTokenUsage 测试带用量的响应连同模型一起被记录
+func TestRecordTokenUsage(t *testing.T) {
+	g := &SimpleTradingGraph{config: &config.Config{}, logger: logger.NewColorLogger(false)}
+
+	type call struct {
+		model              string
+		prompt, completion int
+	}
+	var calls []call
+	g.llmUsage = func(model string, promptTokens, completionTokens int) {
+		calls = append(calls, call{model, promptTokens, completionTokens})
+	}
+
+	g.recordTokenUsage("gpt-4o-mini", &schema.Message{ResponseMeta: &schema.ResponseMeta{
+		Usage: &schema.TokenUsage{PromptTokens: 1200, CompletionTokens: 300, TotalTokens: 1500},
+	}})
+	// Responses without usage (e.g. a provider that doesn't report it) are skipped
+	// 没有用量的响应（例如服务商不返回用量）被跳过
+	g.recordTokenUsage("gpt-4o-mini", &schema.Message{Content: "{}"})
+	g.recordTokenUsage("gpt-4o-mini", nil)
+
+	if len(calls) != 1 || calls[0] != (call{"gpt-4o-mini", 1200, 300}) {
+		t.Errorf("Unexpected recorded usage: %+v", calls)
+	}
+}
+
+// TestTokenBudgetExhausted tests the monthly token budget check
+// TestTokenBudgetExhausted 测试月度 token 预算检查
+func TestTokenBudgetExhausted(t *testing.T) {
+	used, readErr := 0, error(nil)
+	g := &SimpleTradingGraph{
+		config:      &config.Config{LLMMonthlyTokenBudget: 10000},
+		logger:      logger.NewColorLogger(false),
+		monthTokens: func() (int, error) { return used, readErr },
+	}
+
+	tests := []struct {
+		name   string
+		used   int
+		err    error
+		budget int
+		want   bool
+	}{
+		{"below budget", 9999, nil, 10000, false},
+		{"budget reached", 10000, nil, 10000, true},
+		{"over budget", 12000, nil, 10000, true},
+		{"no budget", 12000, nil, 0, false},
+		{"usage unreadable", 12000, errors.New("database is locked"), 10000, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, readErr = tt.used, tt.err
+			g.config.LLMMonthlyTokenBudget = tt.budget
+			if got := g.tokenBudgetExhausted(); got != tt.want {
+				t.Errorf("tokenBudgetExhausted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Not tracked: never exhausted
+	// 未启用统计时不限制
+	g.monthTokens = nil
+	g.config.LLMMonthlyTokenBudget = 1
+	if g.tokenBudgetExhausted() {
+		t.Error("Expected no limit without usage tracking")
+	}
+}
@@ -0,0 +1,26 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/risk"
+)
+
+// SetLossStreak passes the intraday loss-streak ladder to the trader prompt (nil = not checked)
+// SetLossStreak 将日内连亏阶梯状态传给交易员 Prompt（nil 表示未检查）
+func (g *SimpleTradingGraph) SetLossStreak(streak *risk.LossStreak) {
+	g.lossStreak = streak
+}
+
+// lossStreakGuidance returns the prompt guidance of the current ladder step (empty below the first step)
+// lossStreakGuidance 返回当前阶梯级别的 Prompt 提示（未达到第一级时为空）
+func lossStreakGuidance(language string, streak *risk.LossStreak) string {
+	labels := labelsFor(language)
+	switch {
+	case streak.Halted():
+		return fmt.Sprintf(labels.LossStreakHalted, streak.Longest, streak.SessionEnd().Local().Format("2006-01-02 15:04"))
+	case streak.Reduced():
+		return fmt.Sprintf(labels.LossStreakHalved, streak.Current, streak.Reduce)
+	}
+	return ""
+}
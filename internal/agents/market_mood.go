@@ -0,0 +1,21 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// moodReport returns the Fear & Greed Index and on-chain metrics of a symbol for the sentiment report
+// moodReport 返回交易对的恐慌贪婪指数和链上指标（写入情绪报告），未启用时返回 ""
+func (g *SimpleTradingGraph) moodReport(ctx context.Context, mood *dataflows.MarketMood, symbol string) string {
+	if !mood.Enabled() {
+		return ""
+	}
+	snapshot := mood.Snapshot(ctx, symbol)
+	for _, err := range snapshot.Errors {
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s 情绪指标获取失败: %s", symbol, err))
+	}
+	return dataflows.FormatMoodSnapshot(snapshot)
+}
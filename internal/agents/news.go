@@ -0,0 +1,22 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// newsReport returns the headline digest of a symbol for the sentiment report, or "" without news providers
+// newsReport 返回交易对的新闻头条摘要（写入情绪报告），未配置新闻源时返回 ""
+func (g *SimpleTradingGraph) newsReport(ctx context.Context, news *dataflows.NewsFeed, symbol string) string {
+	if !news.Enabled() {
+		return ""
+	}
+	digest := news.Digest(ctx, symbol)
+	for _, err := range digest.Errors {
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s 新闻获取失败: %s", symbol, err))
+	}
+	g.logger.Info(fmt.Sprintf("  📰 %s 获取到 %d 条新闻头条", symbol, len(digest.Headlines)))
+	return dataflows.FormatNewsDigest(digest)
+}
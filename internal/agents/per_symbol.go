@@ -0,0 +1,174 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+// symbolDecision is the outcome of one per-symbol LLM call
+// symbolDecision 是单个交易对 LLM 调用的结果
+type symbolDecision struct {
+	Decision TradeDecision
+	Path     string // 决策来源路径（失败时为空）/ How the decision was produced (empty on failure)
+	Err      error
+}
+
+// pickSymbolDecision returns the decision for symbol from a per-symbol answer
+// pickSymbolDecision 从单个交易对的回答中取出该交易对的决策
+//
+// The model only saw one symbol, so a lone decision under another key (e.g. BTCUSDT for BTC/USDT) is taken as it.
+// 模型只看到一个交易对，因此键名不同的唯一决策（如 BTC/USDT 写成 BTCUSDT）也视为该交易对的决策。
+func pickSymbolDecision(symbol string, decisions map[string]TradeDecision) (TradeDecision, bool) {
+	if d, ok := decisions[symbol]; ok {
+		d.Symbol = symbol
+		return d, true
+	}
+	if len(decisions) == 1 {
+		for _, d := range decisions {
+			d.Symbol = symbol
+			return d, true
+		}
+	}
+	return TradeDecision{}, false
+}
+
+// mergeSymbolDecisions merges the per-symbol results into the decisions map; failed symbols hold
+// mergeSymbolDecisions 将各交易对的结果合并为决策映射，失败的交易对观望
+//
+// Returns nil when every call failed, so the caller can fall back to rules.
+// 所有调用都失败时返回 nil，由调用方使用规则决策。
+func mergeSymbolDecisions(symbols []string, results []symbolDecision) map[string]TradeDecision {
+	decisions := make(map[string]TradeDecision, len(symbols))
+	usable := 0
+	for i, symbol := range symbols {
+		result := results[i]
+		if result.Err != nil {
+			decisions[symbol] = TradeDecision{
+				Symbol:    symbol,
+				Action:    string(executors.ActionHold),
+				Reasoning: fmt.Sprintf("该交易对 LLM 决策失败（%v），本轮观望", result.Err),
+			}
+			continue
+		}
+		usable++
+		decisions[symbol] = result.Decision
+	}
+	if usable == 0 {
+		return nil
+	}
+	return decisions
+}
+
+// decideSymbol asks the LLM for one symbol's decision, giving up at the shared deadline
+// decideSymbol 请求单个交易对的 LLM 决策，到达共同截止时间后放弃
+func (g *SimpleTradingGraph) decideSymbol(ctx context.Context, symbol, sessionContext string, deadline time.Time) symbolDecision {
+	var budget time.Duration
+	if !deadline.IsZero() {
+		if budget = time.Until(deadline); budget <= 0 {
+			return symbolDecision{Err: errDecisionBudgetExceeded}
+		}
+	}
+
+	messages := g.decisionMessagesFor([]string{symbol}, sessionContext)
+	var path string
+	response, err := g.generateWithinBudget(ctx, budget, func(ctx context.Context) (*schema.Message, error) {
+		response, chainPath, err := g.generateWithFallback(ctx, messages)
+		path = chainPath
+		return response, err
+	})
+	if err != nil {
+		return symbolDecision{Err: err}
+	}
+
+	decisions, err := parseModelDecisions(response.Content)
+	if err != nil {
+		return symbolDecision{Err: err}
+	}
+	decision, ok := pickSymbolDecision(symbol, decisions)
+	if !ok {
+		return symbolDecision{Err: errors.New("回答中没有该交易对的决策")}
+	}
+	return symbolDecision{Decision: decision, Path: path}
+}
+
+// makePerSymbolDecision fans out one LLM call per symbol (at most PER_SYMBOL_CONCURRENCY at a time)
+// and returns the merged decisions as JSON
+// makePerSymbolDecision 为每个交易对单独调用一次 LLM（同时最多 PER_SYMBOL_CONCURRENCY 个），返回合并后的决策 JSON
+//
+// Each prompt carries the account overview and only that symbol's reports, so the prompt no longer grows
+// with the number of symbols. LLM_DECISION_BUDGET covers all calls together. High-stakes entries are
+// confirmed with the combined prompt afterwards.
+// 每个 Prompt 只包含账户总览和该交易对的报告，Prompt 长度不再随交易对数量增长。LLM_DECISION_BUDGET 覆盖全部调用；
+// 高风险开仓之后使用合并的 Prompt 确认。
+func (g *SimpleTradingGraph) makePerSymbolDecision(ctx context.Context) string {
+	symbols := g.state.Symbols
+	concurrency := max(g.config.PerSymbolConcurrency, 1)
+	g.logger.Info(fmt.Sprintf("🔀 按交易对决策: %d 个交易对，并发 %d", len(symbols), concurrency))
+
+	var deadline time.Time
+	budget := g.decisionBudget()
+	if budget > 0 {
+		deadline = time.Now().Add(budget)
+	}
+
+	sessionContext := g.decisionSessionContext()
+	results := make([]symbolDecision, len(symbols))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = g.decideSymbol(ctx, symbol, sessionContext, deadline)
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	pathCounts := make(map[string]int)
+	for i, symbol := range symbols {
+		if results[i].Err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  【%s】LLM 决策失败，本轮观望: %v", symbol, results[i].Err))
+			continue
+		}
+		pathCounts[results[i].Path]++
+	}
+
+	decisions := mergeSymbolDecisions(symbols, results)
+	if decisions == nil {
+		g.logger.Warning("所有交易对的 LLM 决策均失败，使用简单规则决策")
+		g.state.SetDecisionPath(rulesPath("LLM failed"))
+		return g.makeSimpleDecision()
+	}
+
+	content, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("按交易对决策序列化失败，使用简单规则决策: %v", err))
+		g.state.SetDecisionPath(rulesPath("LLM failed"))
+		return g.makeSimpleDecision()
+	}
+
+	paths := make([]string, 0, len(pathCounts))
+	for path, count := range pathCounts {
+		paths = append(paths, fmt.Sprintf("%s×%d", path, count))
+	}
+	sort.Strings(paths)
+	path := "per-symbol " + strings.Join(paths, ", ")
+	g.state.SetDecisionPath(path)
+	g.logger.Success(fmt.Sprintf("✅ 按交易对决策生成完成 (%s)", path))
+
+	if len(HighStakesSymbols(g.config, symbols, decisions)) == 0 {
+		return string(content)
+	}
+	return g.confirmHighStakes(ctx, g.decisionMessagesFor(symbols, sessionContext), string(content), path)
+}
@@ -0,0 +1,62 @@
+package agents
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestPickSymbolDecision tests the exact key, a lone decision under another key and a missing symbol
+// TestPickSymbolDecision 测试精确匹配、键名不同的唯一决策以及缺失的交易对
+func TestPickSymbolDecision(t *testing.T) {
+	d, ok := pickSymbolDecision("BTC/USDT", map[string]TradeDecision{"BTC/USDT": {Action: "BUY"}})
+	if !ok || d.Action != "BUY" || d.Symbol != "BTC/USDT" {
+		t.Errorf("Expected the exact match, got %+v (%v)", d, ok)
+	}
+
+	d, ok = pickSymbolDecision("BTC/USDT", map[string]TradeDecision{"BTCUSDT": {Symbol: "BTCUSDT", Action: "SELL"}})
+	if !ok || d.Action != "SELL" || d.Symbol != "BTC/USDT" {
+		t.Errorf("Expected the lone decision renamed, got %+v (%v)", d, ok)
+	}
+
+	if _, ok := pickSymbolDecision("BTC/USDT", map[string]TradeDecision{"ETH/USDT": {}, "SOL/USDT": {}}); ok {
+		t.Error("Expected no decision when several other symbols are answered")
+	}
+}
+
+// TestMergeSymbolDecisions tests that failed symbols hold and an all-failed round returns nil
+// TestMergeSymbolDecisions 测试失败的交易对观望，全部失败时返回 nil
+func TestMergeSymbolDecisions(t *testing.T) {
+	symbols := []string{"BTC/USDT", "ETH/USDT"}
+	decisions := mergeSymbolDecisions(symbols, []symbolDecision{
+		{Decision: TradeDecision{Symbol: "BTC/USDT", Action: "BUY"}, Path: "primary"},
+		{Err: errors.New("timeout")},
+	})
+	if decisions["BTC/USDT"].Action != "BUY" {
+		t.Errorf("Expected BTC/USDT to keep its decision, got %+v", decisions["BTC/USDT"])
+	}
+	if eth := decisions["ETH/USDT"]; eth.Action != "HOLD" || !strings.Contains(eth.Reasoning, "timeout") {
+		t.Errorf("Expected ETH/USDT to hold with the error, got %+v", eth)
+	}
+
+	if mergeSymbolDecisions(symbols, []symbolDecision{{Err: errors.New("a")}, {Err: errors.New("b")}}) != nil {
+		t.Error("Expected nil when every call failed")
+	}
+}
+
+// TestGetReportsFor tests that only the requested symbols are included with the account overview
+// TestGetReportsFor 测试只包含指定交易对的报告以及账户总览
+func TestGetReportsFor(t *testing.T) {
+	state := NewAgentState([]string{"BTC/USDT", "ETH/USDT"}, "1h")
+	state.SetAccountInfo("balance 1000")
+	state.SetMarketReport("BTC/USDT", "btc market")
+	state.SetMarketReport("ETH/USDT", "eth market")
+
+	report := state.GetReportsFor([]string{"ETH/USDT"})
+	if !strings.Contains(report, "balance 1000") || !strings.Contains(report, "eth market") || strings.Contains(report, "btc market") {
+		t.Errorf("Unexpected per-symbol report:\n%s", report)
+	}
+	if all := state.GetAllReports(); !strings.Contains(all, "btc market") || !strings.Contains(all, "eth market") {
+		t.Errorf("Expected every symbol in the combined report:\n%s", all)
+	}
+}
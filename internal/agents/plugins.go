@@ -0,0 +1,56 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/plugins"
+)
+
+// runReportPlugins passes the built reports to the OnReportBuilt hooks and keeps the context they add for the prompt
+// runReportPlugins 将生成的报告交给 OnReportBuilt 钩子，并保存钩子追加到 Prompt 的补充信息
+func (g *SimpleTradingGraph) runReportPlugins(ctx context.Context) {
+	g.state.mu.Lock()
+	g.state.PluginContext = "" // 清除上一轮的补充信息 / Clear the previous round's context
+	g.state.mu.Unlock()
+
+	event := &plugins.ReportEvent{
+		Symbols:   g.state.Symbols,
+		Timeframe: g.state.Timeframe,
+		Reports:   g.state.GetAllReports(),
+	}
+	if err := plugins.ReportBuilt(ctx, event); err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  插件处理报告失败: %v", err))
+	}
+	if len(event.Context) == 0 {
+		return
+	}
+
+	g.state.mu.Lock()
+	g.state.PluginContext = strings.Join(event.Context, "\n")
+	g.state.mu.Unlock()
+	g.logger.Info(fmt.Sprintf("🔌 插件追加了 %d 条补充信息", len(event.Context)))
+}
+
+// notifyDecisionPlugins passes the final decision and the review vetoes to the OnDecision hooks
+// notifyDecisionPlugins 将最终决策和复核否决结果交给 OnDecision 钩子
+func (g *SimpleTradingGraph) notifyDecisionPlugins(ctx context.Context) {
+	g.state.mu.RLock()
+	event := &plugins.DecisionEvent{
+		Symbols:  g.state.Symbols,
+		Decision: g.state.FinalDecision,
+		Path:     g.state.DecisionPath,
+		Vetoes:   make(map[string]string),
+	}
+	for symbol, review := range g.state.Reviews {
+		if review != nil && !review.Approved {
+			event.Vetoes[symbol] = review.Reason
+		}
+	}
+	g.state.mu.RUnlock()
+
+	if err := plugins.Decision(ctx, event); err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  插件处理决策失败: %v", err))
+	}
+}
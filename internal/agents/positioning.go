@@ -0,0 +1,24 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// TrackLiquidations adds the liquidations collected by a running tracker to the crypto report
+// TrackLiquidations 将运行中的统计器收集到的爆仓数据加入加密货币报告
+func (g *SimpleTradingGraph) TrackLiquidations(tracker *dataflows.LiquidationTracker) {
+	g.liquidations = tracker
+}
+
+// positioningReport returns the long/short ratios and liquidations of a symbol (Binance format) for the crypto report
+// positioningReport 返回交易对（币安格式）的多空比和爆仓统计（写入加密货币报告）
+func (g *SimpleTradingGraph) positioningReport(ctx context.Context, marketData *dataflows.MarketData, binanceSymbol string) string {
+	snapshot := marketData.Positioning(ctx, binanceSymbol, g.liquidations)
+	for _, err := range snapshot.Errors {
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s 多空数据获取失败: %s", binanceSymbol, err))
+	}
+	return dataflows.FormatPositioning(snapshot)
+}
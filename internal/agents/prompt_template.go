@@ -0,0 +1,179 @@
+package agents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// PromptData is the data available to trader prompt templates, e.g. {{.Symbol}} or {{.Leverage.Max}}
+// PromptData 是交易员 Prompt 模板可用的数据，例如 {{.Symbol}} 或 {{.Leverage.Max}}
+type PromptData struct {
+	Symbols     []string            // 所有交易对 / All trading pairs
+	Symbol      string              // 当前交易对（仅交易对专属 Prompt 或单交易对时）/ Current symbol (per-symbol prompts or a single symbol only)
+	Timeframe   string              // K 线周期 / K-line timeframe
+	RunInterval string              // 运行间隔 / Run interval
+	Language    string              // 报告语言 zh/en / Report language zh/en
+	Now         string              // 当前时间 / Current time
+	Leverage    PromptLeverage      // 杠杆限制 / Leverage limits
+	Performance *storage.TradeStats // 历史交易表现（nil 表示未知）/ Trading performance (nil = unknown)
+	Risk        PromptRisk          // 账户风险状态 / Account risk status
+	Thresholds  DecisionThresholds  // 开仓决策门槛（执行前强制检查）/ Entry thresholds (enforced before execution)
+}
+
+// PromptLeverage holds the leverage limits of the trader
+// PromptLeverage 保存交易员的杠杆限制
+type PromptLeverage struct {
+	Dynamic bool // 是否动态杠杆 / Whether leverage is dynamic
+	Min     int  // 动态杠杆下限 / Dynamic leverage lower bound
+	Max     int  // 动态杠杆上限 / Dynamic leverage upper bound
+	Fixed   int  // 固定杠杆 / Fixed leverage
+}
+
+// PromptRisk holds the account risk status
+// PromptRisk 保存账户风险状态
+type PromptRisk struct {
+	Balance      float64 // 可用余额（0 表示未知）/ Available balance (0 = unknown)
+	SmallAccount bool    // 是否处于小账户模式 / Whether small-account mode is on
+	Overtrading  bool    // 最近 24 小时开仓是否超过上限 / Whether the last 24 hours exceeded the trade target
+	RecentTrades int     // 最近 24 小时开仓次数 / Positions opened in the last 24 hours
+	MaxTrades    int     // 每日开仓上限（0 表示不限）/ Daily trade upper bound (0 = none)
+	LossStreak   int     // 本交易时段当前连续亏损笔数 / Current losing streak of the session
+	SizeHalved   bool    // 连亏后开仓是否减半 / Whether entries are halved by the losing streak
+	EntryHalted  bool    // 连亏后本时段是否停止开仓 / Whether entries are halted for the session
+	AnalysisOnly bool    // 是否仅分析模式 / Whether the bot runs analysis-only
+}
+
+// promptFuncs are the helper functions available to prompt templates
+// promptFuncs 是 Prompt 模板可用的辅助函数
+var promptFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// SetTradeStats passes the trading performance to the prompt templates (nil = unknown)
+// SetTradeStats 将历史交易表现传给 Prompt 模板（nil 表示未知）
+func (g *SimpleTradingGraph) SetTradeStats(stats *storage.TradeStats) {
+	g.tradeStats = stats
+}
+
+// promptData collects the template data; symbol is empty for the shared prompt of several symbols
+// promptData 收集模板数据；多交易对共用的 Prompt 中 symbol 为空
+func (g *SimpleTradingGraph) promptData(symbol string) PromptData {
+	data := PromptData{
+		Symbols:     g.state.Symbols,
+		Symbol:      symbol,
+		Timeframe:   g.config.CryptoTimeframe,
+		RunInterval: g.config.TradingInterval,
+		Language:    g.state.Language,
+		Now:         time.Now().Format("2006-01-02 15:04:05"),
+		Leverage: PromptLeverage{
+			Dynamic: g.config.BinanceLeverageDynamic,
+			Min:     g.config.BinanceLeverageMin,
+			Max:     g.config.BinanceLeverageMax,
+			Fixed:   g.config.BinanceLeverage,
+		},
+		Performance: g.tradeStats,
+		Risk: PromptRisk{
+			Balance:      g.balance,
+			SmallAccount: g.config.IsSmallAccount(g.balance),
+			Overtrading:  g.tradeFrequency.Overtrading(),
+			SizeHalved:   g.lossStreak.Reduced(),
+			EntryHalted:  g.lossStreak.Halted(),
+			AnalysisOnly: g.config.IsAnalysisOnly(),
+		},
+		Thresholds: ThresholdsFromConfig(g.config),
+	}
+	if data.Symbol == "" && len(data.Symbols) == 1 {
+		data.Symbol = data.Symbols[0]
+	}
+	if g.tradeFrequency != nil {
+		data.Risk.RecentTrades = g.tradeFrequency.Trades
+		data.Risk.MaxTrades = g.tradeFrequency.Max
+	}
+	if g.lossStreak != nil {
+		data.Risk.LossStreak = g.lossStreak.Current
+	}
+	return data
+}
+
+// renderPrompt executes a prompt as a text/template; prompts without actions are returned unchanged
+// renderPrompt 将 Prompt 作为 text/template 执行；不含模板语法的 Prompt 原样返回
+func renderPrompt(name, text string, data PromptData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// systemPrompt builds the trader system prompt from TRADER_PROMPT_PATH and the per-symbol prompts
+// systemPrompt 根据 TRADER_PROMPT_PATH 和交易对专属 Prompt 构建交易员系统 Prompt
+//
+// With a single symbol its prompt file replaces the main prompt; with several, each symbol's prompt
+// is appended as a section that takes precedence for that symbol. A template that fails to render is
+// used as plain text.
+// 单个交易对时其专属 Prompt 替换主 Prompt；多个交易对时每个专属 Prompt 作为该交易对优先适用的章节追加。
+// 模板渲染失败时按纯文本使用。
+func (g *SimpleTradingGraph) systemPrompt() string {
+	return g.systemPromptFor(g.state.Symbols)
+}
+
+// systemPromptFor returns the trader system prompt with the symbol prompts of the given symbols only
+// systemPromptFor 返回只包含指定交易对专属 Prompt 的交易员系统 Prompt
+func (g *SimpleTradingGraph) systemPromptFor(symbols []string) string {
+	labels := labelsFor(g.state.Language)
+	prompt := g.renderOrRaw(g.config.TraderPromptPath,
+		loadPromptFromFile(g.config.TraderPromptPath, g.logger), g.promptData(""))
+
+	var sections strings.Builder
+	for _, symbol := range symbols {
+		path := g.config.SymbolPromptPath(symbol)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				g.logger.Warning(fmt.Sprintf("⚠️  无法读取 %s 专属 Prompt %s: %v", symbol, path, err))
+			}
+			continue
+		}
+		text := strings.TrimSpace(string(content))
+		if text == "" {
+			continue
+		}
+		text = g.renderOrRaw(path, text, g.promptData(symbol))
+		g.logger.Success(fmt.Sprintf("成功加载 %s 专属 Prompt: %s", symbol, path))
+
+		if len(symbols) == 1 {
+			return text
+		}
+		sections.WriteString(fmt.Sprintf("\n\n=== %s ===\n", fmt.Sprintf(labels.SymbolPrompt, symbol)))
+		sections.WriteString(text)
+	}
+	return prompt + sections.String()
+}
+
+// renderOrRaw renders a prompt template, falling back to the raw text on error
+// renderOrRaw 渲染 Prompt 模板，出错时回退为原始文本
+func (g *SimpleTradingGraph) renderOrRaw(name, text string, data PromptData) string {
+	rendered, err := renderPrompt(name, text, data)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  Prompt 模板渲染失败，按原文使用: %v", err))
+		return text
+	}
+	return rendered
+}
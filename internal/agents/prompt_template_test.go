@@ -0,0 +1,88 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestRenderPrompt tests prompt variables, helper functions and plain prompts
+// TestRenderPrompt 测试 Prompt 变量、辅助函数和普通 Prompt
+func TestRenderPrompt(t *testing.T) {
+	data := PromptData{
+		Symbols:     []string{"BTC/USDT", "ETH/USDT"},
+		Symbol:      "BTC/USDT",
+		Timeframe:   "1h",
+		Leverage:    PromptLeverage{Dynamic: true, Min: 5, Max: 20},
+		Performance: &storage.TradeStats{WinRate: 55.5},
+		Risk:        PromptRisk{Balance: 80, SmallAccount: true},
+		Thresholds:  DecisionThresholds{MinConfidence: 0.75, MinRiskReward: 2},
+	}
+
+	got, err := renderPrompt("test", `{{lower .Symbol}} {{.Timeframe}} {{join .Symbols ","}} {{.Leverage.Min}}-{{.Leverage.Max}}x`+
+		`{{with .Performance}} win {{printf "%.1f" .WinRate}}%{{end}}{{if .Risk.SmallAccount}} small{{end}}`+
+		` ≥ {{.Thresholds.MinConfidence}} {{.Thresholds.MinRiskReward}}:1`, data)
+	if err != nil {
+		t.Fatalf("renderPrompt failed: %v", err)
+	}
+	if want := "btc/usdt 1h BTC/USDT,ETH/USDT 5-20x win 55.5% small ≥ 0.75 2:1"; got != want {
+		t.Errorf("renderPrompt = %q, want %q", got, want)
+	}
+
+	// Prompts without template actions are returned unchanged, even with stray braces
+	// 不含模板语法的 Prompt 原样返回，即使包含单个花括号
+	plain := `输出 { "BTC/USDT": {...} }`
+	if got, err := renderPrompt("plain", plain, data); err != nil || got != plain {
+		t.Errorf("renderPrompt(plain) = %q, %v", got, err)
+	}
+
+	if _, err := renderPrompt("broken", "{{.Unknown}}", data); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+// TestSystemPromptPerSymbol tests that per-symbol prompts replace or extend the main prompt
+// TestSystemPromptPerSymbol 测试交易对专属 Prompt 替换或追加到主 Prompt
+func TestSystemPromptPerSymbol(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "trader_system.txt")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("trader_system.txt", "Trade {{join .Symbols \" \"}} on {{.Timeframe}}")
+	write("trader_btc.txt", "BTC rules for {{.Symbol}}")
+
+	newGraph := func(symbols ...string) *SimpleTradingGraph {
+		cfg := &config.Config{TraderPromptPath: mainPath, CryptoTimeframe: "4h", CryptoSymbols: symbols, PaperTrading: true}
+		return &SimpleTradingGraph{config: cfg, logger: logger.NewColorLogger(false), state: NewAgentState(symbols, "4h")}
+	}
+
+	// Several symbols: the BTC prompt is appended as its own section
+	// 多个交易对：BTC 专属 Prompt 作为单独章节追加
+	got := newGraph("BTC/USDT", "ETH/USDT").systemPrompt()
+	if !strings.HasPrefix(got, "Trade BTC/USDT ETH/USDT on 4h") {
+		t.Errorf("Expected the rendered main prompt first, got %q", got)
+	}
+	if !strings.Contains(got, "BTC/USDT 专属策略") || !strings.HasSuffix(got, "BTC rules for BTC/USDT") {
+		t.Errorf("Expected a BTC section, got %q", got)
+	}
+
+	// A single symbol with its own prompt uses it instead of the main prompt
+	// 单个交易对且有专属 Prompt 时替换主 Prompt
+	if got := newGraph("BTC/USDT").systemPrompt(); got != "BTC rules for BTC/USDT" {
+		t.Errorf("systemPrompt = %q, want the BTC prompt", got)
+	}
+
+	// Without a per-symbol prompt only the main prompt is used
+	// 没有专属 Prompt 时只使用主 Prompt
+	if got := newGraph("ETH/USDT").systemPrompt(); got != "Trade ETH/USDT on 4h" {
+		t.Errorf("systemPrompt = %q, want the main prompt", got)
+	}
+}
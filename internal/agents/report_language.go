@@ -0,0 +1,149 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report languages
+// 报告语言
+const (
+	ReportLanguageAuto = "auto" // 根据模型自动选择 / Pick from the model name
+	ReportLanguageZH   = "zh"   // 中文 / Chinese
+	ReportLanguageEN   = "en"   // 英文 / English
+)
+
+// chineseModelPrefixes lists model families trained primarily on Chinese data
+// chineseModelPrefixes 列出以中文语料为主训练的模型系列
+var chineseModelPrefixes = []string{
+	"deepseek", "qwen", "qwq", "glm", "chatglm", "moonshot", "kimi", "doubao",
+	"yi-", "baichuan", "ernie", "hunyuan", "minimax", "abab", "spark", "internlm", "step-",
+}
+
+// ResolveReportLanguage returns the language used for report headers and prompt framing
+// ResolveReportLanguage 返回报告标题和 Prompt 框架文本使用的语言
+//
+// "auto" keeps Chinese for Chinese-centric models and switches to English for everything else (GPT, Claude, Gemini, Llama, ...).
+// "auto" 对中文模型保持中文，其余模型（GPT、Claude、Gemini、Llama 等）切换为英文。
+func ResolveReportLanguage(setting, model string) string {
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case ReportLanguageZH:
+		return ReportLanguageZH
+	case ReportLanguageEN:
+		return ReportLanguageEN
+	}
+
+	// Strip provider prefixes such as "deepseek-ai/DeepSeek-V3" or "openrouter/qwen/..."
+	// 去掉 "deepseek-ai/DeepSeek-V3"、"openrouter/qwen/..." 之类的提供商前缀
+	name := strings.ToLower(strings.TrimSpace(model))
+	if name == "" {
+		return ReportLanguageZH
+	}
+	for _, part := range strings.Split(name, "/") {
+		for _, prefix := range chineseModelPrefixes {
+			if strings.HasPrefix(part, prefix) {
+				return ReportLanguageZH
+			}
+		}
+	}
+	return ReportLanguageEN
+}
+
+// reportLabels holds the localized section headers and prompt framing text
+// reportLabels 保存本地化的报告标题和 Prompt 框架文本
+type reportLabels struct {
+	AccountOverview  string // 账户总览 / Account overview
+	PositionsSummary string // 持仓汇总 / Positions summary
+	SymbolReport     string // 交易对报告标题（%s = 交易对）/ Per-symbol header (%s = symbol)
+	MarketAnalysis   string // 市场技术分析 / Market technical analysis
+	CryptoAnalysis   string // 加密货币专属分析 / Crypto-specific analysis
+	CryptoData       string // 加密货币数据标题（%s = 交易对）/ Crypto data header (%s = symbol)
+	SessionContext   string // 会话上下文（分钟、时间、交易次数）/ Session context (minutes, time, trade count)
+	DynamicLeverage  string // 动态杠杆范围（最小、最大）/ Dynamic leverage range (min, max)
+	FixedLeverage    string // 固定杠杆 / Fixed leverage
+	KlineInfo        string // K 线与运行间隔 / K-line and run interval
+	PromptIntro      string // 用户 Prompt 开头 / User prompt intro
+	PromptOutro      string // 用户 Prompt 结尾 / User prompt outro
+	Overtrading      string // 过度交易提示（开仓次数、上限）/ Overtrading guidance (trades, upper bound)
+	SmallAccount     string // 小账户模式提示（余额、阈值、最低杠杆）/ Small-account guidance (balance, threshold, leverage floor)
+	Pyramiding       string // 加仓规则（最多次数、最大倍数、最低浮盈%）/ Add-on rules (max adds, max multiple, min profit %)
+	LossStreakHalved string // 连亏减仓提示（连亏笔数、阈值）/ Losing-streak halving guidance (losses, threshold)
+	LossStreakHalted string // 连亏停止开仓提示（连亏笔数、重置时间）/ Losing-streak halt guidance (losses, reset time)
+	AnalysisOnly     string // 仅分析模式下账户和持仓不可用的说明 / Notice that account and positions are unavailable in analysis-only mode
+	PluginContext    string // 插件补充信息 / Extra context from plugins
+	SymbolPrompt     string // 交易对专属策略标题（%s = 交易对）/ Per-symbol strategy header (%s = symbol)
+	TradeMemory      string // 近期交易复盘标题 / Recent trade review header
+	TradeMemoryLine  string // 单笔交易（平仓时间、方向、入场、出场、原因、盈亏、盈亏%）/ One trade (closed at, side, entry, exit, reason, PnL, PnL %)
+	TradeMemoryTotal string // 复盘合计（胜、负、净盈亏）/ Review totals (wins, losses, net PnL)
+	StopOutStreak    string // 连续止损提示（次数）/ Repeated stop-out warning (count)
+}
+
+var reportLabelSets = map[string]reportLabels{
+	ReportLanguageZH: {
+		AccountOverview:  "账户总览",
+		PositionsSummary: "持仓汇总",
+		SymbolReport:     "%s 分析报告",
+		MarketAnalysis:   "市场技术分析",
+		CryptoAnalysis:   "加密货币专属分析",
+		CryptoData:       "%s 加密货币数据",
+		SessionContext:   "\n- 这是你开始交易的第 %d 分钟,目前的时间是：%s,你已经参与了交易 %d 次，\n",
+		DynamicLeverage:  "\n**动态杠杆范围**: %d-%d 倍\n",
+		FixedLeverage:    "\n**固定杠杆**: %d 倍（本次交易将使用固定杠杆）\n",
+		KlineInfo:        "\n**K 线数据间隔**: %s（市场报告中的技术指标基于此时间周期计算）\n**系统运行间隔**: %s（系统每隔此时间运行一次分析）\n",
+		PromptIntro:      "下方我们将为您提供各种市场技术分析、加密货币状态分析，助您发掘超额收益。再下方是您当前的当前持仓信息，包括价值、业绩和持仓情况。请分析以下各种数据并给出交易决策：",
+		PromptOutro:      "请给出你的分析和最终决策。",
+		Overtrading:      "\n⚠️ **过度交易警告**: 最近 24 小时你已开仓 %d 次，超过每日目标上限 %d 次。频繁交易会放大手续费、滑点和噪音信号带来的亏损。本轮请只在趋势明确、多项指标共振的高确定性机会下开仓，其余情况请选择 HOLD；平仓和止损调整不受影响。\n",
+		LossStreakHalved: "\n📉 **连亏降风险**: 本交易时段已连续亏损 %d 笔（阈值 %d 笔），新开仓的仓位将被自动减半。请重新审视市场状态，只在把握较大的机会下开仓。\n",
+		LossStreakHalted: "\n🛑 **连亏停止开仓**: 本交易时段已连续亏损 %d 笔，%s 之前不会执行任何新开仓，请对所有交易对选择 HOLD，只考虑持有、调整止损或平仓。\n",
+		SmallAccount:     "\n💰 **小账户模式**: 账户余额 %.2f USDT 低于 %.2f USDT，最多同时持有 1 个仓位，杠杆不低于 %d 倍。请只在所有交易对中把握最大的一个机会上开仓（BUY 或 SELL），其余交易对选择 HOLD；不要使用 BUY_STOP / SELL_STOP 条件入场和分批止盈。已有持仓时只考虑持有、调整止损或平仓。\n",
+		Pyramiding:       "\n📈 **加仓（金字塔）**: 对已盈利的持仓可以用 ADD_LONG（加多）或 ADD_SHORT（加空）顺势加仓，`position_size` 为本次加仓的资金比例，`stop_loss` 可选（省略时按混合入场价保持原风险距离）。每个持仓最多加仓 %d 次，总数量不超过开仓数量的 %.1f 倍，浮盈低于 %.2f%% 时不会加仓；不要对亏损持仓加仓摊低成本。\n",
+		AnalysisOnly:     "不可用（仅分析模式：未连接交易所账户，本次决策不会被执行）。请按无持仓、无账户限制进行分析，给出你认为合理的决策。\n",
+		PluginContext:    "补充信息",
+		SymbolPrompt:     "%s 专属策略（仅适用于该交易对，与上文冲突时以此为准）",
+		TradeMemory:      "近期交易复盘（最近平仓在前）",
+		TradeMemoryLine:  "- %s %s | 入场 %s → 出场 %s | %s | 盈亏 %+.2f USDT (%+.2f%%)\n",
+		TradeMemoryTotal: "合计: %d 胜 %d 负，净盈亏 %+.2f USDT\n",
+		StopOutStreak:    "⚠️ 最近连续 %d 笔交易止损出场，请反思这些入场的共同问题，避免在相同形态下重复犯错。\n",
+	},
+	ReportLanguageEN: {
+		AccountOverview:  "Account Overview",
+		PositionsSummary: "Positions Summary",
+		SymbolReport:     "%s Analysis Report",
+		MarketAnalysis:   "Market Technical Analysis",
+		CryptoAnalysis:   "Crypto-Specific Analysis",
+		CryptoData:       "%s Crypto Data",
+		SessionContext:   "\n- It has been %d minutes since you started trading, the current time is %s, and you have traded %d times.\n",
+		DynamicLeverage:  "\n**Dynamic leverage range**: %d-%dx\n",
+		FixedLeverage:    "\n**Fixed leverage**: %dx (this trade will use fixed leverage)\n",
+		KlineInfo:        "\n**K-line interval**: %s (technical indicators in the market report are computed on this timeframe)\n**Run interval**: %s (the system runs an analysis at this interval)\n",
+		PromptIntro:      "Below you will find market technical analysis and crypto state analysis to help you find excess returns, followed by your current positions including value, performance and holdings. Analyze the data below and make your trading decision:",
+		PromptOutro:      "Give your analysis and final decision.",
+		Overtrading:      "\n⚠️ **Overtrading warning**: you have opened %d positions in the last 24 hours, above the daily target of at most %d. Frequent trading amplifies losses from fees, slippage and noisy signals. This round, only open a position on a high-conviction setup with a clear trend confirmed by several indicators, and choose HOLD otherwise; closing positions and stop adjustments are unaffected.\n",
+		LossStreakHalved: "\n📉 **Losing-streak de-risking**: %d losing trades in a row this session (threshold %d), so new entries are automatically halved in size. Reassess the market and only open on setups you are confident in.\n",
+		LossStreakHalted: "\n🛑 **Losing-streak halt**: %d losing trades in a row this session, so no new entry will be executed until %s. Choose HOLD for every symbol and only consider holding, adjusting stops or closing positions.\n",
+		SmallAccount:     "\n💰 **Small-account mode**: the balance of %.2f USDT is below %.2f USDT, so at most one position can be open and leverage is at least %dx. Only open your single best opportunity across all symbols (BUY or SELL) and choose HOLD for the rest; don't use BUY_STOP / SELL_STOP entries or partial take-profits. While a position is open, only consider holding it, adjusting its stop or closing it.\n",
+		Pyramiding:       "\n📈 **Pyramiding**: you may scale into a winning position with ADD_LONG or ADD_SHORT; `position_size` is the share of funds of this add-on and `stop_loss` is optional (when omitted the original risk distance is kept behind the blended entry). A position can be added to at most %d times, up to %.1fx its opening quantity, and only once it is at least %.2f%% in profit; never add to a losing position to average down.\n",
+		AnalysisOnly:     "Unavailable (analysis-only mode: no exchange account is connected and this decision will not be executed). Analyze as if there were no open positions and no account limits, and give the decision you consider sound.\n",
+		PluginContext:    "Additional Context",
+		SymbolPrompt:     "%s-Specific Strategy (applies to this symbol only and takes precedence over the rules above)",
+		TradeMemory:      "Recent Trade Review (most recently closed first)",
+		TradeMemoryLine:  "- %s %s | entry %s → exit %s | %s | PnL %+.2f USDT (%+.2f%%)\n",
+		TradeMemoryTotal: "Total: %d wins, %d losses, net PnL %+.2f USDT\n",
+		StopOutStreak:    "⚠️ The last %d trades were stopped out in a row. Reflect on what these entries had in common and avoid repeating the same setup.\n",
+	},
+}
+
+// labelsFor returns the labels of a language, defaulting to Chinese
+// labelsFor 返回指定语言的标签，默认中文
+func labelsFor(language string) reportLabels {
+	if labels, ok := reportLabelSets[language]; ok {
+		return labels
+	}
+	return reportLabelSets[ReportLanguageZH]
+}
+
+// sessionContextLine formats the session context line in the given language
+// sessionContextLine 使用指定语言格式化会话上下文行
+func sessionContextLine(language string, minutes int, currentTime string, tradeCount int) string {
+	return fmt.Sprintf(labelsFor(language).SessionContext, minutes, currentTime, tradeCount)
+}
@@ -0,0 +1,61 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResolveReportLanguage tests automatic language selection from the model name
+// TestResolveReportLanguage 测试根据模型名称自动选择语言
+func TestResolveReportLanguage(t *testing.T) {
+	tests := []struct {
+		setting  string
+		model    string
+		expected string
+	}{
+		{"auto", "deepseek-chat", ReportLanguageZH},
+		{"auto", "qwen-plus", ReportLanguageZH},
+		{"auto", "deepseek-ai/DeepSeek-V3", ReportLanguageZH},
+		{"auto", "gpt-4o-mini", ReportLanguageEN},
+		{"auto", "anthropic/claude-3.5-sonnet", ReportLanguageEN},
+		{"", "gemini-1.5-pro", ReportLanguageEN},
+		{"auto", "", ReportLanguageZH},
+		{"zh", "gpt-4o", ReportLanguageZH},
+		{"EN", "deepseek-chat", ReportLanguageEN},
+	}
+
+	for _, tt := range tests {
+		if got := ResolveReportLanguage(tt.setting, tt.model); got != tt.expected {
+			t.Errorf("ResolveReportLanguage(%q, %q) = %q, want %q", tt.setting, tt.model, got, tt.expected)
+		}
+	}
+}
+
+// TestGetAllReportsLanguage tests that only headers change with the language
+// TestGetAllReportsLanguage 测试切换语言时只有标题发生变化
+func TestGetAllReportsLanguage(t *testing.T) {
+	state := NewAgentState([]string{"BTC/USDT"}, "1h")
+	state.SetMarketReport("BTC/USDT", "RSI: 55.20")
+	state.SetCryptoReport("BTC/USDT", "Funding: 0.000100")
+	state.SetAccountInfo("Balance: 1000.00")
+
+	zh := state.GetAllReports()
+	if !strings.Contains(zh, "=== 市场技术分析 ===") || !strings.Contains(zh, "BTC/USDT 分析报告") {
+		t.Errorf("Expected Chinese headers, got:\n%s", zh)
+	}
+
+	state.Language = ReportLanguageEN
+	en := state.GetAllReports()
+	if !strings.Contains(en, "=== Market Technical Analysis ===") || !strings.Contains(en, "BTC/USDT Analysis Report") {
+		t.Errorf("Expected English headers, got:\n%s", en)
+	}
+	if strings.Contains(en, "市场技术分析") {
+		t.Error("English report should not contain Chinese headers")
+	}
+
+	for _, value := range []string{"RSI: 55.20", "Funding: 0.000100", "Balance: 1000.00"} {
+		if !strings.Contains(en, value) {
+			t.Errorf("Expected numeric content %q to be unchanged", value)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+// riskReviewSystemPrompt instructs DEEP_THINK_LLM to approve or veto each entry
+// riskReviewSystemPrompt 指示 DEEP_THINK_LLM 批准或否决每个开仓决策
+const riskReviewSystemPrompt = `你是一名资深加密货币期货风控复核员。交易员已经给出了交易决策，你需要在执行前结合账户和持仓状态独立复核其中的每个开仓决策。
+
+只有当决策存在明显问题时才否决，例如：
+- 缺少止损，或止损位置不合理（方向错误、距离过近或过远）
+- 杠杆或仓位相对账户规模明显过大
+- 与现有持仓冲突，或会让账户整体风险过度集中
+- 理由与给出的数据明显矛盾
+
+只输出 JSON 对象，键为交易对，值包含 approved（布尔值）和 reason（简短理由），例如：
+{"BTC/USDT": {"approved": true, "reason": "止损合理，仓位适中"}}`
+
+// ReviewVerdict is the deep-think reviewer's verdict on one entry
+// ReviewVerdict 是深度复核员对单个开仓决策的结论
+type ReviewVerdict struct {
+	Approved bool   `json:"approved"` // 是否批准 / Whether the entry is approved
+	Reason   string `json:"reason"`   // 批准或否决理由 / Why it was approved or vetoed
+}
+
+// reviewableSymbols returns the symbols whose decision opens or adds to a position, the only ones that are reviewed
+// reviewableSymbols 返回决策为开仓或加仓的交易对，仅这些交易对需要复核
+//
+// Closing and holding only reduce or keep risk, so they are never held up by the review.
+// 平仓和观望只会降低或维持风险，因此不会被复核拦截。
+func reviewableSymbols(symbols []string, decisions map[string]*TradingDecision) []string {
+	var reviewable []string
+	for _, symbol := range symbols {
+		d, ok := decisions[symbol]
+		if !ok || !d.Valid {
+			continue
+		}
+		if d.Action == executors.ActionBuy || d.Action == executors.ActionSell || executors.IsStopEntry(d.Action) || executors.IsAddOn(d.Action) {
+			reviewable = append(reviewable, symbol)
+		}
+	}
+	return reviewable
+}
+
+// parseReviewVerdicts parses the reviewer's JSON answer for the reviewed symbols
+// parseReviewVerdicts 解析复核员对所复核交易对给出的 JSON 结论
+//
+// A symbol the reviewer didn't answer for is vetoed, an entry is only executed on an explicit approval.
+// 复核员未给出结论的交易对按否决处理，只有明确批准的开仓才会执行。
+func parseReviewVerdicts(content string, symbols []string) (map[string]*ReviewVerdict, error) {
+	var answers map[string]*ReviewVerdict
+	if err := sonic.Unmarshal([]byte(strings.TrimSpace(extractJSONPayload(content))), &answers); err != nil {
+		return nil, fmt.Errorf("复核结果 JSON 解析失败: %w", err)
+	}
+
+	verdicts := make(map[string]*ReviewVerdict, len(symbols))
+	for _, symbol := range symbols {
+		verdict := answers[symbol]
+		if verdict == nil {
+			verdict = &ReviewVerdict{Reason: "复核员未给出结论"}
+		}
+		if strings.TrimSpace(verdict.Reason) == "" {
+			verdict.Reason = "未说明理由"
+		}
+		verdicts[symbol] = verdict
+	}
+	return verdicts, nil
+}
+
+// vetoAll vetoes every reviewed symbol with the same reason (used when the review itself fails)
+// vetoAll 以相同理由否决所有待复核的交易对（复核本身失败时使用）
+func vetoAll(symbols []string, reason string) map[string]*ReviewVerdict {
+	verdicts := make(map[string]*ReviewVerdict, len(symbols))
+	for _, symbol := range symbols {
+		verdicts[symbol] = &ReviewVerdict{Reason: reason}
+	}
+	return verdicts
+}
+
+// reviewDecision asks DEEP_THINK_LLM to approve or veto the entries of the trader's decision
+// reviewDecision 请 DEEP_THINK_LLM 批准或否决交易员决策中的开仓
+//
+// The review fails closed: if the model can't be reached, runs past the decision budget, the monthly
+// token budget is spent or it answers unparseable JSON, every reviewed entry is vetoed.
+// 复核失败时按否决处理：模型不可用、超出决策预算、月度 token 预算已用完或返回无法解析的 JSON 时，所有待复核开仓均被否决。
+func (g *SimpleTradingGraph) reviewDecision(ctx context.Context, decision string) map[string]*ReviewVerdict {
+	symbols := reviewableSymbols(g.state.Symbols, ParseMultiCurrencyDecision(decision, g.state.Symbols))
+	if len(symbols) == 0 {
+		g.logger.Info("🧐 风控复核：本轮无开仓决策，无需复核")
+		return nil
+	}
+
+	if g.tokenBudgetExhausted() {
+		return vetoAll(symbols, "本月 LLM token 预算已用完，无法复核")
+	}
+
+	g.logger.Info(fmt.Sprintf("🧐 风控复核：使用 %s 复核 %s 的开仓决策...", g.config.DeepThinkLLM, strings.Join(symbols, ", ")))
+
+	chatModel, err := openaiComponent.NewChatModel(ctx, &openaiComponent.ChatModelConfig{
+		APIKey:  g.config.APIKey,
+		BaseURL: g.config.BackendURL,
+		Model:   g.config.DeepThinkLLM,
+		ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
+			Type: openaiComponent.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return vetoAll(symbols, fmt.Sprintf("复核模型初始化失败: %v", err))
+	}
+
+	state := g.state
+	state.mu.RLock()
+	accountInfo, allPositions := state.AccountInfo, state.AllPositions
+	state.mu.RUnlock()
+
+	userPrompt := fmt.Sprintf(`## 账户状态
+%s
+
+## 当前持仓
+%s
+
+## 交易员决策
+%s
+
+## 需要复核的交易对
+%s`, accountInfo, allPositions, decision, strings.Join(symbols, ", "))
+
+	// The review shares the decision budget but isn't recorded as a late decision
+	// 复核使用相同的决策时间预算，但不作为迟到决策记录
+	if budget := g.decisionBudget(); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	response, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(riskReviewSystemPrompt),
+		schema.UserMessage(userPrompt),
+	})
+	if err != nil {
+		return vetoAll(symbols, fmt.Sprintf("复核调用失败: %v", err))
+	}
+	g.recordTokenUsage(g.config.DeepThinkLLM, response)
+
+	verdicts, err := parseReviewVerdicts(response.Content, symbols)
+	if err != nil {
+		return vetoAll(symbols, err.Error())
+	}
+	return verdicts
+}
@@ -0,0 +1,49 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+// TestReviewableSymbols tests that only valid entries are sent to the reviewer
+// TestReviewableSymbols 测试只有有效的开仓决策会提交复核
+func TestReviewableSymbols(t *testing.T) {
+	symbols := []string{"BTC/USDT", "ETH/USDT", "SOL/USDT", "BNB/USDT", "XRP/USDT"}
+	decisions := map[string]*TradingDecision{
+		"BTC/USDT": {Action: executors.ActionBuy, Valid: true},
+		"ETH/USDT": {Action: executors.ActionCloseLong, Valid: true},
+		"SOL/USDT": {Action: executors.ActionSellStop, Valid: true},
+		"BNB/USDT": {Action: executors.ActionHold, Valid: true},
+		"XRP/USDT": {Action: executors.ActionSell, Valid: false},
+	}
+
+	got := reviewableSymbols(symbols, decisions)
+	if len(got) != 2 || got[0] != "BTC/USDT" || got[1] != "SOL/USDT" {
+		t.Errorf("Expected BTC/USDT and SOL/USDT to be reviewed, got %v", got)
+	}
+}
+
+// TestParseReviewVerdicts tests that only an explicit approval lets an entry through
+// TestParseReviewVerdicts 测试只有明确批准的开仓才会放行
+func TestParseReviewVerdicts(t *testing.T) {
+	content := `{"BTC/USDT": {"approved": true, "reason": "止损合理"}, "SOL/USDT": {"approved": false, "reason": "杠杆过高"}}`
+	verdicts, err := parseReviewVerdicts(content, []string{"BTC/USDT", "SOL/USDT", "ETH/USDT"})
+	if err != nil {
+		t.Fatalf("parseReviewVerdicts failed: %v", err)
+	}
+
+	if v := verdicts["BTC/USDT"]; !v.Approved || v.Reason != "止损合理" {
+		t.Errorf("Expected BTC/USDT approved, got %+v", v)
+	}
+	if v := verdicts["SOL/USDT"]; v.Approved || v.Reason != "杠杆过高" {
+		t.Errorf("Expected SOL/USDT vetoed, got %+v", v)
+	}
+	if v := verdicts["ETH/USDT"]; v == nil || v.Approved {
+		t.Errorf("Expected an unanswered symbol to be vetoed, got %+v", v)
+	}
+
+	if _, err := parseReviewVerdicts("approved", []string{"BTC/USDT"}); err == nil {
+		t.Error("Expected an error for a non-JSON answer")
+	}
+}
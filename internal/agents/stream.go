@@ -0,0 +1,226 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+)
+
+// decisionStreamBuffer is how many events a slow subscriber may lag behind before events are dropped for it
+// decisionStreamBuffer 是慢订阅者最多可积压的事件数，超出后丢弃该订阅者的事件
+const decisionStreamBuffer = 256
+
+// errStreamStalled is returned when a streamed generation produces no output within the idle timeout
+// errStreamStalled 表示流式生成在空闲超时内没有新输出
+var errStreamStalled = errors.New("LLM stream stalled")
+
+// DecisionStreamEvent is one piece of a decision being streamed by a model
+// DecisionStreamEvent 是模型流式生成决策时的一个片段
+type DecisionStreamEvent struct {
+	Model     string    `json:"model"`               // 生成决策的模型 / Model generating the decision
+	Delta     string    `json:"delta,omitempty"`     // 新增的输出 / Newly generated output
+	Reasoning string    `json:"reasoning,omitempty"` // 新增的思考过程（推理模型）/ Newly generated reasoning (reasoning models)
+	Done      bool      `json:"done,omitempty"`      // 生成是否结束 / Whether the generation finished
+	Error     string    `json:"error,omitempty"`     // 生成失败或中止的原因 / Why the generation failed or was aborted
+	Time      time.Time `json:"time"`
+}
+
+// DecisionStream fans streamed decision output out to subscribers such as web UI clients
+// DecisionStream 将流式决策输出分发给订阅者（例如 Web 界面客户端）
+type DecisionStream struct {
+	mu          sync.Mutex
+	subscribers map[chan DecisionStreamEvent]struct{}
+}
+
+// NewDecisionStream creates a decision stream without subscribers
+// NewDecisionStream 创建一个没有订阅者的决策流
+func NewDecisionStream() *DecisionStream {
+	return &DecisionStream{subscribers: make(map[chan DecisionStreamEvent]struct{})}
+}
+
+// Subscribe returns the events published from now on and a function that ends the subscription
+// Subscribe 返回此后发布的事件，以及用于取消订阅的函数
+func (d *DecisionStream) Subscribe() (<-chan DecisionStreamEvent, func()) {
+	ch := make(chan DecisionStreamEvent, decisionStreamBuffer)
+	d.mu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			d.mu.Lock()
+			delete(d.subscribers, ch)
+			d.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends an event to every subscriber without blocking; a nil stream discards it
+// Publish 以非阻塞方式将事件发送给所有订阅者；nil 决策流直接丢弃
+func (d *DecisionStream) Publish(event DecisionStreamEvent) {
+	if d == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// The subscriber is too slow; it misses this piece rather than stalling the decision
+			// 订阅者过慢时丢弃该片段，而不是拖慢决策
+		}
+	}
+}
+
+// SetDecisionStream publishes streamed decision output to the given stream (LLM_STREAMING only)
+// SetDecisionStream 将流式决策输出发布到指定的决策流（仅 LLM_STREAMING 启用时）
+func (g *SimpleTradingGraph) SetDecisionStream(stream *DecisionStream) {
+	g.stream = stream
+}
+
+// streamIdleTimeout returns the configured streaming idle timeout (0 = unlimited)
+// streamIdleTimeout 返回配置的流式输出空闲超时（0 表示不限制）
+func (g *SimpleTradingGraph) streamIdleTimeout() time.Duration {
+	if g.config == nil || g.config.LLMStreamIdleTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(g.config.LLMStreamIdleTimeout) * time.Second
+}
+
+// generateDecision calls the model, streaming the output when LLM_STREAMING is enabled
+// generateDecision 调用模型；启用 LLM_STREAMING 时以流式方式生成
+//
+// The token usage of every answer is recorded, including answers that later fail to parse.
+// 每次返回结果的 token 用量都会被记录，包括之后解析失败的结果。
+func (g *SimpleTradingGraph) generateDecision(ctx context.Context, chatModel *openaiComponent.ChatModel, model string, messages []*schema.Message) (*schema.Message, error) {
+	var response *schema.Message
+	var err error
+	if g.config == nil || !g.config.LLMStreaming {
+		response, err = chatModel.Generate(ctx, messages)
+	} else {
+		var reader *schema.StreamReader[*schema.Message]
+		reader, err = chatModel.Stream(ctx, messages)
+		if err != nil {
+			g.stream.Publish(DecisionStreamEvent{Model: model, Done: true, Error: err.Error()})
+			return nil, err
+		}
+		response, err = g.collectStream(ctx, model, reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.recordTokenUsage(model, response)
+	return response, nil
+}
+
+// collectStream reads a streamed generation, publishing and logging the output as it arrives
+// collectStream 读取流式生成的输出，边接收边发布并记录日志
+//
+// The generation is aborted once no chunk arrives within the idle timeout.
+// 超过空闲超时仍没有新片段时中止生成。
+func (g *SimpleTradingGraph) collectStream(ctx context.Context, model string, reader *schema.StreamReader[*schema.Message]) (*schema.Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer reader.Close()
+
+	type chunkResult struct {
+		chunk *schema.Message
+		err   error
+	}
+	chunkCh := make(chan chunkResult)
+	go func() {
+		for {
+			chunk, err := reader.Recv()
+			select {
+			case chunkCh <- chunkResult{chunk: chunk, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	idle := g.streamIdleTimeout()
+	var idleCh <-chan time.Time
+	var timer *time.Timer
+	if idle > 0 {
+		timer = time.NewTimer(idle)
+		defer timer.Stop()
+		idleCh = timer.C
+	}
+
+	var chunks []*schema.Message
+	var line strings.Builder
+	fail := func(err error) (*schema.Message, error) {
+		g.stream.Publish(DecisionStreamEvent{Model: model, Done: true, Error: err.Error()})
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fail(ctx.Err())
+		case <-idleCh:
+			return fail(fmt.Errorf("%w: no output for %s", errStreamStalled, idle))
+		case result := <-chunkCh:
+			if errors.Is(result.err, io.EOF) {
+				g.logStreamLine(model, line.String())
+				g.stream.Publish(DecisionStreamEvent{Model: model, Done: true})
+				if len(chunks) == 0 {
+					return nil, fmt.Errorf("LLM stream ended without output")
+				}
+				return schema.ConcatMessages(chunks)
+			}
+			if result.err != nil {
+				return fail(result.err)
+			}
+			if timer != nil {
+				timer.Reset(idle)
+			}
+			if result.chunk == nil {
+				continue
+			}
+
+			chunks = append(chunks, result.chunk)
+			if result.chunk.Content != "" || result.chunk.ReasoningContent != "" {
+				g.stream.Publish(DecisionStreamEvent{Model: model, Delta: result.chunk.Content, Reasoning: result.chunk.ReasoningContent})
+			}
+
+			// Log complete lines so the console follows along without one entry per token
+			// 按整行记录日志，控制台可以跟随进度又不会每个 token 一条
+			line.WriteString(result.chunk.ReasoningContent)
+			line.WriteString(result.chunk.Content)
+			if text := line.String(); strings.Contains(text, "\n") {
+				last := strings.LastIndex(text, "\n")
+				for _, l := range strings.Split(text[:last], "\n") {
+					g.logStreamLine(model, l)
+				}
+				line.Reset()
+				line.WriteString(text[last+1:])
+			}
+		}
+	}
+}
+
+// logStreamLine logs one non-empty line of streamed output at debug level
+// logStreamLine 以 debug 级别记录一行非空的流式输出
+func (g *SimpleTradingGraph) logStreamLine(model, line string) {
+	if line = strings.TrimSpace(line); line != "" {
+		g.logger.Debug(fmt.Sprintf("💭 [%s] %s", model, line))
+	}
+}
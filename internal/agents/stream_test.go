@@ -0,0 +1,94 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestCollectStream tests that streamed chunks are published as they arrive and concatenated at the end
+// TestCollectStream 测试流式片段边到达边发布，并在结束时拼接为完整响应
+func TestCollectStream(t *testing.T) {
+	stream := NewDecisionStream()
+	events, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+
+	g := &SimpleTradingGraph{config: &config.Config{LLMStreaming: true, LLMStreamIdleTimeout: 5}, logger: logger.NewColorLogger(false), stream: stream}
+
+	reader, writer := schema.Pipe[*schema.Message](4)
+	go func() {
+		defer writer.Close()
+		writer.Send(&schema.Message{Role: schema.Assistant, Content: `{"BTC/USDT": `}, nil)
+		writer.Send(&schema.Message{Role: schema.Assistant, Content: `{"action": "HOLD"}}`}, nil)
+	}()
+
+	response, err := g.collectStream(context.Background(), "gpt-4o-mini", reader)
+	if err != nil {
+		t.Fatalf("collectStream failed: %v", err)
+	}
+	if response.Content != `{"BTC/USDT": {"action": "HOLD"}}` {
+		t.Errorf("Unexpected concatenated content: %q", response.Content)
+	}
+
+	var deltas string
+	for event := range events {
+		if event.Model != "gpt-4o-mini" {
+			t.Errorf("Unexpected event model %q", event.Model)
+		}
+		deltas += event.Delta
+		if event.Done {
+			if event.Error != "" {
+				t.Errorf("Unexpected error event: %+v", event)
+			}
+			break
+		}
+	}
+	if deltas != response.Content {
+		t.Errorf("Published deltas %q don't add up to the response", deltas)
+	}
+}
+
+// TestCollectStreamStalled tests that a generation without output is aborted after the idle timeout
+// TestCollectStreamStalled 测试没有输出的生成在空闲超时后被中止
+func TestCollectStreamStalled(t *testing.T) {
+	stream := NewDecisionStream()
+	events, unsubscribe := stream.Subscribe()
+	defer unsubscribe()
+
+	g := &SimpleTradingGraph{config: &config.Config{LLMStreaming: true, LLMStreamIdleTimeout: 1}, logger: logger.NewColorLogger(false), stream: stream}
+
+	reader, writer := schema.Pipe[*schema.Message](1)
+	defer writer.Close()
+	writer.Send(&schema.Message{Role: schema.Assistant, Content: "{"}, nil)
+
+	_, err := g.collectStream(context.Background(), "gpt-4o-mini", reader)
+	if !errors.Is(err, errStreamStalled) {
+		t.Fatalf("Expected errStreamStalled, got %v", err)
+	}
+
+	<-events // the "{" delta / "{" 片段
+	if last := <-events; !last.Done || last.Error == "" {
+		t.Errorf("Expected a final error event, got %+v", last)
+	}
+}
+
+// TestDecisionStreamUnsubscribe tests that unsubscribed clients stop receiving events
+// TestDecisionStreamUnsubscribe 测试取消订阅后不再收到事件
+func TestDecisionStreamUnsubscribe(t *testing.T) {
+	stream := NewDecisionStream()
+	events, unsubscribe := stream.Subscribe()
+	unsubscribe()
+	unsubscribe() // Safe to call twice / 可重复调用
+
+	stream.Publish(DecisionStreamEvent{Model: "gpt-4o", Delta: "x"})
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed after unsubscribing")
+	}
+
+	var nilStream *DecisionStream
+	nilStream.Publish(DecisionStreamEvent{Model: "gpt-4o"}) // Must not panic / 不应 panic
+}
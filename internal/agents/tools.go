@@ -67,11 +67,15 @@ func (t *MarketDataTool) InvokableRun(ctx context.Context, argumentsInJSON strin
 		return "", fmt.Errorf("failed to fetch market data: %w", err)
 	}
 
-	// Calculate indicators
-	indicators := dataflows.CalculateIndicators(ohlcvData)
+	// Calculate the configured indicators (invalid INDICATORS falls back to the default set, the graph logs it)
+	indicatorSet, _ := dataflows.ParseIndicatorSet(t.config.Indicators)
+	indicators := dataflows.CalculateIndicatorSet(ohlcvData, indicatorSet)
 
 	// Generate report
 	report := dataflows.FormatIndicatorReport(args.Symbol, timeframe, ohlcvData, indicators)
+	if t.config.AdvancedIndicators || indicatorSet != nil {
+		report += dataflows.FormatAdvancedIndicators(ohlcvData, indicators)
+	}
 
 	return report, nil
 }
@@ -0,0 +1,51 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+)
+
+// SetTradeFrequency passes the recent trade frequency to the trader prompt (nil = not checked)
+// SetTradeFrequency 将最近的交易频率传给交易员 Prompt（nil 表示未检查）
+func (g *SimpleTradingGraph) SetTradeFrequency(freq *risk.TradeFrequency) {
+	g.tradeFrequency = freq
+}
+
+// overtradingGuidance returns the "be selective" guidance added to the prompt while overtrading
+// overtradingGuidance 返回过度交易期间加入 Prompt 的"只做高确定性机会"提示
+//
+// Empty when the frequency is within the target; undertrading is left to the operator and never
+// pushes the trader to open more.
+// 交易频率在目标范围内时为空；交易过少只提示操作员，不会促使交易员增加开仓。
+func overtradingGuidance(language string, freq *risk.TradeFrequency) string {
+	if !freq.Overtrading() {
+		return ""
+	}
+	return fmt.Sprintf(labelsFor(language).Overtrading, freq.Trades, freq.Max)
+}
+
+// smallAccountGuidance returns the prompt guidance narrowing the alternatives of a small account
+// smallAccountGuidance 返回小账户模式下收窄可选动作的 Prompt 提示
+//
+// Empty unless the balance is below SMALL_ACCOUNT_EQUITY.
+// 仅在余额低于 SMALL_ACCOUNT_EQUITY 时返回内容。
+func smallAccountGuidance(language string, cfg *config.Config, balance float64) string {
+	if !cfg.IsSmallAccount(balance) {
+		return ""
+	}
+	return fmt.Sprintf(labelsFor(language).SmallAccount, balance, cfg.SmallAccountEquity, cfg.SmallAccountLeverageFloor())
+}
+
+// pyramidingGuidance returns the prompt guidance describing ADD_LONG / ADD_SHORT and their limits
+// pyramidingGuidance 返回介绍 ADD_LONG / ADD_SHORT 及其限制的 Prompt 提示
+//
+// Empty unless PYRAMID_MAX_ADDS enables add-ons.
+// 仅在 PYRAMID_MAX_ADDS 启用加仓时返回内容。
+func pyramidingGuidance(language string, cfg *config.Config) string {
+	if cfg.PyramidMaxAdds <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(labelsFor(language).Pyramiding, cfg.PyramidMaxAdds, cfg.PyramidMaxSizeMultiple, cfg.PyramidMinProfitPercent)
+}
@@ -0,0 +1,96 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+)
+
+// TestOvertradingGuidance tests that only overtrading adds guidance, in the report language
+// TestOvertradingGuidance 测试只有过度交易时才加入提示，且使用报告语言
+func TestOvertradingGuidance(t *testing.T) {
+	over := &risk.TradeFrequency{Trades: 9, Min: 2, Max: 6}
+
+	zh := overtradingGuidance(ReportLanguageZH, over)
+	if !strings.Contains(zh, "过度交易") || !strings.Contains(zh, "9 次") || !strings.Contains(zh, "6 次") {
+		t.Errorf("Unexpected Chinese guidance: %q", zh)
+	}
+	en := overtradingGuidance(ReportLanguageEN, over)
+	if !strings.Contains(en, "Overtrading") || !strings.Contains(en, "opened 9 positions") || strings.Contains(en, "过度") {
+		t.Errorf("Unexpected English guidance: %q", en)
+	}
+
+	for _, freq := range []*risk.TradeFrequency{
+		nil,
+		{Trades: 6, Max: 6},
+		{Trades: 0, Min: 2, Max: 6}, // 交易过少不影响 Prompt / Undertrading leaves the prompt alone
+		{Trades: 20},
+	} {
+		if got := overtradingGuidance(ReportLanguageZH, freq); got != "" {
+			t.Errorf("overtradingGuidance(%+v) = %q, want empty", freq, got)
+		}
+	}
+}
+
+// TestSmallAccountGuidance tests that only balances below the threshold narrow the prompt
+// TestSmallAccountGuidance 测试只有余额低于阈值时才收窄 Prompt
+func TestSmallAccountGuidance(t *testing.T) {
+	cfg := &config.Config{SmallAccountEquity: 200, SmallAccountMinLeverage: 5, BinanceLeverageMax: 10}
+
+	zh := smallAccountGuidance(ReportLanguageZH, cfg, 80)
+	if !strings.Contains(zh, "小账户模式") || !strings.Contains(zh, "80.00") || !strings.Contains(zh, "5 倍") {
+		t.Errorf("Expected Chinese small-account guidance, got %q", zh)
+	}
+	if en := smallAccountGuidance(ReportLanguageEN, cfg, 80); !strings.Contains(en, "Small-account mode") {
+		t.Errorf("Expected English small-account guidance, got %q", en)
+	}
+
+	if got := smallAccountGuidance(ReportLanguageZH, cfg, 500); got != "" {
+		t.Errorf("Expected no guidance above the threshold, got %q", got)
+	}
+	cfg.SmallAccountEquity = 0
+	if got := smallAccountGuidance(ReportLanguageZH, cfg, 80); got != "" {
+		t.Errorf("Expected no guidance when disabled, got %q", got)
+	}
+}
+
+// TestPyramidingGuidance tests that the add-on rules are only described when add-ons are enabled
+// TestPyramidingGuidance 测试只有启用加仓时才介绍加仓规则
+func TestPyramidingGuidance(t *testing.T) {
+	cfg := &config.Config{PyramidMaxAdds: 2, PyramidMaxSizeMultiple: 2.5, PyramidMinProfitPercent: 1}
+
+	zh := pyramidingGuidance(ReportLanguageZH, cfg)
+	if !strings.Contains(zh, "ADD_LONG") || !strings.Contains(zh, "2 次") || !strings.Contains(zh, "2.5 倍") {
+		t.Errorf("Expected Chinese pyramiding guidance, got %q", zh)
+	}
+	if en := pyramidingGuidance(ReportLanguageEN, cfg); !strings.Contains(en, "Pyramiding") {
+		t.Errorf("Expected English pyramiding guidance, got %q", en)
+	}
+
+	cfg.PyramidMaxAdds = 0
+	if got := pyramidingGuidance(ReportLanguageZH, cfg); got != "" {
+		t.Errorf("Expected no guidance when disabled, got %q", got)
+	}
+}
+
+// TestLossStreakGuidance tests the guidance of each ladder step
+// TestLossStreakGuidance 测试每一级阶梯的提示
+func TestLossStreakGuidance(t *testing.T) {
+	halved := &risk.LossStreak{Current: 2, Longest: 2, Reduce: 2, Halt: 4, SessionStart: time.Now()}
+	if zh := lossStreakGuidance(ReportLanguageZH, halved); !strings.Contains(zh, "减半") || !strings.Contains(zh, "2 笔") {
+		t.Errorf("Expected the halving guidance, got %q", zh)
+	}
+	halted := &risk.LossStreak{Current: 0, Longest: 4, Reduce: 2, Halt: 4, SessionStart: time.Now()}
+	if en := lossStreakGuidance(ReportLanguageEN, halted); !strings.Contains(en, "Losing-streak halt") || !strings.Contains(en, "HOLD") {
+		t.Errorf("Expected the halt guidance, got %q", en)
+	}
+
+	for _, streak := range []*risk.LossStreak{nil, {Current: 1, Longest: 1, Reduce: 2, Halt: 4}, {Current: 6, Longest: 6}} {
+		if got := lossStreakGuidance(ReportLanguageZH, streak); got != "" {
+			t.Errorf("lossStreakGuidance(%+v) = %q, want empty", streak, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/format"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// maxMemoryReasonRunes caps the close reason of a reviewed trade, as LLM close reasons can be long
+// maxMemoryReasonRunes 限制复盘中平仓原因的长度（LLM 平仓理由可能很长）
+const maxMemoryReasonRunes = 60
+
+// RecallTrades feeds each symbol's last DECISION_MEMORY_TRADES closed positions back into the trader prompt
+// RecallTrades 将每个交易对最近 DECISION_MEMORY_TRADES 笔已平仓交易回传给交易员 Prompt
+func (g *SimpleTradingGraph) RecallTrades(db *storage.Storage) {
+	g.closedTrades = db.GetRecentClosedPositions
+}
+
+// recallTradeMemory sets the trade review of every symbol in the reports
+// recallTradeMemory 为每个交易对的报告设置近期交易复盘
+func (g *SimpleTradingGraph) recallTradeMemory() {
+	if g.closedTrades == nil || g.config.DecisionMemoryTrades <= 0 {
+		return
+	}
+	for _, symbol := range g.state.Symbols {
+		positions, err := g.closedTrades(symbol, g.config.DecisionMemoryTrades)
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  读取 %s 近期交易失败: %v", symbol, err))
+			continue
+		}
+		memory := tradeMemory(g.state.Language, positions)
+		g.state.mu.Lock()
+		if reports, ok := g.state.Reports[symbol]; ok {
+			reports.TradeMemory = memory
+		}
+		g.state.mu.Unlock()
+	}
+}
+
+// tradeMemory summarizes closed positions (most recently closed first) in the report language
+// tradeMemory 使用报告语言汇总已平仓持仓（按平仓时间倒序）
+//
+// Consecutive losing stop-outs from the latest trade are called out so the trader reconsiders the setup.
+// 从最近一笔起连续亏损止损出场时单独提示，促使交易员反思入场条件。
+func tradeMemory(language string, positions []*storage.PositionRecord) string {
+	if len(positions) == 0 {
+		return ""
+	}
+	labels := labelsFor(language)
+
+	var sb strings.Builder
+	var wins, losses, stopOuts int
+	var netPnL float64
+	streak := true
+	for _, pos := range positions {
+		closedAt := "-"
+		if pos.CloseTime != nil {
+			closedAt = pos.CloseTime.Format("01-02 15:04")
+		}
+		pnlPercent := 0.0
+		if pos.EntryPrice > 0 && pos.Quantity > 0 {
+			pnlPercent = pos.RealizedPnL / (pos.EntryPrice * pos.Quantity) * 100
+		}
+		sb.WriteString(fmt.Sprintf(labels.TradeMemoryLine, closedAt, strings.ToUpper(pos.Side),
+			format.Adaptive(pos.EntryPrice), format.Adaptive(pos.ClosePrice),
+			truncateRunes(pos.CloseReason, maxMemoryReasonRunes), pos.RealizedPnL, pnlPercent))
+
+		switch {
+		case pos.RealizedPnL > 0:
+			wins++
+		case pos.RealizedPnL < 0:
+			losses++
+		}
+		netPnL += pos.RealizedPnL
+
+		if streak && pos.RealizedPnL < 0 && strings.Contains(pos.CloseReason, "止损") {
+			stopOuts++
+		} else {
+			streak = false
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf(labels.TradeMemoryTotal, wins, losses, netPnL))
+	if stopOuts >= 2 {
+		sb.WriteString(fmt.Sprintf(labels.StopOutStreak, stopOuts))
+	}
+	return sb.String()
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…"
+// truncateRunes 将 s 截断为最多 n 个字符，截断处以 "…" 标记
+func truncateRunes(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
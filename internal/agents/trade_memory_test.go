@@ -0,0 +1,56 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestTradeMemory tests the trade review lines, totals and the stop-out streak warning
+// TestTradeMemory 测试交易复盘明细、合计和连续止损提示
+func TestTradeMemory(t *testing.T) {
+	closedAt := time.Date(2026, 10, 15, 14, 30, 0, 0, time.UTC)
+	closed := func(side string, entry, exit, pnl float64, reason string) *storage.PositionRecord {
+		return &storage.PositionRecord{Side: side, EntryPrice: entry, ClosePrice: exit, Quantity: 0.1,
+			RealizedPnL: pnl, CloseReason: reason, CloseTime: &closedAt, Closed: true}
+	}
+
+	positions := []*storage.PositionRecord{
+		closed("long", 100000, 98000, -200, "止损单触发（币安自动执行）"),
+		closed("short", 99000, 100500, -150, "止损单成交（订单ID: 123）"),
+		closed("long", 95000, 99000, 400, "LLM决策平仓: 趋势减弱"),
+		closed("long", 94000, 93000, -100, "止损单触发（币安自动执行）"),
+	}
+
+	zh := tradeMemory(ReportLanguageZH, positions)
+	for _, want := range []string{
+		"- 10-15 14:30 LONG | 入场 100,000.00 → 出场 98,000.00 | 止损单触发（币安自动执行） | 盈亏 -200.00 USDT (-2.00%)",
+		"合计: 1 胜 3 负，净盈亏 -50.00 USDT",
+		"最近连续 2 笔交易止损出场",
+	} {
+		if !strings.Contains(zh, want) {
+			t.Errorf("Expected %q in:\n%s", want, zh)
+		}
+	}
+
+	// A latest winning trade breaks the streak
+	// 最近一笔盈利时不算连续止损
+	if en := tradeMemory(ReportLanguageEN, positions[2:]); strings.Contains(en, "stopped out") || !strings.Contains(en, "1 wins, 1 losses") {
+		t.Errorf("Unexpected review:\n%s", en)
+	}
+
+	if got := tradeMemory(ReportLanguageZH, nil); got != "" {
+		t.Errorf("Expected no review without trades, got %q", got)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncateRunes("LLM决策平仓:\n 趋势减弱", 8); got != "LLM决策平仓:…" {
+		t.Errorf("truncateRunes = %q", got)
+	}
+	if got := truncateRunes("止损", 8); got != "止损" {
+		t.Errorf("truncateRunes = %q", got)
+	}
+}
@@ -3,28 +3,86 @@ package config
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/oak/crypto-trading-bot/internal/constant"
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds all configuration for the crypto trading bot
 type Config struct {
 	// Project paths
-	ProjectDir   string
-	ResultsDir   string
-	DataCacheDir string
-	DatabasePath string
+	ProjectDir        string
+	ResultsDir        string
+	DataCacheDir      string
+	DatabasePath      string
+	ReportCompression bool // 是否 gzip 压缩保存分析报告 / Gzip-compress stored analysis reports
+
+	// Trading session retention (0 keeps everything)
+	// 交易会话保留策略（0 表示全部保留）
+	SessionRetentionDays  int // 会话保留天数 / Days of sessions to keep
+	SessionRetentionCount int // 每个交易对保留的最新会话数 / Latest sessions kept per symbol
 
 	// LLM Configuration
-	LLMProvider      string
-	DeepThinkLLM     string
-	QuickThinkLLM    string
-	BackendURL       string
-	APIKey           string
-	TraderPromptPath string // 交易策略 Prompt 文件路径 / Path to trader strategy prompt file
+	LLMProvider       string
+	DeepThinkLLM      string
+	QuickThinkLLM     string
+	BackendURL        string
+	APIKey            string
+	TraderPromptPath  string // 交易策略 Prompt 文件路径 / Path to trader strategy prompt file
+	ReportLanguage    string // 报告标题语言 auto/zh/en / Report header language auto/zh/en
+	LLMDecisionBudget int    // LLM 决策时间预算（秒，0 不限制）/ LLM decision budget in seconds (0 = unlimited)
+
+	LLMStreaming         bool // 流式生成决策并推送到 Web 界面 / Stream the decision and push it to the web UI
+	LLMStreamIdleTimeout int  // 流式输出无新内容的最长等待（秒，0 不限制）/ Max wait for new streamed output in seconds (0 = unlimited)
+
+	// LLM fallback chain: retries, then a secondary model/provider, then rules
+	// LLM 后备链：先重试，再使用备用模型/服务商，最后使用规则决策
+	LLMMaxRetries         int    // 主模型失败后的重试次数 / Retries of the primary model after a failure
+	LLMRetryBackoff       int    // 首次重试前的等待（秒，之后逐次翻倍）/ Wait before the first retry in seconds (doubles each time)
+	LLMFallbackModel      string // 备用模型（空表示不启用）/ Secondary model (empty = disabled)
+	LLMFallbackBackendURL string // 备用模型的 API 地址（空表示同 LLM_BACKEND_URL）/ Secondary API URL (empty = LLM_BACKEND_URL)
+	LLMFallbackAPIKey     string // 备用模型的 API Key（空表示同 OPENAI_API_KEY）/ Secondary API key (empty = OPENAI_API_KEY)
+
+	// LLM cost accounting
+	// LLM 费用统计
+	LLMPrices             map[string]LLMPrice // 各模型的价格（未列出的模型按 0 计费）/ Price per model (unlisted models cost 0)
+	LLMMonthlyTokenBudget int                 // 每月 token 预算，用完后暂停 LLM 调用（0 不限制）/ Monthly token budget, LLM calls pause once spent (0 = unlimited)
+
+	// Ensemble mode: several models vote on the decision
+	// 委员会模式：多个模型对决策投票
+	EnsembleModels []string // 参与投票的模型（少于 2 个时不启用）/ Models that vote (disabled with fewer than 2)
+	EnsembleQuorum int      // 执行所需的同向票数（0 表示过半数）/ Votes in one direction needed to execute (0 = majority)
+
+	// Per-symbol decisions: one LLM call per symbol instead of one combined prompt
+	// 按交易对决策：每个交易对单独调用一次 LLM，代替合并的大 Prompt
+	PerSymbolDecisions   bool // 是否按交易对分别决策 / Decide each symbol with its own call
+	PerSymbolConcurrency int  // 同时进行的调用数 / Calls in flight at once
+
+	// Self-consistency voting for high-stakes entries of a single-model decision
+	// 单模型决策中高风险开仓的自洽投票
+	HighStakesLeverage     int      // 杠杆高于此值需确认（0 不检查）/ Leverage above this needs confirmation (0 = not checked)
+	HighStakesPositionSize float64  // 仓位百分比高于此值需确认（0 不检查）/ Position size % above this needs confirmation (0 = not checked)
+	HighStakesSamples      int      // 含原决策在内的投票数（2-3）/ Votes including the original decision (2-3)
+	HighStakesModels       []string // 重新询问使用的模型（为空使用 QUICK_THINK_LLM）/ Models for the re-queries (empty = QUICK_THINK_LLM)
+
+	RiskReviewEnabled bool // 开仓前由 DEEP_THINK_LLM 复核交易员决策 / Review entries with DEEP_THINK_LLM before execution
+
+	// External risk oracle consulted before every entry (disabled when the URL is empty)
+	// 每次开仓前调用的外部风控服务（地址为空时不启用）
+	RiskOracleURL      string // 风控服务地址 / Risk oracle endpoint
+	RiskOracleToken    string // Bearer 令牌（可选）/ Bearer token (optional)
+	RiskOracleBotID    string // 上报给风控服务的机器人标识（为空使用主机名）/ Bot ID sent to the oracle (empty = hostname)
+	RiskOracleTimeout  int    // 请求超时（秒）/ Request timeout in seconds
+	RiskOracleFailOpen bool   // 风控服务不可用时是否放行 / Allow entries when the oracle is unreachable
 
 	// Agent behavior
 	MaxDebateRounds      int
@@ -43,43 +101,256 @@ type Config struct {
 	BinanceAPISecret            string
 	BinanceProxy                string
 	BinanceProxyInsecureSkipTLS bool // 是否跳过代理 TLS 验证（某些代理需要）/ Skip TLS verification for proxy (required by some proxies)
+	BinanceWeightPerMinute      int  // REST 请求权重预算（每分钟，0 表示不限流）/ REST request weight budget per minute (0 disables limiting)
 	BinanceLeverage             int  // 固定杠杆（向后兼容）/ Fixed leverage (backward compatible)
 	BinanceLeverageMin          int  // 最小杠杆 / Minimum leverage
 	BinanceLeverageMax          int  // 最大杠杆 / Maximum leverage
 	BinanceLeverageDynamic      bool // 是否启用动态杠杆 / Enable dynamic leverage
 	BinanceTestMode             bool
 	BinancePositionMode         string
+	BinancePositionModeSwitch   bool   // 启动时无持仓则切换为 BinancePositionMode / Switch to BinancePositionMode at startup when flat
+	BinanceMarginType           string // 保证金类型：cross / isolated / auto（auto 保持交易所设置）/ Margin type: cross, isolated or auto (keeps the exchange setting)
+	AnalysisOnly                bool   // 仅分析：不读取账户、不下单（未配置密钥时自动启用）/ Analysis only: no account access or orders (automatic without keys)
+
+	// Paper trading configuration
+	// 模拟盘配置
+	PaperTrading        bool    // 是否启用模拟盘（本地撮合，不向交易所下单）/ Enable paper trading (local matching, no exchange orders)
+	PaperInitialBalance float64 // 模拟盘初始资金（USDT）/ Paper trading initial balance (USDT)
+	PaperTakerFeeRate   float64 // 模拟盘吃单手续费率 / Paper trading taker fee rate
+	PaperSlippageBps    float64 // 盘口深度不足时的滑点（基点）/ Fallback slippage in bps when book depth is insufficient
+
+	// Shadow mode configuration
+	// 影子模式配置
+	ShadowMode      bool // 是否启用影子模式（记录意向订单，不下单）/ Enable shadow mode (record intended orders, send none)
+	ShadowEvalHours int  // 影子订单的评估时长（小时）/ Hours after which a shadow order is evaluated
 
 	// Trading parameters
 	// 交易参数
 	CryptoSymbols      []string // 交易对列表（支持单个或多个，用逗号分隔）/ Trading pairs list (supports single or multiple, comma-separated)
 	CryptoTimeframe    string   // K线数据时间间隔 / K-line data timeframe
 	TradingInterval    string   // 系统运行间隔（独立于K线间隔）/ System execution interval (independent from K-line timeframe)
+	QuoteAsset         string   // 账户计价/保证金资产（USDT、USDC、FDUSD）/ Account quote / margin asset (USDT, USDC, FDUSD)
+	ActiveInterval     string   // 有持仓时的运行间隔（为空不加速）/ Execution interval while positions are open (empty = no acceleration)
 	CryptoLookbackDays int
 	// PositionSize removed - now uses LLM's position size recommendation
 	// 移除 PositionSize - 现在使用 LLM 的仓位建议
 
 	// Multi-timeframe analysis
 	// 多时间周期分析
-	EnableMultiTimeframe     bool   // 是否启用多时间周期分析 / Enable multi-timeframe analysis
-	CryptoLongerTimeframe    string // 更长期的时间周期（如 4h）/ Longer timeframe (e.g., 4h)
-	CryptoLongerLookbackDays int    // 更长期时间周期的回看天数 / Lookback days for longer timeframe
+	EnableMultiTimeframe     bool     // 是否启用多时间周期分析 / Enable multi-timeframe analysis
+	CryptoLongerTimeframe    string   // 更长期的时间周期（如 4h）/ Longer timeframe (e.g., 4h)
+	CryptoLongerLookbackDays int      // 更长期时间周期的回看天数 / Lookback days for longer timeframe
+	AdvancedIndicators       bool     // 报告中加入 Ichimoku、SuperTrend、StochRSI / Add Ichimoku, SuperTrend and StochRSI to the reports
+	Indicators               []string // 计算并输出的指标（为空使用默认指标集）/ Indicators to compute and report (empty = default set)
+	KlineCacheEnabled        bool     // K 线缓存到数据库，只获取缺失部分 / Cache candles in the database and fetch only the missing tail
 
 	// Analysis options
 	// 分析选项
 	EnableSentimentAnalysis bool // 是否启用市场情绪分析 / Enable sentiment analysis (CryptoOracle API)
 
+	// News headlines for the sentiment report (enabled when a provider is configured)
+	// 情绪报告的新闻头条（配置了任一数据源时启用）
+	CryptoPanicAPIKey string   // CryptoPanic API 令牌 / CryptoPanic API token
+	NewsRSSFeeds      []string // RSS 新闻源地址 / RSS feed URLs
+	NewsMaxHeadlines  int      // 每个交易对最多写入的头条数 / Headlines per symbol in the report
+	NewsMaxAgeHours   int      // 只保留最近 N 小时的头条 / Only keep headlines of the last N hours
+
+	// Fear & Greed Index and on-chain metrics for the sentiment report
+	// 情绪报告的恐慌贪婪指数和链上指标
+	FearGreedEnabled     bool   // 是否获取 alternative.me 恐慌贪婪指数 / Fetch the alternative.me Fear & Greed Index
+	OnChainNetflowURL    string // 交易所净流入 API（{base} 替换为币种，为空不获取）/ Exchange netflow API ({base} = coin, empty disables)
+	OnChainStablecoinURL string // 稳定币供应量 API（为空不获取）/ Stablecoin supply API (empty disables)
+
+	// Long/short ratios and liquidations for the crypto report
+	// 加密货币报告的多空比和爆仓统计
+	LiquidationWindowMinutes int // 爆仓统计窗口（分钟，0 为不监听）/ Liquidation window in minutes (0 disables the stream)
+
 	// Stop-loss management configuration (LLM-driven fixed stop-loss only)
 	// 止损管理配置（仅 LLM 驱动的固定止损）
 	EnableStopLoss         bool    // 是否启用止损管理 / Enable stop-loss management
 	StopLossScopeThreshold float64 // 止损价格变化阈值（百分比）/ Stop-loss price change threshold (percentage)
 
+	// ATR trailing stop
+	// ATR 追踪止损
+	TrailingStopEnabled       bool    // 是否启用 ATR 追踪止损 / Enable ATR trailing stop
+	TrailingStopATRMultiplier float64 // 追踪距离 = N × ATR / Trailing distance = N × ATR
+
+	// Partial take-profit
+	// 分批止盈
+	PartialTPEnabled   bool    // 是否为新持仓自动设置分批止盈 / Auto partial take-profit for new positions
+	PartialTPRMultiple float64 // 分批止盈目标（R 倍数，R=入场价与初始止损距离）/ Target in R multiples (R = entry to initial stop)
+	PartialTPPercent   float64 // 分批止盈平仓比例 0-100 / Percent of position to close at target
+	PartialTPBreakeven bool    // 分批止盈后是否将止损移至保本 / Move stop to breakeven after partial TP
+
+	// Bracket take-profit
+	// 括号单止盈
+	TakeProfitRMultiple float64 // 开仓时与止损一起挂的止盈单目标（R 倍数，0 表示不挂）/ Take-profit placed with the stop at entry, in R multiples (0 disables)
+
+	// Add-ons (pyramiding into winners with ADD_LONG / ADD_SHORT)
+	// 加仓（ADD_LONG / ADD_SHORT 对盈利持仓加仓）
+	PyramidMaxAdds          int     // 每个持仓最多加仓次数（0 表示不允许加仓）/ Max add-ons per position (0 disables add-ons)
+	PyramidMaxSizeMultiple  float64 // 加仓后总数量上限（开仓数量的倍数）/ Max total quantity after add-ons, as a multiple of the opening quantity
+	PyramidMinProfitPercent float64 // 加仓要求的最低浮盈（相对混合入场价的百分比）/ Min unrealized profit vs the blended entry before adding (%)
+
+	// Global risk limits (0 disables a limit)
+	// 全局风控限制（0 表示不启用）
+	RiskMaxPositions         int     // 最大同时持仓数 / Max concurrent positions
+	RiskMaxNotionalPerSymbol float64 // 单个交易对最大名义价值 USDT / Max notional per symbol in USDT
+	RiskMaxEquityAtRisk      float64 // 最大权益风险占比（百分比）/ Max % of equity at risk
+	RiskDailyMaxLoss         float64 // 单日最大亏损（百分比），触发后停止开仓 / Daily max loss %, halts opening
+
+	// Portfolio allocator: ranks the round's entries and trims them to the free margin
+	// 组合分配器：对本轮开仓决策排序，并按可用保证金缩减
+	AllocatorEnabled   bool    // 是否启用组合分配 / Enable the portfolio allocator
+	AllocatorMaxMargin float64 // 本轮开仓可使用的可用余额百分比 / % of the available balance new entries may use per round
+
+	// Position sizing policy
+	// 仓位计算策略
+	SizingPolicy        string  // compound（按当前余额复利）或 fixed（按固定资金）/ compound (current balance) or fixed (fixed capital)
+	SizingFixedCapital  float64 // fixed 模式下 LLM 仓位百分比对应的资金（USDT）/ Capital the LLM percent applies to in fixed mode (USDT)
+	SizingRiskPerTrade  float64 // 单笔风险占资金的百分比，按止损距离/ATR 缩减仓位（0 表示不启用）/ % of capital risked per trade, shrinks size by stop distance / ATR (0 disables)
+	SizingATRMultiplier float64 // 计算仓位时止损距离的 ATR 下限倍数 / ATR multiple used as the minimum stop distance for sizing
+
+	SizingMinNotionalBuffer float64 // 订单价值需超出交易所最小订单价值的百分比 / % the order value must exceed the exchange minimum notional by
+	SizingMinNotionalSkip   bool    // 订单价值不足时跳过并说明原因，而不是报错 / Skip with an explanation instead of failing when the order value is too small
+
+	// Small-account mode (equity below SMALL_ACCOUNT_EQUITY)
+	// 小账户模式（权益低于 SMALL_ACCOUNT_EQUITY）
+	SmallAccountEquity            float64 // 低于该权益（USDT）时启用小账户模式（0 表示不启用）/ Equity (USDT) below which small-account mode applies (0 disables)
+	SmallAccountMinLeverage       int     // 小账户模式的最低杠杆 / Leverage floor in small-account mode
+	SmallAccountNotionalTolerance float64 // 订单价值低于最小要求多少百分比以内时向上取整 / Round up to the minimum notional when short by at most this %
+
+	// Conditional stop-entry orders (BUY_STOP / SELL_STOP)
+	// 条件入场单（BUY_STOP / SELL_STOP）
+	StopEntryExpiryMinutes int // 条件入场单未触发时的有效期（分钟）/ Minutes an untriggered stop entry stays open
+	StopEntryCheckInterval int // Web 模式下检查条件入场单成交的间隔（秒）/ Seconds between stop entry fill checks in web mode
+
+	// Cron schedules (web mode, UTC)
+	// Cron 定时任务（Web 模式，UTC）
+	AnalysisCron     string // 分析任务的 cron 表达式（为空按 TRADING_INTERVAL 对齐）/ Cron for analysis (empty = align to TRADING_INTERVAL)
+	StopReviewCron   string // 两次分析之间复查止损的 cron 表达式（为空不启用）/ Cron for stop reviews between analyses (empty disables)
+	DailyReportCron  string // 发送每日报告的 cron 表达式（为空不启用）/ Cron for the daily report notification (empty disables)
+	WeeklyReportCron string // 发送每周报告的 cron 表达式（为空不启用）/ Cron for the weekly report notification (empty disables)
+	ScheduleJitter   int    // 定时任务的最大随机延迟（秒）/ Max random delay added to each scheduled job (seconds)
+
+	// Funding rate guard
+	// 资金费率限制
+	FundingRateMaxPercent float64 // 开仓方向需支付的资金费率上限（百分比/每次结算，0 表示不启用）/ Max funding % per settlement the opening side may pay (0 disables)
+
+	// Liquidity guard (order book checked before market entries)
+	// 盘口流动性限制（市价开仓前检查订单簿）
+	MaxSpreadBps float64 // 允许的最大买卖价差（基点，0 表示不启用）/ Max bid/ask spread in bps (0 disables)
+	MaxImpactBps float64 // 按计划数量吃单的预计冲击上限（相对中间价的基点，0 表示不启用）/ Max expected impact of the sized order vs the mid price in bps (0 disables)
+
+	// Entry execution algorithm
+	// 开仓执行算法
+	ExecutionAlgo       string  // market（市价单）或 chase（只做 Maker 限价单追价）/ market, or chase (post-only limit orders re-pegged to the touch)
+	ChaseRepegSeconds   int     // 追价间隔：限价单未成交时多少秒后撤单重挂（秒）/ Seconds before an unfilled limit order is re-pegged
+	ChaseTimeoutSeconds int     // 追价超时：超时后剩余数量改用市价单（秒）/ Seconds after which the remaining quantity is sent as a market order
+	ChaseMinNotional    float64 // 仅对名义价值不低于该值的开仓追价（USDT，0 表示全部）/ Only chase entries of at least this notional (USDT, 0 = all)
+
+	// Large order slicing (entries above a notional threshold)
+	// 大单拆分执行（名义价值超过阈值的开仓）
+	LargeOrderAlgo        string  // off、twap（按时间均匀拆成市价单）或 iceberg（每次只挂一片追价限价单）/ off, twap (market slices spread over a window) or iceberg (one chased limit slice visible at a time)
+	LargeOrderMinNotional float64 // 名义价值不低于该值的开仓才拆分（USDT）/ Only entries of at least this notional are sliced (USDT)
+	LargeOrderSlices      int     // 拆分的片数 / Number of slices
+	TWAPWindowSeconds     int     // TWAP 执行窗口（秒），各片均匀分布在窗口内 / TWAP window in seconds the slices are spread over
+
+	// Trade frequency target (opened positions per rolling 24h, 0 disables a bound)
+	// 交易频率目标（滚动 24 小时内的开仓次数，0 表示不启用该边界）
+	TradesPerDayMin int // 每日开仓次数下限，低于时仅在仪表板提示 / Lower bound, only shown on the dashboard
+	TradesPerDayMax int // 每日开仓次数上限，超过时视为过度交易 / Upper bound, above it counts as overtrading
+
+	// Intraday loss-streak ladder (consecutive losing trades in the session, 0 disables a step)
+	// 日内连亏降风险阶梯（本交易时段内的连续亏损笔数，0 表示不启用该级）
+	LossStreakReduce      int // 连续亏损达到该笔数时仓位减半 / Consecutive losses that halve the position size
+	LossStreakHalt        int // 连续亏损达到该笔数时本时段停止开仓 / Consecutive losses that halt entries for the rest of the session
+	LossStreakSessionHour int // 交易时段重置的 UTC 小时（0-23）/ UTC hour the session resets at (0-23)
+
+	// Circuit breaker on repeated Binance order or LLM failures
+	// 币安下单或 LLM 连续失败熔断
+	CircuitBreakerThreshold int // 触发熔断的连续失败次数（0 表示不启用）/ Consecutive failures that pause auto-execution (0 disables)
+	CircuitBreakerCooldown  int // 熔断后暂停自动执行的时长（分钟）/ Minutes auto-execution stays paused after a trip
+
+	// Emergency stop
+	// 急停
+	KillSwitchFile string // 存在时启用急停开关的文件路径（"" 表示不检查）/ Sentinel file that engages the kill switch ("" disables)
+
+	// Decision memory
+	// 决策记忆
+	DecisionMemoryTrades int // 每个交易对写入 Prompt 的最近已平仓交易数（0 表示不启用）/ Recent closed trades per symbol added to the prompt (0 disables)
+
+	// Decision thresholds, shown in the prompt and enforced before execution
+	// 决策门槛，写入 Prompt 并在执行前强制检查
+	MinDecisionConfidence float64 // 开仓决策的最低置信度 0-1（0 表示不检查）/ Minimum confidence of an entry 0-1 (0 disables)
+	MinRiskReward         float64 // 开仓决策的最低预期盈亏比（0 表示不检查）/ Minimum expected risk/reward of an entry (0 disables)
+
+	// Execution filters applied to entries before validation; rejections are saved with the filter name
+	// 执行过滤器，在验证前作用于开仓决策；被拒绝的决策会连同过滤器名称一起保存
+	StopOutCooldownMinutes int // 同一交易对止损出场后暂停开仓的分钟数（0 表示不启用）/ Minutes a symbol can't be entered after a stop-out (0 disables)
+	MaxTradesPerDay        int // 每个 UTC 自然日最多开仓次数（0 表示不限制）/ Maximum entries per UTC day (0 disables)
+
+	// Profit sweeping to the spot wallet
+	// 利润提取到现货钱包
+	ProfitSweepEnabled        bool    // 是否启用利润提取 / Whether profit sweeping is enabled
+	ProfitSweepInitialCapital float64 // 初始本金（USDT，0 表示模拟盘使用初始资金）/ Initial capital (USDT, 0 = paper initial balance)
+	ProfitSweepThreshold      float64 // 权益超过基准的百分比达到该值时提取 / Sweep when equity exceeds the baseline by this %
+	ProfitSweepPercent        float64 // 每次提取超出部分的百分比 / % of the excess transferred per sweep
+	ProfitSweepInterval       int     // 检查间隔（秒）/ Seconds between checks
+
+	// End-of-day state checkpoint
+	// 日终状态快照
+	CheckpointEnabled bool // 是否每天保存状态快照并对比前一天 / Whether to snapshot the state daily and diff it against the previous day
+	CheckpointHour    int  // 快照的 UTC 小时（0-23）/ UTC hour the checkpoint is taken at (0-23)
+
+	// Exchange maintenance awareness
+	// 交易所维护感知
+	MaintenanceCheckInterval int    // 币安系统状态检查间隔（秒，0 表示不检查）/ Seconds between Binance system status checks (0 disables)
+	MaintenanceStatusURL     string // 系统状态接口地址（为空时实盘使用币安主网接口）/ System status endpoint (empty uses the Binance mainnet endpoint for live trading)
+
+	// Clock drift against the Binance server time
+	// 与币安服务器时间的时钟偏差
+	ClockDriftCheckInterval int // 时钟偏差检查及时间偏移校正间隔（秒，0 表示只在启动时检查）/ Seconds between drift checks and offset corrections (0 = startup only)
+	ClockDriftAlertMs       int // 偏差超过该值（毫秒）时告警 / Alert when the drift exceeds this many milliseconds
+
+	// Liquidation guard
+	// 强平距离监控
+	LiquidationGuardDistancePercent float64 // 标记价格距强平价低于该百分比时触发（0 表示不监控）/ Trigger when the mark price is within this % of the liquidation price (0 disables)
+	LiquidationGuardAction          string  // 触发后的动作：reduce / add_margin / alert / Action taken: reduce, add_margin or alert
+	LiquidationGuardReducePercent   float64 // 减仓比例（%）/ Percentage of the position closed when reducing
+	LiquidationGuardInterval        int     // 检查间隔（秒）/ Seconds between checks
+
+	// Binance user data stream
+	// 币安用户数据流
+	UserDataStreamEnabled bool // 是否订阅用户数据流获取实时成交、持仓和强平事件 / Whether to subscribe to the user data stream for real-time fill, position and liquidation events
+
+	// Notification webhooks (empty disables a sink)
+	// 通知 Webhook（为空表示不启用该渠道）
+	NotifyDiscordWebhook string // Discord 频道 Webhook 地址 / Discord channel webhook URL
+	NotifySlackWebhook   string // Slack Incoming Webhook 地址 / Slack incoming webhook URL
+	NotifyWebhookURL     string // 通用 HTTP Webhook 地址 / Generic HTTP webhook URL
+
+	// In-memory history retention
+	// 内存历史保留策略
+	HistoryPricePoints   int // 每个持仓在内存中保留的价格点数 / Price points kept in memory per position
+	HistoryTradeResults  int // 执行器在内存中保留的交易结果条数 / Trade results kept in memory by the executor
+	HistoryFlushInterval int // 历史定期写入数据库的间隔（秒，0 表示不定期写入）/ Seconds between periodic flushes (0 disables)
+
+	// Graceful shutdown
+	// 优雅关闭
+	ShutdownTimeout int // 关闭时等待进行中分析批次的最长时间（秒）/ Max seconds to wait for an in-flight batch on shutdown
+
+	// Strategy freeze window
+	// 策略冻结观察期
+	FreezeCycles int // Prompt / 风控配置变更后仅影子模式运行的周期数（0 表示不冻结）/ Shadow-only cycles after a prompt / risk change (0 disables)
+
 	// Memory system
 	UseMemory  bool
 	MemoryTopK int
 
 	// Debug options
 	DebugMode        bool
+	LogFormat        string // 日志格式：console 或 json / Log format: console or json
 	SelectedAnalysts []string
 	AutoExecute      bool
 
@@ -88,6 +359,16 @@ type Config struct {
 	WebPort     int
 	WebUsername string // Web 登录用户名 / Web login username
 	WebPassword string // Web 登录密码 / Web login password
+	WebAPIToken string // Web API 令牌（为空则只允许登录会话）/ Web API token (empty = login sessions only)
+
+	WebLoginRateLimit int  // 每个 IP 每分钟最多登录尝试次数 / Max login attempts per IP per minute
+	WebSecureCookie   bool // 会话 Cookie 仅通过 HTTPS 发送 / Send the session cookie over HTTPS only
+
+	// Public performance page
+	// 公开业绩页配置
+	PublicPageEnabled bool   // 是否启用公开业绩页 / Enable public performance page
+	PublicPageToken   string // 分享链接令牌（/public/<token>）/ Share link token (/public/<token>)
+	PublicRateLimit   int    // 每个 IP 每分钟最大请求数 / Max requests per minute per IP
 }
 
 // LoadConfig loads configuration from .env file or a custom path
@@ -116,18 +397,52 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 
 	cfg := &Config{
 		// Project paths
-		ProjectDir:   getProjectDir(),
-		ResultsDir:   viper.GetString("RESULTS_DIR"),
-		DataCacheDir: viper.GetString("DATA_CACHE_DIR"),
-		DatabasePath: viper.GetString("DATABASE_PATH"),
+		ProjectDir:        getProjectDir(),
+		ResultsDir:        viper.GetString("RESULTS_DIR"),
+		DataCacheDir:      viper.GetString("DATA_CACHE_DIR"),
+		DatabasePath:      viper.GetString("DATABASE_PATH"),
+		ReportCompression: viper.GetBool("REPORT_COMPRESSION"),
+
+		// Trading session retention
+		SessionRetentionDays:  viper.GetInt("SESSION_RETENTION_DAYS"),
+		SessionRetentionCount: viper.GetInt("SESSION_RETENTION_COUNT"),
 
 		// LLM Configuration
-		LLMProvider:      viper.GetString("LLM_PROVIDER"),
-		DeepThinkLLM:     viper.GetString("DEEP_THINK_LLM"),
-		QuickThinkLLM:    viper.GetString("QUICK_THINK_LLM"),
-		BackendURL:       viper.GetString("LLM_BACKEND_URL"),
-		APIKey:           viper.GetString("OPENAI_API_KEY"),
-		TraderPromptPath: viper.GetString("TRADER_PROMPT_PATH"),
+		LLMProvider:       viper.GetString("LLM_PROVIDER"),
+		DeepThinkLLM:      viper.GetString("DEEP_THINK_LLM"),
+		QuickThinkLLM:     viper.GetString("QUICK_THINK_LLM"),
+		BackendURL:        viper.GetString("LLM_BACKEND_URL"),
+		APIKey:            viper.GetString("OPENAI_API_KEY"),
+		TraderPromptPath:  viper.GetString("TRADER_PROMPT_PATH"),
+		ReportLanguage:    viper.GetString("REPORT_LANGUAGE"),
+		LLMDecisionBudget: viper.GetInt("LLM_DECISION_BUDGET"),
+		LLMStreaming:      viper.GetBool("LLM_STREAMING"),
+		EnsembleQuorum:    viper.GetInt("ENSEMBLE_QUORUM"),
+		RiskReviewEnabled: viper.GetBool("RISK_REVIEW_ENABLED"),
+
+		PerSymbolDecisions:   viper.GetBool("PER_SYMBOL_DECISIONS"),
+		PerSymbolConcurrency: viper.GetInt("PER_SYMBOL_CONCURRENCY"),
+
+		HighStakesLeverage:     viper.GetInt("HIGH_STAKES_LEVERAGE"),
+		HighStakesPositionSize: viper.GetFloat64("HIGH_STAKES_POSITION_SIZE"),
+		HighStakesSamples:      viper.GetInt("HIGH_STAKES_SAMPLES"),
+
+		RiskOracleURL:      viper.GetString("RISK_ORACLE_URL"),
+		RiskOracleToken:    viper.GetString("RISK_ORACLE_TOKEN"),
+		RiskOracleBotID:    viper.GetString("RISK_ORACLE_BOT_ID"),
+		RiskOracleTimeout:  viper.GetInt("RISK_ORACLE_TIMEOUT"),
+		RiskOracleFailOpen: viper.GetBool("RISK_ORACLE_FAIL_OPEN"),
+
+		LLMStreamIdleTimeout: viper.GetInt("LLM_STREAM_IDLE_TIMEOUT"),
+
+		LLMMaxRetries:         viper.GetInt("LLM_MAX_RETRIES"),
+		LLMRetryBackoff:       viper.GetInt("LLM_RETRY_BACKOFF"),
+		LLMFallbackModel:      viper.GetString("LLM_FALLBACK_MODEL"),
+		LLMFallbackBackendURL: viper.GetString("LLM_FALLBACK_BACKEND_URL"),
+		LLMFallbackAPIKey:     viper.GetString("LLM_FALLBACK_API_KEY"),
+
+		LLMPrices:             parseLLMPrices(viper.GetString("LLM_PRICES")),
+		LLMMonthlyTokenBudget: viper.GetInt("LLM_MONTHLY_TOKEN_BUDGET"),
 
 		// Agent behavior
 		MaxDebateRounds:      viper.GetInt("MAX_DEBATE_ROUNDS"),
@@ -145,13 +460,31 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		BinanceAPISecret:            viper.GetString("BINANCE_API_SECRET"),
 		BinanceProxy:                viper.GetString("BINANCE_PROXY"),
 		BinanceProxyInsecureSkipTLS: viper.GetBool("BINANCE_PROXY_INSECURE_SKIP_TLS"),
+		BinanceWeightPerMinute:      viper.GetInt("BINANCE_WEIGHT_PER_MINUTE"),
 		BinanceLeverage:             viper.GetInt("BINANCE_LEVERAGE"),
 		BinanceTestMode:             viper.GetBool("BINANCE_TEST_MODE"),
 		BinancePositionMode:         viper.GetString("BINANCE_POSITION_MODE"),
+		BinancePositionModeSwitch:   viper.GetBool("BINANCE_POSITION_MODE_SWITCH"),
+		BinanceMarginType:           strings.ToLower(viper.GetString("BINANCE_MARGIN_TYPE")),
+		AnalysisOnly:                viper.GetBool("ANALYSIS_ONLY"),
+
+		// Paper trading configuration
+		// 模拟盘配置
+		PaperTrading:        viper.GetBool("PAPER_TRADING"),
+		PaperInitialBalance: viper.GetFloat64("PAPER_INITIAL_BALANCE"),
+		PaperTakerFeeRate:   viper.GetFloat64("PAPER_TAKER_FEE_RATE"),
+		PaperSlippageBps:    viper.GetFloat64("PAPER_SLIPPAGE_BPS"),
+
+		// Shadow mode configuration
+		// 影子模式配置
+		ShadowMode:      viper.GetBool("SHADOW_MODE"),
+		ShadowEvalHours: viper.GetInt("SHADOW_EVAL_HOURS"),
 
 		// Trading parameters
 		CryptoTimeframe:    viper.GetString("CRYPTO_TIMEFRAME"),
 		TradingInterval:    viper.GetString("TRADING_INTERVAL"),
+		QuoteAsset:         strings.ToUpper(strings.TrimSpace(viper.GetString("QUOTE_ASSET"))),
+		ActiveInterval:     viper.GetString("ACTIVE_TRADING_INTERVAL"),
 		CryptoLookbackDays: viper.GetInt("CRYPTO_LOOKBACK_DAYS"),
 		// PositionSize removed - now uses LLM's position size recommendation
 
@@ -160,20 +493,204 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		EnableMultiTimeframe:     viper.GetBool("ENABLE_MULTI_TIMEFRAME"),
 		CryptoLongerTimeframe:    viper.GetString("CRYPTO_LONGER_TIMEFRAME"),
 		CryptoLongerLookbackDays: viper.GetInt("CRYPTO_LONGER_LOOKBACK_DAYS"),
+		AdvancedIndicators:       viper.GetBool("ADVANCED_INDICATORS"),
+		KlineCacheEnabled:        viper.GetBool("KLINE_CACHE_ENABLED"),
 
 		// Analysis options
 		EnableSentimentAnalysis: viper.GetBool("ENABLE_SENTIMENT_ANALYSIS"),
 
+		// News headlines
+		CryptoPanicAPIKey: viper.GetString("CRYPTOPANIC_API_KEY"),
+		NewsMaxHeadlines:  viper.GetInt("NEWS_MAX_HEADLINES"),
+		NewsMaxAgeHours:   viper.GetInt("NEWS_MAX_AGE_HOURS"),
+
+		// Fear & Greed Index and on-chain metrics
+		FearGreedEnabled:     viper.GetBool("FEAR_GREED_ENABLED"),
+		OnChainNetflowURL:    viper.GetString("ONCHAIN_NETFLOW_URL"),
+		OnChainStablecoinURL: viper.GetString("ONCHAIN_STABLECOIN_URL"),
+
+		// Long/short ratios and liquidations
+		LiquidationWindowMinutes: viper.GetInt("LIQUIDATION_WINDOW_MINUTES"),
+
 		// Stop-loss management (LLM-driven)
 		EnableStopLoss:         viper.GetBool("ENABLE_STOPLOSS"),
 		StopLossScopeThreshold: viper.GetFloat64("STOPLOSS_SCOPE_THRESHOLD"),
 
+		// ATR trailing stop
+		// ATR 追踪止损
+		TrailingStopEnabled:       viper.GetBool("TRAILING_STOP_ENABLED"),
+		TrailingStopATRMultiplier: viper.GetFloat64("TRAILING_STOP_ATR_MULTIPLIER"),
+
+		// Partial take-profit
+		// 分批止盈
+		PartialTPEnabled:   viper.GetBool("PARTIAL_TP_ENABLED"),
+		PartialTPRMultiple: viper.GetFloat64("PARTIAL_TP_R_MULTIPLE"),
+		PartialTPPercent:   viper.GetFloat64("PARTIAL_TP_PERCENT"),
+		PartialTPBreakeven: viper.GetBool("PARTIAL_TP_BREAKEVEN"),
+
+		// Bracket take-profit
+		// 括号单止盈
+		TakeProfitRMultiple: viper.GetFloat64("TAKE_PROFIT_R_MULTIPLE"),
+
+		// Add-ons
+		// 加仓
+		PyramidMaxAdds:          viper.GetInt("PYRAMID_MAX_ADDS"),
+		PyramidMaxSizeMultiple:  viper.GetFloat64("PYRAMID_MAX_SIZE_MULTIPLE"),
+		PyramidMinProfitPercent: viper.GetFloat64("PYRAMID_MIN_PROFIT_PERCENT"),
+
+		// Global risk limits
+		// 全局风控限制
+		RiskMaxPositions:         viper.GetInt("RISK_MAX_POSITIONS"),
+		RiskMaxNotionalPerSymbol: viper.GetFloat64("RISK_MAX_NOTIONAL_PER_SYMBOL"),
+		RiskMaxEquityAtRisk:      viper.GetFloat64("RISK_MAX_EQUITY_AT_RISK"),
+		RiskDailyMaxLoss:         viper.GetFloat64("RISK_DAILY_MAX_LOSS"),
+
+		// Portfolio allocator
+		// 组合分配器
+		AllocatorEnabled:   viper.GetBool("ALLOCATOR_ENABLED"),
+		AllocatorMaxMargin: viper.GetFloat64("ALLOCATOR_MAX_MARGIN_PERCENT"),
+
+		// Position sizing policy
+		// 仓位计算策略
+		SizingPolicy:        viper.GetString("SIZING_POLICY"),
+		SizingFixedCapital:  viper.GetFloat64("SIZING_FIXED_CAPITAL"),
+		SizingRiskPerTrade:  viper.GetFloat64("SIZING_RISK_PER_TRADE"),
+		SizingATRMultiplier: viper.GetFloat64("SIZING_ATR_MULTIPLIER"),
+
+		SizingMinNotionalBuffer: viper.GetFloat64("SIZING_MIN_NOTIONAL_BUFFER"),
+		SizingMinNotionalSkip:   viper.GetBool("SIZING_MIN_NOTIONAL_SKIP"),
+
+		SmallAccountEquity:            viper.GetFloat64("SMALL_ACCOUNT_EQUITY"),
+		SmallAccountMinLeverage:       viper.GetInt("SMALL_ACCOUNT_MIN_LEVERAGE"),
+		SmallAccountNotionalTolerance: viper.GetFloat64("SMALL_ACCOUNT_NOTIONAL_TOLERANCE"),
+
+		// Conditional stop-entry orders
+		// 条件入场单
+		StopEntryExpiryMinutes: viper.GetInt("STOP_ENTRY_EXPIRY_MINUTES"),
+		StopEntryCheckInterval: viper.GetInt("STOP_ENTRY_CHECK_INTERVAL"),
+
+		// Cron schedules
+		// Cron 定时任务
+		AnalysisCron:     strings.TrimSpace(viper.GetString("ANALYSIS_CRON")),
+		StopReviewCron:   strings.TrimSpace(viper.GetString("STOP_REVIEW_CRON")),
+		DailyReportCron:  strings.TrimSpace(viper.GetString("DAILY_REPORT_CRON")),
+		WeeklyReportCron: strings.TrimSpace(viper.GetString("WEEKLY_REPORT_CRON")),
+		ScheduleJitter:   viper.GetInt("SCHEDULE_JITTER"),
+
+		// Funding rate guard
+		// 资金费率限制
+		FundingRateMaxPercent: viper.GetFloat64("FUNDING_RATE_MAX_PERCENT"),
+
+		// Liquidity guard
+		// 盘口流动性限制
+		MaxSpreadBps: viper.GetFloat64("MAX_SPREAD_BPS"),
+		MaxImpactBps: viper.GetFloat64("MAX_IMPACT_BPS"),
+
+		// Entry execution algorithm
+		// 开仓执行算法
+		ExecutionAlgo:       strings.ToLower(strings.TrimSpace(viper.GetString("EXECUTION_ALGO"))),
+		ChaseRepegSeconds:   viper.GetInt("CHASE_REPEG_SECONDS"),
+		ChaseTimeoutSeconds: viper.GetInt("CHASE_TIMEOUT_SECONDS"),
+		ChaseMinNotional:    viper.GetFloat64("CHASE_MIN_NOTIONAL"),
+
+		// Large order slicing
+		// 大单拆分执行
+		LargeOrderAlgo:        strings.ToLower(strings.TrimSpace(viper.GetString("LARGE_ORDER_ALGO"))),
+		LargeOrderMinNotional: viper.GetFloat64("LARGE_ORDER_MIN_NOTIONAL"),
+		LargeOrderSlices:      viper.GetInt("LARGE_ORDER_SLICES"),
+		TWAPWindowSeconds:     viper.GetInt("TWAP_WINDOW_SECONDS"),
+
+		// Trade frequency target
+		// 交易频率目标
+		TradesPerDayMin: viper.GetInt("TRADES_PER_DAY_MIN"),
+		TradesPerDayMax: viper.GetInt("TRADES_PER_DAY_MAX"),
+
+		// Intraday loss-streak ladder
+		// 日内连亏降风险阶梯
+		LossStreakReduce:      viper.GetInt("LOSS_STREAK_REDUCE"),
+		LossStreakHalt:        viper.GetInt("LOSS_STREAK_HALT"),
+		LossStreakSessionHour: viper.GetInt("LOSS_STREAK_SESSION_HOUR"),
+
+		CircuitBreakerThreshold: viper.GetInt("CIRCUIT_BREAKER_THRESHOLD"),
+		CircuitBreakerCooldown:  viper.GetInt("CIRCUIT_BREAKER_COOLDOWN"),
+
+		KillSwitchFile: viper.GetString("KILL_SWITCH_FILE"),
+
+		// Decision memory
+		// 决策记忆
+		DecisionMemoryTrades: viper.GetInt("DECISION_MEMORY_TRADES"),
+
+		// Decision thresholds
+		// 决策门槛
+		MinDecisionConfidence: viper.GetFloat64("MIN_DECISION_CONFIDENCE"),
+		MinRiskReward:         viper.GetFloat64("MIN_RISK_REWARD"),
+
+		// Execution filters
+		// 执行过滤器
+		StopOutCooldownMinutes: viper.GetInt("STOP_OUT_COOLDOWN_MINUTES"),
+		MaxTradesPerDay:        viper.GetInt("MAX_TRADES_PER_DAY"),
+
+		// Profit sweeping
+		// 利润提取
+		ProfitSweepEnabled:        viper.GetBool("PROFIT_SWEEP_ENABLED"),
+		ProfitSweepInitialCapital: viper.GetFloat64("PROFIT_SWEEP_INITIAL_CAPITAL"),
+		ProfitSweepThreshold:      viper.GetFloat64("PROFIT_SWEEP_THRESHOLD"),
+		ProfitSweepPercent:        viper.GetFloat64("PROFIT_SWEEP_PERCENT"),
+		ProfitSweepInterval:       viper.GetInt("PROFIT_SWEEP_INTERVAL"),
+
+		// End-of-day state checkpoint
+		// 日终状态快照
+		CheckpointEnabled: viper.GetBool("CHECKPOINT_ENABLED"),
+		CheckpointHour:    viper.GetInt("CHECKPOINT_HOUR"),
+
+		// Exchange maintenance awareness
+		// 交易所维护感知
+		MaintenanceCheckInterval: viper.GetInt("MAINTENANCE_CHECK_INTERVAL"),
+		MaintenanceStatusURL:     viper.GetString("MAINTENANCE_STATUS_URL"),
+
+		// Clock drift
+		// 时钟偏差
+		ClockDriftCheckInterval: viper.GetInt("CLOCK_DRIFT_CHECK_INTERVAL"),
+		ClockDriftAlertMs:       viper.GetInt("CLOCK_DRIFT_ALERT_MS"),
+
+		// Liquidation guard
+		// 强平距离监控
+		LiquidationGuardDistancePercent: viper.GetFloat64("LIQUIDATION_GUARD_DISTANCE_PERCENT"),
+		LiquidationGuardAction:          strings.ToLower(viper.GetString("LIQUIDATION_GUARD_ACTION")),
+		LiquidationGuardReducePercent:   viper.GetFloat64("LIQUIDATION_GUARD_REDUCE_PERCENT"),
+		LiquidationGuardInterval:        viper.GetInt("LIQUIDATION_GUARD_INTERVAL"),
+
+		// Binance user data stream
+		// 币安用户数据流
+		UserDataStreamEnabled: viper.GetBool("USER_DATA_STREAM_ENABLED"),
+
+		// Notification webhooks
+		// 通知 Webhook
+		NotifyDiscordWebhook: viper.GetString("NOTIFY_DISCORD_WEBHOOK"),
+		NotifySlackWebhook:   viper.GetString("NOTIFY_SLACK_WEBHOOK"),
+		NotifyWebhookURL:     viper.GetString("NOTIFY_WEBHOOK_URL"),
+
+		// In-memory history retention
+		// 内存历史保留策略
+		HistoryPricePoints:   viper.GetInt("HISTORY_PRICE_POINTS"),
+		HistoryTradeResults:  viper.GetInt("HISTORY_TRADE_RESULTS"),
+		HistoryFlushInterval: viper.GetInt("HISTORY_FLUSH_INTERVAL"),
+
+		// Graceful shutdown
+		// 优雅关闭
+		ShutdownTimeout: viper.GetInt("SHUTDOWN_TIMEOUT"),
+
+		// Strategy freeze window
+		// 策略冻结观察期
+		FreezeCycles: viper.GetInt("FREEZE_CYCLES"),
+
 		// Memory system
 		UseMemory:  viper.GetBool("USE_MEMORY"),
 		MemoryTopK: viper.GetInt("MEMORY_TOP_K"),
 
 		// Debug options
 		DebugMode:        viper.GetBool("DEBUG_MODE"),
+		LogFormat:        viper.GetString("LOG_FORMAT"),
 		SelectedAnalysts: strings.Split(viper.GetString("SELECTED_ANALYSTS"), ","),
 		AutoExecute:      viper.GetBool("AUTO_EXECUTE"),
 
@@ -182,6 +699,16 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		WebPort:     viper.GetInt("WEB_PORT"),
 		WebUsername: viper.GetString("WEB_USERNAME"),
 		WebPassword: viper.GetString("WEB_PASSWORD"),
+		WebAPIToken: viper.GetString("WEB_API_TOKEN"),
+
+		WebLoginRateLimit: viper.GetInt("WEB_LOGIN_RATE_LIMIT"),
+		WebSecureCookie:   viper.GetBool("WEB_SECURE_COOKIE"),
+
+		// Public performance page
+		// 公开业绩页配置
+		PublicPageEnabled: viper.GetBool("PUBLIC_PAGE_ENABLED"),
+		PublicPageToken:   viper.GetString("PUBLIC_PAGE_TOKEN"),
+		PublicRateLimit:   viper.GetInt("PUBLIC_RATE_LIMIT"),
 	}
 
 	// Auto-calculate lookback days if not set
@@ -222,6 +749,41 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		cfg.CryptoSymbols = []string{"BTC/USDT"}
 	}
 
+	// Parse ensemble models (comma-separated, duplicates and blanks dropped)
+	// 解析委员会模型（逗号分隔，去除重复和空值）
+	seenModels := make(map[string]bool)
+	for _, model := range strings.Split(viper.GetString("ENSEMBLE_MODELS"), ",") {
+		model = strings.TrimSpace(model)
+		if model != "" && !seenModels[model] {
+			seenModels[model] = true
+			cfg.EnsembleModels = append(cfg.EnsembleModels, model)
+		}
+	}
+
+	// Parse high-stakes confirmation models (comma-separated, blanks dropped; repeats allowed)
+	// 解析高风险确认模型（逗号分隔，去除空值；允许重复）
+	for _, model := range strings.Split(viper.GetString("HIGH_STAKES_MODELS"), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			cfg.HighStakesModels = append(cfg.HighStakesModels, model)
+		}
+	}
+
+	// Parse the indicator set (comma-separated, lowercased, blanks dropped; names are checked by dataflows)
+	// 解析指标集（逗号分隔，转小写，去除空值；名称由 dataflows 校验）
+	for _, name := range strings.Split(viper.GetString("INDICATORS"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			cfg.Indicators = append(cfg.Indicators, name)
+		}
+	}
+
+	// Parse RSS news feeds (comma-separated, blanks dropped)
+	// 解析 RSS 新闻源（逗号分隔，去除空值）
+	for _, feed := range strings.Split(viper.GetString("NEWS_RSS_FEEDS"), ",") {
+		if feed = strings.TrimSpace(feed); feed != "" {
+			cfg.NewsRSSFeeds = append(cfg.NewsRSSFeeds, feed)
+		}
+	}
+
 	// Parse leverage range (support "10-20" format)
 	// 解析杠杆范围（支持 "10-20" 格式）
 	leverageStr := viper.GetString("BINANCE_LEVERAGE")
@@ -270,12 +832,47 @@ func setDefaults() {
 	viper.SetDefault("RESULTS_DIR", "./crypto_results")
 	viper.SetDefault("DATA_CACHE_DIR", "./internal/dataflows/data_cache")
 	viper.SetDefault("DATABASE_PATH", "./data/trading.db")
+	viper.SetDefault("REPORT_COMPRESSION", true)  // 默认压缩报告 / Compress reports by default
+	viper.SetDefault("SESSION_RETENTION_DAYS", 0) // 默认全部保留 / Keep all sessions by default
+	viper.SetDefault("SESSION_RETENTION_COUNT", 0)
 
 	viper.SetDefault("LLM_PROVIDER", "openai")
 	viper.SetDefault("DEEP_THINK_LLM", "gpt-4o")
 	viper.SetDefault("QUICK_THINK_LLM", "gpt-4o-mini")
 	viper.SetDefault("LLM_BACKEND_URL", "https://api.openai.com/v1")
 	viper.SetDefault("TRADER_PROMPT_PATH", "prompts/trader_system.txt")
+	viper.SetDefault("REPORT_LANGUAGE", "auto") // 根据模型自动选择 / Pick from the model name
+	viper.SetDefault("LLM_DECISION_BUDGET", 0)  // 默认不限制 LLM 决策时间 / No LLM decision budget by default
+	viper.SetDefault("ENSEMBLE_MODELS", "")     // 默认单模型决策 / Single-model decisions by default
+	viper.SetDefault("ENSEMBLE_QUORUM", 0)      // 默认过半数 / Majority by default
+
+	viper.SetDefault("PER_SYMBOL_DECISIONS", false) // 默认合并为一次调用 / One combined call by default
+	viper.SetDefault("PER_SYMBOL_CONCURRENCY", 3)   // 最多同时 3 个调用 / Up to 3 calls at once
+
+	viper.SetDefault("HIGH_STAKES_LEVERAGE", 0)        // 默认不按杠杆确认 / No leverage check by default
+	viper.SetDefault("HIGH_STAKES_POSITION_SIZE", 0.0) // 默认不按仓位确认 / No position size check by default
+	viper.SetDefault("HIGH_STAKES_SAMPLES", 3)         // 原决策加两次重新询问 / The original plus two re-queries
+	viper.SetDefault("HIGH_STAKES_MODELS", "")         // 默认用同一模型重新询问 / Re-query the same model by default
+
+	viper.SetDefault("RISK_REVIEW_ENABLED", false) // 默认不复核 / No second-pass review by default
+
+	viper.SetDefault("RISK_ORACLE_URL", "")          // 默认不调用外部风控 / No external risk oracle by default
+	viper.SetDefault("RISK_ORACLE_TOKEN", "")        // 默认不带令牌 / No token by default
+	viper.SetDefault("RISK_ORACLE_BOT_ID", "")       // 默认使用主机名 / Hostname by default
+	viper.SetDefault("RISK_ORACLE_TIMEOUT", 5)       // 5 秒超时 / 5-second timeout
+	viper.SetDefault("RISK_ORACLE_FAIL_OPEN", false) // 风控服务不可用时拒绝开仓 / Reject entries when the oracle is unreachable
+
+	viper.SetDefault("LLM_STREAMING", false)        // 默认一次性生成 / Blocking generation by default
+	viper.SetDefault("LLM_STREAM_IDLE_TIMEOUT", 30) // 30 秒无输出视为卡住 / 30s without output counts as hung
+
+	viper.SetDefault("LLM_MAX_RETRIES", 2)           // 主模型最多重试 2 次 / Retry the primary model up to twice
+	viper.SetDefault("LLM_RETRY_BACKOFF", 2)         // 2 秒、4 秒…… / 2s, 4s, ...
+	viper.SetDefault("LLM_FALLBACK_MODEL", "")       // 默认无备用模型 / No secondary model by default
+	viper.SetDefault("LLM_FALLBACK_BACKEND_URL", "") // 默认同 LLM_BACKEND_URL / Same as LLM_BACKEND_URL by default
+	viper.SetDefault("LLM_FALLBACK_API_KEY", "")     // 默认同 OPENAI_API_KEY / Same as OPENAI_API_KEY by default
+
+	viper.SetDefault("LLM_PRICES", "")              // 默认不计费，只统计 token / Tokens only, no prices by default
+	viper.SetDefault("LLM_MONTHLY_TOKEN_BUDGET", 0) // 默认不限制 / Unlimited by default
 
 	viper.SetDefault("MAX_DEBATE_ROUNDS", 2)
 	viper.SetDefault("MAX_RISK_DISCUSS_ROUNDS", 2)
@@ -289,31 +886,167 @@ func setDefaults() {
 	viper.SetDefault("BINANCE_LEVERAGE", 10)
 	viper.SetDefault("BINANCE_TEST_MODE", true)
 	viper.SetDefault("BINANCE_POSITION_MODE", "auto")
+	viper.SetDefault("BINANCE_POSITION_MODE_SWITCH", false) // 默认只检测不切换 / Detect only by default
+	viper.SetDefault("BINANCE_MARGIN_TYPE", "auto")         // 默认保持交易所设置 / Keep the exchange setting by default
+	viper.SetDefault("BINANCE_WEIGHT_PER_MINUTE", 1200)     // 币安 IP 上限 2400 的一半 / Half of Binance's 2400 per-IP limit
+	viper.SetDefault("ANALYSIS_ONLY", false)
+
+	viper.SetDefault("PAPER_TRADING", false)
+	viper.SetDefault("PAPER_INITIAL_BALANCE", 10000.0)
+	viper.SetDefault("PAPER_TAKER_FEE_RATE", 0.0004)
+	viper.SetDefault("PAPER_SLIPPAGE_BPS", 2.0)
+
+	viper.SetDefault("SHADOW_MODE", false)
+	viper.SetDefault("SHADOW_EVAL_HOURS", 4)
 
 	viper.SetDefault("CRYPTO_SYMBOL", "BTC/USDT")
 	viper.SetDefault("CRYPTO_TIMEFRAME", "1h")
+	viper.SetDefault("QUOTE_ASSET", "USDT")
 	// POSITION_SIZE removed - now uses LLM's position size recommendation
 	// 移除 POSITION_SIZE - 现在使用 LLM 的仓位建议
 
 	// Analysis defaults
 	// 分析选项默认值
 	viper.SetDefault("ENABLE_SENTIMENT_ANALYSIS", true) // 默认启用情绪分析 / Enable sentiment analysis by default
+	viper.SetDefault("ADVANCED_INDICATORS", false)      // 默认不输出高级指标，控制 Prompt 长度 / Off by default to keep the prompt short
+	viper.SetDefault("INDICATORS", "")                  // 默认使用内置指标集 / Built-in indicator set by default
+	viper.SetDefault("KLINE_CACHE_ENABLED", false)      // 默认每次完整获取 K 线 / Full kline fetch by default
+	viper.SetDefault("CRYPTOPANIC_API_KEY", "")         // 默认不使用 CryptoPanic / No CryptoPanic by default
+	viper.SetDefault("NEWS_RSS_FEEDS", "")              // 默认不读取 RSS / No RSS feeds by default
+	viper.SetDefault("NEWS_MAX_HEADLINES", 8)           // 每个交易对最多 8 条头条 / Up to 8 headlines per symbol
+	viper.SetDefault("NEWS_MAX_AGE_HOURS", 24)          // 只保留最近 24 小时 / Last 24 hours only
+	viper.SetDefault("FEAR_GREED_ENABLED", true)        // 默认获取恐慌贪婪指数（免费接口）/ Fear & Greed Index by default (free API)
+	viper.SetDefault("ONCHAIN_NETFLOW_URL", "")         // 默认不获取交易所净流入 / No exchange netflow by default
+	viper.SetDefault("ONCHAIN_STABLECOIN_URL", "")      // 默认不获取稳定币供应量 / No stablecoin supply by default
+	viper.SetDefault("LIQUIDATION_WINDOW_MINUTES", 60)  // 统计最近 60 分钟的爆仓 / Liquidations of the last 60 minutes
 
 	// Stop-loss management defaults (LLM-driven fixed stop-loss)
 	// 止损管理默认值（LLM 驱动的固定止损）
-	viper.SetDefault("ENABLE_STOPLOSS", true)         // 启用止损管理 / Enable stop-loss management
-	viper.SetDefault("STOPLOSS_SCOPE_THRESHOLD", 1.0) // 止损价格变化阈值 1.0% / Stop-loss change threshold 1.0%
+	viper.SetDefault("ENABLE_STOPLOSS", true)             // 启用止损管理 / Enable stop-loss management
+	viper.SetDefault("STOPLOSS_SCOPE_THRESHOLD", 1.0)     // 止损价格变化阈值 1.0% / Stop-loss change threshold 1.0%
+	viper.SetDefault("TRAILING_STOP_ENABLED", false)      // 默认关闭追踪止损 / Trailing stop disabled by default
+	viper.SetDefault("TRAILING_STOP_ATR_MULTIPLIER", 2.5) // 追踪距离 2.5 × ATR / Trailing distance 2.5 × ATR
+	viper.SetDefault("PARTIAL_TP_ENABLED", false)         // 默认不自动分批止盈 / No automatic partial TP by default
+	viper.SetDefault("PARTIAL_TP_R_MULTIPLE", 1.0)        // 1R 处分批止盈 / Take partial profit at 1R
+	viper.SetDefault("PARTIAL_TP_PERCENT", 50.0)          // 平掉 50% / Close 50%
+	viper.SetDefault("PARTIAL_TP_BREAKEVEN", true)        // 止损移至保本 / Move stop to breakeven
+	viper.SetDefault("TAKE_PROFIT_R_MULTIPLE", 0.0)       // 默认不挂止盈单 / No take-profit order by default
+	viper.SetDefault("PYRAMID_MAX_ADDS", 0)               // 默认不允许加仓 / No add-ons by default
+	viper.SetDefault("PYRAMID_MAX_SIZE_MULTIPLE", 2.0)    // 加仓后最多为开仓数量的 2 倍 / At most twice the opening quantity
+	viper.SetDefault("PYRAMID_MIN_PROFIT_PERCENT", 0.5)   // 浮盈至少 0.5% 才能加仓 / Add only once 0.5% in profit
+	viper.SetDefault("RISK_MAX_POSITIONS", 0)             // 默认不限制持仓数 / No position count cap by default
+	viper.SetDefault("RISK_MAX_NOTIONAL_PER_SYMBOL", 0.0) // 默认不限制单币名义价值 / No per-symbol notional cap by default
+	viper.SetDefault("RISK_MAX_EQUITY_AT_RISK", 0.0)      // 默认不限制权益风险 / No equity-at-risk cap by default
+	viper.SetDefault("RISK_DAILY_MAX_LOSS", 0.0)          // 默认不启用单日亏损停止 / No daily loss halt by default
+
+	viper.SetDefault("ALLOCATOR_ENABLED", false)            // 默认各交易对独立执行 / Symbols execute independently by default
+	viper.SetDefault("ALLOCATOR_MAX_MARGIN_PERCENT", 100.0) // 最多用完全部可用余额 / Up to the whole available balance
+
+	viper.SetDefault("SIZING_POLICY", "compound")       // 默认按当前余额复利 / Compound on the current balance by default
+	viper.SetDefault("SIZING_FIXED_CAPITAL", 0.0)       // fixed 模式必须设置 / Required in fixed mode
+	viper.SetDefault("SIZING_RISK_PER_TRADE", 0.0)      // 默认不按风险缩减仓位 / No risk-based sizing by default
+	viper.SetDefault("SIZING_ATR_MULTIPLIER", 1.0)      // 止损距离至少 1 倍 ATR / Stop distance is at least 1 ATR
+	viper.SetDefault("SIZING_MIN_NOTIONAL_BUFFER", 5.0) // 留 5% 余量应对下单前的价格波动 / 5% headroom for price moves before the order lands
+	viper.SetDefault("SIZING_MIN_NOTIONAL_SKIP", true)  // 默认跳过并说明原因 / Skip with an explanation by default
+
+	viper.SetDefault("SMALL_ACCOUNT_EQUITY", 0.0)              // 默认不启用小账户模式 / Small-account mode off by default
+	viper.SetDefault("SMALL_ACCOUNT_MIN_LEVERAGE", 5)          // 小账户至少 5 倍杠杆 / At least 5x leverage for small accounts
+	viper.SetDefault("SMALL_ACCOUNT_NOTIONAL_TOLERANCE", 25.0) // 差距 25% 以内向上取整 / Round up when short by at most 25%
+
+	viper.SetDefault("STOP_ENTRY_EXPIRY_MINUTES", 240) // 条件入场单 4 小时未触发则撤销 / Cancel untriggered stop entries after 4 hours
+	viper.SetDefault("STOP_ENTRY_CHECK_INTERVAL", 30)  // 每 30 秒检查一次成交 / Check fills every 30 seconds
+
+	viper.SetDefault("ANALYSIS_CRON", "")      // 默认按 TRADING_INTERVAL 对齐 / Align to TRADING_INTERVAL by default
+	viper.SetDefault("STOP_REVIEW_CRON", "")   // 默认不单独复查止损 / No separate stop review by default
+	viper.SetDefault("DAILY_REPORT_CRON", "")  // 默认不发送每日报告 / No daily report by default
+	viper.SetDefault("WEEKLY_REPORT_CRON", "") // 默认不发送每周报告 / No weekly report by default
+	viper.SetDefault("SCHEDULE_JITTER", 0)     // 默认不随机延迟 / No jitter by default
+
+	viper.SetDefault("FUNDING_RATE_MAX_PERCENT", 0.1) // 付费方向费率超过 0.1% 时不开仓 / Skip entries paying more than 0.1% per settlement
+
+	viper.SetDefault("MAX_SPREAD_BPS", 10) // 价差超过 10 个基点时不市价开仓 / No market entries above a 10 bps spread
+	viper.SetDefault("MAX_IMPACT_BPS", 30) // 预计冲击超过 30 个基点时不市价开仓 / No market entries above 30 bps expected impact
+
+	viper.SetDefault("EXECUTION_ALGO", "market")  // 默认市价开仓 / Market entries by default
+	viper.SetDefault("CHASE_REPEG_SECONDS", 5)    // 每 5 秒重挂一次 / Re-peg every 5 seconds
+	viper.SetDefault("CHASE_TIMEOUT_SECONDS", 60) // 追价 1 分钟后改用市价单 / Fall back to market after a minute
+	viper.SetDefault("CHASE_MIN_NOTIONAL", 0)     // 默认对全部开仓追价 / Chase every entry by default
+
+	viper.SetDefault("LARGE_ORDER_ALGO", "off")         // 默认不拆分大单 / No slicing by default
+	viper.SetDefault("LARGE_ORDER_MIN_NOTIONAL", 10000) // 1 万 USDT 以上的开仓才拆分 / Slice entries of 10k USDT and more
+	viper.SetDefault("LARGE_ORDER_SLICES", 5)           // 拆成 5 片 / Five slices
+	viper.SetDefault("TWAP_WINDOW_SECONDS", 300)        // 5 分钟内执行完 / Spread over five minutes
+
+	viper.SetDefault("TRADES_PER_DAY_MIN", 0) // 默认不设下限 / No lower bound by default
+	viper.SetDefault("TRADES_PER_DAY_MAX", 0) // 默认不检测过度交易 / No overtrading detection by default
+
+	viper.SetDefault("LOSS_STREAK_REDUCE", 0)       // 默认不因连亏减仓 / No size reduction on losing streaks by default
+	viper.SetDefault("LOSS_STREAK_HALT", 0)         // 默认不因连亏停止开仓 / No halt on losing streaks by default
+	viper.SetDefault("LOSS_STREAK_SESSION_HOUR", 0) // 与单日亏损限制一样按 UTC 日重置 / Resets on the UTC day like the daily loss limit
+
+	// Circuit breaker defaults
+	// 熔断默认值
+	viper.SetDefault("CIRCUIT_BREAKER_THRESHOLD", 3) // 连续失败 3 次后暂停 / Pause after 3 consecutive failures
+	viper.SetDefault("CIRCUIT_BREAKER_COOLDOWN", 60) // 暂停 60 分钟 / Pause for 60 minutes
+
+	viper.SetDefault("KILL_SWITCH_FILE", "./data/KILL_SWITCH") // 与数据库放在同一目录 / Next to the database
+
+	viper.SetDefault("DECISION_MEMORY_TRADES", 5) // 每个交易对回顾最近 5 笔交易 / Review the last 5 trades per symbol
+
+	viper.SetDefault("MIN_DECISION_CONFIDENCE", 0.75) // 与默认 Prompt 的置信度要求一致 / Matches the default prompt's confidence rule
+	viper.SetDefault("MIN_RISK_REWARD", 2.0)          // 与默认 Prompt 的 2:1 盈亏比一致 / Matches the default prompt's 2:1 rule
+
+	viper.SetDefault("STOP_OUT_COOLDOWN_MINUTES", 0) // 默认止损后不冷却 / No cooldown after a stop-out by default
+	viper.SetDefault("MAX_TRADES_PER_DAY", 0)        // 默认不限制每日开仓次数 / No daily entry cap by default
+
+	viper.SetDefault("PROFIT_SWEEP_ENABLED", false)       // 默认不提取利润 / No profit sweeping by default
+	viper.SetDefault("PROFIT_SWEEP_INITIAL_CAPITAL", 0.0) // 实盘必须设置 / Required for live trading
+	viper.SetDefault("PROFIT_SWEEP_THRESHOLD", 20.0)      // 权益超过基准 20% 时提取 / Sweep once equity is 20% above the baseline
+	viper.SetDefault("PROFIT_SWEEP_PERCENT", 50.0)        // 提取超出部分的 50% / Transfer 50% of the excess
+	viper.SetDefault("PROFIT_SWEEP_INTERVAL", 3600)       // 每小时检查一次 / Check hourly
+
+	viper.SetDefault("CHECKPOINT_ENABLED", false) // 默认不保存日终快照 / No end-of-day checkpoint by default
+	viper.SetDefault("CHECKPOINT_HOUR", 0)        // UTC 0 点，与单日亏损限制的日界一致 / UTC midnight, the day boundary of the daily loss limit
+
+	viper.SetDefault("MAINTENANCE_CHECK_INTERVAL", 60) // 每分钟检查一次 / Check every minute
+	viper.SetDefault("MAINTENANCE_STATUS_URL", "")     // 实盘使用主网接口 / Mainnet endpoint for live trading
+
+	viper.SetDefault("CLOCK_DRIFT_CHECK_INTERVAL", 900) // 每 15 分钟检查一次 / Check every 15 minutes
+	viper.SetDefault("CLOCK_DRIFT_ALERT_MS", 1000)      // 远小于签名请求默认 5 秒的 recvWindow / Well within the 5s default recvWindow of signed requests
+
+	viper.SetDefault("LIQUIDATION_GUARD_DISTANCE_PERCENT", 0.0) // 默认不监控 / Disabled by default
+	viper.SetDefault("LIQUIDATION_GUARD_ACTION", "reduce")      // 默认减仓 / Reduce by default
+	viper.SetDefault("LIQUIDATION_GUARD_REDUCE_PERCENT", 30.0)  // 每次减仓 30% / Close 30% per trigger
+	viper.SetDefault("LIQUIDATION_GUARD_INTERVAL", 30)          // 每 30 秒检查一次 / Check every 30 seconds
+
+	viper.SetDefault("USER_DATA_STREAM_ENABLED", true) // 实盘默认订阅 / Subscribed by default in live trading
+
+	viper.SetDefault("HISTORY_PRICE_POINTS", 1000)  // 每个持仓保留 1000 个价格点 / Keep 1000 price points per position
+	viper.SetDefault("HISTORY_TRADE_RESULTS", 200)  // 保留最近 200 条交易结果 / Keep the latest 200 trade results
+	viper.SetDefault("HISTORY_FLUSH_INTERVAL", 300) // 每 5 分钟写入数据库 / Flush to storage every 5 minutes
+
+	viper.SetDefault("SHUTDOWN_TIMEOUT", 120) // 最多等待 2 分钟 / Wait at most 2 minutes
+
+	viper.SetDefault("FREEZE_CYCLES", 0) // 默认不冻结 / No freeze window by default
 
 	viper.SetDefault("USE_MEMORY", true)
 	viper.SetDefault("MEMORY_TOP_K", 3)
 
 	viper.SetDefault("DEBUG_MODE", false)
+	viper.SetDefault("LOG_FORMAT", "console") // 日志格式 / Log format: console, json
 	viper.SetDefault("SELECTED_ANALYSTS", "market,crypto,sentiment")
 	viper.SetDefault("AUTO_EXECUTE", false)
 
 	viper.SetDefault("WEB_PORT", 8080)
 	viper.SetDefault("WEB_USERNAME", "admin")
 	viper.SetDefault("WEB_PASSWORD", "changeme")
+	viper.SetDefault("WEB_API_TOKEN", "")        // 默认不启用 API 令牌 / API token disabled by default
+	viper.SetDefault("WEB_LOGIN_RATE_LIMIT", 10) // 每个 IP 每分钟 10 次登录尝试 / 10 login attempts per IP per minute
+	viper.SetDefault("WEB_SECURE_COOKIE", false) // 默认允许 HTTP / Allow plain HTTP by default
+
+	viper.SetDefault("PUBLIC_PAGE_ENABLED", false) // 默认关闭公开业绩页 / Public page disabled by default
+	viper.SetDefault("PUBLIC_PAGE_TOKEN", "")
+	viper.SetDefault("PUBLIC_RATE_LIMIT", 30) // 每 IP 每分钟 30 次 / 30 requests per minute per IP
 }
 
 func getProjectDir() string {
@@ -348,6 +1081,154 @@ func (c *Config) GetBinanceSymbolFor(symbol string) string {
 	return strings.ReplaceAll(symbol, "/", "")
 }
 
+// quoteAssets are the quote assets recognized at the end of a symbol without a slash, longest first
+// quoteAssets 是识别不带斜杠的交易对结尾计价资产时使用的列表，长的在前
+var quoteAssets = []string{"FDUSD", "USDT", "USDC", "BUSD"}
+
+// QuoteAssetFor returns the quote asset of a symbol: "BTC/USDC" and "BTCUSDC" give USDC,
+// unrecognized symbols give QUOTE_ASSET
+// QuoteAssetFor 返回交易对的计价资产："BTC/USDC" 和 "BTCUSDC" 均返回 USDC，无法识别时返回 QUOTE_ASSET
+func (c *Config) QuoteAssetFor(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if _, quote, ok := strings.Cut(symbol, "/"); ok && quote != "" {
+		return quote
+	}
+	for _, quote := range quoteAssets {
+		if len(symbol) > len(quote) && strings.HasSuffix(symbol, quote) {
+			return quote
+		}
+	}
+	if c.QuoteAsset == "" {
+		return "USDT"
+	}
+	return c.QuoteAsset
+}
+
+// MarginAssets returns the distinct quote assets of CRYPTO_SYMBOLS, QUOTE_ASSET first
+// MarginAssets 返回 CRYPTO_SYMBOLS 中不重复的计价资产，QUOTE_ASSET 在最前
+func (c *Config) MarginAssets() []string {
+	assets := []string{c.QuoteAssetFor("")}
+	for _, symbol := range c.CryptoSymbols {
+		asset := c.QuoteAssetFor(symbol)
+		if !slices.Contains(assets, asset) {
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// Fingerprint hashes the whole configuration with credentials and webhook URLs blanked
+// Fingerprint 计算整个配置的哈希（密钥和 Webhook 地址置空后计算）
+func (c *Config) Fingerprint() string {
+	redacted := *c
+	for _, secret := range []*string{
+		&redacted.APIKey, &redacted.LLMFallbackAPIKey, &redacted.BinanceAPIKey, &redacted.BinanceAPISecret,
+		&redacted.CryptoPanicAPIKey, &redacted.RiskOracleToken, &redacted.WebPassword, &redacted.WebAPIToken,
+		&redacted.PublicPageToken, &redacted.NotifyDiscordWebhook, &redacted.NotifySlackWebhook, &redacted.NotifyWebhookURL,
+	} {
+		*secret = ""
+	}
+	data, _ := json.Marshal(redacted) // 字段均为基本类型，不会失败 / Plain fields only, can't fail
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SymbolPromptPath returns the per-symbol prompt override next to TRADER_PROMPT_PATH,
+// e.g. prompts/trader_btc.txt for BTC/USDT
+// SymbolPromptPath 返回与 TRADER_PROMPT_PATH 同目录的交易对专属 Prompt 路径，
+// 例如 BTC/USDT 对应 prompts/trader_btc.txt
+func (c *Config) SymbolPromptPath(symbol string) string {
+	base, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(symbol)), "/")
+	base = strings.TrimSuffix(base, c.QuoteAssetFor(symbol))
+	dir := "prompts"
+	if c.TraderPromptPath != "" {
+		dir = filepath.Dir(c.TraderPromptPath)
+	}
+	return filepath.Join(dir, "trader_"+strings.ToLower(base)+".txt")
+}
+
+// IsSmallAccount reports whether an account of the given equity runs in small-account mode
+// IsSmallAccount 判断给定权益的账户是否处于小账户模式
+func (c *Config) IsSmallAccount(equity float64) bool {
+	return c.SmallAccountEquity > 0 && equity > 0 && equity < c.SmallAccountEquity
+}
+
+// SmallAccountLeverageFloor returns the small-account leverage floor, never above the BINANCE_LEVERAGE maximum
+// SmallAccountLeverageFloor 返回小账户模式的杠杆下限，不超过 BINANCE_LEVERAGE 的上限
+func (c *Config) SmallAccountLeverageFloor() int {
+	if c.BinanceLeverageMax > 0 && c.SmallAccountMinLeverage > c.BinanceLeverageMax {
+		return c.BinanceLeverageMax
+	}
+	return c.SmallAccountMinLeverage
+}
+
+// LLMPrice is the price of a model in USD per million tokens
+// LLMPrice 表示模型价格（美元 / 百万 token）
+type LLMPrice struct {
+	Prompt     float64 // 输入价格 / Prompt price
+	Completion float64 // 输出价格 / Completion price
+}
+
+// parseLLMPrices parses "model=prompt/completion" pairs separated by commas, skipping malformed entries
+// parseLLMPrices 解析逗号分隔的 "模型=输入价格/输出价格"，跳过格式错误的条目
+func parseLLMPrices(raw string) map[string]LLMPrice {
+	prices := make(map[string]LLMPrice)
+	for _, entry := range strings.Split(raw, ",") {
+		model, price, ok := strings.Cut(entry, "=")
+		model = strings.TrimSpace(model)
+		if !ok || model == "" {
+			continue
+		}
+		promptStr, completionStr, ok := strings.Cut(price, "/")
+		if !ok {
+			continue
+		}
+		prompt, err1 := strconv.ParseFloat(strings.TrimSpace(promptStr), 64)
+		completion, err2 := strconv.ParseFloat(strings.TrimSpace(completionStr), 64)
+		if err1 != nil || err2 != nil || prompt < 0 || completion < 0 {
+			continue
+		}
+		prices[model] = LLMPrice{Prompt: prompt, Completion: completion}
+	}
+	return prices
+}
+
+// LLMCost estimates the USD cost of a call from LLM_PRICES (0 for models without a price)
+// LLMCost 根据 LLM_PRICES 估算一次调用的费用（美元，未配置价格的模型为 0）
+func (c *Config) LLMCost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := c.LLMPrices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1_000_000
+}
+
+// HasBinanceKeys reports whether real Binance API credentials are configured (not the .env.example placeholders)
+// HasBinanceKeys 判断是否配置了真实的币安 API 密钥（而不是 .env.example 中的占位符）
+func (c *Config) HasBinanceKeys() bool {
+	key, secret := strings.TrimSpace(c.BinanceAPIKey), strings.TrimSpace(c.BinanceAPISecret)
+	return key != "" && secret != "" &&
+		key != "your-binance-api-key-here" && secret != "your-binance-api-secret-here"
+}
+
+// IsAnalysisOnly reports whether the bot only analyzes: no account access, no exchange setup and no orders
+// IsAnalysisOnly 判断是否为仅分析模式：不读取账户、不设置交易所参数、不下单
+//
+// It is on with ANALYSIS_ONLY=true, or automatically when no Binance keys are configured outside paper trading.
+// 设置 ANALYSIS_ONLY=true 时启用；非模拟盘且未配置币安密钥时自动启用。
+func (c *Config) IsAnalysisOnly() bool {
+	return c.AnalysisOnly || (!c.PaperTrading && !c.HasBinanceKeys())
+}
+
+// SessionRetentionCutoff returns the time before which sessions are pruned, or zero when there is no age limit
+// SessionRetentionCutoff 返回会话清理的截止时间，不按时间清理时返回零值
+func (c *Config) SessionRetentionCutoff(now time.Time) time.Time {
+	if c.SessionRetentionDays <= 0 {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, -c.SessionRetentionDays)
+}
+
 // GetAllBinanceSymbols returns all trading pairs in Binance format
 // GetAllBinanceSymbols 返回所有交易对的币安格式
 func (c *Config) GetAllBinanceSymbols() []string {
@@ -364,8 +1245,121 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("OPENAI_API_KEY is required")
 	}
 
-	if c.BinanceAPIKey == "" || c.BinanceAPISecret == "" {
-		return fmt.Errorf("BINANCE_API_KEY and BINANCE_API_SECRET are required")
+	// Binance keys are optional: without them the bot runs in analysis-only mode (see IsAnalysisOnly)
+	// 币安密钥是可选的：未配置时以仅分析模式运行（见 IsAnalysisOnly）
+	if c.AnalysisOnly && c.PaperTrading {
+		return fmt.Errorf("ANALYSIS_ONLY and PAPER_TRADING cannot both be enabled")
+	}
+	if c.ShadowMode && c.PaperTrading {
+		return fmt.Errorf("SHADOW_MODE and PAPER_TRADING cannot both be enabled")
+	}
+	if c.BinancePositionModeSwitch && c.BinancePositionMode != "oneway" && c.BinancePositionMode != "hedge" {
+		return fmt.Errorf("BINANCE_POSITION_MODE_SWITCH requires BINANCE_POSITION_MODE oneway or hedge, got %q", c.BinancePositionMode)
+	}
+	if c.BinanceMarginType != "" && c.BinanceMarginType != "auto" && c.BinanceMarginType != "cross" && c.BinanceMarginType != "isolated" {
+		return fmt.Errorf("BINANCE_MARGIN_TYPE must be cross, isolated or auto, got %q", c.BinanceMarginType)
+	}
+	if c.ShadowEvalHours <= 0 {
+		return fmt.Errorf("SHADOW_EVAL_HOURS must be positive, got %d", c.ShadowEvalHours)
+	}
+	if c.MaxSpreadBps < 0 || c.MaxImpactBps < 0 {
+		return fmt.Errorf("MAX_SPREAD_BPS and MAX_IMPACT_BPS cannot be negative, got %g and %g", c.MaxSpreadBps, c.MaxImpactBps)
+	}
+	if c.ExecutionAlgo != "market" && c.ExecutionAlgo != "chase" {
+		return fmt.Errorf("EXECUTION_ALGO must be market or chase, got %q", c.ExecutionAlgo)
+	}
+	if c.ExecutionAlgo == "chase" || c.LargeOrderAlgo == "iceberg" {
+		if c.ChaseRepegSeconds <= 0 || c.ChaseTimeoutSeconds < c.ChaseRepegSeconds {
+			return fmt.Errorf("CHASE_REPEG_SECONDS must be positive and at most CHASE_TIMEOUT_SECONDS, got %d and %d",
+				c.ChaseRepegSeconds, c.ChaseTimeoutSeconds)
+		}
+		if c.ChaseMinNotional < 0 {
+			return fmt.Errorf("CHASE_MIN_NOTIONAL cannot be negative, got %g", c.ChaseMinNotional)
+		}
+	}
+	if c.PyramidMaxAdds < 0 {
+		return fmt.Errorf("PYRAMID_MAX_ADDS cannot be negative, got %d", c.PyramidMaxAdds)
+	}
+	if c.PyramidMaxAdds > 0 && c.PyramidMaxSizeMultiple <= 1 {
+		return fmt.Errorf("PYRAMID_MAX_SIZE_MULTIPLE must be greater than 1 when add-ons are enabled, got %g", c.PyramidMaxSizeMultiple)
+	}
+	if c.PyramidMinProfitPercent < 0 {
+		return fmt.Errorf("PYRAMID_MIN_PROFIT_PERCENT cannot be negative, got %g", c.PyramidMinProfitPercent)
+	}
+	if c.LiquidationGuardDistancePercent < 0 {
+		return fmt.Errorf("LIQUIDATION_GUARD_DISTANCE_PERCENT cannot be negative, got %g", c.LiquidationGuardDistancePercent)
+	}
+	if c.LiquidationGuardDistancePercent > 0 {
+		if c.LiquidationGuardAction != "reduce" && c.LiquidationGuardAction != "add_margin" && c.LiquidationGuardAction != "alert" {
+			return fmt.Errorf("LIQUIDATION_GUARD_ACTION must be reduce, add_margin or alert, got %q", c.LiquidationGuardAction)
+		}
+		if c.LiquidationGuardReducePercent <= 0 || c.LiquidationGuardReducePercent >= 100 {
+			return fmt.Errorf("LIQUIDATION_GUARD_REDUCE_PERCENT must be between 0 and 100 (exclusive), got %g", c.LiquidationGuardReducePercent)
+		}
+		if c.LiquidationGuardInterval <= 0 {
+			return fmt.Errorf("LIQUIDATION_GUARD_INTERVAL must be positive, got %d", c.LiquidationGuardInterval)
+		}
+	}
+	switch c.LargeOrderAlgo {
+	case "off":
+	case "twap", "iceberg":
+		if c.LargeOrderSlices < 2 {
+			return fmt.Errorf("LARGE_ORDER_SLICES must be at least 2, got %d", c.LargeOrderSlices)
+		}
+		if c.LargeOrderMinNotional < 0 {
+			return fmt.Errorf("LARGE_ORDER_MIN_NOTIONAL cannot be negative, got %g", c.LargeOrderMinNotional)
+		}
+		if c.LargeOrderAlgo == "twap" && c.TWAPWindowSeconds <= 0 {
+			return fmt.Errorf("TWAP_WINDOW_SECONDS must be positive, got %d", c.TWAPWindowSeconds)
+		}
+	default:
+		return fmt.Errorf("LARGE_ORDER_ALGO must be off, twap or iceberg, got %q", c.LargeOrderAlgo)
+	}
+
+	if c.MinDecisionConfidence < 0 || c.MinDecisionConfidence > 1 {
+		return fmt.Errorf("MIN_DECISION_CONFIDENCE must be between 0 and 1, got %g", c.MinDecisionConfidence)
+	}
+	if c.MinRiskReward < 0 {
+		return fmt.Errorf("MIN_RISK_REWARD cannot be negative, got %g", c.MinRiskReward)
+	}
+	if c.StopOutCooldownMinutes < 0 || c.MaxTradesPerDay < 0 {
+		return fmt.Errorf("STOP_OUT_COOLDOWN_MINUTES and MAX_TRADES_PER_DAY cannot be negative, got %d and %d",
+			c.StopOutCooldownMinutes, c.MaxTradesPerDay)
+	}
+	if c.HighStakesSamples < 2 || c.HighStakesSamples > 3 {
+		return fmt.Errorf("HIGH_STAKES_SAMPLES must be 2 or 3, got %d", c.HighStakesSamples)
+	}
+	if c.ScheduleJitter < 0 {
+		return fmt.Errorf("SCHEDULE_JITTER cannot be negative, got %d", c.ScheduleJitter)
+	}
+	if c.AllocatorMaxMargin <= 0 || c.AllocatorMaxMargin > 100 {
+		return fmt.Errorf("ALLOCATOR_MAX_MARGIN_PERCENT must be between 0 (exclusive) and 100, got %g", c.AllocatorMaxMargin)
+	}
+	if c.PerSymbolConcurrency < 1 {
+		return fmt.Errorf("PER_SYMBOL_CONCURRENCY must be at least 1, got %d", c.PerSymbolConcurrency)
+	}
+	if c.LossStreakSessionHour < 0 || c.LossStreakSessionHour > 23 {
+		return fmt.Errorf("LOSS_STREAK_SESSION_HOUR must be between 0 and 23, got %d", c.LossStreakSessionHour)
+	}
+	if c.CheckpointHour < 0 || c.CheckpointHour > 23 {
+		return fmt.Errorf("CHECKPOINT_HOUR must be between 0 and 23, got %d", c.CheckpointHour)
+	}
+	if c.SessionRetentionDays < 0 || c.SessionRetentionCount < 0 {
+		return fmt.Errorf("SESSION_RETENTION_DAYS and SESSION_RETENTION_COUNT cannot be negative, got %d and %d",
+			c.SessionRetentionDays, c.SessionRetentionCount)
+	}
+	if c.BinanceWeightPerMinute < 0 {
+		return fmt.Errorf("BINANCE_WEIGHT_PER_MINUTE cannot be negative, got %d", c.BinanceWeightPerMinute)
+	}
+	if c.ClockDriftCheckInterval < 0 {
+		return fmt.Errorf("CLOCK_DRIFT_CHECK_INTERVAL cannot be negative, got %d", c.ClockDriftCheckInterval)
+	}
+	if c.ClockDriftAlertMs <= 0 {
+		return fmt.Errorf("CLOCK_DRIFT_ALERT_MS must be positive, got %d", c.ClockDriftAlertMs)
+	}
+	if c.CircuitBreakerThreshold < 0 || c.CircuitBreakerCooldown < 0 {
+		return fmt.Errorf("CIRCUIT_BREAKER_THRESHOLD and CIRCUIT_BREAKER_COOLDOWN cannot be negative, got %d and %d",
+			c.CircuitBreakerThreshold, c.CircuitBreakerCooldown)
 	}
 
 	// PositionSize validation removed - now relies on LLM's position size recommendation
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -44,3 +46,121 @@ func TestCalculateLookbackDays(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAnalysisOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"no keys", Config{}, true},
+		{"placeholder keys", Config{BinanceAPIKey: "your-binance-api-key-here", BinanceAPISecret: "your-binance-api-secret-here"}, true},
+		{"real keys", Config{BinanceAPIKey: "key", BinanceAPISecret: "secret"}, false},
+		{"forced with keys", Config{BinanceAPIKey: "key", BinanceAPISecret: "secret", AnalysisOnly: true}, true},
+		{"paper trading without keys", Config{PaperTrading: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.IsAnalysisOnly(); got != tt.want {
+				t.Errorf("IsAnalysisOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionRetentionCutoff(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if cutoff := (&Config{}).SessionRetentionCutoff(now); !cutoff.IsZero() {
+		t.Errorf("Expected no cutoff without retention days, got %v", cutoff)
+	}
+	if cutoff := (&Config{SessionRetentionDays: 30}).SessionRetentionCutoff(now); !cutoff.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("Expected a 30-day cutoff, got %v", cutoff)
+	}
+}
+
+func TestLLMPrices(t *testing.T) {
+	cfg := Config{LLMPrices: parseLLMPrices(" gpt-4o-mini = 0.15/0.6 ,deepseek-chat=0.27/1.1,broken=1,=1/2,neg=-1/2")}
+
+	if len(cfg.LLMPrices) != 2 {
+		t.Fatalf("expected 2 valid prices, got %+v", cfg.LLMPrices)
+	}
+	if got := cfg.LLMPrices["gpt-4o-mini"]; got != (LLMPrice{Prompt: 0.15, Completion: 0.6}) {
+		t.Errorf("unexpected gpt-4o-mini price: %+v", got)
+	}
+
+	// 1M prompt + 0.5M completion tokens: 0.15 + 0.3
+	if got := cfg.LLMCost("gpt-4o-mini", 1_000_000, 500_000); got < 0.4499 || got > 0.4501 {
+		t.Errorf("LLMCost = %v, want 0.45", got)
+	}
+	if got := cfg.LLMCost("unknown", 1000, 1000); got != 0 {
+		t.Errorf("unpriced model should cost 0, got %v", got)
+	}
+}
+
+func TestSymbolPromptPath(t *testing.T) {
+	tests := []struct {
+		promptPath, symbol, want string
+	}{
+		{"prompts/trader_system.txt", "BTC/USDT", "prompts/trader_btc.txt"},
+		{"custom/my_strategy.txt", "ETHUSDT", "custom/trader_eth.txt"},
+		{"", "sol/usdt", "prompts/trader_sol.txt"},
+		{"", "BTCUSDC", "prompts/trader_btc.txt"},
+	}
+	for _, tt := range tests {
+		cfg := Config{TraderPromptPath: tt.promptPath}
+		if got := cfg.SymbolPromptPath(tt.symbol); got != tt.want {
+			t.Errorf("SymbolPromptPath(%q) with %q = %q, want %q", tt.symbol, tt.promptPath, got, tt.want)
+		}
+	}
+}
+
+// TestQuoteAssetFor tests quote asset detection and the margin assets of the configured symbols
+// TestQuoteAssetFor 测试计价资产识别和已配置交易对的保证金资产
+func TestQuoteAssetFor(t *testing.T) {
+	cfg := Config{QuoteAsset: "USDT", CryptoSymbols: []string{"BTC/USDT", "ETHUSDC", "SOL/FDUSD", "BNBUSDT"}}
+	tests := map[string]string{
+		"BTC/USDT":  "USDT",
+		"btc/usdc":  "USDC",
+		"BTCUSDC":   "USDC",
+		"ETHFDUSD":  "FDUSD",
+		"BTCUSDT":   "USDT",
+		"BTCDOMUSD": "USDT", // 无法识别时使用 QUOTE_ASSET / Unrecognized falls back to QUOTE_ASSET
+	}
+	for symbol, want := range tests {
+		if got := cfg.QuoteAssetFor(symbol); got != want {
+			t.Errorf("QuoteAssetFor(%q) = %q, want %q", symbol, got, want)
+		}
+	}
+
+	if got := strings.Join(cfg.MarginAssets(), ","); got != "USDT,USDC,FDUSD" {
+		t.Errorf("MarginAssets() = %s, want USDT,USDC,FDUSD", got)
+	}
+	usdc := Config{QuoteAsset: "USDC", CryptoSymbols: []string{"BTCUSDC"}}
+	if got := strings.Join(usdc.MarginAssets(), ","); got != "USDC" {
+		t.Errorf("MarginAssets() = %s, want USDC", got)
+	}
+}
+
+// TestFingerprint tests that settings change the fingerprint and credentials don't
+// TestFingerprint 测试配置项会改变指纹，而密钥不会
+func TestFingerprint(t *testing.T) {
+	base := Config{CryptoSymbols: []string{"BTC/USDT"}, BinanceLeverageMax: 10}
+	fingerprint := base.Fingerprint()
+	if len(fingerprint) != 16 {
+		t.Fatalf("Expected a 16-character fingerprint, got %q", fingerprint)
+	}
+
+	secret := base
+	secret.BinanceAPISecret = "rotated"
+	secret.NotifySlackWebhook = "https://hooks.slack.com/x"
+	if secret.Fingerprint() != fingerprint {
+		t.Error("Expected credentials not to change the fingerprint")
+	}
+
+	changed := base
+	changed.BinanceLeverageMax = 20
+	if changed.Fingerprint() == fingerprint {
+		t.Error("Expected a setting change to change the fingerprint")
+	}
+}
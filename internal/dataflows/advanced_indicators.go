@@ -0,0 +1,238 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	ichimokuTenkan       = 9  // 转换线周期 / Conversion line period
+	ichimokuKijun        = 26 // 基准线周期（也是云层前移周期）/ Base line period (also the cloud displacement)
+	ichimokuSenkouB      = 52 // 先行带 B 周期 / Leading span B period
+	superTrendPeriod     = 10 // SuperTrend 的 ATR 周期 / SuperTrend ATR period
+	superTrendMultiplier = 3  // SuperTrend 的 ATR 倍数 / SuperTrend ATR multiplier
+	stochRSIPeriod       = 14 // StochRSI 的 RSI 与随机周期 / StochRSI RSI and stochastic period
+	stochRSISmooth       = 3  // StochRSI 的 K / D 平滑周期 / StochRSI K / D smoothing
+)
+
+// midpoint returns the (highest high + lowest low) / 2 of the last period candles, NaN during warm-up
+// midpoint 返回最近 period 根 K 线的 (最高价 + 最低价) / 2，预热期为 NaN
+func midpoint(highs, lows []float64, period int) []float64 {
+	result := make([]float64, len(highs))
+	for i := range highs {
+		if i < period-1 {
+			result[i] = math.NaN()
+			continue
+		}
+		high, low := highs[i], lows[i]
+		for j := i - period + 1; j < i; j++ {
+			high = math.Max(high, highs[j])
+			low = math.Min(low, lows[j])
+		}
+		result[i] = (high + low) / 2
+	}
+	return result
+}
+
+// calculateIchimoku calculates the conversion line, base line and the cloud (leading spans)
+// calculateIchimoku 计算一目均衡表的转换线、基准线和云层（先行带）
+//
+// The leading spans are displaced forward: the cloud at index i was computed ichimokuKijun candles earlier,
+// so it is the cloud the current price trades against.
+// 先行带向前平移：索引 i 处的云层由 ichimokuKijun 根 K 线之前的数据计算，即当前价格所对应的云层。
+func calculateIchimoku(highs, lows []float64) (tenkan, kijun, senkouA, senkouB []float64) {
+	tenkan = midpoint(highs, lows, ichimokuTenkan)
+	kijun = midpoint(highs, lows, ichimokuKijun)
+	spanB := midpoint(highs, lows, ichimokuSenkouB)
+
+	senkouA = make([]float64, len(highs))
+	senkouB = make([]float64, len(highs))
+	for i := range highs {
+		if i < ichimokuKijun {
+			senkouA[i], senkouB[i] = math.NaN(), math.NaN()
+			continue
+		}
+		senkouA[i] = (tenkan[i-ichimokuKijun] + kijun[i-ichimokuKijun]) / 2 // NaN 会自然传递 / NaN propagates
+		senkouB[i] = spanB[i-ichimokuKijun]
+	}
+	return tenkan, kijun, senkouA, senkouB
+}
+
+// calculateSuperTrend calculates the SuperTrend line and its direction (1 = up, -1 = down, NaN during warm-up)
+// calculateSuperTrend 计算 SuperTrend 线及其方向（1 = 上升，-1 = 下降，预热期为 NaN）
+func calculateSuperTrend(highs, lows, closes []float64, period int, multiplier float64) (line, direction []float64) {
+	atr := calculateATR(highs, lows, closes, period)
+	line = make([]float64, len(closes))
+	direction = make([]float64, len(closes))
+
+	var upper, lower float64
+	started := false
+	for i := range closes {
+		if math.IsNaN(atr[i]) {
+			line[i], direction[i] = math.NaN(), math.NaN()
+			continue
+		}
+
+		hl2 := (highs[i] + lows[i]) / 2
+		basicUpper := hl2 + multiplier*atr[i]
+		basicLower := hl2 - multiplier*atr[i]
+
+		if !started {
+			upper, lower = basicUpper, basicLower
+			direction[i] = 1
+			if closes[i] < hl2 {
+				direction[i] = -1
+			}
+			started = true
+		} else {
+			// Bands only tighten, unless the previous close broke through them
+			// 轨道只收紧不放宽，除非上一根收盘价已突破
+			if basicUpper < upper || closes[i-1] > upper {
+				upper = basicUpper
+			}
+			if basicLower > lower || closes[i-1] < lower {
+				lower = basicLower
+			}
+
+			direction[i] = direction[i-1]
+			if direction[i] > 0 && closes[i] < lower {
+				direction[i] = -1
+			} else if direction[i] < 0 && closes[i] > upper {
+				direction[i] = 1
+			}
+		}
+
+		if direction[i] > 0 {
+			line[i] = lower
+		} else {
+			line[i] = upper
+		}
+	}
+	return line, direction
+}
+
+// smoothSMA is a simple moving average that stays NaN until period valid values are available
+// smoothSMA 是简单移动平均，在凑齐 period 个有效值之前保持 NaN
+func smoothSMA(data []float64, period int) []float64 {
+	result := make([]float64, len(data))
+	for i := range data {
+		result[i] = math.NaN()
+		if i < period-1 {
+			continue
+		}
+		sum := 0.0
+		for j := i - period + 1; j <= i; j++ {
+			sum += data[j]
+		}
+		if !math.IsNaN(sum) {
+			result[i] = sum / float64(period)
+		}
+	}
+	return result
+}
+
+// calculateStochRSI calculates the stochastic oscillator of RSI, smoothed into K and D (0-100)
+// calculateStochRSI 计算 RSI 的随机指标，并平滑为 K 和 D（0-100）
+//
+// A flat RSI window (highest = lowest) reads 50, neither overbought nor oversold.
+// RSI 窗口内没有波动（最高 = 最低）时取 50，既不超买也不超卖。
+func calculateStochRSI(closes []float64, period, smooth int) (k, d []float64) {
+	rsi := calculateRSI(closes, period)
+	raw := make([]float64, len(closes))
+	for i := range rsi {
+		raw[i] = math.NaN()
+		if i < period-1 {
+			continue
+		}
+		high, low := math.Inf(-1), math.Inf(1)
+		for j := i - period + 1; j <= i; j++ {
+			high = math.Max(high, rsi[j])
+			low = math.Min(low, rsi[j])
+		}
+		switch {
+		case math.IsNaN(high) || math.IsNaN(low) || math.IsNaN(rsi[i]):
+		case high == low:
+			raw[i] = 50
+		default:
+			raw[i] = (rsi[i] - low) / (high - low) * 100
+		}
+	}
+
+	k = smoothSMA(raw, smooth)
+	d = smoothSMA(k, smooth)
+	return k, d
+}
+
+// lastValid returns the last value of a series and whether it is a number
+// lastValid 返回序列的最后一个值及其是否为有效数字
+func lastValid(data []float64) (float64, bool) {
+	if len(data) == 0 || math.IsNaN(data[len(data)-1]) {
+		return 0, false
+	}
+	return data[len(data)-1], true
+}
+
+// FormatAdvancedIndicators renders Ichimoku, SuperTrend and StochRSI; lines still in warm-up are omitted
+// FormatAdvancedIndicators 输出一目均衡表、SuperTrend 和 StochRSI，仍处于预热期的指标不输出
+//
+// Enabled by ADVANCED_INDICATORS and appended to both the intraday and the longer timeframe report.
+// 由 ADVANCED_INDICATORS 启用，追加到日内报告和长期报告之后。
+func FormatAdvancedIndicators(ohlcvData []OHLCV, indicators *TechnicalIndicators) string {
+	if len(ohlcvData) == 0 || indicators == nil {
+		return ""
+	}
+	price := ohlcvData[len(ohlcvData)-1].Close
+
+	var lines []string
+
+	tenkan, okT := lastValid(indicators.Tenkan)
+	kijun, okK := lastValid(indicators.Kijun)
+	spanA, okA := lastValid(indicators.SenkouA)
+	spanB, okB := lastValid(indicators.SenkouB)
+	if okT && okK {
+		line := fmt.Sprintf("Ichimoku: 转换线 = %.1f, 基准线 = %.1f", tenkan, kijun)
+		if okA && okB {
+			top, bottom := math.Max(spanA, spanB), math.Min(spanA, spanB)
+			position := "云层内 (inside the cloud)"
+			if price > top {
+				position = "云层上方 (above the cloud)"
+			} else if price < bottom {
+				position = "云层下方 (below the cloud)"
+			}
+			color := "绿云 (bullish)"
+			if spanA < spanB {
+				color = "红云 (bearish)"
+			}
+			line += fmt.Sprintf(", 云层 = %.1f - %.1f %s, 价格位于%s", bottom, top, color, position)
+		}
+		lines = append(lines, line)
+	}
+
+	if st, ok := lastValid(indicators.SuperTrend); ok {
+		dir := indicators.SuperTrendDir
+		trend := "多头 (uptrend)"
+		if dir[len(dir)-1] < 0 {
+			trend = "空头 (downtrend)"
+		}
+		held := 1
+		for i := len(dir) - 2; i >= 0 && dir[i] == dir[len(dir)-1]; i-- {
+			held++
+		}
+		lines = append(lines, fmt.Sprintf("SuperTrend(%d, %d) = %.1f, %s, 已持续 %d 根K线",
+			superTrendPeriod, superTrendMultiplier, st, trend, held))
+	}
+
+	if k, ok := lastValid(indicators.StochRSI_K); ok {
+		line := fmt.Sprintf("StochRSI(%d,%d,%d,%d): K = %.1f", stochRSIPeriod, stochRSIPeriod, stochRSISmooth, stochRSISmooth, k)
+		if d, ok := lastValid(indicators.StochRSI_D); ok {
+			line += fmt.Sprintf(", D = %.1f", d)
+		}
+		lines = append(lines, line+" (>80 超买, <20 超卖)")
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "高级指标:\n" + strings.Join(lines, "\n") + "\n"
+}
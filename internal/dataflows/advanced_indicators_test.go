@@ -0,0 +1,117 @@
+package dataflows
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// trendCandles returns n candles rising (or falling) by step per candle with a range of 2
+// trendCandles 返回 n 根每根涨（跌）step、振幅为 2 的 K 线
+func trendCandles(n int, start, step float64) []OHLCV {
+	candles := make([]OHLCV, n)
+	for i := range candles {
+		price := start + float64(i)*step
+		candles[i] = OHLCV{Open: price - step/2, High: price + 1, Low: price - 1, Close: price, Volume: 10}
+	}
+	return candles
+}
+
+// TestIchimokuWarmup tests the warm-up NaNs and the displaced cloud in an uptrend
+// TestIchimokuWarmup 测试预热期的 NaN 以及上升趋势中前移的云层
+func TestIchimokuWarmup(t *testing.T) {
+	candles := trendCandles(100, 100, 1)
+	ind := CalculateIndicators(candles)
+
+	if !math.IsNaN(ind.Tenkan[7]) || math.IsNaN(ind.Tenkan[8]) {
+		t.Errorf("Expected the conversion line to start at index 8, got %v", ind.Tenkan[7:9])
+	}
+	// 先行带 B 需要 52 期数据并前移 26 期 / Span B needs 52 candles, displaced by 26
+	if !math.IsNaN(ind.SenkouB[76]) || math.IsNaN(ind.SenkouB[77]) {
+		t.Errorf("Expected span B to start at index 77, got %v", ind.SenkouB[76:78])
+	}
+
+	last := len(candles) - 1
+	price := candles[last].Close
+	if ind.Tenkan[last] <= ind.Kijun[last] {
+		t.Errorf("Expected the conversion line above the base line in an uptrend")
+	}
+	if price <= math.Max(ind.SenkouA[last], ind.SenkouB[last]) {
+		t.Errorf("Expected the price above the cloud in an uptrend")
+	}
+}
+
+// TestSuperTrendFlip tests that SuperTrend follows an uptrend and flips once the trend reverses
+// TestSuperTrendFlip 测试 SuperTrend 跟随上升趋势，并在趋势反转后翻转
+func TestSuperTrendFlip(t *testing.T) {
+	candles := append(trendCandles(40, 100, 1), trendCandles(40, 139, -2)...)
+	highs, lows, closes := make([]float64, len(candles)), make([]float64, len(candles)), make([]float64, len(candles))
+	for i, c := range candles {
+		highs[i], lows[i], closes[i] = c.High, c.Low, c.Close
+	}
+
+	line, dir := calculateSuperTrend(highs, lows, closes, 10, 3)
+	if !math.IsNaN(line[9]) || !math.IsNaN(dir[9]) {
+		t.Errorf("Expected NaN during the ATR warm-up, got %.2f / %.0f", line[9], dir[9])
+	}
+	if dir[39] != 1 || line[39] >= closes[39] {
+		t.Errorf("Expected an uptrend with the line below price at the top, got %.0f / %.2f", dir[39], line[39])
+	}
+	if dir[79] != -1 || line[79] <= closes[79] {
+		t.Errorf("Expected a downtrend with the line above price at the end, got %.0f / %.2f", dir[79], line[79])
+	}
+}
+
+// TestStochRSI tests the range, the warm-up and the flat-RSI case
+// TestStochRSI 测试取值范围、预热期和 RSI 无波动的情况
+func TestStochRSI(t *testing.T) {
+	closes := make([]float64, 80)
+	for i := range closes {
+		closes[i] = 100 + 10*math.Sin(float64(i)/5)
+	}
+
+	k, d := calculateStochRSI(closes, 14, 3)
+	// RSI 从第 14 期开始，随机窗口 14 期，再平滑 3 期 / RSI from 14, 14-period window, 3-period smoothing
+	if !math.IsNaN(k[28]) || math.IsNaN(k[29]) {
+		t.Errorf("Expected K to start at index 29, got %v", k[28:30])
+	}
+	if !math.IsNaN(d[30]) || math.IsNaN(d[31]) {
+		t.Errorf("Expected D to start at index 31, got %v", d[30:32])
+	}
+	for i := 29; i < len(k); i++ {
+		if k[i] < 0 || k[i] > 100 {
+			t.Fatalf("K out of range at %d: %.2f", i, k[i])
+		}
+	}
+
+	flat := make([]float64, 40)
+	for i := range flat {
+		flat[i] = 100 + float64(i) // RSI 恒为 100 / RSI stays at 100
+	}
+	if k, _ := calculateStochRSI(flat, 14, 3); k[len(k)-1] != 50 {
+		t.Errorf("Expected 50 for a flat RSI, got %.2f", k[len(k)-1])
+	}
+}
+
+// TestFormatAdvancedIndicators tests that warm-up lines are omitted and complete data is reported
+// TestFormatAdvancedIndicators 测试预热期指标不输出，数据充足时完整输出
+func TestFormatAdvancedIndicators(t *testing.T) {
+	// 28 根：转换线和基准线已就绪，云层和 StochRSI 仍在预热 / Lines ready, cloud and StochRSI still warming up
+	short := trendCandles(28, 100, 1)
+	report := FormatAdvancedIndicators(short, CalculateIndicators(short))
+	if !strings.Contains(report, "Ichimoku: 转换线") || strings.Contains(report, "云层 =") || strings.Contains(report, "StochRSI") {
+		t.Errorf("Expected only the warmed-up lines, got:\n%s", report)
+	}
+
+	candles := trendCandles(120, 100, 1)
+	report = FormatAdvancedIndicators(candles, CalculateIndicators(candles))
+	for _, want := range []string{"价格位于云层上方", "SuperTrend(10, 3)", "多头", "StochRSI(14,14,3,3): K ="} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+
+	if FormatAdvancedIndicators(short, &TechnicalIndicators{}) != "" {
+		t.Error("Expected an empty report before any indicator warms up")
+	}
+}
@@ -0,0 +1,64 @@
+package dataflows
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IndicatorNames lists the indicator names INDICATORS accepts, in report order
+// IndicatorNames 列出 INDICATORS 支持的指标名称（按报告顺序）
+var IndicatorNames = []string{
+	"ema12", "ema20", "ema26", "sma20", "sma50", "sma200",
+	"macd", "rsi7", "rsi14", "bb", "atr", "adx", "volume",
+	"vwap", "profile", "ichimoku", "supertrend", "stochrsi",
+}
+
+// IndicatorSet is the set of indicators to compute and report; nil means the built-in default set
+// IndicatorSet 是需要计算并输出的指标集合；nil 表示使用内置的默认指标集
+//
+// ATR(14) is always computed because stop-loss sizing depends on it; "atr" only controls the report.
+// ATR(14) 始终计算，因为止损计算依赖它；"atr" 只控制报告中是否输出。
+type IndicatorSet map[string]bool
+
+// ParseIndicatorSet builds a set from indicator names; an empty list returns nil (the default set)
+// ParseIndicatorSet 根据指标名称构建集合；列表为空时返回 nil（默认指标集）
+func ParseIndicatorSet(names []string) (IndicatorSet, error) {
+	set := IndicatorSet{}
+	var unknown []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(IndicatorNames, name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		set[name] = true
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown indicators %s (supported: %s)",
+			strings.Join(unknown, ", "), strings.Join(IndicatorNames, ", "))
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// Has reports whether the indicator is computed: every default indicator for a nil set
+// Has 判断是否计算该指标：nil 集合（默认指标集）时全部计算
+func (s IndicatorSet) Has(name string) bool {
+	return s == nil || s[name]
+}
+
+// Listed reports whether the indicator was explicitly configured (always false for a nil set)
+// Listed 判断该指标是否被显式配置（nil 集合时始终为 false）
+//
+// Used for series the default report leaves out, such as SMA(50) in the intraday report.
+// 用于默认报告不输出的序列，例如日内报告中的 SMA(50)。
+func (s IndicatorSet) Listed(name string) bool {
+	return s[name]
+}
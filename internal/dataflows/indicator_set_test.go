@@ -0,0 +1,71 @@
+package dataflows
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseIndicatorSet tests normalisation, the default set and unknown names
+// TestParseIndicatorSet 测试名称规范化、默认指标集和未知名称
+func TestParseIndicatorSet(t *testing.T) {
+	set, err := ParseIndicatorSet([]string{" EMA20", "rsi7", "", "macd"})
+	if err != nil {
+		t.Fatalf("ParseIndicatorSet failed: %v", err)
+	}
+	if !set.Has("ema20") || !set.Has("rsi7") || set.Has("rsi14") || !set.Listed("macd") {
+		t.Errorf("Unexpected set: %v", set)
+	}
+
+	if set, err := ParseIndicatorSet(nil); err != nil || set != nil {
+		t.Errorf("Expected the default (nil) set, got %v (%v)", set, err)
+	}
+	var defaults IndicatorSet
+	if !defaults.Has("rsi14") || defaults.Listed("sma50") {
+		t.Error("Expected the default set to compute every indicator and list none")
+	}
+
+	if _, err := ParseIndicatorSet([]string{"ema20", "kdj"}); err == nil || !strings.Contains(err.Error(), "kdj") {
+		t.Errorf("Expected an error naming kdj, got %v", err)
+	}
+}
+
+// TestIndicatorSetReport tests that only the configured indicators are computed and reported
+// TestIndicatorSetReport 测试只计算并输出配置的指标
+func TestIndicatorSetReport(t *testing.T) {
+	candles := trendCandles(120, 100, 1)
+	set, _ := ParseIndicatorSet([]string{"ema20", "rsi7", "macd", "adx"})
+	ind := CalculateIndicatorSet(candles, set)
+
+	if ind.RSI != nil || ind.EMA_12 != nil || ind.VWAP != nil || ind.SuperTrend != nil {
+		t.Error("Expected unselected indicators to stay nil")
+	}
+	if len(ind.ATR) != len(candles) {
+		t.Error("Expected ATR(14) to be computed for stop-loss placement")
+	}
+
+	report := FormatIndicatorReport("BTC/USDT", "1h", candles, ind)
+	for _, want := range []string{"EMA(20) =", "MACD =", "RSI(7) =", "ADX =", "EMA(20): ["} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+	for _, unwanted := range []string{"EMA(12)", "RSI(14)", "BB_Upper", "VWAP"} {
+		if strings.Contains(report, unwanted) {
+			t.Errorf("Unexpected %q in report:\n%s", unwanted, report)
+		}
+	}
+
+	longer := FormatLongerTimeframeReport("BTC/USDT", "4h", candles, ind)
+	if !strings.Contains(longer, "EMA(20):") || strings.Contains(longer, "ATR(3)") || strings.Contains(longer, "RSI(14)") {
+		t.Errorf("Unexpected longer timeframe report:\n%s", longer)
+	}
+	if FormatAdvancedIndicators(candles, ind) != "" {
+		t.Error("Expected no advanced indicators outside the set")
+	}
+
+	// 默认指标集保持原有报告 / The default set keeps the original report
+	report = FormatIndicatorReport("BTC/USDT", "1h", candles, CalculateIndicators(candles))
+	if !strings.Contains(report, "EMA(12) =") || !strings.Contains(report, "RSI(14) =") || strings.Contains(report, "EMA(20)") {
+		t.Errorf("Unexpected default report:\n%s", report)
+	}
+}
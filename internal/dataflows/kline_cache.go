@@ -0,0 +1,116 @@
+package dataflows
+
+import (
+	"context"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+const (
+	klineFetchLimit = 1000 // 单次请求的最大 K 线数 / Max candles per request
+	maxKlinePages   = 5    // 补齐缓存时最多请求的页数 / Max requests while filling the cache
+)
+
+// klineDurations maps Binance intervals to their length (monthly candles vary and are not cached)
+// klineDurations 是币安周期到时长的映射（月线长度不固定，不缓存）
+var klineDurations = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"2h":  2 * time.Hour,
+	"4h":  4 * time.Hour,
+	"6h":  6 * time.Hour,
+	"8h":  8 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+	"3d":  72 * time.Hour,
+	"1w":  7 * 24 * time.Hour,
+}
+
+// UseKlineCache keeps fetched candles in the database so later calls only request the missing tail
+// UseKlineCache 将获取的 K 线保存到数据库，之后只请求缺失的最新部分
+func (m *MarketData) UseKlineCache(db *storage.Storage) {
+	m.cache = db
+}
+
+// getCachedOHLCV returns the candles since startTime from the cache, topped up from Binance
+// getCachedOHLCV 从缓存返回 startTime 之后的 K 线，并从币安补齐缺失部分
+//
+// The last cached candle is always refetched because it may still have been open when stored.
+// Cache errors fall back to a full fetch; the cache only ever saves requests.
+// 最后一根缓存 K 线总会重新获取，因为保存时它可能尚未收盘。缓存出错时退回完整获取，缓存只用于减少请求。
+func (m *MarketData) getCachedOHLCV(ctx context.Context, symbol, interval string, startTime, endTime time.Time) ([]OHLCV, error) {
+	step, ok := klineDurations[interval]
+	if !ok {
+		return m.fetchKlines(ctx, symbol, interval, startTime, endTime)
+	}
+
+	stored, err := m.cache.GetKlines(symbol, interval, startTime)
+	if err != nil {
+		return m.fetchKlines(ctx, symbol, interval, startTime, endTime)
+	}
+	cached := make([]OHLCV, len(stored))
+	for i, k := range stored {
+		cached[i] = OHLCV{Timestamp: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume}
+	}
+
+	// Page through the missing candles (a cold cache may need more than one request)
+	// 分页获取缺失的 K 线（冷启动时可能需要多次请求）
+	from := resumeTime(cached, startTime, step)
+	var fetched []OHLCV
+	for page := 0; page < maxKlinePages; page++ {
+		batch, err := m.fetchKlines(ctx, symbol, interval, from, endTime)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, batch...)
+		if len(batch) < klineFetchLimit {
+			break
+		}
+		from = batch[len(batch)-1].Timestamp.Add(step)
+	}
+
+	klines := make([]*storage.Kline, len(fetched))
+	for i, c := range fetched {
+		klines[i] = &storage.Kline{OpenTime: c.Timestamp, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: c.Volume}
+	}
+	if err := m.cache.SaveKlines(symbol, interval, klines); err == nil {
+		m.cache.PruneKlines(symbol, interval, startTime)
+	}
+
+	return mergeCandles(cached, fetched), nil
+}
+
+// resumeTime returns where fetching should resume: the last cached candle before the first gap,
+// or startTime when the cache is empty or does not reach back to it
+// resumeTime 返回继续获取的起点：第一个缺口前的最后一根缓存 K 线；缓存为空或未覆盖 startTime 时返回 startTime
+func resumeTime(cached []OHLCV, startTime time.Time, step time.Duration) time.Time {
+	if len(cached) == 0 || cached[0].Timestamp.Sub(startTime) >= step {
+		return startTime
+	}
+	for i := 1; i < len(cached); i++ {
+		if cached[i].Timestamp.Sub(cached[i-1].Timestamp) != step {
+			return cached[i-1].Timestamp
+		}
+	}
+	return cached[len(cached)-1].Timestamp
+}
+
+// mergeCandles keeps the cached candles older than the first fetched one and appends the fetched candles
+// mergeCandles 保留早于首根新获取 K 线的缓存 K 线，并追加新获取的 K 线
+func mergeCandles(cached, fetched []OHLCV) []OHLCV {
+	if len(fetched) == 0 {
+		return cached
+	}
+	merged := make([]OHLCV, 0, len(cached)+len(fetched))
+	for _, c := range cached {
+		if c.Timestamp.Before(fetched[0].Timestamp) {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, fetched...)
+}
@@ -0,0 +1,58 @@
+package dataflows
+
+import (
+	"testing"
+	"time"
+)
+
+// hourlyCandles returns candles opened every hour at the given offsets from start
+// hourlyCandles 返回从 start 起在给定小时偏移处开盘的 K 线
+func hourlyCandles(start time.Time, hours ...int) []OHLCV {
+	candles := make([]OHLCV, len(hours))
+	for i, h := range hours {
+		candles[i] = OHLCV{Timestamp: start.Add(time.Duration(h) * time.Hour), Close: float64(h)}
+	}
+	return candles
+}
+
+// TestResumeTime tests where fetching resumes for empty, stale, gapped and complete caches
+// TestResumeTime 测试缓存为空、过旧、有缺口和完整时的继续获取起点
+func TestResumeTime(t *testing.T) {
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		cached []OHLCV
+		want   time.Time
+	}{
+		{"empty", nil, start},
+		{"does not reach startTime", hourlyCandles(start, 3, 4, 5), start},
+		{"gap", hourlyCandles(start, 0, 1, 2, 5, 6), start.Add(2 * time.Hour)},
+		{"complete", hourlyCandles(start, 0, 1, 2, 3), start.Add(3 * time.Hour)},
+	}
+	for _, tt := range tests {
+		if got := resumeTime(tt.cached, start, time.Hour); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestMergeCandles tests that fetched candles replace the cached tail
+// TestMergeCandles 测试新获取的 K 线替换缓存的末尾部分
+func TestMergeCandles(t *testing.T) {
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	cached := hourlyCandles(start, 0, 1, 2, 3)
+	fetched := hourlyCandles(start, 3, 4)
+	fetched[0].Close = 99 // 重新获取的未收盘 K 线 / Refetched open candle
+
+	merged := mergeCandles(cached, fetched)
+	if len(merged) != 5 {
+		t.Fatalf("Expected 5 candles, got %d", len(merged))
+	}
+	if merged[3].Close != 99 || merged[4].Close != 4 {
+		t.Errorf("Expected the fetched candles at the tail, got %+v", merged[3:])
+	}
+	if got := mergeCandles(cached, nil); len(got) != len(cached) {
+		t.Errorf("Expected the cache unchanged without new candles, got %d", len(got))
+	}
+}
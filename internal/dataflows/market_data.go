@@ -13,6 +13,8 @@ import (
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/ratelimit"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // OHLCV represents a candlestick data point
@@ -50,12 +52,34 @@ type TechnicalIndicators struct {
 	DI_Plus     []float64 // +DI - 上升趋向指标
 	DI_Minus    []float64 // -DI - 下降趋向指标
 	VolumeRatio []float64 // Volume Ratio - 成交量比率
+
+	// Volume-at-price context
+	// 成交量价位信息
+	VWAP          []float64      // Session VWAP - 日内 VWAP（UTC 0 点重置）
+	VWAP_20       []float64      // Rolling VWAP(20) - 20期滚动 VWAP
+	VolumeProfile *VolumeProfile // Volume profile - 最近 200 根 K 线的成交量分布（无成交量时为 nil）
+
+	// Advanced indicators (reported only with ADVANCED_INDICATORS, NaN during warm-up)
+	// 高级指标（仅在 ADVANCED_INDICATORS 启用时输出，预热期为 NaN）
+	Tenkan        []float64 // Ichimoku Tenkan-sen(9) - 转换线
+	Kijun         []float64 // Ichimoku Kijun-sen(26) - 基准线
+	SenkouA       []float64 // Ichimoku Senkou Span A - 先行带 A（已前移 26 期，对应当前价格）
+	SenkouB       []float64 // Ichimoku Senkou Span B(52) - 先行带 B（已前移 26 期，对应当前价格）
+	SuperTrend    []float64 // SuperTrend(10, 3) - 趋势跟踪止损线
+	SuperTrendDir []float64 // SuperTrend 方向：1 上升，-1 下降
+	StochRSI_K    []float64 // StochRSI(14,14,3) %K
+	StochRSI_D    []float64 // StochRSI %D（%K 的 3 期均线）
+
+	// Set is the configured indicator set (nil = default); unselected series are nil
+	// Set 为配置的指标集（nil 为默认），未选中的指标序列为 nil
+	Set IndicatorSet
 }
 
 // MarketData handles crypto market data fetching
 type MarketData struct {
 	client *futures.Client
 	config *config.Config
+	cache  *storage.Storage // K 线缓存（nil 表示每次完整获取）/ Kline cache (nil = full fetch every time)
 }
 
 // NewMarketData creates a new MarketData instance
@@ -94,6 +118,10 @@ func NewMarketData(cfg *config.Config) *MarketData {
 		}
 	}
 
+	// Share the Binance request weight budget with every other client of the process
+	// 与进程内其他客户端共享币安请求权重预算
+	client.HTTPClient = ratelimit.WrapClient(client.HTTPClient, ratelimit.Shared(cfg.BinanceWeightPerMinute))
+
 	return &MarketData{
 		client: client,
 		config: cfg,
@@ -101,18 +129,28 @@ func NewMarketData(cfg *config.Config) *MarketData {
 }
 
 // GetOHLCV fetches OHLCV data for a symbol
+// GetOHLCV 获取交易对的 OHLCV 数据（启用 K 线缓存时只请求缺失的部分）
 func (m *MarketData) GetOHLCV(ctx context.Context, symbol string, timeframe string, lookbackDays int) ([]OHLCV, error) {
 	interval := convertTimeframe(timeframe)
 
 	startTime := time.Now().AddDate(0, 0, -lookbackDays)
 	endTime := time.Now()
 
+	if m.cache != nil {
+		return m.getCachedOHLCV(ctx, symbol, interval, startTime, endTime)
+	}
+	return m.fetchKlines(ctx, symbol, interval, startTime, endTime)
+}
+
+// fetchKlines requests up to klineFetchLimit candles opened between startTime and endTime from Binance
+// fetchKlines 从币安请求 startTime 到 endTime 之间开盘的 K 线（最多 klineFetchLimit 根）
+func (m *MarketData) fetchKlines(ctx context.Context, symbol, interval string, startTime, endTime time.Time) ([]OHLCV, error) {
 	klines, err := m.client.NewKlinesService().
 		Symbol(symbol).
 		Interval(interval).
 		StartTime(startTime.UnixMilli()).
 		EndTime(endTime.UnixMilli()).
-		Limit(1000).
+		Limit(klineFetchLimit).
 		Do(ctx)
 
 	if err != nil {
@@ -142,8 +180,14 @@ func (m *MarketData) GetOHLCV(ctx context.Context, symbol string, timeframe stri
 
 // CalculateIndicators calculates technical indicators from OHLCV data
 func CalculateIndicators(ohlcvData []OHLCV) *TechnicalIndicators {
+	return CalculateIndicatorSet(ohlcvData, nil)
+}
+
+// CalculateIndicatorSet calculates only the indicators in set (nil = the default set)
+// CalculateIndicatorSet 只计算 set 中的指标（nil 为默认指标集），未选中的指标序列为 nil
+func CalculateIndicatorSet(ohlcvData []OHLCV, set IndicatorSet) *TechnicalIndicators {
 	if len(ohlcvData) == 0 {
-		return &TechnicalIndicators{}
+		return &TechnicalIndicators{Set: set}
 	}
 
 	// Extract price and volume arrays
@@ -159,50 +203,81 @@ func CalculateIndicators(ohlcvData []OHLCV) *TechnicalIndicators {
 		volumes[i] = candle.Volume
 	}
 
+	// ATR(14) is always needed: stop-loss placement depends on it
+	// ATR(14) 始终计算：止损位依赖它
+	ind := &TechnicalIndicators{
+		ATR:    calculateATR(highs, lows, closes, 14),
+		Volume: volumes,
+		Set:    set,
+	}
+
 	// Calculate indicators
-	rsi := calculateRSI(closes, 14)
-	rsi7 := calculateRSI(closes, 7) // 新增：7期RSI（短期超买超卖判断）
-	macd, signal := calculateMACD(closes)
-	bbUpper, bbMiddle, bbLower := calculateBollingerBands(closes, 20, 2.0)
-	sma20 := calculateSMA(closes, 20)
-	sma50 := calculateSMA(closes, 50)
-	sma200 := calculateSMA(closes, 200)
-	ema12 := calculateEMA(closes, 12)
-	ema20 := calculateEMA(closes, 20) // 新增：20期EMA（常用趋势线）
-	ema26 := calculateEMA(closes, 26)
-	atr := calculateATR(highs, lows, closes, 14)
-	atr3 := calculateATR(highs, lows, closes, 3) // 新增：3期ATR（短期波动率）
+	if set.Has("rsi14") {
+		ind.RSI = calculateRSI(closes, 14)
+	}
+	if set.Has("rsi7") {
+		ind.RSI_7 = calculateRSI(closes, 7) // 新增：7期RSI（短期超买超卖判断）
+	}
+	if set.Has("macd") {
+		ind.MACD, ind.Signal = calculateMACD(closes)
+	}
+	if set.Has("bb") {
+		ind.BB_Upper, ind.BB_Middle, ind.BB_Lower = calculateBollingerBands(closes, 20, 2.0)
+	}
+	if set.Has("sma20") {
+		ind.SMA_20 = calculateSMA(closes, 20)
+	}
+	if set.Has("sma50") {
+		ind.SMA_50 = calculateSMA(closes, 50)
+	}
+	if set.Has("sma200") {
+		ind.SMA_200 = calculateSMA(closes, 200)
+	}
+	if set.Has("ema12") {
+		ind.EMA_12 = calculateEMA(closes, 12)
+	}
+	if set.Has("ema20") {
+		ind.EMA_20 = calculateEMA(closes, 20) // 新增：20期EMA（常用趋势线）
+	}
+	if set.Has("ema26") {
+		ind.EMA_26 = calculateEMA(closes, 26)
+	}
+	if set.Has("atr") {
+		ind.ATR_3 = calculateATR(highs, lows, closes, 3) // 新增：3期ATR（短期波动率）
+	}
 
 	// New indicators for trend strength and volume confirmation
 	// 新增指标：趋势强度和成交量确认
-	adx, diPlus, diMinus := calculateADX(highs, lows, closes, 14)
-	volumeRatio := calculateVolumeRatio(volumes, 20)
-
-	return &TechnicalIndicators{
-		RSI:       rsi,
-		RSI_7:     rsi7, // 新增
-		MACD:      macd,
-		Signal:    signal,
-		BB_Upper:  bbUpper,
-		BB_Middle: bbMiddle,
-		BB_Lower:  bbLower,
-		SMA_20:    sma20,
-		SMA_50:    sma50,
-		SMA_200:   sma200,
-		EMA_12:    ema12,
-		EMA_20:    ema20, // 新增
-		EMA_26:    ema26,
-		ATR:       atr,
-		ATR_3:     atr3, // 新增
-		Volume:    volumes,
-
-		// New indicators
-		// 新增指标
-		ADX:         adx,
-		DI_Plus:     diPlus,
-		DI_Minus:    diMinus,
-		VolumeRatio: volumeRatio,
+	if set.Has("adx") {
+		ind.ADX, ind.DI_Plus, ind.DI_Minus = calculateADX(highs, lows, closes, 14)
 	}
+	if set.Has("volume") {
+		ind.VolumeRatio = calculateVolumeRatio(volumes, 20)
+	}
+
+	// Volume-at-price context: VWAP and volume profile
+	// 成交量价位信息：VWAP 和成交量分布
+	if set.Has("vwap") {
+		ind.VWAP = calculateSessionVWAP(ohlcvData)
+		ind.VWAP_20 = calculateRollingVWAP(ohlcvData, rollingVWAPPeriod)
+	}
+	if set.Has("profile") {
+		ind.VolumeProfile = CalculateVolumeProfile(ohlcvData, volumeProfileWindow, volumeProfileBins)
+	}
+
+	// Advanced indicators: Ichimoku, SuperTrend, StochRSI
+	// 高级指标：一目均衡表、SuperTrend、StochRSI
+	if set.Has("ichimoku") {
+		ind.Tenkan, ind.Kijun, ind.SenkouA, ind.SenkouB = calculateIchimoku(highs, lows)
+	}
+	if set.Has("supertrend") {
+		ind.SuperTrend, ind.SuperTrendDir = calculateSuperTrend(highs, lows, closes, superTrendPeriod, superTrendMultiplier)
+	}
+	if set.Has("stochrsi") {
+		ind.StochRSI_K, ind.StochRSI_D = calculateStochRSI(closes, stochRSIPeriod, stochRSISmooth)
+	}
+
+	return ind
 }
 
 // calculateSMA calculates Simple Moving Average
@@ -635,43 +710,69 @@ func FormatIndicatorReport(symbol string, timeframe string, ohlcvData []OHLCV, i
 
 	// === 当前值摘要 ===
 	// === Current Values Summary ===
-	currentEMA12 := 0.0
-	if len(indicators.EMA_12) > lastIdx && !math.IsNaN(indicators.EMA_12[lastIdx]) {
-		currentEMA12 = indicators.EMA_12[lastIdx]
+	// Only the configured indicators are summarised; a missing value reads 0
+	// 只汇总配置的指标；缺失值显示为 0
+	set := indicators.Set
+	current := func(data []float64) float64 {
+		if len(data) > lastIdx && !math.IsNaN(data[lastIdx]) {
+			return data[lastIdx]
+		}
+		return 0.0
+	}
+
+	prices := []string{fmt.Sprintf("当前中间价 = %.1f", latestMidPrice)}
+	averages := []struct {
+		name, label string
+		data        []float64
+		listed      bool // 默认不在摘要中 / Not in the default summary
+	}{
+		{"ema12", "EMA(12)", indicators.EMA_12, false},
+		{"ema20", "EMA(20)", indicators.EMA_20, true},
+		{"ema26", "EMA(26)", indicators.EMA_26, false},
+		{"sma20", "SMA(20)", indicators.SMA_20, true},
+		{"sma50", "SMA(50)", indicators.SMA_50, true},
+		{"sma200", "SMA(200)", indicators.SMA_200, true},
+	}
+	for _, avg := range averages {
+		if (avg.listed && set.Listed(avg.name)) || (!avg.listed && set.Has(avg.name)) {
+			prices = append(prices, fmt.Sprintf("%s = %.1f", avg.label, current(avg.data)))
+		}
 	}
+	sb.WriteString(strings.Join(prices, ", ") + "\n")
 
-	currentEMA26 := 0.0
-	if len(indicators.EMA_26) > lastIdx && !math.IsNaN(indicators.EMA_26[lastIdx]) {
-		currentEMA26 = indicators.EMA_26[lastIdx]
+	var momentum []string
+	if set.Has("macd") {
+		momentum = append(momentum, fmt.Sprintf("MACD = %.1f", current(indicators.MACD)))
 	}
-
-	currentMACD := 0.0
-	if len(indicators.MACD) > lastIdx && !math.IsNaN(indicators.MACD[lastIdx]) {
-		currentMACD = indicators.MACD[lastIdx]
+	if set.Has("rsi7") {
+		momentum = append(momentum, fmt.Sprintf("RSI(7) = %.1f", current(indicators.RSI_7)))
 	}
-
-	//currentMACDSignal := 0.0
-	//if len(indicators.Signal) > lastIdx && !math.IsNaN(indicators.Signal[lastIdx]) {
-	//	currentMACDSignal = indicators.Signal[lastIdx]
-	//}
-
-	currentRSI7 := 0.0
-	if len(indicators.RSI_7) > lastIdx && !math.IsNaN(indicators.RSI_7[lastIdx]) {
-		currentRSI7 = indicators.RSI_7[lastIdx]
+	if set.Has("rsi14") {
+		momentum = append(momentum, fmt.Sprintf("RSI(14) = %.1f", current(indicators.RSI)))
 	}
-
-	currentRSI14 := 0.0
-	if len(indicators.RSI) > lastIdx && !math.IsNaN(indicators.RSI[lastIdx]) {
-		currentRSI14 = indicators.RSI[lastIdx]
+	if set.Has("adx") {
+		momentum = append(momentum, fmt.Sprintf("ADX = %.1f", current(indicators.ADX)))
 	}
-
-	currentADX := 0.0
-	if len(indicators.ADX) > lastIdx && !math.IsNaN(indicators.ADX[lastIdx]) {
-		currentADX = indicators.ADX[lastIdx]
+	if len(momentum) > 0 {
+		sb.WriteString(strings.Join(momentum, ", ") + "\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("当前中间价 = %.1f, EMA(12) = %.1f, EMA(26) = %.1f\n", latestMidPrice, currentEMA12, currentEMA26))
-	sb.WriteString(fmt.Sprintf("MACD = %.1f,  RSI(7) = %.1f, RSI(14) = %.1f, ADX = %.1f\n\n", currentMACD, currentRSI7, currentRSI14, currentADX))
+	// Volume-at-price: where the close sits relative to VWAP and the value area
+	// 成交量价位：收盘价相对 VWAP 和价值区的位置
+	latestClose := ohlcvData[lastIdx].Close
+	if len(indicators.VWAP) > lastIdx && indicators.VWAP[lastIdx] > 0 {
+		vwap := indicators.VWAP[lastIdx]
+		sb.WriteString(fmt.Sprintf("VWAP(日内) = %.1f (收盘价偏离 %+.2f%%)", vwap, (latestClose-vwap)/vwap*100))
+		if len(indicators.VWAP_20) > lastIdx && !math.IsNaN(indicators.VWAP_20[lastIdx]) {
+			sb.WriteString(fmt.Sprintf(", VWAP(20) = %.1f", indicators.VWAP_20[lastIdx]))
+		}
+		sb.WriteString("\n")
+	}
+	if profile := indicators.VolumeProfile; profile != nil {
+		sb.WriteString(fmt.Sprintf("成交量分布(最近%d根): POC = %.1f, 价值区(70%%) = %.1f - %.1f, 收盘价位于%s\n",
+			profile.Candles, profile.POC, profile.ValueAreaLow, profile.ValueAreaHigh, profile.Location(latestClose)))
+	}
+	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("下述所有价格或信号数据均按时间从旧到新排列。\n\n"))
 
 	// === 日内数据（最近10期）===
@@ -751,6 +852,32 @@ func FormatIndicatorReport(symbol string, timeframe string, ohlcvData []OHLCV, i
 		sb.WriteString(fmt.Sprintf("ADX: %s\n\n", formatSeries(indicators.ADX, startIdx, lastIdx, 1)))
 	}
 
+	// 7. VWAP 日内成交量加权均价（机构成本线）
+	// Session VWAP: volume-weighted average price of the UTC day
+	if len(indicators.VWAP) > lastIdx {
+		sb.WriteString(fmt.Sprintf("VWAP(日内): %s\n\n", formatSeries(indicators.VWAP, startIdx, lastIdx, 1)))
+	}
+
+	// 8. 显式配置的额外序列（默认报告不输出）
+	// Extra series only reported when listed in INDICATORS
+	extras := []struct {
+		name, label string
+		data        []float64
+		decimals    int
+	}{
+		{"ema20", "EMA(20)", indicators.EMA_20, 1},
+		{"sma20", "SMA(20)", indicators.SMA_20, 1},
+		{"sma50", "SMA(50)", indicators.SMA_50, 1},
+		{"sma200", "SMA(200)", indicators.SMA_200, 1},
+		{"atr", "ATR(14)", indicators.ATR, 2},
+		{"volume", "成交量比率", indicators.VolumeRatio, 2},
+	}
+	for _, extra := range extras {
+		if set.Listed(extra.name) && len(extra.data) > lastIdx {
+			sb.WriteString(fmt.Sprintf("%s: %s\n\n", extra.label, formatSeries(extra.data, startIdx, lastIdx, extra.decimals)))
+		}
+	}
+
 	return sb.String()
 }
 
@@ -773,6 +900,34 @@ func (m *MarketData) GetFundingRate(ctx context.Context, symbol string) (float64
 	return fundingRate, nil
 }
 
+// FundingInfo holds the upcoming funding settlement of a perpetual contract
+// FundingInfo 保存永续合约下一次资金费结算的信息
+type FundingInfo struct {
+	Rate            float64   // 预测资金费率（正数为多头支付）/ Predicted funding rate (positive = longs pay)
+	MarkPrice       float64   // 标记价格 / Mark price
+	NextFundingTime time.Time // 下次结算时间 / Next settlement time
+}
+
+// GetFundingInfo fetches the predicted funding rate and next settlement time from /fapi/v1/premiumIndex
+// GetFundingInfo 从 /fapi/v1/premiumIndex 获取预测资金费率和下次结算时间
+func (m *MarketData) GetFundingInfo(ctx context.Context, symbol string) (*FundingInfo, error) {
+	indexes, err := m.client.NewPremiumIndexService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch premium index: %w", err)
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no premium index data available")
+	}
+
+	rate, _ := strconv.ParseFloat(indexes[0].LastFundingRate, 64)
+	markPrice, _ := strconv.ParseFloat(indexes[0].MarkPrice, 64)
+	return &FundingInfo{
+		Rate:            rate,
+		MarkPrice:       markPrice,
+		NextFundingTime: time.UnixMilli(indexes[0].NextFundingTime),
+	}, nil
+}
+
 // GetOrderBook fetches the order book depth
 func (m *MarketData) GetOrderBook(ctx context.Context, symbol string, limit int) (map[string]interface{}, error) {
 	depth, err := m.client.NewDepthService().
@@ -786,13 +941,19 @@ func (m *MarketData) GetOrderBook(ctx context.Context, symbol string, limit int)
 
 	// Calculate bid/ask strength
 	var bidVolume, askVolume float64
+	bids := make([]OrderBookLevel, 0, len(depth.Bids))
 	for _, bid := range depth.Bids {
+		price, _ := strconv.ParseFloat(bid.Price, 64)
 		qty, _ := strconv.ParseFloat(bid.Quantity, 64)
 		bidVolume += qty
+		bids = append(bids, OrderBookLevel{Price: price, Quantity: qty})
 	}
+	asks := make([]OrderBookLevel, 0, len(depth.Asks))
 	for _, ask := range depth.Asks {
+		price, _ := strconv.ParseFloat(ask.Price, 64)
 		qty, _ := strconv.ParseFloat(ask.Quantity, 64)
 		askVolume += qty
+		asks = append(asks, OrderBookLevel{Price: price, Quantity: qty})
 	}
 
 	result := map[string]interface{}{
@@ -801,6 +962,7 @@ func (m *MarketData) GetOrderBook(ctx context.Context, symbol string, limit int)
 		"bid_volume":    bidVolume,
 		"ask_volume":    askVolume,
 		"bid_ask_ratio": bidVolume / (askVolume + 0.0001),
+		"metrics":       AnalyzeOrderBook(bids, asks),
 	}
 
 	return result, nil
@@ -874,58 +1036,6 @@ func (m *MarketData) GetOpenInterest(ctx context.Context, symbol string) (map[st
 	return result, nil
 }
 
-// GetTopLongShortPositionRatio 获取大户持仓多空比（支持 1h 和 4h 周期）
-// GetTopLongShortPositionRatio gets top trader long/short position ratio for specified period
-func (m *MarketData) GetTopLongShortPositionRatio(ctx context.Context, symbol string, period string, limit int) (map[string]interface{}, error) {
-	ratios, err := m.client.NewTopLongShortPositionRatioService().
-		Symbol(symbol).
-		Period(period).
-		Limit(uint32(limit)).
-		Do(ctx)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch top long/short position ratio: %w", err)
-	}
-
-	if len(ratios) == 0 {
-		return nil, fmt.Errorf("no data returned for top long/short position ratio")
-	}
-
-	// Binance API returns data in oldest-to-newest order (same as OpenInterestStatistics)
-	// 币安 API 返回数据按从旧到新的顺序（与 OpenInterestStatistics 相同）
-	// ratios[0] = oldest, ratios[len-1] = newest
-	// ratios[0] = 最旧，ratios[len-1] = 最新
-
-	// Prepare series data (API already returns oldest to newest, no need to reverse)
-	// 准备序列数据（API 已经按从旧到新返回，无需反转）
-	seriesRatios := make([]float64, 0, len(ratios))
-	for i := 0; i < len(ratios); i++ {
-		value, err := strconv.ParseFloat(ratios[i].LongShortRatio, 64)
-		if err != nil {
-			continue
-		}
-		seriesRatios = append(seriesRatios, value)
-	}
-
-	// Get the latest data point (last element in array)
-	// 获取最新数据点（数组最后一个元素）
-	latest := ratios[len(ratios)-1]
-	longShortRatio, _ := strconv.ParseFloat(latest.LongShortRatio, 64)
-	longAccount, _ := strconv.ParseFloat(latest.LongAccount, 64)
-	shortAccount, _ := strconv.ParseFloat(latest.ShortAccount, 64)
-
-	result := map[string]interface{}{
-		"period":           period,
-		"long_short_ratio": longShortRatio,
-		"long_account":     longAccount * 100,  // Convert to percentage
-		"short_account":    shortAccount * 100, // Convert to percentage
-		"timestamp":        latest.Timestamp,
-		"series_ratios":    seriesRatios,
-	}
-
-	return result, nil
-}
-
 // GetOpenInterestChange 获取持仓量变化统计（对比当前和历史数据）
 // GetOpenInterestChange gets open interest change by comparing current and historical data
 func (m *MarketData) GetOpenInterestChange(ctx context.Context, symbol string, period string, limit int) (map[string]interface{}, error) {
@@ -1004,6 +1114,9 @@ func FormatOrderBookReport(orderBook map[string]interface{}, topN int) string {
 	report.WriteString(fmt.Sprintf("📊 当前订单簿深度分析（前 %d 档）:\n", topN))
 	report.WriteString(fmt.Sprintf("  买卖盘总量: 买 %.2f vs 卖 %.2f\n", bidVolume, askVolume))
 	report.WriteString(fmt.Sprintf("  买卖比: %.2f\n", bidAskRatio))
+	if metrics, ok := orderBook["metrics"].(*OrderBookMetrics); ok && metrics != nil {
+		report.WriteString(formatOrderBookMetrics(metrics))
+	}
 
 	return report.String()
 }
@@ -1101,6 +1214,8 @@ func FormatLongerTimeframeReport(symbol string, timeframe string, ohlcvData []OH
 	}
 	sb.WriteString(fmt.Sprintf("中间价(%s间隔): [%s]\n", timeframe, strings.Join(middlePrices, ", ")))
 
+	set := indicators.Set
+
 	// === EMA(20) vs 50-Period EMA ===
 	ema20Val := 0.0
 	sma50Val := 0.0
@@ -1110,7 +1225,9 @@ func FormatLongerTimeframeReport(symbol string, timeframe string, ohlcvData []OH
 	if len(indicators.SMA_50) > lastIdx && !math.IsNaN(indicators.SMA_50[lastIdx]) {
 		sma50Val = indicators.SMA_50[lastIdx]
 	}
-	sb.WriteString(fmt.Sprintf("EMA(20): %.1f vs. EMA(50): %.1f\n\n", ema20Val, sma50Val))
+	if set.Has("ema20") || set.Has("sma50") {
+		sb.WriteString(fmt.Sprintf("EMA(20): %.1f vs. EMA(50): %.1f\n\n", ema20Val, sma50Val))
+	}
 
 	// === ATR(3) vs 14-Period ATR ===
 	atr3Val := 0.0
@@ -1121,7 +1238,9 @@ func FormatLongerTimeframeReport(symbol string, timeframe string, ohlcvData []OH
 	if len(indicators.ATR) > lastIdx && !math.IsNaN(indicators.ATR[lastIdx]) {
 		atr14Val = indicators.ATR[lastIdx]
 	}
-	sb.WriteString(fmt.Sprintf("ATR(3): %.1f vs. ATR(14): %.1f\n\n", atr3Val, atr14Val))
+	if set.Has("atr") {
+		sb.WriteString(fmt.Sprintf("ATR(3): %.1f vs. ATR(14): %.1f\n\n", atr3Val, atr14Val))
+	}
 
 	// === 当前成交量 vs 平均成交量 ===
 	// === Current Volume vs Average Volume ===
@@ -1134,7 +1253,9 @@ func FormatLongerTimeframeReport(symbol string, timeframe string, ohlcvData []OH
 		}
 		avgVolume /= 20
 	}
-	sb.WriteString(fmt.Sprintf("当前成交量: %.1f vs. 平均成交量: %.1f\n\n", currentVolume, avgVolume))
+	if set.Has("volume") {
+		sb.WriteString(fmt.Sprintf("当前成交量: %.1f vs. 平均成交量: %.1f\n\n", currentVolume, avgVolume))
+	}
 
 	// === MACD 序列（最近10期）===
 	// === MACD Series (Last 10 periods) ===
@@ -0,0 +1,300 @@
+package dataflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/format"
+)
+
+const (
+	fearGreedAPIURL = "https://api.alternative.me/fng/?limit=8" // 今天及过去 7 天 / Today and the last 7 days
+	moodCacheTTL    = 5 * time.Minute                           // 全市场指标在同一轮分析中只拉取一次 / Market-wide metrics are fetched once per cycle
+)
+
+// FearGreed is the alternative.me Crypto Fear & Greed Index (0 = extreme fear, 100 = extreme greed)
+// FearGreed 是 alternative.me 加密货币恐慌贪婪指数（0 为极度恐慌，100 为极度贪婪）
+type FearGreed struct {
+	Value          int
+	Classification string
+	Yesterday      int // 昨日数值（-1 表示未知）/ Yesterday's value (-1 = unknown)
+	WeekAgo        int // 7 天前数值（-1 表示未知）/ Value 7 days ago (-1 = unknown)
+	Timestamp      time.Time
+}
+
+// MetricTrend is the latest value of an on-chain metric and its change from the previous point
+// MetricTrend 是链上指标的最新值及其相对上一个数据点的变化
+type MetricTrend struct {
+	Name      string
+	Latest    float64
+	Previous  float64
+	Timestamp time.Time
+}
+
+// MoodSnapshot holds the Fear & Greed Index and the on-chain metrics of a symbol
+// MoodSnapshot 保存恐慌贪婪指数和交易对的链上指标
+type MoodSnapshot struct {
+	Symbol    string
+	FearGreed *FearGreed
+	Metrics   []MetricTrend
+	Errors    []string // 获取失败的指标 / Metrics that failed
+}
+
+// onChainSource is a configured on-chain metric API; {base} in the URL is replaced by the base symbol
+// onChainSource 是已配置的链上指标 API；URL 中的 {base} 会被替换为基础币种
+type onChainSource struct {
+	name string
+	url  string
+}
+
+// MarketMood fetches the Fear & Greed Index and the configured on-chain metrics
+// MarketMood 获取恐慌贪婪指数和已配置的链上指标
+type MarketMood struct {
+	fearGreedURL string // 为空时不获取 / Not fetched when empty
+	sources      []onChainSource
+
+	mu    sync.Mutex
+	cache map[string]cachedBody // 按请求地址缓存 / Cached by request URL
+}
+
+// cachedBody is a cached API response
+// cachedBody 是缓存的 API 响应
+type cachedBody struct {
+	body    []byte
+	fetched time.Time
+}
+
+// NewMarketMood creates the fetcher from FEAR_GREED_ENABLED, ONCHAIN_NETFLOW_URL and ONCHAIN_STABLECOIN_URL
+// NewMarketMood 根据 FEAR_GREED_ENABLED、ONCHAIN_NETFLOW_URL 和 ONCHAIN_STABLECOIN_URL 创建获取器
+func NewMarketMood(cfg *config.Config) *MarketMood {
+	fearGreedURL := ""
+	if cfg.FearGreedEnabled {
+		fearGreedURL = fearGreedAPIURL
+	}
+	return NewMarketMoodWith(fearGreedURL, cfg.OnChainNetflowURL, cfg.OnChainStablecoinURL)
+}
+
+// NewMarketMoodWith creates the fetcher from explicit URLs (an empty URL disables that metric)
+// NewMarketMoodWith 使用指定地址创建获取器（地址为空表示不获取该指标）
+func NewMarketMoodWith(fearGreedURL, netflowURL, stablecoinURL string) *MarketMood {
+	m := &MarketMood{fearGreedURL: fearGreedURL, cache: make(map[string]cachedBody)}
+	if netflowURL != "" {
+		m.sources = append(m.sources, onChainSource{name: "交易所净流入", url: netflowURL})
+	}
+	if stablecoinURL != "" {
+		m.sources = append(m.sources, onChainSource{name: "稳定币供应量", url: stablecoinURL})
+	}
+	return m
+}
+
+// Enabled reports whether any metric is configured
+// Enabled 返回是否配置了任一指标
+func (m *MarketMood) Enabled() bool {
+	return m.fearGreedURL != "" || len(m.sources) > 0
+}
+
+// Snapshot fetches the Fear & Greed Index and the on-chain metrics of a symbol
+// Snapshot 获取恐慌贪婪指数和交易对的链上指标
+//
+// Responses are cached for moodCacheTTL, so market-wide metrics are fetched once for all symbols.
+// 响应缓存 moodCacheTTL，全市场指标只为所有交易对拉取一次。
+func (m *MarketMood) Snapshot(ctx context.Context, symbol string) *MoodSnapshot {
+	base, _, _ := strings.Cut(strings.ToUpper(symbol), "/")
+	snapshot := &MoodSnapshot{Symbol: symbol}
+
+	if m.fearGreedURL != "" {
+		fearGreed, err := m.fearGreed(ctx)
+		if err != nil {
+			snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("恐慌贪婪指数: %v", err))
+		}
+		snapshot.FearGreed = fearGreed
+	}
+	for _, source := range m.sources {
+		trend, err := m.metric(ctx, source, base)
+		if err != nil {
+			snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("%s: %v", source.name, err))
+			continue
+		}
+		snapshot.Metrics = append(snapshot.Metrics, *trend)
+	}
+	return snapshot
+}
+
+// fearGreed fetches and parses the Fear & Greed Index history
+// fearGreed 获取并解析恐慌贪婪指数历史
+func (m *MarketMood) fearGreed(ctx context.Context) (*FearGreed, error) {
+	body, err := m.fetch(ctx, m.fearGreedURL)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data []struct {
+			Value          string `json:"value"`
+			Classification string `json:"value_classification"`
+			Timestamp      string `json:"timestamp"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	// Data is ordered newest first, one point per day
+	// 数据按时间倒序，每天一个数据点
+	values := make([]int, len(resp.Data))
+	for i, point := range resp.Data {
+		value, err := strconv.Atoi(point.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", point.Value, err)
+		}
+		values[i] = value
+	}
+	fearGreed := &FearGreed{Value: values[0], Classification: resp.Data[0].Classification, Yesterday: -1, WeekAgo: -1}
+	if seconds, err := strconv.ParseInt(resp.Data[0].Timestamp, 10, 64); err == nil {
+		fearGreed.Timestamp = time.Unix(seconds, 0)
+	}
+	if len(values) > 1 {
+		fearGreed.Yesterday = values[1]
+	}
+	if len(values) > 7 {
+		fearGreed.WeekAgo = values[7]
+	}
+	return fearGreed, nil
+}
+
+// metric fetches an on-chain metric series and returns its latest two points
+// metric 获取链上指标序列并返回最近两个数据点
+//
+// The API must return a JSON array of {"timestamp": <unix seconds>, "value": <number>} ordered by time,
+// either directly or under "data"; numbers may be encoded as strings.
+// API 需返回按时间排序的 {"timestamp": <Unix 秒>, "value": <数值>} JSON 数组（直接返回或放在 "data" 下），数值可以是字符串。
+func (m *MarketMood) metric(ctx context.Context, source onChainSource, base string) (*MetricTrend, error) {
+	body, err := m.fetch(ctx, strings.ReplaceAll(source.url, "{base}", base))
+	if err != nil {
+		return nil, err
+	}
+
+	type point struct {
+		Timestamp json.Number `json:"timestamp"`
+		Value     json.Number `json:"value"`
+	}
+	var points []point
+	if err := json.Unmarshal(body, &points); err != nil {
+		var wrapped struct {
+			Data []point `json:"data"`
+		}
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		points = wrapped.Data
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	latest := points[len(points)-1]
+	trend := &MetricTrend{Name: source.name}
+	if trend.Latest, err = latest.Value.Float64(); err != nil {
+		return nil, fmt.Errorf("invalid value %q: %w", latest.Value, err)
+	}
+	trend.Previous = trend.Latest
+	if len(points) > 1 {
+		if previous, err := points[len(points)-2].Value.Float64(); err == nil {
+			trend.Previous = previous
+		}
+	}
+	if seconds, err := latest.Timestamp.Int64(); err == nil {
+		trend.Timestamp = time.Unix(seconds, 0)
+	}
+	return trend, nil
+}
+
+// fetch returns the response body of a URL, cached for moodCacheTTL
+// fetch 返回 URL 的响应内容（缓存 moodCacheTTL）
+func (m *MarketMood) fetch(ctx context.Context, target string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.cache[target]; ok && time.Since(cached.fetched) < moodCacheTTL {
+		return cached.body, nil
+	}
+	body, err := fetchURL(ctx, nil, target)
+	if err != nil {
+		return nil, err
+	}
+	m.cache[target] = cachedBody{body: body, fetched: time.Now()}
+	return body, nil
+}
+
+// trendArrow returns ↑, ↓ or → for the change from previous to latest
+// trendArrow 根据从 previous 到 latest 的变化返回 ↑、↓ 或 →
+func trendArrow(previous, latest float64) string {
+	switch {
+	case latest > previous:
+		return "↑"
+	case latest < previous:
+		return "↓"
+	default:
+		return "→"
+	}
+}
+
+// FormatMoodSnapshot formats the Fear & Greed Index and on-chain metrics as sections of the sentiment report
+// FormatMoodSnapshot 将恐慌贪婪指数和链上指标格式化为情绪报告的章节
+func FormatMoodSnapshot(snapshot *MoodSnapshot) string {
+	if snapshot == nil {
+		return ""
+	}
+	var sb strings.Builder
+
+	if fg := snapshot.FearGreed; fg != nil {
+		sb.WriteString("\n## 恐慌贪婪指数（alternative.me）\n")
+		sb.WriteString(fmt.Sprintf("- **当前**: %d（%s）\n", fg.Value, fg.Classification))
+		if fg.Yesterday >= 0 {
+			sb.WriteString(fmt.Sprintf("- **较昨日**: %s %+d（昨日 %d）\n", trendArrow(float64(fg.Yesterday), float64(fg.Value)), fg.Value-fg.Yesterday, fg.Yesterday))
+		}
+		if fg.WeekAgo >= 0 {
+			sb.WriteString(fmt.Sprintf("- **较 7 天前**: %s %+d（7 天前 %d）\n", trendArrow(float64(fg.WeekAgo), float64(fg.Value)), fg.Value-fg.WeekAgo, fg.WeekAgo))
+		}
+		switch {
+		case fg.Value <= 25:
+			sb.WriteString("- 解读: 极度恐慌往往伴随超卖，逆向信号，警惕空头衰竭\n")
+		case fg.Value >= 75:
+			sb.WriteString("- 解读: 极度贪婪往往伴随过热，警惕多头拥挤和回调\n")
+		}
+	}
+
+	if len(snapshot.Metrics) > 0 {
+		sb.WriteString("\n## 链上数据\n")
+		for _, metric := range snapshot.Metrics {
+			change := metric.Latest - metric.Previous
+			delta := format.Compact(change, 2)
+			if change > 0 {
+				delta = "+" + delta
+			}
+			line := fmt.Sprintf("- **%s**: %s %s（变化 %s", metric.Name, format.Compact(metric.Latest, 2),
+				trendArrow(metric.Previous, metric.Latest), delta)
+			if metric.Previous != 0 {
+				line += fmt.Sprintf("，%+.2f%%", change/math.Abs(metric.Previous)*100)
+			}
+			line += "）"
+			if !metric.Timestamp.IsZero() {
+				line += fmt.Sprintf("，数据时间 %s", metric.Timestamp.Format("01-02 15:04"))
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	for _, err := range snapshot.Errors {
+		sb.WriteString(fmt.Sprintf("⚠️ 指标获取失败: %s\n", err))
+	}
+	return sb.String()
+}
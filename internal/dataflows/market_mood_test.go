@@ -0,0 +1,64 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMarketMoodSnapshot tests the Fear & Greed trend, on-chain metrics and response caching
+// TestMarketMoodSnapshot 测试恐慌贪婪指数趋势、链上指标和响应缓存
+func TestMarketMoodSnapshot(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/fng":
+			var points []string
+			for i, value := range []int{72, 65, 60, 55, 50, 45, 40, 30} {
+				points = append(points, fmt.Sprintf(`{"value": "%d", "value_classification": "Greed", "timestamp": "%d"}`, value, 1700000000-i*86400))
+			}
+			fmt.Fprintf(w, `{"data": [%s]}`, strings.Join(points, ","))
+		case "/netflow/BTC":
+			w.Write([]byte(`[{"timestamp": 1700000000, "value": 1200}, {"timestamp": 1700003600, "value": "-800.5"}]`))
+		case "/stablecoins":
+			w.Write([]byte(`{"data": [{"timestamp": 1700000000, "value": 150000000000}, {"timestamp": 1700086400, "value": 151500000000}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	mood := NewMarketMoodWith(server.URL+"/fng", server.URL+"/netflow/{base}", server.URL+"/stablecoins")
+	snapshot := mood.Snapshot(context.Background(), "BTC/USDT")
+	if len(snapshot.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", snapshot.Errors)
+	}
+	fg := snapshot.FearGreed
+	if fg == nil || fg.Value != 72 || fg.Yesterday != 65 || fg.WeekAgo != 30 {
+		t.Fatalf("Unexpected Fear & Greed: %+v", fg)
+	}
+	if len(snapshot.Metrics) != 2 || snapshot.Metrics[0].Latest != -800.5 || snapshot.Metrics[0].Previous != 1200 {
+		t.Fatalf("Unexpected metrics: %+v", snapshot.Metrics)
+	}
+
+	report := FormatMoodSnapshot(snapshot)
+	for _, want := range []string{"72（Greed）", "↑ +7（昨日 65）", "↑ +42（7 天前 30）", "交易所净流入**: -800.50 ↓", "稳定币供应量**: 151.50B ↑（变化 +1.50B，+1.00%）"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+
+	// The market-wide index is cached across symbols
+	// 全市场指数在交易对之间共用缓存
+	mood.Snapshot(context.Background(), "ETH/USDT")
+	if requests["/fng"] != 1 || requests["/stablecoins"] != 1 {
+		t.Errorf("Expected market-wide metrics to be fetched once, got %v", requests)
+	}
+	if requests["/netflow/ETH"] != 1 {
+		t.Errorf("Expected the per-coin netflow to be fetched for ETH, got %v", requests)
+	}
+}
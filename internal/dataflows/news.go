@@ -0,0 +1,418 @@
+package dataflows
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+const (
+	cryptoPanicAPIURL = "https://cryptopanic.com/api/v1/posts/"
+	newsTimeout       = 10 * time.Second
+	rssCacheTTL       = 5 * time.Minute // 同一轮分析中多个交易对共用 RSS 结果 / Symbols of one cycle share the feeds
+)
+
+// coinNames maps base symbols to the names used in headlines, for matching RSS items
+// coinNames 将基础币种映射到新闻标题中常用的名称，用于匹配 RSS 条目
+var coinNames = map[string][]string{
+	"BTC":  {"bitcoin"},
+	"ETH":  {"ethereum", "ether"},
+	"SOL":  {"solana"},
+	"BNB":  {"binance coin"},
+	"XRP":  {"ripple"},
+	"DOGE": {"dogecoin"},
+	"ADA":  {"cardano"},
+	"AVAX": {"avalanche"},
+	"LINK": {"chainlink"},
+	"DOT":  {"polkadot"},
+	"LTC":  {"litecoin"},
+	"TRX":  {"tron"},
+}
+
+// NewsHeadline is a recent news headline about a coin
+// NewsHeadline 是关于某个币种的近期新闻头条
+type NewsHeadline struct {
+	Title       string
+	Source      string
+	URL         string
+	PublishedAt time.Time
+	Mentions    int // 报道该新闻的来源数 / Number of sources that carried the story
+}
+
+// NewsProvider fetches recent headlines about a base symbol, e.g. BTC
+// NewsProvider 获取关于基础币种（如 BTC）的近期新闻头条
+type NewsProvider interface {
+	Name() string
+	Headlines(ctx context.Context, base string) ([]NewsHeadline, error)
+}
+
+// CryptoPanicProvider reads headlines from the CryptoPanic posts API
+// CryptoPanicProvider 从 CryptoPanic posts API 读取新闻头条
+type CryptoPanicProvider struct {
+	APIKey  string
+	BaseURL string // 为空时使用官方地址 / Official API when empty
+	Client  *http.Client
+}
+
+// cryptoPanicResponse is the part of the CryptoPanic posts response that is used
+// cryptoPanicResponse 是 CryptoPanic posts 响应中用到的部分
+type cryptoPanicResponse struct {
+	Results []struct {
+		Title       string    `json:"title"`
+		URL         string    `json:"url"`
+		PublishedAt time.Time `json:"published_at"`
+		Source      struct {
+			Title string `json:"title"`
+		} `json:"source"`
+	} `json:"results"`
+}
+
+// Name returns the provider name
+// Name 返回数据源名称
+func (p *CryptoPanicProvider) Name() string {
+	return "CryptoPanic"
+}
+
+// Headlines returns the latest news posts tagged with the coin
+// Headlines 返回标记了该币种的最新新闻
+func (p *CryptoPanicProvider) Headlines(ctx context.Context, base string) ([]NewsHeadline, error) {
+	endpoint := p.BaseURL
+	if endpoint == "" {
+		endpoint = cryptoPanicAPIURL
+	}
+	query := url.Values{}
+	query.Set("auth_token", p.APIKey)
+	query.Set("currencies", strings.ToUpper(base))
+	query.Set("kind", "news")
+	query.Set("public", "true")
+
+	body, err := fetchURL(ctx, p.Client, endpoint+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var resp cryptoPanicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse CryptoPanic response: %w", err)
+	}
+
+	headlines := make([]NewsHeadline, 0, len(resp.Results))
+	for _, post := range resp.Results {
+		headlines = append(headlines, NewsHeadline{
+			Title:       post.Title,
+			Source:      post.Source.Title,
+			URL:         post.URL,
+			PublishedAt: post.PublishedAt,
+		})
+	}
+	return headlines, nil
+}
+
+// RSSProvider reads RSS 2.0 feeds and keeps the items whose title mentions the coin
+// RSSProvider 读取 RSS 2.0 新闻源，只保留标题提到该币种的条目
+type RSSProvider struct {
+	Feeds  []string
+	Client *http.Client
+
+	mu      sync.Mutex
+	items   []NewsHeadline
+	fetched time.Time
+	errs    error
+}
+
+// rssDocument is the part of an RSS 2.0 document that is used
+// rssDocument 是 RSS 2.0 文档中用到的部分
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Name returns the provider name
+// Name 返回数据源名称
+func (p *RSSProvider) Name() string {
+	return "RSS"
+}
+
+// Headlines returns the feed items that mention the coin by symbol or name
+// Headlines 返回以代码或名称提到该币种的新闻源条目
+//
+// Feeds are fetched at most once per rssCacheTTL and shared by all symbols; a feed that fails is
+// reported alongside the items of the feeds that succeeded.
+// 新闻源在 rssCacheTTL 内最多拉取一次并由所有交易对共用；部分新闻源失败时仍返回成功的条目并附带错误。
+func (p *RSSProvider) Headlines(ctx context.Context, base string) ([]NewsHeadline, error) {
+	items, err := p.fetchAll(ctx)
+
+	pattern := mentionPattern(base)
+	var headlines []NewsHeadline
+	for _, item := range items {
+		if pattern.MatchString(item.Title) {
+			headlines = append(headlines, item)
+		}
+	}
+	return headlines, err
+}
+
+// fetchAll returns the items of all feeds, refreshing them when the cache has expired
+// fetchAll 返回所有新闻源的条目，缓存过期时重新拉取
+func (p *RSSProvider) fetchAll(ctx context.Context) ([]NewsHeadline, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.fetched.IsZero() && time.Since(p.fetched) < rssCacheTTL {
+		return p.items, p.errs
+	}
+
+	var items []NewsHeadline
+	var errs []error
+	for _, feed := range p.Feeds {
+		feedItems, err := p.fetchFeed(ctx, feed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", feed, err))
+			continue
+		}
+		items = append(items, feedItems...)
+	}
+	p.items, p.errs, p.fetched = items, errors.Join(errs...), time.Now()
+	return p.items, p.errs
+}
+
+// fetchFeed downloads and parses one RSS feed
+// fetchFeed 下载并解析单个 RSS 新闻源
+func (p *RSSProvider) fetchFeed(ctx context.Context, feed string) ([]NewsHeadline, error) {
+	body, err := fetchURL(ctx, p.Client, feed)
+	if err != nil {
+		return nil, err
+	}
+	var doc rssDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS: %w", err)
+	}
+
+	items := make([]NewsHeadline, 0, len(doc.Channel.Items))
+	for _, item := range doc.Channel.Items {
+		published, err := parseRSSTime(item.PubDate)
+		if err != nil {
+			continue // 没有发布时间无法判断新旧 / Items without a date cannot be aged
+		}
+		items = append(items, NewsHeadline{
+			Title:       strings.TrimSpace(item.Title),
+			Source:      strings.TrimSpace(doc.Channel.Title),
+			URL:         strings.TrimSpace(item.Link),
+			PublishedAt: published,
+		})
+	}
+	return items, nil
+}
+
+// parseRSSTime parses the RFC 1123 dates used by RSS, with or without a numeric zone
+// parseRSSTime 解析 RSS 使用的 RFC 1123 日期（时区可为数字或名称）
+func parseRSSTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format: %q", value)
+}
+
+// mentionPattern matches the coin's symbol or one of its names as a whole word
+// mentionPattern 以整词匹配币种代码或名称
+func mentionPattern(base string) *regexp.Regexp {
+	base = strings.ToUpper(base)
+	terms := []string{regexp.QuoteMeta(strings.ToLower(base))}
+	for _, name := range coinNames[base] {
+		terms = append(terms, regexp.QuoteMeta(name))
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(terms, "|") + `)\b`)
+}
+
+// fetchURL performs a GET request and returns the body of a 200 response
+// fetchURL 发起 GET 请求并返回 200 响应的内容
+func fetchURL(ctx context.Context, client *http.Client, target string) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: newsTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: status_code=%d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+}
+
+// NewsDigest holds the deduplicated recent headlines of a symbol
+// NewsDigest 保存某个交易对去重后的近期新闻头条
+type NewsDigest struct {
+	Symbol    string
+	Headlines []NewsHeadline // 按发布时间倒序 / Newest first
+	MaxAge    time.Duration
+	Errors    []string // 失败的数据源 / Providers that failed
+}
+
+// NewsFeed combines the configured news providers
+// NewsFeed 组合已配置的新闻数据源
+type NewsFeed struct {
+	providers    []NewsProvider
+	maxHeadlines int
+	maxAge       time.Duration
+}
+
+// NewNewsFeed creates a news feed from CRYPTOPANIC_API_KEY and NEWS_RSS_FEEDS
+// NewNewsFeed 根据 CRYPTOPANIC_API_KEY 和 NEWS_RSS_FEEDS 创建新闻源
+func NewNewsFeed(cfg *config.Config) *NewsFeed {
+	var providers []NewsProvider
+	if cfg.CryptoPanicAPIKey != "" {
+		providers = append(providers, &CryptoPanicProvider{APIKey: cfg.CryptoPanicAPIKey})
+	}
+	if len(cfg.NewsRSSFeeds) > 0 {
+		providers = append(providers, &RSSProvider{Feeds: cfg.NewsRSSFeeds})
+	}
+	return NewNewsFeedWith(providers, cfg.NewsMaxHeadlines, time.Duration(cfg.NewsMaxAgeHours)*time.Hour)
+}
+
+// NewNewsFeedWith creates a news feed from explicit providers (maxHeadlines or maxAge 0 = no limit)
+// NewNewsFeedWith 使用指定数据源创建新闻源（maxHeadlines 或 maxAge 为 0 表示不限制）
+func NewNewsFeedWith(providers []NewsProvider, maxHeadlines int, maxAge time.Duration) *NewsFeed {
+	return &NewsFeed{providers: providers, maxHeadlines: maxHeadlines, maxAge: maxAge}
+}
+
+// Enabled reports whether any news provider is configured
+// Enabled 返回是否配置了新闻数据源
+func (f *NewsFeed) Enabled() bool {
+	return len(f.providers) > 0
+}
+
+// Digest fetches the recent headlines of a symbol from all providers, newest first
+// Digest 从所有数据源获取交易对的近期新闻头条（按发布时间倒序）
+//
+// The same story from several providers or outlets is kept once, counting the sources that carried it.
+// 多个数据源或媒体报道的同一条新闻只保留一次，并统计报道来源数。
+func (f *NewsFeed) Digest(ctx context.Context, symbol string) *NewsDigest {
+	base, _, _ := strings.Cut(strings.ToUpper(symbol), "/")
+	digest := &NewsDigest{Symbol: symbol, MaxAge: f.maxAge}
+
+	var all []NewsHeadline
+	for _, provider := range f.providers {
+		headlines, err := provider.Headlines(ctx, base)
+		if err != nil {
+			digest.Errors = append(digest.Errors, fmt.Sprintf("%s: %v", provider.Name(), err))
+		}
+		all = append(all, headlines...)
+	}
+
+	cutoff := time.Time{}
+	if f.maxAge > 0 {
+		cutoff = time.Now().Add(-f.maxAge)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].PublishedAt.After(all[j].PublishedAt) })
+
+	seen := make(map[string]int) // 去重键 → 在 Headlines 中的下标 / Dedupe key → index in Headlines
+	for _, headline := range all {
+		if headline.Title == "" || headline.PublishedAt.Before(cutoff) {
+			continue
+		}
+		key := headlineKey(headline.Title)
+		if i, ok := seen[key]; ok {
+			digest.Headlines[i].Mentions++
+			continue
+		}
+		if i, ok := seen[headline.URL]; ok && headline.URL != "" {
+			digest.Headlines[i].Mentions++
+			continue
+		}
+		headline.Mentions = 1
+		seen[key] = len(digest.Headlines)
+		if headline.URL != "" {
+			seen[headline.URL] = len(digest.Headlines)
+		}
+		digest.Headlines = append(digest.Headlines, headline)
+	}
+
+	if f.maxHeadlines > 0 && len(digest.Headlines) > f.maxHeadlines {
+		digest.Headlines = digest.Headlines[:f.maxHeadlines]
+	}
+	return digest
+}
+
+// headlineKey normalizes a title for deduplication, ignoring case, punctuation and spacing
+// headlineKey 将标题标准化用于去重（忽略大小写、标点和空白）
+func headlineKey(title string) string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// FormatNewsDigest formats the headlines as a section of the sentiment report
+// FormatNewsDigest 将新闻头条格式化为情绪报告的一个章节
+func FormatNewsDigest(digest *NewsDigest) string {
+	if digest == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if digest.MaxAge > 0 {
+		sb.WriteString(fmt.Sprintf("\n## 新闻头条（最近 %.0f 小时）\n", digest.MaxAge.Hours()))
+	} else {
+		sb.WriteString("\n## 新闻头条\n")
+	}
+
+	if len(digest.Headlines) == 0 {
+		sb.WriteString("- 暂无相关新闻\n")
+	}
+	for _, headline := range digest.Headlines {
+		sb.WriteString(fmt.Sprintf("- [%s] %s", newsAge(time.Since(headline.PublishedAt)), headline.Title))
+		if headline.Source != "" {
+			sb.WriteString(fmt.Sprintf(" — %s", headline.Source))
+		}
+		if headline.Mentions > 1 {
+			sb.WriteString(fmt.Sprintf("（%d 个来源）", headline.Mentions))
+		}
+		sb.WriteString("\n")
+	}
+	for _, err := range digest.Errors {
+		sb.WriteString(fmt.Sprintf("⚠️ 新闻源获取失败: %s\n", err))
+	}
+	sb.WriteString("\n说明: 新闻标题仅供参考，请结合价格走势判断市场是否已经消化该消息。\n")
+	return sb.String()
+}
+
+// newsAge formats the age of a headline, e.g. "35分钟前" or "3小时前"
+// newsAge 格式化新闻发布时长，例如 "35分钟前" 或 "3小时前"
+func newsAge(age time.Duration) string {
+	switch {
+	case age < 0:
+		return "刚刚"
+	case age < time.Hour:
+		return fmt.Sprintf("%d分钟前", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%d小时前", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%d天前", int(age.Hours()/24))
+	}
+}
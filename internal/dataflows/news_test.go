@@ -0,0 +1,120 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCryptoPanicProvider tests the request parameters and response parsing of CryptoPanic
+// TestCryptoPanicProvider 测试 CryptoPanic 的请求参数和响应解析
+func TestCryptoPanicProvider(t *testing.T) {
+	published := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("auth_token") != "token" || query.Get("currencies") != "BTC" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		fmt.Fprintf(w, `{"results": [{"title": "Bitcoin ETF inflows rise", "url": "https://example.com/1",
+			"published_at": %q, "source": {"title": "CoinDesk"}}]}`, published)
+	}))
+	defer server.Close()
+
+	provider := &CryptoPanicProvider{APIKey: "token", BaseURL: server.URL}
+	headlines, err := provider.Headlines(context.Background(), "btc")
+	if err != nil {
+		t.Fatalf("Headlines failed: %v", err)
+	}
+	if len(headlines) != 1 || headlines[0].Source != "CoinDesk" || headlines[0].PublishedAt.IsZero() {
+		t.Errorf("Unexpected headlines: %+v", headlines)
+	}
+}
+
+// TestRSSProvider tests that RSS items are filtered by coin symbol or name
+// TestRSSProvider 测试 RSS 条目按币种代码或名称过滤
+func TestRSSProvider(t *testing.T) {
+	now := time.Now()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `<rss><channel><title>Crypto News</title>
+<item><title>Ethereum upgrade goes live</title><link>https://example.com/eth</link><pubDate>%s</pubDate></item>
+<item><title>BTC miners sell reserves</title><link>https://example.com/btc</link><pubDate>%s</pubDate></item>
+<item><title>Tethered markets calm</title><link>https://example.com/other</link><pubDate>%s</pubDate></item>
+</channel></rss>`, now.Format(time.RFC1123Z), now.Format(time.RFC1123Z), now.Format(time.RFC1123Z))
+	}))
+	defer server.Close()
+
+	provider := &RSSProvider{Feeds: []string{server.URL}}
+	eth, err := provider.Headlines(context.Background(), "ETH")
+	if err != nil {
+		t.Fatalf("Headlines failed: %v", err)
+	}
+	if len(eth) != 1 || eth[0].Title != "Ethereum upgrade goes live" || eth[0].Source != "Crypto News" {
+		t.Errorf("Expected only the Ethereum item, got %+v", eth)
+	}
+
+	// The second symbol reuses the cached feed
+	// 第二个交易对复用缓存的新闻源
+	if btc, _ := provider.Headlines(context.Background(), "BTC"); len(btc) != 1 {
+		t.Errorf("Expected one BTC item, got %+v", btc)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the feed to be fetched once, got %d requests", requests)
+	}
+}
+
+// staticNews is a news provider returning fixed headlines
+// staticNews 是返回固定头条的新闻数据源
+type staticNews struct {
+	headlines []NewsHeadline
+	err       error
+}
+
+func (s *staticNews) Name() string { return "static" }
+
+func (s *staticNews) Headlines(ctx context.Context, base string) ([]NewsHeadline, error) {
+	return s.headlines, s.err
+}
+
+// TestNewsDigest tests deduplication, age filtering, ordering and the report section
+// TestNewsDigest 测试去重、时间过滤、排序和报告章节
+func TestNewsDigest(t *testing.T) {
+	now := time.Now()
+	first := &staticNews{headlines: []NewsHeadline{
+		{Title: "Bitcoin hits new high!", Source: "A", URL: "https://a.com/1", PublishedAt: now.Add(-2 * time.Hour)},
+		{Title: "Old bitcoin story", Source: "A", URL: "https://a.com/2", PublishedAt: now.Add(-48 * time.Hour)},
+	}}
+	second := &staticNews{headlines: []NewsHeadline{
+		{Title: "bitcoin hits  new high", Source: "B", URL: "https://b.com/1", PublishedAt: now.Add(-time.Hour)},
+		{Title: "Miners sell BTC", Source: "B", URL: "https://b.com/2", PublishedAt: now.Add(-30 * time.Minute)},
+	}}
+	failing := &staticNews{err: fmt.Errorf("timeout")}
+
+	digest := NewNewsFeedWith([]NewsProvider{first, second, failing}, 5, 24*time.Hour).Digest(context.Background(), "BTC/USDT")
+	if len(digest.Headlines) != 2 {
+		t.Fatalf("Expected 2 headlines after dedupe and age filter, got %+v", digest.Headlines)
+	}
+	if digest.Headlines[0].Title != "Miners sell BTC" || digest.Headlines[1].Mentions != 2 {
+		t.Errorf("Expected newest first with the duplicate counted, got %+v", digest.Headlines)
+	}
+	if len(digest.Errors) != 1 {
+		t.Errorf("Expected the failing provider to be reported, got %v", digest.Errors)
+	}
+
+	report := FormatNewsDigest(digest)
+	for _, want := range []string{"新闻头条（最近 24 小时）", "Miners sell BTC — B", "（2 个来源）", "static: timeout"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+
+	limited := NewNewsFeedWith([]NewsProvider{first, second}, 1, 0).Digest(context.Background(), "BTC/USDT")
+	if len(limited.Headlines) != 1 {
+		t.Errorf("Expected the headline limit to apply, got %d", len(limited.Headlines))
+	}
+}
@@ -0,0 +1,209 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/format"
+)
+
+const (
+	weightedSpreadBand = 1.0 // 加权价差统计范围（距中间价 %）/ Band of the weighted spread (% from mid)
+	wallMultiple       = 5.0 // 挂单金额达到中位数的倍数视为大单墙 / A level this many times the median notional is a wall
+	maxWallsPerSide    = 3   // 每侧最多报告的大单墙 / Walls reported per side
+)
+
+// depthBands are the distances from mid (%) where the depth imbalance is measured
+// depthBands 是统计深度失衡的距中间价距离（%）
+var depthBands = []float64{0.5, 1, 2}
+
+// OrderBookLevel is a price level of the order book
+// OrderBookLevel 是订单簿的一个价位
+type OrderBookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// DepthBand is the bid and ask notional within Percent of the mid price
+// DepthBand 是距中间价 Percent 范围内的买卖盘金额
+type DepthBand struct {
+	Percent     float64
+	BidNotional float64 // 买盘金额（USDT）/ Bid notional (USDT)
+	AskNotional float64 // 卖盘金额（USDT）/ Ask notional (USDT)
+	Imbalance   float64 // (买 - 卖) / (买 + 卖)，-1 到 1 / (bid - ask) / (bid + ask), -1 to 1
+	Covered     bool    // 获取的深度是否覆盖整个范围 / Whether the fetched depth spans the whole band
+}
+
+// OrderBookWall is a price level much larger than its neighbours
+// OrderBookWall 是明显大于周围挂单的价位
+type OrderBookWall struct {
+	Side            string // bid 或 ask / bid or ask
+	Price           float64
+	Notional        float64
+	DistancePercent float64 // 距中间价 % / Distance from mid (%)
+}
+
+// OrderBookMetrics are the depth metrics computed from an order book snapshot
+// OrderBookMetrics 是根据订单簿快照计算的深度指标
+type OrderBookMetrics struct {
+	BestBid           float64
+	BestAsk           float64
+	Mid               float64
+	SpreadBps         float64 // 买一卖一价差（基点）/ Top-of-book spread (bps)
+	MicroPrice        float64 // 按买一卖一数量加权的价格 / Price weighted by the top-of-book sizes
+	WeightedSpreadBps float64 // 1% 范围内卖盘与买盘加权均价之差（基点）/ Ask VWAP minus bid VWAP within 1% (bps)
+	Bands             []DepthBand
+	Walls             []OrderBookWall
+}
+
+// AnalyzeOrderBook computes depth imbalance, weighted spread and walls; bids and asks are ordered best first
+// AnalyzeOrderBook 计算深度失衡、加权价差和大单墙；买卖盘均按最优价在前排列
+//
+// Returns nil when either side is empty.
+// 任一侧为空时返回 nil。
+func AnalyzeOrderBook(bids, asks []OrderBookLevel) *OrderBookMetrics {
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil
+	}
+	bestBid, bestAsk := bids[0], asks[0]
+	mid := (bestBid.Price + bestAsk.Price) / 2
+	if mid <= 0 {
+		return nil
+	}
+
+	m := &OrderBookMetrics{
+		BestBid:   bestBid.Price,
+		BestAsk:   bestAsk.Price,
+		Mid:       mid,
+		SpreadBps: (bestAsk.Price - bestBid.Price) / mid * 10000,
+	}
+	if size := bestBid.Quantity + bestAsk.Quantity; size > 0 {
+		m.MicroPrice = (bestBid.Price*bestAsk.Quantity + bestAsk.Price*bestBid.Quantity) / size
+	}
+
+	for _, percent := range depthBands {
+		band := DepthBand{
+			Percent:     percent,
+			BidNotional: notionalWithin(bids, mid, percent),
+			AskNotional: notionalWithin(asks, mid, percent),
+			Covered: distancePercent(bids[len(bids)-1].Price, mid) >= percent &&
+				distancePercent(asks[len(asks)-1].Price, mid) >= percent,
+		}
+		if total := band.BidNotional + band.AskNotional; total > 0 {
+			band.Imbalance = (band.BidNotional - band.AskNotional) / total
+		}
+		m.Bands = append(m.Bands, band)
+	}
+
+	bidVWAP, askVWAP := vwapWithin(bids, mid, weightedSpreadBand), vwapWithin(asks, mid, weightedSpreadBand)
+	if bidVWAP > 0 && askVWAP > 0 {
+		m.WeightedSpreadBps = (askVWAP - bidVWAP) / mid * 10000
+	}
+
+	m.Walls = append(findWalls("bid", bids, mid), findWalls("ask", asks, mid)...)
+	return m
+}
+
+// distancePercent returns how far price is from mid in percent
+// distancePercent 返回价格距中间价的百分比距离
+func distancePercent(price, mid float64) float64 {
+	return math.Abs(price-mid) / mid * 100
+}
+
+// notionalWithin sums the notional of the levels within percent of mid
+// notionalWithin 汇总距中间价 percent 范围内各价位的金额
+func notionalWithin(levels []OrderBookLevel, mid, percent float64) float64 {
+	var notional float64
+	for _, level := range levels {
+		if distancePercent(level.Price, mid) > percent {
+			break
+		}
+		notional += level.Price * level.Quantity
+	}
+	return notional
+}
+
+// vwapWithin returns the volume-weighted price of the levels within percent of mid (0 when empty)
+// vwapWithin 返回距中间价 percent 范围内各价位的成交量加权价格（为空时返回 0）
+func vwapWithin(levels []OrderBookLevel, mid, percent float64) float64 {
+	var notional, quantity float64
+	for _, level := range levels {
+		if distancePercent(level.Price, mid) > percent {
+			break
+		}
+		notional += level.Price * level.Quantity
+		quantity += level.Quantity
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return notional / quantity
+}
+
+// findWalls returns the largest levels of a side whose notional is at least wallMultiple times the side's median
+// findWalls 返回一侧中金额至少为该侧中位数 wallMultiple 倍的最大价位
+func findWalls(side string, levels []OrderBookLevel, mid float64) []OrderBookWall {
+	notionals := make([]float64, len(levels))
+	for i, level := range levels {
+		notionals[i] = level.Price * level.Quantity
+	}
+	sorted := append([]float64(nil), notionals...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if median <= 0 {
+		return nil
+	}
+
+	var walls []OrderBookWall
+	for i, level := range levels {
+		if notionals[i] >= median*wallMultiple {
+			walls = append(walls, OrderBookWall{
+				Side:            side,
+				Price:           level.Price,
+				Notional:        notionals[i],
+				DistancePercent: distancePercent(level.Price, mid),
+			})
+		}
+	}
+	sort.SliceStable(walls, func(i, j int) bool { return walls[i].Notional > walls[j].Notional })
+	if len(walls) > maxWallsPerSide {
+		walls = walls[:maxWallsPerSide]
+	}
+	return walls
+}
+
+// formatOrderBookMetrics formats the depth metrics for the crypto report
+// formatOrderBookMetrics 将深度指标格式化为加密货币报告内容
+func formatOrderBookMetrics(m *OrderBookMetrics) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  买一/卖一: %s / %s，价差 %.2f bps，微观价格 %s\n",
+		format.Adaptive(m.BestBid), format.Adaptive(m.BestAsk), m.SpreadBps, format.Adaptive(m.MicroPrice)))
+	if m.WeightedSpreadBps > 0 {
+		sb.WriteString(fmt.Sprintf("  加权价差（%.0f%% 范围内买卖盘均价）: %.2f bps\n", weightedSpreadBand, m.WeightedSpreadBps))
+	}
+
+	sb.WriteString("  深度失衡（正值买盘占优）:\n")
+	for _, band := range m.Bands {
+		line := fmt.Sprintf("    ±%.1f%%: 买 $%s vs 卖 $%s，失衡 %+.2f", band.Percent,
+			format.Compact(band.BidNotional, 2), format.Compact(band.AskNotional, 2), band.Imbalance)
+		if !band.Covered {
+			line += "（深度未覆盖整个范围）"
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if len(m.Walls) > 0 {
+		sb.WriteString("  大单墙:\n")
+		for _, wall := range m.Walls {
+			label := "买墙（支撑）"
+			if wall.Side == "ask" {
+				label = "卖墙（阻力）"
+			}
+			sb.WriteString(fmt.Sprintf("    %s %s: $%s，距中间价 %.2f%%\n",
+				label, format.Adaptive(wall.Price), format.Compact(wall.Notional, 2), wall.DistancePercent))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+package dataflows
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestAnalyzeOrderBook tests the spread, the depth bands, their coverage and wall detection
+// TestAnalyzeOrderBook 测试价差、深度范围及其覆盖情况和大单墙识别
+func TestAnalyzeOrderBook(t *testing.T) {
+	bids := []OrderBookLevel{{99.9, 10}, {99.6, 10}, {99.2, 100}, {98.5, 10}, {97.9, 10}}
+	asks := []OrderBookLevel{{100.1, 10}, {100.4, 10}, {100.8, 10}, {101.5, 10}, {101.9, 10}}
+
+	m := AnalyzeOrderBook(bids, asks)
+	if m == nil {
+		t.Fatal("Expected metrics for a two-sided book")
+	}
+	if math.Abs(m.Mid-100) > 1e-9 || math.Abs(m.SpreadBps-20) > 1e-6 || math.Abs(m.MicroPrice-100) > 1e-9 {
+		t.Errorf("Unexpected top of book: %+v", m)
+	}
+	if len(m.Bands) != 3 {
+		t.Fatalf("Expected 3 depth bands, got %d", len(m.Bands))
+	}
+
+	half, one, two := m.Bands[0], m.Bands[1], m.Bands[2]
+	if math.Abs(half.BidNotional-1995) > 1e-6 || math.Abs(half.AskNotional-2005) > 1e-6 || half.Imbalance >= 0 {
+		t.Errorf("Unexpected ±0.5%% band: %+v", half)
+	}
+	if math.Abs(one.BidNotional-11915) > 1e-6 || one.Imbalance <= 0.5 {
+		t.Errorf("Expected the bid wall to dominate ±1%%, got %+v", one)
+	}
+	if !half.Covered || !one.Covered || two.Covered {
+		t.Errorf("Expected only ±2%% to be beyond the ask depth, got %+v", m.Bands)
+	}
+	if m.WeightedSpreadBps <= m.SpreadBps {
+		t.Errorf("Expected the weighted spread to be wider than the top of book, got %.2f", m.WeightedSpreadBps)
+	}
+
+	if len(m.Walls) != 1 || m.Walls[0].Side != "bid" || m.Walls[0].Price != 99.2 {
+		t.Errorf("Expected one bid wall at 99.2, got %+v", m.Walls)
+	}
+
+	report := formatOrderBookMetrics(m)
+	if !strings.Contains(report, "买墙（支撑）") || !strings.Contains(report, "深度未覆盖整个范围") {
+		t.Errorf("Expected the wall and the coverage note in the report, got:\n%s", report)
+	}
+
+	if AnalyzeOrderBook(bids, nil) != nil {
+		t.Error("Expected nil metrics for a one-sided book")
+	}
+}
@@ -0,0 +1,342 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/format"
+)
+
+const (
+	positioningPeriod    = "15m"           // 多空比采样间隔 / Long/short ratio sampling interval
+	positioningPoints    = 8               // 8 个 15 分钟数据点 = 2 小时 / 8 points of 15 minutes = 2 hours
+	liquidationReconnect = 5 * time.Second // 强平推送断开后的重连间隔 / Delay before reconnecting the liquidation stream
+)
+
+// RatioPoint is one point of a Binance long/short ratio series
+// RatioPoint 是币安多空比序列中的一个数据点
+type RatioPoint struct {
+	Ratio     float64   // 多空比 / Long/short ratio
+	Long      float64   // 多头占比（%）/ Long share (%)
+	Short     float64   // 空头占比（%）/ Short share (%)
+	Timestamp time.Time // 数据时间 / Data time
+}
+
+// LiquidationStats sums the liquidations of a symbol over the tracking window
+// LiquidationStats 汇总交易对在统计窗口内的爆仓
+type LiquidationStats struct {
+	Window        time.Duration // 统计窗口（监听时间不足时为实际监听时长）/ Window (the listening time if shorter)
+	LongCount     int           // 多头爆仓笔数 / Liquidated longs
+	ShortCount    int           // 空头爆仓笔数 / Liquidated shorts
+	LongNotional  float64       // 多头爆仓金额（USDT）/ Liquidated long notional (USDT)
+	ShortNotional float64       // 空头爆仓金额（USDT）/ Liquidated short notional (USDT)
+	Largest       float64       // 最大单笔爆仓金额（USDT）/ Largest single liquidation (USDT)
+}
+
+// PositioningSnapshot holds the long/short ratios and liquidations of a symbol
+// PositioningSnapshot 保存交易对的多空比和爆仓统计
+type PositioningSnapshot struct {
+	Symbol         string
+	GlobalAccounts []RatioPoint      // 全市场账户多空比（从旧到新）/ Global account ratio (oldest first)
+	TopPositions   []RatioPoint      // 大户持仓多空比（从旧到新）/ Top trader position ratio (oldest first)
+	Liquidations   *LiquidationStats // nil 表示未监听强平 / nil when liquidations are not tracked
+	Errors         []string          // 获取失败的数据 / Data that failed
+}
+
+// GetGlobalLongShortAccountRatio gets the long/short ratio of all accounts, oldest first
+// GetGlobalLongShortAccountRatio 获取全市场账户多空比（从旧到新）
+func (m *MarketData) GetGlobalLongShortAccountRatio(ctx context.Context, symbol string, period string, limit int) ([]RatioPoint, error) {
+	ratios, err := m.client.NewLongShortRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(limit).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch global long/short account ratio: %w", err)
+	}
+
+	points := make([]RatioPoint, 0, len(ratios))
+	for _, r := range ratios {
+		if point, ok := parseRatioPoint(r.LongShortRatio, r.LongAccount, r.ShortAccount, r.Timestamp); ok {
+			points = append(points, point)
+		}
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data returned for global long/short account ratio")
+	}
+	return points, nil
+}
+
+// GetTopLongShortPositionRatio gets the long/short position ratio of top traders, oldest first
+// GetTopLongShortPositionRatio 获取大户持仓多空比（从旧到新）
+func (m *MarketData) GetTopLongShortPositionRatio(ctx context.Context, symbol string, period string, limit int) ([]RatioPoint, error) {
+	ratios, err := m.client.NewTopLongShortPositionRatioService().
+		Symbol(symbol).
+		Period(period).
+		Limit(uint32(limit)).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch top long/short position ratio: %w", err)
+	}
+
+	// Binance API returns data in oldest-to-newest order (same as OpenInterestStatistics)
+	// 币安 API 返回数据按从旧到新的顺序（与 OpenInterestStatistics 相同）
+	points := make([]RatioPoint, 0, len(ratios))
+	for _, r := range ratios {
+		if point, ok := parseRatioPoint(r.LongShortRatio, r.LongAccount, r.ShortAccount, int64(r.Timestamp)); ok {
+			points = append(points, point)
+		}
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data returned for top long/short position ratio")
+	}
+	return points, nil
+}
+
+// parseRatioPoint parses a ratio point; the long and short shares are converted to percentages
+// parseRatioPoint 解析多空比数据点，多空占比转换为百分比
+func parseRatioPoint(ratio, long, short string, timestamp int64) (RatioPoint, bool) {
+	value, err := strconv.ParseFloat(ratio, 64)
+	if err != nil {
+		return RatioPoint{}, false
+	}
+	longShare, _ := strconv.ParseFloat(long, 64)
+	shortShare, _ := strconv.ParseFloat(short, 64)
+	return RatioPoint{
+		Ratio:     value,
+		Long:      longShare * 100,
+		Short:     shortShare * 100,
+		Timestamp: time.UnixMilli(timestamp),
+	}, true
+}
+
+// Positioning fetches the long/short ratios of a symbol and, when tracked, its recent liquidations
+// Positioning 获取交易对的多空比，以及（已监听时）最近的爆仓统计
+func (m *MarketData) Positioning(ctx context.Context, symbol string, liquidations *LiquidationTracker) *PositioningSnapshot {
+	snapshot := &PositioningSnapshot{Symbol: symbol}
+
+	global, err := m.GetGlobalLongShortAccountRatio(ctx, symbol, positioningPeriod, positioningPoints)
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("全市场账户多空比: %v", err))
+	}
+	snapshot.GlobalAccounts = global
+
+	top, err := m.GetTopLongShortPositionRatio(ctx, symbol, positioningPeriod, positioningPoints)
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("大户持仓多空比: %v", err))
+	}
+	snapshot.TopPositions = top
+
+	snapshot.Liquidations = liquidations.Stats(symbol, time.Now())
+	return snapshot
+}
+
+// liquidation is a forced order received from the liquidation stream
+// liquidation 是从强平推送收到的一笔强平单
+type liquidation struct {
+	long     bool // 被强平的是多头（强平单方向为 SELL）/ A long was liquidated (the forced order sells)
+	notional float64
+	time     time.Time
+}
+
+// LiquidationTracker sums the liquidations of the watched symbols from the Binance forceOrder stream
+// LiquidationTracker 通过币安 forceOrder 推送统计所监听交易对的爆仓
+//
+// The REST endpoint for market-wide forced orders was retired by Binance, so liquidations can only be
+// collected while the bot runs. Binance pushes at most the latest liquidation per symbol each second,
+// so the totals are a lower bound.
+// 币安已下线全市场强平单的 REST 接口，只能在程序运行期间收集强平数据。币安每秒最多推送每个交易对的
+// 最新一笔强平，因此统计值为下限。
+type LiquidationTracker struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	started map[string]time.Time     // 开始监听的时间 / When listening started
+	events  map[string][]liquidation // 窗口内的强平单 / Liquidations within the window
+}
+
+// NewLiquidationTracker creates a tracker keeping the liquidations of the last window
+// NewLiquidationTracker 创建保留最近 window 内爆仓数据的统计器
+func NewLiquidationTracker(window time.Duration) *LiquidationTracker {
+	return &LiquidationTracker{
+		window:  window,
+		started: make(map[string]time.Time),
+		events:  make(map[string][]liquidation),
+	}
+}
+
+// Run subscribes to the liquidation stream of each symbol (Binance format) until ctx is cancelled, reconnecting on errors
+// Run 订阅每个交易对（币安格式）的强平推送直到 ctx 取消，出错时自动重连
+func (t *LiquidationTracker) Run(ctx context.Context, symbols []string, onError func(symbol string, err error)) {
+	var wg sync.WaitGroup
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		t.mu.Lock()
+		t.started[symbol] = time.Now()
+		t.mu.Unlock()
+
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			for {
+				handler := func(event *futures.WsLiquidationOrderEvent) {
+					order := event.LiquidationOrder
+					quantity, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+					price, _ := strconv.ParseFloat(order.AvgPrice, 64)
+					if price == 0 {
+						price, _ = strconv.ParseFloat(order.Price, 64)
+					}
+					t.record(symbol, order.Side == futures.SideTypeSell, quantity*price, time.UnixMilli(order.TradeTime))
+				}
+				errHandler := func(err error) {
+					if onError != nil {
+						onError(symbol, err)
+					}
+				}
+
+				doneC, stopC, err := futures.WsLiquidationOrderServe(symbol, handler, errHandler)
+				if err != nil {
+					errHandler(err)
+				} else {
+					select {
+					case <-ctx.Done():
+						close(stopC)
+						<-doneC
+						return
+					case <-doneC:
+					}
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(liquidationReconnect):
+				}
+			}
+		}(symbol)
+	}
+	wg.Wait()
+}
+
+// record adds a liquidation and drops the ones that left the window
+// record 记录一笔强平并丢弃已超出窗口的数据
+func (t *LiquidationTracker) record(symbol string, long bool, notional float64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := append(t.events[symbol], liquidation{long: long, notional: notional, time: at})
+	cutoff := at.Add(-t.window)
+	for len(events) > 0 && events[0].time.Before(cutoff) {
+		events = events[1:]
+	}
+	t.events[symbol] = events
+}
+
+// Stats returns the liquidations of a symbol (Binance format) within the window (nil when not tracked)
+// Stats 返回交易对（币安格式）在统计窗口内的爆仓（未监听时返回 nil）
+func (t *LiquidationTracker) Stats(symbol string, now time.Time) *LiquidationStats {
+	if t == nil {
+		return nil
+	}
+	symbol = strings.ToUpper(symbol)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	started, ok := t.started[symbol]
+	if !ok {
+		return nil
+	}
+
+	stats := &LiquidationStats{Window: t.window}
+	if listened := now.Sub(started); listened < t.window {
+		stats.Window = listened
+	}
+	cutoff := now.Add(-t.window)
+	for _, event := range t.events[symbol] {
+		if event.time.Before(cutoff) {
+			continue
+		}
+		if event.long {
+			stats.LongCount++
+			stats.LongNotional += event.notional
+		} else {
+			stats.ShortCount++
+			stats.ShortNotional += event.notional
+		}
+		if event.notional > stats.Largest {
+			stats.Largest = event.notional
+		}
+	}
+	return stats
+}
+
+// formatRatioSeries formats the latest point and the ratio series of a long/short ratio section
+// formatRatioSeries 格式化多空比章节的最新数据和序列
+func formatRatioSeries(sb *strings.Builder, title, longLabel, shortLabel string, points []RatioPoint) {
+	if len(points) == 0 {
+		return
+	}
+	latest := points[len(points)-1]
+	sb.WriteString(fmt.Sprintf("%s（2h，15分钟间隔）:\n", title))
+	sb.WriteString(fmt.Sprintf("  最新: 多空比 %.2f（%s %.1f%% vs %s %.1f%%）\n", latest.Ratio, longLabel, latest.Long, shortLabel, latest.Short))
+	chunks := make([]string, 0, len(points))
+	for _, point := range points {
+		chunks = append(chunks, fmt.Sprintf("%.2f", point.Ratio))
+	}
+	sb.WriteString(fmt.Sprintf("  多空比序列: [%s]\n", strings.Join(chunks, ", ")))
+	if first := points[0].Ratio; first > 0 {
+		sb.WriteString(fmt.Sprintf("  2h 变化: %s %+.2f%%\n", trendArrow(first, latest.Ratio), (latest.Ratio-first)/first*100))
+	}
+	sb.WriteString("\n")
+}
+
+// FormatPositioning formats the long/short ratios and liquidations as a section of the crypto report
+// FormatPositioning 将多空比和爆仓统计格式化为加密货币报告的章节
+func FormatPositioning(snapshot *PositioningSnapshot) string {
+	if snapshot == nil {
+		return ""
+	}
+	var sb strings.Builder
+
+	formatRatioSeries(&sb, "👥 全市场账户多空比", "多头账户", "空头账户", snapshot.GlobalAccounts)
+	formatRatioSeries(&sb, "🐋 大户持仓多空比", "多头持仓", "空头持仓", snapshot.TopPositions)
+
+	// Retail crowding against top traders is a contrarian signal
+	// 散户与大户方向相反时是逆向信号
+	if len(snapshot.GlobalAccounts) > 0 && len(snapshot.TopPositions) > 0 {
+		global := snapshot.GlobalAccounts[len(snapshot.GlobalAccounts)-1].Ratio
+		top := snapshot.TopPositions[len(snapshot.TopPositions)-1].Ratio
+		switch {
+		case global > 1 && top < 1:
+			sb.WriteString("  解读: 散户偏多而大户偏空，警惕多头拥挤后的下跌\n\n")
+		case global < 1 && top > 1:
+			sb.WriteString("  解读: 散户偏空而大户偏多，警惕空头回补带来的上涨\n\n")
+		}
+	}
+
+	if stats := snapshot.Liquidations; stats != nil {
+		sb.WriteString(fmt.Sprintf("💥 爆仓统计（最近 %.0f 分钟）:\n", stats.Window.Minutes()))
+		if stats.LongCount+stats.ShortCount == 0 {
+			sb.WriteString("  无爆仓\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("  多头爆仓: %d 笔，$%s | 空头爆仓: %d 笔，$%s\n",
+				stats.LongCount, format.Compact(stats.LongNotional, 2), stats.ShortCount, format.Compact(stats.ShortNotional, 2)))
+			sb.WriteString(fmt.Sprintf("  最大单笔: $%s\n", format.Compact(stats.Largest, 2)))
+			switch {
+			case stats.LongNotional > 2*stats.ShortNotional:
+				sb.WriteString("  解读: 多头集中爆仓，下跌中的被动抛售，关注是否出现止跌\n")
+			case stats.ShortNotional > 2*stats.LongNotional:
+				sb.WriteString("  解读: 空头集中爆仓，上涨中的被动买入，关注是否出现见顶\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	for _, err := range snapshot.Errors {
+		sb.WriteString(fmt.Sprintf("⚠️ 数据获取失败: %s\n", err))
+	}
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// TestLiquidationTrackerStats tests the window, the long/short split and untracked symbols
+// TestLiquidationTrackerStats 测试统计窗口、多空拆分和未监听的交易对
+func TestLiquidationTrackerStats(t *testing.T) {
+	now := time.Now()
+	tracker := NewLiquidationTracker(time.Hour)
+	tracker.started["BTCUSDT"] = now.Add(-2 * time.Hour)
+
+	tracker.record("BTCUSDT", true, 500_000, now.Add(-90*time.Minute))
+	tracker.record("BTCUSDT", true, 120_000, now.Add(-30*time.Minute))
+	tracker.record("BTCUSDT", true, 80_000, now.Add(-10*time.Minute))
+	tracker.record("BTCUSDT", false, 50_000, now.Add(-5*time.Minute))
+
+	stats := tracker.Stats("btcusdt", now)
+	if stats == nil {
+		t.Fatal("Expected stats for a tracked symbol")
+	}
+	if stats.LongCount != 2 || stats.ShortCount != 1 {
+		t.Errorf("Expected the liquidation outside the window to be dropped, got %+v", stats)
+	}
+	if stats.LongNotional != 200_000 || stats.ShortNotional != 50_000 || stats.Largest != 120_000 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if stats.Window != time.Hour {
+		t.Errorf("Expected a full window, got %s", stats.Window)
+	}
+
+	if tracker.Stats("ETHUSDT", now) != nil {
+		t.Error("Expected nil stats for an untracked symbol")
+	}
+	var untracked *LiquidationTracker
+	if untracked.Stats("BTCUSDT", now) != nil {
+		t.Error("Expected nil stats without a tracker")
+	}
+}
+
+// TestFormatPositioning tests the ratio sections, the divergence hint and the liquidation section
+// TestFormatPositioning 测试多空比章节、散户大户背离提示和爆仓章节
+func TestFormatPositioning(t *testing.T) {
+	snapshot := &PositioningSnapshot{
+		Symbol: "BTCUSDT",
+		GlobalAccounts: []RatioPoint{
+			{Ratio: 1.5, Long: 60, Short: 40},
+			{Ratio: 1.8, Long: 64.3, Short: 35.7},
+		},
+		TopPositions: []RatioPoint{
+			{Ratio: 1.1, Long: 52.4, Short: 47.6},
+			{Ratio: 0.9, Long: 47.4, Short: 52.6},
+		},
+		Liquidations: &LiquidationStats{Window: time.Hour, LongCount: 2, LongNotional: 200_000, ShortCount: 1, ShortNotional: 50_000, Largest: 120_000},
+		Errors:       []string{"大户持仓多空比: timeout"},
+	}
+
+	report := FormatPositioning(snapshot)
+	for _, want := range []string{
+		"👥 全市场账户多空比", "多空比 1.80（多头账户 64.3% vs 空头账户 35.7%）", "[1.50, 1.80]", "↑ +20.00%",
+		"🐋 大户持仓多空比", "散户偏多而大户偏空",
+		"💥 爆仓统计（最近 60 分钟）", "多头爆仓: 2 笔", "多头集中爆仓",
+		"⚠️ 数据获取失败: 大户持仓多空比: timeout",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+
+	if report := FormatPositioning(&PositioningSnapshot{Liquidations: &LiquidationStats{Window: 20 * time.Minute}}); !strings.Contains(report, "最近 20 分钟") || !strings.Contains(report, "无爆仓") {
+		t.Errorf("Expected an empty liquidation section, got:\n%s", report)
+	}
+}
+
+// TestPositioningRatios tests fetching both ratios through the Binance client
+// TestPositioningRatios 测试通过币安客户端获取两种多空比
+func TestPositioningRatios(t *testing.T) {
+	limits := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limits[r.URL.Path] = r.URL.Query().Get("limit")
+		switch r.URL.Path {
+		case "/futures/data/globalLongShortAccountRatio", "/futures/data/topLongShortPositionRatio":
+			fmt.Fprint(w, `[
+				{"symbol": "BTCUSDT", "longShortRatio": "1.5", "longAccount": "0.6", "shortAccount": "0.4", "timestamp": 1700000000000},
+				{"symbol": "BTCUSDT", "longShortRatio": "bad", "longAccount": "0.5", "shortAccount": "0.5", "timestamp": 1700000900000}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	m := &MarketData{client: client}
+
+	snapshot := m.Positioning(context.Background(), "BTCUSDT", nil)
+	if len(snapshot.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", snapshot.Errors)
+	}
+	for name, points := range map[string][]RatioPoint{"global": snapshot.GlobalAccounts, "top": snapshot.TopPositions} {
+		if len(points) != 1 || points[0].Ratio != 1.5 || points[0].Long != 60 || !points[0].Timestamp.Equal(time.UnixMilli(1700000000000)) {
+			t.Errorf("Unexpected %s ratio points: %+v", name, points)
+		}
+	}
+	for path, limit := range limits {
+		if limit != fmt.Sprint(positioningPoints) {
+			t.Errorf("Expected limit %d for %s, got %q", positioningPoints, path, limit)
+		}
+	}
+	if snapshot.Liquidations != nil {
+		t.Error("Expected no liquidation stats without a tracker")
+	}
+}
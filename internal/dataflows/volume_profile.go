@@ -0,0 +1,171 @@
+package dataflows
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	rollingVWAPPeriod   = 20   // 滚动 VWAP 周期 / Rolling VWAP period
+	volumeProfileBins   = 50   // 成交量分布的价格分档数 / Price bins of the volume profile
+	volumeProfileWindow = 200  // 成交量分布统计最近的 K 线数 / Candles the volume profile covers
+	valueAreaShare      = 0.70 // 价值区包含的成交量比例 / Share of volume inside the value area
+)
+
+// VolumeProfile is the volume traded at each price over a window of candles
+// VolumeProfile 是一段 K 线内各价位的成交量分布
+type VolumeProfile struct {
+	POC           float64 // 成交量最大的价位（控制点）/ Point of control: the price with the most volume
+	ValueAreaHigh float64 // 价值区上沿 / Value area high
+	ValueAreaLow  float64 // 价值区下沿 / Value area low
+	Candles       int     // 统计的 K 线数 / Candles covered
+}
+
+// Location describes where a price sits relative to the value area
+// Location 描述价格相对价值区的位置
+func (p *VolumeProfile) Location(price float64) string {
+	switch {
+	case price > p.ValueAreaHigh:
+		return "价值区上方 (above value area)"
+	case price < p.ValueAreaLow:
+		return "价值区下方 (below value area)"
+	}
+	return "价值区内 (inside value area)"
+}
+
+// typicalPrice returns (high + low + close) / 3
+// typicalPrice 返回典型价格 (最高 + 最低 + 收盘) / 3
+func typicalPrice(c OHLCV) float64 {
+	return (c.High + c.Low + c.Close) / 3
+}
+
+// calculateSessionVWAP calculates the VWAP anchored at each UTC day, reset at midnight
+// calculateSessionVWAP 计算以 UTC 日为锚点的 VWAP，每天 0 点重置
+func calculateSessionVWAP(ohlcvData []OHLCV) []float64 {
+	result := make([]float64, len(ohlcvData))
+
+	var pv, volume float64
+	var session time.Time
+	for i, candle := range ohlcvData {
+		day := candle.Timestamp.UTC().Truncate(24 * time.Hour)
+		if !day.Equal(session) {
+			session = day
+			pv, volume = 0, 0
+		}
+		pv += typicalPrice(candle) * candle.Volume
+		volume += candle.Volume
+		if volume > 0 {
+			result[i] = pv / volume
+		} else {
+			result[i] = typicalPrice(candle)
+		}
+	}
+
+	return result
+}
+
+// calculateRollingVWAP calculates the VWAP of the last period candles
+// calculateRollingVWAP 计算最近 period 根 K 线的 VWAP
+func calculateRollingVWAP(ohlcvData []OHLCV, period int) []float64 {
+	result := make([]float64, len(ohlcvData))
+
+	var pv, volume float64
+	for i, candle := range ohlcvData {
+		pv += typicalPrice(candle) * candle.Volume
+		volume += candle.Volume
+		if i >= period {
+			old := ohlcvData[i-period]
+			pv -= typicalPrice(old) * old.Volume
+			volume -= old.Volume
+		}
+
+		switch {
+		case i < period-1:
+			result[i] = math.NaN()
+		case volume > 0:
+			result[i] = pv / volume
+		default:
+			result[i] = typicalPrice(candle)
+		}
+	}
+
+	return result
+}
+
+// CalculateVolumeProfile builds the volume profile of the last window candles
+// CalculateVolumeProfile 计算最近 window 根 K 线的成交量分布
+//
+// Each candle's volume is spread evenly over the bins its high-low range covers. The value area
+// grows from the POC toward the heavier neighbouring bin until it holds valueAreaShare of the volume.
+// Returns nil when there is no volume or no price range.
+// 每根 K 线的成交量平均分配到其最高价至最低价覆盖的价格档位。价值区从控制点开始，每次向成交量
+// 较大的相邻档位扩展，直到包含 valueAreaShare 的成交量。没有成交量或价格区间时返回 nil。
+func CalculateVolumeProfile(ohlcvData []OHLCV, window, bins int) *VolumeProfile {
+	if len(ohlcvData) > window {
+		ohlcvData = ohlcvData[len(ohlcvData)-window:]
+	}
+	if len(ohlcvData) == 0 || bins <= 0 {
+		return nil
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, candle := range ohlcvData {
+		low = math.Min(low, candle.Low)
+		high = math.Max(high, candle.High)
+	}
+	if high <= low {
+		return nil
+	}
+	binSize := (high - low) / float64(bins)
+	binOf := func(price float64) int {
+		return min(int((price-low)/binSize), bins-1)
+	}
+
+	volumes := make([]float64, bins)
+	total := 0.0
+	for _, candle := range ohlcvData {
+		first, last := binOf(candle.Low), binOf(candle.High)
+		share := candle.Volume / float64(last-first+1)
+		for b := first; b <= last; b++ {
+			volumes[b] += share
+		}
+		total += candle.Volume
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	poc := 0
+	for b := range volumes {
+		if volumes[b] > volumes[poc] {
+			poc = b
+		}
+	}
+
+	lo, hi := poc, poc
+	inside := volumes[poc]
+	for inside < total*valueAreaShare && (lo > 0 || hi < bins-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = volumes[lo-1]
+		}
+		if hi < bins-1 {
+			above = volumes[hi+1]
+		}
+		if above >= below {
+			hi++
+			inside += above
+		} else {
+			lo--
+			inside += below
+		}
+	}
+
+	binCenter := func(b int) float64 { return low + (float64(b)+0.5)*binSize }
+	return &VolumeProfile{
+		POC:           binCenter(poc),
+		ValueAreaHigh: low + float64(hi+1)*binSize,
+		ValueAreaLow:  low + float64(lo)*binSize,
+		Candles:       len(ohlcvData),
+	}
+}
@@ -0,0 +1,99 @@
+package dataflows
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSessionVWAP tests the volume weighting and the reset at UTC midnight
+// TestSessionVWAP 测试成交量加权以及 UTC 0 点重置
+func TestSessionVWAP(t *testing.T) {
+	day := time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC)
+	candles := []OHLCV{
+		{Timestamp: day, High: 100, Low: 100, Close: 100, Volume: 1},
+		{Timestamp: day.Add(30 * time.Minute), High: 110, Low: 110, Close: 110, Volume: 3},
+		{Timestamp: day.Add(time.Hour), High: 200, Low: 200, Close: 200, Volume: 5}, // 新的一天 / New day
+	}
+
+	vwap := calculateSessionVWAP(candles)
+	if vwap[0] != 100 || math.Abs(vwap[1]-107.5) > 1e-9 {
+		t.Errorf("Expected 100 and 107.5, got %v", vwap[:2])
+	}
+	if vwap[2] != 200 {
+		t.Errorf("Expected the VWAP to reset at midnight, got %.2f", vwap[2])
+	}
+}
+
+// TestRollingVWAP tests the warm-up period and that old candles leave the window
+// TestRollingVWAP 测试预热期以及旧 K 线移出窗口
+func TestRollingVWAP(t *testing.T) {
+	candles := []OHLCV{
+		{High: 100, Low: 100, Close: 100, Volume: 1},
+		{High: 100, Low: 100, Close: 100, Volume: 1},
+		{High: 130, Low: 130, Close: 130, Volume: 2},
+	}
+
+	vwap := calculateRollingVWAP(candles, 2)
+	if !math.IsNaN(vwap[0]) {
+		t.Errorf("Expected NaN during warm-up, got %.2f", vwap[0])
+	}
+	if vwap[1] != 100 || vwap[2] != 120 {
+		t.Errorf("Expected 100 and 120, got %.2f and %.2f", vwap[1], vwap[2])
+	}
+}
+
+// TestVolumeProfile tests the POC and that the value area holds the heavy prices
+// TestVolumeProfile 测试控制点以及价值区覆盖成交集中的价位
+func TestVolumeProfile(t *testing.T) {
+	var candles []OHLCV
+	// 大部分成交集中在 104-106，少量分布在 100-110
+	candles = append(candles, OHLCV{High: 110, Low: 100, Close: 105, Volume: 10})
+	for i := 0; i < 10; i++ {
+		candles = append(candles, OHLCV{High: 106, Low: 104, Close: 105, Volume: 100})
+	}
+
+	profile := CalculateVolumeProfile(candles, 200, 10)
+	if profile == nil {
+		t.Fatal("Expected a volume profile")
+	}
+	if profile.POC < 104 || profile.POC > 106 {
+		t.Errorf("Expected the POC between 104 and 106, got %.2f", profile.POC)
+	}
+	if profile.ValueAreaLow > 104 || profile.ValueAreaHigh < 106 || profile.ValueAreaHigh-profile.ValueAreaLow > 4 {
+		t.Errorf("Expected a tight value area around 104-106, got %.2f-%.2f", profile.ValueAreaLow, profile.ValueAreaHigh)
+	}
+	if !strings.Contains(profile.Location(109), "上方") || !strings.Contains(profile.Location(105), "价值区内") {
+		t.Errorf("Unexpected locations: %s / %s", profile.Location(109), profile.Location(105))
+	}
+
+	if CalculateVolumeProfile(candles[:1], 1, 10).Candles != 1 {
+		t.Error("Expected the window to limit the candles")
+	}
+	flat := []OHLCV{{High: 100, Low: 100, Close: 100, Volume: 5}}
+	if CalculateVolumeProfile(flat, 200, 10) != nil {
+		t.Error("Expected no profile without a price range")
+	}
+}
+
+// TestIndicatorReportVolume tests that the indicator report includes VWAP and the volume profile
+// TestIndicatorReportVolume 测试指标报告包含 VWAP 和成交量分布
+func TestIndicatorReportVolume(t *testing.T) {
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	var candles []OHLCV
+	for i := 0; i < 60; i++ {
+		price := 100 + float64(i%10)
+		candles = append(candles, OHLCV{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Open:      price, High: price + 1, Low: price - 1, Close: price, Volume: 10,
+		})
+	}
+
+	report := FormatIndicatorReport("BTC/USDT", "1h", candles, CalculateIndicators(candles))
+	for _, want := range []string{"VWAP(日内) =", "VWAP(20) =", "POC =", "价值区(70%)", "VWAP(日内): ["} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in report:\n%s", want, report)
+		}
+	}
+}
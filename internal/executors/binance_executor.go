@@ -7,13 +7,17 @@ import (
 	"math"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/jpillora/backoff"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/ratelimit"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // TradeAction represents trading actions
@@ -25,6 +29,21 @@ const (
 	ActionCloseLong  TradeAction = "CLOSE_LONG"
 	ActionCloseShort TradeAction = "CLOSE_SHORT"
 	ActionHold       TradeAction = "HOLD"
+
+	// Conditional entries: open with a STOP_MARKET order once the price breaks the trigger
+	// 条件入场：价格突破触发价时以 STOP_MARKET 订单开仓
+	ActionBuyStop  TradeAction = "BUY_STOP"
+	ActionSellStop TradeAction = "SELL_STOP"
+
+	// Add-ons: scale into a winning position on the same side (pyramiding)
+	// 加仓：对同方向的盈利持仓加仓（金字塔加仓）
+	ActionAddLong  TradeAction = "ADD_LONG"
+	ActionAddShort TradeAction = "ADD_SHORT"
+
+	// Reductions: close part of a position to de-risk without exiting it
+	// 减仓：平掉部分持仓以降低风险，但不完全离场
+	ActionReduceLong  TradeAction = "REDUCE_LONG"
+	ActionReduceShort TradeAction = "REDUCE_SHORT"
 )
 
 // PositionMode represents the position mode
@@ -69,19 +88,30 @@ type Position struct {
 	StopLossType      string  // 止损类型：fixed, breakeven, trailing
 	TrailingDistance  float64 // 追踪距离（百分比）/ Trailing distance
 	PartialTPExecuted bool    // 是否已执行分批止盈 / Whether partial TP has been executed
+	PartialTPPrice    float64 // 分批止盈目标价 / Partial take-profit target price
+	PartialTPPercent  float64 // 分批止盈平仓比例 0-100 / Percent to close at partial TP
 	ATR               float64 // ATR 值用于动态追踪距离 / ATR value for dynamic trailing distance
+	TakeProfitPrice   float64 // 止盈价格（0 表示不挂止盈单）/ Take-profit price (0 = no take-profit order)
+
+	// Add-ons (pyramiding)
+	// 加仓
+	AddCount        int     // 已加仓次数 / Add-ons executed
+	InitialQuantity float64 // 开仓时的数量（0 表示从未加仓）/ Opening quantity (0 = never added to)
 
 	// Order management
 	// 订单管理
-	StopLossOrderID string // 当前止损单 ID / Stop-loss order ID
+	StopLossOrderID   string // 当前止损单 ID / Stop-loss order ID
+	TakeProfitOrderID string // 当前止盈单 ID（与止损单组成括号单）/ Take-profit order ID (bracketed with the stop-loss)
 
 	// History and context
 	// 历史和上下文
 	StopLossHistory []StopLossEvent // 止损变更历史 / Stop-loss history
 	PriceHistory    []PricePoint    // 价格历史 / Price history
+	MaxPriceHistory int             // 价格历史保留点数（0 表示 1000）/ Price points kept (0 = 1000)
 	OpenReason      string          // 开仓理由 / Opening reason
 	LastLLMReview   time.Time       // 上次 LLM 复查时间 / Last LLM review
 	LLMSuggestions  []string        // LLM 建议 / LLM suggestions
+	Stale           bool            // 恢复时交易所已无该持仓，等待对账 / No exchange position at restore, awaiting reconciliation
 }
 
 // StopLossEvent represents a stop-loss change event
@@ -113,8 +143,12 @@ type TradeResult struct {
 	OrderID     string
 	Price       float64
 	Filled      float64
+	Fee         float64 // 手续费（USDT）/ Trading fee (USDT)
+	Slippage    float64 // 相对决策价的滑点成本（USDT，正数为成本）/ Slippage cost against the decision price (USDT, positive = cost)
 	Message     string
+	Skipped     bool // 未满足下单条件而跳过（未下单）/ Skipped without ordering because a precondition wasn't met
 	NewPosition *Position
+	StopLoss    float64 // 插件或外部风控调整后的止损价（0 表示未调整）/ Stop-loss adjusted by a plugin or the risk oracle (0 = unchanged)
 }
 
 // BinanceExecutor handles Binance futures trading
@@ -124,7 +158,25 @@ type BinanceExecutor struct {
 	testMode     bool
 	positionMode PositionMode
 	logger       *logger.ColorLogger
-	tradeHistory []TradeResult
+	tradeHistory []TradeResult       // 最近的交易结果（有上限）/ Latest trade results (bounded)
+	paper        *PaperExecutor      // 模拟盘执行器（nil 表示实盘）/ Paper executor (nil = live trading)
+	trades       *storage.Storage    // 实盘成交记录存储（nil 表示不记录）/ Live fill storage (nil = not recorded)
+	maintenance  *MaintenanceMonitor // 交易所维护监控（nil 表示不检查）/ Exchange maintenance monitor (nil = not checked)
+	userStream   *UserDataStream     // 用户数据流（nil 表示固定等待持仓生效）/ User data stream (nil = fixed wait for positions to settle)
+	killSwitch   *KillSwitch         // 急停开关（nil 表示不检查）/ Kill switch (nil = not checked)
+
+	// Order filters from exchangeInfo
+	// 来自 exchangeInfo 的下单过滤规则
+	symbolFilters      map[string]SymbolFilters
+	filtersLoadedAt    time.Time        // 最近一次获取尝试的时间 / Time of the last fetch attempt
+	filtersRefreshedAt time.Time        // 缓存数据的刷新时间 / Refresh time of the cached data
+	registry           *storage.Storage // 交易对元数据存储（nil 表示不持久化）/ Symbol metadata storage (nil = not persisted)
+	filtersMu          sync.Mutex
+
+	// Trade results not yet flushed to storage
+	// 尚未写入数据库的交易结果
+	unflushedTrades []TradeResult
+	historyMu       sync.Mutex
 }
 
 // NewBinanceExecutor creates a new BinanceExecutor
@@ -158,6 +210,10 @@ func NewBinanceExecutor(cfg *config.Config, log *logger.ColorLogger) *BinanceExe
 		}
 	}
 
+	// Share the Binance request weight budget with every other client of the process
+	// 与进程内其他客户端共享币安请求权重预算
+	client.HTTPClient = ratelimit.WrapClient(client.HTTPClient, ratelimit.Shared(cfg.BinanceWeightPerMinute))
+
 	executor := &BinanceExecutor{
 		client:       client,
 		config:       cfg,
@@ -173,6 +229,19 @@ func NewBinanceExecutor(cfg *config.Config, log *logger.ColorLogger) *BinanceExe
 	return executor
 }
 
+// EnablePaperTrading routes all trading and account calls to a simulated SQLite-backed account
+// EnablePaperTrading 将所有交易和账户调用切换到基于 SQLite 的模拟账户
+func (e *BinanceExecutor) EnablePaperTrading(db *storage.Storage) {
+	e.paper = NewPaperExecutor(e.config, e.client, e.logger, db)
+	e.positionMode = PositionModeOneWay
+}
+
+// IsPaperTrading reports whether the executor is running in paper trading mode
+// IsPaperTrading 返回执行器是否运行在模拟盘模式
+func (e *BinanceExecutor) IsPaperTrading() bool {
+	return e.paper != nil
+}
+
 // DetectPositionMode detects the current position mode
 func (e *BinanceExecutor) DetectPositionMode(ctx context.Context) error {
 	if e.positionMode != "" {
@@ -210,9 +279,98 @@ func (e *BinanceExecutor) DetectPositionMode(ctx context.Context) error {
 	return nil
 }
 
+// ApplyPositionMode switches the account to BINANCE_POSITION_MODE when BINANCE_POSITION_MODE_SWITCH is enabled
+// ApplyPositionMode 启用 BINANCE_POSITION_MODE_SWITCH 时将账户切换为 BINANCE_POSITION_MODE 指定的持仓模式
+//
+// The position mode applies to the whole account, so it is only switched when no symbol has a position;
+// otherwise the current mode is kept with a warning. Paper trading always uses one-way mode.
+// 持仓模式作用于整个账户，因此只在所有交易对都没有持仓时切换，否则保持当前模式并发出警告。模拟盘始终使用单向持仓模式。
+func (e *BinanceExecutor) ApplyPositionMode(ctx context.Context) error {
+	target := PositionMode(e.config.BinancePositionMode)
+	if !e.config.BinancePositionModeSwitch || e.paper != nil || (target != PositionModeOneWay && target != PositionModeHedge) {
+		return nil
+	}
+	modeName := "单向持仓模式（One-way）"
+	if target == PositionModeHedge {
+		modeName = "双向持仓模式（Hedge）"
+	}
+
+	res, err := e.client.NewGetPositionModeService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get position mode: %w", err)
+	}
+	if res.DualSidePosition == (target == PositionModeHedge) {
+		e.positionMode = target
+		e.logger.Info(fmt.Sprintf("✓ 持仓模式已是%s，无需切换", modeName))
+		return nil
+	}
+
+	risks, err := e.client.NewGetPositionRiskService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get positions: %w", err)
+	}
+	if symbols := openPositionSymbols(risks); len(symbols) > 0 {
+		e.logger.Warning(fmt.Sprintf("⚠️  账户有持仓（%s），不切换为%s，保持当前持仓模式", strings.Join(symbols, ", "), modeName))
+		return nil
+	}
+
+	// Not retried, like the margin type change
+	// 与切换保证金类型一样不重试
+	err = e.client.NewChangePositionModeService().
+		DualSide(target == PositionModeHedge).
+		Do(ctx)
+	switch {
+	case err == nil:
+		e.logger.Success(fmt.Sprintf("已切换为%s", modeName))
+	case isPositionModeUnchanged(err):
+		e.logger.Info(fmt.Sprintf("✓ 持仓模式已是%s，无需切换", modeName))
+	case isPositionModeLocked(err):
+		e.logger.Warning(fmt.Sprintf("⚠️  账户有挂单或持仓，暂时无法切换为%s，保持当前持仓模式", modeName))
+		return nil
+	default:
+		return fmt.Errorf("failed to change position mode: %w", err)
+	}
+	e.positionMode = target
+	return nil
+}
+
+// openPositionSymbols returns the symbols with a non-zero position
+// openPositionSymbols 返回持仓数量不为 0 的交易对
+func openPositionSymbols(risks []*futures.PositionRisk) []string {
+	var symbols []string
+	for _, risk := range risks {
+		if amt, _ := parseFloat(risk.PositionAmt); amt != 0 && !slices.Contains(symbols, risk.Symbol) {
+			symbols = append(symbols, risk.Symbol)
+		}
+	}
+	return symbols
+}
+
+// isPositionModeUnchanged reports whether a position mode change failed because the account already uses it (-4059)
+// isPositionModeUnchanged 判断切换持仓模式失败是否因为已是目标模式（-4059）
+func isPositionModeUnchanged(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-4059") || strings.Contains(msg, "No need to change position side")
+}
+
+// isPositionModeLocked reports whether a position mode change was refused because of open orders (-4067)
+// or a position (-4068)
+// isPositionModeLocked 判断切换持仓模式是否因挂单（-4067）或持仓（-4068）被拒绝
+func isPositionModeLocked(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-4067") || strings.Contains(msg, "-4068") ||
+		strings.Contains(msg, "Position side cannot be changed")
+}
+
 // DetectMarginType detects the current margin type for a symbol
 // DetectMarginType 检测指定交易对的当前保证金类型（全仓/逐仓）
 func (e *BinanceExecutor) DetectMarginType(ctx context.Context, symbol string) (MarginType, error) {
+	// Paper trading uses cross margin accounting
+	// 模拟盘使用全仓保证金计算
+	if e.paper != nil {
+		return MarginTypeCross, nil
+	}
+
 	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
 
 	var marginType MarginType
@@ -256,6 +414,67 @@ func (e *BinanceExecutor) DetectMarginType(ctx context.Context, symbol string) (
 	return marginType, nil
 }
 
+// ApplyMarginType switches a symbol to the margin type of BINANCE_MARGIN_TYPE (auto keeps the exchange setting)
+// ApplyMarginType 将交易对切换为 BINANCE_MARGIN_TYPE 指定的保证金类型（auto 保持交易所当前设置）
+//
+// Binance refuses the change while the symbol has a position or open orders; the current type is then
+// kept with a warning and the change is retried on the next setup.
+// 交易对有持仓或挂单时币安拒绝切换；此时保持当前类型并发出警告，下次设置时再重试。
+func (e *BinanceExecutor) ApplyMarginType(ctx context.Context, symbol string) error {
+	var target futures.MarginType
+	switch MarginType(e.config.BinanceMarginType) {
+	case MarginTypeCross:
+		target = futures.MarginTypeCrossed
+	case MarginTypeIsolated:
+		target = futures.MarginTypeIsolated
+	default:
+		return nil
+	}
+
+	// Paper trading uses cross margin accounting
+	// 模拟盘使用全仓保证金计算
+	if e.paper != nil {
+		if target == futures.MarginTypeIsolated {
+			e.logger.Warning("⚠️  模拟盘使用全仓保证金计算，忽略 BINANCE_MARGIN_TYPE=isolated")
+		}
+		return nil
+	}
+
+	// Not retried: "no need to change" and position errors are answers, not transient failures
+	// 不重试："无需切换"和有持仓的错误是确定的结果，而不是临时故障
+	err := e.client.NewChangeMarginTypeService().
+		Symbol(e.config.GetBinanceSymbolFor(symbol)).
+		MarginType(target).
+		Do(ctx)
+	switch {
+	case err == nil:
+		e.logger.Success(fmt.Sprintf("设置保证金类型: %s", e.config.BinanceMarginType))
+	case isMarginTypeUnchanged(err):
+		e.logger.Info(fmt.Sprintf("✓ 保证金类型已是 %s，无需调整", e.config.BinanceMarginType))
+	case isMarginTypeLocked(err):
+		e.logger.Warning(fmt.Sprintf("⚠️  %s 有持仓或挂单，暂时无法切换为 %s，保持当前保证金类型", symbol, e.config.BinanceMarginType))
+	default:
+		return fmt.Errorf("failed to set margin type: %w", err)
+	}
+	return nil
+}
+
+// isMarginTypeUnchanged reports whether a margin type change failed because the symbol already uses it (-4046)
+// isMarginTypeUnchanged 判断切换保证金类型失败是否因为已是目标类型（-4046）
+func isMarginTypeUnchanged(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-4046") || strings.Contains(msg, "No need to change margin type")
+}
+
+// isMarginTypeLocked reports whether a margin type change was refused because of open orders (-4047)
+// or a position (-4048)
+// isMarginTypeLocked 判断切换保证金类型是否因挂单（-4047）或持仓（-4048）被拒绝
+func isMarginTypeLocked(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-4047") || strings.Contains(msg, "-4048") ||
+		strings.Contains(msg, "Margin type cannot be changed")
+}
+
 // SetupExchange sets up exchange parameters
 func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leverage int) error {
 	// Detect position mode
@@ -263,6 +482,12 @@ func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leve
 		return fmt.Errorf("failed to detect position mode: %w", err)
 	}
 
+	// Apply the configured margin type before the leverage, which is set per margin type
+	// 在设置杠杆之前切换保证金类型
+	if err := e.ApplyMarginType(ctx, symbol); err != nil {
+		return err
+	}
+
 	// Check current position to avoid leverage reduction error (-4161)
 	// 检查当前持仓，避免杠杆降低错误 (-4161)
 	currentPosition, err := e.GetCurrentPosition(ctx, symbol)
@@ -288,6 +513,16 @@ func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leve
 		// 如果 leverage > currentPosition.Leverage，继续设置（允许提高杠杆）
 	}
 
+	// Paper trading only records the leverage locally
+	// 模拟盘仅在本地记录杠杆
+	if e.paper != nil {
+		if err := e.paper.SetLeverage(symbol, leverage); err != nil {
+			return fmt.Errorf("failed to set leverage: %w", err)
+		}
+		e.logger.Success(fmt.Sprintf("设置杠杆倍数: %dx（模拟盘）", leverage))
+		goto checkBalance
+	}
+
 	// Set leverage with retry
 	err = e.withRetry(func() error {
 		_, err := e.client.NewChangeLeverageService().
@@ -305,28 +540,57 @@ func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leve
 
 checkBalance:
 	// Get balance
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get account info: %w", err)
 	}
 
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			balance, _ := parseFloat(asset.AvailableBalance)
-			e.logger.Success(fmt.Sprintf("当前 USDT 余额: %.2f", balance))
-			break
-		}
+	if balance, _, label := e.marginBalance(ctx, account); balance > 0 {
+		e.logger.Success(fmt.Sprintf("当前 %s 余额: %.2f", label, balance))
 	}
 
 	return nil
 }
 
 // GetCurrentPosition gets the current position for a symbol
+//
+// In hedge mode a symbol can hold a long and a short at once; this returns the first one.
+// Use GetCurrentPositions or GetCurrentPositionSide when both sides matter.
+// 双向持仓模式下同一交易对可同时持有多仓和空仓，此方法只返回第一个；需要区分方向时请使用
+// GetCurrentPositions 或 GetCurrentPositionSide。
 func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string) (*Position, error) {
-	var position *Position
+	positions, err := e.GetCurrentPositions(ctx, symbol)
+	if err != nil || len(positions) == 0 {
+		return nil, err
+	}
+	return positions[0], nil
+}
+
+// GetCurrentPositionSide gets the current position of a symbol on one side (nil when that side is flat)
+// GetCurrentPositionSide 获取交易对某一方向的当前持仓（该方向无持仓时为 nil）
+func (e *BinanceExecutor) GetCurrentPositionSide(ctx context.Context, symbol, side string) (*Position, error) {
+	positions, err := e.GetCurrentPositions(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return PositionOnSide(positions, side), nil
+}
+
+// GetCurrentPositions gets all open positions of a symbol: at most one in one-way mode, a long and a short in hedge mode
+// GetCurrentPositions 获取交易对的所有持仓：单向持仓模式最多一个，双向持仓模式可同时有多仓和空仓
+func (e *BinanceExecutor) GetCurrentPositions(ctx context.Context, symbol string) ([]*Position, error) {
+	if e.paper != nil {
+		position, err := e.paper.GetPosition(ctx, symbol)
+		if err != nil || position == nil {
+			return nil, err
+		}
+		return []*Position{position}, nil
+	}
+
+	var positions []*Position
 
 	err := e.withRetry(func() error {
-		positions, err := e.client.NewGetPositionRiskService().
+		risks, err := e.client.NewGetPositionRiskService().
 			Symbol(e.config.GetBinanceSymbolFor(symbol)).
 			Do(ctx)
 
@@ -334,33 +598,7 @@ func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string)
 			return err
 		}
 
-		for _, pos := range positions {
-			posAmt, _ := parseFloat(pos.PositionAmt)
-			if posAmt != 0 {
-				entryPrice, _ := parseFloat(pos.EntryPrice)
-				unrealizedPnL, _ := parseFloat(pos.UnRealizedProfit)
-				liquidationPrice, _ := parseFloat(pos.LiquidationPrice)
-				leverage, _ := parseInt(pos.Leverage)
-
-				side := "long"
-				if posAmt < 0 {
-					side = "short"
-				}
-
-				position = &Position{
-					Side:             side,
-					Size:             math.Abs(posAmt),
-					EntryPrice:       entryPrice,
-					UnrealizedPnL:    unrealizedPnL,
-					PositionAmt:      posAmt,
-					Symbol:           pos.Symbol,
-					Leverage:         leverage,
-					LiquidationPrice: liquidationPrice,
-				}
-				break
-			}
-		}
-
+		positions = positionsFromRisks(risks)
 		return nil
 	})
 
@@ -368,7 +606,96 @@ func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string)
 		return nil, fmt.Errorf("failed to get position: %w", err)
 	}
 
-	return position, nil
+	return positions, nil
+}
+
+// GetPositions gets every open position of the account, whether or not its symbol is configured
+// GetPositions 获取账户的所有持仓，无论交易对是否在配置中
+func (e *BinanceExecutor) GetPositions(ctx context.Context) ([]*Position, error) {
+	if e.paper != nil {
+		return e.paper.GetPositions(ctx)
+	}
+
+	var positions []*Position
+	err := e.withRetry(func() error {
+		risks, err := e.client.NewGetPositionRiskService().Do(ctx)
+		if err != nil {
+			return err
+		}
+		positions = positionsFromRisks(risks)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+	return positions, nil
+}
+
+// positionsFromRisks converts the non-empty entries of a position risk response into positions
+// positionsFromRisks 将持仓风险响应中的非空条目转换为持仓
+func positionsFromRisks(risks []*futures.PositionRisk) []*Position {
+	var positions []*Position
+	for _, pos := range risks {
+		posAmt, _ := parseFloat(pos.PositionAmt)
+		if posAmt == 0 {
+			continue
+		}
+		entryPrice, _ := parseFloat(pos.EntryPrice)
+		unrealizedPnL, _ := parseFloat(pos.UnRealizedProfit)
+		liquidationPrice, _ := parseFloat(pos.LiquidationPrice)
+		markPrice, _ := parseFloat(pos.MarkPrice)
+		leverage, _ := parseInt(pos.Leverage)
+
+		side := "long"
+		if posAmt < 0 {
+			side = "short"
+		}
+
+		positions = append(positions, &Position{
+			Side:             side,
+			Size:             math.Abs(posAmt),
+			EntryPrice:       entryPrice,
+			CurrentPrice:     markPrice,
+			UnrealizedPnL:    unrealizedPnL,
+			PositionAmt:      posAmt,
+			Symbol:           pos.Symbol,
+			Leverage:         leverage,
+			LiquidationPrice: liquidationPrice,
+		})
+	}
+	return positions
+}
+
+// IsHedgeMode reports whether the account uses hedge (dual-side) position mode
+// IsHedgeMode 返回账户是否为双向持仓模式
+func (e *BinanceExecutor) IsHedgeMode(ctx context.Context) bool {
+	if e.paper == nil && !e.testMode {
+		e.DetectPositionMode(ctx)
+	}
+	return e.positionMode == PositionModeHedge
+}
+
+// PositionOnSide returns the position held on side, or nil
+// PositionOnSide 返回指定方向的持仓，没有则返回 nil
+func PositionOnSide(positions []*Position, side string) *Position {
+	for _, pos := range positions {
+		if pos.Side == side {
+			return pos
+		}
+	}
+	return nil
+}
+
+// ActionSide returns the position side an action opens or closes ("" for HOLD)
+// ActionSide 返回动作开仓或平仓的持仓方向（HOLD 返回 ""）
+func ActionSide(action TradeAction) string {
+	switch action {
+	case ActionBuy, ActionBuyStop, ActionAddLong, ActionReduceLong, ActionCloseLong:
+		return "long"
+	case ActionSell, ActionSellStop, ActionAddShort, ActionReduceShort, ActionCloseShort:
+		return "short"
+	}
+	return ""
 }
 
 // ExecuteTrade executes a trade
@@ -383,6 +710,22 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 		TestMode:  e.testMode,
 	}
 
+	// Exchange maintenance: refuse orders before touching the API
+	// 交易所维护：在调用 API 之前拒绝下单
+	if e.InMaintenance() && action != ActionHold {
+		result.Message = "交易所维护中，暂停下单"
+		e.logger.Warning(fmt.Sprintf("🚧 %s %s %s（维护开始于 %s）", symbol, action, result.Message, e.maintenance.Since().Format("15:04:05")))
+		return result
+	}
+
+	// Kill switch: entries are refused, closes still go through
+	// 急停开关：拒绝开仓，平仓照常执行
+	if (action == ActionBuy || action == ActionSell) && e.KillSwitchEngaged() {
+		result.Message = "急停开关已启用，暂停开仓"
+		e.logger.Warning(fmt.Sprintf("🛑 %s %s %s", symbol, action, result.Message))
+		return result
+	}
+
 	// Get current position
 	currentPosition, _ := e.GetCurrentPosition(ctx, symbol)
 
@@ -399,6 +742,24 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 		e.logger.Info("当前持仓: 无")
 	}
 
+	if e.paper != nil && action != ActionHold {
+		e.logger.Warning("📝 模拟盘模式 - 基于实时盘口本地撮合，不实际下单")
+
+		markSent(ctx)
+		if err := e.paper.ExecuteTrade(ctx, symbol, action, amount, result); err != nil {
+			result.Message = fmt.Sprintf("订单执行失败: %v", err)
+			e.logger.Error(result.Message)
+			return result
+		}
+		markFilled(ctx)
+
+		newPosition, _ := e.GetCurrentPosition(ctx, symbol)
+		result.NewPosition = newPosition
+		e.recordExecutionTiming(ctx, result)
+		e.addTradeHistory(*result)
+		return result
+	}
+
 	if e.testMode {
 		e.logger.Warning("测试模式 - 仅模拟交易，不实际下单")
 
@@ -414,13 +775,27 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 		result.Price = currentPrice
 		result.Filled = amount
 		result.Message = fmt.Sprintf("测试模式：模拟交易成功 @ $%.2f", currentPrice)
+		if action != ActionHold {
+			e.modelTestFill(result, currentPrice)
+			result.Message = fmt.Sprintf("测试模式：模拟交易成功 @ $%.2f（模拟手续费 %.4f USDT，滑点 %.4f USDT）",
+				result.Price, result.Fee, result.Slippage)
+		}
 		return result
 	}
 
 	// Detect position mode
 	e.DetectPositionMode(ctx)
 
+	// In hedge mode each side is a separate position: act only on the side of the action,
+	// so BUY opens a long next to an existing short instead of reversing it
+	// 双向持仓模式下每个方向是独立持仓：只处理动作对应的方向，
+	// 因此已有空仓时 BUY 会另开多仓而不是反手
+	if e.positionMode == PositionModeHedge {
+		currentPosition, _ = e.GetCurrentPositionSide(ctx, symbol, ActionSide(action))
+	}
+
 	// Execute trade based on action
+	sentAt := time.Now()
 	var err error
 	switch action {
 	case ActionBuy:
@@ -443,18 +818,136 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 	}
 
 	if err != nil {
+		if isMinNotionalRejection(err) {
+			result.Skipped = true
+			result.Message = fmt.Sprintf("⏭️  订单价值低于 %s 的交易所最小要求（MIN_NOTIONAL），未成交。请提高仓位百分比或增加账户资金", symbol)
+			e.logger.Warning(result.Message)
+			return result
+		}
 		result.Message = fmt.Sprintf("订单执行失败: %v", err)
 		e.logger.Error(result.Message)
 		return result
 	}
 
 	// Get updated position
-	time.Sleep(2 * time.Second)
+	e.awaitPositionUpdate(ctx, symbol, sentAt)
 	newPosition, _ := e.GetCurrentPosition(ctx, symbol)
 	result.NewPosition = newPosition
 
 	// Record to history
-	e.tradeHistory = append(e.tradeHistory, *result)
+	e.recordExecutionTiming(ctx, result)
+	e.addTradeHistory(*result)
+
+	return result
+}
+
+// ClosePartialPosition closes part of the current position on side with a market order
+// ClosePartialPosition 以市价单平掉指定方向当前持仓的一部分
+func (e *BinanceExecutor) ClosePartialPosition(ctx context.Context, symbol, side string, quantity float64, reason string) *TradeResult {
+	result := &TradeResult{
+		Success:   false,
+		Symbol:    symbol,
+		Amount:    quantity,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Reason:    reason,
+		TestMode:  e.testMode,
+	}
+
+	if e.InMaintenance() {
+		result.Message = "交易所维护中，暂停部分平仓"
+		e.logger.Warning("🚧 " + result.Message)
+		return result
+	}
+
+	currentPosition, err := e.GetCurrentPositionSide(ctx, symbol, side)
+	if err != nil || currentPosition == nil {
+		result.Message = "没有持仓可部分平仓"
+		e.logger.Warning("⚠️ 没有持仓可部分平仓")
+		return result
+	}
+
+	result.Action = ActionCloseLong
+	if currentPosition.Side == "short" {
+		result.Action = ActionCloseShort
+	}
+
+	if quantity <= 0 || quantity >= currentPosition.Size {
+		result.Message = fmt.Sprintf("部分平仓数量无效: %.4f（当前持仓 %.4f）", quantity, currentPosition.Size)
+		e.logger.Warning("⚠️ " + result.Message)
+		return result
+	}
+
+	e.logger.Info(fmt.Sprintf("📤 部分平仓 (%s): %.4f / %.4f (%s)",
+		currentPosition.Side, quantity, currentPosition.Size, reason))
+
+	if e.paper != nil {
+		if err := e.paper.ClosePartial(ctx, symbol, quantity, result); err != nil {
+			result.Message = fmt.Sprintf("订单执行失败: %v", err)
+			e.logger.Error(result.Message)
+			return result
+		}
+		result.NewPosition, _ = e.GetCurrentPosition(ctx, symbol)
+		e.addTradeHistory(*result)
+		return result
+	}
+
+	if e.testMode {
+		e.logger.Warning("测试模式 - 仅模拟部分平仓，不实际下单")
+		currentPrice, _ := e.GetCurrentPrice(ctx, symbol)
+		result.Success = true
+		result.Price = currentPrice
+		result.Filled = quantity
+		e.modelTestFill(result, currentPrice)
+		result.Message = fmt.Sprintf("测试模式：模拟部分平仓成功 @ $%.2f（模拟手续费 %.4f USDT）", result.Price, result.Fee)
+		return result
+	}
+
+	e.DetectPositionMode(ctx)
+
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	orderSide, positionSide := futures.SideTypeSell, futures.PositionSideTypeLong
+	if currentPosition.Side == "short" {
+		orderSide, positionSide = futures.SideTypeBuy, futures.PositionSideTypeShort
+	}
+	if e.positionMode == PositionModeOneWay {
+		positionSide = futures.PositionSideTypeBoth
+	}
+
+	orderService := e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(orderSide).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeMarket).
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(quantity))
+
+	// Only use ReduceOnly in Hedge mode, not in One-way mode
+	// 只在双向持仓模式使用 ReduceOnly，单向模式不使用
+	if e.positionMode == PositionModeHedge {
+		orderService = orderService.ReduceOnly(true)
+	}
+
+	sentAt := time.Now()
+	order, err := e.sendOrder(ctx, orderService, binanceSymbol, LegPartial, orderSide, quantity)
+	if err != nil {
+		result.Message = fmt.Sprintf("订单执行失败: %v", err)
+		e.logger.Error(result.Message)
+		return result
+	}
+
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", order.OrderID)
+	result.Filled = quantity
+	result.Price, _ = parseFloat(order.AvgPrice)
+	if result.Price == 0 {
+		result.Price, _ = e.GetCurrentPrice(ctx, symbol)
+	}
+	result.Message = "部分平仓订单执行成功"
+	e.logger.Success(fmt.Sprintf("✅ 部分平仓成功，订单ID: %d", order.OrderID))
+	result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, orderSide, quantity, result.Price)
+
+	e.awaitPositionUpdate(ctx, symbol, sentAt)
+	result.NewPosition, _ = e.GetCurrentPosition(ctx, symbol)
+	e.addTradeHistory(*result)
 
 	return result
 }
@@ -470,17 +963,19 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		_, err := e.client.NewCreateOrderService().
+		closeOrder, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeBuy).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
-			Do(ctx)
+			Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(currentPosition.Size)),
+			binanceSymbol, LegClose, futures.SideTypeBuy, currentPosition.Size)
 
 		if err != nil {
 			return err
 		}
+		closePrice, _ := parseFloat(closeOrder.AvgPrice)
+		result.Fee += e.recordOrderFills(ctx, binanceSymbol, closeOrder.OrderID, futures.OrderTypeMarket, futures.SideTypeBuy, currentPosition.Size, closePrice)
 		time.Sleep(1 * time.Second)
 	}
 
@@ -492,17 +987,26 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		order, err := e.client.NewCreateOrderService().
+		if plan := slicingFrom(ctx); plan != nil {
+			return e.executeSlicedEntry(ctx, plan, symbol, futures.SideTypeBuy, positionSide, amount, result)
+		}
+		if plan := chaseFrom(ctx); plan != nil {
+			return e.executeChaseEntry(ctx, plan, symbol, futures.SideTypeBuy, positionSide, LegOpen, amount, result)
+		}
+
+		markSent(ctx)
+		order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeBuy).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", amount)).
-			Do(ctx)
+			Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(amount)),
+			binanceSymbol, LegOpen, futures.SideTypeBuy, amount)
 
 		if err != nil {
 			return err
 		}
+		markFilled(ctx)
 
 		// Get fill price from order
 		// 从订单获取成交价格
@@ -521,6 +1025,7 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 		result.Price = fillPrice
 		result.Message = "订单执行成功"
 		e.logger.Success(fmt.Sprintf("✅ 订单执行成功，订单ID: %d, 成交价: %.2f", order.OrderID, fillPrice))
+		result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, futures.SideTypeBuy, amount, fillPrice)
 	} else {
 		result.Message = "已有多仓，不重复开仓（系统保护：防止意外加仓）"
 		e.logger.Warning("⚠️ 已有多仓，不重复开仓")
@@ -540,17 +1045,19 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		_, err := e.client.NewCreateOrderService().
+		closeOrder, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeSell).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
-			Do(ctx)
+			Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(currentPosition.Size)),
+			binanceSymbol, LegClose, futures.SideTypeSell, currentPosition.Size)
 
 		if err != nil {
 			return err
 		}
+		closePrice, _ := parseFloat(closeOrder.AvgPrice)
+		result.Fee += e.recordOrderFills(ctx, binanceSymbol, closeOrder.OrderID, futures.OrderTypeMarket, futures.SideTypeSell, currentPosition.Size, closePrice)
 		time.Sleep(1 * time.Second)
 	}
 
@@ -562,17 +1069,26 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		order, err := e.client.NewCreateOrderService().
+		if plan := slicingFrom(ctx); plan != nil {
+			return e.executeSlicedEntry(ctx, plan, symbol, futures.SideTypeSell, positionSide, amount, result)
+		}
+		if plan := chaseFrom(ctx); plan != nil {
+			return e.executeChaseEntry(ctx, plan, symbol, futures.SideTypeSell, positionSide, LegOpen, amount, result)
+		}
+
+		markSent(ctx)
+		order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeSell).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", amount)).
-			Do(ctx)
+			Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(amount)),
+			binanceSymbol, LegOpen, futures.SideTypeSell, amount)
 
 		if err != nil {
 			return err
 		}
+		markFilled(ctx)
 
 		// Get fill price from order
 		// 从订单获取成交价格
@@ -591,6 +1107,7 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 		result.Price = fillPrice
 		result.Message = "订单执行成功"
 		e.logger.Success(fmt.Sprintf("✅ 订单执行成功，订单ID: %d, 成交价: %.2f", order.OrderID, fillPrice))
+		result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, futures.SideTypeSell, amount, fillPrice)
 	} else {
 		result.Message = "已有空仓，不重复开仓（系统保护：防止意外加仓）"
 		e.logger.Warning("⚠️ 已有空仓，不重复开仓")
@@ -620,7 +1137,7 @@ func (e *BinanceExecutor) executeCloseLong(ctx context.Context, symbol string, c
 		Side(futures.SideTypeSell).
 		PositionSide(positionSide).
 		Type(futures.OrderTypeMarket).
-		Quantity(fmt.Sprintf("%.4f", currentPosition.Size))
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(currentPosition.Size))
 
 	// Only use ReduceOnly in Hedge mode, not in One-way mode
 	// 只在双向持仓模式使用 ReduceOnly，单向模式不使用
@@ -628,16 +1145,21 @@ func (e *BinanceExecutor) executeCloseLong(ctx context.Context, symbol string, c
 		orderService = orderService.ReduceOnly(true)
 	}
 
-	order, err := orderService.Do(ctx)
+	markSent(ctx)
+	order, err := e.sendOrder(ctx, orderService, binanceSymbol, LegClose, futures.SideTypeSell, currentPosition.Size)
 
 	if err != nil {
 		return err
 	}
+	markFilled(ctx)
 
 	result.Success = true
 	result.OrderID = fmt.Sprintf("%d", order.OrderID)
 	result.Message = "订单执行成功"
 	e.logger.Success(fmt.Sprintf("✅ 订单执行成功，订单ID: %d", order.OrderID))
+	closePrice, _ := parseFloat(order.AvgPrice)
+	result.Price = closePrice
+	result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, futures.SideTypeSell, currentPosition.Size, closePrice)
 	return nil
 }
 
@@ -662,7 +1184,7 @@ func (e *BinanceExecutor) executeCloseShort(ctx context.Context, symbol string,
 		Side(futures.SideTypeBuy).
 		PositionSide(positionSide).
 		Type(futures.OrderTypeMarket).
-		Quantity(fmt.Sprintf("%.4f", currentPosition.Size))
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(currentPosition.Size))
 
 	// Only use ReduceOnly in Hedge mode, not in One-way mode
 	// 只在双向持仓模式使用 ReduceOnly，单向模式不使用
@@ -670,46 +1192,46 @@ func (e *BinanceExecutor) executeCloseShort(ctx context.Context, symbol string,
 		orderService = orderService.ReduceOnly(true)
 	}
 
-	order, err := orderService.Do(ctx)
+	markSent(ctx)
+	order, err := e.sendOrder(ctx, orderService, binanceSymbol, LegClose, futures.SideTypeBuy, currentPosition.Size)
 
 	if err != nil {
 		return err
 	}
+	markFilled(ctx)
 
 	result.Success = true
 	result.OrderID = fmt.Sprintf("%d", order.OrderID)
 	result.Message = "订单执行成功"
 	e.logger.Success(fmt.Sprintf("✅ 订单执行成功，订单ID: %d", order.OrderID))
+	closePrice, _ := parseFloat(order.AvgPrice)
+	result.Price = closePrice
+	result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, futures.SideTypeBuy, currentPosition.Size, closePrice)
 	return nil
 }
 
-// GetAccountSummary returns a formatted account summary (balance and margin usage)
-// GetAccountSummary 返回格式化的账户摘要信息（余额和保证金使用情况）
+// GetAccountSummary returns a formatted account summary (balance, margin usage, margin ratio and per-asset balances)
+// GetAccountSummary 返回格式化的账户摘要信息（余额、保证金使用情况、保证金率和分资产余额）
 func (e *BinanceExecutor) GetAccountSummary(ctx context.Context) string {
 	var summary strings.Builder
 
 	// Get account balance
 	// 获取账户余额
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Sprintf("**获取账户信息失败**: %v", err)
 	}
 
-	var usdtFree, usdtTotal float64
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			usdtFree, _ = parseFloat(asset.AvailableBalance)
-			usdtTotal, _ = parseFloat(asset.WalletBalance)
-			break
-		}
-	}
+	// Balance of the margin assets in use (QUOTE_ASSET and the quote assets of CRYPTO_SYMBOLS)
+	// 正在使用的保证金资产余额（QUOTE_ASSET 及 CRYPTO_SYMBOLS 的计价资产）
+	marginFree, marginTotal, asset := e.marginBalance(ctx, account)
 
 	// Calculate used margin and usage rate
 	// 计算已用保证金和资金使用率
-	usedMargin := usdtTotal - usdtFree
+	usedMargin := marginTotal - marginFree
 	usageRate := 0.0
-	if usdtTotal > 0 {
-		usageRate = (usedMargin / usdtTotal) * 100
+	if marginTotal > 0 {
+		usageRate = (usedMargin / marginTotal) * 100
 	}
 
 	// Determine risk level based on usage rate
@@ -726,13 +1248,26 @@ func (e *BinanceExecutor) GetAccountSummary(ctx context.Context) string {
 	}
 
 	summary.WriteString("- 总余额: ")
-	summary.WriteString(fmt.Sprintf("%.2f USDT\n", usdtTotal))
+	summary.WriteString(fmt.Sprintf("%.2f %s\n", marginTotal, asset))
 	summary.WriteString("- 可用余额: ")
-	summary.WriteString(fmt.Sprintf("%.2f USDT\n", usdtFree))
+	summary.WriteString(fmt.Sprintf("%.2f %s\n", marginFree, asset))
 	summary.WriteString("- 已用保证金: ")
-	summary.WriteString(fmt.Sprintf("%.2f USDT\n", usedMargin))
+	summary.WriteString(fmt.Sprintf("%.2f %s\n", usedMargin, asset))
 	summary.WriteString(fmt.Sprintf("- 资金使用率: %.1f%% %s\n", usageRate, riskLevel))
 
+	// Margin ratio: what the exchange liquidates on, unlike the usage rate above
+	// 保证金率：交易所据此强平，与上面的资金使用率不同
+	risk := AccountRiskOf(account, e.MarginAssets(ctx)...)
+	summary.WriteString(fmt.Sprintf("- 保证金余额（含未实现盈亏）: %.2f %s\n", risk.MarginBalance, asset))
+	summary.WriteString(fmt.Sprintf("- 维持保证金: %.2f %s\n", risk.MaintenanceMargin, asset))
+	summary.WriteString(fmt.Sprintf("- 保证金率: %.2f%% %s（达到 100%% 触发强平）\n", risk.MarginRatio, MarginRatioLevel(risk.MarginRatio)))
+	if len(risk.Assets) > 1 {
+		summary.WriteString("- 资产明细:\n")
+		for _, a := range risk.Assets {
+			summary.WriteString(fmt.Sprintf("  - %s: 钱包 %.4f，可用 %.4f，未实现盈亏 %+.4f\n", a.Asset, a.Wallet, a.Available, a.UnrealizedPnL))
+		}
+	}
+
 	return summary.String()
 }
 
@@ -746,14 +1281,20 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 	var position *Position
 	var managedPos *Position // Position from StopLossManager (has HighestPrice)
 
-	if stopLossManager != nil {
-		managedPos = stopLossManager.GetPosition(symbol)
-	}
-
 	// Always get fresh data from Binance for real-time UnrealizedPnL, LiquidationPrice, etc.
 	// 始终从币安获取最新数据（实时盈亏、爆仓价等）
 	position, _ = e.GetCurrentPosition(ctx, symbol)
 
+	// Match the managed position on the same side (both sides may be held in hedge mode)
+	// 匹配同一方向的托管持仓（双向持仓模式下可能同时持有多空）
+	if stopLossManager != nil {
+		if position != nil {
+			managedPos = stopLossManager.GetPositionSide(symbol, position.Side)
+		} else {
+			managedPos = stopLossManager.GetPosition(symbol)
+		}
+	}
+
 	// If we have both, merge HighestPrice from managed position into fresh position
 	// 如果两个都有，将托管持仓的 HighestPrice 合并到最新持仓中
 	if position != nil && managedPos != nil {
@@ -835,10 +1376,33 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 				}
 				summary.WriteString(fmt.Sprintf(" (距离当前价 %.2f%%)\n", stopDistance))
 			}
+
+			// Partial take-profit plan/status
+			// 分批止盈计划/状态
+			if managedPos != nil && managedPos.PartialTPExecuted {
+				summary.WriteString("- 分批止盈: 已执行\n")
+			} else if managedPos != nil && managedPos.PartialTPPrice > 0 {
+				summary.WriteString(fmt.Sprintf("- 分批止盈: 目标 $%.2f 平仓 %.0f%%\n", managedPos.PartialTPPrice, managedPos.PartialTPPercent))
+			}
 		}
 
 	} else {
 		summary.WriteString("无持仓\n")
+
+		// Pending stop entries are shown so the LLM can keep, replace or drop them
+		// 展示未触发的条件入场单，便于 LLM 决定保留、替换或放弃
+		if stopLossManager != nil && stopLossManager.storage != nil {
+			if entries, err := stopLossManager.storage.GetPendingEntries(e.config.GetBinanceSymbolFor(symbol)); err == nil {
+				for _, entry := range entries {
+					action := ActionBuyStop
+					if entry.Side == "short" {
+						action = ActionSellStop
+					}
+					summary.WriteString(fmt.Sprintf("- 条件入场单: %s @ $%.2f，数量 %.4f，%s 过期（再次给出 %s 会替换该单）\n",
+						action, entry.TriggerPrice, entry.Quantity, entry.ExpiresAt.Format("01-02 15:04"), action))
+				}
+			}
+		}
 	}
 
 	return summary.String()
@@ -850,26 +1414,21 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	var summary strings.Builder
 
 	// Get account balance
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Sprintf("**获取账户信息失败**: %v", err)
 	}
 
-	var usdtFree, usdtTotal float64
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			usdtFree, _ = parseFloat(asset.AvailableBalance)
-			usdtTotal, _ = parseFloat(asset.WalletBalance)
-			break
-		}
-	}
+	// Balance of the margin assets in use (QUOTE_ASSET and the quote assets of CRYPTO_SYMBOLS)
+	// 正在使用的保证金资产余额（QUOTE_ASSET 及 CRYPTO_SYMBOLS 的计价资产）
+	marginFree, marginTotal, asset := e.marginBalance(ctx, account)
 
 	// Calculate used margin and usage rate
 	// 计算已用保证金和资金使用率
-	usedMargin := usdtTotal - usdtFree
+	usedMargin := marginTotal - marginFree
 	usageRate := 0.0
-	if usdtTotal > 0 {
-		usageRate = (usedMargin / usdtTotal) * 100
+	if marginTotal > 0 {
+		usageRate = (usedMargin / marginTotal) * 100
 	}
 
 	// Determine risk level based on usage rate
@@ -886,9 +1445,9 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	}
 
 	summary.WriteString("**账户信息**:\n")
-	summary.WriteString(fmt.Sprintf("- 总余额: %.2f USDT\n", usdtTotal))
-	summary.WriteString(fmt.Sprintf("- 可用余额: %.2f USDT\n", usdtFree))
-	summary.WriteString(fmt.Sprintf("- 已用保证金: %.2f USDT\n", usedMargin))
+	summary.WriteString(fmt.Sprintf("- 总余额: %.2f %s\n", marginTotal, asset))
+	summary.WriteString(fmt.Sprintf("- 可用余额: %.2f %s\n", marginFree, asset))
+	summary.WriteString(fmt.Sprintf("- 已用保证金: %.2f %s\n", usedMargin, asset))
 	summary.WriteString(fmt.Sprintf("- 资金使用率: %.1f%% %s\n", usageRate, riskLevel))
 
 	// Get position (prioritize StopLossManager for accurate HighestPrice tracking)
@@ -896,14 +1455,20 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	var position *Position
 	var managedPos *Position // Position from StopLossManager (has HighestPrice)
 
-	if stopLossManager != nil {
-		managedPos = stopLossManager.GetPosition(symbol)
-	}
-
 	// Always get fresh data from Binance for real-time UnrealizedPnL, LiquidationPrice, etc.
 	// 始终从币安获取最新数据（实时盈亏、爆仓价等）
 	position, _ = e.GetCurrentPosition(ctx, symbol)
 
+	// Match the managed position on the same side (both sides may be held in hedge mode)
+	// 匹配同一方向的托管持仓（双向持仓模式下可能同时持有多空）
+	if stopLossManager != nil {
+		if position != nil {
+			managedPos = stopLossManager.GetPositionSide(symbol, position.Side)
+		} else {
+			managedPos = stopLossManager.GetPosition(symbol)
+		}
+	}
+
 	// If we have both, merge HighestPrice from managed position into fresh position
 	// 如果两个都有，将托管持仓的 HighestPrice 合并到最新持仓中
 	if position != nil && managedPos != nil {
@@ -1045,30 +1610,37 @@ func (e *BinanceExecutor) withRetry(fn func() error) error {
 // GetAccountInfo gets account information from Binance
 // GetAccountInfo 从币安获取账户信息
 func (e *BinanceExecutor) GetAccountInfo(ctx context.Context) (*futures.Account, error) {
+	if e.paper != nil {
+		return e.paper.GetAccountInfo(ctx)
+	}
 	return e.client.NewGetAccountService().Do(ctx)
 }
 
-// GetBalance returns the available USDT balance
-// GetBalance 返回可用的 USDT 余额
+// GetBalance returns the available balance of the margin assets in use (QUOTE_ASSET and the quote assets of CRYPTO_SYMBOLS)
+// GetBalance 返回正在使用的保证金资产（QUOTE_ASSET 及 CRYPTO_SYMBOLS 的计价资产）的可用余额
 func (e *BinanceExecutor) GetBalance(ctx context.Context) (float64, error) {
+	return e.getAssetBalance(ctx, e.MarginAssets(ctx)...)
+}
+
+// GetBalanceFor returns the available balance of a symbol's margin asset, e.g. USDC for BTCUSDC
+// GetBalanceFor 返回交易对保证金资产的可用余额，例如 BTCUSDC 使用 USDC
+func (e *BinanceExecutor) GetBalanceFor(ctx context.Context, symbol string) (float64, error) {
+	return e.getAssetBalance(ctx, e.MarginAssetFor(ctx, symbol))
+}
+
+// getAssetBalance returns the available balance of the given assets
+// getAssetBalance 返回指定资产的可用余额
+func (e *BinanceExecutor) getAssetBalance(ctx context.Context, assets ...string) (float64, error) {
 	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get account info: %w", err)
 	}
 
-	// Find USDT balance
-	// 查找 USDT 余额
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			balance, err := parseFloat(asset.AvailableBalance)
-			if err != nil {
-				return 0, fmt.Errorf("failed to parse balance: %w", err)
-			}
-			return balance, nil
-		}
+	available, _, found := AssetBalance(account, assets...)
+	if !found {
+		return 0, fmt.Errorf("%s balance not found", strings.Join(assets, "/"))
 	}
-
-	return 0, fmt.Errorf("USDT balance not found")
+	return available, nil
 }
 
 // GetCurrentPrice returns the current market price for a symbol
@@ -1128,6 +1700,15 @@ func (p *Position) GetUnrealizedPnLUSDT() float64 {
 	return p.GetUnrealizedPnL() * p.EntryPrice * p.Quantity
 }
 
+// RealizedPnLAt calculates the realized profit/loss in USDT of closing quantity at price
+// RealizedPnLAt 计算以指定价格平掉指定数量时的已实现盈亏（USDT）
+func (p *Position) RealizedPnLAt(price, quantity float64) float64 {
+	if p.Side == "short" {
+		return (p.EntryPrice - price) * quantity
+	}
+	return (price - p.EntryPrice) * quantity
+}
+
 // GetHoldingDuration returns how long the position has been held
 // GetHoldingDuration 返回持仓时间
 func (p *Position) GetHoldingDuration() time.Duration {
@@ -1180,15 +1761,9 @@ func (p *Position) UpdatePrice(newPrice float64) {
 		}
 	}
 
-	// Add to price history (limit to last 1000 points)
-	// 添加到价格历史（限制最近 1000 个点）
-	p.PriceHistory = append(p.PriceHistory, PricePoint{
-		Time:  time.Now(),
-		Price: newPrice,
-	})
-	if len(p.PriceHistory) > 1000 {
-		p.PriceHistory = p.PriceHistory[1:]
-	}
+	// Add to price history (limited to MaxPriceHistory points)
+	// 添加到价格历史（最多保留 MaxPriceHistory 个点）
+	p.appendPricePoint(time.Now(), newPrice)
 }
 
 // AddStopLossEvent adds a stop-loss change event to history
@@ -1224,69 +1799,13 @@ func (p *Position) GetStopLossHistoryString() string {
 	return result
 }
 
-// AdjustQuantityPrecision adjusts quantity to match symbol's precision requirements
-// AdjustQuantityPrecision 调整数量以符合交易对的精度要求
-func AdjustQuantityPrecision(symbol string, quantity float64) (float64, error) {
-	// Get precision and min quantity for the symbol
-	// 获取交易对的精度和最小数量要求
-	precision, minQty := getSymbolPrecision(symbol)
-
-	// Round to the required precision
-	// 四舍五入到所需精度
-	multiplier := math.Pow(10, float64(precision))
-	adjusted := math.Round(quantity*multiplier) / multiplier
-
-	// Ensure it meets minimum quantity
-	// 确保满足最小数量要求
-	if adjusted < minQty {
-		return 0, fmt.Errorf("数量 %.4f 低于最小要求 %.4f (交易对: %s)", adjusted, minQty, symbol)
-	}
-
-	return adjusted, nil
-}
-
-// getSymbolPrecision returns the quantity precision and minimum quantity for a symbol
-// getSymbolPrecision 返回交易对的数量精度和最小数量
-func getSymbolPrecision(symbol string) (precision int, minQty float64) {
-	// Default values
-	// 默认值
-	precision = 2
-	minQty = 0.01
-
-	// Symbol-specific configurations (based on Binance futures)
-	// 特定交易对的配置（基于币安期货）
-	switch strings.ToUpper(symbol) {
-	case "BTCUSDT", "BTC/USDT":
-		precision = 3 // 0.001 BTC
-		minQty = 0.001
-	case "ETHUSDT", "ETH/USDT":
-		precision = 3 // 0.001 ETH
-		minQty = 0.001
-	case "SOLUSDT", "SOL/USDT":
-		precision = 2 // 0.01 SOL (2025-04-02 更新)
-		minQty = 0.01
-	case "BNBUSDT", "BNB/USDT":
-		precision = 2 // 0.01 BNB
-		minQty = 0.01
-	case "XRPUSDT", "XRP/USDT":
-		precision = 1 // 0.1 XRP
-		minQty = 0.1
-	case "ADAUSDT", "ADA/USDT":
-		precision = 0 // 1 ADA
-		minQty = 1.0
-	case "DOGEUSDT", "DOGE/USDT":
-		precision = 0 // 1 DOGE
-		minQty = 1.0
-	case "DOTUSDT", "DOT/USDT":
-		precision = 1 // 0.1 DOT
-		minQty = 0.1
-	case "MATICUSDT", "MATIC/USDT":
-		precision = 0 // 1 MATIC
-		minQty = 1.0
-	case "AVAXUSDT", "AVAX/USDT":
-		precision = 2 // 0.01 AVAX
-		minQty = 0.1
-	}
-
-	return precision, minQty
+// isMinNotionalRejection reports whether an order was rejected for being below MIN_NOTIONAL
+// isMinNotionalRejection 判断订单是否因低于 MIN_NOTIONAL 被拒绝
+//
+// Matched by code -4164 or the "notional must be no smaller than" message, as the SDK has no typed errors.
+// SDK 不提供类型化错误，因此匹配错误码 -4164 或 "notional must be no smaller than" 消息。
+func isMinNotionalRejection(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "-4164") ||
+		strings.Contains(strings.ToLower(errMsg), "notional must be no smaller than")
 }
@@ -2,7 +2,9 @@ package executors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/adshao/go-binance/v2/futures"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/logger"
 	"os"
@@ -54,3 +56,51 @@ func TestBinanceConnecting(t *testing.T) {
 	}
 	t.Logf("✅ Successfully connected to Binance via proxy!")
 }
+
+// TestMarginTypeErrors tests how margin type change errors are classified
+// TestMarginTypeErrors 测试切换保证金类型错误的分类
+func TestMarginTypeErrors(t *testing.T) {
+	tests := []struct {
+		err       string
+		unchanged bool
+		locked    bool
+	}{
+		{"<APIError> code=-4046, msg=No need to change margin type.", true, false},
+		{"<APIError> code=-4047, msg=Margin type cannot be changed if there exists open orders.", false, true},
+		{"<APIError> code=-4048, msg=Margin type cannot be changed if there exists position.", false, true},
+		{"<APIError> code=-1021, msg=Timestamp for this request is outside of the recvWindow.", false, false},
+	}
+	for _, tt := range tests {
+		err := errors.New(tt.err)
+		if isMarginTypeUnchanged(err) != tt.unchanged || isMarginTypeLocked(err) != tt.locked {
+			t.Errorf("%q: unchanged = %v, locked = %v, want %v, %v",
+				tt.err, isMarginTypeUnchanged(err), isMarginTypeLocked(err), tt.unchanged, tt.locked)
+		}
+	}
+}
+
+// TestPositionModeSwitch tests the position check and the error classification of a position mode change
+// TestPositionModeSwitch 测试切换持仓模式前的持仓检查和错误分类
+func TestPositionModeSwitch(t *testing.T) {
+	risks := []*futures.PositionRisk{
+		{Symbol: "BTCUSDT", PositionAmt: "0.000"},
+		{Symbol: "ETHUSDT", PositionAmt: "-1.5"},
+		{Symbol: "ETHUSDT", PositionAmt: "2"},
+	}
+	if got := openPositionSymbols(risks); len(got) != 1 || got[0] != "ETHUSDT" {
+		t.Errorf("Expected only ETHUSDT to hold a position, got %v", got)
+	}
+	if got := openPositionSymbols(risks[:1]); len(got) != 0 {
+		t.Errorf("Expected no position, got %v", got)
+	}
+
+	if !isPositionModeUnchanged(errors.New("<APIError> code=-4059, msg=No need to change position side.")) {
+		t.Error("Expected -4059 to mean the mode is unchanged")
+	}
+	if !isPositionModeLocked(errors.New("<APIError> code=-4068, msg=Position side cannot be changed if there exists position.")) {
+		t.Error("Expected -4068 to mean the mode is locked")
+	}
+	if isPositionModeLocked(errors.New("<APIError> code=-1021, msg=Timestamp for this request is outside of the recvWindow.")) {
+		t.Error("Expected other errors not to mean the mode is locked")
+	}
+}
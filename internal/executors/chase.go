@@ -0,0 +1,310 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Entry execution algorithms (EXECUTION_ALGO)
+// 开仓执行算法（EXECUTION_ALGO）
+const (
+	// ExecutionAlgoMarket opens positions with a single market order
+	// ExecutionAlgoMarket 使用一笔市价单开仓
+	ExecutionAlgoMarket = "market"
+	// ExecutionAlgoChase works post-only limit orders at the touch, re-pegged until a timeout, then goes to market
+	// ExecutionAlgoChase 在买一/卖一挂只做 Maker 的限价单并不断重挂，超时后改用市价单
+	ExecutionAlgoChase = "chase"
+)
+
+// chaseRejectDelay is the pause before re-pegging an order rejected for taking liquidity
+// chaseRejectDelay 是只做 Maker 订单因会吃单被拒绝后重挂前的等待时间
+const chaseRejectDelay = time.Second
+
+// chasePlan holds the timing of a chased entry
+// chasePlan 保存限价追单开仓的时间参数
+type chasePlan struct {
+	Repeg   time.Duration // 重挂间隔 / Re-peg interval
+	Timeout time.Duration // 改用市价单前的总时长 / Total time before falling back to market
+}
+
+// chaseKey is the context key carrying the chase plan of an entry
+// chaseKey 是携带开仓追单计划的 context 键
+type chaseKey struct{}
+
+// withChase returns a context whose market entries are worked as chased limit orders
+// withChase 返回一个 context，其市价开仓改为限价追单执行
+func withChase(ctx context.Context, plan chasePlan) context.Context {
+	return context.WithValue(ctx, chaseKey{}, &plan)
+}
+
+// chaseFrom returns the chase plan carried by ctx (nil for market orders)
+// chaseFrom 返回 ctx 中携带的追单计划（市价单为 nil）
+func chaseFrom(ctx context.Context) *chasePlan {
+	plan, _ := ctx.Value(chaseKey{}).(*chasePlan)
+	return plan
+}
+
+// chaseFill accumulates the fills of a chased entry
+// chaseFill 累计限价追单开仓的成交
+type chaseFill struct {
+	Quantity    float64 // 总成交数量 / Total filled quantity
+	Notional    float64 // 总成交额 / Total filled notional
+	MakerQty    float64 // 限价单成交数量 / Quantity filled by the limit orders
+	Fee         float64 // 手续费（USDT）/ Fees (USDT)
+	LimitOrders int     // 已发送的限价单数 / Limit orders sent
+}
+
+// add records a fill of quantity at price
+// add 记录一笔按 price 成交 quantity 的成交
+func (f *chaseFill) add(quantity, price float64, maker bool) {
+	f.Quantity += quantity
+	f.Notional += quantity * price
+	if maker {
+		f.MakerQty += quantity
+	}
+}
+
+// AvgPrice returns the average fill price (0 without fills)
+// AvgPrice 返回成交均价（无成交为 0）
+func (f *chaseFill) AvgPrice() float64 {
+	if f.Quantity <= 0 {
+		return 0
+	}
+	return f.Notional / f.Quantity
+}
+
+// chasePrice returns the price of a post-only order joining the touch: best bid to buy, best ask to sell
+// chasePrice 返回加入盘口最优价的只做 Maker 订单价格：买入挂买一，卖出挂卖一
+func chasePrice(side futures.SideType, bestBid, bestAsk float64) float64 {
+	if side == futures.SideTypeBuy {
+		return bestBid
+	}
+	return bestAsk
+}
+
+// isPostOnlyRejection reports whether a post-only order was rejected because it would have taken liquidity
+// isPostOnlyRejection 判断只做 Maker 订单是否因会立即吃单而被拒绝
+func isPostOnlyRejection(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "-5022") || strings.Contains(msg, "could not be executed as maker")
+}
+
+// planEntryExecution returns the context a market entry of quantity is executed with
+// planEntryExecution 返回执行数量为 quantity 的市价开仓所使用的 context
+//
+// Live entries whose notional reaches LARGE_ORDER_MIN_NOTIONAL are sliced per LARGE_ORDER_ALGO; otherwise, with
+// EXECUTION_ALGO=chase, entries whose notional reaches CHASE_MIN_NOTIONAL are worked as chased limit orders.
+// Paper trading and test mode always fill at market.
+// 名义价值达到 LARGE_ORDER_MIN_NOTIONAL 的实盘开仓按 LARGE_ORDER_ALGO 拆分执行；否则 EXECUTION_ALGO=chase 时，
+// 名义价值达到 CHASE_MIN_NOTIONAL 的实盘开仓改为限价追单。模拟盘和测试模式始终按市价成交。
+func (tc *TradeCoordinator) planEntryExecution(ctx context.Context, symbol string, quantity float64) context.Context {
+	if tc.executor.paper != nil || tc.executor.testMode {
+		return ctx
+	}
+	slicing := tc.config.LargeOrderAlgo == LargeOrderAlgoTWAP || tc.config.LargeOrderAlgo == LargeOrderAlgoIceberg
+	chase := tc.config.ExecutionAlgo == ExecutionAlgoChase
+	if !slicing && !chase {
+		return ctx
+	}
+
+	notional := 0.0
+	if slicing || tc.config.ChaseMinNotional > 0 {
+		price, err := tc.executor.GetCurrentPrice(ctx, symbol)
+		if err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  获取 %s 价格失败: %v，使用市价单开仓", symbol, err))
+			return ctx
+		}
+		notional = quantity * price
+	}
+	if slicing && notional >= tc.config.LargeOrderMinNotional {
+		if sliced, ok := tc.planSlicing(ctx, symbol, notional); ok {
+			return sliced
+		}
+	}
+
+	if !chase {
+		return ctx
+	}
+	if tc.config.ChaseMinNotional > 0 && notional < tc.config.ChaseMinNotional {
+		tc.logger.Info(fmt.Sprintf("开仓名义价值 %.2f USDT 低于 CHASE_MIN_NOTIONAL %.2f，使用市价单", notional, tc.config.ChaseMinNotional))
+		return ctx
+	}
+
+	plan := chasePlan{
+		Repeg:   time.Duration(tc.config.ChaseRepegSeconds) * time.Second,
+		Timeout: time.Duration(tc.config.ChaseTimeoutSeconds) * time.Second,
+	}
+	tc.logger.Info(fmt.Sprintf("🎯 开仓执行算法: 限价追单（每 %s 重挂，%s 后剩余数量改用市价单）", plan.Repeg, plan.Timeout))
+	return withChase(ctx, plan)
+}
+
+// executeChaseEntry opens a position with post-only limit orders at the touch, re-pegged every plan.Repeg,
+// and sends what is left after plan.Timeout as a market order
+// executeChaseEntry 以买一/卖一价的只做 Maker 限价单开仓，每 plan.Repeg 重挂一次，plan.Timeout 后剩余数量以市价单发送
+//
+// leg names the client orders: the limit orders are leg-chase-N and the market fallback is leg itself.
+// leg 用于命名客户端订单：限价单为 leg-chase-N，市价兜底单即为 leg。
+func (e *BinanceExecutor) executeChaseEntry(ctx context.Context, plan *chasePlan, symbol string, side futures.SideType, positionSide futures.PositionSideType, leg string, quantity float64, result *TradeResult) error {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	filters := e.SymbolFiltersFor(ctx, symbol)
+	fill := &chaseFill{}
+	var lastOrderID int64
+	var unsettled error // 撤单失败时订单可能仍在挂单，不再追加市价单 / A failed cancel may leave the order working: no market fallback then
+
+	markSent(ctx)
+	deadline := time.Now().Add(plan.Timeout)
+	for attempt := 1; time.Now().Before(deadline) && ctx.Err() == nil; attempt++ {
+		remaining := filters.RoundQuantity(quantity - fill.Quantity)
+		if remaining <= 0 {
+			break
+		}
+
+		depth, err := e.client.NewDepthService().Symbol(binanceSymbol).Limit(5).Do(ctx)
+		if err != nil || len(depth.Bids) == 0 || len(depth.Asks) == 0 {
+			e.logger.Warning(fmt.Sprintf("⚠️  获取 %s 盘口失败: %v，剩余数量改用市价单", symbol, err))
+			break
+		}
+		bestBid, _ := parseFloat(depth.Bids[0].Price)
+		bestAsk, _ := parseFloat(depth.Asks[0].Price)
+		price := chasePrice(side, bestBid, bestAsk)
+
+		chaseLeg := fmt.Sprintf("%s-chase-%d", leg, attempt)
+		order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(side).
+			PositionSide(positionSide).
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTX).
+			Price(filters.FormatPrice(price)).
+			Quantity(filters.FormatQuantity(remaining)),
+			binanceSymbol, chaseLeg, side, remaining)
+		if err != nil {
+			if isPostOnlyRejection(err) {
+				e.logger.Info(fmt.Sprintf("🎯 限价追单 #%d @ %s 会立即吃单被拒绝，稍后重挂", attempt, filters.FormatPrice(price)))
+				sleepCtx(ctx, chaseRejectDelay)
+				continue
+			}
+			if fill.Quantity > 0 {
+				e.logger.Warning(fmt.Sprintf("⚠️  限价追单 #%d 下单失败: %v，剩余数量改用市价单", attempt, err))
+				break
+			}
+			return err
+		}
+		fill.LimitOrders++
+		e.logger.Info(fmt.Sprintf("🎯 限价追单 #%d: %s %s %s @ %s（只做 Maker）",
+			attempt, side, filters.FormatQuantity(remaining), binanceSymbol, filters.FormatPrice(price)))
+
+		sleepCtx(ctx, min(plan.Repeg, time.Until(deadline)))
+
+		executed, avgPrice, err := e.settleChaseOrder(ctx, binanceSymbol, chaseLeg, order.OrderID)
+		if err != nil {
+			unsettled = fmt.Errorf("限价追单 %d 撤单或查询失败，订单可能仍在挂单: %w", order.OrderID, err)
+			e.logger.Error(fmt.Sprintf("❌ %v", unsettled))
+			break
+		}
+		if executed > 0 {
+			fill.add(executed, avgPrice, true)
+			fill.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeLimit, side, executed, avgPrice)
+			lastOrderID = order.OrderID
+		}
+	}
+
+	if remaining := filters.RoundQuantity(quantity - fill.Quantity); remaining > 0 && ctx.Err() == nil && unsettled == nil {
+		e.logger.Info(fmt.Sprintf("⏱️  限价追单未完全成交（已成交 %s），剩余 %s 改用市价单",
+			filters.FormatQuantity(fill.Quantity), filters.FormatQuantity(remaining)))
+		order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(side).
+			PositionSide(positionSide).
+			Type(futures.OrderTypeMarket).
+			Quantity(filters.FormatQuantity(remaining)),
+			binanceSymbol, leg, side, remaining)
+		if err != nil {
+			if fill.Quantity == 0 {
+				return err
+			}
+			e.logger.Warning(fmt.Sprintf("⚠️  剩余数量市价单失败: %v，仅部分开仓", err))
+		} else {
+			fillPrice, _ := parseFloat(order.AvgPrice)
+			if fillPrice == 0 {
+				fillPrice, _ = e.GetCurrentPrice(ctx, symbol)
+			}
+			fill.add(remaining, fillPrice, false)
+			fill.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, side, remaining, fillPrice)
+			lastOrderID = order.OrderID
+		}
+	}
+	if fill.Quantity <= 0 {
+		if unsettled != nil {
+			return unsettled
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("限价追单未成交: %w", err)
+		}
+		return fmt.Errorf("限价追单未成交：数量 %.8f 低于数量步长", quantity)
+	}
+	markFilled(ctx)
+
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", lastOrderID)
+	result.Price = fill.AvgPrice()
+	result.Filled = fill.Quantity
+	result.Fee += fill.Fee
+	if fill.Quantity < result.Amount {
+		// Positions are registered with Amount, which must match what was actually opened
+		// 持仓按 Amount 注册，必须与实际开仓数量一致
+		e.logger.Warning(fmt.Sprintf("⚠️  仅部分开仓: %.4f / %.4f", fill.Quantity, result.Amount))
+		result.Amount = fill.Quantity
+	}
+	result.Message = fmt.Sprintf("限价追单成交 %.4f @ $%.2f（%d 笔限价单，Maker 成交 %.0f%%）",
+		fill.Quantity, result.Price, fill.LimitOrders, fill.MakerQty/fill.Quantity*100)
+	e.logger.Success(fmt.Sprintf("✅ %s", result.Message))
+	return nil
+}
+
+// settleChaseOrder cancels a chase order that is still working and returns its executed quantity and average price
+// settleChaseOrder 撤销仍在挂单的追单限价单，并返回其成交数量和均价
+//
+// Cancelling uses a context detached from ctx, so an abandoned entry never leaves a working order behind.
+// 撤单使用与 ctx 分离的 context，确保放弃的开仓不会留下挂单。
+func (e *BinanceExecutor) settleChaseOrder(ctx context.Context, binanceSymbol, leg string, orderID int64) (float64, float64, error) {
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	// The order may have filled or expired meanwhile
+	// 订单可能已经成交或过期
+	if _, err := e.client.NewCancelOrderService().Symbol(binanceSymbol).OrderID(orderID).Do(cleanupCtx); err != nil && !isUnknownOrder(err) {
+		return 0, 0, err
+	}
+	order, err := e.client.NewGetOrderService().Symbol(binanceSymbol).OrderID(orderID).Do(cleanupCtx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	executed, _ := parseFloat(order.ExecutedQuantity)
+	avgPrice, _ := parseFloat(order.AvgPrice)
+	if batchID := batchIDFrom(ctx); batchID != "" && e.trades != nil {
+		if err := e.trades.UpdateClientOrder(ClientOrderID(batchID, binanceSymbol, leg), orderID, string(order.Status), avgPrice, executed); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  更新订单 %d 状态失败: %v", orderID, err))
+		}
+	}
+	return executed, avgPrice, nil
+}
+
+// sleepCtx waits for d or until ctx is done
+// sleepCtx 等待 d 或直到 ctx 结束
+func sleepCtx(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
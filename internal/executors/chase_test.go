@@ -0,0 +1,47 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// TestChaseHelpers tests the chase plan context, the joined price and the accumulated fills
+// TestChaseHelpers 测试追单计划 context、挂单价格以及成交累计
+func TestChaseHelpers(t *testing.T) {
+	if chaseFrom(context.Background()) != nil {
+		t.Error("Expected no chase plan on a plain context")
+	}
+	ctx := withChase(context.Background(), chasePlan{Repeg: 5 * time.Second, Timeout: time.Minute})
+	if plan := chaseFrom(ctx); plan == nil || plan.Repeg != 5*time.Second || plan.Timeout != time.Minute {
+		t.Errorf("Unexpected chase plan: %+v", plan)
+	}
+
+	if price := chasePrice(futures.SideTypeBuy, 99.9, 100.1); price != 99.9 {
+		t.Errorf("Buy should join the best bid, got %.2f", price)
+	}
+	if price := chasePrice(futures.SideTypeSell, 99.9, 100.1); price != 100.1 {
+		t.Errorf("Sell should join the best ask, got %.2f", price)
+	}
+
+	if !isPostOnlyRejection(errors.New("<APIError> code=-5022, msg=Due to the order could not be executed as maker, the Post Only order will be rejected.")) {
+		t.Error("Expected -5022 to be a post-only rejection")
+	}
+	if isPostOnlyRejection(errors.New("<APIError> code=-2019, msg=Margin is insufficient.")) {
+		t.Error("Insufficient margin is not a post-only rejection")
+	}
+
+	fill := &chaseFill{}
+	if fill.AvgPrice() != 0 {
+		t.Errorf("Expected no average price without fills, got %.2f", fill.AvgPrice())
+	}
+	fill.add(0.6, 100, true)
+	fill.add(0.4, 101, false)
+	if math.Abs(fill.AvgPrice()-100.4) > 1e-9 || fill.Quantity != 1 || fill.MakerQty != 0.6 {
+		t.Errorf("Unexpected fill: %+v (avg %.4f)", fill, fill.AvgPrice())
+	}
+}
@@ -0,0 +1,449 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// StateSnapshot is the bot state captured by an end-of-day checkpoint
+// StateSnapshot 是日终快照记录的机器人状态
+type StateSnapshot struct {
+	TakenAt        time.Time                  `json:"taken_at"`
+	ConfigHash     string                     `json:"config_hash"`
+	Balances       map[string]BalanceSnapshot `json:"balances"`
+	Positions      []PositionSnapshot         `json:"positions"`
+	OpenOrders     []OrderSnapshot            `json:"open_orders"`
+	PendingEntries []EntrySnapshot            `json:"pending_entries"`
+}
+
+// BalanceSnapshot is the balance of one margin asset
+// BalanceSnapshot 是单个保证金资产的余额
+type BalanceSnapshot struct {
+	Wallet    float64 `json:"wallet"`
+	Available float64 `json:"available"`
+}
+
+// PositionSnapshot is an open position
+// PositionSnapshot 是一个持仓
+type PositionSnapshot struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Quantity   float64 `json:"quantity"`
+	EntryPrice float64 `json:"entry_price"`
+	Leverage   int     `json:"leverage"`
+}
+
+// OrderSnapshot is an order resting on the exchange (or the paper book)
+// OrderSnapshot 是交易所（或模拟盘）上的挂单
+type OrderSnapshot struct {
+	Symbol     string  `json:"symbol"`
+	OrderID    int64   `json:"order_id"`
+	Side       string  `json:"side"`
+	Type       string  `json:"type"`
+	Quantity   float64 `json:"quantity"`
+	StopPrice  float64 `json:"stop_price"`
+	ReduceOnly bool    `json:"reduce_only"`
+}
+
+// EntrySnapshot is a stop entry still waiting for its trigger
+// EntrySnapshot 是仍在等待触发的条件入场单
+type EntrySnapshot struct {
+	ID           int64     `json:"id"`
+	Symbol       string    `json:"symbol"`
+	Side         string    `json:"side"`
+	TriggerPrice float64   `json:"trigger_price"`
+	Quantity     float64   `json:"quantity"`
+	OrderID      string    `json:"order_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (p PositionSnapshot) key() string { return p.Symbol + " " + p.Side }
+func (o OrderSnapshot) key() string    { return fmt.Sprintf("%s #%d", o.Symbol, o.OrderID) }
+
+func (p PositionSnapshot) String() string {
+	return fmt.Sprintf("%s %s %.4f @ %.4f (%dx)", p.Symbol, p.Side, p.Quantity, p.EntryPrice, p.Leverage)
+}
+
+func (o OrderSnapshot) String() string {
+	return fmt.Sprintf("%s #%d %s %s %.4f @ %.4f", o.Symbol, o.OrderID, o.Type, o.Side, o.Quantity, o.StopPrice)
+}
+
+func (e EntrySnapshot) String() string {
+	return fmt.Sprintf("%s #%d %s 触发价 %.4f 数量 %.4f", e.Symbol, e.ID, e.Side, e.TriggerPrice, e.Quantity)
+}
+
+// OpenOrders returns the orders resting on a symbol
+// OpenOrders 返回交易对的所有挂单
+func (e *BinanceExecutor) OpenOrders(ctx context.Context, symbol string) ([]*futures.Order, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	if e.paper != nil {
+		paperOrders, err := e.paper.storage.GetOpenPaperOrders(binanceSymbol)
+		if err != nil {
+			return nil, err
+		}
+		orders := make([]*futures.Order, 0, len(paperOrders))
+		for _, order := range paperOrders {
+			orders = append(orders, &futures.Order{
+				Symbol:       order.Symbol,
+				OrderID:      order.ID,
+				Side:         futures.SideType(order.Side),
+				Type:         futures.OrderType(order.Type),
+				OrigQuantity: formatPaperFloat(order.Quantity),
+				StopPrice:    formatPaperFloat(order.StopPrice),
+				ReduceOnly:   order.ReduceOnly,
+			})
+		}
+		return orders, nil
+	}
+
+	var orders []*futures.Order
+	err := e.withRetry(func() error {
+		var err error
+		orders, err = e.client.NewListOpenOrdersService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+	return orders, nil
+}
+
+// TakeSnapshot captures balances, positions, open orders and pending stop entries of CRYPTO_SYMBOLS
+// TakeSnapshot 记录 CRYPTO_SYMBOLS 的余额、持仓、挂单和等待中的条件入场单
+func (e *BinanceExecutor) TakeSnapshot(ctx context.Context, db *storage.Storage) (*StateSnapshot, error) {
+	snapshot := &StateSnapshot{
+		TakenAt:    time.Now().UTC(),
+		ConfigHash: e.config.Fingerprint(),
+		Balances:   make(map[string]BalanceSnapshot),
+	}
+
+	account, err := e.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+	for _, asset := range e.MarginAssets(ctx) {
+		if available, wallet, found := AssetBalance(account, asset); found {
+			snapshot.Balances[asset] = BalanceSnapshot{Wallet: wallet, Available: available}
+		}
+	}
+
+	for _, symbol := range e.config.CryptoSymbols {
+		binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+		positions, err := e.GetCurrentPositions(ctx, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", binanceSymbol, err)
+		}
+		for _, pos := range positions {
+			snapshot.Positions = append(snapshot.Positions, PositionSnapshot{
+				Symbol:     binanceSymbol,
+				Side:       pos.Side,
+				Quantity:   pos.Size,
+				EntryPrice: pos.EntryPrice,
+				Leverage:   pos.Leverage,
+			})
+		}
+
+		orders, err := e.OpenOrders(ctx, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", binanceSymbol, err)
+		}
+		for _, order := range orders {
+			quantity, _ := parseFloat(order.OrigQuantity)
+			stopPrice, _ := parseFloat(order.StopPrice)
+			if stopPrice == 0 {
+				stopPrice, _ = parseFloat(order.Price)
+			}
+			snapshot.OpenOrders = append(snapshot.OpenOrders, OrderSnapshot{
+				Symbol:     binanceSymbol,
+				OrderID:    order.OrderID,
+				Side:       string(order.Side),
+				Type:       string(order.Type),
+				Quantity:   quantity,
+				StopPrice:  stopPrice,
+				ReduceOnly: order.ReduceOnly || order.ClosePosition,
+			})
+		}
+	}
+
+	// All symbols, so entries left behind by a symbol removed from CRYPTO_SYMBOLS still show up
+	// 查询全部交易对，从 CRYPTO_SYMBOLS 移除的交易对遗留的条件入场单也会出现在快照中
+	entries, err := db.GetPendingEntries("")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		snapshot.PendingEntries = append(snapshot.PendingEntries, EntrySnapshot{
+			ID:           entry.ID,
+			Symbol:       entry.Symbol,
+			Side:         entry.Side,
+			TriggerPrice: entry.TriggerPrice,
+			Quantity:     entry.Quantity,
+			OrderID:      entry.OrderID,
+			ExpiresAt:    entry.ExpiresAt,
+		})
+	}
+	return snapshot, nil
+}
+
+// DiffSnapshots lists what changed between two snapshots, one line per change
+// DiffSnapshots 列出两次快照之间的变化，每行一项
+func DiffSnapshots(prev, cur *StateSnapshot) []string {
+	var diff []string
+
+	if prev.ConfigHash != cur.ConfigHash {
+		diff = append(diff, fmt.Sprintf("⚙️ 配置变更: %s → %s", prev.ConfigHash, cur.ConfigHash))
+	}
+
+	assets := make([]string, 0, len(cur.Balances))
+	for asset := range cur.Balances {
+		assets = append(assets, asset)
+	}
+	for asset := range prev.Balances {
+		if _, ok := cur.Balances[asset]; !ok {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		before, after := prev.Balances[asset].Wallet, cur.Balances[asset].Wallet
+		if math.Abs(after-before) >= 0.005 {
+			diff = append(diff, fmt.Sprintf("💰 %s 钱包余额: %.2f → %.2f (%+.2f)", asset, before, after, after-before))
+		}
+	}
+
+	prevPositions := make(map[string]PositionSnapshot, len(prev.Positions))
+	for _, p := range prev.Positions {
+		prevPositions[p.key()] = p
+	}
+	for _, p := range cur.Positions {
+		old, ok := prevPositions[p.key()]
+		switch {
+		case !ok:
+			diff = append(diff, "📈 新持仓: "+p.String())
+		case old.Quantity != p.Quantity || old.EntryPrice != p.EntryPrice || old.Leverage != p.Leverage:
+			diff = append(diff, fmt.Sprintf("🔄 持仓变化: %s → %s", old, p))
+		}
+		delete(prevPositions, p.key())
+	}
+	for _, p := range prev.Positions {
+		if _, ok := prevPositions[p.key()]; ok {
+			diff = append(diff, "📉 已平仓: "+p.String())
+		}
+	}
+
+	prevOrders := make(map[string]bool, len(prev.OpenOrders))
+	for _, o := range prev.OpenOrders {
+		prevOrders[o.key()] = true
+	}
+	curOrders := make(map[string]bool, len(cur.OpenOrders))
+	for _, o := range cur.OpenOrders {
+		curOrders[o.key()] = true
+		if !prevOrders[o.key()] {
+			diff = append(diff, "➕ 新挂单: "+o.String())
+		}
+	}
+	for _, o := range prev.OpenOrders {
+		if !curOrders[o.key()] {
+			diff = append(diff, "➖ 挂单已结束: "+o.String())
+		}
+	}
+
+	prevEntries := make(map[int64]bool, len(prev.PendingEntries))
+	for _, entry := range prev.PendingEntries {
+		prevEntries[entry.ID] = true
+	}
+	curEntries := make(map[int64]bool, len(cur.PendingEntries))
+	for _, entry := range cur.PendingEntries {
+		curEntries[entry.ID] = true
+		if !prevEntries[entry.ID] {
+			diff = append(diff, "⏳ 新条件入场单: "+entry.String())
+		}
+	}
+	for _, entry := range prev.PendingEntries {
+		if !curEntries[entry.ID] {
+			diff = append(diff, "✔️ 条件入场单已结束: "+entry.String())
+		}
+	}
+
+	return diff
+}
+
+// SnapshotAnomalies lists state that shouldn't exist: orders with neither a position nor a pending
+// entry behind them, and positions without a resting stop order
+// SnapshotAnomalies 列出不应存在的状态：既没有对应持仓也不属于条件入场单的孤儿订单，以及没有止损挂单的持仓
+//
+// Any STOP* order that isn't a stop entry counts as a stop-loss (hedge mode stops aren't reduce-only).
+// 不属于条件入场单的 STOP* 订单都视为止损单（双向持仓模式的止损单不是只减仓单）。
+func SnapshotAnomalies(s *StateSnapshot) []string {
+	var anomalies []string
+
+	positionSymbols := make(map[string]bool, len(s.Positions))
+	for _, p := range s.Positions {
+		positionSymbols[p.Symbol] = true
+	}
+	entryOrders := make(map[string]bool, len(s.PendingEntries))
+	for _, entry := range s.PendingEntries {
+		if entry.OrderID != "" {
+			entryOrders[entry.Symbol+" #"+entry.OrderID] = true
+		}
+	}
+	protected := make(map[string]bool)
+	for _, o := range s.OpenOrders {
+		if entryOrders[o.Symbol+" #"+strconv.FormatInt(o.OrderID, 10)] {
+			continue
+		}
+		if !positionSymbols[o.Symbol] {
+			anomalies = append(anomalies, "🚨 孤儿订单（无对应持仓或条件入场单）: "+o.String())
+			continue
+		}
+		if strings.HasPrefix(o.Type, "STOP") {
+			protected[o.Symbol] = true
+		}
+	}
+	for _, p := range s.Positions {
+		if !protected[p.Symbol] {
+			anomalies = append(anomalies, "🚨 持仓没有止损挂单: "+p.String())
+		}
+	}
+	return anomalies
+}
+
+// StateCheckpointer saves an end-of-day snapshot once a day at CHECKPOINT_HOUR (UTC) and
+// reports its diff against the previous checkpoint
+// StateCheckpointer 每天在 CHECKPOINT_HOUR（UTC）保存一次日终快照，并报告与上一次快照的差异
+type StateCheckpointer struct {
+	config   *config.Config
+	executor *BinanceExecutor
+	storage  *storage.Storage
+	notifier notify.Notifier
+	logger   *logger.ColorLogger
+}
+
+// NewStateCheckpointer creates a new StateCheckpointer
+// NewStateCheckpointer 创建新的日终快照器
+func NewStateCheckpointer(cfg *config.Config, executor *BinanceExecutor, db *storage.Storage, notifier notify.Notifier, log *logger.ColorLogger) *StateCheckpointer {
+	return &StateCheckpointer{config: cfg, executor: executor, storage: db, notifier: notifier, logger: log}
+}
+
+// CheckpointDay returns the day label of the latest checkpoint boundary at or before now (UTC, YYYY-MM-DD)
+// CheckpointDay 返回 now 及之前最近一次快照时刻所属的日期（UTC，YYYY-MM-DD）
+func CheckpointDay(now time.Time, hour int) string {
+	now = now.UTC()
+	boundary := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if boundary.After(now) {
+		boundary = boundary.AddDate(0, 0, -1)
+	}
+	return boundary.Format("2006-01-02")
+}
+
+// Checkpoint takes today's checkpoint unless it was already taken; returns nil when there was nothing to do
+// Checkpoint 保存今天的快照（已保存过则跳过），无需保存时返回 nil
+//
+// A bot started after CHECKPOINT_HOUR catches up right away, so a restart never skips a day.
+// 在 CHECKPOINT_HOUR 之后启动时立即补存，重启不会漏掉某一天。
+func (c *StateCheckpointer) Checkpoint(ctx context.Context, now time.Time) (*storage.StateCheckpoint, error) {
+	day := CheckpointDay(now, c.config.CheckpointHour)
+	if done, err := c.storage.HasStateCheckpoint(day); err != nil || done {
+		return nil, err
+	}
+
+	snapshot, err := c.executor.TakeSnapshot(ctx, c.storage)
+	if err != nil {
+		return nil, err
+	}
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode state snapshot: %w", err)
+	}
+
+	lines := []string{}
+	prev, err := c.storage.GetStateCheckpointBefore(day)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		var prevSnapshot StateSnapshot
+		if err := json.Unmarshal([]byte(prev.State), &prevSnapshot); err != nil {
+			c.logger.Warning(fmt.Sprintf("⚠️  解析 %s 的状态快照失败，不对比差异: %v", prev.Day, err))
+		} else {
+			lines = DiffSnapshots(&prevSnapshot, snapshot)
+		}
+	}
+	anomalies := SnapshotAnomalies(snapshot)
+	lines = append(lines, anomalies...)
+
+	checkpoint := &storage.StateCheckpoint{
+		Day:        day,
+		State:      string(state),
+		ConfigHash: snapshot.ConfigHash,
+		Diff:       strings.Join(lines, "\n"),
+		Anomalies:  len(anomalies),
+		CreatedAt:  snapshot.TakenAt,
+	}
+	if checkpoint.ID, err = c.storage.SaveStateCheckpoint(checkpoint); err != nil {
+		return nil, err
+	}
+
+	c.report(ctx, checkpoint, prev)
+	return checkpoint, nil
+}
+
+// report logs the checkpoint and notifies its diff; the first checkpoint only notifies anomalies
+// report 记录快照日志并通知差异；首次快照只在有异常时通知
+func (c *StateCheckpointer) report(ctx context.Context, checkpoint *storage.StateCheckpoint, prev *storage.StateCheckpoint) {
+	since := "首次快照"
+	if prev != nil {
+		since = "对比 " + prev.Day
+	}
+	if checkpoint.Diff == "" {
+		c.logger.Info(fmt.Sprintf("📸 日终快照 %s 已保存（%s）：无变化", checkpoint.Day, since))
+		return
+	}
+	c.logger.Info(fmt.Sprintf("📸 日终快照 %s 已保存（%s）:\n%s", checkpoint.Day, since, checkpoint.Diff))
+	if prev == nil && checkpoint.Anomalies == 0 {
+		return
+	}
+
+	level := notify.LevelInfo
+	if checkpoint.Anomalies > 0 {
+		level = notify.LevelWarning
+	}
+	if err := c.notifier.Notify(ctx, notify.Message{
+		Title: fmt.Sprintf("日终快照 %s（%s）", checkpoint.Day, since),
+		Text:  checkpoint.Diff,
+		Level: level,
+	}); err != nil {
+		c.logger.Warning(fmt.Sprintf("⚠️  发送日终快照通知失败: %v", err))
+	}
+}
+
+// Run checks every interval whether today's checkpoint is due, until ctx is done
+// Run 每隔 interval 检查一次是否需要保存今天的快照，直到 ctx 结束
+func (c *StateCheckpointer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Checkpoint(ctx, time.Now()); err != nil {
+			c.logger.Warning(fmt.Sprintf("⚠️  保存日终快照失败: %v", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
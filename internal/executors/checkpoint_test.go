@@ -0,0 +1,84 @@
+package executors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckpointDay tests the day label before and after the checkpoint hour
+// TestCheckpointDay 测试快照时刻之前和之后的日期
+func TestCheckpointDay(t *testing.T) {
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+	if got := CheckpointDay(now, 0); got != "2025-03-10" {
+		t.Errorf("Expected 2025-03-10, got %s", got)
+	}
+	if got := CheckpointDay(now, 22); got != "2025-03-09" {
+		t.Errorf("Expected 2025-03-09 before the checkpoint hour, got %s", got)
+	}
+}
+
+// TestDiffSnapshots tests balance, position, order, entry and config changes between two checkpoints
+// TestDiffSnapshots 测试两次快照之间余额、持仓、挂单、条件入场单和配置的变化
+func TestDiffSnapshots(t *testing.T) {
+	prev := &StateSnapshot{
+		ConfigHash: "aaaa",
+		Balances:   map[string]BalanceSnapshot{"USDT": {Wallet: 1000}},
+		Positions: []PositionSnapshot{
+			{Symbol: "BTCUSDT", Side: "long", Quantity: 0.01, EntryPrice: 60000, Leverage: 5},
+			{Symbol: "ETHUSDT", Side: "short", Quantity: 0.5, EntryPrice: 3000, Leverage: 5},
+		},
+		OpenOrders:     []OrderSnapshot{{Symbol: "ETHUSDT", OrderID: 1, Type: "STOP_MARKET"}},
+		PendingEntries: []EntrySnapshot{{ID: 7, Symbol: "SOLUSDT"}},
+	}
+	cur := &StateSnapshot{
+		ConfigHash: "bbbb",
+		Balances:   map[string]BalanceSnapshot{"USDT": {Wallet: 1012.5}},
+		Positions: []PositionSnapshot{
+			{Symbol: "BTCUSDT", Side: "long", Quantity: 0.02, EntryPrice: 61000, Leverage: 5},
+		},
+		OpenOrders: []OrderSnapshot{{Symbol: "BTCUSDT", OrderID: 2, Type: "STOP_MARKET"}},
+	}
+
+	diff := strings.Join(DiffSnapshots(prev, cur), "\n")
+	for _, want := range []string{"配置变更: aaaa → bbbb", "USDT 钱包余额: 1000.00 → 1012.50 (+12.50)",
+		"持仓变化: BTCUSDT long", "已平仓: ETHUSDT short", "新挂单: BTCUSDT #2", "挂单已结束: ETHUSDT #1",
+		"条件入场单已结束: SOLUSDT #7"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Expected %q in diff:\n%s", want, diff)
+		}
+	}
+
+	if same := DiffSnapshots(cur, cur); len(same) != 0 {
+		t.Errorf("Expected no diff against itself, got %v", same)
+	}
+}
+
+// TestSnapshotAnomalies tests that orphaned orders and unprotected positions are flagged
+// TestSnapshotAnomalies 测试孤儿订单和没有止损的持仓会被标记
+func TestSnapshotAnomalies(t *testing.T) {
+	s := &StateSnapshot{
+		Positions: []PositionSnapshot{
+			{Symbol: "BTCUSDT", Side: "long"},
+			{Symbol: "ETHUSDT", Side: "long"},
+		},
+		OpenOrders: []OrderSnapshot{
+			{Symbol: "BTCUSDT", OrderID: 1, Type: "STOP_MARKET", ReduceOnly: true}, // 止损 / Stop-loss
+			{Symbol: "ETHUSDT", OrderID: 2, Type: "TAKE_PROFIT_MARKET"},            // 只有止盈 / Take-profit only
+			{Symbol: "SOLUSDT", OrderID: 3, Type: "STOP_MARKET"},                   // 条件入场单 / Stop entry
+			{Symbol: "XRPUSDT", OrderID: 4, Type: "STOP_MARKET", ReduceOnly: true}, // 孤儿订单 / Orphan
+		},
+		PendingEntries: []EntrySnapshot{{ID: 9, Symbol: "SOLUSDT", OrderID: "3"}},
+	}
+
+	anomalies := SnapshotAnomalies(s)
+	if len(anomalies) != 2 {
+		t.Fatalf("Expected 2 anomalies, got %v", anomalies)
+	}
+	if !strings.Contains(anomalies[0], "孤儿订单") || !strings.Contains(anomalies[0], "XRPUSDT #4") {
+		t.Errorf("Expected the XRPUSDT order flagged as orphaned, got %s", anomalies[0])
+	}
+	if !strings.Contains(anomalies[1], "没有止损") || !strings.Contains(anomalies[1], "ETHUSDT") {
+		t.Errorf("Expected the ETHUSDT position flagged as unprotected, got %s", anomalies[1])
+	}
+}
@@ -0,0 +1,178 @@
+package executors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Order legs, the last part of a client order id
+// 订单用途，即客户端订单 ID 的最后一段
+const (
+	LegOpen    = "open"    // 开仓 / Open a position
+	LegClose   = "close"   // 平仓（含反手前的平仓）/ Close a position (including before a reversal)
+	LegPartial = "partial" // 部分平仓 / Partial close
+	LegAdd     = "add"     // 加仓 / Add to a position
+)
+
+// clientOrderPrefix marks the orders placed by the bot
+// clientOrderPrefix 标记由本程序下达的订单
+const clientOrderPrefix = "ctb"
+
+// clientOrderIDPattern is Binance's rule for newClientOrderId
+// clientOrderIDPattern 是币安对 newClientOrderId 的格式要求
+var clientOrderIDPattern = regexp.MustCompile(`^[.A-Z:/a-z0-9_-]{1,36}$`)
+
+// batchIDKey is the context key carrying the analysis batch that places an order
+// batchIDKey 是携带下单分析批次的 context 键
+type batchIDKey struct{}
+
+// WithBatchID returns a context whose orders get client order ids derived from the batch
+// WithBatchID 返回一个 context，其订单使用由批次派生的客户端订单 ID
+func WithBatchID(ctx context.Context, batchID string) context.Context {
+	return context.WithValue(ctx, batchIDKey{}, batchID)
+}
+
+// batchIDFrom returns the batch carried by ctx ("" if none)
+// batchIDFrom 返回 ctx 中携带的批次 ID（没有则为 ""）
+func batchIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(batchIDKey{}).(string)
+	return id
+}
+
+// ClientOrderID derives the deterministic client order id of an order from its batch, symbol and leg
+// ClientOrderID 根据批次、交易对和订单用途生成确定性的客户端订单 ID
+//
+// The readable form "ctb-<batch>-<symbol>-<leg>" is used when it fits Binance's 36-character limit,
+// otherwise a hash of the same parts.
+// 符合币安 36 个字符限制时使用可读格式 "ctb-<批次>-<交易对>-<用途>"，否则使用相同内容的哈希。
+func ClientOrderID(batchID, binanceSymbol, leg string) string {
+	id := strings.Join([]string{clientOrderPrefix, strings.TrimPrefix(batchID, "batch-"), binanceSymbol, leg}, "-")
+	if clientOrderIDPattern.MatchString(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(batchID + "|" + binanceSymbol + "|" + leg))
+	return clientOrderPrefix + "-" + hex.EncodeToString(sum[:])[:36-len(clientOrderPrefix)-1]
+}
+
+// sendOrder sends a live order with a deterministic client order id, recorded before it is sent
+// sendOrder 使用确定性客户端订单 ID 发送实盘订单，发送前先记录
+//
+// Orders outside an analysis batch (manual trades, stop-loss closes) or without storage are sent as before.
+// An id that was already sent is refused, so the same batch can't place the same order twice.
+// 不属于分析批次的订单（手动交易、止损平仓）或没有存储时照常发送。已发送过的 ID 会被拒绝，
+// 因此同一批次不会重复下达同一订单。
+func (e *BinanceExecutor) sendOrder(ctx context.Context, service *futures.CreateOrderService, binanceSymbol, leg string, side futures.SideType, quantity float64) (*futures.CreateOrderResponse, error) {
+	batchID := batchIDFrom(ctx)
+	if batchID == "" || e.trades == nil {
+		return service.Do(ctx)
+	}
+
+	record := &storage.ClientOrder{
+		ClientOrderID: ClientOrderID(batchID, binanceSymbol, leg),
+		BatchID:       batchID,
+		Symbol:        binanceSymbol,
+		Leg:           leg,
+		Side:          string(side),
+		Quantity:      quantity,
+	}
+	saved, err := e.trades.SaveClientOrder(record)
+	if err != nil {
+		return nil, fmt.Errorf("记录订单 %s 失败，未下单: %w", record.ClientOrderID, err)
+	}
+	if !saved {
+		existing, _ := e.trades.GetClientOrder(record.ClientOrderID)
+		status := "unknown"
+		if existing != nil {
+			status = existing.Status
+		}
+		return nil, fmt.Errorf("订单 %s 已发送过（状态 %s），不重复下单", record.ClientOrderID, status)
+	}
+
+	// A failed send stays PENDING: the order may still have reached the exchange, ReconcileOrders settles it
+	// 发送失败时保持 PENDING：订单可能已到达交易所，由 ReconcileOrders 确认
+	order, err := service.NewClientOrderID(record.ClientOrderID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	avgPrice, _ := parseFloat(order.AvgPrice)
+	executedQty, _ := parseFloat(order.ExecutedQuantity)
+	if err := e.trades.UpdateClientOrder(record.ClientOrderID, order.OrderID, string(order.Status), avgPrice, executedQty); err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  更新订单 %s 状态失败: %v", record.ClientOrderID, err))
+	}
+	return order, nil
+}
+
+// OrderReconciliation summarizes a reconciliation of client orders with the exchange
+// OrderReconciliation 汇总一次客户端订单与交易所的对账
+type OrderReconciliation struct {
+	Checked int                    // 检查的订单数 / Orders checked
+	Filled  []*storage.ClientOrder // 确认成交的订单 / Orders confirmed filled
+	Open    int                    // 仍在挂单的订单数 / Orders still open
+	Missing int                    // 交易所查无此单的订单数 / Orders unknown to the exchange
+}
+
+// ReconcileOrders looks up the orders without a known outcome on Binance by client order id and records their state
+// ReconcileOrders 按客户端订单 ID 在币安查询结果未知的订单并记录其状态
+//
+// It is meant for startup: orders whose response was lost in a crash are settled, and the fills of orders
+// that filled unseen are recorded. Orders that can't be queried stay unsettled for the next run.
+// 用于启动时：崩溃时丢失响应的订单会被确认，未被看到的成交会被记录。查询失败的订单保持未确认，留待下次对账。
+func (e *BinanceExecutor) ReconcileOrders(ctx context.Context) (*OrderReconciliation, error) {
+	report := &OrderReconciliation{}
+	if e.trades == nil || e.paper != nil || e.testMode {
+		return report, nil
+	}
+
+	orders, err := e.trades.GetUnsettledClientOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range orders {
+		report.Checked++
+		order, err := e.client.NewGetOrderService().
+			Symbol(o.Symbol).
+			OrigClientOrderID(o.ClientOrderID).
+			Do(ctx)
+		if err != nil {
+			if isOrderNotFound(err) {
+				e.logger.Warning(fmt.Sprintf("⚠️  订单 %s 未到达交易所，标记为未找到", o.ClientOrderID))
+				if err := e.trades.UpdateClientOrder(o.ClientOrderID, 0, storage.ClientOrderMissing, 0, 0); err != nil {
+					e.logger.Warning(fmt.Sprintf("⚠️  更新订单 %s 状态失败: %v", o.ClientOrderID, err))
+				}
+				report.Missing++
+				continue
+			}
+			e.logger.Warning(fmt.Sprintf("⚠️  查询订单 %s 失败: %v（下次对账时重试）", o.ClientOrderID, err))
+			continue
+		}
+
+		avgPrice, _ := parseFloat(order.AvgPrice)
+		executedQty, _ := parseFloat(order.ExecutedQuantity)
+		if err := e.trades.UpdateClientOrder(o.ClientOrderID, order.OrderID, string(order.Status), avgPrice, executedQty); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  更新订单 %s 状态失败: %v", o.ClientOrderID, err))
+		}
+
+		switch order.Status {
+		case futures.OrderStatusTypeFilled:
+			// Without an order id the response was lost, so the fills were never recorded
+			// 没有订单 ID 说明响应丢失，成交尚未记录
+			if o.OrderID == 0 {
+				e.recordOrderFills(ctx, o.Symbol, order.OrderID, order.Type, order.Side, executedQty, avgPrice)
+			}
+			o.OrderID, o.Status, o.AvgPrice, o.ExecutedQty = order.OrderID, string(order.Status), avgPrice, executedQty
+			report.Filled = append(report.Filled, o)
+			e.logger.Info(fmt.Sprintf("🔄 订单 %s 已成交: %s %.4f @ %.2f", o.ClientOrderID, o.Side, executedQty, avgPrice))
+		case futures.OrderStatusTypeNew, futures.OrderStatusTypePartiallyFilled:
+			report.Open++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,25 @@
+package executors
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestClientOrderID tests the readable form, the hash fallback and that ids are deterministic
+// TestClientOrderID 测试可读格式、哈希回退以及 ID 的确定性
+func TestClientOrderID(t *testing.T) {
+	if got := ClientOrderID("batch-1760000000", "BTCUSDT", LegOpen); got != "ctb-1760000000-BTCUSDT-open" {
+		t.Errorf("Expected readable id, got %q", got)
+	}
+
+	long := ClientOrderID("batch-1760000000", "1000000MOGUSDT", LegPartial)
+	if len(long) != 36 || !strings.HasPrefix(long, "ctb-") || !clientOrderIDPattern.MatchString(long) {
+		t.Errorf("Expected a 36-character hashed id, got %q", long)
+	}
+	if again := ClientOrderID("batch-1760000000", "1000000MOGUSDT", LegPartial); again != long {
+		t.Errorf("Expected the same id for the same order, got %q and %q", long, again)
+	}
+	if other := ClientOrderID("batch-1760000000", "1000000MOGUSDT", LegClose); other == long {
+		t.Errorf("Expected different legs to get different ids, got %q", other)
+	}
+}
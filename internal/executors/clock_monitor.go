@@ -0,0 +1,116 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// ClockMonitor sets the Binance client's time offset from the server time at startup and alerts when
+// the local clock drifts beyond CLOCK_DRIFT_ALERT_MS
+// ClockMonitor 在启动时按服务器时间设置币安客户端的时间偏移，本地时钟偏差超过 CLOCK_DRIFT_ALERT_MS 时告警
+//
+// The offset is only set by Sync, before other goroutines send requests, since the client reads it
+// unsynchronized; later checks only measure the drift. It keeps growing until the system clock is
+// fixed, so the alert asks for NTP to be checked. It is sent once per episode.
+// 偏移只由 Sync 在其他 goroutine 发送请求之前设置，因为客户端无同步地读取它；之后的检查只测量偏差。
+// 系统时钟修复前偏差会继续增大，因此告警提示检查 NTP 同步。每次超限只告警一次。
+type ClockMonitor struct {
+	config   *config.Config
+	executor *BinanceExecutor
+	notifier notify.Notifier
+	logger   *logger.ColorLogger
+
+	mu       sync.Mutex
+	alerting bool // 当前偏差是否超过阈值 / Whether the drift is currently beyond the threshold
+}
+
+// NewClockMonitor creates a new ClockMonitor
+// NewClockMonitor 创建新的时钟偏差监控
+func NewClockMonitor(cfg *config.Config, executor *BinanceExecutor, notifier notify.Notifier, log *logger.ColorLogger) *ClockMonitor {
+	return &ClockMonitor{config: cfg, executor: executor, notifier: notifier, logger: log}
+}
+
+// driftTransition returns whether a drift starts or ends an episode beyond the threshold
+// driftTransition 返回本次偏差是否开始或结束一次超限
+func driftTransition(alerting bool, drift, threshold time.Duration) (started, recovered bool) {
+	exceeded := drift > threshold || drift < -threshold
+	return exceeded && !alerting, !exceeded && alerting
+}
+
+// Sync sets the client's time offset and alerts when the drift is beyond the threshold; call it at
+// startup, before the executor is used by other goroutines
+// Sync 设置客户端的时间偏移，偏差超过阈值时告警；需在启动时、执行器被其他 goroutine 使用之前调用
+func (m *ClockMonitor) Sync(ctx context.Context) {
+	drift, err := m.executor.SyncServerTime(ctx)
+	if err != nil {
+		m.logger.Warning(fmt.Sprintf("⚠️  获取币安服务器时间失败，未校正时钟偏移: %v", err))
+		return
+	}
+	m.report(ctx, drift, true)
+}
+
+// Check measures the drift and alerts when it crosses the threshold, leaving the time offset alone
+// Check 测量时钟偏差，越过阈值时告警，不修改时间偏移
+func (m *ClockMonitor) Check(ctx context.Context) {
+	drift, err := m.executor.ServerTimeDrift(ctx)
+	if err != nil {
+		m.logger.Warning(fmt.Sprintf("⚠️  获取币安服务器时间失败: %v", err))
+		return
+	}
+	m.report(ctx, drift, false)
+}
+
+// report logs and notifies when the drift starts or ends an episode beyond the threshold
+// report 在偏差开始或结束一次超限时记录日志并发送通知
+func (m *ClockMonitor) report(ctx context.Context, drift time.Duration, synced bool) {
+	drift = drift.Round(time.Millisecond)
+	threshold := time.Duration(m.config.ClockDriftAlertMs) * time.Millisecond
+
+	m.mu.Lock()
+	started, recovered := driftTransition(m.alerting, drift, threshold)
+	if started || recovered {
+		m.alerting = started
+	}
+	m.mu.Unlock()
+
+	correction := "已自动校正签名请求的时间偏移"
+	if !synced {
+		correction = "签名请求仍使用启动时校正的时间偏移，修复时钟后请重启"
+	}
+	switch {
+	case started:
+		m.logger.Warning(fmt.Sprintf("⏱️  本地时钟与币安服务器相差 %s（阈值 %s），%s，请检查 NTP 同步", drift, threshold, correction))
+		if err := m.notifier.Notify(ctx, notify.Message{
+			Title: "时钟偏差",
+			Text: fmt.Sprintf("本地时钟与币安服务器相差 %s，超过阈值 %s\n%s，请检查服务器的 NTP 时间同步",
+				drift, threshold, correction),
+			Level: notify.LevelWarning,
+		}); err != nil {
+			m.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+		}
+	case recovered:
+		m.logger.Success(fmt.Sprintf("✅ 时钟偏差已恢复到 %s 以内（当前 %s）", threshold, drift))
+	}
+}
+
+// Run checks every interval until ctx is done
+// Run 每隔 interval 检查一次，直到 ctx 结束
+func (m *ClockMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
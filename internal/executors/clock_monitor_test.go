@@ -0,0 +1,112 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// TestDriftTransition tests that an alert is raised once per episode beyond the threshold
+// TestDriftTransition 测试每次超限只触发一次告警
+func TestDriftTransition(t *testing.T) {
+	threshold := time.Second
+	tests := []struct {
+		name          string
+		alerting      bool
+		drift         time.Duration
+		wantStarted   bool
+		wantRecovered bool
+	}{
+		{"within threshold", false, 300 * time.Millisecond, false, false},
+		{"clock behind", false, 1500 * time.Millisecond, true, false},
+		{"clock ahead", false, -2 * time.Second, true, false},
+		{"still beyond", true, 3 * time.Second, false, false},
+		{"recovered", true, 100 * time.Millisecond, false, true},
+		{"at the threshold", false, time.Second, false, false},
+	}
+	for _, tt := range tests {
+		started, recovered := driftTransition(tt.alerting, tt.drift, threshold)
+		if started != tt.wantStarted || recovered != tt.wantRecovered {
+			t.Errorf("%s: driftTransition = %v, %v, want %v, %v", tt.name, started, recovered, tt.wantStarted, tt.wantRecovered)
+		}
+	}
+}
+
+// TestClockMonitorConcurrentRequests tests that periodic checks don't touch the time offset signed
+// requests read (run with -race) and that the offset set at startup is applied
+// TestClockMonitorConcurrentRequests 测试定期检查不会修改签名请求读取的时间偏移（使用 -race 运行），
+// 且启动时设置的偏移已生效
+func TestClockMonitorConcurrentRequests(t *testing.T) {
+	const drift = 5 * time.Second // 服务器时钟比本地快 5 秒 / The server clock is 5s ahead
+	var stale atomic.Int32
+
+	// Answered in memory: a real connection pool's locks would order the requests and hide a race
+	// 在内存中应答：真实连接池的锁会使请求有序，从而掩盖数据竞争
+	client := futures.NewClient("key", "secret")
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		serverNow := time.Now().Add(drift)
+		body := "[]"
+		switch r.URL.Path {
+		case "/fapi/v1/ping":
+			body = "{}"
+		case "/fapi/v1/time":
+			body = fmt.Sprintf(`{"serverTime":%d}`, serverNow.UnixMilli())
+		default:
+			// 签名请求的时间戳应已按偏差校正 / Signed requests should carry the corrected timestamp
+			ts, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+			if d := serverNow.Sub(time.UnixMilli(ts)); d > time.Second || d < -time.Second {
+				stale.Add(1)
+			}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})}
+	executor := &BinanceExecutor{client: client, config: &config.Config{}, logger: logger.NewColorLogger(false)}
+	monitor := NewClockMonitor(&config.Config{ClockDriftAlertMs: 1000}, executor, notify.NewMultiNotifier(), logger.NewColorLogger(false))
+
+	ctx := context.Background()
+	monitor.Sync(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if _, err := client.NewGetBalanceService().Do(ctx); err != nil {
+					t.Errorf("Signed request failed: %v", err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		monitor.Check(ctx)
+	}
+	wg.Wait()
+
+	if n := stale.Load(); n > 0 {
+		t.Errorf("Expected every signed request to use the startup offset, %d did not", n)
+	}
+	if !monitor.alerting {
+		t.Error("Expected a 5s drift to be reported")
+	}
+}
+
+// roundTripFunc answers HTTP requests with a function
+// roundTripFunc 使用函数应答 HTTP 请求
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
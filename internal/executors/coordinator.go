@@ -2,11 +2,14 @@ package executors
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/format"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
 )
 
 // TradeCoordinator coordinates the entire trading flow from decision to execution
@@ -16,6 +19,7 @@ type TradeCoordinator struct {
 	executor        *BinanceExecutor
 	logger          *logger.ColorLogger
 	stopLossManager *StopLossManager
+	riskOracle      *RiskOracle // 外部风控服务（nil 表示不调用）/ External risk oracle (nil = not consulted)
 }
 
 // NewTradeCoordinator creates a new TradeCoordinator
@@ -26,6 +30,7 @@ func NewTradeCoordinator(cfg *config.Config, executor *BinanceExecutor, log *log
 		executor:        executor,
 		logger:          log,
 		stopLossManager: stopLossManager,
+		riskOracle:      NewRiskOracle(cfg),
 	}
 }
 
@@ -34,12 +39,15 @@ func NewTradeCoordinator(cfg *config.Config, executor *BinanceExecutor, log *log
 func (tc *TradeCoordinator) ExecuteDecision(ctx context.Context, symbol string, action TradeAction, reason string) (*TradeResult, error) {
 	// Use default values (no leverage/position size override)
 	// 使用默认值（不覆盖杠杆/仓位大小）
-	return tc.ExecuteDecisionWithParams(ctx, symbol, action, reason, 0, 0)
+	return tc.ExecuteDecisionWithParams(ctx, symbol, action, reason, 0, 0, 0, 0)
 }
 
 // ExecuteDecisionWithParams executes a trading decision with custom leverage and position size
 // ExecuteDecisionWithParams 使用自定义杠杆和仓位大小执行交易决策
-func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbol string, action TradeAction, reason string, leverage int, positionSizePercent float64) (*TradeResult, error) {
+//
+// stopLoss and atr (0 = unknown) feed risk-per-trade sizing when SIZING_RISK_PER_TRADE is set.
+// stopLoss 和 atr（0 表示未知）在设置 SIZING_RISK_PER_TRADE 时用于按单笔风险计算仓位。
+func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbol string, action TradeAction, reason string, leverage int, positionSizePercent, stopLoss, atr float64) (*TradeResult, error) {
 	tc.logger.Header("交易执行协调器", '=', 80)
 	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
 	tc.logger.Info(fmt.Sprintf("决策动作: %s", action))
@@ -51,6 +59,69 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 		tc.logger.Info(fmt.Sprintf("LLM 建议仓位: %.1f%% 资金", positionSizePercent))
 	}
 
+	// Analysis-only mode never places orders
+	// 仅分析模式从不下单
+	if tc.config.IsAnalysisOnly() {
+		message := "⏭️  仅分析模式：未配置币安 API Key，决策不会被执行"
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   message,
+		}, nil
+	}
+
+	// Plugins may veto the trade or adjust its leverage and size
+	// 插件可以否决交易或调整杠杆和仓位
+	hookEvent := &plugins.ExecuteEvent{
+		Symbol:              symbol,
+		Action:              string(action),
+		Reason:              reason,
+		Leverage:            leverage,
+		PositionSizePercent: positionSizePercent,
+		StopLoss:            stopLoss,
+	}
+	if err := plugins.BeforeExecute(ctx, hookEvent); err != nil {
+		message := fmt.Sprintf("🔌 插件否决了本次交易: %v", err)
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   message,
+		}, nil
+	}
+	if hookEvent.Leverage != leverage || hookEvent.PositionSizePercent != positionSizePercent {
+		tc.logger.Info(fmt.Sprintf("🔌 插件调整了参数: 杠杆 %dx → %dx, 仓位 %.1f%% → %.1f%%",
+			leverage, hookEvent.Leverage, positionSizePercent, hookEvent.PositionSizePercent))
+		leverage, positionSizePercent = hookEvent.Leverage, hookEvent.PositionSizePercent
+	}
+
+	// The external risk oracle may reject the entry or change its leverage, size and stop-loss
+	// 外部风控服务可以拒绝开仓，或调整杠杆、仓位和止损
+	if err := tc.consultRiskOracle(ctx, hookEvent); err != nil {
+		message := fmt.Sprintf("🛡️  %v", err)
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   message,
+		}, nil
+	}
+	leverage, positionSizePercent = hookEvent.Leverage, hookEvent.PositionSizePercent
+	adjustedStopLoss := 0.0
+	if hookEvent.StopLoss != stopLoss {
+		adjustedStopLoss, stopLoss = hookEvent.StopLoss, hookEvent.StopLoss
+	}
+
 	// Step 1: Pre-execution safety checks
 	// 步骤 1: 执行前安全检查
 	tc.logger.Info("\n[步骤 1/5] 执行前安全检查...")
@@ -63,10 +134,20 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	// Step 2: Get current position
 	// 步骤 2: 获取当前持仓
 	tc.logger.Info("\n[步骤 2/5] 获取当前持仓...")
-	currentPosition, err := tc.executor.GetCurrentPosition(ctx, symbol)
+	positions, err := tc.executor.GetCurrentPositions(ctx, symbol)
 	if err != nil {
 		tc.logger.Warning(fmt.Sprintf("⚠️  无法获取持仓: %v，假设无持仓", err))
-		currentPosition = nil
+		positions = nil
+	}
+
+	// In hedge mode the action only concerns the position on its own side
+	// 双向持仓模式下动作只涉及其对应方向的持仓
+	var currentPosition *Position
+	if len(positions) > 0 {
+		currentPosition = positions[0]
+	}
+	if tc.executor.IsHedgeMode(ctx) {
+		currentPosition = PositionOnSide(positions, ActionSide(action))
 	}
 
 	if currentPosition != nil {
@@ -79,15 +160,28 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	// Step 3: Validate action against current position
 	// 步骤 3: 验证动作与当前持仓的一致性
 	tc.logger.Info("\n[步骤 3/5] 验证交易动作...")
-	if err := tc.validateAction(action, currentPosition); err != nil {
+	if err := tc.validateAction(action, positions); err != nil {
 		tc.logger.Error(fmt.Sprintf("❌ 动作验证失败: %v", err))
 		return nil, fmt.Errorf("action validation failed: %w", err)
 	}
+	if action == ActionBuy || action == ActionSell {
+		if err := tc.checkFundingCost(ctx, symbol, action); err != nil {
+			tc.logger.Error(fmt.Sprintf("❌ 资金费检查失败: %v", err))
+			return nil, fmt.Errorf("action validation failed: %w", err)
+		}
+	}
 	tc.logger.Success("✅ 动作验证通过")
+	markValidated(ctx)
+
+	if action == ActionBuy || action == ActionSell {
+		leverage = tc.smallAccountLeverage(ctx, leverage)
+	}
 
 	// Step 4: Update leverage if LLM provided recommendation
 	// 步骤 4: 如果 LLM 提供了杠杆建议，更新杠杆设置
-	if leverage > 0 {
+	if leverage > 0 && tc.config.ShadowMode {
+		tc.logger.Info(fmt.Sprintf("\n[步骤 4/7] 影子模式：按 %dx 计算仓位，不修改交易所杠杆", leverage))
+	} else if leverage > 0 {
 		tc.logger.Info(fmt.Sprintf("\n[步骤 4/7] 更新杠杆设置为 %dx...", leverage))
 		if err := tc.executor.SetupExchange(ctx, symbol, leverage); err != nil {
 			tc.logger.Warning(fmt.Sprintf("⚠️  更新杠杆失败: %v，使用当前杠杆继续", err))
@@ -101,13 +195,46 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	// Step 5: Calculate position size
 	// 步骤 5: 计算仓位大小
 	tc.logger.Info("\n[步骤 5/7] 计算仓位大小...")
-	positionSize, err := tc.calculatePositionSize(ctx, symbol, action, currentPosition, leverage, positionSizePercent)
+	positionSize, err := tc.calculatePositionSize(ctx, symbol, action, currentPosition, leverage, positionSizePercent, stopLoss, atr, 0)
+	var shortfall *MinNotionalShortfall
+	if errors.As(err, &shortfall) && tc.config.SizingMinNotionalSkip {
+		message := "⏭️  已跳过本次交易：" + shortfall.Error()
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			TestMode:  tc.config.BinanceTestMode,
+			Skipped:   true,
+			Message:   message,
+		}, nil
+	}
 	if err != nil {
 		tc.logger.Error(fmt.Sprintf("❌ 仓位计算失败: %v", err))
 		return nil, fmt.Errorf("position size calculation failed: %w", err)
 	}
 	tc.logger.Info(fmt.Sprintf("仓位大小: %.4f", positionSize))
 
+	// Thin or wide books would fill the market entry far from the analyzed price
+	// 盘口过薄或价差过大时，市价开仓会远离分析时的价格成交
+	if (action == ActionBuy || action == ActionSell) && positionSize > 0 {
+		if err := tc.checkLiquidity(ctx, symbol, action, positionSize); err != nil {
+			message := "⏭️  已跳过本次交易：" + err.Error()
+			tc.logger.Warning(message)
+			return &TradeResult{
+				Action:    action,
+				Symbol:    symbol,
+				Amount:    positionSize,
+				Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+				Reason:    reason,
+				TestMode:  tc.config.BinanceTestMode,
+				Skipped:   true,
+				Message:   message,
+			}, nil
+		}
+	}
+
 	// Step 6: Execute the trade
 	// 步骤 6: 执行交易
 	tc.logger.Info("\n[步骤 6/7] 执行交易...")
@@ -126,13 +253,47 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 		}, nil
 	}
 
+	// Shadow mode: the fully sized and validated order is recorded instead of sent
+	// 影子模式：记录已计算仓位并通过检查的订单，而不是发送
+	if tc.config.ShadowMode {
+		order, err := tc.recordShadowOrder(ctx, symbol, action, positionSize, leverage, 0, stopLoss, reason)
+		if err != nil {
+			tc.logger.Error(fmt.Sprintf("❌ 记录影子订单失败: %v", err))
+			return nil, fmt.Errorf("shadow order failed: %w", err)
+		}
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Amount:    positionSize,
+			Price:     order.DecisionPrice,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   fmt.Sprintf("👻 影子模式：已记录 %s %.4f @ $%.2f（#%d），未下单", action, positionSize, order.DecisionPrice, order.ID),
+		}, nil
+	}
+
+	// A market entry supersedes any pending stop entry of the symbol
+	// 市价开仓会取代该交易对未触发的条件入场单
+	if action == ActionBuy || action == ActionSell {
+		if _, err := tc.CancelPendingEntries(ctx, symbol, "已改为市价开仓"); err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  撤销条件入场单失败: %v", err))
+		}
+	}
+
+	if action == ActionBuy || action == ActionSell {
+		ctx = tc.planEntryExecution(ctx, symbol, positionSize)
+	}
+
+	sentAt := time.Now()
 	result := tc.executor.ExecuteTrade(ctx, symbol, action, positionSize, reason)
+	result.StopLoss = adjustedStopLoss
 
 	// Step 7: Post-execution verification
 	// 步骤 7: 执行后验证
 	tc.logger.Info("\n[步骤 7/7] 执行后验证...")
 	if result.Success {
-		if err := tc.postExecutionVerification(ctx, symbol, action, result); err != nil {
+		if err := tc.postExecutionVerification(ctx, symbol, action, result, sentAt); err != nil {
 			tc.logger.Warning(fmt.Sprintf("⚠️  执行后验证发现问题: %v", err))
 		} else {
 			tc.logger.Success("✅ 执行后验证通过")
@@ -142,29 +303,76 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	return result, nil
 }
 
+// ExecutePartialTakeProfit closes closePercent of a position now, or at targetPrice if it hasn't been reached yet
+// ExecutePartialTakeProfit 立即平掉持仓的 closePercent 部分，或在价格到达 targetPrice 时再平仓
+//
+// targetPrice <= 0 means close immediately.
+// targetPrice <= 0 表示立即平仓。
+func (tc *TradeCoordinator) ExecutePartialTakeProfit(ctx context.Context, symbol string, targetPrice, closePercent float64, reason string) (*TradeResult, error) {
+	tc.logger.Header("分批止盈", '=', 80)
+	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
+	tc.logger.Info(fmt.Sprintf("平仓比例: %.1f%%", closePercent))
+	if targetPrice > 0 {
+		tc.logger.Info(fmt.Sprintf("目标价格: $%.2f", targetPrice))
+	}
+	tc.logger.Info(fmt.Sprintf("理由: %s", reason))
+
+	if tc.stopLossManager == nil {
+		return nil, fmt.Errorf("止损管理器未初始化，无法分批止盈")
+	}
+
+	pos := tc.stopLossManager.GetPosition(symbol)
+	if pos == nil {
+		return nil, fmt.Errorf("持仓 %s 不存在或未被止损管理器跟踪", symbol)
+	}
+
+	if targetPrice > 0 {
+		currentPrice, err := tc.executor.GetCurrentPrice(ctx, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取当前价格失败: %w", err)
+		}
+
+		reached := (pos.Side == "long" && currentPrice >= targetPrice) || (pos.Side == "short" && currentPrice <= targetPrice)
+		if !reached {
+			if err := tc.stopLossManager.SetPartialTakeProfit(symbol, targetPrice, closePercent); err != nil {
+				return nil, err
+			}
+			return &TradeResult{
+				Success:   true,
+				Action:    ActionHold,
+				Symbol:    symbol,
+				Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+				Reason:    reason,
+				TestMode:  tc.config.BinanceTestMode,
+				Message:   fmt.Sprintf("分批止盈已挂起: 价格到达 $%.2f 时平仓 %.0f%%", targetPrice, closePercent),
+			}, nil
+		}
+		tc.logger.Info(fmt.Sprintf("当前价格 $%.2f 已到达目标，立即执行", currentPrice))
+	}
+
+	return tc.stopLossManager.TakePartialProfit(ctx, symbol, closePercent, reason)
+}
+
 // preExecutionChecks performs safety checks before executing a trade
 // preExecutionChecks 在执行交易前进行安全检查
 func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol string, action TradeAction) error {
 	// Check 1: Verify balance
 	// 检查 1: 验证余额
-	account, err := tc.executor.client.NewGetAccountService().Do(ctx)
+	account, err := tc.executor.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Errorf("无法获取账户信息: %w", err)
 	}
 
-	var availableBalance float64
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			fmt.Sscanf(asset.AvailableBalance, "%f", &availableBalance)
-			break
-		}
-	}
+	// The symbol's margin asset, e.g. USDC for BTCUSDC
+	// 交易对的保证金资产，例如 BTCUSDC 使用 USDC
+	marginAsset := tc.executor.MarginAssetFor(ctx, symbol)
+	availableBalance, _, _ := AssetBalance(account, marginAsset)
 
 	if availableBalance < 10.0 { // Minimum balance check
-		return fmt.Errorf("可用余额不足: %.2f USDT < 10 USDT", availableBalance)
+		return fmt.Errorf("可用余额不足: %.2f %s < 10 %s", availableBalance, marginAsset, marginAsset)
 	}
 
-	tc.logger.Info(fmt.Sprintf("  ✓ 账户余额: %.2f USDT", availableBalance))
+	tc.logger.Info(fmt.Sprintf("  ✓ 账户余额: %.2f %s", availableBalance, marginAsset))
 
 	// Check 2: Verify symbol exists and is trading
 	// 检查 2: 验证交易对存在且正在交易
@@ -183,10 +391,13 @@ func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol strin
 	return nil
 }
 
-// validateAction validates the action against current position
-// validateAction 验证动作与当前持仓的一致性
-func (tc *TradeCoordinator) validateAction(action TradeAction, currentPosition *Position) error {
-	if currentPosition == nil {
+// validateAction validates the action against the current positions of the symbol
+// validateAction 验证动作与交易对当前持仓的一致性
+//
+// Each side is checked on its own, so in hedge mode BUY is valid next to a short and SELL next to a long.
+// 每个方向单独检查，因此双向持仓模式下持有空仓时可以 BUY，持有多仓时可以 SELL。
+func (tc *TradeCoordinator) validateAction(action TradeAction, positions []*Position) error {
+	if len(positions) == 0 {
 		// No position, only BUY and SELL are valid
 		// 无持仓，只有 BUY 和 SELL 有效
 		if action != ActionBuy && action != ActionSell && action != ActionHold {
@@ -197,21 +408,22 @@ func (tc *TradeCoordinator) validateAction(action TradeAction, currentPosition *
 
 	// Has position, validate close actions
 	// 有持仓，验证平仓动作
+	long, short := PositionOnSide(positions, "long"), PositionOnSide(positions, "short")
 	switch action {
 	case ActionBuy:
-		if currentPosition.Side == "long" {
+		if long != nil {
 			return fmt.Errorf("已有多仓，不能重复开多")
 		}
 	case ActionSell:
-		if currentPosition.Side == "short" {
+		if short != nil {
 			return fmt.Errorf("已有空仓，不能重复开空")
 		}
 	case ActionCloseLong:
-		if currentPosition.Side != "long" {
+		if long == nil {
 			return fmt.Errorf("当前无多仓，无法平多")
 		}
 	case ActionCloseShort:
-		if currentPosition.Side != "short" {
+		if short == nil {
 			return fmt.Errorf("当前无空仓，无法平空")
 		}
 	}
@@ -219,9 +431,9 @@ func (tc *TradeCoordinator) validateAction(action TradeAction, currentPosition *
 	return nil
 }
 
-// calculatePositionSize calculates the position size for the trade
-// calculatePositionSize 计算交易的仓位大小
-func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol string, action TradeAction, currentPosition *Position, llmLeverage int, positionSizePercent float64) (float64, error) {
+// calculatePositionSize calculates the position size for the trade, priced at entryPrice (0 = current price)
+// calculatePositionSize 计算交易的仓位大小，按 entryPrice 定价（0 表示当前价格）
+func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol string, action TradeAction, currentPosition *Position, llmLeverage int, positionSizePercent, stopLoss, atr, entryPrice float64) (float64, error) {
 	// For close actions, use the current position size
 	// 平仓动作使用当前持仓大小
 	if action == ActionCloseLong || action == ActionCloseShort {
@@ -243,79 +455,98 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 		return 0, fmt.Errorf("❌ LLM 仓位建议超过 100%% (%.1f%%)，拒绝交易", positionSizePercent)
 	}
 
-	// Get account balance
-	// 获取账户余额
-	balance, err := tc.executor.GetBalance(ctx)
+	// Get the balance of the symbol's margin asset
+	// 获取交易对保证金资产的余额
+	balance, err := tc.executor.GetBalanceFor(ctx, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("获取账户余额失败: %w", err)
 	}
 
-	// Get current price
-	// 获取当前价格
-	currentPrice, err := tc.executor.GetCurrentPrice(ctx, symbol)
-	if err != nil {
-		return 0, fmt.Errorf("获取当前价格失败: %w", err)
-	}
-
-	// Use LLM leverage if provided, otherwise use config default
-	// 如果 LLM 提供了杠杆建议则使用，否则使用配置默认值
-	actualLeverage := llmLeverage
-	if actualLeverage <= 0 {
-		actualLeverage = tc.config.BinanceLeverage
+	// Get current price (stop entries are sized at their trigger price)
+	// 获取当前价格（条件入场单按触发价计算）
+	currentPrice := entryPrice
+	if currentPrice <= 0 {
+		if currentPrice, err = tc.executor.GetCurrentPrice(ctx, symbol); err != nil {
+			return 0, fmt.Errorf("获取当前价格失败: %w", err)
+		}
 	}
 
-	// Calculate position size based on percentage and leverage
-	// 根据百分比和杠杆倍数计算仓位大小
-	// Formula: (Balance × Percentage% × Leverage) / Price = Quantity
-	// 公式：(余额 × 百分比% × 杠杆倍数) / 价格 = 数量
-	fundsToUse := balance * (positionSizePercent / 100.0)
-	leveragedFunds := fundsToUse * float64(actualLeverage)
-	rawSize := leveragedFunds / currentPrice
+	// Calculate position size based on percentage and leverage, shrunk by risk-per-trade sizing
+	// 根据百分比和杠杆倍数计算仓位大小，并按单笔风险缩减
+	// Formula: (Capital × Percentage% × Leverage) / Price = Quantity
+	// 公式：(资金基数 × 百分比% × 杠杆倍数) / 价格 = 数量
+	// Capital is the balance when compounding, SIZING_FIXED_CAPITAL in fixed mode
+	// 复利模式下资金基数为余额，固定模式下为 SIZING_FIXED_CAPITAL
+	plan := PlanPosition(tc.config, balance, currentPrice, positionSizePercent, llmLeverage, stopLoss, atr)
+	capital, fundsToUse, actualLeverage := plan.Capital, plan.Margin, plan.Leverage
+	rawSize := plan.PercentQuantity
 
 	tc.logger.Info(fmt.Sprintf("💰 账户余额: %.2f USDT", balance))
+	if SizingPolicy(tc.config) == SizingPolicyFixed {
+		tc.logger.Info(fmt.Sprintf("📏 仓位策略: 固定资金 %.2f USDT（不随账户增长复利）", capital))
+	} else {
+		tc.logger.Info("📏 仓位策略: 复利（按当前余额计算）")
+	}
 	tc.logger.Info(fmt.Sprintf("📊 LLM 建议: %.1f%% 资金 = %.2f USDT (保证金)", positionSizePercent, fundsToUse))
 	tc.logger.Info(fmt.Sprintf("⚡ 杠杆倍数: %dx", actualLeverage))
 	tc.logger.Info(fmt.Sprintf("💵 当前价格: $%.2f", currentPrice))
 	tc.logger.Info(fmt.Sprintf("📐 计算数量: %.2f USDT × %d倍 / $%.2f = %.4f %s",
 		fundsToUse, actualLeverage, currentPrice, rawSize, symbol))
 
+	// Risk-per-trade sizing: a stop-out loses at most SIZING_RISK_PER_TRADE% of capital
+	// 按单笔风险计算仓位：止损触发时最多亏损资金的 SIZING_RISK_PER_TRADE%
+	if tc.config.SizingRiskPerTrade > 0 {
+		if sizing := plan.Risk; sizing == nil {
+			tc.logger.Warning("⚠️  缺少止损价和 ATR，无法按单笔风险计算仓位，使用 LLM 仓位")
+		} else {
+			source := "止损价"
+			if sizing.FromATR {
+				source = fmt.Sprintf("%.1f × ATR", tc.config.SizingATRMultiplier)
+			}
+			tc.logger.Info(fmt.Sprintf("🎯 单笔风险: %.2f%% 资金 = %.2f USDT，止损距离 $%.2f（%s）→ %.4f %s",
+				tc.config.SizingRiskPerTrade, sizing.RiskAmount, sizing.StopDistance, source, sizing.Quantity, symbol))
+			if plan.Quantity < rawSize {
+				tc.logger.Info(fmt.Sprintf("📉 按风险缩减仓位: %.4f → %.4f", rawSize, plan.Quantity))
+			}
+		}
+	}
+	rawSize = plan.Quantity
+
 	// Adjust quantity to meet symbol's precision and minimum quantity requirements
 	// 调整数量以符合交易对的精度和最小数量要求
-	adjustedSize, err := AdjustQuantityPrecision(symbol, rawSize)
+	adjustedSize, err := tc.executor.AdjustQuantityPrecision(ctx, symbol, rawSize)
 	if err != nil {
 		return 0, fmt.Errorf("精度调整失败: %w", err)
 	}
 
 	tc.logger.Info(fmt.Sprintf("原始数量: %.4f → 调整后: %.4f (符合 %s 精度要求)", rawSize, adjustedSize, symbol))
 
-	// Check minimum notional value (Binance requires ≥ $100 USDT)
-	// 检查最小订单价值（币安要求 ≥ $100 USDT）
+	// Check minimum notional value (MIN_NOTIONAL filter from exchangeInfo)
+	// 检查最小订单价值（来自 exchangeInfo 的 MIN_NOTIONAL 规则）
 	notionalValue := adjustedSize * currentPrice
-	minNotional := 100.0
-
-	if notionalValue < minNotional {
-		return 0, fmt.Errorf(`
-❌ 订单价值不足: $%.2f < $%.2f (币安最小要求)
-
-原因分析：
-- LLM 建议仓位: %.1f%% 资金 = $%.2f 保证金
-- 杠杆倍数: %dx
-- 订单价值: $%.2f × %d = $%.2f
-- 精度调整: %.4f → %.4f (导致订单价值降低)
-
-解决方案：
-1. 增加仓位百分比至至少 %.1f%% (推荐)
-2. 或选择 HOLD 等待更好的机会
+	minNotional := tc.executor.SymbolFiltersFor(ctx, symbol).MinNotional
+
+	// Orders below the minimum (plus SIZING_MIN_NOTIONAL_BUFFER) would be rejected by the exchange
+	// 低于最小值（加 SIZING_MIN_NOTIONAL_BUFFER 缓冲）的订单会被交易所拒绝
+	shortfall := CheckMinNotional(tc.config, symbol, notionalValue, minNotional, plan, positionSizePercent)
+
+	// Small accounts round a nearly large enough order up, as long as the margin covers it
+	// 小账户在订单价值接近要求且保证金足够时向上取整
+	if shortfall != nil && tc.config.IsSmallAccount(balance) {
+		stepSize := tc.executor.SymbolFiltersFor(ctx, symbol).StepSize
+		if rounded, ok := RoundUpToMinNotional(adjustedSize, currentPrice, shortfall.Required, stepSize, tc.config.SmallAccountNotionalTolerance); ok &&
+			rounded*currentPrice/float64(actualLeverage) <= balance {
+			tc.logger.Info(fmt.Sprintf("💰 小账户模式：数量 %.4f → %.4f，订单价值 $%.2f → $%.2f（满足最小要求 $%.2f）",
+				adjustedSize, rounded, notionalValue, rounded*currentPrice, shortfall.Required))
+			adjustedSize, notionalValue, shortfall = rounded, rounded*currentPrice, nil
+		}
+	}
 
-💡 提示: 当前余额 $%.2f 在 %dx 杠杆下，最小仓位约需 %.1f%%`,
-			notionalValue, minNotional,
-			positionSizePercent, fundsToUse,
-			actualLeverage,
-			adjustedSize, actualLeverage, notionalValue,
-			rawSize, adjustedSize,
-			(minNotional/float64(actualLeverage)/balance)*100,
-			balance, actualLeverage,
-			(minNotional/float64(actualLeverage)/balance)*100)
+	if shortfall != nil {
+		if adjustedSize < rawSize {
+			tc.logger.Info(fmt.Sprintf("精度调整降低了订单价值: %.4f → %.4f", rawSize, adjustedSize))
+		}
+		return 0, shortfall
 	}
 
 	tc.logger.Success(fmt.Sprintf("✅ 订单价值: $%.2f ≥ $%.2f (符合要求)", notionalValue, minNotional))
@@ -323,16 +554,40 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 	return adjustedSize, nil
 }
 
+// smallAccountLeverage floors the leverage of an entry in small-account mode (unchanged otherwise)
+// smallAccountLeverage 在小账户模式下为开仓杠杆设置下限（否则保持不变）
+func (tc *TradeCoordinator) smallAccountLeverage(ctx context.Context, leverage int) int {
+	if tc.config.SmallAccountEquity <= 0 {
+		return leverage
+	}
+	balance, err := tc.executor.GetBalance(ctx)
+	if err != nil || !tc.config.IsSmallAccount(balance) {
+		return leverage
+	}
+
+	current := leverage
+	if current <= 0 {
+		current = tc.config.BinanceLeverage
+	}
+	floored := SmallAccountLeverage(tc.config, leverage)
+	if floored == current {
+		return leverage
+	}
+	tc.logger.Info(fmt.Sprintf("💰 小账户模式（余额 %.2f < %.2f USDT）：杠杆 %dx → %dx",
+		balance, tc.config.SmallAccountEquity, current, floored))
+	return floored
+}
+
 // postExecutionVerification verifies the trade was executed correctly
 // postExecutionVerification 验证交易是否正确执行
-func (tc *TradeCoordinator) postExecutionVerification(ctx context.Context, symbol string, action TradeAction, result *TradeResult) error {
-	// Wait a moment for the order to be processed
+func (tc *TradeCoordinator) postExecutionVerification(ctx context.Context, symbol string, action TradeAction, result *TradeResult, sentAt time.Time) error {
+	// Wait for the order to be processed
 	// 等待订单处理
-	time.Sleep(2 * time.Second)
+	tc.executor.awaitPositionUpdate(ctx, symbol, sentAt)
 
-	// Get updated position
-	// 获取更新后的持仓
-	newPosition, err := tc.executor.GetCurrentPosition(ctx, symbol)
+	// Get updated position on the side of the action (the other side may be held in hedge mode)
+	// 获取动作对应方向更新后的持仓（双向持仓模式下另一方向可能仍有持仓）
+	newPosition, err := tc.executor.GetCurrentPositionSide(ctx, symbol, ActionSide(action))
 	if err != nil {
 		return fmt.Errorf("无法获取更新后的持仓: %w", err)
 	}
@@ -372,13 +627,15 @@ func (tc *TradeCoordinator) GetExecutionSummary(result *TradeResult) string {
 
 	if result.Success {
 		summary += "✅ 执行状态: 成功\n"
+	} else if result.Skipped {
+		summary += "⏭️  执行状态: 已跳过\n"
 	} else {
 		summary += "❌ 执行状态: 失败\n"
 	}
 
 	summary += fmt.Sprintf("交易对: %s\n", result.Symbol)
 	summary += fmt.Sprintf("动作: %s\n", result.Action)
-	summary += fmt.Sprintf("数量: %.4f\n", result.Amount)
+	summary += fmt.Sprintf("数量: %s\n", format.Adaptive(result.Amount))
 	summary += fmt.Sprintf("时间: %s\n", result.Timestamp)
 	summary += fmt.Sprintf("理由: %s\n", result.Reason)
 
@@ -390,12 +647,16 @@ func (tc *TradeCoordinator) GetExecutionSummary(result *TradeResult) string {
 		summary += fmt.Sprintf("\n订单ID: %s\n", result.OrderID)
 	}
 
+	if result.Fee != 0 || result.Slippage != 0 {
+		summary += fmt.Sprintf("交易成本: 手续费 %s USDT，滑点 %s USDT\n", format.Adaptive(result.Fee), format.Signed(result.Slippage, 4))
+	}
+
 	if result.NewPosition != nil {
 		summary += "\n当前持仓:\n"
 		summary += fmt.Sprintf("  方向: %s\n", result.NewPosition.Side)
-		summary += fmt.Sprintf("  数量: %.4f\n", result.NewPosition.Size)
-		summary += fmt.Sprintf("  入场价: $%.2f\n", result.NewPosition.EntryPrice)
-		summary += fmt.Sprintf("  未实现盈亏: %+.2f USDT\n", result.NewPosition.UnrealizedPnL)
+		summary += fmt.Sprintf("  数量: %s\n", format.Adaptive(result.NewPosition.Size))
+		summary += fmt.Sprintf("  入场价: $%s\n", format.Adaptive(result.NewPosition.EntryPrice))
+		summary += fmt.Sprintf("  未实现盈亏: %s USDT\n", format.Signed(result.NewPosition.UnrealizedPnL, 2))
 	}
 
 	summary += "\n" + result.Message + "\n"
@@ -0,0 +1,473 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// defaultEntryStopLossPercent is the initial stop-loss distance used when a filled entry has none
+// defaultEntryStopLossPercent 是条件入场单成交后未指定止损时使用的默认止损距离
+const defaultEntryStopLossPercent = 2.5
+
+// entryMu serializes pending entry changes across coordinators (trading loop, dashboard, background watcher)
+// so a fill is never registered twice
+// entryMu 在多个协调器（交易循环、控制台、后台检查）之间串行化条件入场单的变更，避免重复登记成交
+var entryMu sync.Mutex
+
+// EntryAction returns the market action a stop entry opens with (BUY_STOP → BUY, SELL_STOP → SELL), "" otherwise
+// EntryAction 返回条件入场单对应的开仓动作（BUY_STOP → BUY，SELL_STOP → SELL），其他动作返回空
+func EntryAction(action TradeAction) TradeAction {
+	switch action {
+	case ActionBuyStop:
+		return ActionBuy
+	case ActionSellStop:
+		return ActionSell
+	default:
+		return ""
+	}
+}
+
+// IsStopEntry reports whether the action is a conditional stop entry
+// IsStopEntry 返回动作是否为条件入场单
+func IsStopEntry(action TradeAction) bool {
+	return EntryAction(action) != ""
+}
+
+// IsOpening reports whether the action opens a new position, at market or with a stop entry
+// IsOpening 返回动作是否开新仓（市价开仓或条件入场单）
+func IsOpening(action TradeAction) bool {
+	return action == ActionBuy || action == ActionSell || IsStopEntry(action)
+}
+
+// entryTriggered reports whether the price has broken the trigger of an entry
+// entryTriggered 返回价格是否已突破条件入场单的触发价
+func entryTriggered(entry *storage.PendingEntry, price float64) bool {
+	if entry.Side == "short" {
+		return price <= entry.TriggerPrice
+	}
+	return price >= entry.TriggerPrice
+}
+
+// PlaceStopEntryOrder places a STOP_MARKET order that opens a position when the price reaches triggerPrice
+// PlaceStopEntryOrder 下一个价格到达 triggerPrice 时开仓的 STOP_MARKET 订单
+//
+// In test mode no order is sent and the empty order ID means the trigger is watched locally.
+// 测试模式下不实际下单，返回空订单 ID，由程序在本地检查触发。
+func (e *BinanceExecutor) PlaceStopEntryOrder(ctx context.Context, symbol string, action TradeAction, quantity, triggerPrice float64) (string, error) {
+	if e.InMaintenance() {
+		return "", fmt.Errorf("交易所维护中，暂停下单")
+	}
+	if e.KillSwitchEngaged() {
+		return "", fmt.Errorf("急停开关已启用，暂停开仓")
+	}
+
+	side := futures.SideTypeBuy
+	positionSide := futures.PositionSideTypeLong
+	if EntryAction(action) == ActionSell {
+		side = futures.SideTypeSell
+		positionSide = futures.PositionSideTypeShort
+	}
+
+	if e.paper != nil {
+		orderID, err := e.paper.PlaceStopEntryOrder(ctx, symbol, side, triggerPrice, quantity)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d", orderID), nil
+	}
+
+	if e.testMode {
+		e.logger.Warning("测试模式 - 条件入场单仅在本地跟踪，不实际下单")
+		return "", nil
+	}
+
+	e.DetectPositionMode(ctx)
+	if e.positionMode == PositionModeOneWay {
+		positionSide = futures.PositionSideTypeBoth
+	}
+
+	order, err := e.client.NewCreateOrderService().
+		Symbol(e.config.GetBinanceSymbolFor(symbol)).
+		Side(side).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeStopMarket).
+		StopPrice(e.SymbolFiltersFor(ctx, symbol).FormatPrice(triggerPrice)).
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(quantity)).
+		Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d", order.OrderID), nil
+}
+
+// GetEntryOrder queries a stop entry order
+// GetEntryOrder 查询条件入场单
+func (e *BinanceExecutor) GetEntryOrder(ctx context.Context, symbol, orderID string) (*futures.Order, error) {
+	if e.paper != nil {
+		return e.paper.GetOrder(ctx, symbol, parseInt64(orderID))
+	}
+	return e.client.NewGetOrderService().
+		Symbol(e.config.GetBinanceSymbolFor(symbol)).
+		OrderID(parseInt64(orderID)).
+		Do(ctx)
+}
+
+// CancelEntryOrder cancels a stop entry order (no-op for locally watched entries)
+// CancelEntryOrder 撤销条件入场单（本地跟踪的入场单无需撤销）
+func (e *BinanceExecutor) CancelEntryOrder(ctx context.Context, symbol, orderID string) error {
+	if orderID == "" {
+		return nil
+	}
+	if e.paper != nil {
+		return e.paper.CancelOrder(symbol, parseInt64(orderID))
+	}
+	_, err := e.client.NewCancelOrderService().
+		Symbol(e.config.GetBinanceSymbolFor(symbol)).
+		OrderID(parseInt64(orderID)).
+		Do(ctx)
+	return err
+}
+
+// PlaceStopEntry places a conditional entry (BUY_STOP / SELL_STOP) that opens a position when the price breaks triggerPrice
+// PlaceStopEntry 挂条件入场单（BUY_STOP / SELL_STOP），价格突破 triggerPrice 时开仓
+//
+// The order is sized at the trigger price and expires after STOP_ENTRY_EXPIRY_MINUTES. A new entry replaces
+// the pending one of the same symbol. Pending entries are tracked apart from open positions until they fill.
+// 订单按触发价计算仓位，STOP_ENTRY_EXPIRY_MINUTES 分钟后过期。同一交易对的新入场单会替换旧单。
+// 条件入场单在成交前与持仓分开跟踪。
+func (tc *TradeCoordinator) PlaceStopEntry(ctx context.Context, symbol string, action TradeAction, triggerPrice float64, reason string, leverage int, positionSizePercent, stopLoss, atr float64) (*storage.PendingEntry, error) {
+	tc.logger.Header("条件入场单", '=', 80)
+	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
+	tc.logger.Info(fmt.Sprintf("动作: %s @ $%.2f", action, triggerPrice))
+	tc.logger.Info(fmt.Sprintf("理由: %s", reason))
+
+	if !IsStopEntry(action) {
+		return nil, fmt.Errorf("%s 不是条件入场动作", action)
+	}
+	if tc.stopLossManager == nil || tc.stopLossManager.storage == nil {
+		return nil, fmt.Errorf("止损管理器或数据库未初始化，无法跟踪条件入场单")
+	}
+	if triggerPrice <= 0 {
+		return nil, fmt.Errorf("条件入场单缺少触发价格")
+	}
+
+	hookEvent := &plugins.ExecuteEvent{
+		Symbol:              symbol,
+		Action:              string(action),
+		Reason:              reason,
+		Leverage:            leverage,
+		PositionSizePercent: positionSizePercent,
+		StopLoss:            stopLoss,
+	}
+	if err := plugins.BeforeExecute(ctx, hookEvent); err != nil {
+		return nil, fmt.Errorf("插件否决了条件入场单: %w", err)
+	}
+	if err := tc.consultRiskOracle(ctx, hookEvent); err != nil {
+		return nil, err
+	}
+	leverage, positionSizePercent, stopLoss = hookEvent.Leverage, hookEvent.PositionSizePercent, hookEvent.StopLoss
+
+	entryMu.Lock()
+	defer entryMu.Unlock()
+
+	side := "long"
+	if EntryAction(action) == ActionSell {
+		side = "short"
+	}
+
+	// The trigger must not be hit already, and the stop must sit on the losing side of it
+	// 触发价不能已被突破，止损必须位于触发价的亏损一侧
+	currentPrice, err := tc.executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前价格失败: %w", err)
+	}
+	if side == "long" && triggerPrice <= currentPrice {
+		return nil, fmt.Errorf("BUY_STOP 触发价 %.2f 必须高于当前价 %.2f", triggerPrice, currentPrice)
+	}
+	if side == "short" && triggerPrice >= currentPrice {
+		return nil, fmt.Errorf("SELL_STOP 触发价 %.2f 必须低于当前价 %.2f", triggerPrice, currentPrice)
+	}
+	if stopLoss > 0 && ((side == "long" && stopLoss >= triggerPrice) || (side == "short" && stopLoss <= triggerPrice)) {
+		return nil, fmt.Errorf("止损价 %.2f 与 %s 触发价 %.2f 方向不符", stopLoss, action, triggerPrice)
+	}
+
+	if position, err := tc.executor.GetCurrentPosition(ctx, symbol); err == nil && position != nil {
+		return nil, fmt.Errorf("已有 %s 持仓，不挂条件入场单", position.Side)
+	}
+
+	if err := tc.checkFundingCost(ctx, symbol, EntryAction(action)); err != nil {
+		return nil, err
+	}
+
+	if _, err := tc.cancelPendingEntries(ctx, symbol, "被新的条件入场单替换"); err != nil {
+		return nil, err
+	}
+
+	leverage = tc.smallAccountLeverage(ctx, leverage)
+	if leverage > 0 && !tc.config.ShadowMode {
+		if err := tc.executor.SetupExchange(ctx, symbol, leverage); err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  更新杠杆失败: %v，使用当前杠杆继续", err))
+		}
+	} else if leverage <= 0 {
+		leverage = tc.config.BinanceLeverage
+	}
+
+	quantity, err := tc.calculatePositionSize(ctx, symbol, EntryAction(action), nil, leverage, positionSizePercent, stopLoss, atr, triggerPrice)
+	if err != nil {
+		return nil, fmt.Errorf("position size calculation failed: %w", err)
+	}
+
+	// Shadow mode: record the entry instead of placing it; the returned entry is not tracked
+	// 影子模式：记录入场单而不是挂单；返回的入场单不会被跟踪
+	if tc.config.ShadowMode {
+		if _, err := tc.recordShadowOrder(ctx, symbol, action, quantity, leverage, triggerPrice, stopLoss, reason); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		return &storage.PendingEntry{
+			Symbol:       tc.config.GetBinanceSymbolFor(symbol),
+			Side:         side,
+			TriggerPrice: triggerPrice,
+			Quantity:     quantity,
+			Leverage:     leverage,
+			StopLoss:     stopLoss,
+			Reason:       reason,
+			Status:       storage.EntryStatusShadow,
+			CreatedAt:    now,
+			ExpiresAt:    now.Add(time.Duration(tc.config.StopEntryExpiryMinutes) * time.Minute),
+		}, nil
+	}
+
+	orderID, err := tc.executor.PlaceStopEntryOrder(ctx, symbol, action, quantity, triggerPrice)
+	if err != nil {
+		return nil, fmt.Errorf("下条件入场单失败: %w", err)
+	}
+
+	now := time.Now()
+	entry := &storage.PendingEntry{
+		Symbol:       tc.config.GetBinanceSymbolFor(symbol),
+		Side:         side,
+		TriggerPrice: triggerPrice,
+		Quantity:     quantity,
+		Leverage:     leverage,
+		StopLoss:     stopLoss,
+		ATR:          atr,
+		OrderID:      orderID,
+		Reason:       reason,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(time.Duration(tc.config.StopEntryExpiryMinutes) * time.Minute),
+	}
+	if _, err := tc.stopLossManager.storage.SavePendingEntry(entry); err != nil {
+		// The order is live but untracked: cancel it rather than leave an orphan entry
+		// 订单已挂出但无法跟踪：撤销订单，避免留下无人管理的入场单
+		if cancelErr := tc.executor.CancelEntryOrder(ctx, symbol, orderID); cancelErr != nil {
+			tc.logger.Error(fmt.Sprintf("❌ 撤销未记录的条件入场单 %s 失败: %v", orderID, cancelErr))
+		}
+		return nil, err
+	}
+
+	tc.logger.Success(fmt.Sprintf("✅ 条件入场单已挂出: %s %.4f %s @ $%.2f（订单ID: %s，%s 过期）",
+		action, quantity, symbol, triggerPrice, orderID, entry.ExpiresAt.Format("01-02 15:04")))
+	return entry, nil
+}
+
+// CancelPendingEntries cancels the pending entries of a symbol, returning how many were cancelled
+// CancelPendingEntries 撤销交易对的条件入场单，返回撤销数量
+func (tc *TradeCoordinator) CancelPendingEntries(ctx context.Context, symbol, reason string) (int, error) {
+	if tc.stopLossManager == nil || tc.stopLossManager.storage == nil {
+		return 0, nil
+	}
+	entryMu.Lock()
+	defer entryMu.Unlock()
+	return tc.cancelPendingEntries(ctx, symbol, reason)
+}
+
+// cancelPendingEntries cancels the pending entries of a symbol (caller holds entryMu)
+// cancelPendingEntries 撤销交易对的条件入场单（调用方需持有 entryMu）
+func (tc *TradeCoordinator) cancelPendingEntries(ctx context.Context, symbol, reason string) (int, error) {
+	entries, err := tc.stopLossManager.storage.GetPendingEntries(tc.config.GetBinanceSymbolFor(symbol))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if err := tc.executor.CancelEntryOrder(ctx, entry.Symbol, entry.OrderID); err != nil && !isUnknownOrder(err) {
+			return 0, fmt.Errorf("撤销条件入场单 %s 失败: %w", entry.OrderID, err)
+		}
+		if err := tc.resolveEntry(entry, storage.EntryStatusCancelled); err != nil {
+			return 0, err
+		}
+		tc.logger.Info(fmt.Sprintf("🗑️  【%s】条件入场单 #%d 已撤销: %s", entry.Symbol, entry.ID, reason))
+	}
+	return len(entries), nil
+}
+
+// SyncPendingEntries checks every pending entry: fills open a managed position with its initial stop,
+// expired entries are cancelled. Returns the entries that filled.
+// SyncPendingEntries 检查所有条件入场单：成交的入场单开仓并下初始止损，过期的入场单被撤销。返回已成交的入场单。
+func (tc *TradeCoordinator) SyncPendingEntries(ctx context.Context) ([]*storage.PendingEntry, error) {
+	if tc.stopLossManager == nil || tc.stopLossManager.storage == nil {
+		return nil, nil
+	}
+	entryMu.Lock()
+	defer entryMu.Unlock()
+
+	entries, err := tc.stopLossManager.storage.GetPendingEntries("")
+	if err != nil {
+		return nil, err
+	}
+
+	var filled []*storage.PendingEntry
+	for _, entry := range entries {
+		ok, err := tc.syncEntry(ctx, entry)
+		if err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  【%s】检查条件入场单 #%d 失败: %v", entry.Symbol, entry.ID, err))
+			continue
+		}
+		if ok {
+			filled = append(filled, entry)
+		}
+	}
+	return filled, nil
+}
+
+// syncEntry checks one pending entry, returning true when it filled
+// syncEntry 检查单个条件入场单，成交时返回 true
+func (tc *TradeCoordinator) syncEntry(ctx context.Context, entry *storage.PendingEntry) (bool, error) {
+	expired := time.Now().After(entry.ExpiresAt)
+
+	// Locally watched entry (test mode): fill at the current price once the trigger is broken
+	// 本地跟踪的入场单（测试模式）：价格突破触发价时按当前价成交
+	if entry.OrderID == "" {
+		price, err := tc.executor.GetCurrentPrice(ctx, entry.Symbol)
+		if err != nil {
+			return false, err
+		}
+		if entryTriggered(entry, price) {
+			return true, tc.fillEntry(ctx, entry, price, entry.Quantity)
+		}
+		if expired {
+			tc.logger.Info(fmt.Sprintf("⌛ 【%s】条件入场单 #%d 已过期未触发", entry.Symbol, entry.ID))
+			return false, tc.resolveEntry(entry, storage.EntryStatusExpired)
+		}
+		return false, nil
+	}
+
+	order, err := tc.executor.GetEntryOrder(ctx, entry.Symbol, entry.OrderID)
+	if err != nil {
+		if isUnknownOrder(err) {
+			tc.logger.Warning(fmt.Sprintf("⚠️  【%s】条件入场单 %s 已不存在", entry.Symbol, entry.OrderID))
+			return false, tc.resolveEntry(entry, storage.EntryStatusCancelled)
+		}
+		return false, err
+	}
+
+	executedQty, _ := parseFloat(order.ExecutedQuantity)
+	switch order.Status {
+	case futures.OrderStatusTypeFilled:
+		return true, tc.fillOrder(ctx, entry, order, executedQty)
+	case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired, futures.OrderStatusTypeRejected:
+		status := storage.EntryStatusCancelled
+		if order.Status == futures.OrderStatusTypeExpired {
+			status = storage.EntryStatusExpired
+		}
+		tc.logger.Warning(fmt.Sprintf("⚠️  【%s】条件入场单 %s 在交易所已%s", entry.Symbol, entry.OrderID, order.Status))
+		if executedQty > 0 {
+			return true, tc.fillOrder(ctx, entry, order, executedQty)
+		}
+		return false, tc.resolveEntry(entry, status)
+	}
+
+	if !expired {
+		return false, nil
+	}
+
+	// Expired: cancel the rest of the order; a partial fill still becomes a position
+	// 已过期：撤销剩余订单；部分成交的数量仍作为持仓管理
+	if err := tc.executor.CancelEntryOrder(ctx, entry.Symbol, entry.OrderID); err != nil && !isUnknownOrder(err) {
+		return false, fmt.Errorf("撤销过期条件入场单失败: %w", err)
+	}
+	tc.logger.Info(fmt.Sprintf("⌛ 【%s】条件入场单 #%d 已过期，订单已撤销", entry.Symbol, entry.ID))
+	if executedQty > 0 {
+		return true, tc.fillOrder(ctx, entry, order, executedQty)
+	}
+	return false, tc.resolveEntry(entry, storage.EntryStatusExpired)
+}
+
+// fillOrder records the fills of an exchange entry order and opens the position
+// fillOrder 记录交易所入场单的成交明细并开仓
+func (tc *TradeCoordinator) fillOrder(ctx context.Context, entry *storage.PendingEntry, order *futures.Order, quantity float64) error {
+	fillPrice, _ := parseFloat(order.AvgPrice)
+	if fillPrice == 0 {
+		fillPrice = entry.TriggerPrice
+	}
+	tc.executor.recordOrderFills(ctx, entry.Symbol, order.OrderID, order.Type, order.Side, quantity, fillPrice)
+	return tc.fillEntry(ctx, entry, fillPrice, quantity)
+}
+
+// fillEntry registers the position opened by an entry, saves it and places its initial stop-loss
+// fillEntry 注册条件入场单开出的持仓，保存到数据库并下初始止损单
+func (tc *TradeCoordinator) fillEntry(ctx context.Context, entry *storage.PendingEntry, fillPrice, quantity float64) error {
+	stopLoss := entry.StopLoss
+	if stopLoss == 0 {
+		if entry.Side == "long" {
+			stopLoss = fillPrice * (1 - defaultEntryStopLossPercent/100)
+		} else {
+			stopLoss = fillPrice * (1 + defaultEntryStopLossPercent/100)
+		}
+	}
+
+	now := time.Now()
+	position := &Position{
+		ID:              fmt.Sprintf("%s-%d", entry.Symbol, now.Unix()),
+		Symbol:          entry.Symbol,
+		Side:            entry.Side,
+		EntryPrice:      fillPrice,
+		EntryTime:       now,
+		Quantity:        quantity,
+		Leverage:        entry.Leverage,
+		InitialStopLoss: stopLoss,
+		CurrentStopLoss: stopLoss,
+		StopLossType:    "fixed",
+		OpenReason:      fmt.Sprintf("条件入场 @ %.2f: %s", entry.TriggerPrice, entry.Reason),
+		ATR:             entry.ATR,
+	}
+	tc.stopLossManager.RegisterPosition(position)
+
+	if err := tc.stopLossManager.storage.SavePosition(position.Record()); err != nil {
+		tc.logger.Warning(fmt.Sprintf("⚠️  保存 %s 持仓到数据库失败: %v", entry.Symbol, err))
+	}
+
+	tc.logger.Success(fmt.Sprintf("🎯 【%s】条件入场单 #%d 已触发: %s %.4f @ $%.2f",
+		entry.Symbol, entry.ID, entry.Side, quantity, fillPrice))
+	if err := tc.stopLossManager.PlaceInitialStopLoss(ctx, position); err != nil {
+		tc.logger.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", err))
+	}
+
+	entry.FillPrice = fillPrice
+	entry.Quantity = quantity
+	entry.PositionID = position.ID
+	return tc.resolveEntry(entry, storage.EntryStatusFilled)
+}
+
+// resolveEntry marks an entry as no longer pending
+// resolveEntry 将条件入场单标记为已结束
+func (tc *TradeCoordinator) resolveEntry(entry *storage.PendingEntry, status string) error {
+	entry.Status = status
+	entry.ResolvedAt = time.Now()
+	return tc.stopLossManager.storage.UpdatePendingEntry(entry)
+}
+
+// isUnknownOrder reports whether Binance says the order does not exist
+// isUnknownOrder 判断币安是否返回订单不存在
+func isUnknownOrder(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Unknown order") || strings.Contains(msg, "Order does not exist") || strings.Contains(msg, "-2011")
+}
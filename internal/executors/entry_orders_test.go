@@ -0,0 +1,86 @@
+package executors
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestEntryAction tests the mapping of stop entries to their opening action
+// TestEntryAction 测试条件入场单到开仓动作的映射
+func TestEntryAction(t *testing.T) {
+	tests := map[TradeAction]TradeAction{
+		ActionBuyStop:   ActionBuy,
+		ActionSellStop:  ActionSell,
+		ActionBuy:       "",
+		ActionCloseLong: "",
+		ActionHold:      "",
+	}
+	for action, want := range tests {
+		if got := EntryAction(action); got != want {
+			t.Errorf("EntryAction(%s) = %q, want %q", action, got, want)
+		}
+		if IsStopEntry(action) != (want != "") {
+			t.Errorf("IsStopEntry(%s) = %v", action, IsStopEntry(action))
+		}
+	}
+
+	long := &storage.PendingEntry{Side: "long", TriggerPrice: 100}
+	short := &storage.PendingEntry{Side: "short", TriggerPrice: 100}
+	if entryTriggered(long, 99.9) || !entryTriggered(long, 100) || !entryTriggered(long, 101) {
+		t.Error("Long entries trigger at or above the trigger price")
+	}
+	if entryTriggered(short, 100.1) || !entryTriggered(short, 100) || !entryTriggered(short, 99) {
+		t.Error("Short entries trigger at or below the trigger price")
+	}
+}
+
+// TestCancelPendingEntries tests that cancelling resolves only the entries of the symbol
+// TestCancelPendingEntries 测试撤销只影响该交易对的条件入场单
+func TestCancelPendingEntries(t *testing.T) {
+	tmpDB := "./test_entry_orders.db"
+	defer os.Remove(tmpDB)
+
+	db, err := storage.NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{}
+	log := logger.NewColorLogger(false)
+	e := &BinanceExecutor{config: cfg, testMode: true, logger: log}
+	tc := NewTradeCoordinator(cfg, e, log, NewStopLossManager(cfg, e, log, db))
+
+	// Locally watched entries (test mode) have no exchange order to cancel
+	// 本地跟踪的入场单（测试模式）无需撤销交易所订单
+	now := time.Now()
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		if _, err := db.SavePendingEntry(&storage.PendingEntry{
+			Symbol: symbol, Side: "long", TriggerPrice: 100, Quantity: 1, ExpiresAt: now.Add(time.Hour),
+		}); err != nil {
+			t.Fatalf("SavePendingEntry failed: %v", err)
+		}
+	}
+
+	n, err := tc.CancelPendingEntries(context.Background(), "BTCUSDT", "test")
+	if err != nil || n != 1 {
+		t.Fatalf("CancelPendingEntries = %d, %v; want 1, nil", n, err)
+	}
+
+	pending, _ := db.GetPendingEntries("")
+	if len(pending) != 1 || pending[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected only the ETHUSDT entry to stay pending, got %+v", pending)
+	}
+	recent, _ := db.GetRecentEntries(10)
+	for _, entry := range recent {
+		if entry.Symbol == "BTCUSDT" && (entry.Status != storage.EntryStatusCancelled || entry.ResolvedAt.IsZero()) {
+			t.Errorf("Expected BTCUSDT entry to be cancelled, got %+v", entry)
+		}
+	}
+}
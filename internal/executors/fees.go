@@ -0,0 +1,75 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// feeWindowSlack widens the fill window of a position backwards, as the entry fill is recorded
+// just before the position is registered
+// feeWindowSlack 向前扩展持仓的成交时间窗口，因为开仓成交记录早于持仓注册
+const feeWindowSlack = time.Minute
+
+// modelTestFill applies the paper trading fee and slippage model to a fake fill of test mode
+// modelTestFill 对测试模式的模拟成交应用模拟盘的手续费和滑点模型
+//
+// The fill price is moved against the taker by PAPER_SLIPPAGE_BPS and charged PAPER_TAKER_FEE_RATE,
+// so test mode results carry the same costs a paper fill without book depth would.
+// 成交价按 PAPER_SLIPPAGE_BPS 向吃单方不利的方向调整，并按 PAPER_TAKER_FEE_RATE 收取手续费，
+// 使测试模式的结果与无盘口深度时的模拟盘成交成本一致。
+func (e *BinanceExecutor) modelTestFill(result *TradeResult, price float64) {
+	if price <= 0 || result.Filled <= 0 {
+		return
+	}
+	fillPrice, fee, slippage := modelFill(result.Action, price, result.Filled, e.config.PaperSlippageBps, e.config.PaperTakerFeeRate)
+	result.Price = fillPrice
+	result.Fee = fee
+	result.Slippage = slippage
+}
+
+// modelFill returns the fill price, fee and slippage cost of a market order of action at price
+// modelFill 返回按 price 执行 action 市价单的成交价、手续费和滑点成本
+func modelFill(action TradeAction, price, quantity, slippageBps, feeRate float64) (float64, float64, float64) {
+	var fillPrice float64
+	switch action {
+	case ActionBuy, ActionAddLong, ActionReduceShort, ActionCloseShort:
+		fillPrice = price * (1 + slippageBps/10000)
+	case ActionSell, ActionAddShort, ActionReduceLong, ActionCloseLong:
+		fillPrice = price * (1 - slippageBps/10000)
+	default:
+		return price, 0, 0
+	}
+	return fillPrice, fillPrice * quantity * feeRate, math.Abs(fillPrice-price) * quantity
+}
+
+// FeesPaid returns the trading fees a position paid from its entry to closing at closePrice
+// FeesPaid 返回持仓从开仓到以 closePrice 平仓所支付的手续费
+//
+// Live and paper trading sum the fees of the recorded fills of the symbol since the entry, partial
+// closes and stop fills included. Test mode models the entry and the close at PAPER_TAKER_FEE_RATE.
+// 实盘和模拟盘汇总开仓以来该交易对成交记录中的手续费（含分批平仓和止损成交）。
+// 测试模式按 PAPER_TAKER_FEE_RATE 估算开仓和平仓的手续费。
+func (e *BinanceExecutor) FeesPaid(pos *Position, closePrice float64) (float64, error) {
+	quantity := pos.Quantity
+	if quantity == 0 {
+		quantity = pos.Size
+	}
+
+	if e.paper == nil && e.testMode {
+		return quantity * (pos.EntryPrice + closePrice) * e.config.PaperTakerFeeRate, nil
+	}
+
+	db := e.trades
+	if e.paper != nil {
+		db = e.paper.storage
+	}
+	if db == nil {
+		return 0, nil
+	}
+	fees, err := db.SumTradeFees(e.config.GetBinanceSymbolFor(pos.Symbol), pos.EntryTime.Add(-feeWindowSlack))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trading fees: %w", err)
+	}
+	return fees, nil
+}
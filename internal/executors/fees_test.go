@@ -0,0 +1,33 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+// TestModelFill tests the modeled fill price, fee and slippage of test mode orders
+// TestModelFill 测试测试模式订单的模拟成交价、手续费和滑点
+func TestModelFill(t *testing.T) {
+	tests := []struct {
+		action   TradeAction
+		price    float64
+		fee      float64
+		slippage float64
+	}{
+		{ActionBuy, 100.1, 0.4004, 1},
+		{ActionCloseShort, 100.1, 0.4004, 1},
+		{ActionAddLong, 100.1, 0.4004, 1},
+		{ActionSell, 99.9, 0.3996, 1},
+		{ActionCloseLong, 99.9, 0.3996, 1},
+		{ActionReduceLong, 99.9, 0.3996, 1},
+		{ActionHold, 100, 0, 0},
+	}
+
+	for _, tt := range tests {
+		price, fee, slippage := modelFill(tt.action, 100, 10, 10, 0.0004)
+		if math.Abs(price-tt.price) > 1e-9 || math.Abs(fee-tt.fee) > 1e-9 || math.Abs(slippage-tt.slippage) > 1e-9 {
+			t.Errorf("%s: got price %.4f, fee %.4f, slippage %.4f; want %.4f, %.4f, %.4f",
+				tt.action, price, fee, slippage, tt.price, tt.fee, tt.slippage)
+		}
+	}
+}
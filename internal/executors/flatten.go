@@ -0,0 +1,211 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ClosedPosition is a position closed by CloseAllPositions
+// ClosedPosition 是 CloseAllPositions 平掉的持仓
+type ClosedPosition struct {
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side"`
+	Quantity    float64 `json:"quantity"`
+	Price       float64 `json:"price"`        // 平仓成交价 / Close fill price
+	RealizedPnL float64 `json:"realized_pnl"` // 已实现盈亏（USDT）/ Realized P&L (USDT)
+}
+
+// FlattenReport is the outcome of CloseAllPositions
+// FlattenReport 是 CloseAllPositions 的执行结果
+type FlattenReport struct {
+	Closed           []ClosedPosition `json:"closed"`
+	CancelledEntries int              `json:"cancelled_entries"` // 撤销的条件入场单 / Pending stop entries cancelled
+	CancelledStops   int              `json:"cancelled_stops"`   // 撤销的止损/止盈单 / Stop-loss and take-profit orders cancelled
+	Errors           []string         `json:"errors,omitempty"`
+}
+
+// Lines formats the report one line per action, for logs and notifications
+// Lines 将结果按每个动作一行格式化，用于日志和通知
+func (r *FlattenReport) Lines() []string {
+	var lines []string
+	for _, c := range r.Closed {
+		lines = append(lines, fmt.Sprintf("%s: 已平%s仓 %.4f @ %.2f，盈亏 %+.2f USDT", c.Symbol, sideLabel(c.Side), c.Quantity, c.Price, c.RealizedPnL))
+	}
+	if r.CancelledEntries > 0 {
+		lines = append(lines, fmt.Sprintf("已撤销 %d 个条件入场单", r.CancelledEntries))
+	}
+	if r.CancelledStops > 0 {
+		lines = append(lines, fmt.Sprintf("已撤销 %d 个止损/止盈单", r.CancelledStops))
+	}
+	return append(lines, r.Errors...)
+}
+
+// sideLabel names a position side in Chinese
+// sideLabel 返回持仓方向的中文名称
+func sideLabel(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
+
+// CloseAllPositions flattens the account: cancels the pending stop entries of the configured symbols, closes
+// every open position the exchange reports with a market order, then cancels the remaining stop-loss and
+// take-profit orders
+// CloseAllPositions 平掉账户的所有持仓：撤销已配置交易对的条件入场单，市价平掉交易所返回的每个持仓，
+// 再撤销剩余的止损单和止盈单
+//
+// Positions are closed straight through the executor: plugins, the risk oracle and the pre-execution
+// balance check gate new risk and must never keep an exit from running. Closed positions are recorded in
+// storage through the stop-loss manager. A symbol whose position could not be closed keeps its protective
+// orders. The report is always returned; the error joins the failures.
+// 平仓直接通过执行器下单：插件、外部风控和执行前余额检查只用于限制新增风险，不能阻止平仓。
+// 平仓通过止损管理器记录到数据库。未能平仓的交易对保留其保护单。结果总会返回，错误为各项失败的合并。
+func (tc *TradeCoordinator) CloseAllPositions(ctx context.Context, reason string) (*FlattenReport, error) {
+	tc.logger.Header("一键平仓", '=', 80)
+	tc.logger.Warning(fmt.Sprintf("⚠️  平掉所有持仓: %s", reason))
+
+	report := &FlattenReport{}
+	err := tc.flatten(ctx, tc.config.CryptoSymbols, reason, report)
+
+	for _, line := range report.Lines() {
+		tc.logger.Info("🧹 " + line)
+	}
+	if len(report.Closed) == 0 && err == nil {
+		tc.logger.Info("暂无持仓，无需平仓")
+	}
+	return report, err
+}
+
+// flatten cancels the pending entries of symbols, closes every open position of the account and sweeps the
+// protective orders of each symbol left flat; failures are added to the report and joined in the error
+// flatten 撤销交易对的条件入场单，平掉账户的所有持仓，并清理已无持仓的交易对的保护单；失败项写入结果并合并为错误
+func (tc *TradeCoordinator) flatten(ctx context.Context, symbols []string, reason string, report *FlattenReport) error {
+	var errs []error
+	fail := func(symbol string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", symbol, err))
+		errs = append(errs, fmt.Errorf("%s: %w", symbol, err))
+	}
+
+	// Symbols whose protective orders are swept once flat, in order
+	// 平仓后需要清理保护单的交易对（保持顺序）
+	var swept []string
+	seen := make(map[string]bool)
+	sweep := func(symbol string) {
+		if !seen[symbol] {
+			seen[symbol] = true
+			swept = append(swept, symbol)
+		}
+	}
+
+	// Entries go first so none can open a new position while closing
+	// 先撤销入场单，避免平仓期间又开新仓
+	for _, symbol := range symbols {
+		binanceSymbol := tc.config.GetBinanceSymbolFor(symbol)
+		sweep(binanceSymbol)
+		n, err := tc.CancelPendingEntries(ctx, symbol, reason)
+		if err != nil {
+			fail(binanceSymbol, fmt.Errorf("撤销条件入场单失败: %w", err))
+			continue
+		}
+		report.CancelledEntries += n
+	}
+
+	// Every position on the exchange, including symbols no longer configured
+	// 交易所上的所有持仓，包括已不在配置中的交易对
+	positions, err := tc.executor.GetPositions(ctx)
+	if err != nil {
+		err = fmt.Errorf("获取持仓失败，未平仓: %w", err)
+		report.Errors = append(report.Errors, err.Error())
+		return errors.Join(append(errs, err)...)
+	}
+
+	stillOpen := make(map[string]bool)
+	for _, pos := range positions {
+		sweep(pos.Symbol)
+		if err := tc.closePosition(ctx, pos, reason, report); err != nil {
+			stillOpen[pos.Symbol] = true
+			fail(pos.Symbol, err)
+		}
+	}
+
+	// Flat symbols: stops left on the exchange (e.g. of untracked positions) can only misfire now
+	// 已无持仓的交易对：交易所上残留的止损单（例如未跟踪持仓的）只会误触发
+	for _, symbol := range swept {
+		if stillOpen[symbol] {
+			continue
+		}
+		cancelled, err := tc.executor.CancelProtectiveOrders(ctx, symbol)
+		report.CancelledStops += cancelled
+		if err != nil {
+			fail(symbol, fmt.Errorf("撤销止损/止盈单失败: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closePosition closes a position with a market order straight through the executor and records it
+// closePosition 直接通过执行器以市价平掉持仓并记录
+func (tc *TradeCoordinator) closePosition(ctx context.Context, pos *Position, reason string, report *FlattenReport) error {
+	action := ActionCloseLong
+	if pos.Side == "short" {
+		action = ActionCloseShort
+	}
+	result := tc.executor.ExecuteTrade(ctx, pos.Symbol, action, pos.Size, reason)
+	if !result.Success {
+		return fmt.Errorf("%s 失败: %s", action, result.Message)
+	}
+
+	closed := result.Filled
+	if closed <= 0 {
+		closed = pos.Size
+	}
+	realizedPnL := pos.RealizedPnLAt(result.Price, closed)
+	if tc.stopLossManager != nil {
+		if err := tc.stopLossManager.ClosePositionSide(ctx, pos.Symbol, pos.Side, result.Price, reason, realizedPnL); err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  关闭 %s 持仓记录失败: %v", pos.Symbol, err))
+		}
+	}
+	report.Closed = append(report.Closed, ClosedPosition{
+		Symbol:      pos.Symbol,
+		Side:        pos.Side,
+		Quantity:    closed,
+		Price:       result.Price,
+		RealizedPnL: realizedPnL,
+	})
+	return nil
+}
+
+// CancelProtectiveOrders cancels the reduce-only stop-loss and take-profit orders resting on a symbol,
+// returning how many were cancelled
+// CancelProtectiveOrders 撤销交易对上所有只减仓的止损单和止盈单，返回撤销数量
+func (e *BinanceExecutor) CancelProtectiveOrders(ctx context.Context, symbol string) (int, error) {
+	// Test mode places no orders
+	// 测试模式不会下单
+	if e.paper == nil && e.testMode {
+		return 0, nil
+	}
+	orders, err := e.OpenOrders(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, order := range orders {
+		if !order.ReduceOnly && !order.ClosePosition {
+			continue
+		}
+		var err error
+		if e.paper != nil {
+			err = e.paper.CancelOrder(symbol, order.OrderID)
+		} else {
+			_, err = e.client.NewCancelOrderService().Symbol(order.Symbol).OrderID(order.OrderID).Do(ctx)
+		}
+		if err != nil && !isUnknownOrder(err) {
+			return cancelled, fmt.Errorf("撤销订单 %d 失败: %w", order.OrderID, err)
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
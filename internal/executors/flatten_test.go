@@ -0,0 +1,81 @@
+package executors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestCancelProtectiveOrders tests that only the reduce-only stop-loss and take-profit orders are swept
+// TestCancelProtectiveOrders 测试只清理只减仓的止损单和止盈单
+func TestCancelProtectiveOrders(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10, TakeProfitRMultiple: 2}
+	sm, pos := newBracketTestManager(t, market, cfg)
+	ctx := context.Background()
+
+	if err := sm.PlaceInitialStopLoss(ctx, pos); err != nil {
+		t.Fatalf("PlaceInitialStopLoss failed: %v", err)
+	}
+	entryID, err := sm.executor.paper.PlaceStopEntryOrder(ctx, "BTCUSDT", futures.SideTypeBuy, 120, 1)
+	if err != nil {
+		t.Fatalf("PlaceStopEntryOrder failed: %v", err)
+	}
+
+	n, err := sm.executor.CancelProtectiveOrders(ctx, "BTCUSDT")
+	if err != nil || n != 2 {
+		t.Fatalf("CancelProtectiveOrders = %d, %v; want 2, nil", n, err)
+	}
+	for _, orderID := range []string{pos.StopLossOrderID, pos.TakeProfitOrderID} {
+		if status := paperOrderStatus(t, sm, orderID); status != string(futures.OrderStatusTypeCanceled) {
+			t.Errorf("Order %s status = %s, want CANCELED", orderID, status)
+		}
+	}
+	orders, _ := sm.executor.OpenOrders(ctx, "BTCUSDT")
+	if len(orders) != 1 || orders[0].OrderID != entryID {
+		t.Errorf("Expected only the stop entry to stay open, got %+v", orders)
+	}
+
+	report := &FlattenReport{
+		Closed:         []ClosedPosition{{Symbol: "BTCUSDT", Side: "short", Quantity: 1, Price: 100, RealizedPnL: -2.5}},
+		CancelledStops: n,
+		Errors:         []string{"ETHUSDT: 获取持仓失败"},
+	}
+	lines := report.Lines()
+	if len(lines) != 3 || !strings.Contains(lines[0], "已平空仓") || !strings.Contains(lines[0], "-2.50") {
+		t.Errorf("Unexpected report lines: %q", lines)
+	}
+}
+
+// TestCloseAllPositions tests that every position on the exchange is closed straight through the executor,
+// including symbols no longer configured
+// TestCloseAllPositions 测试直接通过执行器平掉交易所上的所有持仓，包括已不在配置中的交易对
+func TestCloseAllPositions(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10, CryptoSymbols: []string{"BTC/USDT"}}
+	sm, _ := newBracketTestManager(t, market, cfg)
+	e := sm.executor
+	if _, _, err := e.paper.applyFill("ETHUSDT", "SELL", 2, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// The 24h ticker of the pre-execution checks is not served: closing must not depend on it
+	// 不提供执行前检查使用的 24 小时行情：平仓不能依赖它
+	tc := NewTradeCoordinator(cfg, e, sm.logger, sm)
+	report, err := tc.CloseAllPositions(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("CloseAllPositions failed: %v (report %+v)", err, report)
+	}
+	if len(report.Closed) != 2 || report.Closed[0].Symbol != "BTCUSDT" || report.Closed[1].Symbol != "ETHUSDT" || report.Closed[1].Side != "short" {
+		t.Errorf("Expected both positions to be closed, got %+v", report.Closed)
+	}
+	if positions, err := e.GetPositions(context.Background()); err != nil || len(positions) != 0 {
+		t.Errorf("Expected no position left, got %+v, %v", positions, err)
+	}
+	if sm.GetPosition("BTCUSDT") != nil {
+		t.Error("Expected the managed position to be closed")
+	}
+}
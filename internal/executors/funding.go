@@ -0,0 +1,120 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// fundingPaymentRate returns the funding rate paid by a position side (negative = received)
+// fundingPaymentRate 返回持仓方向需要支付的资金费率（负数表示收取）
+//
+// A positive rate means longs pay shorts.
+// 正费率表示多头支付给空头。
+func fundingPaymentRate(side string, rate float64) float64 {
+	if side == "short" {
+		return -rate
+	}
+	return rate
+}
+
+// checkFundingCost refuses an entry whose side would pay more than FUNDING_RATE_MAX_PERCENT per settlement
+// checkFundingCost 开仓方向每次结算需支付的资金费率超过 FUNDING_RATE_MAX_PERCENT 时拒绝开仓
+//
+// The guard fails open: if the predicted rate cannot be fetched the entry is allowed with a warning.
+// 该检查失败时放行：无法获取预测费率时仅记录警告并允许开仓。
+func (tc *TradeCoordinator) checkFundingCost(ctx context.Context, symbol string, action TradeAction) error {
+	if tc.config.FundingRateMaxPercent <= 0 {
+		return nil
+	}
+
+	side := "long"
+	if action == ActionSell {
+		side = "short"
+	}
+
+	indexes, err := tc.executor.client.NewPremiumIndexService().Symbol(tc.config.GetBinanceSymbolFor(symbol)).Do(ctx)
+	if err != nil || len(indexes) == 0 {
+		tc.logger.Warning(fmt.Sprintf("⚠️  获取 %s 预测资金费率失败: %v，跳过资金费检查", symbol, err))
+		return nil
+	}
+	rate, _ := parseFloat(indexes[0].LastFundingRate)
+	nextFunding := time.UnixMilli(indexes[0].NextFundingTime)
+
+	costPercent := fundingPaymentRate(side, rate) * 100
+	if costPercent > tc.config.FundingRateMaxPercent {
+		return fmt.Errorf("开%s需支付资金费率 %.4f%%（%s 结算），超过上限 %.4f%%",
+			sideName(side), costPercent, nextFunding.Format("15:04"), tc.config.FundingRateMaxPercent)
+	}
+
+	tc.logger.Info(fmt.Sprintf("  ✓ 资金费率: %.4f%%（开%s每次结算成本 %.4f%%，下次结算 %s）",
+		rate*100, sideName(side), costPercent, nextFunding.Format("15:04")))
+	return nil
+}
+
+// sideName returns the Chinese name of a position side
+// sideName 返回持仓方向的中文名称
+func sideName(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
+
+// FundingPaid returns the funding a position paid since it was opened (negative = received)
+// FundingPaid 返回持仓自开仓以来支付的资金费（负数表示收取）
+//
+// Live trading sums the FUNDING_FEE entries of the income history. Paper and test mode replay the
+// public funding rate history against the current quantity, the same way the paper account settles it.
+// 实盘汇总收益历史中的 FUNDING_FEE 记录；模拟盘和测试模式按公开的资金费率历史和当前数量计算，
+// 与模拟盘账户的结算方式一致。
+func (e *BinanceExecutor) FundingPaid(ctx context.Context, pos *Position) (float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(pos.Symbol)
+	start, end := pos.EntryTime.UnixMilli(), time.Now().UnixMilli()
+
+	if e.paper == nil && !e.testMode {
+		incomes, err := e.client.NewGetIncomeHistoryService().
+			Symbol(binanceSymbol).
+			IncomeType("FUNDING_FEE").
+			StartTime(start).
+			EndTime(end).
+			Limit(1000).
+			Do(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get funding income: %w", err)
+		}
+
+		var paid float64
+		for _, income := range incomes {
+			amount, _ := parseFloat(income.Income)
+			paid -= amount
+		}
+		return paid, nil
+	}
+
+	rates, err := e.client.NewFundingRateService().
+		Symbol(binanceSymbol).
+		StartTime(start).
+		EndTime(end).
+		Limit(1000).
+		Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get funding rates: %w", err)
+	}
+
+	quantity := pos.Quantity
+	if quantity == 0 {
+		quantity = pos.Size
+	}
+
+	var paid float64
+	for _, r := range rates {
+		rate, _ := parseFloat(r.FundingRate)
+		markPrice, _ := parseFloat(r.MarkPrice)
+		if markPrice == 0 {
+			markPrice = pos.EntryPrice
+		}
+		paid += quantity * markPrice * fundingPaymentRate(pos.Side, rate)
+	}
+	return paid, nil
+}
@@ -0,0 +1,23 @@
+package executors
+
+import "testing"
+
+// TestFundingPaymentRate tests which side pays funding
+// TestFundingPaymentRate 测试资金费的支付方向
+func TestFundingPaymentRate(t *testing.T) {
+	tests := []struct {
+		side string
+		rate float64
+		want float64
+	}{
+		{"long", 0.0001, 0.0001},   // 正费率多头支付 / Longs pay a positive rate
+		{"short", 0.0001, -0.0001}, // 正费率空头收取 / Shorts receive a positive rate
+		{"long", -0.0003, -0.0003},
+		{"short", -0.0003, 0.0003},
+	}
+	for _, tt := range tests {
+		if got := fundingPaymentRate(tt.side, tt.rate); got != tt.want {
+			t.Errorf("fundingPaymentRate(%s, %v) = %v, want %v", tt.side, tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+const (
+	defaultPriceHistoryPoints = 1000 // 每个持仓默认保留的价格点数 / Default price points kept per position
+	defaultTradeHistorySize   = 200  // 执行器默认保留的交易结果条数 / Default trade results kept by the executor
+)
+
+// appendPricePoint adds a price point, dropping the oldest ones beyond MaxPriceHistory
+// appendPricePoint 添加价格点，超出 MaxPriceHistory 时丢弃最旧的点
+func (p *Position) appendPricePoint(t time.Time, price float64) {
+	p.PriceHistory = append(p.PriceHistory, PricePoint{Time: t, Price: price})
+
+	limit := p.MaxPriceHistory
+	if limit <= 0 {
+		limit = defaultPriceHistoryPoints
+	}
+	if over := len(p.PriceHistory) - limit; over > 0 {
+		p.PriceHistory = p.PriceHistory[over:]
+	}
+}
+
+// GetPriceHistory returns a copy of the in-memory price history of a managed position
+// GetPriceHistory 返回受管持仓内存价格历史的副本
+func (sm *StopLossManager) GetPriceHistory(symbol string) []PricePoint {
+	pos := sm.GetPosition(symbol)
+	if pos == nil {
+		return nil
+	}
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return append([]PricePoint(nil), pos.PriceHistory...)
+}
+
+// addTradeHistory records a trade result, keeping at most HistoryTradeResults entries
+// addTradeHistory 记录交易结果，最多保留 HistoryTradeResults 条
+func (e *BinanceExecutor) addTradeHistory(result TradeResult) {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	limit := e.config.HistoryTradeResults
+	if limit <= 0 {
+		limit = defaultTradeHistorySize
+	}
+	e.tradeHistory = appendBounded(e.tradeHistory, result, limit)
+	e.unflushedTrades = appendBounded(e.unflushedTrades, result, limit)
+}
+
+// GetTradeHistory returns a copy of the in-memory trade results, oldest first
+// GetTradeHistory 返回内存中交易结果的副本，按时间正序
+func (e *BinanceExecutor) GetTradeHistory() []TradeResult {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	return append([]TradeResult(nil), e.tradeHistory...)
+}
+
+// takeUnflushedTrades returns the trade results not yet written to storage and clears them
+// takeUnflushedTrades 返回尚未写入数据库的交易结果并清空
+func (e *BinanceExecutor) takeUnflushedTrades() []TradeResult {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	pending := e.unflushedTrades
+	e.unflushedTrades = nil
+	return pending
+}
+
+// requeueTrades puts back trade results whose flush failed
+// requeueTrades 将写入失败的交易结果放回队列
+func (e *BinanceExecutor) requeueTrades(results []TradeResult) {
+	e.historyMu.Lock()
+	defer e.historyMu.Unlock()
+
+	limit := e.config.HistoryTradeResults
+	if limit <= 0 {
+		limit = defaultTradeHistorySize
+	}
+	merged := append(results, e.unflushedTrades...)
+	if over := len(merged) - limit; over > 0 {
+		merged = merged[over:]
+	}
+	e.unflushedTrades = merged
+}
+
+func appendBounded(history []TradeResult, result TradeResult, limit int) []TradeResult {
+	history = append(history, result)
+	if over := len(history) - limit; over > 0 {
+		history = history[over:]
+	}
+	return history
+}
+
+// HistoryFlusher periodically writes the in-memory price and trade histories to storage
+// HistoryFlusher 定期将内存中的价格历史和交易结果写入数据库
+//
+// Memory only holds the latest entries (see HISTORY_PRICE_POINTS / HISTORY_TRADE_RESULTS),
+// the full history used for charting lives in the price_history and execution_history tables.
+// 内存只保留最近的数据（见 HISTORY_PRICE_POINTS / HISTORY_TRADE_RESULTS），
+// 图表使用的完整历史保存在 price_history 和 execution_history 表中。
+type HistoryFlusher struct {
+	executor        *BinanceExecutor
+	stopLossManager *StopLossManager
+	storage         *storage.Storage
+	logger          *logger.ColorLogger
+
+	// flushedAt tracks the last flushed price point per position ID
+	// flushedAt 记录每个持仓最后写入的价格点时间
+	flushedAt map[string]time.Time
+	mu        sync.Mutex
+}
+
+// NewHistoryFlusher creates a new HistoryFlusher
+// NewHistoryFlusher 创建新的历史写入器
+func NewHistoryFlusher(executor *BinanceExecutor, stopLossMgr *StopLossManager, db *storage.Storage, log *logger.ColorLogger) *HistoryFlusher {
+	return &HistoryFlusher{
+		executor:        executor,
+		stopLossManager: stopLossMgr,
+		storage:         db,
+		logger:          log,
+		flushedAt:       make(map[string]time.Time),
+	}
+}
+
+// Flush writes new price points and trade results to storage
+// Flush 将新的价格点和交易结果写入数据库
+//
+// Safe to call concurrently with Run, e.g. from an API handler before reading the tables.
+// 可以与 Run 并发调用，例如在 API 读取历史表之前调用。
+func (f *HistoryFlusher) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var points []*storage.PricePointRecord
+	active := make(map[string]bool)
+
+	if f.stopLossManager != nil {
+		sm := f.stopLossManager
+		sm.mu.RLock()
+		for _, pos := range sm.positions {
+			active[pos.ID] = true
+			last := f.flushedAt[pos.ID]
+			for _, point := range pos.PriceHistory {
+				if point.Time.After(last) {
+					points = append(points, &storage.PricePointRecord{
+						PositionID: pos.ID,
+						Symbol:     pos.Symbol,
+						Time:       point.Time,
+						Price:      point.Price,
+					})
+				}
+			}
+		}
+		sm.mu.RUnlock()
+	}
+
+	if err := f.storage.SavePricePoints(points); err != nil {
+		return err
+	}
+	for _, point := range points {
+		if point.Time.After(f.flushedAt[point.PositionID]) {
+			f.flushedAt[point.PositionID] = point.Time
+		}
+	}
+	// Forget closed positions
+	// 清理已平仓持仓的记录
+	for id := range f.flushedAt {
+		if !active[id] {
+			delete(f.flushedAt, id)
+		}
+	}
+
+	if f.executor == nil {
+		return nil
+	}
+	results := f.executor.takeUnflushedTrades()
+	records := make([]*storage.ExecutionRecord, 0, len(results))
+	for _, r := range results {
+		createdAt, err := time.ParseInLocation("2006-01-02 15:04:05", r.Timestamp, time.Local)
+		if err != nil {
+			createdAt = time.Now()
+		}
+		records = append(records, &storage.ExecutionRecord{
+			Symbol:    f.executor.config.GetBinanceSymbolFor(r.Symbol),
+			Action:    string(r.Action),
+			Amount:    r.Amount,
+			Price:     r.Price,
+			Filled:    r.Filled,
+			OrderID:   r.OrderID,
+			Success:   r.Success,
+			TestMode:  r.TestMode,
+			Reason:    r.Reason,
+			Message:   r.Message,
+			CreatedAt: createdAt,
+		})
+	}
+	if err := f.storage.SaveExecutions(records); err != nil {
+		f.executor.requeueTrades(results)
+		return err
+	}
+	return nil
+}
+
+// Run flushes every interval until ctx is done, with a final flush on exit
+// Run 每隔 interval 写入一次，直到 ctx 结束，退出前再写入一次
+func (f *HistoryFlusher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := f.Flush(); err != nil {
+				f.logger.Warning(fmt.Sprintf("⚠️  写入历史数据失败: %v", err))
+			}
+			return
+		case <-ticker.C:
+			if err := f.Flush(); err != nil {
+				f.logger.Warning(fmt.Sprintf("⚠️  写入历史数据失败: %v", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package executors
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestHistoryRetention tests that price and trade histories are bounded by the configured limits
+// TestHistoryRetention 测试价格历史和交易结果受配置上限约束
+func TestHistoryRetention(t *testing.T) {
+	pos := &Position{Side: "long", MaxPriceHistory: 3}
+	for i := 1; i <= 5; i++ {
+		pos.UpdatePrice(float64(i))
+	}
+	if len(pos.PriceHistory) != 3 || pos.PriceHistory[0].Price != 3 || pos.PriceHistory[2].Price != 5 {
+		t.Errorf("PriceHistory = %+v, want the last 3 points", pos.PriceHistory)
+	}
+
+	e := &BinanceExecutor{config: &config.Config{HistoryTradeResults: 2}}
+	for _, symbol := range []string{"A", "B", "C"} {
+		e.addTradeHistory(TradeResult{Symbol: symbol})
+	}
+	history := e.GetTradeHistory()
+	if len(history) != 2 || history[0].Symbol != "B" || history[1].Symbol != "C" {
+		t.Errorf("GetTradeHistory() = %+v, want B, C", history)
+	}
+}
+
+// TestHistoryFlusher tests that each point and result is written exactly once
+// TestHistoryFlusher 测试每个价格点和交易结果只写入一次
+func TestHistoryFlusher(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{}
+	log := logger.NewColorLogger(false)
+	e := &BinanceExecutor{config: cfg, logger: log}
+	sm := NewStopLossManager(cfg, e, log, db)
+	sm.positions[positionKey("BTCUSDT", "long")] = &Position{ID: "BTCUSDT-1", Symbol: "BTCUSDT", Side: "long"}
+	flusher := NewHistoryFlusher(e, sm, db, log)
+
+	start := time.Now().Add(-time.Minute)
+	sm.positions[positionKey("BTCUSDT", "long")].appendPricePoint(start, 100)
+	sm.positions[positionKey("BTCUSDT", "long")].appendPricePoint(start.Add(time.Second), 101)
+	e.addTradeHistory(TradeResult{Symbol: "BTC/USDT", Action: ActionBuy, Success: true, Timestamp: start.Format("2006-01-02 15:04:05")})
+
+	if err := flusher.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	sm.positions[positionKey("BTCUSDT", "long")].appendPricePoint(start.Add(2*time.Second), 102)
+	if err := flusher.Flush(); err != nil {
+		t.Fatalf("second Flush() error = %v", err)
+	}
+
+	points, err := db.GetPriceHistory("BTCUSDT", start.Add(-time.Hour), 100)
+	if err != nil || len(points) != 3 || points[2].Price != 102 {
+		t.Errorf("price history = %d points, %v; want 3 ending at 102", len(points), err)
+	}
+	executions, err := db.GetExecutions("BTCUSDT", 10)
+	if err != nil || len(executions) != 1 || executions[0].Action != "BUY" {
+		t.Errorf("executions = %+v, %v; want a single BUY", executions, err)
+	}
+	// Flushed results stay available in memory
+	// 已写入的结果仍保留在内存中
+	if len(e.GetTradeHistory()) != 1 {
+		t.Errorf("in-memory trade history should be kept after flush")
+	}
+}
@@ -0,0 +1,317 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// killSwitchStateKey is the run_state key holding the kill switch state
+// killSwitchStateKey 是 run_state 中保存急停开关状态的键
+const killSwitchStateKey = "kill_switch"
+
+// killSwitchPollInterval is how often Run looks for the sentinel file
+// killSwitchPollInterval 是 Run 检查急停文件的间隔
+const killSwitchPollInterval = 5 * time.Second
+
+// killSwitchFlattenWord as the first line of the sentinel file also flattens every position
+// 急停文件第一行为 killSwitchFlattenWord 时同时平掉所有持仓
+const killSwitchFlattenWord = "flatten"
+
+// Kill switch sources
+// 急停开关的触发来源
+const (
+	KillSwitchSourceAPI  = "api"  // 控制台 / API
+	KillSwitchSourceFile = "file" // 急停文件 / Sentinel file
+)
+
+// ErrKillSwitchFilePresent is returned by Rearm while the sentinel file still exists
+// ErrKillSwitchFilePresent 在急停文件仍存在时由 Rearm 返回
+var ErrKillSwitchFilePresent = errors.New("kill switch file still exists")
+
+// KillSwitchState is the persisted state of the kill switch
+// KillSwitchState 是急停开关持久化的状态
+type KillSwitchState struct {
+	Engaged     bool      `json:"engaged"`
+	Reason      string    `json:"reason,omitempty"`
+	Source      string    `json:"source,omitempty"`     // api / file
+	Flatten     bool      `json:"flatten"`              // 是否要求平掉所有持仓 / Whether every position was to be flattened
+	EngagedAt   time.Time `json:"engaged_at,omitempty"` // 启用时间 / When it was engaged
+	FilePresent bool      `json:"file_present"`         // 急停文件当前是否存在 / Whether the sentinel file exists now
+}
+
+// KillSwitch halts new order placement during an incident until it is explicitly re-armed
+// KillSwitch 在事故期间停止下新单，直到被明确重新启用交易
+//
+// It is engaged from the dashboard (POST /api/killswitch) or by creating the KILL_SWITCH_FILE
+// sentinel file, which works even when the dashboard is unreachable. The state lives in run_state,
+// so it survives restarts and removing the file doesn't resume trading on its own: trading resumes
+// only through Rearm. While engaged, entries (market and stop entries) are refused; closes, partial
+// closes and stop-loss orders keep working so positions can still be reduced and stay protected.
+// A failed state read counts as engaged.
+// 可在控制台启用（POST /api/killswitch），或创建 KILL_SWITCH_FILE 急停文件启用（控制台无法访问时同样有效）。
+// 状态保存在 run_state 中，重启后依然有效；删除文件不会自动恢复交易，只能通过 Rearm 恢复。启用期间拒绝开仓
+// （市价开仓和条件入场单），平仓、部分平仓和止损单不受影响，持仓仍可减仓并保持保护。读取状态失败时按已启用处理。
+type KillSwitch struct {
+	db     *storage.Storage
+	file   string
+	logger *logger.ColorLogger
+
+	mu sync.Mutex
+}
+
+// NewKillSwitch creates a kill switch backed by storage and the KILL_SWITCH_FILE sentinel ("" = no file)
+// NewKillSwitch 创建基于数据库和 KILL_SWITCH_FILE 急停文件（"" 表示不检查文件）的急停开关
+func NewKillSwitch(db *storage.Storage, cfg *config.Config, log *logger.ColorLogger) *KillSwitch {
+	return &KillSwitch{db: db, file: cfg.KillSwitchFile, logger: log}
+}
+
+// Check returns the current state, engaging the switch if the sentinel file appeared
+// Check 返回当前状态，检测到急停文件时启用急停
+//
+// The second result is true when this call engaged the switch from the file.
+// 本次调用因急停文件而启用急停时，第二个返回值为 true。
+func (k *KillSwitch) Check() (*KillSwitchState, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	content, present := k.readFile()
+	state.FilePresent = present
+	if !present || state.Engaged {
+		return state, false, nil
+	}
+
+	firstLine, rest, _ := strings.Cut(content, "\n")
+	reason := strings.TrimSpace(rest)
+	if reason == "" {
+		reason = fmt.Sprintf("检测到急停文件 %s", k.file)
+	}
+	state.Engaged = true
+	state.Reason = reason
+	state.Source = KillSwitchSourceFile
+	state.Flatten = strings.EqualFold(strings.TrimSpace(firstLine), killSwitchFlattenWord)
+	state.EngagedAt = time.Now()
+	if err := k.save(state); err != nil {
+		return nil, false, err
+	}
+	k.logger.Error(fmt.Sprintf("🛑 急停开关已启用（急停文件 %s）: %s，停止开仓", k.file, reason))
+	return state, true, nil
+}
+
+// Engage engages the switch; it returns false if it was already engaged
+// Engage 启用急停；已经启用时返回 false
+//
+// Asking to flatten an engaged switch records the request, so the caller can still flatten.
+// 对已启用的急停要求平仓时会记录该请求，调用方仍可执行平仓。
+func (k *KillSwitch) Engage(reason, source string, flatten bool) (*KillSwitchState, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state, err := k.load()
+	if err != nil {
+		return nil, false, err
+	}
+	_, state.FilePresent = k.readFile()
+	if state.Engaged {
+		if flatten && !state.Flatten {
+			state.Flatten = true
+			if err := k.save(state); err != nil {
+				return nil, false, err
+			}
+		}
+		return state, false, nil
+	}
+
+	state.Engaged = true
+	state.Reason = reason
+	state.Source = source
+	state.Flatten = flatten
+	state.EngagedAt = time.Now()
+	if err := k.save(state); err != nil {
+		return nil, false, err
+	}
+	k.logger.Error(fmt.Sprintf("🛑 急停开关已启用（%s）: %s，停止开仓", source, reason))
+	return state, true, nil
+}
+
+// Rearm clears the switch so entries resume; the sentinel file must be removed first
+// Rearm 解除急停以恢复开仓；需先删除急停文件
+func (k *KillSwitch) Rearm() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, present := k.readFile(); present {
+		return fmt.Errorf("%w: remove %s first", ErrKillSwitchFilePresent, k.file)
+	}
+	state, err := k.load()
+	if err != nil {
+		return err
+	}
+	if !state.Engaged {
+		return nil
+	}
+	if err := k.save(&KillSwitchState{}); err != nil {
+		return err
+	}
+	k.logger.Success(fmt.Sprintf("✅ 急停开关已解除，恢复开仓（启用于 %s）", state.EngagedAt.Format("01-02 15:04:05")))
+	return nil
+}
+
+// Engaged reports whether entries are halted (nil-safe, a failed check counts as engaged)
+// Engaged 返回是否停止开仓（nil 安全，检查失败时按已启用处理）
+func (k *KillSwitch) Engaged() bool {
+	if k == nil {
+		return false
+	}
+	state, _, err := k.Check()
+	if err != nil {
+		k.logger.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败，按已启用处理: %v", err))
+		return true
+	}
+	return state.Engaged
+}
+
+// Run watches for the sentinel file until ctx is done, calling onEngage when it engages the switch
+// Run 监视急停文件直到 ctx 结束，因文件启用急停时调用 onEngage
+func (k *KillSwitch) Run(ctx context.Context, onEngage func(*KillSwitchState)) {
+	ticker := time.NewTicker(killSwitchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state, engaged, err := k.Check()
+		if err != nil {
+			k.logger.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败: %v", err))
+			continue
+		}
+		if engaged && onEngage != nil {
+			onEngage(state)
+		}
+	}
+}
+
+// readFile returns the sentinel file's content and whether it exists
+// readFile 返回急停文件的内容及其是否存在
+func (k *KillSwitch) readFile() (string, bool) {
+	if k.file == "" {
+		return "", false
+	}
+	content, err := os.ReadFile(k.file)
+	if err != nil {
+		// An unreadable file that exists still engages the switch
+		// 文件存在但无法读取时同样启用急停
+		_, statErr := os.Stat(k.file)
+		return "", statErr == nil
+	}
+	return string(content), true
+}
+
+// load reads the state from run_state (caller holds mu)
+// load 从 run_state 读取状态（调用方持有 mu）
+func (k *KillSwitch) load() (*KillSwitchState, error) {
+	state := &KillSwitchState{}
+	raw, err := k.db.GetRunState(killSwitchStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), state); err != nil {
+			return nil, fmt.Errorf("failed to decode kill switch state: %w", err)
+		}
+	}
+	return state, nil
+}
+
+// save writes the state to run_state (caller holds mu)
+// save 将状态写入 run_state（调用方持有 mu）
+func (k *KillSwitch) save(state *KillSwitchState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return k.db.SetRunState(killSwitchStateKey, string(raw))
+}
+
+// SetKillSwitch refuses entries while the kill switch is engaged
+// SetKillSwitch 在急停开关启用期间拒绝开仓
+func (e *BinanceExecutor) SetKillSwitch(k *KillSwitch) {
+	e.killSwitch = k
+}
+
+// KillSwitchEngaged reports whether entries are halted by the kill switch (nil-safe)
+// KillSwitchEngaged 返回是否因急停开关停止开仓（nil 安全）
+func (e *BinanceExecutor) KillSwitchEngaged() bool {
+	return e != nil && e.killSwitch.Engaged()
+}
+
+// EmergencyStop cancels the pending stop entries of the symbols and, with flatten, closes every open
+// position of the account the way CloseAllPositions does
+// EmergencyStop 撤销交易对的条件入场单，flatten 为 true 时按 CloseAllPositions 的方式平掉账户的所有持仓
+//
+// Returns one line per action taken or failed, for logs and notifications: each position that could not
+// be closed has its own line.
+// 返回每个已执行或失败操作的描述，用于日志和通知：每个未能平掉的持仓单独一行。
+func (tc *TradeCoordinator) EmergencyStop(ctx context.Context, symbols []string, flatten bool, reason string) []string {
+	if flatten {
+		report := &FlattenReport{}
+		_ = tc.flatten(ctx, symbols, reason, report) // 失败项已写入 report / Failures are in the report
+		return report.Lines()
+	}
+
+	var report []string
+	for _, symbol := range symbols {
+		if n, err := tc.CancelPendingEntries(ctx, symbol, reason); err != nil {
+			report = append(report, fmt.Sprintf("%s: 撤销条件入场单失败: %v", symbol, err))
+		} else if n > 0 {
+			report = append(report, fmt.Sprintf("%s: 已撤销 %d 个条件入场单", symbol, n))
+		}
+	}
+	return report
+}
+
+// RespondToKillSwitch runs the emergency stop of a newly engaged kill switch on every configured symbol and alerts the notifier
+// RespondToKillSwitch 对所有已配置交易对执行新启用急停的紧急停止，并通过通知渠道告警
+func (tc *TradeCoordinator) RespondToKillSwitch(ctx context.Context, state *KillSwitchState, notifier notify.Notifier) []string {
+	report := tc.EmergencyStop(ctx, tc.config.CryptoSymbols, state.Flatten, "急停: "+state.Reason)
+	for _, line := range report {
+		tc.logger.Warning("🛑 " + line)
+	}
+
+	text := fmt.Sprintf("来源: %s\n原因: %s\n已停止开仓，解除前不会恢复", state.Source, state.Reason)
+	if state.Flatten {
+		text += "，并已请求平掉所有持仓"
+	}
+	if len(report) > 0 {
+		text += "\n\n" + strings.Join(report, "\n")
+	}
+	if notifier != nil {
+		if err := notifier.Notify(ctx, notify.Message{
+			Title: "急停开关已启用",
+			Text:  text,
+			Level: notify.LevelError,
+		}); err != nil {
+			tc.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+		}
+	}
+	return report
+}
@@ -0,0 +1,128 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestKillSwitch tests engaging from the API and the sentinel file, the entry gate and the explicit re-arm
+// TestKillSwitch 测试通过 API 和急停文件启用、开仓拦截以及必须明确解除
+func TestKillSwitch(t *testing.T) {
+	dir := t.TempDir()
+	db, err := storage.NewStorage(filepath.Join(dir, "killswitch.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	log := logger.NewColorLogger(false)
+	cfg := &config.Config{KillSwitchFile: filepath.Join(dir, "KILL_SWITCH")}
+	k := NewKillSwitch(db, cfg, log)
+	e := &BinanceExecutor{config: cfg, testMode: true, logger: log}
+	e.SetKillSwitch(k)
+
+	if e.KillSwitchEngaged() {
+		t.Fatal("Expected the kill switch to start disengaged")
+	}
+
+	state, engaged, err := k.Engage("incident", KillSwitchSourceAPI, false)
+	if err != nil || !engaged || !state.Engaged {
+		t.Fatalf("Engage failed: %+v, %v, %v", state, engaged, err)
+	}
+	if _, engaged, _ := k.Engage("again", KillSwitchSourceAPI, true); engaged {
+		t.Error("Expected a second Engage not to engage again")
+	}
+	if state, _, _ := k.Check(); !state.Flatten || state.Reason != "incident" {
+		t.Errorf("Expected the flatten request to be recorded on the first engagement, got %+v", state)
+	}
+
+	ctx := context.Background()
+	if result := e.ExecuteTrade(ctx, "BTCUSDT", ActionBuy, 0.01, "test"); result.Success {
+		t.Error("Entries should be refused while the kill switch is engaged")
+	}
+	if _, err := e.PlaceStopEntryOrder(ctx, "BTCUSDT", ActionBuyStop, 0.01, 50000); err == nil {
+		t.Error("Stop entries should be refused while the kill switch is engaged")
+	}
+
+	// 状态跨实例保存
+	if !NewKillSwitch(db, cfg, log).Engaged() {
+		t.Error("Expected the state to be shared through storage")
+	}
+
+	if err := k.Rearm(); err != nil {
+		t.Fatalf("Rearm failed: %v", err)
+	}
+	if e.KillSwitchEngaged() {
+		t.Fatal("Expected the kill switch to be re-armed")
+	}
+
+	// 急停文件：启用后删除文件不会自动解除
+	if err := os.WriteFile(cfg.KillSwitchFile, []byte("flatten\nexchange incident\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, engaged, err = k.Check()
+	if err != nil || !engaged {
+		t.Fatalf("Expected the file to engage the switch: %+v, %v", state, err)
+	}
+	if state.Source != KillSwitchSourceFile || !state.Flatten || state.Reason != "exchange incident" {
+		t.Errorf("Unexpected file engagement: %+v", state)
+	}
+	if _, engaged, _ := k.Check(); engaged {
+		t.Error("Expected the file to engage the switch only once")
+	}
+	if err := k.Rearm(); !errors.Is(err, ErrKillSwitchFilePresent) {
+		t.Errorf("Expected re-arm to be refused while the file exists, got %v", err)
+	}
+
+	if err := os.Remove(cfg.KillSwitchFile); err != nil {
+		t.Fatal(err)
+	}
+	if !k.Engaged() {
+		t.Error("Removing the file should not re-arm the switch")
+	}
+	if err := k.Rearm(); err != nil || k.Engaged() {
+		t.Errorf("Expected re-arm after removing the file, got %v", err)
+	}
+
+	var unset *BinanceExecutor
+	if unset.KillSwitchEngaged() {
+		t.Error("Expected a nil executor not to be halted")
+	}
+}
+
+// TestEmergencyStopReportsFailedCloses tests that the flatten of an emergency stop reports every position it could not close
+// TestEmergencyStopReportsFailedCloses 测试急停平仓时报告每个未能平掉的持仓
+func TestEmergencyStopReportsFailedCloses(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10, CryptoSymbols: []string{"BTC/USDT"}}
+	sm, _ := newBracketTestManager(t, market, cfg)
+	e := sm.executor
+	if _, _, err := e.paper.applyFill("ETHUSDT", "SELL", 2, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// Maintenance refuses every order, so neither position can be closed
+	// 维护期间拒绝所有订单，两个持仓都无法平掉
+	e.maintenance = &MaintenanceMonitor{active: true, since: time.Now()}
+	tc := NewTradeCoordinator(cfg, e, sm.logger, sm)
+	lines := tc.EmergencyStop(context.Background(), cfg.CryptoSymbols, true, "急停: test")
+
+	report := strings.Join(lines, "\n")
+	for _, want := range []string{"BTCUSDT: CLOSE_LONG 失败: 交易所维护中", "ETHUSDT: CLOSE_SHORT 失败: 交易所维护中"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", want, report)
+		}
+	}
+	if positions, _ := e.GetPositions(context.Background()); len(positions) != 2 {
+		t.Errorf("Expected both positions to stay open, got %+v", positions)
+	}
+}
@@ -0,0 +1,127 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// ExecutionTiming collects the pipeline timestamps of one decision, from market snapshot to fill
+// ExecutionTiming 收集一次决策从行情快照到成交的各阶段时间戳
+//
+// The trading loop fills in the signal and decision fields and attaches it to the context with
+// WithExecutionTiming; the coordinator marks validation and the executor marks order sent/filled.
+// 交易循环填写行情和决策字段并通过 WithExecutionTiming 附加到 context；
+// 协调器记录验证通过时间，执行器记录下单和成交时间。
+type ExecutionTiming struct {
+	SignalAt    time.Time // 行情快照时间（LLM 分析的数据）/ Market snapshot time (data analyzed by the LLM)
+	SignalPrice float64   // 快照价格 / Snapshot price
+	DecisionAt  time.Time // 决策生成时间 / Decision generated
+	ValidatedAt time.Time // 验证通过时间 / Validation passed
+	SentAt      time.Time // 下单时间 / Order sent
+	FilledAt    time.Time // 成交时间 / Order filled
+}
+
+// executionTimingKey is the context key carrying the ExecutionTiming of a decision
+// executionTimingKey 是携带决策 ExecutionTiming 的 context 键
+type executionTimingKey struct{}
+
+// WithExecutionTiming returns a context whose executed order records the given timing
+// WithExecutionTiming 返回一个 context，其下单执行时会记录指定的时间信息
+func WithExecutionTiming(ctx context.Context, timing *ExecutionTiming) context.Context {
+	return context.WithValue(ctx, executionTimingKey{}, timing)
+}
+
+// executionTimingFrom returns the timing carried by ctx (nil if none)
+// executionTimingFrom 返回 ctx 中携带的时间信息（没有则为 nil）
+func executionTimingFrom(ctx context.Context) *ExecutionTiming {
+	timing, _ := ctx.Value(executionTimingKey{}).(*ExecutionTiming)
+	return timing
+}
+
+// markValidated records that the decision passed validation
+// markValidated 记录决策验证通过的时间
+func markValidated(ctx context.Context) {
+	if timing := executionTimingFrom(ctx); timing != nil {
+		timing.ValidatedAt = time.Now()
+	}
+}
+
+// markSent records that the order is being sent
+// markSent 记录开始下单的时间
+func markSent(ctx context.Context) {
+	if timing := executionTimingFrom(ctx); timing != nil {
+		timing.SentAt = time.Now()
+	}
+}
+
+// markFilled records that the order was filled
+// markFilled 记录订单成交的时间
+func markFilled(ctx context.Context) {
+	if timing := executionTimingFrom(ctx); timing != nil {
+		timing.FilledAt = time.Now()
+	}
+}
+
+// adverseSlippageBps returns the slippage of a fill against the signal price in bps, positive when it is a cost
+// adverseSlippageBps 返回成交价相对快照价的滑点（基点），正值表示成本
+func adverseSlippageBps(action TradeAction, signalPrice, fillPrice float64) float64 {
+	if signalPrice <= 0 || fillPrice <= 0 {
+		return 0
+	}
+	bps := (fillPrice - signalPrice) / signalPrice * 10000
+	if action == ActionSell || action == ActionCloseLong {
+		return -bps // 卖出成交价越低越差 / Selling lower is worse
+	}
+	return bps
+}
+
+// recordExecutionTiming stores the timing of a filled decision in the execution_timings table
+// recordExecutionTiming 将已成交决策的时间信息写入 execution_timings 表
+//
+// Only decisions that carry an ExecutionTiming and actually filled are recorded; fake fills of
+// test mode are skipped. Failures are only logged.
+// 只记录携带 ExecutionTiming 且实际成交的决策；测试模式的模拟成交不记录。失败只记录日志。
+func (e *BinanceExecutor) recordExecutionTiming(ctx context.Context, result *TradeResult) {
+	timing := executionTimingFrom(ctx)
+	if timing == nil || e.trades == nil || e.testMode || !result.Success || result.Price <= 0 || timing.FilledAt.IsZero() {
+		return
+	}
+
+	quantity := result.Filled
+	if quantity <= 0 {
+		quantity = result.Amount
+	}
+	slippageBps := adverseSlippageBps(result.Action, timing.SignalPrice, result.Price)
+	result.Slippage = slippageBps / 10000 * timing.SignalPrice * quantity
+
+	record := &storage.ExecutionTimingRecord{
+		SessionID:   sessionIDFrom(ctx),
+		Symbol:      e.config.GetBinanceSymbolFor(result.Symbol),
+		Action:      string(result.Action),
+		Quantity:    quantity,
+		SignalPrice: timing.SignalPrice,
+		FillPrice:   result.Price,
+		SlippageBps: slippageBps,
+		SlippageUSD: result.Slippage,
+		SignalAt:    timing.SignalAt,
+		DecisionAt:  timing.DecisionAt,
+		ValidatedAt: timing.ValidatedAt,
+		SentAt:      timing.SentAt,
+		FilledAt:    timing.FilledAt,
+		Paper:       e.paper != nil,
+	}
+	if record.SignalAt.IsZero() {
+		record.SignalAt = record.DecisionAt
+	}
+	if _, err := e.trades.SaveExecutionTiming(record); err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  保存执行延迟记录失败: %v", err))
+		return
+	}
+
+	e.logger.Info(fmt.Sprintf("⏱️  决策到成交耗时 %s（LLM %s），滑点 %+.1f bps (%+.2f USDT)",
+		record.Latency().Round(time.Millisecond), record.DecisionAt.Sub(record.SignalAt).Round(time.Millisecond),
+		slippageBps, record.SlippageUSD))
+}
@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestAdverseSlippageBps tests that slippage is positive when the fill is worse than the signal
+// TestAdverseSlippageBps 测试成交价劣于快照价时滑点为正
+func TestAdverseSlippageBps(t *testing.T) {
+	tests := []struct {
+		action TradeAction
+		fill   float64
+		want   float64
+	}{
+		{ActionBuy, 101, 100},
+		{ActionCloseShort, 99, -100},
+		{ActionSell, 99, 100},
+		{ActionCloseLong, 101, -100},
+	}
+	for _, tt := range tests {
+		if got := adverseSlippageBps(tt.action, 100, tt.fill); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("adverseSlippageBps(%s, 100, %.0f) = %.2f, want %.2f", tt.action, tt.fill, got, tt.want)
+		}
+	}
+	if got := adverseSlippageBps(ActionBuy, 0, 101); got != 0 {
+		t.Errorf("Unknown signal price should give 0, got %.2f", got)
+	}
+}
+
+// TestRecordExecutionTiming tests that the timing carried by the context is stored with its slippage
+// TestRecordExecutionTiming 测试 context 携带的时间信息连同滑点一起被保存
+func TestRecordExecutionTiming(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "latency.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	e := &BinanceExecutor{config: &config.Config{}, logger: logger.NewColorLogger(false), trades: db}
+	result := &TradeResult{Success: true, Action: ActionBuy, Symbol: "BTC/USDT", Amount: 0.5, Price: 100100}
+
+	// Without timing nothing is recorded
+	// 没有时间信息时不记录
+	e.recordExecutionTiming(context.Background(), result)
+
+	signalAt := time.Now().Add(-time.Minute)
+	timing := &ExecutionTiming{SignalAt: signalAt, SignalPrice: 100000, DecisionAt: signalAt.Add(50 * time.Second)}
+	ctx := WithExecutionTiming(WithSessionID(context.Background(), 7), timing)
+	markValidated(ctx)
+	markSent(ctx)
+	markFilled(ctx)
+	e.recordExecutionTiming(ctx, result)
+
+	timings, err := db.GetExecutionTimings("BTCUSDT", 10)
+	if err != nil || len(timings) != 1 {
+		t.Fatalf("Expected 1 timing, got %d, %v", len(timings), err)
+	}
+	got := timings[0]
+	if got.SessionID != 7 || got.Action != "BUY" || math.Abs(got.SlippageBps-10) > 1e-9 || math.Abs(got.SlippageUSD-50) > 1e-6 {
+		t.Errorf("Unexpected timing: %+v", got)
+	}
+	if got.ValidatedAt.Before(got.DecisionAt) || got.FilledAt.Before(got.SentAt) {
+		t.Errorf("Stages out of order: %+v", got)
+	}
+}
@@ -0,0 +1,270 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// liquidationGuardCooldown is how long a position is left alone after the guard acted on it
+// liquidationGuardCooldown 是监控对某个持仓采取动作后的冷却时间
+//
+// In isolated mode a reduction does not move the liquidation price, so without a cooldown the
+// guard would keep trimming the same position every check.
+// 逐仓模式下减仓不会移动强平价，没有冷却时间的话每次检查都会继续减同一个持仓。
+const liquidationGuardCooldown = 10 * time.Minute
+
+// liquidationGuardTargetMultiple is the distance restored by adding margin, as a multiple of the threshold
+// liquidationGuardTargetMultiple 是追加保证金后恢复到的距离（阈值的倍数）
+const liquidationGuardTargetMultiple = 2.0
+
+// LiquidationGuard watches the distance between the mark price and the liquidation price of every open position
+// LiquidationGuard 监控每个持仓的标记价格与强平价之间的距离
+//
+// Once a position gets within LIQUIDATION_GUARD_DISTANCE_PERCENT% of its liquidation price, the guard
+// sends an urgent notification and, depending on LIQUIDATION_GUARD_ACTION, closes
+// LIQUIDATION_GUARD_REDUCE_PERCENT% of it (reduce) or tops up its isolated margin (add_margin, falling back
+// to reduce in cross margin mode or when the balance is short). Shadow mode only alerts.
+// 当持仓距强平价不足 LIQUIDATION_GUARD_DISTANCE_PERCENT% 时，发送紧急通知，并根据 LIQUIDATION_GUARD_ACTION
+// 平掉 LIQUIDATION_GUARD_REDUCE_PERCENT% 的仓位（reduce）或追加逐仓保证金（add_margin，全仓模式或余额不足时改为减仓）。影子模式下只告警。
+type LiquidationGuard struct {
+	config   *config.Config
+	executor *BinanceExecutor
+	stopLoss *StopLossManager
+	notifier notify.Notifier
+	logger   *logger.ColorLogger
+
+	mu       sync.Mutex
+	lastActs map[string]time.Time // 每个持仓最近一次触发的时间 / When the guard last acted on each position
+}
+
+// NewLiquidationGuard creates a new LiquidationGuard
+// NewLiquidationGuard 创建新的强平距离监控
+func NewLiquidationGuard(cfg *config.Config, executor *BinanceExecutor, stopLoss *StopLossManager, notifier notify.Notifier, log *logger.ColorLogger) *LiquidationGuard {
+	return &LiquidationGuard{
+		config:   cfg,
+		executor: executor,
+		stopLoss: stopLoss,
+		notifier: notifier,
+		logger:   log,
+		lastActs: make(map[string]time.Time),
+	}
+}
+
+// liquidationDistance returns how far the mark price is from the liquidation price, in percent of the mark price
+// liquidationDistance 返回标记价格距强平价的距离（占标记价格的百分比）
+//
+// ok is false when there is no liquidation price, e.g. a position fully covered by the wallet in cross margin.
+// 没有强平价时（例如全仓模式下钱包足以覆盖的持仓）ok 为 false。
+func liquidationDistance(side string, mark, liquidation float64) (distance float64, ok bool) {
+	if mark <= 0 || liquidation <= 0 {
+		return 0, false
+	}
+	if side == "short" {
+		return (liquidation - mark) / mark * 100, true
+	}
+	return (mark - liquidation) / mark * 100, true
+}
+
+// marginToRestore returns the isolated margin (rounded up to cents) that moves the liquidation price back
+// to target% away from the mark price
+// marginToRestore 返回使强平价恢复到距标记价格 target% 所需的逐仓保证金（向上取整到分）
+//
+// Each unit of margin moves the liquidation price by 1/quantity, ignoring the maintenance margin rate.
+// 每单位保证金使强平价移动 1/数量（忽略维持保证金率）。
+func marginToRestore(quantity, mark, distance, target float64) float64 {
+	if quantity <= 0 || mark <= 0 || distance >= target {
+		return 0
+	}
+	return math.Ceil(quantity*mark*(target-distance)/100*100) / 100
+}
+
+// Check inspects the positions of CRYPTO_SYMBOLS and acts on those too close to liquidation
+// Check 检查 CRYPTO_SYMBOLS 的持仓，对距强平价过近的持仓采取动作
+func (g *LiquidationGuard) Check(ctx context.Context) {
+	for _, symbol := range g.config.CryptoSymbols {
+		positions, err := g.executor.GetCurrentPositions(ctx, symbol)
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  获取 %s 持仓失败，跳过强平距离检查: %v", symbol, err))
+			continue
+		}
+		for _, pos := range positions {
+			g.checkPosition(ctx, symbol, pos)
+		}
+	}
+}
+
+// checkPosition acts on one position if it is within the threshold and not cooling down
+// checkPosition 持仓距强平价低于阈值且不在冷却期时采取动作
+func (g *LiquidationGuard) checkPosition(ctx context.Context, symbol string, pos *Position) {
+	mark := pos.CurrentPrice
+	if mark <= 0 {
+		price, err := g.executor.GetCurrentPrice(ctx, g.config.GetBinanceSymbolFor(symbol))
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️  获取 %s 价格失败，跳过强平距离检查: %v", symbol, err))
+			return
+		}
+		mark = price
+	}
+
+	threshold := g.config.LiquidationGuardDistancePercent
+	distance, ok := liquidationDistance(pos.Side, mark, pos.LiquidationPrice)
+	if !ok || distance >= threshold {
+		return
+	}
+
+	key := positionKey(g.config.GetBinanceSymbolFor(symbol), pos.Side)
+	g.mu.Lock()
+	if last, exists := g.lastActs[key]; exists && time.Since(last) < liquidationGuardCooldown {
+		g.mu.Unlock()
+		return
+	}
+	g.lastActs[key] = time.Now()
+	g.mu.Unlock()
+
+	g.logger.Warning(fmt.Sprintf("🚨 %s %s 距强平价仅 %.2f%%（标记价 $%.2f，强平价 $%.2f，阈值 %.2f%%）",
+		symbol, pos.Side, distance, mark, pos.LiquidationPrice, threshold))
+
+	var outcome string
+	switch {
+	case g.config.ShadowMode:
+		outcome = "影子模式：仅告警，未自动处理"
+	case g.config.LiquidationGuardAction == "alert":
+		outcome = "仅告警，未自动处理"
+	case g.config.LiquidationGuardAction == "add_margin":
+		amount, err := g.addMargin(ctx, symbol, pos, mark, distance)
+		if err == nil {
+			outcome = fmt.Sprintf("已追加逐仓保证金 %.2f", amount)
+			break
+		}
+		g.logger.Warning(fmt.Sprintf("⚠️  %s 追加保证金失败，改为减仓: %v", symbol, err))
+		outcome = g.reduce(ctx, symbol, pos, distance)
+	default:
+		outcome = g.reduce(ctx, symbol, pos, distance)
+	}
+
+	if err := g.notifier.Notify(ctx, notify.Message{
+		Title: "强平预警",
+		Text: fmt.Sprintf("%s %s 距强平价仅 %.2f%%（标记价 $%.2f，强平价 $%.2f）\n处理: %s",
+			symbol, pos.Side, distance, mark, pos.LiquidationPrice, outcome),
+		Level: notify.LevelError,
+	}); err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// reduce closes LIQUIDATION_GUARD_REDUCE_PERCENT% of the position and describes the outcome
+// reduce 平掉 LIQUIDATION_GUARD_REDUCE_PERCENT% 的仓位并返回处理结果描述
+//
+// A managed position goes through the StopLossManager so its protective orders are re-placed for the
+// remaining quantity; an unmanaged one is closed directly.
+// 受管持仓通过止损管理器减仓，以便按剩余数量重挂保护单；非受管持仓直接部分平仓。
+func (g *LiquidationGuard) reduce(ctx context.Context, symbol string, pos *Position, distance float64) string {
+	percent := g.config.LiquidationGuardReducePercent
+	reason := fmt.Sprintf("强平预警减仓：距强平价仅 %.2f%%", distance)
+
+	if g.stopLoss != nil && g.stopLoss.GetPositionSide(symbol, pos.Side) != nil {
+		result, err := g.stopLoss.ReducePosition(ctx, symbol, pos.Side, percent, reason)
+		if err != nil {
+			g.logger.Error(fmt.Sprintf("❌ %s 强平预警减仓失败: %v", symbol, err))
+			return fmt.Sprintf("减仓失败: %v", err)
+		}
+		return result.Message
+	}
+
+	quantity, err := g.executor.AdjustQuantityPrecision(ctx, symbol, pos.Size*percent/100)
+	if err != nil || quantity <= 0 {
+		g.logger.Error(fmt.Sprintf("❌ %s 强平预警减仓数量无效: %.4f (%v)", symbol, quantity, err))
+		return "减仓失败：数量无效"
+	}
+	result := g.executor.ClosePartialPosition(ctx, symbol, pos.Side, quantity, reason)
+	if !result.Success {
+		g.logger.Error(fmt.Sprintf("❌ %s 强平预警减仓失败: %s", symbol, result.Message))
+		return fmt.Sprintf("减仓失败: %s", result.Message)
+	}
+	return fmt.Sprintf("减仓 %.0f%%: 平仓 %.4f @ $%.2f", percent, quantity, result.Price)
+}
+
+// addMargin tops up the isolated margin of the position so its liquidation price moves back to twice the threshold
+// addMargin 追加持仓的逐仓保证金，使强平距离恢复到阈值的两倍
+func (g *LiquidationGuard) addMargin(ctx context.Context, symbol string, pos *Position, mark, distance float64) (float64, error) {
+	marginType, _ := g.executor.DetectMarginType(ctx, symbol)
+	if marginType != MarginTypeIsolated {
+		return 0, fmt.Errorf("%s 不是逐仓模式", symbol)
+	}
+
+	amount := marginToRestore(pos.Size, mark, distance, g.config.LiquidationGuardDistancePercent*liquidationGuardTargetMultiple)
+	available, err := g.executor.GetBalanceFor(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if amount > available {
+		return 0, fmt.Errorf("可用余额不足：需要 %.2f，可用 %.2f", amount, available)
+	}
+	if err := g.executor.AddIsolatedMargin(ctx, symbol, pos.Side, amount); err != nil {
+		return 0, err
+	}
+	g.logger.Success(fmt.Sprintf("【%s】✅ 已追加逐仓保证金 %.2f", symbol, amount))
+	return amount, nil
+}
+
+// Run checks every interval until ctx is done
+// Run 每隔 interval 检查一次，直到 ctx 结束
+func (g *LiquidationGuard) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Check(ctx)
+		}
+	}
+}
+
+// AddIsolatedMargin adds amount of margin to the isolated position on side
+// AddIsolatedMargin 为指定方向的逐仓持仓追加 amount 保证金
+//
+// The paper wallet uses cross margin accounting, so paper trading does not support it.
+// 模拟盘使用全仓保证金计算，因此不支持追加逐仓保证金。
+func (e *BinanceExecutor) AddIsolatedMargin(ctx context.Context, symbol, side string, amount float64) error {
+	if e.paper != nil {
+		return fmt.Errorf("模拟盘不支持追加逐仓保证金")
+	}
+	if e.InMaintenance() {
+		return fmt.Errorf("交易所维护中，暂停追加保证金")
+	}
+	if e.testMode {
+		e.logger.Warning(fmt.Sprintf("测试模式 - 仅模拟追加保证金 %.2f，不实际调用", amount))
+		return nil
+	}
+
+	e.DetectPositionMode(ctx)
+	positionSide := futures.PositionSideType(strings.ToUpper(side))
+	if e.positionMode == PositionModeOneWay {
+		positionSide = futures.PositionSideTypeBoth
+	}
+
+	// Not retried: a timed-out request may still have been applied
+	// 不重试：超时的请求可能已经生效
+	err := e.client.NewUpdatePositionMarginService().
+		Symbol(e.config.GetBinanceSymbolFor(symbol)).
+		PositionSide(positionSide).
+		Amount(fmt.Sprintf("%.2f", amount)).
+		Type(1).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add isolated margin: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+// TestLiquidationDistance tests the distance to the liquidation price and the margin needed to restore it
+// TestLiquidationDistance 测试距强平价的距离以及恢复距离所需的保证金
+func TestLiquidationDistance(t *testing.T) {
+	tests := []struct {
+		name        string
+		side        string
+		mark        float64
+		liquidation float64
+		want        float64
+		wantOK      bool
+	}{
+		{"long", "long", 100, 95, 5, true},
+		{"short", "short", 100, 104, 4, true},
+		{"long past liquidation", "long", 100, 101, -1, true},
+		{"no liquidation price", "long", 100, 0, 0, false},
+		{"no mark price", "short", 0, 104, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := liquidationDistance(tt.side, tt.mark, tt.liquidation)
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: liquidationDistance = %.4f, %v, want %.4f, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if got := marginToRestore(2, 100, 3, 10); math.Abs(got-14) > 1e-9 {
+		t.Errorf("Expected 14 USDT of margin, got %.4f", got)
+	}
+	if got := marginToRestore(0.003, 100, 3, 10); math.Abs(got-0.03) > 1e-9 {
+		t.Errorf("Expected the margin to be rounded up to cents, got %.4f", got)
+	}
+	if got := marginToRestore(2, 100, 12, 10); got != 0 {
+		t.Errorf("Expected no margin beyond the target, got %.4f", got)
+	}
+}
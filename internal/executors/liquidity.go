@@ -0,0 +1,117 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// liquidityDepthLimit is the number of order book levels fetched by the liquidity guard
+// liquidityDepthLimit 是流动性检查读取的订单簿档位数
+const liquidityDepthLimit = 100
+
+// bookLevel is a price level of the order book
+// bookLevel 是订单簿中的一档价格
+type bookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// liquidityEstimate is the expected cost of taking a quantity from the order book
+// liquidityEstimate 是按数量吃单的预计成本
+type liquidityEstimate struct {
+	SpreadBps float64 // 买卖价差（相对中间价的基点）/ Bid/ask spread in bps of the mid price
+	ImpactBps float64 // 成交均价相对中间价的不利偏离（基点，含半个价差）/ Adverse distance of the average fill from the mid, in bps (half the spread included)
+	AvgPrice  float64 // 预计成交均价 / Expected average fill price
+	Filled    bool    // 盘口深度是否足以成交全部数量 / Whether the book can fill the whole quantity
+}
+
+// estimateLiquidity walks the levels a market order takes (asks for a buy, bids for a sell) for quantity
+// estimateLiquidity 按数量遍历市价单会吃掉的档位（买单吃卖盘，卖单吃买盘）
+func estimateLiquidity(bestBid, bestAsk float64, levels []bookLevel, quantity float64, buy bool) liquidityEstimate {
+	mid := (bestBid + bestAsk) / 2
+	estimate := liquidityEstimate{}
+	if mid <= 0 || quantity <= 0 {
+		return estimate
+	}
+	estimate.SpreadBps = (bestAsk - bestBid) / mid * 10000
+
+	remaining, cost := quantity, 0.0
+	for _, level := range levels {
+		take := math.Min(remaining, level.Quantity)
+		cost += take * level.Price
+		remaining -= take
+		if remaining <= 1e-12 {
+			estimate.Filled = true
+			break
+		}
+	}
+	if filled := quantity - remaining; filled > 0 {
+		estimate.AvgPrice = cost / filled
+	}
+
+	impact := (estimate.AvgPrice - mid) / mid * 10000
+	if !buy {
+		impact = -impact
+	}
+	estimate.ImpactBps = impact
+	return estimate
+}
+
+// checkLiquidity refuses a market entry whose spread or expected impact exceeds MAX_SPREAD_BPS / MAX_IMPACT_BPS
+// checkLiquidity 价差或预计冲击超过 MAX_SPREAD_BPS / MAX_IMPACT_BPS 时拒绝市价开仓
+//
+// The entry is also refused when the fetched levels cannot fill the whole quantity. Like the funding
+// guard it fails open: if the order book cannot be fetched the entry is allowed with a warning.
+// 获取到的档位不足以成交全部数量时同样拒绝。与资金费检查一样失败时放行：无法获取订单簿时仅记录警告并允许开仓。
+func (tc *TradeCoordinator) checkLiquidity(ctx context.Context, symbol string, action TradeAction, quantity float64) error {
+	if tc.config.MaxSpreadBps <= 0 && tc.config.MaxImpactBps <= 0 {
+		return nil
+	}
+
+	depth, err := tc.executor.client.NewDepthService().
+		Symbol(tc.config.GetBinanceSymbolFor(symbol)).
+		Limit(liquidityDepthLimit).
+		Do(ctx)
+	if err != nil || len(depth.Bids) == 0 || len(depth.Asks) == 0 {
+		tc.logger.Warning(fmt.Sprintf("⚠️  获取 %s 订单簿失败: %v，跳过流动性检查", symbol, err))
+		return nil
+	}
+
+	bids := make([]bookLevel, 0, len(depth.Bids))
+	for _, bid := range depth.Bids {
+		price, _ := parseFloat(bid.Price)
+		qty, _ := parseFloat(bid.Quantity)
+		bids = append(bids, bookLevel{Price: price, Quantity: qty})
+	}
+	asks := make([]bookLevel, 0, len(depth.Asks))
+	for _, ask := range depth.Asks {
+		price, _ := parseFloat(ask.Price)
+		qty, _ := parseFloat(ask.Quantity)
+		asks = append(asks, bookLevel{Price: price, Quantity: qty})
+	}
+
+	buy := action == ActionBuy
+	levels := bids
+	if buy {
+		levels = asks
+	}
+	estimate := estimateLiquidity(bids[0].Price, asks[0].Price, levels, quantity, buy)
+
+	if tc.config.MaxSpreadBps > 0 && estimate.SpreadBps > tc.config.MaxSpreadBps {
+		return fmt.Errorf("%s 买卖价差 %.1f bps 超过上限 %.1f bps", symbol, estimate.SpreadBps, tc.config.MaxSpreadBps)
+	}
+	if tc.config.MaxImpactBps > 0 {
+		if !estimate.Filled {
+			return fmt.Errorf("%s 盘口前 %d 档不足以成交 %.4f", symbol, liquidityDepthLimit, quantity)
+		}
+		if estimate.ImpactBps > tc.config.MaxImpactBps {
+			return fmt.Errorf("%s 按 %.4f 吃单预计冲击 %.1f bps（均价 $%.4f）超过上限 %.1f bps",
+				symbol, quantity, estimate.ImpactBps, estimate.AvgPrice, tc.config.MaxImpactBps)
+		}
+	}
+
+	tc.logger.Info(fmt.Sprintf("  ✓ 盘口流动性: 价差 %.1f bps，预计冲击 %.1f bps（均价 $%.4f）",
+		estimate.SpreadBps, estimate.ImpactBps, estimate.AvgPrice))
+	return nil
+}
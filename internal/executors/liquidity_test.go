@@ -0,0 +1,39 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEstimateLiquidity tests the spread and the impact of market orders walking the order book
+// TestEstimateLiquidity 测试市价单遍历订单簿时的价差和冲击
+func TestEstimateLiquidity(t *testing.T) {
+	bids := []bookLevel{{Price: 99.9, Quantity: 1}, {Price: 99.8, Quantity: 2}}
+	asks := []bookLevel{{Price: 100.1, Quantity: 1}, {Price: 100.3, Quantity: 1}}
+
+	tests := []struct {
+		name     string
+		levels   []bookLevel
+		quantity float64
+		buy      bool
+		impact   float64
+		avg      float64
+		filled   bool
+	}{
+		{"buy within the best ask", asks, 0.5, true, 10, 100.1, true},
+		{"buy walking two levels", asks, 2, true, 20, 100.2, true},
+		{"sell walking two levels", bids, 3, false, 16.666666666666, 99.833333333333, true},
+		{"buy larger than the book", asks, 3, true, 20, 100.2, false},
+	}
+
+	for _, tt := range tests {
+		estimate := estimateLiquidity(99.9, 100.1, tt.levels, tt.quantity, tt.buy)
+		if math.Abs(estimate.SpreadBps-20) > 1e-9 {
+			t.Errorf("%s: spread %.4f bps, want 20", tt.name, estimate.SpreadBps)
+		}
+		if math.Abs(estimate.ImpactBps-tt.impact) > 1e-6 || math.Abs(estimate.AvgPrice-tt.avg) > 1e-6 || estimate.Filled != tt.filled {
+			t.Errorf("%s: impact %.4f bps @ %.4f (filled %v), want %.4f bps @ %.4f (filled %v)",
+				tt.name, estimate.ImpactBps, estimate.AvgPrice, estimate.Filled, tt.impact, tt.avg, tt.filled)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// systemStatusURL is Binance's public mainnet system status endpoint (status 0 = normal, 1 = maintenance)
+// systemStatusURL 是币安主网公开的系统状态接口（status 0 = 正常，1 = 维护中）
+const systemStatusURL = "https://api.binance.com/sapi/v1/system/status"
+
+// systemStatusMaintenance is the status value reported during system maintenance
+// systemStatusMaintenance 是系统维护期间返回的状态值
+const systemStatusMaintenance = 1
+
+// MaintenanceMonitor tracks Binance scheduled maintenance so order placement can pause
+// MaintenanceMonitor 跟踪币安计划维护状态，以便维护期间暂停下单
+//
+// While maintenance is active, ExecuteTrade and partial closes are refused and existing stop-loss
+// orders are left in place rather than replaced, so positions are never unprotected mid-swap.
+// A failed status check keeps the last known state. Only maintenance in progress is detected: the
+// status endpoint doesn't announce upcoming windows, so orders sent before the first check inside
+// a window can still fail.
+// 维护期间拒绝 ExecuteTrade 和部分平仓，且保留现有止损单而不替换，避免替换过程中持仓失去保护。
+// 状态检查失败时保持上一次已知的状态。只能检测正在进行的维护：状态接口不会预告计划维护，
+// 维护开始后首次检查前发出的订单仍可能失败。
+type MaintenanceMonitor struct {
+	url    string
+	client *http.Client
+	logger *logger.ColorLogger
+
+	mu     sync.RWMutex
+	active bool      // 是否处于维护中 / Whether maintenance is in progress
+	since  time.Time // 维护开始（首次检测到）的时间 / When maintenance was first detected
+}
+
+// SystemStatusURL returns the status endpoint to poll, or "" when maintenance checks don't apply
+// SystemStatusURL 返回要轮询的系统状态接口，不适用维护检查时返回 ""
+//
+// Paper trading sends no exchange orders and is never checked. The mainnet endpoint is used for
+// live trading; testnet has no status endpoint, so it is only checked when MAINTENANCE_STATUS_URL is set.
+// 模拟盘不向交易所下单，从不检查。实盘使用主网接口；测试网没有状态接口，仅在设置 MAINTENANCE_STATUS_URL 时检查。
+func SystemStatusURL(cfg *config.Config) string {
+	switch {
+	case cfg.PaperTrading:
+		return ""
+	case cfg.MaintenanceStatusURL != "":
+		return cfg.MaintenanceStatusURL
+	case cfg.BinanceTestMode:
+		return ""
+	}
+	return systemStatusURL
+}
+
+// NewMaintenanceMonitor creates a new MaintenanceMonitor using the executor's HTTP client (proxy settings)
+// NewMaintenanceMonitor 创建新的维护监控器，使用执行器的 HTTP 客户端（代理设置）
+//
+// Callers should skip the monitor when SystemStatusURL returns "".
+// SystemStatusURL 返回 "" 时调用方应不启用监控器。
+func NewMaintenanceMonitor(executor *BinanceExecutor, log *logger.ColorLogger) *MaintenanceMonitor {
+	client := executor.client.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &MaintenanceMonitor{
+		url:    SystemStatusURL(executor.config),
+		client: client,
+		logger: log,
+	}
+}
+
+// Active reports whether the exchange is in maintenance (nil-safe)
+// Active 返回交易所是否处于维护中（nil 安全）
+func (m *MaintenanceMonitor) Active() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// Since returns when the current maintenance was first detected (zero when not in maintenance)
+// Since 返回当前维护首次被检测到的时间（未维护时为零值）
+func (m *MaintenanceMonitor) Since() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.since
+}
+
+// Check queries the system status and updates the maintenance state
+// Check 查询系统状态并更新维护状态
+//
+// Returns how long trading was paused when this check ends a maintenance window, 0 otherwise.
+// 本次检查结束维护时返回暂停交易的时长，否则返回 0。
+func (m *MaintenanceMonitor) Check(ctx context.Context) (time.Duration, error) {
+	status, msg, err := m.fetchStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return m.update(status == systemStatusMaintenance, msg, time.Now()), nil
+}
+
+// update applies a status observation and logs transitions
+// update 应用一次状态观测并记录状态切换
+func (m *MaintenanceMonitor) update(maintenance bool, msg string, now time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case maintenance && !m.active:
+		m.active = true
+		m.since = now
+		m.logger.Warning(fmt.Sprintf("🚧 币安系统维护中 (%s)，暂停下单，现有止损单保持不变", msg))
+	case !maintenance && m.active:
+		gap := now.Sub(m.since)
+		m.logger.Success(fmt.Sprintf("✅ 币安系统维护结束，恢复下单（暂停 %s，%s - %s）",
+			gap.Round(time.Second), m.since.Format("15:04:05"), now.Format("15:04:05")))
+		m.active = false
+		m.since = time.Time{}
+		return gap
+	}
+	return 0
+}
+
+// fetchStatus calls the system status endpoint
+// fetchStatus 调用系统状态接口
+func (m *MaintenanceMonitor) fetchStatus(ctx context.Context) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get system status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("unexpected system status response: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Status int    `json:"status"`
+		Msg    string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, "", fmt.Errorf("failed to decode system status: %w", err)
+	}
+	return body.Status, body.Msg, nil
+}
+
+// Run checks every interval until ctx is done
+// Run 每隔 interval 检查一次，直到 ctx 结束
+func (m *MaintenanceMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Check(ctx); err != nil {
+				m.logger.Warning(fmt.Sprintf("⚠️  检查币安系统状态失败: %v", err))
+			}
+		}
+	}
+}
+
+// SetMaintenanceMonitor pauses order placement while the monitor reports maintenance
+// SetMaintenanceMonitor 在监控器报告维护期间暂停下单
+func (e *BinanceExecutor) SetMaintenanceMonitor(m *MaintenanceMonitor) {
+	e.maintenance = m
+}
+
+// InMaintenance reports whether order placement is paused for exchange maintenance (nil-safe)
+// InMaintenance 返回是否因交易所维护而暂停下单（nil 安全）
+func (e *BinanceExecutor) InMaintenance() bool {
+	return e != nil && e.maintenance.Active()
+}
@@ -0,0 +1,111 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestMaintenancePausesOrders tests that orders pause during maintenance and resume afterwards
+// TestMaintenancePausesOrders 测试维护期间暂停下单、维护结束后恢复
+func TestMaintenancePausesOrders(t *testing.T) {
+	var status atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status.Load() < 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"status": %d, "msg": "test"}`, status.Load())
+	}))
+	defer server.Close()
+
+	log := logger.NewColorLogger(false)
+	e := &BinanceExecutor{config: &config.Config{}, testMode: true, logger: log}
+	m := &MaintenanceMonitor{url: server.URL, client: server.Client(), logger: log}
+	e.SetMaintenanceMonitor(m)
+	ctx := context.Background()
+
+	status.Store(systemStatusMaintenance)
+	if _, err := m.Check(ctx); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !e.InMaintenance() {
+		t.Fatal("Expected maintenance to be detected")
+	}
+
+	result := e.ExecuteTrade(ctx, "BTCUSDT", ActionBuy, 0.01, "test")
+	if result.Success {
+		t.Error("Orders should be refused during maintenance")
+	}
+	if result := e.ClosePartialPosition(ctx, "BTCUSDT", "long", 0.01, "test"); result.Success {
+		t.Error("Partial closes should be refused during maintenance")
+	}
+
+	// 检查失败时保持维护状态
+	status.Store(-1)
+	if _, err := m.Check(ctx); err == nil {
+		t.Fatal("Expected an error for a failed status check")
+	}
+	if !e.InMaintenance() {
+		t.Fatal("A failed check should keep the last known state")
+	}
+
+	status.Store(0)
+	gap, err := m.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if gap <= 0 || e.InMaintenance() || !m.Since().IsZero() {
+		t.Fatalf("Expected maintenance to end with a gap, got %s", gap)
+	}
+}
+
+// TestMaintenanceGap tests the reported pause duration
+// TestMaintenanceGap 测试记录的暂停时长
+func TestMaintenanceGap(t *testing.T) {
+	m := &MaintenanceMonitor{logger: logger.NewColorLogger(false)}
+	start := time.Date(2025, 1, 2, 1, 0, 0, 0, time.UTC)
+
+	if gap := m.update(true, "upgrade", start); gap != 0 {
+		t.Errorf("Entering maintenance should report no gap, got %s", gap)
+	}
+	if gap := m.update(true, "upgrade", start.Add(10*time.Minute)); gap != 0 || !m.Since().Equal(start) {
+		t.Errorf("Repeated maintenance status should keep the start time")
+	}
+	if gap := m.update(false, "normal", start.Add(45*time.Minute)); gap != 45*time.Minute {
+		t.Errorf("gap = %s, want 45m", gap)
+	}
+
+	var nilMonitor *MaintenanceMonitor
+	if nilMonitor.Active() {
+		t.Error("A nil monitor should never report maintenance")
+	}
+}
+
+// TestSystemStatusURL tests that the status endpoint follows the trading mode
+// TestSystemStatusURL 测试系统状态接口随交易模式选择
+func TestSystemStatusURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{"live", config.Config{}, systemStatusURL},
+		{"testnet", config.Config{BinanceTestMode: true}, ""},
+		{"testnet with endpoint", config.Config{BinanceTestMode: true, MaintenanceStatusURL: "http://status.test"}, "http://status.test"},
+		{"live with endpoint", config.Config{MaintenanceStatusURL: "http://status.test"}, "http://status.test"},
+		{"paper", config.Config{PaperTrading: true, MaintenanceStatusURL: "http://status.test"}, ""},
+	}
+	for _, tt := range tests {
+		if got := SystemStatusURL(&tt.cfg); got != tt.want {
+			t.Errorf("%s: SystemStatusURL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
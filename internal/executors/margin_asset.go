@@ -0,0 +1,125 @@
+package executors
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// MarginAssetFor returns the margin asset of a symbol from exchangeInfo, falling back to its quote asset
+// MarginAssetFor 根据 exchangeInfo 返回交易对的保证金资产，未知时使用其计价资产
+//
+// The paper wallet holds QUOTE_ASSET only, so paper trading always uses it.
+// 模拟盘钱包只持有 QUOTE_ASSET，因此模拟盘始终使用该资产。
+func (e *BinanceExecutor) MarginAssetFor(ctx context.Context, symbol string) string {
+	if e.paper != nil {
+		return e.config.QuoteAssetFor("")
+	}
+	if f := e.SymbolFiltersFor(ctx, symbol); f.MarginAsset != "" {
+		return f.MarginAsset
+	}
+	return e.config.QuoteAssetFor(symbol)
+}
+
+// MarginAssets returns the distinct margin assets of CRYPTO_SYMBOLS, QUOTE_ASSET first
+// MarginAssets 返回 CRYPTO_SYMBOLS 中不重复的保证金资产，QUOTE_ASSET 在最前
+func (e *BinanceExecutor) MarginAssets(ctx context.Context) []string {
+	assets := []string{e.config.QuoteAssetFor("")}
+	for _, symbol := range e.config.CryptoSymbols {
+		if asset := e.MarginAssetFor(ctx, symbol); !slices.Contains(assets, asset) {
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// AssetBalance sums the available and wallet balance of the given assets; found is false when the account holds none of them
+// AssetBalance 汇总指定资产的可用余额和钱包余额；账户中不存在任一资产时 found 为 false
+//
+// The supported quote assets are all USD stablecoins, so their balances are added at par.
+// 支持的计价资产都是美元稳定币，因此余额按 1:1 相加。
+func AssetBalance(account *futures.Account, assets ...string) (available, wallet float64, found bool) {
+	for _, asset := range account.Assets {
+		if !slices.Contains(assets, asset.Asset) {
+			continue
+		}
+		free, _ := parseFloat(asset.AvailableBalance)
+		total, _ := parseFloat(asset.WalletBalance)
+		available += free
+		wallet += total
+		found = true
+	}
+	return available, wallet, found
+}
+
+// marginBalance returns the balance of the margin assets in use and their label, e.g. "USDT" or "USDT+USDC"
+// marginBalance 返回正在使用的保证金资产余额及其名称，例如 "USDT" 或 "USDT+USDC"
+func (e *BinanceExecutor) marginBalance(ctx context.Context, account *futures.Account) (available, wallet float64, label string) {
+	assets := e.MarginAssets(ctx)
+	available, wallet, _ = AssetBalance(account, assets...)
+	return available, wallet, strings.Join(assets, "+")
+}
+
+// AccountRisk is the margin state of the margin assets in use, with the per-asset breakdown of the wallet
+// AccountRisk 表示正在使用的保证金资产的保证金状态，以及钱包的分资产明细
+type AccountRisk struct {
+	MarginBalance     float64                // 保证金余额（钱包余额 + 未实现盈亏）/ Margin balance (wallet balance + unrealized PnL)
+	MaintenanceMargin float64                // 维持保证金 / Maintenance margin
+	MarginRatio       float64                // 保证金率 %（维持保证金 / 保证金余额，100% 触发强平）/ Margin ratio % (liquidation at 100%)
+	Assets            []storage.BalanceAsset // 余额或未实现盈亏不为 0 的资产 / Assets with a balance or unrealized PnL
+}
+
+// AccountRiskOf computes the account risk of the given margin assets; the breakdown lists every held asset
+// AccountRiskOf 计算指定保证金资产的账户风险；分资产明细包含所有持有的资产
+//
+// Like AssetBalance, the stablecoin margin assets are added at par.
+// 与 AssetBalance 一样，稳定币保证金资产按 1:1 相加。
+func AccountRiskOf(account *futures.Account, assets ...string) AccountRisk {
+	var risk AccountRisk
+	for _, asset := range account.Assets {
+		wallet, _ := parseFloat(asset.WalletBalance)
+		available, _ := parseFloat(asset.AvailableBalance)
+		pnl, _ := parseFloat(asset.UnrealizedProfit)
+		if wallet != 0 || pnl != 0 {
+			risk.Assets = append(risk.Assets, storage.BalanceAsset{
+				Asset:         asset.Asset,
+				Wallet:        wallet,
+				Available:     available,
+				UnrealizedPnL: pnl,
+			})
+		}
+		if !slices.Contains(assets, asset.Asset) {
+			continue
+		}
+		marginBalance, _ := parseFloat(asset.MarginBalance)
+		maintMargin, _ := parseFloat(asset.MaintMargin)
+		risk.MarginBalance += marginBalance
+		risk.MaintenanceMargin += maintMargin
+	}
+
+	switch {
+	case risk.MarginBalance > 0:
+		risk.MarginRatio = risk.MaintenanceMargin / risk.MarginBalance * 100
+	case risk.MaintenanceMargin > 0:
+		risk.MarginRatio = 100 // 保证金余额耗尽 / Margin balance exhausted
+	}
+	return risk
+}
+
+// MarginRatioLevel returns the risk level label of a margin ratio
+// MarginRatioLevel 返回保证金率对应的风险等级
+func MarginRatioLevel(ratio float64) string {
+	switch {
+	case ratio < 30:
+		return "✅ 安全"
+	case ratio < 50:
+		return "⚠️ 谨慎"
+	case ratio < 80:
+		return "🚨 警戒"
+	default:
+		return "❌ 危险"
+	}
+}
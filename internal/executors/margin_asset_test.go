@@ -0,0 +1,41 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// TestAccountRiskOf tests the margin ratio of the margin assets in use and the per-asset breakdown
+// TestAccountRiskOf 测试正在使用的保证金资产的保证金率以及分资产明细
+func TestAccountRiskOf(t *testing.T) {
+	account := &futures.Account{
+		Assets: []*futures.AccountAsset{
+			{Asset: "USDT", WalletBalance: "800", AvailableBalance: "500", UnrealizedProfit: "-50", MarginBalance: "750", MaintMargin: "15"},
+			{Asset: "USDC", WalletBalance: "200", AvailableBalance: "200", UnrealizedProfit: "0", MarginBalance: "200", MaintMargin: "0"},
+			{Asset: "BNB", WalletBalance: "0", AvailableBalance: "0", UnrealizedProfit: "0", MarginBalance: "0", MaintMargin: "0"},
+		},
+	}
+
+	risk := AccountRiskOf(account, "USDT")
+	if risk.MarginBalance != 750 || risk.MaintenanceMargin != 15 || math.Abs(risk.MarginRatio-2) > 1e-9 {
+		t.Errorf("Unexpected USDT risk: %+v", risk)
+	}
+	if len(risk.Assets) != 2 || risk.Assets[0].Asset != "USDT" || risk.Assets[1].Asset != "USDC" {
+		t.Errorf("Expected the breakdown to list the held USDT and USDC, got %+v", risk.Assets)
+	}
+
+	if risk := AccountRiskOf(account, "USDT", "USDC"); risk.MarginBalance != 950 || math.Abs(risk.MarginRatio-15.0/950*100) > 1e-9 {
+		t.Errorf("Expected USDT and USDC to be added at par, got %+v", risk)
+	}
+
+	exhausted := &futures.Account{Assets: []*futures.AccountAsset{{Asset: "USDT", MarginBalance: "-5", MaintMargin: "10"}}}
+	if risk := AccountRiskOf(exhausted, "USDT"); risk.MarginRatio != 100 {
+		t.Errorf("Expected a 100%% margin ratio once the margin balance is exhausted, got %.2f", risk.MarginRatio)
+	}
+
+	if MarginRatioLevel(10) != "✅ 安全" || MarginRatioLevel(90) != "❌ 危险" {
+		t.Error("Unexpected margin ratio levels")
+	}
+}
@@ -0,0 +1,992 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// paperMaintMarginRate approximates Binance's lowest-tier maintenance margin rate
+// paperMaintMarginRate 近似币安最低档位的维持保证金率
+const paperMaintMarginRate = 0.004
+
+// PaperExecutor simulates futures trading locally against live market data
+// PaperExecutor 基于实时行情在本地模拟合约交易
+//
+// Balance, leverage, positions and stop-market orders are persisted in SQLite,
+// so the stop-loss manager, portfolio manager and web UI behave exactly as in live trading.
+// 余额、杠杆、持仓和止损市价单都保存在 SQLite 中，
+// 因此止损管理器、组合管理器和 Web 界面的行为与实盘完全一致。
+type PaperExecutor struct {
+	config  *config.Config
+	client  *futures.Client // 仅用于公共行情接口 / Public market data endpoints only
+	logger  *logger.ColorLogger
+	storage *storage.Storage
+
+	// checkedAt tracks liquidation checks per symbol (klines checked up to)
+	// checkedAt 记录每个交易对强平检查的进度（K 线已检查到的时间）
+	checkedAt map[string]time.Time
+	mu        sync.Mutex
+}
+
+// NewPaperExecutor creates a new PaperExecutor
+// NewPaperExecutor 创建一个新的模拟盘执行器
+func NewPaperExecutor(cfg *config.Config, client *futures.Client, log *logger.ColorLogger, db *storage.Storage) *PaperExecutor {
+	return &PaperExecutor{
+		config:    cfg,
+		client:    client,
+		logger:    log,
+		storage:   db,
+		checkedAt: make(map[string]time.Time),
+	}
+}
+
+// SetLeverage stores the simulated leverage for a symbol
+// SetLeverage 保存交易对的模拟杠杆
+func (p *PaperExecutor) SetLeverage(symbol string, leverage int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.storage.SetPaperLeverage(p.config.GetBinanceSymbolFor(symbol), leverage)
+}
+
+// GetPosition returns the simulated position for a symbol after settling pending events
+// GetPosition 结算待处理事件后返回交易对的模拟持仓
+func (p *PaperExecutor) GetPosition(ctx context.Context, symbol string) (*Position, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if err := p.settle(ctx, binanceSymbol); err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败: %v", err))
+	}
+
+	pos, err := p.storage.GetPaperPosition(binanceSymbol)
+	if err != nil {
+		return nil, err
+	}
+	if pos == nil {
+		return nil, nil
+	}
+
+	price, err := p.lastPrice(ctx, binanceSymbol)
+	if err != nil {
+		price = pos.EntryPrice
+	}
+
+	return p.toPosition(pos, price), nil
+}
+
+// GetPositions returns every simulated position, each settled like GetPosition
+// GetPositions 返回所有模拟持仓，每个持仓都像 GetPosition 一样先结算
+func (p *PaperExecutor) GetPositions(ctx context.Context) ([]*Position, error) {
+	stored, err := p.storage.GetPaperPositions()
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []*Position
+	for _, pos := range stored {
+		// Settling may close the position (stop-loss, liquidation)
+		// 结算可能平掉该持仓（止损、强平）
+		position, err := p.GetPosition(ctx, pos.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if position != nil {
+			positions = append(positions, position)
+		}
+	}
+	return positions, nil
+}
+
+// GetAccountInfo returns the simulated account in Binance's account format
+// GetAccountInfo 以币安账户格式返回模拟账户
+func (p *PaperExecutor) GetAccountInfo(ctx context.Context) (*futures.Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	positions, err := p.storage.GetPaperPositions()
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		if err := p.settle(ctx, pos.Symbol); err != nil {
+			p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败 (%s): %v", pos.Symbol, err))
+		}
+	}
+
+	account, err := p.storage.GetPaperAccount(p.config.PaperInitialBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	// Positions may have been closed by settlement, reload
+	// 结算可能已平掉部分持仓，重新加载
+	positions, err = p.storage.GetPaperPositions()
+	if err != nil {
+		return nil, err
+	}
+
+	var unrealized, usedMargin, maintMargin float64
+	accountPositions := make([]*futures.AccountPosition, 0, len(positions))
+	for _, pos := range positions {
+		price, err := p.lastPrice(ctx, pos.Symbol)
+		if err != nil {
+			price = pos.EntryPrice
+		}
+		pnl := paperPnL(pos.Side, pos.EntryPrice, price, pos.Quantity)
+		unrealized += pnl
+		usedMargin += pos.Margin
+		maintMargin += price * pos.Quantity * paperMaintMarginRate
+
+		amt := pos.Quantity
+		if pos.Side == "short" {
+			amt = -amt
+		}
+		accountPositions = append(accountPositions, &futures.AccountPosition{
+			Symbol:                 pos.Symbol,
+			Leverage:               strconv.Itoa(pos.Leverage),
+			InitialMargin:          formatPaperFloat(pos.Margin),
+			PositionInitialMargin:  formatPaperFloat(pos.Margin),
+			UnrealizedProfit:       formatPaperFloat(pnl),
+			EntryPrice:             formatPaperFloat(pos.EntryPrice),
+			PositionAmt:            formatPaperFloat(amt),
+			PositionSide:           futures.PositionSideTypeBoth,
+			MaintMargin:            formatPaperFloat(price * pos.Quantity * paperMaintMarginRate),
+			OpenOrderInitialMargin: "0",
+		})
+	}
+
+	marginBalance := account.WalletBalance + unrealized
+	available := math.Max(marginBalance-usedMargin, 0)
+
+	asset := &futures.AccountAsset{
+		Asset:                  p.config.QuoteAssetFor(""),
+		WalletBalance:          formatPaperFloat(account.WalletBalance),
+		UnrealizedProfit:       formatPaperFloat(unrealized),
+		MarginBalance:          formatPaperFloat(marginBalance),
+		InitialMargin:          formatPaperFloat(usedMargin),
+		PositionInitialMargin:  formatPaperFloat(usedMargin),
+		MaintMargin:            formatPaperFloat(maintMargin),
+		OpenOrderInitialMargin: "0",
+		CrossWalletBalance:     formatPaperFloat(account.WalletBalance),
+		CrossUnPnl:             formatPaperFloat(unrealized),
+		AvailableBalance:       formatPaperFloat(available),
+		MaxWithdrawAmount:      formatPaperFloat(available),
+		MarginAvailable:        true,
+		UpdateTime:             account.UpdatedAt.UnixMilli(),
+	}
+
+	return &futures.Account{
+		Assets:                      []*futures.AccountAsset{asset},
+		CanTrade:                    true,
+		UpdateTime:                  time.Now().UnixMilli(),
+		TotalInitialMargin:          asset.InitialMargin,
+		TotalMaintMargin:            asset.MaintMargin,
+		TotalWalletBalance:          asset.WalletBalance,
+		TotalUnrealizedProfit:       asset.UnrealizedProfit,
+		TotalMarginBalance:          asset.MarginBalance,
+		TotalPositionInitialMargin:  asset.PositionInitialMargin,
+		TotalOpenOrderInitialMargin: "0",
+		TotalCrossWalletBalance:     asset.CrossWalletBalance,
+		TotalCrossUnPnl:             asset.CrossUnPnl,
+		AvailableBalance:            asset.AvailableBalance,
+		MaxWithdrawAmount:           asset.MaxWithdrawAmount,
+		Positions:                   accountPositions,
+	}, nil
+}
+
+// ExecuteTrade simulates a trade with the same semantics as the live executor
+// ExecuteTrade 以与实盘执行器相同的语义模拟交易
+//
+// BUY/SELL close the opposite position first and never add to an existing position.
+// BUY/SELL 会先平掉反向持仓，且不会对已有持仓加仓。
+func (p *PaperExecutor) ExecuteTrade(ctx context.Context, symbol string, action TradeAction, amount float64, result *TradeResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if err := p.settle(ctx, binanceSymbol); err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败: %v", err))
+	}
+
+	pos, err := p.storage.GetPaperPosition(binanceSymbol)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case ActionBuy, ActionSell:
+		side, openSide, closeSide := "BUY", "long", "short"
+		if action == ActionSell {
+			side, openSide, closeSide = "SELL", "short", "long"
+		}
+
+		if pos != nil && pos.Side == closeSide {
+			p.logger.Info(fmt.Sprintf("📤 模拟盘：平%s仓...", paperSideCN(closeSide)))
+			closeOrder, err := p.marketOrder(ctx, binanceSymbol, side, pos.Quantity, true)
+			if err != nil {
+				return err
+			}
+			result.Fee += closeOrder.Fee
+			pos = nil
+		}
+
+		if pos != nil && pos.Side == openSide {
+			result.Message = fmt.Sprintf("已有%s仓，不重复开仓（系统保护：防止意外加仓）", paperSideCN(openSide))
+			p.logger.Warning(fmt.Sprintf("⚠️ 已有%s仓，不重复开仓", paperSideCN(openSide)))
+			return nil
+		}
+
+		if openSide == "long" {
+			p.logger.Info("📈 模拟盘：开多仓...")
+		} else {
+			p.logger.Info("📉 模拟盘：开空仓...")
+		}
+		order, err := p.marketOrder(ctx, binanceSymbol, side, amount, false)
+		if err != nil {
+			return err
+		}
+		p.fillResult(result, order)
+
+	case ActionCloseLong, ActionCloseShort:
+		closeSide, side := "long", "SELL"
+		if action == ActionCloseShort {
+			closeSide, side = "short", "BUY"
+		}
+
+		if pos == nil || pos.Side != closeSide {
+			result.Message = fmt.Sprintf("没有%s仓可平", paperSideCN(closeSide))
+			p.logger.Warning(fmt.Sprintf("⚠️ 没有%s仓可平", paperSideCN(closeSide)))
+			return nil
+		}
+
+		p.logger.Info(fmt.Sprintf("📤 模拟盘：平%s仓...", paperSideCN(closeSide)))
+		order, err := p.marketOrder(ctx, binanceSymbol, side, pos.Quantity, true)
+		if err != nil {
+			return err
+		}
+		p.fillResult(result, order)
+
+	default:
+		return fmt.Errorf("unsupported action: %s", action)
+	}
+
+	return nil
+}
+
+// ClosePartial simulates a reduce-only market order closing part of the position
+// ClosePartial 模拟一笔只减仓市价单，平掉部分持仓
+func (p *PaperExecutor) ClosePartial(ctx context.Context, symbol string, quantity float64, result *TradeResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if err := p.settle(ctx, binanceSymbol); err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败: %v", err))
+	}
+
+	pos, err := p.storage.GetPaperPosition(binanceSymbol)
+	if err != nil {
+		return err
+	}
+	if pos == nil {
+		return fmt.Errorf("ReduceOnly Order is rejected: no open position for %s", binanceSymbol)
+	}
+
+	side := "SELL"
+	if pos.Side == "short" {
+		side = "BUY"
+	}
+
+	p.logger.Info(fmt.Sprintf("📤 模拟盘：部分平%s仓 %.4f...", paperSideCN(pos.Side), quantity))
+	order, err := p.marketOrder(ctx, binanceSymbol, side, math.Min(quantity, pos.Quantity), true)
+	if err != nil {
+		return err
+	}
+	p.fillResult(result, order)
+	return nil
+}
+
+// AddToPosition simulates a market order adding quantity to the position on its own side
+// AddToPosition 模拟一笔同方向市价单，为持仓加仓 quantity
+func (p *PaperExecutor) AddToPosition(ctx context.Context, symbol string, quantity float64, result *TradeResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if err := p.settle(ctx, binanceSymbol); err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败: %v", err))
+	}
+
+	pos, err := p.storage.GetPaperPosition(binanceSymbol)
+	if err != nil {
+		return err
+	}
+	if pos == nil {
+		return fmt.Errorf("no open position to add to for %s", binanceSymbol)
+	}
+
+	side := "BUY"
+	if pos.Side == "short" {
+		side = "SELL"
+	}
+
+	p.logger.Info(fmt.Sprintf("📈 模拟盘：%s仓加仓 %.4f...", paperSideCN(pos.Side), quantity))
+	order, err := p.marketOrder(ctx, binanceSymbol, side, quantity, false)
+	if err != nil {
+		return err
+	}
+	p.fillResult(result, order)
+	return nil
+}
+
+// PlaceStopMarketOrder places a resting reduce-only stop-market order
+// PlaceStopMarketOrder 挂一个只减仓的止损市价单
+func (p *PaperExecutor) PlaceStopMarketOrder(ctx context.Context, symbol string, side futures.SideType, stopPrice, quantity float64) (int64, error) {
+	return p.placeStopOrder(ctx, symbol, side, futures.OrderTypeStopMarket, stopPrice, quantity, true)
+}
+
+// PlaceTakeProfitOrder places a resting reduce-only take-profit-market order
+// PlaceTakeProfitOrder 挂一个只减仓的止盈市价单
+func (p *PaperExecutor) PlaceTakeProfitOrder(ctx context.Context, symbol string, side futures.SideType, stopPrice, quantity float64) (int64, error) {
+	return p.placeStopOrder(ctx, symbol, side, futures.OrderTypeTakeProfitMarket, stopPrice, quantity, true)
+}
+
+// PlaceStopEntryOrder places a resting stop-market order that opens a position when triggered
+// PlaceStopEntryOrder 挂一个触发后开仓的止损市价单（条件入场）
+func (p *PaperExecutor) PlaceStopEntryOrder(ctx context.Context, symbol string, side futures.SideType, stopPrice, quantity float64) (int64, error) {
+	return p.placeStopOrder(ctx, symbol, side, futures.OrderTypeStopMarket, stopPrice, quantity, false)
+}
+
+// placeStopOrder stores a resting stop-market or take-profit-market order
+// placeStopOrder 保存一个挂单中的止损市价单或止盈市价单
+func (p *PaperExecutor) placeStopOrder(ctx context.Context, symbol string, side futures.SideType, orderType futures.OrderType, stopPrice, quantity float64, reduceOnly bool) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if reduceOnly {
+		pos, err := p.storage.GetPaperPosition(binanceSymbol)
+		if err != nil {
+			return 0, err
+		}
+		if pos == nil {
+			return 0, fmt.Errorf("ReduceOnly Order is rejected: no open position for %s", binanceSymbol)
+		}
+	}
+
+	// Reject stops that would trigger immediately (same as Binance -2021)
+	// 拒绝会立即触发的止损单（与币安 -2021 错误一致）
+	if price, err := p.lastPrice(ctx, binanceSymbol); err == nil {
+		if paperTriggered(string(orderType), string(side), stopPrice, price, price) {
+			return 0, fmt.Errorf("Order would immediately trigger (stop %.2f, last %.2f)", stopPrice, price)
+		}
+	}
+
+	order := &storage.PaperOrder{
+		Symbol:     binanceSymbol,
+		Side:       string(side),
+		Type:       string(orderType),
+		Quantity:   quantity,
+		StopPrice:  stopPrice,
+		Status:     string(futures.OrderStatusTypeNew),
+		ReduceOnly: reduceOnly,
+	}
+	return p.storage.SavePaperOrder(order)
+}
+
+// CancelOrder cancels a resting simulated order
+// CancelOrder 撤销一个挂单中的模拟订单
+func (p *PaperExecutor) CancelOrder(symbol string, orderID int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	order, err := p.storage.GetPaperOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil || order.Symbol != p.config.GetBinanceSymbolFor(symbol) {
+		return fmt.Errorf("Unknown order sent (order %d)", orderID)
+	}
+	if order.Status != string(futures.OrderStatusTypeNew) {
+		return fmt.Errorf("Unknown order sent (order %d is %s)", orderID, order.Status)
+	}
+
+	order.Status = string(futures.OrderStatusTypeCanceled)
+	return p.storage.UpdatePaperOrder(order)
+}
+
+// GetOrder returns a simulated order in Binance's order format
+// GetOrder 以币安订单格式返回模拟订单
+func (p *PaperExecutor) GetOrder(ctx context.Context, symbol string, orderID int64) (*futures.Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	binanceSymbol := p.config.GetBinanceSymbolFor(symbol)
+	if err := p.settle(ctx, binanceSymbol); err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘结算失败: %v", err))
+	}
+
+	order, err := p.storage.GetPaperOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Symbol != binanceSymbol {
+		return nil, fmt.Errorf("Unknown order sent (order %d)", orderID)
+	}
+
+	executed := "0"
+	if order.Status == string(futures.OrderStatusTypeFilled) {
+		executed = formatPaperFloat(order.Quantity)
+	}
+
+	return &futures.Order{
+		Symbol:           order.Symbol,
+		OrderID:          order.ID,
+		ReduceOnly:       order.ReduceOnly,
+		OrigQuantity:     formatPaperFloat(order.Quantity),
+		ExecutedQuantity: executed,
+		Status:           futures.OrderStatusType(order.Status),
+		Type:             futures.OrderType(order.Type),
+		OrigType:         futures.OrderType(order.Type),
+		Side:             futures.SideType(order.Side),
+		StopPrice:        formatPaperFloat(order.StopPrice),
+		AvgPrice:         formatPaperFloat(order.AvgPrice),
+		Time:             order.CreatedAt.UnixMilli(),
+		UpdateTime:       order.UpdatedAt.UnixMilli(),
+		PositionSide:     futures.PositionSideTypeBoth,
+	}, nil
+}
+
+// marketOrder fills a market order against the live order book and records it
+// marketOrder 基于实时盘口撮合市价单并记录
+func (p *PaperExecutor) marketOrder(ctx context.Context, symbol, side string, quantity float64, reduceOnly bool) (*storage.PaperOrder, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("invalid quantity: %.4f", quantity)
+	}
+
+	price, err := p.simulateFill(ctx, symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, realized, err := p.applyFill(symbol, side, quantity, price, reduceOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &storage.PaperOrder{
+		Symbol:     symbol,
+		Side:       side,
+		Type:       string(futures.OrderTypeMarket),
+		Quantity:   quantity,
+		AvgPrice:   price,
+		Fee:        fee,
+		Status:     string(futures.OrderStatusTypeFilled),
+		ReduceOnly: reduceOnly,
+	}
+	if _, err := p.storage.SavePaperOrder(order); err != nil {
+		return nil, err
+	}
+	p.recordTrade(sessionIDFrom(ctx), order.ID, symbol, side, order.Type, quantity, price, fee, realized)
+
+	return order, nil
+}
+
+// applyFill updates balance and position for a fill, returning the fee charged and the realized PnL
+// applyFill 根据成交更新余额和持仓，返回收取的手续费和已实现盈亏
+func (p *PaperExecutor) applyFill(symbol, side string, quantity, price float64, reduceOnly bool) (float64, float64, error) {
+	account, err := p.storage.GetPaperAccount(p.config.PaperInitialBalance)
+	if err != nil {
+		return 0, 0, err
+	}
+	pos, err := p.storage.GetPaperPosition(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fillSide := "long"
+	if side == "SELL" {
+		fillSide = "short"
+	}
+	// Reduce or close an opposite position
+	// 减少或平掉反向持仓
+	if pos != nil && pos.Side != fillSide {
+		closeQty := math.Min(quantity, pos.Quantity)
+		realized := paperPnL(pos.Side, pos.EntryPrice, price, closeQty)
+		releasedMargin := pos.Margin * closeQty / pos.Quantity
+		fee := closeQty * price * p.config.PaperTakerFeeRate
+
+		account.WalletBalance += realized - fee
+		account.TotalFees += fee
+
+		pos.Quantity -= closeQty
+		pos.Margin -= releasedMargin
+		if pos.Quantity <= 1e-12 {
+			if err := p.storage.DeletePaperPosition(symbol); err != nil {
+				return 0, 0, err
+			}
+			delete(p.checkedAt, symbol)
+		} else if err := p.storage.SavePaperPosition(pos); err != nil {
+			return 0, 0, err
+		}
+
+		p.logger.Info(fmt.Sprintf("📝 模拟盘成交: %s %.4f %s @ $%.2f，已实现盈亏 %+.2f USDT，手续费 %.4f USDT",
+			side, closeQty, symbol, price, realized, fee))
+
+		if err := p.storage.UpdatePaperAccount(account); err != nil {
+			return 0, 0, err
+		}
+
+		// In one-way mode a non reduce-only order flips the position with the remainder
+		// 单向持仓模式下，非只减仓订单会用剩余数量反向开仓
+		remaining := quantity - closeQty
+		if reduceOnly || remaining <= 1e-12 {
+			return fee, realized, nil
+		}
+		remainingFee, _, err := p.applyFill(symbol, side, remaining, price, false)
+		return fee + remainingFee, realized, err
+	}
+
+	if reduceOnly {
+		return 0, 0, fmt.Errorf("ReduceOnly Order is rejected: no position to reduce for %s", symbol)
+	}
+
+	// Open or add to a position
+	// 开仓或加仓
+	leverage, err := p.storage.GetPaperLeverage(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	if pos != nil {
+		leverage = pos.Leverage
+	}
+	if leverage <= 0 {
+		leverage = p.config.BinanceLeverage
+	}
+	if leverage <= 0 {
+		leverage = 1
+	}
+
+	margin := quantity * price / float64(leverage)
+	fee := quantity * price * p.config.PaperTakerFeeRate
+	available, err := p.availableBalance(account)
+	if err != nil {
+		return 0, 0, err
+	}
+	if margin+fee > available {
+		return 0, 0, fmt.Errorf("Margin is insufficient: required %.2f USDT, available %.2f USDT", margin+fee, available)
+	}
+
+	now := time.Now()
+	if pos == nil {
+		pos = &storage.PaperPosition{
+			Symbol:          symbol,
+			Side:            fillSide,
+			Leverage:        leverage,
+			OpenedAt:        now,
+			LastFundingTime: now,
+		}
+		p.checkedAt[symbol] = now
+	}
+	totalQty := pos.Quantity + quantity
+	pos.EntryPrice = (pos.EntryPrice*pos.Quantity + price*quantity) / totalQty
+	pos.Quantity = totalQty
+	pos.Margin += margin
+
+	if err := p.storage.SavePaperPosition(pos); err != nil {
+		return 0, 0, err
+	}
+
+	account.WalletBalance -= fee
+	account.TotalFees += fee
+	if err := p.storage.UpdatePaperAccount(account); err != nil {
+		return 0, 0, err
+	}
+
+	p.logger.Info(fmt.Sprintf("📝 模拟盘成交: %s %.4f %s @ $%.2f，保证金 %.2f USDT (%dx)，手续费 %.4f USDT",
+		side, quantity, symbol, price, margin, leverage, fee))
+
+	return fee, 0, nil
+}
+
+// availableBalance computes available margin: wallet + unrealized PnL - used margin
+// availableBalance 计算可用保证金：钱包余额 + 未实现盈亏 - 已用保证金
+func (p *PaperExecutor) availableBalance(account *storage.PaperAccount) (float64, error) {
+	positions, err := p.storage.GetPaperPositions()
+	if err != nil {
+		return 0, err
+	}
+
+	available := account.WalletBalance
+	for _, pos := range positions {
+		available -= pos.Margin
+		if price, err := p.lastPrice(context.Background(), pos.Symbol); err == nil {
+			available += paperPnL(pos.Side, pos.EntryPrice, price, pos.Quantity)
+		}
+	}
+
+	return math.Max(available, 0), nil
+}
+
+// simulateFill walks the live order book to compute a VWAP fill price
+// simulateFill 遍历实时盘口计算成交均价（VWAP）
+//
+// Falls back to the last price plus configured slippage when depth is unavailable or insufficient.
+// 盘口不可用或深度不足时，回退为最新价加上配置的滑点。
+func (p *PaperExecutor) simulateFill(ctx context.Context, symbol, side string, quantity float64) (float64, error) {
+	depth, err := p.client.NewDepthService().Symbol(symbol).Limit(100).Do(ctx)
+	if err == nil {
+		levels := make([][2]float64, 0)
+		if side == "BUY" {
+			for _, ask := range depth.Asks {
+				price, _ := parseFloat(ask.Price)
+				qty, _ := parseFloat(ask.Quantity)
+				levels = append(levels, [2]float64{price, qty})
+			}
+		} else {
+			for _, bid := range depth.Bids {
+				price, _ := parseFloat(bid.Price)
+				qty, _ := parseFloat(bid.Quantity)
+				levels = append(levels, [2]float64{price, qty})
+			}
+		}
+
+		remaining := quantity
+		cost := 0.0
+		for _, level := range levels {
+			take := math.Min(remaining, level[1])
+			cost += take * level[0]
+			remaining -= take
+			if remaining <= 1e-12 {
+				return cost / quantity, nil
+			}
+		}
+		p.logger.Warning(fmt.Sprintf("⚠️  模拟盘：%s 盘口深度不足，使用最新价 + 滑点", symbol))
+	}
+
+	price, err := p.lastPrice(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	return p.slipped(price, side), nil
+}
+
+// slipped applies the configured fallback slippage against the taker
+// slipped 按配置的滑点对吃单方不利地调整价格
+func (p *PaperExecutor) slipped(price float64, side string) float64 {
+	slippage := p.config.PaperSlippageBps / 10000
+	if side == "BUY" {
+		return price * (1 + slippage)
+	}
+	return price * (1 - slippage)
+}
+
+// settle processes triggered stop orders, liquidations and funding fees for a symbol
+// settle 处理交易对已触发的止损单、强平和资金费
+func (p *PaperExecutor) settle(ctx context.Context, symbol string) error {
+	if err := p.settleTriggers(ctx, symbol); err != nil {
+		return err
+	}
+	return p.settleFunding(ctx, symbol)
+}
+
+// settleTriggers replays 1m klines since the last check to fill stop orders and detect liquidation
+// settleTriggers 回放上次检查以来的 1 分钟 K 线，撮合止损单并检测强平
+func (p *PaperExecutor) settleTriggers(ctx context.Context, symbol string) error {
+	pos, err := p.storage.GetPaperPosition(symbol)
+	if err != nil {
+		return err
+	}
+	orders, err := p.storage.GetOpenPaperOrders(symbol)
+	if err != nil {
+		return err
+	}
+	if pos == nil && len(orders) == 0 {
+		return nil
+	}
+
+	var liqCheckedAt time.Time
+	if pos != nil {
+		liqCheckedAt = p.checkedAt[symbol]
+		if liqCheckedAt.IsZero() || liqCheckedAt.Before(pos.OpenedAt) {
+			liqCheckedAt = pos.OpenedAt
+		}
+	}
+
+	start := liqCheckedAt
+	for _, order := range orders {
+		if start.IsZero() || order.CheckedAt.Before(start) {
+			start = order.CheckedAt
+		}
+	}
+
+	klines, err := p.client.NewKlinesService().
+		Symbol(symbol).
+		Interval("1m").
+		StartTime(start.Truncate(time.Minute).UnixMilli()).
+		Limit(1000).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get klines: %w", err)
+	}
+	if len(klines) == 0 {
+		return nil
+	}
+
+	for _, k := range klines {
+		openTime := time.UnixMilli(k.OpenTime)
+		open, _ := parseFloat(k.Open)
+		high, _ := parseFloat(k.High)
+		low, _ := parseFloat(k.Low)
+
+		for _, order := range orders {
+			if order.Status != string(futures.OrderStatusTypeNew) || openTime.Before(order.CheckedAt.Truncate(time.Minute)) {
+				continue
+			}
+
+			if !paperTriggered(order.Type, order.Side, order.StopPrice, high, low) {
+				continue
+			}
+
+			// Gaps through the stop fill at the open, otherwise at the stop price, plus slippage
+			// 跳空穿过止损价时按开盘价成交，否则按止损价成交，并加上滑点
+			fillPrice := order.StopPrice
+			if paperTriggered(order.Type, order.Side, order.StopPrice, open, open) {
+				fillPrice = open
+			}
+			fillPrice = p.slipped(fillPrice, order.Side)
+
+			fee, realized, err := p.applyFill(symbol, order.Side, order.Quantity, fillPrice, order.ReduceOnly)
+			if err != nil {
+				// Reduce-only stop without a position expires, like on Binance
+				// 无持仓时只减仓止损单过期，与币安一致
+				order.Status = string(futures.OrderStatusTypeExpired)
+				p.logger.Warning(fmt.Sprintf("⚠️  模拟盘止损单 %d 已过期: %v", order.ID, err))
+			} else {
+				order.Status = string(futures.OrderStatusTypeFilled)
+				order.AvgPrice = fillPrice
+				order.Fee = fee
+				label := "🛑 模拟盘止损单"
+				if order.Type == string(futures.OrderTypeTakeProfitMarket) {
+					label = "🎯 模拟盘止盈单"
+				}
+				p.logger.Warning(fmt.Sprintf("%s %d 已触发: %s %.4f %s @ $%.2f",
+					label, order.ID, order.Side, order.Quantity, symbol, fillPrice))
+				p.recordTrade(0, order.ID, symbol, order.Side, order.Type, order.Quantity, fillPrice, fee, realized)
+			}
+			order.CheckedAt = openTime
+			if err := p.storage.UpdatePaperOrder(order); err != nil {
+				return err
+			}
+		}
+
+		// Liquidation check against the (possibly reduced) position
+		// 对（可能已减少的）持仓进行强平检查
+		if pos != nil && !openTime.Before(liqCheckedAt.Truncate(time.Minute)) {
+			pos, err = p.storage.GetPaperPosition(symbol)
+			if err != nil {
+				return err
+			}
+			if pos != nil {
+				liqPrice := paperLiquidationPrice(pos)
+				if (pos.Side == "long" && low <= liqPrice) || (pos.Side == "short" && high >= liqPrice) {
+					side := "SELL"
+					if pos.Side == "short" {
+						side = "BUY"
+					}
+					p.logger.Error(fmt.Sprintf("💥 模拟盘强平: %s %s %.4f @ $%.2f", symbol, pos.Side, pos.Quantity, liqPrice))
+					fee, realized, err := p.applyFill(symbol, side, pos.Quantity, liqPrice, true)
+					if err != nil {
+						return err
+					}
+					p.recordTrade(0, 0, symbol, side, "LIQUIDATION", pos.Quantity, liqPrice, fee, realized)
+					pos = nil
+				}
+			}
+		}
+	}
+
+	// The last kline is still forming, so it is re-checked next time
+	// 最后一根 K 线尚未收盘，下次会重新检查
+	last := time.UnixMilli(klines[len(klines)-1].OpenTime)
+	for _, order := range orders {
+		if order.Status == string(futures.OrderStatusTypeNew) {
+			order.CheckedAt = last
+			if err := p.storage.UpdatePaperOrder(order); err != nil {
+				return err
+			}
+		}
+	}
+	if pos != nil {
+		p.checkedAt[symbol] = last
+	}
+
+	return nil
+}
+
+// paperTriggered reports whether a price range [low, high] triggers a resting order
+// paperTriggered 判断价格区间 [low, high] 是否触发挂单
+//
+// Stop orders trigger against the position (sell stops on a drop), take-profit orders in its favour
+// (sell take-profits on a rise).
+// 止损单在不利方向触发（卖出止损在下跌时触发），止盈单在有利方向触发（卖出止盈在上涨时触发）。
+func paperTriggered(orderType, side string, stopPrice, high, low float64) bool {
+	if orderType == string(futures.OrderTypeTakeProfitMarket) {
+		return (side == "SELL" && high >= stopPrice) || (side == "BUY" && low <= stopPrice)
+	}
+	return (side == "SELL" && low <= stopPrice) || (side == "BUY" && high >= stopPrice)
+}
+
+// settleFunding applies funding payments accrued since the last settlement
+// settleFunding 结算上次结算以来产生的资金费
+func (p *PaperExecutor) settleFunding(ctx context.Context, symbol string) error {
+	pos, err := p.storage.GetPaperPosition(symbol)
+	if err != nil || pos == nil {
+		return err
+	}
+
+	rates, err := p.client.NewFundingRateService().
+		Symbol(symbol).
+		StartTime(pos.LastFundingTime.UnixMilli() + 1).
+		EndTime(time.Now().UnixMilli()).
+		Limit(100).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get funding rates: %w", err)
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+
+	account, err := p.storage.GetPaperAccount(p.config.PaperInitialBalance)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rates {
+		fundingTime := time.UnixMilli(r.FundingTime)
+		if !fundingTime.After(pos.LastFundingTime) {
+			continue
+		}
+
+		rate, _ := parseFloat(r.FundingRate)
+		markPrice, _ := parseFloat(r.MarkPrice)
+		if markPrice == 0 {
+			markPrice = pos.EntryPrice
+		}
+
+		payment := pos.Quantity * markPrice * fundingPaymentRate(pos.Side, rate)
+
+		account.WalletBalance -= payment
+		account.TotalFunding += payment
+		pos.LastFundingTime = fundingTime
+
+		direction := "收取"
+		if payment > 0 {
+			direction = "支付"
+		}
+		p.logger.Info(fmt.Sprintf("📝 模拟盘资金费: %s 费率 %.4f%%，%s %.4f USDT",
+			symbol, rate*100, direction, math.Abs(payment)))
+	}
+
+	if err := p.storage.SavePaperPosition(pos); err != nil {
+		return err
+	}
+	return p.storage.UpdatePaperAccount(account)
+}
+
+// lastPrice returns the latest traded price for a Binance symbol
+// lastPrice 返回币安交易对的最新成交价
+func (p *PaperExecutor) lastPrice(ctx context.Context, symbol string) (float64, error) {
+	prices, err := p.client.NewListPricesService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price: %w", err)
+	}
+	if len(prices) == 0 {
+		return 0, fmt.Errorf("no price data for %s", symbol)
+	}
+	return parseFloat(prices[0].Price)
+}
+
+// toPosition converts a stored paper position into the executor's Position
+// toPosition 将存储的模拟持仓转换为执行器的 Position
+func (p *PaperExecutor) toPosition(pos *storage.PaperPosition, price float64) *Position {
+	amt := pos.Quantity
+	if pos.Side == "short" {
+		amt = -amt
+	}
+
+	return &Position{
+		Symbol:           pos.Symbol,
+		Side:             pos.Side,
+		Size:             pos.Quantity,
+		Quantity:         pos.Quantity,
+		EntryPrice:       pos.EntryPrice,
+		EntryTime:        pos.OpenedAt,
+		CurrentPrice:     price,
+		UnrealizedPnL:    paperPnL(pos.Side, pos.EntryPrice, price, pos.Quantity),
+		PositionAmt:      amt,
+		Leverage:         pos.Leverage,
+		LiquidationPrice: paperLiquidationPrice(pos),
+	}
+}
+
+// fillResult copies a filled order into the trade result
+// fillResult 将已成交订单写入交易结果
+func (p *PaperExecutor) fillResult(result *TradeResult, order *storage.PaperOrder) {
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", order.ID)
+	result.Price = order.AvgPrice
+	result.Filled = order.Quantity
+	result.Fee += order.Fee
+	result.Message = fmt.Sprintf("模拟盘：订单成交 @ $%.2f，手续费 %.4f USDT", order.AvgPrice, order.Fee)
+	p.logger.Success(fmt.Sprintf("✅ 模拟盘订单成交，订单ID: %d, 成交价: %.2f", order.ID, order.AvgPrice))
+}
+
+// paperPnL calculates PnL for a position side
+// paperPnL 计算持仓方向的盈亏
+func paperPnL(side string, entryPrice, price, quantity float64) float64 {
+	if side == "short" {
+		return (entryPrice - price) * quantity
+	}
+	return (price - entryPrice) * quantity
+}
+
+// paperLiquidationPrice approximates the isolated liquidation price of a position
+// paperLiquidationPrice 近似计算持仓的逐仓强平价格
+func paperLiquidationPrice(pos *storage.PaperPosition) float64 {
+	if pos.Quantity <= 0 {
+		return 0
+	}
+	marginPerUnit := pos.Margin / pos.Quantity
+	if pos.Side == "short" {
+		return (pos.EntryPrice + marginPerUnit) / (1 + paperMaintMarginRate)
+	}
+	return (pos.EntryPrice - marginPerUnit) / (1 - paperMaintMarginRate)
+}
+
+// paperSideCN returns the Chinese name of a position side
+// paperSideCN 返回持仓方向的中文名称
+func paperSideCN(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
+
+// formatPaperFloat formats a float the way Binance returns numeric strings
+// formatPaperFloat 按币安返回数值字符串的方式格式化浮点数
+func formatPaperFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
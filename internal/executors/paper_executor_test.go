@@ -0,0 +1,417 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// paperMarket serves the public futures endpoints used by the paper executor
+// paperMarket 提供模拟盘执行器使用的公共合约接口
+type paperMarket struct {
+	price   string // 最新价 / Last price
+	klines  string // 1m K 线 JSON / 1m klines JSON
+	funding string // 资金费率历史 JSON / Funding rate history JSON
+}
+
+func (m *paperMarket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/fapi/v1/ticker/price", "/fapi/v2/ticker/price":
+		fmt.Fprintf(w, `{"symbol": "%s", "price": "%s", "time": 0}`, r.URL.Query().Get("symbol"), m.price)
+	case "/fapi/v1/klines":
+		w.Write([]byte(orEmptyList(m.klines)))
+	case "/fapi/v1/fundingRate":
+		w.Write([]byte(orEmptyList(m.funding)))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code": -1000, "msg": "not served"}`))
+	}
+}
+
+func orEmptyList(body string) string {
+	if body == "" {
+		return "[]"
+	}
+	return body
+}
+
+// paperKline formats a single 1m kline opening at the current minute
+// paperKline 格式化一根在当前分钟开盘的 1 分钟 K 线
+func paperKline(open, high, low, close float64) string {
+	openTime := time.Now().Truncate(time.Minute).UnixMilli()
+	return fmt.Sprintf(`[[%d, "%g", "%g", "%g", "%g", "10", %d, "1000", 5, "5", "500", "0"]]`,
+		openTime, open, high, low, close, openTime+59999)
+}
+
+// newTestPaperExecutor creates a paper executor backed by a temporary database and a fake market
+// newTestPaperExecutor 创建基于临时数据库和模拟行情的模拟盘执行器
+func newTestPaperExecutor(t *testing.T, market *paperMarket) *PaperExecutor {
+	t.Helper()
+
+	server := httptest.NewServer(market)
+	t.Cleanup(server.Close)
+
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "paper.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10}
+	return NewPaperExecutor(cfg, client, logger.NewColorLogger(false), db)
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestPaperPnL 测试模拟盘盈亏计算
+// TestPaperPnL tests paper trading PnL calculation
+func TestPaperPnL(t *testing.T) {
+	if pnl := paperPnL("long", 50000, 51000, 0.1); math.Abs(pnl-100) > 1e-9 {
+		t.Errorf("long pnl: expected 100, got %.4f", pnl)
+	}
+	if pnl := paperPnL("short", 50000, 51000, 0.1); math.Abs(pnl+100) > 1e-9 {
+		t.Errorf("short pnl: expected -100, got %.4f", pnl)
+	}
+}
+
+// TestPaperLiquidationPrice 测试模拟盘强平价格近似计算
+// TestPaperLiquidationPrice tests the approximate paper liquidation price
+func TestPaperLiquidationPrice(t *testing.T) {
+	long := &storage.PaperPosition{Side: "long", Quantity: 1, EntryPrice: 100, Margin: 10}   // 10x
+	short := &storage.PaperPosition{Side: "short", Quantity: 1, EntryPrice: 100, Margin: 10} // 10x
+
+	longLiq := paperLiquidationPrice(long)
+	if longLiq <= 90 || longLiq >= 100 {
+		t.Errorf("long liquidation price should be just above 90, got %.4f", longLiq)
+	}
+
+	shortLiq := paperLiquidationPrice(short)
+	if shortLiq >= 110 || shortLiq <= 100 {
+		t.Errorf("short liquidation price should be just below 110, got %.4f", shortLiq)
+	}
+
+	// At the liquidation price the remaining margin equals the maintenance margin
+	// 在强平价格处，剩余保证金等于维持保证金
+	remaining := long.Margin + paperPnL("long", long.EntryPrice, longLiq, long.Quantity)
+	if math.Abs(remaining-longLiq*long.Quantity*paperMaintMarginRate) > 1e-9 {
+		t.Errorf("remaining margin %.6f should equal maintenance margin at liquidation", remaining)
+	}
+}
+
+// TestPaperSlippage 测试模拟盘滑点方向
+// TestPaperSlippage tests that slippage always works against the taker
+func TestPaperSlippage(t *testing.T) {
+	p := &PaperExecutor{config: &config.Config{PaperSlippageBps: 10}}
+
+	if price := p.slipped(100, "BUY"); math.Abs(price-100.1) > 1e-9 {
+		t.Errorf("buy slippage: expected 100.1, got %.4f", price)
+	}
+	if price := p.slipped(100, "SELL"); math.Abs(price-99.9) > 1e-9 {
+		t.Errorf("sell slippage: expected 99.9, got %.4f", price)
+	}
+}
+
+// TestFormatPaperFloat 测试数值字符串格式化
+// TestFormatPaperFloat tests numeric string formatting
+func TestFormatPaperFloat(t *testing.T) {
+	cases := map[float64]string{0: "0", 10000: "10000", 0.0004: "0.0004", -12.5: "-12.5"}
+	for in, want := range cases {
+		if got := formatPaperFloat(in); got != want {
+			t.Errorf("formatPaperFloat(%v) = %s, want %s", in, got, want)
+		}
+		if parsed, _ := parseFloat(formatPaperFloat(in)); parsed != in {
+			t.Errorf("round trip failed for %v", in)
+		}
+	}
+}
+
+// TestPaperApplyFill 测试模拟盘账本：开仓、加仓、减仓、平仓、反手及拒单
+// TestPaperApplyFill tests the paper ledger: open, add, reduce, close, flip and rejections
+func TestPaperApplyFill(t *testing.T) {
+	p := newTestPaperExecutor(t, &paperMarket{price: "100"})
+	const symbol = "BTCUSDT"
+
+	position := func() *storage.PaperPosition {
+		t.Helper()
+		pos, err := p.storage.GetPaperPosition(symbol)
+		if err != nil {
+			t.Fatalf("GetPaperPosition failed: %v", err)
+		}
+		return pos
+	}
+
+	// Open: 1 @ 100 at 10x locks 10 USDT of margin and pays 0.04 USDT fee
+	// 开仓：10 倍杠杆 1 @ 100 占用 10 USDT 保证金，手续费 0.04 USDT
+	fee, realized, err := p.applyFill(symbol, "BUY", 1, 100, false)
+	if err != nil || !approxEqual(fee, 0.04) || realized != 0 {
+		t.Fatalf("open = %v, %v, %v; want 0.04, 0, nil", fee, realized, err)
+	}
+	if pos := position(); pos == nil || pos.Side != "long" || pos.Quantity != 1 || !approxEqual(pos.Margin, 10) {
+		t.Fatalf("Unexpected position after open: %+v", pos)
+	}
+
+	// Add: 1 @ 110 averages the entry to 105
+	// 加仓：1 @ 110，开仓均价变为 105
+	if _, _, err := p.applyFill(symbol, "BUY", 1, 110, false); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if pos := position(); pos.Quantity != 2 || !approxEqual(pos.EntryPrice, 105) || !approxEqual(pos.Margin, 21) {
+		t.Fatalf("Unexpected position after add: %+v", pos)
+	}
+
+	// Reduce: 0.5 @ 120 realizes 7.5 and releases a quarter of the margin
+	// 减仓：0.5 @ 120 实现盈利 7.5，释放四分之一保证金
+	_, realized, err = p.applyFill(symbol, "SELL", 0.5, 120, true)
+	if err != nil || !approxEqual(realized, 7.5) {
+		t.Fatalf("reduce = %v, %v; want 7.5, nil", realized, err)
+	}
+	if pos := position(); pos.Quantity != 1.5 || !approxEqual(pos.Margin, 15.75) {
+		t.Fatalf("Unexpected position after reduce: %+v", pos)
+	}
+
+	// Close: a reduce-only order larger than the position closes it without flipping
+	// 平仓：数量大于持仓的只减仓订单只平仓、不反手
+	_, realized, err = p.applyFill(symbol, "SELL", 5, 100, true)
+	if err != nil || !approxEqual(realized, -7.5) {
+		t.Fatalf("close = %v, %v; want -7.5, nil", realized, err)
+	}
+	if pos := position(); pos != nil {
+		t.Fatalf("Position should be closed, got %+v", pos)
+	}
+
+	account, err := p.storage.GetPaperAccount(1000)
+	if err != nil {
+		t.Fatalf("GetPaperAccount failed: %v", err)
+	}
+	// Fees: 0.04 + 0.044 + 0.024 + 0.06, realized PnL nets to zero
+	// 手续费：0.04 + 0.044 + 0.024 + 0.06，已实现盈亏合计为零
+	if !approxEqual(account.TotalFees, 0.168) || !approxEqual(account.WalletBalance, 999.832) {
+		t.Errorf("Unexpected account: %+v", account)
+	}
+
+	// Reduce-only without a position is rejected
+	// 无持仓时只减仓订单被拒绝
+	if _, _, err := p.applyFill(symbol, "SELL", 1, 100, true); err == nil {
+		t.Error("Expected reduce-only rejection without a position")
+	}
+
+	// Flip: selling 3 against a long of 1 closes it and opens a short of 2
+	// 反手：对 1 个多仓卖出 3 个，平多后开 2 个空仓
+	if _, _, err := p.applyFill(symbol, "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, _, err := p.applyFill(symbol, "SELL", 3, 100, false); err != nil {
+		t.Fatalf("flip failed: %v", err)
+	}
+	if pos := position(); pos == nil || pos.Side != "short" || pos.Quantity != 2 || !approxEqual(pos.Margin, 20) {
+		t.Fatalf("Unexpected position after flip: %+v", pos)
+	}
+
+	// Insufficient margin: 1000 @ 100 at 10x needs 10000 USDT
+	// 保证金不足：10 倍杠杆 1000 @ 100 需要 10000 USDT
+	if _, _, err := p.applyFill("ETHUSDT", "BUY", 1000, 100, false); err == nil {
+		t.Error("Expected insufficient margin error")
+	}
+	if pos, _ := p.storage.GetPaperPosition("ETHUSDT"); pos != nil {
+		t.Errorf("Rejected order must not open a position, got %+v", pos)
+	}
+}
+
+// TestPaperSettleTriggers 测试 K 线内触发的止损单成交
+// TestPaperSettleTriggers tests stop orders triggered inside a kline
+func TestPaperSettleTriggers(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	p := newTestPaperExecutor(t, market)
+	ctx := context.Background()
+
+	// A low through the stop fills it at the stop price and closes the position
+	// 最低价穿过止损价时按止损价成交并平仓
+	if _, _, err := p.applyFill("BTCUSDT", "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	stopID, err := p.PlaceStopMarketOrder(ctx, "BTCUSDT", futures.SideTypeSell, 95, 1)
+	if err != nil {
+		t.Fatalf("PlaceStopMarketOrder failed: %v", err)
+	}
+
+	market.klines = paperKline(99, 101, 96, 98)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	if order, _ := p.storage.GetPaperOrder(stopID); order.Status != string(futures.OrderStatusTypeNew) {
+		t.Fatalf("Stop above the low must stay open, got %s", order.Status)
+	}
+
+	market.klines = paperKline(99, 101, 94, 96)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	order, _ := p.storage.GetPaperOrder(stopID)
+	if order.Status != string(futures.OrderStatusTypeFilled) || !approxEqual(order.AvgPrice, 95) {
+		t.Fatalf("Expected stop filled at 95, got %s @ %.2f", order.Status, order.AvgPrice)
+	}
+	if pos, _ := p.storage.GetPaperPosition("BTCUSDT"); pos != nil {
+		t.Fatalf("Position should be closed by the stop, got %+v", pos)
+	}
+
+	// A gap through the stop fills at the open
+	// 跳空穿过止损价时按开盘价成交
+	if _, _, err := p.applyFill("BTCUSDT", "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	stopID, err = p.PlaceStopMarketOrder(ctx, "BTCUSDT", futures.SideTypeSell, 95, 1)
+	if err != nil {
+		t.Fatalf("PlaceStopMarketOrder failed: %v", err)
+	}
+	market.klines = paperKline(93, 94, 92, 93)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	if order, _ := p.storage.GetPaperOrder(stopID); !approxEqual(order.AvgPrice, 93) {
+		t.Errorf("Expected gap fill at the open 93, got %.2f", order.AvgPrice)
+	}
+}
+
+// TestPaperTriggered tests the trigger direction of stop-market and take-profit-market orders
+// TestPaperTriggered 测试止损市价单和止盈市价单的触发方向
+func TestPaperTriggered(t *testing.T) {
+	stop, takeProfit := string(futures.OrderTypeStopMarket), string(futures.OrderTypeTakeProfitMarket)
+	tests := []struct {
+		orderType string
+		side      string
+		stopPrice float64
+		want      bool
+	}{
+		// Kline range 95 - 105
+		// K 线区间 95 - 105
+		{stop, "SELL", 96, true},
+		{stop, "SELL", 94, false},
+		{stop, "BUY", 104, true},
+		{stop, "BUY", 106, false},
+		{takeProfit, "SELL", 104, true},
+		{takeProfit, "SELL", 106, false},
+		{takeProfit, "BUY", 96, true},
+		{takeProfit, "BUY", 94, false},
+	}
+	for _, tt := range tests {
+		if got := paperTriggered(tt.orderType, tt.side, tt.stopPrice, 105, 95); got != tt.want {
+			t.Errorf("paperTriggered(%s, %s, %.0f) = %v, want %v", tt.orderType, tt.side, tt.stopPrice, got, tt.want)
+		}
+	}
+}
+
+// TestPaperSettleTakeProfit 测试 K 线内触发的止盈单成交
+// TestPaperSettleTakeProfit tests take-profit orders triggered inside a kline
+func TestPaperSettleTakeProfit(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	p := newTestPaperExecutor(t, market)
+	ctx := context.Background()
+
+	if _, _, err := p.applyFill("BTCUSDT", "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// A take-profit below the last price would trigger immediately and is rejected
+	// 低于最新价的多仓止盈单会立即触发，因此被拒绝
+	if _, err := p.PlaceTakeProfitOrder(ctx, "BTCUSDT", futures.SideTypeSell, 99, 1); err == nil {
+		t.Error("Expected immediate-trigger rejection")
+	}
+
+	takeProfitID, err := p.PlaceTakeProfitOrder(ctx, "BTCUSDT", futures.SideTypeSell, 105, 1)
+	if err != nil {
+		t.Fatalf("PlaceTakeProfitOrder failed: %v", err)
+	}
+	stopID, err := p.PlaceStopMarketOrder(ctx, "BTCUSDT", futures.SideTypeSell, 95, 1)
+	if err != nil {
+		t.Fatalf("PlaceStopMarketOrder failed: %v", err)
+	}
+
+	// A low through the take-profit price does not trigger it
+	// 最低价穿过止盈价不会触发
+	market.klines = paperKline(100, 104, 99, 103)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	if order, _ := p.storage.GetPaperOrder(takeProfitID); order.Status != string(futures.OrderStatusTypeNew) {
+		t.Fatalf("Take-profit above the high must stay open, got %s", order.Status)
+	}
+
+	// A gap through the take-profit fills at the open
+	// 跳空穿过止盈价时按开盘价成交
+	market.klines = paperKline(107, 108, 104, 106)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	order, _ := p.storage.GetPaperOrder(takeProfitID)
+	if order.Status != string(futures.OrderStatusTypeFilled) || !approxEqual(order.AvgPrice, 107) {
+		t.Fatalf("Expected take-profit filled at the open 107, got %s @ %.2f", order.Status, order.AvgPrice)
+	}
+	if order.Type != string(futures.OrderTypeTakeProfitMarket) {
+		t.Errorf("Order type = %s, want TAKE_PROFIT_MARKET", order.Type)
+	}
+	if pos, _ := p.storage.GetPaperPosition("BTCUSDT"); pos != nil {
+		t.Fatalf("Position should be closed by the take-profit, got %+v", pos)
+	}
+
+	// The stop left behind expires once triggered without a position
+	// 遗留的止损单在无持仓时触发即过期
+	market.klines = paperKline(96, 97, 94, 95)
+	if err := p.settleTriggers(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("settleTriggers failed: %v", err)
+	}
+	if order, _ := p.storage.GetPaperOrder(stopID); order.Status != string(futures.OrderStatusTypeExpired) {
+		t.Errorf("Stop without a position should expire, got %s", order.Status)
+	}
+}
+
+// TestPaperSettleFunding 测试模拟盘资金费结算
+// TestPaperSettleFunding tests paper funding settlement
+func TestPaperSettleFunding(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	p := newTestPaperExecutor(t, market)
+
+	if _, _, err := p.applyFill("BTCUSDT", "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	pos, _ := p.storage.GetPaperPosition("BTCUSDT")
+	pos.LastFundingTime = time.Now().Add(-8 * time.Hour)
+	if err := p.storage.SavePaperPosition(pos); err != nil {
+		t.Fatalf("SavePaperPosition failed: %v", err)
+	}
+	before, _ := p.storage.GetPaperAccount(1000)
+
+	// The settlement before the last one is skipped, the new one charges the long 0.01% of 100
+	// 上次结算之前的记录被跳过，新的一次向多仓收取 100 的 0.01%
+	old := time.Now().Add(-9 * time.Hour).UnixMilli()
+	settled := time.Now().Add(-time.Hour).Truncate(time.Second)
+	market.funding = fmt.Sprintf(`[
+		{"symbol": "BTCUSDT", "fundingRate": "0.0005", "fundingTime": %d, "markPrice": "100"},
+		{"symbol": "BTCUSDT", "fundingRate": "0.0001", "fundingTime": %d, "markPrice": "100"}
+	]`, old, settled.UnixMilli())
+
+	if err := p.settleFunding(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("settleFunding failed: %v", err)
+	}
+
+	after, _ := p.storage.GetPaperAccount(1000)
+	if !approxEqual(after.TotalFunding-before.TotalFunding, 0.01) || !approxEqual(before.WalletBalance-after.WalletBalance, 0.01) {
+		t.Errorf("Expected 0.01 USDT funding paid, got %+v (before %+v)", after, before)
+	}
+	if pos, _ := p.storage.GetPaperPosition("BTCUSDT"); !pos.LastFundingTime.Equal(settled) {
+		t.Errorf("LastFundingTime = %s, want %s", pos.LastFundingTime, settled)
+	}
+}
@@ -0,0 +1,149 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// PositionFromRecord converts a stored position into a managed position with a Binance-format symbol
+// PositionFromRecord 将数据库中的持仓转换为受管持仓，交易对使用币安格式
+//
+// Partial take-profit, take-profit orders and add-ons are stored separately and restored by RestoreActivePositions.
+// 分批止盈、止盈单和加仓状态单独保存，由 RestoreActivePositions 恢复。
+func PositionFromRecord(rec *storage.PositionRecord) *Position {
+	return &Position{
+		ID:               rec.ID,
+		Symbol:           storage.NormalizeSymbol(rec.Symbol),
+		Side:             rec.Side,
+		Size:             rec.Quantity,
+		EntryPrice:       rec.EntryPrice,
+		EntryTime:        rec.EntryTime,
+		CurrentPrice:     rec.CurrentPrice,
+		HighestPrice:     rec.HighestPrice,
+		Quantity:         rec.Quantity,
+		UnrealizedPnL:    rec.UnrealizedPnL,
+		Leverage:         rec.Leverage,
+		InitialStopLoss:  rec.InitialStopLoss,
+		CurrentStopLoss:  rec.CurrentStopLoss,
+		StopLossType:     rec.StopLossType,
+		TrailingDistance: rec.TrailingDistance,
+		ATR:              rec.ATR,
+		StopLossOrderID:  rec.StopLossOrderID,
+		OpenReason:       rec.OpenReason,
+	}
+}
+
+// Record converts a managed position into its database record
+// Record 将受管持仓转换为数据库记录
+//
+// A new position's highest and current price start at the entry price.
+// 新持仓的最高价和当前价从入场价开始。
+func (p *Position) Record() *storage.PositionRecord {
+	rec := &storage.PositionRecord{
+		ID:               p.ID,
+		Symbol:           storage.NormalizeSymbol(p.Symbol),
+		Side:             p.Side,
+		EntryPrice:       p.EntryPrice,
+		EntryTime:        p.EntryTime,
+		Quantity:         p.Quantity,
+		Leverage:         p.Leverage,
+		InitialStopLoss:  p.InitialStopLoss,
+		CurrentStopLoss:  p.CurrentStopLoss,
+		StopLossType:     p.StopLossType,
+		TrailingDistance: p.TrailingDistance,
+		HighestPrice:     p.HighestPrice,
+		CurrentPrice:     p.CurrentPrice,
+		UnrealizedPnL:    p.UnrealizedPnL,
+		OpenReason:       p.OpenReason,
+		ATR:              p.ATR,
+		StopLossOrderID:  p.StopLossOrderID,
+	}
+	if rec.HighestPrice == 0 {
+		rec.HighestPrice = p.EntryPrice
+	}
+	if rec.CurrentPrice == 0 {
+		rec.CurrentPrice = p.EntryPrice
+	}
+	return rec
+}
+
+// RestoreActivePositions registers the active positions stored in the database, e.g. after a restart
+// RestoreActivePositions 注册数据库中的活跃持仓（例如重启后）
+//
+// Records of the same symbol and side are deduplicated, preferring one with an entry price. When the
+// exchange can be queried, a record without a matching exchange position is marked stale and reconciled,
+// which closes it in the database. It returns the number of restored and stale positions.
+// 同一交易对和方向的记录只保留一条（优先保留有入场价的）。能查询交易所时，没有对应交易所持仓的记录会被
+// 标记为过期并对账（在数据库中关闭）。返回恢复的持仓数和过期的持仓数。
+func (sm *StopLossManager) RestoreActivePositions(ctx context.Context, verify bool) (restored, stale int, err error) {
+	if sm.storage == nil {
+		return 0, 0, nil
+	}
+	records, err := sm.storage.GetActivePositions()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load active positions: %w", err)
+	}
+
+	// Deduplicate by normalized symbol and side; a hedge-mode long and short are both kept
+	// 按标准化符号和方向去重；双向持仓模式下的多仓和空仓都保留
+	var keys []string
+	byKey := make(map[string]*storage.PositionRecord)
+	for _, rec := range records {
+		key := positionKey(storage.NormalizeSymbol(rec.Symbol), rec.Side)
+		existing, ok := byKey[key]
+		if !ok {
+			keys = append(keys, key)
+			byKey[key] = rec
+			continue
+		}
+		sm.logger.Warning(fmt.Sprintf("⚠️  发现重复持仓记录: %s (%s) 和 %s (%s)", existing.ID, existing.Symbol, rec.ID, rec.Symbol))
+		if existing.EntryPrice == 0 && rec.EntryPrice > 0 {
+			byKey[key] = rec
+		}
+	}
+
+	for _, key := range keys {
+		rec := byKey[key]
+		pos := PositionFromRecord(rec)
+
+		// Restore the partial take-profit plan, the take-profit side of the bracket and the add-ons
+		// 恢复分批止盈计划、括号单的止盈一方以及加仓状态
+		if pt, err := sm.storage.GetPartialTakeProfit(rec.ID); err == nil && pt != nil {
+			pos.PartialTPPrice = pt.TargetPrice
+			pos.PartialTPPercent = pt.ClosePercent
+			pos.PartialTPExecuted = pt.Executed
+		}
+		if price, orderID, err := sm.storage.GetTakeProfit(rec.ID); err == nil {
+			pos.TakeProfitPrice = price
+			pos.TakeProfitOrderID = orderID
+		}
+		if addCount, initialQuantity, err := sm.storage.GetPositionAdds(rec.ID); err == nil {
+			pos.AddCount = addCount
+			pos.InitialQuantity = initialQuantity
+		}
+
+		if verify {
+			positions, err := sm.executor.GetCurrentPositions(ctx, pos.Symbol)
+			if err != nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  无法核对 %s 的交易所持仓: %v（按数据库记录恢复）", pos.Symbol, err))
+			} else if PositionOnSide(positions, pos.Side) == nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  %s %s 在交易所已无持仓，标记为过期并对账", pos.Symbol, pos.Side))
+				pos.Stale = true
+			}
+		}
+
+		sm.RegisterPosition(pos)
+		if pos.Stale {
+			stale++
+			if err := sm.reconcilePosition(ctx, pos.Symbol, pos.Side); err != nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  %s 过期持仓对账失败: %v（下次对账时重试）", pos.Symbol, err))
+			}
+			continue
+		}
+		restored++
+		sm.logger.Success(fmt.Sprintf("已恢复持仓: %s %s @ $%.2f", pos.Symbol, pos.Side, pos.EntryPrice))
+	}
+	return restored, stale, nil
+}
@@ -0,0 +1,40 @@
+package executors
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPositionRecordRoundTrip tests that positions and records convert both ways with a normalized symbol
+// TestPositionRecordRoundTrip 测试持仓与数据库记录双向转换且交易对被标准化
+func TestPositionRecordRoundTrip(t *testing.T) {
+	pos := &Position{
+		ID:              "btc-1",
+		Symbol:          "BTC/USDT",
+		Side:            "short",
+		EntryPrice:      100,
+		EntryTime:       time.Now(),
+		Quantity:        0.5,
+		Leverage:        8,
+		InitialStopLoss: 105,
+		CurrentStopLoss: 103,
+		StopLossType:    "trailing",
+		OpenReason:      "test",
+	}
+
+	rec := pos.Record()
+	if rec.Symbol != "BTCUSDT" {
+		t.Errorf("Record symbol = %s, want BTCUSDT", rec.Symbol)
+	}
+	if rec.HighestPrice != 100 || rec.CurrentPrice != 100 {
+		t.Errorf("Expected prices to default to the entry price, got highest %.2f current %.2f", rec.HighestPrice, rec.CurrentPrice)
+	}
+
+	back := PositionFromRecord(rec)
+	if back.Symbol != "BTCUSDT" || back.Side != "short" || back.Size != 0.5 || back.Quantity != 0.5 {
+		t.Errorf("Unexpected restored position: %+v", back)
+	}
+	if back.Leverage != 8 || back.CurrentStopLoss != 103 || back.StopLossType != "trailing" || back.OpenReason != "test" {
+		t.Errorf("Expected risk fields to survive the round trip, got %+v", back)
+	}
+}
@@ -0,0 +1,383 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
+)
+
+// AddOnAction returns the market action an add-on increases the position with (ADD_LONG → BUY, ADD_SHORT → SELL), "" otherwise
+// AddOnAction 返回加仓动作对应的市价动作（ADD_LONG → BUY，ADD_SHORT → SELL），其他动作返回空
+func AddOnAction(action TradeAction) TradeAction {
+	switch action {
+	case ActionAddLong:
+		return ActionBuy
+	case ActionAddShort:
+		return ActionSell
+	default:
+		return ""
+	}
+}
+
+// IsAddOn reports whether the action adds to an existing position
+// IsAddOn 返回动作是否为加仓
+func IsAddOn(action TradeAction) bool {
+	return AddOnAction(action) != ""
+}
+
+// blendedEntry returns the average entry price of quantity at entryPrice after adding addQty at addPrice
+// blendedEntry 返回在 entryPrice 持有 quantity 后以 addPrice 加仓 addQty 的混合入场价
+func blendedEntry(quantity, entryPrice, addQty, addPrice float64) float64 {
+	total := quantity + addQty
+	if total <= 0 {
+		return entryPrice
+	}
+	return (quantity*entryPrice + addQty*addPrice) / total
+}
+
+// pyramidStop returns the stop of a position after an add-on: stopLoss when given, otherwise the risk
+// distance behind the blended entry, and never looser than currentStop
+// pyramidStop 返回加仓后持仓的止损价：给出 stopLoss 时使用该价格，否则为混合入场价后方 risk 距离处，
+// 且不会比 currentStop 更宽松
+func pyramidStop(side string, blended, risk, currentStop, stopLoss float64) float64 {
+	stop := stopLoss
+	if stop <= 0 {
+		stop = blended - risk
+		if side == "short" {
+			stop = blended + risk
+		}
+	}
+	if currentStop > 0 {
+		if side == "short" {
+			stop = math.Min(stop, currentStop)
+		} else {
+			stop = math.Max(stop, currentStop)
+		}
+	}
+	return stop
+}
+
+// addOnAllowance returns how much can still be added before the position reaches PYRAMID_MAX_SIZE_MULTIPLE
+// times its opening quantity
+// addOnAllowance 返回持仓达到开仓数量 PYRAMID_MAX_SIZE_MULTIPLE 倍之前还能加仓的数量
+func addOnAllowance(cfg *config.Config, pos *Position) float64 {
+	initial := pos.InitialQuantity
+	if initial <= 0 {
+		initial = pos.Quantity
+	}
+	return math.Max(initial*cfg.PyramidMaxSizeMultiple-pos.Quantity, 0)
+}
+
+// checkAddOn returns why an add-on to pos at price is refused, nil when the guardrails allow it
+// checkAddOn 返回在 price 对 pos 加仓被拒绝的原因，护栏允许时返回 nil
+//
+// Add-ons must be enabled (PYRAMID_MAX_ADDS), below the add count and size caps, and the position must be
+// at least PYRAMID_MIN_PROFIT_PERCENT in profit against its blended entry: only winners are scaled into.
+// 加仓必须已启用（PYRAMID_MAX_ADDS）、未达到次数和数量上限，且持仓相对混合入场价的浮盈不低于
+// PYRAMID_MIN_PROFIT_PERCENT：只对盈利持仓加仓。
+func checkAddOn(cfg *config.Config, pos *Position, price float64) error {
+	if cfg.PyramidMaxAdds <= 0 {
+		return errors.New("未启用加仓（PYRAMID_MAX_ADDS=0）")
+	}
+	if pos.AddCount >= cfg.PyramidMaxAdds {
+		return fmt.Errorf("已加仓 %d 次，达到上限 PYRAMID_MAX_ADDS=%d", pos.AddCount, cfg.PyramidMaxAdds)
+	}
+	if pos.EntryPrice <= 0 || price <= 0 {
+		return errors.New("缺少入场价或当前价格，无法判断浮盈")
+	}
+
+	profit := (price - pos.EntryPrice) / pos.EntryPrice * 100
+	if pos.Side == "short" {
+		profit = -profit
+	}
+	if profit < cfg.PyramidMinProfitPercent {
+		return fmt.Errorf("浮盈 %.2f%% 低于加仓要求 %.2f%%（只对盈利持仓加仓）", profit, cfg.PyramidMinProfitPercent)
+	}
+	if addOnAllowance(cfg, pos) <= 0 {
+		return fmt.Errorf("持仓数量 %.4f 已达到开仓数量的 %.1f 倍上限", pos.Quantity, cfg.PyramidMaxSizeMultiple)
+	}
+	return nil
+}
+
+// ExecuteAddOn adds to the managed position on the side of an ADD_LONG / ADD_SHORT decision
+// ExecuteAddOn 为 ADD_LONG / ADD_SHORT 决策对应方向的受管持仓加仓
+//
+// The add-on is sized like an entry from positionSizePercent, capped by PYRAMID_MAX_SIZE_MULTIPLE and refused by
+// the guardrails of checkAddOn. After the fill the stop is recomputed over the blended entry (stopLoss when given).
+// 加仓数量与开仓一样按 positionSizePercent 计算，受 PYRAMID_MAX_SIZE_MULTIPLE 限制，并由 checkAddOn 的护栏把关。
+// 成交后按混合入场价重新计算止损（给出 stopLoss 时使用该价格）。
+func (tc *TradeCoordinator) ExecuteAddOn(ctx context.Context, symbol string, action TradeAction, reason string, positionSizePercent, stopLoss, atr float64) (*TradeResult, error) {
+	tc.logger.Header("加仓", '=', 80)
+	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
+	tc.logger.Info(fmt.Sprintf("动作: %s", action))
+	tc.logger.Info(fmt.Sprintf("理由: %s", reason))
+
+	if !IsAddOn(action) {
+		return nil, fmt.Errorf("%s 不是加仓动作", action)
+	}
+	skipped := func(message string) *TradeResult {
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			TestMode:  tc.config.BinanceTestMode,
+			Skipped:   true,
+			Message:   message,
+		}
+	}
+	if tc.config.IsAnalysisOnly() {
+		return skipped("⏭️  仅分析模式：未配置币安 API Key，决策不会被执行"), nil
+	}
+	if tc.stopLossManager == nil {
+		return nil, fmt.Errorf("止损管理器未初始化，无法加仓")
+	}
+
+	side := ActionSide(action)
+	pos := tc.stopLossManager.GetPositionSide(symbol, side)
+	if pos == nil {
+		return nil, fmt.Errorf("没有受管的 %s 持仓可加仓", side)
+	}
+
+	hookEvent := &plugins.ExecuteEvent{
+		Symbol:              symbol,
+		Action:              string(action),
+		Reason:              reason,
+		Leverage:            pos.Leverage,
+		PositionSizePercent: positionSizePercent,
+		StopLoss:            stopLoss,
+	}
+	if err := plugins.BeforeExecute(ctx, hookEvent); err != nil {
+		return skipped(fmt.Sprintf("🔌 插件否决了本次加仓: %v", err)), nil
+	}
+	if err := tc.consultRiskOracle(ctx, hookEvent); err != nil {
+		return skipped(fmt.Sprintf("🛡️  %v", err)), nil
+	}
+	positionSizePercent, stopLoss = hookEvent.PositionSizePercent, hookEvent.StopLoss
+
+	currentPrice, err := tc.executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前价格失败: %w", err)
+	}
+	if err := checkAddOn(tc.config, pos, currentPrice); err != nil {
+		return skipped("⏭️  已跳过加仓：" + err.Error()), nil
+	}
+	if err := tc.checkFundingCost(ctx, symbol, AddOnAction(action)); err != nil {
+		return skipped("⏭️  已跳过加仓：" + err.Error()), nil
+	}
+	tc.logger.Info(fmt.Sprintf("当前持仓: %s %.4f @ $%.2f，已加仓 %d/%d 次",
+		pos.Side, pos.Quantity, pos.EntryPrice, pos.AddCount, tc.config.PyramidMaxAdds))
+
+	// The add-on uses the leverage the position was opened with
+	// 加仓使用持仓开仓时的杠杆
+	quantity, err := tc.calculatePositionSize(ctx, symbol, AddOnAction(action), nil, pos.Leverage, positionSizePercent, stopLoss, atr, 0)
+	var shortfall *MinNotionalShortfall
+	if errors.As(err, &shortfall) && tc.config.SizingMinNotionalSkip {
+		return skipped("⏭️  已跳过加仓：" + shortfall.Error()), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("position size calculation failed: %w", err)
+	}
+	if allowance := addOnAllowance(tc.config, pos); quantity > allowance {
+		capped, err := tc.executor.AdjustQuantityPrecision(ctx, symbol, allowance)
+		if err != nil || capped <= 0 {
+			return skipped(fmt.Sprintf("⏭️  已跳过加仓：距离数量上限仅剩 %.4f，低于最小下单数量", allowance)), nil
+		}
+		tc.logger.Info(fmt.Sprintf("📏 加仓数量受 PYRAMID_MAX_SIZE_MULTIPLE=%.1f 限制: %.4f → %.4f",
+			tc.config.PyramidMaxSizeMultiple, quantity, capped))
+		quantity = capped
+	}
+
+	if err := tc.checkLiquidity(ctx, symbol, AddOnAction(action), quantity); err != nil {
+		return skipped("⏭️  已跳过加仓：" + err.Error()), nil
+	}
+
+	if tc.config.ShadowMode {
+		order, err := tc.recordShadowOrder(ctx, symbol, action, quantity, pos.Leverage, 0, stopLoss, reason)
+		if err != nil {
+			return nil, fmt.Errorf("shadow order failed: %w", err)
+		}
+		result := skipped(fmt.Sprintf("👻 影子模式：已记录 %s %.4f @ $%.2f（#%d），未下单", action, quantity, order.DecisionPrice, order.ID))
+		result.Amount = quantity
+		result.Price = order.DecisionPrice
+		return result, nil
+	}
+
+	return tc.stopLossManager.AddToPosition(ctx, symbol, side, quantity, stopLoss, reason)
+}
+
+// AddToPosition adds quantity to the managed position on side and re-places its stop over the blended entry
+// AddToPosition 为指定方向的受管持仓加仓 quantity，并按混合入场价重挂止损
+//
+// The stop keeps the position's initial risk distance behind the blended entry unless stopLoss is given,
+// and never moves against the position. The take-profit order is resized for the new quantity.
+// 除非给出 stopLoss，止损保持在混合入场价后方初始风险距离处，且不会朝不利方向移动。止盈单按新数量重挂。
+func (sm *StopLossManager) AddToPosition(ctx context.Context, symbol, side string, quantity, stopLoss float64, reason string) (*TradeResult, error) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists {
+		return nil, fmt.Errorf("持仓 %s 不存在", symbol)
+	}
+
+	result := sm.executor.AddToPosition(ctx, normalizedSymbol, side, quantity, reason)
+	if !result.Success {
+		return result, fmt.Errorf("加仓失败: %s", result.Message)
+	}
+
+	addPrice := result.Price
+	if addPrice <= 0 {
+		addPrice = pos.CurrentPrice
+	}
+	if pos.InitialQuantity <= 0 {
+		pos.InitialQuantity = pos.Quantity
+	}
+
+	// The initial stop moves with the entry, so R (entry to initial stop) stays the same
+	// 初始止损随入场价一起移动，保持 R（入场价到初始止损的距离）不变
+	initialStop := pos.InitialStopLoss
+	if initialStop <= 0 {
+		initialStop = pos.CurrentStopLoss
+	}
+	risk := math.Abs(pos.EntryPrice - initialStop)
+	oldEntry := pos.EntryPrice
+	pos.EntryPrice = blendedEntry(pos.Quantity, pos.EntryPrice, result.Filled, addPrice)
+	pos.InitialStopLoss = initialStop + pos.EntryPrice - oldEntry
+	pos.Quantity += result.Filled
+	pos.Size = pos.Quantity
+	pos.AddCount++
+	sm.logger.Success(fmt.Sprintf("【%s】✅ 第 %d 次加仓成交: %.4f @ %.2f，混合入场价 %.2f → %.2f，总数量 %.4f",
+		pos.Symbol, pos.AddCount, result.Filled, addPrice, oldEntry, pos.EntryPrice, pos.Quantity))
+
+	if sm.storage != nil {
+		if err := sm.storage.SavePositionAdd(pos.ID, pos.EntryPrice, pos.Quantity, pos.InitialStopLoss, pos.AddCount, pos.InitialQuantity); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  保存加仓状态失败: %v", err))
+		}
+	}
+
+	newStop := pyramidStop(pos.Side, pos.EntryPrice, risk, pos.CurrentStopLoss, stopLoss)
+	if err := sm.replaceStopLoss(ctx, symbol, pos, newStop, "加仓后按混合入场价重算止损", "program"); err != nil {
+		return result, fmt.Errorf("加仓后重挂止损失败: %w", err)
+	}
+	sm.resizeTakeProfit(ctx, pos)
+
+	result.Message = fmt.Sprintf("第 %d 次加仓 %.4f @ $%.2f，混合入场价 $%.2f，总数量 %.4f，止损 $%.2f",
+		pos.AddCount, result.Filled, addPrice, pos.EntryPrice, pos.Quantity, pos.CurrentStopLoss)
+	return result, nil
+}
+
+// AddToPosition adds quantity to the current position on side with a market order
+// AddToPosition 以市价单为指定方向的当前持仓加仓
+func (e *BinanceExecutor) AddToPosition(ctx context.Context, symbol, side string, quantity float64, reason string) *TradeResult {
+	result := &TradeResult{
+		Success:   false,
+		Action:    ActionAddLong,
+		Symbol:    symbol,
+		Amount:    quantity,
+		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Reason:    reason,
+		TestMode:  e.testMode,
+	}
+	if side == "short" {
+		result.Action = ActionAddShort
+	}
+
+	if e.InMaintenance() {
+		result.Message = "交易所维护中，暂停加仓"
+		e.logger.Warning("🚧 " + result.Message)
+		return result
+	}
+	if e.KillSwitchEngaged() {
+		result.Message = "急停开关已启用，暂停加仓"
+		e.logger.Warning(fmt.Sprintf("🛑 %s %s %s", symbol, result.Action, result.Message))
+		return result
+	}
+
+	currentPosition, err := e.GetCurrentPositionSide(ctx, symbol, side)
+	if err != nil || currentPosition == nil {
+		result.Message = "没有持仓可加仓"
+		e.logger.Warning("⚠️ 没有持仓可加仓")
+		return result
+	}
+	if quantity <= 0 {
+		result.Message = fmt.Sprintf("加仓数量无效: %.4f", quantity)
+		e.logger.Warning("⚠️ " + result.Message)
+		return result
+	}
+
+	e.logger.Info(fmt.Sprintf("📈 加仓 (%s): %.4f + %.4f (%s)", currentPosition.Side, currentPosition.Size, quantity, reason))
+
+	if e.paper != nil {
+		if err := e.paper.AddToPosition(ctx, symbol, quantity, result); err != nil {
+			result.Message = fmt.Sprintf("订单执行失败: %v", err)
+			e.logger.Error(result.Message)
+			return result
+		}
+		result.NewPosition, _ = e.GetCurrentPosition(ctx, symbol)
+		e.addTradeHistory(*result)
+		return result
+	}
+
+	if e.testMode {
+		e.logger.Warning("测试模式 - 仅模拟加仓，不实际下单")
+		currentPrice, _ := e.GetCurrentPrice(ctx, symbol)
+		result.Success = true
+		result.Price = currentPrice
+		result.Filled = quantity
+		e.modelTestFill(result, currentPrice)
+		result.Message = fmt.Sprintf("测试模式：模拟加仓成功 @ $%.2f（模拟手续费 %.4f USDT）", result.Price, result.Fee)
+		return result
+	}
+
+	e.DetectPositionMode(ctx)
+
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	orderSide, positionSide := futures.SideTypeBuy, futures.PositionSideTypeLong
+	if currentPosition.Side == "short" {
+		orderSide, positionSide = futures.SideTypeSell, futures.PositionSideTypeShort
+	}
+	if e.positionMode == PositionModeOneWay {
+		positionSide = futures.PositionSideTypeBoth
+	}
+
+	sentAt := time.Now()
+	order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(orderSide).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeMarket).
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(quantity)),
+		binanceSymbol, LegAdd, orderSide, quantity)
+	if err != nil {
+		result.Message = fmt.Sprintf("订单执行失败: %v", err)
+		e.logger.Error(result.Message)
+		return result
+	}
+
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", order.OrderID)
+	result.Filled = quantity
+	result.Price, _ = parseFloat(order.AvgPrice)
+	if result.Price == 0 {
+		result.Price, _ = e.GetCurrentPrice(ctx, symbol)
+	}
+	result.Message = "加仓订单执行成功"
+	e.logger.Success(fmt.Sprintf("✅ 加仓成功，订单ID: %d", order.OrderID))
+	result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, orderSide, quantity, result.Price)
+
+	e.awaitPositionUpdate(ctx, symbol, sentAt)
+	result.NewPosition, _ = e.GetCurrentPosition(ctx, symbol)
+	e.addTradeHistory(*result)
+
+	return result
+}
@@ -0,0 +1,80 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestPyramidHelpers tests the blended entry, the stop after an add-on and the add-on allowance
+// TestPyramidHelpers 测试混合入场价、加仓后的止损以及剩余可加仓数量
+func TestPyramidHelpers(t *testing.T) {
+	if AddOnAction(ActionAddLong) != ActionBuy || AddOnAction(ActionAddShort) != ActionSell || IsAddOn(ActionBuy) {
+		t.Error("Unexpected add-on action mapping")
+	}
+
+	if entry := blendedEntry(1, 100, 1, 110); math.Abs(entry-105) > 1e-9 {
+		t.Errorf("Expected blended entry 105, got %.4f", entry)
+	}
+	if entry := blendedEntry(0, 100, 0, 110); entry != 100 {
+		t.Errorf("Expected the entry to be kept without quantity, got %.4f", entry)
+	}
+
+	tests := []struct {
+		name        string
+		side        string
+		currentStop float64
+		stopLoss    float64
+		want        float64
+	}{
+		{"long keeps R behind the blended entry", "long", 95, 0, 100},
+		{"long never loosens the stop", "long", 102, 0, 102},
+		{"short keeps R behind the blended entry", "short", 115, 0, 110},
+		{"LLM stop is used", "long", 95, 101, 101},
+		{"LLM stop is not looser", "short", 108, 112, 108},
+	}
+	for _, tt := range tests {
+		if got := pyramidStop(tt.side, 105, 5, tt.currentStop, tt.stopLoss); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: pyramidStop = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+
+	cfg := &config.Config{PyramidMaxSizeMultiple: 2}
+	if got := addOnAllowance(cfg, &Position{Quantity: 1.5, InitialQuantity: 1}); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("Expected allowance 0.5, got %.4f", got)
+	}
+	if got := addOnAllowance(cfg, &Position{Quantity: 1}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected allowance 1 before the first add-on, got %.4f", got)
+	}
+}
+
+// TestCheckAddOn tests the pyramiding guardrails
+// TestCheckAddOn 测试加仓护栏
+func TestCheckAddOn(t *testing.T) {
+	cfg := &config.Config{PyramidMaxAdds: 2, PyramidMaxSizeMultiple: 2, PyramidMinProfitPercent: 1}
+
+	tests := []struct {
+		name    string
+		pos     Position
+		price   float64
+		wantErr bool
+	}{
+		{"winning long", Position{Side: "long", EntryPrice: 100, Quantity: 1}, 102, false},
+		{"winning short", Position{Side: "short", EntryPrice: 100, Quantity: 1}, 98, false},
+		{"long below the profit threshold", Position{Side: "long", EntryPrice: 100, Quantity: 1}, 100.5, true},
+		{"losing short", Position{Side: "short", EntryPrice: 100, Quantity: 1}, 101, true},
+		{"max adds reached", Position{Side: "long", EntryPrice: 100, Quantity: 1, AddCount: 2}, 105, true},
+		{"max size reached", Position{Side: "long", EntryPrice: 100, Quantity: 2, InitialQuantity: 1, AddCount: 1}, 105, true},
+	}
+	for _, tt := range tests {
+		if err := checkAddOn(cfg, &tt.pos, tt.price); (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkAddOn() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	cfg.PyramidMaxAdds = 0
+	if err := checkAddOn(cfg, &Position{Side: "long", EntryPrice: 100, Quantity: 1}, 110); err == nil {
+		t.Error("Expected add-ons to be refused when disabled")
+	}
+}
@@ -0,0 +1,120 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IsReduce reports whether the action closes part of a position (REDUCE_LONG / REDUCE_SHORT)
+// IsReduce 返回动作是否为减仓（REDUCE_LONG / REDUCE_SHORT）
+func IsReduce(action TradeAction) bool {
+	return action == ActionReduceLong || action == ActionReduceShort
+}
+
+// ExecuteReduce closes reducePercent of the managed position on the side of a REDUCE_LONG / REDUCE_SHORT decision
+// ExecuteReduce 平掉 REDUCE_LONG / REDUCE_SHORT 决策对应方向受管持仓的 reducePercent 部分
+//
+// Unlike a partial take-profit the stop is not moved to breakeven: it is only re-placed for the remaining quantity.
+// 与分批止盈不同，止损不会移至保本价，只按剩余数量重挂。
+func (tc *TradeCoordinator) ExecuteReduce(ctx context.Context, symbol string, action TradeAction, reducePercent float64, reason string) (*TradeResult, error) {
+	tc.logger.Header("减仓", '=', 80)
+	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
+	tc.logger.Info(fmt.Sprintf("动作: %s", action))
+	tc.logger.Info(fmt.Sprintf("减仓比例: %.1f%%", reducePercent))
+	tc.logger.Info(fmt.Sprintf("理由: %s", reason))
+
+	if !IsReduce(action) {
+		return nil, fmt.Errorf("%s 不是减仓动作", action)
+	}
+	if reducePercent <= 0 || reducePercent >= 100 {
+		return nil, fmt.Errorf("减仓比例必须在 0-100 之间（不含），当前: %.1f%%", reducePercent)
+	}
+	if tc.config.IsAnalysisOnly() {
+		message := "⏭️  仅分析模式：未配置币安 API Key，决策不会被执行"
+		tc.logger.Warning(message)
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   message,
+		}, nil
+	}
+	if tc.stopLossManager == nil {
+		return nil, fmt.Errorf("止损管理器未初始化，无法减仓")
+	}
+
+	side := ActionSide(action)
+	pos := tc.stopLossManager.GetPositionSide(symbol, side)
+	if pos == nil {
+		return nil, fmt.Errorf("没有受管的 %s 持仓可减仓", side)
+	}
+
+	// Shadow mode: the reduction is recorded instead of sent
+	// 影子模式：记录减仓订单，而不是发送
+	if tc.config.ShadowMode {
+		quantity, err := tc.executor.AdjustQuantityPrecision(ctx, symbol, pos.Quantity*reducePercent/100)
+		if err != nil {
+			return nil, fmt.Errorf("减仓数量精度调整失败: %w", err)
+		}
+		order, err := tc.recordShadowOrder(ctx, symbol, action, quantity, pos.Leverage, 0, pos.CurrentStopLoss, reason)
+		if err != nil {
+			return nil, fmt.Errorf("shadow order failed: %w", err)
+		}
+		return &TradeResult{
+			Action:    action,
+			Symbol:    symbol,
+			Amount:    quantity,
+			Price:     order.DecisionPrice,
+			Timestamp: time.Now().Format("2006-01-02 15:04:05"),
+			Reason:    reason,
+			Skipped:   true,
+			Message:   fmt.Sprintf("👻 影子模式：已记录 %s %.4f @ $%.2f（#%d），未下单", action, quantity, order.DecisionPrice, order.ID),
+		}, nil
+	}
+
+	return tc.stopLossManager.ReducePosition(ctx, symbol, side, reducePercent, reason)
+}
+
+// ReducePosition closes reducePercent of the managed position on side and re-places its protective orders
+// for the remaining quantity
+// ReducePosition 平掉指定方向受管持仓的 reducePercent 部分，并按剩余数量重挂保护单
+func (sm *StopLossManager) ReducePosition(ctx context.Context, symbol, side string, reducePercent float64, reason string) (*TradeResult, error) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists {
+		return nil, fmt.Errorf("持仓 %s 不存在", symbol)
+	}
+
+	result, closeQty, realizedPnL, err := sm.closePortion(ctx, normalizedSymbol, pos, reducePercent, reason)
+	if err != nil {
+		return result, err
+	}
+	result.Action = ActionReduceLong
+	if pos.Side == "short" {
+		result.Action = ActionReduceShort
+	}
+	sm.logger.Success(fmt.Sprintf("【%s】✅ 减仓成交: 平仓 %.4f @ %.2f，已实现盈亏 %+.2f USDT，剩余 %.4f",
+		pos.Symbol, closeQty, result.Price, realizedPnL, pos.Quantity))
+
+	// Reductions share the partial close totals, so the final PnL includes them
+	// 减仓与分批止盈共用部分平仓累计，最终盈亏会计入减仓部分
+	if err := sm.savePartialTakeProfit(pos, closeQty, realizedPnL); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  保存减仓状态失败: %v", err))
+	}
+
+	if err := sm.replaceStopLoss(ctx, symbol, pos, pos.CurrentStopLoss, "减仓后按剩余数量重挂止损", "program"); err != nil {
+		return result, fmt.Errorf("减仓后重挂止损失败: %w", err)
+	}
+	sm.resizeTakeProfit(ctx, pos)
+
+	result.Message = fmt.Sprintf("减仓 %.0f%%: 平仓 %.4f @ $%.2f，已实现盈亏 %+.2f USDT，剩余 %.4f",
+		reducePercent, closeQty, result.Price, realizedPnL, pos.Quantity)
+	return result, nil
+}
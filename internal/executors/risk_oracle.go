@@ -0,0 +1,165 @@
+package executors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
+)
+
+// RiskOracleRequest is the proposed entry posted to RISK_ORACLE_URL
+// RiskOracleRequest 是发送到 RISK_ORACLE_URL 的拟开仓交易
+type RiskOracleRequest struct {
+	BotID               string  `json:"bot_id"`
+	Symbol              string  `json:"symbol"`
+	Action              string  `json:"action"`
+	Reason              string  `json:"reason"`
+	Leverage            int     `json:"leverage"`              // 0 表示使用默认杠杆 / 0 = default leverage
+	PositionSizePercent float64 `json:"position_size_percent"` // 0 表示使用默认仓位 / 0 = default size
+	StopLoss            float64 `json:"stop_loss"`             // 0 表示未设置 / 0 = not set
+	TestMode            bool    `json:"test_mode"`
+	PaperTrading        bool    `json:"paper_trading"`
+	Timestamp           string  `json:"timestamp"`
+}
+
+// RiskOracleVerdict is the oracle's answer; omitted fields keep the proposed values
+// RiskOracleVerdict 是风控服务的答复，省略的字段保持原值
+type RiskOracleVerdict struct {
+	Approved            bool     `json:"approved"`
+	Reason              string   `json:"reason"`
+	Leverage            *int     `json:"leverage,omitempty"`
+	PositionSizePercent *float64 `json:"position_size_percent,omitempty"`
+	StopLoss            *float64 `json:"stop_loss,omitempty"`
+}
+
+// RiskOracle asks an external service to approve, reject or resize entries, so several bots share one risk control
+// RiskOracle 请外部服务批准、拒绝或调整开仓，使多个机器人共用同一套风控
+type RiskOracle struct {
+	url      string
+	token    string
+	botID    string
+	failOpen bool
+	paper    bool
+	client   *http.Client
+}
+
+// NewRiskOracle creates the oracle client from the RISK_ORACLE_* settings (nil when RISK_ORACLE_URL is empty)
+// NewRiskOracle 根据 RISK_ORACLE_* 配置创建风控服务客户端（RISK_ORACLE_URL 为空时返回 nil）
+func NewRiskOracle(cfg *config.Config) *RiskOracle {
+	if cfg.RiskOracleURL == "" {
+		return nil
+	}
+	botID := cfg.RiskOracleBotID
+	if botID == "" {
+		botID, _ = os.Hostname()
+	}
+	timeout := time.Duration(cfg.RiskOracleTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &RiskOracle{
+		url:      cfg.RiskOracleURL,
+		token:    cfg.RiskOracleToken,
+		botID:    botID,
+		failOpen: cfg.RiskOracleFailOpen,
+		paper:    cfg.PaperTrading,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Review posts the proposed entry and returns the verdict
+// Review 发送拟开仓交易并返回风控结论
+func (o *RiskOracle) Review(ctx context.Context, req RiskOracleRequest) (*RiskOracleVerdict, error) {
+	req.BotID = o.botID
+	req.PaperTrading = o.paper
+	req.Timestamp = time.Now().Format(time.RFC3339)
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.token)
+	}
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var verdict RiskOracleVerdict
+	if err := json.Unmarshal(respBody, &verdict); err != nil {
+		return nil, fmt.Errorf("failed to parse verdict: %w", err)
+	}
+	return &verdict, nil
+}
+
+// consultRiskOracle sends an entry to the risk oracle and applies its adjustments to the event
+// consultRiskOracle 将开仓交易发送给风控服务，并把调整应用到事件上
+//
+// Closes and holds reduce risk and are never sent. An unreachable oracle rejects the entry unless
+// RISK_ORACLE_FAIL_OPEN is set; non-positive adjustments are ignored.
+// 平仓和观望会降低风险，从不发送。风控服务不可用时拒绝开仓，除非设置了 RISK_ORACLE_FAIL_OPEN；
+// 非正数的调整值会被忽略。
+func (tc *TradeCoordinator) consultRiskOracle(ctx context.Context, event *plugins.ExecuteEvent) error {
+	action := TradeAction(event.Action)
+	if tc.riskOracle == nil || (action != ActionBuy && action != ActionSell && !IsStopEntry(action) && !IsAddOn(action)) {
+		return nil
+	}
+
+	verdict, err := tc.riskOracle.Review(ctx, RiskOracleRequest{
+		Symbol:              event.Symbol,
+		Action:              event.Action,
+		Reason:              event.Reason,
+		Leverage:            event.Leverage,
+		PositionSizePercent: event.PositionSizePercent,
+		StopLoss:            event.StopLoss,
+		TestMode:            tc.config.BinanceTestMode,
+	})
+	if err != nil {
+		if tc.riskOracle.failOpen {
+			tc.logger.Warning(fmt.Sprintf("⚠️  外部风控服务不可用，按 RISK_ORACLE_FAIL_OPEN 放行: %v", err))
+			return nil
+		}
+		return fmt.Errorf("外部风控服务不可用: %w", err)
+	}
+	if !verdict.Approved {
+		return fmt.Errorf("外部风控拒绝: %s", verdict.Reason)
+	}
+
+	if verdict.Leverage != nil && *verdict.Leverage > 0 && *verdict.Leverage != event.Leverage {
+		tc.logger.Info(fmt.Sprintf("🛡️  外部风控调整杠杆: %dx → %dx", event.Leverage, *verdict.Leverage))
+		event.Leverage = *verdict.Leverage
+	}
+	if verdict.PositionSizePercent != nil && *verdict.PositionSizePercent > 0 && *verdict.PositionSizePercent != event.PositionSizePercent {
+		tc.logger.Info(fmt.Sprintf("🛡️  外部风控调整仓位: %.1f%% → %.1f%%", event.PositionSizePercent, *verdict.PositionSizePercent))
+		event.PositionSizePercent = *verdict.PositionSizePercent
+	}
+	if verdict.StopLoss != nil && *verdict.StopLoss > 0 && *verdict.StopLoss != event.StopLoss {
+		tc.logger.Info(fmt.Sprintf("🛡️  外部风控调整止损: %.4f → %.4f", event.StopLoss, *verdict.StopLoss))
+		event.StopLoss = *verdict.StopLoss
+	}
+	tc.logger.Success(fmt.Sprintf("🛡️  外部风控批准: %s", verdict.Reason))
+	return nil
+}
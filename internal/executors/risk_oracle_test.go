@@ -0,0 +1,83 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
+)
+
+// TestConsultRiskOracle tests approval with adjustments, rejection and that closes are never sent
+// TestConsultRiskOracle 测试带调整的批准、拒绝，以及平仓从不发送
+func TestConsultRiskOracle(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected the bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		var req RiskOracleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if req.BotID != "bot-1" || !req.TestMode {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		if req.Symbol == "ETH/USDT" {
+			fmt.Fprint(w, `{"approved": false, "reason": "组合敞口超限"}`)
+			return
+		}
+		fmt.Fprint(w, `{"approved": true, "reason": "ok", "position_size_percent": 10, "stop_loss": 95000}`)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{BinanceTestMode: true, RiskOracleURL: server.URL, RiskOracleToken: "secret", RiskOracleBotID: "bot-1"}
+	tc := &TradeCoordinator{config: cfg, logger: logger.NewColorLogger(false), riskOracle: NewRiskOracle(cfg)}
+	ctx := context.Background()
+
+	event := &plugins.ExecuteEvent{Symbol: "BTC/USDT", Action: "BUY", Leverage: 10, PositionSizePercent: 30, StopLoss: 94000}
+	if err := tc.consultRiskOracle(ctx, event); err != nil {
+		t.Fatalf("Expected approval, got %v", err)
+	}
+	if event.Leverage != 10 || event.PositionSizePercent != 10 || event.StopLoss != 95000 {
+		t.Errorf("Expected the size and stop to be adjusted, got %+v", event)
+	}
+
+	err := tc.consultRiskOracle(ctx, &plugins.ExecuteEvent{Symbol: "ETH/USDT", Action: "SELL_STOP"})
+	if err == nil || !strings.Contains(err.Error(), "组合敞口超限") {
+		t.Errorf("Expected the rejection reason, got %v", err)
+	}
+
+	if err := tc.consultRiskOracle(ctx, &plugins.ExecuteEvent{Symbol: "BTC/USDT", Action: "CLOSE_LONG"}); err != nil || requests != 2 {
+		t.Errorf("Expected closes to skip the oracle, got %v after %d requests", err, requests)
+	}
+}
+
+// TestRiskOracleUnreachable tests that an unreachable oracle rejects entries unless RISK_ORACLE_FAIL_OPEN is set
+// TestRiskOracleUnreachable 测试风控服务不可用时拒绝开仓，除非设置了 RISK_ORACLE_FAIL_OPEN
+func TestRiskOracleUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	for _, failOpen := range []bool{false, true} {
+		cfg := &config.Config{RiskOracleURL: server.URL, RiskOracleFailOpen: failOpen}
+		tc := &TradeCoordinator{config: cfg, logger: logger.NewColorLogger(false), riskOracle: NewRiskOracle(cfg)}
+		err := tc.consultRiskOracle(context.Background(), &plugins.ExecuteEvent{Symbol: "BTC/USDT", Action: "BUY"})
+		if (err == nil) != failOpen {
+			t.Errorf("failOpen=%v: got %v", failOpen, err)
+		}
+	}
+
+	if NewRiskOracle(&config.Config{}) != nil {
+		t.Error("Expected no oracle without RISK_ORACLE_URL")
+	}
+}
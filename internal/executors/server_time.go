@@ -0,0 +1,44 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ServerTimeDrift pings the Binance REST API and returns the server time minus the local time
+// ServerTimeDrift 对币安 REST API 执行 ping，并返回服务器时间减去本地时间的差值
+//
+// The local time is taken halfway through the request so network latency doesn't count as drift.
+// 本地时间取请求往返的中点，避免把网络延迟算作时钟偏差。
+func (e *BinanceExecutor) ServerTimeDrift(ctx context.Context) (time.Duration, error) {
+	if err := e.client.NewPingService().Do(ctx); err != nil {
+		return 0, fmt.Errorf("ping 失败: %w", err)
+	}
+
+	sent := time.Now()
+	serverMs, err := e.client.NewServerTimeService().Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("获取服务器时间失败: %w", err)
+	}
+	local := sent.Add(time.Since(sent) / 2)
+	return time.UnixMilli(serverMs).Sub(local), nil
+}
+
+// SyncServerTime measures the drift and sets the client's time offset, so signed requests carry
+// timestamps in Binance's time even when the local clock is off
+// SyncServerTime 测量时钟偏差并设置客户端的时间偏移，使本地时钟不准时签名请求仍使用币安时间的时间戳
+//
+// Call it only at startup, before the executor is shared with other goroutines: the go-binance client
+// reads TimeOffset without synchronization on every signed request.
+// 只能在启动时、执行器被其他 goroutine 使用之前调用：go-binance 客户端在每次签名请求时无同步地读取 TimeOffset。
+func (e *BinanceExecutor) SyncServerTime(ctx context.Context) (time.Duration, error) {
+	drift, err := e.ServerTimeDrift(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// The client sends local time minus TimeOffset
+	// 客户端发送的时间戳为本地时间减去 TimeOffset
+	e.client.TimeOffset = -drift.Milliseconds()
+	return drift, nil
+}
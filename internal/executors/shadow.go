@@ -0,0 +1,169 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// shadowBar is the part of a kline used to evaluate a shadow order
+// shadowBar 是评估影子订单所用的 K 线部分
+type shadowBar struct {
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// recordShadowOrder stores the order a decision would have sent in shadow mode, priced at the current market
+// recordShadowOrder 记录影子模式下决策本应发送的订单，价格取当前市价
+func (tc *TradeCoordinator) recordShadowOrder(ctx context.Context, symbol string, action TradeAction, quantity float64, leverage int, triggerPrice, stopLoss float64, reason string) (*storage.ShadowOrder, error) {
+	if tc.stopLossManager == nil || tc.stopLossManager.storage == nil {
+		return nil, fmt.Errorf("数据库未初始化，无法记录影子订单")
+	}
+	price, err := tc.executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前价格失败: %w", err)
+	}
+	if leverage <= 0 {
+		leverage = tc.config.BinanceLeverage
+	}
+
+	order := &storage.ShadowOrder{
+		Symbol:        tc.config.GetBinanceSymbolFor(symbol),
+		Action:        string(action),
+		Quantity:      quantity,
+		Leverage:      leverage,
+		DecisionPrice: price,
+		TriggerPrice:  triggerPrice,
+		StopLoss:      stopLoss,
+		Reason:        reason,
+	}
+	if err := tc.stopLossManager.storage.SaveShadowOrder(order); err != nil {
+		return nil, err
+	}
+	tc.logger.Warning(fmt.Sprintf("👻 影子模式：已记录意向订单 #%d %s %.4f %s @ $%.2f，未下单",
+		order.ID, action, quantity, symbol, price))
+	return order, nil
+}
+
+// EvaluateShadowOrders evaluates the shadow orders older than SHADOW_EVAL_HOURS against the klines that followed them,
+// returning how many were evaluated
+// EvaluateShadowOrders 按随后的 K 线评估超过 SHADOW_EVAL_HOURS 的影子订单，返回评估数量
+func (e *BinanceExecutor) EvaluateShadowOrders(ctx context.Context, db *storage.Storage, now time.Time) (int, error) {
+	horizon := time.Duration(e.config.ShadowEvalHours) * time.Hour
+	orders, err := db.GetUnevaluatedShadowOrders(now.Add(-horizon))
+	if err != nil {
+		return 0, err
+	}
+
+	evaluated := 0
+	for _, order := range orders {
+		bars, err := e.shadowBars(ctx, order.Symbol, order.CreatedAt, order.CreatedAt.Add(horizon))
+		if err != nil {
+			return evaluated, fmt.Errorf("获取 %s K 线失败: %w", order.Symbol, err)
+		}
+		if len(bars) == 0 {
+			e.logger.Warning(fmt.Sprintf("⚠️  影子订单 #%d 没有评估期内的 K 线，跳过", order.ID))
+			continue
+		}
+
+		evaluateShadowOrder(order, bars)
+		order.EvaluatedAt = now
+		if err := db.UpdateShadowEvaluation(order); err != nil {
+			return evaluated, err
+		}
+		evaluated++
+	}
+	return evaluated, nil
+}
+
+// shadowBars fetches the klines between start and end, 5m bars when they fit in one request and 1h bars otherwise
+// shadowBars 获取 start 到 end 之间的 K 线，单次请求能容纳时使用 5 分钟线，否则使用 1 小时线
+func (e *BinanceExecutor) shadowBars(ctx context.Context, symbol string, start, end time.Time) ([]shadowBar, error) {
+	interval := "5m"
+	if end.Sub(start) > 1000*5*time.Minute {
+		interval = "1h"
+	}
+	klines, err := e.client.NewKlinesService().
+		Symbol(symbol).
+		Interval(interval).
+		StartTime(start.UnixMilli()).
+		EndTime(end.UnixMilli()).
+		Limit(1000).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bars := make([]shadowBar, 0, len(klines))
+	for _, k := range klines {
+		high, _ := parseFloat(k.High)
+		low, _ := parseFloat(k.Low)
+		closePrice, _ := parseFloat(k.Close)
+		bars = append(bars, shadowBar{High: high, Low: low, Close: closePrice})
+	}
+	return bars, nil
+}
+
+// shadowDirection returns +1 for orders that profit from a rising price and -1 for those that profit from a falling one
+// shadowDirection 对价格上涨获利的订单返回 +1，对价格下跌获利的订单返回 -1
+func shadowDirection(action string) float64 {
+	switch TradeAction(action) {
+	case ActionSell, ActionSellStop, ActionAddShort, ActionReduceLong, ActionCloseLong:
+		return -1
+	}
+	return 1
+}
+
+// evaluateShadowOrder fills in the outcome of an order from the bars of its evaluation horizon
+// evaluateShadowOrder 根据评估期内的 K 线计算订单结果
+//
+// A stop entry starts at its trigger price on the first bar that reaches it. The stop-loss is assumed to fill
+// at its price on the first bar that touches it; otherwise the order exits at the last close.
+// 条件入场单在首根触及触发价的 K 线以触发价入场。止损假设在首根触及的 K 线按止损价成交，否则按最后收盘价退出。
+func evaluateShadowOrder(order *storage.ShadowOrder, bars []shadowBar) {
+	dir := shadowDirection(order.Action)
+	entry := order.DecisionPrice
+	pct := func(price float64) float64 {
+		return dir * (price - entry) / entry * 100
+	}
+
+	if order.TriggerPrice > 0 {
+		start := -1
+		for i, bar := range bars {
+			if (dir > 0 && bar.High >= order.TriggerPrice) || (dir < 0 && bar.Low <= order.TriggerPrice) {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			order.Outcome = storage.ShadowOutcomeUntriggered
+			order.ExitPrice = bars[len(bars)-1].Close
+			return
+		}
+		entry = order.TriggerPrice
+		bars = bars[start:]
+	}
+
+	order.Outcome = storage.ShadowOutcomeHorizon
+	order.ExitPrice = bars[len(bars)-1].Close
+	order.MaxFavorablePct, order.MaxAdversePct = 0, 0
+	for _, bar := range bars {
+		favorable, adverse := bar.High, bar.Low
+		if dir < 0 {
+			favorable, adverse = bar.Low, bar.High
+		}
+		if order.StopLoss > 0 && ((dir > 0 && bar.Low <= order.StopLoss) || (dir < 0 && bar.High >= order.StopLoss)) {
+			order.Outcome = storage.ShadowOutcomeStop
+			order.ExitPrice = order.StopLoss
+			order.MaxAdversePct = math.Min(order.MaxAdversePct, pct(order.StopLoss))
+			break
+		}
+		order.MaxFavorablePct = math.Max(order.MaxFavorablePct, pct(favorable))
+		order.MaxAdversePct = math.Min(order.MaxAdversePct, pct(adverse))
+	}
+	order.ReturnPct = pct(order.ExitPrice)
+}
@@ -0,0 +1,72 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestEvaluateShadowOrder tests the outcome of shadow orders: held to the horizon, stopped out and stop entries
+// TestEvaluateShadowOrder 测试影子订单的评估结果：持有到期、触发止损以及条件入场单
+func TestEvaluateShadowOrder(t *testing.T) {
+	bars := []shadowBar{
+		{High: 101, Low: 99, Close: 100.5},
+		{High: 104, Low: 100, Close: 103},
+		{High: 103.5, Low: 97, Close: 98},
+		{High: 99, Low: 96, Close: 97},
+	}
+
+	tests := []struct {
+		name    string
+		order   storage.ShadowOrder
+		outcome string
+		exit    float64
+		ret     float64
+		mfe     float64
+		mae     float64
+	}{
+		{
+			name:    "long held to the horizon",
+			order:   storage.ShadowOrder{Action: "BUY", DecisionPrice: 100},
+			outcome: storage.ShadowOutcomeHorizon, exit: 97, ret: -3, mfe: 4, mae: -4,
+		},
+		{
+			name:    "long stopped out on the third bar",
+			order:   storage.ShadowOrder{Action: "BUY", DecisionPrice: 100, StopLoss: 98},
+			outcome: storage.ShadowOutcomeStop, exit: 98, ret: -2, mfe: 4, mae: -2,
+		},
+		{
+			name:    "short held to the horizon",
+			order:   storage.ShadowOrder{Action: "SELL", DecisionPrice: 100},
+			outcome: storage.ShadowOutcomeHorizon, exit: 97, ret: 3, mfe: 4, mae: -4,
+		},
+		{
+			name:    "closing a long profits from the fall",
+			order:   storage.ShadowOrder{Action: "CLOSE_LONG", DecisionPrice: 100},
+			outcome: storage.ShadowOutcomeHorizon, exit: 97, ret: 3, mfe: 4, mae: -4,
+		},
+		{
+			name:    "buy stop entered at its trigger on the second bar",
+			order:   storage.ShadowOrder{Action: "BUY_STOP", DecisionPrice: 100, TriggerPrice: 102},
+			outcome: storage.ShadowOutcomeHorizon, exit: 97, ret: -4.901960784313726, mfe: 1.9607843137254901, mae: -5.88235294117647,
+		},
+		{
+			name:    "buy stop never triggered",
+			order:   storage.ShadowOrder{Action: "BUY_STOP", DecisionPrice: 100, TriggerPrice: 105},
+			outcome: storage.ShadowOutcomeUntriggered, exit: 97,
+		},
+	}
+
+	for _, tt := range tests {
+		order := tt.order
+		evaluateShadowOrder(&order, bars)
+		if order.Outcome != tt.outcome || order.ExitPrice != tt.exit {
+			t.Errorf("%s: outcome %s @ %.2f, want %s @ %.2f", tt.name, order.Outcome, order.ExitPrice, tt.outcome, tt.exit)
+		}
+		if math.Abs(order.ReturnPct-tt.ret) > 1e-9 || math.Abs(order.MaxFavorablePct-tt.mfe) > 1e-9 || math.Abs(order.MaxAdversePct-tt.mae) > 1e-9 {
+			t.Errorf("%s: return %.4f%% (MFE %.4f%%, MAE %.4f%%), want %.4f%% (MFE %.4f%%, MAE %.4f%%)",
+				tt.name, order.ReturnPct, order.MaxFavorablePct, order.MaxAdversePct, tt.ret, tt.mfe, tt.mae)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// Position sizing policies (SIZING_POLICY)
+// 仓位计算策略（SIZING_POLICY）
+const (
+	// SizingPolicyCompound sizes as a percent of the current balance, so positions grow with the account
+	// SizingPolicyCompound 按当前余额的百分比计算仓位，仓位随账户增长而复利放大
+	SizingPolicyCompound = "compound"
+
+	// SizingPolicyFixed sizes as a percent of SIZING_FIXED_CAPITAL, so the same percent is always the same USDT
+	// SizingPolicyFixed 按 SIZING_FIXED_CAPITAL 的百分比计算仓位，相同百分比始终对应相同 USDT
+	SizingPolicyFixed = "fixed"
+)
+
+// SizingPolicy returns the active sizing policy, falling back to compounding when fixed sizing has no capital
+// SizingPolicy 返回当前生效的仓位策略，固定仓位未配置资金时回退为复利模式
+func SizingPolicy(cfg *config.Config) string {
+	if strings.EqualFold(cfg.SizingPolicy, SizingPolicyFixed) && cfg.SizingFixedCapital > 0 {
+		return SizingPolicyFixed
+	}
+	return SizingPolicyCompound
+}
+
+// SizingCapital returns the capital the LLM position percentage applies to
+// SizingCapital 返回 LLM 仓位百分比所对应的资金基数
+//
+// Fixed sizing never commits more than the available balance: after a drawdown below
+// SIZING_FIXED_CAPITAL the balance is used instead.
+// 固定仓位不会超过可用余额：回撤至 SIZING_FIXED_CAPITAL 以下时改用可用余额。
+func SizingCapital(cfg *config.Config, balance float64) float64 {
+	if SizingPolicy(cfg) == SizingPolicyFixed && cfg.SizingFixedCapital < balance {
+		return cfg.SizingFixedCapital
+	}
+	return balance
+}
+
+// SizingPolicyLabel describes the active sizing policy for session reports
+// SizingPolicyLabel 返回当前仓位策略的描述，用于会话记录
+func SizingPolicyLabel(cfg *config.Config) string {
+	if SizingPolicy(cfg) == SizingPolicyFixed {
+		return fmt.Sprintf("%s (%.2f USDT)", SizingPolicyFixed, cfg.SizingFixedCapital)
+	}
+	return SizingPolicyCompound
+}
+
+// RiskSizing is the result of risk-per-trade sizing
+// RiskSizing 是按单笔风险计算仓位的结果
+type RiskSizing struct {
+	RiskAmount   float64 // 单笔风险金额（USDT）/ Amount risked on the trade (USDT)
+	StopDistance float64 // 用于计算的止损距离（价格）/ Stop distance used for sizing (price)
+	FromATR      bool    // 止损距离是否来自 ATR / Whether the distance came from ATR
+	Quantity     float64 // 风险对应的数量 / Quantity matching the risk
+}
+
+// RiskSizedQuantity computes the quantity that loses riskPercent of capital if the stop is hit
+// RiskSizedQuantity 计算止损触发时恰好亏损资金 riskPercent 的数量
+//
+// The stop distance is the distance to stopLoss, floored at ATR × atrMultiplier so a stop tighter
+// than normal volatility doesn't inflate the size; without a stop the ATR distance is used.
+// Returns nil when neither a stop nor an ATR is available.
+// 止损距离为当前价到 stopLoss 的距离，并以 ATR × atrMultiplier 为下限，避免比正常波动更窄的止损放大仓位；
+// 未提供止损时使用 ATR 距离。止损和 ATR 都不可用时返回 nil。
+func RiskSizedQuantity(capital, riskPercent, price, stopLoss, atr, atrMultiplier float64) *RiskSizing {
+	if capital <= 0 || riskPercent <= 0 || price <= 0 {
+		return nil
+	}
+
+	sizing := &RiskSizing{RiskAmount: capital * riskPercent / 100}
+	if stopLoss > 0 {
+		sizing.StopDistance = math.Abs(price - stopLoss)
+	}
+	if atrDistance := atr * atrMultiplier; atrDistance > sizing.StopDistance {
+		sizing.StopDistance = atrDistance
+		sizing.FromATR = true
+	}
+	if sizing.StopDistance <= 0 {
+		return nil
+	}
+
+	sizing.Quantity = sizing.RiskAmount / sizing.StopDistance
+	return sizing
+}
+
+// PositionPlan is the size of an opening order before exchange precision is applied
+// PositionPlan 是应用交易所精度之前的开仓仓位
+type PositionPlan struct {
+	Capital         float64     // 资金基数 / Sizing capital
+	Margin          float64     // LLM 仓位百分比对应的保证金 / Margin from the LLM position percent
+	Leverage        int         // 使用的杠杆 / Leverage used
+	PercentQuantity float64     // 仅按仓位百分比计算的数量 / Quantity from the position percent alone
+	Quantity        float64     // 最终数量（已按单笔风险缩减）/ Final quantity (after the risk-per-trade reduction)
+	Risk            *RiskSizing // 单笔风险计算结果（未启用或不可用时为 nil）/ Risk-per-trade sizing (nil when disabled or unavailable)
+}
+
+// PlanPosition sizes an opening order: capital × percent × leverage / price, then shrunk so a
+// stop-out loses at most SIZING_RISK_PER_TRADE% of capital
+// PlanPosition 计算开仓数量：资金基数 × 百分比 × 杠杆 / 价格，再按单笔风险缩减，使止损最多亏损资金的 SIZING_RISK_PER_TRADE%
+//
+// The coordinator sizes orders with it and the portfolio manager uses it to estimate orders for
+// the risk check, so both always agree. A leverage of 0 uses BINANCE_LEVERAGE.
+// 协调器用它计算订单数量，组合管理器用它为风控检查估算订单，二者始终一致。杠杆为 0 时使用 BINANCE_LEVERAGE。
+func PlanPosition(cfg *config.Config, balance, price, positionSizePercent float64, leverage int, stopLoss, atr float64) PositionPlan {
+	if leverage <= 0 {
+		leverage = cfg.BinanceLeverage
+	}
+
+	plan := PositionPlan{
+		Capital:  SizingCapital(cfg, balance),
+		Leverage: leverage,
+	}
+	plan.Margin = plan.Capital * positionSizePercent / 100
+	if price > 0 {
+		plan.PercentQuantity = plan.Margin * float64(leverage) / price
+	}
+	plan.Quantity = plan.PercentQuantity
+
+	if cfg.SizingRiskPerTrade > 0 {
+		plan.Risk = RiskSizedQuantity(plan.Capital, cfg.SizingRiskPerTrade, price, stopLoss, atr, cfg.SizingATRMultiplier)
+		if plan.Risk != nil && plan.Risk.Quantity < plan.Quantity {
+			plan.Quantity = plan.Risk.Quantity
+		}
+	}
+	return plan
+}
+
+// MinNotionalShortfall describes an opening order whose value is below the exchange minimum
+// MinNotionalShortfall 描述订单价值低于交易所最小要求的开仓订单
+type MinNotionalShortfall struct {
+	Symbol              string
+	Notional            float64 // 调整精度后的订单价值 / Order value after precision adjustment
+	MinNotional         float64 // 交易所 MIN_NOTIONAL / Exchange MIN_NOTIONAL
+	Required            float64 // 含缓冲的最小订单价值 / Minimum order value including the buffer
+	PositionSizePercent float64 // LLM 仓位百分比 / LLM position percent
+	Capital             float64 // 资金基数 / Sizing capital
+	Leverage            int     // 使用的杠杆 / Leverage used
+	MinPercent          float64 // 当前资金基数下所需的最小仓位百分比 / Minimum position percent at the current capital
+	MinCapital          float64 // 当前仓位百分比下所需的最小资金 / Minimum capital at the current position percent
+}
+
+// CheckMinNotional returns the shortfall when notional is below MIN_NOTIONAL plus SIZING_MIN_NOTIONAL_BUFFER%, nil otherwise
+// CheckMinNotional 在订单价值低于 MIN_NOTIONAL 加 SIZING_MIN_NOTIONAL_BUFFER% 时返回不足说明，否则返回 nil
+//
+// The suggested account size scales the current capital by required / notional, so it accounts for
+// risk-per-trade sizing as well as the position percent and leverage.
+// 建议资金按"所需价值 / 订单价值"等比放大当前资金基数，因此同时考虑了单笔风险缩减、仓位百分比和杠杆。
+func CheckMinNotional(cfg *config.Config, symbol string, notional, minNotional float64, plan PositionPlan, positionSizePercent float64) *MinNotionalShortfall {
+	buffer := cfg.SizingMinNotionalBuffer
+	if buffer < 0 {
+		buffer = 0
+	}
+	required := minNotional * (1 + buffer/100)
+	if minNotional <= 0 || notional >= required {
+		return nil
+	}
+
+	shortfall := &MinNotionalShortfall{
+		Symbol:              symbol,
+		Notional:            notional,
+		MinNotional:         minNotional,
+		Required:            required,
+		PositionSizePercent: positionSizePercent,
+		Capital:             plan.Capital,
+		Leverage:            plan.Leverage,
+	}
+	if plan.Capital > 0 && plan.Leverage > 0 {
+		shortfall.MinPercent = required / float64(plan.Leverage) / plan.Capital * 100
+	}
+	if notional > 0 {
+		shortfall.MinCapital = plan.Capital * required / notional
+	} else if positionSizePercent > 0 && plan.Leverage > 0 {
+		shortfall.MinCapital = required / float64(plan.Leverage) / (positionSizePercent / 100)
+	}
+	return shortfall
+}
+
+// Error explains the shortfall and how to fix it
+// Error 说明订单价值不足的原因及解决方法
+func (s *MinNotionalShortfall) Error() string {
+	msg := fmt.Sprintf("%s 订单价值 $%.2f 低于最小要求 $%.2f（交易所 MIN_NOTIONAL $%.2f + 缓冲）。"+
+		"当前资金基数 $%.2f，仓位 %.1f%%，杠杆 %dx。",
+		s.Symbol, s.Notional, s.Required, s.MinNotional, s.Capital, s.PositionSizePercent, s.Leverage)
+	if s.MinPercent > 0 && s.MinPercent <= 100 {
+		msg += fmt.Sprintf("建议将仓位提高到至少 %.1f%%，", s.MinPercent)
+	}
+	if s.MinCapital > 0 {
+		msg += fmt.Sprintf("或将账户资金增加到约 $%.2f 以上", s.MinCapital)
+	}
+	return strings.TrimSuffix(msg, "，")
+}
+
+// SmallAccountLeverage floors the leverage at SMALL_ACCOUNT_MIN_LEVERAGE, never above the BINANCE_LEVERAGE maximum
+// SmallAccountLeverage 将杠杆下限设为 SMALL_ACCOUNT_MIN_LEVERAGE，但不超过 BINANCE_LEVERAGE 的上限
+//
+// A leverage of 0 means BINANCE_LEVERAGE.
+// 杠杆为 0 时使用 BINANCE_LEVERAGE。
+func SmallAccountLeverage(cfg *config.Config, leverage int) int {
+	if leverage <= 0 {
+		leverage = cfg.BinanceLeverage
+	}
+	if floor := cfg.SmallAccountLeverageFloor(); leverage < floor {
+		return floor
+	}
+	return leverage
+}
+
+// RoundUpToMinNotional rounds a quantity up to the step that reaches the required notional
+// RoundUpToMinNotional 将数量按步长向上取整到满足所需的订单价值
+//
+// Only done when the order is short by at most tolerancePercent of the required value; returns false otherwise.
+// 仅在订单价值与所需值的差距不超过 tolerancePercent 时取整，否则返回 false。
+func RoundUpToMinNotional(quantity, price, required, stepSize, tolerancePercent float64) (float64, bool) {
+	if price <= 0 || required <= 0 || tolerancePercent <= 0 {
+		return quantity, false
+	}
+	if shortBy := (required - quantity*price) / required * 100; shortBy > tolerancePercent {
+		return quantity, false
+	}
+
+	rounded := required / price
+	if stepSize > 0 {
+		// The small epsilon keeps an exact multiple from rounding up an extra step
+		// 小的容差避免恰好整除时多进一个步长
+		rounded = math.Ceil(rounded/stepSize-1e-9) * stepSize
+	}
+	return rounded, true
+}
@@ -0,0 +1,165 @@
+package executors
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestSizingCapital tests the capital each sizing policy applies the position percentage to
+// TestSizingCapital 测试各仓位策略下仓位百分比对应的资金基数
+func TestSizingCapital(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		capital float64
+		balance float64
+		want    float64
+		label   string
+	}{
+		{"compound uses balance", "compound", 0, 2500, 2500, "compound"},
+		{"fixed uses fixed capital", "fixed", 1000, 2500, 1000, "fixed (1000.00 USDT)"},
+		{"fixed capped at balance", "FIXED", 1000, 600, 600, "fixed (1000.00 USDT)"},
+		{"fixed without capital falls back", "fixed", 0, 2500, 2500, "compound"},
+		{"unknown policy compounds", "", 1000, 2500, 2500, "compound"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{SizingPolicy: tt.policy, SizingFixedCapital: tt.capital}
+		if got := SizingCapital(cfg, tt.balance); got != tt.want {
+			t.Errorf("%s: SizingCapital = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+		if got := SizingPolicyLabel(cfg); got != tt.label {
+			t.Errorf("%s: SizingPolicyLabel = %q, want %q", tt.name, got, tt.label)
+		}
+	}
+}
+
+// TestRiskSizedQuantity tests that wider stops and higher ATR produce smaller sizes
+// TestRiskSizedQuantity 测试止损越宽、ATR 越大仓位越小
+func TestRiskSizedQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		stopLoss float64
+		atr      float64
+		want     float64 // 数量 / Quantity
+		fromATR  bool
+	}{
+		// 资金 10000，单笔风险 1% = 100 USDT，价格 100，ATR 倍数 1.5
+		{"stop distance", 95, 2, 20, false},
+		{"wide stop gets smaller size", 90, 2, 10, false},
+		{"tight stop floored at ATR", 99, 2, 100.0 / 3, true},
+		{"no stop uses ATR", 0, 4, 100.0 / 6, true},
+		{"no ATR uses stop", 98, 0, 50, false},
+	}
+	for _, tt := range tests {
+		sizing := RiskSizedQuantity(10000, 1, 100, tt.stopLoss, tt.atr, 1.5)
+		if sizing == nil {
+			t.Fatalf("%s: expected a sizing", tt.name)
+		}
+		if math.Abs(sizing.Quantity-tt.want) > 1e-9 || sizing.FromATR != tt.fromATR || sizing.RiskAmount != 100 {
+			t.Errorf("%s: got %+v, want quantity %.4f (fromATR %v)", tt.name, sizing, tt.want, tt.fromATR)
+		}
+	}
+
+	if RiskSizedQuantity(10000, 1, 100, 0, 0, 1.5) != nil {
+		t.Error("Expected nil without a stop or ATR")
+	}
+	if RiskSizedQuantity(10000, 0, 100, 95, 2, 1.5) != nil {
+		t.Error("Expected nil when risk per trade is disabled")
+	}
+}
+
+func TestPlanPosition(t *testing.T) {
+	cfg := &config.Config{BinanceLeverage: 10, SizingATRMultiplier: 1.5}
+
+	// 余额 1000，20% 仓位，默认 10 倍杠杆，价格 100 → 20 个
+	plan := PlanPosition(cfg, 1000, 100, 20, 0, 95, 2)
+	if plan.Leverage != 10 || plan.Margin != 200 || plan.PercentQuantity != 20 || plan.Quantity != 20 || plan.Risk != nil {
+		t.Errorf("Unexpected plan without risk sizing: %+v", plan)
+	}
+
+	// 单笔风险 1% = 10 USDT，止损距离 5 → 2 个，小于按百分比的 20 个
+	cfg.SizingRiskPerTrade = 1
+	plan = PlanPosition(cfg, 1000, 100, 20, 5, 95, 2)
+	if plan.Leverage != 5 || plan.PercentQuantity != 10 || plan.Risk == nil || math.Abs(plan.Quantity-2) > 1e-9 {
+		t.Errorf("Expected risk sizing to shrink the plan to 2, got %+v", plan)
+	}
+
+	// 按风险计算的数量更大时保持 LLM 仓位
+	plan = PlanPosition(cfg, 1000, 100, 1, 5, 99.9, 0)
+	if plan.Quantity != plan.PercentQuantity {
+		t.Errorf("Expected the percent quantity when it is smaller, got %+v", plan)
+	}
+}
+
+// TestCheckMinNotional tests the minimum notional guard and its suggested fixes
+// TestCheckMinNotional 测试最小订单价值检查及其建议
+func TestCheckMinNotional(t *testing.T) {
+	cfg := &config.Config{BinanceLeverage: 5, SizingMinNotionalBuffer: 10}
+
+	// 余额 50，10% 仓位，5 倍杠杆 → 订单价值 25，不低于 20 × 1.1 = 22
+	plan := PlanPosition(cfg, 50, 100, 10, 0, 0, 0)
+	if s := CheckMinNotional(cfg, "BTCUSDT", 25, 20, plan, 10); s != nil {
+		t.Errorf("Expected no shortfall for 25 >= 22, got %+v", s)
+	}
+
+	// 订单价值 21 低于含缓冲的 22
+	s := CheckMinNotional(cfg, "BTCUSDT", 21, 20, plan, 10)
+	if s == nil {
+		t.Fatal("Expected a shortfall for 21 < 22")
+	}
+	if math.Abs(s.Required-22) > 1e-9 {
+		t.Errorf("Expected required 22, got %.4f", s.Required)
+	}
+	// 22 / 5 / 50 = 8.8%
+	if math.Abs(s.MinPercent-8.8) > 1e-9 {
+		t.Errorf("Expected min percent 8.8, got %.4f", s.MinPercent)
+	}
+	// 50 × 22 / 21
+	if math.Abs(s.MinCapital-50*22.0/21) > 1e-9 {
+		t.Errorf("Expected min capital %.4f, got %.4f", 50*22.0/21, s.MinCapital)
+	}
+	if msg := s.Error(); !strings.Contains(msg, "8.8%") || !strings.Contains(msg, "BTCUSDT") {
+		t.Errorf("Expected the explanation to name the symbol and minimum percent, got %q", msg)
+	}
+
+	// 精度调整后数量为 0 时按仓位百分比估算所需资金：22 / 5 / 10% = 44
+	s = CheckMinNotional(cfg, "BTCUSDT", 0, 20, plan, 10)
+	if s == nil || math.Abs(s.MinCapital-44) > 1e-9 {
+		t.Errorf("Expected min capital 44 for a zero notional, got %+v", s)
+	}
+
+	// 未知的 MIN_NOTIONAL 不拦截
+	if s := CheckMinNotional(cfg, "BTCUSDT", 1, 0, plan, 10); s != nil {
+		t.Errorf("Expected no shortfall without a minimum, got %+v", s)
+	}
+}
+
+// TestSmallAccountSizing tests the leverage floor and rounding up to the minimum notional
+// TestSmallAccountSizing 测试小账户的杠杆下限及向上取整到最小订单价值
+func TestSmallAccountSizing(t *testing.T) {
+	cfg := &config.Config{BinanceLeverage: 2, BinanceLeverageMax: 10, SmallAccountMinLeverage: 5}
+	if got := SmallAccountLeverage(cfg, 0); got != 5 {
+		t.Errorf("Expected the default leverage floored at 5, got %d", got)
+	}
+	if got := SmallAccountLeverage(cfg, 8); got != 8 {
+		t.Errorf("Expected a higher leverage to be kept, got %d", got)
+	}
+	cfg.BinanceLeverageMax = 3
+	if got := SmallAccountLeverage(cfg, 2); got != 3 {
+		t.Errorf("Expected the floor capped at the maximum leverage, got %d", got)
+	}
+
+	// 订单价值 4.5，需要 5.25（差距约 14%）→ 取整到 0.21 × 25 = 5.25
+	qty, ok := RoundUpToMinNotional(0.18, 25, 5.25, 0.01, 25)
+	if !ok || math.Abs(qty-0.21) > 1e-9 {
+		t.Errorf("Expected rounding up to 0.21, got %.4f, %v", qty, ok)
+	}
+
+	// 差距超过容差时不取整
+	if qty, ok := RoundUpToMinNotional(0.1, 25, 5.25, 0.01, 25); ok || qty != 0.1 {
+		t.Errorf("Expected no rounding beyond the tolerance, got %.4f, %v", qty, ok)
+	}
+}
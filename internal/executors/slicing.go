@@ -0,0 +1,224 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Large order slicing algorithms (LARGE_ORDER_ALGO)
+// 大单拆分执行算法（LARGE_ORDER_ALGO）
+const (
+	// LargeOrderAlgoOff opens large positions like any other entry
+	// LargeOrderAlgoOff 大仓位与普通开仓相同，不拆分
+	LargeOrderAlgoOff = "off"
+	// LargeOrderAlgoTWAP sends equal market slices spread evenly over TWAP_WINDOW_SECONDS
+	// LargeOrderAlgoTWAP 将数量等分为多笔市价单，均匀分布在 TWAP_WINDOW_SECONDS 内发送
+	LargeOrderAlgoTWAP = "twap"
+	// LargeOrderAlgoIceberg shows one slice at a time as a chased post-only limit order
+	// LargeOrderAlgoIceberg 每次只挂出一片，以只做 Maker 的限价单追价成交后再挂下一片
+	LargeOrderAlgoIceberg = "iceberg"
+)
+
+// sliceMinNotionalBuffer keeps every slice comfortably above the exchange MIN_NOTIONAL
+// sliceMinNotionalBuffer 确保每一片的价值都明显高于交易所 MIN_NOTIONAL
+const sliceMinNotionalBuffer = 1.1
+
+// slicePlan holds how a large entry is split
+// slicePlan 保存大单开仓的拆分方式
+type slicePlan struct {
+	Algo     string        // twap 或 iceberg / twap or iceberg
+	Slices   int           // 片数 / Number of slices
+	Interval time.Duration // TWAP 相邻两片的间隔 / Pause between two TWAP slices
+	Chase    chasePlan     // 冰山单每一片的追价参数 / Chase timing of each iceberg slice
+}
+
+// sliceKey is the context key carrying the slice plan of an entry
+// sliceKey 是携带开仓拆分计划的 context 键
+type sliceKey struct{}
+
+// withSlicing returns a context whose market entries are split per plan
+// withSlicing 返回一个 context，其市价开仓按 plan 拆分执行
+func withSlicing(ctx context.Context, plan slicePlan) context.Context {
+	return context.WithValue(ctx, sliceKey{}, &plan)
+}
+
+// slicingFrom returns the slice plan carried by ctx (nil for unsliced entries)
+// slicingFrom 返回 ctx 中携带的拆分计划（不拆分时为 nil）
+func slicingFrom(ctx context.Context) *slicePlan {
+	plan, _ := ctx.Value(sliceKey{}).(*slicePlan)
+	return plan
+}
+
+// withoutExecutionTiming returns a context whose orders don't touch the decision's ExecutionTiming
+// withoutExecutionTiming 返回一个 context，其订单不会改动决策的 ExecutionTiming
+//
+// Slices are executed with it so SentAt / FilledAt span the whole sliced entry.
+// 各片使用该 context 执行，使 SentAt / FilledAt 覆盖整个拆分开仓过程。
+func withoutExecutionTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, executionTimingKey{}, (*ExecutionTiming)(nil))
+}
+
+// sliceCount returns how many slices an entry of notional is split into, fewer than slices when
+// each slice would fall below the exchange minimum (a result below 2 means the entry is not sliced)
+// sliceCount 返回名义价值为 notional 的开仓拆分的片数；每片会低于交易所最小值时减少片数（结果小于 2 表示不拆分）
+func sliceCount(notional, minNotional float64, slices int) int {
+	if minNotional > 0 {
+		slices = min(slices, int(notional/(minNotional*sliceMinNotionalBuffer)))
+	}
+	return slices
+}
+
+// sliceQuantities splits quantity into slices parts rounded down by round, the last part taking the remainder
+// sliceQuantities 将 quantity 拆成 slices 份并按 round 向下取整，余量计入最后一份
+func sliceQuantities(quantity float64, slices int, round func(float64) float64) []float64 {
+	each := round(quantity / float64(max(slices, 1)))
+	if slices <= 1 || each <= 0 {
+		return []float64{round(quantity)}
+	}
+	parts := make([]float64, slices)
+	for i := range parts[:slices-1] {
+		parts[i] = each
+	}
+	parts[slices-1] = round(quantity - each*float64(slices-1))
+	return parts
+}
+
+// planSlicing returns the context slicing an entry of notional per LARGE_ORDER_ALGO, false when the
+// entry is too small to be split into at least two slices
+// planSlicing 返回按 LARGE_ORDER_ALGO 拆分名义价值为 notional 的开仓所使用的 context，
+// 开仓太小无法拆成至少两片时返回 false
+func (tc *TradeCoordinator) planSlicing(ctx context.Context, symbol string, notional float64) (context.Context, bool) {
+	minNotional := tc.executor.SymbolFiltersFor(ctx, symbol).MinNotional
+	slices := sliceCount(notional, minNotional, tc.config.LargeOrderSlices)
+	if slices < 2 {
+		tc.logger.Info(fmt.Sprintf("开仓名义价值 %.2f USDT 拆分后每片低于最小订单价值 %.2f，不拆分", notional, minNotional))
+		return ctx, false
+	}
+
+	plan := slicePlan{
+		Algo:   tc.config.LargeOrderAlgo,
+		Slices: slices,
+		Chase: chasePlan{
+			Repeg:   time.Duration(tc.config.ChaseRepegSeconds) * time.Second,
+			Timeout: time.Duration(tc.config.ChaseTimeoutSeconds) * time.Second,
+		},
+	}
+	if plan.Algo == LargeOrderAlgoTWAP {
+		plan.Interval = time.Duration(tc.config.TWAPWindowSeconds) * time.Second / time.Duration(slices)
+		tc.logger.Info(fmt.Sprintf("🧊 大单拆分执行: TWAP，名义价值 %.2f USDT 拆成 %d 笔市价单，每 %s 一笔",
+			notional, slices, plan.Interval))
+	} else {
+		tc.logger.Info(fmt.Sprintf("🧊 大单拆分执行: 冰山单，名义价值 %.2f USDT 拆成 %d 片，每片限价追单（每 %s 重挂，%s 后改用市价单）",
+			notional, slices, plan.Chase.Repeg, plan.Chase.Timeout))
+	}
+	return withSlicing(ctx, plan), true
+}
+
+// executeSlicedEntry opens a position in plan.Slices slices: market orders spaced by plan.Interval for TWAP,
+// one chased limit slice after another for an iceberg
+// executeSlicedEntry 分 plan.Slices 片开仓：TWAP 每隔 plan.Interval 发送一笔市价单，冰山单逐片限价追单
+//
+// Slicing stops at the first slice that fails or fills short, and the position is registered with what
+// was actually opened.
+// 任一片失败或未完全成交时停止拆单，持仓按实际开仓数量登记。
+func (e *BinanceExecutor) executeSlicedEntry(ctx context.Context, plan *slicePlan, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64, result *TradeResult) error {
+	filters := e.SymbolFiltersFor(ctx, symbol)
+	parts := sliceQuantities(quantity, plan.Slices, filters.RoundQuantity)
+	sliceCtx := withoutExecutionTiming(ctx)
+	fill := &chaseFill{}
+	var lastOrderID string
+	done := 0
+
+	markSent(ctx)
+	for i, part := range parts {
+		if i > 0 && plan.Algo == LargeOrderAlgoTWAP {
+			sleepCtx(ctx, plan.Interval)
+		}
+		if ctx.Err() != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  拆单在第 %d/%d 片前中止: %v", i+1, len(parts), ctx.Err()))
+			break
+		}
+
+		leg := fmt.Sprintf("%s-slice-%d", LegOpen, i+1)
+		slice := &TradeResult{Amount: part}
+		var err error
+		if plan.Algo == LargeOrderAlgoIceberg {
+			err = e.executeChaseEntry(sliceCtx, &plan.Chase, symbol, side, positionSide, leg, part, slice)
+		} else {
+			err = e.executeMarketSlice(sliceCtx, symbol, side, positionSide, leg, part, slice)
+		}
+		if err != nil {
+			if fill.Quantity == 0 {
+				return err
+			}
+			e.logger.Warning(fmt.Sprintf("⚠️  第 %d/%d 片执行失败: %v，停止拆单", i+1, len(parts), err))
+			break
+		}
+
+		fill.add(slice.Amount, slice.Price, false)
+		fill.Fee += slice.Fee
+		lastOrderID = slice.OrderID
+		done++
+		e.logger.Info(fmt.Sprintf("🧊 第 %d/%d 片成交 %s @ $%.4f（累计 %s / %s）", i+1, len(parts),
+			filters.FormatQuantity(slice.Amount), slice.Price, filters.FormatQuantity(fill.Quantity), filters.FormatQuantity(quantity)))
+		if slice.Amount < part {
+			e.logger.Warning(fmt.Sprintf("⚠️  第 %d/%d 片未完全成交，停止拆单", i+1, len(parts)))
+			break
+		}
+	}
+	if fill.Quantity <= 0 {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("拆单开仓未成交: %w", err)
+		}
+		return fmt.Errorf("拆单开仓未成交：数量 %.8f 低于数量步长", quantity)
+	}
+	markFilled(ctx)
+
+	result.Success = true
+	result.OrderID = lastOrderID
+	result.Price = fill.AvgPrice()
+	result.Filled = fill.Quantity
+	result.Fee += fill.Fee
+	if fill.Quantity < result.Amount {
+		// Positions are registered with Amount, which must match what was actually opened
+		// 持仓按 Amount 注册，必须与实际开仓数量一致
+		e.logger.Warning(fmt.Sprintf("⚠️  仅部分开仓: %.4f / %.4f", fill.Quantity, result.Amount))
+		result.Amount = fill.Quantity
+	}
+	name := "TWAP"
+	if plan.Algo == LargeOrderAlgoIceberg {
+		name = "冰山单"
+	}
+	result.Message = fmt.Sprintf("%s 拆单成交 %.4f @ $%.2f（%d/%d 片）", name, fill.Quantity, result.Price, done, len(parts))
+	e.logger.Success(fmt.Sprintf("✅ %s", result.Message))
+	return nil
+}
+
+// executeMarketSlice sends one slice of a sliced entry as a market order
+// executeMarketSlice 以市价单发送拆分开仓中的一片
+func (e *BinanceExecutor) executeMarketSlice(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, leg string, quantity float64, result *TradeResult) error {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	order, err := e.sendOrder(ctx, e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(side).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeMarket).
+		Quantity(e.SymbolFiltersFor(ctx, symbol).FormatQuantity(quantity)),
+		binanceSymbol, leg, side, quantity)
+	if err != nil {
+		return err
+	}
+
+	fillPrice, _ := parseFloat(order.AvgPrice)
+	if fillPrice == 0 {
+		fillPrice, _ = e.GetCurrentPrice(ctx, symbol)
+	}
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", order.OrderID)
+	result.Price = fillPrice
+	result.Fee += e.recordOrderFills(ctx, binanceSymbol, order.OrderID, futures.OrderTypeMarket, side, quantity, fillPrice)
+	return nil
+}
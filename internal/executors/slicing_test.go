@@ -0,0 +1,52 @@
+package executors
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestSlicing tests the slice count, the slice quantities and the slice plan context
+// TestSlicing 测试拆分片数、每片数量以及拆分计划 context
+func TestSlicing(t *testing.T) {
+	if n := sliceCount(10000, 5, 5); n != 5 {
+		t.Errorf("Expected 5 slices, got %d", n)
+	}
+	if n := sliceCount(30, 5, 10); n != 5 {
+		t.Errorf("Expected slices capped by MIN_NOTIONAL to 5, got %d", n)
+	}
+	if n := sliceCount(8, 5, 5); n >= 2 {
+		t.Errorf("Expected no slicing below twice MIN_NOTIONAL, got %d slices", n)
+	}
+
+	round := SymbolFilters{StepSize: 0.001}.RoundQuantity
+	parts := sliceQuantities(1.0, 3, round)
+	if len(parts) != 3 || parts[0] != 0.333 || parts[1] != 0.333 || parts[2] != 0.334 {
+		t.Errorf("Unexpected slices of 1.0 in 3: %v", parts)
+	}
+	total := 0.0
+	for _, part := range sliceQuantities(0.3, 3, round) {
+		total += part
+	}
+	if math.Abs(total-0.3) > 1e-9 {
+		t.Errorf("Slices of 0.3 should add up to 0.3, got %v", total)
+	}
+	if parts := sliceQuantities(0.002, 5, round); len(parts) != 1 || parts[0] != 0.002 {
+		t.Errorf("Expected a single slice below the step size, got %v", parts)
+	}
+
+	if slicingFrom(context.Background()) != nil {
+		t.Error("Expected no slice plan on a plain context")
+	}
+	ctx := withSlicing(context.Background(), slicePlan{Algo: LargeOrderAlgoTWAP, Slices: 4, Interval: time.Minute})
+	if plan := slicingFrom(ctx); plan == nil || plan.Slices != 4 || plan.Interval != time.Minute {
+		t.Errorf("Unexpected slice plan: %+v", plan)
+	}
+
+	timing := &ExecutionTiming{}
+	markSent(withoutExecutionTiming(WithExecutionTiming(ctx, timing)))
+	if !timing.SentAt.IsZero() {
+		t.Error("Slices should not mark the decision's order sent time")
+	}
+}
@@ -0,0 +1,43 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReviewStops runs the stop maintenance the trading graph does before each analysis, for every symbol with a
+// managed position: refresh the high/low from klines, partial take-profit, trailing stop, reconcile and
+// check the stop-loss / take-profit orders
+// ReviewStops 对每个有受管持仓的交易对执行交易图在每次分析前进行的止损维护：从 K 线刷新最高/最低价、
+// 分批止盈、追踪止损、对账，以及检查止损/止盈单状态
+//
+// Used by the STOP_REVIEW_CRON job so stops are maintained between analyses. Errors are collected per step
+// and returned together; one failing step does not stop the rest.
+// 供 STOP_REVIEW_CRON 定时任务使用，使两次分析之间也能维护止损。各步骤的错误汇总后一起返回，某一步失败不影响其余步骤。
+func (sm *StopLossManager) ReviewStops(ctx context.Context, symbols []string) error {
+	steps := []struct {
+		name string
+		fn   func(context.Context, string) error
+	}{
+		{"更新价格", sm.UpdatePositionPriceFromKlines},
+		{"分批止盈", sm.CheckPartialTakeProfit},
+		{"追踪止损", sm.UpdateTrailingStop},
+		{"对账", sm.ReconcilePosition},
+		{"检查止损单", sm.CheckStopLossOrderStatus},
+		{"检查止盈单", sm.CheckTakeProfitOrderStatus},
+	}
+
+	var errs []error
+	for _, symbol := range symbols {
+		if len(sm.heldSides(symbol)) == 0 {
+			continue
+		}
+		for _, step := range steps {
+			if err := step.fn(ctx, symbol); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", symbol, step.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/plugins"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
@@ -37,7 +38,7 @@ import (
 //   - No duplicate execution risk
 //     无重复执行风险
 type StopLossManager struct {
-	positions map[string]*Position // symbol -> Position
+	positions map[string]*Position // symbol:side -> Position
 	executor  *BinanceExecutor     // 执行器 / Executor
 	config    *config.Config       // 配置 / Config
 	logger    *logger.ColorLogger  // 日志 / Logger
@@ -62,6 +63,61 @@ func NewStopLossManager(cfg *config.Config, executor *BinanceExecutor, log *logg
 	}
 }
 
+// positionSides lists the sides a symbol can be held on, in lookup order
+// positionSides 列出交易对可持有的方向，按查找顺序排列
+var positionSides = []string{"long", "short"}
+
+// positionKey returns the key of a managed position: the Binance symbol plus the side,
+// so a long and a short on the same symbol in hedge mode are tracked separately
+// positionKey 返回受管持仓的键：币安交易对加方向，使双向持仓模式下同一交易对的多仓和空仓分别跟踪
+func positionKey(binanceSymbol, side string) string {
+	return binanceSymbol + ":" + side
+}
+
+// heldSides returns the sides of symbol that have a managed position, long first
+// heldSides 返回交易对有受管持仓的方向，多仓在前
+func (sm *StopLossManager) heldSides(symbol string) []string {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var sides []string
+	for _, side := range positionSides {
+		if _, exists := sm.positions[positionKey(normalizedSymbol, side)]; exists {
+			sides = append(sides, side)
+		}
+	}
+	return sides
+}
+
+// resolveSide returns the side a call without a side refers to: the only side held on symbol ("" when none)
+// resolveSide 返回未指定方向的调用所指的方向：交易对唯一持有的方向（无持仓时为 ""）
+//
+// Holding both sides in hedge mode is ambiguous and returns an error.
+// 双向持仓模式下同时持有多空时无法确定方向，返回错误。
+func (sm *StopLossManager) resolveSide(symbol string) (string, error) {
+	sides := sm.heldSides(symbol)
+	switch len(sides) {
+	case 0:
+		return "", nil
+	case 1:
+		return sides[0], nil
+	}
+	return "", fmt.Errorf("%s 同时持有多仓和空仓，请指定方向", symbol)
+}
+
+// forEachSide calls fn for every side held on symbol, stopping at the first error
+// forEachSide 对交易对持有的每个方向调用 fn，遇到第一个错误即停止
+func (sm *StopLossManager) forEachSide(symbol string, fn func(side string) error) error {
+	for _, side := range sm.heldSides(symbol) {
+		if err := fn(side); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RegisterPosition registers a new position for stop-loss management
 // RegisterPosition 注册新持仓进行止损管理
 func (sm *StopLossManager) RegisterPosition(pos *Position) {
@@ -78,14 +134,17 @@ func (sm *StopLossManager) RegisterPosition(pos *Position) {
 	pos.HighestPrice = pos.EntryPrice // 初始化最高价/最低价 / Initialize highest/lowest
 	pos.CurrentPrice = pos.EntryPrice
 	pos.StopLossType = "fixed" // LLM 驱动的固定止损 / LLM-driven fixed stop
+	if pos.MaxPriceHistory <= 0 {
+		pos.MaxPriceHistory = sm.config.HistoryPricePoints
+	}
 
-	sm.positions[normalizedSymbol] = pos
+	sm.positions[positionKey(normalizedSymbol, pos.Side)] = pos
 	sm.logger.Success(fmt.Sprintf("【%s】持仓已注册，入场价: %.2f, 初始止损: %.2f, 当前止损: %.2f",
 		normalizedSymbol, pos.EntryPrice, pos.InitialStopLoss, pos.CurrentStopLoss))
 }
 
-// RemovePosition removes a position from management
-// RemovePosition 从管理中移除持仓
+// RemovePosition removes the positions of a symbol (both sides in hedge mode) from management
+// RemovePosition 从管理中移除交易对的持仓（双向持仓模式下包括多空两个方向）
 func (sm *StopLossManager) RemovePosition(symbol string) {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
@@ -94,19 +153,31 @@ func (sm *StopLossManager) RemovePosition(symbol string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	delete(sm.positions, normalizedSymbol)
+	for _, side := range positionSides {
+		delete(sm.positions, positionKey(normalizedSymbol, side))
+	}
 	sm.logger.Info(fmt.Sprintf("【%s】持仓已移除", symbol))
 }
 
-// ClosePosition closes a position completely: cancels stop-loss order, removes from memory, and updates database
-// ClosePosition 完整关闭持仓：取消止损单、从内存移除、更新数据库
+// ClosePosition closes the only position of a symbol completely, see ClosePositionSide
+// ClosePosition 完整关闭交易对唯一的持仓，参见 ClosePositionSide
 func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, closePrice float64, closeReason string, realizedPnL float64) error {
+	side, err := sm.resolveSide(symbol)
+	if err != nil {
+		return err
+	}
+	return sm.ClosePositionSide(ctx, symbol, side, closePrice, closeReason, realizedPnL)
+}
+
+// ClosePositionSide closes a position completely: cancels the stop-loss and take-profit orders, removes from memory, and updates database
+// ClosePositionSide 完整关闭持仓：取消止损单和止盈单、从内存移除、更新数据库
+func (sm *StopLossManager) ClosePositionSide(ctx context.Context, symbol, side string, closePrice float64, closeReason string, realizedPnL float64) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
-	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	key := positionKey(sm.config.GetBinanceSymbolFor(symbol), side)
 
 	sm.mu.Lock()
-	pos, exists := sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[key]
 	sm.mu.Unlock()
 
 	if !exists {
@@ -126,15 +197,26 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 		}
 	}
 
+	// Cancel the other side of the bracket so it cannot act on a later position
+	// 撤销括号单的另一方，避免其作用于之后的持仓
+	if pos.TakeProfitOrderID != "" {
+		if err := sm.cancelTakeProfitOrder(ctx, pos); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  取消 %s 止盈单失败: %v（继续关闭流程）", symbol, err))
+		} else {
+			sm.logger.Success(fmt.Sprintf("✅ %s 止盈单已取消", symbol))
+		}
+	}
+
 	// Step 2: Remove from memory
 	// 步骤 2：从内存移除
 	sm.mu.Lock()
-	delete(sm.positions, normalizedSymbol)
+	delete(sm.positions, key)
 	sm.mu.Unlock()
 	sm.logger.Info(fmt.Sprintf("✅ %s 已从止损管理器移除", symbol))
 
 	// Step 3: Update database status with retry
 	// 步骤 3：更新数据库状态（带重试）
+	netPnL := realizedPnL
 	if sm.storage != nil {
 		// Get position record from database
 		// 从数据库获取持仓记录
@@ -142,6 +224,30 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 		if err != nil {
 			sm.logger.Warning(fmt.Sprintf("⚠️  获取 %s 持仓记录失败: %v（跳过数据库更新）", symbol, err))
 		} else if posRecord != nil {
+			// Book the funding paid while holding into the realized PnL
+			// 将持仓期间支付的资金费计入已实现盈亏
+			funding, err := sm.executor.FundingPaid(ctx, pos)
+			if err != nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  获取 %s 资金费失败: %v（已实现盈亏不含资金费）", symbol, err))
+			} else if funding != 0 {
+				sm.logger.Info(fmt.Sprintf("💰 %s 持仓期间资金费: %+.4f USDT（正数为支付），已计入已实现盈亏", symbol, funding))
+				if err := sm.storage.SavePositionFunding(pos.ID, funding); err != nil {
+					sm.logger.Warning(fmt.Sprintf("⚠️  保存 %s 资金费失败: %v", symbol, err))
+				}
+			}
+
+			// Book the trading fees of the entry and the closes into the realized PnL
+			// 将开仓和平仓的手续费计入已实现盈亏
+			fees, err := sm.executor.FeesPaid(pos, closePrice)
+			if err != nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  获取 %s 手续费失败: %v（已实现盈亏不含手续费）", symbol, err))
+			} else if fees != 0 {
+				sm.logger.Info(fmt.Sprintf("💸 %s 开平仓手续费: %.4f USDT，已从已实现盈亏中扣除", symbol, fees))
+				if err := sm.storage.SavePositionFees(pos.ID, fees); err != nil {
+					sm.logger.Warning(fmt.Sprintf("⚠️  保存 %s 手续费失败: %v", symbol, err))
+				}
+			}
+
 			// Update position record
 			// 更新持仓记录
 			now := time.Now()
@@ -149,7 +255,8 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 			posRecord.CloseTime = &now
 			posRecord.ClosePrice = closePrice
 			posRecord.CloseReason = closeReason
-			posRecord.RealizedPnL = realizedPnL
+			netPnL = realizedPnL - funding - fees
+			posRecord.RealizedPnL = netPnL
 
 			// Retry database update up to 3 times
 			// 重试数据库更新最多 3 次
@@ -169,7 +276,22 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 		}
 	}
 
-	sm.logger.Success(fmt.Sprintf("✅【%s】持仓完全关闭（止损单已取消，内存已清理，数据库已更新）", symbol))
+	sm.logger.Success(fmt.Sprintf("✅【%s】持仓完全关闭（止损/止盈单已取消，内存已清理，数据库已更新）", symbol))
+
+	if err := plugins.PositionClosed(ctx, &plugins.PositionClosedEvent{
+		PositionID:  pos.ID,
+		Symbol:      pos.Symbol,
+		Side:        pos.Side,
+		EntryPrice:  pos.EntryPrice,
+		ClosePrice:  closePrice,
+		Quantity:    pos.Quantity,
+		RealizedPnL: netPnL,
+		CloseReason: closeReason,
+		OpenedAt:    pos.EntryTime,
+		ClosedAt:    time.Now(),
+	}); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  插件处理平仓事件失败: %v", err))
+	}
 	return nil
 }
 
@@ -214,12 +336,55 @@ func (sm *StopLossManager) PlaceInitialStopLoss(ctx context.Context, pos *Positi
 		}
 	}
 
+	// The stop is in place, complete the bracket with the take-profit
+	// 止损单已就位，挂止盈单组成括号单
+	sm.placeBracketTakeProfit(ctx, pos)
+
 	return nil
 }
 
-// GetPosition gets a position by symbol
-// GetPosition 根据交易对获取持仓
+// placeBracketTakeProfit places the take-profit side of the entry bracket
+// placeBracketTakeProfit 挂开仓括号单的止盈一方
+//
+// The target is pos.TakeProfitPrice, or TAKE_PROFIT_R_MULTIPLE × R when unset. The stop-loss is
+// already in place, so a failure only leaves the position without a take-profit and is logged.
+// 目标价为 pos.TakeProfitPrice，未设置时使用 TAKE_PROFIT_R_MULTIPLE × R。止损单已就位，
+// 失败只会导致持仓没有止盈单，因此仅记录日志。
+func (sm *StopLossManager) placeBracketTakeProfit(ctx context.Context, pos *Position) {
+	target := pos.TakeProfitPrice
+	if target <= 0 {
+		target = partialTPTarget(pos.Side, pos.EntryPrice, pos.InitialStopLoss, sm.config.TakeProfitRMultiple)
+	}
+	if target <= 0 {
+		return
+	}
+
+	if err := sm.placeTakeProfitOrder(ctx, pos, target); err != nil {
+		pos.TakeProfitPrice = 0
+		sm.logger.Warning(fmt.Sprintf("⚠️  【%s】下止盈单失败: %v（止损单已生效）", pos.Symbol, err))
+		return
+	}
+	if sm.storage != nil {
+		if err := sm.storage.SaveTakeProfit(pos.ID, pos.TakeProfitPrice, pos.TakeProfitOrderID); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  保存止盈单失败: %v", err))
+		}
+	}
+}
+
+// GetPosition gets a position by symbol, the long first when both sides are held in hedge mode
+// GetPosition 根据交易对获取持仓，双向持仓模式下同时持有多空时优先返回多仓
 func (sm *StopLossManager) GetPosition(symbol string) *Position {
+	for _, side := range positionSides {
+		if pos := sm.GetPositionSide(symbol, side); pos != nil {
+			return pos
+		}
+	}
+	return nil
+}
+
+// GetPositionSide gets the position of a symbol on one side
+// GetPositionSide 获取交易对指定方向的持仓
+func (sm *StopLossManager) GetPositionSide(symbol, side string) *Position {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
@@ -227,7 +392,38 @@ func (sm *StopLossManager) GetPosition(symbol string) *Position {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
-	return sm.positions[normalizedSymbol]
+	return sm.positions[positionKey(normalizedSymbol, side)]
+}
+
+// Kinds of protective orders a managed position can have on the exchange
+// 受管持仓在交易所可能挂有的保护单类型
+const (
+	protectiveOrderStop       = "stop_loss"   // 止损单 / Stop-loss order
+	protectiveOrderTakeProfit = "take_profit" // 止盈单 / Take-profit order
+)
+
+// PositionByOrder finds the managed position whose stop-loss or take-profit order has the given id
+// PositionByOrder 查找止损单或止盈单为指定订单 ID 的受管持仓
+//
+// Returns the position and the kind of order, or nil and "" when no position owns the order.
+// 返回持仓及订单类型；没有持仓拥有该订单时返回 nil 和 ""。
+func (sm *StopLossManager) PositionByOrder(binanceSymbol string, orderID int64) (*Position, string) {
+	id := fmt.Sprintf("%d", orderID)
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	for _, side := range positionSides {
+		pos, ok := sm.positions[positionKey(binanceSymbol, side)]
+		if !ok {
+			continue
+		}
+		switch id {
+		case pos.StopLossOrderID:
+			return pos, protectiveOrderStop
+		case pos.TakeProfitOrderID:
+			return pos, protectiveOrderTakeProfit
+		}
+	}
+	return nil, ""
 }
 
 // validateStopLossPrice validates if a stop-loss price is valid for the given position
@@ -270,6 +466,11 @@ func (sm *StopLossManager) validateStopLossPrice(ctx context.Context, symbol str
 // UpdateStopLoss updates stop-loss price for a position (called by LLM every 15 minutes)
 // UpdateStopLoss 更新持仓的止损价格（每 15 分钟由 LLM 调用）
 func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, newStopLoss float64, reason string) error {
+	side, err := sm.resolveSide(symbol)
+	if err != nil {
+		return err
+	}
+
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
@@ -277,7 +478,7 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	pos, exists := sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
 	if !exists {
 		return fmt.Errorf("持仓 %s 不存在", symbol)
 	}
@@ -307,9 +508,352 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 		return nil
 	}
 
-	// Record history
-	// 记录历史
-	pos.AddStopLossEvent(oldStop, newStopLoss, reason, "llm")
+	if err := sm.replaceStopLoss(ctx, symbol, pos, newStopLoss, reason, "llm"); err != nil {
+		return err
+	}
+
+	sm.logger.Success(fmt.Sprintf("【%s】✅ LLM 止损已更新: %.2f → %.2f (%s)",
+		pos.Symbol, oldStop, newStopLoss, reason))
+	return nil
+}
+
+// UpdateTrailingStop moves the stop-loss order to N×ATR behind the best price since entry
+// UpdateTrailingStop 将止损单移动到距离入场以来最优价格 N×ATR 的位置
+//
+// Should be called after UpdatePositionPriceFromKlines so HighestPrice reflects the latest kline.
+// The stop only moves in the favorable direction; a no-op when trailing is disabled or ATR is unknown.
+// 应在 UpdatePositionPriceFromKlines 之后调用，以确保 HighestPrice 包含最新 K 线。
+// 止损只朝有利方向移动；未启用追踪止损或 ATR 未知时不做任何操作。
+func (sm *StopLossManager) UpdateTrailingStop(ctx context.Context, symbol string) error {
+	if !sm.config.TrailingStopEnabled || sm.config.TrailingStopATRMultiplier <= 0 {
+		return nil
+	}
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.updateTrailingStop(ctx, symbol, side)
+	})
+}
+
+// updateTrailingStop moves the trailing stop of the position on one side
+// updateTrailingStop 移动指定方向持仓的追踪止损
+func (sm *StopLossManager) updateTrailingStop(ctx context.Context, symbol, side string) error {
+	// Normalize symbol to match internal storage format
+	// 标准化符号以匹配内部存储格式
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists || pos.ATR <= 0 || pos.HighestPrice <= 0 {
+		return nil
+	}
+
+	oldStop := pos.CurrentStopLoss
+	newStop := trailingStopPrice(pos.Side, pos.HighestPrice, pos.ATR, sm.config.TrailingStopATRMultiplier)
+
+	// Only ratchet towards profit
+	// 只朝盈利方向收紧
+	if pos.Side == "short" {
+		if newStop <= 0 || (oldStop > 0 && newStop >= oldStop) {
+			return nil
+		}
+	} else if newStop <= oldStop {
+		return nil
+	}
+
+	// Same threshold as LLM updates to avoid churning orders
+	// 与 LLM 更新使用相同阈值，避免频繁撤单重下
+	if oldStop > 0 {
+		changePercent := math.Abs((newStop-oldStop)/oldStop) * 100
+		if changePercent < sm.config.StopLossScopeThreshold {
+			return nil
+		}
+	}
+
+	distance := sm.config.TrailingStopATRMultiplier * pos.ATR
+	extremeName, sign := "最高价", "-"
+	if pos.Side == "short" {
+		extremeName, sign = "最低价", "+"
+	}
+	reason := fmt.Sprintf("ATR 追踪止损: %s %.2f %s %.1f×ATR(%.2f)",
+		extremeName, pos.HighestPrice, sign, sm.config.TrailingStopATRMultiplier, pos.ATR)
+
+	pos.StopLossType = "trailing"
+	pos.TrailingDistance = distance / pos.HighestPrice * 100
+
+	if err := sm.replaceStopLoss(ctx, symbol, pos, newStop, reason, "program"); err != nil {
+		return err
+	}
+
+	sm.logger.Success(fmt.Sprintf("【%s】✅ 追踪止损已移动: %.2f → %.2f (%s)",
+		pos.Symbol, oldStop, newStop, reason))
+	return nil
+}
+
+// trailingStopPrice returns the trailing stop for a side given the extreme price since entry
+// trailingStopPrice 根据入场以来的极值价格计算对应方向的追踪止损价
+func trailingStopPrice(side string, extremePrice, atr, multiplier float64) float64 {
+	distance := atr * multiplier
+	if side == "short" {
+		return extremePrice + distance
+	}
+	return extremePrice - distance
+}
+
+// SetPartialTakeProfit sets the price at which closePercent of the position will be closed
+// SetPartialTakeProfit 设置持仓的分批止盈目标价和平仓比例
+func (sm *StopLossManager) SetPartialTakeProfit(symbol string, targetPrice, closePercent float64) error {
+	side, err := sm.resolveSide(symbol)
+	if err != nil {
+		return err
+	}
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists {
+		return fmt.Errorf("持仓 %s 不存在", symbol)
+	}
+	if pos.PartialTPExecuted {
+		return fmt.Errorf("持仓 %s 已执行过分批止盈", symbol)
+	}
+	if closePercent <= 0 || closePercent >= 100 {
+		return fmt.Errorf("分批止盈比例必须在 0-100 之间（不含），当前: %.1f%%", closePercent)
+	}
+	if (pos.Side == "long" && targetPrice <= pos.EntryPrice) || (pos.Side == "short" && targetPrice >= pos.EntryPrice) {
+		return fmt.Errorf("分批止盈目标价 %.2f 必须位于入场价 %.2f 的盈利方向", targetPrice, pos.EntryPrice)
+	}
+
+	pos.PartialTPPrice = targetPrice
+	pos.PartialTPPercent = closePercent
+	sm.logger.Info(fmt.Sprintf("【%s】🎯 分批止盈已设置: 价格到达 %.2f 时平仓 %.0f%%", pos.Symbol, targetPrice, closePercent))
+
+	return sm.savePartialTakeProfit(pos, 0, 0)
+}
+
+// CheckPartialTakeProfit closes part of the position once its partial take-profit target is reached
+// CheckPartialTakeProfit 在价格到达分批止盈目标时平掉部分持仓
+//
+// Uses the position's own target if set, otherwise PARTIAL_TP_R_MULTIPLE × R when PARTIAL_TP_ENABLED.
+// Should be called after UpdatePositionPriceFromKlines so CurrentPrice is fresh.
+// 优先使用持仓自身的目标价，未设置时若启用 PARTIAL_TP_ENABLED 则使用 PARTIAL_TP_R_MULTIPLE × R。
+// 应在 UpdatePositionPriceFromKlines 之后调用，以确保 CurrentPrice 为最新价格。
+func (sm *StopLossManager) CheckPartialTakeProfit(ctx context.Context, symbol string) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.checkPartialTakeProfit(ctx, symbol, side)
+	})
+}
+
+// checkPartialTakeProfit checks the partial take-profit target of the position on one side
+// checkPartialTakeProfit 检查指定方向持仓的分批止盈目标
+func (sm *StopLossManager) checkPartialTakeProfit(ctx context.Context, symbol, side string) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists || pos.PartialTPExecuted {
+		sm.mu.RUnlock()
+		return nil
+	}
+	currentPrice := pos.CurrentPrice
+	target, closePercent := pos.PartialTPPrice, pos.PartialTPPercent
+	if target <= 0 && sm.config.PartialTPEnabled {
+		target = partialTPTarget(side, pos.EntryPrice, pos.InitialStopLoss, sm.config.PartialTPRMultiple)
+		closePercent = sm.config.PartialTPPercent
+	}
+	sm.mu.RUnlock()
+
+	if target <= 0 || closePercent <= 0 || currentPrice <= 0 {
+		return nil
+	}
+	if (side == "long" && currentPrice < target) || (side == "short" && currentPrice > target) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("分批止盈: 价格 %.2f 到达目标 %.2f", currentPrice, target)
+	_, err := sm.takePartialProfit(ctx, symbol, side, closePercent, reason)
+	return err
+}
+
+// TakePartialProfit closes closePercent of a position and re-places the stop for the remaining size
+// TakePartialProfit 平掉持仓的 closePercent 部分，并按剩余数量重新下止损单
+//
+// When PARTIAL_TP_BREAKEVEN is on, the stop is moved to the entry price if that is more favorable.
+// 启用 PARTIAL_TP_BREAKEVEN 时，如果保本价更有利，止损将移至入场价。
+func (sm *StopLossManager) TakePartialProfit(ctx context.Context, symbol string, closePercent float64, reason string) (*TradeResult, error) {
+	side, err := sm.resolveSide(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return sm.takePartialProfit(ctx, symbol, side, closePercent, reason)
+}
+
+// takePartialProfit closes closePercent of the position on one side
+// takePartialProfit 平掉指定方向持仓的 closePercent 部分
+func (sm *StopLossManager) takePartialProfit(ctx context.Context, symbol, side string, closePercent float64, reason string) (*TradeResult, error) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	if !exists {
+		return nil, fmt.Errorf("持仓 %s 不存在", symbol)
+	}
+	if closePercent <= 0 || closePercent >= 100 {
+		return nil, fmt.Errorf("分批止盈比例必须在 0-100 之间（不含），当前: %.1f%%", closePercent)
+	}
+
+	result, closeQty, realizedPnL, err := sm.closePortion(ctx, normalizedSymbol, pos, closePercent, reason)
+	if err != nil {
+		return result, err
+	}
+	pos.PartialTPExecuted = true
+	sm.logger.Success(fmt.Sprintf("【%s】✅ 分批止盈成交: 平仓 %.4f @ %.2f，已实现盈亏 %+.2f USDT，剩余 %.4f",
+		pos.Symbol, closeQty, result.Price, realizedPnL, pos.Quantity))
+
+	if err := sm.savePartialTakeProfit(pos, closeQty, realizedPnL); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  保存分批止盈状态失败: %v", err))
+	}
+
+	// Re-place the stop for the remaining size, at breakeven if configured and more favorable
+	// 按剩余数量重新下止损单，如配置且更有利则移至保本价
+	const resizeReason = "分批止盈后按剩余数量重挂止损"
+	oldType := pos.StopLossType
+	if sm.config.PartialTPBreakeven &&
+		((pos.Side == "long" && pos.EntryPrice > pos.CurrentStopLoss) || (pos.Side == "short" && pos.EntryPrice < pos.CurrentStopLoss)) {
+		pos.StopLossType = "breakeven"
+		err := sm.replaceStopLoss(ctx, symbol, pos, pos.EntryPrice, "分批止盈后止损移至保本", "program")
+		if err == nil {
+			sm.resizeTakeProfit(ctx, pos)
+			return result, nil
+		}
+
+		// Breakeven no longer valid (price back through entry): keep the old stop price
+		// 保本价已不合法（价格已回到入场价另一侧）：保留原止损价
+		sm.logger.Warning(fmt.Sprintf("【%s】⚠️  止损移至保本失败: %v，按原止损价重挂", pos.Symbol, err))
+		pos.StopLossType = oldType
+	}
+
+	if err := sm.replaceStopLoss(ctx, symbol, pos, pos.CurrentStopLoss, resizeReason, "program"); err != nil {
+		return result, fmt.Errorf("分批止盈后重挂止损失败: %w", err)
+	}
+	sm.resizeTakeProfit(ctx, pos)
+
+	return result, nil
+}
+
+// closePortion closes closePercent of pos with a market order and shrinks its quantity
+// closePortion 以市价单平掉 pos 的 closePercent 部分，并减少其数量
+//
+// It returns the order result, the closed quantity and the realized PnL; result.Price falls back to the
+// last known price. Caller must hold sm.mu.
+// 返回订单结果、平仓数量和已实现盈亏；result.Price 缺失时使用最近已知价格。调用方必须持有 sm.mu。
+func (sm *StopLossManager) closePortion(ctx context.Context, normalizedSymbol string, pos *Position, closePercent float64, reason string) (*TradeResult, float64, float64, error) {
+	closeQty, err := sm.executor.AdjustQuantityPrecision(ctx, normalizedSymbol, pos.Quantity*closePercent/100)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("分批平仓数量精度调整失败: %w", err)
+	}
+	if closeQty >= pos.Quantity {
+		return nil, 0, 0, fmt.Errorf("分批平仓数量 %.4f 不小于持仓数量 %.4f，请使用全部平仓", closeQty, pos.Quantity)
+	}
+
+	result := sm.executor.ClosePartialPosition(ctx, normalizedSymbol, pos.Side, closeQty, reason)
+	if !result.Success {
+		return result, 0, 0, fmt.Errorf("分批平仓失败: %s", result.Message)
+	}
+
+	if result.Price <= 0 {
+		result.Price = pos.CurrentPrice
+	}
+	realizedPnL := (result.Price - pos.EntryPrice) * closeQty
+	if pos.Side == "short" {
+		realizedPnL = (pos.EntryPrice - result.Price) * closeQty
+	}
+
+	pos.Quantity -= closeQty
+	pos.Size = pos.Quantity
+	return result, closeQty, realizedPnL, nil
+}
+
+// resizeTakeProfit re-places the take-profit order for the remaining quantity
+// resizeTakeProfit 按剩余数量重挂止盈单
+//
+// Caller must hold sm.mu.
+// 调用方必须持有 sm.mu。
+func (sm *StopLossManager) resizeTakeProfit(ctx context.Context, pos *Position) {
+	if pos.TakeProfitOrderID == "" {
+		return
+	}
+	target := pos.TakeProfitPrice
+	if err := sm.cancelTakeProfitOrder(ctx, pos); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  【%s】取消旧止盈单失败: %v", pos.Symbol, err))
+		return
+	}
+	if err := sm.placeTakeProfitOrder(ctx, pos, target); err != nil {
+		pos.TakeProfitPrice = 0
+		sm.logger.Warning(fmt.Sprintf("⚠️  【%s】按剩余数量重挂止盈单失败: %v", pos.Symbol, err))
+	}
+	if sm.storage != nil {
+		if err := sm.storage.SaveTakeProfit(pos.ID, pos.TakeProfitPrice, pos.TakeProfitOrderID); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  保存止盈单失败: %v", err))
+		}
+	}
+}
+
+// partialTPTarget returns the price rMultiple × R away from entry in the profit direction
+// partialTPTarget 返回入场价向盈利方向 rMultiple × R 处的价格
+func partialTPTarget(side string, entryPrice, initialStop, rMultiple float64) float64 {
+	risk := math.Abs(entryPrice - initialStop)
+	if risk == 0 || rMultiple <= 0 {
+		return 0
+	}
+	if side == "short" {
+		return entryPrice - rMultiple*risk
+	}
+	return entryPrice + rMultiple*risk
+}
+
+// savePartialTakeProfit persists the partial take-profit plan, adding closedQty and realizedPnL to the totals
+// savePartialTakeProfit 持久化分批止盈计划，并累加本次平仓数量和已实现盈亏
+func (sm *StopLossManager) savePartialTakeProfit(pos *Position, closedQty, realizedPnL float64) error {
+	if sm.storage == nil {
+		return nil
+	}
+
+	pt, err := sm.storage.GetPartialTakeProfit(pos.ID)
+	if err != nil {
+		return err
+	}
+	if pt == nil {
+		return nil // Position not persisted / 持仓未保存到数据库
+	}
+
+	pt.TargetPrice = pos.PartialTPPrice
+	pt.ClosePercent = pos.PartialTPPercent
+	pt.Executed = pos.PartialTPExecuted
+	pt.ClosedQuantity += closedQty
+	pt.RealizedPnL += realizedPnL
+	pt.Quantity = pos.Quantity
+	return sm.storage.SavePartialTakeProfit(pt)
+}
+
+// replaceStopLoss validates the new stop, swaps the Binance order and persists the change
+// replaceStopLoss 校验新止损价，替换币安止损单并持久化变更
+//
+// Caller must hold sm.mu.
+// 调用方必须持有 sm.mu。
+func (sm *StopLossManager) replaceStopLoss(ctx context.Context, symbol string, pos *Position, newStopLoss float64, reason, trigger string) error {
+	oldStop := pos.CurrentStopLoss
+
+	// Never cancel a protective order during exchange maintenance: the replacement may not be accepted
+	// 交易所维护期间不取消保护性订单：新止损单可能无法下单
+	if sm.executor.InMaintenance() {
+		sm.logger.Warning(fmt.Sprintf("【%s】🚧 交易所维护中，保留原止损单 %.2f", pos.Symbol, oldStop))
+		return fmt.Errorf("交易所维护中，原止损单 %.2f 保持不变", oldStop)
+	}
 
 	// CRITICAL FIX: Validate new stop-loss price BEFORE cancelling old order
 	// 关键修复：在取消旧订单之前先验证新止损价格
@@ -344,16 +888,31 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 	}
 
 	pos.CurrentStopLoss = newStopLoss
-	sm.logger.Success(fmt.Sprintf("【%s】✅ LLM 止损已更新: %.2f → %.2f (%s)",
-		pos.Symbol, oldStop, newStopLoss, reason))
+
+	// Record history
+	// 记录历史
+	pos.AddStopLossEvent(oldStop, newStopLoss, reason, trigger)
 
 	// Persist to database with retry
 	// 持久化到数据库（带重试）
 	if sm.storage != nil {
+		if err := sm.storage.SaveStopLossEvent(&storage.StopLossEvent{
+			PositionID: pos.ID,
+			Timestamp:  time.Now(),
+			OldStop:    oldStop,
+			NewStop:    newStopLoss,
+			Reason:     reason,
+			Trigger:    trigger,
+		}); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  保存止损变更记录失败: %v", err))
+		}
+
 		posRecord, err := sm.storage.GetPositionByID(pos.ID)
 		if err == nil && posRecord != nil {
 			posRecord.CurrentStopLoss = newStopLoss
 			posRecord.StopLossOrderID = pos.StopLossOrderID // ✅ 同步止损单 ID
+			posRecord.StopLossType = pos.StopLossType
+			posRecord.TrailingDistance = pos.TrailingDistance
 			// Retry database update up to 3 times
 			// 重试数据库更新最多 3 次
 			for i := 0; i < 3; i++ {
@@ -388,14 +947,23 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 // 如果 K 线最高价（$930）> 数据库最高价（$920），更新为 $930。
 // 否则保持 $920。这避免了每次都重新获取所有历史 K 线。
 func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, symbol string) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.updatePositionPriceFromKlines(ctx, symbol, side)
+	})
+}
+
+// updatePositionPriceFromKlines updates the position on one side from the latest kline
+// updatePositionPriceFromKlines 使用最新 K 线更新指定方向的持仓
+func (sm *StopLossManager) updatePositionPriceFromKlines(ctx context.Context, symbol, side string) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	key := positionKey(normalizedSymbol, side)
 
 	// Step 1: Get position data under lock
 	// 步骤 1：在锁内获取持仓数据
 	sm.mu.RLock()
-	pos, exists := sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[key]
 	if !exists {
 		sm.mu.RUnlock()
 		return nil // 无持仓 / No position
@@ -483,7 +1051,7 @@ func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, sy
 	sm.mu.Lock()
 	// Re-check position still exists
 	// 再次检查持仓是否仍存在
-	pos, exists = sm.positions[normalizedSymbol]
+	pos, exists = sm.positions[key]
 	if !exists {
 		sm.mu.Unlock()
 		return nil // Position was closed during API call / 持仓在 API 调用期间被关闭
@@ -503,6 +1071,7 @@ func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, sy
 	pos.HighestPrice = newHighestPrice
 	pos.CurrentPrice = currentPrice
 	pos.UnrealizedPnL = unrealizedPnL
+	pos.appendPricePoint(time.Now(), currentPrice)
 	sm.mu.Unlock()
 
 	// Update database immediately (outside lock to avoid holding lock during I/O)
@@ -552,14 +1121,22 @@ func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, sy
 // the stop-loss automatically, and the system needs to sync this change.
 // 这对于服务器端止损策略至关重要，因为币安会自动执行止损，系统需要同步这个变化。
 func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.reconcilePosition(ctx, symbol, side)
+	})
+}
+
+// reconcilePosition reconciles the managed position on one side with Binance
+// reconcilePosition 对账指定方向的受管持仓与币安持仓
+func (sm *StopLossManager) reconcilePosition(ctx context.Context, symbol, side string) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
-	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	key := positionKey(sm.config.GetBinanceSymbolFor(symbol), side)
 
 	// Step 1: Get position data under lock
 	// 步骤 1：在锁内获取持仓数据
 	sm.mu.RLock()
-	managedPos, exists := sm.positions[normalizedSymbol]
+	managedPos, exists := sm.positions[key]
 	if !exists {
 		sm.mu.RUnlock()
 		return nil // No position in memory, nothing to reconcile
@@ -570,11 +1147,19 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 	posQuantity := managedPos.Quantity
 	posEntryPrice := managedPos.EntryPrice
 	posCurrentStopLoss := managedPos.CurrentStopLoss
+	hasTakeProfit := managedPos.TakeProfitOrderID != ""
+	stale := managedPos.Stale
 	sm.mu.RUnlock()
 
-	// Get actual position from Binance
-	// 从币安获取实际持仓
-	actualPos, err := sm.executor.GetCurrentPosition(ctx, symbol)
+	// Get actual position from Binance, the same side in hedge mode
+	// 从币安获取实际持仓，双向持仓模式下取同一方向
+	var actualPos *Position
+	var err error
+	if sm.executor.IsHedgeMode(ctx) {
+		actualPos, err = sm.executor.GetCurrentPositionSide(ctx, symbol, posSide)
+	} else {
+		actualPos, err = sm.executor.GetCurrentPosition(ctx, symbol)
+	}
 	if err != nil {
 		sm.logger.Warning(fmt.Sprintf("⚠️  对账失败（无法获取 %s 币安持仓）: %v", symbol, err))
 		return err
@@ -607,7 +1192,13 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 		// Close position (removes from memory and updates database)
 		// 关闭持仓（从内存移除并更新数据库）
 		reason := "止损单触发（币安自动执行）"
-		if err := sm.ClosePosition(ctx, symbol, closePrice, reason, realizedPnL); err != nil {
+		if hasTakeProfit {
+			reason = "止损或止盈单触发（币安自动执行）"
+		}
+		if stale {
+			reason = "恢复时币安已无该持仓（停机期间已平仓）"
+		}
+		if err := sm.ClosePositionSide(ctx, symbol, posSide, closePrice, reason, realizedPnL); err != nil {
 			sm.logger.Warning(fmt.Sprintf("⚠️  清理已止损持仓失败: %v", err))
 			return err
 		}
@@ -626,7 +1217,7 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 
 	// Re-check position still exists
 	// 再次检查持仓是否仍存在
-	managedPos, exists = sm.positions[normalizedSymbol]
+	managedPos, exists = sm.positions[key]
 	if !exists {
 		return nil // Position was closed during API call
 	}
@@ -637,6 +1228,8 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 		sm.logger.Warning(fmt.Sprintf("⚠️【%s】持仓方向不一致！币安:%s, 内存:%s，以币安为准",
 			symbol, actualPos.Side, managedPos.Side))
 		managedPos.Side = actualPos.Side
+		delete(sm.positions, key)
+		sm.positions[positionKey(managedPos.Symbol, managedPos.Side)] = managedPos
 	}
 
 	// Check position size (with 0.1% tolerance for rounding)
@@ -664,12 +1257,20 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 // when a stop-loss is triggered.
 // 这是一个辅助方法，当止损触发时能提供更精确的平仓价格信息。
 func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol string) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.checkStopLossOrderStatus(ctx, symbol, side)
+	})
+}
+
+// checkStopLossOrderStatus checks the stop-loss order of the position on one side
+// checkStopLossOrderStatus 检查指定方向持仓的止损单
+func (sm *StopLossManager) checkStopLossOrderStatus(ctx context.Context, symbol, side string) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
 
 	sm.mu.RLock()
-	pos, exists := sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
 	sm.mu.RUnlock()
 
 	if !exists || pos.StopLossOrderID == "" {
@@ -680,28 +1281,13 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 
 	// Query order status from Binance
 	// 从币安查询订单状态
-	order, err := sm.executor.client.NewGetOrderService().
-		Symbol(binanceSymbol).
-		OrderID(parseInt64(pos.StopLossOrderID)).
-		Do(ctx)
-
+	order, err := sm.getOrder(ctx, binanceSymbol, pos.StopLossOrderID)
 	if err != nil {
-		// Check if order not found (likely executed or cancelled)
-		// 检查订单是否不存在（可能已执行或已取消）
-		// Note: Binance Go SDK doesn't provide typed errors, so we use string matching
-		// 注意：币安 Go SDK 不提供类型化错误，所以使用字符串匹配
-		// Common error messages: "Unknown order", "Order does not exist", "-2011"
-		// 常见错误消息："Unknown order"、"Order does not exist"、"-2011"
-		errMsg := err.Error()
-		isOrderNotFound := strings.Contains(errMsg, "Unknown order") ||
-			strings.Contains(errMsg, "Order does not exist") ||
-			strings.Contains(errMsg, "-2011") // Binance error code for unknown order
-
-		if isOrderNotFound {
+		if isOrderNotFound(err) {
 			sm.logger.Warning(fmt.Sprintf("🔔【%s】止损单已不存在（可能已执行），订单ID: %s", symbol, pos.StopLossOrderID))
 			// Trigger reconciliation to clean up
 			// 触发对账以清理持仓
-			return sm.ReconcilePosition(ctx, symbol)
+			return sm.reconcilePosition(ctx, symbol, side)
 		}
 		return fmt.Errorf("查询止损单状态失败: %w", err)
 	}
@@ -719,6 +1305,8 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 			sm.logger.Warning(fmt.Sprintf("⚠️  无法解析成交价格，使用止损价: %.2f", pos.CurrentStopLoss))
 			closePrice = pos.CurrentStopLoss
 		}
+		executedQty, _ := parseFloat(order.ExecutedQuantity)
+		sm.executor.recordOrderFills(ctx, binanceSymbol, order.OrderID, order.Type, order.Side, executedQty, closePrice)
 
 		// Calculate realized PnL
 		// 计算已实现盈亏
@@ -732,7 +1320,7 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 		// Close position
 		// 关闭持仓
 		reason := fmt.Sprintf("止损单成交（订单ID: %s）", pos.StopLossOrderID)
-		return sm.ClosePosition(ctx, symbol, closePrice, reason, realizedPnL)
+		return sm.ClosePositionSide(ctx, symbol, side, closePrice, reason, realizedPnL)
 	}
 
 	// Order still active
@@ -741,6 +1329,86 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 	return nil
 }
 
+// CheckTakeProfitOrderStatus closes the position when its take-profit order has filled
+// CheckTakeProfitOrderStatus 在止盈单成交时关闭持仓
+//
+// Closing cancels the stop-loss, the other side of the bracket. A missing order triggers
+// reconciliation, like a missing stop-loss.
+// 关闭持仓时会撤销括号单的另一方止损单。订单不存在时与止损单一样触发对账。
+func (sm *StopLossManager) CheckTakeProfitOrderStatus(ctx context.Context, symbol string) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.checkTakeProfitOrderStatus(ctx, symbol, side)
+	})
+}
+
+// checkTakeProfitOrderStatus checks the take-profit order of the position on one side
+// checkTakeProfitOrderStatus 检查指定方向持仓的止盈单
+func (sm *StopLossManager) checkTakeProfitOrderStatus(ctx context.Context, symbol, side string) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
+	sm.mu.RUnlock()
+
+	if !exists || pos.TakeProfitOrderID == "" {
+		return nil // No position or no take-profit order
+	}
+
+	order, err := sm.getOrder(ctx, normalizedSymbol, pos.TakeProfitOrderID)
+	if err != nil {
+		if isOrderNotFound(err) {
+			sm.logger.Warning(fmt.Sprintf("🔔【%s】止盈单已不存在（可能已执行），订单ID: %s", symbol, pos.TakeProfitOrderID))
+			return sm.reconcilePosition(ctx, symbol, side)
+		}
+		return fmt.Errorf("查询止盈单状态失败: %w", err)
+	}
+
+	if order.Status != futures.OrderStatusTypeFilled {
+		return nil
+	}
+
+	sm.logger.Success(fmt.Sprintf("🎯【%s】止盈单已成交，订单ID: %s", symbol, pos.TakeProfitOrderID))
+	closePrice, err := parseFloat(order.AvgPrice)
+	if err != nil || closePrice == 0 {
+		closePrice = pos.TakeProfitPrice
+	}
+	executedQty, _ := parseFloat(order.ExecutedQuantity)
+	sm.executor.recordOrderFills(ctx, normalizedSymbol, order.OrderID, order.Type, order.Side, executedQty, closePrice)
+
+	// The filled order no longer needs cancelling
+	// 已成交的订单无需再取消
+	reason := fmt.Sprintf("止盈单成交（订单ID: %s）", pos.TakeProfitOrderID)
+	sm.mu.Lock()
+	pos.TakeProfitOrderID = ""
+	sm.mu.Unlock()
+	return sm.ClosePositionSide(ctx, symbol, side, closePrice, reason, pos.RealizedPnLAt(closePrice, pos.Quantity))
+}
+
+// getOrder queries an order from Binance, or from the simulated order book in paper trading
+// getOrder 从币安查询订单，模拟盘从模拟订单簿查询
+func (sm *StopLossManager) getOrder(ctx context.Context, binanceSymbol, orderID string) (*futures.Order, error) {
+	if sm.executor.paper != nil {
+		return sm.executor.paper.GetOrder(ctx, binanceSymbol, parseInt64(orderID))
+	}
+	return sm.executor.client.NewGetOrderService().
+		Symbol(binanceSymbol).
+		OrderID(parseInt64(orderID)).
+		Do(ctx)
+}
+
+// isOrderNotFound reports whether an order query failed because the order no longer exists
+// isOrderNotFound 判断订单查询失败是否因为订单已不存在
+//
+// The Binance Go SDK doesn't provide typed errors, so the messages are matched:
+// "Unknown order", "Order does not exist" or code -2011.
+// 币安 Go SDK 不提供类型化错误，因此匹配错误消息："Unknown order"、"Order does not exist" 或错误码 -2011。
+func isOrderNotFound(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "Unknown order") ||
+		strings.Contains(errMsg, "Order does not exist") ||
+		strings.Contains(errMsg, "-2011")
+}
+
 // UpdatePosition updates position price and checks if stop-loss should trigger
 // UpdatePosition 更新持仓价格并检查是否应触发止损
 //
@@ -749,21 +1417,29 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 // Use Binance server-side STOP_MARKET orders instead.
 // 请使用币安服务器端 STOP_MARKET 订单。
 func (sm *StopLossManager) UpdatePosition(ctx context.Context, symbol string, currentPrice float64) error {
+	return sm.forEachSide(symbol, func(side string) error {
+		return sm.updatePosition(ctx, symbol, side, currentPrice)
+	})
+}
+
+// updatePosition updates the price of the position on one side (deprecated local monitoring)
+// updatePosition 更新指定方向持仓的价格（已弃用的本地监控）
+func (sm *StopLossManager) updatePosition(ctx context.Context, symbol, side string, currentPrice float64) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
 
 	sm.mu.Lock()
-	pos, exists := sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[positionKey(normalizedSymbol, side)]
 	if !exists {
 		sm.mu.Unlock()
 		return nil // 无持仓 / No position
 	}
-	sm.mu.Unlock()
 
 	// Update price
 	// 更新价格
 	pos.UpdatePrice(currentPrice)
+	sm.mu.Unlock()
 
 	// Check if stop-loss should be triggered (simple fixed stop-loss check)
 	// 检查是否应该触发止损（简单的固定止损检查）
@@ -786,24 +1462,38 @@ func (sm *StopLossManager) placeStopLossOrder(ctx context.Context, pos *Position
 		return fmt.Errorf("获取当前价格失败: %w", err)
 	}
 
+	// Round to the symbol's tick size before validating, so the checked price is the one sent
+	// 验证前先按交易对的价格步长取整，确保验证的就是实际下单的价格
+	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	filters := sm.executor.SymbolFiltersFor(ctx, binanceSymbol)
+	stopPrice = filters.RoundPrice(stopPrice)
+	stopText, currentText := filters.FormatPrice(stopPrice), filters.FormatPrice(currentPrice)
+
 	// Validate stop-loss price to prevent immediate trigger
 	// 验证止损价格以防止立即触发
 	if pos.Side == "short" {
 		// 空仓止损买入：止损价格必须高于当前市场价
 		if stopPrice <= currentPrice {
-			sm.logger.Warning(fmt.Sprintf("【%s】❌ 空仓止损价格设置错误: %.2f <= 当前价 %.2f (会立即触发)",
-				pos.Symbol, stopPrice, currentPrice))
-			return fmt.Errorf("空仓止损价格 %.2f 必须高于当前市场价 %.2f，否则会立即触发", stopPrice, currentPrice)
+			sm.logger.Warning(fmt.Sprintf("【%s】❌ 空仓止损价格设置错误: %s <= 当前价 %s (会立即触发)",
+				pos.Symbol, stopText, currentText))
+			return fmt.Errorf("空仓止损价格 %s 必须高于当前市场价 %s，否则会立即触发", stopText, currentText)
 		}
 	} else {
 		// 多仓止损卖出：止损价格必须低于当前市场价
 		if stopPrice >= currentPrice {
-			sm.logger.Warning(fmt.Sprintf("【%s】❌ 多仓止损价格设置错误: %.2f >= 当前价 %.2f (会立即触发)",
-				pos.Symbol, stopPrice, currentPrice))
-			return fmt.Errorf("多仓止损价格 %.2f 必须低于当前市场价 %.2f，否则会立即触发", stopPrice, currentPrice)
+			sm.logger.Warning(fmt.Sprintf("【%s】❌ 多仓止损价格设置错误: %s >= 当前价 %s (会立即触发)",
+				pos.Symbol, stopText, currentText))
+			return fmt.Errorf("多仓止损价格 %s 必须低于当前市场价 %s，否则会立即触发", stopText, currentText)
 		}
 	}
 
+	// Binance rejects stops too far from the market price (PERCENT_PRICE)
+	// 币安拒绝距离市场价过远的止损价（PERCENT_PRICE）
+	if err := filters.CheckPercentPrice(stopPrice, currentPrice); err != nil {
+		sm.logger.Warning(fmt.Sprintf("【%s】❌ 止损价格超出交易所允许范围: %v", pos.Symbol, err))
+		return fmt.Errorf("止损价格超出交易所允许范围: %w", err)
+	}
+
 	var orderSide futures.SideType
 	if pos.Side == "short" {
 		orderSide = futures.SideTypeBuy
@@ -811,17 +1501,28 @@ func (sm *StopLossManager) placeStopLossOrder(ctx context.Context, pos *Position
 		orderSide = futures.SideTypeSell
 	}
 
-	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	// Paper trading keeps the stop order in the simulated order book
+	// 模拟盘将止损单保存在模拟订单簿中
+	if sm.executor.paper != nil {
+		orderID, err := sm.executor.paper.PlaceStopMarketOrder(ctx, pos.Symbol, orderSide, stopPrice, pos.Quantity)
+		if err != nil {
+			return fmt.Errorf("下止损单失败: %w", err)
+		}
+
+		pos.StopLossOrderID = fmt.Sprintf("%d", orderID)
+		sm.logger.Success(fmt.Sprintf("【%s】止损单已下达（模拟盘）: %s (订单ID: %s, 当前价: %s)",
+			pos.Symbol, stopText, pos.StopLossOrderID, currentText))
+		return nil
+	}
 
-	// Create stop-loss order
-	// 创建止损单
-	order, err := sm.executor.client.NewCreateOrderService().
+	// Create stop-loss order, formatted with the symbol's tick and step size
+	// 创建止损单，价格和数量按交易对的价格步长和数量步长格式化
+	order, err := sm.closeOnly(ctx, pos, sm.executor.client.NewCreateOrderService().
 		Symbol(binanceSymbol).
 		Side(orderSide).
 		Type(futures.OrderTypeStopMarket).
-		StopPrice(fmt.Sprintf("%.2f", stopPrice)).
-		Quantity(fmt.Sprintf("%.4f", pos.Quantity)).
-		ReduceOnly(true). // 只平仓不开仓 / Close only
+		StopPrice(stopText).
+		Quantity(filters.FormatQuantity(pos.Quantity))).
 		Do(ctx)
 
 	if err != nil {
@@ -829,12 +1530,29 @@ func (sm *StopLossManager) placeStopLossOrder(ctx context.Context, pos *Position
 	}
 
 	pos.StopLossOrderID = fmt.Sprintf("%d", order.OrderID)
-	sm.logger.Success(fmt.Sprintf("【%s】止损单已下达: %.2f (订单ID: %s, 当前价: %.2f)",
-		pos.Symbol, stopPrice, pos.StopLossOrderID, currentPrice))
+	sm.logger.Success(fmt.Sprintf("【%s】止损单已下达: %s (订单ID: %s, 当前价: %s)",
+		pos.Symbol, stopText, pos.StopLossOrderID, currentText))
 
 	return nil
 }
 
+// closeOnly makes a protective order close pos only
+// closeOnly 使保护性订单只平掉 pos
+//
+// One-way mode uses reduceOnly. In hedge mode the order targets the position's side instead,
+// so the long and the short each get their own stop, and Binance rejects reduceOnly there.
+// 单向持仓模式使用 reduceOnly。双向持仓模式下订单改为指定持仓方向，使多仓和空仓各有自己的止损单，
+// 且币安在该模式下不接受 reduceOnly。
+func (sm *StopLossManager) closeOnly(ctx context.Context, pos *Position, service *futures.CreateOrderService) *futures.CreateOrderService {
+	if !sm.executor.IsHedgeMode(ctx) {
+		return service.ReduceOnly(true) // 只平仓不开仓 / Close only
+	}
+	if pos.Side == "short" {
+		return service.PositionSide(futures.PositionSideTypeShort)
+	}
+	return service.PositionSide(futures.PositionSideTypeLong)
+}
+
 // cancelStopLossOrder cancels an existing stop-loss order
 // cancelStopLossOrder 取消现有的止损单
 func (sm *StopLossManager) cancelStopLossOrder(ctx context.Context, pos *Position) error {
@@ -851,10 +1569,15 @@ func (sm *StopLossManager) cancelStopLossOrder(ctx context.Context, pos *Positio
 	sm.logger.Info(fmt.Sprintf("【%s】正在取消止损单: OrderID=%s, Symbol=%s",
 		pos.Symbol, pos.StopLossOrderID, binanceSymbol))
 
-	_, err := sm.executor.client.NewCancelOrderService().
-		Symbol(binanceSymbol).
-		OrderID(parseInt64(pos.StopLossOrderID)).
-		Do(ctx)
+	var err error
+	if sm.executor.paper != nil {
+		err = sm.executor.paper.CancelOrder(pos.Symbol, parseInt64(pos.StopLossOrderID))
+	} else {
+		_, err = sm.executor.client.NewCancelOrderService().
+			Symbol(binanceSymbol).
+			OrderID(parseInt64(pos.StopLossOrderID)).
+			Do(ctx)
+	}
 
 	if err != nil {
 		// Provide detailed error context
@@ -869,6 +1592,87 @@ func (sm *StopLossManager) cancelStopLossOrder(ctx context.Context, pos *Positio
 	return nil
 }
 
+// placeTakeProfitOrder places a reduce-only take-profit-market order on Binance
+// placeTakeProfitOrder 在币安下只减仓的止盈市价单
+func (sm *StopLossManager) placeTakeProfitOrder(ctx context.Context, pos *Position, takeProfitPrice float64) error {
+	currentPrice, err := sm.getCurrentPrice(ctx, pos.Symbol)
+	if err != nil {
+		return fmt.Errorf("获取当前价格失败: %w", err)
+	}
+
+	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	filters := sm.executor.SymbolFiltersFor(ctx, binanceSymbol)
+	takeProfitPrice = filters.RoundPrice(takeProfitPrice)
+	priceText, currentText := filters.FormatPrice(takeProfitPrice), filters.FormatPrice(currentPrice)
+
+	// The target must be in the profit direction, otherwise the order triggers immediately
+	// 止盈价必须位于盈利方向，否则会立即触发
+	orderSide := futures.SideTypeSell
+	if pos.Side == "short" {
+		orderSide = futures.SideTypeBuy
+		if takeProfitPrice >= currentPrice {
+			return fmt.Errorf("空仓止盈价格 %s 必须低于当前市场价 %s，否则会立即触发", priceText, currentText)
+		}
+	} else if takeProfitPrice <= currentPrice {
+		return fmt.Errorf("多仓止盈价格 %s 必须高于当前市场价 %s，否则会立即触发", priceText, currentText)
+	}
+	if err := filters.CheckPercentPrice(takeProfitPrice, currentPrice); err != nil {
+		return fmt.Errorf("止盈价格超出交易所允许范围: %w", err)
+	}
+
+	var orderID int64
+	if sm.executor.paper != nil {
+		orderID, err = sm.executor.paper.PlaceTakeProfitOrder(ctx, pos.Symbol, orderSide, takeProfitPrice, pos.Quantity)
+	} else {
+		var order *futures.CreateOrderResponse
+		order, err = sm.closeOnly(ctx, pos, sm.executor.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(orderSide).
+			Type(futures.OrderTypeTakeProfitMarket).
+			StopPrice(priceText).
+			Quantity(filters.FormatQuantity(pos.Quantity))).
+			Do(ctx)
+		if err == nil {
+			orderID = order.OrderID
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("下止盈单失败: %w", err)
+	}
+
+	pos.TakeProfitPrice = takeProfitPrice
+	pos.TakeProfitOrderID = fmt.Sprintf("%d", orderID)
+	sm.logger.Success(fmt.Sprintf("【%s】🎯 止盈单已下达: %s (订单ID: %s, 当前价: %s)",
+		pos.Symbol, priceText, pos.TakeProfitOrderID, currentText))
+	return nil
+}
+
+// cancelTakeProfitOrder cancels the take-profit order of a position
+// cancelTakeProfitOrder 取消持仓的止盈单
+func (sm *StopLossManager) cancelTakeProfitOrder(ctx context.Context, pos *Position) error {
+	if pos.TakeProfitOrderID == "" {
+		return nil
+	}
+
+	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+
+	var err error
+	if sm.executor.paper != nil {
+		err = sm.executor.paper.CancelOrder(pos.Symbol, parseInt64(pos.TakeProfitOrderID))
+	} else {
+		_, err = sm.executor.client.NewCancelOrderService().
+			Symbol(binanceSymbol).
+			OrderID(parseInt64(pos.TakeProfitOrderID)).
+			Do(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("取消止盈单失败 (Symbol=%s, OrderID=%s): %w", binanceSymbol, pos.TakeProfitOrderID, err)
+	}
+
+	pos.TakeProfitOrderID = ""
+	return nil
+}
+
 // executeStopLoss executes stop-loss (close position)
 // executeStopLoss 执行止损（平仓）
 //
@@ -891,7 +1695,9 @@ func (sm *StopLossManager) executeStopLoss(ctx context.Context, pos *Position) e
 	if result.Success {
 		sm.logger.Success(fmt.Sprintf("【%s】止损平仓成功，盈亏: %.2f%%",
 			pos.Symbol, pos.GetUnrealizedPnL()*100))
-		sm.RemovePosition(pos.Symbol)
+		sm.mu.Lock()
+		delete(sm.positions, positionKey(pos.Symbol, pos.Side))
+		sm.mu.Unlock()
 	} else {
 		sm.logger.Error(fmt.Sprintf("【%s】止损平仓失败: %s", pos.Symbol, result.Message))
 		return fmt.Errorf("止损平仓失败: %s", result.Message)
@@ -950,7 +1756,7 @@ func (sm *StopLossManager) MonitorPositions(interval time.Duration) {
 
 				// Update position and check stop-loss trigger
 				// 更新持仓并检查止损触发
-				if err := sm.UpdatePosition(sm.ctx, pos.Symbol, currentPrice); err != nil {
+				if err := sm.updatePosition(sm.ctx, pos.Symbol, pos.Side, currentPrice); err != nil {
 					sm.logger.Error(fmt.Sprintf("更新 %s 持仓失败: %v", pos.Symbol, err))
 				}
 			}
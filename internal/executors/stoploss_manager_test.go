@@ -0,0 +1,311 @@
+package executors
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestTrailingStopPrice tests trailing stop calculation for long and short positions
+// TestTrailingStopPrice 测试多仓和空仓的追踪止损价计算
+func TestTrailingStopPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     string
+		extreme  float64
+		atr      float64
+		mult     float64
+		expected float64
+	}{
+		{name: "Long below highest", side: "long", extreme: 100000, atr: 800, mult: 2.5, expected: 98000},
+		{name: "Short above lowest", side: "short", extreme: 3000, atr: 40, mult: 2, expected: 3080},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trailingStopPrice(tt.side, tt.extreme, tt.atr, tt.mult)
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("trailingStopPrice() = %.4f, want %.4f", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestUpdateTrailingStopSkips tests that no order is touched when trailing is disabled or would loosen the stop
+// TestUpdateTrailingStopSkips 测试未启用追踪止损或会放宽止损时不操作订单
+func TestUpdateTrailingStopSkips(t *testing.T) {
+	cfg := &config.Config{TrailingStopATRMultiplier: 2, StopLossScopeThreshold: 1.0}
+	sm := NewStopLossManager(cfg, nil, logger.NewColorLogger(false), nil)
+	sm.positions[positionKey("BTCUSDT", "long")] = &Position{
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		HighestPrice:    100000,
+		CurrentStopLoss: 99000,
+		ATR:             1000,
+	}
+
+	// Disabled: nothing happens
+	// 未启用：不做任何操作
+	if err := sm.UpdateTrailingStop(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("Expected no error when disabled, got %v", err)
+	}
+
+	// Enabled but 100000 - 2×1000 = 98000 is below the current stop: nothing happens
+	// 已启用但 98000 低于当前止损：不做任何操作
+	cfg.TrailingStopEnabled = true
+	if err := sm.UpdateTrailingStop(context.Background(), "BTCUSDT"); err != nil {
+		t.Fatalf("Expected no error for looser stop, got %v", err)
+	}
+	if stop := sm.positions[positionKey("BTCUSDT", "long")].CurrentStopLoss; stop != 99000 {
+		t.Errorf("Stop should be unchanged, got %.2f", stop)
+	}
+}
+
+// TestPartialTPTarget tests R-multiple partial take-profit targets
+// TestPartialTPTarget 测试基于 R 倍数的分批止盈目标价
+func TestPartialTPTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     string
+		entry    float64
+		stop     float64
+		r        float64
+		expected float64
+	}{
+		{name: "Long 1R", side: "long", entry: 100000, stop: 98000, r: 1, expected: 102000},
+		{name: "Short 1.5R", side: "short", entry: 3000, stop: 3100, r: 1.5, expected: 2850},
+		{name: "No risk", side: "long", entry: 100, stop: 100, r: 1, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := partialTPTarget(tt.side, tt.entry, tt.stop, tt.r)
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("partialTPTarget() = %.4f, want %.4f", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSetPartialTakeProfitValidation tests that targets on the losing side are rejected
+// TestSetPartialTakeProfitValidation 测试亏损方向的目标价会被拒绝
+func TestSetPartialTakeProfitValidation(t *testing.T) {
+	sm := NewStopLossManager(&config.Config{}, nil, logger.NewColorLogger(false), nil)
+	sm.positions[positionKey("ETHUSDT", "short")] = &Position{Symbol: "ETHUSDT", Side: "short", EntryPrice: 3000, Quantity: 1}
+
+	if err := sm.SetPartialTakeProfit("ETH/USDT", 3100, 50); err == nil {
+		t.Error("Expected error for target above short entry")
+	}
+	if err := sm.SetPartialTakeProfit("ETH/USDT", 2800, 100); err == nil {
+		t.Error("Expected error for 100% close")
+	}
+	if err := sm.SetPartialTakeProfit("ETH/USDT", 2800, 50); err != nil {
+		t.Fatalf("Expected valid plan, got %v", err)
+	}
+	if pos := sm.positions[positionKey("ETHUSDT", "short")]; pos.PartialTPPrice != 2800 || pos.PartialTPPercent != 50 {
+		t.Errorf("Plan not stored: %.2f / %.1f", pos.PartialTPPrice, pos.PartialTPPercent)
+	}
+}
+
+// TestHedgePositionsTrackedPerSide tests that a long and a short on the same symbol are managed separately
+// TestHedgePositionsTrackedPerSide 测试同一交易对的多仓和空仓分别管理
+func TestHedgePositionsTrackedPerSide(t *testing.T) {
+	ctx := context.Background()
+	sm := NewStopLossManager(&config.Config{}, nil, logger.NewColorLogger(false), nil)
+	sm.RegisterPosition(&Position{ID: "long-1", Symbol: "BTC/USDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 95, CurrentStopLoss: 95})
+	sm.RegisterPosition(&Position{ID: "short-1", Symbol: "BTCUSDT", Side: "short", EntryPrice: 101, Quantity: 2, InitialStopLoss: 106, CurrentStopLoss: 106})
+
+	if n := len(sm.GetAllPositions()); n != 2 {
+		t.Fatalf("Expected 2 managed positions, got %d", n)
+	}
+	if pos := sm.GetPositionSide("BTC/USDT", "short"); pos == nil || pos.ID != "short-1" {
+		t.Errorf("Expected the short, got %+v", pos)
+	}
+	if pos := sm.GetPosition("BTCUSDT"); pos == nil || pos.ID != "long-1" {
+		t.Errorf("Expected GetPosition to return the long first, got %+v", pos)
+	}
+
+	// Calls without a side are ambiguous while both sides are held
+	// 同时持有多空时，未指定方向的调用无法确定持仓
+	if err := sm.ClosePosition(ctx, "BTCUSDT", 100, "test", 0); err == nil {
+		t.Error("Expected an error closing without a side")
+	}
+	if err := sm.UpdateStopLoss(ctx, "BTCUSDT", 97, "test"); err == nil {
+		t.Error("Expected an error updating the stop without a side")
+	}
+
+	if err := sm.ClosePositionSide(ctx, "BTCUSDT", "long", 102, "test", 2); err != nil {
+		t.Fatalf("ClosePositionSide failed: %v", err)
+	}
+	if sm.GetPositionSide("BTCUSDT", "long") != nil {
+		t.Error("Long should be removed")
+	}
+	if pos := sm.GetPosition("BTCUSDT"); pos == nil || pos.ID != "short-1" {
+		t.Fatalf("Short should still be managed, got %+v", pos)
+	}
+
+	// With one side left, calls without a side act on it
+	// 只剩一个方向时，未指定方向的调用作用于该持仓
+	if err := sm.ClosePosition(ctx, "BTCUSDT", 100, "test", 2); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+	if n := len(sm.GetAllPositions()); n != 0 {
+		t.Errorf("Expected no managed positions, got %d", n)
+	}
+}
+
+// newBracketTestManager creates a stop-loss manager on a paper executor with an open 1 BTC long @ 100
+// newBracketTestManager 创建基于模拟盘执行器的止损管理器，并开 1 BTC 多仓 @ 100
+func newBracketTestManager(t *testing.T, market *paperMarket, cfg *config.Config) (*StopLossManager, *Position) {
+	t.Helper()
+
+	server := httptest.NewServer(market)
+	t.Cleanup(server.Close)
+
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "bracket.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	log := logger.NewColorLogger(false)
+	e := &BinanceExecutor{config: cfg, client: client, logger: log}
+	e.EnablePaperTrading(db)
+	sm := NewStopLossManager(cfg, e, log, db)
+
+	if _, _, err := e.paper.applyFill("BTCUSDT", "BUY", 1, 100, false); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	pos := &Position{
+		ID:              "BTCUSDT-1",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      100,
+		EntryTime:       time.Now(),
+		Quantity:        1,
+		Leverage:        10,
+		InitialStopLoss: 95,
+		CurrentStopLoss: 95,
+		StopLossType:    "fixed",
+	}
+	if err := db.SavePosition(&storage.PositionRecord{
+		ID:              pos.ID,
+		Symbol:          pos.Symbol,
+		Side:            pos.Side,
+		EntryPrice:      pos.EntryPrice,
+		EntryTime:       pos.EntryTime,
+		Quantity:        pos.Quantity,
+		Leverage:        pos.Leverage,
+		InitialStopLoss: pos.InitialStopLoss,
+		CurrentStopLoss: pos.CurrentStopLoss,
+		StopLossType:    pos.StopLossType,
+	}); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+	sm.RegisterPosition(pos)
+	return sm, pos
+}
+
+// paperOrderStatus returns the status of a simulated order
+// paperOrderStatus 返回模拟订单的状态
+func paperOrderStatus(t *testing.T, sm *StopLossManager, orderID string) string {
+	t.Helper()
+	order, err := sm.executor.paper.storage.GetPaperOrder(parseInt64(orderID))
+	if err != nil || order == nil {
+		t.Fatalf("GetPaperOrder(%s) = %v, %v", orderID, order, err)
+	}
+	return order.Status
+}
+
+// TestBracketTakeProfitFill tests that the entry bracket places both sides and a take-profit fill cancels the stop
+// TestBracketTakeProfitFill 测试开仓括号单同时挂止损和止盈，止盈成交后撤销止损单
+func TestBracketTakeProfitFill(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10, TakeProfitRMultiple: 2}
+	sm, pos := newBracketTestManager(t, market, cfg)
+	ctx := context.Background()
+
+	if err := sm.PlaceInitialStopLoss(ctx, pos); err != nil {
+		t.Fatalf("PlaceInitialStopLoss failed: %v", err)
+	}
+	stopID, takeProfitID := pos.StopLossOrderID, pos.TakeProfitOrderID
+	if stopID == "" || takeProfitID == "" {
+		t.Fatalf("Expected both bracket orders, got stop %q take-profit %q", stopID, takeProfitID)
+	}
+	// 2R above the entry: 100 + 2 × 5
+	// 入场价上方 2R：100 + 2 × 5
+	if pos.TakeProfitPrice != 110 {
+		t.Errorf("TakeProfitPrice = %.2f, want 110", pos.TakeProfitPrice)
+	}
+	if price, orderID, err := sm.storage.GetTakeProfit(pos.ID); err != nil || price != 110 || orderID != takeProfitID {
+		t.Errorf("GetTakeProfit = %.2f, %q, %v; want 110, %q", price, orderID, err, takeProfitID)
+	}
+
+	market.klines = paperKline(100, 111, 99, 110)
+	if err := sm.CheckTakeProfitOrderStatus(ctx, "BTCUSDT"); err != nil {
+		t.Fatalf("CheckTakeProfitOrderStatus failed: %v", err)
+	}
+	if status := paperOrderStatus(t, sm, takeProfitID); status != string(futures.OrderStatusTypeFilled) {
+		t.Errorf("Take-profit status = %s, want FILLED", status)
+	}
+	if status := paperOrderStatus(t, sm, stopID); status != string(futures.OrderStatusTypeCanceled) {
+		t.Errorf("Stop-loss status = %s, want CANCELED after the take-profit fill", status)
+	}
+	if sm.GetPosition("BTCUSDT") != nil {
+		t.Error("Position should be removed after the take-profit fill")
+	}
+}
+
+// TestBracketManualClose tests that closing a position cancels both sides of the bracket
+// TestBracketManualClose 测试关闭持仓时撤销括号单的两方
+func TestBracketManualClose(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10}
+	sm, pos := newBracketTestManager(t, market, cfg)
+	ctx := context.Background()
+
+	// An explicit target is used even with TAKE_PROFIT_R_MULTIPLE unset
+	// 即使未设置 TAKE_PROFIT_R_MULTIPLE，也使用显式指定的止盈价
+	pos.TakeProfitPrice = 108
+	if err := sm.PlaceInitialStopLoss(ctx, pos); err != nil {
+		t.Fatalf("PlaceInitialStopLoss failed: %v", err)
+	}
+	stopID, takeProfitID := pos.StopLossOrderID, pos.TakeProfitOrderID
+	if takeProfitID == "" {
+		t.Fatal("Expected a take-profit order")
+	}
+
+	if err := sm.ClosePosition(ctx, "BTCUSDT", 101, "人工平仓", 1); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+	for name, id := range map[string]string{"stop-loss": stopID, "take-profit": takeProfitID} {
+		if status := paperOrderStatus(t, sm, id); status != string(futures.OrderStatusTypeCanceled) {
+			t.Errorf("%s status = %s, want CANCELED", name, status)
+		}
+	}
+}
+
+// TestBracketTakeProfitOptional tests that no take-profit is placed without a target
+// TestBracketTakeProfitOptional 测试未设置止盈目标时不挂止盈单
+func TestBracketTakeProfitOptional(t *testing.T) {
+	market := &paperMarket{price: "100"}
+	cfg := &config.Config{PaperInitialBalance: 1000, PaperTakerFeeRate: 0.0004, BinanceLeverage: 10}
+	sm, pos := newBracketTestManager(t, market, cfg)
+
+	if err := sm.PlaceInitialStopLoss(context.Background(), pos); err != nil {
+		t.Fatalf("PlaceInitialStopLoss failed: %v", err)
+	}
+	if pos.StopLossOrderID == "" || pos.TakeProfitOrderID != "" {
+		t.Errorf("Expected only a stop-loss, got stop %q take-profit %q", pos.StopLossOrderID, pos.TakeProfitOrderID)
+	}
+}
@@ -0,0 +1,220 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// minSweepAmount is the smallest transfer worth making (USDT)
+// minSweepAmount 是值得划转的最小金额（USDT）
+const minSweepAmount = 1.0
+
+// ProfitSweeper moves a slice of the profits from the futures wallet to the spot wallet
+// ProfitSweeper 将部分利润从合约钱包划转到现货钱包
+//
+// Profits are measured from a baseline: the initial capital, then the equity left after the
+// last sweep. Once equity exceeds the baseline by PROFIT_SWEEP_THRESHOLD%, PROFIT_SWEEP_PERCENT%
+// of the excess is transferred (capped at the withdrawable amount) and recorded.
+// 利润以基准计算：初始本金，之后为上一次提取后剩余的权益。权益超过基准 PROFIT_SWEEP_THRESHOLD% 时，
+// 划转超出部分的 PROFIT_SWEEP_PERCENT%（不超过可划转金额）并记录。
+type ProfitSweeper struct {
+	config   *config.Config
+	executor *BinanceExecutor
+	storage  *storage.Storage
+	logger   *logger.ColorLogger
+	mu       sync.Mutex
+}
+
+// NewProfitSweeper creates a new ProfitSweeper
+// NewProfitSweeper 创建新的利润提取器
+func NewProfitSweeper(cfg *config.Config, executor *BinanceExecutor, db *storage.Storage, log *logger.ColorLogger) *ProfitSweeper {
+	return &ProfitSweeper{
+		config:   cfg,
+		executor: executor,
+		storage:  db,
+		logger:   log,
+	}
+}
+
+// initialCapital returns the configured initial capital (the paper initial balance when unset)
+// initialCapital 返回配置的初始本金（未设置时模拟盘使用初始资金）
+func (s *ProfitSweeper) initialCapital() float64 {
+	if s.config.ProfitSweepInitialCapital <= 0 && s.executor.IsPaperTrading() {
+		return s.config.PaperInitialBalance
+	}
+	return s.config.ProfitSweepInitialCapital
+}
+
+// sweepAmount returns how much to transfer for the given equity and baseline (0 = nothing to sweep)
+// sweepAmount 根据权益和基准计算应划转的金额（0 表示无需提取）
+func sweepAmount(equity, baseline, thresholdPct, slicePct, withdrawable float64) float64 {
+	if baseline <= 0 || slicePct <= 0 || equity < baseline*(1+thresholdPct/100) {
+		return 0
+	}
+	amount := math.Min((equity-baseline)*math.Min(slicePct, 100)/100, withdrawable)
+	amount = math.Floor(amount*100) / 100 // 保留 2 位小数 / Round down to cents
+	if amount < minSweepAmount {
+		return 0
+	}
+	return amount
+}
+
+// Sweep checks the equity and transfers profits if the threshold is reached
+// Sweep 检查权益，达到阈值时划转利润
+//
+// Returns the recorded sweep, or nil when nothing was swept.
+// 返回已记录的提取，未提取时返回 nil。
+func (s *ProfitSweeper) Sweep(ctx context.Context) (*storage.ProfitSweep, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baseline := s.initialCapital()
+	if baseline <= 0 {
+		return nil, fmt.Errorf("PROFIT_SWEEP_INITIAL_CAPITAL is not set")
+	}
+	last, err := s.storage.GetLastProfitSweep()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && last.EquityAfter > baseline {
+		baseline = last.EquityAfter
+	}
+
+	account, err := s.executor.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+	equity, err := parseFloat(account.TotalMarginBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse margin balance: %w", err)
+	}
+	withdrawable, err := parseFloat(account.MaxWithdrawAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max withdraw amount: %w", err)
+	}
+
+	amount := sweepAmount(equity, baseline, s.config.ProfitSweepThreshold, s.config.ProfitSweepPercent, withdrawable)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	// Record the sweep before transferring: a transfer whose record failed to save would leave the
+	// old baseline in place and the same profit would be transferred again next time
+	// 先记录再划转：若划转后记录保存失败，基准不会更新，下一次会重复划转同一笔利润
+	sweep := &storage.ProfitSweep{
+		Amount:       amount,
+		Baseline:     baseline,
+		EquityBefore: equity,
+		EquityAfter:  equity - amount,
+		Paper:        s.executor.IsPaperTrading() || s.executor.testMode,
+		CreatedAt:    time.Now(),
+	}
+	sweep.ID, err = s.storage.SaveProfitSweep(sweep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record profit sweep, nothing transferred: %w", err)
+	}
+
+	transferID, err := s.executor.TransferToSpot(ctx, amount)
+	if err != nil {
+		// Drop the record so the profit is swept next time; if that fails too the baseline stays
+		// raised, which skips this profit rather than transferring it twice
+		// 删除记录以便下次重新提取；删除失败时基准保持提高，宁可漏提也不重复划转
+		if delErr := s.storage.DeleteProfitSweep(sweep.ID); delErr != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  删除未完成的利润提取记录失败，本次利润不会再次提取: %v", delErr))
+		}
+		return nil, err
+	}
+
+	sweep.TransferID = transferID
+	if err := s.storage.SetProfitSweepTransferID(sweep.ID, transferID); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  保存划转 ID %d 失败: %v", transferID, err))
+	}
+
+	s.logger.Success(fmt.Sprintf("💰 利润提取: 权益 %.2f USDT 超过基准 %.2f USDT，已划转 %.2f USDT 到现货钱包",
+		equity, baseline, amount))
+	return sweep, nil
+}
+
+// Run checks every interval until ctx is done
+// Run 每隔 interval 检查一次，直到 ctx 结束
+func (s *ProfitSweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Sweep(ctx); err != nil {
+				s.logger.Warning(fmt.Sprintf("⚠️  利润提取失败: %v", err))
+			}
+		}
+	}
+}
+
+// TransferToSpot moves QUOTE_ASSET from the futures wallet to the spot wallet and returns the transfer ID
+// TransferToSpot 将 QUOTE_ASSET 从合约钱包划转到现货钱包，返回划转 ID
+//
+// Paper trading debits the simulated wallet; testnet has no universal transfer, so nothing is moved.
+// 模拟盘从模拟钱包扣除；测试网不支持万向划转，不实际划转。
+func (e *BinanceExecutor) TransferToSpot(ctx context.Context, amount float64) (int64, error) {
+	if e.paper != nil {
+		return 0, e.paper.Withdraw(amount)
+	}
+	if e.testMode {
+		e.logger.Info(fmt.Sprintf("🧪 测试模式：模拟划转 %.2f %s 到现货钱包", amount, e.config.QuoteAssetFor("")))
+		return 0, nil
+	}
+
+	spot := binance.NewClient(e.config.BinanceAPIKey, e.config.BinanceAPISecret)
+	spot.HTTPClient = e.client.HTTPClient // 复用代理设置 / Reuse the proxy settings
+	spot.TimeOffset = e.client.TimeOffset // 复用启动时的时钟偏移校正 / Reuse the clock offset set at startup
+
+	// Not retried: a transfer whose response was lost may still have been executed
+	// 不重试：响应丢失的划转可能已经执行
+	res, err := spot.NewUserUniversalTransferService().
+		Type(binance.UserUniversalTransferTypeUmFuturesToMain).
+		Asset(e.config.QuoteAssetFor("")).
+		Amount(strconv.FormatFloat(amount, 'f', 2, 64)).
+		Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer to spot wallet: %w", err)
+	}
+	return res.ID, nil
+}
+
+// Withdraw removes funds from the simulated wallet, as a transfer out of the futures wallet would
+// Withdraw 从模拟钱包扣除资金，模拟从合约钱包转出
+func (p *PaperExecutor) Withdraw(amount float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	account, err := p.storage.GetPaperAccount(p.config.PaperInitialBalance)
+	if err != nil {
+		return err
+	}
+	available, err := p.availableBalance(account)
+	if err != nil {
+		return err
+	}
+	if amount > available {
+		return fmt.Errorf("insufficient paper balance: %.2f > %.2f", amount, available)
+	}
+
+	account.WalletBalance -= amount
+	if err := p.storage.UpdatePaperAccount(account); err != nil {
+		return err
+	}
+	p.logger.Info(fmt.Sprintf("📝 模拟盘划转 %.2f USDT 到现货钱包，钱包余额 %.2f USDT", amount, account.WalletBalance))
+	return nil
+}
@@ -0,0 +1,88 @@
+package executors
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestSweepAmount tests the threshold, slice and withdrawable cap of profit sweeps
+// TestSweepAmount 测试利润提取的阈值、比例和可划转上限
+func TestSweepAmount(t *testing.T) {
+	tests := []struct {
+		name         string
+		equity       float64
+		withdrawable float64
+		want         float64
+	}{
+		{"below threshold", 1190, 1190, 0},
+		{"at threshold", 1200, 1200, 100},
+		{"capped by withdrawable", 1400, 150, 150},
+		{"rounded down to cents", 1200.555, 1200, 100.27},
+		{"below minimum", 1201, 0.5, 0},
+	}
+	for _, tt := range tests {
+		// 基准 1000，阈值 20%，提取超出部分的 50%
+		if got := sweepAmount(tt.equity, 1000, 20, 50, tt.withdrawable); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: sweepAmount = %.2f, want %.2f", tt.name, got, tt.want)
+		}
+	}
+	if got := sweepAmount(2000, 0, 20, 50, 2000); got != 0 {
+		t.Errorf("No baseline should sweep nothing, got %.2f", got)
+	}
+}
+
+// TestPaperProfitSweep tests that a paper sweep debits the wallet and raises the next baseline
+// TestPaperProfitSweep 测试模拟盘提取会扣除钱包余额并提高下一次的基准
+func TestPaperProfitSweep(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "sweep.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{PaperInitialBalance: 1000, ProfitSweepThreshold: 20, ProfitSweepPercent: 50}
+	e := &BinanceExecutor{config: cfg, logger: logger.NewColorLogger(false)}
+	e.EnablePaperTrading(db)
+
+	// 模拟盈利：钱包余额从 1000 增至 1300
+	account, err := db.GetPaperAccount(cfg.PaperInitialBalance)
+	if err != nil {
+		t.Fatalf("GetPaperAccount failed: %v", err)
+	}
+	account.WalletBalance = 1300
+	if err := db.UpdatePaperAccount(account); err != nil {
+		t.Fatalf("UpdatePaperAccount failed: %v", err)
+	}
+
+	sweeper := NewProfitSweeper(cfg, e, db, e.logger)
+	sweep, err := sweeper.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if sweep == nil || sweep.Amount != 150 || sweep.Baseline != 1000 || sweep.EquityAfter != 1150 || !sweep.Paper {
+		t.Fatalf("Unexpected sweep: %+v", sweep)
+	}
+
+	account, err = db.GetPaperAccount(cfg.PaperInitialBalance)
+	if err != nil {
+		t.Fatalf("GetPaperAccount failed: %v", err)
+	}
+	if account.WalletBalance != 1150 {
+		t.Errorf("Wallet balance should be 1150 after the sweep, got %.2f", account.WalletBalance)
+	}
+
+	if saved, err := db.GetLastProfitSweep(); err != nil || saved == nil || saved.ID != sweep.ID || saved.EquityAfter != 1150 {
+		t.Errorf("Expected the sweep to be recorded, got %+v (%v)", saved, err)
+	}
+
+	// 新基准为 1150，需要超过 1380 才会再次提取
+	if sweep, err := sweeper.Sweep(context.Background()); err != nil || sweep != nil {
+		t.Errorf("Second sweep should do nothing, got %+v (%v)", sweep, err)
+	}
+}
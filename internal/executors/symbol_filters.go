@@ -0,0 +1,370 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// symbolFiltersRetry is how long to wait before retrying a failed exchangeInfo fetch
+// symbolFiltersRetry 是 exchangeInfo 获取失败后重试前的等待时间
+const symbolFiltersRetry = 5 * time.Minute
+
+// symbolFiltersRefresh is how old the symbol metadata may get before exchangeInfo is fetched again
+// symbolFiltersRefresh 是交易对元数据的最长有效期，超过后重新获取 exchangeInfo
+const symbolFiltersRefresh = 24 * time.Hour
+
+// symbolRegistryExchange is the exchange name under which the metadata is persisted
+// symbolRegistryExchange 是持久化元数据时使用的交易所名称
+const symbolRegistryExchange = "binance"
+
+// fallbackSymbolFilters are the filters of common pairs, used when exchangeInfo is unavailable
+// (paper replay, network errors) or lacks a filter
+// fallbackSymbolFilters 是常见交易对的过滤规则，在无法获取 exchangeInfo（模拟盘回放、网络错误等）或缺少某项规则时使用
+var fallbackSymbolFilters = map[string]SymbolFilters{
+	"BTCUSDT":   {StepSize: 0.001, MinQty: 0.001, TickSize: 0.1, MinNotional: 100},
+	"ETHUSDT":   {StepSize: 0.001, MinQty: 0.001, TickSize: 0.01, MinNotional: 20},
+	"SOLUSDT":   {StepSize: 0.01, MinQty: 0.01, TickSize: 0.01, MinNotional: 5},
+	"BNBUSDT":   {StepSize: 0.01, MinQty: 0.01, TickSize: 0.01, MinNotional: 5},
+	"XRPUSDT":   {StepSize: 0.1, MinQty: 0.1, TickSize: 0.0001, MinNotional: 5},
+	"ADAUSDT":   {StepSize: 1, MinQty: 1, TickSize: 0.0001, MinNotional: 5},
+	"DOGEUSDT":  {StepSize: 1, MinQty: 1, TickSize: 0.00001, MinNotional: 5},
+	"DOTUSDT":   {StepSize: 0.1, MinQty: 0.1, TickSize: 0.001, MinNotional: 5},
+	"MATICUSDT": {StepSize: 1, MinQty: 1, TickSize: 0.0001, MinNotional: 5},
+	"AVAXUSDT":  {StepSize: 1, MinQty: 1, TickSize: 0.001, MinNotional: 5},
+}
+
+// defaultSymbolFilters are used for pairs missing from fallbackSymbolFilters
+// defaultSymbolFilters 用于 fallbackSymbolFilters 中没有的交易对
+var defaultSymbolFilters = SymbolFilters{StepSize: 0.001, MinQty: 0.001, TickSize: 0.01, MinNotional: 5}
+
+// fallbackFiltersFor returns the fallback filters of a Binance symbol
+// fallbackFiltersFor 返回币安交易对的备用过滤规则
+func fallbackFiltersFor(binanceSymbol string) SymbolFilters {
+	if f, ok := fallbackSymbolFilters[binanceSymbol]; ok {
+		return f
+	}
+	return defaultSymbolFilters
+}
+
+// SymbolFilters holds the order filters Binance enforces for a futures symbol
+// SymbolFilters 保存币安对合约交易对下单时的过滤规则
+type SymbolFilters struct {
+	StepSize    float64 // 数量步长（LOT_SIZE）/ Quantity step (LOT_SIZE)
+	MinQty      float64 // 最小下单数量 / Minimum order quantity
+	TickSize    float64 // 价格步长（PRICE_FILTER）/ Price tick (PRICE_FILTER)
+	MinNotional float64 // 最小订单价值 USDT（MIN_NOTIONAL）/ Minimum order value in USDT (MIN_NOTIONAL)
+
+	// PERCENT_PRICE bounds relative to the market price (0 = not enforced)
+	// PERCENT_PRICE 相对市场价的上下限（0 表示不检查）
+	MultiplierUp   float64
+	MultiplierDown float64
+
+	ContractType string    // 合约类型（如 PERPETUAL，未知时为空）/ Contract type (e.g. PERPETUAL, empty when unknown)
+	OnboardDate  time.Time // 上线时间（未知时为零值）/ Listing time (zero when unknown)
+	MarginAsset  string    // 保证金资产（未知时为空）/ Margin asset (empty when unknown)
+}
+
+// ListedFor returns how long the symbol has been listed at now (0 when the onboard date is unknown)
+// ListedFor 返回交易对截至 now 的上线时长（上线时间未知时为 0）
+func (f SymbolFilters) ListedFor(now time.Time) time.Duration {
+	if f.OnboardDate.IsZero() || now.Before(f.OnboardDate) {
+		return 0
+	}
+	return now.Sub(f.OnboardDate)
+}
+
+// RoundQuantity rounds a quantity down to the step size
+// RoundQuantity 将数量向下取整到数量步长
+func (f SymbolFilters) RoundQuantity(quantity float64) float64 {
+	return roundToStep(quantity, f.StepSize, math.Floor)
+}
+
+// FormatQuantity formats a quantity for an order, rounded down to the step size
+// FormatQuantity 将数量格式化为下单参数（向下取整到数量步长）
+func (f SymbolFilters) FormatQuantity(quantity float64) string {
+	return strconv.FormatFloat(f.RoundQuantity(quantity), 'f', stepDecimals(f.StepSize), 64)
+}
+
+// RoundPrice rounds a price to the nearest tick
+// RoundPrice 将价格四舍五入到价格步长
+func (f SymbolFilters) RoundPrice(price float64) float64 {
+	return roundToStep(price, f.TickSize, math.Round)
+}
+
+// FormatPrice formats a price for an order, rounded to the nearest tick
+// FormatPrice 将价格格式化为下单参数（四舍五入到价格步长）
+func (f SymbolFilters) FormatPrice(price float64) string {
+	return strconv.FormatFloat(f.RoundPrice(price), 'f', stepDecimals(f.TickSize), 64)
+}
+
+// CheckPercentPrice rejects a price outside the PERCENT_PRICE bounds around the market price
+// CheckPercentPrice 拒绝超出市场价 PERCENT_PRICE 上下限的价格
+func (f SymbolFilters) CheckPercentPrice(price, marketPrice float64) error {
+	if f.MultiplierUp > 0 && price > marketPrice*f.MultiplierUp {
+		return fmt.Errorf("价格 %s 高于 PERCENT_PRICE 上限 %s（市场价 %s × %s）",
+			f.FormatPrice(price), f.FormatPrice(marketPrice*f.MultiplierUp), f.FormatPrice(marketPrice),
+			strconv.FormatFloat(f.MultiplierUp, 'f', -1, 64))
+	}
+	if f.MultiplierDown > 0 && price < marketPrice*f.MultiplierDown {
+		return fmt.Errorf("价格 %s 低于 PERCENT_PRICE 下限 %s（市场价 %s × %s）",
+			f.FormatPrice(price), f.FormatPrice(marketPrice*f.MultiplierDown), f.FormatPrice(marketPrice),
+			strconv.FormatFloat(f.MultiplierDown, 'f', -1, 64))
+	}
+	return nil
+}
+
+// roundToStep rounds value to a multiple of step with the given rounding function
+// roundToStep 使用给定的取整函数将 value 取整为 step 的整数倍
+func roundToStep(value, step float64, round func(float64) float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	// The epsilon keeps 0.3/0.1 = 2.9999999999999996 from flooring to 2
+	// 加上 epsilon，避免 0.3/0.1 = 2.9999999999999996 被向下取整为 2
+	steps := round(value/step + 1e-9)
+	decimals := math.Pow(10, float64(stepDecimals(step)))
+	return math.Round(steps*step*decimals) / decimals
+}
+
+// stepDecimals returns the number of decimals of a step such as 0.001 (3) or 10 (0)
+// stepDecimals 返回步长的小数位数，例如 0.001 为 3、10 为 0
+func stepDecimals(step float64) int {
+	s := strconv.FormatFloat(step, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// EnableSymbolRegistry persists the symbol metadata so restarts and exchangeInfo outages reuse the last refresh
+// EnableSymbolRegistry 持久化交易对元数据，重启或 exchangeInfo 不可用时复用最近一次刷新结果
+func (e *BinanceExecutor) EnableSymbolRegistry(db *storage.Storage) {
+	e.filtersMu.Lock()
+	defer e.filtersMu.Unlock()
+	e.registry = db
+}
+
+// LoadSymbolFilters fetches exchangeInfo and caches the filters of every futures symbol
+// LoadSymbolFilters 获取 exchangeInfo 并缓存所有合约交易对的下单过滤规则
+//
+// When the fetch fails and nothing is cached yet, the metadata persisted by the last refresh is used.
+// 获取失败且尚无缓存时，使用上次刷新时持久化的元数据。
+func (e *BinanceExecutor) LoadSymbolFilters(ctx context.Context) error {
+	e.filtersMu.Lock()
+	defer e.filtersMu.Unlock()
+
+	err := e.loadSymbolFilters(ctx)
+	if err != nil && e.symbolFilters == nil {
+		if refreshedAt, ok := e.restoreSymbolFilters(); ok {
+			e.logger.Warning(fmt.Sprintf("⚠️  获取 exchangeInfo 失败，使用 %s 保存的交易对元数据",
+				refreshedAt.Local().Format("2006-01-02 15:04")))
+			return nil
+		}
+	}
+	return err
+}
+
+// loadSymbolFilters fetches exchangeInfo (caller holds filtersMu)
+// loadSymbolFilters 获取 exchangeInfo（调用方需持有 filtersMu）
+func (e *BinanceExecutor) loadSymbolFilters(ctx context.Context) error {
+	e.filtersLoadedAt = time.Now()
+
+	info, err := e.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange info: %w", err)
+	}
+
+	filters := make(map[string]SymbolFilters, len(info.Symbols))
+	for i := range info.Symbols {
+		symbol := &info.Symbols[i]
+		f := fallbackFiltersFor(symbol.Symbol)
+		if lot := symbol.LotSizeFilter(); lot != nil {
+			e.parseFilterValue(symbol.Symbol, "stepSize", lot.StepSize, &f.StepSize)
+			e.parseFilterValue(symbol.Symbol, "minQty", lot.MinQuantity, &f.MinQty)
+		}
+		if price := symbol.PriceFilter(); price != nil {
+			e.parseFilterValue(symbol.Symbol, "tickSize", price.TickSize, &f.TickSize)
+		}
+		if notional := symbol.MinNotionalFilter(); notional != nil {
+			e.parseFilterValue(symbol.Symbol, "notional", notional.Notional, &f.MinNotional)
+		}
+		if percent := symbol.PercentPriceFilter(); percent != nil {
+			e.parseFilterValue(symbol.Symbol, "multiplierUp", percent.MultiplierUp, &f.MultiplierUp)
+			e.parseFilterValue(symbol.Symbol, "multiplierDown", percent.MultiplierDown, &f.MultiplierDown)
+		}
+		f.ContractType = string(symbol.ContractType)
+		f.MarginAsset = symbol.MarginAsset
+		if symbol.OnboardDate > 0 {
+			f.OnboardDate = time.UnixMilli(symbol.OnboardDate)
+		}
+		filters[symbol.Symbol] = f
+	}
+	e.symbolFilters = filters
+	e.filtersRefreshedAt = e.filtersLoadedAt
+
+	if e.registry != nil {
+		if err := e.registry.SaveSymbolMetadata(symbolRegistryExchange, symbolMetadataFrom(filters, e.filtersRefreshedAt)); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  保存交易对元数据失败: %v", err))
+		}
+	}
+	return nil
+}
+
+// restoreSymbolFilters loads the persisted metadata into the cache (caller holds filtersMu)
+// restoreSymbolFilters 将持久化的元数据载入缓存（调用方需持有 filtersMu）
+//
+// It returns the oldest refresh time of the restored symbols, or false when nothing is stored.
+// 返回已恢复交易对中最早的刷新时间；没有保存的数据时返回 false。
+func (e *BinanceExecutor) restoreSymbolFilters() (time.Time, bool) {
+	if e.registry == nil {
+		return time.Time{}, false
+	}
+	stored, err := e.registry.GetSymbolMetadata(symbolRegistryExchange)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  读取交易对元数据失败: %v", err))
+		return time.Time{}, false
+	}
+	if len(stored) == 0 {
+		return time.Time{}, false
+	}
+
+	filters, refreshedAt := symbolFiltersFrom(stored)
+	e.symbolFilters = filters
+	e.filtersRefreshedAt = refreshedAt
+	return refreshedAt, true
+}
+
+// symbolMetadataFrom converts cached filters into registry rows
+// symbolMetadataFrom 将缓存的过滤规则转换为元数据记录
+func symbolMetadataFrom(filters map[string]SymbolFilters, refreshedAt time.Time) []*storage.SymbolMetadata {
+	items := make([]*storage.SymbolMetadata, 0, len(filters))
+	for symbol, f := range filters {
+		items = append(items, &storage.SymbolMetadata{
+			Symbol:       symbol,
+			TickSize:     f.TickSize,
+			StepSize:     f.StepSize,
+			MinQty:       f.MinQty,
+			MinNotional:  f.MinNotional,
+			ContractType: f.ContractType,
+			OnboardDate:  f.OnboardDate,
+			UpdatedAt:    refreshedAt,
+		})
+	}
+	return items
+}
+
+// symbolFiltersFrom converts registry rows into filters, returning the oldest refresh time
+// symbolFiltersFrom 将元数据记录转换为过滤规则，并返回最早的刷新时间
+//
+// PERCENT_PRICE bounds are not persisted and are not enforced until the next exchangeInfo refresh.
+// PERCENT_PRICE 上下限不会持久化，在下次刷新 exchangeInfo 之前不做检查。
+func symbolFiltersFrom(stored map[string]*storage.SymbolMetadata) (map[string]SymbolFilters, time.Time) {
+	filters := make(map[string]SymbolFilters, len(stored))
+	var oldest time.Time
+	for symbol, m := range stored {
+		filters[symbol] = SymbolFilters{
+			StepSize:     m.StepSize,
+			MinQty:       m.MinQty,
+			TickSize:     m.TickSize,
+			MinNotional:  m.MinNotional,
+			ContractType: m.ContractType,
+			OnboardDate:  m.OnboardDate,
+		}
+		if oldest.IsZero() || m.UpdatedAt.Before(oldest) {
+			oldest = m.UpdatedAt
+		}
+	}
+	return filters, oldest
+}
+
+// parseFilterValue parses one exchangeInfo filter value, keeping the fallback when it is malformed
+// parseFilterValue 解析 exchangeInfo 中的单项过滤规则，格式错误时保留备用值
+func (e *BinanceExecutor) parseFilterValue(symbol, name, raw string, value *float64) {
+	parsed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || parsed <= 0 {
+		e.logger.Warning(fmt.Sprintf("⚠️  %s 的 %s 无效 (%q)，使用默认值 %s",
+			symbol, name, raw, strconv.FormatFloat(*value, 'f', -1, 64)))
+		return
+	}
+	*value = parsed
+}
+
+// SymbolFiltersFor returns the cached filters of a symbol, loading exchangeInfo on first use
+// SymbolFiltersFor 返回交易对缓存的过滤规则，首次使用时加载 exchangeInfo
+//
+// The first use prefers metadata persisted within symbolFiltersRefresh; the cache is refreshed from
+// exchangeInfo once it is older than symbolFiltersRefresh. Unknown symbols and failed fetches fall back
+// to fallbackSymbolFilters (or defaultSymbolFilters); a failed fetch is retried after symbolFiltersRetry.
+// 首次使用时优先采用 symbolFiltersRefresh 内持久化的元数据；缓存超过 symbolFiltersRefresh 后从 exchangeInfo 刷新。
+// 未知交易对或获取失败时使用 fallbackSymbolFilters（或 defaultSymbolFilters）；获取失败会在 symbolFiltersRetry 后重试。
+func (e *BinanceExecutor) SymbolFiltersFor(ctx context.Context, symbol string) SymbolFilters {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.filtersMu.Lock()
+	defer e.filtersMu.Unlock()
+
+	if e.symbolFilters == nil {
+		// Stale metadata is kept as the fallback while exchangeInfo is refreshed below
+		// 过期的元数据作为备用保留，同时在下方刷新 exchangeInfo
+		e.restoreSymbolFilters()
+	}
+	stale := e.symbolFilters == nil || time.Since(e.filtersRefreshedAt) >= symbolFiltersRefresh
+	if stale && e.client != nil && time.Since(e.filtersLoadedAt) >= symbolFiltersRetry {
+		if err := e.loadSymbolFilters(ctx); err != nil {
+			if e.symbolFilters != nil {
+				e.logger.Warning(fmt.Sprintf("⚠️  刷新交易对元数据失败: %v，继续使用 %s 的数据",
+					err, e.filtersRefreshedAt.Local().Format("2006-01-02 15:04")))
+			} else {
+				e.logger.Warning(fmt.Sprintf("⚠️  获取交易对精度规则失败: %v，使用默认精度", err))
+			}
+		}
+	}
+	if f, ok := e.symbolFilters[binanceSymbol]; ok {
+		return f
+	}
+	return fallbackFiltersFor(binanceSymbol)
+}
+
+// UnlistedSymbols returns the configured symbols that exchangeInfo doesn't list
+// UnlistedSymbols 返回 exchangeInfo 中不存在的已配置交易对
+//
+// exchangeInfo is only fetched when nothing is cached or restored from the registry yet.
+// 仅在尚无缓存、也无法从元数据注册表恢复时才获取 exchangeInfo。
+func (e *BinanceExecutor) UnlistedSymbols(ctx context.Context, symbols []string) ([]string, error) {
+	e.filtersMu.Lock()
+	defer e.filtersMu.Unlock()
+
+	if e.symbolFilters == nil {
+		if _, ok := e.restoreSymbolFilters(); !ok {
+			if err := e.loadSymbolFilters(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var unlisted []string
+	for _, symbol := range symbols {
+		if _, ok := e.symbolFilters[e.config.GetBinanceSymbolFor(symbol)]; !ok {
+			unlisted = append(unlisted, symbol)
+		}
+	}
+	return unlisted, nil
+}
+
+// AdjustQuantityPrecision rounds a quantity down to the symbol's step size and checks the minimum quantity
+// AdjustQuantityPrecision 将数量向下取整到交易对的数量步长，并检查最小数量
+func (e *BinanceExecutor) AdjustQuantityPrecision(ctx context.Context, symbol string, quantity float64) (float64, error) {
+	f := e.SymbolFiltersFor(ctx, symbol)
+	adjusted := f.RoundQuantity(quantity)
+	if adjusted < f.MinQty {
+		return 0, fmt.Errorf("数量 %s 低于最小要求 %s (交易对: %s)",
+			f.FormatQuantity(quantity), strconv.FormatFloat(f.MinQty, 'f', -1, 64), symbol)
+	}
+	return adjusted, nil
+}
@@ -0,0 +1,190 @@
+package executors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestSymbolFiltersFormatting tests rounding quantities and prices to the step and tick size
+// TestSymbolFiltersFormatting 测试数量和价格按数量步长、价格步长取整
+func TestSymbolFiltersFormatting(t *testing.T) {
+	btc := SymbolFilters{StepSize: 0.001, MinQty: 0.001, TickSize: 0.1, MinNotional: 100}
+	doge := SymbolFilters{StepSize: 1, MinQty: 1, TickSize: 0.00001, MinNotional: 5}
+
+	tests := []struct {
+		got, want string
+	}{
+		{btc.FormatQuantity(0.01999), "0.019"}, // 数量向下取整 / Quantities round down
+		{btc.FormatQuantity(0.3), "0.300"},
+		{btc.FormatPrice(101234.56), "101234.6"}, // 价格四舍五入 / Prices round to nearest
+		{doge.FormatQuantity(1234.9), "1234"},
+		{doge.FormatPrice(0.123456), "0.12346"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("Got %s, want %s", tt.got, tt.want)
+		}
+	}
+
+	if got := (SymbolFilters{StepSize: 0.1}).RoundQuantity(0.3); got != 0.3 {
+		t.Errorf("RoundQuantity(0.3) = %v, want 0.3", got)
+	}
+	if stepDecimals(10) != 0 || stepDecimals(0.0001) != 4 {
+		t.Errorf("Unexpected step decimals: %d, %d", stepDecimals(10), stepDecimals(0.0001))
+	}
+}
+
+// TestCheckPercentPrice tests the PERCENT_PRICE bounds around the market price
+// TestCheckPercentPrice 测试市场价附近的 PERCENT_PRICE 上下限
+func TestCheckPercentPrice(t *testing.T) {
+	doge := SymbolFilters{TickSize: 0.00001, MultiplierUp: 1.05, MultiplierDown: 0.95}
+	tests := []struct {
+		price float64
+		valid bool
+	}{
+		{0.1, true},
+		{0.104, true},
+		{0.096, true},
+		{0.106, false},
+		{0.094, false},
+	}
+	for _, tt := range tests {
+		if err := doge.CheckPercentPrice(tt.price, 0.1); (err == nil) != tt.valid {
+			t.Errorf("CheckPercentPrice(%v, 0.1) = %v, want valid %v", tt.price, err, tt.valid)
+		}
+	}
+
+	// Without the filter any price passes
+	// 未设置过滤规则时任何价格都通过
+	if err := (SymbolFilters{TickSize: 0.01}).CheckPercentPrice(1000, 1); err != nil {
+		t.Errorf("Expected no PERCENT_PRICE check without multipliers, got %v", err)
+	}
+	if got := doge.RoundPrice(0.0987654); got != 0.09877 {
+		t.Errorf("RoundPrice(0.0987654) = %v, want 0.09877", got)
+	}
+}
+
+// TestLoadSymbolFilters tests loading filters from exchangeInfo and falling back to defaults
+// TestLoadSymbolFilters 测试从 exchangeInfo 加载过滤规则以及回退到默认规则
+func TestLoadSymbolFilters(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"symbols": [{"symbol": "SOLUSDT", "filters": [
+			{"filterType": "PRICE_FILTER", "tickSize": "0.0100", "minPrice": "0.4200", "maxPrice": "6857"},
+			{"filterType": "LOT_SIZE", "stepSize": "1", "minQty": "1", "maxQty": "1000000"},
+			{"filterType": "MIN_NOTIONAL", "notional": "5"},
+			{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500", "multiplierDown": "0.9500", "multiplierDecimal": "4"}
+		]}, {"symbol": "XRPUSDT", "filters": [
+			{"filterType": "PRICE_FILTER", "tickSize": "bad", "minPrice": "0.0143", "maxPrice": "100000"},
+			{"filterType": "LOT_SIZE", "stepSize": "0.1", "minQty": "", "maxQty": "10000000"}
+		]}]}`))
+	}))
+	defer server.Close()
+
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	e := &BinanceExecutor{client: client, config: &config.Config{}, testMode: true, logger: logger.NewColorLogger(false)}
+	ctx := context.Background()
+
+	// Loaded lazily on first use, then served from the cache
+	// 首次使用时加载，之后从缓存读取
+	sol := e.SymbolFiltersFor(ctx, "SOL/USDT")
+	if sol.StepSize != 1 || sol.MinQty != 1 || sol.TickSize != 0.01 || sol.MinNotional != 5 ||
+		sol.MultiplierUp != 1.05 || sol.MultiplierDown != 0.95 {
+		t.Fatalf("Unexpected SOLUSDT filters: %+v", sol)
+	}
+	if got := e.SymbolFiltersFor(ctx, "BTCUSDT"); got != fallbackSymbolFilters["BTCUSDT"] {
+		t.Errorf("Symbols missing from exchangeInfo should use the fallback table, got %+v", got)
+	}
+	if got := e.SymbolFiltersFor(ctx, "PEPEUSDT"); got != defaultSymbolFilters {
+		t.Errorf("Symbols missing from the fallback table should use the defaults, got %+v", got)
+	}
+
+	// Malformed values keep the fallback of that field only
+	// 格式错误的字段仅该项使用备用值
+	xrp := e.SymbolFiltersFor(ctx, "XRPUSDT")
+	if xrp.StepSize != 0.1 || xrp.MinQty != 0.1 || xrp.TickSize != 0.0001 || xrp.MinNotional != 5 {
+		t.Errorf("Unexpected XRPUSDT filters: %+v", xrp)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected exchangeInfo to be fetched once, got %d", requests.Load())
+	}
+
+	qty, err := e.AdjustQuantityPrecision(ctx, "SOLUSDT", 12.7)
+	if err != nil || qty != 12 {
+		t.Errorf("AdjustQuantityPrecision = %v, %v; want 12, nil", qty, err)
+	}
+	if _, err := e.AdjustQuantityPrecision(ctx, "SOLUSDT", 0.5); err == nil {
+		t.Error("Expected an error below the minimum quantity")
+	}
+}
+
+// TestSymbolRegistry tests persisting the metadata and reusing it until it is a day old
+// TestSymbolRegistry 测试持久化元数据，并在一天内复用
+func TestSymbolRegistry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"symbols": [{"symbol": "SOLUSDT", "contractType": "PERPETUAL", "onboardDate": 1599321600000, "filters": [
+			{"filterType": "PRICE_FILTER", "tickSize": "0.0100"},
+			{"filterType": "LOT_SIZE", "stepSize": "1", "minQty": "1"},
+			{"filterType": "MIN_NOTIONAL", "notional": "5"}
+		]}]}`))
+	}))
+	defer server.Close()
+
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	newExecutor := func() *BinanceExecutor {
+		client := futures.NewClient("", "")
+		client.BaseURL = server.URL
+		e := &BinanceExecutor{client: client, config: &config.Config{}, testMode: true, logger: logger.NewColorLogger(false)}
+		e.EnableSymbolRegistry(db)
+		return e
+	}
+	ctx := context.Background()
+
+	sol := newExecutor().SymbolFiltersFor(ctx, "SOL/USDT")
+	if sol.ContractType != "PERPETUAL" || !sol.OnboardDate.Equal(time.UnixMilli(1599321600000)) || sol.StepSize != 1 {
+		t.Fatalf("Unexpected SOLUSDT metadata: %+v", sol)
+	}
+	if sol.ListedFor(sol.OnboardDate.Add(48*time.Hour)) != 48*time.Hour {
+		t.Errorf("ListedFor = %v, want 48h", sol.ListedFor(sol.OnboardDate.Add(48*time.Hour)))
+	}
+
+	// A restart within a day reuses the persisted metadata without calling exchangeInfo
+	// 一天内重启时复用持久化的元数据，不调用 exchangeInfo
+	if got := newExecutor().SymbolFiltersFor(ctx, "SOLUSDT"); got.TickSize != 0.01 || got.ContractType != "PERPETUAL" {
+		t.Errorf("Unexpected restored SOLUSDT metadata: %+v", got)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected exchangeInfo to be fetched once, got %d", requests.Load())
+	}
+
+	// Metadata older than a day is refreshed
+	// 超过一天的元数据会被刷新
+	stale, _ := db.GetSymbolMetadata(symbolRegistryExchange)
+	stale["SOLUSDT"].UpdatedAt = time.Now().Add(-25 * time.Hour)
+	if err := db.SaveSymbolMetadata(symbolRegistryExchange, []*storage.SymbolMetadata{stale["SOLUSDT"]}); err != nil {
+		t.Fatalf("SaveSymbolMetadata failed: %v", err)
+	}
+	newExecutor().SymbolFiltersFor(ctx, "SOLUSDT")
+	if requests.Load() != 2 {
+		t.Errorf("Expected stale metadata to be refreshed, got %d fetches", requests.Load())
+	}
+}
@@ -0,0 +1,119 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// sessionIDKey is the context key carrying the trading session that triggered an order
+// sessionIDKey 是携带触发订单的交易会话 ID 的 context 键
+type sessionIDKey struct{}
+
+// WithSessionID returns a context whose orders are attributed to the given trading session
+// WithSessionID 返回一个 context，其下单产生的成交会归属到指定的交易会话
+func WithSessionID(ctx context.Context, sessionID int64) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, sessionID)
+}
+
+// sessionIDFrom returns the trading session carried by ctx (0 if none)
+// sessionIDFrom 返回 ctx 中携带的交易会话 ID（没有则为 0）
+func sessionIDFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(sessionIDKey{}).(int64)
+	return id
+}
+
+// EnableTradeRecording stores every live fill in the trades table
+// EnableTradeRecording 将每笔实盘成交写入 trades 表
+//
+// Paper fills are always recorded in the paper trading database, so this only matters for
+// live trading. Fake fills of test mode are never recorded.
+// 模拟盘成交总是记录在模拟盘数据库中，因此该设置只影响实盘。测试模式的模拟成交不会被记录。
+func (e *BinanceExecutor) EnableTradeRecording(db *storage.Storage) {
+	e.trades = db
+}
+
+// recordOrderFills stores the fills of a live order, aggregated from /fapi/v1/userTrades
+// recordOrderFills 记录实盘订单的成交，数据由 /fapi/v1/userTrades 汇总而来
+//
+// Returns the fee of the fills (0 when nothing was recorded). If the fills cannot be queried, the
+// executed quantity and average price reported by the order are recorded without fee and realized
+// PnL. Failures are only logged: the order has already been executed and must not be reported as failed.
+// 返回成交的手续费（未记录时为 0）。如果无法查询成交明细，则记录订单返回的成交数量和均价（不含手续费和已实现盈亏）。
+// 失败只记录日志：订单已经成交，不能因此被报告为失败。
+func (e *BinanceExecutor) recordOrderFills(ctx context.Context, binanceSymbol string, orderID int64, orderType futures.OrderType, side futures.SideType, quantity, price float64) float64 {
+	if e.trades == nil || e.paper != nil || e.testMode {
+		return 0
+	}
+
+	trade := &storage.TradeRecord{
+		SessionID: sessionIDFrom(ctx),
+		OrderID:   orderID,
+		Symbol:    binanceSymbol,
+		Side:      string(side),
+		Type:      string(orderType),
+		Quantity:  quantity,
+		Price:     price,
+		CreatedAt: time.Now(),
+	}
+
+	fills, err := e.client.NewListAccountTradeService().
+		Symbol(binanceSymbol).
+		OrderID(orderID).
+		Do(ctx)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  查询订单 %d 成交明细失败，按订单数据记录: %v", orderID, err))
+	} else if len(fills) > 0 {
+		var qty, notional float64
+		for _, fill := range fills {
+			fillQty, _ := parseFloat(fill.Quantity)
+			fillPrice, _ := parseFloat(fill.Price)
+			commission, _ := parseFloat(fill.Commission)
+			realized, _ := parseFloat(fill.RealizedPnl)
+
+			qty += fillQty
+			notional += fillQty * fillPrice
+			// Commission is in the commission asset (USDT unless paid with BNB)
+			// 手续费以手续费资产计价（除非使用 BNB 抵扣，否则为 USDT）
+			trade.Fee += commission
+			trade.RealizedPnL += realized
+		}
+		if qty > 0 {
+			trade.Quantity = qty
+			trade.Price = notional / qty
+		}
+		trade.CreatedAt = time.UnixMilli(fills[len(fills)-1].Time)
+	}
+
+	if trade.Quantity <= 0 {
+		return 0
+	}
+	if _, err := e.trades.SaveTrade(trade); err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  保存订单 %d 成交记录失败: %v", orderID, err))
+	}
+	return trade.Fee
+}
+
+// recordTrade stores a simulated fill in the trades table
+// recordTrade 将模拟成交写入 trades 表
+func (p *PaperExecutor) recordTrade(sessionID, orderID int64, symbol, side, orderType string, quantity, price, fee, realized float64) {
+	_, err := p.storage.SaveTrade(&storage.TradeRecord{
+		SessionID:   sessionID,
+		OrderID:     orderID,
+		Symbol:      symbol,
+		Side:        side,
+		Type:        orderType,
+		Quantity:    quantity,
+		Price:       price,
+		Fee:         fee,
+		RealizedPnL: realized,
+		Paper:       true,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		p.logger.Warning(fmt.Sprintf("⚠️  保存模拟成交记录失败: %v", err))
+	}
+}
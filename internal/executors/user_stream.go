@@ -0,0 +1,367 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+const (
+	// listenKeyKeepalive is how often the listen key is extended; Binance expires it after 60 minutes
+	// listenKeyKeepalive 是延长 listenKey 有效期的间隔；币安 60 分钟后使其过期
+	listenKeyKeepalive = 30 * time.Minute
+
+	// userStreamReconnect is the pause before reconnecting a dropped user data stream
+	// userStreamReconnect 是用户数据流断开后重连前的等待时间
+	userStreamReconnect = 5 * time.Second
+
+	// positionSettleDelay is how long to wait for an order to show in the positions
+	// positionSettleDelay 是等待订单反映到持仓的时长
+	positionSettleDelay = 2 * time.Second
+)
+
+// Execution types of ORDER_TRADE_UPDATE ("x") handled by the stream
+// 用户数据流处理的 ORDER_TRADE_UPDATE 执行类型（"x"）
+const (
+	executionTypeTrade      = "TRADE"      // 成交 / Fill
+	executionTypeCalculated = "CALCULATED" // 强平或自动减仓 / Liquidation or ADL
+)
+
+// UserDataStream listens to the Binance futures user data stream for fills, position changes and margin calls
+// UserDataStream 监听币安合约用户数据流，获取成交、持仓变化和追加保证金通知
+//
+// Fills are reported as they happen instead of being polled: a filled stop-loss or take-profit order
+// closes its position in the StopLossManager right away, and liquidations and margin calls are sent to
+// the notifier. Position updates wake up executions waiting for their order to settle, so they no
+// longer sleep a fixed delay. Polling stays in place as the fallback while the stream is down.
+// 成交实时推送而非轮询：止损单或止盈单成交时立即在止损管理器中关闭持仓，强平和追加保证金通知发送到通知渠道。
+// 持仓更新会唤醒等待订单生效的执行流程，不再固定等待。数据流断开期间仍以轮询兜底。
+type UserDataStream struct {
+	executor *BinanceExecutor
+	stopLoss *StopLossManager
+	notifier notify.Notifier
+	logger   *logger.ColorLogger
+
+	mu        sync.Mutex
+	connected bool                 // 数据流是否已连接 / Whether the stream is connected
+	updates   map[string]time.Time // 每个交易对最近一次持仓更新的接收时间 / When each symbol's position last changed
+	changed   chan struct{}        // 收到持仓更新时关闭并替换 / Closed and replaced on every position update
+}
+
+// NewUserDataStream creates a new UserDataStream (live trading only)
+// NewUserDataStream 创建新的用户数据流监听器（仅实盘）
+func NewUserDataStream(executor *BinanceExecutor, stopLoss *StopLossManager, notifier notify.Notifier, log *logger.ColorLogger) *UserDataStream {
+	return &UserDataStream{
+		executor: executor,
+		stopLoss: stopLoss,
+		notifier: notifier,
+		logger:   log,
+		updates:  make(map[string]time.Time),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Connected reports whether the stream is receiving events (nil-safe)
+// Connected 返回数据流是否正在接收事件（nil 安全）
+func (u *UserDataStream) Connected() bool {
+	if u == nil {
+		return false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.connected
+}
+
+// setConnected records the connection state and wakes up waiters when it drops
+// setConnected 记录连接状态，断开时唤醒等待者
+func (u *UserDataStream) setConnected(connected bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.connected = connected
+	if !connected {
+		close(u.changed)
+		u.changed = make(chan struct{})
+	}
+}
+
+// noteAccountUpdate records a position update of a symbol (Binance format) and wakes up waiters
+// noteAccountUpdate 记录交易对（币安格式）的持仓更新并唤醒等待者
+func (u *UserDataStream) noteAccountUpdate(binanceSymbol string, at time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.updates[binanceSymbol] = at
+	close(u.changed)
+	u.changed = make(chan struct{})
+}
+
+// WaitAccountUpdate waits up to timeout for a position update of a symbol (Binance format) received after since
+// WaitAccountUpdate 最多等待 timeout，直到收到 since 之后该交易对（币安格式）的持仓更新
+//
+// Returns false right away when the stream is not connected, and when it disconnects while waiting.
+// 数据流未连接或等待期间断开时立即返回 false。
+func (u *UserDataStream) WaitAccountUpdate(ctx context.Context, binanceSymbol string, since time.Time, timeout time.Duration) bool {
+	if u == nil {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		u.mu.Lock()
+		if !u.connected {
+			u.mu.Unlock()
+			return false
+		}
+		if u.updates[binanceSymbol].After(since) {
+			u.mu.Unlock()
+			return true
+		}
+		changed := u.changed
+		u.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// Run listens to the user data stream until ctx is done, reconnecting when it drops
+// Run 监听用户数据流直到 ctx 结束，断开时自动重连
+func (u *UserDataStream) Run(ctx context.Context) {
+	for {
+		if err := u.serve(ctx); err != nil {
+			u.logger.Warning(fmt.Sprintf("⚠️  用户数据流异常: %v（%s 后重连，期间改为轮询）", err, userStreamReconnect))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(userStreamReconnect):
+		}
+	}
+}
+
+// serve runs one connection of the stream with its own listen key
+// serve 使用独立的 listenKey 运行一次数据流连接
+func (u *UserDataStream) serve(ctx context.Context) error {
+	client := u.executor.client
+	listenKey, err := client.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("获取 listenKey 失败: %w", err)
+	}
+
+	expired := make(chan struct{}, 1)
+	handler := func(event *futures.WsUserDataEvent) {
+		if event.Event == futures.UserDataEventTypeListenKeyExpired {
+			select {
+			case expired <- struct{}{}:
+			default:
+			}
+			return
+		}
+		u.handle(ctx, event)
+	}
+	var streamErr error
+	errHandler := func(err error) {
+		streamErr = err
+	}
+
+	doneC, stopC, err := futures.WsUserDataServe(listenKey, handler, errHandler)
+	if err != nil {
+		return fmt.Errorf("连接用户数据流失败: %w", err)
+	}
+	u.setConnected(true)
+	u.logger.Success("📡 用户数据流已连接：成交、持仓和强平事件实时推送")
+
+	keepalive := time.NewTicker(listenKeyKeepalive)
+	defer keepalive.Stop()
+	defer func() {
+		u.setConnected(false)
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = client.NewCloseUserStreamService().ListenKey(listenKey).Do(closeCtx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(stopC)
+			<-doneC
+			return nil
+		case <-doneC:
+			if streamErr != nil {
+				return streamErr
+			}
+			return fmt.Errorf("连接已断开")
+		case <-expired:
+			close(stopC)
+			<-doneC
+			return fmt.Errorf("listenKey 已过期")
+		case <-keepalive.C:
+			if err := client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx); err != nil {
+				u.logger.Warning(fmt.Sprintf("⚠️  延长 listenKey 有效期失败: %v", err))
+			}
+		}
+	}
+}
+
+// handle dispatches one user data event
+// handle 分发一个用户数据事件
+func (u *UserDataStream) handle(ctx context.Context, event *futures.WsUserDataEvent) {
+	switch event.Event {
+	case futures.UserDataEventTypeAccountUpdate:
+		now := time.Now()
+		for _, p := range event.AccountUpdate.Positions {
+			u.noteAccountUpdate(p.Symbol, now)
+		}
+	case futures.UserDataEventTypeOrderTradeUpdate:
+		u.handleOrderUpdate(ctx, event.OrderTradeUpdate)
+	case futures.UserDataEventTypeMarginCall:
+		u.handleMarginCall(ctx, event)
+	}
+}
+
+// handleOrderUpdate records fills and settles the positions whose protective orders filled
+// handleOrderUpdate 记录成交，并处理保护单已成交的持仓
+func (u *UserDataStream) handleOrderUpdate(ctx context.Context, update futures.WsOrderTradeUpdate) {
+	avgPrice, _ := parseFloat(update.AveragePrice)
+	filledQty, _ := parseFloat(update.AccumulatedFilledQty)
+
+	// Orders placed with our client order ids (see sendOrder) get their outcome right away
+	// 使用本程序客户端订单 ID 下达的订单（见 sendOrder）立即记录结果
+	if strings.HasPrefix(update.ClientOrderID, clientOrderPrefix+"-") && u.executor.trades != nil {
+		if err := u.executor.trades.UpdateClientOrder(update.ClientOrderID, update.ID, string(update.Status), avgPrice, filledQty); err != nil {
+			u.logger.Warning(fmt.Sprintf("⚠️  更新订单 %s 状态失败: %v", update.ClientOrderID, err))
+		}
+	}
+
+	if isLiquidationOrder(update.ClientOrderID, string(update.ExecutionType)) {
+		u.handleLiquidation(ctx, update, avgPrice, filledQty)
+		return
+	}
+	if update.ExecutionType != executionTypeTrade {
+		return
+	}
+
+	lastPrice, _ := parseFloat(update.LastFilledPrice)
+	lastQty, _ := parseFloat(update.LastFilledQty)
+	u.logger.Info(fmt.Sprintf("📨 成交回报 %s %s %.4f @ %.4f（订单 %d，%s，累计 %.4f）",
+		update.Symbol, update.Side, lastQty, lastPrice, update.ID, update.Status, filledQty))
+
+	if update.Status != futures.OrderStatusTypeFilled || u.stopLoss == nil {
+		return
+	}
+
+	pos, kind := u.stopLoss.PositionByOrder(update.Symbol, update.ID)
+	switch kind {
+	case protectiveOrderStop:
+		u.logger.Warning(fmt.Sprintf("🔔【%s】止损单成交 @ %.4f（订单 %d）", pos.Symbol, avgPrice, update.ID))
+		u.notify(ctx, notify.Message{
+			Title: fmt.Sprintf("%s 止损触发", pos.Symbol),
+			Text:  fmt.Sprintf("%s 仓位止损单已成交：%.4f @ %.4f，入场价 %.4f", pos.Side, filledQty, avgPrice, pos.EntryPrice),
+			Level: notify.LevelWarning,
+		})
+		if err := u.stopLoss.CheckStopLossOrderStatus(ctx, pos.Symbol); err != nil {
+			u.logger.Warning(fmt.Sprintf("⚠️  处理 %s 止损成交失败: %v", pos.Symbol, err))
+		}
+	case protectiveOrderTakeProfit:
+		u.notify(ctx, notify.Message{
+			Title: fmt.Sprintf("%s 止盈成交", pos.Symbol),
+			Text:  fmt.Sprintf("%s 仓位止盈单已成交：%.4f @ %.4f，入场价 %.4f", pos.Side, filledQty, avgPrice, pos.EntryPrice),
+			Level: notify.LevelInfo,
+		})
+		if err := u.stopLoss.CheckTakeProfitOrderStatus(ctx, pos.Symbol); err != nil {
+			u.logger.Warning(fmt.Sprintf("⚠️  处理 %s 止盈成交失败: %v", pos.Symbol, err))
+		}
+	}
+}
+
+// handleLiquidation alerts on a liquidation or auto-deleverage and reconciles the affected position
+// handleLiquidation 对强平或自动减仓发出告警，并对账受影响的持仓
+func (u *UserDataStream) handleLiquidation(ctx context.Context, update futures.WsOrderTradeUpdate, avgPrice, filledQty float64) {
+	if update.Status != futures.OrderStatusTypeFilled {
+		return
+	}
+	u.logger.Error(fmt.Sprintf("🚨 %s 仓位被强平/自动减仓: %s %.4f @ %.4f，已实现盈亏 %s",
+		update.Symbol, update.Side, filledQty, avgPrice, update.RealizedPnL))
+	u.notify(ctx, notify.Message{
+		Title: fmt.Sprintf("%s 强平", update.Symbol),
+		Text: fmt.Sprintf("%s 仓位被交易所强平/自动减仓：%s %.4f @ %.4f，已实现盈亏 %s",
+			update.PositionSide, update.Side, filledQty, avgPrice, update.RealizedPnL),
+		Level: notify.LevelError,
+	})
+	if u.stopLoss != nil && u.stopLoss.GetPosition(update.Symbol) != nil {
+		if err := u.stopLoss.ReconcilePosition(ctx, update.Symbol); err != nil {
+			u.logger.Warning(fmt.Sprintf("⚠️  对账 %s 持仓失败: %v", update.Symbol, err))
+		}
+	}
+}
+
+// handleMarginCall alerts that positions are close to liquidation
+// handleMarginCall 对接近强平的持仓发出告警
+func (u *UserDataStream) handleMarginCall(ctx context.Context, event *futures.WsUserDataEvent) {
+	var lines []string
+	for _, p := range event.MarginCallPositions {
+		lines = append(lines, fmt.Sprintf("%s %s 数量 %s，标记价 %s，维持保证金 %s，未实现盈亏 %s",
+			p.Symbol, p.Side, p.Amount, p.MarkPrice, p.MaintenanceMarginRequired, p.UnrealizedPnL))
+	}
+	text := strings.Join(lines, "\n")
+	u.logger.Warning(fmt.Sprintf("🚨 追加保证金通知（全仓钱包余额 %s）:\n%s", event.CrossWalletBalance, text))
+	u.notify(ctx, notify.Message{
+		Title: "追加保证金通知",
+		Text:  fmt.Sprintf("以下持仓接近强平，请追加保证金或减仓（全仓钱包余额 %s）:\n%s", event.CrossWalletBalance, text),
+		Level: notify.LevelError,
+	})
+}
+
+// notify sends a message, logging delivery failures
+// notify 发送通知，发送失败时记录日志
+func (u *UserDataStream) notify(ctx context.Context, msg notify.Message) {
+	if u.notifier == nil {
+		return
+	}
+	if err := u.notifier.Notify(ctx, msg); err != nil {
+		u.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// isLiquidationOrder reports whether an order update comes from a liquidation or auto-deleverage
+// isLiquidationOrder 判断订单更新是否来自强平或自动减仓
+//
+// Binance sends those orders with the client order id "autoclose-…" (liquidation) or "adl_autoclose"
+// (ADL) and the execution type CALCULATED.
+// 币安对这类订单使用客户端订单 ID "autoclose-…"（强平）或 "adl_autoclose"（自动减仓），执行类型为 CALCULATED。
+func isLiquidationOrder(clientOrderID, executionType string) bool {
+	return executionType == executionTypeCalculated ||
+		strings.HasPrefix(clientOrderID, "autoclose-") ||
+		strings.HasPrefix(clientOrderID, "adl_autoclose")
+}
+
+// SetUserDataStream lets executions wait for the stream's position updates instead of a fixed delay
+// SetUserDataStream 使执行流程等待数据流的持仓更新，而不是固定等待
+func (e *BinanceExecutor) SetUserDataStream(u *UserDataStream) {
+	e.userStream = u
+}
+
+// awaitPositionUpdate waits until an order sent at since shows in the positions of a symbol
+// awaitPositionUpdate 等待 since 时发送的订单反映到交易对的持仓中
+//
+// With the user data stream connected it returns as soon as Binance pushes the position update
+// (at most positionSettleDelay); otherwise it sleeps positionSettleDelay.
+// 用户数据流已连接时，币安推送持仓更新后立即返回（最多 positionSettleDelay）；否则等待 positionSettleDelay。
+func (e *BinanceExecutor) awaitPositionUpdate(ctx context.Context, symbol string, since time.Time) {
+	if e.userStream.Connected() {
+		e.userStream.WaitAccountUpdate(ctx, e.config.GetBinanceSymbolFor(symbol), since, positionSettleDelay)
+		return
+	}
+	time.Sleep(positionSettleDelay)
+}
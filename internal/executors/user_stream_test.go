@@ -0,0 +1,89 @@
+package executors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestWaitAccountUpdate tests that waiters wake up on a position update and give up when disconnected
+// TestWaitAccountUpdate 测试等待者在持仓更新时被唤醒，断开时放弃等待
+func TestWaitAccountUpdate(t *testing.T) {
+	ctx := context.Background()
+	u := NewUserDataStream(nil, nil, nil, logger.NewColorLogger(false))
+
+	if u.WaitAccountUpdate(ctx, "BTCUSDT", time.Now(), time.Second) {
+		t.Error("Expected no wait while disconnected")
+	}
+
+	u.setConnected(true)
+	sentAt := time.Now()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		u.noteAccountUpdate("ETHUSDT", time.Now())
+		u.noteAccountUpdate("BTCUSDT", time.Now())
+	}()
+	if !u.WaitAccountUpdate(ctx, "BTCUSDT", sentAt, time.Second) {
+		t.Error("Expected the BTCUSDT update to end the wait")
+	}
+
+	// An update received before the order doesn't count
+	// 下单前收到的更新不算
+	if u.WaitAccountUpdate(ctx, "BTCUSDT", time.Now(), 20*time.Millisecond) {
+		t.Error("Expected a timeout without a newer update")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		u.setConnected(false)
+	}()
+	start := time.Now()
+	if u.WaitAccountUpdate(ctx, "BTCUSDT", time.Now(), time.Second) || time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected a disconnect to end the wait right away")
+	}
+}
+
+// TestIsLiquidationOrder tests that liquidation and ADL orders are recognized
+// TestIsLiquidationOrder 测试识别强平和自动减仓订单
+func TestIsLiquidationOrder(t *testing.T) {
+	tests := []struct {
+		clientOrderID string
+		executionType string
+		want          bool
+	}{
+		{"autoclose-1760000000000", "TRADE", true},
+		{"adl_autoclose", "TRADE", true},
+		{"web_abc123", "CALCULATED", true},
+		{"ctb-1760000000-BTCUSDT-open", "TRADE", false},
+		{"web_abc123", "NEW", false},
+	}
+	for _, tt := range tests {
+		if got := isLiquidationOrder(tt.clientOrderID, tt.executionType); got != tt.want {
+			t.Errorf("isLiquidationOrder(%q, %q) = %v, want %v", tt.clientOrderID, tt.executionType, got, tt.want)
+		}
+	}
+}
+
+// TestPositionByOrder tests finding the position that owns a stop-loss or take-profit order
+// TestPositionByOrder 测试查找拥有止损单或止盈单的持仓
+func TestPositionByOrder(t *testing.T) {
+	sm := NewStopLossManager(&config.Config{}, nil, logger.NewColorLogger(false), nil)
+	sm.RegisterPosition(&Position{ID: "long-1", Symbol: "BTC/USDT", Side: "long", EntryPrice: 100, Quantity: 1, StopLossOrderID: "11", TakeProfitOrderID: "12"})
+	sm.RegisterPosition(&Position{ID: "short-1", Symbol: "BTCUSDT", Side: "short", EntryPrice: 101, Quantity: 1, StopLossOrderID: "21"})
+
+	if pos, kind := sm.PositionByOrder("BTCUSDT", 21); pos == nil || pos.ID != "short-1" || kind != protectiveOrderStop {
+		t.Errorf("Expected the short's stop-loss, got %+v, %q", pos, kind)
+	}
+	if pos, kind := sm.PositionByOrder("BTCUSDT", 12); pos == nil || pos.ID != "long-1" || kind != protectiveOrderTakeProfit {
+		t.Errorf("Expected the long's take-profit, got %+v, %q", pos, kind)
+	}
+	if pos, kind := sm.PositionByOrder("BTCUSDT", 99); pos != nil || kind != "" {
+		t.Errorf("Expected no position for an unknown order, got %+v, %q", pos, kind)
+	}
+	if pos, _ := sm.PositionByOrder("ETHUSDT", 11); pos != nil {
+		t.Errorf("Expected no position on another symbol, got %+v", pos)
+	}
+}
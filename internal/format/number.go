@@ -0,0 +1,85 @@
+package format
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Number formats a value with thousands separators and a fixed number of decimals
+// Number 使用千位分隔符和固定小数位格式化数值
+func Number(value float64, decimals int) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "-"
+	}
+
+	s := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot:]
+	}
+
+	var b strings.Builder
+	if value < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	b.WriteString(fracPart)
+	return b.String()
+}
+
+// Decimals returns how many decimals a price or quantity needs to stay readable at its magnitude
+// Decimals 返回价格或数量在其数量级下保持可读所需的小数位数
+//
+// Large values need fewer decimals (BTC 97,123.45) while small ones need more (PEPE 0.00001234).
+// 大数值需要更少的小数位（BTC 97,123.45），小数值需要更多（PEPE 0.00001234）。
+func Decimals(value float64) int {
+	abs := math.Abs(value)
+	switch {
+	case abs == 0 || abs >= 1000:
+		return 2
+	case abs >= 1:
+		return 4
+	case abs >= 0.01:
+		return 6
+	default:
+		return 8
+	}
+}
+
+// Adaptive formats a value with thousands separators and magnitude-based decimals
+// Adaptive 使用千位分隔符和按数量级确定的小数位格式化数值
+func Adaptive(value float64) string {
+	return Number(value, Decimals(value))
+}
+
+// Signed formats a value with an explicit +/- sign, e.g. for PnL
+// Signed 格式化带有显式正负号的数值，例如盈亏
+func Signed(value float64, decimals int) string {
+	formatted := Number(value, decimals)
+	if value > 0 && formatted != Number(0, decimals) {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// Compact formats large values with a B/M/K suffix, e.g. 1.235B
+// Compact 使用 B/M/K 后缀格式化大数值，例如 1.235B
+func Compact(value float64, decimals int) string {
+	abs := math.Abs(value)
+	switch {
+	case abs >= 1e9:
+		return Number(value/1e9, decimals) + "B"
+	case abs >= 1e6:
+		return Number(value/1e6, decimals) + "M"
+	case abs >= 1e3:
+		return Number(value/1e3, decimals) + "K"
+	default:
+		return Number(value, decimals)
+	}
+}
@@ -0,0 +1,68 @@
+package format
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		expected string
+	}{
+		{0, 2, "0.00"},
+		{999.999, 2, "1,000.00"},
+		{1234567.891, 2, "1,234,567.89"},
+		{-1234.5, 1, "-1,234.5"},
+		{-0.001, 2, "0.00"},
+		{123456, 0, "123,456"},
+		{math.NaN(), 2, "-"},
+	}
+
+	for _, tt := range tests {
+		if got := Number(tt.value, tt.decimals); got != tt.expected {
+			t.Errorf("Number(%v, %d) = %q, want %q", tt.value, tt.decimals, got, tt.expected)
+		}
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{97123.456, "97,123.46"},
+		{2.34567, "2.3457"},
+		{0.123456789, "0.123457"},
+		{0.0000123456, "0.00001235"},
+		{-3456.7, "-3,456.70"},
+	}
+
+	for _, tt := range tests {
+		if got := Adaptive(tt.value); got != tt.expected {
+			t.Errorf("Adaptive(%v) = %q, want %q", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestSignedAndCompact(t *testing.T) {
+	if got := Signed(1234.5, 2); got != "+1,234.50" {
+		t.Errorf("Signed() = %q", got)
+	}
+	if got := Signed(-12.345, 2); got != "-12.35" {
+		t.Errorf("Signed() = %q", got)
+	}
+	if got := Signed(0.001, 2); got != "0.00" {
+		t.Errorf("Signed() rounding to zero = %q", got)
+	}
+	if got := Compact(1234567890, 3); got != "1.235B" {
+		t.Errorf("Compact() = %q", got)
+	}
+	if got := Compact(-2500000, 3); got != "-2.500M" {
+		t.Errorf("Compact() = %q", got)
+	}
+	if got := Compact(999, 3); got != "999.000" {
+		t.Errorf("Compact() = %q", got)
+	}
+}
@@ -0,0 +1,205 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+const (
+	// heartbeatStaleAfter is how long the trading loop, which checks the schedule every minute, may stay silent
+	// heartbeatStaleAfter 是交易循环（每分钟检查一次调度）允许的最长静默时间
+	heartbeatStaleAfter = 3 * time.Minute
+
+	// lastRunGrace is added to two analysis periods before a missing successful run counts as stale
+	// lastRunGrace 是判断成功运行过期时在两个分析周期之外额外留出的时间
+	lastRunGrace = 15 * time.Minute
+)
+
+// run_state keys of the probes
+// 探测使用的 run_state 键
+const (
+	probeStateKey   = "health_probe"
+	lastRunStateKey = "last_successful_run"
+)
+
+// SuccessfulRun is the last analysis batch that finished without error
+// SuccessfulRun 表示最近一次无错误完成的分析批次
+type SuccessfulRun struct {
+	BatchID    string    `json:"batch_id"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// SQLite checks that the database accepts a write and returns it
+// SQLite 检查数据库能否写入并读回
+func SQLite(db *storage.Storage) Check {
+	return Check{
+		Name: "sqlite",
+		Run: func(ctx context.Context) Dependency {
+			value := time.Now().UTC().Format(time.RFC3339Nano)
+			if err := db.SetRunState(probeStateKey, value); err != nil {
+				return Dependency{Status: StatusDown, Message: fmt.Sprintf("写入失败: %v", err)}
+			}
+			got, err := db.GetRunState(probeStateKey)
+			if err != nil {
+				return Dependency{Status: StatusDown, Message: fmt.Sprintf("读取失败: %v", err)}
+			}
+			if got != value {
+				return Dependency{Status: StatusDown, Message: "读回的值与写入的不一致"}
+			}
+			return Dependency{Status: StatusOK}
+		},
+	}
+}
+
+// Binance checks that the Binance REST API answers a ping and that the local clock is within maxDrift
+// of its server time
+// Binance 检查币安 REST API 能否响应 ping，以及本地时钟与其服务器时间的偏差是否在 maxDrift 以内
+//
+// serverTimeDrift returns the server time minus the local time. Signed requests are corrected for the
+// drift, so it only degrades the status: the system clock still needs fixing.
+// serverTimeDrift 返回服务器时间减去本地时间。签名请求会按偏差校正，因此偏差只会使状态降为 degraded：系统时钟仍需修复。
+func Binance(maxDrift time.Duration, serverTimeDrift func(ctx context.Context) (time.Duration, error)) Check {
+	return Check{
+		Name: "binance",
+		Run: func(ctx context.Context) Dependency {
+			drift, err := serverTimeDrift(ctx)
+			if err != nil {
+				return Dependency{Status: StatusDown, Message: err.Error()}
+			}
+			dep := Dependency{Status: StatusOK, Details: map[string]any{"server_time_drift_ms": drift.Milliseconds()}}
+			if drift > maxDrift || drift < -maxDrift {
+				dep.Status = StatusDegraded
+				dep.Message = fmt.Sprintf("本地时钟与币安服务器相差 %s，超过 %s，请检查 NTP 同步", drift.Round(time.Millisecond), maxDrift)
+			}
+			return dep
+		},
+	}
+}
+
+// LLM checks that the OpenAI-compatible endpoint answers GET /models with the configured key
+// LLM 检查 OpenAI 兼容端点能否使用配置的 Key 响应 GET /models
+//
+// Nothing is generated, so the probe costs no tokens.
+// 不生成任何内容，因此探测不消耗 token。
+func LLM(client *http.Client, baseURL, apiKey string) Check {
+	return Check{
+		Name: "llm",
+		Run: func(ctx context.Context) Dependency {
+			if baseURL == "" {
+				return Dependency{Status: StatusSkipped, Message: "未配置 LLM_BACKEND_URL"}
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+			if err != nil {
+				return Dependency{Status: StatusDown, Message: err.Error()}
+			}
+			if apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+apiKey)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return Dependency{Status: StatusDown, Message: err.Error()}
+			}
+			resp.Body.Close()
+
+			dep := Dependency{Status: StatusOK, Details: map[string]any{"status_code": resp.StatusCode}}
+			switch {
+			case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+				dep.Status = StatusDown
+				dep.Message = "认证失败，请检查 OPENAI_API_KEY"
+			case resp.StatusCode >= 500:
+				dep.Status = StatusDown
+				dep.Message = fmt.Sprintf("服务端错误 %d", resp.StatusCode)
+			}
+			// Other 4xx (e.g. providers without /models) still prove the endpoint is reachable
+			// 其他 4xx（例如不提供 /models 的服务商）同样说明端点可达
+			return dep
+		},
+	}
+}
+
+// Scheduler checks that the trading loop is alive, from the last time it checked the schedule
+// Scheduler 根据交易循环最近一次检查调度的时间判断其是否存活
+func Scheduler(heartbeat, nextRun func() time.Time) Check {
+	return Check{
+		Name: "scheduler",
+		Run: func(ctx context.Context) Dependency {
+			last := heartbeat()
+			details := map[string]any{"next_run": nextRun()}
+			if last.IsZero() {
+				return Dependency{Status: StatusDown, Message: "交易循环尚未启动", Details: details}
+			}
+			details["last_heartbeat"] = last
+			if since := time.Since(last); since > heartbeatStaleAfter {
+				return Dependency{
+					Status:  StatusDown,
+					Message: fmt.Sprintf("交易循环已 %s 未检查调度", since.Round(time.Second)),
+					Details: details,
+				}
+			}
+			return Dependency{Status: StatusOK, Details: details}
+		},
+	}
+}
+
+// LastRun reports the last successful analysis batch; it is degraded when none finished in two
+// analysis periods (plus a grace period), counted from startedAt at the latest
+// LastRun 返回最近一次成功的分析批次；两个分析周期（加宽限时间）内没有成功完成的批次时为 degraded，
+// 最早从 startedAt 开始计算
+func LastRun(db *storage.Storage, startedAt time.Time, period func() time.Duration) Check {
+	return Check{
+		Name: "last_run",
+		Run: func(ctx context.Context) Dependency {
+			last, err := LastSuccessfulRun(db)
+			if err != nil {
+				return Dependency{Status: StatusDegraded, Message: err.Error()}
+			}
+
+			dep := Dependency{Status: StatusOK, Details: map[string]any{}}
+			since := startedAt
+			if last != nil {
+				dep.Details["batch_id"] = last.BatchID
+				dep.Details["finished_at"] = last.FinishedAt
+				if last.FinishedAt.After(since) {
+					since = last.FinishedAt
+				}
+			} else {
+				dep.Message = "尚无成功完成的分析批次"
+			}
+			if limit := 2*period() + lastRunGrace; time.Since(since) > limit {
+				dep.Status = StatusDegraded
+				dep.Message = fmt.Sprintf("已超过 %s 没有成功完成的分析批次", limit)
+			}
+			return dep
+		},
+	}
+}
+
+// RecordSuccessfulRun stores the batch that just finished without error
+// RecordSuccessfulRun 保存刚刚无错误完成的批次
+func RecordSuccessfulRun(db *storage.Storage, batchID string, finishedAt time.Time) error {
+	raw, err := json.Marshal(SuccessfulRun{BatchID: batchID, FinishedAt: finishedAt})
+	if err != nil {
+		return err
+	}
+	return db.SetRunState(lastRunStateKey, string(raw))
+}
+
+// LastSuccessfulRun returns the last batch recorded by RecordSuccessfulRun (nil if none)
+// LastSuccessfulRun 返回 RecordSuccessfulRun 记录的最近一个批次（没有则返回 nil）
+func LastSuccessfulRun(db *storage.Storage) (*SuccessfulRun, error) {
+	raw, err := db.GetRunState(lastRunStateKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	var last SuccessfulRun
+	if err := json.Unmarshal([]byte(raw), &last); err != nil {
+		return nil, fmt.Errorf("failed to decode last successful run: %w", err)
+	}
+	return &last, nil
+}
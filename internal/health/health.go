@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds each check so one unreachable dependency can't stall the probe
+// checkTimeout 限制每项检查的耗时，避免某个不可达的依赖拖慢探测
+const checkTimeout = 5 * time.Second
+
+// Status is the state of a dependency or of the whole service
+// Status 表示单个依赖或整个服务的状态
+type Status string
+
+const (
+	StatusOK       Status = "ok"       // 正常 / Healthy
+	StatusDegraded Status = "degraded" // 可用但需要关注 / Usable but needs attention
+	StatusDown     Status = "down"     // 不可用，服务未就绪 / Unavailable, the service is not ready
+	StatusSkipped  Status = "skipped"  // 当前配置下不适用 / Not applicable with the current configuration
+)
+
+// Dependency is the outcome of one dependency check
+// Dependency 表示一项依赖检查的结果
+type Dependency struct {
+	Status    Status         `json:"status"`
+	Message   string         `json:"message,omitempty"`
+	LatencyMs int64          `json:"latency_ms"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Check is one readiness check of a dependency
+// Check 表示一项依赖的就绪检查
+type Check struct {
+	Name string                               // 依赖名称，作为 JSON 键 / Dependency name, used as the JSON key
+	Run  func(ctx context.Context) Dependency // 执行检查，耗时由 Run 填写 / Runs the check; the latency is filled in by Run
+}
+
+// Report is the readiness of the service and of each dependency
+// Report 表示服务整体及各依赖的就绪状态
+type Report struct {
+	Status       Status                `json:"status"`
+	Time         time.Time             `json:"time"`
+	Dependencies map[string]Dependency `json:"dependencies"`
+}
+
+// Run executes the checks concurrently, each with its own timeout
+// Run 并发执行各项检查，每项检查有独立的超时时间
+//
+// The service is down when any dependency is down and degraded when any is degraded.
+// 任一依赖不可用时服务为 down，任一依赖需要关注时为 degraded。
+func Run(ctx context.Context, checks []Check) *Report {
+	report := &Report{Status: StatusOK, Time: time.Now(), Dependencies: make(map[string]Dependency, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			dep := check.Run(checkCtx)
+			dep.LatencyMs = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			report.Dependencies[check.Name] = dep
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		switch {
+		case dep.Status == StatusDown:
+			report.Status = StatusDown
+		case dep.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// Ready reports whether no dependency is down
+// Ready 返回是否没有不可用的依赖
+func (r *Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Summary returns a generic message for the status, safe to show without authentication
+// Summary 返回与状态对应的通用说明，可在无需认证的接口中返回
+func (r *Report) Summary() string {
+	switch r.Status {
+	case StatusOK:
+		return "ready"
+	case StatusDegraded:
+		return "ready, some dependencies need attention"
+	default:
+		return "not ready"
+	}
+}
+
+// Cache reuses the last report for ttl, so frequent probes don't hit the database, Binance and the
+// LLM endpoint on every request
+// Cache 在 ttl 内复用上一次的报告，避免频繁探测时每次请求都访问数据库、币安和 LLM 端点
+//
+// Concurrent callers wait for the run in progress instead of starting their own.
+// 并发调用会等待正在执行的检查，而不是各自发起检查。
+type Cache struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewCache creates a report cache
+// NewCache 创建报告缓存
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl}
+}
+
+// Run returns the cached report, running the checks when it is older than ttl
+// Run 返回缓存的报告，超过 ttl 时重新执行检查
+func (c *Cache) Run(ctx context.Context, checks func() []Check) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report == nil || time.Since(c.report.Time) >= c.ttl {
+		c.report = Run(ctx, checks())
+	}
+	return c.report
+}
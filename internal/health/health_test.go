@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestRunAggregatesStatus tests that the worst dependency decides the service status
+// TestRunAggregatesStatus 测试服务状态由最差的依赖决定
+func TestRunAggregatesStatus(t *testing.T) {
+	check := func(name string, status Status) Check {
+		return Check{Name: name, Run: func(ctx context.Context) Dependency { return Dependency{Status: status} }}
+	}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"all ok", []Check{check("a", StatusOK), check("b", StatusSkipped)}, StatusOK},
+		{"degraded", []Check{check("a", StatusOK), check("b", StatusDegraded)}, StatusDegraded},
+		{"down wins", []Check{check("a", StatusDown), check("b", StatusDegraded)}, StatusDown},
+	}
+	for _, tt := range tests {
+		report := Run(context.Background(), tt.checks)
+		if report.Status != tt.want || len(report.Dependencies) != len(tt.checks) {
+			t.Errorf("%s: status = %s with %d dependencies, want %s", tt.name, report.Status, len(report.Dependencies), tt.want)
+		}
+		if report.Ready() != (tt.want != StatusDown) {
+			t.Errorf("%s: Ready() = %v", tt.name, report.Ready())
+		}
+	}
+}
+
+// TestCacheReusesReport tests that checks run again only once the cached report expires
+// TestCacheReusesReport 测试缓存的报告过期后才重新执行检查
+func TestCacheReusesReport(t *testing.T) {
+	runs := 0
+	checks := func() []Check {
+		runs++
+		return []Check{{Name: "a", Run: func(ctx context.Context) Dependency { return Dependency{Status: StatusDegraded} }}}
+	}
+
+	cache := NewCache(50 * time.Millisecond)
+	first := cache.Run(context.Background(), checks)
+	if second := cache.Run(context.Background(), checks); second != first || runs != 1 {
+		t.Fatalf("Expected the cached report within ttl, got %d runs", runs)
+	}
+	if first.Summary() != "ready, some dependencies need attention" {
+		t.Errorf("Unexpected summary: %q", first.Summary())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if cache.Run(context.Background(), checks); runs != 2 {
+		t.Errorf("Expected the checks to run again after ttl, got %d runs", runs)
+	}
+}
+
+// TestDependencyChecks tests the SQLite, Binance, LLM, scheduler and last run checks
+// TestDependencyChecks 测试 SQLite、币安、LLM、调度器和最近运行检查
+func TestDependencyChecks(t *testing.T) {
+	ctx := context.Background()
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "health.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	if dep := SQLite(db).Run(ctx); dep.Status != StatusOK {
+		t.Errorf("Expected a writable database, got %+v", dep)
+	}
+
+	drift := func(d time.Duration, err error) func(ctx context.Context) (time.Duration, error) {
+		return func(ctx context.Context) (time.Duration, error) { return d, err }
+	}
+	if dep := Binance(time.Second, drift(200*time.Millisecond, nil)).Run(ctx); dep.Status != StatusOK || dep.Details["server_time_drift_ms"] != int64(200) {
+		t.Errorf("Expected a small drift to be ok, got %+v", dep)
+	}
+	if dep := Binance(time.Second, drift(-3*time.Second, nil)).Run(ctx); dep.Status != StatusDegraded {
+		t.Errorf("Expected a large drift to degrade, got %+v", dep)
+	}
+	if dep := Binance(time.Second, drift(0, errors.New("timeout"))).Run(ctx); dep.Status != StatusDown {
+		t.Errorf("Expected an unreachable API to be down, got %+v", dep)
+	}
+
+	var gotAuth string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer llm.Close()
+	if dep := LLM(llm.Client(), llm.URL+"/v1/", "good").Run(ctx); dep.Status != StatusOK || gotAuth != "Bearer good" {
+		t.Errorf("Expected the endpoint to be ok, got %+v (auth %q)", dep, gotAuth)
+	}
+	if dep := LLM(llm.Client(), llm.URL, "bad").Run(ctx); dep.Status != StatusDown {
+		t.Errorf("Expected a rejected key to be down, got %+v", dep)
+	}
+	if dep := LLM(llm.Client(), "", "good").Run(ctx); dep.Status != StatusSkipped {
+		t.Errorf("Expected no backend URL to skip, got %+v", dep)
+	}
+
+	now := time.Now()
+	at := func(t time.Time) func() time.Time { return func() time.Time { return t } }
+	if dep := Scheduler(at(now.Add(-time.Minute)), at(now)).Run(ctx); dep.Status != StatusOK {
+		t.Errorf("Expected a recent heartbeat to be ok, got %+v", dep)
+	}
+	if dep := Scheduler(at(now.Add(-10*time.Minute)), at(now)).Run(ctx); dep.Status != StatusDown {
+		t.Errorf("Expected a stale heartbeat to be down, got %+v", dep)
+	}
+	if dep := Scheduler(at(time.Time{}), at(now)).Run(ctx); dep.Status != StatusDown {
+		t.Errorf("Expected no heartbeat to be down, got %+v", dep)
+	}
+
+	hour := func() time.Duration { return time.Hour }
+	if dep := LastRun(db, now, hour).Run(ctx); dep.Status != StatusOK {
+		t.Errorf("Expected no run yet right after startup to be ok, got %+v", dep)
+	}
+	if dep := LastRun(db, now.Add(-5*time.Hour), hour).Run(ctx); dep.Status != StatusDegraded {
+		t.Errorf("Expected no run for hours to degrade, got %+v", dep)
+	}
+	if err := RecordSuccessfulRun(db, "batch-1", now.Add(-30*time.Minute)); err != nil {
+		t.Fatalf("RecordSuccessfulRun failed: %v", err)
+	}
+	dep := LastRun(db, now.Add(-5*time.Hour), hour).Run(ctx)
+	if dep.Status != StatusOK || dep.Details["batch_id"] != "batch-1" {
+		t.Errorf("Expected the recorded batch to be ok, got %+v", dep)
+	}
+}
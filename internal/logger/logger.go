@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -43,35 +44,136 @@ const (
 	BgWhite   = "\033[47m"
 )
 
-// ColorLogger provides colored terminal output
+// Log output formats (LOG_FORMAT)
+// 日志输出格式（LOG_FORMAT）
+const (
+	// FormatConsole writes colored text for interactive use
+	// FormatConsole 输出彩色文本，适合交互使用
+	FormatConsole = "console"
+
+	// FormatJSON writes one JSON object per line for log shippers (Loki, ELK)
+	// FormatJSON 每行输出一个 JSON 对象，便于日志采集（Loki、ELK）
+	FormatJSON = "json"
+)
+
+// ColorLogger provides colored terminal output, or JSON lines in JSON mode
+// ColorLogger 提供彩色终端输出，JSON 模式下输出 JSON 行
 type ColorLogger struct {
 	logger zerolog.Logger
 	writer io.Writer
+	json   bool      // JSON 模式：不输出彩色文本 / JSON mode: no colored text
+	sink   *sinkHook // 与派生日志器共享 / Shared with derived loggers
+}
+
+// Sink receives every log entry that passes the level filter, e.g. to stream it to the web UI
+// Sink 接收每条通过级别过滤的日志，例如推送到 Web 界面
+type Sink func(level, message string)
+
+// sinkHook forwards zerolog entries to the current sink
+// sinkHook 将 zerolog 日志转发给当前的 Sink
+type sinkHook struct {
+	mu   sync.RWMutex
+	sink Sink
+}
+
+// Run implements zerolog.Hook
+func (h *sinkHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	h.mu.RLock()
+	sink := h.sink
+	h.mu.RUnlock()
+	if sink != nil && msg != "" {
+		sink(level.String(), msg)
+	}
 }
 
 // NewColorLogger creates a new ColorLogger instance
 func NewColorLogger(debug bool) *ColorLogger {
-	output := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
-		NoColor:    false,
-	}
+	return NewLogger(debug, FormatConsole)
+}
+
+// NewLogger creates a logger writing to stdout in the given format (unknown formats use console)
+// NewLogger 创建以指定格式输出到 stdout 的日志器（未知格式使用 console）
+func NewLogger(debug bool, format string) *ColorLogger {
+	return newLogger(os.Stdout, debug, format)
+}
 
+// newLogger creates a logger writing to w
+// newLogger 创建输出到 w 的日志器
+func newLogger(w io.Writer, debug bool, format string) *ColorLogger {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
-	logger := zerolog.New(output).With().Timestamp().Logger()
+	sink := &sinkHook{}
+	if strings.EqualFold(format, FormatJSON) {
+		return &ColorLogger{
+			logger: zerolog.New(w).With().Timestamp().Logger().Hook(sink),
+			writer: w,
+			json:   true,
+			sink:   sink,
+		}
+	}
+
+	output := zerolog.ConsoleWriter{
+		Out:        w,
+		TimeFormat: time.RFC3339,
+		NoColor:    false,
+	}
+
+	logger := zerolog.New(output).With().Timestamp().Logger().Hook(sink)
 
 	return &ColorLogger{
 		logger: logger,
-		writer: os.Stdout,
+		writer: w,
+		sink:   sink,
 	}
 }
 
+// WithComponent returns a logger tagging every entry with the component name
+// WithComponent 返回一个为每条日志附加组件名的日志器
+func (l *ColorLogger) WithComponent(component string) *ColorLogger {
+	return l.with(l.logger.With().Str("component", component).Logger())
+}
+
+// WithSymbol returns a logger tagging every entry with the trading symbol
+// WithSymbol 返回一个为每条日志附加交易对的日志器
+func (l *ColorLogger) WithSymbol(symbol string) *ColorLogger {
+	return l.with(l.logger.With().Str("symbol", symbol).Logger())
+}
+
+// WithSessionID returns a logger tagging every entry with the trading session ID
+// WithSessionID 返回一个为每条日志附加交易会话 ID 的日志器
+func (l *ColorLogger) WithSessionID(sessionID int64) *ColorLogger {
+	return l.with(l.logger.With().Int64("session_id", sessionID).Logger())
+}
+
+// with returns a copy of the logger using the given zerolog logger
+// with 返回使用指定 zerolog 日志器的副本
+func (l *ColorLogger) with(logger zerolog.Logger) *ColorLogger {
+	return &ColorLogger{logger: logger, writer: l.writer, json: l.json, sink: l.sink}
+}
+
+// SetSink sends every entry of this logger, its parent and the loggers derived from them to sink (nil stops)
+// SetSink 将此日志器、其父日志器及它们派生的日志器的每条日志发送给 sink（传 nil 停止）
+func (l *ColorLogger) SetSink(sink Sink) {
+	l.sink.mu.Lock()
+	l.sink.sink = sink
+	l.sink.mu.Unlock()
+}
+
+// IsJSON reports whether the logger writes JSON lines
+// IsJSON 返回日志器是否输出 JSON 行
+func (l *ColorLogger) IsJSON() bool {
+	return l.json
+}
+
 // Header prints a header with the given text
 func (l *ColorLogger) Header(text string, char rune, width int) {
+	if l.json {
+		l.logger.Info().Str("kind", "header").Msg(text)
+		return
+	}
 	line := strings.Repeat(string(char), width)
 	fmt.Fprintf(l.writer, "\n%s%s%s%s\n", Bold, BrightCyan, line, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s%s\n", Bold, BrightCyan, center(text, width), Reset)
@@ -80,6 +182,10 @@ func (l *ColorLogger) Header(text string, char rune, width int) {
 
 // Subheader prints a subheader
 func (l *ColorLogger) Subheader(text string, char rune, width int) {
+	if l.json {
+		l.logger.Info().Str("kind", "subheader").Msg(text)
+		return
+	}
 	line := strings.Repeat(string(char), width)
 	fmt.Fprintf(l.writer, "\n%s%s%s\n", BrightBlue, line, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s%s\n", Bold, BrightBlue, text, Reset)
@@ -88,42 +194,58 @@ func (l *ColorLogger) Subheader(text string, char rune, width int) {
 
 // Success prints a success message
 func (l *ColorLogger) Success(text string) {
-	fmt.Fprintf(l.writer, "%s✅ %s%s\n", BrightGreen, text, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%s✅ %s%s\n", BrightGreen, text, Reset)
+	}
 	l.logger.Info().Msg(text)
 }
 
 // Error prints an error message
 func (l *ColorLogger) Error(text string) {
-	fmt.Fprintf(l.writer, "%s❌ %s%s\n", BrightRed, text, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%s❌ %s%s\n", BrightRed, text, Reset)
+	}
 	l.logger.Error().Msg(text)
 }
 
 // Warning prints a warning message
 func (l *ColorLogger) Warning(text string) {
-	fmt.Fprintf(l.writer, "%s⚠️  %s%s\n", BrightYellow, text, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%s⚠️  %s%s\n", BrightYellow, text, Reset)
+	}
 	l.logger.Warn().Msg(text)
 }
 
 // Info prints an info message
 func (l *ColorLogger) Info(text string) {
-	fmt.Fprintf(l.writer, "%sℹ️  %s%s\n", Cyan, text, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%sℹ️  %s%s\n", Cyan, text, Reset)
+	}
 	l.logger.Info().Msg(text)
 }
 
 // Step prints a step message
 func (l *ColorLogger) Step(stepNum int, text string) {
-	fmt.Fprintf(l.writer, "%s%s🔄 [步骤 %d] %s%s\n", Bold, BrightMagenta, stepNum, text, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%s%s🔄 [步骤 %d] %s%s\n", Bold, BrightMagenta, stepNum, text, Reset)
+	}
 	l.logger.Info().Int("step", stepNum).Msg(text)
 }
 
 // ToolCall prints a tool call message
 func (l *ColorLogger) ToolCall(toolName string) {
-	fmt.Fprintf(l.writer, "%s🔧 调用工具: %s%s%s\n", Yellow, Bold, toolName, Reset)
+	if !l.json {
+		fmt.Fprintf(l.writer, "%s🔧 调用工具: %s%s%s\n", Yellow, Bold, toolName, Reset)
+	}
 	l.logger.Debug().Str("tool", toolName).Msg("Tool called")
 }
 
 // ToolResult prints a tool result
 func (l *ColorLogger) ToolResult(toolName string, result string, maxLines int) {
+	if l.json {
+		l.logger.Info().Str("tool", toolName).Msg(truncateLines(result, maxLines))
+		return
+	}
 	fmt.Fprintf(l.writer, "\n%s%s%s Tool Message: %s %s\n", Bold, BgBlue, White, toolName, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s\n", Green, strings.Repeat("─", 80), Reset)
 
@@ -140,6 +262,10 @@ func (l *ColorLogger) ToolResult(toolName string, result string, maxLines int) {
 
 // LLMResponse prints an LLM response
 func (l *ColorLogger) LLMResponse(agentName string, content string, maxLines int) {
+	if l.json {
+		l.logger.Info().Str("agent", agentName).Msg(truncateLines(content, maxLines))
+		return
+	}
 	fmt.Fprintf(l.writer, "\n%s%s%s %s LLM 响应 %s\n", Bold, BgMagenta, White, agentName, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s\n", Magenta, strings.Repeat("─", 80), Reset)
 
@@ -156,6 +282,10 @@ func (l *ColorLogger) LLMResponse(agentName string, content string, maxLines int
 
 // PositionInfo prints position information
 func (l *ColorLogger) PositionInfo(info string) {
+	if l.json {
+		l.logger.Info().Str("kind", "position_info").Msg(info)
+		return
+	}
 	fmt.Fprintf(l.writer, "\n%s%s%s 💼 账户和持仓信息 %s\n", Bold, BgCyan, White, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s\n", Cyan, strings.Repeat("─", 80), Reset)
 	fmt.Fprintln(l.writer, info)
@@ -164,6 +294,10 @@ func (l *ColorLogger) PositionInfo(info string) {
 
 // Decision prints the final trading decision
 func (l *ColorLogger) Decision(decisionText string) {
+	if l.json {
+		l.logger.Info().Str("kind", "decision").Msg(decisionText)
+		return
+	}
 	fmt.Fprintf(l.writer, "\n%s%s%s ✅ 最终交易决策 %s\n", Bold, BgGreen, White, Reset)
 	fmt.Fprintf(l.writer, "%s%s%s\n", Green, strings.Repeat("=", 80), Reset)
 	fmt.Fprintln(l.writer, decisionText)
@@ -180,6 +314,16 @@ func (l *ColorLogger) Debug(text string) {
 	l.logger.Debug().Msg(text)
 }
 
+// truncateLines keeps the first maxLines lines of text, noting how many were omitted
+// truncateLines 保留文本前 maxLines 行，并注明省略的行数
+func truncateLines(text string, maxLines int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= maxLines {
+		return text
+	}
+	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (省略 %d 行)", len(lines)-maxLines)
+}
+
 // Helper function to center text
 func center(text string, width int) string {
 	if len(text) >= width {
@@ -192,7 +336,7 @@ func center(text string, width int) string {
 // Global logger instance
 var Global *ColorLogger
 
-// Init initializes the global logger
-func Init(debug bool) {
-	Global = NewColorLogger(debug)
+// Init initializes the global logger in the given format (console or json)
+func Init(debug bool, format string) {
+	Global = NewLogger(debug, format)
 }
\ No newline at end of file
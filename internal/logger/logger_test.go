@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestJSONLogger tests that JSON mode writes one JSON object per entry with the tagged fields
+// TestJSONLogger 测试 JSON 模式每条日志输出一个带附加字段的 JSON 对象
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := newLogger(&buf, false, "JSON").WithComponent("executor").WithSymbol("BTC/USDT").WithSessionID(42)
+	if !log.IsJSON() {
+		t.Fatal("Logger should be in JSON mode")
+	}
+
+	log.Warning("止损单下单失败")
+	log.Header("交易周期", '=', 80)
+	log.LLMResponse("trader", "line1\nline2\nline3", 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 JSON lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Line is not JSON: %v (%s)", err, lines[0])
+	}
+	want := map[string]interface{}{
+		"level":      "warn",
+		"component":  "executor",
+		"symbol":     "BTC/USDT",
+		"session_id": float64(42),
+		"message":    "止损单下单失败",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(lines[2]), &entry); err != nil {
+		t.Fatalf("Line is not JSON: %v (%s)", err, lines[2])
+	}
+	if entry["agent"] != "trader" || entry["message"] != "line1\nline2\n... (省略 1 行)" {
+		t.Errorf("Unexpected LLM response entry: %v", entry)
+	}
+}
+
+// TestConsoleLogger tests that console mode keeps the colored text output
+// TestConsoleLogger 测试 console 模式保留彩色文本输出
+func TestConsoleLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := newLogger(&buf, false, "").WithComponent("web")
+	if log.IsJSON() {
+		t.Fatal("Unknown format should fall back to console")
+	}
+
+	log.Success("已连接")
+	if !strings.Contains(buf.String(), BrightGreen+"✅ 已连接"+Reset) {
+		t.Errorf("Colored output missing: %q", buf.String())
+	}
+}
+
+// TestSetSink tests that a sink set on the root logger receives entries from derived loggers
+// TestSetSink 测试根日志器上设置的 Sink 能收到派生日志器的日志
+func TestSetSink(t *testing.T) {
+	var buf bytes.Buffer
+	root := newLogger(&buf, false, "")
+	derived := root.WithComponent("executor")
+
+	var got []string
+	root.SetSink(func(level, message string) {
+		got = append(got, level+":"+message)
+	})
+	derived.Warning("止损单下单失败")
+	root.Debug("调试信息") // 低于日志级别，不应转发 / Below the level, not forwarded
+
+	if len(got) != 1 || got[0] != "warn:止损单下单失败" {
+		t.Errorf("Unexpected sink entries: %v", got)
+	}
+
+	root.SetSink(nil)
+	derived.Info("已停止")
+	if len(got) != 1 {
+		t.Errorf("Expected no entries after removing the sink, got %v", got)
+	}
+}
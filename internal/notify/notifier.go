@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// Notification levels
+// 通知级别
+const (
+	LevelInfo    = "info"    // 普通信息 / Informational
+	LevelWarning = "warning" // 警告 / Warning
+	LevelError   = "error"   // 错误 / Error
+)
+
+// Message is a notification sent to every configured sink
+// Message 是发送到所有已配置渠道的通知
+type Message struct {
+	Title string `json:"title"` // 标题 / Title
+	Text  string `json:"text"`  // 正文 / Body
+	Level string `json:"level"` // 级别 info/warning/error / Level info/warning/error
+}
+
+// String renders the message as plain text for chat-style sinks
+// String 将消息渲染为纯文本，供聊天类渠道使用
+func (m Message) String() string {
+	icon := "ℹ️"
+	switch m.Level {
+	case LevelWarning:
+		icon = "⚠️"
+	case LevelError:
+		icon = "🚨"
+	}
+
+	if m.Title == "" {
+		return fmt.Sprintf("%s %s", icon, m.Text)
+	}
+	return fmt.Sprintf("%s **%s**\n%s", icon, m.Title, m.Text)
+}
+
+// Notifier delivers notifications to an alerting channel
+// Notifier 将通知发送到告警渠道
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// MultiNotifier fans a notification out to several sinks
+// MultiNotifier 将通知分发到多个渠道
+type MultiNotifier struct {
+	sinks []Notifier
+}
+
+// NewMultiNotifier creates a notifier that fans out to all given sinks (nil sinks are skipped)
+// NewMultiNotifier 创建分发到所有给定渠道的通知器（忽略 nil）
+func NewMultiNotifier(sinks ...Notifier) *MultiNotifier {
+	m := &MultiNotifier{}
+	for _, sink := range sinks {
+		if sink != nil {
+			m.sinks = append(m.sinks, sink)
+		}
+	}
+	return m
+}
+
+// Notify sends the message to every sink; one failing sink doesn't stop the others
+// Notify 将消息发送到每个渠道，单个渠道失败不影响其他渠道
+func (m *MultiNotifier) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, sink := range m.sinks {
+		if err := sink.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Len returns the number of configured sinks
+// Len 返回已配置的渠道数量
+func (m *MultiNotifier) Len() int {
+	return len(m.sinks)
+}
+
+// NewFromConfig builds a fan-out notifier from the configured webhook URLs
+// NewFromConfig 根据配置的 Webhook 地址构建分发通知器
+//
+// Returns an empty notifier (Notify is a no-op) when no sink is configured.
+// 未配置任何渠道时返回空通知器（Notify 不做任何操作）。
+func NewFromConfig(cfg *config.Config) *MultiNotifier {
+	var sinks []Notifier
+	if url := strings.TrimSpace(cfg.NotifyDiscordWebhook); url != "" {
+		sinks = append(sinks, NewDiscordNotifier(url))
+	}
+	if url := strings.TrimSpace(cfg.NotifySlackWebhook); url != "" {
+		sinks = append(sinks, NewSlackNotifier(url))
+	}
+	if url := strings.TrimSpace(cfg.NotifyWebhookURL); url != "" {
+		sinks = append(sinks, NewWebhookNotifier(url))
+	}
+	return NewMultiNotifier(sinks...)
+}
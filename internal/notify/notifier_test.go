@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingNotifier struct {
+	messages []Message
+	err      error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, msg Message) error {
+	r.messages = append(r.messages, msg)
+	return r.err
+}
+
+func TestMultiNotifierFanOut(t *testing.T) {
+	ok := &recordingNotifier{}
+	failing := &recordingNotifier{err: errors.New("boom")}
+	m := NewMultiNotifier(failing, nil, ok)
+
+	if m.Len() != 2 {
+		t.Fatalf("Expected nil sinks to be skipped, got %d sinks", m.Len())
+	}
+
+	err := m.Notify(context.Background(), Message{Title: "t", Text: "x"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected sink error to be returned, got %v", err)
+	}
+	// 一个渠道失败不影响其他渠道
+	if len(ok.messages) != 1 {
+		t.Errorf("Expected healthy sink to still receive the message")
+	}
+
+	if err := NewMultiNotifier().Notify(context.Background(), Message{Text: "x"}); err != nil {
+		t.Errorf("Empty notifier should be a no-op, got %v", err)
+	}
+}
+
+func TestWebhookPayloads(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	msg := Message{Title: "成交", Text: "BTC/USDT BUY", Level: LevelInfo}
+	ctx := context.Background()
+
+	if err := NewDiscordNotifier(server.URL).Notify(ctx, msg); err != nil {
+		t.Fatalf("Discord notify failed: %v", err)
+	}
+	if content, _ := got["content"].(string); !strings.Contains(content, "**成交**") {
+		t.Errorf("Unexpected Discord payload: %v", got)
+	}
+
+	if err := NewSlackNotifier(server.URL).Notify(ctx, msg); err != nil {
+		t.Fatalf("Slack notify failed: %v", err)
+	}
+	if text, _ := got["text"].(string); text != "*成交*\nBTC/USDT BUY" {
+		t.Errorf("Unexpected Slack payload: %v", got)
+	}
+
+	if err := NewWebhookNotifier(server.URL).Notify(ctx, msg); err != nil {
+		t.Fatalf("Webhook notify failed: %v", err)
+	}
+	if got["title"] != "成交" || got["level"] != LevelInfo || got["timestamp"] == nil {
+		t.Errorf("Unexpected webhook payload: %v", got)
+	}
+
+	if err := NewWebhookNotifier(server.URL+"/fail").Notify(ctx, msg); err == nil {
+		t.Error("Expected non-2xx status to be an error")
+	}
+}
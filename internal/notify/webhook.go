@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Discord rejects message content longer than this
+// Discord 拒绝超过此长度的消息内容
+const discordMaxContent = 2000
+
+// webhookTimeout bounds a single webhook request
+// webhookTimeout 限制单次 Webhook 请求的耗时
+const webhookTimeout = 10 * time.Second
+
+// DiscordNotifier posts notifications to a Discord channel webhook
+// DiscordNotifier 通过 Discord 频道 Webhook 发送通知
+type DiscordNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewDiscordNotifier creates a new DiscordNotifier
+// NewDiscordNotifier 创建新的 Discord 通知器
+func NewDiscordNotifier(url string) *DiscordNotifier {
+	return &DiscordNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify sends the message to Discord
+// Notify 发送消息到 Discord
+func (d *DiscordNotifier) Notify(ctx context.Context, msg Message) error {
+	content := []rune(msg.String())
+	if len(content) > discordMaxContent {
+		content = append(content[:discordMaxContent-1], '…')
+	}
+	if err := postJSON(ctx, d.client, d.url, map[string]string{"content": string(content)}); err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+// SlackNotifier 通过 Slack Incoming Webhook 发送通知
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier creates a new SlackNotifier
+// NewSlackNotifier 创建新的 Slack 通知器
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify sends the message to Slack
+// Notify 发送消息到 Slack
+func (s *SlackNotifier) Notify(ctx context.Context, msg Message) error {
+	// Slack uses *bold* instead of **bold**
+	// Slack 使用 *粗体* 而不是 **粗体**
+	text := msg.Text
+	if msg.Title != "" {
+		text = fmt.Sprintf("*%s*\n%s", msg.Title, msg.Text)
+	}
+	if err := postJSON(ctx, s.client, s.url, map[string]string{"text": text}); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// WebhookNotifier posts the raw message as JSON to a generic HTTP endpoint
+// WebhookNotifier 将原始消息以 JSON 形式发送到通用 HTTP 端点
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier
+// NewWebhookNotifier 创建新的通用 Webhook 通知器
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Notify sends the message as {"title", "text", "level", "timestamp"}
+// Notify 以 {"title", "text", "level", "timestamp"} 格式发送消息
+func (w *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+	payload := struct {
+		Message
+		Timestamp string `json:"timestamp"`
+	}{
+		Message:   msg,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if err := postJSON(ctx, w.client, w.url, payload); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// postJSON posts payload as JSON and treats any non-2xx status as an error
+// postJSON 以 JSON 形式发送 payload，非 2xx 状态码视为错误
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
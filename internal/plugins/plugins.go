@@ -0,0 +1,212 @@
+// Package plugins lets custom Go code hook into the trading flow without forking the agents or executors.
+// Package plugins 允许自定义 Go 代码接入交易流程，无需修改 agents 或 executors。
+//
+// A plugin is a type with a Name that implements any of the hook interfaces below. Add a file to this
+// package (or to any package imported by the binaries) that registers it from init:
+// 插件是一个带 Name 方法、并实现下列任意钩子接口的类型。在本包（或二进制会导入的任意包）中新建文件，
+// 在 init 中注册即可：
+//
+//	func init() { plugins.Register(&myFilter{}) }
+//
+// Hooks run synchronously in the trading loop, so they should return quickly. A panicking hook is
+// recovered and reported as an error; for OnBeforeExecute it vetoes the trade.
+// 钩子在交易循环中同步执行，应尽快返回。钩子 panic 会被恢复并作为错误返回；OnBeforeExecute panic 时否决本次交易。
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Plugin is the base interface every plugin implements
+// Plugin 是所有插件都要实现的基础接口
+type Plugin interface {
+	Name() string
+}
+
+// ReportBuiltHook is called after the analyst reports are built, before the trader sees them
+// ReportBuiltHook 在分析师报告生成后、交易员决策前调用
+type ReportBuiltHook interface {
+	OnReportBuilt(ctx context.Context, event *ReportEvent) error
+}
+
+// DecisionHook is called after the trader's decision and the risk review
+// DecisionHook 在交易员决策及风控复核之后调用
+type DecisionHook interface {
+	OnDecision(ctx context.Context, event *DecisionEvent) error
+}
+
+// BeforeExecuteHook is called before a decision is executed; returning an error vetoes it
+// BeforeExecuteHook 在执行决策前调用；返回错误即否决本次执行
+type BeforeExecuteHook interface {
+	OnBeforeExecute(ctx context.Context, event *ExecuteEvent) error
+}
+
+// PositionClosedHook is called after a position is closed and recorded
+// PositionClosedHook 在持仓平仓并记录后调用
+type PositionClosedHook interface {
+	OnPositionClosed(ctx context.Context, event *PositionClosedEvent) error
+}
+
+// ReportEvent carries the combined reports the trader is about to see
+// ReportEvent 包含交易员即将看到的完整报告
+type ReportEvent struct {
+	Symbols   []string // 交易对 / Trading pairs
+	Timeframe string   // K 线周期 / K-line timeframe
+	Reports   string   // 报告全文 / Combined reports
+	Context   []string // 插件追加到 Prompt 的补充信息 / Extra context plugins append to the prompt
+}
+
+// AddContext appends a paragraph to the trader's prompt
+// AddContext 向交易员 Prompt 追加一段补充信息
+func (e *ReportEvent) AddContext(text string) {
+	e.Context = append(e.Context, text)
+}
+
+// DecisionEvent carries the final decision of one run
+// DecisionEvent 包含一次运行的最终决策
+type DecisionEvent struct {
+	Symbols  []string          // 交易对 / Trading pairs
+	Decision string            // 决策全文（JSON）/ Full decision text (JSON)
+	Path     string            // 决策来源路径 / How the decision was produced
+	Vetoes   map[string]string // 被风控复核否决的交易对及理由 / Symbols vetoed by the risk review and why
+}
+
+// ExecuteEvent describes a trade about to be executed
+// ExecuteEvent 描述即将执行的交易
+//
+// Plugins may lower or raise Leverage and PositionSizePercent (0 = default); the coordinator uses the
+// values left after all hooks ran.
+// 插件可以修改 Leverage 和 PositionSizePercent（0 表示默认值），协调器使用所有钩子执行后的值。
+type ExecuteEvent struct {
+	Symbol              string  // 交易对 / Trading pair
+	Action              string  // 交易动作 / Trade action
+	Reason              string  // 决策理由 / Decision reasoning
+	Leverage            int     // 杠杆倍数 / Leverage
+	PositionSizePercent float64 // 仓位百分比 / Position size percentage
+	StopLoss            float64 // 止损价格 / Stop-loss price
+}
+
+// PositionClosedEvent describes a closed position
+// PositionClosedEvent 描述已平仓的持仓
+type PositionClosedEvent struct {
+	PositionID  string    // 持仓 ID / Position ID
+	Symbol      string    // 交易对 / Trading pair
+	Side        string    // 方向 long/short / Side long/short
+	EntryPrice  float64   // 开仓价 / Entry price
+	ClosePrice  float64   // 平仓价 / Close price
+	Quantity    float64   // 数量 / Quantity
+	RealizedPnL float64   // 已实现盈亏（USDT）/ Realized PnL (USDT)
+	CloseReason string    // 平仓原因 / Close reason
+	OpenedAt    time.Time // 开仓时间 / Entry time
+	ClosedAt    time.Time // 平仓时间 / Close time
+}
+
+var (
+	mu       sync.RWMutex
+	registry []Plugin
+)
+
+// Register adds a plugin; it panics on a nil plugin or a duplicate name, like database/sql.Register
+// Register 注册插件；插件为 nil 或名称重复时 panic（与 database/sql.Register 一致）
+func Register(p Plugin) {
+	if p == nil {
+		panic("plugins: Register plugin is nil")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, existing := range registry {
+		if existing.Name() == p.Name() {
+			panic("plugins: Register called twice for plugin " + p.Name())
+		}
+	}
+	registry = append(registry, p)
+}
+
+// Names returns the sorted names of the registered plugins
+// Names 返回已注册插件的名称（已排序）
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for _, p := range registry {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registered returns a snapshot of the plugins in registration order
+// registered 返回按注册顺序排列的插件快照
+func registered() []Plugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Plugin(nil), registry...)
+}
+
+// call runs one hook, turning a panic into an error
+// call 执行单个钩子，将 panic 转为错误
+func call(p Plugin, hook string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s %s panicked: %v", p.Name(), hook, r)
+		}
+	}()
+	if err := fn(); err != nil {
+		return fmt.Errorf("plugin %s %s: %w", p.Name(), hook, err)
+	}
+	return nil
+}
+
+// ReportBuilt runs every OnReportBuilt hook; failing hooks don't stop the others
+// ReportBuilt 执行所有 OnReportBuilt 钩子；单个钩子失败不影响其他钩子
+func ReportBuilt(ctx context.Context, event *ReportEvent) error {
+	var errs []error
+	for _, p := range registered() {
+		if h, ok := p.(ReportBuiltHook); ok {
+			errs = append(errs, call(p, "OnReportBuilt", func() error { return h.OnReportBuilt(ctx, event) }))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Decision runs every OnDecision hook; failing hooks don't stop the others
+// Decision 执行所有 OnDecision 钩子；单个钩子失败不影响其他钩子
+func Decision(ctx context.Context, event *DecisionEvent) error {
+	var errs []error
+	for _, p := range registered() {
+		if h, ok := p.(DecisionHook); ok {
+			errs = append(errs, call(p, "OnDecision", func() error { return h.OnDecision(ctx, event) }))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BeforeExecute runs the OnBeforeExecute hooks in order and stops at the first veto
+// BeforeExecute 依次执行 OnBeforeExecute 钩子，遇到第一个否决即停止
+func BeforeExecute(ctx context.Context, event *ExecuteEvent) error {
+	for _, p := range registered() {
+		if h, ok := p.(BeforeExecuteHook); ok {
+			if err := call(p, "OnBeforeExecute", func() error { return h.OnBeforeExecute(ctx, event) }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PositionClosed runs every OnPositionClosed hook; failing hooks don't stop the others
+// PositionClosed 执行所有 OnPositionClosed 钩子；单个钩子失败不影响其他钩子
+func PositionClosed(ctx context.Context, event *PositionClosedEvent) error {
+	var errs []error
+	for _, p := range registered() {
+		if h, ok := p.(PositionClosedHook); ok {
+			errs = append(errs, call(p, "OnPositionClosed", func() error { return h.OnPositionClosed(ctx, event) }))
+		}
+	}
+	return errors.Join(errs...)
+}
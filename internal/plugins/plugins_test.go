@@ -0,0 +1,179 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testPlugin implements every hook through optional funcs
+// testPlugin 通过可选函数实现所有钩子
+type testPlugin struct {
+	name     string
+	report   func(*ReportEvent) error
+	decision func(*DecisionEvent) error
+	execute  func(*ExecuteEvent) error
+	closed   func(*PositionClosedEvent) error
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) OnReportBuilt(_ context.Context, e *ReportEvent) error {
+	if p.report == nil {
+		return nil
+	}
+	return p.report(e)
+}
+
+func (p *testPlugin) OnDecision(_ context.Context, e *DecisionEvent) error {
+	if p.decision == nil {
+		return nil
+	}
+	return p.decision(e)
+}
+
+func (p *testPlugin) OnBeforeExecute(_ context.Context, e *ExecuteEvent) error {
+	if p.execute == nil {
+		return nil
+	}
+	return p.execute(e)
+}
+
+func (p *testPlugin) OnPositionClosed(_ context.Context, e *PositionClosedEvent) error {
+	if p.closed == nil {
+		return nil
+	}
+	return p.closed(e)
+}
+
+// nameOnly implements no hooks
+// nameOnly 不实现任何钩子
+type nameOnly string
+
+func (n nameOnly) Name() string { return string(n) }
+
+// withRegistry runs a test against a clean registry
+// withRegistry 在空注册表上运行测试
+func withRegistry(t *testing.T, ps ...Plugin) {
+	t.Helper()
+	mu.Lock()
+	saved := registry
+	registry = nil
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		registry = saved
+		mu.Unlock()
+	})
+	for _, p := range ps {
+		Register(p)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	withRegistry(t, nameOnly("zeta"), nameOnly("alpha"))
+
+	if got := strings.Join(Names(), ","); got != "alpha,zeta" {
+		t.Errorf("Names() = %s, want alpha,zeta", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a duplicate plugin name")
+		}
+	}()
+	Register(nameOnly("alpha"))
+}
+
+func TestBeforeExecute(t *testing.T) {
+	var ran []string
+	withRegistry(t,
+		nameOnly("observer"),
+		&testPlugin{name: "half-size", execute: func(e *ExecuteEvent) error {
+			ran = append(ran, "half-size")
+			e.PositionSizePercent /= 2
+			return nil
+		}},
+		&testPlugin{name: "no-doge", execute: func(e *ExecuteEvent) error {
+			ran = append(ran, "no-doge")
+			if e.Symbol == "DOGEUSDT" {
+				return errors.New("DOGE is blocked")
+			}
+			return nil
+		}},
+		&testPlugin{name: "last", execute: func(e *ExecuteEvent) error {
+			ran = append(ran, "last")
+			return nil
+		}},
+	)
+
+	// Changes made by hooks are kept
+	// 钩子的修改会保留
+	event := &ExecuteEvent{Symbol: "BTCUSDT", Action: "BUY", PositionSizePercent: 30}
+	if err := BeforeExecute(context.Background(), event); err != nil {
+		t.Fatalf("Unexpected veto: %v", err)
+	}
+	if event.PositionSizePercent != 15 {
+		t.Errorf("PositionSizePercent = %.1f, want 15", event.PositionSizePercent)
+	}
+
+	// The first veto stops the remaining hooks
+	// 第一个否决会停止后续钩子
+	ran = nil
+	err := BeforeExecute(context.Background(), &ExecuteEvent{Symbol: "DOGEUSDT", Action: "BUY"})
+	if err == nil || !strings.Contains(err.Error(), "no-doge") {
+		t.Fatalf("Expected a veto naming the plugin, got %v", err)
+	}
+	if strings.Join(ran, ",") != "half-size,no-doge" {
+		t.Errorf("Unexpected hooks run after the veto: %v", ran)
+	}
+}
+
+func TestHookPanics(t *testing.T) {
+	var notified bool
+	withRegistry(t,
+		&testPlugin{name: "broken",
+			execute:  func(*ExecuteEvent) error { panic("boom") },
+			decision: func(*DecisionEvent) error { panic("boom") },
+		},
+		&testPlugin{name: "notifier", decision: func(*DecisionEvent) error {
+			notified = true
+			return nil
+		}},
+	)
+
+	// A panicking filter vetoes the trade
+	// 过滤钩子 panic 时否决交易
+	if err := BeforeExecute(context.Background(), &ExecuteEvent{Symbol: "BTCUSDT"}); err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Errorf("Expected the panic to veto, got %v", err)
+	}
+
+	// Observer hooks keep running after another one panics
+	// 观察类钩子在其他钩子 panic 后继续执行
+	if err := Decision(context.Background(), &DecisionEvent{}); err == nil {
+		t.Error("Expected the panic to be reported")
+	}
+	if !notified {
+		t.Error("Expected the other OnDecision hook to run")
+	}
+}
+
+func TestReportBuilt(t *testing.T) {
+	withRegistry(t,
+		&testPlugin{name: "macro", report: func(e *ReportEvent) error {
+			e.AddContext("FOMC meeting today")
+			return nil
+		}},
+		&testPlugin{name: "failing", report: func(*ReportEvent) error { return errors.New("feed down") }},
+	)
+
+	event := &ReportEvent{Symbols: []string{"BTCUSDT"}, Reports: "..."}
+	err := ReportBuilt(context.Background(), event)
+	if err == nil || !strings.Contains(err.Error(), "feed down") {
+		t.Errorf("Expected the failing hook's error, got %v", err)
+	}
+	if len(event.Context) != 1 || event.Context[0] != "FOMC meeting today" {
+		t.Errorf("Unexpected context: %v", event.Context)
+	}
+}
@@ -3,10 +3,14 @@ package portfolio
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/format"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // PositionInfo represents information about a position for a symbol
@@ -14,6 +18,7 @@ import (
 type PositionInfo struct {
 	Symbol           string                // 交易对 / Trading pair
 	Position         *executors.Position   // 当前持仓 / Current position
+	Positions        []*executors.Position // 所有方向的持仓（双向持仓模式可同时有多空）/ Positions on every side (long and short at once in hedge mode)
 	AllocatedBalance float64               // 分配的余额 / Allocated balance
 	MaxPositionSize  float64               // 最大仓位大小 / Max position size
 	Action           executors.TradeAction // 待执行动作 / Pending action
@@ -27,6 +32,7 @@ type PortfolioManager struct {
 	logger           *logger.ColorLogger
 	totalBalance     float64                  // 总余额 / Total balance
 	availableBalance float64                  // 可用余额 / Available balance
+	accountRisk      executors.AccountRisk    // 保证金率和分资产余额 / Margin ratio and per-asset balances
 	positions        map[string]*PositionInfo // 各交易对的仓位 / Positions for each pair
 	maxTotalRisk     float64                  // 最大总风险敞口 / Max total risk exposure
 }
@@ -51,15 +57,13 @@ func (pm *PortfolioManager) UpdateBalance(ctx context.Context) error {
 		return fmt.Errorf("failed to get account balance: %w", err)
 	}
 
-	for _, asset := range account.Assets {
-		if asset.Asset == "USDT" {
-			pm.totalBalance, _ = parseFloat(asset.WalletBalance)
-			pm.availableBalance, _ = parseFloat(asset.AvailableBalance)
-			// Balance log removed to reduce verbosity (logged when saving balance snapshot)
-			// 移除余额日志以减少冗余（在保存余额快照时会打印）
-			break
-		}
-	}
+	// Sum the margin assets in use (QUOTE_ASSET and the quote assets of CRYPTO_SYMBOLS)
+	// 汇总正在使用的保证金资产（QUOTE_ASSET 及 CRYPTO_SYMBOLS 的计价资产）
+	// Balance log removed to reduce verbosity (logged when saving balance snapshot)
+	// 移除余额日志以减少冗余（在保存余额快照时会打印）
+	assets := pm.executor.MarginAssets(ctx)
+	pm.availableBalance, pm.totalBalance, _ = executors.AssetBalance(account, assets...)
+	pm.accountRisk = executors.AccountRiskOf(account, assets...)
 
 	return nil
 }
@@ -67,7 +71,7 @@ func (pm *PortfolioManager) UpdateBalance(ctx context.Context) error {
 // UpdatePosition updates position information for a symbol
 // UpdatePosition 更新某个交易对的仓位信息
 func (pm *PortfolioManager) UpdatePosition(ctx context.Context, symbol string) error {
-	position, err := pm.executor.GetCurrentPosition(ctx, symbol)
+	positions, err := pm.executor.GetCurrentPositions(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get position for %s: %w", symbol, err)
 	}
@@ -78,7 +82,11 @@ func (pm *PortfolioManager) UpdatePosition(ctx context.Context, symbol string) e
 		}
 	}
 
-	pm.positions[symbol].Position = position
+	pm.positions[symbol].Position = nil
+	if len(positions) > 0 {
+		pm.positions[symbol].Position = positions[0]
+	}
+	pm.positions[symbol].Positions = positions
 	return nil
 }
 
@@ -120,9 +128,10 @@ func (pm *PortfolioManager) CheckRiskLimits(symbol string, positionSize float64,
 // GetPortfolioSummary 返回所有仓位的摘要
 func (pm *PortfolioManager) GetPortfolioSummary() string {
 	summary := fmt.Sprintf("\n=== 投资组合摘要 ===\n")
-	summary += fmt.Sprintf("总余额: %.2f USDT\n", pm.totalBalance)
-	summary += fmt.Sprintf("可用余额: %.2f USDT\n", pm.availableBalance)
-	summary += fmt.Sprintf("已用保证金: %.2f USDT\n\n", pm.totalBalance-pm.availableBalance)
+	summary += fmt.Sprintf("总余额: %s USDT\n", format.Number(pm.totalBalance, 2))
+	summary += fmt.Sprintf("可用余额: %s USDT\n", format.Number(pm.availableBalance, 2))
+	summary += fmt.Sprintf("已用保证金: %s USDT\n", format.Number(pm.totalBalance-pm.availableBalance, 2))
+	summary += fmt.Sprintf("保证金率: %.2f%% %s\n\n", pm.accountRisk.MarginRatio, executors.MarginRatioLevel(pm.accountRisk.MarginRatio))
 
 	if len(pm.positions) == 0 {
 		summary += "当前无持仓\n"
@@ -134,14 +143,14 @@ func (pm *PortfolioManager) GetPortfolioSummary() string {
 		if posInfo.Position != nil && posInfo.Position.Size > 0 {
 			summary += fmt.Sprintf("【%s】\n", symbol)
 			summary += fmt.Sprintf("  方向: %s\n", posInfo.Position.Side)
-			summary += fmt.Sprintf("  数量: %.4f\n", posInfo.Position.Size)
-			summary += fmt.Sprintf("  入场价: $%.2f\n", posInfo.Position.EntryPrice)
-			summary += fmt.Sprintf("  未实现盈亏: %+.2f USDT\n\n", posInfo.Position.UnrealizedPnL)
+			summary += fmt.Sprintf("  数量: %s\n", format.Adaptive(posInfo.Position.Size))
+			summary += fmt.Sprintf("  入场价: $%s\n", format.Adaptive(posInfo.Position.EntryPrice))
+			summary += fmt.Sprintf("  未实现盈亏: %s USDT\n\n", format.Signed(posInfo.Position.UnrealizedPnL, 2))
 			totalPnL += posInfo.Position.UnrealizedPnL
 		}
 	}
 
-	summary += fmt.Sprintf("总未实现盈亏: %+.2f USDT\n", totalPnL)
+	summary += fmt.Sprintf("总未实现盈亏: %s USDT\n", format.Signed(totalPnL, 2))
 	return summary
 }
 
@@ -175,14 +184,6 @@ func (pm *PortfolioManager) BalancePortfolio() map[string]string {
 	return suggestions
 }
 
-// Helper function to parse float from string
-// 辅助函数：从字符串解析浮点数
-func parseFloat(s string) (float64, error) {
-	var f float64
-	_, err := fmt.Sscanf(s, "%f", &f)
-	return f, err
-}
-
 // RebalanceAllocation rebalances position allocation across multiple symbols
 // RebalanceAllocation 在多个交易对之间重新分配仓位
 func (pm *PortfolioManager) RebalanceAllocation(symbols []string) map[string]float64 {
@@ -214,6 +215,28 @@ func (pm *PortfolioManager) GetAvailableBalance() float64 {
 	return pm.availableBalance
 }
 
+// GetAccountRisk returns the margin ratio and per-asset balances of the last UpdateBalance
+// GetAccountRisk 返回最近一次 UpdateBalance 获取的保证金率和分资产余额
+func (pm *PortfolioManager) GetAccountRisk() executors.AccountRisk {
+	return pm.accountRisk
+}
+
+// BalanceSnapshot returns the current balances, positions and account risk as a balance history record
+// BalanceSnapshot 将当前余额、持仓和账户风险作为余额历史记录返回
+func (pm *PortfolioManager) BalanceSnapshot() *storage.BalanceHistory {
+	return &storage.BalanceHistory{
+		Timestamp:         time.Now(),
+		TotalBalance:      pm.GetTotalBalance(),
+		AvailableBalance:  pm.GetAvailableBalance(),
+		UnrealizedPnL:     pm.GetTotalUnrealizedPnL(),
+		Positions:         pm.GetPositionCount(),
+		MarginBalance:     pm.accountRisk.MarginBalance,
+		MaintenanceMargin: pm.accountRisk.MaintenanceMargin,
+		MarginRatio:       pm.accountRisk.MarginRatio,
+		Assets:            pm.accountRisk.Assets,
+	}
+}
+
 // GetTotalUnrealizedPnL calculates total unrealized PnL across all positions
 // GetTotalUnrealizedPnL 计算所有持仓的总未实现盈亏
 func (pm *PortfolioManager) GetTotalUnrealizedPnL() float64 {
@@ -246,3 +269,98 @@ func (pm *PortfolioManager) GetPosition(symbol string) *executors.Position {
 	}
 	return nil
 }
+
+// RiskSnapshot builds the account state used by the global risk manager
+// RiskSnapshot 构建全局风控管理器使用的账户状态
+//
+// Stop prices come from the stop-loss manager; positions without a stop assume risk.DefaultStopPercent.
+// Both legs of a symbol held long and short in hedge mode are included.
+// 止损价格来自止损管理器，没有止损的持仓按 risk.DefaultStopPercent 估算。双向持仓模式下同时持有的多空两条腿都会计入。
+func (pm *PortfolioManager) RiskSnapshot(stopLossManager *executors.StopLossManager) risk.Snapshot {
+	snapshot := risk.Snapshot{
+		Equity: pm.totalBalance + pm.GetTotalUnrealizedPnL(),
+	}
+
+	for symbol, posInfo := range pm.positions {
+		for _, pos := range posInfo.Positions {
+			if pos == nil || pos.Size <= 0 {
+				continue
+			}
+			snapshot.Exposures = append(snapshot.Exposures, positionExposure(symbol, pos, stopLossManager))
+		}
+	}
+
+	return snapshot
+}
+
+// positionExposure estimates the notional and stop risk of one position
+// positionExposure 估算单个持仓的名义价值和止损风险
+func positionExposure(symbol string, pos *executors.Position, stopLossManager *executors.StopLossManager) risk.Exposure {
+	notional := pos.Size * pos.EntryPrice
+	stopPrice := 0.0
+	if stopLossManager != nil {
+		if managed := stopLossManager.GetPositionSide(symbol, pos.Side); managed != nil {
+			stopPrice = managed.CurrentStopLoss
+		}
+	}
+
+	// A stop already beyond entry (breakeven/trailing) locks in profit and carries no risk
+	// 止损已越过入场价（保本/追踪）时已锁定利润，不计风险
+	positionRisk := risk.StopRisk(notional, pos.EntryPrice, stopPrice)
+	if stopPrice > 0 && ((pos.Side == "long" && stopPrice >= pos.EntryPrice) ||
+		(pos.Side == "short" && stopPrice <= pos.EntryPrice)) {
+		positionRisk = 0
+	}
+
+	return risk.Exposure{
+		Symbol:   symbol,
+		Side:     pos.Side,
+		Notional: notional,
+		Risk:     positionRisk,
+	}
+}
+
+// ProposedOrder estimates the notional and stop risk of an opening order before it is sized by the coordinator
+// ProposedOrder 在协调器计算仓位之前估算开仓订单的名义价值和止损风险
+//
+// The quantity comes from executors.PlanPosition, the same sizing the coordinator uses, so the
+// risk-per-trade reduction is included. atr is the latest ATR (0 if unknown).
+// 数量来自 executors.PlanPosition，与协调器使用相同的仓位计算，因此包含按单笔风险的缩减。atr 为最新 ATR（未知时为 0）。
+func (pm *PortfolioManager) ProposedOrder(ctx context.Context, symbol string, action executors.TradeAction, positionSizePercent float64, leverage int, stopLoss, atr float64) (risk.Order, error) {
+	side := "long"
+	if action == executors.ActionSell {
+		side = "short"
+	}
+
+	price, err := pm.executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return risk.Order{}, fmt.Errorf("failed to get price for %s: %w", symbol, err)
+	}
+
+	plan := executors.PlanPosition(pm.config, pm.availableBalance, price, positionSizePercent, leverage, stopLoss, atr)
+	notional := plan.Quantity * price
+	order := risk.Order{
+		Symbol:   symbol,
+		Side:     side,
+		Notional: notional,
+		Risk:     risk.StopRisk(notional, price, stopLoss),
+	}
+	if plan.Leverage > 0 {
+		order.Margin = notional / float64(plan.Leverage)
+	}
+	return order, nil
+}
+
+// PercentForMargin converts a margin amount back into the position percent that sizes to it
+// PercentForMargin 将保证金金额换算回对应的仓位百分比
+//
+// Used by the allocator to trim a decision to the margin it was granted. Risk-per-trade sizing
+// only ever shrinks the order further, so the trimmed order never uses more than margin.
+// 供组合分配器将决策缩减到分配的保证金。按单笔风险的缩减只会让订单更小，因此缩减后的订单不会超过该保证金。
+func (pm *PortfolioManager) PercentForMargin(margin float64) float64 {
+	capital := executors.SizingCapital(pm.config, pm.availableBalance)
+	if capital <= 0 {
+		return 0
+	}
+	return margin / capital * 100
+}
@@ -0,0 +1,189 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// LLM checks that the model answers a test request
+// LLM 检查模型能否响应测试请求
+func LLM(cfg *config.Config, generate func(ctx context.Context) error) Check {
+	return Check{
+		Name: "LLM 服务",
+		Hint: "检查 OPENAI_API_KEY、LLM_BACKEND_URL 和 QUICK_THINK_LLM 配置",
+		Run: func(ctx context.Context) (string, error) {
+			if err := generate(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s @ %s", cfg.QuickThinkLLM, cfg.BackendURL), nil
+		},
+	}
+}
+
+// BinanceKeys checks that the API keys can read the futures account
+// BinanceKeys 检查 API Key 能否读取合约账户
+func BinanceKeys(cfg *config.Config, balance func(ctx context.Context) (float64, error)) Check {
+	return Check{
+		Name: "币安 API Key",
+		Hint: "检查 BINANCE_API_KEY / BINANCE_API_SECRET 是否正确、是否开启合约权限以及 IP 白名单（测试模式需使用测试网 Key）",
+		Run: func(ctx context.Context) (string, error) {
+			if cfg.IsAnalysisOnly() {
+				return "", Skip("仅分析模式，不连接账户")
+			}
+			if cfg.PaperTrading {
+				return "", Skip("模拟盘不使用交易所账户")
+			}
+			total, err := balance(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("账户余额 %.2f USDT", total), nil
+		},
+	}
+}
+
+// Symbols checks that every configured symbol is listed on the exchange
+// Symbols 检查所有配置的交易对均已在交易所上架
+func Symbols(symbols []string, unlisted func(ctx context.Context, symbols []string) ([]string, error)) Check {
+	return Check{
+		Name: "交易对",
+		Hint: "检查 CRYPTO_SYMBOLS 的拼写（例如 BTC/USDT），并确认交易所存在对应的 USDT 永续合约",
+		Run: func(ctx context.Context) (string, error) {
+			missing, err := unlisted(ctx, symbols)
+			if err != nil {
+				return "", err
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("交易所不存在: %s", strings.Join(missing, ", "))
+			}
+			return fmt.Sprintf("%d 个交易对均已上架", len(symbols)), nil
+		},
+	}
+}
+
+// PromptFile checks that the trader prompt file is readable and not empty
+// PromptFile 检查交易员 Prompt 文件可读且非空
+func PromptFile(path string) Check {
+	return Check{
+		Name: "Prompt 文件",
+		Hint: "检查 TRADER_PROMPT_PATH 的路径和文件权限",
+		Run: func(ctx context.Context) (string, error) {
+			if path == "" {
+				return "", Skip("未配置，使用内置 Prompt")
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(string(content)) == "" {
+				return "", fmt.Errorf("%s 是空文件", path)
+			}
+			return fmt.Sprintf("%s（%d 字节）", path, len(content)), nil
+		},
+	}
+}
+
+// Database checks that the database directory and file are writable
+// Database 检查数据库目录和文件可写
+//
+// SQLite opens a read-only file without complaint and only fails at the first write,
+// so the permissions are checked on the file system instead.
+// SQLite 打开只读文件时不会报错，直到第一次写入才失败，因此直接在文件系统上检查权限。
+func Database(path string) Check {
+	return Check{
+		Name: "数据库",
+		Hint: "检查 DATABASE_PATH 所在目录和数据库文件的写权限",
+		Run: func(ctx context.Context) (string, error) {
+			probe, err := os.CreateTemp(filepath.Dir(path), ".preflight-*")
+			if err != nil {
+				return "", fmt.Errorf("目录不可写: %w", err)
+			}
+			probe.Close()
+			os.Remove(probe.Name())
+
+			file, err := os.OpenFile(path, os.O_WRONLY, 0)
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Sprintf("%s（将自动创建）", path), nil
+			}
+			if err != nil {
+				return "", fmt.Errorf("数据库文件不可写: %w", err)
+			}
+			file.Close()
+			return path, nil
+		},
+	}
+}
+
+// WebPort checks that the web server port is free
+// WebPort 检查 Web 服务器端口未被占用
+func WebPort(port int) Check {
+	return Check{
+		Name: "Web 端口",
+		Hint: "停止占用该端口的进程，或修改 WEB_PORT",
+		Run: func(ctx context.Context) (string, error) {
+			address := fmt.Sprintf(":%d", port)
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				return "", err
+			}
+			listener.Close()
+			return fmt.Sprintf("%s 可用", address), nil
+		},
+	}
+}
+
+// Proxy checks that the Binance proxy accepts connections
+// Proxy 检查币安代理能否建立连接
+func Proxy(proxy string) Check {
+	return Check{
+		Name: "币安代理",
+		Hint: "检查 BINANCE_PROXY 的地址，并确认代理服务已启动",
+		Run: func(ctx context.Context) (string, error) {
+			if proxy == "" {
+				return "", Skip("未配置")
+			}
+			address, err := proxyAddress(proxy)
+			if err != nil {
+				return "", err
+			}
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return "", err
+			}
+			conn.Close()
+			return fmt.Sprintf("%s 可连接", address), nil
+		},
+	}
+}
+
+// proxyAddress returns host:port of a proxy URL, using the scheme's default port when none is given
+// proxyAddress 返回代理 URL 的 host:port，未指定端口时使用协议的默认端口
+func proxyAddress(proxy string) (string, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return "", fmt.Errorf("代理地址无效: %w", err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("代理地址无效: %q 缺少主机名", proxy)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,146 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// checkTimeout bounds each check so an unreachable dependency can't hang startup
+// checkTimeout 限制每项检查的耗时，避免不可达的依赖卡住启动
+const checkTimeout = 15 * time.Second
+
+// Status is the outcome of a single check
+// Status 表示单项检查的结果
+type Status string
+
+const (
+	StatusPass Status = "PASS" // 检查通过 / Check passed
+	StatusFail Status = "FAIL" // 检查失败，阻止启动 / Check failed, startup is aborted
+	StatusSkip Status = "SKIP" // 当前配置下不适用 / Not applicable with the current configuration
+)
+
+// Check is one startup dependency check
+// Check 表示一项启动依赖检查
+type Check struct {
+	Name string                                    // 检查项名称 / Check name
+	Hint string                                    // 失败时的修复建议 / How to fix a failure
+	Run  func(ctx context.Context) (string, error) // 返回结果说明；返回 skipped 错误表示跳过 / Returns the detail; a skipped error marks the check as skipped
+}
+
+// Result is the outcome of a check with its detail
+// Result 表示一项检查的结果及说明
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Hint   string // 仅失败时填写 / Only set on failure
+}
+
+// Report holds the results of all checks in order
+// Report 按顺序保存所有检查的结果
+type Report struct {
+	Results []Result
+}
+
+// skipped marks a check that doesn't apply to the current configuration
+// skipped 表示检查不适用于当前配置
+type skipped struct {
+	reason string
+}
+
+func (s skipped) Error() string { return s.reason }
+
+// Skip returns the error a check returns when it doesn't apply
+// Skip 返回检查不适用时应返回的错误
+func Skip(reason string) error {
+	return skipped{reason: reason}
+}
+
+// Run executes the checks one by one, each with its own timeout
+// Run 逐项执行检查，每项检查有独立的超时时间
+func Run(ctx context.Context, checks []Check) *Report {
+	report := &Report{Results: make([]Result, 0, len(checks))}
+	for _, check := range checks {
+		report.Results = append(report.Results, runCheck(ctx, check))
+	}
+	return report
+}
+
+// runCheck executes one check and converts its outcome into a Result
+// runCheck 执行单项检查并将结果转换为 Result
+func runCheck(ctx context.Context, check Check) Result {
+	checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	detail, err := check.Run(checkCtx)
+	var skip skipped
+	switch {
+	case errors.As(err, &skip):
+		return Result{Name: check.Name, Status: StatusSkip, Detail: skip.reason}
+	case err != nil:
+		return Result{Name: check.Name, Status: StatusFail, Detail: err.Error(), Hint: check.Hint}
+	default:
+		return Result{Name: check.Name, Status: StatusPass, Detail: detail}
+	}
+}
+
+// Passed reports whether no check failed
+// Passed 返回是否没有失败的检查项
+func (r *Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the failed results
+// Failures 返回失败的检查结果
+func (r *Report) Failures() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Table renders the results as a fixed-width text table, one row per check
+// Table 将检查结果渲染为定宽文本表格，每项检查一行
+func (r *Report) Table() string {
+	nameWidth := displayWidth("检查项")
+	for _, result := range r.Results {
+		if w := displayWidth(result.Name); w > nameWidth {
+			nameWidth = w
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s  %s  %s\n", pad("检查项", nameWidth), pad("状态", 4), "说明"))
+	sb.WriteString(strings.Repeat("─", nameWidth+2+4+2+4) + "\n")
+	for _, result := range r.Results {
+		sb.WriteString(fmt.Sprintf("%s  %-4s  %s\n", pad(result.Name, nameWidth), result.Status, result.Detail))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// pad right-pads s to the given display width
+// pad 将 s 右侧补齐到指定的显示宽度
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-displayWidth(s)))
+}
+
+// displayWidth counts CJK characters as two terminal columns
+// displayWidth 计算终端显示宽度，中日韩字符按两列计算
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if utf8.RuneLen(r) > 2 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,156 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestRun tests that check outcomes map to PASS / FAIL / SKIP
+// TestRun 测试检查结果被映射为 PASS / FAIL / SKIP
+func TestRun(t *testing.T) {
+	report := Run(context.Background(), []Check{
+		{Name: "ok", Run: func(ctx context.Context) (string, error) { return "fine", nil }},
+		{Name: "broken", Hint: "fix it", Run: func(ctx context.Context) (string, error) { return "", errors.New("boom") }},
+		{Name: "n/a", Hint: "unused", Run: func(ctx context.Context) (string, error) { return "", Skip("not configured") }},
+	})
+
+	want := []Result{
+		{Name: "ok", Status: StatusPass, Detail: "fine"},
+		{Name: "broken", Status: StatusFail, Detail: "boom", Hint: "fix it"},
+		{Name: "n/a", Status: StatusSkip, Detail: "not configured"},
+	}
+	for i, result := range report.Results {
+		if result != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, result, want[i])
+		}
+	}
+	if report.Passed() {
+		t.Error("report with a failure should not pass")
+	}
+	if failures := report.Failures(); len(failures) != 1 || failures[0].Name != "broken" {
+		t.Errorf("failures = %+v", failures)
+	}
+
+	table := report.Table()
+	lines := strings.Split(table, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("table should have a header, a rule and 3 rows:\n%s", table)
+	}
+	if !strings.HasPrefix(lines[3], "broken  FAIL  boom") {
+		t.Errorf("unexpected row %q", lines[3])
+	}
+}
+
+// TestChecks tests the built-in checks against the local file system and network
+// TestChecks 测试内置检查项（使用本地文件系统和网络）
+func TestChecks(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	run := func(check Check) Result { return runCheck(ctx, check) }
+
+	t.Run("prompt file", func(t *testing.T) {
+		if got := run(PromptFile("")); got.Status != StatusSkip {
+			t.Errorf("empty path: %+v", got)
+		}
+		if got := run(PromptFile(filepath.Join(dir, "missing.txt"))); got.Status != StatusFail {
+			t.Errorf("missing file: %+v", got)
+		}
+		path := filepath.Join(dir, "prompt.txt")
+		os.WriteFile(path, []byte("  \n"), 0o644)
+		if got := run(PromptFile(path)); got.Status != StatusFail {
+			t.Errorf("blank file: %+v", got)
+		}
+		os.WriteFile(path, []byte("trade carefully"), 0o644)
+		if got := run(PromptFile(path)); got.Status != StatusPass {
+			t.Errorf("readable file: %+v", got)
+		}
+	})
+
+	t.Run("database", func(t *testing.T) {
+		if got := run(Database(filepath.Join(dir, "new.db"))); got.Status != StatusPass {
+			t.Errorf("new database: %+v", got)
+		}
+		if got := run(Database(filepath.Join(dir, "missing", "trading.db"))); got.Status != StatusFail {
+			t.Errorf("missing directory: %+v", got)
+		}
+	})
+
+	t.Run("web port", func(t *testing.T) {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Skipf("cannot listen: %v", err)
+		}
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		if got := run(WebPort(port)); got.Status != StatusFail {
+			t.Errorf("busy port: %+v", got)
+		}
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		if got := run(Proxy("")); got.Status != StatusSkip {
+			t.Errorf("no proxy: %+v", got)
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Skipf("cannot listen: %v", err)
+		}
+		address := listener.Addr().String()
+		if got := run(Proxy("http://" + address)); got.Status != StatusPass {
+			t.Errorf("listening proxy: %+v", got)
+		}
+		listener.Close()
+		if got := run(Proxy("http://" + address)); got.Status != StatusFail {
+			t.Errorf("closed proxy: %+v", got)
+		}
+	})
+
+	t.Run("binance keys", func(t *testing.T) {
+		balance := func(ctx context.Context) (float64, error) { return 0, errors.New("invalid API-key") }
+		if got := run(BinanceKeys(&config.Config{AnalysisOnly: true}, balance)); got.Status != StatusSkip {
+			t.Errorf("analysis-only: %+v", got)
+		}
+		if got := run(BinanceKeys(&config.Config{PaperTrading: true}, balance)); got.Status != StatusSkip {
+			t.Errorf("paper trading: %+v", got)
+		}
+		live := &config.Config{BinanceAPIKey: "key", BinanceAPISecret: "secret"}
+		if got := run(BinanceKeys(live, balance)); got.Status != StatusFail {
+			t.Errorf("invalid keys: %+v", got)
+		}
+	})
+
+	t.Run("symbols", func(t *testing.T) {
+		unlisted := func(ctx context.Context, symbols []string) ([]string, error) { return []string{"FOO/USDT"}, nil }
+		got := run(Symbols([]string{"BTC/USDT", "FOO/USDT"}, unlisted))
+		if got.Status != StatusFail || !strings.Contains(got.Detail, "FOO/USDT") {
+			t.Errorf("unlisted symbol: %+v", got)
+		}
+	})
+}
+
+// TestProxyAddress tests default ports per proxy scheme
+// TestProxyAddress 测试各代理协议的默认端口
+func TestProxyAddress(t *testing.T) {
+	tests := map[string]string{
+		"http://127.0.0.1:7890":  "127.0.0.1:7890",
+		"http://proxy.local":     "proxy.local:80",
+		"https://proxy.local":    "proxy.local:443",
+		"socks5://user:pw@[::1]": "[::1]:1080",
+	}
+	for proxy, want := range tests {
+		got, err := proxyAddress(proxy)
+		if err != nil || got != want {
+			t.Errorf("proxyAddress(%q) = %q, %v; want %q", proxy, got, err, want)
+		}
+	}
+	if _, err := proxyAddress("127.0.0.1:7890"); err == nil {
+		t.Error("a proxy without scheme should be rejected")
+	}
+}
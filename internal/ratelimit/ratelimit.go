@@ -0,0 +1,307 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is how long requests pause after a 429/418 without a Retry-After header
+// defaultRetryAfter 是收到不带 Retry-After 的 429/418 响应后暂停请求的时长
+const defaultRetryAfter = time.Minute
+
+// usedWeightHeader is the response header with the IP's request weight used in the current minute
+// usedWeightHeader 是返回当前分钟内该 IP 已用请求权重的响应头
+const usedWeightHeader = "X-Mbx-Used-Weight-1m"
+
+// Stats are the throttling metrics of a limiter
+// Stats 是限流器的限流指标
+type Stats struct {
+	Budget      int   `json:"budget"`       // 每分钟权重预算 / Weight budget per minute
+	Requests    int64 `json:"requests"`     // 请求总数 / Requests sent
+	Weight      int64 `json:"weight"`       // 消耗的权重总数 / Total weight spent
+	Throttled   int64 `json:"throttled"`    // 因限流而等待的请求数 / Requests that had to wait
+	WaitedMs    int64 `json:"waited_ms"`    // 累计等待时间（毫秒）/ Total time spent waiting (ms)
+	MaxWaitMs   int64 `json:"max_wait_ms"`  // 单次最长等待（毫秒）/ Longest single wait (ms)
+	RateLimited int64 `json:"rate_limited"` // 收到的 429/418 响应数 / 429/418 responses received
+	UsedWeight  int   `json:"used_weight"`  // 币安最近报告的当前分钟已用权重 / Used weight last reported by Binance
+}
+
+// Limiter is a token bucket of Binance request weight shared by every client of the process
+// Limiter 是进程内所有客户端共享的币安请求权重令牌桶
+//
+// The bucket holds one minute of budget and refills continuously. Requests reserve their weight up front,
+// so concurrent callers queue in order instead of bursting. The weight Binance reports as used also drains
+// the bucket, which accounts for requests made by other processes on the same IP.
+// 令牌桶容量为一分钟的预算并持续补充。请求预先预留权重，并发调用按顺序排队而不是突发。
+// 币安报告的已用权重也会扣减令牌，从而计入同一 IP 上其他进程发出的请求。
+type Limiter struct {
+	capacity float64 // 每分钟预算 / Budget per minute
+	rate     float64 // 每秒补充的权重 / Weight refilled per second
+	now      func() time.Time
+
+	mu           sync.Mutex
+	tokens       float64   // 可用权重（预留后可为负）/ Available weight (negative while reserved ahead)
+	last         time.Time // 上次补充时间 / Last refill time
+	blockedUntil time.Time // 被币安限流后暂停到的时间 / Pause after Binance rate-limited us
+	stats        Stats
+}
+
+// NewLimiter creates a limiter allowing budgetPerMinute request weight per minute
+// NewLimiter 创建每分钟允许 budgetPerMinute 请求权重的限流器
+func NewLimiter(budgetPerMinute int) *Limiter {
+	l := &Limiter{
+		capacity: float64(budgetPerMinute),
+		rate:     float64(budgetPerMinute) / 60,
+		now:      time.Now,
+		tokens:   float64(budgetPerMinute),
+		stats:    Stats{Budget: budgetPerMinute},
+	}
+	l.last = l.now()
+	return l
+}
+
+// refill adds the weight earned since the last refill (caller holds mu)
+// refill 补充自上次补充以来恢复的权重（调用方持有 mu）
+func (l *Limiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+}
+
+// reserve takes weight from the bucket and returns how long the caller must wait before sending
+// reserve 从令牌桶中预留权重，返回发送前需要等待的时长
+func (l *Limiter) reserve(weight int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.refill(now)
+	if float64(weight) > l.capacity {
+		weight = int(l.capacity)
+	}
+	l.tokens -= float64(weight)
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if blocked := l.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+
+	l.stats.Requests++
+	l.stats.Weight += int64(weight)
+	if wait > 0 {
+		l.stats.Throttled++
+		l.stats.WaitedMs += wait.Milliseconds()
+		if ms := wait.Milliseconds(); ms > l.stats.MaxWaitMs {
+			l.stats.MaxWaitMs = ms
+		}
+	}
+	return wait
+}
+
+// Wait blocks until weight may be spent, or returns ctx's error (the weight is then given back)
+// Wait 阻塞直到可以消耗 weight 权重，ctx 结束时返回其错误（并退还权重）
+func (l *Limiter) Wait(ctx context.Context, weight int) error {
+	wait := l.reserve(weight)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens += float64(weight)
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe applies the weight Binance reports as used in the current minute
+// Observe 应用币安报告的当前分钟已用权重
+func (l *Limiter) Observe(usedWeight int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(l.now())
+	l.stats.UsedWeight = usedWeight
+	if left := l.capacity - float64(usedWeight); l.tokens > left {
+		l.tokens = left
+	}
+}
+
+// Block pauses all requests for d after Binance answered 429 (too many requests) or 418 (IP banned)
+// Block 在币安返回 429（请求过多）或 418（IP 被封禁）后暂停所有请求 d
+func (l *Limiter) Block(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.RateLimited++
+	if until := l.now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// Stats returns a snapshot of the throttling metrics (zero value for a nil limiter)
+// Stats 返回限流指标的快照（nil 限流器返回零值）
+func (l *Limiter) Stats() Stats {
+	if l == nil {
+		return Stats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Transport is an http.RoundTripper that spends limiter weight before each Binance request
+// Transport 是在每次币安请求前消耗限流器权重的 http.RoundTripper
+type Transport struct {
+	Base    http.RoundTripper // 底层传输（nil 使用 http.DefaultTransport）/ Underlying transport (nil uses http.DefaultTransport)
+	Limiter *Limiter
+}
+
+// RoundTrip waits for the request's weight, sends it and learns from Binance's rate limit headers
+// RoundTrip 等待请求所需权重后发送，并根据币安的限流响应头调整
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Limiter.Wait(req.Context(), Weight(req.Method, req.URL.Path, req.URL.Query())); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if used, err := strconv.Atoi(resp.Header.Get(usedWeightHeader)); err == nil {
+		t.Limiter.Observe(used)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		retryAfter := defaultRetryAfter
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		t.Limiter.Block(retryAfter)
+	}
+	return resp, nil
+}
+
+// WrapClient returns a copy of client whose requests go through the limiter (client itself if limiter is nil)
+// WrapClient 返回请求经过限流器的 client 副本（limiter 为 nil 时返回 client 本身）
+func WrapClient(client *http.Client, limiter *Limiter) *http.Client {
+	if limiter == nil {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = &Transport{Base: client.Transport, Limiter: limiter}
+	return &wrapped
+}
+
+// Weight returns the request weight of a Binance futures REST endpoint
+// Weight 返回币安合约 REST 接口的请求权重
+//
+// The table follows Binance's documented weights for the endpoints the bot uses; others count as 1.
+// 权重表按币安文档整理了本程序使用的接口，其他接口按 1 计算。
+func Weight(method, path string, query url.Values) int {
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	allSymbols := query.Get("symbol") == ""
+
+	switch path {
+	case "/fapi/v1/klines", "/fapi/v1/continuousKlines", "/fapi/v1/markPriceKlines", "/fapi/v1/indexPriceKlines":
+		switch {
+		case limit == 0:
+			return 2 // 默认 500 根 / Default 500 klines
+		case limit < 100:
+			return 1
+		case limit < 500:
+			return 2
+		case limit <= 1000:
+			return 5
+		}
+		return 10
+	case "/fapi/v1/depth":
+		switch {
+		case limit == 0:
+			return 10 // 默认 500 档 / Default 500 levels
+		case limit <= 50:
+			return 2
+		case limit <= 100:
+			return 5
+		case limit <= 500:
+			return 10
+		}
+		return 20
+	case "/fapi/v1/ticker/24hr":
+		if allSymbols {
+			return 40
+		}
+	case "/fapi/v1/ticker/price", "/fapi/v2/ticker/price":
+		if allSymbols {
+			return 2
+		}
+	case "/fapi/v1/ticker/bookTicker":
+		if allSymbols {
+			return 5
+		}
+		return 2
+	case "/fapi/v1/openOrders":
+		if method == http.MethodGet && allSymbols {
+			return 40
+		}
+	case "/fapi/v2/account", "/fapi/v3/account", "/fapi/v2/balance", "/fapi/v3/balance",
+		"/fapi/v2/positionRisk", "/fapi/v3/positionRisk", "/fapi/v1/allOrders", "/fapi/v1/userTrades":
+		return 5
+	case "/fapi/v1/income":
+		return 30
+	case "/fapi/v1/premiumIndex":
+		if allSymbols {
+			return 10
+		}
+	}
+	return 1
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   *Limiter
+)
+
+// Shared returns the process-wide limiter, created with the budget of the first call
+// Shared 返回进程内共享的限流器，按首次调用的预算创建
+//
+// Returns nil (no limiting) when budgetPerMinute is 0 or less.
+// budgetPerMinute 小于等于 0 时返回 nil（不限流）。
+func Shared(budgetPerMinute int) *Limiter {
+	if budgetPerMinute <= 0 {
+		return nil
+	}
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	if shared == nil {
+		shared = NewLimiter(budgetPerMinute)
+	}
+	return shared
+}
+
+// Default returns the limiter created by Shared (nil when requests are not limited)
+// Default 返回由 Shared 创建的限流器（不限流时为 nil）
+func Default() *Limiter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	return shared
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	l := NewLimiter(60) // 每秒补充 1 / Refills 1 per second
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	if wait := l.reserve(50); wait != 0 {
+		t.Errorf("Expected no wait within the budget, got %v", wait)
+	}
+	if wait := l.reserve(20); wait != 10*time.Second {
+		t.Errorf("Expected a 10s wait for 10 weight over budget, got %v", wait)
+	}
+
+	// The bucket refills over time but never above one minute of budget
+	// 令牌桶随时间补充，但不超过一分钟的预算
+	now = now.Add(5 * time.Minute)
+	if wait := l.reserve(60); wait != 0 {
+		t.Errorf("Expected a full bucket after 5 minutes, got %v", wait)
+	}
+	if wait := l.reserve(1); wait != time.Second {
+		t.Errorf("Expected the refill to be capped at the budget, got %v", wait)
+	}
+
+	stats := l.Stats()
+	if stats.Requests != 4 || stats.Weight != 131 || stats.Throttled != 2 || stats.MaxWaitMs != 10000 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimiterObserveAndBlock(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	l := NewLimiter(60)
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	// Weight used by other processes on the same IP drains the bucket
+	// 同一 IP 上其他进程使用的权重会扣减令牌
+	l.Observe(55)
+	if wait := l.reserve(10); wait != 5*time.Second {
+		t.Errorf("Expected the reported weight to be spent, got %v", wait)
+	}
+
+	l.Block(30 * time.Second)
+	now = now.Add(10 * time.Minute)
+	l.Block(time.Minute)
+	if wait := l.reserve(1); wait != time.Minute {
+		t.Errorf("Expected requests to pause after a 429, got %v", wait)
+	}
+	if stats := l.Stats(); stats.RateLimited != 2 || stats.UsedWeight != 55 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	l := NewLimiter(60)
+	if err := l.Wait(context.Background(), 60); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 30); err == nil {
+		t.Error("Expected the wait to end with the context")
+	}
+}
+
+func TestWeight(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		query  string
+		want   int
+	}{
+		{"GET", "/fapi/v1/klines", "symbol=BTCUSDT&limit=50", 1},
+		{"GET", "/fapi/v1/klines", "symbol=BTCUSDT&limit=499", 2},
+		{"GET", "/fapi/v1/klines", "symbol=BTCUSDT&limit=1000", 5},
+		{"GET", "/fapi/v1/klines", "symbol=BTCUSDT&limit=1500", 10},
+		{"GET", "/fapi/v1/depth", "symbol=BTCUSDT&limit=20", 2},
+		{"GET", "/fapi/v1/depth", "symbol=BTCUSDT&limit=1000", 20},
+		{"GET", "/fapi/v1/openOrders", "", 40},
+		{"GET", "/fapi/v1/openOrders", "symbol=BTCUSDT", 1},
+		{"GET", "/fapi/v2/positionRisk", "", 5},
+		{"GET", "/fapi/v1/income", "", 30},
+		{"POST", "/fapi/v1/order", "", 1},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		if got := Weight(tt.method, tt.path, query); got != tt.want {
+			t.Errorf("Weight(%s %s?%s) = %d, want %d", tt.method, tt.path, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(usedWeightHeader, "42")
+		if r.URL.Path == "/fapi/v1/order" {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	l := NewLimiter(1200)
+	client := WrapClient(server.Client(), l)
+	for _, path := range []string{"/fapi/v1/klines?limit=1000", "/fapi/v1/order"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	stats := l.Stats()
+	if stats.Requests != 2 || stats.Weight != 6 || stats.UsedWeight != 42 || stats.RateLimited != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if WrapClient(server.Client(), nil) != server.Client() {
+		t.Error("Expected a nil limiter to leave the client unchanged")
+	}
+}
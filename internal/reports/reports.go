@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Report periods
+// 报告周期
+const (
+	PeriodDaily  = "daily"  // 上一个完整的 UTC 日 / The last complete UTC day
+	PeriodWeekly = "weekly" // 上一个完整的 UTC 周（周一开始）/ The last complete UTC week (starting Monday)
+)
+
+// PeriodBounds returns the last complete period before now as [start, end) in UTC
+// PeriodBounds 返回 now 之前最近一个完整周期的 UTC 区间 [start, end)
+func PeriodBounds(period string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodDaily:
+		return today.AddDate(0, 0, -1), today, nil
+	case PeriodWeekly:
+		// Go's weekdays start on Sunday; shift so that Monday is 0
+		// Go 的星期从周日开始，平移使周一为 0
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return monday.AddDate(0, 0, -7), monday, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("未知的报告周期: %s", period)
+	}
+}
+
+// Generate aggregates the trades closed and the balance history of the last complete period before now
+// Generate 汇总 now 之前最近一个完整周期的平仓交易和余额历史
+//
+// Equity is the total balance plus unrealized PnL, as on the equity page; the drawdown is measured
+// from the running peak within the period. Profit sweeps are withdrawals, not losses: the amount
+// swept since the first snapshot is added back for the drawdown and the return.
+// 资产为总余额加未实现盈亏，与资产曲线页面一致；回撤按周期内的历史最高点计算。利润提取是出金而不是亏损：
+// 计算回撤和收益率时加回自首个快照以来提取的金额。
+func Generate(db *storage.Storage, period string, now time.Time) (*storage.PerformanceReport, error) {
+	start, end, err := PeriodBounds(period, now)
+	if err != nil {
+		return nil, err
+	}
+	report := &storage.PerformanceReport{Period: period, PeriodStart: start, PeriodEnd: end}
+
+	closed, err := db.GetPositionsClosedSince(start)
+	if err != nil {
+		return nil, fmt.Errorf("获取平仓记录失败: %w", err)
+	}
+	for _, pos := range closed {
+		if pos.CloseTime == nil || !pos.CloseTime.Before(end) {
+			continue
+		}
+		fees, err := db.GetPositionFees(pos.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取持仓手续费失败: %w", err)
+		}
+		report.Fees += fees
+		report.RealizedPnL += pos.RealizedPnL
+		if pos.RealizedPnL > 0 {
+			report.Wins++
+		}
+
+		trade := fmt.Sprintf("%s %s", pos.Symbol, pos.Side)
+		if report.Trades == 0 || pos.RealizedPnL > report.BestPnL {
+			report.BestTrade, report.BestPnL = trade, pos.RealizedPnL
+		}
+		if report.Trades == 0 || pos.RealizedPnL < report.WorstPnL {
+			report.WorstTrade, report.WorstPnL = trade, pos.RealizedPnL
+		}
+		report.Trades++
+	}
+	if report.Trades > 0 {
+		report.WinRate = float64(report.Wins) / float64(report.Trades) * 100
+	}
+
+	history, err := db.GetBalanceHistoryBetween(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取余额历史失败: %w", err)
+	}
+	withdrawals, err := db.GetWithdrawalsSince(start)
+	if err != nil {
+		return nil, fmt.Errorf("获取利润提取记录失败: %w", err)
+	}
+	var peak float64
+	var first time.Time
+	for _, h := range history {
+		equity := h.TotalBalance + h.UnrealizedPnL
+		if equity <= 0 {
+			continue
+		}
+		if peak == 0 {
+			report.StartEquity = equity
+			first = h.Timestamp
+		}
+		report.Withdrawn = withdrawals.Between(first, h.Timestamp)
+		performance := equity + report.Withdrawn
+		peak = math.Max(peak, performance)
+		report.MaxDrawdownPct = math.Max(report.MaxDrawdownPct, (peak-performance)/peak*100)
+		report.EndEquity = equity
+	}
+	return report, nil
+}
+
+// ReturnPct returns the equity change over the period in percent, counting swept profits as kept
+// (0 without balance history)
+// ReturnPct 返回周期内的资产变化百分比，已提取的利润计为收益（没有余额历史时为 0）
+func ReturnPct(r *storage.PerformanceReport) float64 {
+	if r.StartEquity <= 0 {
+		return 0
+	}
+	return ((r.EndEquity+r.Withdrawn)/r.StartEquity - 1) * 100
+}
+
+// Title returns the notification title of a report
+// Title 返回报告的通知标题
+func Title(r *storage.PerformanceReport) string {
+	if r.Period == PeriodWeekly {
+		return fmt.Sprintf("每周报告 %s ~ %s", r.PeriodStart.Format("2006-01-02"), r.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return fmt.Sprintf("每日报告 %s", r.PeriodStart.Format("2006-01-02"))
+}
+
+// Format renders a report as the notification text
+// Format 将报告渲染为通知正文
+func Format(r *storage.PerformanceReport) string {
+	var lines []string
+	if r.Trades > 0 {
+		lines = append(lines, fmt.Sprintf("平仓 %d 笔，盈利 %d 笔（胜率 %.0f%%）", r.Trades, r.Wins, r.WinRate))
+		lines = append(lines, fmt.Sprintf("已实现盈亏: %+.2f USDT（手续费 %.2f USDT）", r.RealizedPnL, r.Fees))
+		lines = append(lines, fmt.Sprintf("最佳交易: %s %+.2f USDT", r.BestTrade, r.BestPnL))
+		lines = append(lines, fmt.Sprintf("最差交易: %s %+.2f USDT", r.WorstTrade, r.WorstPnL))
+	} else {
+		lines = append(lines, "本周期没有平仓")
+	}
+	if r.EndEquity > 0 {
+		lines = append(lines, fmt.Sprintf("资产: %.2f → %.2f USDT（%+.2f%%），最大回撤 %.2f%%",
+			r.StartEquity, r.EndEquity, ReturnPct(r), r.MaxDrawdownPct))
+		if r.Withdrawn > 0 {
+			lines = append(lines, fmt.Sprintf("利润提取: %.2f USDT（已计入收益率）", r.Withdrawn))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Send generates the report of the last complete period, stores it and sends it via the notifier
+// Send 生成最近一个完整周期的报告，保存并通过通知渠道发送
+func Send(ctx context.Context, db *storage.Storage, notifier notify.Notifier, period string, now time.Time) (*storage.PerformanceReport, error) {
+	report, err := Generate(db, period, now)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.SaveReport(report); err != nil {
+		return report, fmt.Errorf("保存报告失败: %w", err)
+	}
+	return report, notifier.Notify(ctx, notify.Message{
+		Title: Title(report),
+		Text:  Format(report),
+		Level: notify.LevelInfo,
+	})
+}
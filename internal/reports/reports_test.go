@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// recordingNotifier keeps the messages it was asked to send
+// recordingNotifier 记录要求发送的消息
+type recordingNotifier struct {
+	messages []notify.Message
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// TestPeriodBounds tests the last complete day and week
+// TestPeriodBounds 测试最近一个完整的日和周
+func TestPeriodBounds(t *testing.T) {
+	// 2025-03-12 is a Wednesday / 2025-03-12 是周三
+	now := time.Date(2025, 3, 12, 8, 30, 0, 0, time.UTC)
+
+	start, end, err := PeriodBounds(PeriodDaily, now)
+	if err != nil || !start.Equal(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected daily bounds: %s ~ %s (%v)", start, end, err)
+	}
+
+	start, end, err = PeriodBounds(PeriodWeekly, now)
+	if err != nil || !start.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected weekly bounds: %s ~ %s (%v)", start, end, err)
+	}
+
+	// On a Sunday the current week isn't complete yet / 周日时本周尚未结束
+	start, _, _ = PeriodBounds(PeriodWeekly, time.Date(2025, 3, 16, 23, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the week of 2025-03-03 on Sunday, got %s", start)
+	}
+
+	if _, _, err := PeriodBounds("monthly", now); err == nil {
+		t.Error("Expected an error for an unknown period")
+	}
+}
+
+// TestSend tests aggregating closed trades and balance history into a stored and notified report
+// TestSend 测试将平仓交易和余额历史汇总为报告并保存、发送
+func TestSend(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "reports.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+	for i, p := range []struct {
+		symbol string
+		side   string
+		closed time.Time
+		pnl    float64
+		fee    float64
+	}{
+		{"BTCUSDT", "long", day.Add(2 * time.Hour), 30, 0.5},
+		{"ETHUSDT", "short", day.Add(10 * time.Hour), -12, 0.3},
+		{"BTCUSDT", "short", day.Add(20 * time.Hour), 5, 0.2},
+		{"SOLUSDT", "long", day.Add(25 * time.Hour), 100, 1}, // 下一天，不计入 / Next day, not included
+	} {
+		pos := &storage.PositionRecord{
+			ID: p.symbol + "-" + string(rune('a'+i)), Symbol: p.symbol, Side: p.side, EntryPrice: 100,
+			EntryTime: p.closed.Add(-time.Hour), Quantity: 1, Leverage: 10, StopLossType: "fixed",
+		}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+		closeTime := p.closed
+		pos.Closed, pos.CloseTime, pos.RealizedPnL = true, &closeTime, p.pnl
+		if err := db.UpdatePosition(pos); err != nil {
+			t.Fatalf("UpdatePosition failed: %v", err)
+		}
+		if err := db.SavePositionFees(pos.ID, p.fee); err != nil {
+			t.Fatalf("SavePositionFees failed: %v", err)
+		}
+	}
+
+	// Equity 1000 → 1100 → 990 → 1020: 10% drawdown from the peak
+	// 资产 1000 → 1100 → 990 → 1020：距最高点回撤 10%
+	for i, equity := range []float64{1000, 1100, 990, 1020} {
+		if err := db.SaveBalanceHistory(&storage.BalanceHistory{
+			Timestamp: day.Add(time.Duration(i*6) * time.Hour), TotalBalance: equity - 20, UnrealizedPnL: 20,
+		}); err != nil {
+			t.Fatalf("SaveBalanceHistory failed: %v", err)
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	report, err := Send(context.Background(), db, notifier, PeriodDaily, day.Add(30*time.Hour))
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if report.Trades != 3 || report.Wins != 2 || math.Abs(report.RealizedPnL-23) > 1e-9 || math.Abs(report.Fees-1) > 1e-9 {
+		t.Errorf("Unexpected trade totals: %+v", report)
+	}
+	if report.BestTrade != "BTCUSDT long" || report.BestPnL != 30 || report.WorstTrade != "ETHUSDT short" || report.WorstPnL != -12 {
+		t.Errorf("Unexpected best/worst trade: %+v", report)
+	}
+	if report.StartEquity != 1000 || report.EndEquity != 1020 || math.Abs(report.MaxDrawdownPct-10) > 1e-9 || math.Abs(ReturnPct(report)-2) > 1e-9 {
+		t.Errorf("Unexpected equity: %+v", report)
+	}
+
+	if len(notifier.messages) != 1 || notifier.messages[0].Title != "每日报告 2025-03-11" ||
+		!strings.Contains(notifier.messages[0].Text, "胜率 67%") {
+		t.Errorf("Unexpected notification: %+v", notifier.messages)
+	}
+
+	saved, err := db.GetReports(PeriodDaily, 10)
+	if err != nil || len(saved) != 1 || saved[0].Trades != 3 {
+		t.Fatalf("Expected the report to be stored, got %+v (%v)", saved, err)
+	}
+}
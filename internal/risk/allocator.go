@@ -0,0 +1,61 @@
+package risk
+
+import "sort"
+
+// Candidate is an opening decision competing for the account's free margin
+// Candidate 是争夺账户可用保证金的开仓决策
+type Candidate struct {
+	Symbol     string  // 交易对 / Trading pair
+	Confidence float64 // LLM 置信度 / LLM confidence
+	RiskReward float64 // 预期盈亏比（0 表示未给出）/ Expected risk/reward ratio (0 = not stated)
+	Margin     float64 // 按决策仓位占用的保证金 USDT / Margin the decision would use, in USDT
+}
+
+// Score ranks the candidate: confidence × risk/reward, with an unstated ratio counted as 1:1
+// Score 用于排序：置信度 × 盈亏比，未给出盈亏比时按 1:1 计算
+func (c Candidate) Score() float64 {
+	if c.RiskReward <= 0 {
+		return c.Confidence
+	}
+	return c.Confidence * c.RiskReward
+}
+
+// Allocation is the margin granted to one candidate
+// Allocation 是分配给单个候选决策的保证金
+type Allocation struct {
+	Candidate
+	Rank    int     // 排名（从 1 开始）/ Rank, starting at 1
+	Granted float64 // 分配的保证金 USDT（0 表示拒绝）/ Granted margin in USDT (0 = rejected)
+}
+
+// Trimmed reports whether the candidate got less margin than it asked for
+// Trimmed 返回分配的保证金是否少于申请的保证金
+func (a Allocation) Trimmed() bool {
+	return a.Granted < a.Margin
+}
+
+// Allocate hands out budget to the candidates from the best score down
+// Allocate 按评分从高到低将预算分配给候选决策
+//
+// Each candidate gets its full margin while the budget lasts; the first one that doesn't fit gets the remainder
+// and everything after it is rejected, so the combined margin never exceeds the budget.
+// 预算充足时每个候选获得全部保证金；第一个放不下的候选获得剩余预算，其后的候选全部拒绝，因此保证金合计不会超过预算。
+func Allocate(candidates []Candidate, budget float64) []Allocation {
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score() != ranked[j].Score() {
+			return ranked[i].Score() > ranked[j].Score()
+		}
+		return ranked[i].Symbol < ranked[j].Symbol
+	})
+
+	remaining := max(budget, 0)
+	allocations := make([]Allocation, len(ranked))
+	for i, c := range ranked {
+		granted := min(max(c.Margin, 0), remaining)
+		remaining -= granted
+		allocations[i] = Allocation{Candidate: c, Rank: i + 1, Granted: granted}
+	}
+	return allocations
+}
@@ -0,0 +1,35 @@
+package risk
+
+import "testing"
+
+// TestAllocate tests ranking by confidence × risk/reward and trimming once the budget runs out
+// TestAllocate 测试按置信度 × 盈亏比排序，以及预算用完后的缩减
+func TestAllocate(t *testing.T) {
+	allocations := Allocate([]Candidate{
+		{Symbol: "SOL/USDT", Confidence: 0.9, RiskReward: 1.5, Margin: 300}, // 1.35
+		{Symbol: "BTC/USDT", Confidence: 0.8, RiskReward: 2.5, Margin: 400}, // 2.0
+		{Symbol: "ETH/USDT", Confidence: 0.7, Margin: 200},                  // 0.7
+	}, 500)
+
+	want := []struct {
+		symbol  string
+		granted float64
+	}{
+		{"BTC/USDT", 400},
+		{"SOL/USDT", 100},
+		{"ETH/USDT", 0},
+	}
+	for i, w := range want {
+		a := allocations[i]
+		if a.Symbol != w.symbol || a.Granted != w.granted || a.Rank != i+1 {
+			t.Errorf("#%d: expected %s granted %.0f, got %s granted %.0f (rank %d)", i+1, w.symbol, w.granted, a.Symbol, a.Granted, a.Rank)
+		}
+	}
+	if allocations[0].Trimmed() || !allocations[1].Trimmed() || !allocations[2].Trimmed() {
+		t.Errorf("Unexpected trimmed flags: %+v", allocations)
+	}
+
+	if got := Allocate([]Candidate{{Symbol: "BTC/USDT", Confidence: 0.8, Margin: 100}}, -50); got[0].Granted != 0 {
+		t.Errorf("Expected nothing granted from a negative budget, got %.2f", got[0].Granted)
+	}
+}
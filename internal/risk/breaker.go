@@ -0,0 +1,173 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Failure kinds counted by the circuit breaker
+// 熔断器统计的失败类型
+const (
+	BreakerExchange = "exchange" // 币安下单失败 / Binance order failures
+	BreakerLLM      = "llm"      // LLM 决策失败（使用了规则后备）/ LLM decision failures (the rules fallback was used)
+)
+
+// breakerStateKey is the run_state key holding the circuit breaker state
+// breakerStateKey 是 run_state 中保存熔断器状态的键
+const breakerStateKey = "circuit_breaker"
+
+// breakerLabels name the failure kinds in logs and notifications
+// breakerLabels 是日志和通知中失败类型的名称
+var breakerLabels = map[string]string{
+	BreakerExchange: "币安下单",
+	BreakerLLM:      "LLM 决策",
+}
+
+// BreakerState is the persisted state of the circuit breaker
+// BreakerState 是熔断器持久化的状态
+type BreakerState struct {
+	Failures   map[string]int    `json:"failures"`             // 每种类型的连续失败次数 / Consecutive failures per kind
+	LastErrors map[string]string `json:"last_errors"`          // 每种类型最近一次失败 / Latest failure per kind
+	OpenUntil  time.Time         `json:"open_until,omitempty"` // 暂停自动执行到的时间 / Auto-execution is paused until
+	Reason     string            `json:"reason,omitempty"`     // 最近一次熔断原因 / Reason of the latest trip
+	Trips      int               `json:"trips"`                // 累计熔断次数 / Trips so far
+}
+
+// Breaker pauses auto-execution for a cool-down after CIRCUIT_BREAKER_THRESHOLD consecutive
+// Binance order failures or LLM failures
+// Breaker 在连续 CIRCUIT_BREAKER_THRESHOLD 次币安下单失败或 LLM 失败后，暂停自动执行一段冷却时间
+//
+// The state lives in run_state, so the count carries over between runs and the dashboard sees the same
+// state as the trading loop. Each kind counts on its own and a success of that kind resets it. Once the
+// cool-down is over the counts start again from zero. Existing stop-loss orders stay on the exchange
+// while execution is paused.
+// 状态保存在 run_state 中，因此计数在多次运行之间延续，控制台看到的状态与交易循环一致。每种类型单独计数，
+// 该类型成功一次即归零。冷却结束后重新从零计数。暂停执行期间交易所上已有的止损单保持不变。
+type Breaker struct {
+	Threshold int           // 触发熔断的连续失败次数（0 表示不启用）/ Consecutive failures that trip it (0 disables)
+	Cooldown  time.Duration // 暂停时长 / How long execution is paused
+	State     BreakerState
+
+	db *storage.Storage
+}
+
+// LoadBreaker loads the circuit breaker state from storage
+// LoadBreaker 从数据库加载熔断器状态
+func LoadBreaker(db *storage.Storage, cfg *config.Config) (*Breaker, error) {
+	b := &Breaker{
+		Threshold: cfg.CircuitBreakerThreshold,
+		Cooldown:  time.Duration(cfg.CircuitBreakerCooldown) * time.Minute,
+		db:        db,
+	}
+	raw, err := db.GetRunState(breakerStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &b.State); err != nil {
+			return nil, fmt.Errorf("failed to decode circuit breaker state: %w", err)
+		}
+	}
+	if b.State.Failures == nil {
+		b.State.Failures = make(map[string]int)
+	}
+	if b.State.LastErrors == nil {
+		b.State.LastErrors = make(map[string]string)
+	}
+	return b, nil
+}
+
+// Enabled reports whether the breaker is configured (nil-safe)
+// Enabled 返回熔断器是否启用（nil 安全）
+func (b *Breaker) Enabled() bool {
+	return b != nil && b.Threshold > 0
+}
+
+// Open reports whether auto-execution is paused at now (nil-safe)
+// Open 返回 now 时自动执行是否处于暂停状态（nil 安全）
+func (b *Breaker) Open(now time.Time) bool {
+	return b.Enabled() && now.Before(b.State.OpenUntil)
+}
+
+// RecordFailure counts a failure of a kind and returns true when it trips the breaker
+// RecordFailure 记录一次某类型的失败，触发熔断时返回 true
+func (b *Breaker) RecordFailure(kind, detail string, now time.Time) (bool, error) {
+	if !b.Enabled() {
+		return false, nil
+	}
+
+	// The cool-down is over: failures count again from zero
+	// 冷却已结束：失败重新从零计数
+	if !b.State.OpenUntil.IsZero() && !now.Before(b.State.OpenUntil) {
+		b.State.Failures = make(map[string]int)
+		b.State.OpenUntil = time.Time{}
+	}
+
+	b.State.Failures[kind]++
+	b.State.LastErrors[kind] = detail
+	tripped := false
+	if n := b.State.Failures[kind]; n >= b.Threshold && !b.Open(now) {
+		b.State.OpenUntil = now.Add(b.Cooldown)
+		b.State.Reason = fmt.Sprintf("%s连续失败 %d 次: %s", breakerLabels[kind], n, detail)
+		b.State.Trips++
+		tripped = true
+	}
+	return tripped, b.save()
+}
+
+// RecordSuccess resets the consecutive failures of a kind
+// RecordSuccess 将某类型的连续失败次数归零
+//
+// A success doesn't end a pause early; the cool-down always runs out.
+// 成功不会提前结束暂停，冷却时间总会完整执行。
+func (b *Breaker) RecordSuccess(kind string) error {
+	if !b.Enabled() || b.State.Failures[kind] == 0 {
+		return nil
+	}
+	b.State.Failures[kind] = 0
+	return b.save()
+}
+
+// save writes the state to run_state
+// save 将状态写入 run_state
+func (b *Breaker) save() error {
+	raw, err := json.Marshal(b.State)
+	if err != nil {
+		return err
+	}
+	return b.db.SetRunState(breakerStateKey, string(raw))
+}
+
+// BreakerStatus is the breaker state shown on /api/health and the dashboard
+// BreakerStatus 是在 /api/health 和控制台展示的熔断器状态
+type BreakerStatus struct {
+	Enabled   bool           `json:"enabled"`
+	Open      bool           `json:"open"`                 // 是否暂停自动执行 / Whether auto-execution is paused
+	OpenUntil time.Time      `json:"open_until,omitempty"` // 暂停结束时间 / When the pause ends
+	Reason    string         `json:"-"`                    // 熔断原因（含错误信息，不公开）/ Trip reason (holds error details, not public)
+	Failures  map[string]int `json:"failures"`             // 每种类型的连续失败次数 / Consecutive failures per kind
+	Threshold int            `json:"threshold"`
+}
+
+// Status returns the breaker state at now (nil-safe)
+// Status 返回 now 时的熔断器状态（nil 安全）
+func (b *Breaker) Status(now time.Time) BreakerStatus {
+	if !b.Enabled() {
+		return BreakerStatus{}
+	}
+	status := BreakerStatus{
+		Enabled:   true,
+		Open:      b.Open(now),
+		Failures:  b.State.Failures,
+		Threshold: b.Threshold,
+	}
+	if status.Open {
+		status.OpenUntil = b.State.OpenUntil
+		status.Reason = b.State.Reason
+	}
+	return status
+}
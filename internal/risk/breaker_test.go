@@ -0,0 +1,113 @@
+package risk
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestBreakerTrip tests tripping after consecutive failures, reset by a success and the cool-down
+// TestBreakerTrip 测试连续失败后熔断、成功后归零以及冷却时间
+func TestBreakerTrip(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "breaker.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{CircuitBreakerThreshold: 3, CircuitBreakerCooldown: 60}
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	breaker, err := LoadBreaker(db, cfg)
+	if err != nil {
+		t.Fatalf("LoadBreaker failed: %v", err)
+	}
+
+	// 成功会中断连续失败
+	breaker.RecordFailure(BreakerExchange, "timeout", now)
+	breaker.RecordFailure(BreakerExchange, "timeout", now)
+	if err := breaker.RecordSuccess(BreakerExchange); err != nil {
+		t.Fatalf("RecordSuccess failed: %v", err)
+	}
+	breaker.RecordFailure(BreakerExchange, "timeout", now)
+	breaker.RecordFailure(BreakerLLM, "LLM failed", now)
+	if breaker.Open(now) {
+		t.Fatalf("Expected no trip after a success, got %+v", breaker.State)
+	}
+
+	// 计数跨运行保存
+	breaker, err = LoadBreaker(db, cfg)
+	if err != nil {
+		t.Fatalf("LoadBreaker failed: %v", err)
+	}
+	if breaker.State.Failures[BreakerExchange] != 1 || breaker.State.Failures[BreakerLLM] != 1 {
+		t.Fatalf("Expected the counts to be restored, got %+v", breaker.State.Failures)
+	}
+
+	breaker.RecordFailure(BreakerExchange, "timeout", now)
+	tripped, err := breaker.RecordFailure(BreakerExchange, "insufficient margin", now)
+	if err != nil {
+		t.Fatalf("RecordFailure failed: %v", err)
+	}
+	if !tripped || !breaker.Open(now) {
+		t.Fatalf("Expected a trip on the third failure, got %+v", breaker.State)
+	}
+	if !strings.Contains(breaker.State.Reason, "insufficient margin") {
+		t.Errorf("Expected the last error in the reason, got %q", breaker.State.Reason)
+	}
+
+	// 暂停期间的失败不会重复熔断
+	if tripped, _ := breaker.RecordFailure(BreakerExchange, "timeout", now.Add(time.Minute)); tripped {
+		t.Error("Expected no second trip while open")
+	}
+
+	status := breaker.Status(now.Add(59 * time.Minute))
+	if !status.Open || !status.OpenUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected open until %s, got %+v", now.Add(time.Hour), status)
+	}
+
+	// 冷却结束后重新计数
+	later := now.Add(time.Hour)
+	if breaker.Open(later) {
+		t.Error("Expected the breaker to close after the cool-down")
+	}
+	if tripped, _ := breaker.RecordFailure(BreakerExchange, "timeout", later); tripped {
+		t.Error("Expected counts to restart after the cool-down")
+	}
+	if breaker.State.Failures[BreakerExchange] != 1 || breaker.State.Trips != 1 {
+		t.Errorf("Expected a fresh count, got %+v", breaker.State)
+	}
+}
+
+// TestBreakerDisabled tests that a zero threshold or a nil breaker never pauses execution
+// TestBreakerDisabled 测试阈值为 0 或 nil 熔断器时不会暂停执行
+func TestBreakerDisabled(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "breaker.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	breaker, err := LoadBreaker(db, &config.Config{CircuitBreakerCooldown: 60})
+	if err != nil {
+		t.Fatalf("LoadBreaker failed: %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if tripped, _ := breaker.RecordFailure(BreakerLLM, "LLM failed", now); tripped {
+			t.Fatal("Expected a disabled breaker not to trip")
+		}
+	}
+	if breaker.Open(now) || breaker.Status(now).Enabled {
+		t.Errorf("Expected a disabled breaker, got %+v", breaker.Status(now))
+	}
+
+	var unloaded *Breaker
+	if unloaded.Open(now) || unloaded.Status(now).Open {
+		t.Error("Expected a nil breaker to leave execution alone")
+	}
+}
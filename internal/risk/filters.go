@@ -0,0 +1,69 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Execution filter names, saved with the rejected session
+// 执行过滤器名称，随被拒绝的会话一起保存
+const (
+	FilterMinConfidence   = "min_confidence"
+	FilterStopOutCooldown = "stop_out_cooldown"
+	FilterMaxTradesPerDay = "max_trades_per_day"
+)
+
+// FilterRejection is the execution filter that refused an entry and why
+// FilterRejection 表示拒绝开仓的执行过滤器及原因
+type FilterRejection struct {
+	Filter string // 过滤器名称 / Filter name
+	Reason string // 拒绝原因 / Why the entry was refused
+}
+
+// CheckExecutionFilters runs the entry filters in order: minimum confidence, the cooldown after a stop-out
+// on the same symbol and the daily trade cap (nil when the entry passes)
+// CheckExecutionFilters 依次检查开仓过滤器：最低置信度、同一交易对止损后的冷却期和每日开仓上限（通过时返回 nil）
+//
+// The day is the UTC calendar day, unlike the rolling 24h window of the trade frequency target.
+// 每日按 UTC 自然日计算，与交易频率目标的滚动 24 小时窗口不同。
+func CheckExecutionFilters(db *storage.Storage, cfg *config.Config, symbol string, confidence float64, now time.Time) (*FilterRejection, error) {
+	if cfg.MinDecisionConfidence > 0 && confidence < cfg.MinDecisionConfidence {
+		return &FilterRejection{
+			Filter: FilterMinConfidence,
+			Reason: fmt.Sprintf("置信度 %.2f 低于最低要求 %.2f", confidence, cfg.MinDecisionConfidence),
+		}, nil
+	}
+
+	if cfg.StopOutCooldownMinutes > 0 {
+		last, err := db.GetLastStopOut(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			until := last.Add(time.Duration(cfg.StopOutCooldownMinutes) * time.Minute)
+			if now.Before(until) {
+				return &FilterRejection{
+					Filter: FilterStopOutCooldown,
+					Reason: fmt.Sprintf("%s 止损后冷却中，%s 前不开仓", symbol, until.Local().Format("01-02 15:04")),
+				}, nil
+			}
+		}
+	}
+
+	if cfg.MaxTradesPerDay > 0 {
+		trades, err := db.CountPositionsOpenedSince(SessionStart(now, 0))
+		if err != nil {
+			return nil, err
+		}
+		if trades >= cfg.MaxTradesPerDay {
+			return &FilterRejection{
+				Filter: FilterMaxTradesPerDay,
+				Reason: fmt.Sprintf("今日（UTC）已开仓 %d 笔，达到上限 %d", trades, cfg.MaxTradesPerDay),
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+package risk
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestCheckExecutionFilters tests the confidence floor, the stop-out cooldown and the daily trade cap
+// TestCheckExecutionFilters 测试置信度下限、止损后冷却期和每日开仓上限
+func TestCheckExecutionFilters(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "filters.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	stoppedAt := now.Add(-30 * time.Minute)
+	pos := &storage.PositionRecord{
+		ID: "BTCUSDT-1", Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, EntryTime: now.Add(-2 * time.Hour),
+		Quantity: 1, Leverage: 5, InitialStopLoss: 95, CurrentStopLoss: 95, StopLossType: "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+	pos.Closed, pos.CloseTime, pos.CloseReason, pos.RealizedPnL = true, &stoppedAt, "止损单触发（币安自动执行）", -5
+	if err := db.UpdatePosition(pos); err != nil {
+		t.Fatalf("UpdatePosition failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		symbol     string
+		confidence float64
+		want       string
+	}{
+		{"all disabled", config.Config{}, "BTC/USDT", 0.1, ""},
+		{"low confidence", config.Config{MinDecisionConfidence: 0.75}, "BTC/USDT", 0.6, FilterMinConfidence},
+		{"in cooldown", config.Config{StopOutCooldownMinutes: 60}, "BTC/USDT", 0.9, FilterStopOutCooldown},
+		{"cooldown over", config.Config{StopOutCooldownMinutes: 20}, "BTC/USDT", 0.9, ""},
+		{"other symbol", config.Config{StopOutCooldownMinutes: 60}, "ETH/USDT", 0.9, ""},
+		{"daily cap reached", config.Config{MaxTradesPerDay: 1}, "ETH/USDT", 0.9, FilterMaxTradesPerDay},
+		{"below daily cap", config.Config{MaxTradesPerDay: 2}, "ETH/USDT", 0.9, ""},
+	}
+	for _, tt := range tests {
+		rejection, err := CheckExecutionFilters(db, &tt.cfg, tt.symbol, tt.confidence, now)
+		if err != nil {
+			t.Fatalf("%s: CheckExecutionFilters failed: %v", tt.name, err)
+		}
+		got := ""
+		if rejection != nil {
+			got = rejection.Filter
+		}
+		if got != tt.want {
+			t.Errorf("%s: filter = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// 前一个 UTC 自然日的开仓不计入今日上限
+	cfg := &config.Config{MaxTradesPerDay: 1}
+	if rejection, _ := CheckExecutionFilters(db, cfg, "ETH/USDT", 0.9, now.AddDate(0, 0, 1)); rejection != nil {
+		t.Errorf("Expected yesterday's entry not to count, got %+v", rejection)
+	}
+}
@@ -0,0 +1,210 @@
+package risk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// StrategyDescription lists the prompt and risk settings that define the trading strategy
+// StrategyDescription 列出定义交易策略的 Prompt 和风控配置
+func StrategyDescription(cfg *config.Config) string {
+	return fmt.Sprintf("prompt=%s leverage=%d-%d dynamic=%v max_positions=%d max_notional=%.2f equity_at_risk=%.2f daily_loss=%.2f sizing=%s/%.2f/%.2f%%/%.2fATR partial_tp=%v/%.2fR/%.0f%% trailing=%v/%.2f thresholds=%.2f/%.2f",
+		cfg.TraderPromptPath, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax, cfg.BinanceLeverageDynamic,
+		cfg.RiskMaxPositions, cfg.RiskMaxNotionalPerSymbol, cfg.RiskMaxEquityAtRisk, cfg.RiskDailyMaxLoss,
+		cfg.SizingPolicy, cfg.SizingFixedCapital, cfg.SizingRiskPerTrade, cfg.SizingATRMultiplier,
+		cfg.PartialTPEnabled, cfg.PartialTPRMultiple, cfg.PartialTPPercent,
+		cfg.TrailingStopEnabled, cfg.TrailingStopATRMultiplier, cfg.MinDecisionConfidence, cfg.MinRiskReward)
+}
+
+// StrategyFingerprint hashes the trader prompt contents, the per-symbol prompts and the risk settings
+// StrategyFingerprint 计算交易员 Prompt 内容、交易对专属 Prompt 和风控配置的哈希
+//
+// A missing prompt file hashes as empty (the built-in prompt is used).
+// Prompt 文件不存在时按空内容计算（使用内置 Prompt）。
+func StrategyFingerprint(cfg *config.Config) string {
+	h := sha256.New()
+	if prompt, err := os.ReadFile(cfg.TraderPromptPath); err == nil {
+		h.Write(prompt)
+	}
+	for _, symbol := range cfg.CryptoSymbols {
+		if prompt, err := os.ReadFile(cfg.SymbolPromptPath(symbol)); err == nil {
+			h.Write([]byte{0})
+			h.Write([]byte(symbol))
+			h.Write(prompt)
+		}
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(StrategyDescription(cfg)))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FreezeStatus is the freeze window state of the current cycle
+// FreezeStatus 表示当前周期的冻结观察期状态
+type FreezeStatus struct {
+	Version  *storage.StrategyVersion // 当前策略版本 / Current strategy version
+	Previous *storage.StrategyVersion // 上一个策略版本（可为 nil）/ Previous strategy version (may be nil)
+	Changed  bool                     // 本周期检测到配置变更 / Configuration change detected this cycle
+	Err      error                    // 版本检查失败（本周期以影子模式运行）/ Version check failure (the cycle runs shadow-only)
+}
+
+// FailedFreezeCheck returns the status of a cycle whose version check failed
+// FailedFreezeCheck 返回版本检查失败的周期状态
+//
+// An unchecked configuration may be a new one, so the cycle runs shadow-only; it doesn't count
+// toward any observation window.
+// 未检查的配置可能是新配置，因此本周期以影子模式运行，且不计入任何观察期。
+func FailedFreezeCheck(err error) *FreezeStatus {
+	return &FreezeStatus{Err: err}
+}
+
+// Active reports whether this cycle must run shadow-only (nil-safe)
+// Active 返回本周期是否只能以影子模式运行（nil 安全）
+func (f *FreezeStatus) Active() bool {
+	return f != nil && (f.Err != nil || f.Version.Frozen())
+}
+
+// Cycle returns the number of the current shadow cycle, starting at 1
+// Cycle 返回当前影子周期的序号（从 1 开始）
+func (f *FreezeStatus) Cycle() int {
+	return f.Version.ShadowCycles + 1
+}
+
+// Label describes the shadow cycle for logs and execution records
+// Label 描述影子周期，用于日志和执行记录
+func (f *FreezeStatus) Label() string {
+	if f.Err != nil {
+		return "策略版本检查失败"
+	}
+	return fmt.Sprintf("观察期 %d/%d", f.Cycle(), f.Version.FreezeCycles)
+}
+
+// CheckFreeze detects prompt / risk configuration changes and returns the freeze state of this cycle
+// CheckFreeze 检测 Prompt / 风控配置变更并返回本周期的冻结状态
+//
+// A change starts an observation window of FREEZE_CYCLES shadow-only cycles (0 only records the
+// new version). The first version ever seen is recorded as the baseline without a window.
+// 配置变更会开启 FREEZE_CYCLES 个仅影子模式的观察周期（0 表示只记录新版本）。
+// 首次记录的版本作为基准，不进入观察期。
+func CheckFreeze(db *storage.Storage, cfg *config.Config) (*FreezeStatus, error) {
+	versions, err := db.GetStrategyVersions(2)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := StrategyFingerprint(cfg)
+	if len(versions) > 0 && versions[0].Fingerprint == fingerprint {
+		status := &FreezeStatus{Version: versions[0]}
+		if len(versions) > 1 {
+			status.Previous = versions[1]
+		}
+		return status, nil
+	}
+
+	now := time.Now()
+	version := &storage.StrategyVersion{
+		Fingerprint: fingerprint,
+		Description: StrategyDescription(cfg),
+		CreatedAt:   now,
+	}
+	status := &FreezeStatus{Version: version}
+	if len(versions) > 0 {
+		version.FreezeCycles = cfg.FreezeCycles
+		status.Previous = versions[0]
+		status.Changed = true
+	}
+	if !version.Frozen() {
+		version.ReleasedAt = now
+	}
+	if _, err := db.SaveStrategyVersion(version); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// CompleteShadowCycle records a finished shadow cycle; when the window ends it releases live
+// execution and returns the comparison summary (empty while the window is still running)
+// CompleteShadowCycle 记录完成的影子周期；观察期结束时恢复实盘执行并返回对比摘要（观察期内返回空）
+//
+// Cycles run shadow-only because the version check failed are not counted.
+// 因版本检查失败而以影子模式运行的周期不计入。
+func CompleteShadowCycle(db *storage.Storage, status *FreezeStatus) (string, error) {
+	if status.Err != nil {
+		return "", nil
+	}
+	version := status.Version
+	version.ShadowCycles++
+	if version.Frozen() {
+		return "", db.UpdateStrategyVersion(version)
+	}
+
+	shadow, err := db.GetDecisionMix(version.CreatedAt, time.Time{})
+	if err != nil {
+		return "", err
+	}
+	var previous *storage.DecisionMix
+	if status.Previous != nil {
+		if previous, err = db.GetDecisionMix(status.Previous.CreatedAt, version.CreatedAt); err != nil {
+			return "", err
+		}
+	}
+
+	version.Summary = FreezeSummary(previous, shadow)
+	version.ReleasedAt = time.Now()
+	if err := db.UpdateStrategyVersion(version); err != nil {
+		return "", err
+	}
+	return version.Summary, nil
+}
+
+// FreezeSummary compares the shadow decisions of the new configuration with the live decisions of the previous one
+// FreezeSummary 对比新配置的影子决策与上一配置的实盘决策
+func FreezeSummary(previous, shadow *storage.DecisionMix) string {
+	var b strings.Builder
+	b.WriteString("策略观察期结束，对比（上一配置 → 新配置影子决策）:\n")
+	if previous == nil || previous.Count == 0 {
+		previous = &storage.DecisionMix{Actions: map[string]int{}}
+		b.WriteString("  上一配置没有可对比的决策\n")
+	}
+
+	fmt.Fprintf(&b, "  决策数: %d → %d\n", previous.Count, shadow.Count)
+
+	actions := make(map[string]bool)
+	for action := range previous.Actions {
+		actions[action] = true
+	}
+	for action := range shadow.Actions {
+		actions[action] = true
+	}
+	names := make([]string, 0, len(actions))
+	for action := range actions {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	for _, action := range names {
+		fmt.Fprintf(&b, "  %s: %.0f%% → %.0f%%\n", action,
+			share(previous.Actions[action], previous.Count), share(shadow.Actions[action], shadow.Count))
+	}
+
+	fmt.Fprintf(&b, "  平均置信度: %.2f → %.2f\n", previous.AvgConfidence, shadow.AvgConfidence)
+	fmt.Fprintf(&b, "  开仓平均杠杆: %.1fx → %.1fx\n", previous.AvgLeverage, shadow.AvgLeverage)
+	fmt.Fprintf(&b, "  开仓平均仓位: %.1f%% → %.1f%%\n", previous.AvgPosition, shadow.AvgPosition)
+	b.WriteString("已恢复实盘执行")
+	return b.String()
+}
+
+// share returns n as a percentage of total
+// share 返回 n 占 total 的百分比
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
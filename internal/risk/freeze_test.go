@@ -0,0 +1,144 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestFreezeWindow tests that a prompt change freezes live execution for FREEZE_CYCLES cycles
+// TestFreezeWindow 测试 Prompt 变更后冻结实盘执行 FREEZE_CYCLES 个周期
+func TestFreezeWindow(t *testing.T) {
+	dir := t.TempDir()
+	db, err := storage.NewStorage(filepath.Join(dir, "freeze.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	prompt := filepath.Join(dir, "trader.txt")
+	if err := os.WriteFile(prompt, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{TraderPromptPath: prompt, FreezeCycles: 2, BinanceLeverageMin: 10, BinanceLeverageMax: 10}
+
+	// 首次运行只记录基准版本
+	status, err := CheckFreeze(db, cfg)
+	if err != nil {
+		t.Fatalf("CheckFreeze failed: %v", err)
+	}
+	if status.Active() || status.Changed {
+		t.Fatalf("Expected the baseline not to be frozen: %+v", status.Version)
+	}
+
+	// 配置未变更
+	if status, _ = CheckFreeze(db, cfg); status.Active() || status.Changed {
+		t.Fatalf("Expected no change")
+	}
+
+	// Prompt 内容变更开启观察期
+	if err := os.WriteFile(prompt, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for cycle := 1; cycle <= 2; cycle++ {
+		status, err = CheckFreeze(db, cfg)
+		if err != nil {
+			t.Fatalf("CheckFreeze failed: %v", err)
+		}
+		if !status.Active() || status.Cycle() != cycle || status.Changed != (cycle == 1) {
+			t.Fatalf("Cycle %d: unexpected status %+v", cycle, status.Version)
+		}
+		summary, err := CompleteShadowCycle(db, status)
+		if err != nil {
+			t.Fatalf("CompleteShadowCycle failed: %v", err)
+		}
+		if (summary != "") != (cycle == 2) {
+			t.Fatalf("Cycle %d: unexpected summary %q", cycle, summary)
+		}
+	}
+
+	// 观察期结束后恢复实盘执行
+	if status, _ = CheckFreeze(db, cfg); status.Active() {
+		t.Fatalf("Expected live execution after the window")
+	}
+
+	// 风控配置变更同样触发观察期
+	cfg.BinanceLeverageMax = 20
+	if status, _ = CheckFreeze(db, cfg); !status.Active() {
+		t.Fatalf("Expected a risk change to freeze execution")
+	}
+}
+
+// TestFreezeDisabled tests that FREEZE_CYCLES=0 records the change without freezing
+// TestFreezeDisabled 测试 FREEZE_CYCLES=0 时只记录变更不冻结
+func TestFreezeDisabled(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "freeze.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{BinanceLeverageMax: 10}
+	if _, err := CheckFreeze(db, cfg); err != nil {
+		t.Fatalf("CheckFreeze failed: %v", err)
+	}
+	cfg.BinanceLeverageMax = 20
+	status, err := CheckFreeze(db, cfg)
+	if err != nil {
+		t.Fatalf("CheckFreeze failed: %v", err)
+	}
+	if !status.Changed || status.Active() {
+		t.Fatalf("Expected a recorded, unfrozen change")
+	}
+
+	var nilStatus *FreezeStatus
+	if nilStatus.Active() {
+		t.Fatalf("Expected nil status to be inactive")
+	}
+}
+
+// TestFreezeSummary tests the comparison summary
+// TestFreezeSummary 测试对比摘要
+func TestFreezeSummary(t *testing.T) {
+	previous := &storage.DecisionMix{Count: 4, Actions: map[string]int{"BUY": 1, "HOLD": 3}, AvgLeverage: 10}
+	shadow := &storage.DecisionMix{Count: 2, Actions: map[string]int{"SELL": 2}, AvgLeverage: 5}
+
+	summary := FreezeSummary(previous, shadow)
+	for _, want := range []string{"决策数: 4 → 2", "BUY: 25% → 0%", "HOLD: 75% → 0%", "SELL: 0% → 100%", "10.0x → 5.0x"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Summary missing %q:\n%s", want, summary)
+		}
+	}
+	if !strings.Contains(FreezeSummary(nil, shadow), "没有可对比的决策") {
+		t.Errorf("Expected a note when there is nothing to compare")
+	}
+}
+
+// TestFailedFreezeCheck tests that a failed version check runs the cycle shadow-only without counting it
+// TestFailedFreezeCheck 测试版本检查失败时本周期以影子模式运行且不计入观察期
+func TestFailedFreezeCheck(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "freeze.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	db.Close()
+
+	_, err = CheckFreeze(db, &config.Config{})
+	if err == nil {
+		t.Fatal("Expected CheckFreeze to fail on a closed database")
+	}
+	status := FailedFreezeCheck(err)
+	if !status.Active() {
+		t.Fatal("Expected a failed check to run shadow-only")
+	}
+	if !strings.Contains(status.Label(), "检查失败") {
+		t.Errorf("Unexpected label %q", status.Label())
+	}
+	if summary, err := CompleteShadowCycle(db, status); err != nil || summary != "" {
+		t.Errorf("Expected the failed cycle not to be counted, got %q (%v)", summary, err)
+	}
+}
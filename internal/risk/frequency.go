@@ -0,0 +1,59 @@
+package risk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// tradeFrequencyWindow is the rolling window trades are counted over
+// tradeFrequencyWindow 是统计开仓次数的滚动窗口
+const tradeFrequencyWindow = 24 * time.Hour
+
+// TradeFrequency is the number of positions opened in the last 24 hours against the target range
+// TradeFrequency 表示最近 24 小时的开仓次数及其目标范围
+type TradeFrequency struct {
+	Trades int // 最近 24 小时开仓次数 / Positions opened in the last 24 hours
+	Min    int // 目标下限（0 表示不启用）/ Lower bound (0 disables)
+	Max    int // 目标上限（0 表示不启用）/ Upper bound (0 disables)
+}
+
+// CheckTradeFrequency counts the positions opened in the 24 hours before now
+// CheckTradeFrequency 统计 now 之前 24 小时内的开仓次数
+func CheckTradeFrequency(db *storage.Storage, cfg *config.Config, now time.Time) (*TradeFrequency, error) {
+	trades, err := db.CountPositionsOpenedSince(now.Add(-tradeFrequencyWindow))
+	if err != nil {
+		return nil, err
+	}
+	return &TradeFrequency{Trades: trades, Min: cfg.TradesPerDayMin, Max: cfg.TradesPerDayMax}, nil
+}
+
+// Overtrading reports whether more positions were opened than the upper bound (nil-safe)
+// Overtrading 返回开仓次数是否超过上限（nil 安全）
+func (f *TradeFrequency) Overtrading() bool {
+	return f != nil && f.Max > 0 && f.Trades > f.Max
+}
+
+// Undertrading reports whether fewer positions were opened than the lower bound (nil-safe)
+// Undertrading 返回开仓次数是否低于下限（nil 安全）
+func (f *TradeFrequency) Undertrading() bool {
+	return f != nil && f.Min > 0 && f.Trades < f.Min
+}
+
+// Target describes the configured range, e.g. "2-6", "≤6" or "≥2" (empty when disabled)
+// Target 描述配置的目标范围，例如 "2-6"、"≤6" 或 "≥2"（未启用时为空）
+func (f *TradeFrequency) Target() string {
+	switch {
+	case f == nil:
+		return ""
+	case f.Min > 0 && f.Max > 0:
+		return fmt.Sprintf("%d-%d", f.Min, f.Max)
+	case f.Max > 0:
+		return fmt.Sprintf("≤%d", f.Max)
+	case f.Min > 0:
+		return fmt.Sprintf("≥%d", f.Min)
+	}
+	return ""
+}
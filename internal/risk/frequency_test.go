@@ -0,0 +1,76 @@
+package risk
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestCheckTradeFrequency tests counting the positions opened in the last 24 hours
+// TestCheckTradeFrequency 测试统计最近 24 小时的开仓次数
+func TestCheckTradeFrequency(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "frequency.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	// Three positions today (one already closed) and one from two days ago
+	// 今天开仓三次（其中一笔已平仓），两天前开仓一次
+	for i, age := range []time.Duration{time.Hour, 3 * time.Hour, 20 * time.Hour, 48 * time.Hour} {
+		if err := db.SavePosition(&storage.PositionRecord{
+			ID:        fmt.Sprintf("BTCUSDT-%d", i),
+			Symbol:    "BTCUSDT",
+			Side:      "long",
+			EntryTime: now.Add(-age),
+			Closed:    i == 1,
+		}); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+	}
+
+	freq, err := CheckTradeFrequency(db, &config.Config{TradesPerDayMin: 1, TradesPerDayMax: 2}, now)
+	if err != nil {
+		t.Fatalf("CheckTradeFrequency failed: %v", err)
+	}
+	if freq.Trades != 3 {
+		t.Errorf("Trades = %d, want 3", freq.Trades)
+	}
+	if !freq.Overtrading() || freq.Undertrading() {
+		t.Errorf("3 trades against 1-2 should be overtrading, got %+v", freq)
+	}
+}
+
+// TestTradeFrequencyBounds tests the target range checks
+// TestTradeFrequencyBounds 测试目标范围判断
+func TestTradeFrequencyBounds(t *testing.T) {
+	tests := []struct {
+		freq        *TradeFrequency
+		over, under bool
+		target      string
+	}{
+		{&TradeFrequency{Trades: 7, Min: 2, Max: 6}, true, false, "2-6"},
+		{&TradeFrequency{Trades: 6, Min: 2, Max: 6}, false, false, "2-6"},
+		{&TradeFrequency{Trades: 1, Min: 2, Max: 6}, false, true, "2-6"},
+		{&TradeFrequency{Trades: 50}, false, false, ""}, // 未启用 / Disabled
+		{&TradeFrequency{Trades: 0, Min: 1}, false, true, "≥1"},
+		{&TradeFrequency{Trades: 4, Max: 3}, true, false, "≤3"},
+		{nil, false, false, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.freq.Overtrading(); got != tt.over {
+			t.Errorf("%+v Overtrading() = %v, want %v", tt.freq, got, tt.over)
+		}
+		if got := tt.freq.Undertrading(); got != tt.under {
+			t.Errorf("%+v Undertrading() = %v, want %v", tt.freq, got, tt.under)
+		}
+		if got := tt.freq.Target(); got != tt.target {
+			t.Errorf("%+v Target() = %q, want %q", tt.freq, got, tt.target)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package risk
+
+import (
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// LossStreak is the intraday de-risking ladder: entries are halved after LOSS_STREAK_REDUCE consecutive
+// losing trades and halted for the rest of the session after LOSS_STREAK_HALT
+// LossStreak 是日内降风险阶梯：连续亏损 LOSS_STREAK_REDUCE 笔后开仓减半，连续亏损 LOSS_STREAK_HALT 笔后
+// 本交易时段剩余时间停止开仓
+type LossStreak struct {
+	Current      int       // 当前连续亏损笔数（盈利后归零）/ Current run of losing trades (reset by a win)
+	Longest      int       // 本时段最长连续亏损笔数 / Longest run of the session
+	Reduce       int       // 减半阈值（0 表示不启用）/ Halving threshold (0 disables)
+	Halt         int       // 停止开仓阈值（0 表示不启用）/ Halt threshold (0 disables)
+	SessionStart time.Time // 本交易时段开始时间 / Start of the current session
+}
+
+// SessionStart returns the latest session boundary at the given UTC hour at or before now
+// SessionStart 返回 now 及之前最近一次位于指定 UTC 小时的交易时段边界
+func SessionStart(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// CheckLossStreak counts the consecutive losing trades closed in the current session
+// CheckLossStreak 统计本交易时段内平仓交易的连续亏损笔数
+func CheckLossStreak(db *storage.Storage, cfg *config.Config, now time.Time) (*LossStreak, error) {
+	start := SessionStart(now, cfg.LossStreakSessionHour)
+	closed, err := db.GetPositionsClosedSince(start)
+	if err != nil {
+		return nil, err
+	}
+	return LossStreakFrom(closed, cfg, start), nil
+}
+
+// LossStreakFrom builds the ladder state from the session's closed positions, oldest first
+// LossStreakFrom 根据本时段已平仓的持仓（按平仓时间正序）构建阶梯状态
+//
+// A trade with a negative realized PnL is a loss; anything else ends the run.
+// 已实现盈亏为负的交易视为亏损，其他情况都会中断连亏。
+func LossStreakFrom(closed []*storage.PositionRecord, cfg *config.Config, sessionStart time.Time) *LossStreak {
+	streak := &LossStreak{Reduce: cfg.LossStreakReduce, Halt: cfg.LossStreakHalt, SessionStart: sessionStart}
+	for _, pos := range closed {
+		if pos.RealizedPnL >= 0 {
+			streak.Current = 0
+			continue
+		}
+		streak.Current++
+		if streak.Current > streak.Longest {
+			streak.Longest = streak.Current
+		}
+	}
+	return streak
+}
+
+// Halted reports whether entries are halted for the rest of the session (nil-safe)
+// Halted 返回本时段剩余时间是否停止开仓（nil 安全）
+//
+// The halt is sticky: a later win in the same session doesn't lift it.
+// 停止开仓一旦触发，本时段内之后的盈利交易也不会解除。
+func (s *LossStreak) Halted() bool {
+	return s != nil && s.Halt > 0 && s.Longest >= s.Halt
+}
+
+// Reduced reports whether entries are halved by the current run of losses (nil-safe)
+// Reduced 返回当前连亏是否使开仓减半（nil 安全）
+func (s *LossStreak) Reduced() bool {
+	return s != nil && !s.Halted() && s.Reduce > 0 && s.Current >= s.Reduce
+}
+
+// SizeMultiplier returns the factor applied to the position size of new entries
+// SizeMultiplier 返回新开仓仓位的缩放系数
+func (s *LossStreak) SizeMultiplier() float64 {
+	switch {
+	case s.Halted():
+		return 0
+	case s.Reduced():
+		return 0.5
+	}
+	return 1
+}
+
+// Enabled reports whether any step of the ladder is configured (nil-safe)
+// Enabled 返回是否配置了任一级阶梯（nil 安全）
+func (s *LossStreak) Enabled() bool {
+	return s != nil && (s.Reduce > 0 || s.Halt > 0)
+}
+
+// SessionEnd returns when the ladder resets
+// SessionEnd 返回阶梯重置的时间
+func (s *LossStreak) SessionEnd() time.Time {
+	return s.SessionStart.AddDate(0, 0, 1)
+}
@@ -0,0 +1,63 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestSessionStart tests the session boundary before and after the reset hour
+// TestSessionStart 测试重置时刻之前和之后的交易时段边界
+func TestSessionStart(t *testing.T) {
+	now := time.Date(2025, 3, 10, 5, 30, 0, 0, time.UTC)
+	if got := SessionStart(now, 0); !got.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected midnight, got %s", got)
+	}
+	if got := SessionStart(now, 8); !got.Equal(time.Date(2025, 3, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 08:00 of the previous day, got %s", got)
+	}
+}
+
+// TestLossStreakLadder tests halving after the first threshold, the sticky halt and reset by a win
+// TestLossStreakLadder 测试达到第一级阈值后减半、停止开仓不会解除以及盈利后连亏归零
+func TestLossStreakLadder(t *testing.T) {
+	cfg := &config.Config{LossStreakReduce: 2, LossStreakHalt: 4}
+	trades := func(pnls ...float64) []*storage.PositionRecord {
+		closed := make([]*storage.PositionRecord, len(pnls))
+		for i, pnl := range pnls {
+			closed[i] = &storage.PositionRecord{RealizedPnL: pnl}
+		}
+		return closed
+	}
+
+	tests := []struct {
+		name       string
+		pnls       []float64
+		multiplier float64
+	}{
+		{"no trades", nil, 1},
+		{"one loss", []float64{-5}, 1},
+		{"two losses", []float64{10, -5, -3}, 0.5},
+		{"reset by a win", []float64{-5, -3, 8}, 1},
+		{"breakeven ends the run", []float64{-5, -3, 0}, 1},
+		{"halted", []float64{-1, -2, -3, -4}, 0},
+		{"halt is sticky", []float64{-1, -2, -3, -4, 20}, 0},
+	}
+	for _, tt := range tests {
+		streak := LossStreakFrom(trades(tt.pnls...), cfg, time.Now())
+		if got := streak.SizeMultiplier(); got != tt.multiplier {
+			t.Errorf("%s: multiplier %.1f, want %.1f (%+v)", tt.name, got, tt.multiplier, streak)
+		}
+	}
+
+	disabled := LossStreakFrom(trades(-1, -2, -3, -4, -5), &config.Config{}, time.Now())
+	if disabled.Enabled() || disabled.SizeMultiplier() != 1 || disabled.Current != 5 {
+		t.Errorf("Expected no ladder without thresholds, got %+v", disabled)
+	}
+	var unchecked *LossStreak
+	if unchecked.Halted() || unchecked.Reduced() || unchecked.SizeMultiplier() != 1 {
+		t.Error("Expected a nil streak to leave entries alone")
+	}
+}
@@ -0,0 +1,233 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// DefaultStopPercent is the stop distance assumed when an order or position has no stop-loss (matches the 2.5% default stop)
+// DefaultStopPercent 是订单或持仓没有止损时假定的止损距离（与默认 2.5% 止损一致）
+const DefaultStopPercent = 2.5
+
+// Limits holds the global risk limits; a zero value disables that limit
+// Limits 保存全局风控限制，值为 0 表示不启用该项限制
+type Limits struct {
+	MaxConcurrentPositions int     // 最大同时持仓数 / Max concurrent positions
+	MaxNotionalPerSymbol   float64 // 单个交易对最大名义价值（USDT）/ Max notional per symbol (USDT)
+	MaxEquityAtRiskPercent float64 // 最大权益风险占比（止损亏损合计 / 权益）/ Max % of equity at risk (sum of stop losses / equity)
+	DailyMaxLossPercent    float64 // 单日最大亏损百分比，触发后当日停止开仓 / Daily max loss %, halts opening for the day
+	SmallAccountEquity     float64 // 权益低于该值时最多持有 1 个仓位（0 表示不启用）/ Below this equity only one position is allowed (0 disables)
+}
+
+// LimitsFromConfig builds the risk limits from config
+// LimitsFromConfig 从配置构建风控限制
+func LimitsFromConfig(cfg *config.Config) Limits {
+	return Limits{
+		MaxConcurrentPositions: cfg.RiskMaxPositions,
+		MaxNotionalPerSymbol:   cfg.RiskMaxNotionalPerSymbol,
+		MaxEquityAtRiskPercent: cfg.RiskMaxEquityAtRisk,
+		DailyMaxLossPercent:    cfg.RiskDailyMaxLoss,
+		SmallAccountEquity:     cfg.SmallAccountEquity,
+	}
+}
+
+// Exposure describes one open position for risk purposes
+// Exposure 描述用于风控计算的单个持仓
+type Exposure struct {
+	Symbol   string  // 交易对 / Trading pair
+	Side     string  // long/short
+	Notional float64 // 名义价值 USDT / Notional value in USDT
+	Risk     float64 // 触发止损时的亏损 USDT / Loss in USDT if the stop is hit
+}
+
+// Order describes a proposed opening order
+// Order 描述拟开仓订单
+type Order struct {
+	Symbol   string  // 交易对 / Trading pair
+	Side     string  // long/short
+	Notional float64 // 名义价值 USDT / Notional value in USDT
+	Risk     float64 // 触发止损时的亏损 USDT / Loss in USDT if the stop is hit
+	Margin   float64 // 占用保证金 USDT（名义价值 / 杠杆）/ Margin used in USDT (notional / leverage)
+}
+
+// Snapshot is the account state the limits are checked against
+// Snapshot 是风控检查所依据的账户状态
+type Snapshot struct {
+	Equity    float64    // 账户权益（余额 + 未实现盈亏）/ Account equity (balance + unrealized PnL)
+	Exposures []Exposure // 当前持仓 / Open positions
+}
+
+// StopRisk returns the loss of a position of the given notional if price moves from entry to stop
+// StopRisk 返回给定名义价值的仓位从入场价运行到止损价时的亏损
+//
+// Falls back to DefaultStopPercent when no valid stop is given.
+// 未提供有效止损时使用 DefaultStopPercent。
+func StopRisk(notional, entryPrice, stopPrice float64) float64 {
+	if entryPrice <= 0 || stopPrice <= 0 {
+		return notional * DefaultStopPercent / 100
+	}
+	return notional * math.Abs(entryPrice-stopPrice) / entryPrice
+}
+
+// Manager enforces global exposure limits and the daily loss halt
+// Manager 执行全局敞口限制和单日亏损熔断
+type Manager struct {
+	limits         Limits
+	day            string  // 当前交易日（UTC）/ Current trading day (UTC)
+	dayStartEquity float64 // 当日起始权益 / Equity at start of day
+	dayLowEquity   float64 // 当日最低权益 / Lowest equity of the day
+	mu             sync.Mutex
+}
+
+// NewManager creates a new risk manager
+// NewManager 创建新的风控管理器
+func NewManager(limits Limits) *Manager {
+	return &Manager{limits: limits}
+}
+
+// LoadHistory replays balance snapshots and profit sweeps in time order so the daily loss survives restarts
+// LoadHistory 按时间顺序回放余额快照和利润提取，使单日亏损统计在重启后依然有效
+func (m *Manager) LoadHistory(history []*storage.BalanceHistory, withdrawals storage.Withdrawals) {
+	next := 0
+	for _, h := range history {
+		for ; next < len(withdrawals) && !withdrawals[next].CreatedAt.After(h.Timestamp); next++ {
+			m.ObserveWithdrawal(withdrawals[next].Amount, withdrawals[next].CreatedAt)
+		}
+		m.ObserveEquity(h.TotalBalance+h.UnrealizedPnL, h.Timestamp)
+	}
+	for ; next < len(withdrawals); next++ {
+		m.ObserveWithdrawal(withdrawals[next].Amount, withdrawals[next].CreatedAt)
+	}
+}
+
+// ObserveEquity records an equity observation; the first one of a UTC day becomes the day's baseline
+// ObserveEquity 记录一次权益观测值，每个 UTC 日的第一次观测作为当日基准
+func (m *Manager) ObserveEquity(equity float64, at time.Time) {
+	if equity <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	day := at.UTC().Format("2006-01-02")
+	if day != m.day {
+		if day < m.day {
+			return // Stale observation from a previous day / 前一日的过期数据
+		}
+		m.day = day
+		m.dayStartEquity = equity
+		m.dayLowEquity = equity
+		return
+	}
+
+	if equity < m.dayLowEquity {
+		m.dayLowEquity = equity
+	}
+}
+
+// ObserveWithdrawal records cash moved out of the account (a profit sweep) so the drop in equity is
+// not counted as a loss: the day's baseline and low are lowered by the amount
+// ObserveWithdrawal 记录转出账户的资金（利润提取），使权益的下降不被计为亏损：当日基准和最低值同时减去该金额
+func (m *Manager) ObserveWithdrawal(amount float64, at time.Time) {
+	if amount <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Before the day's first observation the baseline is taken after the withdrawal anyway
+	// 当日首次观测之前的提取无需处理，基准本就取自提取之后
+	if at.UTC().Format("2006-01-02") != m.day {
+		return
+	}
+	m.dayStartEquity -= amount
+	m.dayLowEquity -= amount
+}
+
+// DailyLossPercent returns the worst drawdown from the day's starting equity, in percent
+// DailyLossPercent 返回相对当日起始权益的最大回撤百分比
+func (m *Manager) DailyLossPercent() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dayStartEquity <= 0 {
+		return 0
+	}
+	return (m.dayStartEquity - m.dayLowEquity) / m.dayStartEquity * 100
+}
+
+// Halted reports whether the daily loss limit has been hit
+// Halted 返回是否已触发单日亏损限制
+//
+// Once hit, trading stays halted for the rest of the UTC day even if equity recovers.
+// 一旦触发，即使权益回升，当日（UTC）剩余时间也保持停止交易。
+func (m *Manager) Halted() bool {
+	return m.limits.DailyMaxLossPercent > 0 && m.DailyLossPercent() >= m.limits.DailyMaxLossPercent
+}
+
+// CheckOrder validates an opening order against all limits
+// CheckOrder 检查开仓订单是否违反任一风控限制
+//
+// Only opening orders are checked; closing positions always reduces risk and is never refused.
+// In hedge mode an opposite order opens a second leg next to the existing one, so both legs count.
+// 仅检查开仓订单；平仓总是降低风险，因此从不拒绝。双向持仓模式下反向订单会在已有持仓旁开出第二条腿，因此两条腿都计入。
+func (m *Manager) CheckOrder(snapshot Snapshot, order Order, hedgeMode bool) error {
+	if m.Halted() {
+		return fmt.Errorf("触发单日最大亏损限制: 当日亏损 %.2f%% ≥ %.2f%%，今日停止开仓",
+			m.DailyLossPercent(), m.limits.DailyMaxLossPercent)
+	}
+
+	// In one-way mode an opposite position on the same symbol is closed by the flip, so it doesn't count
+	// 单向持仓模式下同一交易对的反向持仓会在反手时平掉，因此不计入
+	positions := 0
+	symbolNotional := 0.0
+	totalRisk := order.Risk
+	hasSameSide := false
+	for _, exp := range snapshot.Exposures {
+		if exp.Symbol == order.Symbol && exp.Side != order.Side && !hedgeMode {
+			continue
+		}
+		positions++
+		totalRisk += exp.Risk
+		if exp.Symbol == order.Symbol {
+			hasSameSide = hasSameSide || exp.Side == order.Side
+			symbolNotional += exp.Notional
+		}
+	}
+
+	// Small accounts can't spread their margin, so they hold a single position
+	// 小账户资金无法分散，因此只持有一个仓位
+	if m.limits.SmallAccountEquity > 0 && snapshot.Equity > 0 && snapshot.Equity < m.limits.SmallAccountEquity &&
+		!hasSameSide && positions >= 1 {
+		return fmt.Errorf("小账户模式（权益 %.2f < %.2f USDT）最多同时持有 1 个仓位: 当前 %d 个",
+			snapshot.Equity, m.limits.SmallAccountEquity, positions)
+	}
+
+	if m.limits.MaxConcurrentPositions > 0 && !hasSameSide && positions >= m.limits.MaxConcurrentPositions {
+		return fmt.Errorf("超过最大同时持仓数: 当前 %d 个 / 限制 %d 个", positions, m.limits.MaxConcurrentPositions)
+	}
+
+	if m.limits.MaxNotionalPerSymbol > 0 && symbolNotional+order.Notional > m.limits.MaxNotionalPerSymbol {
+		return fmt.Errorf("超过单个交易对最大名义价值: %s %.2f + %.2f USDT > %.2f USDT",
+			order.Symbol, symbolNotional, order.Notional, m.limits.MaxNotionalPerSymbol)
+	}
+
+	if m.limits.MaxEquityAtRiskPercent > 0 {
+		if snapshot.Equity <= 0 {
+			return fmt.Errorf("账户权益无效 (%.2f USDT)，无法评估风险", snapshot.Equity)
+		}
+		if atRisk := totalRisk / snapshot.Equity * 100; atRisk > m.limits.MaxEquityAtRiskPercent {
+			return fmt.Errorf("超过最大权益风险占比: 止损合计亏损 %.2f USDT = %.2f%% 权益 > %.2f%%",
+				totalRisk, atRisk, m.limits.MaxEquityAtRiskPercent)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package risk
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestStopRisk tests the loss estimate for a stop distance
+// TestStopRisk 测试按止损距离估算亏损
+func TestStopRisk(t *testing.T) {
+	if got := StopRisk(1000, 100, 95); got != 50 {
+		t.Errorf("StopRisk() = %.2f, want 50", got)
+	}
+	if got := StopRisk(1000, 100, 0); got != 25 {
+		t.Errorf("StopRisk() without stop = %.2f, want 25", got)
+	}
+}
+
+// TestCheckOrderLimits tests each exposure limit
+// TestCheckOrderLimits 测试各项敞口限制
+func TestCheckOrderLimits(t *testing.T) {
+	snapshot := Snapshot{
+		Equity: 1000,
+		Exposures: []Exposure{
+			{Symbol: "BTC/USDT", Side: "long", Notional: 2000, Risk: 40},
+			{Symbol: "ETH/USDT", Side: "short", Notional: 1000, Risk: 30},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		order   Order
+		hedge   bool
+		wantErr string
+	}{
+		{
+			name:   "Within limits",
+			limits: Limits{MaxConcurrentPositions: 3, MaxNotionalPerSymbol: 3000, MaxEquityAtRiskPercent: 10},
+			order:  Order{Symbol: "SOL/USDT", Side: "long", Notional: 500, Risk: 20},
+		},
+		{
+			name:    "Too many positions",
+			limits:  Limits{MaxConcurrentPositions: 2},
+			order:   Order{Symbol: "SOL/USDT", Side: "long", Notional: 500, Risk: 20},
+			wantErr: "最大同时持仓数",
+		},
+		{
+			name:   "Flip doesn't add a position",
+			limits: Limits{MaxConcurrentPositions: 2},
+			order:  Order{Symbol: "ETH/USDT", Side: "long", Notional: 500, Risk: 20},
+		},
+		{
+			name:    "Hedge leg adds a position",
+			limits:  Limits{MaxConcurrentPositions: 2},
+			order:   Order{Symbol: "ETH/USDT", Side: "long", Notional: 500, Risk: 20},
+			hedge:   true,
+			wantErr: "最大同时持仓数",
+		},
+		{
+			name:    "Hedge legs share the symbol notional",
+			limits:  Limits{MaxNotionalPerSymbol: 2400},
+			order:   Order{Symbol: "ETH/USDT", Side: "long", Notional: 1500, Risk: 20},
+			hedge:   true,
+			wantErr: "最大名义价值",
+		},
+		{
+			name:   "Flip replaces the symbol notional",
+			limits: Limits{MaxNotionalPerSymbol: 2400},
+			order:  Order{Symbol: "ETH/USDT", Side: "long", Notional: 1500, Risk: 20},
+		},
+		{
+			name:    "Hedge leg keeps the opposite risk",
+			limits:  Limits{MaxEquityAtRiskPercent: 8},
+			order:   Order{Symbol: "ETH/USDT", Side: "long", Notional: 500, Risk: 20},
+			hedge:   true,
+			wantErr: "最大权益风险占比",
+		},
+		{
+			name:   "Adding to a hedged leg adds no position",
+			limits: Limits{MaxConcurrentPositions: 2},
+			order:  Order{Symbol: "BTC/USDT", Side: "long", Notional: 500, Risk: 20},
+			hedge:  true,
+		},
+		{
+			name:    "Symbol notional exceeded",
+			limits:  Limits{MaxNotionalPerSymbol: 2400},
+			order:   Order{Symbol: "BTC/USDT", Side: "long", Notional: 500, Risk: 20},
+			wantErr: "最大名义价值",
+		},
+		{
+			name:    "Equity at risk exceeded",
+			limits:  Limits{MaxEquityAtRiskPercent: 8},
+			order:   Order{Symbol: "SOL/USDT", Side: "long", Notional: 500, Risk: 20},
+			wantErr: "最大权益风险占比",
+		},
+		{
+			name:    "Small account holds one position",
+			limits:  Limits{MaxConcurrentPositions: 3, SmallAccountEquity: 2000},
+			order:   Order{Symbol: "SOL/USDT", Side: "long", Notional: 500, Risk: 20},
+			wantErr: "小账户模式",
+		},
+		{
+			name:   "Small account mode off above the threshold",
+			limits: Limits{MaxConcurrentPositions: 3, SmallAccountEquity: 500},
+			order:  Order{Symbol: "SOL/USDT", Side: "long", Notional: 500, Risk: 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewManager(tt.limits).CheckOrder(snapshot, tt.order, tt.hedge)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected order to pass, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestDailyLossHalt tests that the daily loss halt persists until the next UTC day
+// TestDailyLossHalt 测试单日亏损熔断持续到下一个 UTC 日
+func TestDailyLossHalt(t *testing.T) {
+	m := NewManager(Limits{DailyMaxLossPercent: 5})
+	day := time.Date(2025, 1, 2, 1, 0, 0, 0, time.UTC)
+
+	m.LoadHistory([]*storage.BalanceHistory{
+		{Timestamp: day, TotalBalance: 1000},
+		{Timestamp: day.Add(time.Hour), TotalBalance: 980, UnrealizedPnL: -20},
+	}, nil)
+	if m.Halted() {
+		t.Fatal("4% loss should not halt trading")
+	}
+
+	// 权益回升后依然保持熔断
+	m.ObserveEquity(940, day.Add(2*time.Hour))
+	m.ObserveEquity(1010, day.Add(3*time.Hour))
+	if !m.Halted() {
+		t.Fatal("6% intraday loss should halt trading")
+	}
+	if err := m.CheckOrder(Snapshot{Equity: 1010}, Order{Symbol: "BTC/USDT", Side: "long"}, false); err == nil {
+		t.Error("Expected order to be refused while halted")
+	}
+
+	// 新的一天重置基准
+	m.ObserveEquity(1010, day.Add(24*time.Hour))
+	if m.Halted() {
+		t.Error("Halt should reset on a new UTC day")
+	}
+}
+
+// TestDailyLossExcludesWithdrawals tests that a profit sweep does not count toward the daily loss
+// TestDailyLossExcludesWithdrawals 测试利润提取不计入单日亏损
+func TestDailyLossExcludesWithdrawals(t *testing.T) {
+	m := NewManager(Limits{DailyMaxLossPercent: 5})
+	day := time.Date(2025, 1, 2, 1, 0, 0, 0, time.UTC)
+
+	// 提取 100 USDT 后权益从 1000 降到 900，没有任何交易亏损
+	m.LoadHistory([]*storage.BalanceHistory{
+		{Timestamp: day, TotalBalance: 1000},
+		{Timestamp: day.Add(2 * time.Hour), TotalBalance: 900},
+	}, storage.Withdrawals{
+		{Amount: 100, CreatedAt: day.Add(time.Hour)},
+	})
+	if loss := m.DailyLossPercent(); loss != 0 {
+		t.Fatalf("Expected no daily loss after a sweep, got %.2f%%", loss)
+	}
+	if m.Halted() {
+		t.Fatal("A profit sweep should not halt trading")
+	}
+
+	// 提取之后的真实亏损仍然计入：900 → 855 为基准 900 的 5%
+	m.ObserveEquity(855, day.Add(3*time.Hour))
+	if loss := m.DailyLossPercent(); math.Abs(loss-5) > 1e-9 {
+		t.Errorf("Expected 5%% daily loss after the sweep, got %.2f%%", loss)
+	}
+
+	// 前一日的提取不影响当日基准
+	m.ObserveWithdrawal(50, day.Add(-2*time.Hour))
+	if loss := m.DailyLossPercent(); math.Abs(loss-5) > 1e-9 {
+		t.Errorf("A sweep from a previous day should be ignored, got %.2f%%", loss)
+	}
+}
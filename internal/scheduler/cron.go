@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors are the shorthand schedules accepted in place of the five fields
+// cronDescriptors 是可替代五个字段的简写调度
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// cronField is the set of values a cron field matches, one bit per value
+// cronField 是 cron 字段匹配的取值集合，每个取值占一位
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// CronSchedule is a parsed five-field cron expression (minute hour day-of-month month day-of-week), evaluated in UTC
+// CronSchedule 是解析后的五字段 cron 表达式（分 时 日 月 周），按 UTC 计算
+type CronSchedule struct {
+	spec   string
+	minute cronField
+	hour   cronField
+	dom    cronField
+	month  cronField
+	dow    cronField
+	domAny bool // 日字段为 * / Day-of-month is *
+	dowAny bool // 周字段为 * / Day-of-week is *
+}
+
+// ParseCron parses a cron expression such as "*/15 * * * *" or "@daily"
+// ParseCron 解析 cron 表达式，如 "*/15 * * * *" 或 "@daily"
+//
+// Each field accepts *, values, ranges (a-b), steps (*/n, a-b/n) and comma lists. Day-of-week
+// runs 0-6 from Sunday (7 is also Sunday). As in standard cron, when both day fields are
+// restricted a time matching either one is due.
+// 每个字段支持 *、单个值、范围（a-b）、步长（*/n、a-b/n）和逗号列表。星期取值 0-6，0 为周日（7 也表示周日）。
+// 与标准 cron 一致，日和星期字段都有限制时，满足其中之一即触发。
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	expr := spec
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var parsed [5]cronField
+	for i, field := range fields {
+		f, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		parsed[i] = f
+	}
+
+	// Sunday is both 0 and 7
+	// 周日既是 0 也是 7
+	dow := parsed[4]
+	if dow.has(7) {
+		dow |= 1
+	}
+
+	return &CronSchedule{
+		spec:   spec,
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    dow,
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses one comma-separated field within [lo, hi]
+// parseCronField 解析 [lo, hi] 范围内的一个逗号分隔字段
+func parseCronField(field string, lo, hi int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+			start, end = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start, end = v, v
+			if step > 1 {
+				end = hi // "5/15" means every 15 starting at 5 / "5/15" 表示从 5 开始每 15 个
+			}
+		}
+		if start < lo || end > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// String returns the expression as written
+// String 返回原始表达式
+func (c *CronSchedule) String() string {
+	return c.spec
+}
+
+// dayMatches applies the day-of-month / day-of-week rule
+// dayMatches 按日和星期字段的规则判断日期是否匹配
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom.has(t.Day())
+	dowOK := c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// Matches reports whether the minute containing t is due
+// Matches 返回 t 所在的分钟是否需要执行
+func (c *CronSchedule) Matches(t time.Time) bool {
+	t = t.UTC()
+	return c.month.has(int(t.Month())) && c.dayMatches(t) && c.hour.has(t.Hour()) && c.minute.has(t.Minute())
+}
+
+// Next returns the first due minute strictly after t, in t's location (zero if none within five years)
+// Next 返回严格晚于 t 的第一个执行时间（使用 t 的时区；五年内没有时返回零值）
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	next := t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		switch {
+		case !c.month.has(int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !c.hour.has(next.Hour()):
+			next = next.Truncate(time.Hour).Add(time.Hour)
+		case !c.minute.has(next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next.In(loc)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestParseCron tests accepted and rejected expressions
+// TestParseCron 测试合法与非法的表达式
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{"*/15 * * * *", "0 0 * * *", "@daily", "5,35 8-20/2 * 1-6 1-5", "0 12 * * 7"} {
+		if _, err := ParseCron(spec); err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Expected ParseCron(%q) to fail", spec)
+		}
+	}
+}
+
+// TestCronNext tests the next due time for common schedules
+// TestCronNext 测试常见调度的下次执行时间
+func TestCronNext(t *testing.T) {
+	from := time.Date(2025, 3, 10, 13, 7, 30, 0, time.UTC) // 周一 / Monday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, 3, 10, 13, 15, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2025, 3, 10, 13, 10, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 0", time.Date(2025, 3, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)}, // 日或星期任一匹配 / Either day field matches
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) failed: %v", tt.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.spec, tt.want, got)
+		}
+		if !schedule.Matches(tt.want) {
+			t.Errorf("%s: expected %v to match", tt.spec, tt.want)
+		}
+	}
+}
+
+// TestJobsSkipIfRunning tests that a job still running when it comes due again is skipped
+// TestJobsSkipIfRunning 测试再次到期时仍在运行的任务会被跳过
+func TestJobsSkipIfRunning(t *testing.T) {
+	jobs := NewJobs(logger.NewColorLogger(false))
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	if err := jobs.Add("review", "* * * * *", 0, func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return errors.New("boom")
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := jobs.Add("review", "@daily", 0, nil); err == nil {
+		t.Error("Expected a duplicate name to be rejected")
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	jobs.runDue(ctx, now.Add(2*time.Minute))
+	<-started
+	jobs.runDue(ctx, now.Add(4*time.Minute))
+
+	status := jobs.Status()[0]
+	if !status.Running || status.Skipped != 1 {
+		t.Errorf("Expected the running job to skip once, got %+v", status)
+	}
+	if !status.NextRun.After(now.Add(4 * time.Minute)) {
+		t.Errorf("Expected the next run after the last check, got %v", status.NextRun)
+	}
+
+	close(release)
+	jobs.wg.Wait()
+	if status := jobs.Status()[0]; status.Running || status.Runs != 1 || status.LastError != "boom" {
+		t.Errorf("Expected one finished run with its error, got %+v", status)
+	}
+}
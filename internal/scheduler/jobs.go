@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// JobFunc is the work done by a scheduled job
+// JobFunc 是定时任务执行的工作
+type JobFunc func(ctx context.Context) error
+
+// JobStatus describes a scheduled job for the web UI
+// JobStatus 描述定时任务状态，供 Web 界面展示
+type JobStatus struct {
+	Name      string    `json:"name"`
+	Spec      string    `json:"spec"`                 // cron 表达式 / Cron expression
+	NextRun   time.Time `json:"next_run"`             // 下次执行时间（含随机延迟）/ Next run, jitter included
+	LastRun   time.Time `json:"last_run"`             // 上次开始时间 / Start of the last run
+	LastError string    `json:"last_error,omitempty"` // 上次执行错误 / Error of the last run
+	Running   bool      `json:"running"`
+	Runs      int       `json:"runs"`    // 已执行次数 / Completed runs
+	Skipped   int       `json:"skipped"` // 因上次仍在运行而跳过的次数 / Runs skipped because the previous one was still going
+}
+
+// job is one registered job; guarded by Jobs.mu
+// job 是一个已注册的任务，由 Jobs.mu 保护
+type job struct {
+	status   JobStatus
+	schedule *CronSchedule
+	jitter   time.Duration
+	due      time.Time // 不含随机延迟的计划时间 / Scheduled time without jitter
+	fn       JobFunc
+}
+
+// Jobs runs named background jobs on cron schedules
+// Jobs 按 cron 调度运行具名后台任务
+//
+// A job whose previous run is still going when it comes due is skipped rather than run twice.
+// Each run is delayed by a random jitter so jobs sharing a schedule don't hit the exchange at the same second.
+// 到期时上一次执行仍未结束的任务会被跳过，而不会并发执行两次。每次执行会随机延迟，避免相同调度的任务在同一秒请求交易所。
+type Jobs struct {
+	mu     sync.Mutex
+	jobs   []*job
+	logger *logger.ColorLogger
+	wg     sync.WaitGroup
+}
+
+// NewJobs creates an empty job scheduler
+// NewJobs 创建空的任务调度器
+func NewJobs(log *logger.ColorLogger) *Jobs {
+	return &Jobs{logger: log}
+}
+
+// Add registers a job; jitter is the maximum random delay added to each run
+// Add 注册任务；jitter 是每次执行的最大随机延迟
+func (j *Jobs) Add(name, spec string, jitter time.Duration, fn JobFunc) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, existing := range j.jobs {
+		if existing.status.Name == name {
+			return fmt.Errorf("job %q already registered", name)
+		}
+	}
+
+	jb := &job{
+		status:   JobStatus{Name: name, Spec: schedule.String()},
+		schedule: schedule,
+		jitter:   jitter,
+		fn:       fn,
+	}
+	jb.plan(time.Now())
+	j.jobs = append(j.jobs, jb)
+	return nil
+}
+
+// plan sets the job's next run after now; caller must hold the lock
+// plan 设置 now 之后的下次执行时间，调用方必须持有锁
+func (jb *job) plan(now time.Time) {
+	jb.due = jb.schedule.Next(now)
+	jb.status.NextRun = jb.due
+	if jb.jitter > 0 && !jb.due.IsZero() {
+		jb.status.NextRun = jb.due.Add(time.Duration(rand.Int63n(int64(jb.jitter))))
+	}
+}
+
+// Run checks for due jobs every tick until ctx is cancelled, then waits for running jobs to return
+// Run 每隔 tick 检查一次到期任务，直到 ctx 取消，然后等待运行中的任务返回
+func (j *Jobs) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.wg.Wait()
+			return
+		case now := <-ticker.C:
+			j.runDue(ctx, now)
+		}
+	}
+}
+
+// runDue starts every job due at now, skipping those still running
+// runDue 启动 now 时刻到期的所有任务，跳过仍在运行的任务
+func (j *Jobs) runDue(ctx context.Context, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, jb := range j.jobs {
+		if jb.status.NextRun.IsZero() || now.Before(jb.status.NextRun) {
+			continue
+		}
+		jb.plan(now)
+
+		if jb.status.Running {
+			jb.status.Skipped++
+			j.logger.Warning(fmt.Sprintf("⚠️  定时任务 %s 上一次执行仍未结束，跳过本次", jb.status.Name))
+			continue
+		}
+
+		jb.status.Running = true
+		jb.status.LastRun = now
+		j.wg.Add(1)
+		go j.execute(ctx, jb)
+	}
+}
+
+// execute runs one job and records the outcome
+// execute 执行单个任务并记录结果
+func (j *Jobs) execute(ctx context.Context, jb *job) {
+	defer j.wg.Done()
+	err := jb.fn(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jb.status.Running = false
+	jb.status.Runs++
+	jb.status.LastError = ""
+	if err != nil {
+		jb.status.LastError = err.Error()
+		j.logger.Warning(fmt.Sprintf("⚠️  定时任务 %s 执行失败: %v", jb.status.Name, err))
+	}
+}
+
+// Status returns every job's state in registration order
+// Status 按注册顺序返回所有任务的状态
+func (j *Jobs) Status() []JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	statuses := make([]JobStatus, len(j.jobs))
+	for i, jb := range j.jobs {
+		statuses[i] = jb.status
+	}
+	return statuses
+}
@@ -8,10 +8,19 @@ import (
 
 // TradingScheduler handles trading schedule based on K-line timeframe
 // TradingScheduler 根据 K 线时间周期处理交易调度
+//
+// With an active timeframe set, the scheduler runs on it while positions are open and on the
+// base timeframe when flat. A cron expression (SetCron) replaces both.
+// 设置了持仓周期时，有持仓期间按持仓周期运行，空仓时按基础周期运行。设置 cron 表达式（SetCron）后二者均不再生效。
 type TradingScheduler struct {
-	mu        sync.RWMutex // Protects timeframe and minutes / 保护 timeframe 和 minutes
-	timeframe string
-	minutes   int
+	mu              sync.RWMutex // Protects all fields / 保护所有字段
+	timeframe       string
+	minutes         int
+	activeTimeframe string // 有持仓时的周期（为空不加速）/ Timeframe while positions are open (empty = no acceleration)
+	activeMinutes   int
+	positionsOpen   bool
+	cron            *CronSchedule // 分析任务的 cron 调度（nil 表示按周期对齐）/ Cron schedule for analysis (nil = timeframe alignment)
+	heartbeat       time.Time     // 交易循环最近一次检查调度的时间 / Last time the trading loop checked the schedule
 }
 
 // Timeframe minute mappings
@@ -42,14 +51,94 @@ func NewTradingScheduler(timeframe string) (*TradingScheduler, error) {
 	}, nil
 }
 
+// SetActiveTimeframe sets the faster timeframe used while positions are open ("" disables it)
+// SetActiveTimeframe 设置有持仓时使用的更快周期（"" 表示禁用）
+func (s *TradingScheduler) SetActiveTimeframe(timeframe string) error {
+	minutes := 0
+	if timeframe != "" {
+		var ok bool
+		if minutes, ok = timeframeMinutes[timeframe]; !ok {
+			return fmt.Errorf("unsupported timeframe: %s", timeframe)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeTimeframe = timeframe
+	s.activeMinutes = minutes
+	return nil
+}
+
+// SetCron runs analysis on a cron expression instead of timeframe boundaries ("" restores the timeframe)
+// SetCron 按 cron 表达式而不是周期边界运行分析（"" 表示恢复按周期运行）
+func (s *TradingScheduler) SetCron(spec string) error {
+	var schedule *CronSchedule
+	if spec != "" {
+		var err error
+		if schedule, err = ParseCron(spec); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cron = schedule
+	return nil
+}
+
+// Cron returns the analysis cron expression ("" when running on the timeframe)
+// Cron 返回分析任务的 cron 表达式（按周期运行时为 ""）
+func (s *TradingScheduler) Cron() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cron == nil {
+		return ""
+	}
+	return s.cron.String()
+}
+
+// SetPositionsOpen switches between the active and base timeframe; returns true when the cadence changed
+// SetPositionsOpen 在持仓周期和基础周期之间切换，运行周期发生变化时返回 true
+func (s *TradingScheduler) SetPositionsOpen(open bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.positionsOpen == open {
+		return false
+	}
+	s.positionsOpen = open
+	return s.cron == nil && s.activeMinutes > 0 && s.activeMinutes != s.minutes
+}
+
+// current returns the timeframe and minutes in effect; caller must hold the lock
+// current 返回当前生效的周期和分钟数，调用方必须持有锁
+func (s *TradingScheduler) current() (string, int) {
+	if s.positionsOpen && s.activeMinutes > 0 {
+		return s.activeTimeframe, s.activeMinutes
+	}
+	return s.timeframe, s.minutes
+}
+
+// CurrentTimeframe returns the timeframe in effect: the active one while positions are open
+// CurrentTimeframe 返回当前生效的周期：有持仓时为持仓周期
+func (s *TradingScheduler) CurrentTimeframe() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	timeframe, _ := s.current()
+	return timeframe
+}
+
 // GetNextTimeframeTime returns the next K-line period start time
 // GetNextTimeframeTime 返回下一个 K 线周期开始时间
 func (s *TradingScheduler) GetNextTimeframeTime() time.Time {
 	s.mu.RLock()
-	minutes := s.minutes
+	_, minutes := s.current()
+	cron := s.cron
 	s.mu.RUnlock()
 
 	now := time.Now()
+	if cron != nil {
+		return cron.Next(now)
+	}
 
 	// Calculate current minute of the day
 	// 计算当天的当前分钟数
@@ -81,9 +170,7 @@ func (s *TradingScheduler) WaitForNextTimeframe(verbose bool) {
 	waitDuration := nextTime.Sub(now)
 
 	if verbose {
-		s.mu.RLock()
-		timeframe := s.timeframe
-		s.mu.RUnlock()
+		timeframe := s.CurrentTimeframe()
 
 		fmt.Printf("⏰ 当前时间: %s\n", now.Format("2006-01-02 15:04:05"))
 		fmt.Printf("⏳ 下一个 %s K线周期: %s\n", timeframe, nextTime.Format("2006-01-02 15:04:05"))
@@ -113,14 +200,34 @@ func (s *TradingScheduler) WaitForNextTimeframe(verbose bool) {
 	}
 }
 
+// Heartbeat records that the trading loop is alive and checking the schedule
+// Heartbeat 记录交易循环仍在运行并检查调度
+func (s *TradingScheduler) Heartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeat = time.Now()
+}
+
+// LastHeartbeat returns the last time Heartbeat was called (zero if never)
+// LastHeartbeat 返回最近一次调用 Heartbeat 的时间（从未调用时为零值）
+func (s *TradingScheduler) LastHeartbeat() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.heartbeat
+}
+
 // IsOnTimeframe checks if current time is on a K-line period boundary
 // IsOnTimeframe 检查当前时间是否在 K 线周期边界上
 func (s *TradingScheduler) IsOnTimeframe() bool {
 	s.mu.RLock()
-	minutes := s.minutes
+	_, minutes := s.current()
+	cron := s.cron
 	s.mu.RUnlock()
 
 	now := time.Now()
+	if cron != nil {
+		return cron.Matches(now)
+	}
 	currentMinute := now.Hour()*60 + now.Minute()
 
 	// Check if on period boundary (allow 60 second tolerance)
@@ -132,7 +239,7 @@ func (s *TradingScheduler) IsOnTimeframe() bool {
 // GetAlignedIntervals 返回一天内所有对齐的时间点
 func (s *TradingScheduler) GetAlignedIntervals() []string {
 	s.mu.RLock()
-	minutes := s.minutes
+	_, minutes := s.current()
 	s.mu.RUnlock()
 
 	intervals := []string{}
@@ -148,16 +255,16 @@ func (s *TradingScheduler) GetAlignedIntervals() []string {
 	return intervals
 }
 
-// GetTimeframe returns the timeframe string
-// GetTimeframe 返回时间周期字符串
+// GetTimeframe returns the base timeframe string
+// GetTimeframe 返回基础时间周期字符串
 func (s *TradingScheduler) GetTimeframe() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.timeframe
 }
 
-// GetMinutes returns the timeframe in minutes
-// GetMinutes 返回时间周期的分钟数
+// GetMinutes returns the base timeframe in minutes
+// GetMinutes 返回基础时间周期的分钟数
 func (s *TradingScheduler) GetMinutes() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -169,3 +169,96 @@ func TestTimeframeAlignment(t *testing.T) {
 		})
 	}
 }
+
+// TestActiveTimeframe tests that the scheduler runs on the active timeframe only while positions are open
+// TestActiveTimeframe 测试调度器仅在有持仓时按持仓周期运行
+func TestActiveTimeframe(t *testing.T) {
+	scheduler, err := NewTradingScheduler("30m")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+	if err := scheduler.SetActiveTimeframe("7m"); err == nil {
+		t.Error("Expected an error for an unsupported active timeframe")
+	}
+	if err := scheduler.SetActiveTimeframe("5m"); err != nil {
+		t.Fatalf("SetActiveTimeframe failed: %v", err)
+	}
+
+	if scheduler.CurrentTimeframe() != "30m" || len(scheduler.GetAlignedIntervals()) != 48 {
+		t.Errorf("Expected the base timeframe when flat, got %s", scheduler.CurrentTimeframe())
+	}
+	if !scheduler.SetPositionsOpen(true) {
+		t.Error("Expected opening a position to change the cadence")
+	}
+	if scheduler.SetPositionsOpen(true) {
+		t.Error("Expected no change when positions stay open")
+	}
+	if scheduler.CurrentTimeframe() != "5m" || len(scheduler.GetAlignedIntervals()) != 288 {
+		t.Errorf("Expected the active timeframe with positions, got %s", scheduler.CurrentTimeframe())
+	}
+	if scheduler.GetTimeframe() != "30m" {
+		t.Errorf("Expected the base timeframe to be unchanged, got %s", scheduler.GetTimeframe())
+	}
+	if next := scheduler.GetNextTimeframeTime(); next.Minute()%5 != 0 || next.Sub(time.Now()) > 5*time.Minute {
+		t.Errorf("Expected the next run within 5 minutes on a 5m boundary, got %s", next)
+	}
+
+	if !scheduler.SetPositionsOpen(false) || scheduler.CurrentTimeframe() != "30m" {
+		t.Errorf("Expected the base timeframe after closing, got %s", scheduler.CurrentTimeframe())
+	}
+
+	if err := scheduler.SetActiveTimeframe(""); err != nil {
+		t.Fatalf("SetActiveTimeframe failed: %v", err)
+	}
+	if scheduler.SetPositionsOpen(true) || scheduler.CurrentTimeframe() != "30m" {
+		t.Error("Expected no acceleration without an active timeframe")
+	}
+}
+
+// TestSchedulerCron tests that a cron expression replaces timeframe alignment
+// TestSchedulerCron 测试 cron 表达式取代周期对齐
+func TestSchedulerCron(t *testing.T) {
+	scheduler, err := NewTradingScheduler("4h")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+	if err := scheduler.SetCron("bad"); err == nil {
+		t.Error("Expected an error for an invalid cron expression")
+	}
+	if err := scheduler.SetCron("* * * * *"); err != nil {
+		t.Fatalf("SetCron failed: %v", err)
+	}
+	if scheduler.Cron() != "* * * * *" || !scheduler.IsOnTimeframe() {
+		t.Error("Expected every minute to be due")
+	}
+	if next := scheduler.GetNextTimeframeTime(); next.Sub(time.Now()) > time.Minute {
+		t.Errorf("Expected the next run within a minute, got %s", next)
+	}
+	if err := scheduler.SetActiveTimeframe("5m"); err != nil {
+		t.Fatalf("SetActiveTimeframe failed: %v", err)
+	}
+	if scheduler.SetPositionsOpen(true) {
+		t.Error("Expected the active timeframe to be ignored with a cron schedule")
+	}
+
+	if err := scheduler.SetCron(""); err != nil || scheduler.Cron() != "" {
+		t.Errorf("Expected the timeframe to be restored, got %q (%v)", scheduler.Cron(), err)
+	}
+}
+
+// TestSchedulerHeartbeat tests the trading loop liveness timestamp
+// TestSchedulerHeartbeat 测试交易循环存活时间戳
+func TestSchedulerHeartbeat(t *testing.T) {
+	scheduler, err := NewTradingScheduler("1h")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+	if !scheduler.LastHeartbeat().IsZero() {
+		t.Error("Expected no heartbeat before the loop starts")
+	}
+	before := time.Now()
+	scheduler.Heartbeat()
+	if beat := scheduler.LastHeartbeat(); beat.Before(before) {
+		t.Errorf("Expected a heartbeat after %s, got %s", before, beat)
+	}
+}
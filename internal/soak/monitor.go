@@ -0,0 +1,198 @@
+package soak
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sample is one resource measurement taken during a soak run
+// Sample 是浸泡测试期间的一次资源采样
+type Sample struct {
+	Time        time.Time
+	Cycles      int    // 已完成的交易循环数 / Completed trading cycles
+	Goroutines  int    // 协程数 / Goroutine count
+	HeapAlloc   uint64 // 堆上已分配字节数（GC 后）/ Heap bytes allocated (after GC)
+	HeapObjects uint64 // 堆对象数 / Live heap objects
+	DBSize      int64  // 数据库文件大小（含 WAL）/ Database size including WAL
+}
+
+// Thresholds define the growth rates reported as suspected leaks (0 disables a check)
+// Thresholds 定义被判定为疑似泄漏的增长速度（0 表示不检查）
+type Thresholds struct {
+	GoroutinesPerHour float64 // 每小时协程增长数 / Goroutine growth per hour
+	HeapMBPerHour     float64 // 每小时堆内存增长 MB / Heap growth in MB per hour
+	DBMBPerHour       float64 // 每小时数据库增长 MB / Database growth in MB per hour
+}
+
+// DefaultThresholds returns conservative limits for an accelerated paper run
+// DefaultThresholds 返回适用于加速模拟盘运行的保守阈值
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		GoroutinesPerHour: 5,
+		HeapMBPerHour:     20,
+		DBMBPerHour:       200,
+	}
+}
+
+// Monitor periodically samples goroutines, heap and database size
+// Monitor 定期采样协程数、堆内存和数据库大小
+type Monitor struct {
+	dbPath  string
+	samples []Sample
+	mu      sync.Mutex
+}
+
+// NewMonitor creates a monitor for the database at dbPath
+// NewMonitor 为 dbPath 处的数据库创建监控器
+func NewMonitor(dbPath string) *Monitor {
+	return &Monitor{dbPath: dbPath}
+}
+
+// Sample forces a GC, records the current resource usage and returns it
+// Sample 强制 GC 后记录并返回当前资源使用情况
+//
+// GC runs first so HeapAlloc reflects live memory rather than garbage awaiting collection.
+// 先执行 GC，使 HeapAlloc 反映存活内存而不是待回收的垃圾。
+func (m *Monitor) Sample(cycles int) Sample {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	s := Sample{
+		Time:        time.Now(),
+		Cycles:      cycles,
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   ms.HeapAlloc,
+		HeapObjects: ms.HeapObjects,
+		DBSize:      fileSize(m.dbPath) + fileSize(m.dbPath+"-wal"),
+	}
+
+	m.mu.Lock()
+	m.samples = append(m.samples, s)
+	m.mu.Unlock()
+	return s
+}
+
+// Samples returns a copy of all samples taken so far
+// Samples 返回目前所有采样的副本
+func (m *Monitor) Samples() []Sample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Sample(nil), m.samples...)
+}
+
+// Report summarizes resource growth over a soak run
+// Report 汇总浸泡测试期间的资源增长
+type Report struct {
+	First    Sample
+	Last     Sample
+	Duration time.Duration
+
+	// Growth per hour from a least-squares fit, ignoring the warm-up samples
+	// 基于最小二乘拟合的每小时增长（忽略预热采样）
+	GoroutinesPerHour float64
+	HeapMBPerHour     float64
+	DBMBPerHour       float64
+
+	// Suspected leaks, empty when all growth rates are within thresholds
+	// 疑似泄漏，所有增长均在阈值内时为空
+	Leaks []string
+}
+
+// Analyze computes growth rates and flags those above the thresholds
+// Analyze 计算增长速度并标记超出阈值的项
+//
+// The first quarter of the samples is treated as warm-up (caches, connection pools, first
+// positions) and excluded from the fit. At least three remaining samples are required.
+// 前四分之一的采样视为预热（缓存、连接池、首次开仓），不参与拟合。剩余采样至少需要 3 个。
+func Analyze(samples []Sample, thresholds Thresholds) (*Report, error) {
+	if len(samples) < 4 {
+		return nil, fmt.Errorf("采样数不足: %d（至少需要 4 个）", len(samples))
+	}
+
+	steady := samples[len(samples)/4:]
+	report := &Report{
+		First:    samples[0],
+		Last:     samples[len(samples)-1],
+		Duration: samples[len(samples)-1].Time.Sub(samples[0].Time),
+	}
+
+	report.GoroutinesPerHour = slopePerHour(steady, func(s Sample) float64 { return float64(s.Goroutines) })
+	report.HeapMBPerHour = slopePerHour(steady, func(s Sample) float64 { return float64(s.HeapAlloc) / 1024 / 1024 })
+	report.DBMBPerHour = slopePerHour(steady, func(s Sample) float64 { return float64(s.DBSize) / 1024 / 1024 })
+
+	if thresholds.GoroutinesPerHour > 0 && report.GoroutinesPerHour > thresholds.GoroutinesPerHour {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("协程数每小时增长 %.1f（阈值 %.1f）",
+			report.GoroutinesPerHour, thresholds.GoroutinesPerHour))
+	}
+	if thresholds.HeapMBPerHour > 0 && report.HeapMBPerHour > thresholds.HeapMBPerHour {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("堆内存每小时增长 %.2f MB（阈值 %.2f MB）",
+			report.HeapMBPerHour, thresholds.HeapMBPerHour))
+	}
+	if thresholds.DBMBPerHour > 0 && report.DBMBPerHour > thresholds.DBMBPerHour {
+		report.Leaks = append(report.Leaks, fmt.Sprintf("数据库每小时增长 %.2f MB（阈值 %.2f MB）",
+			report.DBMBPerHour, thresholds.DBMBPerHour))
+	}
+
+	return report, nil
+}
+
+// String formats the report for the console
+// String 将报告格式化为控制台输出
+func (r *Report) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("运行时长: %s，交易循环: %d\n", r.Duration.Round(time.Second), r.Last.Cycles))
+	sb.WriteString(fmt.Sprintf("协程数: %d → %d（%+.1f/小时）\n", r.First.Goroutines, r.Last.Goroutines, r.GoroutinesPerHour))
+	sb.WriteString(fmt.Sprintf("堆内存: %.2f MB → %.2f MB（%+.2f MB/小时）\n",
+		float64(r.First.HeapAlloc)/1024/1024, float64(r.Last.HeapAlloc)/1024/1024, r.HeapMBPerHour))
+	sb.WriteString(fmt.Sprintf("堆对象: %d → %d\n", r.First.HeapObjects, r.Last.HeapObjects))
+	sb.WriteString(fmt.Sprintf("数据库: %.2f MB → %.2f MB（%+.2f MB/小时）\n",
+		float64(r.First.DBSize)/1024/1024, float64(r.Last.DBSize)/1024/1024, r.DBMBPerHour))
+	if len(r.Leaks) == 0 {
+		sb.WriteString("未发现疑似泄漏")
+	} else {
+		sb.WriteString("疑似泄漏:\n")
+		for _, leak := range r.Leaks {
+			sb.WriteString("  • " + leak + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// slopePerHour fits value = a + b*t by least squares and returns b in units per hour
+// slopePerHour 用最小二乘拟合 value = a + b*t，返回每小时的斜率 b
+func slopePerHour(samples []Sample, value func(Sample) float64) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	start := samples[0].Time
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.Time.Sub(start).Hours()
+		y := value(s)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// fileSize returns the size of a file, or 0 if it does not exist
+// fileSize 返回文件大小，文件不存在时返回 0
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,55 @@
+package soak
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAnalyzeFlagsGrowth tests that steady growth is reported and warm-up is ignored
+// TestAnalyzeFlagsGrowth 测试持续增长会被报告且预热阶段被忽略
+func TestAnalyzeFlagsGrowth(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := make([]Sample, 0, 8)
+	for i := 0; i < 8; i++ {
+		s := Sample{
+			Time:       start.Add(time.Duration(i) * 30 * time.Minute),
+			Cycles:     i * 100,
+			Goroutines: 20,
+			HeapAlloc:  uint64(10+i*15) * 1024 * 1024, // 30 MB / 小时
+			DBSize:     1024 * 1024,
+		}
+		if i == 0 {
+			// Warm-up spike must not affect the fit
+			// 预热阶段的突增不应影响拟合
+			s.Goroutines = 200
+		}
+		samples = append(samples, s)
+	}
+
+	report, err := Analyze(samples, DefaultThresholds())
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if math.Abs(report.HeapMBPerHour-30) > 0.01 {
+		t.Errorf("HeapMBPerHour = %.2f, want 30", report.HeapMBPerHour)
+	}
+	if report.GoroutinesPerHour != 0 {
+		t.Errorf("GoroutinesPerHour = %.2f, want 0", report.GoroutinesPerHour)
+	}
+	if len(report.Leaks) != 1 || !strings.Contains(report.Leaks[0], "堆内存") {
+		t.Errorf("Leaks = %v, want a single heap leak", report.Leaks)
+	}
+	if report.Duration != 210*time.Minute {
+		t.Errorf("Duration = %s, want 3h30m", report.Duration)
+	}
+}
+
+// TestAnalyzeRequiresSamples tests that too few samples are rejected
+// TestAnalyzeRequiresSamples 测试采样数不足时返回错误
+func TestAnalyzeRequiresSamples(t *testing.T) {
+	if _, err := Analyze(make([]Sample, 3), DefaultThresholds()); err == nil {
+		t.Error("Analyze() with 3 samples should fail")
+	}
+}
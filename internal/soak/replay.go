@@ -0,0 +1,338 @@
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bar is one recorded kline
+// bar 是一根录制的 K 线
+type bar struct {
+	OpenTime  int64
+	CloseTime int64
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// Replay serves recorded klines through the Binance futures public REST endpoints
+// Replay 通过币安合约公共 REST 接口提供录制的 K 线数据
+//
+// Each call to Advance moves every symbol forward by one recorded bar, so a soak run can push
+// hours of market history through the full trading loop in minutes. Timestamps are shifted so
+// the current bar always opens at the current minute, which keeps the paper executor's
+// time-based stop and liquidation checks working. Recordings wrap around when exhausted.
+// 每次调用 Advance 会让所有交易对前进一根录制的 K 线，使浸泡测试可以在几分钟内让数小时的行情
+// 流经完整的交易循环。时间戳会被平移，使当前 K 线始终在当前分钟开盘，从而保证模拟盘基于时间的
+// 止损与强平检查正常工作。录制数据用完后从头循环。
+type Replay struct {
+	series map[string][]bar // 按币安交易对（如 BTCUSDT）索引 / Keyed by Binance symbol (e.g. BTCUSDT)
+	warmup int
+	cursor int
+	length int
+	laps   int
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// LoadRecording loads every <SYMBOL>.json file in dir as recorded klines
+// LoadRecording 将 dir 中每个 <SYMBOL>.json 文件加载为录制的 K 线
+//
+// Files hold the raw /fapi/v1/klines response, e.g.
+// curl 'https://fapi.binance.com/fapi/v1/klines?symbol=BTCUSDT&interval=15m&limit=1500' > BTCUSDT.json
+// The first warmup bars are only served as history for indicator calculation.
+// 文件内容为 /fapi/v1/klines 的原始响应。前 warmup 根 K 线仅作为指标计算所需的历史数据。
+func LoadRecording(dir string, warmup int) (*Replay, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("录制目录 %s 中没有 K 线文件（<SYMBOL>.json）", dir)
+	}
+
+	r := &Replay{
+		series: make(map[string][]bar),
+		warmup: warmup,
+		cursor: warmup,
+		now:    time.Now,
+	}
+	for _, file := range files {
+		symbol := strings.ToUpper(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+		bars, err := readBars(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", file, err)
+		}
+		if len(bars) <= warmup {
+			return nil, fmt.Errorf("%s 只有 %d 根 K 线，至少需要 %d 根", file, len(bars), warmup+1)
+		}
+		r.series[symbol] = bars
+		if r.length == 0 || len(bars) < r.length {
+			r.length = len(bars)
+		}
+	}
+	return r, nil
+}
+
+// Symbols returns the recorded Binance symbols
+// Symbols 返回已录制的币安交易对
+func (r *Replay) Symbols() []string {
+	symbols := make([]string, 0, len(r.series))
+	for symbol := range r.series {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Advance moves the replay forward by one bar, wrapping to the start after the last bar
+// Advance 让回放前进一根 K 线，到达末尾后从头开始
+func (r *Replay) Advance() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cursor++
+	if r.cursor >= r.length {
+		r.cursor = r.warmup
+		r.laps++
+	}
+}
+
+// Laps returns how many times the recording has wrapped around
+// Laps 返回录制数据已循环的次数
+func (r *Replay) Laps() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.laps
+}
+
+// ServeHTTP implements the subset of the futures REST API used by the paper trading loop
+// ServeHTTP 实现模拟盘交易循环所用到的合约 REST 接口子集
+//
+// Endpoints that are not recorded (open interest, long/short ratios...) return a Binance
+// style error, which the analysts already tolerate.
+// 未录制的接口（持仓量、多空比等）返回币安格式的错误，分析师节点本身可以容忍这些错误。
+func (r *Replay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/fapi/v1/positionSide/dual" {
+		writeJSON(w, http.StatusOK, map[string]any{"dualSidePosition": false})
+		return
+	}
+
+	query := req.URL.Query()
+	r.mu.Lock()
+	bars, offset, ok := r.visible(query.Get("symbol"))
+	r.mu.Unlock()
+	if !ok {
+		writeAPIError(w, -1121, "Invalid symbol.")
+		return
+	}
+	last := bars[len(bars)-1]
+
+	switch req.URL.Path {
+	case "/fapi/v1/klines":
+		writeJSON(w, http.StatusOK, klines(bars, offset, query))
+	case "/fapi/v2/ticker/price", "/fapi/v1/ticker/price":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"symbol": query.Get("symbol"),
+			"price":  formatFloat(last.Close),
+			"time":   r.now().UnixMilli(),
+		})
+	case "/fapi/v1/ticker/24hr":
+		writeJSON(w, http.StatusOK, ticker24h(query.Get("symbol"), bars))
+	case "/fapi/v1/depth":
+		writeJSON(w, http.StatusOK, depth(last.Close, r.now()))
+	case "/fapi/v1/fundingRate":
+		writeJSON(w, http.StatusOK, []any{})
+	case "/fapi/v1/premiumIndex":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"symbol":          query.Get("symbol"),
+			"markPrice":       formatFloat(last.Close),
+			"lastFundingRate": "0",
+			"nextFundingTime": r.now().Truncate(8 * time.Hour).Add(8 * time.Hour).UnixMilli(),
+			"time":            r.now().UnixMilli(),
+		})
+	default:
+		writeAPIError(w, -1000, fmt.Sprintf("soak replay: %s is not recorded", req.URL.Path))
+	}
+}
+
+// visible returns the bars up to the cursor and the time offset that maps the cursor bar to now
+// visible 返回截至游标的 K 线，以及将游标 K 线映射到当前时间的时间偏移
+func (r *Replay) visible(symbol string) ([]bar, int64, bool) {
+	series, ok := r.series[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, 0, false
+	}
+	bars := series[:r.cursor+1]
+	offset := r.now().Truncate(time.Minute).UnixMilli() - bars[len(bars)-1].OpenTime
+	return bars, offset, true
+}
+
+// klines applies startTime/endTime/limit like Binance: with a start time the earliest bars
+// are returned, otherwise the latest
+// klines 按币安的规则处理 startTime/endTime/limit：指定起始时间时返回最早的 K 线，否则返回最新的
+func klines(bars []bar, offset int64, query url.Values) [][]any {
+	get := func(key string) int64 {
+		n, _ := strconv.ParseInt(query.Get(key), 10, 64)
+		return n
+	}
+	start, end, limit := get("startTime"), get("endTime"), int(get("limit"))
+	if limit <= 0 {
+		limit = 500
+	}
+
+	selected := make([]bar, 0, len(bars))
+	for _, b := range bars {
+		openTime := b.OpenTime + offset
+		if (start > 0 && openTime < start) || (end > 0 && openTime > end) {
+			continue
+		}
+		selected = append(selected, b)
+	}
+	if len(selected) > limit {
+		if start > 0 {
+			selected = selected[:limit]
+		} else {
+			selected = selected[len(selected)-limit:]
+		}
+	}
+
+	rows := make([][]any, 0, len(selected))
+	for _, b := range selected {
+		rows = append(rows, []any{
+			b.OpenTime + offset,
+			formatFloat(b.Open),
+			formatFloat(b.High),
+			formatFloat(b.Low),
+			formatFloat(b.Close),
+			formatFloat(b.Volume),
+			b.CloseTime + offset,
+			formatFloat(b.Volume * b.Close),
+			0,
+			"0",
+			"0",
+			"0",
+		})
+	}
+	return rows
+}
+
+// ticker24h builds 24h statistics from the bars covering the last day
+// ticker24h 根据最近一天的 K 线生成 24 小时统计
+func ticker24h(symbol string, bars []bar) map[string]any {
+	last := bars[len(bars)-1]
+	first := last
+	high, low, volume := last.High, last.Low, 0.0
+	for i := len(bars) - 1; i >= 0 && last.CloseTime-bars[i].OpenTime < (24*time.Hour).Milliseconds(); i-- {
+		first = bars[i]
+		if bars[i].High > high {
+			high = bars[i].High
+		}
+		if bars[i].Low < low {
+			low = bars[i].Low
+		}
+		volume += bars[i].Volume
+	}
+
+	change := last.Close - first.Open
+	changePercent := 0.0
+	if first.Open > 0 {
+		changePercent = change / first.Open * 100
+	}
+	return map[string]any{
+		"symbol":             symbol,
+		"priceChange":        formatFloat(change),
+		"priceChangePercent": formatFloat(changePercent),
+		"weightedAvgPrice":   formatFloat(last.Close),
+		"prevClosePrice":     formatFloat(first.Open),
+		"lastPrice":          formatFloat(last.Close),
+		"lastQty":            "0",
+		"openPrice":          formatFloat(first.Open),
+		"highPrice":          formatFloat(high),
+		"lowPrice":           formatFloat(low),
+		"volume":             formatFloat(volume),
+		"quoteVolume":        formatFloat(volume * last.Close),
+	}
+}
+
+// depth builds a synthetic order book around the current price, deep enough for paper fills
+// depth 围绕当前价格生成合成盘口，深度足以满足模拟盘成交
+func depth(price float64, now time.Time) map[string]any {
+	const levels = 20
+	const step = 0.0001 // 每档 1 个基点 / One basis point per level
+	bids := make([][]string, 0, levels)
+	asks := make([][]string, 0, levels)
+	qty := formatFloat(100000 / price)
+	for i := 1; i <= levels; i++ {
+		bids = append(bids, []string{formatFloat(price * (1 - step*float64(i))), qty})
+		asks = append(asks, []string{formatFloat(price * (1 + step*float64(i))), qty})
+	}
+	return map[string]any{
+		"lastUpdateId": now.UnixMilli(),
+		"E":            now.UnixMilli(),
+		"T":            now.UnixMilli(),
+		"bids":         bids,
+		"asks":         asks,
+	}
+}
+
+// readBars parses a raw /fapi/v1/klines response
+// readBars 解析 /fapi/v1/klines 的原始响应
+func readBars(path string) ([]bar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	bars := make([]bar, 0, len(rows))
+	for i, row := range rows {
+		if len(row) < 7 {
+			return nil, fmt.Errorf("第 %d 行字段不足", i+1)
+		}
+		openTime, ok1 := row[0].(float64)
+		closeTime, ok2 := row[6].(float64)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("第 %d 行时间格式错误", i+1)
+		}
+		b := bar{OpenTime: int64(openTime), CloseTime: int64(closeTime)}
+		for j, field := range []*float64{&b.Open, &b.High, &b.Low, &b.Close, &b.Volume} {
+			s, _ := row[j+1].(string)
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行价格格式错误: %w", i+1, err)
+			}
+			*field = v
+		}
+		bars = append(bars, b)
+	}
+	return bars, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{"code": code, "msg": msg})
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,104 @@
+package soak
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// writeRecording writes n 15m bars with closes 100, 101, 102... to dir/BTCUSDT.json
+// writeRecording 向 dir/BTCUSDT.json 写入 n 根收盘价为 100, 101, 102... 的 15 分钟 K 线
+func writeRecording(t *testing.T, dir string, n int) {
+	t.Helper()
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	step := (15 * time.Minute).Milliseconds()
+	rows := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		open := start + int64(i)*step
+		price := 100 + i
+		rows = append(rows, fmt.Sprintf(`[%d,"%d","%d","%d","%d","10",%d,"1000",5,"5","500","0"]`,
+			open, price, price+1, price-1, price, open+step-1))
+	}
+	data := "[" + strings.Join(rows, ",") + "]"
+	if err := os.WriteFile(filepath.Join(dir, "BTCUSDT.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestReplayServesRecordedKlines tests the replay through the real futures client
+// TestReplayServesRecordedKlines 通过真实的合约客户端测试回放
+func TestReplayServesRecordedKlines(t *testing.T) {
+	dir := t.TempDir()
+	writeRecording(t, dir, 5)
+
+	replay, err := LoadRecording(dir, 2)
+	if err != nil {
+		t.Fatalf("LoadRecording() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 12, 30, 20, 0, time.UTC)
+	replay.now = func() time.Time { return now }
+
+	server := httptest.NewServer(replay)
+	defer server.Close()
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	ctx := context.Background()
+
+	// Only bars up to the cursor are visible, the current one opening at the current minute
+	// 仅游标之前的 K 线可见，当前 K 线在当前分钟开盘
+	klines, err := client.NewKlinesService().Symbol("BTCUSDT").Interval("15m").Do(ctx)
+	if err != nil {
+		t.Fatalf("klines error = %v", err)
+	}
+	if len(klines) != 3 || klines[2].Close != "102" {
+		t.Fatalf("klines = %d bars (last close %s), want 3 bars ending at 102", len(klines), klines[len(klines)-1].Close)
+	}
+	if got := time.UnixMilli(klines[2].OpenTime).UTC(); !got.Equal(now.Truncate(time.Minute)) {
+		t.Errorf("current bar opens at %s, want %s", got, now.Truncate(time.Minute))
+	}
+
+	// A start time after the previous bar returns only the current one
+	// 起始时间晚于上一根 K 线时只返回当前 K 线
+	klines, err = client.NewKlinesService().Symbol("BTCUSDT").Interval("1m").
+		StartTime(now.Add(-time.Minute).UnixMilli()).Do(ctx)
+	if err != nil || len(klines) != 1 {
+		t.Fatalf("klines since start = %d, %v; want 1 bar", len(klines), err)
+	}
+
+	replay.Advance()
+	prices, err := client.NewListPricesService().Symbol("BTCUSDT").Do(ctx)
+	if err != nil || len(prices) != 1 || prices[0].Price != "103" {
+		t.Fatalf("price after Advance = %v, %v; want 103", prices, err)
+	}
+
+	depth, err := client.NewDepthService().Symbol("BTCUSDT").Do(ctx)
+	if err != nil || len(depth.Asks) == 0 || len(depth.Bids) == 0 {
+		t.Fatalf("depth = %v, %v; want a two-sided book", depth, err)
+	}
+
+	// The recording wraps back to the first bar after warm-up
+	// 录制数据循环后回到预热后的第一根 K 线
+	replay.Advance()
+	replay.Advance()
+	if replay.Laps() != 1 {
+		t.Errorf("Laps() = %d, want 1", replay.Laps())
+	}
+	prices, _ = client.NewListPricesService().Symbol("BTCUSDT").Do(ctx)
+	if len(prices) != 1 || prices[0].Price != "102" {
+		t.Errorf("price after wrap = %v, want 102", prices)
+	}
+
+	if _, err := client.NewGetOpenInterestService().Symbol("BTCUSDT").Do(ctx); err == nil {
+		t.Error("unrecorded endpoint should return an error")
+	}
+	if _, err := client.NewListPricesService().Symbol("ETHUSDT").Do(ctx); err == nil {
+		t.Error("unrecorded symbol should return an error")
+	}
+}
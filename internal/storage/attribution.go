@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// AttributionRow is the performance of the closed positions sharing one symbol, entry action or leverage bucket
+// AttributionRow 是同一交易对、开仓动作或杠杆区间的已平仓持仓表现
+type AttributionRow struct {
+	Key         string  `json:"key"`          // 交易对 / 动作 / 杠杆区间 / Symbol, action or leverage bucket
+	Trades      int     `json:"trades"`       // 已平仓持仓数 / Closed positions
+	Wins        int     `json:"wins"`         // 盈利持仓数 / Winning positions
+	Losses      int     `json:"losses"`       // 亏损持仓数 / Losing positions
+	WinRate     float64 `json:"win_rate"`     // 胜率（%）/ Win rate (%)
+	RealizedPnL float64 `json:"realized_pnl"` // 已实现盈亏（含分批平仓，已扣成本）/ Realized PnL including partial closes, net of costs
+	Fees        float64 `json:"fees"`         // 其中的手续费 / Trading fees included in it
+	AverageR    float64 `json:"average_r"`    // 平均 R 倍数 / Average R multiple
+	RTrades     int     `json:"r_trades"`     // 参与平均 R 计算的持仓数 / Positions used for the average R
+	totalR      float64
+}
+
+// PerformanceAttribution breaks the closed positions down by symbol, entry action and leverage bucket
+// PerformanceAttribution 按交易对、开仓动作和杠杆区间拆分已平仓持仓的表现
+type PerformanceAttribution struct {
+	Since      time.Time         `json:"since"`       // 统计起点（零值表示全部）/ Start of the period (zero = all)
+	BySymbol   []*AttributionRow `json:"by_symbol"`   // 按已实现盈亏从低到高 / Lowest PnL first
+	ByAction   []*AttributionRow `json:"by_action"`   // BUY / SELL / BUY_STOP / SELL_STOP，按已实现盈亏从低到高 / Lowest PnL first
+	ByLeverage []*AttributionRow `json:"by_leverage"` // 按杠杆从低到高 / Lowest leverage first
+}
+
+// leverageBuckets are the upper bounds of the leverage buckets (the last one is open-ended)
+// leverageBuckets 是杠杆区间的上限（最后一个区间没有上限）
+var leverageBuckets = []struct {
+	max   int
+	label string
+}{
+	{3, "1-3x"},
+	{5, "4-5x"},
+	{10, "6-10x"},
+	{20, "11-20x"},
+	{math.MaxInt, "21x+"},
+}
+
+// LeverageBucket returns the label of the bucket a leverage falls in
+// LeverageBucket 返回杠杆所属区间的标签
+func LeverageBucket(leverage int) string {
+	for _, b := range leverageBuckets {
+		if leverage <= b.max {
+			return b.label
+		}
+	}
+	return leverageBuckets[len(leverageBuckets)-1].label
+}
+
+// leverageBucketIndex returns the position of a bucket label in leverageBuckets
+// leverageBucketIndex 返回杠杆区间标签在 leverageBuckets 中的位置
+func leverageBucketIndex(label string) int {
+	for i, b := range leverageBuckets {
+		if b.label == label {
+			return i
+		}
+	}
+	return len(leverageBuckets)
+}
+
+// GetPerformanceAttribution returns the performance of the positions closed since a time (zero = all)
+// GetPerformanceAttribution 返回指定时间以来已平仓持仓的表现拆分（零值表示全部）
+//
+// The entry action is BUY_STOP / SELL_STOP for positions opened by a stop-entry fill, otherwise BUY / SELL
+// by side. PnL and R are counted as in GetTradeStats, over the position including its partial closes.
+// 开仓动作：由条件入场单成交创建的持仓为 BUY_STOP / SELL_STOP，其余按方向为 BUY / SELL。
+// 盈亏和 R 的计算与 GetTradeStats 相同，包含分批平仓部分。
+func (s *Storage) GetPerformanceAttribution(since time.Time) (*PerformanceAttribution, error) {
+	rows, err := s.db.Query(`
+	SELECT p.symbol, p.side, p.leverage, p.entry_price, p.initial_stop_loss,
+		   p.quantity + COALESCE(p.partial_closed_qty, 0),
+		   COALESCE(p.realized_pnl, 0) + COALESCE(p.partial_realized_pnl, 0),
+		   COALESCE(p.trading_fee, 0),
+		   EXISTS (SELECT 1 FROM pending_entries e WHERE e.position_id = p.id)
+	FROM positions p
+	WHERE p.closed = 1 AND (? OR p.close_time >= ?)
+	`, since.IsZero(), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed positions: %w", err)
+	}
+	defer rows.Close()
+
+	symbols := make(map[string]*AttributionRow)
+	actions := make(map[string]*AttributionRow)
+	leverages := make(map[string]*AttributionRow)
+	for rows.Next() {
+		var symbol, side string
+		var leverage int
+		var entry, stop, qty, pnl, fees float64
+		var stopEntry bool
+		if err := rows.Scan(&symbol, &side, &leverage, &entry, &stop, &qty, &pnl, &fees, &stopEntry); err != nil {
+			return nil, fmt.Errorf("failed to scan closed position: %w", err)
+		}
+
+		action := "BUY"
+		if side == "short" {
+			action = "SELL"
+		}
+		if stopEntry {
+			action += "_STOP"
+		}
+		for _, row := range []*AttributionRow{
+			attributionRow(symbols, symbol),
+			attributionRow(actions, action),
+			attributionRow(leverages, LeverageBucket(leverage)),
+		} {
+			row.add(entry, stop, qty, pnl, fees)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	byPnL := func(m map[string]*AttributionRow) []*AttributionRow {
+		result := finishAttribution(m)
+		sort.SliceStable(result, func(i, j int) bool { return result[i].RealizedPnL < result[j].RealizedPnL })
+		return result
+	}
+	attribution := &PerformanceAttribution{
+		Since:      since,
+		BySymbol:   byPnL(symbols),
+		ByAction:   byPnL(actions),
+		ByLeverage: finishAttribution(leverages),
+	}
+	sort.SliceStable(attribution.ByLeverage, func(i, j int) bool {
+		return leverageBucketIndex(attribution.ByLeverage[i].Key) < leverageBucketIndex(attribution.ByLeverage[j].Key)
+	})
+	return attribution, nil
+}
+
+// attributionRow returns the row of a key, creating it if needed
+// attributionRow 返回 key 对应的行，不存在时创建
+func attributionRow(rows map[string]*AttributionRow, key string) *AttributionRow {
+	row, ok := rows[key]
+	if !ok {
+		row = &AttributionRow{Key: key}
+		rows[key] = row
+	}
+	return row
+}
+
+// add counts one closed position
+// add 计入一个已平仓持仓
+func (r *AttributionRow) add(entry, stop, qty, pnl, fees float64) {
+	r.Trades++
+	switch {
+	case pnl > 0:
+		r.Wins++
+	case pnl < 0:
+		r.Losses++
+	}
+	r.RealizedPnL += pnl
+	r.Fees += fees
+	if risk := math.Abs(entry-stop) * qty; risk > 0 {
+		r.totalR += pnl / risk
+		r.RTrades++
+	}
+}
+
+// finishAttribution computes the derived fields of the rows and returns them sorted by key
+// finishAttribution 计算各行的派生字段，并按 key 排序返回
+func finishAttribution(rows map[string]*AttributionRow) []*AttributionRow {
+	result := make([]*AttributionRow, 0, len(rows))
+	for _, row := range rows {
+		if row.Trades > 0 {
+			row.WinRate = float64(row.Wins) / float64(row.Trades) * 100
+		}
+		if row.RTrades > 0 {
+			row.AverageR = row.totalR / float64(row.RTrades)
+		}
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
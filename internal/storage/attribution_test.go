@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPerformanceAttribution tests the breakdown of closed positions by symbol, entry action and leverage
+// TestPerformanceAttribution 测试已平仓持仓按交易对、开仓动作和杠杆的拆分
+func TestPerformanceAttribution(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "attribution.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, p := range []struct {
+		id       string
+		symbol   string
+		side     string
+		leverage int
+		pnl      float64
+		fee      float64
+		closed   bool
+	}{
+		{"btc-1", "BTCUSDT", "long", 10, 20, 1, true},  // 1R
+		{"btc-2", "BTCUSDT", "short", 5, -10, 1, true}, // -0.5R
+		{"eth-1", "ETHUSDT", "long", 25, -30, 2, true}, // 条件入场 / Stop entry
+		{"eth-2", "ETHUSDT", "long", 3, 5, 0, false},   // 未平仓，不计入 / Open, not counted
+	} {
+		pos := &PositionRecord{
+			ID: p.id, Symbol: p.symbol, Side: p.side, EntryPrice: 100, EntryTime: now.Add(-time.Hour),
+			Quantity: 10, Leverage: p.leverage, InitialStopLoss: 98, CurrentStopLoss: 98, StopLossType: "fixed",
+		}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+		if p.closed {
+			pos.Closed, pos.CloseTime, pos.RealizedPnL = true, &now, p.pnl
+			if err := db.UpdatePosition(pos); err != nil {
+				t.Fatalf("UpdatePosition failed: %v", err)
+			}
+		}
+		if err := db.SavePositionFees(pos.ID, p.fee); err != nil {
+			t.Fatalf("SavePositionFees failed: %v", err)
+		}
+	}
+	if _, err := db.SavePendingEntry(&PendingEntry{
+		Symbol: "ETHUSDT", Side: "long", TriggerPrice: 100, Quantity: 10, Leverage: 25,
+		Status: EntryStatusFilled, FillPrice: 100, PositionID: "eth-1", ExpiresAt: now.Add(time.Hour), ResolvedAt: now,
+	}); err != nil {
+		t.Fatalf("SavePendingEntry failed: %v", err)
+	}
+
+	attribution, err := db.GetPerformanceAttribution(time.Time{})
+	if err != nil {
+		t.Fatalf("GetPerformanceAttribution failed: %v", err)
+	}
+
+	if len(attribution.BySymbol) != 2 || attribution.BySymbol[0].Key != "ETHUSDT" {
+		t.Fatalf("Expected ETHUSDT (the loser) first, got %+v", attribution.BySymbol)
+	}
+	btc := attribution.BySymbol[1]
+	if btc.Trades != 2 || btc.Wins != 1 || btc.Losses != 1 || btc.WinRate != 50 || btc.RealizedPnL != 10 ||
+		btc.Fees != 2 || math.Abs(btc.AverageR-0.25) > 1e-9 || btc.RTrades != 2 {
+		t.Errorf("Unexpected BTCUSDT attribution: %+v", btc)
+	}
+
+	actions := make(map[string]int)
+	for _, row := range attribution.ByAction {
+		actions[row.Key] = row.Trades
+	}
+	if len(actions) != 3 || actions["BUY"] != 1 || actions["SELL"] != 1 || actions["BUY_STOP"] != 1 {
+		t.Errorf("Unexpected action attribution: %v", actions)
+	}
+
+	var buckets []string
+	for _, row := range attribution.ByLeverage {
+		buckets = append(buckets, row.Key)
+	}
+	if len(buckets) != 3 || buckets[0] != "4-5x" || buckets[1] != "6-10x" || buckets[2] != "21x+" {
+		t.Errorf("Unexpected leverage buckets: %v", buckets)
+	}
+
+	// Positions closed before since are left out / 早于起点平仓的持仓不计入
+	if later, err := db.GetPerformanceAttribution(now.Add(time.Minute)); err != nil || len(later.BySymbol) != 0 {
+		t.Errorf("Expected no positions after since, got %+v (%v)", later, err)
+	}
+
+	if LeverageBucket(1) != "1-3x" || LeverageBucket(20) != "11-20x" || LeverageBucket(125) != "21x+" {
+		t.Error("Unexpected leverage bucket labels")
+	}
+}
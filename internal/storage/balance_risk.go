@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// BalanceAsset is the balance of one asset of the futures wallet
+// BalanceAsset 表示合约钱包中单个资产的余额
+type BalanceAsset struct {
+	Asset         string  `json:"asset"`          // 资产 / Asset
+	Wallet        float64 `json:"wallet"`         // 钱包余额 / Wallet balance
+	Available     float64 `json:"available"`      // 可用余额 / Available balance
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏 / Unrealized PnL
+}
+
+// balanceHistoryColumns are the balance_history columns read by scanBalanceHistory
+// balanceHistoryColumns 是 scanBalanceHistory 读取的 balance_history 字段
+//
+// Snapshots saved before the account risk columns existed read them as zero.
+// 账户风险字段加入之前保存的快照读取为零值。
+const balanceHistoryColumns = `id, timestamp, total_balance, available_balance, unrealized_pnl, positions,
+	COALESCE(margin_balance, 0), COALESCE(maintenance_margin, 0), COALESCE(margin_ratio, 0), assets`
+
+// initBalanceRiskSchema adds the account risk columns to balance_history
+// initBalanceRiskSchema 为 balance_history 表添加账户风险字段
+func (s *Storage) initBalanceRiskSchema() {
+	columns := []string{
+		"margin_balance REAL",
+		"maintenance_margin REAL",
+		"margin_ratio REAL",
+		"assets TEXT",
+	}
+
+	// Run each ALTER separately so one existing column doesn't skip the rest
+	// 逐条执行 ALTER，避免某个字段已存在导致后续字段被跳过
+	for _, column := range columns {
+		s.exec("ALTER TABLE balance_history ADD COLUMN " + column)
+	}
+}
+
+// encodeBalanceAssets encodes the per-asset breakdown as JSON (NULL when empty)
+// encodeBalanceAssets 将分资产明细编码为 JSON（为空时为 NULL）
+func encodeBalanceAssets(assets []BalanceAsset) (sql.NullString, error) {
+	if len(assets) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(assets)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode balance assets: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// scanBalanceHistory scans a row selected with balanceHistoryColumns
+// scanBalanceHistory 读取以 balanceHistoryColumns 查询的一行
+func scanBalanceHistory(rows *sql.Rows) (*BalanceHistory, error) {
+	h := &BalanceHistory{}
+	var assets sql.NullString
+	err := rows.Scan(
+		&h.ID,
+		&h.Timestamp,
+		&h.TotalBalance,
+		&h.AvailableBalance,
+		&h.UnrealizedPnL,
+		&h.Positions,
+		&h.MarginBalance,
+		&h.MaintenanceMargin,
+		&h.MarginRatio,
+		&assets,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan balance history: %w", err)
+	}
+	if assets.Valid && assets.String != "" {
+		if err := json.Unmarshal([]byte(assets.String), &h.Assets); err != nil {
+			return nil, fmt.Errorf("failed to decode balance assets: %w", err)
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBalanceRiskRoundTrip(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "balance.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	// 旧格式快照：没有账户风险字段
+	if err := db.SaveBalanceHistory(&BalanceHistory{Timestamp: now.Add(-time.Minute), TotalBalance: 1000, AvailableBalance: 900}); err != nil {
+		t.Fatalf("SaveBalanceHistory failed: %v", err)
+	}
+	if err := db.SaveBalanceHistory(&BalanceHistory{
+		Timestamp:         now,
+		TotalBalance:      1000,
+		AvailableBalance:  600,
+		UnrealizedPnL:     -50,
+		Positions:         1,
+		MarginBalance:     950,
+		MaintenanceMargin: 19,
+		MarginRatio:       2,
+		Assets: []BalanceAsset{
+			{Asset: "USDT", Wallet: 800, Available: 500, UnrealizedPnL: -50},
+			{Asset: "USDC", Wallet: 200, Available: 100},
+		},
+	}); err != nil {
+		t.Fatalf("SaveBalanceHistory failed: %v", err)
+	}
+
+	history, err := db.GetBalanceHistoryBetween(now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil || len(history) != 2 {
+		t.Fatalf("GetBalanceHistoryBetween = %d snapshots, %v; want 2", len(history), err)
+	}
+	if old := history[0]; old.MarginBalance != 0 || old.MarginRatio != 0 || old.Assets != nil {
+		t.Errorf("Expected zero account risk for the old snapshot, got %+v", old)
+	}
+	h := history[1]
+	if h.MarginBalance != 950 || h.MaintenanceMargin != 19 || h.MarginRatio != 2 {
+		t.Errorf("Unexpected account risk: %.2f, %.2f, %.2f", h.MarginBalance, h.MaintenanceMargin, h.MarginRatio)
+	}
+	if len(h.Assets) != 2 || h.Assets[0].Asset != "USDT" || h.Assets[0].UnrealizedPnL != -50 || h.Assets[1].Wallet != 200 {
+		t.Errorf("Unexpected per-asset breakdown: %+v", h.Assets)
+	}
+}
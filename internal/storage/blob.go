@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reports shorter than this are stored as plain text (gzip overhead isn't worth it)
+// 短于此长度的报告以纯文本保存（压缩收益不足以抵消 gzip 开销）
+const minCompressSize = 512
+
+// gzipMagic is the gzip header used to detect compressed values on read
+// gzipMagic 是读取时用于识别压缩值的 gzip 文件头
+const gzipMagic = "\x1f\x8b"
+
+// SetReportCompression enables gzip compression of report columns on write
+// SetReportCompression 启用写入时对报告字段的 gzip 压缩
+//
+// Reads always decompress transparently, so plain and compressed rows can coexist.
+// 读取时始终透明解压，因此明文和压缩数据可以共存。
+func (s *Storage) SetReportCompression(enabled bool) {
+	s.compressReports = enabled
+}
+
+// encodeBlob returns the value to store for a report column ([]byte when compressed, string otherwise)
+// encodeBlob 返回报告字段要保存的值（压缩时为 []byte，否则为 string）
+func (s *Storage) encodeBlob(text string) interface{} {
+	if !s.compressReports || len(text) < minCompressSize {
+		return text
+	}
+
+	compressed, err := compressText(text)
+	if err != nil || len(compressed) >= len(text) {
+		return text
+	}
+	return compressed
+}
+
+// compressText gzips text
+// compressText 使用 gzip 压缩文本
+func compressText(text string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBlob returns the plain text of a stored report column, decompressing if needed
+// decodeBlob 返回报告字段的明文内容，必要时进行解压
+func decodeBlob(value string) string {
+	if !strings.HasPrefix(value, gzipMagic) {
+		return value
+	}
+
+	zr, err := gzip.NewReader(strings.NewReader(value))
+	if err != nil {
+		return value
+	}
+	defer zr.Close()
+
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return value
+	}
+	return string(plain)
+}
+
+// decodeSessionBlobs decompresses the report columns of a scanned session in place
+// decodeSessionBlobs 原地解压已读取会话的报告字段
+func decodeSessionBlobs(session *TradingSession) {
+	session.MarketReport = decodeBlob(session.MarketReport)
+	session.CryptoReport = decodeBlob(session.CryptoReport)
+	session.SentimentReport = decodeBlob(session.SentimentReport)
+	session.FullDecision = decodeBlob(session.FullDecision)
+}
+
+// CompressExistingReports compresses report columns of rows written before compression was enabled
+// CompressExistingReports 压缩启用压缩之前写入的历史报告字段
+//
+// Runs VACUUM afterwards when anything changed so the file actually shrinks. Returns the number of rows rewritten.
+// 有数据变更时随后执行 VACUUM 以真正缩小数据库文件。返回被重写的行数。
+func (s *Storage) CompressExistingReports() (int, error) {
+	if !s.compressReports {
+		return 0, nil
+	}
+
+	rows, err := s.db.Query(`
+	SELECT id, COALESCE(market_report, ''), COALESCE(crypto_report, ''),
+		   COALESCE(sentiment_report, ''), COALESCE(full_decision, '')
+	FROM trading_sessions
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query reports: %w", err)
+	}
+
+	type reportRow struct {
+		id                                int64
+		market, crypto, sentiment, decide string
+	}
+	var pending []reportRow
+	for rows.Next() {
+		var r reportRow
+		if err := rows.Scan(&r.id, &r.market, &r.crypto, &r.sentiment, &r.decide); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan reports: %w", err)
+		}
+		if needsCompression(r.market) || needsCompression(r.crypto) ||
+			needsCompression(r.sentiment) || needsCompression(r.decide) {
+			pending = append(pending, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range pending {
+		_, err := s.exec(`
+		UPDATE trading_sessions SET market_report = ?, crypto_report = ?, sentiment_report = ?, full_decision = ?
+		WHERE id = ?
+		`, s.encodeBlob(r.market), s.encodeBlob(r.crypto), s.encodeBlob(r.sentiment), s.encodeBlob(r.decide), r.id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to compress session %d: %w", r.id, err)
+		}
+	}
+
+	if len(pending) > 0 {
+		if _, err := s.exec("VACUUM"); err != nil {
+			return len(pending), fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	return len(pending), nil
+}
+
+// needsCompression reports whether a stored value is plain text large enough to compress
+// needsCompression 判断保存的值是否为足够大的未压缩文本
+func needsCompression(value string) bool {
+	return len(value) >= minCompressSize && !strings.HasPrefix(value, gzipMagic)
+}
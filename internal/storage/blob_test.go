@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportCompressionRoundTrip(t *testing.T) {
+	tmpDB := "./test_blob.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 先以明文写入一条旧记录
+	longReport := strings.Repeat("BTC 价格在 EMA20 上方运行，MACD 金叉。\n", 100)
+	legacyID, err := db.SaveSession(&TradingSession{
+		Symbol:       "BTC/USDT",
+		Timeframe:    "1h",
+		CreatedAt:    time.Now(),
+		MarketReport: longReport,
+		Decision:     "HOLD",
+	})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	db.SetReportCompression(true)
+
+	// 压缩写入新记录，短报告保持明文
+	newID, err := db.SaveSession(&TradingSession{
+		Symbol:       "BTC/USDT",
+		Timeframe:    "1h",
+		CreatedAt:    time.Now(),
+		MarketReport: longReport,
+		CryptoReport: "short",
+		FullDecision: longReport,
+		Decision:     "BUY",
+	})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	var stored, short string
+	if err := db.db.QueryRow("SELECT market_report, crypto_report FROM trading_sessions WHERE id = ?", newID).Scan(&stored, &short); err != nil {
+		t.Fatalf("raw query failed: %v", err)
+	}
+	if !strings.HasPrefix(stored, gzipMagic) || len(stored) >= len(longReport) {
+		t.Errorf("Expected compressed market report, got %d bytes", len(stored))
+	}
+	if short != "short" {
+		t.Errorf("Expected short report stored as plain text, got %q", short)
+	}
+
+	// 读取时透明解压
+	session, err := db.GetSessionByID(newID)
+	if err != nil {
+		t.Fatalf("GetSessionByID failed: %v", err)
+	}
+	if session.MarketReport != longReport || session.FullDecision != longReport {
+		t.Error("Expected reports to be decompressed on read")
+	}
+
+	// 压缩历史明文记录
+	compacted, err := db.CompressExistingReports()
+	if err != nil {
+		t.Fatalf("CompressExistingReports failed: %v", err)
+	}
+	if compacted != 1 {
+		t.Errorf("Expected 1 compacted session, got %d", compacted)
+	}
+
+	sessions, err := db.GetLatestSessions(10)
+	if err != nil {
+		t.Fatalf("GetLatestSessions failed: %v", err)
+	}
+	for _, s := range sessions {
+		if s.ID == legacyID && s.MarketReport != longReport {
+			t.Error("Expected legacy report to survive compaction")
+		}
+	}
+}
+
+func TestDecodeBlobPlainText(t *testing.T) {
+	// 未压缩的旧数据原样返回
+	if got := decodeBlob("plain report"); got != "plain report" {
+		t.Errorf("decodeBlob() = %q, want plain text", got)
+	}
+
+	// 损坏的压缩数据原样返回，不丢失内容
+	broken := gzipMagic + "garbage"
+	if got := decodeBlob(broken); got != broken {
+		t.Errorf("decodeBlob() = %q, want original value", got)
+	}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// StateCheckpoint is the end-of-day snapshot of the bot state and its diff against the previous one
+// StateCheckpoint 是机器人状态的日终快照及其与上一次快照的差异
+type StateCheckpoint struct {
+	ID         int64
+	Day        string    // 快照日期（UTC，YYYY-MM-DD）/ Checkpoint day (UTC, YYYY-MM-DD)
+	State      string    // 状态快照（JSON）/ State snapshot (JSON)
+	ConfigHash string    // 配置指纹 / Configuration fingerprint
+	Diff       string    // 与上一次快照的差异（每行一项，首次为空）/ Diff against the previous checkpoint (one line per change, empty the first time)
+	Anomalies  int       // 需要关注的异常数（如孤儿订单）/ Anomalies needing attention (e.g. orphaned orders)
+	CreatedAt  time.Time // 快照时间 / When the snapshot was taken
+}
+
+// initCheckpointSchema creates the state_checkpoints table if it doesn't exist
+// initCheckpointSchema 创建 state_checkpoints 表（如果不存在）
+func (s *Storage) initCheckpointSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS state_checkpoints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		day TEXT NOT NULL UNIQUE,
+		state TEXT NOT NULL,
+		config_hash TEXT,
+		diff TEXT,
+		anomalies INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveStateCheckpoint stores the checkpoint of a day, replacing an earlier one of the same day
+// SaveStateCheckpoint 保存某一天的快照，覆盖同一天较早的快照
+func (s *Storage) SaveStateCheckpoint(cp *StateCheckpoint) (int64, error) {
+	if cp.CreatedAt.IsZero() {
+		cp.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT OR REPLACE INTO state_checkpoints (day, state, config_hash, diff, anomalies, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`, cp.Day, cp.State, cp.ConfigHash, cp.Diff, cp.Anomalies, cp.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save state checkpoint: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetStateCheckpoints retrieves the latest checkpoints, newest first
+// GetStateCheckpoints 获取最近的状态快照，按日期倒序
+func (s *Storage) GetStateCheckpoints(limit int) ([]*StateCheckpoint, error) {
+	return s.queryCheckpoints(`ORDER BY day DESC LIMIT ?`, limit)
+}
+
+// GetStateCheckpointBefore returns the latest checkpoint of a day before the given one (nil if there is none)
+// GetStateCheckpointBefore 返回指定日期之前最近的快照（没有则返回 nil）
+func (s *Storage) GetStateCheckpointBefore(day string) (*StateCheckpoint, error) {
+	checkpoints, err := s.queryCheckpoints(`WHERE day < ? ORDER BY day DESC LIMIT 1`, day)
+	if err != nil || len(checkpoints) == 0 {
+		return nil, err
+	}
+	return checkpoints[0], nil
+}
+
+// HasStateCheckpoint reports whether a checkpoint was already taken on the given day
+// HasStateCheckpoint 返回指定日期是否已有快照
+func (s *Storage) HasStateCheckpoint(day string) (bool, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM state_checkpoints WHERE day = ?`, day).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to query state checkpoint: %w", err)
+	}
+	return count > 0, nil
+}
+
+// queryCheckpoints runs a checkpoint query with the given clause
+// queryCheckpoints 使用给定条件查询状态快照
+func (s *Storage) queryCheckpoints(clause string, args ...interface{}) ([]*StateCheckpoint, error) {
+	rows, err := s.db.Query(`
+	SELECT id, day, state, config_hash, diff, anomalies, created_at
+	FROM state_checkpoints
+	`+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []*StateCheckpoint
+	for rows.Next() {
+		cp := &StateCheckpoint{}
+		var configHash, diff sql.NullString
+		if err := rows.Scan(&cp.ID, &cp.Day, &cp.State, &configHash, &diff, &cp.Anomalies, &cp.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan state checkpoint: %w", err)
+		}
+		cp.ConfigHash = configHash.String
+		cp.Diff = diff.String
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, rows.Err()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateCheckpoints(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "checkpoints.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 没有快照时返回 nil
+	if prev, err := db.GetStateCheckpointBefore("2025-03-10"); err != nil || prev != nil {
+		t.Fatalf("Expected no checkpoint, got %+v (%v)", prev, err)
+	}
+
+	for _, cp := range []*StateCheckpoint{
+		{Day: "2025-03-08", State: `{"a":1}`, ConfigHash: "aaaa"},
+		{Day: "2025-03-09", State: `{"a":2}`, ConfigHash: "aaaa", Diff: "💰 USDT", Anomalies: 1},
+		{Day: "2025-03-09", State: `{"a":3}`, ConfigHash: "bbbb"}, // 同一天覆盖 / Same day replaces
+	} {
+		if _, err := db.SaveStateCheckpoint(cp); err != nil {
+			t.Fatalf("SaveStateCheckpoint failed: %v", err)
+		}
+	}
+
+	prev, err := db.GetStateCheckpointBefore("2025-03-10")
+	if err != nil {
+		t.Fatalf("GetStateCheckpointBefore failed: %v", err)
+	}
+	if prev.Day != "2025-03-09" || prev.State != `{"a":3}` || prev.ConfigHash != "bbbb" || prev.Anomalies != 0 {
+		t.Errorf("Unexpected previous checkpoint: %+v", prev)
+	}
+
+	if done, err := db.HasStateCheckpoint("2025-03-09"); err != nil || !done {
+		t.Errorf("Expected a checkpoint on 2025-03-09 (%v)", err)
+	}
+	if done, _ := db.HasStateCheckpoint("2025-03-10"); done {
+		t.Error("Expected no checkpoint on 2025-03-10")
+	}
+
+	all, err := db.GetStateCheckpoints(10)
+	if err != nil {
+		t.Fatalf("GetStateCheckpoints failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Day != "2025-03-09" || all[1].Day != "2025-03-08" {
+		t.Errorf("Unexpected checkpoints: %+v", all)
+	}
+}
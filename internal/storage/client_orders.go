@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Client order statuses besides Binance's own (NEW, PARTIALLY_FILLED, FILLED, CANCELED, REJECTED, EXPIRED)
+// 除币安自身状态（NEW、PARTIALLY_FILLED、FILLED、CANCELED、REJECTED、EXPIRED）外的客户端订单状态
+const (
+	ClientOrderPending = "PENDING" // 已发送，尚未收到交易所响应 / Sent, no exchange response yet
+	ClientOrderMissing = "MISSING" // 交易所查无此单（未送达）/ Unknown to the exchange (never arrived)
+)
+
+// ClientOrder is an order placed with our own deterministic newClientOrderId
+// ClientOrder 是使用确定性 newClientOrderId 下达的订单
+//
+// It is saved before the order is sent, so after a crash the order can be looked up on the exchange
+// by its client order id even if the response was never received.
+// 订单在发送前保存，因此即使崩溃时未收到响应，也能按客户端订单 ID 在交易所查到该订单。
+type ClientOrder struct {
+	ClientOrderID string    // 客户端订单 ID / Client order id
+	BatchID       string    // 下单批次 / Batch that placed it
+	Symbol        string    // 交易对（币安格式）/ Trading pair (Binance format)
+	Leg           string    // 订单用途（open / close / partial）/ What the order does (open / close / partial)
+	Side          string    // BUY/SELL
+	Quantity      float64   // 下单数量 / Ordered quantity
+	OrderID       int64     // 交易所订单 ID（未收到响应时为 0）/ Exchange order id (0 until known)
+	Status        string    // 订单状态 / Order status
+	AvgPrice      float64   // 成交均价 / Average fill price
+	ExecutedQty   float64   // 已成交数量 / Executed quantity
+	CreatedAt     time.Time // 发送时间 / Send time
+	UpdatedAt     time.Time
+}
+
+// initClientOrderSchema creates the client_orders table if it doesn't exist
+// initClientOrderSchema 创建 client_orders 表（如果不存在）
+func (s *Storage) initClientOrderSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS client_orders (
+		client_order_id TEXT PRIMARY KEY,
+		batch_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		leg TEXT NOT NULL,
+		side TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		order_id INTEGER DEFAULT 0,
+		status TEXT NOT NULL,
+		avg_price REAL DEFAULT 0,
+		executed_qty REAL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_client_orders_status ON client_orders(status);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveClientOrder stores an order about to be sent; it returns false if the client order id already exists
+// SaveClientOrder 保存即将发送的订单；客户端订单 ID 已存在时返回 false
+func (s *Storage) SaveClientOrder(order *ClientOrder) (bool, error) {
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	if order.Status == "" {
+		order.Status = ClientOrderPending
+	}
+	order.UpdatedAt = order.CreatedAt
+	result, err := s.exec(`
+	INSERT OR IGNORE INTO client_orders (
+		client_order_id, batch_id, symbol, leg, side, quantity, order_id, status, avg_price, executed_qty, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, order.ClientOrderID, order.BatchID, order.Symbol, order.Leg, order.Side, order.Quantity,
+		order.OrderID, order.Status, order.AvgPrice, order.ExecutedQty, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to save client order: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// UpdateClientOrder records the exchange's view of an order
+// UpdateClientOrder 记录交易所返回的订单状态
+func (s *Storage) UpdateClientOrder(clientOrderID string, orderID int64, status string, avgPrice, executedQty float64) error {
+	_, err := s.exec(`
+	UPDATE client_orders SET order_id = ?, status = ?, avg_price = ?, executed_qty = ?, updated_at = ?
+	WHERE client_order_id = ?
+	`, orderID, status, avgPrice, executedQty, time.Now(), clientOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to update client order: %w", err)
+	}
+	return nil
+}
+
+// GetClientOrder retrieves an order by client order id (nil if unknown)
+// GetClientOrder 按客户端订单 ID 获取订单（不存在时为 nil）
+func (s *Storage) GetClientOrder(clientOrderID string) (*ClientOrder, error) {
+	orders, err := s.queryClientOrders(`WHERE client_order_id = ?`, clientOrderID)
+	if err != nil || len(orders) == 0 {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// GetUnsettledClientOrders returns the orders whose final state is not known yet, oldest first
+// GetUnsettledClientOrders 返回尚未确定最终状态的订单，按时间正序
+func (s *Storage) GetUnsettledClientOrders() ([]*ClientOrder, error) {
+	return s.queryClientOrders(`WHERE status IN (?, 'NEW', 'PARTIALLY_FILLED') ORDER BY created_at ASC`, ClientOrderPending)
+}
+
+// queryClientOrders runs a client order query with the given filter
+// queryClientOrders 使用给定条件查询客户端订单
+func (s *Storage) queryClientOrders(filter string, args ...interface{}) ([]*ClientOrder, error) {
+	rows, err := s.db.Query(`
+	SELECT client_order_id, batch_id, symbol, leg, side, quantity, order_id, status, avg_price, executed_qty, created_at, updated_at
+	FROM client_orders
+	`+filter, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query client orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*ClientOrder
+	for rows.Next() {
+		o := &ClientOrder{}
+		var orderID sql.NullInt64
+		var avgPrice, executedQty sql.NullFloat64
+		if err := rows.Scan(&o.ClientOrderID, &o.BatchID, &o.Symbol, &o.Leg, &o.Side, &o.Quantity, &orderID,
+			&o.Status, &avgPrice, &executedQty, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan client order: %w", err)
+		}
+		o.OrderID = orderID.Int64
+		o.AvgPrice = avgPrice.Float64
+		o.ExecutedQty = executedQty.Float64
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientOrders(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "client_orders.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	orders := []*ClientOrder{
+		{ClientOrderID: "ctb-1700000000-BTCUSDT-open", BatchID: "batch-1700000000", Symbol: "BTCUSDT", Leg: "open", Side: "BUY", Quantity: 0.01, CreatedAt: now.Add(-time.Minute)},
+		{ClientOrderID: "ctb-1700000000-ETHUSDT-close", BatchID: "batch-1700000000", Symbol: "ETHUSDT", Leg: "close", Side: "SELL", Quantity: 0.5, CreatedAt: now},
+	}
+	for _, order := range orders {
+		if saved, err := db.SaveClientOrder(order); err != nil || !saved {
+			t.Fatalf("SaveClientOrder failed: %v, %v", saved, err)
+		}
+	}
+	if saved, err := db.SaveClientOrder(&ClientOrder{ClientOrderID: orders[0].ClientOrderID, BatchID: "batch-1700000000", Symbol: "BTCUSDT", Leg: "open", Side: "BUY"}); err != nil || saved {
+		t.Errorf("Expected a duplicate client order id to be refused, got %v, %v", saved, err)
+	}
+
+	unsettled, err := db.GetUnsettledClientOrders()
+	if err != nil || len(unsettled) != 2 || unsettled[0].ClientOrderID != orders[0].ClientOrderID || unsettled[0].Status != ClientOrderPending {
+		t.Fatalf("Expected both orders pending oldest first, got %+v, %v", unsettled, err)
+	}
+
+	if err := db.UpdateClientOrder(orders[0].ClientOrderID, 42, "FILLED", 50000, 0.01); err != nil {
+		t.Fatalf("UpdateClientOrder failed: %v", err)
+	}
+	got, err := db.GetClientOrder(orders[0].ClientOrderID)
+	if err != nil || got == nil || got.OrderID != 42 || got.Status != "FILLED" || got.AvgPrice != 50000 || got.ExecutedQty != 0.01 {
+		t.Errorf("Unexpected order after update: %+v, %v", got, err)
+	}
+	if unsettled, _ := db.GetUnsettledClientOrders(); len(unsettled) != 1 || unsettled[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected only the ETH order unsettled, got %+v", unsettled)
+	}
+	if got, err := db.GetClientOrder("unknown"); err != nil || got != nil {
+		t.Errorf("Expected nil for an unknown order, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DecisionOutcome links a closed position back to the decision that opened it
+// DecisionOutcome 将已平仓持仓与开仓时的决策关联起来
+type DecisionOutcome struct {
+	PositionID  string    `json:"position_id"`
+	SessionID   int64     `json:"session_id"` // 开仓决策的会话 ID（0 表示未找到，例如手动开仓）/ Session of the opening decision (0 = not found, e.g. manual entry)
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Action      string    `json:"action"`       // 开仓决策动作 / Action of the opening decision
+	Confidence  float64   `json:"confidence"`   // 决策置信度 0-1 / Decision confidence 0-1
+	RiskReward  float64   `json:"risk_reward"`  // 决策预期盈亏比（0 表示未给出）/ Expected risk/reward (0 = not stated)
+	RealizedPnL float64   `json:"realized_pnl"` // 已实现盈亏（含分批平仓）/ Realized PnL including partial closes
+	RMultiple   float64   `json:"r_multiple"`   // 实际 R 倍数（没有初始风险时为 0）/ Realized R multiple (0 without initial risk)
+	Win         bool      `json:"win"`
+	ClosedAt    time.Time `json:"closed_at"`
+	ScoredAt    time.Time `json:"scored_at"`
+}
+
+// CalibrationBucket compares the stated expectations of a group of decisions with their outcomes
+// CalibrationBucket 比较一组决策声明的预期与实际结果
+type CalibrationBucket struct {
+	Label         string  `json:"label"`
+	Trades        int     `json:"trades"`
+	Wins          int     `json:"wins"`
+	WinRate       float64 `json:"win_rate"`       // 实际胜率 % / Realized win rate %
+	AvgConfidence float64 `json:"avg_confidence"` // 平均置信度 %（校准良好时接近胜率）/ Average confidence % (close to the win rate when calibrated)
+	AvgRiskReward float64 `json:"avg_risk_reward"`
+	AvgR          float64 `json:"avg_r"` // 平均实际 R 倍数 / Average realized R multiple
+	AvgPnL        float64 `json:"avg_pnl"`
+}
+
+// DecisionCalibration summarizes whether the model's confidence and risk/reward mean anything
+// DecisionCalibration 汇总模型的置信度和盈亏比是否有参考价值
+type DecisionCalibration struct {
+	Scored       int                  `json:"scored"`      // 已关联到决策的平仓持仓数 / Closed positions linked to a decision
+	Unlinked     int                  `json:"unlinked"`    // 未找到开仓决策的平仓持仓数 / Closed positions without an opening decision
+	BrierScore   float64              `json:"brier_score"` // 置信度与输赢的均方误差（越低越好，0.25 相当于总是给 50%）/ Mean squared error of confidence vs outcome (lower is better, 0.25 = always 50%)
+	Confidence   []*CalibrationBucket `json:"confidence"`  // 按置信度分组 / By confidence
+	RiskReward   []*CalibrationBucket `json:"risk_reward"` // 按预期盈亏比分组 / By expected risk/reward
+	LatestScored []*DecisionOutcome   `json:"latest,omitempty"`
+}
+
+// confidenceBuckets are the lower bounds of the confidence buckets
+// confidenceBuckets 是置信度分组的下限
+var confidenceBuckets = []struct {
+	min   float64
+	label string
+}{
+	{0, "<50%"},
+	{0.5, "50-60%"},
+	{0.6, "60-70%"},
+	{0.7, "70-80%"},
+	{0.8, "80-90%"},
+	{0.9, "≥90%"},
+}
+
+// riskRewardBuckets are the lower bounds of the expected risk/reward buckets (0 = not stated)
+// riskRewardBuckets 是预期盈亏比分组的下限（0 表示未给出）
+var riskRewardBuckets = []struct {
+	min   float64
+	label string
+}{
+	{0, "未给出"},
+	{math.SmallestNonzeroFloat64, "<1.5"},
+	{1.5, "1.5-2"},
+	{2, "2-3"},
+	{3, "≥3"},
+}
+
+// initDecisionOutcomeSchema creates the decision_outcomes table if it doesn't exist
+// initDecisionOutcomeSchema 创建 decision_outcomes 表（如果不存在）
+func (s *Storage) initDecisionOutcomeSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS decision_outcomes (
+		position_id TEXT PRIMARY KEY,
+		session_id INTEGER DEFAULT 0,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		action TEXT,
+		confidence REAL DEFAULT 0,
+		risk_reward REAL DEFAULT 0,
+		realized_pnl REAL DEFAULT 0,
+		r_multiple REAL DEFAULT 0,
+		win BOOLEAN DEFAULT 0,
+		closed_at DATETIME,
+		scored_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_decision_outcomes_closed_at ON decision_outcomes(closed_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// ScoreClosedPositions links up to limit closed positions that haven't been scored yet to their opening
+// decision and stores the outcomes, returning how many were scored
+// ScoreClosedPositions 将最多 limit 个尚未评分的已平仓持仓关联到开仓决策并保存结果，返回评分数量
+//
+// Positions don't record the session that opened them, so the opening decision is the latest executed
+// BUY / BUY_STOP (long) or SELL / SELL_STOP (short) decision of the symbol made at or before the entry.
+// Positions without one (e.g. opened by hand) are stored with session 0 so they aren't looked up again.
+// 持仓没有记录开仓会话，因此开仓决策取该交易对在入场时间及之前最近一次已执行的 BUY / BUY_STOP（多）
+// 或 SELL / SELL_STOP（空）决策。找不到决策的持仓（例如手动开仓）以会话 0 保存，不会重复查找。
+func (s *Storage) ScoreClosedPositions(limit int) (int, error) {
+	rows, err := s.db.Query(`
+	SELECT p.id, p.symbol, p.side, p.entry_time, p.entry_price, p.initial_stop_loss,
+		   p.quantity + COALESCE(p.partial_closed_qty, 0),
+		   COALESCE(p.realized_pnl, 0) + COALESCE(p.partial_realized_pnl, 0),
+		   p.close_time
+	FROM positions p
+	LEFT JOIN decision_outcomes o ON o.position_id = p.id
+	WHERE p.closed = 1 AND o.position_id IS NULL
+	ORDER BY p.close_time ASC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query unscored positions: %w", err)
+	}
+
+	var outcomes []*DecisionOutcome
+	var entryTimes []time.Time
+	for rows.Next() {
+		o := &DecisionOutcome{}
+		var entryTime time.Time
+		var entry, stop, qty float64
+		var closeTime sql.NullTime
+		if err := rows.Scan(&o.PositionID, &o.Symbol, &o.Side, &entryTime, &entry, &stop, &qty, &o.RealizedPnL, &closeTime); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan unscored position: %w", err)
+		}
+		if risk := math.Abs(entry-stop) * qty; risk > 0 {
+			o.RMultiple = o.RealizedPnL / risk
+		}
+		o.Win = o.RealizedPnL > 0
+		o.ClosedAt = closeTime.Time
+		outcomes = append(outcomes, o)
+		entryTimes = append(entryTimes, entryTime)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, o := range outcomes {
+		actions := []string{"BUY", "BUY_STOP"}
+		if o.Side == "short" {
+			actions = []string{"SELL", "SELL_STOP"}
+		}
+		err := s.db.QueryRow(`
+		SELECT id, decision_action, COALESCE(decision_confidence, 0), COALESCE(decision_risk_reward, 0)
+		FROM trading_sessions
+		WHERE UPPER(REPLACE(symbol, '/', '')) = ? AND executed = 1 AND decision_action IN (?, ?) AND created_at <= ?
+		ORDER BY created_at DESC
+		LIMIT 1
+		`, NormalizeSymbol(o.Symbol), actions[0], actions[1], entryTimes[i]).Scan(&o.SessionID, &o.Action, &o.Confidence, &o.RiskReward)
+		if err != nil && err != sql.ErrNoRows {
+			return i, fmt.Errorf("failed to find opening decision: %w", err)
+		}
+
+		o.ScoredAt = time.Now()
+		if _, err := s.exec(`
+		INSERT OR REPLACE INTO decision_outcomes (
+			position_id, session_id, symbol, side, action, confidence, risk_reward,
+			realized_pnl, r_multiple, win, closed_at, scored_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, o.PositionID, o.SessionID, o.Symbol, o.Side, o.Action, o.Confidence, o.RiskReward,
+			o.RealizedPnL, o.RMultiple, o.Win, o.ClosedAt, o.ScoredAt); err != nil {
+			return i, fmt.Errorf("failed to save decision outcome: %w", err)
+		}
+	}
+	return len(outcomes), nil
+}
+
+// GetDecisionCalibration summarizes the scored decisions of positions closed since a time (zero = all)
+// with the latest limit outcomes
+// GetDecisionCalibration 汇总指定时间以来平仓（零值表示全部）的已评分决策，并返回最近 limit 条结果
+func (s *Storage) GetDecisionCalibration(since time.Time, limit int) (*DecisionCalibration, error) {
+	rows, err := s.db.Query(`
+	SELECT position_id, session_id, symbol, side, COALESCE(action, ''), confidence, risk_reward,
+		   realized_pnl, r_multiple, win, closed_at, scored_at
+	FROM decision_outcomes
+	WHERE ? OR closed_at >= ?
+	ORDER BY closed_at DESC
+	`, since.IsZero(), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decision outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	calibration := &DecisionCalibration{
+		Confidence: make([]*CalibrationBucket, len(confidenceBuckets)),
+		RiskReward: make([]*CalibrationBucket, len(riskRewardBuckets)),
+	}
+	for i, b := range confidenceBuckets {
+		calibration.Confidence[i] = &CalibrationBucket{Label: b.label}
+	}
+	for i, b := range riskRewardBuckets {
+		calibration.RiskReward[i] = &CalibrationBucket{Label: b.label}
+	}
+
+	var brier float64
+	for rows.Next() {
+		o := &DecisionOutcome{}
+		var closedAt sql.NullTime
+		if err := rows.Scan(&o.PositionID, &o.SessionID, &o.Symbol, &o.Side, &o.Action, &o.Confidence, &o.RiskReward,
+			&o.RealizedPnL, &o.RMultiple, &o.Win, &closedAt, &o.ScoredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan decision outcome: %w", err)
+		}
+		o.ClosedAt = closedAt.Time
+		if o.SessionID == 0 {
+			calibration.Unlinked++
+			continue
+		}
+
+		calibration.Scored++
+		if len(calibration.LatestScored) < limit {
+			calibration.LatestScored = append(calibration.LatestScored, o)
+		}
+		outcome := 0.0
+		if o.Win {
+			outcome = 1
+		}
+		brier += (o.Confidence - outcome) * (o.Confidence - outcome)
+
+		i := len(confidenceBuckets) - 1
+		for i > 0 && o.Confidence < confidenceBuckets[i].min {
+			i--
+		}
+		calibration.Confidence[i].add(o)
+		j := len(riskRewardBuckets) - 1
+		for j > 0 && o.RiskReward < riskRewardBuckets[j].min {
+			j--
+		}
+		calibration.RiskReward[j].add(o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if calibration.Scored > 0 {
+		calibration.BrierScore = brier / float64(calibration.Scored)
+	}
+	for _, b := range append(calibration.Confidence, calibration.RiskReward...) {
+		b.finish()
+	}
+	return calibration, nil
+}
+
+// add counts one outcome; the averages hold sums until finish
+// add 计入一条结果；均值字段在 finish 之前保存累计值
+func (b *CalibrationBucket) add(o *DecisionOutcome) {
+	b.Trades++
+	if o.Win {
+		b.Wins++
+	}
+	b.AvgConfidence += o.Confidence * 100
+	b.AvgRiskReward += o.RiskReward
+	b.AvgR += o.RMultiple
+	b.AvgPnL += o.RealizedPnL
+}
+
+// finish turns the sums into averages
+// finish 将累计值转换为均值
+func (b *CalibrationBucket) finish() {
+	if b.Trades == 0 {
+		return
+	}
+	n := float64(b.Trades)
+	b.WinRate = float64(b.Wins) / n * 100
+	b.AvgConfidence /= n
+	b.AvgRiskReward /= n
+	b.AvgR /= n
+	b.AvgPnL /= n
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDecisionCalibration tests linking closed positions to their opening decision and the calibration buckets
+// TestDecisionCalibration 测试已平仓持仓与开仓决策的关联以及校准分组
+func TestDecisionCalibration(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "calibration.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, d := range []struct {
+		action     string
+		confidence float64
+		riskReward float64
+		executed   bool
+		at         time.Time
+	}{
+		{"BUY", 0.55, 0, true, now.Add(-5 * time.Hour)},
+		{"BUY", 0.85, 2.5, true, now.Add(-3 * time.Hour)},    // 多单 btc-1 的开仓决策 / Opens long btc-1
+		{"BUY", 0.95, 4, false, now.Add(-150 * time.Minute)}, // 未执行 / Not executed
+		{"SELL", 0.65, 1.2, true, now.Add(-2 * time.Hour)},   // 空单 btc-2 的开仓决策 / Opens short btc-2
+		{"BUY", 0.75, 2, true, now.Add(-time.Minute)},        // 入场之后，不关联 / After the entries
+	} {
+		id, err := db.SaveSession(&TradingSession{
+			Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: d.at, Decision: d.action,
+			Structured: &StructuredDecision{Action: d.action, Confidence: d.confidence, RiskReward: d.riskReward, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+		if err := db.UpdateExecutionResult(id, d.executed, ""); err != nil {
+			t.Fatalf("UpdateExecutionResult failed: %v", err)
+		}
+	}
+
+	for _, p := range []struct {
+		id    string
+		side  string
+		entry time.Time
+		pnl   float64
+	}{
+		{"btc-1", "long", now.Add(-170 * time.Minute), 40}, // 2R
+		{"btc-2", "short", now.Add(-110 * time.Minute), -20},
+		{"eth-1", "long", now.Add(-time.Hour), 5}, // 没有 ETH 决策 / No ETH decision
+	} {
+		symbol := "BTCUSDT"
+		if p.id == "eth-1" {
+			symbol = "ETHUSDT"
+		}
+		pos := &PositionRecord{
+			ID: p.id, Symbol: symbol, Side: p.side, EntryPrice: 100, EntryTime: p.entry, Quantity: 10,
+			Leverage: 10, InitialStopLoss: 98, CurrentStopLoss: 98, StopLossType: "fixed",
+		}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+		closeTime := now.Add(-30 * time.Minute)
+		pos.Closed, pos.CloseTime, pos.RealizedPnL = true, &closeTime, p.pnl
+		if err := db.UpdatePosition(pos); err != nil {
+			t.Fatalf("UpdatePosition failed: %v", err)
+		}
+	}
+
+	scored, err := db.ScoreClosedPositions(100)
+	if err != nil || scored != 3 {
+		t.Fatalf("ScoreClosedPositions = %d, %v; want 3", scored, err)
+	}
+	if again, err := db.ScoreClosedPositions(100); err != nil || again != 0 {
+		t.Fatalf("Expected scored positions to be skipped, got %d, %v", again, err)
+	}
+
+	calibration, err := db.GetDecisionCalibration(time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("GetDecisionCalibration failed: %v", err)
+	}
+	if calibration.Scored != 2 || calibration.Unlinked != 1 || len(calibration.LatestScored) != 2 {
+		t.Fatalf("Unexpected calibration totals: %+v", calibration)
+	}
+	// (0.85 - 1)² and (0.65 - 0)² / (0.85 - 1)² 与 (0.65 - 0)²
+	if want := (0.15*0.15 + 0.65*0.65) / 2; math.Abs(calibration.BrierScore-want) > 1e-9 {
+		t.Errorf("Expected Brier score %.4f, got %.4f", want, calibration.BrierScore)
+	}
+
+	buckets := make(map[string]*CalibrationBucket)
+	for _, b := range calibration.Confidence {
+		buckets[b.Label] = b
+	}
+	if b := buckets["80-90%"]; b.Trades != 1 || b.WinRate != 100 || math.Abs(b.AvgConfidence-85) > 1e-9 || math.Abs(b.AvgR-2) > 1e-9 {
+		t.Errorf("Unexpected 80-90%% bucket: %+v", b)
+	}
+	if b := buckets["60-70%"]; b.Trades != 1 || b.WinRate != 0 || math.Abs(b.AvgR+1) > 1e-9 {
+		t.Errorf("Unexpected 60-70%% bucket: %+v", b)
+	}
+	if b := buckets["≥90%"]; b.Trades != 0 {
+		t.Errorf("Expected the unexecuted decision to be ignored, got %+v", b)
+	}
+
+	rr := make(map[string]int)
+	for _, b := range calibration.RiskReward {
+		rr[b.Label] = b.Trades
+	}
+	if rr["2-3"] != 1 || rr["<1.5"] != 1 || rr["未给出"] != 0 {
+		t.Errorf("Unexpected risk/reward buckets: %v", rr)
+	}
+}
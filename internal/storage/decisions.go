@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StructuredDecision is the parsed form of a symbol's decision, stored next to the raw LLM output
+// StructuredDecision 表示交易对决策的结构化解析结果，与 LLM 原始输出一起保存
+type StructuredDecision struct {
+	Action              string  // 交易动作 / Trading action
+	Confidence          float64 // 置信度 0-1 / Confidence level 0-1
+	Leverage            int     // 杠杆倍数 / Leverage
+	PositionSizePercent float64 // 仓位百分比 0-100 / Position size percentage
+	StopLoss            float64 // 止损价格 / Stop-loss price
+	RiskReward          float64 // 预期盈亏比（0 表示未给出）/ Expected risk/reward ratio (0 = not stated)
+	Reason              string  // 决策理由 / Decision reason
+	Valid               bool    // 决策是否有效 / Whether decision is valid
+	Source              string  // 解析来源：json/text / Parse source: json/text
+
+	Citations []DecisionCitation // 决策引用的报告数据 / Report facts the decision relied on
+}
+
+// DecisionCitation is a report fact cited by a decision, e.g. "ADX=32 rising", and whether its numbers were found in the reports
+// DecisionCitation 是决策引用的报告数据（例如 "ADX=32 上升"）以及其中的数值是否能在报告中找到
+type DecisionCitation struct {
+	Text      string   `json:"text"`
+	Verified  bool     `json:"verified"`
+	Unmatched []string `json:"unmatched,omitempty"` // 报告中找不到的数值 / Numbers not found in the reports
+}
+
+// DecisionVerification pairs a stored structured decision with the raw LLM output it was parsed from
+// DecisionVerification 将保存的结构化决策与其对应的 LLM 原始输出配对
+type DecisionVerification struct {
+	SessionID  int64               // 会话 ID / Session ID
+	BatchID    string              // 批次 ID / Batch ID
+	Symbol     string              // 交易对 / Trading pair
+	CreatedAt  time.Time           // 创建时间 / Created at
+	RawOutput  string              // LLM 原始完整输出 / Raw full LLM output
+	Structured *StructuredDecision // 结构化决策 / Structured decision
+	Divergence string              // 不一致说明（空表示一致）/ Divergence description (empty = consistent)
+	VerifiedAt time.Time           // 校验时间 / Verification time
+}
+
+// initDecisionSchema adds structured decision columns to trading_sessions
+// initDecisionSchema 为 trading_sessions 添加结构化决策字段
+func (s *Storage) initDecisionSchema() {
+	columns := []string{
+		"decision_action TEXT",
+		"decision_confidence REAL",
+		"decision_leverage INTEGER",
+		"decision_position_size REAL",
+		"decision_stop_loss REAL",
+		"decision_reason TEXT",
+		"decision_valid BOOLEAN",
+		"decision_source TEXT",
+		"decision_divergence TEXT",
+		"decision_verified_at DATETIME",
+		"decision_citations TEXT",
+		"decision_risk_reward REAL",
+	}
+
+	// Run each ALTER separately so one existing column doesn't skip the rest
+	// 逐条执行 ALTER，避免某个字段已存在导致后续字段被跳过
+	for _, column := range columns {
+		s.exec("ALTER TABLE trading_sessions ADD COLUMN " + column)
+	}
+
+	s.exec(`CREATE INDEX IF NOT EXISTS idx_decision_verified_at ON trading_sessions(decision_verified_at)`)
+}
+
+// SaveStructuredDecision stores the structured decision for a session
+// SaveStructuredDecision 保存会话的结构化决策
+func (s *Storage) SaveStructuredDecision(sessionID int64, decision *StructuredDecision) error {
+	var citations sql.NullString
+	if len(decision.Citations) > 0 {
+		data, err := json.Marshal(decision.Citations)
+		if err != nil {
+			return fmt.Errorf("failed to encode citations: %w", err)
+		}
+		citations = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := s.exec(`
+	UPDATE trading_sessions SET
+		decision_action = ?, decision_confidence = ?, decision_leverage = ?,
+		decision_position_size = ?, decision_stop_loss = ?, decision_reason = ?,
+		decision_valid = ?, decision_source = ?, decision_citations = ?, decision_risk_reward = ?
+	WHERE id = ?
+	`,
+		decision.Action, decision.Confidence, decision.Leverage,
+		decision.PositionSizePercent, decision.StopLoss, decision.Reason,
+		decision.Valid, decision.Source, citations, decision.RiskReward, sessionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save structured decision: %w", err)
+	}
+	return nil
+}
+
+// GetStructuredDecision retrieves the structured decision for a session (nil if not stored)
+// GetStructuredDecision 获取会话的结构化决策（未保存返回 nil）
+func (s *Storage) GetStructuredDecision(sessionID int64) (*StructuredDecision, error) {
+	var action, reason, source, citations sql.NullString
+	var confidence, positionSize, stopLoss, riskReward sql.NullFloat64
+	var leverage sql.NullInt64
+	var valid sql.NullBool
+
+	err := s.db.QueryRow(`
+	SELECT decision_action, decision_confidence, decision_leverage, decision_position_size,
+		   decision_stop_loss, decision_reason, decision_valid, decision_source, decision_citations,
+		   decision_risk_reward
+	FROM trading_sessions WHERE id = ?
+	`, sessionID).Scan(&action, &confidence, &leverage, &positionSize, &stopLoss, &reason, &valid, &source, &citations,
+		&riskReward)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get structured decision: %w", err)
+	}
+	if !action.Valid {
+		return nil, nil // Legacy session without structured columns / 没有结构化字段的旧会话
+	}
+
+	decision := &StructuredDecision{
+		Action:              action.String,
+		Confidence:          confidence.Float64,
+		Leverage:            int(leverage.Int64),
+		PositionSizePercent: positionSize.Float64,
+		StopLoss:            stopLoss.Float64,
+		RiskReward:          riskReward.Float64,
+		Reason:              reason.String,
+		Valid:               valid.Bool,
+		Source:              source.String,
+	}
+	if citations.String != "" {
+		if err := json.Unmarshal([]byte(citations.String), &decision.Citations); err != nil {
+			return nil, fmt.Errorf("failed to decode citations: %w", err)
+		}
+	}
+	return decision, nil
+}
+
+// GetUnverifiedDecisions retrieves sessions with a structured decision that haven't been verified yet
+// GetUnverifiedDecisions 获取已保存结构化决策但尚未校验的会话
+func (s *Storage) GetUnverifiedDecisions(limit int) ([]*DecisionVerification, error) {
+	return s.queryDecisionVerifications(`
+	WHERE decision_action IS NOT NULL AND decision_verified_at IS NULL
+	ORDER BY id ASC LIMIT ?
+	`, limit)
+}
+
+// GetDecisionDivergences retrieves the latest sessions flagged with a divergence
+// GetDecisionDivergences 获取最近被标记为不一致的会话
+func (s *Storage) GetDecisionDivergences(limit int) ([]*DecisionVerification, error) {
+	return s.queryDecisionVerifications(`
+	WHERE decision_divergence IS NOT NULL AND decision_divergence != ''
+	ORDER BY id DESC LIMIT ?
+	`, limit)
+}
+
+// MarkDecisionVerified records the verification result for a session
+// MarkDecisionVerified 记录会话的校验结果
+func (s *Storage) MarkDecisionVerified(sessionID int64, divergence string) error {
+	_, err := s.exec(`
+	UPDATE trading_sessions SET decision_divergence = ?, decision_verified_at = ? WHERE id = ?
+	`, divergence, time.Now(), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to mark decision verified: %w", err)
+	}
+	return nil
+}
+
+// queryDecisionVerifications runs a decision verification query with the given filter
+// queryDecisionVerifications 使用给定条件查询决策校验记录
+func (s *Storage) queryDecisionVerifications(filter string, args ...interface{}) ([]*DecisionVerification, error) {
+	rows, err := s.db.Query(`
+	SELECT id, batch_id, symbol, created_at, full_decision,
+		   decision_action, decision_confidence, decision_leverage, decision_position_size,
+		   decision_stop_loss, decision_reason, decision_valid, decision_source,
+		   decision_divergence, decision_verified_at
+	FROM trading_sessions
+	`+filter, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*DecisionVerification
+	for rows.Next() {
+		v := &DecisionVerification{Structured: &StructuredDecision{}}
+		var batchID, fullDecision, action, reason, source, divergence sql.NullString
+		var confidence, positionSize, stopLoss sql.NullFloat64
+		var leverage sql.NullInt64
+		var valid sql.NullBool
+		var verifiedAt sql.NullTime
+
+		if err := rows.Scan(
+			&v.SessionID, &batchID, &v.Symbol, &v.CreatedAt, &fullDecision,
+			&action, &confidence, &leverage, &positionSize,
+			&stopLoss, &reason, &valid, &source,
+			&divergence, &verifiedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan decision: %w", err)
+		}
+
+		v.BatchID = batchID.String
+		v.RawOutput = decodeBlob(fullDecision.String)
+		v.Structured.Action = action.String
+		v.Structured.Confidence = confidence.Float64
+		v.Structured.Leverage = int(leverage.Int64)
+		v.Structured.PositionSizePercent = positionSize.Float64
+		v.Structured.StopLoss = stopLoss.Float64
+		v.Structured.Reason = reason.String
+		v.Structured.Valid = valid.Bool
+		v.Structured.Source = source.String
+		v.Divergence = divergence.String
+		if verifiedAt.Valid {
+			v.VerifiedAt = verifiedAt.Time
+		}
+
+		results = append(results, v)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStructuredDecisionDualWrite(t *testing.T) {
+	tmpDB := "./test_structured_decisions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 旧格式会话（无结构化决策）
+	legacyID, err := db.SaveSession(&TradingSession{
+		Symbol:    "ETH/USDT",
+		Timeframe: "1h",
+		CreatedAt: time.Now(),
+		Decision:  "HOLD",
+	})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	// 双写会话
+	id, err := db.SaveSession(&TradingSession{
+		Symbol:       "BTC/USDT",
+		Timeframe:    "1h",
+		CreatedAt:    time.Now(),
+		Decision:     "【BTC/USDT】BUY",
+		FullDecision: `{"symbol": "BTC/USDT", "action": "BUY"}`,
+		Structured: &StructuredDecision{
+			Action:     "BUY",
+			Confidence: 0.8,
+			Leverage:   10,
+			StopLoss:   95000,
+			RiskReward: 2.5,
+			Valid:      true,
+			Source:     "json",
+			Citations: []DecisionCitation{
+				{Text: "ADX=32", Verified: true},
+				{Text: "funding -0.05%", Unmatched: []string{"-0.05"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	legacy, err := db.GetStructuredDecision(legacyID)
+	if err != nil || legacy != nil {
+		t.Errorf("Expected nil structured decision for legacy session, got %+v (err=%v)", legacy, err)
+	}
+
+	structured, err := db.GetStructuredDecision(id)
+	if err != nil {
+		t.Fatalf("GetStructuredDecision failed: %v", err)
+	}
+	if structured == nil || structured.Action != "BUY" || structured.Leverage != 10 || structured.RiskReward != 2.5 || structured.Source != "json" {
+		t.Fatalf("Unexpected structured decision: %+v", structured)
+	}
+	if len(structured.Citations) != 2 || !structured.Citations[0].Verified || structured.Citations[1].Unmatched[0] != "-0.05" {
+		t.Errorf("Unexpected citations: %+v", structured.Citations)
+	}
+
+	// 只有双写会话需要校验
+	pending, err := db.GetUnverifiedDecisions(10)
+	if err != nil {
+		t.Fatalf("GetUnverifiedDecisions failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].SessionID != id || pending[0].RawOutput == "" {
+		t.Fatalf("Unexpected pending decisions: %+v", pending)
+	}
+
+	if err := db.MarkDecisionVerified(id, "action: parsed=HOLD json=BUY"); err != nil {
+		t.Fatalf("MarkDecisionVerified failed: %v", err)
+	}
+
+	pending, _ = db.GetUnverifiedDecisions(10)
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending decisions, got %d", len(pending))
+	}
+
+	divergences, err := db.GetDecisionDivergences(10)
+	if err != nil {
+		t.Fatalf("GetDecisionDivergences failed: %v", err)
+	}
+	if len(divergences) != 1 || divergences[0].Divergence == "" || divergences[0].VerifiedAt.IsZero() {
+		t.Errorf("Unexpected divergences: %+v", divergences)
+	}
+}
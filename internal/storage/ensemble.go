@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// EnsembleVote is one model's vote on a symbol's decision in ensemble mode
+// EnsembleVote 是委员会模式下单个模型对某个交易对决策的投票
+type EnsembleVote struct {
+	ID                  int64
+	SessionID           int64   // 会话 ID / Session ID
+	Symbol              string  // 交易对 / Trading pair
+	Model               string  // 投票的模型 / Voting model
+	Action              string  // 投票的交易动作（调用失败时为空）/ Voted action (empty when the call failed)
+	Confidence          float64 // 置信度 / Confidence
+	Leverage            int     // 杠杆倍数 / Leverage
+	PositionSizePercent float64 // 仓位百分比 / Position size percentage
+	StopLoss            float64 // 止损价格 / Stop-loss price
+	Reason              string  // 决策理由 / Decision reason
+	Error               string  // 调用或解析失败的原因 / Why the call or parsing failed
+	Agreed              bool    // 是否与委员会最终方向一致 / Whether it sided with the committee's direction
+	CreatedAt           time.Time
+}
+
+// initEnsembleSchema creates the ensemble_votes table if it doesn't exist
+// initEnsembleSchema 创建 ensemble_votes 表（如果不存在）
+func (s *Storage) initEnsembleSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS ensemble_votes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id INTEGER NOT NULL,
+		symbol TEXT NOT NULL,
+		model TEXT NOT NULL,
+		action TEXT,
+		confidence REAL,
+		leverage INTEGER,
+		position_size REAL,
+		stop_loss REAL,
+		reason TEXT,
+		error TEXT,
+		agreed BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ensemble_votes_session ON ensemble_votes(session_id);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveEnsembleVotes stores the votes behind a session's decision
+// SaveEnsembleVotes 保存会话决策对应的所有投票
+func (s *Storage) SaveEnsembleVotes(sessionID int64, votes []*EnsembleVote) error {
+	for _, vote := range votes {
+		vote.SessionID = sessionID
+		if vote.CreatedAt.IsZero() {
+			vote.CreatedAt = time.Now()
+		}
+		result, err := s.exec(`
+		INSERT INTO ensemble_votes (
+			session_id, symbol, model, action, confidence, leverage,
+			position_size, stop_loss, reason, error, agreed, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			vote.SessionID, vote.Symbol, vote.Model, vote.Action, vote.Confidence, vote.Leverage,
+			vote.PositionSizePercent, vote.StopLoss, vote.Reason, vote.Error, vote.Agreed, vote.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save ensemble vote: %w", err)
+		}
+		vote.ID, _ = result.LastInsertId()
+	}
+	return nil
+}
+
+// GetEnsembleVotes retrieves the votes of a session in the order they were cast (empty outside ensemble mode)
+// GetEnsembleVotes 按投票顺序获取会话的所有投票（非委员会模式时为空）
+func (s *Storage) GetEnsembleVotes(sessionID int64) ([]*EnsembleVote, error) {
+	rows, err := s.db.Query(`
+	SELECT id, session_id, symbol, model, action, confidence, leverage,
+		   position_size, stop_loss, reason, error, agreed, created_at
+	FROM ensemble_votes
+	WHERE session_id = ?
+	ORDER BY id ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ensemble votes: %w", err)
+	}
+	defer rows.Close()
+
+	var votes []*EnsembleVote
+	for rows.Next() {
+		vote := &EnsembleVote{}
+		if err := rows.Scan(&vote.ID, &vote.SessionID, &vote.Symbol, &vote.Model, &vote.Action,
+			&vote.Confidence, &vote.Leverage, &vote.PositionSizePercent, &vote.StopLoss,
+			&vote.Reason, &vote.Error, &vote.Agreed, &vote.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ensemble vote: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnsembleVotes(t *testing.T) {
+	tmpDB := "./test_ensemble.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	votes := []*EnsembleVote{
+		{Symbol: "BTC/USDT", Model: "gpt-4o-mini", Action: "BUY", Confidence: 0.8, Leverage: 5, PositionSizePercent: 20, StopLoss: 95000, Reason: "突破", Agreed: true},
+		{Symbol: "BTC/USDT", Model: "gpt-4o", Action: "BUY_STOP", Confidence: 0.7, Agreed: true},
+		{Symbol: "BTC/USDT", Model: "deepseek-chat", Error: "LLM decision budget exceeded"},
+	}
+	if err := db.SaveEnsembleVotes(7, votes); err != nil {
+		t.Fatalf("SaveEnsembleVotes failed: %v", err)
+	}
+	if err := db.SaveEnsembleVotes(8, []*EnsembleVote{{Symbol: "ETH/USDT", Model: "gpt-4o", Action: "HOLD"}}); err != nil {
+		t.Fatalf("SaveEnsembleVotes failed: %v", err)
+	}
+
+	got, err := db.GetEnsembleVotes(7)
+	if err != nil {
+		t.Fatalf("GetEnsembleVotes failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 votes for session 7, got %d", len(got))
+	}
+	if got[0].Model != "gpt-4o-mini" || got[0].Action != "BUY" || got[0].StopLoss != 95000 || !got[0].Agreed || got[0].SessionID != 7 {
+		t.Errorf("Unexpected first vote: %+v", got[0])
+	}
+	if got[2].Action != "" || got[2].Error == "" || got[2].Agreed {
+		t.Errorf("Expected the failed vote to keep its error, got %+v", got[2])
+	}
+
+	none, err := db.GetEnsembleVotes(99)
+	if err != nil || len(none) != 0 {
+		t.Errorf("Expected no votes for an unknown session, got %v, %v", none, err)
+	}
+}
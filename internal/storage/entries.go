@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Pending entry statuses
+// 条件入场单状态
+const (
+	EntryStatusPending   = "pending"   // 等待触发 / Waiting for the trigger
+	EntryStatusFilled    = "filled"    // 已触发成交 / Triggered and filled
+	EntryStatusCancelled = "cancelled" // 已撤销 / Cancelled
+	EntryStatusExpired   = "expired"   // 已过期 / Expired
+	EntryStatusShadow    = "shadow"    // 影子模式记录，未挂单 / Recorded in shadow mode, never placed
+)
+
+// PendingEntry is a conditional stop-entry order waiting for its trigger price
+// PendingEntry 是等待触发价的条件入场单（止损入场 / 突破入场）
+type PendingEntry struct {
+	ID           int64
+	Symbol       string
+	Side         string    // long/short
+	TriggerPrice float64   // 触发价格 / Trigger price
+	Quantity     float64   // 下单数量 / Order quantity
+	Leverage     int       // 杠杆倍数 / Leverage
+	StopLoss     float64   // 成交后的初始止损（0 表示默认）/ Initial stop-loss after fill (0 = default)
+	ATR          float64   // 下单时的 ATR / ATR when placed
+	OrderID      string    // 交易所订单 ID（测试模式为空）/ Exchange order ID (empty in test mode)
+	Reason       string    // 下单理由 / Reason
+	Status       string    // pending/filled/cancelled/expired
+	FillPrice    float64   // 成交价格 / Fill price
+	PositionID   string    // 成交后创建的持仓 ID / Position created by the fill
+	CreatedAt    time.Time // 下单时间 / When placed
+	ExpiresAt    time.Time // 过期时间 / Expiry
+	ResolvedAt   time.Time // 成交/撤销/过期时间（零值表示仍在等待）/ When filled/cancelled/expired (zero = pending)
+}
+
+// initEntriesSchema creates the pending_entries table if it doesn't exist
+// initEntriesSchema 创建 pending_entries 表（如果不存在）
+func (s *Storage) initEntriesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS pending_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		trigger_price REAL NOT NULL,
+		quantity REAL NOT NULL,
+		leverage INTEGER DEFAULT 0,
+		stop_loss REAL DEFAULT 0,
+		atr REAL DEFAULT 0,
+		order_id TEXT,
+		reason TEXT,
+		status TEXT NOT NULL,
+		fill_price REAL DEFAULT 0,
+		position_id TEXT,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		resolved_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pending_entries_status ON pending_entries(status, symbol);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SavePendingEntry stores a new pending entry and returns its ID
+// SavePendingEntry 保存新的条件入场单并返回其 ID
+func (s *Storage) SavePendingEntry(entry *PendingEntry) (int64, error) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if entry.Status == "" {
+		entry.Status = EntryStatusPending
+	}
+	var resolvedAt interface{}
+	if !entry.ResolvedAt.IsZero() {
+		resolvedAt = entry.ResolvedAt
+	}
+	result, err := s.exec(`
+	INSERT INTO pending_entries (
+		symbol, side, trigger_price, quantity, leverage, stop_loss, atr, order_id, reason,
+		status, fill_price, position_id, created_at, expires_at, resolved_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.Symbol, entry.Side, entry.TriggerPrice, entry.Quantity, entry.Leverage, entry.StopLoss, entry.ATR,
+		entry.OrderID, entry.Reason, entry.Status, entry.FillPrice, entry.PositionID,
+		entry.CreatedAt, entry.ExpiresAt, resolvedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save pending entry: %w", err)
+	}
+	entry.ID, err = result.LastInsertId()
+	return entry.ID, err
+}
+
+// UpdatePendingEntry stores the status, fill and resolution time of an entry
+// UpdatePendingEntry 保存条件入场单的状态、成交信息和结束时间
+func (s *Storage) UpdatePendingEntry(entry *PendingEntry) error {
+	var resolvedAt interface{}
+	if !entry.ResolvedAt.IsZero() {
+		resolvedAt = entry.ResolvedAt
+	}
+	_, err := s.exec(`
+	UPDATE pending_entries SET status = ?, fill_price = ?, quantity = ?, position_id = ?, resolved_at = ?
+	WHERE id = ?
+	`, entry.Status, entry.FillPrice, entry.Quantity, entry.PositionID, resolvedAt, entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update pending entry: %w", err)
+	}
+	return nil
+}
+
+// GetPendingEntries retrieves the entries still waiting for their trigger (empty symbol = all symbols), oldest first
+// GetPendingEntries 获取仍在等待触发的条件入场单（symbol 为空表示全部），按时间正序
+func (s *Storage) GetPendingEntries(symbol string) ([]*PendingEntry, error) {
+	query := `WHERE status = ?`
+	args := []interface{}{EntryStatusPending}
+	if symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, symbol)
+	}
+	return s.queryEntries(query+` ORDER BY created_at ASC, id ASC`, args...)
+}
+
+// GetRecentEntries retrieves the latest entries of any status, newest first
+// GetRecentEntries 获取最近的条件入场单（任意状态），按时间倒序
+func (s *Storage) GetRecentEntries(limit int) ([]*PendingEntry, error) {
+	return s.queryEntries(`ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// queryEntries runs a pending_entries query with the given WHERE / ORDER clause
+// queryEntries 使用给定的 WHERE / ORDER 子句查询 pending_entries
+func (s *Storage) queryEntries(clause string, args ...interface{}) ([]*PendingEntry, error) {
+	rows, err := s.db.Query(`
+	SELECT id, symbol, side, trigger_price, quantity, leverage, stop_loss, atr, COALESCE(order_id, ''),
+		   COALESCE(reason, ''), status, fill_price, COALESCE(position_id, ''), created_at, expires_at, resolved_at
+	FROM pending_entries
+	`+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*PendingEntry
+	for rows.Next() {
+		entry := &PendingEntry{}
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(
+			&entry.ID, &entry.Symbol, &entry.Side, &entry.TriggerPrice, &entry.Quantity, &entry.Leverage,
+			&entry.StopLoss, &entry.ATR, &entry.OrderID, &entry.Reason, &entry.Status, &entry.FillPrice,
+			&entry.PositionID, &entry.CreatedAt, &entry.ExpiresAt, &resolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan pending entry: %w", err)
+		}
+		if resolvedAt.Valid {
+			entry.ResolvedAt = resolvedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPendingEntries(t *testing.T) {
+	tmpDB := "./test_entries.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	btc := &PendingEntry{Symbol: "BTCUSDT", Side: "long", TriggerPrice: 101000, Quantity: 0.01, Leverage: 10,
+		StopLoss: 99000, OrderID: "123", Reason: "突破前高", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(3 * time.Hour)}
+	eth := &PendingEntry{Symbol: "ETHUSDT", Side: "short", TriggerPrice: 3400, Quantity: 0.5,
+		CreatedAt: now, ExpiresAt: now.Add(4 * time.Hour)}
+	for _, entry := range []*PendingEntry{btc, eth} {
+		if _, err := db.SavePendingEntry(entry); err != nil {
+			t.Fatalf("SavePendingEntry failed: %v", err)
+		}
+	}
+
+	pending, err := db.GetPendingEntries("")
+	if err != nil {
+		t.Fatalf("GetPendingEntries failed: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Symbol != "BTCUSDT" || pending[0].Status != EntryStatusPending {
+		t.Fatalf("Expected 2 pending entries oldest first, got %+v", pending)
+	}
+	if pending[0].OrderID != "123" || pending[0].StopLoss != 99000 || !pending[0].ResolvedAt.IsZero() {
+		t.Errorf("Unexpected stored entry: %+v", pending[0])
+	}
+
+	btc.Status = EntryStatusFilled
+	btc.FillPrice = 101050
+	btc.PositionID = "BTCUSDT-1"
+	btc.ResolvedAt = now
+	if err := db.UpdatePendingEntry(btc); err != nil {
+		t.Fatalf("UpdatePendingEntry failed: %v", err)
+	}
+
+	if pending, _ := db.GetPendingEntries("BTCUSDT"); len(pending) != 0 {
+		t.Errorf("Filled entry should no longer be pending, got %d", len(pending))
+	}
+	if pending, _ := db.GetPendingEntries("ETHUSDT"); len(pending) != 1 {
+		t.Errorf("Expected 1 pending ETHUSDT entry, got %d", len(pending))
+	}
+
+	recent, err := db.GetRecentEntries(10)
+	if err != nil {
+		t.Fatalf("GetRecentEntries failed: %v", err)
+	}
+	if len(recent) != 2 || recent[1].Status != EntryStatusFilled || recent[1].FillPrice != 101050 ||
+		recent[1].PositionID != "BTCUSDT-1" || recent[1].ResolvedAt.IsZero() {
+		t.Errorf("Unexpected recent entries: %+v", recent)
+	}
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionExport is a trading session as exported to CSV/JSON, without the (large) analyst reports
+// SessionExport 是导出为 CSV/JSON 的交易会话，不含（体积较大的）分析报告
+type SessionExport struct {
+	ID              int64
+	BatchID         string
+	Symbol          string
+	Timeframe       string
+	CreatedAt       time.Time
+	Action          string  // 结构化决策动作（旧会话为空）/ Structured action (empty for older sessions)
+	Confidence      float64 // 结构化决策置信度 0-1 / Structured confidence 0-1
+	Leverage        int
+	Decision        string // 该交易对的决策文本 / Symbol-specific decision text
+	Executed        bool
+	ExecutionResult string
+	SizingPolicy    string
+	DecisionPath    string
+}
+
+// ExportSessions returns the sessions created in [from, to), oldest first
+// ExportSessions 返回 [from, to) 区间内创建的会话，按时间正序
+func (s *Storage) ExportSessions(from, to time.Time) ([]*SessionExport, error) {
+	rows, err := s.db.Query(`
+	SELECT id, COALESCE(batch_id, ''), symbol, timeframe, created_at,
+		   COALESCE(decision_action, ''), COALESCE(decision_confidence, 0), COALESCE(decision_leverage, 0),
+		   COALESCE(decision, ''), executed, COALESCE(execution_result, ''),
+		   COALESCE(sizing_policy, ''), COALESCE(decision_path, '')
+	FROM trading_sessions
+	WHERE created_at >= ? AND created_at < ?
+	ORDER BY created_at ASC
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*SessionExport
+	for rows.Next() {
+		session := &SessionExport{}
+		if err := rows.Scan(
+			&session.ID, &session.BatchID, &session.Symbol, &session.Timeframe, &session.CreatedAt,
+			&session.Action, &session.Confidence, &session.Leverage,
+			&session.Decision, &session.Executed, &session.ExecutionResult,
+			&session.SizingPolicy, &session.DecisionPath,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// GetBalanceHistoryBetween returns the balance snapshots taken in [from, to), oldest first
+// GetBalanceHistoryBetween 返回 [from, to) 区间内的余额快照，按时间正序
+func (s *Storage) GetBalanceHistoryBetween(from, to time.Time) ([]*BalanceHistory, error) {
+	rows, err := s.db.Query(`
+	SELECT `+balanceHistoryColumns+`
+	FROM balance_history
+	WHERE timestamp >= ? AND timestamp < ?
+	ORDER BY timestamp ASC
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*BalanceHistory
+	for rows.Next() {
+		h, err := scanBalanceHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExportRanges tests that session and balance exports honor [from, to) and run oldest first
+// TestExportRanges 测试会话和余额导出遵循 [from, to) 区间并按时间正序
+func TestExportRanges(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for i, offset := range []time.Duration{-3 * time.Hour, -2 * time.Hour, -time.Hour} {
+		sessionID, err := db.SaveSession(&TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.Add(offset), Decision: "HOLD"})
+		if err != nil {
+			t.Fatalf("SaveSession %d failed: %v", i, err)
+		}
+		if i == 2 {
+			if err := db.SaveStructuredDecision(sessionID, &StructuredDecision{Action: "BUY", Confidence: 0.8, Leverage: 5, Valid: true}); err != nil {
+				t.Fatalf("SaveStructuredDecision failed: %v", err)
+			}
+		}
+		if err := db.SaveBalanceHistory(&BalanceHistory{Timestamp: now.Add(offset), TotalBalance: 1000 + float64(i)}); err != nil {
+			t.Fatalf("SaveBalanceHistory %d failed: %v", i, err)
+		}
+	}
+
+	from, to := now.Add(-150*time.Minute), now
+	sessions, err := db.ExportSessions(from, to)
+	if err != nil {
+		t.Fatalf("ExportSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || !sessions[0].CreatedAt.Before(sessions[1].CreatedAt) {
+		t.Fatalf("Expected 2 sessions oldest first, got %+v", sessions)
+	}
+	if sessions[0].Action != "" || sessions[1].Action != "BUY" || sessions[1].Leverage != 5 {
+		t.Errorf("Unexpected structured fields: %+v / %+v", sessions[0], sessions[1])
+	}
+
+	history, err := db.GetBalanceHistoryBetween(from, to)
+	if err != nil {
+		t.Fatalf("GetBalanceHistoryBetween failed: %v", err)
+	}
+	if len(history) != 2 || history[0].TotalBalance != 1001 || history[1].TotalBalance != 1002 {
+		t.Errorf("Expected the last 2 snapshots oldest first, got %+v", history)
+	}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TradingCosts summarizes what trading cost on top of the price moves
+// TradingCosts 汇总价格变动之外的交易成本
+type TradingCosts struct {
+	Trades   int     `json:"trades"`   // 成交笔数 / Number of fills
+	Fees     float64 `json:"fees"`     // 累计手续费（USDT）/ Cumulative fees (USDT)
+	Slippage float64 `json:"slippage"` // 累计滑点成本（USDT，正数为成本）/ Cumulative slippage cost (USDT, positive = cost)
+	Funding  float64 `json:"funding"`  // 已平仓持仓的累计资金费（正数为支付）/ Funding of closed positions (positive = paid)
+}
+
+// Total returns fees, slippage and funding combined
+// Total 返回手续费、滑点和资金费之和
+func (c *TradingCosts) Total() float64 {
+	return c.Fees + c.Slippage + c.Funding
+}
+
+// initFeeSchema adds the trading fee column to positions
+// initFeeSchema 为 positions 表添加手续费字段
+func (s *Storage) initFeeSchema() {
+	// Ignore the error as the column may already exist
+	// 忽略错误，因为字段可能已经存在
+	s.exec("ALTER TABLE positions ADD COLUMN trading_fee REAL DEFAULT 0")
+}
+
+// SavePositionFees stores the trading fees a position paid (entry, partial and final closes)
+// SavePositionFees 保存持仓支付的手续费（开仓、分批平仓和最终平仓）
+func (s *Storage) SavePositionFees(positionID string, fees float64) error {
+	if _, err := s.exec(`UPDATE positions SET trading_fee = ? WHERE id = ?`, fees, positionID); err != nil {
+		return fmt.Errorf("failed to save position fees: %w", err)
+	}
+	return nil
+}
+
+// GetPositionFees retrieves the trading fees a position paid (0 if not found)
+// GetPositionFees 获取持仓支付的手续费（不存在返回 0）
+func (s *Storage) GetPositionFees(positionID string) (float64, error) {
+	var fees sql.NullFloat64
+	err := s.db.QueryRow(`SELECT trading_fee FROM positions WHERE id = ?`, positionID).Scan(&fees)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get position fees: %w", err)
+	}
+	return fees.Float64, nil
+}
+
+// SumTradeFees returns the fees of the fills of a symbol recorded since a time
+// SumTradeFees 返回指定时间以来交易对成交记录的手续费之和
+func (s *Storage) SumTradeFees(symbol string, since time.Time) (float64, error) {
+	var fees float64
+	err := s.db.QueryRow(`
+	SELECT COALESCE(SUM(fee), 0) FROM trades WHERE symbol = ? AND created_at >= ?
+	`, symbol, since).Scan(&fees)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum trade fees: %w", err)
+	}
+	return fees, nil
+}
+
+// GetTradingCosts summarizes fees, slippage and funding (empty symbol = all symbols)
+// GetTradingCosts 汇总手续费、滑点和资金费（symbol 为空表示全部交易对）
+//
+// Fees come from the recorded fills, slippage from the execution timings (decision price to fill)
+// and funding from the closed positions.
+// 手续费来自成交记录，滑点来自执行延迟记录（决策价到成交价），资金费来自已平仓持仓。
+func (s *Storage) GetTradingCosts(symbol string) (*TradingCosts, error) {
+	costs := &TradingCosts{}
+	err := s.db.QueryRow(`
+	SELECT COUNT(*), COALESCE(SUM(fee), 0) FROM trades WHERE (? = '' OR symbol = ?)
+	`, symbol, symbol).Scan(&costs.Trades, &costs.Fees)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum trade fees: %w", err)
+	}
+	err = s.db.QueryRow(`
+	SELECT COALESCE(SUM(slippage_usd), 0) FROM execution_timings WHERE (? = '' OR symbol = ?)
+	`, symbol, symbol).Scan(&costs.Slippage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum slippage: %w", err)
+	}
+	err = s.db.QueryRow(`
+	SELECT COALESCE(SUM(funding_fee), 0) FROM positions WHERE closed = 1 AND (? = '' OR symbol = ?)
+	`, symbol, symbol).Scan(&costs.Funding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum funding: %w", err)
+	}
+	return costs, nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTradingCosts tests position fees, the fee window of a symbol and the cumulative trading costs
+// TestTradingCosts 测试持仓手续费、交易对的手续费时间窗口以及累计交易成本
+func TestTradingCosts(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "fees.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	pos := &PositionRecord{
+		ID: "BTCUSDT-1", Symbol: "BTCUSDT", Side: "long", EntryPrice: 100000, EntryTime: now.Add(-time.Hour),
+		Quantity: 0.01, Leverage: 10, InitialStopLoss: 98000, CurrentStopLoss: 98000, StopLossType: "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+	if fees, err := db.GetPositionFees(pos.ID); err != nil || fees != 0 {
+		t.Fatalf("Expected no fees, got %.4f, %v", fees, err)
+	}
+	if fees, err := db.GetPositionFees("missing"); err != nil || fees != 0 {
+		t.Fatalf("Expected 0 for unknown position, got %.4f, %v", fees, err)
+	}
+
+	trades := []*TradeRecord{
+		{OrderID: 1, Symbol: "BTCUSDT", Side: "SELL", Type: "MARKET", Quantity: 0.01, Price: 99000, Fee: 0.4, RealizedPnL: 5, CreatedAt: now.Add(-3 * time.Hour)},
+		{OrderID: 2, Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 0.01, Price: 100000, Fee: 0.4, CreatedAt: now.Add(-time.Hour)},
+		{OrderID: 3, Symbol: "ETHUSDT", Side: "BUY", Type: "MARKET", Quantity: 1, Price: 3000, Fee: 1.2, CreatedAt: now.Add(-30 * time.Minute)},
+		{OrderID: 4, Symbol: "BTCUSDT", Side: "SELL", Type: "STOP_MARKET", Quantity: 0.01, Price: 101000, Fee: 0.5, RealizedPnL: 10, CreatedAt: now},
+	}
+	for _, trade := range trades {
+		if _, err := db.SaveTrade(trade); err != nil {
+			t.Fatalf("SaveTrade failed: %v", err)
+		}
+	}
+
+	// Only the fills of the symbol since the entry belong to the position
+	// 只有开仓以来该交易对的成交属于该持仓
+	fees, err := db.SumTradeFees("BTCUSDT", pos.EntryTime.Add(-time.Minute))
+	if err != nil || fees != 0.9 {
+		t.Fatalf("SumTradeFees = %.4f, %v; want 0.9", fees, err)
+	}
+	if err := db.SavePositionFees(pos.ID, fees); err != nil {
+		t.Fatalf("SavePositionFees failed: %v", err)
+	}
+	if saved, _ := db.GetPositionFees(pos.ID); saved != 0.9 {
+		t.Errorf("Expected position fees 0.9, got %.4f", saved)
+	}
+
+	if _, err := db.SaveExecutionTiming(&ExecutionTimingRecord{
+		Symbol: "BTCUSDT", Action: "BUY", Quantity: 0.01, SignalPrice: 99900, FillPrice: 100000,
+		SlippageBps: 10, SlippageUSD: 1, SignalAt: now.Add(-time.Hour), DecisionAt: now.Add(-time.Hour),
+		SentAt: now.Add(-time.Hour), FilledAt: now.Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("SaveExecutionTiming failed: %v", err)
+	}
+
+	// Funding only counts once the position is closed
+	// 资金费仅在持仓平仓后计入
+	if err := db.SavePositionFunding(pos.ID, 0.3); err != nil {
+		t.Fatalf("SavePositionFunding failed: %v", err)
+	}
+	costs, err := db.GetTradingCosts("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetTradingCosts failed: %v", err)
+	}
+	if costs.Trades != 3 || costs.Fees != 1.3 || costs.Slippage != 1 || costs.Funding != 0 {
+		t.Errorf("Unexpected costs before close: %+v", costs)
+	}
+
+	pos.Closed = true
+	if err := db.UpdatePosition(pos); err != nil {
+		t.Fatalf("UpdatePosition failed: %v", err)
+	}
+	if costs, _ := db.GetTradingCosts("BTCUSDT"); costs.Funding != 0.3 || math.Abs(costs.Total()-2.6) > 1e-9 {
+		t.Errorf("Expected funding 0.3 and total 2.6 after close, got %+v", costs)
+	}
+	if costs, _ := db.GetTradingCosts(""); costs.Trades != 4 || costs.Fees != 2.5 {
+		t.Errorf("Expected 4 fills and 2.5 USDT fees for all symbols, got %+v", costs)
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// initFilterSchema adds the execution filter columns to trading_sessions
+// initFilterSchema 为 trading_sessions 表添加执行过滤器字段
+func (s *Storage) initFilterSchema() {
+	// Run each ALTER separately so one existing column doesn't skip the other
+	// 逐条执行 ALTER，避免某个字段已存在导致另一个字段被跳过
+	for _, column := range []string{"filter_name TEXT", "filter_reason TEXT"} {
+		s.exec("ALTER TABLE trading_sessions ADD COLUMN " + column)
+	}
+}
+
+// SaveFilterRejection records the execution filter that rejected a session's decision
+// SaveFilterRejection 记录拒绝会话决策的执行过滤器及原因
+func (s *Storage) SaveFilterRejection(sessionID int64, filter, reason string) error {
+	if _, err := s.exec(`UPDATE trading_sessions SET filter_name = ?, filter_reason = ? WHERE id = ?`,
+		filter, reason, sessionID); err != nil {
+		return fmt.Errorf("failed to save filter rejection: %w", err)
+	}
+	return nil
+}
+
+// GetFilterRejection returns the filter name and reason saved for a session (empty if it passed)
+// GetFilterRejection 返回会话保存的过滤器名称和原因（未被过滤时为空）
+func (s *Storage) GetFilterRejection(sessionID int64) (string, string, error) {
+	var filter, reason sql.NullString
+	err := s.db.QueryRow(`SELECT filter_name, filter_reason FROM trading_sessions WHERE id = ?`, sessionID).Scan(&filter, &reason)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get filter rejection: %w", err)
+	}
+	return filter.String, reason.String, nil
+}
+
+// GetLastStopOut returns the close time of the symbol's latest losing position closed by its stop (nil if none)
+// GetLastStopOut 返回该交易对最近一次止损亏损平仓的时间（没有则返回 nil）
+//
+// "止损或止盈单触发" closes are ambiguous, so only losing closes count as stop-outs.
+// "止损或止盈单触发" 的平仓无法区分止损和止盈，因此只统计亏损的平仓。
+func (s *Storage) GetLastStopOut(symbol string) (*time.Time, error) {
+	var closeTime sql.NullTime
+	err := s.db.QueryRow(`
+	SELECT close_time FROM positions
+	WHERE symbol = ? AND closed = 1 AND realized_pnl < 0
+		AND close_reason LIKE '%止损%'
+	ORDER BY close_time DESC
+	LIMIT 1
+	`, NormalizeSymbol(symbol)).Scan(&closeTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last stop-out: %w", err)
+	}
+	if !closeTime.Valid {
+		return nil, nil
+	}
+	return &closeTime.Time, nil
+}
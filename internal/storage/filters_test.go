@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecutionFilterRecords(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "filters.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 没有止损记录时返回 nil
+	if last, err := db.GetLastStopOut("BTC/USDT"); err != nil || last != nil {
+		t.Fatalf("Expected no stop-out, got %v, %v", last, err)
+	}
+
+	base := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	closes := []struct {
+		id     string
+		reason string
+		pnl    float64
+		at     time.Time
+	}{
+		{"BTCUSDT-1", "止损单触发（币安自动执行）", -12, base},
+		{"BTCUSDT-2", "止损或止盈单触发（币安自动执行）", 30, base.Add(2 * time.Hour)}, // 止盈，不算止损
+		{"BTCUSDT-3", "LLM 决策平仓", -5, base.Add(3 * time.Hour)},         // 非止损平仓
+	}
+	for _, c := range closes {
+		closeTime := c.at
+		pos := &PositionRecord{
+			ID: c.id, Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, EntryTime: c.at.Add(-time.Hour),
+			Quantity: 1, Leverage: 5, InitialStopLoss: 95, CurrentStopLoss: 95, StopLossType: "fixed",
+		}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+		pos.Closed, pos.CloseTime, pos.CloseReason, pos.RealizedPnL = true, &closeTime, c.reason, c.pnl
+		if err := db.UpdatePosition(pos); err != nil {
+			t.Fatalf("UpdatePosition failed: %v", err)
+		}
+	}
+
+	last, err := db.GetLastStopOut("btc/usdt")
+	if err != nil || last == nil {
+		t.Fatalf("GetLastStopOut failed: %v, %v", last, err)
+	}
+	if !last.Equal(base) {
+		t.Errorf("Expected the losing stop at %v, got %v", base, last)
+	}
+	if last, _ := db.GetLastStopOut("ETHUSDT"); last != nil {
+		t.Errorf("Expected no stop-out for another symbol, got %v", last)
+	}
+
+	sessionID, err := db.SaveSession(&TradingSession{Symbol: "BTCUSDT", Timeframe: "1h", Decision: "BUY"})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+	if filter, reason, err := db.GetFilterRejection(sessionID); err != nil || filter != "" || reason != "" {
+		t.Fatalf("Expected no rejection, got %q, %q, %v", filter, reason, err)
+	}
+	if err := db.SaveFilterRejection(sessionID, "stop_out_cooldown", "止损后冷却中"); err != nil {
+		t.Fatalf("SaveFilterRejection failed: %v", err)
+	}
+	if filter, reason, _ := db.GetFilterRejection(sessionID); filter != "stop_out_cooldown" || reason != "止损后冷却中" {
+		t.Errorf("Unexpected rejection %q, %q", filter, reason)
+	}
+}
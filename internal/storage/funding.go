@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// initFundingSchema adds the funding column to positions
+// initFundingSchema 为 positions 表添加资金费字段
+func (s *Storage) initFundingSchema() {
+	// Ignore the error as the column may already exist
+	// 忽略错误，因为字段可能已经存在
+	s.exec("ALTER TABLE positions ADD COLUMN funding_fee REAL DEFAULT 0")
+}
+
+// SavePositionFunding stores the funding a position paid (negative = received)
+// SavePositionFunding 保存持仓支付的资金费（负数表示收取）
+func (s *Storage) SavePositionFunding(positionID string, funding float64) error {
+	if _, err := s.exec(`UPDATE positions SET funding_fee = ? WHERE id = ?`, funding, positionID); err != nil {
+		return fmt.Errorf("failed to save position funding: %w", err)
+	}
+	return nil
+}
+
+// GetPositionFunding retrieves the funding a position paid (0 if not found)
+// GetPositionFunding 获取持仓支付的资金费（不存在返回 0）
+func (s *Storage) GetPositionFunding(positionID string) (float64, error) {
+	var funding sql.NullFloat64
+	err := s.db.QueryRow(`SELECT funding_fee FROM positions WHERE id = ?`, positionID).Scan(&funding)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get position funding: %w", err)
+	}
+	return funding.Float64, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPositionFunding(t *testing.T) {
+	tmpDB := "./test_funding.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	pos := &PositionRecord{
+		ID: "ETHUSDT-1", Symbol: "ETHUSDT", Side: "short", EntryPrice: 3500, EntryTime: time.Now(),
+		Quantity: 1, Leverage: 5, InitialStopLoss: 3600, CurrentStopLoss: 3600, StopLossType: "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// 未结算资金费时为 0，未知持仓也返回 0
+	if funding, err := db.GetPositionFunding(pos.ID); err != nil || funding != 0 {
+		t.Fatalf("Expected no funding, got %.4f, %v", funding, err)
+	}
+	if funding, err := db.GetPositionFunding("missing"); err != nil || funding != 0 {
+		t.Fatalf("Expected 0 for unknown position, got %.4f, %v", funding, err)
+	}
+
+	// 负数表示收取资金费
+	if err := db.SavePositionFunding(pos.ID, -1.25); err != nil {
+		t.Fatalf("SavePositionFunding failed: %v", err)
+	}
+	if funding, _ := db.GetPositionFunding(pos.ID); funding != -1.25 {
+		t.Errorf("Expected funding -1.25, got %.4f", funding)
+	}
+}
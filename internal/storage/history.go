@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PricePointRecord is a flushed point of a position's in-memory price history
+// PricePointRecord 是从持仓内存价格历史写入数据库的价格点
+type PricePointRecord struct {
+	PositionID string    // 持仓 ID / Position ID
+	Symbol     string    // 交易对（币安格式）/ Trading pair (Binance format)
+	Time       time.Time // 时间 / Time
+	Price      float64   // 价格 / Price
+}
+
+// ExecutionRecord is a flushed trade execution result of the executor
+// ExecutionRecord 是从执行器写入数据库的交易执行结果
+type ExecutionRecord struct {
+	ID        int64
+	Symbol    string    // 交易对 / Trading pair
+	Action    string    // BUY/SELL/CLOSE_LONG/CLOSE_SHORT
+	Amount    float64   // 请求数量 / Requested amount
+	Price     float64   // 成交价 / Fill price
+	Filled    float64   // 成交数量 / Filled quantity
+	OrderID   string    // 订单 ID / Order ID
+	Success   bool      // 是否成功 / Whether succeeded
+	TestMode  bool      // 是否测试模式 / Whether in test mode
+	Reason    string    // 交易理由 / Trade reason
+	Message   string    // 执行结果信息 / Result message
+	CreatedAt time.Time // 执行时间 / Execution time
+}
+
+// initHistorySchema creates the price and execution history tables if they don't exist
+// initHistorySchema 创建价格历史和执行历史表（如果不存在）
+func (s *Storage) initHistorySchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS price_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		position_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		time DATETIME NOT NULL,
+		price REAL NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_price_history_symbol ON price_history(symbol, time DESC);
+
+	CREATE TABLE IF NOT EXISTS execution_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		action TEXT NOT NULL,
+		amount REAL DEFAULT 0,
+		price REAL DEFAULT 0,
+		filled REAL DEFAULT 0,
+		order_id TEXT,
+		success BOOLEAN DEFAULT 0,
+		test_mode BOOLEAN DEFAULT 0,
+		reason TEXT,
+		message TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_execution_history_created_at ON execution_history(created_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SavePricePoints stores a batch of price points in one transaction
+// SavePricePoints 在一个事务中批量保存价格点
+func (s *Storage) SavePricePoints(points []*PricePointRecord) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO price_history (position_id, symbol, time, price) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare price history insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range points {
+		if _, err := stmt.Exec(p.PositionID, p.Symbol, p.Time, p.Price); err != nil {
+			return fmt.Errorf("failed to save price point: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPriceHistory retrieves price points of a symbol since the given time, oldest first
+// GetPriceHistory 获取交易对自指定时间以来的价格点，按时间正序
+func (s *Storage) GetPriceHistory(symbol string, since time.Time, limit int) ([]*PricePointRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT position_id, symbol, time, price FROM (
+		SELECT position_id, symbol, time, price
+		FROM price_history
+		WHERE symbol = ? AND time >= ?
+		ORDER BY time DESC
+		LIMIT ?
+	) ORDER BY time ASC
+	`, symbol, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*PricePointRecord
+	for rows.Next() {
+		p := &PricePointRecord{}
+		if err := rows.Scan(&p.PositionID, &p.Symbol, &p.Time, &p.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan price point: %w", err)
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// SaveExecutions stores a batch of execution results in one transaction
+// SaveExecutions 在一个事务中批量保存执行结果
+func (s *Storage) SaveExecutions(records []*ExecutionRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO execution_history (
+		symbol, action, amount, price, filled, order_id,
+		success, test_mode, reason, message, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare execution history insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.Exec(
+			r.Symbol, r.Action, r.Amount, r.Price, r.Filled, r.OrderID,
+			r.Success, r.TestMode, r.Reason, r.Message, r.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to save execution: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetExecutions retrieves the latest execution results, newest first (empty symbol = all symbols)
+// GetExecutions 获取最近的执行结果，按时间倒序（symbol 为空表示全部交易对）
+func (s *Storage) GetExecutions(symbol string, limit int) ([]*ExecutionRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT id, symbol, action, amount, price, filled, COALESCE(order_id, ''),
+		   success, test_mode, COALESCE(reason, ''), COALESCE(message, ''), created_at
+	FROM execution_history
+	WHERE (? = '' OR symbol = ?)
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`, symbol, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query executions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ExecutionRecord
+	for rows.Next() {
+		r := &ExecutionRecord{}
+		if err := rows.Scan(
+			&r.ID, &r.Symbol, &r.Action, &r.Amount, &r.Price, &r.Filled, &r.OrderID,
+			&r.Success, &r.TestMode, &r.Reason, &r.Message, &r.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan execution: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPriceAndExecutionHistory(t *testing.T) {
+	tmpDB := "./test_history.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	var points []*PricePointRecord
+	for i := 0; i < 5; i++ {
+		points = append(points, &PricePointRecord{
+			PositionID: "BTCUSDT-1",
+			Symbol:     "BTCUSDT",
+			Time:       now.Add(time.Duration(i-5) * time.Minute),
+			Price:      100000 + float64(i),
+		})
+	}
+	points = append(points, &PricePointRecord{PositionID: "ETHUSDT-1", Symbol: "ETHUSDT", Time: now, Price: 3000})
+	if err := db.SavePricePoints(points); err != nil {
+		t.Fatalf("SavePricePoints failed: %v", err)
+	}
+
+	// 最近的 3 个点，按时间正序
+	history, err := db.GetPriceHistory("BTCUSDT", now.Add(-time.Hour), 3)
+	if err != nil {
+		t.Fatalf("GetPriceHistory failed: %v", err)
+	}
+	if len(history) != 3 || history[0].Price != 100002 || history[2].Price != 100004 {
+		t.Fatalf("Unexpected price history: %+v", history)
+	}
+
+	// since 之前的点被过滤
+	history, err = db.GetPriceHistory("BTCUSDT", now.Add(-2*time.Minute-time.Second), 100)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("Expected 2 points since cutoff, got %d, %v", len(history), err)
+	}
+
+	records := []*ExecutionRecord{
+		{Symbol: "BTCUSDT", Action: "BUY", Amount: 0.01, Price: 100000, Filled: 0.01, OrderID: "1", Success: true, Reason: "突破", CreatedAt: now.Add(-time.Minute)},
+		{Symbol: "ETHUSDT", Action: "SELL", Amount: 1, Message: "订单执行失败", CreatedAt: now},
+	}
+	if err := db.SaveExecutions(records); err != nil {
+		t.Fatalf("SaveExecutions failed: %v", err)
+	}
+
+	all, err := db.GetExecutions("", 10)
+	if err != nil || len(all) != 2 || all[0].Symbol != "ETHUSDT" || all[0].Success {
+		t.Fatalf("Unexpected executions: %+v, %v", all, err)
+	}
+	btc, err := db.GetExecutions("BTCUSDT", 10)
+	if err != nil || len(btc) != 1 || btc[0].OrderID != "1" || btc[0].Reason != "突破" || !btc[0].Success {
+		t.Fatalf("Unexpected BTC executions: %+v, %v", btc, err)
+	}
+
+	// 空批次不报错
+	if err := db.SavePricePoints(nil); err != nil {
+		t.Errorf("SavePricePoints(nil) error = %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetOriginatingSession returns the session whose decision opened a position: the latest session of the
+// symbol created at or before entryTime and no more than window earlier
+// GetOriginatingSession 返回开仓决策所在的会话：该交易对在 entryTime 及之前、window 时长以内最近的会话
+//
+// Sessions store "BTC/USDT" while positions store "BTCUSDT"; both forms match. Returns nil without an
+// error when there is no such session, e.g. for manual trades.
+// 会话以 "BTC/USDT" 保存而持仓以 "BTCUSDT" 保存，两种格式都能匹配。没有对应会话时（例如人工交易）返回 nil 且不报错。
+func (s *Storage) GetOriginatingSession(symbol string, entryTime time.Time, window time.Duration) (*TradingSession, error) {
+	var id int64
+	err := s.db.QueryRow(`
+	SELECT id FROM trading_sessions
+	WHERE REPLACE(UPPER(symbol), '/', '') = ? AND created_at <= ? AND created_at >= ?
+	ORDER BY created_at DESC
+	LIMIT 1
+	`, NormalizeSymbol(symbol), entryTime, entryTime.Add(-window)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query originating session: %w", err)
+	}
+
+	return s.GetSessionByID(id)
+}
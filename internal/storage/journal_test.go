@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestGetOriginatingSession tests matching the latest session before entry within the window
+// TestGetOriginatingSession 测试匹配开仓前时间窗口内最近的会话
+func TestGetOriginatingSession(t *testing.T) {
+	tmpDB := "./test_journal.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	entry := time.Now().Add(-time.Hour)
+	for _, session := range []*TradingSession{
+		{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: entry.Add(-3 * time.Hour), Decision: "太早 / Too early"},
+		{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: entry.Add(-2 * time.Minute), Decision: "BUY BTC"},
+		{Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: entry.Add(-time.Minute), Decision: "其他交易对 / Other symbol"},
+		{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: entry.Add(time.Minute), Decision: "开仓之后 / After entry"},
+	} {
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	session, err := db.GetOriginatingSession("BTCUSDT", entry, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("GetOriginatingSession failed: %v", err)
+	}
+	if session == nil || session.Decision != "BUY BTC" {
+		t.Fatalf("Expected the BUY BTC session, got %+v", session)
+	}
+
+	session, err = db.GetOriginatingSession("SOLUSDT", entry, 2*time.Hour)
+	if err != nil || session != nil {
+		t.Errorf("Expected no session for a symbol without sessions, got %+v (%v)", session, err)
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kline is one cached candle of a symbol and interval
+// Kline 是某个交易对和周期的一根缓存 K 线
+type Kline struct {
+	OpenTime time.Time // 开盘时间 / Open time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Volume   float64
+}
+
+// initKlineSchema creates the klines table if it doesn't exist
+// initKlineSchema 创建 klines 表（如果不存在）
+//
+// open_time is stored in Unix milliseconds, the same key Binance uses for a candle.
+// open_time 以 Unix 毫秒保存，与币安 K 线的主键一致。
+func (s *Storage) initKlineSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS klines (
+		symbol TEXT NOT NULL,
+		interval TEXT NOT NULL,
+		open_time INTEGER NOT NULL,
+		open REAL NOT NULL,
+		high REAL NOT NULL,
+		low REAL NOT NULL,
+		close REAL NOT NULL,
+		volume REAL NOT NULL,
+		PRIMARY KEY (symbol, interval, open_time)
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveKlines inserts or replaces candles in one transaction (a refetched open candle overwrites the stored one)
+// SaveKlines 在一个事务中插入或替换 K 线（重新获取的未收盘 K 线会覆盖已保存的）
+func (s *Storage) SaveKlines(symbol, interval string, klines []*Kline) error {
+	if len(klines) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT OR REPLACE INTO klines (symbol, interval, open_time, open, high, low, close, volume)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare kline insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, k := range klines {
+		if _, err := stmt.Exec(symbol, interval, k.OpenTime.UnixMilli(), k.Open, k.High, k.Low, k.Close, k.Volume); err != nil {
+			return fmt.Errorf("failed to save kline: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetKlines retrieves the cached candles opened at or after since, oldest first
+// GetKlines 获取 since 及之后开盘的缓存 K 线，按时间从旧到新排列
+func (s *Storage) GetKlines(symbol, interval string, since time.Time) ([]*Kline, error) {
+	rows, err := s.db.Query(`
+	SELECT open_time, open, high, low, close, volume
+	FROM klines
+	WHERE symbol = ? AND interval = ? AND open_time >= ?
+	ORDER BY open_time ASC
+	`, symbol, interval, since.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query klines: %w", err)
+	}
+	defer rows.Close()
+
+	var klines []*Kline
+	for rows.Next() {
+		k := &Kline{}
+		var openTime int64
+		if err := rows.Scan(&openTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume); err != nil {
+			return nil, fmt.Errorf("failed to scan kline: %w", err)
+		}
+		k.OpenTime = time.UnixMilli(openTime)
+		klines = append(klines, k)
+	}
+	return klines, rows.Err()
+}
+
+// PruneKlines deletes the candles of a symbol and interval opened before the cutoff
+// PruneKlines 删除某个交易对和周期在 cutoff 之前开盘的 K 线
+func (s *Storage) PruneKlines(symbol, interval string, before time.Time) (int64, error) {
+	result, err := s.exec(`DELETE FROM klines WHERE symbol = ? AND interval = ? AND open_time < ?`,
+		symbol, interval, before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune klines: %w", err)
+	}
+	return result.RowsAffected()
+}
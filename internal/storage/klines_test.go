@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKlines(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "klines.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	var klines []*Kline
+	for i := 0; i < 5; i++ {
+		klines = append(klines, &Kline{OpenTime: start.Add(time.Duration(i) * time.Hour), Open: 100, High: 101, Low: 99, Close: 100 + float64(i), Volume: 10})
+	}
+	if err := db.SaveKlines("BTCUSDT", "1h", klines); err != nil {
+		t.Fatalf("SaveKlines failed: %v", err)
+	}
+	// 最后一根未收盘 K 线被重新获取后覆盖 / The refetched open candle replaces the stored one
+	if err := db.SaveKlines("BTCUSDT", "1h", []*Kline{{OpenTime: klines[4].OpenTime, Close: 110, Volume: 20}}); err != nil {
+		t.Fatalf("SaveKlines failed: %v", err)
+	}
+	if err := db.SaveKlines("BTCUSDT", "4h", klines[:1]); err != nil {
+		t.Fatalf("SaveKlines failed: %v", err)
+	}
+
+	got, err := db.GetKlines("BTCUSDT", "1h", start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetKlines failed: %v", err)
+	}
+	if len(got) != 4 || !got[0].OpenTime.Equal(start.Add(time.Hour)) {
+		t.Fatalf("Expected 4 candles from 01:00, got %d", len(got))
+	}
+	if got[3].Close != 110 || got[3].Volume != 20 {
+		t.Errorf("Expected the replaced candle, got %+v", got[3])
+	}
+
+	pruned, err := db.PruneKlines("BTCUSDT", "1h", start.Add(2*time.Hour))
+	if err != nil || pruned != 2 {
+		t.Errorf("Expected 2 pruned candles, got %d (%v)", pruned, err)
+	}
+	if other, _ := db.GetKlines("BTCUSDT", "4h", start); len(other) != 1 {
+		t.Errorf("Expected other intervals to be untouched, got %d", len(other))
+	}
+}
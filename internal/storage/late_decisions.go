@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LateDecision is an LLM decision that arrived after the decision budget was spent
+// LateDecision 表示在决策时间预算耗尽后才返回的 LLM 决策
+//
+// The batch has already been decided by the fallback, so the late result is kept for review only.
+// 该批次已使用后备决策，迟到结果仅用于事后复盘。
+type LateDecision struct {
+	ID        int64
+	BatchID   string        // 批次 ID / Batch ID
+	Decision  string        // 迟到的 LLM 原始决策 / Late raw LLM decision
+	Error     string        // 调用最终失败时的错误 / Error if the call eventually failed
+	Latency   time.Duration // 从调用开始到返回的耗时 / Time from the call to its answer
+	CreatedAt time.Time
+}
+
+// initLateDecisionSchema creates the late_decisions table if it doesn't exist
+// initLateDecisionSchema 创建 late_decisions 表（如果不存在）
+func (s *Storage) initLateDecisionSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS late_decisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch_id TEXT NOT NULL,
+		decision TEXT,
+		error TEXT,
+		latency_ms INTEGER NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_late_decisions_batch ON late_decisions(batch_id);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveLateDecision stores a late LLM decision against its batch
+// SaveLateDecision 按批次保存迟到的 LLM 决策
+//
+// The row is keyed by batch rather than session, because the late answer may arrive before
+// or after the batch's sessions are saved.
+// 以批次而非会话为键，因为迟到结果可能在批次会话保存之前或之后到达。
+func (s *Storage) SaveLateDecision(d *LateDecision) error {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT INTO late_decisions (batch_id, decision, error, latency_ms, created_at)
+	VALUES (?, ?, ?, ?, ?)
+	`, d.BatchID, d.Decision, d.Error, d.Latency.Milliseconds(), d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save late decision: %w", err)
+	}
+	d.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetLateDecision retrieves the late LLM decision of a batch (nil if the LLM answered in time)
+// GetLateDecision 获取批次的迟到 LLM 决策（LLM 按时返回时为 nil）
+func (s *Storage) GetLateDecision(batchID string) (*LateDecision, error) {
+	var decision, errMsg sql.NullString
+	var latencyMs int64
+
+	d := &LateDecision{BatchID: batchID}
+	err := s.db.QueryRow(`
+	SELECT id, decision, error, latency_ms, created_at
+	FROM late_decisions WHERE batch_id = ?
+	ORDER BY id DESC LIMIT 1
+	`, batchID).Scan(&d.ID, &decision, &errMsg, &latencyMs, &d.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get late decision: %w", err)
+	}
+
+	d.Decision = decision.String
+	d.Error = errMsg.String
+	d.Latency = time.Duration(latencyMs) * time.Millisecond
+	return d, nil
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLateDecisionRoundTrip(t *testing.T) {
+	tmpDB := "./test_late_decisions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 按时返回的批次没有迟到决策
+	if d, err := db.GetLateDecision("batch-1"); err != nil || d != nil {
+		t.Fatalf("Expected no late decision, got %+v, %v", d, err)
+	}
+
+	late := &LateDecision{BatchID: "batch-1", Decision: `{"action": "BUY"}`, Latency: 185 * time.Second}
+	if err := db.SaveLateDecision(late); err != nil {
+		t.Fatalf("SaveLateDecision failed: %v", err)
+	}
+	if late.ID == 0 {
+		t.Error("Expected ID to be set")
+	}
+
+	d, err := db.GetLateDecision("batch-1")
+	if err != nil || d == nil {
+		t.Fatalf("GetLateDecision = %+v, %v", d, err)
+	}
+	if d.Decision != late.Decision || d.Latency != 185*time.Second || d.Error != "" {
+		t.Errorf("Unexpected late decision: %+v", d)
+	}
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ExecutionTimingRecord tracks one executed decision from market snapshot to fill
+// ExecutionTimingRecord 记录一次已执行决策从行情快照到成交的各阶段时间
+//
+// Slippage is measured against the price the LLM analyzed (the last close of the snapshot),
+// so it includes the market move during the whole cycle. Positive values are a cost.
+// 滑点以 LLM 分析时的价格（快照最后收盘价）为基准，包含整个周期内的行情变化。正值表示成本。
+type ExecutionTimingRecord struct {
+	ID          int64
+	SessionID   int64     // 交易会话 ID / Trading session ID
+	Symbol      string    // 交易对（币安格式）/ Trading pair (Binance format)
+	Action      string    // BUY/SELL/CLOSE_LONG/CLOSE_SHORT
+	Quantity    float64   // 成交数量 / Filled quantity
+	SignalPrice float64   // LLM 分析时的价格 / Price analyzed by the LLM
+	FillPrice   float64   // 成交均价 / Average fill price
+	SlippageBps float64   // 不利滑点（基点）/ Adverse slippage in bps
+	SlippageUSD float64   // 滑点成本（USDT）/ Slippage cost (USDT)
+	SignalAt    time.Time // 行情快照时间 / Market snapshot time
+	DecisionAt  time.Time // 决策生成时间 / Decision generated
+	ValidatedAt time.Time // 验证通过时间 / Validation passed
+	SentAt      time.Time // 下单时间 / Order sent
+	FilledAt    time.Time // 成交时间 / Order filled
+	Paper       bool      // 是否为模拟盘 / Whether paper trading
+}
+
+// Latency returns the total time from market snapshot to fill
+// Latency 返回从行情快照到成交的总耗时
+func (r *ExecutionTimingRecord) Latency() time.Duration {
+	return r.FilledAt.Sub(r.SignalAt)
+}
+
+// LatencyBucket aggregates executions whose total latency falls in [Min, Max)
+// LatencyBucket 汇总总耗时落在 [Min, Max) 区间内的执行
+type LatencyBucket struct {
+	Label          string        // 区间标签 / Bucket label
+	Min            time.Duration // 区间下限 / Lower bound
+	Max            time.Duration // 区间上限（0 表示无上限）/ Upper bound (0 = unbounded)
+	Count          int           // 执行次数 / Executions
+	AvgSlippageBps float64       // 平均滑点（基点）/ Average slippage (bps)
+	SlippageUSD    float64       // 累计滑点成本（USDT）/ Total slippage cost (USDT)
+}
+
+// LatencyStats summarizes execution latency and its correlation with slippage
+// LatencyStats 汇总执行延迟及其与滑点的相关性
+type LatencyStats struct {
+	Count int // 执行次数 / Executions
+
+	// Average stage durations (stages with missing timestamps are skipped)
+	// 各阶段平均耗时（缺少时间戳的阶段不计入）
+	AvgDecision   time.Duration // 快照 → 决策（LLM 周期）/ Snapshot → decision (LLM cycle)
+	AvgValidation time.Duration // 决策 → 验证通过 / Decision → validation passed
+	AvgRouting    time.Duration // 验证通过 → 下单 / Validation passed → order sent
+	AvgFill       time.Duration // 下单 → 成交 / Order sent → filled
+	AvgTotal      time.Duration // 快照 → 成交 / Snapshot → filled
+
+	AvgSlippageBps float64 // 平均滑点（基点）/ Average slippage (bps)
+	SlippageUSD    float64 // 累计滑点成本（USDT）/ Total slippage cost (USDT)
+
+	// Correlation is the Pearson correlation of total latency and slippage, BpsPerMinute the
+	// least-squares slope: the extra slippage each minute of latency costs on average
+	// Correlation 是总耗时与滑点的皮尔逊相关系数，BpsPerMinute 是最小二乘斜率：平均每多一分钟延迟带来的额外滑点
+	Correlation  float64
+	BpsPerMinute float64
+
+	Buckets []*LatencyBucket // 按总耗时分组 / Grouped by total latency
+}
+
+// latencyBuckets are the total latency ranges used by GetLatencyStats
+// latencyBuckets 是 GetLatencyStats 使用的总耗时区间
+var latencyBuckets = []struct {
+	label    string
+	min, max time.Duration
+}{
+	{"< 30s", 0, 30 * time.Second},
+	{"30s-1m", 30 * time.Second, time.Minute},
+	{"1m-2m", time.Minute, 2 * time.Minute},
+	{"2m-5m", 2 * time.Minute, 5 * time.Minute},
+	{">= 5m", 5 * time.Minute, 0},
+}
+
+// initLatencySchema creates the execution_timings table if it doesn't exist
+// initLatencySchema 创建 execution_timings 表（如果不存在）
+func (s *Storage) initLatencySchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS execution_timings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id INTEGER DEFAULT 0,
+		symbol TEXT NOT NULL,
+		action TEXT NOT NULL,
+		quantity REAL DEFAULT 0,
+		signal_price REAL DEFAULT 0,
+		fill_price REAL DEFAULT 0,
+		slippage_bps REAL DEFAULT 0,
+		slippage_usd REAL DEFAULT 0,
+		signal_at DATETIME,
+		decision_at DATETIME,
+		validated_at DATETIME,
+		sent_at DATETIME,
+		filled_at DATETIME NOT NULL,
+		paper BOOLEAN DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_execution_timings_symbol ON execution_timings(symbol, filled_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveExecutionTiming stores the timings of an executed decision and returns its ID
+// SaveExecutionTiming 保存一次已执行决策的时间记录并返回其 ID
+func (s *Storage) SaveExecutionTiming(r *ExecutionTimingRecord) (int64, error) {
+	result, err := s.exec(`
+	INSERT INTO execution_timings (
+		session_id, symbol, action, quantity, signal_price, fill_price,
+		slippage_bps, slippage_usd, signal_at, decision_at, validated_at,
+		sent_at, filled_at, paper
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		r.SessionID, r.Symbol, r.Action, r.Quantity, r.SignalPrice, r.FillPrice,
+		r.SlippageBps, r.SlippageUSD, r.SignalAt, r.DecisionAt, r.ValidatedAt,
+		r.SentAt, r.FilledAt, r.Paper,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save execution timing: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetExecutionTimings retrieves the latest execution timings, newest first (empty symbol = all symbols)
+// GetExecutionTimings 获取最近的执行时间记录，按时间倒序（symbol 为空表示全部交易对）
+func (s *Storage) GetExecutionTimings(symbol string, limit int) ([]*ExecutionTimingRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT id, session_id, symbol, action, quantity, signal_price, fill_price,
+		   slippage_bps, slippage_usd, signal_at, decision_at, validated_at,
+		   sent_at, filled_at, paper
+	FROM execution_timings
+	WHERE (? = '' OR symbol = ?)
+	ORDER BY filled_at DESC, id DESC
+	LIMIT ?
+	`, symbol, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution timings: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ExecutionTimingRecord
+	for rows.Next() {
+		r := &ExecutionTimingRecord{}
+		if err := rows.Scan(
+			&r.ID, &r.SessionID, &r.Symbol, &r.Action, &r.Quantity, &r.SignalPrice, &r.FillPrice,
+			&r.SlippageBps, &r.SlippageUSD, &r.SignalAt, &r.DecisionAt, &r.ValidatedAt,
+			&r.SentAt, &r.FilledAt, &r.Paper,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan execution timing: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// GetLatencyStats summarizes all execution timings of a symbol (empty symbol = all symbols)
+// GetLatencyStats 汇总交易对的全部执行时间记录（symbol 为空表示全部交易对）
+func (s *Storage) GetLatencyStats(symbol string) (*LatencyStats, error) {
+	records, err := s.GetExecutionTimings(symbol, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	return computeLatencyStats(records), nil
+}
+
+// computeLatencyStats aggregates stage durations, latency buckets and the latency/slippage relation
+// computeLatencyStats 汇总各阶段耗时、延迟分组以及延迟与滑点的关系
+func computeLatencyStats(records []*ExecutionTimingRecord) *LatencyStats {
+	stats := &LatencyStats{Count: len(records)}
+	for _, b := range latencyBuckets {
+		stats.Buckets = append(stats.Buckets, &LatencyBucket{Label: b.label, Min: b.min, Max: b.max})
+	}
+	if len(records) == 0 {
+		return stats
+	}
+
+	var stages [4]struct {
+		sum time.Duration
+		n   int
+	}
+	stage := func(i int, from, to time.Time) {
+		if !from.IsZero() && !to.IsZero() && !to.Before(from) {
+			stages[i].sum += to.Sub(from)
+			stages[i].n++
+		}
+	}
+
+	// Sums for the correlation and regression, latency in minutes
+	// 相关系数和回归所需的累加值，延迟单位为分钟
+	var sumX, sumY, sumXX, sumYY, sumXY float64
+	var total time.Duration
+	for _, r := range records {
+		stage(0, r.SignalAt, r.DecisionAt)
+		stage(1, r.DecisionAt, r.ValidatedAt)
+		stage(2, r.ValidatedAt, r.SentAt)
+		stage(3, r.SentAt, r.FilledAt)
+
+		latency := r.Latency()
+		total += latency
+		stats.AvgSlippageBps += r.SlippageBps
+		stats.SlippageUSD += r.SlippageUSD
+
+		x, y := latency.Minutes(), r.SlippageBps
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumYY += y * y
+		sumXY += x * y
+
+		for _, b := range stats.Buckets {
+			if latency >= b.Min && (b.Max == 0 || latency < b.Max) {
+				b.Count++
+				b.AvgSlippageBps += r.SlippageBps
+				b.SlippageUSD += r.SlippageUSD
+				break
+			}
+		}
+	}
+
+	n := float64(len(records))
+	avg := func(i int) time.Duration {
+		if stages[i].n == 0 {
+			return 0
+		}
+		return stages[i].sum / time.Duration(stages[i].n)
+	}
+	stats.AvgDecision, stats.AvgValidation, stats.AvgRouting, stats.AvgFill = avg(0), avg(1), avg(2), avg(3)
+	stats.AvgTotal = total / time.Duration(len(records))
+	stats.AvgSlippageBps /= n
+	for _, b := range stats.Buckets {
+		if b.Count > 0 {
+			b.AvgSlippageBps /= float64(b.Count)
+		}
+	}
+
+	varX := n*sumXX - sumX*sumX
+	varY := n*sumYY - sumY*sumY
+	if varX > 0 {
+		stats.BpsPerMinute = (n*sumXY - sumX*sumY) / varX
+		if varY > 0 {
+			stats.Correlation = (n*sumXY - sumX*sumY) / math.Sqrt(varX*varY)
+		}
+	}
+	return stats
+}
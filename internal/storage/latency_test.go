@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExecutionTimingsAndLatencyStats(t *testing.T) {
+	tmpDB := "./test_latency.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 延迟越长，滑点越大：20s → 2bps，90s → 9bps，400s → 40bps
+	base := time.Now().Add(-time.Hour)
+	for i, seconds := range []int{20, 90, 400} {
+		signalAt := base.Add(time.Duration(i) * 10 * time.Minute)
+		filledAt := signalAt.Add(time.Duration(seconds) * time.Second)
+		record := &ExecutionTimingRecord{
+			SessionID:   int64(i + 1),
+			Symbol:      "BTCUSDT",
+			Action:      "BUY",
+			Quantity:    0.1,
+			SignalPrice: 100000,
+			FillPrice:   100000 * (1 + float64(seconds)/10/10000),
+			SlippageBps: float64(seconds) / 10,
+			SlippageUSD: float64(seconds) / 10 / 10000 * 100000 * 0.1,
+			SignalAt:    signalAt,
+			DecisionAt:  filledAt.Add(-2 * time.Second),
+			ValidatedAt: filledAt.Add(-1500 * time.Millisecond),
+			SentAt:      filledAt.Add(-time.Second),
+			FilledAt:    filledAt,
+		}
+		if _, err := db.SaveExecutionTiming(record); err != nil {
+			t.Fatalf("SaveExecutionTiming failed: %v", err)
+		}
+	}
+	// 其他交易对、缺少验证时间的记录
+	if _, err := db.SaveExecutionTiming(&ExecutionTimingRecord{
+		Symbol: "ETHUSDT", Action: "SELL", SignalAt: base, DecisionAt: base.Add(time.Minute), FilledAt: base.Add(2 * time.Minute), Paper: true,
+	}); err != nil {
+		t.Fatalf("SaveExecutionTiming failed: %v", err)
+	}
+
+	timings, err := db.GetExecutionTimings("BTCUSDT", 10)
+	if err != nil || len(timings) != 3 {
+		t.Fatalf("Expected 3 BTC timings, got %d, %v", len(timings), err)
+	}
+	if timings[0].SessionID != 3 || timings[0].Latency() != 400*time.Second {
+		t.Errorf("Newest timing = session %d latency %v, want session 3 latency 400s", timings[0].SessionID, timings[0].Latency())
+	}
+
+	stats, err := db.GetLatencyStats("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetLatencyStats failed: %v", err)
+	}
+	if stats.Count != 3 || stats.AvgFill != time.Second || stats.AvgValidation != 500*time.Millisecond {
+		t.Errorf("Unexpected stage averages: %+v", stats)
+	}
+	// 滑点与延迟完全线性相关：每分钟 6bps
+	if math.Abs(stats.Correlation-1) > 1e-9 || math.Abs(stats.BpsPerMinute-6) > 1e-9 {
+		t.Errorf("Correlation = %.4f, BpsPerMinute = %.4f; want 1 and 6", stats.Correlation, stats.BpsPerMinute)
+	}
+	counts := []int{1, 0, 1, 0, 1}
+	for i, b := range stats.Buckets {
+		if b.Count != counts[i] {
+			t.Errorf("Bucket %s count = %d, want %d", b.Label, b.Count, counts[i])
+		}
+	}
+	if stats.Buckets[4].AvgSlippageBps != 40 {
+		t.Errorf("Bucket >= 5m slippage = %.1f, want 40", stats.Buckets[4].AvgSlippageBps)
+	}
+
+	// 缺少时间戳的阶段不计入平均值
+	all, err := db.GetLatencyStats("")
+	if err != nil || all.Count != 4 || all.AvgValidation != 500*time.Millisecond {
+		t.Errorf("Unexpected stats for all symbols: %+v, %v", all, err)
+	}
+
+	empty, err := db.GetLatencyStats("SOLUSDT")
+	if err != nil || empty.Count != 0 || len(empty.Buckets) != 5 {
+		t.Errorf("Unexpected empty stats: %+v, %v", empty, err)
+	}
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LedgerEntry records the one execution allowed for a symbol on a kline
+// LedgerEntry 记录某个交易对在一根 K 线上唯一允许的一次执行
+type LedgerEntry struct {
+	Symbol        string    // 交易对（统一格式，如 BTCUSDT）/ Normalized symbol, e.g. BTCUSDT
+	Timeframe     string    // K 线周期 / Kline timeframe
+	KlineOpenTime time.Time // 被分析的 K 线开盘时间 / Open time of the analyzed kline
+	BatchID       string    // 执行所在批次 / Batch that executed it
+	Action        string    // 执行的交易动作 / Executed action
+	Result        string    // 执行结果（为空表示执行中或进程在执行中途退出）/ Execution result (empty while executing or if the process died mid-execution)
+	CreatedAt     time.Time
+}
+
+// initLedgerSchema creates the execution_ledger table if it doesn't exist
+// initLedgerSchema 创建 execution_ledger 表（如果不存在）
+func (s *Storage) initLedgerSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS execution_ledger (
+		symbol TEXT NOT NULL,
+		timeframe TEXT NOT NULL,
+		kline_open_time INTEGER NOT NULL,
+		batch_id TEXT,
+		action TEXT NOT NULL,
+		result TEXT,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (symbol, timeframe, kline_open_time)
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// ClaimExecution reserves the execution of a symbol on a kline before any order is sent
+// ClaimExecution 在发送任何订单之前预占某个交易对在一根 K 线上的执行
+//
+// Only entries are claimed: closes and reductions must always be able to execute.
+// 只预占开仓：平仓和减仓必须始终可以执行。
+//
+// It returns nil if the claim succeeded, or the existing entry if that kline was already executed
+// (for example by the same batch before a restart). The primary key makes the claim atomic, so two
+// runs can never both execute the same kline.
+// 预占成功时返回 nil；该 K 线已执行过时（例如重启前的同一批次）返回已有记录。
+// 主键保证预占是原子的，两次运行不可能同时执行同一根 K 线。
+func (s *Storage) ClaimExecution(entry *LedgerEntry) (*LedgerEntry, error) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	symbol := NormalizeSymbol(entry.Symbol)
+	result, err := s.exec(`
+	INSERT OR IGNORE INTO execution_ledger (symbol, timeframe, kline_open_time, batch_id, action, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`, symbol, entry.Timeframe, entry.KlineOpenTime.UnixMilli(), entry.BatchID, entry.Action, entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim execution: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	existing := &LedgerEntry{Symbol: symbol, Timeframe: entry.Timeframe}
+	var batchID, res sql.NullString
+	var openMs int64
+	err = s.db.QueryRow(`
+	SELECT kline_open_time, batch_id, action, result, created_at
+	FROM execution_ledger
+	WHERE symbol = ? AND timeframe = ? AND kline_open_time = ?
+	`, symbol, entry.Timeframe, entry.KlineOpenTime.UnixMilli()).Scan(&openMs, &batchID, &existing.Action, &res, &existing.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read execution ledger: %w", err)
+	}
+	existing.KlineOpenTime = time.UnixMilli(openMs)
+	existing.BatchID = batchID.String
+	existing.Result = res.String
+	return existing, nil
+}
+
+// CompleteExecution records the result of a claimed execution
+// CompleteExecution 记录已预占执行的结果
+func (s *Storage) CompleteExecution(symbol, timeframe string, klineOpenTime time.Time, result string) error {
+	_, err := s.exec(`
+	UPDATE execution_ledger SET result = ?
+	WHERE symbol = ? AND timeframe = ? AND kline_open_time = ?
+	`, result, NormalizeSymbol(symbol), timeframe, klineOpenTime.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to complete execution: %w", err)
+	}
+	return nil
+}
+
+// ReleaseExecution removes the claim of an execution that failed, so the kline can be retried
+// ReleaseExecution 删除执行失败的预占，使该 K 线可以重试
+func (s *Storage) ReleaseExecution(symbol, timeframe string, klineOpenTime time.Time) error {
+	_, err := s.exec(`
+	DELETE FROM execution_ledger
+	WHERE symbol = ? AND timeframe = ? AND kline_open_time = ?
+	`, NormalizeSymbol(symbol), timeframe, klineOpenTime.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to release execution: %w", err)
+	}
+	return nil
+}
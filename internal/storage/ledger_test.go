@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClaimExecution(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	kline := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	existing, err := db.ClaimExecution(&LedgerEntry{Symbol: "BTC/USDT", Timeframe: "1h", KlineOpenTime: kline, BatchID: "batch-1", Action: "BUY"})
+	if err != nil || existing != nil {
+		t.Fatalf("Expected the first claim to succeed, got %+v, %v", existing, err)
+	}
+	if err := db.CompleteExecution("BTCUSDT", "1h", kline, "✅ 成功执行 BUY"); err != nil {
+		t.Fatalf("CompleteExecution failed: %v", err)
+	}
+
+	// The same kline after a restart is refused, whatever the symbol format
+	// 重启后同一根 K 线被拒绝，与交易对格式无关
+	existing, err = db.ClaimExecution(&LedgerEntry{Symbol: "BTCUSDT", Timeframe: "1h", KlineOpenTime: kline, BatchID: "batch-2", Action: "BUY"})
+	if err != nil {
+		t.Fatalf("ClaimExecution failed: %v", err)
+	}
+	if existing == nil || existing.BatchID != "batch-1" || existing.Result != "✅ 成功执行 BUY" || !existing.KlineOpenTime.Equal(kline) {
+		t.Errorf("Expected the batch-1 entry, got %+v", existing)
+	}
+
+	// A released claim can be taken again
+	// 释放后的预占可以再次预占
+	failed := kline.Add(-time.Hour)
+	if existing, err := db.ClaimExecution(&LedgerEntry{Symbol: "BTCUSDT", Timeframe: "1h", KlineOpenTime: failed, BatchID: "batch-1", Action: "SELL"}); err != nil || existing != nil {
+		t.Fatalf("Expected the claim to succeed, got %+v, %v", existing, err)
+	}
+	if err := db.ReleaseExecution("BTC/USDT", "1h", failed); err != nil {
+		t.Fatalf("ReleaseExecution failed: %v", err)
+	}
+	if existing, err := db.ClaimExecution(&LedgerEntry{Symbol: "BTCUSDT", Timeframe: "1h", KlineOpenTime: failed, BatchID: "batch-2", Action: "SELL"}); err != nil || existing != nil {
+		t.Errorf("Expected the released kline to be claimable, got %+v, %v", existing, err)
+	}
+
+	// Another kline, timeframe or symbol is a separate execution
+	// 其他 K 线、周期或交易对是独立的执行
+	for _, entry := range []*LedgerEntry{
+		{Symbol: "BTC/USDT", Timeframe: "1h", KlineOpenTime: kline.Add(time.Hour), Action: "SELL"},
+		{Symbol: "BTC/USDT", Timeframe: "4h", KlineOpenTime: kline, Action: "BUY"},
+		{Symbol: "ETH/USDT", Timeframe: "1h", KlineOpenTime: kline, Action: "BUY"},
+	} {
+		if existing, err := db.ClaimExecution(entry); err != nil || existing != nil {
+			t.Errorf("Expected claim %+v to succeed, got %+v, %v", entry, existing, err)
+		}
+	}
+
+	// Concurrent claims: exactly one wins
+	// 并发预占：只有一个成功
+	next := kline.Add(2 * time.Hour)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wins := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			existing, err := db.ClaimExecution(&LedgerEntry{Symbol: "SOL/USDT", Timeframe: "1h", KlineOpenTime: next, Action: "BUY"})
+			if err != nil {
+				t.Errorf("ClaimExecution failed: %v", err)
+				return
+			}
+			if existing == nil {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Errorf("Expected exactly one concurrent claim to win, got %d", wins)
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LLMUsage is the token usage and cost of one model, either within a batch or summed over a period
+// LLMUsage 表示单个模型的 token 用量和费用（单个批次内或某段时间的合计）
+type LLMUsage struct {
+	BatchID          string    `json:"batch_id,omitempty"` // 批次 ID（按时间段汇总时为空）/ Batch ID (empty in period summaries)
+	Model            string    `json:"model"`              // 模型名称 / Model name
+	Calls            int       `json:"calls"`              // 调用次数 / Number of calls
+	PromptTokens     int       `json:"prompt_tokens"`      // 输入 token / Prompt tokens
+	CompletionTokens int       `json:"completion_tokens"`  // 输出 token / Completion tokens
+	CostUSD          float64   `json:"cost_usd"`           // 按 LLM_PRICES 估算的费用（美元）/ Cost estimated from LLM_PRICES (USD)
+	CreatedAt        time.Time `json:"created_at"`         // 批次首次调用时间（汇总时为空）/ First call of the batch (zero in summaries)
+	UpdatedAt        time.Time `json:"updated_at"`         // 批次最近调用时间（汇总时为空）/ Latest call of the batch (zero in summaries)
+}
+
+// TotalTokens returns prompt plus completion tokens
+// TotalTokens 返回输入与输出 token 之和
+func (u *LLMUsage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// MonthStart returns midnight of the first day of t's month, when the monthly token budget resets
+// MonthStart 返回 t 所在月份第一天的零点，即月度 token 预算重置的时间
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// initLLMUsageSchema creates the llm_usage table if it doesn't exist
+// initLLMUsageSchema 创建 llm_usage 表（如果不存在）
+func (s *Storage) initLLMUsageSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS llm_usage (
+		batch_id TEXT NOT NULL,
+		model TEXT NOT NULL,
+		calls INTEGER NOT NULL DEFAULT 0,
+		prompt_tokens INTEGER NOT NULL DEFAULT 0,
+		completion_tokens INTEGER NOT NULL DEFAULT 0,
+		cost_usd REAL NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (batch_id, model)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_llm_usage_created_at ON llm_usage(created_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// AddLLMUsage adds one call's tokens and cost to the batch's row for the model
+// AddLLMUsage 将一次调用的 token 和费用累加到该批次对应模型的记录
+func (s *Storage) AddLLMUsage(batchID, model string, promptTokens, completionTokens int, costUSD float64) error {
+	now := time.Now()
+	_, err := s.exec(`
+	INSERT INTO llm_usage (
+		batch_id, model, calls, prompt_tokens, completion_tokens, cost_usd, created_at, updated_at
+	) VALUES (?, ?, 1, ?, ?, ?, ?, ?)
+	ON CONFLICT(batch_id, model) DO UPDATE SET
+		calls = calls + 1,
+		prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+		completion_tokens = completion_tokens + excluded.completion_tokens,
+		cost_usd = cost_usd + excluded.cost_usd,
+		updated_at = excluded.updated_at
+	`, batchID, model, promptTokens, completionTokens, costUSD, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save llm usage: %w", err)
+	}
+	return nil
+}
+
+// GetLLMUsageByModel sums the usage of every model since the given time, most expensive first
+// GetLLMUsageByModel 汇总指定时间以来各模型的用量，按费用从高到低排列
+func (s *Storage) GetLLMUsageByModel(since time.Time) ([]*LLMUsage, error) {
+	rows, err := s.db.Query(`
+	SELECT model, SUM(calls), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost_usd)
+	FROM llm_usage
+	WHERE created_at >= ?
+	GROUP BY model
+	ORDER BY SUM(cost_usd) DESC, SUM(prompt_tokens + completion_tokens) DESC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query llm usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*LLMUsage
+	for rows.Next() {
+		u := &LLMUsage{}
+		if err := rows.Scan(&u.Model, &u.Calls, &u.PromptTokens, &u.CompletionTokens, &u.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan llm usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// GetLLMUsageByBatch returns the per-model usage of the latest batches, newest first
+// GetLLMUsageByBatch 返回最近批次中各模型的用量，按时间倒序
+func (s *Storage) GetLLMUsageByBatch(limit int) ([]*LLMUsage, error) {
+	rows, err := s.db.Query(`
+	SELECT batch_id, model, calls, prompt_tokens, completion_tokens, cost_usd, created_at, updated_at
+	FROM llm_usage
+	ORDER BY created_at DESC, model
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query llm usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*LLMUsage
+	for rows.Next() {
+		u := &LLMUsage{}
+		if err := rows.Scan(&u.BatchID, &u.Model, &u.Calls, &u.PromptTokens, &u.CompletionTokens, &u.CostUSD, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan llm usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// GetLLMTokensSince returns the tokens used by all models since the given time
+// GetLLMTokensSince 返回指定时间以来所有模型使用的 token 总数
+func (s *Storage) GetLLMTokensSince(since time.Time) (int, error) {
+	var total sql.NullInt64
+	if err := s.db.QueryRow(`
+	SELECT SUM(prompt_tokens + completion_tokens) FROM llm_usage WHERE created_at >= ?
+	`, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum llm usage: %w", err)
+	}
+	return int(total.Int64), nil
+}
+
+// LLMCostSummary is the LLM usage over a period with its per-model breakdown
+// LLMCostSummary 表示某段时间的 LLM 用量及各模型明细
+type LLMCostSummary struct {
+	Since            time.Time   `json:"since"`             // 统计起始时间 / Start of the period
+	Calls            int         `json:"calls"`             // 调用次数 / Number of calls
+	PromptTokens     int         `json:"prompt_tokens"`     // 输入 token / Prompt tokens
+	CompletionTokens int         `json:"completion_tokens"` // 输出 token / Completion tokens
+	CostUSD          float64     `json:"cost_usd"`          // 估算费用（美元）/ Estimated cost (USD)
+	Models           []*LLMUsage `json:"models"`            // 各模型用量，按费用从高到低 / Per-model usage, most expensive first
+}
+
+// TotalTokens returns prompt plus completion tokens
+// TotalTokens 返回输入与输出 token 之和
+func (s *LLMCostSummary) TotalTokens() int {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+// GetLLMCostSummary sums the LLM usage since the given time
+// GetLLMCostSummary 汇总指定时间以来的 LLM 用量
+func (s *Storage) GetLLMCostSummary(since time.Time) (*LLMCostSummary, error) {
+	models, err := s.GetLLMUsageByModel(since)
+	if err != nil {
+		return nil, err
+	}
+	summary := &LLMCostSummary{Since: since, Models: models}
+	for _, u := range models {
+		summary.Calls += u.Calls
+		summary.PromptTokens += u.PromptTokens
+		summary.CompletionTokens += u.CompletionTokens
+		summary.CostUSD += u.CostUSD
+	}
+	return summary, nil
+}
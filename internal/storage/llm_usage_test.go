@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLLMUsage(t *testing.T) {
+	tmpDB := "./test_llm_usage.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Now().Add(-time.Minute)
+
+	// 同一批次同一模型的调用累加到一行
+	calls := []struct {
+		batch, model       string
+		prompt, completion int
+		cost               float64
+	}{
+		{"batch-1", "gpt-4o-mini", 1000, 200, 0.01},
+		{"batch-1", "gpt-4o-mini", 500, 100, 0.005},
+		{"batch-1", "gpt-4o", 2000, 300, 0.5},
+		{"batch-2", "gpt-4o-mini", 800, 150, 0.008},
+	}
+	for _, c := range calls {
+		if err := db.AddLLMUsage(c.batch, c.model, c.prompt, c.completion, c.cost); err != nil {
+			t.Fatalf("AddLLMUsage failed: %v", err)
+		}
+	}
+
+	batches, err := db.GetLLMUsageByBatch(10)
+	if err != nil {
+		t.Fatalf("GetLLMUsageByBatch failed: %v", err)
+	}
+	if len(batches) != 3 {
+		t.Fatalf("Expected 3 batch/model rows, got %d", len(batches))
+	}
+	for _, u := range batches {
+		if u.BatchID == "batch-1" && u.Model == "gpt-4o-mini" {
+			if u.Calls != 2 || u.PromptTokens != 1500 || u.CompletionTokens != 300 || u.TotalTokens() != 1800 {
+				t.Errorf("Unexpected accumulated usage: %+v", u)
+			}
+		}
+	}
+
+	byModel, err := db.GetLLMUsageByModel(start)
+	if err != nil {
+		t.Fatalf("GetLLMUsageByModel failed: %v", err)
+	}
+	if len(byModel) != 2 || byModel[0].Model != "gpt-4o" {
+		t.Fatalf("Expected the most expensive model first, got %+v", byModel)
+	}
+	if mini := byModel[1]; mini.Calls != 3 || mini.TotalTokens() != 2750 {
+		t.Errorf("Unexpected gpt-4o-mini summary: %+v", mini)
+	}
+
+	summary, err := db.GetLLMCostSummary(start)
+	if err != nil {
+		t.Fatalf("GetLLMCostSummary failed: %v", err)
+	}
+	if summary.Calls != 4 || summary.TotalTokens() != 5050 || summary.CostUSD < 0.5229 || summary.CostUSD > 0.5231 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	total, err := db.GetLLMTokensSince(start)
+	if err != nil {
+		t.Fatalf("GetLLMTokensSince failed: %v", err)
+	}
+	if total != 5050 {
+		t.Errorf("Expected 5050 tokens, got %d", total)
+	}
+
+	// 统计时间之后没有记录
+	if total, err := db.GetLLMTokensSince(time.Now().Add(time.Hour)); err != nil || total != 0 {
+		t.Errorf("Expected 0 tokens in the future, got %d (%v)", total, err)
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Note targets
+// 备注对象类型
+const (
+	NoteTargetPosition = "position" // 持仓（target_id 为持仓 ID）/ Position (target_id is the position ID)
+	NoteTargetSession  = "session"  // 会话（target_id 为会话 ID）/ Session (target_id is the session ID)
+)
+
+// Note is a free-text operator annotation attached to a position or session
+// Note 是操作员附加到持仓或会话上的自由文本备注
+type Note struct {
+	ID         int64
+	TargetType string // position / session
+	TargetID   string // 持仓 ID 或会话 ID / Position ID or session ID
+	Text       string
+	CreatedAt  time.Time
+}
+
+// initNotesSchema creates the notes table if it doesn't exist
+// initNotesSchema 创建 notes 表（如果不存在）
+func (s *Storage) initNotesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS notes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_type TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notes_target ON notes(target_type, target_id);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// AddNote stores a note and returns its ID
+// AddNote 保存备注并返回其 ID
+func (s *Storage) AddNote(note *Note) (int64, error) {
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT INTO notes (target_type, target_id, text, created_at) VALUES (?, ?, ?, ?)
+	`, note.TargetType, note.TargetID, note.Text, note.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save note: %w", err)
+	}
+	note.ID, err = result.LastInsertId()
+	return note.ID, err
+}
+
+// DeleteNote removes a note, returning false when it doesn't exist
+// DeleteNote 删除备注，不存在时返回 false
+func (s *Storage) DeleteNote(id int64) (bool, error) {
+	result, err := s.exec(`DELETE FROM notes WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete note: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetNotes retrieves the notes of a target, oldest first
+// GetNotes 获取对象的备注，按时间正序
+func (s *Storage) GetNotes(targetType, targetID string) ([]*Note, error) {
+	notes, err := s.GetNotesFor(targetType, []string{targetID})
+	if err != nil {
+		return nil, err
+	}
+	return notes[targetID], nil
+}
+
+// GetNotesFor retrieves the notes of several targets of one type, keyed by target ID
+// GetNotesFor 获取同一类型多个对象的备注，按对象 ID 分组
+func (s *Storage) GetNotesFor(targetType string, targetIDs []string) (map[string][]*Note, error) {
+	notes := make(map[string][]*Note)
+	if len(targetIDs) == 0 {
+		return notes, nil
+	}
+
+	args := make([]interface{}, 0, len(targetIDs)+1)
+	args = append(args, targetType)
+	for _, id := range targetIDs {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(targetIDs)), ",")
+
+	rows, err := s.db.Query(`
+	SELECT id, target_type, target_id, text, created_at
+	FROM notes
+	WHERE target_type = ? AND target_id IN (`+placeholders+`)
+	ORDER BY created_at ASC, id ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note := &Note{}
+		if err := rows.Scan(&note.ID, &note.TargetType, &note.TargetID, &note.Text, &note.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes[note.TargetID] = append(notes[note.TargetID], note)
+	}
+	return notes, rows.Err()
+}
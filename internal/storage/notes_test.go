@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNotes(t *testing.T) {
+	tmpDB := "./test_notes.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	notes := []*Note{
+		{TargetType: NoteTargetPosition, TargetID: "BTCUSDT-1", Text: "CPI 公布前手动放宽止损", CreatedAt: now.Add(-time.Hour)},
+		{TargetType: NoteTargetPosition, TargetID: "BTCUSDT-1", Text: "止损已恢复", CreatedAt: now},
+		{TargetType: NoteTargetPosition, TargetID: "ETHUSDT-2", Text: "观察资金费率", CreatedAt: now},
+		{TargetType: NoteTargetSession, TargetID: "BTCUSDT-1", Text: "同 ID 的会话备注不应混入", CreatedAt: now},
+	}
+	for _, note := range notes {
+		if _, err := db.AddNote(note); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+
+	got, err := db.GetNotes(NoteTargetPosition, "BTCUSDT-1")
+	if err != nil {
+		t.Fatalf("GetNotes failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Text != "CPI 公布前手动放宽止损" || got[1].Text != "止损已恢复" {
+		t.Fatalf("Expected 2 position notes oldest first, got %+v", got)
+	}
+
+	byTarget, err := db.GetNotesFor(NoteTargetPosition, []string{"BTCUSDT-1", "ETHUSDT-2", "SOLUSDT-3"})
+	if err != nil {
+		t.Fatalf("GetNotesFor failed: %v", err)
+	}
+	if len(byTarget["BTCUSDT-1"]) != 2 || len(byTarget["ETHUSDT-2"]) != 1 || len(byTarget["SOLUSDT-3"]) != 0 {
+		t.Errorf("Unexpected notes by target: %+v", byTarget)
+	}
+
+	deleted, err := db.DeleteNote(notes[0].ID)
+	if err != nil || !deleted {
+		t.Fatalf("DeleteNote failed: %v", err)
+	}
+	if deleted, _ := db.DeleteNote(notes[0].ID); deleted {
+		t.Error("Deleting a missing note should report false")
+	}
+	if got, _ := db.GetNotes(NoteTargetPosition, "BTCUSDT-1"); len(got) != 1 {
+		t.Errorf("Expected 1 note after delete, got %d", len(got))
+	}
+}
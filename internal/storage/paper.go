@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PaperAccount represents the simulated futures wallet used by paper trading
+// PaperAccount 表示模拟盘使用的模拟合约钱包
+type PaperAccount struct {
+	WalletBalance float64   // 钱包余额（含已实现盈亏、手续费、资金费）/ Wallet balance (realized PnL, fees and funding included)
+	TotalFees     float64   // 累计手续费 / Accumulated trading fees
+	TotalFunding  float64   // 累计资金费（正数为支付）/ Accumulated funding (positive = paid)
+	UpdatedAt     time.Time // 更新时间 / Last update time
+}
+
+// PaperPosition represents a simulated open position
+// PaperPosition 表示一个模拟持仓
+type PaperPosition struct {
+	Symbol          string    // 交易对（币安格式）/ Trading pair (Binance format)
+	Side            string    // long/short
+	Quantity        float64   // 持仓数量 / Quantity
+	EntryPrice      float64   // 开仓均价 / Average entry price
+	Leverage        int       // 杠杆倍数 / Leverage
+	Margin          float64   // 占用保证金 / Initial margin
+	OpenedAt        time.Time // 开仓时间 / Open time
+	LastFundingTime time.Time // 最后一次结算资金费的时间 / Last funding settlement time
+}
+
+// PaperOrder represents a simulated order (market fills and resting stop-market orders)
+// PaperOrder 表示一个模拟订单（市价成交单和挂单中的止损市价单）
+type PaperOrder struct {
+	ID         int64     // 订单 ID / Order ID
+	Symbol     string    // 交易对 / Trading pair
+	Side       string    // BUY/SELL
+	Type       string    // MARKET/STOP_MARKET/TAKE_PROFIT_MARKET
+	Quantity   float64   // 数量 / Quantity
+	StopPrice  float64   // 触发价 / Stop price
+	AvgPrice   float64   // 成交均价 / Average fill price
+	Fee        float64   // 手续费 / Fee
+	Status     string    // NEW/FILLED/CANCELED
+	ReduceOnly bool      // 只减仓 / Reduce only
+	CreatedAt  time.Time // 创建时间 / Created at
+	UpdatedAt  time.Time // 更新时间 / Updated at
+	CheckedAt  time.Time // 触发检查进度（K 线已检查到的时间）/ Trigger check progress (klines checked up to)
+}
+
+// initPaperSchema creates paper trading tables if they don't exist
+// initPaperSchema 创建模拟盘相关表（如果不存在）
+func (s *Storage) initPaperSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS paper_account (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		wallet_balance REAL NOT NULL,
+		total_fees REAL DEFAULT 0,
+		total_funding REAL DEFAULT 0,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS paper_positions (
+		symbol TEXT PRIMARY KEY,
+		side TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		entry_price REAL NOT NULL,
+		leverage INTEGER NOT NULL,
+		margin REAL NOT NULL,
+		opened_at DATETIME NOT NULL,
+		last_funding_time DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS paper_leverage (
+		symbol TEXT PRIMARY KEY,
+		leverage INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS paper_orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		type TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		stop_price REAL DEFAULT 0,
+		avg_price REAL DEFAULT 0,
+		fee REAL DEFAULT 0,
+		status TEXT NOT NULL,
+		reduce_only BOOLEAN DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		checked_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_paper_orders_symbol_status ON paper_orders(symbol, status);
+	`
+
+	_, err := s.exec(schema)
+	return err
+}
+
+// GetPaperAccount retrieves the paper account, creating it with the initial balance on first use
+// GetPaperAccount 获取模拟账户，首次使用时以初始余额创建
+func (s *Storage) GetPaperAccount(initialBalance float64) (*PaperAccount, error) {
+	_, err := s.exec(`
+	INSERT OR IGNORE INTO paper_account (id, wallet_balance, total_fees, total_funding, updated_at)
+	VALUES (1, ?, 0, 0, ?)
+	`, initialBalance, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to init paper account: %w", err)
+	}
+
+	account := &PaperAccount{}
+	err = s.db.QueryRow(`
+	SELECT wallet_balance, total_fees, total_funding, updated_at
+	FROM paper_account WHERE id = 1
+	`).Scan(&account.WalletBalance, &account.TotalFees, &account.TotalFunding, &account.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper account: %w", err)
+	}
+
+	return account, nil
+}
+
+// UpdatePaperAccount persists the paper account
+// UpdatePaperAccount 保存模拟账户
+func (s *Storage) UpdatePaperAccount(account *PaperAccount) error {
+	account.UpdatedAt = time.Now()
+	_, err := s.exec(`
+	UPDATE paper_account SET wallet_balance = ?, total_fees = ?, total_funding = ?, updated_at = ?
+	WHERE id = 1
+	`, account.WalletBalance, account.TotalFees, account.TotalFunding, account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update paper account: %w", err)
+	}
+	return nil
+}
+
+// GetPaperPosition retrieves the simulated position for a symbol (nil if flat)
+// GetPaperPosition 获取交易对的模拟持仓（无持仓返回 nil）
+func (s *Storage) GetPaperPosition(symbol string) (*PaperPosition, error) {
+	pos := &PaperPosition{}
+	err := s.db.QueryRow(`
+	SELECT symbol, side, quantity, entry_price, leverage, margin, opened_at, last_funding_time
+	FROM paper_positions WHERE symbol = ?
+	`, symbol).Scan(
+		&pos.Symbol, &pos.Side, &pos.Quantity, &pos.EntryPrice,
+		&pos.Leverage, &pos.Margin, &pos.OpenedAt, &pos.LastFundingTime,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil // No position / 无持仓
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper position: %w", err)
+	}
+
+	return pos, nil
+}
+
+// GetPaperPositions retrieves all simulated positions
+// GetPaperPositions 获取所有模拟持仓
+func (s *Storage) GetPaperPositions() ([]*PaperPosition, error) {
+	rows, err := s.db.Query(`
+	SELECT symbol, side, quantity, entry_price, leverage, margin, opened_at, last_funding_time
+	FROM paper_positions ORDER BY opened_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paper positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []*PaperPosition
+	for rows.Next() {
+		pos := &PaperPosition{}
+		if err := rows.Scan(
+			&pos.Symbol, &pos.Side, &pos.Quantity, &pos.EntryPrice,
+			&pos.Leverage, &pos.Margin, &pos.OpenedAt, &pos.LastFundingTime,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan paper position: %w", err)
+		}
+		positions = append(positions, pos)
+	}
+
+	return positions, rows.Err()
+}
+
+// SavePaperPosition inserts or replaces the simulated position for a symbol
+// SavePaperPosition 插入或替换交易对的模拟持仓
+func (s *Storage) SavePaperPosition(pos *PaperPosition) error {
+	_, err := s.exec(`
+	INSERT OR REPLACE INTO paper_positions (
+		symbol, side, quantity, entry_price, leverage, margin, opened_at, last_funding_time
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		pos.Symbol, pos.Side, pos.Quantity, pos.EntryPrice,
+		pos.Leverage, pos.Margin, pos.OpenedAt, pos.LastFundingTime,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save paper position: %w", err)
+	}
+	return nil
+}
+
+// DeletePaperPosition removes the simulated position for a symbol
+// DeletePaperPosition 删除交易对的模拟持仓
+func (s *Storage) DeletePaperPosition(symbol string) error {
+	if _, err := s.exec(`DELETE FROM paper_positions WHERE symbol = ?`, symbol); err != nil {
+		return fmt.Errorf("failed to delete paper position: %w", err)
+	}
+	return nil
+}
+
+// GetPaperLeverage returns the simulated leverage setting for a symbol (0 if never set)
+// GetPaperLeverage 返回交易对的模拟杠杆设置（从未设置返回 0）
+func (s *Storage) GetPaperLeverage(symbol string) (int, error) {
+	var leverage int
+	err := s.db.QueryRow(`SELECT leverage FROM paper_leverage WHERE symbol = ?`, symbol).Scan(&leverage)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get paper leverage: %w", err)
+	}
+	return leverage, nil
+}
+
+// SetPaperLeverage stores the simulated leverage setting for a symbol
+// SetPaperLeverage 保存交易对的模拟杠杆设置
+func (s *Storage) SetPaperLeverage(symbol string, leverage int) error {
+	_, err := s.exec(`INSERT OR REPLACE INTO paper_leverage (symbol, leverage) VALUES (?, ?)`, symbol, leverage)
+	if err != nil {
+		return fmt.Errorf("failed to set paper leverage: %w", err)
+	}
+	return nil
+}
+
+// SavePaperOrder inserts a simulated order and returns its ID
+// SavePaperOrder 插入模拟订单并返回订单 ID
+func (s *Storage) SavePaperOrder(order *PaperOrder) (int64, error) {
+	now := time.Now()
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = now
+	}
+	order.UpdatedAt = now
+	if order.CheckedAt.IsZero() {
+		order.CheckedAt = order.CreatedAt
+	}
+
+	result, err := s.exec(`
+	INSERT INTO paper_orders (
+		symbol, side, type, quantity, stop_price, avg_price, fee, status,
+		reduce_only, created_at, updated_at, checked_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		order.Symbol, order.Side, order.Type, order.Quantity, order.StopPrice, order.AvgPrice, order.Fee,
+		order.Status, order.ReduceOnly, order.CreatedAt, order.UpdatedAt, order.CheckedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save paper order: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	order.ID = id
+
+	return id, nil
+}
+
+// UpdatePaperOrder updates the mutable fields of a simulated order
+// UpdatePaperOrder 更新模拟订单的可变字段
+func (s *Storage) UpdatePaperOrder(order *PaperOrder) error {
+	order.UpdatedAt = time.Now()
+	_, err := s.exec(`
+	UPDATE paper_orders SET avg_price = ?, fee = ?, status = ?, updated_at = ?, checked_at = ?
+	WHERE id = ?
+	`, order.AvgPrice, order.Fee, order.Status, order.UpdatedAt, order.CheckedAt, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update paper order: %w", err)
+	}
+	return nil
+}
+
+// GetPaperOrder retrieves a simulated order by ID (nil if not found)
+// GetPaperOrder 根据 ID 获取模拟订单（未找到返回 nil）
+func (s *Storage) GetPaperOrder(id int64) (*PaperOrder, error) {
+	order := &PaperOrder{}
+	err := s.db.QueryRow(`
+	SELECT id, symbol, side, type, quantity, stop_price, avg_price, fee, status,
+		   reduce_only, created_at, updated_at, checked_at
+	FROM paper_orders WHERE id = ?
+	`, id).Scan(
+		&order.ID, &order.Symbol, &order.Side, &order.Type, &order.Quantity, &order.StopPrice,
+		&order.AvgPrice, &order.Fee, &order.Status, &order.ReduceOnly,
+		&order.CreatedAt, &order.UpdatedAt, &order.CheckedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOpenPaperOrders retrieves resting (NEW) simulated orders for a symbol
+// GetOpenPaperOrders 获取交易对挂单中（NEW）的模拟订单
+func (s *Storage) GetOpenPaperOrders(symbol string) ([]*PaperOrder, error) {
+	rows, err := s.db.Query(`
+	SELECT id, symbol, side, type, quantity, stop_price, avg_price, fee, status,
+		   reduce_only, created_at, updated_at, checked_at
+	FROM paper_orders
+	WHERE symbol = ? AND status = 'NEW'
+	ORDER BY created_at ASC
+	`, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paper orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*PaperOrder
+	for rows.Next() {
+		order := &PaperOrder{}
+		if err := rows.Scan(
+			&order.ID, &order.Symbol, &order.Side, &order.Type, &order.Quantity, &order.StopPrice,
+			&order.AvgPrice, &order.Fee, &order.Status, &order.ReduceOnly,
+			&order.CreatedAt, &order.UpdatedAt, &order.CheckedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan paper order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
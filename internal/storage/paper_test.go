@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPaperAccountInit(t *testing.T) {
+	tmpDB := "./test_paper_account.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 首次获取时以初始余额创建
+	account, err := db.GetPaperAccount(10000)
+	if err != nil {
+		t.Fatalf("GetPaperAccount failed: %v", err)
+	}
+	if account.WalletBalance != 10000 {
+		t.Errorf("Expected wallet balance 10000, got %.2f", account.WalletBalance)
+	}
+
+	// 更新后再次获取不应被初始余额覆盖
+	account.WalletBalance = 9876.5
+	account.TotalFees = 1.5
+	if err := db.UpdatePaperAccount(account); err != nil {
+		t.Fatalf("UpdatePaperAccount failed: %v", err)
+	}
+
+	account, err = db.GetPaperAccount(10000)
+	if err != nil {
+		t.Fatalf("GetPaperAccount failed: %v", err)
+	}
+	if account.WalletBalance != 9876.5 {
+		t.Errorf("Expected wallet balance 9876.5, got %.2f", account.WalletBalance)
+	}
+	if account.TotalFees != 1.5 {
+		t.Errorf("Expected total fees 1.5, got %.2f", account.TotalFees)
+	}
+}
+
+func TestPaperPositionLifecycle(t *testing.T) {
+	tmpDB := "./test_paper_positions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	pos, err := db.GetPaperPosition("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetPaperPosition failed: %v", err)
+	}
+	if pos != nil {
+		t.Fatal("Expected no position")
+	}
+
+	now := time.Now()
+	if err := db.SavePaperPosition(&PaperPosition{
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		Quantity:        0.01,
+		EntryPrice:      50000,
+		Leverage:        10,
+		Margin:          50,
+		OpenedAt:        now,
+		LastFundingTime: now,
+	}); err != nil {
+		t.Fatalf("SavePaperPosition failed: %v", err)
+	}
+
+	pos, err = db.GetPaperPosition("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetPaperPosition failed: %v", err)
+	}
+	if pos == nil || pos.Side != "long" || pos.Quantity != 0.01 || pos.Leverage != 10 {
+		t.Fatalf("Unexpected position: %+v", pos)
+	}
+
+	positions, err := db.GetPaperPositions()
+	if err != nil {
+		t.Fatalf("GetPaperPositions failed: %v", err)
+	}
+	if len(positions) != 1 {
+		t.Errorf("Expected 1 position, got %d", len(positions))
+	}
+
+	if err := db.DeletePaperPosition("BTCUSDT"); err != nil {
+		t.Fatalf("DeletePaperPosition failed: %v", err)
+	}
+	pos, _ = db.GetPaperPosition("BTCUSDT")
+	if pos != nil {
+		t.Error("Position should be deleted")
+	}
+
+	// 杠杆设置
+	leverage, err := db.GetPaperLeverage("BTCUSDT")
+	if err != nil || leverage != 0 {
+		t.Errorf("Expected unset leverage 0, got %d (err=%v)", leverage, err)
+	}
+	if err := db.SetPaperLeverage("BTCUSDT", 20); err != nil {
+		t.Fatalf("SetPaperLeverage failed: %v", err)
+	}
+	leverage, _ = db.GetPaperLeverage("BTCUSDT")
+	if leverage != 20 {
+		t.Errorf("Expected leverage 20, got %d", leverage)
+	}
+}
+
+func TestPaperOrders(t *testing.T) {
+	tmpDB := "./test_paper_orders.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	order := &PaperOrder{
+		Symbol:     "BTCUSDT",
+		Side:       "SELL",
+		Type:       "STOP_MARKET",
+		Quantity:   0.01,
+		StopPrice:  48000,
+		Status:     "NEW",
+		ReduceOnly: true,
+	}
+	id, err := db.SavePaperOrder(order)
+	if err != nil {
+		t.Fatalf("SavePaperOrder failed: %v", err)
+	}
+	if id <= 0 {
+		t.Fatalf("Order ID should be positive, got %d", id)
+	}
+
+	open, err := db.GetOpenPaperOrders("BTCUSDT")
+	if err != nil {
+		t.Fatalf("GetOpenPaperOrders failed: %v", err)
+	}
+	if len(open) != 1 || open[0].StopPrice != 48000 || !open[0].ReduceOnly {
+		t.Fatalf("Unexpected open orders: %+v", open)
+	}
+
+	// 成交后不再出现在挂单列表中
+	order.Status = "FILLED"
+	order.AvgPrice = 47990
+	if err := db.UpdatePaperOrder(order); err != nil {
+		t.Fatalf("UpdatePaperOrder failed: %v", err)
+	}
+
+	open, _ = db.GetOpenPaperOrders("BTCUSDT")
+	if len(open) != 0 {
+		t.Errorf("Expected no open orders, got %d", len(open))
+	}
+
+	retrieved, err := db.GetPaperOrder(id)
+	if err != nil {
+		t.Fatalf("GetPaperOrder failed: %v", err)
+	}
+	if retrieved.Status != "FILLED" || retrieved.AvgPrice != 47990 {
+		t.Errorf("Unexpected order: %+v", retrieved)
+	}
+
+	missing, err := db.GetPaperOrder(id + 100)
+	if err != nil || missing != nil {
+		t.Errorf("Expected nil for unknown order, got %+v (err=%v)", missing, err)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PartialTakeProfit holds the partial take-profit plan and progress of a position
+// PartialTakeProfit 保存持仓的分批止盈计划和执行进度
+type PartialTakeProfit struct {
+	PositionID     string  // 持仓 ID / Position ID
+	TargetPrice    float64 // 分批止盈目标价（0 表示未设置）/ Target price (0 = not set)
+	ClosePercent   float64 // 到达目标时平仓比例 0-100 / Percent to close at target 0-100
+	Executed       bool    // 是否已执行 / Whether executed
+	ClosedQuantity float64 // 已分批平仓数量 / Quantity closed by partial take-profit
+	RealizedPnL    float64 // 分批平仓已实现盈亏 / Realized PnL from partial closes
+	Quantity       float64 // 剩余持仓数量 / Remaining position quantity
+}
+
+// initPartialTPSchema adds partial take-profit columns to positions
+// initPartialTPSchema 为 positions 表添加分批止盈字段
+func (s *Storage) initPartialTPSchema() {
+	columns := []string{
+		"partial_tp_price REAL DEFAULT 0",
+		"partial_tp_percent REAL DEFAULT 0",
+		"partial_tp_executed BOOLEAN DEFAULT 0",
+		"partial_closed_qty REAL DEFAULT 0",
+		"partial_realized_pnl REAL DEFAULT 0",
+	}
+
+	// Run each ALTER separately so one existing column doesn't skip the rest
+	// 逐条执行 ALTER，避免某个字段已存在导致后续字段被跳过
+	for _, column := range columns {
+		s.exec("ALTER TABLE positions ADD COLUMN " + column)
+	}
+}
+
+// SavePartialTakeProfit stores the partial take-profit state and remaining quantity of a position
+// SavePartialTakeProfit 保存持仓的分批止盈状态和剩余数量
+func (s *Storage) SavePartialTakeProfit(pt *PartialTakeProfit) error {
+	_, err := s.exec(`
+	UPDATE positions SET
+		partial_tp_price = ?, partial_tp_percent = ?, partial_tp_executed = ?,
+		partial_closed_qty = ?, partial_realized_pnl = ?, quantity = ?
+	WHERE id = ?
+	`,
+		pt.TargetPrice, pt.ClosePercent, pt.Executed,
+		pt.ClosedQuantity, pt.RealizedPnL, pt.Quantity,
+		pt.PositionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save partial take-profit: %w", err)
+	}
+	return nil
+}
+
+// GetPartialTakeProfit retrieves the partial take-profit state of a position (nil if not found)
+// GetPartialTakeProfit 获取持仓的分批止盈状态（不存在返回 nil）
+func (s *Storage) GetPartialTakeProfit(positionID string) (*PartialTakeProfit, error) {
+	var targetPrice, closePercent, closedQty, realizedPnL sql.NullFloat64
+	var executed sql.NullBool
+
+	pt := &PartialTakeProfit{PositionID: positionID}
+	err := s.db.QueryRow(`
+	SELECT partial_tp_price, partial_tp_percent, partial_tp_executed,
+		   partial_closed_qty, partial_realized_pnl, quantity
+	FROM positions WHERE id = ?
+	`, positionID).Scan(&targetPrice, &closePercent, &executed, &closedQty, &realizedPnL, &pt.Quantity)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partial take-profit: %w", err)
+	}
+
+	pt.TargetPrice = targetPrice.Float64
+	pt.ClosePercent = closePercent.Float64
+	pt.Executed = executed.Bool
+	pt.ClosedQuantity = closedQty.Float64
+	pt.RealizedPnL = realizedPnL.Float64
+	return pt, nil
+}
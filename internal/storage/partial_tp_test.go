@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPartialTakeProfitRoundTrip(t *testing.T) {
+	tmpDB := "./test_partial_tp.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	pos := &PositionRecord{
+		ID:              "BTCUSDT-1",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      100000,
+		EntryTime:       time.Now(),
+		Quantity:        0.02,
+		Leverage:        10,
+		InitialStopLoss: 98000,
+		CurrentStopLoss: 98000,
+		StopLossType:    "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// 未设置时返回零值
+	pt, err := db.GetPartialTakeProfit(pos.ID)
+	if err != nil {
+		t.Fatalf("GetPartialTakeProfit failed: %v", err)
+	}
+	if pt == nil || pt.TargetPrice != 0 || pt.Executed || pt.Quantity != 0.02 {
+		t.Fatalf("Unexpected initial state: %+v", pt)
+	}
+
+	// 执行分批止盈后剩余数量同步更新
+	pt.TargetPrice = 102000
+	pt.ClosePercent = 50
+	pt.Executed = true
+	pt.ClosedQuantity = 0.01
+	pt.RealizedPnL = 20
+	pt.Quantity = 0.01
+	if err := db.SavePartialTakeProfit(pt); err != nil {
+		t.Fatalf("SavePartialTakeProfit failed: %v", err)
+	}
+
+	pt, err = db.GetPartialTakeProfit(pos.ID)
+	if err != nil {
+		t.Fatalf("GetPartialTakeProfit failed: %v", err)
+	}
+	if !pt.Executed || pt.TargetPrice != 102000 || pt.ClosePercent != 50 || pt.RealizedPnL != 20 {
+		t.Errorf("Unexpected partial take-profit: %+v", pt)
+	}
+
+	record, err := db.GetPositionByID(pos.ID)
+	if err != nil {
+		t.Fatalf("GetPositionByID failed: %v", err)
+	}
+	if record.Quantity != 0.01 {
+		t.Errorf("Expected remaining quantity 0.01, got %.4f", record.Quantity)
+	}
+
+	// 不存在的持仓返回 nil
+	if missing, err := db.GetPartialTakeProfit("missing"); err != nil || missing != nil {
+		t.Errorf("Expected nil for missing position, got %+v, %v", missing, err)
+	}
+}
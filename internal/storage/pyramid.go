@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// initPyramidSchema adds the add-on (pyramiding) columns to positions
+// initPyramidSchema 为 positions 表添加加仓字段
+func (s *Storage) initPyramidSchema() {
+	columns := []string{
+		"add_count INTEGER DEFAULT 0",
+		"initial_quantity REAL DEFAULT 0",
+	}
+
+	// Run each ALTER separately so one existing column doesn't skip the rest
+	// 逐条执行 ALTER，避免某个字段已存在导致后续字段被跳过
+	for _, column := range columns {
+		s.exec("ALTER TABLE positions ADD COLUMN " + column)
+	}
+}
+
+// SavePositionAdd stores a position after an add-on: its blended entry price, total quantity,
+// shifted initial stop, number of adds and the quantity it was opened with
+// SavePositionAdd 保存加仓后的持仓：混合入场价、总数量、平移后的初始止损、加仓次数以及开仓时的数量
+func (s *Storage) SavePositionAdd(positionID string, entryPrice, quantity, initialStopLoss float64, addCount int, initialQuantity float64) error {
+	_, err := s.exec(`UPDATE positions SET entry_price = ?, quantity = ?, initial_stop_loss = ?, add_count = ?, initial_quantity = ? WHERE id = ?`,
+		entryPrice, quantity, initialStopLoss, addCount, initialQuantity, positionID)
+	if err != nil {
+		return fmt.Errorf("failed to save position add-on: %w", err)
+	}
+	return nil
+}
+
+// GetPositionAdds retrieves the number of adds and the opening quantity of a position (zero values if not found)
+// GetPositionAdds 获取持仓的加仓次数和开仓数量（不存在返回零值）
+func (s *Storage) GetPositionAdds(positionID string) (int, float64, error) {
+	var addCount sql.NullInt64
+	var initialQuantity sql.NullFloat64
+	err := s.db.QueryRow(`SELECT add_count, initial_quantity FROM positions WHERE id = ?`, positionID).
+		Scan(&addCount, &initialQuantity)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get position add-ons: %w", err)
+	}
+	return int(addCount.Int64), initialQuantity.Float64, nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPositionAddRoundTrip(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "pyramid.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	pos := &PositionRecord{
+		ID:              "BTCUSDT-1",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      100000,
+		EntryTime:       time.Now(),
+		Quantity:        0.02,
+		Leverage:        10,
+		InitialStopLoss: 98000,
+		CurrentStopLoss: 98000,
+		StopLossType:    "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// 未加仓时返回零值
+	if count, initial, err := db.GetPositionAdds(pos.ID); err != nil || count != 0 || initial != 0 {
+		t.Fatalf("Unexpected initial add-ons: %d, %.4f, %v", count, initial, err)
+	}
+
+	if err := db.SavePositionAdd(pos.ID, 101000, 0.03, 99000, 1, 0.02); err != nil {
+		t.Fatalf("SavePositionAdd failed: %v", err)
+	}
+	if count, initial, err := db.GetPositionAdds(pos.ID); err != nil || count != 1 || initial != 0.02 {
+		t.Errorf("GetPositionAdds = %d, %.4f, %v; want 1, 0.02", count, initial, err)
+	}
+	saved, err := db.GetPositionByID(pos.ID)
+	if err != nil || saved == nil {
+		t.Fatalf("GetPositionByID failed: %v", err)
+	}
+	if saved.EntryPrice != 101000 || saved.Quantity != 0.03 || saved.InitialStopLoss != 99000 {
+		t.Errorf("Expected blended entry 101000, quantity 0.03 and initial stop 99000, got %.2f, %.4f and %.2f",
+			saved.EntryPrice, saved.Quantity, saved.InitialStopLoss)
+	}
+
+	// 不存在的持仓返回零值
+	if count, initial, err := db.GetPositionAdds("missing"); err != nil || count != 0 || initial != 0 {
+		t.Errorf("Expected zero values for missing position, got %d, %.4f, %v", count, initial, err)
+	}
+}
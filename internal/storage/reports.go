@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PerformanceReport is the daily or weekly digest of closed trades and balance history
+// PerformanceReport 是平仓交易和余额历史的日报或周报
+type PerformanceReport struct {
+	ID             int64
+	Period         string    // daily / weekly
+	PeriodStart    time.Time // 统计区间开始（UTC，含）/ Period start (UTC, inclusive)
+	PeriodEnd      time.Time // 统计区间结束（UTC，不含）/ Period end (UTC, exclusive)
+	Trades         int       // 平仓笔数 / Closed trades
+	Wins           int       // 盈利笔数 / Winning trades
+	WinRate        float64   // 胜率 % / Win rate %
+	RealizedPnL    float64   // 已实现盈亏（USDT）/ Realized PnL (USDT)
+	Fees           float64   // 平仓持仓的手续费（USDT）/ Fees of the closed positions (USDT)
+	BestTrade      string    // 最佳交易（交易对和方向）/ Best trade (symbol and side)
+	BestPnL        float64   // 最佳交易盈亏 / Best trade PnL
+	WorstTrade     string    // 最差交易（交易对和方向）/ Worst trade (symbol and side)
+	WorstPnL       float64   // 最差交易盈亏 / Worst trade PnL
+	StartEquity    float64   // 区间首个余额快照的资产 / Equity of the first snapshot of the period
+	EndEquity      float64   // 区间最后一个余额快照的资产 / Equity of the last snapshot of the period
+	MaxDrawdownPct float64   // 区间最大回撤 % / Max drawdown of the period %
+	Withdrawn      float64   // 区间内利润提取的金额（USDT）/ Amount swept out during the period (USDT)
+	CreatedAt      time.Time // 生成时间 / When the report was generated
+}
+
+// initReportSchema creates the reports table if it doesn't exist and adds the withdrawn column to older tables
+// initReportSchema 创建 reports 表（如果不存在），并为旧表添加 withdrawn 字段
+func (s *Storage) initReportSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		period TEXT NOT NULL,
+		period_start DATETIME NOT NULL,
+		period_end DATETIME NOT NULL,
+		trades INTEGER DEFAULT 0,
+		wins INTEGER DEFAULT 0,
+		win_rate REAL DEFAULT 0,
+		realized_pnl REAL DEFAULT 0,
+		fees REAL DEFAULT 0,
+		best_trade TEXT,
+		best_pnl REAL DEFAULT 0,
+		worst_trade TEXT,
+		worst_pnl REAL DEFAULT 0,
+		start_equity REAL DEFAULT 0,
+		end_equity REAL DEFAULT 0,
+		max_drawdown_pct REAL DEFAULT 0,
+		withdrawn REAL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		UNIQUE(period, period_start)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_reports_period_start ON reports(period_start DESC);
+	`
+	if _, err := s.exec(schema); err != nil {
+		return err
+	}
+
+	// Ignore the error as the column may already exist
+	// 忽略错误，因为字段可能已经存在
+	s.exec("ALTER TABLE reports ADD COLUMN withdrawn REAL DEFAULT 0")
+	return nil
+}
+
+// SaveReport stores a report, replacing an earlier one of the same period
+// SaveReport 保存报告，覆盖同一统计区间较早生成的报告
+func (s *Storage) SaveReport(r *PerformanceReport) (int64, error) {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT OR REPLACE INTO reports (
+		period, period_start, period_end, trades, wins, win_rate, realized_pnl, fees,
+		best_trade, best_pnl, worst_trade, worst_pnl, start_equity, end_equity, max_drawdown_pct, withdrawn,
+		created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		r.Period, r.PeriodStart.UTC(), r.PeriodEnd.UTC(), r.Trades, r.Wins, r.WinRate, r.RealizedPnL, r.Fees,
+		r.BestTrade, r.BestPnL, r.WorstTrade, r.WorstPnL, r.StartEquity, r.EndEquity, r.MaxDrawdownPct, r.Withdrawn,
+		r.CreatedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save report: %w", err)
+	}
+	r.ID, err = result.LastInsertId()
+	return r.ID, err
+}
+
+// GetReports retrieves the latest reports of a period (empty = all periods), newest first
+// GetReports 获取最近的报告（period 为空表示所有类型），按统计区间倒序
+func (s *Storage) GetReports(period string, limit int) ([]*PerformanceReport, error) {
+	rows, err := s.db.Query(`
+	SELECT id, period, period_start, period_end, trades, wins, win_rate, realized_pnl, fees,
+		COALESCE(best_trade, ''), best_pnl, COALESCE(worst_trade, ''), worst_pnl,
+		start_equity, end_equity, max_drawdown_pct, withdrawn, created_at
+	FROM reports
+	WHERE (? = '' OR period = ?)
+	ORDER BY period_start DESC, period ASC
+	LIMIT ?
+	`, period, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*PerformanceReport
+	for rows.Next() {
+		r := &PerformanceReport{}
+		if err := rows.Scan(&r.ID, &r.Period, &r.PeriodStart, &r.PeriodEnd, &r.Trades, &r.Wins, &r.WinRate,
+			&r.RealizedPnL, &r.Fees, &r.BestTrade, &r.BestPnL, &r.WorstTrade, &r.WorstPnL,
+			&r.StartEquity, &r.EndEquity, &r.MaxDrawdownPct, &r.Withdrawn, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
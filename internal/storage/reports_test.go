@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReports(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "reports.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)
+	week := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	for _, r := range []*PerformanceReport{
+		{Period: "daily", PeriodStart: day.AddDate(0, 0, -1), PeriodEnd: day, Trades: 1},
+		{Period: "daily", PeriodStart: day, PeriodEnd: day.AddDate(0, 0, 1), Trades: 2},
+		{Period: "daily", PeriodStart: day, PeriodEnd: day.AddDate(0, 0, 1), Trades: 3, Wins: 2, WinRate: 66.67,
+			RealizedPnL: 12.5, BestTrade: "BTC/USDT long", BestPnL: 10}, // 同一区间覆盖 / Same period replaces
+		{Period: "weekly", PeriodStart: week, PeriodEnd: week.AddDate(0, 0, 7), Trades: 8},
+	} {
+		if _, err := db.SaveReport(r); err != nil {
+			t.Fatalf("SaveReport failed: %v", err)
+		}
+	}
+
+	daily, err := db.GetReports("daily", 10)
+	if err != nil {
+		t.Fatalf("GetReports failed: %v", err)
+	}
+	if len(daily) != 2 {
+		t.Fatalf("Expected 2 daily reports, got %d", len(daily))
+	}
+	latest := daily[0]
+	if !latest.PeriodStart.Equal(day) || latest.Trades != 3 || latest.Wins != 2 || latest.BestTrade != "BTC/USDT long" || latest.RealizedPnL != 12.5 {
+		t.Errorf("Unexpected latest daily report: %+v", latest)
+	}
+
+	all, err := db.GetReports("", 10)
+	if err != nil {
+		t.Fatalf("GetReports failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 reports of all periods, got %d", len(all))
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// PruneSessions deletes old trading sessions with their ensemble votes and notes
+// PruneSessions 删除旧的交易会话及其集成投票和备注
+//
+// A session is deleted if it was created before the cutoff (zero means no age limit) or, when keepPerSymbol > 0,
+// if it is not among the latest keepPerSymbol sessions of its symbol. Freed pages are reused by later writes.
+// 会话在 cutoff 之前创建（零值表示不按时间删除），或在 keepPerSymbol > 0 时不属于该交易对最新的 keepPerSymbol 条，即被删除。
+// 释放的页面会被后续写入复用。
+func (s *Storage) PruneSessions(before time.Time, keepPerSymbol int) (int64, error) {
+	if before.IsZero() && keepPerSymbol <= 0 {
+		return 0, nil
+	}
+
+	var pruned int64
+	err := retryBusy(func() error {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		result, err := tx.Exec(`
+		DELETE FROM trading_sessions
+		WHERE (? AND created_at < ?)
+		   OR (? > 0 AND id NOT IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY created_at DESC, id DESC) AS rn
+					FROM trading_sessions
+				) WHERE rn <= ?
+			))
+		`, !before.IsZero(), before, keepPerSymbol, keepPerSymbol)
+		if err != nil {
+			return err
+		}
+		if pruned, err = result.RowsAffected(); err != nil || pruned == 0 {
+			return err
+		}
+
+		// Rows that belonged to the deleted sessions
+		// 属于已删除会话的记录
+		if _, err := tx.Exec(`DELETE FROM ensemble_votes WHERE session_id NOT IN (SELECT id FROM trading_sessions)`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+		DELETE FROM notes
+		WHERE target_type = ? AND target_id NOT IN (SELECT CAST(id AS TEXT) FROM trading_sessions)
+		`, NoteTargetSession); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune sessions: %w", err)
+	}
+	return pruned, nil
+}
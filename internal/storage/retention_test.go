@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPruneSessions(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "retention.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// BTC 每天一条会话（0-4 天前），ETH 只有一条 10 天前的会话
+	now := time.Now()
+	var btcIDs []int64
+	for day := 0; day < 5; day++ {
+		id, err := db.SaveSession(&TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.AddDate(0, 0, -day), Decision: "HOLD"})
+		if err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+		btcIDs = append(btcIDs, id)
+	}
+	ethID, err := db.SaveSession(&TradingSession{Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: now.AddDate(0, 0, -10), Decision: "HOLD"})
+	if err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+	oldest := strconv.FormatInt(btcIDs[4], 10)
+	if _, err := db.AddNote(&Note{TargetType: NoteTargetSession, TargetID: oldest, Text: "旧会话备注", CreatedAt: now}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if err := db.SaveEnsembleVotes(btcIDs[4], []*EnsembleVote{{Symbol: "BTC/USDT", Model: "gpt-4o-mini", Action: "HOLD"}}); err != nil {
+		t.Fatalf("SaveEnsembleVotes failed: %v", err)
+	}
+
+	if pruned, err := db.PruneSessions(time.Time{}, 0); err != nil || pruned != 0 {
+		t.Errorf("Expected no pruning without a policy, got %d, %v", pruned, err)
+	}
+
+	// Keep the latest 3 per symbol: drops the two oldest BTC sessions, keeps the single ETH one
+	// 每个交易对保留最新 3 条：删除两条最旧的 BTC 会话，保留唯一的 ETH 会话
+	pruned, err := db.PruneSessions(time.Time{}, 3)
+	if err != nil || pruned != 2 {
+		t.Fatalf("Expected 2 sessions pruned by count, got %d, %v", pruned, err)
+	}
+	if notes, _ := db.GetNotes(NoteTargetSession, oldest); len(notes) != 0 {
+		t.Errorf("Expected notes of pruned sessions to be deleted, got %d", len(notes))
+	}
+	if votes, _ := db.GetEnsembleVotes(btcIDs[4]); len(votes) != 0 {
+		t.Errorf("Expected votes of pruned sessions to be deleted, got %d", len(votes))
+	}
+
+	// Keep 7 days: drops the 10-day-old ETH session
+	// 保留 7 天：删除 10 天前的 ETH 会话
+	pruned, err = db.PruneSessions(now.AddDate(0, 0, -7), 0)
+	if err != nil || pruned != 1 {
+		t.Fatalf("Expected 1 session pruned by age, got %d, %v", pruned, err)
+	}
+	if _, err := db.GetSessionByID(ethID); err == nil {
+		t.Error("Expected the ETH session to be pruned")
+	}
+	sessions, err := db.GetLatestSessions(10)
+	if err != nil || len(sessions) != 3 {
+		t.Errorf("Expected 3 sessions left, got %d, %v", len(sessions), err)
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// activeBatchKey is the run_state key holding the batch currently being analyzed or executed
+// activeBatchKey 是 run_state 中保存正在分析或执行的批次的键
+const activeBatchKey = "active_batch"
+
+// InterruptedExecutionResult is the execution result of sessions whose batch never completed
+// InterruptedExecutionResult 是批次未完成的会话的执行结果
+const InterruptedExecutionResult = "⚠️ 已中断：程序在执行完成前退出"
+
+// initRunStateSchema creates the run_state table if it doesn't exist
+// initRunStateSchema 创建 run_state 表（如果不存在）
+func (s *Storage) initRunStateSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS run_state (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// BeginBatch records that a batch is in progress, until EndBatch is called
+// BeginBatch 记录批次正在进行，直到调用 EndBatch
+func (s *Storage) BeginBatch(batchID string) error {
+	_, err := s.exec(`INSERT OR REPLACE INTO run_state (key, value, updated_at) VALUES (?, ?, ?)`,
+		activeBatchKey, batchID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to begin batch: %w", err)
+	}
+	return nil
+}
+
+// EndBatch clears the in-progress marker of a batch
+// EndBatch 清除批次的进行中标记
+func (s *Storage) EndBatch(batchID string) error {
+	_, err := s.exec(`DELETE FROM run_state WHERE key = ? AND value = ?`, activeBatchKey, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to end batch: %w", err)
+	}
+	return nil
+}
+
+// MarkBatchInterrupted sets InterruptedExecutionResult on the sessions of a batch that have no result yet
+// MarkBatchInterrupted 为批次中尚无执行结果的会话写入 InterruptedExecutionResult
+func (s *Storage) MarkBatchInterrupted(batchID string) (int64, error) {
+	result, err := s.exec(`
+	UPDATE trading_sessions
+	SET execution_result = ?
+	WHERE batch_id = ? AND executed = 0 AND COALESCE(execution_result, '') = ''
+	`, InterruptedExecutionResult, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark interrupted sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// RecoverInterruptedBatch marks the sessions of a batch left in progress by a previous run
+// RecoverInterruptedBatch 标记上次运行遗留的进行中批次的会话
+//
+// Returns the interrupted batch ID ("" if the previous run completed) and the number of sessions marked.
+// 返回被中断的批次 ID（上次运行正常完成时为空）以及被标记的会话数。
+func (s *Storage) RecoverInterruptedBatch() (string, int64, error) {
+	var batchID string
+	err := s.db.QueryRow(`SELECT value FROM run_state WHERE key = ?`, activeBatchKey).Scan(&batchID)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get active batch: %w", err)
+	}
+
+	marked, err := s.MarkBatchInterrupted(batchID)
+	if err != nil {
+		return batchID, 0, err
+	}
+	return batchID, marked, s.EndBatch(batchID)
+}
+
+// GetRunState returns the value stored under a run_state key ("" if unset)
+// GetRunState 返回 run_state 中某个键的值（未设置时为 ""）
+func (s *Storage) GetRunState(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM run_state WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get run state %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetRunState stores a value under a run_state key
+// SetRunState 将值保存到 run_state 中的某个键
+func (s *Storage) SetRunState(key, value string) error {
+	_, err := s.exec(`INSERT OR REPLACE INTO run_state (key, value, updated_at) VALUES (?, ?, ?)`, key, value, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set run state %s: %w", key, err)
+	}
+	return nil
+}
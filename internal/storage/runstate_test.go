@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecoverInterruptedBatch(t *testing.T) {
+	tmpDB := "./test_runstate.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 正常完成的批次不会留下标记
+	if err := db.BeginBatch("batch-1"); err != nil {
+		t.Fatalf("BeginBatch failed: %v", err)
+	}
+	if err := db.EndBatch("batch-1"); err != nil {
+		t.Fatalf("EndBatch failed: %v", err)
+	}
+	if batchID, _, err := db.RecoverInterruptedBatch(); err != nil || batchID != "" {
+		t.Fatalf("Expected no interrupted batch, got %q (%v)", batchID, err)
+	}
+
+	// 未完成的批次：已执行的会话保持不变，未执行的会话标记为已中断
+	if err := db.BeginBatch("batch-2"); err != nil {
+		t.Fatalf("BeginBatch failed: %v", err)
+	}
+	sessions := []*TradingSession{
+		{BatchID: "batch-2", Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: time.Now(), Executed: true, ExecutionResult: "✅ 成功执行 BUY"},
+		{BatchID: "batch-2", Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: time.Now()},
+		{BatchID: "batch-0", Symbol: "SOL/USDT", Timeframe: "1h", CreatedAt: time.Now()},
+	}
+	ids := make([]int64, len(sessions))
+	for i, session := range sessions {
+		if ids[i], err = db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	batchID, marked, err := db.RecoverInterruptedBatch()
+	if err != nil {
+		t.Fatalf("RecoverInterruptedBatch failed: %v", err)
+	}
+	if batchID != "batch-2" || marked != 1 {
+		t.Errorf("Expected 1 session of batch-2 marked, got %d of %q", marked, batchID)
+	}
+
+	want := []string{"✅ 成功执行 BUY", InterruptedExecutionResult, ""}
+	for i, id := range ids {
+		session, err := db.GetSessionByID(id)
+		if err != nil {
+			t.Fatalf("GetSessionByID failed: %v", err)
+		}
+		if session.ExecutionResult != want[i] {
+			t.Errorf("Session %d: expected result %q, got %q", i, want[i], session.ExecutionResult)
+		}
+	}
+
+	// 标记已清除
+	if batchID, _, err := db.RecoverInterruptedBatch(); err != nil || batchID != "" {
+		t.Errorf("Marker should be cleared, got %q (%v)", batchID, err)
+	}
+}
+
+func TestRunState(t *testing.T) {
+	tmpDB := "./test_runstate_kv.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	if value, err := db.GetRunState("circuit_breaker"); err != nil || value != "" {
+		t.Fatalf("Expected an unset key to be empty, got %q (%v)", value, err)
+	}
+	for _, want := range []string{`{"trips":1}`, `{"trips":2}`} {
+		if err := db.SetRunState("circuit_breaker", want); err != nil {
+			t.Fatalf("SetRunState failed: %v", err)
+		}
+		if value, err := db.GetRunState("circuit_breaker"); err != nil || value != want {
+			t.Errorf("Expected %q, got %q (%v)", want, value, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Outcomes of an evaluated shadow order
+// 影子订单的评估结果
+const (
+	ShadowOutcomeHorizon     = "horizon"     // 持有到评估期结束 / Held to the end of the horizon
+	ShadowOutcomeStop        = "stop"        // 期间触及止损 / The stop-loss was hit first
+	ShadowOutcomeUntriggered = "untriggered" // 条件入场单未触发 / The stop entry never triggered
+)
+
+// ShadowOrder is an order the bot would have sent in shadow mode
+// ShadowOrder 是影子模式下机器人本应发送的订单
+//
+// Returns are the price move in the direction of the order, in percent of the entry price, without
+// leverage or fees: positive for BUY when the price rose, for SELL and CLOSE_LONG when it fell.
+// 收益为按订单方向计算的价格变动，占入场价的百分比，不含杠杆和手续费：
+// BUY 在价格上涨时为正，SELL 和 CLOSE_LONG 在价格下跌时为正。
+type ShadowOrder struct {
+	ID              int64
+	Symbol          string
+	Action          string    // BUY/SELL/CLOSE_LONG/CLOSE_SHORT/BUY_STOP/SELL_STOP
+	Quantity        float64   // 计算出的下单数量 / Sized order quantity
+	Leverage        int       // 杠杆倍数 / Leverage
+	DecisionPrice   float64   // 决策时的价格 / Price at decision time
+	TriggerPrice    float64   // 条件入场单触发价（0 表示市价单）/ Stop entry trigger (0 = market order)
+	StopLoss        float64   // 止损价（0 表示未设置）/ Stop-loss (0 = none)
+	Reason          string    // 决策理由 / Decision reason
+	CreatedAt       time.Time // 决策时间 / Decision time
+	EvaluatedAt     time.Time // 评估时间（零值表示尚未评估）/ When evaluated (zero = not yet)
+	ExitPrice       float64   // 评估的退出价 / Exit price of the evaluation
+	ReturnPct       float64   // 按订单方向的收益率 % / Return in the direction of the order, %
+	MaxFavorablePct float64   // 最大有利波动 % / Maximum favorable excursion, %
+	MaxAdversePct   float64   // 最大不利波动 %（负数）/ Maximum adverse excursion, % (negative)
+	Outcome         string    // horizon/stop/untriggered
+}
+
+// ShadowStats summarizes the evaluated shadow orders
+// ShadowStats 汇总已评估的影子订单
+type ShadowStats struct {
+	Total        int     // 全部影子订单 / All shadow orders
+	Evaluated    int     // 已评估（含未触发）/ Evaluated, including untriggered
+	Triggered    int     // 已评估且已入场 / Evaluated and entered
+	Wins         int     // 收益为正 / Positive return
+	StopHits     int     // 触及止损 / Stop-loss hit
+	AvgReturnPct float64 // 平均收益率 % / Average return, %
+	WinRate      float64 // 胜率（0-1）/ Win rate (0-1)
+}
+
+// initShadowSchema creates the shadow_orders table if it doesn't exist
+// initShadowSchema 创建 shadow_orders 表（如果不存在）
+func (s *Storage) initShadowSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS shadow_orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		action TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		leverage INTEGER DEFAULT 0,
+		decision_price REAL NOT NULL,
+		trigger_price REAL DEFAULT 0,
+		stop_loss REAL DEFAULT 0,
+		reason TEXT,
+		created_at DATETIME NOT NULL,
+		evaluated_at DATETIME,
+		exit_price REAL DEFAULT 0,
+		return_pct REAL DEFAULT 0,
+		max_favorable_pct REAL DEFAULT 0,
+		max_adverse_pct REAL DEFAULT 0,
+		outcome TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_shadow_orders_created_at ON shadow_orders(created_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveShadowOrder stores an intended order and sets its ID
+// SaveShadowOrder 保存一笔意向订单并设置其 ID
+func (s *Storage) SaveShadowOrder(order *ShadowOrder) error {
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT INTO shadow_orders (
+		symbol, action, quantity, leverage, decision_price, trigger_price, stop_loss, reason, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, order.Symbol, order.Action, order.Quantity, order.Leverage, order.DecisionPrice, order.TriggerPrice,
+		order.StopLoss, order.Reason, order.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save shadow order: %w", err)
+	}
+	order.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateShadowEvaluation stores the evaluation of a shadow order
+// UpdateShadowEvaluation 保存影子订单的评估结果
+func (s *Storage) UpdateShadowEvaluation(order *ShadowOrder) error {
+	_, err := s.exec(`
+	UPDATE shadow_orders
+	SET evaluated_at = ?, exit_price = ?, return_pct = ?, max_favorable_pct = ?, max_adverse_pct = ?, outcome = ?
+	WHERE id = ?
+	`, order.EvaluatedAt, order.ExitPrice, order.ReturnPct, order.MaxFavorablePct, order.MaxAdversePct,
+		order.Outcome, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update shadow order: %w", err)
+	}
+	return nil
+}
+
+// GetUnevaluatedShadowOrders retrieves the shadow orders created before a time that are not evaluated yet, oldest first
+// GetUnevaluatedShadowOrders 获取在指定时间之前创建且尚未评估的影子订单，按时间正序
+func (s *Storage) GetUnevaluatedShadowOrders(before time.Time) ([]*ShadowOrder, error) {
+	return s.queryShadowOrders(`WHERE evaluated_at IS NULL AND created_at < ? ORDER BY created_at ASC, id ASC`, before)
+}
+
+// GetShadowOrders retrieves the latest shadow orders, newest first
+// GetShadowOrders 获取最近的影子订单，按时间倒序
+func (s *Storage) GetShadowOrders(limit int) ([]*ShadowOrder, error) {
+	return s.queryShadowOrders(`ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// queryShadowOrders runs a shadow_orders query with the given WHERE / ORDER clause
+// queryShadowOrders 使用给定的 WHERE / ORDER 子句查询 shadow_orders
+func (s *Storage) queryShadowOrders(clause string, args ...interface{}) ([]*ShadowOrder, error) {
+	rows, err := s.db.Query(`
+	SELECT id, symbol, action, quantity, leverage, decision_price, trigger_price, stop_loss, COALESCE(reason, ''),
+		   created_at, evaluated_at, exit_price, return_pct, max_favorable_pct, max_adverse_pct, COALESCE(outcome, '')
+	FROM shadow_orders
+	`+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadow orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*ShadowOrder
+	for rows.Next() {
+		order := &ShadowOrder{}
+		var evaluatedAt sql.NullTime
+		if err := rows.Scan(
+			&order.ID, &order.Symbol, &order.Action, &order.Quantity, &order.Leverage, &order.DecisionPrice,
+			&order.TriggerPrice, &order.StopLoss, &order.Reason, &order.CreatedAt, &evaluatedAt,
+			&order.ExitPrice, &order.ReturnPct, &order.MaxFavorablePct, &order.MaxAdversePct, &order.Outcome,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan shadow order: %w", err)
+		}
+		if evaluatedAt.Valid {
+			order.EvaluatedAt = evaluatedAt.Time
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// GetShadowStats summarizes all shadow orders
+// GetShadowStats 汇总所有影子订单
+func (s *Storage) GetShadowStats() (*ShadowStats, error) {
+	stats := &ShadowStats{}
+	var avgReturn sql.NullFloat64
+	err := s.db.QueryRow(`
+	SELECT COUNT(*),
+		   COUNT(evaluated_at),
+		   COALESCE(SUM(CASE WHEN evaluated_at IS NOT NULL AND outcome != ? THEN 1 ELSE 0 END), 0),
+		   COALESCE(SUM(CASE WHEN evaluated_at IS NOT NULL AND outcome != ? AND return_pct > 0 THEN 1 ELSE 0 END), 0),
+		   COALESCE(SUM(CASE WHEN outcome = ? THEN 1 ELSE 0 END), 0),
+		   AVG(CASE WHEN evaluated_at IS NOT NULL AND outcome != ? THEN return_pct END)
+	FROM shadow_orders
+	`, ShadowOutcomeUntriggered, ShadowOutcomeUntriggered, ShadowOutcomeStop, ShadowOutcomeUntriggered).Scan(
+		&stats.Total, &stats.Evaluated, &stats.Triggered, &stats.Wins, &stats.StopHits, &avgReturn,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize shadow orders: %w", err)
+	}
+	stats.AvgReturnPct = avgReturn.Float64
+	if stats.Triggered > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Triggered)
+	}
+	return stats, nil
+}
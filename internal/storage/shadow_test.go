@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestShadowOrders tests saving, evaluating and summarizing shadow orders
+// TestShadowOrders 测试影子订单的保存、评估和汇总
+func TestShadowOrders(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "shadow.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	orders := []*ShadowOrder{
+		{Symbol: "BTCUSDT", Action: "BUY", Quantity: 0.01, Leverage: 10, DecisionPrice: 100000, StopLoss: 98000, CreatedAt: now.Add(-5 * time.Hour)},
+		{Symbol: "ETHUSDT", Action: "SELL", Quantity: 0.5, DecisionPrice: 3000, CreatedAt: now.Add(-5 * time.Hour)},
+		{Symbol: "BTCUSDT", Action: "BUY_STOP", Quantity: 0.01, DecisionPrice: 100000, TriggerPrice: 101000, CreatedAt: now.Add(-4 * time.Hour)},
+		{Symbol: "ETHUSDT", Action: "BUY", Quantity: 0.5, DecisionPrice: 3100, CreatedAt: now.Add(-time.Hour)},
+	}
+	for _, order := range orders {
+		if err := db.SaveShadowOrder(order); err != nil {
+			t.Fatalf("SaveShadowOrder failed: %v", err)
+		}
+	}
+
+	due, err := db.GetUnevaluatedShadowOrders(now.Add(-2 * time.Hour))
+	if err != nil || len(due) != 3 || due[0].ID != orders[0].ID {
+		t.Fatalf("GetUnevaluatedShadowOrders = %d orders, %v; want the 3 older orders", len(due), err)
+	}
+
+	evaluations := []struct {
+		outcome string
+		ret     float64
+	}{
+		{ShadowOutcomeStop, -2},
+		{ShadowOutcomeHorizon, 1.5},
+		{ShadowOutcomeUntriggered, 0},
+	}
+	for i, e := range evaluations {
+		due[i].EvaluatedAt, due[i].Outcome, due[i].ReturnPct = now, e.outcome, e.ret
+		if err := db.UpdateShadowEvaluation(due[i]); err != nil {
+			t.Fatalf("UpdateShadowEvaluation failed: %v", err)
+		}
+	}
+
+	if due, _ := db.GetUnevaluatedShadowOrders(now); len(due) != 1 || due[0].ID != orders[3].ID {
+		t.Errorf("Expected only the latest order left to evaluate, got %+v", due)
+	}
+
+	latest, err := db.GetShadowOrders(10)
+	if err != nil || len(latest) != 4 {
+		t.Fatalf("GetShadowOrders = %d orders, %v", len(latest), err)
+	}
+	if stopped := latest[3]; stopped.Outcome != ShadowOutcomeStop || stopped.StopLoss != 98000 || stopped.EvaluatedAt.IsZero() {
+		t.Errorf("Unexpected evaluated order: %+v", stopped)
+	}
+
+	stats, err := db.GetShadowStats()
+	if err != nil {
+		t.Fatalf("GetShadowStats failed: %v", err)
+	}
+	// Untriggered entries count as evaluated but not in the returns
+	// 未触发的入场单计入已评估，但不计入收益
+	if stats.Total != 4 || stats.Evaluated != 3 || stats.Triggered != 2 || stats.Wins != 1 || stats.StopHits != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.AvgReturnPct != -0.25 || stats.WinRate != 0.5 {
+		t.Errorf("AvgReturnPct = %.2f, WinRate = %.2f; want -0.25, 0.5", stats.AvgReturnPct, stats.WinRate)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLite concurrency settings: the trading loop, stop-loss monitor and web server all write to the same file
+// SQLite 并发设置：交易循环、止损监控和 Web 服务都会写入同一个数据库文件
+const (
+	busyTimeout    = 5 * time.Second        // 等待写锁的时长 / How long a connection waits for the write lock
+	busyRetries    = 3                      // 超时后仍繁忙时的重试次数 / Retries when still busy after the timeout
+	busyRetryDelay = 200 * time.Millisecond // 重试间隔（逐次递增）/ Delay between retries (grows per attempt)
+	maxOpenConns   = 8                      // WAL 下读连接可并发，写入仍串行 / Readers run concurrently under WAL; writes still serialize
+)
+
+// sqliteDSN adds the per-connection pragmas to a database path
+// sqliteDSN 为数据库路径添加每个连接的 PRAGMA 参数
+//
+// WAL lets readers proceed while one connection writes, busy_timeout makes a writer wait for the lock
+// instead of failing with "database is locked", and immediate transactions take the write lock at BEGIN
+// so they wait there rather than failing when a read upgrades to a write.
+// WAL 使读取在写入时仍可进行；busy_timeout 让写入方等待锁而不是直接报 "database is locked"；
+// immediate 事务在 BEGIN 时获取写锁，在此等待，而不是在读升级为写时失败。
+func sqliteDSN(dbPath string) string {
+	params := []string{
+		fmt.Sprintf("_pragma=busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"_pragma=journal_mode(WAL)",
+		"_pragma=synchronous(NORMAL)",
+		"_txlock=immediate",
+	}
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	return dbPath + sep + strings.Join(params, "&")
+}
+
+// isBusy reports whether err is SQLite's "database is locked" (SQLITE_BUSY or SQLITE_LOCKED)
+// isBusy 判断错误是否为 SQLite 的 "database is locked"（SQLITE_BUSY 或 SQLITE_LOCKED）
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// Extended codes keep the primary code in the low byte
+	// 扩展错误码的低字节为主错误码
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// retryBusy runs fn, retrying with a growing delay while SQLite reports the database as busy
+// retryBusy 执行 fn，SQLite 报告数据库繁忙时以递增间隔重试
+func retryBusy(fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt <= busyRetries && isBusy(err); attempt++ {
+		time.Sleep(time.Duration(attempt) * busyRetryDelay)
+		err = fn()
+	}
+	return err
+}
+
+// exec runs a write statement, retrying if the database stays locked past busy_timeout
+// exec 执行写入语句，数据库在 busy_timeout 之后仍被锁定时重试
+func (s *Storage) exec(query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := retryBusy(func() error {
+		var err error
+		result, err = s.db.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
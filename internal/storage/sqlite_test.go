@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSqliteDSN(t *testing.T) {
+	dsn := sqliteDSN("./data/trading.db")
+	if !strings.HasPrefix(dsn, "./data/trading.db?_pragma=busy_timeout(5000)&") || !strings.Contains(dsn, "journal_mode(WAL)") {
+		t.Errorf("Unexpected DSN: %s", dsn)
+	}
+	if dsn := sqliteDSN("file:trading.db?mode=rwc"); !strings.HasPrefix(dsn, "file:trading.db?mode=rwc&_pragma=") {
+		t.Errorf("Expected pragmas appended to existing query, got %s", dsn)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "concurrent.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("Expected WAL journal mode, got %q, %v", mode, err)
+	}
+
+	// 多个 goroutine 同时写入，不应出现 "database is locked"
+	const writers, perWriter = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				if _, err := db.SaveSession(&TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: time.Now(), Decision: "HOLD"}); err != nil {
+					errs <- err
+				}
+				if err := db.SaveBalanceHistory(&BalanceHistory{Timestamp: time.Now(), TotalBalance: 1000}); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Concurrent write failed: %v", err)
+	}
+
+	sessions, err := db.GetLatestSessions(writers * perWriter)
+	if err != nil || len(sessions) != writers*perWriter {
+		t.Errorf("Expected %d sessions, got %d, %v", writers*perWriter, len(sessions), err)
+	}
+}
+
+func TestRetryBusy(t *testing.T) {
+	calls := 0
+	err := retryBusy(func() error {
+		calls++
+		return errors.New("not busy")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected a non-busy error to be returned without retrying, got %v after %d calls", err, calls)
+	}
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -24,6 +25,12 @@ type TradingSession struct {
 	FullDecision    string // LLM 原始完整决策（包含所有交易对）/ Full LLM decision (all symbols)
 	Executed        bool
 	ExecutionResult string
+	SizingPolicy    string // 本次会话使用的仓位策略 / Position sizing policy used by the session
+	DecisionPath    string // 决策来源路径（主模型/重试/备用模型/规则）/ How the decision was produced (primary/retry/fallback model/rules)
+
+	// Structured decision written alongside the text (dual-write during the JSON transition)
+	// 与文本一起写入的结构化决策（JSON 迁移期间双写）
+	Structured *StructuredDecision
 }
 
 // PositionRecord represents an active trading position
@@ -74,6 +81,13 @@ type BalanceHistory struct {
 	AvailableBalance float64
 	UnrealizedPnL    float64
 	Positions        int
+
+	// Account risk
+	// 账户风险
+	MarginBalance     float64        // 保证金余额（钱包余额 + 未实现盈亏）/ Margin balance (wallet balance + unrealized PnL)
+	MaintenanceMargin float64        // 维持保证金 / Maintenance margin
+	MarginRatio       float64        // 保证金率 %（维持保证金 / 保证金余额，100% 触发强平）/ Margin ratio % (maintenance margin / margin balance, liquidation at 100%)
+	Assets            []BalanceAsset // 分资产明细 / Per-asset breakdown
 }
 
 // BatchSession represents a batch of trading sessions (all symbols from one execution)
@@ -87,15 +101,17 @@ type BatchSession struct {
 
 // Storage handles SQLite database operations
 type Storage struct {
-	db *sql.DB
+	db              *sql.DB
+	compressReports bool // 写入时压缩报告字段 / Compress report columns on write
 }
 
 // NewStorage creates a new storage instance
 func NewStorage(dbPath string) (*Storage, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(maxOpenConns)
 
 	// Test connection
 	if err := db.Ping(); err != nil {
@@ -135,7 +151,6 @@ func (s *Storage) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_symbol_created_at ON trading_sessions(symbol, created_at DESC);
 	CREATE INDEX IF NOT EXISTS idx_created_at ON trading_sessions(created_at DESC);
-	CREATE INDEX IF NOT EXISTS idx_batch_id ON trading_sessions(batch_id);
 
 	CREATE TABLE IF NOT EXISTS positions (
 		id TEXT PRIMARY KEY,
@@ -190,21 +205,196 @@ func (s *Storage) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_balance_timestamp ON balance_history(timestamp DESC);
 	`
 
-	_, err := s.db.Exec(schema)
+	_, err := s.exec(schema)
 	if err != nil {
 		return err
 	}
 
-	// Migrate existing database: add batch_id and full_decision columns if they don't exist
-	// 迁移现有数据库：如果不存在则添加 batch_id 和 full_decision 字段
-	migrationSQL := `
-	ALTER TABLE trading_sessions ADD COLUMN batch_id TEXT;
-	ALTER TABLE trading_sessions ADD COLUMN full_decision TEXT;
-	ALTER TABLE positions ADD COLUMN stop_loss_order_id TEXT;
-	`
-	// Ignore errors as columns may already exist
-	// 忽略错误，因为字段可能已经存在
-	s.db.Exec(migrationSQL)
+	// Migrate existing database: add batch_id, full_decision and stop_loss_order_id columns if they don't exist.
+	// One statement per Exec: a multi-statement Exec stops at the first column that already exists.
+	// 迁移现有数据库：如果不存在则添加 batch_id、full_decision 和 stop_loss_order_id 字段。
+	// 每条语句单独执行：多语句执行会在第一个已存在的字段处中止。
+	for _, stmt := range []string{
+		"ALTER TABLE trading_sessions ADD COLUMN batch_id TEXT",
+		"ALTER TABLE trading_sessions ADD COLUMN full_decision TEXT",
+		"ALTER TABLE positions ADD COLUMN stop_loss_order_id TEXT",
+	} {
+		// Ignore errors as columns may already exist
+		// 忽略错误，因为字段可能已经存在
+		s.exec(stmt)
+	}
+
+	// Created after the migration so databases from before batch_id existed don't fail the schema above
+	// 在迁移之后创建，避免 batch_id 出现之前的旧数据库导致上面的建表语句失败
+	if _, err := s.exec("CREATE INDEX IF NOT EXISTS idx_batch_id ON trading_sessions(batch_id)"); err != nil {
+		return fmt.Errorf("failed to create batch index: %w", err)
+	}
+
+	// Positions saved before symbols were normalized at write time
+	// 在写入时统一交易对格式之前保存的持仓
+	if _, err := s.exec(`UPDATE positions SET symbol = UPPER(REPLACE(symbol, '/', '')) WHERE symbol LIKE '%/%'`); err != nil {
+		return fmt.Errorf("failed to normalize position symbols: %w", err)
+	}
+
+	// Structured decision columns
+	// 结构化决策字段
+	s.initDecisionSchema()
+
+	// Sizing policy column, added on its own so existing columns above can't skip it
+	// 仓位策略字段，单独执行以免上面已存在的字段导致其被跳过
+	s.exec("ALTER TABLE trading_sessions ADD COLUMN sizing_policy TEXT")
+
+	// Decision path column (which LLM, retry or fallback produced the decision)
+	// 决策来源路径字段（由哪个 LLM、重试或后备产生的决策）
+	s.exec("ALTER TABLE trading_sessions ADD COLUMN decision_path TEXT")
+
+	// Partial take-profit columns
+	// 分批止盈字段
+	s.initPartialTPSchema()
+
+	// Funding column
+	// 资金费字段
+	s.initFundingSchema()
+
+	// Trading fee column
+	// 手续费字段
+	s.initFeeSchema()
+
+	// Bracket take-profit columns
+	// 括号单止盈字段
+	s.initTakeProfitSchema()
+
+	// Add-on (pyramiding) columns
+	// 加仓字段
+	s.initPyramidSchema()
+
+	// Account risk columns of balance snapshots
+	// 余额快照的账户风险字段
+	s.initBalanceRiskSchema()
+
+	// Execution filter columns of trading sessions
+	// 交易会话的执行过滤器字段
+	s.initFilterSchema()
+
+	// Paper trading tables
+	// 模拟盘相关表
+	if err := s.initPaperSchema(); err != nil {
+		return fmt.Errorf("failed to initialize paper trading schema: %w", err)
+	}
+
+	// Fill history
+	// 成交记录表
+	if err := s.initTradeSchema(); err != nil {
+		return fmt.Errorf("failed to initialize trades schema: %w", err)
+	}
+
+	// Flushed in-memory histories
+	// 从内存写入的历史数据
+	if err := s.initHistorySchema(); err != nil {
+		return fmt.Errorf("failed to initialize history schema: %w", err)
+	}
+
+	// Decision-to-execution latency
+	// 决策到成交的延迟记录
+	if err := s.initLatencySchema(); err != nil {
+		return fmt.Errorf("failed to initialize latency schema: %w", err)
+	}
+
+	// Profit sweeps to the spot wallet
+	// 划转到现货钱包的利润提取记录
+	if err := s.initSweepSchema(); err != nil {
+		return fmt.Errorf("failed to initialize profit sweep schema: %w", err)
+	}
+
+	// In-progress batch marker for shutdown recovery
+	// 用于关闭恢复的进行中批次标记
+	if err := s.initRunStateSchema(); err != nil {
+		return fmt.Errorf("failed to initialize run state schema: %w", err)
+	}
+
+	// Strategy versions and their freeze windows
+	// 策略版本及其冻结观察期
+	if err := s.initStrategySchema(); err != nil {
+		return fmt.Errorf("failed to initialize strategy schema: %w", err)
+	}
+
+	// Operator notes on positions and sessions
+	// 操作员对持仓和会话的备注
+	if err := s.initNotesSchema(); err != nil {
+		return fmt.Errorf("failed to initialize notes schema: %w", err)
+	}
+
+	// Conditional stop-entry orders
+	// 条件入场单（止损入场 / 突破入场）
+	if err := s.initEntriesSchema(); err != nil {
+		return fmt.Errorf("failed to initialize entries schema: %w", err)
+	}
+
+	// LLM decisions that arrived after the decision budget
+	// 超出决策时间预算后才返回的 LLM 决策
+	if err := s.initLateDecisionSchema(); err != nil {
+		return fmt.Errorf("failed to initialize late decision schema: %w", err)
+	}
+
+	// Per-model votes in ensemble mode
+	// 委员会模式下各模型的投票
+	if err := s.initEnsembleSchema(); err != nil {
+		return fmt.Errorf("failed to initialize ensemble schema: %w", err)
+	}
+
+	// Symbol metadata registry refreshed from exchangeInfo
+	// 从 exchangeInfo 刷新的交易对元数据
+	if err := s.initSymbolMetadataSchema(); err != nil {
+		return fmt.Errorf("failed to initialize symbol metadata schema: %w", err)
+	}
+
+	// LLM token usage and cost per batch and model
+	// 按批次和模型统计的 LLM token 用量和费用
+	if err := s.initLLMUsageSchema(); err != nil {
+		return fmt.Errorf("failed to initialize llm usage schema: %w", err)
+	}
+
+	// End-of-day state checkpoints and their diffs
+	// 日终状态快照及其差异
+	if err := s.initCheckpointSchema(); err != nil {
+		return fmt.Errorf("failed to initialize checkpoint schema: %w", err)
+	}
+
+	// Cached candles for incremental kline fetches
+	// 缓存的 K 线，用于增量获取
+	if err := s.initKlineSchema(); err != nil {
+		return fmt.Errorf("failed to initialize kline schema: %w", err)
+	}
+
+	// Execution ledger guarding against executing a kline twice
+	// 防止同一根 K 线重复执行的执行台账
+	if err := s.initLedgerSchema(); err != nil {
+		return fmt.Errorf("failed to initialize execution ledger schema: %w", err)
+	}
+
+	// Orders placed with deterministic client order ids, for crash recovery
+	// 使用确定性客户端订单 ID 下达的订单，用于崩溃恢复
+	if err := s.initClientOrderSchema(); err != nil {
+		return fmt.Errorf("failed to initialize client order schema: %w", err)
+	}
+
+	// Intended orders recorded in shadow mode and their evaluation
+	// 影子模式记录的意向订单及其评估
+	if err := s.initShadowSchema(); err != nil {
+		return fmt.Errorf("failed to initialize shadow order schema: %w", err)
+	}
+
+	// Daily and weekly performance reports
+	// 日报和周报
+	if err := s.initReportSchema(); err != nil {
+		return fmt.Errorf("failed to initialize report schema: %w", err)
+	}
+
+	// Closed positions linked back to the decision that opened them
+	// 已平仓持仓与开仓决策的关联
+	if err := s.initDecisionOutcomeSchema(); err != nil {
+		return fmt.Errorf("failed to initialize decision outcome schema: %w", err)
+	}
 
 	return nil
 }
@@ -215,24 +405,27 @@ func (s *Storage) SaveSession(session *TradingSession) (int64, error) {
 	INSERT INTO trading_sessions (
 		batch_id, symbol, timeframe, created_at,
 		market_report, crypto_report, sentiment_report,
-		position_info, decision, full_decision, executed, execution_result
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		position_info, decision, full_decision, executed, execution_result,
+		sizing_policy, decision_path
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := s.db.Exec(
+	result, err := s.exec(
 		query,
 		session.BatchID,
 		session.Symbol,
 		session.Timeframe,
 		session.CreatedAt,
-		session.MarketReport,
-		session.CryptoReport,
-		session.SentimentReport,
+		s.encodeBlob(session.MarketReport),
+		s.encodeBlob(session.CryptoReport),
+		s.encodeBlob(session.SentimentReport),
 		session.PositionInfo,
 		session.Decision,
-		session.FullDecision,
+		s.encodeBlob(session.FullDecision),
 		session.Executed,
 		session.ExecutionResult,
+		session.SizingPolicy,
+		session.DecisionPath,
 	)
 
 	if err != nil {
@@ -244,15 +437,19 @@ func (s *Storage) SaveSession(session *TradingSession) (int64, error) {
 		return 0, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
+	if session.Structured != nil {
+		if err := s.SaveStructuredDecision(id, session.Structured); err != nil {
+			return id, err
+		}
+	}
+
 	return id, nil
 }
 
 // GetLatestSessions retrieves the latest N sessions
 func (s *Storage) GetLatestSessions(limit int) ([]*TradingSession, error) {
 	query := `
-	SELECT id, batch_id, symbol, timeframe, created_at,
-		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
+	SELECT ` + sessionColumns + `
 	FROM trading_sessions
 	ORDER BY created_at DESC
 	LIMIT ?
@@ -264,60 +461,19 @@ func (s *Storage) GetLatestSessions(limit int) ([]*TradingSession, error) {
 	}
 	defer rows.Close()
 
-	var sessions []*TradingSession
-	for rows.Next() {
-		session := &TradingSession{}
-		err := rows.Scan(
-			&session.ID,
-			&session.BatchID,
-			&session.Symbol,
-			&session.Timeframe,
-			&session.CreatedAt,
-			&session.MarketReport,
-			&session.CryptoReport,
-			&session.SentimentReport,
-			&session.PositionInfo,
-			&session.Decision,
-			&session.FullDecision,
-			&session.Executed,
-			&session.ExecutionResult,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan session: %w", err)
-		}
-		sessions = append(sessions, session)
-	}
-
-	return sessions, rows.Err()
+	return scanSessionRows(rows)
 }
 
 // GetSessionByID retrieves a session by its ID
 // GetSessionByID 根据 ID 获取会话
 func (s *Storage) GetSessionByID(id int64) (*TradingSession, error) {
 	query := `
-	SELECT id, batch_id, symbol, timeframe, created_at,
-		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
+	SELECT ` + sessionColumns + `
 	FROM trading_sessions
 	WHERE id = ?
 	`
 
-	session := &TradingSession{}
-	err := s.db.QueryRow(query, id).Scan(
-		&session.ID,
-		&session.BatchID,
-		&session.Symbol,
-		&session.Timeframe,
-		&session.CreatedAt,
-		&session.MarketReport,
-		&session.CryptoReport,
-		&session.SentimentReport,
-		&session.PositionInfo,
-		&session.Decision,
-		&session.FullDecision,
-		&session.Executed,
-		&session.ExecutionResult,
-	)
+	session, err := scanSession(s.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("session not found: %d", id)
@@ -369,47 +525,8 @@ func (s *Storage) GetLatestBatches(limit int) ([]*BatchSession, error) {
 
 	// For each batch, get all sessions
 	// 对于每个批次，获取所有会话
-	sessionQuery := `
-	SELECT id, batch_id, symbol, timeframe, created_at,
-		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
-	FROM trading_sessions
-	WHERE batch_id = ?
-	ORDER BY symbol
-	`
-
 	for _, batch := range batches {
-		sessionRows, err := s.db.Query(sessionQuery, batch.BatchID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to query sessions for batch %s: %w", batch.BatchID, err)
-		}
-
-		for sessionRows.Next() {
-			session := &TradingSession{}
-			err := sessionRows.Scan(
-				&session.ID,
-				&session.BatchID,
-				&session.Symbol,
-				&session.Timeframe,
-				&session.CreatedAt,
-				&session.MarketReport,
-				&session.CryptoReport,
-				&session.SentimentReport,
-				&session.PositionInfo,
-				&session.Decision,
-				&session.FullDecision,
-				&session.Executed,
-				&session.ExecutionResult,
-			)
-			if err != nil {
-				sessionRows.Close()
-				return nil, fmt.Errorf("failed to scan session: %w", err)
-			}
-			batch.Sessions = append(batch.Sessions, session)
-		}
-		sessionRows.Close()
-
-		if err := sessionRows.Err(); err != nil {
+		if batch.Sessions, err = s.GetSessionsByBatch(batch.BatchID); err != nil {
 			return nil, err
 		}
 	}
@@ -420,9 +537,7 @@ func (s *Storage) GetLatestBatches(limit int) ([]*BatchSession, error) {
 // GetSessionsBySymbol retrieves sessions for a specific symbol
 func (s *Storage) GetSessionsBySymbol(symbol string, limit int) ([]*TradingSession, error) {
 	query := `
-	SELECT id, batch_id, symbol, timeframe, created_at,
-		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
+	SELECT ` + sessionColumns + `
 	FROM trading_sessions
 	WHERE symbol = ?
 	ORDER BY created_at DESC
@@ -435,24 +550,90 @@ func (s *Storage) GetSessionsBySymbol(symbol string, limit int) ([]*TradingSessi
 	}
 	defer rows.Close()
 
+	return scanSessionRows(rows)
+}
+
+// GetSessionsByBatch retrieves all sessions of one run (one per symbol), ordered by symbol
+// GetSessionsByBatch 获取同一次运行的所有会话（每个交易对一条），按交易对排序
+func (s *Storage) GetSessionsByBatch(batchID string) ([]*TradingSession, error) {
+	query := `
+	SELECT ` + sessionColumns + `
+	FROM trading_sessions
+	WHERE batch_id = ?
+	ORDER BY symbol
+	`
+
+	rows, err := s.db.Query(query, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions for batch %s: %w", batchID, err)
+	}
+	defer rows.Close()
+
+	return scanSessionRows(rows)
+}
+
+// GetBatch retrieves one batch with all its sessions, or nil if the batch does not exist
+// GetBatch 获取单个批次及其所有会话，批次不存在时返回 nil
+func (s *Storage) GetBatch(batchID string) (*BatchSession, error) {
+	if batchID == "" {
+		return nil, nil
+	}
+	sessions, err := s.GetSessionsByBatch(batchID)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+
+	batch := &BatchSession{BatchID: batchID, CreatedAt: sessions[0].CreatedAt, Timeframe: sessions[0].Timeframe, Sessions: sessions}
+	for _, session := range sessions[1:] {
+		if session.CreatedAt.Before(batch.CreatedAt) {
+			batch.CreatedAt = session.CreatedAt
+		}
+	}
+	return batch, nil
+}
+
+// sessionColumns are the columns read by scanSession; nullable text columns of older databases read as empty strings
+// sessionColumns 是 scanSession 读取的列；旧数据库中可为 NULL 的文本字段读取为空字符串
+const sessionColumns = `id, COALESCE(batch_id, ''), symbol, timeframe, created_at,
+		   COALESCE(market_report, ''), COALESCE(crypto_report, ''), COALESCE(sentiment_report, ''),
+		   COALESCE(position_info, ''), COALESCE(decision, ''), COALESCE(full_decision, ''),
+		   executed, COALESCE(execution_result, ''),
+		   COALESCE(sizing_policy, ''), COALESCE(decision_path, '')`
+
+// scanSession scans one row selected with sessionColumns and decompresses its reports
+// scanSession 扫描一行按 sessionColumns 查询的结果并解压报告字段
+func scanSession(row interface{ Scan(dest ...any) error }) (*TradingSession, error) {
+	session := &TradingSession{}
+	err := row.Scan(
+		&session.ID,
+		&session.BatchID,
+		&session.Symbol,
+		&session.Timeframe,
+		&session.CreatedAt,
+		&session.MarketReport,
+		&session.CryptoReport,
+		&session.SentimentReport,
+		&session.PositionInfo,
+		&session.Decision,
+		&session.FullDecision,
+		&session.Executed,
+		&session.ExecutionResult,
+		&session.SizingPolicy,
+		&session.DecisionPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+	decodeSessionBlobs(session)
+	return session, nil
+}
+
+// scanSessionRows scans rows selected with sessionColumns
+// scanSessionRows 扫描按 sessionColumns 查询的行
+func scanSessionRows(rows *sql.Rows) ([]*TradingSession, error) {
 	var sessions []*TradingSession
 	for rows.Next() {
-		session := &TradingSession{}
-		err := rows.Scan(
-			&session.ID,
-			&session.BatchID,
-			&session.Symbol,
-			&session.Timeframe,
-			&session.CreatedAt,
-			&session.MarketReport,
-			&session.CryptoReport,
-			&session.SentimentReport,
-			&session.PositionInfo,
-			&session.Decision,
-			&session.FullDecision,
-			&session.Executed,
-			&session.ExecutionResult,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
@@ -462,47 +643,124 @@ func (s *Storage) GetSessionsBySymbol(symbol string, limit int) ([]*TradingSessi
 	return sessions, rows.Err()
 }
 
-// GetSessionStats returns statistics about trading sessions
-func (s *Storage) GetSessionStats(symbol string) (map[string]interface{}, error) {
-	query := `
-	SELECT
-		COUNT(*) as total_sessions,
-		COALESCE(SUM(CASE WHEN executed = 1 THEN 1 ELSE 0 END), 0) as executed_count,
-		COALESCE(MIN(created_at), '') as first_session,
-		COALESCE(MAX(created_at), '') as last_session
-	FROM trading_sessions
-	WHERE symbol = ?
-	`
+// SessionStats summarizes the trading sessions of a symbol
+// SessionStats 汇总交易对的交易会话
+type SessionStats struct {
+	TotalSessions        int                        `json:"total_sessions"`         // 会话总数 / Total sessions
+	ExecutedCount        int                        `json:"executed_count"`         // 已执行会话数 / Executed sessions
+	ExecutionRate        float64                    `json:"execution_rate"`         // 已执行占全部会话的百分比 / % of all sessions executed
+	ActionableCount      int                        `json:"actionable_count"`       // 非 HOLD 的有效决策数 / Valid decisions other than HOLD
+	ExecutionSuccessRate float64                    `json:"execution_success_rate"` // 非 HOLD 决策中执行成功的百分比 / % of actionable decisions executed
+	Actions              map[string]int             `json:"actions"`                // 按决策动作统计（无结构化决策为 UNKNOWN）/ Count per action (UNKNOWN without a structured decision)
+	AvgConfidence        float64                    `json:"avg_confidence"`         // 结构化决策的平均置信度 0-1 / Average confidence of structured decisions 0-1
+	Timeframes           map[string]*TimeframeStats `json:"timeframes"`             // 按 K 线周期拆分 / Split per timeframe
+	FirstSession         string                     `json:"first_session"`          // 首次会话时间 / First session time
+	LastSession          string                     `json:"last_session"`           // 最近会话时间 / Last session time
+}
 
-	var totalSessions, executedCount int
-	var firstSession, lastSession string
+// TimeframeStats summarizes the sessions of one timeframe
+// TimeframeStats 汇总单个 K 线周期的会话
+type TimeframeStats struct {
+	Sessions      int            `json:"sessions"`       // 会话数 / Sessions
+	Executed      int            `json:"executed"`       // 已执行会话数 / Executed sessions
+	ExecutionRate float64        `json:"execution_rate"` // 已执行百分比 / % executed
+	Actions       map[string]int `json:"actions"`        // 按决策动作统计 / Count per action
+}
 
-	err := s.db.QueryRow(query, symbol).Scan(
-		&totalSessions,
-		&executedCount,
-		&firstSession,
-		&lastSession,
-	)
+// sessionActionUnknown labels sessions stored without a structured decision
+// sessionActionUnknown 标记没有结构化决策的会话
+const sessionActionUnknown = "UNKNOWN"
+
+// GetSessionStats returns statistics about the trading sessions of a symbol
+// GetSessionStats 返回交易对的交易会话统计
+//
+// Actions and confidence come from the structured decision; sessions saved before it existed count as UNKNOWN.
+// 动作和置信度来自结构化决策；在其之前保存的会话计为 UNKNOWN。
+func (s *Storage) GetSessionStats(symbol string) (*SessionStats, error) {
+	stats := &SessionStats{
+		Actions:    make(map[string]int),
+		Timeframes: make(map[string]*TimeframeStats),
+	}
 
+	err := s.db.QueryRow(`
+	SELECT COALESCE(MIN(created_at), ''), COALESCE(MAX(created_at), '')
+	FROM trading_sessions
+	WHERE symbol = ?
+	`, symbol).Scan(&stats.FirstSession, &stats.LastSession)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stats: %w", err)
 	}
 
-	stats := map[string]interface{}{
-		"total_sessions": totalSessions,
-		"executed_count": executedCount,
-		"first_session":  firstSession,
-		"last_session":   lastSession,
-		"execution_rate": 0.0,
+	rows, err := s.db.Query(`
+	SELECT COALESCE(timeframe, ''), COALESCE(decision_action, ''), COALESCE(decision_valid, 0),
+		   COUNT(*), COALESCE(SUM(CASE WHEN executed = 1 THEN 1 ELSE 0 END), 0),
+		   COALESCE(SUM(decision_confidence), 0), COUNT(decision_confidence)
+	FROM trading_sessions
+	WHERE symbol = ?
+	GROUP BY 1, 2, 3
+	`, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
 	}
+	defer rows.Close()
+
+	var actionableExecuted, confidenceCount int
+	var confidenceSum float64
+	for rows.Next() {
+		var timeframe, action string
+		var valid bool
+		var count, executed, confidenced int
+		var confidence float64
+		if err := rows.Scan(&timeframe, &action, &valid, &count, &executed, &confidence, &confidenced); err != nil {
+			return nil, fmt.Errorf("failed to scan stats: %w", err)
+		}
+		if action == "" {
+			action = sessionActionUnknown
+		}
+
+		stats.TotalSessions += count
+		stats.ExecutedCount += executed
+		stats.Actions[action] += count
+		confidenceSum += confidence
+		confidenceCount += confidenced
+		if valid && action != "HOLD" && action != sessionActionUnknown {
+			stats.ActionableCount += count
+			actionableExecuted += executed
+		}
 
-	if totalSessions > 0 {
-		stats["execution_rate"] = float64(executedCount) / float64(totalSessions) * 100
+		tf := stats.Timeframes[timeframe]
+		if tf == nil {
+			tf = &TimeframeStats{Actions: make(map[string]int)}
+			stats.Timeframes[timeframe] = tf
+		}
+		tf.Sessions += count
+		tf.Executed += executed
+		tf.Actions[action] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
+	stats.ExecutionRate = percentOf(stats.ExecutedCount, stats.TotalSessions)
+	stats.ExecutionSuccessRate = percentOf(actionableExecuted, stats.ActionableCount)
+	if confidenceCount > 0 {
+		stats.AvgConfidence = confidenceSum / float64(confidenceCount)
+	}
+	for _, tf := range stats.Timeframes {
+		tf.ExecutionRate = percentOf(tf.Executed, tf.Sessions)
+	}
 	return stats, nil
 }
 
+// percentOf returns n as a percentage of total (0 when total is 0)
+// percentOf 返回 n 占 total 的百分比（total 为 0 时返回 0）
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
 // UpdateExecutionResult updates the execution result for a session
 func (s *Storage) UpdateExecutionResult(sessionID int64, executed bool, result string) error {
 	query := `
@@ -511,7 +769,7 @@ func (s *Storage) UpdateExecutionResult(sessionID int64, executed bool, result s
 	WHERE id = ?
 	`
 
-	_, err := s.db.Exec(query, executed, result, sessionID)
+	_, err := s.exec(query, executed, result, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to update execution result: %w", err)
 	}
@@ -534,7 +792,7 @@ func (s *Storage) UpdateLatestSessionExecution(symbol string, timeframe string,
 	)
 	`
 
-	_, err := s.db.Exec(query, executed, result, symbol, timeframe, symbol, timeframe)
+	_, err := s.exec(query, executed, result, symbol, timeframe, symbol, timeframe)
 	if err != nil {
 		return fmt.Errorf("failed to update latest session execution: %w", err)
 	}
@@ -545,19 +803,29 @@ func (s *Storage) UpdateLatestSessionExecution(symbol string, timeframe string,
 // SaveBalanceHistory saves account balance snapshot to history
 // SaveBalanceHistory 保存账户余额快照到历史记录
 func (s *Storage) SaveBalanceHistory(balance *BalanceHistory) error {
+	assets, err := encodeBalanceAssets(balance.Assets)
+	if err != nil {
+		return err
+	}
+
 	query := `
 	INSERT INTO balance_history (
-		timestamp, total_balance, available_balance, unrealized_pnl, positions
-	) VALUES (?, ?, ?, ?, ?)
+		timestamp, total_balance, available_balance, unrealized_pnl, positions,
+		margin_balance, maintenance_margin, margin_ratio, assets
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(
+	_, err = s.exec(
 		query,
 		balance.Timestamp,
 		balance.TotalBalance,
 		balance.AvailableBalance,
 		balance.UnrealizedPnL,
 		balance.Positions,
+		balance.MarginBalance,
+		balance.MaintenanceMargin,
+		balance.MarginRatio,
+		assets,
 	)
 
 	if err != nil {
@@ -571,7 +839,7 @@ func (s *Storage) SaveBalanceHistory(balance *BalanceHistory) error {
 // GetBalanceHistory 获取最近 N 小时的余额历史
 func (s *Storage) GetBalanceHistory(hours int) ([]*BalanceHistory, error) {
 	query := `
-	SELECT id, timestamp, total_balance, available_balance, unrealized_pnl, positions
+	SELECT ` + balanceHistoryColumns + `
 	FROM balance_history
 	WHERE timestamp >= datetime('now', '-' || ? || ' hours')
 	ORDER BY timestamp ASC
@@ -585,17 +853,9 @@ func (s *Storage) GetBalanceHistory(hours int) ([]*BalanceHistory, error) {
 
 	var history []*BalanceHistory
 	for rows.Next() {
-		h := &BalanceHistory{}
-		err := rows.Scan(
-			&h.ID,
-			&h.Timestamp,
-			&h.TotalBalance,
-			&h.AvailableBalance,
-			&h.UnrealizedPnL,
-			&h.Positions,
-		)
+		h, err := scanBalanceHistory(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan balance history: %w", err)
+			return nil, err
 		}
 		history = append(history, h)
 	}
@@ -611,8 +871,17 @@ func (s *Storage) Close() error {
 	return nil
 }
 
+// NormalizeSymbol returns a symbol in the Binance format positions are stored in ("BTC/USDT" → "BTCUSDT")
+// NormalizeSymbol 返回持仓保存时使用的币安格式交易对（"BTC/USDT" → "BTCUSDT"）
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(symbol), "/", ""))
+}
+
 // SavePosition saves a position to the database
 // SavePosition 保存持仓到数据库
+//
+// The symbol is stored in Binance format (see NormalizeSymbol), so "BTC/USDT" and "BTCUSDT" are the same position.
+// 交易对以币安格式保存（见 NormalizeSymbol），因此 "BTC/USDT" 与 "BTCUSDT" 是同一持仓。
 func (s *Storage) SavePosition(pos *PositionRecord) error {
 	query := `
 	INSERT INTO positions (
@@ -623,9 +892,9 @@ func (s *Storage) SavePosition(pos *PositionRecord) error {
 	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		query,
-		pos.ID, pos.Symbol, pos.Side, pos.EntryPrice, pos.EntryTime, pos.Quantity, pos.Leverage,
+		pos.ID, NormalizeSymbol(pos.Symbol), pos.Side, pos.EntryPrice, pos.EntryTime, pos.Quantity, pos.Leverage,
 		pos.InitialStopLoss, pos.CurrentStopLoss, pos.StopLossType,
 		pos.TrailingDistance, pos.HighestPrice, pos.CurrentPrice,
 		pos.UnrealizedPnL, pos.OpenReason, pos.ATR, pos.StopLossOrderID, pos.Closed,
@@ -658,7 +927,7 @@ func (s *Storage) UpdatePosition(pos *PositionRecord) error {
 	WHERE id = ?
 	`
 
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		query,
 		pos.CurrentStopLoss, pos.StopLossType, pos.TrailingDistance,
 		pos.HighestPrice, pos.CurrentPrice, pos.UnrealizedPnL,
@@ -760,7 +1029,7 @@ func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error)
 	LIMIT 20
 	`
 
-	rows, err := s.db.Query(query, symbol)
+	rows, err := s.db.Query(query, NormalizeSymbol(symbol))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query positions: %w", err)
 	}
@@ -817,6 +1086,154 @@ func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error)
 	return positions, rows.Err()
 }
 
+// PositionFilter selects the positions returned by GetPositions
+// PositionFilter 选择 GetPositions 返回的持仓
+type PositionFilter struct {
+	Symbol string // 交易对，空表示全部 / Symbol, empty = all
+	Open   bool   // 只返回未平仓持仓 / Open positions only
+	Closed bool   // 只返回已平仓持仓 / Closed positions only
+	Limit  int    // 最大数量，0 表示不限 / Max rows, 0 = unlimited
+
+	From time.Time // 开仓时间下限（含），零值不限 / Earliest entry time (inclusive), zero = unbounded
+	To   time.Time // 开仓时间上限（不含），零值不限 / Latest entry time (exclusive), zero = unbounded
+}
+
+// GetPositions retrieves positions matching the filter, newest entry first
+// GetPositions 获取符合过滤条件的持仓，按开仓时间倒序
+func (s *Storage) GetPositions(filter PositionFilter) ([]*PositionRecord, error) {
+	query := `
+	SELECT ` + positionColumns + `
+	FROM positions
+	WHERE 1 = 1`
+	var args []interface{}
+	if filter.Symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, NormalizeSymbol(filter.Symbol))
+	}
+	if !filter.From.IsZero() {
+		query += ` AND entry_time >= ?`
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		query += ` AND entry_time < ?`
+		args = append(args, filter.To)
+	}
+	if filter.Open && !filter.Closed {
+		query += ` AND closed = 0`
+	} else if filter.Closed && !filter.Open {
+		query += ` AND closed = 1`
+	}
+	query += ` ORDER BY entry_time DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query positions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPositionRows(rows)
+}
+
+// positionColumns are the columns read by scanPositionRows
+// positionColumns 是 scanPositionRows 读取的列
+const positionColumns = `id, symbol, side, entry_price, entry_time, quantity, leverage,
+		   initial_stop_loss, current_stop_loss, stop_loss_type,
+		   trailing_distance, highest_price, current_price,
+		   unrealized_pnl, open_reason, atr, stop_loss_order_id, closed,
+		   close_time, close_price, close_reason, realized_pnl`
+
+// scanPositionRows scans rows selected with positionColumns
+// scanPositionRows 扫描按 positionColumns 查询的行
+func scanPositionRows(rows *sql.Rows) ([]*PositionRecord, error) {
+	var positions []*PositionRecord
+	for rows.Next() {
+		pos := &PositionRecord{}
+		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL sql.NullFloat64
+		var closeTime sql.NullTime
+		var closeReason, stopLossOrderID sql.NullString
+
+		err := rows.Scan(
+			&pos.ID, &pos.Symbol, &pos.Side, &pos.EntryPrice, &pos.EntryTime, &pos.Quantity, &pos.Leverage,
+			&pos.InitialStopLoss, &pos.CurrentStopLoss, &pos.StopLossType,
+			&trailingDistance, &pos.HighestPrice, &pos.CurrentPrice,
+			&unrealizedPnL, &pos.OpenReason, &atr, &stopLossOrderID, &pos.Closed,
+			&closeTime, &closePrice, &closeReason, &realizedPnL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+
+		// Handle NULL values
+		// 处理 NULL 值
+		pos.TrailingDistance = trailingDistance.Float64
+		pos.UnrealizedPnL = unrealizedPnL.Float64
+		pos.ATR = atr.Float64
+		pos.StopLossOrderID = stopLossOrderID.String
+		if closeTime.Valid {
+			pos.CloseTime = &closeTime.Time
+		}
+		pos.ClosePrice = closePrice.Float64
+		pos.CloseReason = closeReason.String
+		pos.RealizedPnL = realizedPnL.Float64
+
+		positions = append(positions, pos)
+	}
+
+	return positions, rows.Err()
+}
+
+// GetRecentClosedPositions returns the latest closed positions of a symbol, most recently closed first
+// GetRecentClosedPositions 返回某个交易对最近平仓的持仓，按平仓时间倒序
+//
+// "BTC/USDT" and "BTCUSDT" match the same positions.
+// "BTC/USDT" 与 "BTCUSDT" 匹配相同的持仓。
+func (s *Storage) GetRecentClosedPositions(symbol string, limit int) ([]*PositionRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT `+positionColumns+`
+	FROM positions
+	WHERE closed = 1 AND symbol = ?
+	ORDER BY close_time DESC
+	LIMIT ?
+	`, NormalizeSymbol(symbol), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed positions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPositionRows(rows)
+}
+
+// GetPositionsClosedSince returns the positions of all symbols closed at or after since, oldest first
+// GetPositionsClosedSince 返回指定时间及之后平仓的所有交易对持仓，按平仓时间正序
+func (s *Storage) GetPositionsClosedSince(since time.Time) ([]*PositionRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT `+positionColumns+`
+	FROM positions
+	WHERE closed = 1 AND close_time >= ?
+	ORDER BY close_time ASC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed positions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPositionRows(rows)
+}
+
+// CountPositionsOpenedSince counts positions (open or closed) entered at or after since
+// CountPositionsOpenedSince 统计指定时间及之后开仓的持仓数（包括已平仓）
+func (s *Storage) CountPositionsOpenedSince(since time.Time) (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM positions WHERE entry_time >= ?`, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count positions: %w", err)
+	}
+	return count, nil
+}
+
 // GetPositionByID retrieves a single position by its ID
 // GetPositionByID 根据 ID 获取单个持仓
 func (s *Storage) GetPositionByID(positionID string) (*PositionRecord, error) {
@@ -892,7 +1309,7 @@ func (s *Storage) SaveStopLossEvent(event *StopLossEvent) error {
 	) VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(
+	_, err := s.exec(
 		query,
 		event.PositionID, event.Timestamp, event.OldStop,
 		event.NewStop, event.Reason, event.Trigger,
@@ -953,7 +1370,7 @@ func (s *Storage) GetTotalSessionCount() (int, error) {
 // GetTotalBatchCount 获取唯一批次总数
 func (s *Storage) GetTotalBatchCount() (int, error) {
 	var count int
-	query := "SELECT COUNT(DISTINCT batch_id) FROM trading_sessions"
+	query := "SELECT COUNT(DISTINCT batch_id) FROM trading_sessions WHERE batch_id IS NOT NULL AND batch_id != ''"
 	err := s.db.QueryRow(query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count batches: %w", err)
@@ -972,6 +1389,7 @@ func (s *Storage) GetBatchesWithPagination(offset, limit int) ([]*BatchSession,
 	INNER JOIN (
 		SELECT batch_id, MIN(id) as min_id
 		FROM trading_sessions
+		WHERE batch_id IS NOT NULL AND batch_id != ''
 		GROUP BY batch_id
 	) t2 ON t1.batch_id = t2.batch_id AND t1.id = t2.min_id
 	ORDER BY t1.created_at DESC
@@ -1022,9 +1440,7 @@ func (s *Storage) GetBatchesWithPagination(offset, limit int) ([]*BatchSession,
 	// Get all sessions for these batches
 	// 获取这些批次的所有会话
 	sessionsQuery := fmt.Sprintf(`
-	SELECT id, batch_id, symbol, timeframe, created_at,
-		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
+	SELECT `+sessionColumns+`
 	FROM trading_sessions
 	WHERE batch_id IN (%s)
 	ORDER BY batch_id, symbol
@@ -1047,22 +1463,7 @@ func (s *Storage) GetBatchesWithPagination(offset, limit int) ([]*BatchSession,
 	// 按 batch_id 分组会话
 	sessionsByBatch := make(map[string][]*TradingSession)
 	for sessionRows.Next() {
-		session := &TradingSession{}
-		err := sessionRows.Scan(
-			&session.ID,
-			&session.BatchID,
-			&session.Symbol,
-			&session.Timeframe,
-			&session.CreatedAt,
-			&session.MarketReport,
-			&session.CryptoReport,
-			&session.SentimentReport,
-			&session.PositionInfo,
-			&session.Decision,
-			&session.FullDecision,
-			&session.Executed,
-			&session.ExecutionResult,
-		)
+		session, err := scanSession(sessionRows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"database/sql"
+	"math"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -43,6 +46,8 @@ func TestSaveAndGetSession(t *testing.T) {
 		PositionInfo:    "No position",
 		Decision:        "BUY at 50000",
 		Executed:        false,
+		SizingPolicy:    "fixed (1000.00 USDT)",
+		DecisionPath:    "primary gpt-4o-mini (retry 1)",
 	}
 
 	// 保存会话
@@ -75,6 +80,12 @@ func TestSaveAndGetSession(t *testing.T) {
 	if retrieved.Decision != session.Decision {
 		t.Errorf("Decision mismatch: expected %s, got %s", session.Decision, retrieved.Decision)
 	}
+	if retrieved.SizingPolicy != session.SizingPolicy {
+		t.Errorf("SizingPolicy mismatch: expected %s, got %s", session.SizingPolicy, retrieved.SizingPolicy)
+	}
+	if retrieved.DecisionPath != session.DecisionPath {
+		t.Errorf("DecisionPath mismatch: expected %s, got %s", session.DecisionPath, retrieved.DecisionPath)
+	}
 }
 
 func TestGetSessionsBySymbol(t *testing.T) {
@@ -120,6 +131,111 @@ func TestGetSessionsBySymbol(t *testing.T) {
 	}
 }
 
+func TestGetSessionsByBatch(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 同一批次的两个交易对，另一个批次，以及一条没有 batch_id 的旧会话
+	now := time.Now()
+	for _, session := range []*TradingSession{
+		{BatchID: "batch-1", Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: now, Decision: "HOLD", FullDecision: "全部交易对的完整决策"},
+		{BatchID: "batch-1", Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.Add(-time.Second), Decision: "BUY", FullDecision: "全部交易对的完整决策"},
+		{BatchID: "batch-2", Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.Add(time.Hour), Decision: "SELL"},
+	} {
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+	if _, err := db.db.Exec(`INSERT INTO trading_sessions (symbol, timeframe, created_at) VALUES ('BTC/USDT', '1h', ?)`, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("Insert legacy session failed: %v", err)
+	}
+
+	sessions, err := db.GetSessionsByBatch("batch-1")
+	if err != nil {
+		t.Fatalf("GetSessionsByBatch failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Symbol != "BTC/USDT" || sessions[1].Symbol != "ETH/USDT" {
+		t.Fatalf("Expected BTC/USDT and ETH/USDT in batch-1, got %+v", sessions)
+	}
+	if sessions[0].BatchID != "batch-1" || sessions[0].FullDecision != "全部交易对的完整决策" {
+		t.Errorf("BatchID/FullDecision not persisted: %q / %q", sessions[0].BatchID, sessions[0].FullDecision)
+	}
+
+	batch, err := db.GetBatch("batch-1")
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if batch == nil || len(batch.Sessions) != 2 || !batch.CreatedAt.Equal(sessions[0].CreatedAt) {
+		t.Errorf("Unexpected batch: %+v", batch)
+	}
+	if batch, err := db.GetBatch("missing"); err != nil || batch != nil {
+		t.Errorf("Expected nil for a missing batch, got %+v, %v", batch, err)
+	}
+
+	// The legacy session reads with empty batch fields and is not counted as a batch
+	// 旧会话的批次字段读取为空，且不计为批次
+	latest, err := db.GetLatestSessions(10)
+	if err != nil {
+		t.Fatalf("GetLatestSessions failed: %v", err)
+	}
+	if len(latest) != 4 || latest[3].BatchID != "" || latest[3].FullDecision != "" {
+		t.Errorf("Expected the legacy session last with empty batch fields, got %+v", latest)
+	}
+	if count, err := db.GetTotalBatchCount(); err != nil || count != 2 {
+		t.Errorf("Expected 2 batches, got %d, %v", count, err)
+	}
+	batches, err := db.GetBatchesWithPagination(0, 10)
+	if err != nil {
+		t.Fatalf("GetBatchesWithPagination failed: %v", err)
+	}
+	if len(batches) != 2 || batches[0].BatchID != "batch-2" || len(batches[1].Sessions) != 2 {
+		t.Errorf("Unexpected batches: %+v", batches)
+	}
+}
+
+func TestMigrateLegacySessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// 创建没有 batch_id / full_decision 字段的旧表
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := legacy.Exec(`CREATE TABLE trading_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		timeframe TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		market_report TEXT,
+		crypto_report TEXT,
+		sentiment_report TEXT,
+		position_info TEXT,
+		decision TEXT,
+		executed BOOLEAN DEFAULT 0,
+		execution_result TEXT
+	)`); err != nil {
+		t.Fatalf("Create legacy table failed: %v", err)
+	}
+	legacy.Close()
+
+	db, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("NewStorage on a legacy database failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.SaveSession(&TradingSession{BatchID: "batch-1", Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: time.Now(), FullDecision: "完整决策"}); err != nil {
+		t.Fatalf("SaveSession after migration failed: %v", err)
+	}
+	sessions, err := db.GetSessionsByBatch("batch-1")
+	if err != nil || len(sessions) != 1 || sessions[0].FullDecision != "完整决策" {
+		t.Errorf("Expected the migrated session, got %+v, %v", sessions, err)
+	}
+}
+
 func TestGetSessionStats(t *testing.T) {
 	tmpDB := "./test_trading_stats.db"
 	defer os.Remove(tmpDB)
@@ -152,29 +268,83 @@ func TestGetSessionStats(t *testing.T) {
 	}
 
 	// 检查统计数据
-	totalSessions, ok := stats["total_sessions"].(int)
-	if !ok {
-		t.Fatal("total_sessions should be int")
+	if stats.TotalSessions != 3 {
+		t.Errorf("Expected 3 total sessions for BTC/USDT, got: %d", stats.TotalSessions)
+	}
+	if stats.ExecutedCount != 2 {
+		t.Errorf("Expected 2 executed sessions, got: %d", stats.ExecutedCount)
 	}
-	if totalSessions != 3 {
-		t.Errorf("Expected 3 total sessions for BTC/USDT, got: %d", totalSessions)
+	expectedRate := 66.67
+	if stats.ExecutionRate < expectedRate-1 || stats.ExecutionRate > expectedRate+1 {
+		t.Errorf("Expected execution rate around %.2f%%, got: %.2f%%", expectedRate, stats.ExecutionRate)
 	}
 
-	executedCount, ok := stats["executed_count"].(int)
-	if !ok {
-		t.Fatal("executed_count should be int")
+	// 没有结构化决策的会话计为 UNKNOWN
+	if stats.Actions["UNKNOWN"] != 3 {
+		t.Errorf("Expected 3 UNKNOWN actions without structured decisions, got: %v", stats.Actions)
 	}
-	if executedCount != 2 {
-		t.Errorf("Expected 2 executed sessions, got: %d", executedCount)
+}
+
+// TestGetSessionStatsBreakdown tests the action, confidence and timeframe breakdowns
+// TestGetSessionStatsBreakdown 测试按动作、置信度和 K 线周期的统计
+func TestGetSessionStatsBreakdown(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
 	}
+	defer db.Close()
 
-	executionRate, ok := stats["execution_rate"].(float64)
-	if !ok {
-		t.Fatal("execution_rate should be float64")
+	decision := func(action string, confidence float64, valid bool) *StructuredDecision {
+		return &StructuredDecision{Action: action, Confidence: confidence, Valid: valid, Source: "json"}
 	}
-	expectedRate := 66.67
-	if executionRate < expectedRate-1 || executionRate > expectedRate+1 {
-		t.Errorf("Expected execution rate around %.2f%%, got: %.2f%%", expectedRate, executionRate)
+	sessions := []*TradingSession{
+		{Timeframe: "1h", Executed: true, Structured: decision("BUY", 0.8, true)},
+		{Timeframe: "1h", Executed: false, Structured: decision("SELL", 0.6, true)}, // 执行失败 / Failed to execute
+		{Timeframe: "1h", Executed: false, Structured: decision("HOLD", 0.4, true)},
+		{Timeframe: "4h", Executed: true, Structured: decision("CLOSE_LONG", 0.6, true)},
+		{Timeframe: "4h", Executed: false, Structured: decision("BUY", 0.2, false)}, // 无效决策 / Invalid decision
+		{Timeframe: "4h", Executed: false},
+	}
+	for _, session := range sessions {
+		session.Symbol = "BTC/USDT"
+		session.CreatedAt = time.Now()
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	stats, err := db.GetSessionStats("BTC/USDT")
+	if err != nil {
+		t.Fatalf("GetSessionStats failed: %v", err)
+	}
+
+	wantActions := map[string]int{"BUY": 2, "SELL": 1, "HOLD": 1, "CLOSE_LONG": 1, "UNKNOWN": 1}
+	for action, want := range wantActions {
+		if stats.Actions[action] != want {
+			t.Errorf("Actions[%s] = %d, want %d", action, stats.Actions[action], want)
+		}
+	}
+	// 有效的非 HOLD 决策：BUY、SELL、CLOSE_LONG，其中 2 个已执行
+	if stats.ActionableCount != 3 || math.Abs(stats.ExecutionSuccessRate-66.67) > 0.01 {
+		t.Errorf("Actionable = %d, success rate = %.2f; want 3, 66.67", stats.ActionableCount, stats.ExecutionSuccessRate)
+	}
+	// (0.8 + 0.6 + 0.4 + 0.6 + 0.2) / 5
+	if math.Abs(stats.AvgConfidence-0.52) > 1e-9 {
+		t.Errorf("AvgConfidence = %.4f, want 0.52", stats.AvgConfidence)
+	}
+
+	h1, h4 := stats.Timeframes["1h"], stats.Timeframes["4h"]
+	if h1 == nil || h1.Sessions != 3 || h1.Executed != 1 || h1.Actions["SELL"] != 1 {
+		t.Errorf("Unexpected 1h stats: %+v", h1)
+	}
+	if h4 == nil || h4.Sessions != 3 || h4.Executed != 1 || math.Abs(h4.ExecutionRate-33.33) > 0.01 {
+		t.Errorf("Unexpected 4h stats: %+v", h4)
+	}
+
+	// 没有会话的交易对返回零值
+	empty, err := db.GetSessionStats("ETH/USDT")
+	if err != nil || empty.TotalSessions != 0 || empty.ExecutionRate != 0 || len(empty.Actions) != 0 {
+		t.Errorf("Expected empty stats, got %+v, %v", empty, err)
 	}
 }
 
@@ -228,3 +398,106 @@ func TestUpdateExecutionResult(t *testing.T) {
 			executionResult, updated.ExecutionResult)
 	}
 }
+
+// TestGetPositions tests filtering positions by symbol and open/closed status
+// TestGetPositions 测试按交易对和持仓状态过滤持仓
+func TestGetPositions(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "positions.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for i, pos := range []*PositionRecord{
+		{ID: "btc-1", Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, EntryTime: now.Add(-3 * time.Hour), Quantity: 1, Closed: true},
+		{ID: "eth-1", Symbol: "ETHUSDT", Side: "short", EntryPrice: 10, EntryTime: now.Add(-2 * time.Hour), Quantity: 5},
+		{ID: "btc-2", Symbol: "BTCUSDT", Side: "short", EntryPrice: 110, EntryTime: now.Add(-time.Hour), Quantity: 1},
+	} {
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition %d failed: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter PositionFilter
+		want   []string
+	}{
+		{name: "All", filter: PositionFilter{}, want: []string{"btc-2", "eth-1", "btc-1"}},
+		{name: "Open", filter: PositionFilter{Open: true}, want: []string{"btc-2", "eth-1"}},
+		{name: "Closed", filter: PositionFilter{Closed: true}, want: []string{"btc-1"}},
+		{name: "Symbol", filter: PositionFilter{Symbol: "BTCUSDT"}, want: []string{"btc-2", "btc-1"}},
+		{name: "Limit", filter: PositionFilter{Limit: 1}, want: []string{"btc-2"}},
+		{name: "Range", filter: PositionFilter{From: now.Add(-150 * time.Minute), To: now.Add(-30 * time.Minute)}, want: []string{"btc-2", "eth-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, err := db.GetPositions(tt.filter)
+			if err != nil {
+				t.Fatalf("GetPositions failed: %v", err)
+			}
+			if len(positions) != len(tt.want) {
+				t.Fatalf("Expected %d positions, got %d", len(tt.want), len(positions))
+			}
+			for i, id := range tt.want {
+				if positions[i].ID != id {
+					t.Errorf("Position %d: expected %s, got %s", i, id, positions[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRecentClosedPositions(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "closed.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// Symbols in both formats, closed in a different order than opened
+	// 两种格式的交易对，平仓顺序与开仓顺序不同
+	now := time.Now()
+	for i, p := range []struct {
+		id, symbol string
+		opened     time.Duration
+		closed     time.Duration // 0 = still open
+	}{
+		{"btc-1", "BTC/USDT", 5 * time.Hour, time.Hour},
+		{"btc-2", "BTCUSDT", 4 * time.Hour, 3 * time.Hour},
+		{"btc-3", "BTCUSDT", 2 * time.Hour, 0},
+		{"eth-1", "ETHUSDT", 3 * time.Hour, 2 * time.Hour},
+	} {
+		pos := &PositionRecord{ID: p.id, Symbol: p.symbol, Side: "long", EntryPrice: 100, EntryTime: now.Add(-p.opened), Quantity: 1}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition %d failed: %v", i, err)
+		}
+		if p.closed > 0 {
+			closeTime := now.Add(-p.closed)
+			pos.Closed, pos.CloseTime, pos.ClosePrice, pos.RealizedPnL = true, &closeTime, 98, -2
+			if err := db.UpdatePosition(pos); err != nil {
+				t.Fatalf("UpdatePosition %d failed: %v", i, err)
+			}
+		}
+	}
+
+	positions, err := db.GetRecentClosedPositions("BTC/USDT", 10)
+	if err != nil {
+		t.Fatalf("GetRecentClosedPositions failed: %v", err)
+	}
+	if len(positions) != 2 || positions[0].ID != "btc-1" || positions[1].ID != "btc-2" {
+		t.Fatalf("Expected btc-1, btc-2 (most recently closed first), got %d positions", len(positions))
+	}
+	if positions[0].ClosePrice != 98 || positions[0].RealizedPnL != -2 || positions[0].CloseTime == nil {
+		t.Errorf("Unexpected close details: %+v", positions[0])
+	}
+	if positions[0].Symbol != "BTCUSDT" {
+		t.Errorf("Expected the symbol to be stored normalized, got %s", positions[0].Symbol)
+	}
+
+	if positions, err := db.GetRecentClosedPositions("BTCUSDT", 1); err != nil || len(positions) != 1 {
+		t.Errorf("Expected the limit to apply, got %d (%v)", len(positions), err)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// StrategyVersion records one prompt / risk configuration and its shadow-only observation window
+// StrategyVersion 记录一个 Prompt / 风控配置版本及其影子模式观察期
+type StrategyVersion struct {
+	ID           int64
+	Fingerprint  string    // 配置指纹 / Configuration fingerprint
+	Description  string    // 配置摘要 / Configuration summary
+	FreezeCycles int       // 观察期周期数（0 表示不观察）/ Cycles of the observation window (0 = none)
+	ShadowCycles int       // 已完成的影子周期数 / Shadow cycles completed
+	Summary      string    // 观察期结束时的对比摘要 / Comparison summary at the end of the window
+	CreatedAt    time.Time // 配置生效时间 / When the configuration took effect
+	ReleasedAt   time.Time // 恢复实盘执行的时间（零值表示仍在观察）/ When live execution resumed (zero = still observing)
+}
+
+// Frozen reports whether the version is still in its shadow-only observation window
+// Frozen 返回该版本是否仍处于影子模式观察期
+func (v *StrategyVersion) Frozen() bool {
+	return v.ReleasedAt.IsZero() && v.ShadowCycles < v.FreezeCycles
+}
+
+// DecisionMix aggregates the structured decisions of a period
+// DecisionMix 汇总一段时间内的结构化决策
+type DecisionMix struct {
+	Count         int            // 有效决策数 / Valid decisions
+	Actions       map[string]int // 各动作次数 / Count per action
+	AvgConfidence float64        // 平均置信度 / Average confidence
+	AvgLeverage   float64        // 开仓决策平均杠杆 / Average leverage of opening decisions
+	AvgPosition   float64        // 开仓决策平均仓位百分比 / Average position size % of opening decisions
+}
+
+// initStrategySchema creates the strategy_versions table if it doesn't exist
+// initStrategySchema 创建 strategy_versions 表（如果不存在）
+func (s *Storage) initStrategySchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS strategy_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		fingerprint TEXT NOT NULL,
+		description TEXT,
+		freeze_cycles INTEGER DEFAULT 0,
+		shadow_cycles INTEGER DEFAULT 0,
+		summary TEXT,
+		created_at DATETIME NOT NULL,
+		released_at DATETIME
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveStrategyVersion stores a new strategy version and returns its ID
+// SaveStrategyVersion 保存新的策略版本并返回其 ID
+func (s *Storage) SaveStrategyVersion(v *StrategyVersion) (int64, error) {
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	var releasedAt interface{}
+	if !v.ReleasedAt.IsZero() {
+		releasedAt = v.ReleasedAt
+	}
+	result, err := s.exec(`
+	INSERT INTO strategy_versions (
+		fingerprint, description, freeze_cycles, shadow_cycles, summary, created_at, released_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, v.Fingerprint, v.Description, v.FreezeCycles, v.ShadowCycles, v.Summary, v.CreatedAt, releasedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save strategy version: %w", err)
+	}
+	v.ID, err = result.LastInsertId()
+	return v.ID, err
+}
+
+// UpdateStrategyVersion stores the shadow progress, summary and release time of a version
+// UpdateStrategyVersion 保存版本的影子周期进度、对比摘要和恢复时间
+func (s *Storage) UpdateStrategyVersion(v *StrategyVersion) error {
+	var releasedAt interface{}
+	if !v.ReleasedAt.IsZero() {
+		releasedAt = v.ReleasedAt
+	}
+	_, err := s.exec(`
+	UPDATE strategy_versions SET shadow_cycles = ?, summary = ?, released_at = ? WHERE id = ?
+	`, v.ShadowCycles, v.Summary, releasedAt, v.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update strategy version: %w", err)
+	}
+	return nil
+}
+
+// GetStrategyVersions retrieves the latest strategy versions, newest first
+// GetStrategyVersions 获取最近的策略版本，按时间倒序
+func (s *Storage) GetStrategyVersions(limit int) ([]*StrategyVersion, error) {
+	rows, err := s.db.Query(`
+	SELECT id, fingerprint, COALESCE(description, ''), freeze_cycles, shadow_cycles,
+		   COALESCE(summary, ''), created_at, released_at
+	FROM strategy_versions
+	ORDER BY id DESC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query strategy versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*StrategyVersion
+	for rows.Next() {
+		v := &StrategyVersion{}
+		var releasedAt sql.NullTime
+		if err := rows.Scan(
+			&v.ID, &v.Fingerprint, &v.Description, &v.FreezeCycles, &v.ShadowCycles,
+			&v.Summary, &v.CreatedAt, &releasedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan strategy version: %w", err)
+		}
+		if releasedAt.Valid {
+			v.ReleasedAt = releasedAt.Time
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetDecisionMix aggregates the structured decisions of sessions created in [from, to) (zero to = now)
+// GetDecisionMix 汇总 [from, to) 期间创建的会话的结构化决策（to 为零值表示至今）
+func (s *Storage) GetDecisionMix(from, to time.Time) (*DecisionMix, error) {
+	if to.IsZero() {
+		to = time.Now().Add(time.Second)
+	}
+	rows, err := s.db.Query(`
+	SELECT decision_action, COALESCE(decision_confidence, 0), COALESCE(decision_leverage, 0),
+		   COALESCE(decision_position_size, 0)
+	FROM trading_sessions
+	WHERE decision_action IS NOT NULL AND decision_valid = 1 AND created_at >= ? AND created_at < ?
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query decision mix: %w", err)
+	}
+	defer rows.Close()
+
+	mix := &DecisionMix{Actions: make(map[string]int)}
+	var opening int
+	for rows.Next() {
+		var action string
+		var confidence, position float64
+		var leverage int
+		if err := rows.Scan(&action, &confidence, &leverage, &position); err != nil {
+			return nil, fmt.Errorf("failed to scan decision: %w", err)
+		}
+		mix.Count++
+		mix.Actions[action]++
+		mix.AvgConfidence += confidence
+		if action == "BUY" || action == "SELL" {
+			opening++
+			mix.AvgLeverage += float64(leverage)
+			mix.AvgPosition += position
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if mix.Count > 0 {
+		mix.AvgConfidence /= float64(mix.Count)
+	}
+	if opening > 0 {
+		mix.AvgLeverage /= float64(opening)
+		mix.AvgPosition /= float64(opening)
+	}
+	return mix, nil
+}
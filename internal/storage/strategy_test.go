@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStrategyVersions(t *testing.T) {
+	tmpDB := "./test_strategy.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	baseline := &StrategyVersion{Fingerprint: "aaa", ReleasedAt: time.Now()}
+	if _, err := db.SaveStrategyVersion(baseline); err != nil {
+		t.Fatalf("SaveStrategyVersion failed: %v", err)
+	}
+	changed := &StrategyVersion{Fingerprint: "bbb", Description: "leverage=5-10", FreezeCycles: 2}
+	if _, err := db.SaveStrategyVersion(changed); err != nil {
+		t.Fatalf("SaveStrategyVersion failed: %v", err)
+	}
+	if !changed.Frozen() || baseline.Frozen() {
+		t.Fatalf("Expected only the changed version to be frozen")
+	}
+
+	changed.ShadowCycles = 2
+	changed.Summary = "done"
+	changed.ReleasedAt = time.Now()
+	if err := db.UpdateStrategyVersion(changed); err != nil {
+		t.Fatalf("UpdateStrategyVersion failed: %v", err)
+	}
+
+	versions, err := db.GetStrategyVersions(10)
+	if err != nil {
+		t.Fatalf("GetStrategyVersions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Fingerprint != "bbb" {
+		t.Fatalf("Expected 2 versions newest first, got %+v", versions)
+	}
+	got := versions[0]
+	if got.ShadowCycles != 2 || got.Summary != "done" || got.ReleasedAt.IsZero() || got.Frozen() {
+		t.Errorf("Unexpected updated version: %+v", got)
+	}
+}
+
+func TestGetDecisionMix(t *testing.T) {
+	tmpDB := "./test_decision_mix.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	decisions := []*StructuredDecision{
+		{Action: "BUY", Confidence: 0.8, Leverage: 10, PositionSizePercent: 20, Valid: true},
+		{Action: "SELL", Confidence: 0.6, Leverage: 6, PositionSizePercent: 10, Valid: true},
+		{Action: "HOLD", Confidence: 0.4, Valid: true},
+		{Action: "BUY", Confidence: 0.9, Leverage: 20, Valid: false}, // 无效决策不计入
+	}
+	for _, d := range decisions {
+		session := &TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now, Structured: d}
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+	// 窗口外的会话不计入
+	old := &TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.Add(-time.Hour),
+		Structured: &StructuredDecision{Action: "CLOSE_LONG", Valid: true}}
+	if _, err := db.SaveSession(old); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	mix, err := db.GetDecisionMix(now.Add(-time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("GetDecisionMix failed: %v", err)
+	}
+	if mix.Count != 3 || mix.Actions["BUY"] != 1 || mix.Actions["CLOSE_LONG"] != 0 {
+		t.Fatalf("Unexpected mix: %+v", mix)
+	}
+	if mix.AvgConfidence < 0.599 || mix.AvgConfidence > 0.601 {
+		t.Errorf("AvgConfidence = %.3f, want 0.6", mix.AvgConfidence)
+	}
+	if mix.AvgLeverage != 8 || mix.AvgPosition != 15 {
+		t.Errorf("Opening averages = %.1fx / %.1f%%, want 8x / 15%%", mix.AvgLeverage, mix.AvgPosition)
+	}
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ProfitSweep records a transfer of profits from the futures wallet to the spot wallet
+// ProfitSweep 记录一次从合约钱包划转到现货钱包的利润提取
+type ProfitSweep struct {
+	ID           int64
+	Amount       float64   // 划转金额（USDT）/ Transferred amount (USDT)
+	Baseline     float64   // 计算利润的基准权益 / Equity baseline profits are measured from
+	EquityBefore float64   // 划转前权益 / Equity before the transfer
+	EquityAfter  float64   // 划转后权益（下一次提取的基准）/ Equity after the transfer (baseline of the next sweep)
+	TransferID   int64     // 币安划转 ID（模拟为 0）/ Binance transfer ID (0 when simulated)
+	Paper        bool      // 是否为模拟划转 / Whether the transfer is simulated
+	CreatedAt    time.Time // 划转时间 / Transfer time
+}
+
+// initSweepSchema creates the profit_sweeps table if it doesn't exist
+// initSweepSchema 创建 profit_sweeps 表（如果不存在）
+func (s *Storage) initSweepSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS profit_sweeps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		amount REAL NOT NULL,
+		baseline REAL NOT NULL,
+		equity_before REAL NOT NULL,
+		equity_after REAL NOT NULL,
+		transfer_id INTEGER DEFAULT 0,
+		paper BOOLEAN DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_profit_sweeps_created_at ON profit_sweeps(created_at DESC);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveProfitSweep stores a profit sweep and returns its ID
+// SaveProfitSweep 保存一次利润提取并返回其 ID
+func (s *Storage) SaveProfitSweep(sweep *ProfitSweep) (int64, error) {
+	if sweep.CreatedAt.IsZero() {
+		sweep.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT INTO profit_sweeps (
+		amount, baseline, equity_before, equity_after, transfer_id, paper, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		sweep.Amount, sweep.Baseline, sweep.EquityBefore, sweep.EquityAfter,
+		sweep.TransferID, sweep.Paper, sweep.CreatedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save profit sweep: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// SetProfitSweepTransferID records the Binance transfer ID of a saved sweep
+// SetProfitSweepTransferID 记录已保存提取的币安划转 ID
+func (s *Storage) SetProfitSweepTransferID(id, transferID int64) error {
+	if _, err := s.exec(`UPDATE profit_sweeps SET transfer_id = ? WHERE id = ?`, transferID, id); err != nil {
+		return fmt.Errorf("failed to update profit sweep: %w", err)
+	}
+	return nil
+}
+
+// DeleteProfitSweep removes a sweep whose transfer failed
+// DeleteProfitSweep 删除划转失败的提取记录
+func (s *Storage) DeleteProfitSweep(id int64) error {
+	if _, err := s.exec(`DELETE FROM profit_sweeps WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete profit sweep: %w", err)
+	}
+	return nil
+}
+
+// GetProfitSweeps retrieves the latest profit sweeps, newest first
+// GetProfitSweeps 获取最近的利润提取记录，按时间倒序
+func (s *Storage) GetProfitSweeps(limit int) ([]*ProfitSweep, error) {
+	rows, err := s.db.Query(`
+	SELECT id, amount, baseline, equity_before, equity_after, transfer_id, paper, created_at
+	FROM profit_sweeps
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profit sweeps: %w", err)
+	}
+	defer rows.Close()
+	return scanProfitSweeps(rows)
+}
+
+// Withdrawals are profit sweeps in time order: cash moved out of the futures wallet, which returns,
+// drawdowns and the daily loss must not count as a loss
+// Withdrawals 是按时间正序排列的利润提取：从合约钱包转出的资金，收益率、回撤和单日亏损不应将其计为亏损
+type Withdrawals []*ProfitSweep
+
+// Between returns the amount withdrawn after start and up to at
+// Between 返回 start 之后（不含）至 at（含）之间提取的金额
+func (w Withdrawals) Between(start, at time.Time) float64 {
+	total := 0.0
+	for _, sweep := range w {
+		if sweep.CreatedAt.After(start) && !sweep.CreatedAt.After(at) {
+			total += sweep.Amount
+		}
+	}
+	return total
+}
+
+// GetWithdrawalsSince retrieves the profit sweeps made since a time, oldest first
+// GetWithdrawalsSince 获取某一时间之后的利润提取记录，按时间正序
+func (s *Storage) GetWithdrawalsSince(since time.Time) (Withdrawals, error) {
+	// Sweeps are rare: filtering in Go avoids comparing timestamps stored with different offsets
+	// 提取记录很少：在 Go 中过滤，避免比较以不同时区偏移保存的时间
+	rows, err := s.db.Query(`
+	SELECT id, amount, baseline, equity_before, equity_after, transfer_id, paper, created_at
+	FROM profit_sweeps
+	ORDER BY created_at ASC, id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profit sweeps: %w", err)
+	}
+	defer rows.Close()
+
+	sweeps, err := scanProfitSweeps(rows)
+	if err != nil {
+		return nil, err
+	}
+	var withdrawals Withdrawals
+	for _, sweep := range sweeps {
+		if !sweep.CreatedAt.Before(since) {
+			withdrawals = append(withdrawals, sweep)
+		}
+	}
+	return withdrawals, nil
+}
+
+// scanProfitSweeps reads the rows of a profit_sweeps query
+// scanProfitSweeps 读取 profit_sweeps 查询的结果行
+func scanProfitSweeps(rows *sql.Rows) ([]*ProfitSweep, error) {
+	var sweeps []*ProfitSweep
+	for rows.Next() {
+		sweep := &ProfitSweep{}
+		if err := rows.Scan(
+			&sweep.ID, &sweep.Amount, &sweep.Baseline, &sweep.EquityBefore, &sweep.EquityAfter,
+			&sweep.TransferID, &sweep.Paper, &sweep.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan profit sweep: %w", err)
+		}
+		sweeps = append(sweeps, sweep)
+	}
+	return sweeps, rows.Err()
+}
+
+// GetLastProfitSweep returns the most recent profit sweep (nil if there is none)
+// GetLastProfitSweep 返回最近一次利润提取（没有则返回 nil）
+func (s *Storage) GetLastProfitSweep() (*ProfitSweep, error) {
+	sweeps, err := s.GetProfitSweeps(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(sweeps) == 0 {
+		return nil, nil
+	}
+	return sweeps[0], nil
+}
+
+// GetTotalSwept returns the total amount transferred out by profit sweeps
+// GetTotalSwept 返回利润提取累计划转的金额
+func (s *Storage) GetTotalSwept() (float64, error) {
+	var total sql.NullFloat64
+	if err := s.db.QueryRow(`SELECT SUM(amount) FROM profit_sweeps`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum profit sweeps: %w", err)
+	}
+	return total.Float64, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProfitSweeps(t *testing.T) {
+	tmpDB := "./test_sweeps.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 没有记录时返回 nil
+	if last, err := db.GetLastProfitSweep(); err != nil || last != nil {
+		t.Fatalf("Expected no sweep, got %+v (%v)", last, err)
+	}
+
+	now := time.Now()
+	sweeps := []*ProfitSweep{
+		{Amount: 100, Baseline: 1000, EquityBefore: 1200, EquityAfter: 1100, TransferID: 7, CreatedAt: now.Add(-time.Hour)},
+		{Amount: 50, Baseline: 1100, EquityBefore: 1350, EquityAfter: 1300, Paper: true, CreatedAt: now},
+	}
+	for _, sweep := range sweeps {
+		if _, err := db.SaveProfitSweep(sweep); err != nil {
+			t.Fatalf("SaveProfitSweep failed: %v", err)
+		}
+	}
+
+	last, err := db.GetLastProfitSweep()
+	if err != nil {
+		t.Fatalf("GetLastProfitSweep failed: %v", err)
+	}
+	if last.Amount != 50 || last.EquityAfter != 1300 || !last.Paper {
+		t.Errorf("Unexpected last sweep: %+v", last)
+	}
+
+	all, err := db.GetProfitSweeps(10)
+	if err != nil {
+		t.Fatalf("GetProfitSweeps failed: %v", err)
+	}
+	if len(all) != 2 || all[1].TransferID != 7 {
+		t.Errorf("Unexpected sweeps: %+v", all)
+	}
+
+	total, err := db.GetTotalSwept()
+	if err != nil {
+		t.Fatalf("GetTotalSwept failed: %v", err)
+	}
+	if total != 150 {
+		t.Errorf("Expected 150 swept, got %.2f", total)
+	}
+
+	// 划转成功后补记划转 ID，失败时删除记录
+	if err := db.SetProfitSweepTransferID(last.ID, 42); err != nil {
+		t.Fatalf("SetProfitSweepTransferID failed: %v", err)
+	}
+	if last, err = db.GetLastProfitSweep(); err != nil || last.TransferID != 42 {
+		t.Errorf("Expected transfer ID 42, got %+v (%v)", last, err)
+	}
+	if err := db.DeleteProfitSweep(last.ID); err != nil {
+		t.Fatalf("DeleteProfitSweep failed: %v", err)
+	}
+	if last, err = db.GetLastProfitSweep(); err != nil || last.Amount != 100 {
+		t.Errorf("Expected the earlier sweep to be the last one, got %+v (%v)", last, err)
+	}
+}
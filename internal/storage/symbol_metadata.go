@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// SymbolMetadata is the trading metadata of one symbol on one exchange
+// SymbolMetadata 是某个交易所上单个交易对的交易元数据
+type SymbolMetadata struct {
+	Exchange     string    // 交易所（如 binance）/ Exchange (e.g. binance)
+	Symbol       string    // 交易所格式的交易对 / Exchange-format symbol
+	TickSize     float64   // 价格步长 / Price tick
+	StepSize     float64   // 数量步长 / Quantity step
+	MinQty       float64   // 最小下单数量 / Minimum order quantity
+	MinNotional  float64   // 最小订单价值 USDT / Minimum order value in USDT
+	ContractType string    // 合约类型（如 PERPETUAL）/ Contract type (e.g. PERPETUAL)
+	OnboardDate  time.Time // 上线时间（未知时为零值）/ Listing time (zero when unknown)
+	UpdatedAt    time.Time // 最后刷新时间 / Last refresh time
+}
+
+// initSymbolMetadataSchema creates the symbol_metadata table if it doesn't exist
+// initSymbolMetadataSchema 创建 symbol_metadata 表（如果不存在）
+func (s *Storage) initSymbolMetadataSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS symbol_metadata (
+		exchange TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		tick_size REAL NOT NULL,
+		step_size REAL NOT NULL,
+		min_qty REAL NOT NULL,
+		min_notional REAL NOT NULL,
+		contract_type TEXT,
+		onboard_date DATETIME,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (exchange, symbol)
+	);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveSymbolMetadata replaces the stored metadata of an exchange in one transaction
+// SaveSymbolMetadata 在一个事务中替换某个交易所的全部元数据
+func (s *Storage) SaveSymbolMetadata(exchange string, items []*SymbolMetadata) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM symbol_metadata WHERE exchange = ?`, exchange); err != nil {
+		return fmt.Errorf("failed to clear symbol metadata: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO symbol_metadata (
+		exchange, symbol, tick_size, step_size, min_qty, min_notional,
+		contract_type, onboard_date, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare symbol metadata insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, m := range items {
+		m.Exchange = exchange
+		if m.UpdatedAt.IsZero() {
+			m.UpdatedAt = now
+		}
+		if _, err := stmt.Exec(m.Exchange, m.Symbol, m.TickSize, m.StepSize, m.MinQty, m.MinNotional,
+			m.ContractType, m.OnboardDate, m.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to save symbol metadata: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSymbolMetadata retrieves the stored metadata of an exchange keyed by symbol (empty if never refreshed)
+// GetSymbolMetadata 获取某个交易所已保存的元数据，按交易对索引（从未刷新时为空）
+func (s *Storage) GetSymbolMetadata(exchange string) (map[string]*SymbolMetadata, error) {
+	rows, err := s.db.Query(`
+	SELECT exchange, symbol, tick_size, step_size, min_qty, min_notional,
+		   contract_type, onboard_date, updated_at
+	FROM symbol_metadata
+	WHERE exchange = ?
+	`, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol metadata: %w", err)
+	}
+	defer rows.Close()
+
+	items := make(map[string]*SymbolMetadata)
+	for rows.Next() {
+		m := &SymbolMetadata{}
+		if err := rows.Scan(&m.Exchange, &m.Symbol, &m.TickSize, &m.StepSize, &m.MinQty, &m.MinNotional,
+			&m.ContractType, &m.OnboardDate, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol metadata: %w", err)
+		}
+		items[m.Symbol] = m
+	}
+	return items, rows.Err()
+}
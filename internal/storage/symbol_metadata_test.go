@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSymbolMetadata(t *testing.T) {
+	tmpDB := "./test_symbol_metadata.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	onboard := time.Date(2019, 9, 25, 8, 0, 0, 0, time.UTC)
+	if err := db.SaveSymbolMetadata("binance", []*SymbolMetadata{
+		{Symbol: "BTCUSDT", TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MinNotional: 100, ContractType: "PERPETUAL", OnboardDate: onboard},
+		{Symbol: "ETHUSDT", TickSize: 0.01, StepSize: 0.001, MinQty: 0.001, MinNotional: 20, ContractType: "PERPETUAL"},
+	}); err != nil {
+		t.Fatalf("SaveSymbolMetadata failed: %v", err)
+	}
+
+	got, err := db.GetSymbolMetadata("binance")
+	if err != nil {
+		t.Fatalf("GetSymbolMetadata failed: %v", err)
+	}
+	btc := got["BTCUSDT"]
+	if len(got) != 2 || btc == nil {
+		t.Fatalf("Expected BTCUSDT and ETHUSDT, got %v", got)
+	}
+	if btc.Exchange != "binance" || btc.TickSize != 0.1 || btc.MinNotional != 100 || btc.ContractType != "PERPETUAL" || !btc.OnboardDate.Equal(onboard) || btc.UpdatedAt.IsZero() {
+		t.Errorf("Unexpected BTCUSDT metadata: %+v", btc)
+	}
+
+	// A refresh replaces the whole exchange, dropping delisted symbols
+	// 刷新会替换整个交易所的数据，移除已下架的交易对
+	if err := db.SaveSymbolMetadata("binance", []*SymbolMetadata{{Symbol: "BTCUSDT", TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MinNotional: 50}}); err != nil {
+		t.Fatalf("SaveSymbolMetadata failed: %v", err)
+	}
+	got, _ = db.GetSymbolMetadata("binance")
+	if len(got) != 1 || got["BTCUSDT"].MinNotional != 50 {
+		t.Errorf("Expected only the refreshed BTCUSDT, got %v", got)
+	}
+
+	if other, _ := db.GetSymbolMetadata("okx"); len(other) != 0 {
+		t.Errorf("Expected no metadata for another exchange, got %v", other)
+	}
+}
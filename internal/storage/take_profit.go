@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// initTakeProfitSchema adds the bracket take-profit columns to positions
+// initTakeProfitSchema 为 positions 表添加括号单止盈字段
+func (s *Storage) initTakeProfitSchema() {
+	columns := []string{
+		"take_profit_price REAL DEFAULT 0",
+		"take_profit_order_id TEXT",
+	}
+
+	// Run each ALTER separately so one existing column doesn't skip the rest
+	// 逐条执行 ALTER，避免某个字段已存在导致后续字段被跳过
+	for _, column := range columns {
+		s.exec("ALTER TABLE positions ADD COLUMN " + column)
+	}
+}
+
+// SaveTakeProfit stores the take-profit price and order ID of a position
+// SaveTakeProfit 保存持仓的止盈价格和止盈单 ID
+func (s *Storage) SaveTakeProfit(positionID string, price float64, orderID string) error {
+	_, err := s.exec(`UPDATE positions SET take_profit_price = ?, take_profit_order_id = ? WHERE id = ?`,
+		price, orderID, positionID)
+	if err != nil {
+		return fmt.Errorf("failed to save take-profit: %w", err)
+	}
+	return nil
+}
+
+// GetTakeProfit retrieves the take-profit price and order ID of a position (zero values if not found)
+// GetTakeProfit 获取持仓的止盈价格和止盈单 ID（不存在返回零值）
+func (s *Storage) GetTakeProfit(positionID string) (float64, string, error) {
+	var price sql.NullFloat64
+	var orderID sql.NullString
+	err := s.db.QueryRow(`SELECT take_profit_price, take_profit_order_id FROM positions WHERE id = ?`, positionID).
+		Scan(&price, &orderID)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get take-profit: %w", err)
+	}
+	return price.Float64, orderID.String, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTakeProfitRoundTrip(t *testing.T) {
+	db, err := NewStorage(filepath.Join(t.TempDir(), "take_profit.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	pos := &PositionRecord{
+		ID:              "BTCUSDT-1",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      100000,
+		EntryTime:       time.Now(),
+		Quantity:        0.02,
+		Leverage:        10,
+		InitialStopLoss: 98000,
+		CurrentStopLoss: 98000,
+		StopLossType:    "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// 未设置时返回零值
+	if price, orderID, err := db.GetTakeProfit(pos.ID); err != nil || price != 0 || orderID != "" {
+		t.Fatalf("Unexpected initial take-profit: %.2f, %q, %v", price, orderID, err)
+	}
+
+	if err := db.SaveTakeProfit(pos.ID, 104000, "12345"); err != nil {
+		t.Fatalf("SaveTakeProfit failed: %v", err)
+	}
+	if price, orderID, err := db.GetTakeProfit(pos.ID); err != nil || price != 104000 || orderID != "12345" {
+		t.Errorf("GetTakeProfit = %.2f, %q, %v; want 104000, 12345", price, orderID, err)
+	}
+
+	// 不存在的持仓返回零值
+	if price, orderID, err := db.GetTakeProfit("missing"); err != nil || price != 0 || orderID != "" {
+		t.Errorf("Expected zero values for missing position, got %.2f, %q, %v", price, orderID, err)
+	}
+}
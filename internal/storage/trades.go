@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TradeRecord represents a single fill executed on the exchange (or the paper account)
+// TradeRecord 表示一笔在交易所（或模拟账户）上的成交记录
+type TradeRecord struct {
+	ID          int64
+	SessionID   int64     // 触发该成交的会话 ID（止损、强平等为 0）/ Session that triggered the fill (0 for stops, liquidations...)
+	OrderID     int64     // 订单 ID / Order ID
+	Symbol      string    // 交易对（币安格式）/ Trading pair (Binance format)
+	Side        string    // BUY/SELL
+	Type        string    // MARKET/STOP_MARKET/LIQUIDATION
+	Quantity    float64   // 成交数量 / Filled quantity
+	Price       float64   // 成交均价 / Average fill price
+	Fee         float64   // 手续费（USDT）/ Fee (USDT)
+	RealizedPnL float64   // 已实现盈亏（不含手续费）/ Realized PnL (before fees)
+	Paper       bool      // 是否为模拟盘成交 / Whether the fill is simulated
+	CreatedAt   time.Time // 成交时间 / Fill time
+}
+
+// TradeStats summarizes the recorded fills
+// TradeStats 汇总已记录的成交
+type TradeStats struct {
+	TotalTrades   int     // 成交笔数 / Number of fills
+	ClosingTrades int     // 产生已实现盈亏的成交笔数 / Fills with realized PnL
+	Wins          int     // 盈利笔数 / Winning closing fills
+	Losses        int     // 亏损笔数 / Losing closing fills
+	WinRate       float64 // 胜率（%）/ Win rate (%)
+	GrossPnL      float64 // 累计已实现盈亏 / Cumulative realized PnL
+	TotalFees     float64 // 累计手续费 / Cumulative fees
+	NetPnL        float64 // 扣除手续费后的累计盈亏 / Cumulative PnL after fees
+	AverageR      float64 // 已平仓持仓的平均 R 倍数 / Average R multiple of closed positions
+	RTrades       int     // 参与平均 R 计算的持仓数 / Closed positions used for the average R
+}
+
+// initTradeSchema creates the trades table if it doesn't exist
+// initTradeSchema 创建 trades 表（如果不存在）
+func (s *Storage) initTradeSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS trades (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id INTEGER DEFAULT 0,
+		order_id INTEGER NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		type TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		price REAL NOT NULL,
+		fee REAL DEFAULT 0,
+		realized_pnl REAL DEFAULT 0,
+		paper BOOLEAN DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(symbol, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_trades_session ON trades(session_id);
+	`
+	_, err := s.exec(schema)
+	return err
+}
+
+// SaveTrade stores a fill and returns its ID
+// SaveTrade 保存一笔成交并返回其 ID
+func (s *Storage) SaveTrade(trade *TradeRecord) (int64, error) {
+	if trade.CreatedAt.IsZero() {
+		trade.CreatedAt = time.Now()
+	}
+	result, err := s.exec(`
+	INSERT INTO trades (
+		session_id, order_id, symbol, side, type, quantity, price,
+		fee, realized_pnl, paper, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		trade.SessionID, trade.OrderID, trade.Symbol, trade.Side, trade.Type,
+		trade.Quantity, trade.Price, trade.Fee, trade.RealizedPnL, trade.Paper,
+		trade.CreatedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save trade: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetTrades retrieves the latest fills, newest first (empty symbol = all symbols)
+// GetTrades 获取最近的成交记录，按时间倒序（symbol 为空表示全部交易对）
+func (s *Storage) GetTrades(symbol string, limit int) ([]*TradeRecord, error) {
+	query := `
+	SELECT id, session_id, order_id, symbol, side, type, quantity, price,
+		   fee, realized_pnl, paper, created_at
+	FROM trades
+	WHERE (? = '' OR symbol = ?)
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`
+	rows, err := s.db.Query(query, symbol, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []*TradeRecord
+	for rows.Next() {
+		t := &TradeRecord{}
+		if err := rows.Scan(
+			&t.ID, &t.SessionID, &t.OrderID, &t.Symbol, &t.Side, &t.Type,
+			&t.Quantity, &t.Price, &t.Fee, &t.RealizedPnL, &t.Paper, &t.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+// GetTradeStats summarizes fills and closed positions (empty symbol = all symbols)
+// GetTradeStats 汇总成交记录和已平仓持仓（symbol 为空表示全部交易对）
+//
+// Win rate is counted over fills that realized PnL. Average R uses closed positions:
+// total realized PnL (partial closes included) divided by the initial risk
+// |entry - initial stop| × original quantity.
+// 胜率按产生已实现盈亏的成交计算。平均 R 基于已平仓持仓：总已实现盈亏（含分批平仓）
+// 除以初始风险 |入场价 - 初始止损| × 原始数量。
+func (s *Storage) GetTradeStats(symbol string) (*TradeStats, error) {
+	stats := &TradeStats{}
+	err := s.db.QueryRow(`
+	SELECT
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN realized_pnl != 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN realized_pnl < 0 THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(realized_pnl), 0),
+		COALESCE(SUM(fee), 0)
+	FROM trades
+	WHERE (? = '' OR symbol = ?)
+	`, symbol, symbol).Scan(
+		&stats.TotalTrades, &stats.ClosingTrades, &stats.Wins, &stats.Losses,
+		&stats.GrossPnL, &stats.TotalFees,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade stats: %w", err)
+	}
+	stats.NetPnL = stats.GrossPnL - stats.TotalFees
+	if stats.ClosingTrades > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.ClosingTrades) * 100
+	}
+
+	rows, err := s.db.Query(`
+	SELECT entry_price, initial_stop_loss,
+		   quantity + COALESCE(partial_closed_qty, 0),
+		   COALESCE(realized_pnl, 0) + COALESCE(partial_realized_pnl, 0)
+	FROM positions
+	WHERE closed = 1 AND (? = '' OR symbol = ?)
+	`, symbol, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query closed positions: %w", err)
+	}
+	defer rows.Close()
+
+	var totalR float64
+	for rows.Next() {
+		var entry, stop, qty, pnl float64
+		if err := rows.Scan(&entry, &stop, &qty, &pnl); err != nil {
+			return nil, fmt.Errorf("failed to scan closed position: %w", err)
+		}
+		risk := math.Abs(entry-stop) * qty
+		if risk <= 0 {
+			continue
+		}
+		totalR += pnl / risk
+		stats.RTrades++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if stats.RTrades > 0 {
+		stats.AverageR = totalR / float64(stats.RTrades)
+	}
+	return stats, nil
+}
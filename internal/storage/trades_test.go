@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTradesAndStats(t *testing.T) {
+	tmpDB := "./test_trades.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	trades := []*TradeRecord{
+		{SessionID: 1, OrderID: 10, Symbol: "BTCUSDT", Side: "BUY", Type: "MARKET", Quantity: 0.02, Price: 100000, Fee: 0.8, CreatedAt: now.Add(-3 * time.Hour)},
+		{SessionID: 2, OrderID: 11, Symbol: "BTCUSDT", Side: "SELL", Type: "MARKET", Quantity: 0.02, Price: 104000, Fee: 0.8, RealizedPnL: 80, CreatedAt: now.Add(-2 * time.Hour)},
+		{OrderID: 12, Symbol: "ETHUSDT", Side: "SELL", Type: "STOP_MARKET", Quantity: 1, Price: 3000, Fee: 1.2, RealizedPnL: -40, Paper: true, CreatedAt: now.Add(-time.Hour)},
+	}
+	for _, trade := range trades {
+		if _, err := db.SaveTrade(trade); err != nil {
+			t.Fatalf("SaveTrade failed: %v", err)
+		}
+	}
+
+	// 按交易对过滤，最新的在前
+	btc, err := db.GetTrades("BTCUSDT", 10)
+	if err != nil {
+		t.Fatalf("GetTrades failed: %v", err)
+	}
+	if len(btc) != 2 || btc[0].OrderID != 11 || btc[0].SessionID != 2 || btc[0].RealizedPnL != 80 {
+		t.Fatalf("Unexpected BTC trades: %+v", btc)
+	}
+	all, err := db.GetTrades("", 2)
+	if err != nil || len(all) != 2 || all[0].Symbol != "ETHUSDT" || !all[0].Paper {
+		t.Fatalf("Unexpected latest trades: %+v, %v", all, err)
+	}
+
+	// 已平仓持仓：盈利 2R（含分批平仓）
+	closeTime := now
+	pos := &PositionRecord{
+		ID: "BTCUSDT-1", Symbol: "BTCUSDT", Side: "long", EntryPrice: 100000, EntryTime: now.Add(-3 * time.Hour),
+		Quantity: 0.02, Leverage: 10, InitialStopLoss: 98000, CurrentStopLoss: 98000, StopLossType: "fixed",
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+	if err := db.SavePartialTakeProfit(&PartialTakeProfit{
+		PositionID: pos.ID, Executed: true, ClosedQuantity: 0.01, RealizedPnL: 20, Quantity: 0.01,
+	}); err != nil {
+		t.Fatalf("SavePartialTakeProfit failed: %v", err)
+	}
+	pos.Quantity = 0.01
+	pos.Closed = true
+	pos.CloseTime = &closeTime
+	pos.ClosePrice = 106000
+	pos.RealizedPnL = 60
+	if err := db.UpdatePosition(pos); err != nil {
+		t.Fatalf("UpdatePosition failed: %v", err)
+	}
+
+	stats, err := db.GetTradeStats("")
+	if err != nil {
+		t.Fatalf("GetTradeStats failed: %v", err)
+	}
+	if stats.TotalTrades != 3 || stats.ClosingTrades != 2 || stats.Wins != 1 || stats.Losses != 1 {
+		t.Errorf("Unexpected counts: %+v", stats)
+	}
+	if stats.WinRate != 50 || stats.GrossPnL != 40 || math.Abs(stats.NetPnL-37.2) > 1e-9 {
+		t.Errorf("Unexpected PnL: %+v", stats)
+	}
+	if stats.RTrades != 1 || math.Abs(stats.AverageR-2) > 1e-9 {
+		t.Errorf("Expected average R 2 over 1 position, got %.4f over %d", stats.AverageR, stats.RTrades)
+	}
+
+	eth, err := db.GetTradeStats("ETHUSDT")
+	if err != nil || eth.TotalTrades != 1 || eth.WinRate != 0 || eth.RTrades != 0 {
+		t.Errorf("Unexpected ETH stats: %+v, %v", eth, err)
+	}
+}
@@ -3,13 +3,24 @@ package web
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	authMethodSession = "session" // 登录会话认证 / Authenticated by login session
+	authMethodToken   = "token"   // API 令牌认证 / Authenticated by API token
 )
 
 // SessionManager manages user sessions
@@ -122,38 +133,132 @@ func generateSessionID() (string, error) {
 
 // AuthMiddleware returns a middleware that checks if user is authenticated
 // AuthMiddleware 返回检查用户是否已认证的中间件
+//
+// Requests are accepted with a valid session cookie, or with WEB_API_TOKEN in the
+// "Authorization: Bearer" or "X-API-Key" header. Unauthenticated page requests are
+// redirected to /login, API requests get a JSON 401.
+// 带有效会话 cookie，或在 "Authorization: Bearer" / "X-API-Key" 请求头中携带 WEB_API_TOKEN 的请求会被放行。
+// 未认证的页面请求重定向到登录页，API 请求返回 JSON 401。
 func (s *Server) AuthMiddleware() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
+		// API token (scripts, curl)
+		// API 令牌（脚本、curl）
+		if token := requestToken(c); token != "" {
+			if !s.validAPIToken(token) {
+				s.logger.Warning(fmt.Sprintf("⚠️  无效的 API 令牌 (IP: %s)", c.ClientIP()))
+				c.JSON(http.StatusUnauthorized, utils.H{"error": "invalid API token"})
+				c.Abort()
+				return
+			}
+			c.Set("username", "api")
+			c.Set("auth_method", authMethodToken)
+			c.Next(ctx)
+			return
+		}
+
 		// Get session cookie
 		// 获取会话 cookie
 		sessionID := string(c.Cookie("session_id"))
 
-		if sessionID == "" {
-			// No session cookie, redirect to login
-			// 没有会话 cookie，重定向到登录页
-			c.Redirect(http.StatusFound, []byte("/login"))
-			c.Abort()
-			return
-		}
-
 		// Check if session exists and is valid
 		// 检查会话是否存在且有效
 		session, exists := s.sessionManager.GetSession(sessionID)
-		if !exists {
-			// Invalid session, redirect to login
-			// 无效会话，重定向到登录页
-			c.Redirect(http.StatusFound, []byte("/login"))
-			c.Abort()
+		if sessionID == "" || !exists {
+			s.unauthorized(c)
 			return
 		}
 
 		// Session is valid, store username in context for later use
 		// 会话有效，将用户名存储在上下文中供后续使用
 		c.Set("username", session.Username)
+		c.Set("auth_method", authMethodSession)
+		c.Next(ctx)
+	}
+}
+
+// MutationMiddleware protects state-changing endpoints against cross-site requests
+// MutationMiddleware 保护会修改状态的接口，防止跨站请求伪造
+//
+// Must run after AuthMiddleware. Token requests carry no cookies and pass as is;
+// session requests must come from the dashboard itself (same-origin Origin or Referer).
+// 必须在 AuthMiddleware 之后执行。令牌请求不携带 cookie，直接放行；
+// 会话请求必须来自控制台本身（Origin 或 Referer 与当前主机一致）。
+func (s *Server) MutationMiddleware() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if c.GetString("auth_method") == authMethodToken {
+			c.Next(ctx)
+			return
+		}
+
+		source := string(c.GetHeader("Origin"))
+		if source == "" {
+			source = string(c.GetHeader("Referer"))
+		}
+		// Behind a reverse proxy the public host may only be in X-Forwarded-Host
+		// 反向代理后面，公开主机名可能只出现在 X-Forwarded-Host 中
+		if !sameHost(source, string(c.Host())) && !sameHost(source, string(c.GetHeader("X-Forwarded-Host"))) {
+			s.logger.Warning(fmt.Sprintf("⚠️  拒绝跨站请求: %s %s (来源: %q)", c.Method(), c.Path(), source))
+			c.JSON(http.StatusForbidden, utils.H{"error": "cross-site request rejected, use the dashboard or an API token"})
+			c.Abort()
+			return
+		}
 		c.Next(ctx)
 	}
 }
 
+// unauthorized rejects an unauthenticated request: JSON 401 for the API, redirect to /login for pages
+// unauthorized 拒绝未认证的请求：API 返回 JSON 401，页面重定向到登录页
+func (s *Server) unauthorized(c *app.RequestContext) {
+	if strings.HasPrefix(string(c.Path()), "/api/") {
+		c.JSON(http.StatusUnauthorized, utils.H{"error": "authentication required"})
+	} else {
+		c.Redirect(http.StatusFound, []byte("/login"))
+	}
+	c.Abort()
+}
+
+// validAPIToken reports whether token matches WEB_API_TOKEN (always false when no token is configured)
+// validAPIToken 判断令牌是否与 WEB_API_TOKEN 一致（未配置令牌时始终为 false）
+func (s *Server) validAPIToken(token string) bool {
+	if s.config.WebAPIToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.WebAPIToken)) == 1
+}
+
+// requestToken extracts the API token from the Authorization or X-API-Key header
+// requestToken 从 Authorization 或 X-API-Key 请求头中提取 API 令牌
+func requestToken(c *app.RequestContext) string {
+	if auth := string(c.GetHeader("Authorization")); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(string(c.GetHeader("X-API-Key")))
+}
+
+// sameHost reports whether the Origin/Referer URL points at host
+// sameHost 判断 Origin/Referer 地址是否指向 host
+func sameHost(source, host string) bool {
+	if source == "" || host == "" {
+		return false
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
+
+// validCredentials compares the login form against WEB_USERNAME/WEB_PASSWORD in constant time
+// validCredentials 以常量时间比较登录表单与 WEB_USERNAME/WEB_PASSWORD
+func (s *Server) validCredentials(username, password string) bool {
+	if s.config.WebPassword == "" {
+		return false // 未设置密码时禁止登录 / Login disabled without a password
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(s.config.WebUsername))
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.config.WebPassword))
+	return userOK&passOK == 1
+}
+
 // handleLogin displays the login page or processes login form
 // handleLogin 显示登录页面或处理登录表单
 func (s *Server) handleLogin(ctx context.Context, c *app.RequestContext) {
@@ -170,6 +275,16 @@ func (s *Server) handleLogin(ctx context.Context, c *app.RequestContext) {
 	// Check if this is a POST request (login form submission)
 	// 检查是否为 POST 请求（登录表单提交）
 	if string(c.Method()) == "POST" {
+		// Limit login attempts per IP against password guessing
+		// 按 IP 限制登录尝试次数，防止暴力破解
+		if allowed, retryAfter := s.loginLimiter.Allow(c.ClientIP()); !allowed {
+			s.logger.Warning(fmt.Sprintf("⚠️  登录尝试过于频繁 (IP: %s)", c.ClientIP()))
+			c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+			c.Status(http.StatusTooManyRequests)
+			s.renderLoginPage(c, "登录尝试过于频繁，请稍后再试")
+			return
+		}
+
 		// Get form values
 		// 获取表单值
 		username := c.PostForm("username")
@@ -177,7 +292,7 @@ func (s *Server) handleLogin(ctx context.Context, c *app.RequestContext) {
 
 		// Validate credentials
 		// 验证凭据
-		if username == s.config.WebUsername && password == s.config.WebPassword {
+		if s.validCredentials(username, password) {
 			// Create session
 			// 创建会话
 			session, err := s.sessionManager.CreateSession(username)
@@ -195,9 +310,9 @@ func (s *Server) handleLogin(ctx context.Context, c *app.RequestContext) {
 				int(24*time.Hour.Seconds()), // 24 hours / 24小时
 				"/",
 				"",
-				0,     // SameSite (0 = default)
-				false, // Not HTTPS only (change to true in production with HTTPS) / 非仅 HTTPS
-				true,  // HttpOnly
+				protocol.CookieSameSiteLaxMode, // 阻止跨站 POST 携带 cookie / Keep the cookie off cross-site POSTs
+				s.config.WebSecureCookie,       // HTTPS only when WEB_SECURE_COOKIE=true / 仅 HTTPS（WEB_SECURE_COOKIE=true 时）
+				true,                           // HttpOnly
 			)
 
 			s.logger.Info("用户登录成功: " + username)
@@ -209,6 +324,7 @@ func (s *Server) handleLogin(ctx context.Context, c *app.RequestContext) {
 		} else {
 			// Invalid credentials, show login page with error
 			// 无效凭据，显示登录页面并带错误提示
+			s.logger.Warning(fmt.Sprintf("⚠️  登录失败: %s (IP: %s)", username, c.ClientIP()))
 			s.renderLoginPage(c, "用户名或密码错误")
 			return
 		}
@@ -391,5 +507,7 @@ func (s *Server) renderLoginPage(c *app.RequestContext, errorMsg string) {
 </body>
 </html>`
 
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	// Keep a status set by the caller (e.g. 429), 200 otherwise
+	// 保留调用方设置的状态码（如 429），否则为 200
+	c.Data(c.Response.StatusCode(), "text/html; charset=utf-8", []byte(html))
 }
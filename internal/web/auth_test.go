@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// newAuthTestServer creates a server with one protected and one mutating route
+// newAuthTestServer 创建包含一个受保护路由和一个修改状态路由的测试服务器
+func newAuthTestServer(token string) (*Server, *server.Hertz, string) {
+	s := &Server{
+		config:         &config.Config{WebUsername: "admin", WebPassword: "secret", WebAPIToken: token},
+		logger:         logger.NewColorLogger(false),
+		sessionManager: NewSessionManager(),
+		loginLimiter:   NewRateLimiter(2, time.Minute),
+	}
+	session, _ := s.sessionManager.CreateSession("admin")
+
+	ok := func(ctx context.Context, c *app.RequestContext) { c.String(http.StatusOK, "ok") }
+	h := server.Default()
+	h.GET("/login", s.handleLogin)
+	h.POST("/login", s.handleLogin)
+	h.GET("/api/positions", s.AuthMiddleware(), ok)
+	h.GET("/stats", s.AuthMiddleware(), ok)
+	h.POST("/api/trade", s.AuthMiddleware(), s.MutationMiddleware(), ok)
+	return s, h, "session_id=" + session.ID
+}
+
+// TestAuthMiddleware tests session and API token authentication
+// TestAuthMiddleware 测试会话和 API 令牌认证
+func TestAuthMiddleware(t *testing.T) {
+	_, h, cookie := newAuthTestServer("tok-123")
+
+	tests := []struct {
+		name    string
+		path    string
+		headers []ut.Header
+		want    int
+	}{
+		{"no credentials on API", "/api/positions", nil, http.StatusUnauthorized},
+		{"no credentials on page", "/stats", nil, http.StatusFound},
+		{"session cookie", "/api/positions", []ut.Header{{Key: "Cookie", Value: cookie}}, http.StatusOK},
+		{"unknown session", "/api/positions", []ut.Header{{Key: "Cookie", Value: "session_id=nope"}}, http.StatusUnauthorized},
+		{"bearer token", "/api/positions", []ut.Header{{Key: "Authorization", Value: "Bearer tok-123"}}, http.StatusOK},
+		{"api key header", "/api/positions", []ut.Header{{Key: "X-API-Key", Value: "tok-123"}}, http.StatusOK},
+		{"wrong token", "/api/positions", []ut.Header{{Key: "X-API-Key", Value: "tok-456"}}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		resp := ut.PerformRequest(h.Engine, http.MethodGet, tt.path, nil, tt.headers...).Result()
+		if resp.StatusCode() != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode(), tt.want)
+		}
+	}
+
+	// Without WEB_API_TOKEN no token is accepted, not even an empty match
+	// 未配置 WEB_API_TOKEN 时不接受任何令牌
+	_, h, _ = newAuthTestServer("")
+	resp := ut.PerformRequest(h.Engine, http.MethodGet, "/api/positions", nil, ut.Header{Key: "Authorization", Value: "Bearer x"}).Result()
+	if resp.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("token without WEB_API_TOKEN: status = %d, want 401", resp.StatusCode())
+	}
+}
+
+// TestMutationMiddleware tests that session POSTs must be same-origin while token POSTs pass
+// TestMutationMiddleware 测试会话 POST 必须同源，令牌 POST 直接放行
+func TestMutationMiddleware(t *testing.T) {
+	_, h, cookie := newAuthTestServer("tok-123")
+
+	tests := []struct {
+		name    string
+		headers []ut.Header
+		want    int
+	}{
+		{"same origin", []ut.Header{{Key: "Cookie", Value: cookie}, {Key: "Origin", Value: "http://example.com"}}, http.StatusOK},
+		{"same referer", []ut.Header{{Key: "Cookie", Value: cookie}, {Key: "Referer", Value: "http://example.com/"}}, http.StatusOK},
+		{"cross site", []ut.Header{{Key: "Cookie", Value: cookie}, {Key: "Origin", Value: "http://evil.test"}}, http.StatusForbidden},
+		{"no origin", []ut.Header{{Key: "Cookie", Value: cookie}}, http.StatusForbidden},
+		{"token", []ut.Header{{Key: "Authorization", Value: "Bearer tok-123"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp := ut.PerformRequest(h.Engine, http.MethodPost, "http://example.com/api/trade", nil, tt.headers...).Result()
+		if resp.StatusCode() != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode(), tt.want)
+		}
+	}
+}
+
+// TestLoginRateLimit tests that repeated login attempts from one IP are throttled
+// TestLoginRateLimit 测试同一 IP 的重复登录尝试会被限流
+func TestLoginRateLimit(t *testing.T) {
+	_, h, _ := newAuthTestServer("")
+	form := ut.Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}
+	loginBody := func(values string) *ut.Body {
+		return &ut.Body{Body: strings.NewReader(values), Len: len(values)}
+	}
+
+	wrong := loginBody("username=admin&password=guess")
+	if resp := ut.PerformRequest(h.Engine, http.MethodPost, "/login", wrong, form).Result(); resp.StatusCode() != http.StatusOK {
+		t.Fatalf("wrong password: status = %d, want 200 (login page)", resp.StatusCode())
+	}
+
+	right := loginBody("username=admin&password=secret")
+	if resp := ut.PerformRequest(h.Engine, http.MethodPost, "/login", right, form).Result(); resp.StatusCode() != http.StatusFound {
+		t.Fatalf("correct password: status = %d, want 302", resp.StatusCode())
+	}
+
+	third := loginBody("username=admin&password=secret")
+	if resp := ut.PerformRequest(h.Engine, http.MethodPost, "/login", third, form).Result(); resp.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("attempt beyond limit: status = %d, want 429", resp.StatusCode())
+	}
+}
@@ -0,0 +1,209 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// maxEquityHours caps how far back the equity page can look (1 year)
+// maxEquityHours 限制资产曲线页面的最大回看时长（1 年）
+const maxEquityHours = 24 * 365
+
+// EquityPoint is one balance snapshot on the equity curve
+// EquityPoint 是资产曲线上的一个余额快照
+type EquityPoint struct {
+	Time        string  `json:"time"`
+	Equity      float64 `json:"equity"`       // 总余额 + 未实现盈亏 / Total balance + unrealized PnL
+	DrawdownPct float64 `json:"drawdown_pct"` // 距此前最高点的回撤 / Drawdown from the running peak
+}
+
+// DailyReturn is one UTC day's closing equity and return
+// DailyReturn 是一个 UTC 日的收盘资产和收益率
+type DailyReturn struct {
+	Date      string  `json:"date"`
+	Equity    float64 `json:"equity"`     // 当日最后一个快照 / Last snapshot of the day
+	ReturnPct float64 `json:"return_pct"` // 相对前一日收盘（首日相对首个快照）/ Versus the previous close (first day: the first snapshot)
+}
+
+// EquityStats summarizes the equity curve
+// EquityStats 汇总资产曲线的统计指标
+type EquityStats struct {
+	StartEquity        float64 `json:"start_equity"`
+	EndEquity          float64 `json:"end_equity"`
+	TotalReturnPct     float64 `json:"total_return_pct"`
+	MaxDrawdownPct     float64 `json:"max_drawdown_pct"`
+	CurrentDrawdownPct float64 `json:"current_drawdown_pct"`
+	AvgDailyReturnPct  float64 `json:"avg_daily_return_pct"`
+	DailyVolatilityPct float64 `json:"daily_volatility_pct"` // 日收益率标准差 / Standard deviation of daily returns
+	BestDayPct         float64 `json:"best_day_pct"`
+	WorstDayPct        float64 `json:"worst_day_pct"`
+	Sharpe             float64 `json:"sharpe"` // 年化，无风险利率为 0 / Annualized, zero risk-free rate
+	Days               int     `json:"days"`
+}
+
+// EquityReport is the equity curve with its drawdown, daily returns and statistics
+// EquityReport 是资产曲线及其回撤、日收益率和统计指标
+type EquityReport struct {
+	Points []EquityPoint `json:"points"`
+	Daily  []DailyReturn `json:"daily"`
+	Stats  EquityStats   `json:"stats"`
+}
+
+// ComputeEquity builds the equity report from balance history in time order
+// ComputeEquity 根据按时间排序的余额历史生成资产曲线报告
+//
+// Snapshots without equity (e.g. before the account was funded) are skipped. The Sharpe-like ratio
+// annualizes daily returns over 365 days, since crypto trades every day, and needs at least two days.
+// 没有资产的快照（例如账户入金前）会被跳过。类夏普比率按 365 天年化日收益率（加密货币每天交易），至少需要两天数据。
+//
+// Profit sweeps are withdrawals, not losses: drawdowns and returns add back the amount swept since the
+// first snapshot, while the curve still shows the equity left in the account.
+// 利润提取是出金而不是亏损：回撤和收益率会加回自首个快照以来提取的金额，曲线仍显示账户中剩余的资产。
+func ComputeEquity(history []*storage.BalanceHistory, withdrawals storage.Withdrawals) EquityReport {
+	report := EquityReport{Points: []EquityPoint{}, Daily: []DailyReturn{}}
+
+	var peak float64
+	var start time.Time
+	var closes []float64 // 每日收盘的业绩资产（含已提取金额）/ Daily closing performance equity (swept amounts added back)
+	for _, h := range history {
+		equity := h.TotalBalance + h.UnrealizedPnL
+		if equity <= 0 {
+			continue
+		}
+		if peak == 0 {
+			report.Stats.StartEquity = equity
+			start = h.Timestamp
+		}
+		performance := equity + withdrawals.Between(start, h.Timestamp)
+		peak = math.Max(peak, performance)
+		drawdown := (peak - performance) / peak * 100
+		report.Stats.MaxDrawdownPct = math.Max(report.Stats.MaxDrawdownPct, drawdown)
+		report.Stats.CurrentDrawdownPct = drawdown
+		report.Stats.EndEquity = equity
+
+		report.Points = append(report.Points, EquityPoint{
+			Time:        h.Timestamp.Format("2006-01-02 15:04"),
+			Equity:      round2(equity),
+			DrawdownPct: round2(drawdown),
+		})
+
+		// The last snapshot of each UTC day is its close
+		// 每个 UTC 日的最后一个快照作为当日收盘
+		date := h.Timestamp.UTC().Format("2006-01-02")
+		if n := len(report.Daily); n > 0 && report.Daily[n-1].Date == date {
+			report.Daily[n-1].Equity = equity
+			closes[n-1] = performance
+		} else {
+			report.Daily = append(report.Daily, DailyReturn{Date: date, Equity: equity})
+			closes = append(closes, performance)
+		}
+	}
+	if len(report.Points) == 0 {
+		return report
+	}
+
+	returns := make([]float64, len(report.Daily))
+	prev := report.Stats.StartEquity
+	for i := range report.Daily {
+		day := &report.Daily[i]
+		returns[i] = (closes[i]/prev - 1) * 100
+		prev = closes[i]
+		day.Equity = round2(day.Equity)
+		day.ReturnPct = round2(returns[i])
+	}
+
+	stats := &report.Stats
+	stats.Days = len(returns)
+	stats.TotalReturnPct = (closes[len(closes)-1]/stats.StartEquity - 1) * 100
+	stats.BestDayPct, stats.WorstDayPct = returns[0], returns[0]
+	var sum float64
+	for _, r := range returns {
+		sum += r
+		stats.BestDayPct = math.Max(stats.BestDayPct, r)
+		stats.WorstDayPct = math.Min(stats.WorstDayPct, r)
+	}
+	stats.AvgDailyReturnPct = sum / float64(len(returns))
+	if len(returns) >= 2 {
+		var variance float64
+		for _, r := range returns {
+			variance += (r - stats.AvgDailyReturnPct) * (r - stats.AvgDailyReturnPct)
+		}
+		stats.DailyVolatilityPct = math.Sqrt(variance / float64(len(returns)-1))
+		if stats.DailyVolatilityPct > 0 {
+			stats.Sharpe = stats.AvgDailyReturnPct / stats.DailyVolatilityPct * math.Sqrt(365)
+		}
+	}
+
+	for _, v := range []*float64{&stats.StartEquity, &stats.EndEquity, &stats.TotalReturnPct, &stats.MaxDrawdownPct,
+		&stats.CurrentDrawdownPct, &stats.AvgDailyReturnPct, &stats.DailyVolatilityPct, &stats.BestDayPct,
+		&stats.WorstDayPct, &stats.Sharpe} {
+		*v = round2(*v)
+	}
+	return report
+}
+
+// round2 rounds to two decimals for display
+// round2 保留两位小数用于展示
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// handleEquityPage renders the equity curve and drawdown page
+// handleEquityPage 渲染资产曲线与回撤页面
+func (s *Server) handleEquityPage(ctx context.Context, c *app.RequestContext) {
+	tmpl := template.Must(template.ParseFiles("internal/web/templates/equity.html", "internal/web/templates/number_format.html"))
+
+	data := map[string]interface{}{
+		"Symbols":      s.config.CryptoSymbols,
+		"AnalysisOnly": s.config.IsAnalysisOnly(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// handleEquity returns the equity curve with drawdown, daily returns and summary statistics
+// handleEquity 返回资产曲线及回撤、日收益率和汇总统计
+func (s *Server) handleEquity(ctx context.Context, c *app.RequestContext) {
+	hours := 24 * 30 // Default to last 30 days / 默认最近 30 天
+	if h := c.Query("hours"); h != "" {
+		fmt.Sscanf(h, "%d", &hours)
+	}
+	if hours <= 0 || hours > maxEquityHours {
+		hours = maxEquityHours
+	}
+
+	history, err := s.storage.GetBalanceHistory(hours)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	withdrawals, err := s.storage.GetWithdrawalsSince(time.Now().Add(-time.Duration(hours) * time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	report := ComputeEquity(history, withdrawals)
+	c.JSON(http.StatusOK, utils.H{
+		"hours":  hours,
+		"points": report.Points,
+		"daily":  report.Daily,
+		"stats":  report.Stats,
+	})
+}
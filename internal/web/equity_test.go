@@ -0,0 +1,58 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestComputeEquity tests drawdown, daily closes and the Sharpe-like ratio
+// TestComputeEquity 测试回撤、日收盘和类夏普比率
+func TestComputeEquity(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	snapshot := func(offset time.Duration, balance, pnl float64) *storage.BalanceHistory {
+		return &storage.BalanceHistory{Timestamp: day.Add(offset), TotalBalance: balance, UnrealizedPnL: pnl}
+	}
+
+	report := ComputeEquity([]*storage.BalanceHistory{
+		snapshot(0, 0, 0), // 未入金，跳过 / Not funded yet, skipped
+		snapshot(time.Hour, 1000, 0),
+		snapshot(12*time.Hour, 1000, 100), // 峰值 1100 / Peak 1100
+		snapshot(20*time.Hour, 1000, 50),  // 第一天收盘 1050 / Day 1 close 1050
+		snapshot(30*time.Hour, 990, 0),    // 回撤 10% / 10% drawdown
+		snapshot(46*time.Hour, 1102.5, 0), // 第二天收盘 +5% / Day 2 close +5%
+	}, nil)
+
+	if len(report.Points) != 5 || len(report.Daily) != 2 {
+		t.Fatalf("Expected 5 points over 2 days, got %d points, %d days", len(report.Points), len(report.Daily))
+	}
+	if got := report.Points[3].DrawdownPct; got != 10 {
+		t.Errorf("Expected a 10%% drawdown at 990, got %.2f", got)
+	}
+
+	stats := report.Stats
+	if stats.MaxDrawdownPct != 10 || stats.CurrentDrawdownPct != 0 {
+		t.Errorf("Expected max drawdown 10%% and none now, got %.2f / %.2f", stats.MaxDrawdownPct, stats.CurrentDrawdownPct)
+	}
+	if report.Daily[0].ReturnPct != 5 || report.Daily[1].ReturnPct != 5 || stats.TotalReturnPct != 10.25 {
+		t.Errorf("Expected +5%% per day and +10.25%% total, got %+v / %.2f", report.Daily, stats.TotalReturnPct)
+	}
+	if stats.DailyVolatilityPct != 0 || stats.Sharpe != 0 {
+		t.Errorf("Identical daily returns have no volatility, got %.2f / %.2f", stats.DailyVolatilityPct, stats.Sharpe)
+	}
+
+	// 提取 100 USDT 后资产降到 1000：不是回撤，收益率仍计入已提取的利润
+	swept := ComputeEquity([]*storage.BalanceHistory{
+		snapshot(time.Hour, 1000, 0),
+		snapshot(12*time.Hour, 1100, 0),
+		snapshot(20*time.Hour, 1000, 0),
+	}, storage.Withdrawals{{Amount: 100, CreatedAt: day.Add(13 * time.Hour)}})
+	if swept.Stats.MaxDrawdownPct != 0 || swept.Stats.TotalReturnPct != 10 || swept.Stats.EndEquity != 1000 {
+		t.Errorf("Expected a sweep to be neither a drawdown nor a loss, got %+v", swept.Stats)
+	}
+
+	if empty := ComputeEquity(nil, nil); len(empty.Points) != 0 || empty.Stats.Days != 0 {
+		t.Errorf("Expected an empty report, got %+v", empty)
+	}
+}
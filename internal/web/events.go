@@ -0,0 +1,154 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+)
+
+// Live dashboard event types
+// 实时看板事件类型
+const (
+	EventPosition = "position" // 持仓变化 / Positions changed
+	EventSession  = "session"  // 新的交易会话 / New trading session
+	EventBalance  = "balance"  // 余额快照 / Balance snapshot
+	EventLog      = "log"      // 日志行 / Log line
+)
+
+// eventHubBuffer is how many events a slow dashboard may lag behind before events are dropped for it
+// eventHubBuffer 是慢速看板最多可积压的事件数，超出后丢弃该看板的事件
+const eventHubBuffer = 128
+
+// Event is one update pushed to the live dashboard
+// Event 是推送到实时看板的一条更新
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// EventHub fans dashboard updates (positions, sessions, balance, logs) out to connected browsers
+// EventHub 将看板更新（持仓、会话、余额、日志）分发给已连接的浏览器
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventHub creates an event hub without subscribers
+// NewEventHub 创建一个没有订阅者的事件中心
+func NewEventHub() *EventHub {
+	return &EventHub{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns the events published from now on and a function that ends the subscription
+// Subscribe 返回此后发布的事件，以及用于取消订阅的函数
+func (h *EventHub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventHubBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends an event to every subscriber without blocking; a nil hub discards it
+// Publish 以非阻塞方式将事件发送给所有订阅者；nil 事件中心直接丢弃
+func (h *EventHub) Publish(eventType string, data any) {
+	if h == nil {
+		return
+	}
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// The browser is too slow; it misses this update rather than stalling the bot
+			// 浏览器过慢时丢弃该更新，而不是拖慢交易流程
+		}
+	}
+}
+
+// SetEventHub enables the live dashboard endpoint, fed by the trading loop and the logger
+// SetEventHub 启用实时看板接口，数据来自交易循环和日志
+func (s *Server) SetEventHub(hub *EventHub) {
+	s.events = hub
+}
+
+// handleEvents pushes dashboard updates to the browser as server-sent events, one SSE event name per type
+// handleEvents 以 SSE 将看板更新推送到浏览器，每种类型对应一个 SSE 事件名
+func (s *Server) handleEvents(ctx context.Context, c *app.RequestContext) {
+	if s.events == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "实时推送未启用"})
+		return
+	}
+
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	c.SetStatusCode(http.StatusOK)
+	c.Response.Header.Set("Content-Type", "text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var frame []byte
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			frame = eventFrame(event)
+			if frame == nil {
+				continue
+			}
+		case <-heartbeat.C:
+			if s.stopping.Load() {
+				return
+			}
+			frame = []byte(": ping\n\n")
+		}
+
+		// A failed write means the browser went away
+		// 写入失败说明浏览器已断开
+		if _, err := c.Write(frame); err != nil {
+			return
+		}
+		if err := c.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventFrame encodes an event as an SSE frame, or returns nil if it cannot be marshalled
+// eventFrame 将事件编码为 SSE 帧，无法序列化时返回 nil
+//
+// Failures are not logged: log lines flow through the hub, so logging here could feed back into it.
+// 序列化失败时不记录日志：日志行本身经由事件中心推送，在此记录可能形成循环。
+func eventFrame(event Event) []byte {
+	data, err := sonic.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data))
+}
@@ -0,0 +1,36 @@
+package web
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEventHub tests fan-out, dropping for slow subscribers and unsubscribing
+// TestEventHub 测试事件分发、慢订阅者丢弃事件以及取消订阅
+func TestEventHub(t *testing.T) {
+	hub := NewEventHub()
+	events, unsubscribe := hub.Subscribe()
+
+	for i := 0; i < eventHubBuffer+10; i++ {
+		hub.Publish(EventLog, map[string]string{"message": "line"})
+	}
+	if len(events) != eventHubBuffer {
+		t.Errorf("Expected %d buffered events, got %d", eventHubBuffer, len(events))
+	}
+
+	event := <-events
+	frame := string(eventFrame(event))
+	if !strings.HasPrefix(frame, "event: log\ndata: ") || !strings.HasSuffix(frame, "\n\n") || !strings.Contains(frame, `"message":"line"`) {
+		t.Errorf("Unexpected SSE frame: %q", frame)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	hub.Publish(EventBalance, nil)
+	if len(hub.subscribers) != 0 {
+		t.Errorf("Expected no subscribers after unsubscribing, got %d", len(hub.subscribers))
+	}
+
+	var nilHub *EventHub
+	nilHub.Publish(EventSession, nil) // must not panic / 不应 panic
+}
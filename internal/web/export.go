@@ -0,0 +1,213 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// exportTable is the result of an export: the column names and one record per row, keyed by column
+// exportTable 是导出结果：列名以及每行一条按列名索引的记录
+//
+// JSON returns the records as they are; CSV writes the columns in order, so both formats carry the same fields.
+// JSON 直接返回记录；CSV 按列顺序写出，因此两种格式包含相同的字段。
+type exportTable struct {
+	columns []string
+	records []map[string]any
+}
+
+// add appends a record whose values follow the column order
+// add 追加一条记录，取值顺序与列顺序一致
+func (t *exportTable) add(values ...any) {
+	record := make(map[string]any, len(t.columns))
+	for i, column := range t.columns {
+		record[column] = values[i]
+	}
+	t.records = append(t.records, record)
+}
+
+// writeCSV writes the table with a header row, prefixed by a UTF-8 BOM so spreadsheets detect the encoding
+// writeCSV 写出带表头的表格，并加 UTF-8 BOM，便于电子表格识别编码（中文不乱码）
+func (t *exportTable) writeCSV(buf *bytes.Buffer) error {
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(buf)
+	if err := w.Write(t.columns); err != nil {
+		return err
+	}
+	row := make([]string, len(t.columns))
+	for _, record := range t.records {
+		for i, column := range t.columns {
+			row[i] = csvValue(record[column])
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvValue formats one cell: RFC 3339 times, shortest float form, empty for nil
+// csvValue 格式化单元格：时间为 RFC 3339，浮点数取最短表示，nil 为空
+func csvValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// parseExportTime parses an RFC 3339 time or a YYYY-MM-DD date (local midnight); endOfDay moves a date to the next midnight
+// parseExportTime 解析 RFC 3339 时间或 YYYY-MM-DD 日期（本地零点）；endOfDay 为 true 时日期取次日零点
+func parseExportTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or YYYY-MM-DD", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseExportRange reads from/to: from defaults to the beginning, to to now; a to date includes that whole day
+// parseExportRange 读取 from/to：from 默认不限，to 默认当前时间；to 为日期时包含当天
+func parseExportRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	start, end := time.Time{}, now
+	var err error
+	if from != "" {
+		if start, err = parseExportTime(from, false); err != nil {
+			return start, end, err
+		}
+	}
+	if to != "" {
+		if end, err = parseExportTime(to, true); err != nil {
+			return start, end, err
+		}
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+// exportSessions builds the session export (without analyst reports; see /session/:id for those)
+// exportSessions 构建会话导出（不含分析报告，完整报告见 /session/:id）
+func (s *Server) exportSessions(from, to time.Time) (*exportTable, error) {
+	sessions, err := s.storage.ExportSessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	table := &exportTable{columns: []string{"id", "batch_id", "symbol", "timeframe", "created_at", "action", "confidence",
+		"leverage", "executed", "execution_result", "sizing_policy", "decision_path", "decision"}}
+	for _, session := range sessions {
+		table.add(session.ID, session.BatchID, session.Symbol, session.Timeframe, session.CreatedAt, session.Action, session.Confidence,
+			session.Leverage, session.Executed, session.ExecutionResult, session.SizingPolicy, session.DecisionPath, session.Decision)
+	}
+	return table, nil
+}
+
+// exportPositions builds the position export (open and closed) by entry time, oldest first
+// exportPositions 构建持仓导出（含未平仓和已平仓），按开仓时间正序
+func (s *Server) exportPositions(from, to time.Time) (*exportTable, error) {
+	positions, err := s.storage.GetPositions(storage.PositionFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	table := &exportTable{columns: []string{"id", "symbol", "side", "entry_time", "entry_price", "quantity", "leverage",
+		"initial_stop_loss", "current_stop_loss", "stop_loss_type", "closed", "close_time", "close_price", "close_reason",
+		"realized_pnl", "unrealized_pnl", "open_reason"}}
+	for i := len(positions) - 1; i >= 0; i-- {
+		pos := positions[i]
+		table.add(pos.ID, pos.Symbol, pos.Side, pos.EntryTime, pos.EntryPrice, pos.Quantity, pos.Leverage,
+			pos.InitialStopLoss, pos.CurrentStopLoss, pos.StopLossType, pos.Closed, pos.CloseTime, pos.ClosePrice, pos.CloseReason,
+			pos.RealizedPnL, pos.UnrealizedPnL, pos.OpenReason)
+	}
+	return table, nil
+}
+
+// exportBalances builds the balance snapshot export
+// exportBalances 构建余额快照导出
+func (s *Server) exportBalances(from, to time.Time) (*exportTable, error) {
+	history, err := s.storage.GetBalanceHistoryBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	table := &exportTable{columns: []string{"timestamp", "total_balance", "available_balance", "unrealized_pnl", "equity", "positions"}}
+	for _, h := range history {
+		table.add(h.Timestamp, h.TotalBalance, h.AvailableBalance, h.UnrealizedPnL, h.TotalBalance+h.UnrealizedPnL, h.Positions)
+	}
+	return table, nil
+}
+
+// handleExport exports sessions, positions or balances as CSV or JSON: /api/export/:kind?format=csv|json&from=&to=
+// handleExport 以 CSV 或 JSON 导出会话、持仓或余额：/api/export/:kind?format=csv|json&from=&to=
+func (s *Server) handleExport(ctx context.Context, c *app.RequestContext) {
+	exporters := map[string]func(from, to time.Time) (*exportTable, error){
+		"sessions":  s.exportSessions,
+		"positions": s.exportPositions,
+		"balances":  s.exportBalances,
+	}
+	kind := c.Param("kind")
+	exporter, ok := exporters[kind]
+	if !ok {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("unknown export %q: use sessions, positions or balances", kind)})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "format must be csv or json"})
+		return
+	}
+
+	from, to, err := parseExportRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+
+	table, err := exporter(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	if format == "json" {
+		records := table.records
+		if records == nil {
+			records = []map[string]any{}
+		}
+		c.JSON(http.StatusOK, utils.H{"kind": kind, "from": from, "to": to, "count": len(records), "rows": records})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := table.writeCSV(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.csv"`, kind, to.Format("20060102")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseExportRange tests defaults, whole-day dates and rejected ranges
+// TestParseExportRange 测试默认值、按整天计算的日期以及非法区间
+func TestParseExportRange(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+
+	from, to, err := parseExportRange("", "", now)
+	if err != nil || !from.IsZero() || !to.Equal(now) {
+		t.Errorf("Expected [zero, now), got [%v, %v) %v", from, to, err)
+	}
+
+	from, to, err = parseExportRange("2025-03-01", "2025-03-09", now)
+	if err != nil || !from.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)) || !to.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected the to date to include the whole day, got [%v, %v) %v", from, to, err)
+	}
+
+	if _, to, err = parseExportRange("", "2025-03-09T08:00:00Z", now); err != nil || !to.Equal(time.Date(2025, 3, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected an RFC 3339 to as given, got %v %v", to, err)
+	}
+
+	for _, r := range [][2]string{{"yesterday", ""}, {"2025-03-09", "2025-03-01"}} {
+		if _, _, err := parseExportRange(r[0], r[1], now); err == nil {
+			t.Errorf("Expected %v to be rejected", r)
+		}
+	}
+}
+
+// TestExportTableCSV tests that CSV rows follow the column order and quote multi-line text
+// TestExportTableCSV 测试 CSV 行按列顺序输出并为多行文本加引号
+func TestExportTableCSV(t *testing.T) {
+	table := &exportTable{columns: []string{"timestamp", "symbol", "pnl", "close_time", "decision"}}
+	var closeTime *time.Time
+	table.add(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC), "BTCUSDT", 12.5, closeTime, "BUY\n理由")
+
+	var buf bytes.Buffer
+	if err := table.writeCSV(&buf); err != nil {
+		t.Fatalf("writeCSV failed: %v", err)
+	}
+	want := "\ufefftimestamp,symbol,pnl,close_time,decision\n2025-03-10T08:00:00Z,BTCUSDT,12.5,,\"BUY\n理由\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Unexpected CSV:\n%q\nwant\n%q", got, want)
+	}
+	if !strings.HasPrefix(buf.String(), "\ufeff") {
+		t.Error("Expected a UTF-8 BOM")
+	}
+}
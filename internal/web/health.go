@@ -0,0 +1,91 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/health"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+)
+
+// healthCacheTTL is how long a health report is reused; /health needs no login, so it must not let
+// anyone trigger Binance and LLM requests at will
+// healthCacheTTL 是健康检查报告的复用时长；/health 无需登录，不能让任何人随意触发币安和 LLM 请求
+const healthCacheTTL = 5 * time.Second
+
+// llmProbeClient is used for the LLM reachability check; each check is also bounded by its context
+// llmProbeClient 用于 LLM 可达性检查；每项检查同时受其 context 的超时限制
+var llmProbeClient = &http.Client{Timeout: 10 * time.Second}
+
+// SetExecutor enables the Binance reachability and clock drift check of /health
+// SetExecutor 启用 /health 的币安可达性和时钟偏差检查
+func (s *Server) SetExecutor(executor *executors.BinanceExecutor) {
+	s.executor = executor
+}
+
+// healthChecks returns the readiness checks of the server's dependencies
+// healthChecks 返回服务器各依赖的就绪检查
+func (s *Server) healthChecks() []health.Check {
+	checks := []health.Check{
+		health.SQLite(s.storage),
+		health.LLM(llmProbeClient, s.config.BackendURL, s.config.APIKey),
+		health.Scheduler(s.scheduler.LastHeartbeat, s.scheduler.GetNextTimeframeTime),
+		health.LastRun(s.storage, s.startedAt, func() time.Duration {
+			return time.Duration(s.scheduler.GetMinutes()) * time.Minute
+		}),
+	}
+	if s.executor != nil {
+		maxDrift := time.Duration(s.config.ClockDriftAlertMs) * time.Millisecond
+		checks = append(checks, health.Binance(maxDrift, s.executor.ServerTimeDrift))
+	}
+	return checks
+}
+
+// handleHealth is the public readiness probe: 200 when ready, 503 when a dependency is down
+// handleHealth 是公开的就绪探针：就绪时返回 200，任一依赖不可用时返回 503
+//
+// Only the status and a generic message are returned; the details need a login (/api/health).
+// 只返回状态和通用说明；详情需要登录后查看（/api/health）。
+func (s *Server) handleHealth(ctx context.Context, c *app.RequestContext) {
+	report := s.healthCache.Run(ctx, s.healthChecks)
+
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, utils.H{
+		"status":  report.Status,
+		"message": report.Summary(),
+	})
+}
+
+// handleHealthDetails returns the status, latency, message and details of every dependency
+// handleHealthDetails 返回每个依赖的状态、耗时、说明和详情
+func (s *Server) handleHealthDetails(ctx context.Context, c *app.RequestContext) {
+	report := s.healthCache.Run(ctx, s.healthChecks)
+
+	// The breaker reason carries exchange errors, so only its state is returned
+	// 熔断原因包含交易所错误信息，因此只返回其状态
+	breaker, err := risk.LoadBreaker(s.storage, s.config)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  读取熔断状态失败: %v", err))
+	}
+
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, utils.H{
+		"status":          report.Status,
+		"time":            report.Time,
+		"version":         "1.0.0",
+		"uptime_seconds":  int64(time.Since(s.startedAt).Seconds()),
+		"circuit_breaker": breaker.Status(time.Now()),
+		"dependencies":    report.Dependencies,
+	})
+}
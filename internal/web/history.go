@@ -0,0 +1,103 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+// SetHistoryFlusher lets the history API flush the trading loop's in-memory histories before reading
+// SetHistoryFlusher 让历史 API 在读取前先写入交易循环的内存历史
+func (s *Server) SetHistoryFlusher(flusher *executors.HistoryFlusher) {
+	s.historyFlusher = flusher
+}
+
+// flushHistory writes pending in-memory histories so the tables are up to date
+// flushHistory 写入待处理的内存历史，确保数据表为最新
+func (s *Server) flushHistory() {
+	if s.historyFlusher == nil {
+		return
+	}
+	if err := s.historyFlusher.Flush(); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  写入历史数据失败: %v", err))
+	}
+}
+
+// handlePriceHistory returns the position price history of a symbol for charting
+// handlePriceHistory 返回交易对的持仓价格历史，用于图表展示
+func (s *Server) handlePriceHistory(ctx context.Context, c *app.RequestContext) {
+	symbol := s.config.GetBinanceSymbolFor(c.Param("symbol"))
+	hours := 24 // Default to last 24 hours / 默认最近 24 小时
+	if h := c.Query("hours"); h != "" {
+		fmt.Sscanf(h, "%d", &hours)
+	}
+	limit := 1000
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+
+	s.flushHistory()
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	records, err := s.storage.GetPriceHistory(symbol, since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	type point struct {
+		Time  time.Time `json:"time"`
+		Price float64   `json:"price"`
+	}
+	points := make([]point, 0, len(records))
+	var last time.Time
+	for _, r := range records {
+		points = append(points, point{Time: r.Time, Price: r.Price})
+		last = r.Time
+	}
+
+	// Points not flushed yet (no flusher, or written after the flush) come from memory
+	// 尚未写入的价格点（未配置写入器或写入后产生的）从内存读取
+	if s.stopLossManager != nil {
+		for _, p := range s.stopLossManager.GetPriceHistory(symbol) {
+			if p.Time.After(last) && !p.Time.Before(since) {
+				points = append(points, point{Time: p.Time, Price: p.Price})
+			}
+		}
+	}
+	if len(points) > limit {
+		points = points[len(points)-limit:]
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"symbol": symbol,
+		"points": points,
+		"count":  len(points),
+	})
+}
+
+// handleExecutionHistory returns the latest trade execution results
+// handleExecutionHistory 返回最近的交易执行结果
+func (s *Server) handleExecutionHistory(ctx context.Context, c *app.RequestContext) {
+	symbol := s.config.GetBinanceSymbolFor(c.Query("symbol")) // 为空表示全部 / Empty = all symbols
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+
+	s.flushHistory()
+	executions, err := s.storage.GetExecutions(symbol, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"executions": executions,
+		"count":      len(executions),
+	})
+}
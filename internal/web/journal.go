@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/format"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// journalSessionWindow is how long before a position's entry its originating session may have been created
+// journalSessionWindow 是开仓决策会话最早可早于开仓时间的时长
+//
+// Stop entries can fill hours after the decision that placed them.
+// 条件入场单可能在下单决策数小时后才成交。
+const journalSessionWindow = 24 * time.Hour
+
+// JournalEntry is one closed position with everything needed to audit it
+// JournalEntry 是一个已平仓持仓及审计所需的全部信息
+type JournalEntry struct {
+	Position   *storage.PositionRecord
+	Duration   time.Duration
+	MovePct    float64                  // 按方向计算的价格变动（未含杠杆）/ Side-adjusted price move, before leverage
+	Session    *storage.TradingSession  // 开仓决策所在会话（人工交易为 nil）/ Session that opened it (nil for manual trades)
+	StopEvents []*storage.StopLossEvent // 止损变更时间线 / Stop-loss timeline
+	Notes      []*storage.Note
+}
+
+// newJournalEntry builds the journal entry of a closed position from its records
+// newJournalEntry 根据记录构建已平仓持仓的交易日志条目
+func newJournalEntry(pos *storage.PositionRecord, session *storage.TradingSession, events []*storage.StopLossEvent, notes []*storage.Note) *JournalEntry {
+	entry := &JournalEntry{Position: pos, Session: session, StopEvents: events, Notes: notes}
+	if pos.CloseTime != nil {
+		entry.Duration = pos.CloseTime.Sub(pos.EntryTime)
+	}
+	if pos.EntryPrice > 0 && pos.ClosePrice > 0 {
+		entry.MovePct = (pos.ClosePrice - pos.EntryPrice) / pos.EntryPrice * 100
+		if pos.Side == "short" {
+			entry.MovePct = -entry.MovePct
+		}
+	}
+	return entry
+}
+
+// formatHoldDuration renders a holding time such as "2天3小时", "3小时12分" or "45分"
+// formatHoldDuration 将持仓时长格式化为 "2天3小时"、"3小时12分" 或 "45分"
+func formatHoldDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	days, hours, mins := minutes/(24*60), minutes/60%24, minutes%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d天%d小时", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%d小时%d分", hours, mins)
+	default:
+		return fmt.Sprintf("%d分", mins)
+	}
+}
+
+// handleJournal renders the trade journal: closed positions with their stop timeline and originating decision
+// handleJournal 渲染交易日志：已平仓持仓及其止损时间线和开仓决策
+func (s *Server) handleJournal(ctx context.Context, c *app.RequestContext) {
+	limit := 50 // Default page size / 默认条数
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+		if limit != 20 && limit != 50 && limit != 100 {
+			limit = 50
+		}
+	}
+	symbol := c.Query("symbol")
+
+	positions, err := s.storage.GetPositions(storage.PositionFilter{Symbol: symbol, Closed: true, Limit: limit})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]string, len(positions))
+	for i, pos := range positions {
+		ids[i] = pos.ID
+	}
+	notes, err := s.storage.GetNotesFor(storage.NoteTargetPosition, ids)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  查询持仓备注失败: %v", err))
+	}
+
+	entries := make([]*JournalEntry, 0, len(positions))
+	var totalPnL float64
+	wins := 0
+	for _, pos := range positions {
+		events, err := s.storage.GetStopLossEvents(pos.ID)
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  查询 %s 止损事件失败: %v", pos.ID, err))
+		}
+		session, err := s.storage.GetOriginatingSession(pos.Symbol, pos.EntryTime, journalSessionWindow)
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  查询 %s 开仓会话失败: %v", pos.ID, err))
+		}
+		entries = append(entries, newJournalEntry(pos, session, events, notes[pos.ID]))
+
+		totalPnL += pos.RealizedPnL
+		if pos.RealizedPnL > 0 {
+			wins++
+		}
+	}
+
+	funcMap := template.FuncMap{
+		"price":         format.Adaptive,
+		"signed":        format.Signed,
+		"holdDuration":  formatHoldDuration,
+		"extractAction": extractActionFromDecision,
+	}
+	tmpl := template.Must(template.New("journal.html").Funcs(funcMap).ParseFiles("internal/web/templates/journal.html"))
+
+	data := map[string]interface{}{
+		"Entries":  entries,
+		"Symbols":  s.config.CryptoSymbols,
+		"Symbol":   symbol,
+		"Limit":    limit,
+		"TotalPnL": totalPnL,
+		"Wins":     wins,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,33 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestNewJournalEntry tests the holding time and side-adjusted price move of a closed position
+// TestNewJournalEntry 测试已平仓持仓的持仓时长和按方向计算的价格变动
+func TestNewJournalEntry(t *testing.T) {
+	entryTime := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	closeTime := entryTime.Add(27*time.Hour + 30*time.Minute)
+	short := &storage.PositionRecord{Side: "short", EntryPrice: 100, ClosePrice: 95, EntryTime: entryTime, CloseTime: &closeTime}
+
+	entry := newJournalEntry(short, nil, nil, nil)
+	if entry.MovePct != 5 {
+		t.Errorf("Expected a +5%% move for a short from 100 to 95, got %.2f", entry.MovePct)
+	}
+	if got := formatHoldDuration(entry.Duration); got != "1天3小时" {
+		t.Errorf("Expected 1天3小时, got %s", got)
+	}
+
+	for d, want := range map[time.Duration]string{
+		3*time.Hour + 12*time.Minute: "3小时12分",
+		45 * time.Minute:             "45分",
+	} {
+		if got := formatHoldDuration(d); got != want {
+			t.Errorf("formatHoldDuration(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
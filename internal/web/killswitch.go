@@ -0,0 +1,92 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// handleKillSwitch returns the kill switch state (GET /api/killswitch)
+// handleKillSwitch 返回急停开关状态（GET /api/killswitch）
+func (s *Server) handleKillSwitch(ctx context.Context, c *app.RequestContext) {
+	state, _, err := s.killSwitch.Check()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"kill_switch": state})
+}
+
+// handleEngageKillSwitch engages the kill switch (POST /api/killswitch)
+// handleEngageKillSwitch 启用急停开关（POST /api/killswitch）
+//
+// Entries stop right away and pending stop entries are cancelled; with "flatten": true every position
+// is also closed through the trade coordinator. Trading stays halted until POST /api/killswitch/rearm.
+// 立即停止开仓并撤销条件入场单；"flatten": true 时还会通过交易协调器平掉所有持仓。
+// 在调用 POST /api/killswitch/rearm 之前一直停止开仓。
+func (s *Server) handleEngageKillSwitch(ctx context.Context, c *app.RequestContext) {
+	var req struct {
+		Reason  string `json:"reason"`
+		Flatten bool   `json:"flatten"`
+	}
+	_ = c.BindJSON(&req) // 请求体可选 / Body is optional
+	reason := "控制台急停"
+	if req.Reason != "" {
+		reason = fmt.Sprintf("控制台急停: %s", req.Reason)
+	}
+
+	state, engaged, err := s.killSwitch.Engage(reason, executors.KillSwitchSourceAPI, req.Flatten)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	// An engaged switch only acts again to flatten on request
+	// 已启用的急停仅在要求平仓时再次执行
+	var report []string
+	if engaged || req.Flatten {
+		if s.coordinator != nil {
+			s.tradeMu.Lock()
+			report = s.coordinator.RespondToKillSwitch(ctx, state, s.notifier)
+			s.tradeMu.Unlock()
+		} else if err := s.notifier.Notify(ctx, notify.Message{
+			Title: "急停开关已启用",
+			Text:  fmt.Sprintf("来源: %s\n原因: %s", state.Source, state.Reason),
+			Level: notify.LevelError,
+		}); err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"status":      "engaged",
+		"newly":       engaged,
+		"kill_switch": state,
+		"report":      report,
+	})
+}
+
+// handleRearmKillSwitch clears the kill switch so entries resume (POST /api/killswitch/rearm)
+// handleRearmKillSwitch 解除急停开关以恢复开仓（POST /api/killswitch/rearm）
+//
+// Refused with 409 while the KILL_SWITCH_FILE sentinel still exists.
+// KILL_SWITCH_FILE 急停文件仍存在时返回 409。
+func (s *Server) handleRearmKillSwitch(ctx context.Context, c *app.RequestContext) {
+	if err := s.killSwitch.Rearm(); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, executors.ErrKillSwitchFilePresent) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, utils.H{"error": err.Error()})
+		return
+	}
+
+	s.notifyManual(ctx, "急停开关已解除", "已恢复开仓")
+	c.JSON(http.StatusOK, utils.H{"status": "rearmed"})
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// maxNoteLength is the maximum length of a note in characters
+// maxNoteLength 是备注的最大字符数
+const maxNoteLength = 2000
+
+// noteResponse is the JSON form of a note
+// noteResponse 是备注的 JSON 格式
+type noteResponse struct {
+	ID         int64     `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// toNoteResponses converts stored notes to their JSON form (never nil)
+// toNoteResponses 将备注转换为 JSON 格式（不返回 nil）
+func toNoteResponses(notes []*storage.Note) []noteResponse {
+	result := make([]noteResponse, 0, len(notes))
+	for _, n := range notes {
+		result = append(result, noteResponse{
+			ID:         n.ID,
+			TargetType: n.TargetType,
+			TargetID:   n.TargetID,
+			Text:       n.Text,
+			CreatedAt:  n.CreatedAt,
+		})
+	}
+	return result
+}
+
+// noteTarget validates the :type/:id path parameters, writing an error response when invalid
+// noteTarget 校验 :type/:id 路径参数，无效时写入错误响应
+func (s *Server) noteTarget(c *app.RequestContext) (string, string, bool) {
+	targetType, targetID := c.Param("type"), c.Param("id")
+
+	var exists bool
+	switch targetType {
+	case storage.NoteTargetSession:
+		id, err := strconv.ParseInt(targetID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{"error": "invalid session id"})
+			return "", "", false
+		}
+		_, err = s.storage.GetSessionByID(id)
+		exists = err == nil
+	case storage.NoteTargetPosition:
+		pos, err := s.storage.GetPositionByID(targetID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+			return "", "", false
+		}
+		exists = pos != nil
+	default:
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Note target must be position or session"})
+		return "", "", false
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("%s %s not found", targetType, targetID)})
+		return "", "", false
+	}
+	return targetType, targetID, true
+}
+
+// handleGetNotes returns the notes of a position or session (GET /api/notes/:type/:id)
+// handleGetNotes 返回持仓或会话的备注（GET /api/notes/:type/:id）
+func (s *Server) handleGetNotes(ctx context.Context, c *app.RequestContext) {
+	targetType, targetID, ok := s.noteTarget(c)
+	if !ok {
+		return
+	}
+
+	notes, err := s.storage.GetNotes(targetType, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"notes": toNoteResponses(notes)})
+}
+
+// handleAddNote attaches an operator note to a position or session (POST /api/notes/:type/:id)
+// handleAddNote 为持仓或会话添加操作员备注（POST /api/notes/:type/:id）
+func (s *Server) handleAddNote(ctx context.Context, c *app.RequestContext) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "text is required"})
+		return
+	}
+	if utf8.RuneCountInString(text) > maxNoteLength {
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("text must be at most %d characters", maxNoteLength)})
+		return
+	}
+
+	targetType, targetID, ok := s.noteTarget(c)
+	if !ok {
+		return
+	}
+
+	note := &storage.Note{TargetType: targetType, TargetID: targetID, Text: text}
+	if _, err := s.storage.AddNote(note); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	s.logger.Info(fmt.Sprintf("📝 添加备注: %s %s - %s", targetType, targetID, text))
+	c.JSON(http.StatusOK, utils.H{"note": toNoteResponses([]*storage.Note{note})[0]})
+}
+
+// handleDeleteNote removes a note (DELETE /api/notes/:id)
+// handleDeleteNote 删除备注（DELETE /api/notes/:id）
+func (s *Server) handleDeleteNote(ctx context.Context, c *app.RequestContext) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid note id"})
+		return
+	}
+
+	deleted, err := s.storage.DeleteNote(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, utils.H{"error": "note not found"})
+		return
+	}
+	s.logger.Info(fmt.Sprintf("🗑️  删除备注 #%d", id))
+	c.JSON(http.StatusOK, utils.H{"deleted": id})
+}
+
+// managedPosition returns the stop-loss manager's position for a symbol (nil when not managed)
+// managedPosition 返回止损管理器中该交易对的持仓（未管理时为 nil）
+func (s *Server) managedPosition(symbol string) *executors.Position {
+	if s.stopLossManager == nil {
+		return nil
+	}
+	return s.stopLossManager.GetPosition(symbol)
+}
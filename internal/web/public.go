@@ -0,0 +1,230 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// maxPublicHours caps how far back the public equity curve can look (90 days)
+// maxPublicHours 限制公开收益曲线的最大回看时长（90 天）
+const maxPublicHours = 24 * 90
+
+// RateLimiter is a fixed-window per-client request limiter
+// RateLimiter 是按客户端计数的固定窗口请求限流器
+type RateLimiter struct {
+	limit   int
+	window  time.Duration
+	clients map[string]*rateWindow
+	mu      sync.Mutex
+}
+
+// rateWindow tracks requests of one client within the current window
+// rateWindow 记录单个客户端在当前窗口内的请求数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewRateLimiter creates a limiter allowing limit requests per window per client
+// NewRateLimiter 创建每个客户端每个窗口最多允许 limit 次请求的限流器
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// Allow reports whether a request from key is allowed, and the time until the window resets
+// Allow 返回来自 key 的请求是否允许，以及距离窗口重置的时间
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop stale windows to keep memory bounded
+	// 清理过期窗口，避免内存无限增长
+	if len(rl.clients) > 10000 {
+		for k, w := range rl.clients {
+			if now.Sub(w.start) >= rl.window {
+				delete(rl.clients, k)
+			}
+		}
+	}
+
+	w, exists := rl.clients[key]
+	if !exists || now.Sub(w.start) >= rl.window {
+		rl.clients[key] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+
+	if w.count >= rl.limit {
+		return false, w.start.Add(rl.window).Sub(now)
+	}
+
+	w.count++
+	return true, 0
+}
+
+// PublicMiddleware validates the share token and applies rate limiting to public routes
+// PublicMiddleware 校验分享令牌并对公开路由进行限流
+func (s *Server) PublicMiddleware() app.HandlerFunc {
+	limit := s.config.PublicRateLimit
+	if limit <= 0 {
+		limit = 30
+	}
+	limiter := NewRateLimiter(limit, time.Minute)
+
+	return func(ctx context.Context, c *app.RequestContext) {
+		allowed, retryAfter := limiter.Allow(c.ClientIP())
+		if !allowed {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, utils.H{"error": "rate limit exceeded"})
+			c.Abort()
+			return
+		}
+
+		// Unknown tokens look exactly like missing pages
+		// 无效令牌与不存在的页面返回相同结果
+		token := c.Param("token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.PublicPageToken)) != 1 {
+			c.JSON(http.StatusNotFound, utils.H{"error": "not found"})
+			c.Abort()
+			return
+		}
+
+		c.Header("X-Robots-Tag", "noindex, nofollow")
+		c.Header("Cache-Control", "public, max-age=60")
+		c.Next(ctx)
+	}
+}
+
+// handlePublicPage renders the anonymized performance page
+// handlePublicPage 渲染匿名化的业绩展示页面
+func (s *Server) handlePublicPage(ctx context.Context, c *app.RequestContext) {
+	tmpl := template.Must(template.ParseFiles("internal/web/templates/public.html", "internal/web/templates/number_format.html"))
+
+	data := map[string]interface{}{
+		"Symbols":         s.config.CryptoSymbols,
+		"TradingInterval": s.config.TradingInterval,
+		"Token":           s.config.PublicPageToken,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": "render failed"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// handlePublicPerformance returns the equity curve and trade statistics in percent terms only
+// handlePublicPerformance 仅以百分比形式返回收益曲线和交易统计
+//
+// No balances, quantities, prices or PnL amounts are ever included in the response.
+// 响应中不包含任何余额、数量、价格或盈亏金额。
+func (s *Server) handlePublicPerformance(ctx context.Context, c *app.RequestContext) {
+	hours := 24 * 7 // Default to last 7 days / 默认最近 7 天
+	if h := c.Query("hours"); h != "" {
+		fmt.Sscanf(h, "%d", &hours)
+	}
+	if hours <= 0 || hours > maxPublicHours {
+		hours = maxPublicHours
+	}
+
+	history, err := s.storage.GetBalanceHistory(hours)
+	if err != nil {
+		// Don't leak internal errors publicly
+		// 不向公开接口泄露内部错误
+		s.logger.Warning(fmt.Sprintf("⚠️  公开业绩页查询失败: %v", err))
+		c.JSON(http.StatusInternalServerError, utils.H{"error": "unavailable"})
+		return
+	}
+	withdrawals, err := s.storage.GetWithdrawalsSince(time.Now().Add(-time.Duration(hours) * time.Hour))
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  公开业绩页查询失败: %v", err))
+		c.JSON(http.StatusInternalServerError, utils.H{"error": "unavailable"})
+		return
+	}
+
+	timestamps := make([]string, 0, len(history))
+	returns := make([]float64, 0, len(history))
+	var base, peak, maxDrawdown float64
+	var start time.Time
+
+	// Profit sweeps are withdrawals, not losses: the amount swept since the first snapshot is added back
+	// 利润提取是出金而不是亏损：加回自首个快照以来提取的金额
+	for _, h := range history {
+		equity := h.TotalBalance + h.UnrealizedPnL
+		if base == 0 {
+			if equity <= 0 {
+				continue
+			}
+			base = equity
+			peak = equity
+			start = h.Timestamp
+		}
+		equity += withdrawals.Between(start, h.Timestamp)
+
+		if equity > peak {
+			peak = equity
+		}
+		if drawdown := (peak - equity) / peak * 100; drawdown > maxDrawdown {
+			maxDrawdown = drawdown
+		}
+
+		timestamps = append(timestamps, h.Timestamp.Format("01-02 15:04"))
+		returns = append(returns, math.Round((equity/base-1)*100*100)/100)
+	}
+
+	totalReturn := 0.0
+	if len(returns) > 0 {
+		totalReturn = returns[len(returns)-1]
+	}
+
+	// Closed trade statistics (counts and ratios only)
+	// 已平仓交易统计（仅数量和比例）
+	closedTrades, winningTrades := 0, 0
+	for _, symbol := range s.config.CryptoSymbols {
+		positions, err := s.storage.GetPositionsBySymbol(symbol)
+		if err != nil {
+			continue
+		}
+		for _, pos := range positions {
+			if !pos.Closed {
+				continue
+			}
+			closedTrades++
+			if pos.RealizedPnL > 0 {
+				winningTrades++
+			}
+		}
+	}
+
+	winRate := 0.0
+	if closedTrades > 0 {
+		winRate = math.Round(float64(winningTrades)/float64(closedTrades)*100*100) / 100
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"timestamps":       timestamps,
+		"return_pct":       returns,
+		"total_return_pct": totalReturn,
+		"max_drawdown_pct": math.Round(maxDrawdown*100) / 100,
+		"closed_trades":    closedTrades,
+		"win_rate_pct":     winRate,
+		"hours":            hours,
+	})
+}
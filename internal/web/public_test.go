@@ -0,0 +1,51 @@
+package web
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRateLimiterAllow tests that requests beyond the limit are rejected within a window
+// TestRateLimiterAllow 测试窗口内超过限制的请求会被拒绝
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := NewRateLimiter(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.Allow("1.2.3.4"); !allowed {
+			t.Fatalf("Request %d should be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter := limiter.Allow("1.2.3.4")
+	if allowed {
+		t.Fatal("Request beyond limit should be rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("Expected retry-after within window, got %v", retryAfter)
+	}
+
+	// Other clients have their own window
+	// 其他客户端拥有独立的窗口
+	if allowed, _ := limiter.Allow("5.6.7.8"); !allowed {
+		t.Error("Different client should be allowed")
+	}
+}
+
+// TestRateLimiterWindowReset tests that the counter resets after the window expires
+// TestRateLimiterWindowReset 测试窗口过期后计数会重置
+func TestRateLimiterWindowReset(t *testing.T) {
+	limiter := NewRateLimiter(1, 20*time.Millisecond)
+
+	if allowed, _ := limiter.Allow("client"); !allowed {
+		t.Fatal("First request should be allowed")
+	}
+	if allowed, _ := limiter.Allow("client"); allowed {
+		t.Fatal("Second request should be rejected")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if allowed, _ := limiter.Allow("client"); !allowed {
+		t.Error("Request after window reset should be allowed")
+	}
+}
@@ -0,0 +1,24 @@
+package web
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/ratelimit"
+)
+
+// handleRateLimit returns the throttling metrics of the shared Binance request limiter (GET /api/ratelimit)
+// handleRateLimit 返回共享币安请求限流器的限流指标（GET /api/ratelimit）
+func (s *Server) handleRateLimit(ctx context.Context, c *app.RequestContext) {
+	limiter := ratelimit.Default()
+	if limiter == nil {
+		c.JSON(http.StatusOK, utils.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"enabled": true,
+		"stats":   limiter.Stats(),
+	})
+}
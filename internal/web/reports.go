@@ -0,0 +1,123 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/reports"
+)
+
+// handleReportsPage renders the daily and weekly performance reports page
+// handleReportsPage 渲染日报和周报页面
+func (s *Server) handleReportsPage(ctx context.Context, c *app.RequestContext) {
+	tmpl := template.Must(template.ParseFiles("internal/web/templates/reports.html", "internal/web/templates/number_format.html"))
+
+	data := map[string]interface{}{
+		"Symbols":      s.config.CryptoSymbols,
+		"AnalysisOnly": s.config.IsAnalysisOnly(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// handleReports returns the latest stored reports of a period (daily / weekly, empty = both), newest first
+// handleReports 返回最近保存的报告（daily / weekly，为空表示两者），按统计区间倒序
+func (s *Server) handleReports(ctx context.Context, c *app.RequestContext) {
+	period := c.Query("period")
+	if period != "" && period != reports.PeriodDaily && period != reports.PeriodWeekly {
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("未知的报告周期: %s", period)})
+		return
+	}
+	limit := 30
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if limit <= 0 || limit > 365 {
+		limit = 365
+	}
+
+	stored, err := s.storage.GetReports(period, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]utils.H, 0, len(stored))
+	for _, r := range stored {
+		result = append(result, utils.H{
+			"period":           r.Period,
+			"period_start":     r.PeriodStart.Format("2006-01-02"),
+			"period_end":       r.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+			"title":            reports.Title(r),
+			"trades":           r.Trades,
+			"wins":             r.Wins,
+			"win_rate":         round2(r.WinRate),
+			"realized_pnl":     round2(r.RealizedPnL),
+			"fees":             round2(r.Fees),
+			"best_trade":       r.BestTrade,
+			"best_pnl":         round2(r.BestPnL),
+			"worst_trade":      r.WorstTrade,
+			"worst_pnl":        round2(r.WorstPnL),
+			"start_equity":     round2(r.StartEquity),
+			"end_equity":       round2(r.EndEquity),
+			"return_pct":       round2(reports.ReturnPct(r)),
+			"max_drawdown_pct": round2(r.MaxDrawdownPct),
+			"created_at":       r.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, utils.H{"reports": result})
+}
+
+// handleAttribution returns realized PnL, win rate and average R by symbol, entry action and leverage bucket
+// for the positions closed in the last `days` days (0 = all)
+// handleAttribution 返回最近 days 天（0 表示全部）已平仓持仓按交易对、开仓动作和杠杆区间拆分的已实现盈亏、胜率和平均 R
+func (s *Server) handleAttribution(ctx context.Context, c *app.RequestContext) {
+	days := 0
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	attribution, err := s.storage.GetPerformanceAttribution(since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attribution)
+}
+
+// handleDecisionCalibration returns the win rate and realized R by decision confidence and expected
+// risk/reward for the positions closed in the last `days` days (0 = all)
+// handleDecisionCalibration 返回最近 days 天（0 表示全部）平仓持仓按决策置信度和预期盈亏比分组的胜率和实际 R
+func (s *Server) handleDecisionCalibration(ctx context.Context, c *app.RequestContext) {
+	days := 0
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	calibration, err := s.storage.GetDecisionCalibration(since, 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, calibration)
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// RunTrigger starts an analysis batch outside the schedule and returns its batch ID;
+// when a batch is already running it returns that batch's ID and started = false
+// RunTrigger 在调度之外启动一个分析批次并返回批次 ID；已有批次在运行时返回该批次 ID，started 为 false
+type RunTrigger func() (batchID string, started bool)
+
+// SetRunTrigger enables the "run now" endpoint, wired to the trading loop
+// SetRunTrigger 启用"立即分析"接口，由交易循环提供
+func (s *Server) SetRunTrigger(trigger RunTrigger) {
+	s.runTrigger = trigger
+}
+
+// handleRunNow starts an analysis batch right away (POST /api/run)
+// handleRunNow 立即启动一个分析批次（POST /api/run）
+//
+// Runs never overlap: while a batch is in flight the request is refused with 409 and that batch's ID.
+// The batch runs in the background; its sessions appear on the dashboard under the returned batch_id.
+// 批次不会重叠：已有批次在运行时返回 409 和该批次 ID。批次在后台运行，其会话以返回的 batch_id 显示在控制台。
+func (s *Server) handleRunNow(ctx context.Context, c *app.RequestContext) {
+	if s.runTrigger == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Run now is not enabled on this server"})
+		return
+	}
+	if s.stopping.Load() {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "服务正在关闭，不再启动新的分析批次"})
+		return
+	}
+
+	batchID, started := s.runTrigger()
+	if !started {
+		c.JSON(http.StatusConflict, utils.H{
+			"error":    fmt.Sprintf("分析批次 %s 正在进行，请等待其完成", batchID),
+			"batch_id": batchID,
+		})
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("▶️  控制台手动触发分析批次 %s", batchID))
+	c.JSON(http.StatusAccepted, utils.H{
+		"status":   "started",
+		"batch_id": batchID,
+	})
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// TestHandleRunNow tests the started, already-running and disabled responses
+// TestHandleRunNow 测试已启动、已在运行和未启用三种响应
+func TestHandleRunNow(t *testing.T) {
+	s := &Server{logger: logger.NewColorLogger(false)}
+	h := server.Default()
+	h.POST("/api/run", s.handleRunNow)
+
+	post := func() (int, string) {
+		resp := ut.PerformRequest(h.Engine, http.MethodPost, "/api/run", nil).Result()
+		return resp.StatusCode(), string(resp.Body())
+	}
+
+	if code, _ := post(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a trigger, got %d", code)
+	}
+
+	running := ""
+	s.SetRunTrigger(func() (string, bool) {
+		if running != "" {
+			return running, false
+		}
+		running = "batch-1"
+		return running, true
+	})
+	if code, body := post(); code != http.StatusAccepted || !strings.Contains(body, "batch-1") {
+		t.Errorf("Expected 202 with the batch ID, got %d %s", code, body)
+	}
+	if code, body := post(); code != http.StatusConflict || !strings.Contains(body, "batch-1") {
+		t.Errorf("Expected 409 with the running batch ID, got %d %s", code, body)
+	}
+}
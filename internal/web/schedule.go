@@ -0,0 +1,46 @@
+package web
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/scheduler"
+)
+
+// SetJobs exposes the background cron jobs (stop review, daily report) on the dashboard
+// SetJobs 在控制台展示后台 cron 定时任务（止损复查、每日报告）
+func (s *Server) SetJobs(jobs *scheduler.Jobs) {
+	s.jobs = jobs
+}
+
+// jobStatuses returns the cron jobs' state, nil when none are registered
+// jobStatuses 返回 cron 定时任务的状态，未注册任务时返回 nil
+func (s *Server) jobStatuses() []scheduler.JobStatus {
+	if s.jobs == nil {
+		return nil
+	}
+	return s.jobs.Status()
+}
+
+// handleSchedule returns the next analysis run and the state of every cron job
+// handleSchedule 返回下次分析时间以及每个 cron 定时任务的状态
+func (s *Server) handleSchedule(ctx context.Context, c *app.RequestContext) {
+	analysis := utils.H{
+		"timeframe": s.scheduler.CurrentTimeframe(),
+		"next_run":  s.scheduler.GetNextTimeframeTime(),
+	}
+	if cron := s.scheduler.Cron(); cron != "" {
+		analysis["cron"] = cron
+	}
+
+	jobs := s.jobStatuses()
+	if jobs == nil {
+		jobs = []scheduler.JobStatus{}
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"analysis": analysis,
+		"jobs":     jobs,
+	})
+}
@@ -3,24 +3,36 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/agents"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/health"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/risk"
 	"github.com/oak/crypto-trading-bot/internal/scheduler"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
+// analysisOnlyMessage is returned by the account endpoints when no Binance keys are configured
+// analysisOnlyMessage 是未配置币安 API Key 时账户相关接口返回的错误信息
+const analysisOnlyMessage = "仅分析模式：未配置币安 API Key，账户数据不可用"
+
 // Server represents the web monitoring server
 // Server 表示 Web 监控服务器
 type Server struct {
@@ -29,15 +41,38 @@ type Server struct {
 	storage         *storage.Storage
 	stopLossManager *executors.StopLossManager
 	scheduler       *scheduler.TradingScheduler
-	sessionManager  *SessionManager // Session 管理器 / Session manager
+	sessionManager  *SessionManager             // Session 管理器 / Session manager
+	loginLimiter    *RateLimiter                // 登录限流器 / Login attempt limiter
+	notifier        notify.Notifier             // 通知渠道 / Notification sinks
+	historyFlusher  *executors.HistoryFlusher   // 内存历史写入器（可为 nil）/ In-memory history flusher (may be nil)
+	coordinator     *executors.TradeCoordinator // 人工交易协调器（可为 nil）/ Manual trading coordinator (may be nil)
+	decisionStream  *agents.DecisionStream      // 流式决策输出（可为 nil）/ Streamed decision output (may be nil)
+	jobs            *scheduler.Jobs             // 后台定时任务（可为 nil）/ Background cron jobs (may be nil)
+	runTrigger      RunTrigger                  // 立即分析（可为 nil）/ Run-now trigger (may be nil)
+	events          *EventHub                   // 实时看板推送（可为 nil）/ Live dashboard updates (may be nil)
+	killSwitch      *executors.KillSwitch       // 急停开关 / Kill switch
+	executor        *executors.BinanceExecutor  // 币安执行器，用于健康检查（可为 nil）/ Binance executor for the health check (may be nil)
+	healthCache     *health.Cache               // 健康检查结果缓存 / Cached health check results
+	startedAt       time.Time                   // 服务器创建时间 / When the server was created
+	tradeMu         sync.Mutex                  // 串行化人工交易 / Serializes manual trades
+	stopping        atomic.Bool                 // 是否已调用 Stop / Whether Stop was called
 	hertz           *server.Hertz
 }
 
 // NewServer creates a new web monitoring server
 // NewServer 创建新的 Web 监控服务器
-func NewServer(cfg *config.Config, log *logger.ColorLogger, db *storage.Storage, stopLossMgr *executors.StopLossManager, sched *scheduler.TradingScheduler) *Server {
+func NewServer(cfg *config.Config, log *logger.ColorLogger, db *storage.Storage, stopLossMgr *executors.StopLossManager, sched *scheduler.TradingScheduler, notifier notify.Notifier) *Server {
 	h := server.Default(server.WithHostPorts(fmt.Sprintf(":%d", cfg.WebPort)))
 
+	if notifier == nil {
+		notifier = notify.NewMultiNotifier() // No sinks, Notify is a no-op / 无渠道，Notify 不做任何操作
+	}
+
+	loginLimit := cfg.WebLoginRateLimit
+	if loginLimit <= 0 {
+		loginLimit = 10
+	}
+
 	s := &Server{
 		config:          cfg,
 		logger:          log,
@@ -45,9 +80,18 @@ func NewServer(cfg *config.Config, log *logger.ColorLogger, db *storage.Storage,
 		stopLossManager: stopLossMgr,
 		scheduler:       sched,               // Use provided scheduler / 使用提供的调度器
 		sessionManager:  NewSessionManager(), // 初始化 Session 管理器 / Initialize session manager
+		loginLimiter:    NewRateLimiter(loginLimit, time.Minute),
+		notifier:        notifier,
+		killSwitch:      executors.NewKillSwitch(db, cfg, log),
+		healthCache:     health.NewCache(healthCacheTTL),
+		startedAt:       time.Now(),
 		hertz:           h,
 	}
 
+	if cfg.WebPassword == "" || cfg.WebPassword == "changeme" {
+		log.Warning("⚠️  WEB_PASSWORD 未设置或仍为默认值，请修改为强密码（未设置密码时禁止登录）")
+	}
+
 	s.setupRoutes()
 
 	return s
@@ -62,6 +106,18 @@ func (s *Server) setupRoutes() {
 	s.hertz.POST("/login", s.handleLogin)
 	s.hertz.GET("/health", s.handleHealth)
 
+	// Public read-only performance page (share link, rate-limited, percent terms only)
+	// 公开只读业绩页（分享链接访问，限流，仅百分比数据）
+	if s.config.PublicPageEnabled {
+		if s.config.PublicPageToken == "" {
+			s.logger.Warning("⚠️  已启用公开业绩页但未设置 PUBLIC_PAGE_TOKEN，公开页面不会注册")
+		} else {
+			public := s.hertz.Group("/public/:token", s.PublicMiddleware())
+			public.GET("", s.handlePublicPage)
+			public.GET("/api/performance", s.handlePublicPerformance)
+		}
+	}
+
 	// Protected routes (authentication required)
 	// 受保护路由（需要认证）
 	protected := s.hertz.Group("/", s.AuthMiddleware())
@@ -73,6 +129,9 @@ func (s *Server) setupRoutes() {
 		protected.GET("/session/:id", s.handleSessionDetail)
 		protected.GET("/trade-history", s.handleTradeHistory)
 		protected.GET("/stats", s.handleStats)
+		protected.GET("/equity", s.handleEquityPage)
+		protected.GET("/reports", s.handleReportsPage)
+		protected.GET("/positions", s.handleJournal)
 		protected.GET("/logout", s.handleLogout)
 
 		// API endpoints
@@ -81,14 +140,65 @@ func (s *Server) setupRoutes() {
 		protected.GET("/api/positions/live", s.handleLivePositions) // ✅ Real-time positions from Binance
 		protected.GET("/api/positions/:symbol", s.handlePositionsBySymbol)
 		protected.GET("/api/symbols", s.handleSymbols)
+		protected.GET("/api/batches/:batch_id", s.handleBatch)
 		protected.GET("/api/balance/history", s.handleBalanceHistory)
 		protected.GET("/api/balance/current", s.handleCurrentBalance)
+		protected.GET("/api/equity", s.handleEquity)
+		protected.GET("/api/reports", s.handleReports)         // ?period=daily|weekly&limit=30
+		protected.GET("/api/attribution", s.handleAttribution) // ?days=30（0 为全部）/ ?days=30 (0 = all)
+		protected.GET("/api/export/:kind", s.handleExport)     // sessions / positions / balances
+		protected.GET("/api/decisions/divergences", s.handleDecisionDivergences)
+		protected.GET("/api/decisions/stream", s.handleDecisionStream) // SSE，仅 LLM_STREAMING 启用时 / SSE, LLM_STREAMING only
+		protected.GET("/api/events", s.handleEvents)                   // SSE 实时看板 / SSE live dashboard
+		protected.GET("/api/history/prices/:symbol", s.handlePriceHistory)
+		protected.GET("/api/history/executions", s.handleExecutionHistory)
+		protected.GET("/api/analytics/latency", s.handleLatencyAnalytics)
+		protected.GET("/api/analytics/calibration", s.handleDecisionCalibration) // ?days=30（0 为全部）/ ?days=30 (0 = all)
+		protected.GET("/api/checkpoints", s.handleCheckpoints)
+		protected.GET("/api/schedule", s.handleSchedule)
+		protected.GET("/api/ratelimit", s.handleRateLimit)
+		protected.GET("/api/killswitch", s.handleKillSwitch)
+		protected.GET("/api/health", s.handleHealthDetails)
+		protected.GET("/api/notes/:type/:id", s.handleGetNotes)
 
 		// Configuration management
 		// 配置管理
 		protected.GET("/api/config", s.handleGetConfig)
-		protected.POST("/api/config", s.handleUpdateConfig)
-		protected.POST("/api/config/save", s.handleSaveConfig)
+	}
+
+	// Mutating routes: authentication plus cross-site request protection
+	// 修改状态的路由：需要认证并防止跨站请求伪造
+	mutating := s.hertz.Group("/", s.AuthMiddleware(), s.MutationMiddleware())
+	{
+		// Configuration management
+		// 配置管理
+		mutating.POST("/api/config", s.handleUpdateConfig)
+		mutating.POST("/api/config/save", s.handleSaveConfig)
+
+		// Notifications
+		// 通知
+		mutating.POST("/api/notify/test", s.handleTestNotify)
+
+		// Run an analysis batch outside the schedule
+		// 在调度之外立即运行分析批次
+		mutating.POST("/api/run", s.handleRunNow)
+
+		// Manual trading API (operator overrides the LLM)
+		// 人工交易 API（操作员覆盖 LLM 决策）
+		mutating.POST("/api/trade", s.handleManualTrade)
+		mutating.POST("/api/close/:symbol", s.handleManualClose)
+		mutating.POST("/api/close-all", s.handleCloseAll)
+		mutating.POST("/api/stoploss/:symbol", s.handleManualStopLoss)
+
+		// Emergency stop: halt entries (optionally flatten) until explicitly re-armed
+		// 急停：停止开仓（可选平掉所有持仓），直到被明确解除
+		mutating.POST("/api/killswitch", s.handleEngageKillSwitch)
+		mutating.POST("/api/killswitch/rearm", s.handleRearmKillSwitch)
+
+		// Operator notes on positions and sessions
+		// 操作员对持仓和会话的备注
+		mutating.POST("/api/notes/:type/:id", s.handleAddNote)
+		mutating.DELETE("/api/notes/:id", s.handleDeleteNote)
 	}
 }
 
@@ -97,7 +207,7 @@ func (s *Server) setupRoutes() {
 func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 	// Get stats for the first symbol (or aggregate later)
 	// 获取第一个交易对的统计（或稍后聚合）
-	var stats map[string]interface{}
+	stats := &storage.SessionStats{}
 	var err error
 	if len(s.config.CryptoSymbols) > 0 {
 		stats, err = s.storage.GetSessionStats(s.config.CryptoSymbols[0])
@@ -105,12 +215,6 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 			c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 			return
 		}
-	} else {
-		stats = map[string]interface{}{
-			"total_sessions": 0,
-			"executed_count": 0,
-			"execution_rate": 0.0,
-		}
 	}
 
 	sessions, err := s.storage.GetLatestSessions(10)
@@ -131,6 +235,48 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 	// 获取活跃持仓
 	positions, _ := s.storage.GetActivePositions()
 
+	// Trade frequency against the target range, for the overtrading warning
+	// 交易频率与目标范围，用于过度交易警告
+	tradeFrequency, err := risk.CheckTradeFrequency(s.storage, s.config, time.Now())
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  统计交易频率失败: %v", err))
+	}
+
+	// Intraday loss-streak ladder state (LOSS_STREAK_*)
+	// 日内连亏阶梯状态（LOSS_STREAK_*）
+	lossStreak, err := risk.CheckLossStreak(s.storage, s.config, time.Now())
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  统计连续亏损失败: %v", err))
+	}
+
+	// Circuit breaker on repeated order / LLM failures (CIRCUIT_BREAKER_*)
+	// 下单 / LLM 连续失败熔断状态（CIRCUIT_BREAKER_*）
+	breaker, err := risk.LoadBreaker(s.storage, s.config)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  读取熔断状态失败: %v", err))
+	}
+
+	// Kill switch state (KILL_SWITCH_FILE or POST /api/killswitch)
+	// 急停开关状态（KILL_SWITCH_FILE 或 POST /api/killswitch）
+	killSwitch, _, err := s.killSwitch.Check()
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  读取急停开关状态失败: %v", err))
+	}
+
+	// Month-to-date LLM usage against the monthly token budget
+	// 本月 LLM 用量与月度 token 预算
+	llmCosts, err := s.storage.GetLLMCostSummary(storage.MonthStart(time.Now()))
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  统计 LLM 用量失败: %v", err))
+	}
+
+	// Cumulative fees, slippage and funding of all symbols
+	// 全部交易对的累计手续费、滑点和资金费
+	tradingCosts, err := s.storage.GetTradingCosts("")
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  统计交易成本失败: %v", err))
+	}
+
 	// Create template with custom functions
 	// 创建带自定义函数的模板
 	funcMap := template.FuncMap{
@@ -139,7 +285,7 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 		},
 		"extractAction": extractActionFromDecision,
 	}
-	tmpl := template.Must(template.New("index.html").Funcs(funcMap).ParseFiles("internal/web/templates/index.html"))
+	tmpl := template.Must(template.New("index.html").Funcs(funcMap).ParseFiles("internal/web/templates/index.html", "internal/web/templates/number_format.html"))
 
 	data := map[string]interface{}{
 		"Symbols":         s.config.CryptoSymbols,
@@ -153,10 +299,25 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 		"NextTradeTime":   s.scheduler.GetNextTimeframeTime().Format("2006-01-02 15:04:05"),
 		"LLMEnabled":      s.config.APIKey != "" && s.config.APIKey != "your_openai_key",
 		"TestMode":        s.config.BinanceTestMode,
+		"PaperTrading":    s.config.PaperTrading,
+		"ShadowMode":      s.config.ShadowMode,
+		"AnalysisOnly":    s.config.IsAnalysisOnly(),
 		"AutoExecute":     s.config.AutoExecute,
 		"LeverageMin":     s.config.BinanceLeverageMin,
 		"LeverageMax":     s.config.BinanceLeverageMax,
 		"LeverageDynamic": s.config.BinanceLeverageDynamic,
+		"TradeFrequency":  tradeFrequency,             // 最近 24 小时开仓次数 / Positions opened in the last 24 hours
+		"LossStreak":      lossStreak,                 // 本交易时段连续亏损与阶梯级别 / Session losing streak and ladder step
+		"CircuitBreaker":  breaker.Status(time.Now()), // 下单 / LLM 连续失败熔断状态 / Circuit breaker state
+		"KillSwitch":      killSwitch,                 // 急停开关状态（读取失败时为 nil）/ Kill switch state (nil if unreadable)
+		"LLMCosts":        llmCosts,                   // 本月 LLM 用量和费用 / Month-to-date LLM usage and cost
+		"LLMTokenBudget":  s.config.LLMMonthlyTokenBudget,
+		"TradingCosts":    tradingCosts, // 累计手续费、滑点和资金费 / Cumulative fees, slippage and funding
+		"LLMStreaming":    s.config.LLMStreaming && s.decisionStream != nil,
+		"ScheduledJobs":   s.jobStatuses(), // 后台定时任务及下次执行时间 / Background jobs and their next run
+		"AnalysisCron":    s.scheduler.Cron(),
+		"RunNowEnabled":   s.runTrigger != nil,
+		"LiveEvents":      s.events != nil,
 	}
 
 	// Execute template and render
@@ -215,8 +376,24 @@ func (s *Server) handleSessionDetail(ctx context.Context, c *app.RequestContext)
 	}
 	tmpl := template.Must(template.New("session_detail.html").Funcs(funcMap).ParseFiles("internal/web/templates/session_detail.html"))
 
+	notes, err := s.storage.GetNotes(storage.NoteTargetSession, strconv.FormatInt(sessionID, 10))
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  获取会话备注失败: %v", err))
+	}
+
+	// Other symbols decided in the same run
+	// 同一次运行中其他交易对的决策
+	var batchSessions []*storage.TradingSession
+	if session.BatchID != "" {
+		if batchSessions, err = s.storage.GetSessionsByBatch(session.BatchID); err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  获取同批次会话失败: %v", err))
+		}
+	}
+
 	data := map[string]interface{}{
-		"Session": session,
+		"Session":       session,
+		"Notes":         notes,
+		"BatchSessions": batchSessions,
 	}
 
 	// Execute template and render
@@ -230,6 +407,41 @@ func (s *Server) handleSessionDetail(ctx context.Context, c *app.RequestContext)
 	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
 }
 
+// handleBatch returns every session of one run, one per symbol: /api/batches/:batch_id
+// handleBatch 返回同一次运行的所有会话（每个交易对一条）：/api/batches/:batch_id
+func (s *Server) handleBatch(ctx context.Context, c *app.RequestContext) {
+	batch, err := s.storage.GetBatch(c.Param("batch_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if batch == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "batch not found"})
+		return
+	}
+
+	sessions := make([]utils.H, 0, len(batch.Sessions))
+	for _, session := range batch.Sessions {
+		sessions = append(sessions, utils.H{
+			"id":               session.ID,
+			"symbol":           session.Symbol,
+			"created_at":       session.CreatedAt,
+			"action":           extractActionFromDecision(session.Decision),
+			"decision":         session.Decision,
+			"executed":         session.Executed,
+			"execution_result": session.ExecutionResult,
+		})
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"batch_id":      batch.BatchID,
+		"created_at":    batch.CreatedAt,
+		"timeframe":     batch.Timeframe,
+		"full_decision": batch.Sessions[0].FullDecision,
+		"sessions":      sessions,
+	})
+}
+
 // handleStats returns statistics
 // handleStats 返回统计信息
 func (s *Server) handleStats(ctx context.Context, c *app.RequestContext) {
@@ -250,28 +462,161 @@ func (s *Server) handleStats(ctx context.Context, c *app.RequestContext) {
 		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 		return
 	}
+	llmCosts, err := s.storage.GetLLMCostSummary(storage.MonthStart(time.Now()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	tradingCosts, err := s.storage.GetTradingCosts(s.config.GetBinanceSymbolFor(symbol))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, stats)
+	c.JSON(http.StatusOK, struct {
+		*storage.SessionStats
+		LLMCosts       *storage.LLMCostSummary `json:"llm_costs"`        // 本月 LLM 用量和费用 / Month-to-date LLM usage and cost
+		LLMTokenBudget int                     `json:"llm_token_budget"` // 月度 token 预算（0 不限制）/ Monthly token budget (0 = unlimited)
+		TradingCosts   *storage.TradingCosts   `json:"trading_costs"`    // 累计手续费、滑点和资金费 / Cumulative fees, slippage and funding
+	}{stats, llmCosts, s.config.LLMMonthlyTokenBudget, tradingCosts})
 }
 
-// handleHealth returns health status
-func (s *Server) handleHealth(ctx context.Context, c *app.RequestContext) {
+// handleDecisionDivergences returns sessions whose parsed decision diverges from the JSON in the raw output
+// handleDecisionDivergences 返回解析决策与原始输出 JSON 不一致的会话
+func (s *Server) handleDecisionDivergences(ctx context.Context, c *app.RequestContext) {
+	limit := c.DefaultQuery("limit", "20")
+	var limitInt int
+	fmt.Sscanf(limit, "%d", &limitInt)
+
+	divergences, err := s.storage.GetDecisionDivergences(limitInt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"divergences": divergences,
+		"count":       len(divergences),
+	})
+}
+
+// handleCheckpoints returns the latest end-of-day state checkpoints and their diffs
+// handleCheckpoints 返回最近的日终状态快照及其差异
+func (s *Server) handleCheckpoints(ctx context.Context, c *app.RequestContext) {
+	limit := 7
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+
+	checkpoints, err := s.storage.GetStateCheckpoints(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]utils.H, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		diff := []string{}
+		if cp.Diff != "" {
+			diff = strings.Split(cp.Diff, "\n")
+		}
+		result = append(result, utils.H{
+			"day":         cp.Day,
+			"config_hash": cp.ConfigHash,
+			"diff":        diff,
+			"anomalies":   cp.Anomalies,
+			"state":       json.RawMessage(cp.State),
+			"created_at":  cp.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, utils.H{"checkpoints": result})
+}
+
+// handleLatencyAnalytics returns decision-to-fill latency stages and slippage grouped by latency
+// handleLatencyAnalytics 返回决策到成交的各阶段延迟，以及按延迟分组的滑点
+func (s *Server) handleLatencyAnalytics(ctx context.Context, c *app.RequestContext) {
+	symbol := s.config.GetBinanceSymbolFor(c.Query("symbol")) // 为空表示全部 / Empty = all symbols
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+
+	timings, err := s.storage.GetExecutionTimings(symbol, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	stats, err := s.storage.GetLatencyStats(symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	// Durations are reported in seconds
+	// 耗时以秒为单位返回
+	buckets := make([]utils.H, 0, len(stats.Buckets))
+	for _, b := range stats.Buckets {
+		buckets = append(buckets, utils.H{
+			"label":            b.Label,
+			"min_seconds":      b.Min.Seconds(),
+			"max_seconds":      b.Max.Seconds(),
+			"count":            b.Count,
+			"avg_slippage_bps": b.AvgSlippageBps,
+			"slippage_usdt":    b.SlippageUSD,
+		})
+	}
+	executions := make([]utils.H, 0, len(timings))
+	for _, t := range timings {
+		executions = append(executions, utils.H{
+			"symbol":        t.Symbol,
+			"action":        t.Action,
+			"paper":         t.Paper,
+			"signal_price":  t.SignalPrice,
+			"fill_price":    t.FillPrice,
+			"slippage_bps":  t.SlippageBps,
+			"slippage_usdt": t.SlippageUSD,
+			"decision_at":   t.DecisionAt,
+			"filled_at":     t.FilledAt,
+			"llm_seconds":   t.DecisionAt.Sub(t.SignalAt).Seconds(),
+			"total_seconds": t.Latency().Seconds(),
+		})
+	}
+
 	c.JSON(http.StatusOK, utils.H{
-		"status":  "healthy",
-		"time":    time.Now(),
-		"version": "1.0.0",
+		"summary": utils.H{
+			"count":                  stats.Count,
+			"avg_llm_seconds":        stats.AvgDecision.Seconds(),
+			"avg_validation_seconds": stats.AvgValidation.Seconds(),
+			"avg_routing_seconds":    stats.AvgRouting.Seconds(),
+			"avg_fill_seconds":       stats.AvgFill.Seconds(),
+			"avg_total_seconds":      stats.AvgTotal.Seconds(),
+			"avg_slippage_bps":       stats.AvgSlippageBps,
+			"slippage_usdt":          stats.SlippageUSD,
+			"correlation":            stats.Correlation,
+			"bps_per_minute":         stats.BpsPerMinute,
+		},
+		"buckets":    buckets,
+		"executions": executions,
 	})
 }
 
 // Start starts the web server
+//
+// Blocks until Stop is called. Signals are not handled here: the caller stops the server as
+// part of its own shutdown, so it is not torn down while trades are still being written.
+// 阻塞直到调用 Stop。此处不处理信号：由调用方在自身关闭流程中停止服务器，避免在交易写入过程中被关闭。
 func (s *Server) Start() error {
 	s.logger.Success(fmt.Sprintf("Web 监控启动: http://localhost:%d", s.config.WebPort))
-	s.hertz.Spin()
+	if err := s.hertz.Run(); err != nil && !s.stopping.Load() {
+		return err
+	}
 	return nil
 }
 
-// Stop stops the web server
+// Stop stops the web server, waiting for in-flight requests until ctx is done
+// Stop 停止 Web 服务器，等待进行中的请求直到 ctx 结束
 func (s *Server) Stop(ctx context.Context) error {
+	s.stopping.Store(true)
 	return s.hertz.Shutdown(ctx)
 }
 
@@ -287,6 +632,7 @@ func (s *Server) handlePositions(ctx context.Context, c *app.RequestContext) {
 	c.JSON(http.StatusOK, utils.H{
 		"positions": positions,
 		"count":     len(positions),
+		"notes":     s.positionNotes(positions),
 	})
 }
 
@@ -304,28 +650,67 @@ func (s *Server) handlePositionsBySymbol(ctx context.Context, c *app.RequestCont
 		"symbol":    symbol,
 		"positions": positions,
 		"count":     len(positions),
+		"notes":     s.positionNotes(positions),
 	})
 }
 
+// positionNotes returns the notes of the given positions keyed by position ID
+// positionNotes 返回指定持仓的备注，按持仓 ID 分组
+func (s *Server) positionNotes(positions []*storage.PositionRecord) map[string][]noteResponse {
+	ids := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		ids = append(ids, pos.ID)
+	}
+	notes, err := s.storage.GetNotesFor(storage.NoteTargetPosition, ids)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  获取持仓备注失败: %v", err))
+	}
+	result := make(map[string][]noteResponse, len(notes))
+	for id, list := range notes {
+		result[id] = toNoteResponses(list)
+	}
+	return result
+}
+
+// newExecutor creates an executor for read-only queries, backed by the paper account in paper trading mode
+// newExecutor 创建用于只读查询的执行器，模拟盘模式下使用模拟账户
+func (s *Server) newExecutor() *executors.BinanceExecutor {
+	executor := executors.NewBinanceExecutor(s.config, s.logger)
+	if s.config.PaperTrading {
+		executor.EnablePaperTrading(s.storage)
+	}
+	executor.EnableSymbolRegistry(s.storage)
+	return executor
+}
+
 // handleLivePositions returns real-time positions directly from Binance
 // handleLivePositions 从币安直接获取实时持仓（不依赖数据库）
 func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext) {
+	// Analysis-only mode has no exchange account to query
+	// 仅分析模式没有可查询的交易所账户
+	if s.config.IsAnalysisOnly() {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": analysisOnlyMessage})
+		return
+	}
+
 	// Create executor for querying Binance
 	// 创建执行器用于查询币安
-	executor := executors.NewBinanceExecutor(s.config, s.logger)
+	executor := s.newExecutor()
 
 	// Response structure
 	// 响应结构
 	type PositionResponse struct {
-		Symbol           string  `json:"symbol"`
-		Side             string  `json:"side"`
-		Size             float64 `json:"size"`
-		EntryPrice       float64 `json:"entry_price"`
-		CurrentPrice     float64 `json:"current_price"`
-		UnrealizedPnL    float64 `json:"unrealized_pnl"`
-		ROE              float64 `json:"roe"` // Return on Equity percentage
-		Leverage         int     `json:"leverage"`
-		LiquidationPrice float64 `json:"liquidation_price"`
+		Symbol           string         `json:"symbol"`
+		Side             string         `json:"side"`
+		Size             float64        `json:"size"`
+		EntryPrice       float64        `json:"entry_price"`
+		CurrentPrice     float64        `json:"current_price"`
+		UnrealizedPnL    float64        `json:"unrealized_pnl"`
+		ROE              float64        `json:"roe"` // Return on Equity percentage
+		Leverage         int            `json:"leverage"`
+		LiquidationPrice float64        `json:"liquidation_price"`
+		PositionID       string         `json:"position_id,omitempty"` // 止损管理器中的持仓 ID（用于备注）/ Managed position ID (for notes)
+		Notes            []noteResponse `json:"notes"`
 	}
 
 	var positions []PositionResponse
@@ -362,7 +747,7 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 				currentPrice = pos.CurrentPrice
 			}
 
-			positions = append(positions, PositionResponse{
+			response := PositionResponse{
 				Symbol:           symbol,
 				Side:             pos.Side,
 				Size:             pos.Size,
@@ -372,7 +757,16 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 				ROE:              roe,
 				Leverage:         pos.Leverage,
 				LiquidationPrice: pos.LiquidationPrice,
-			})
+			}
+			if managed := s.managedPosition(symbol); managed != nil {
+				response.PositionID = managed.ID
+				notes, err := s.storage.GetNotes(storage.NoteTargetPosition, managed.ID)
+				if err != nil {
+					s.logger.Warning(fmt.Sprintf("⚠️  获取 %s 持仓备注失败: %v", symbol, err))
+				}
+				response.Notes = toNoteResponses(notes)
+			}
+			positions = append(positions, response)
 		}
 	}
 
@@ -412,6 +806,12 @@ func extractActionFromDecision(decision string) string {
 		action  string
 		matches []string
 	}{
+		{"BUY_STOP", []string{"**交易方向**: BUY_STOP", "交易方向: BUY_STOP", "ACTION: BUY_STOP", "决策: BUY_STOP"}},
+		{"SELL_STOP", []string{"**交易方向**: SELL_STOP", "交易方向: SELL_STOP", "ACTION: SELL_STOP", "决策: SELL_STOP"}},
+		{"ADD_LONG", []string{"**交易方向**: ADD_LONG", "交易方向: ADD_LONG", "ACTION: ADD_LONG", "决策: ADD_LONG"}},
+		{"ADD_SHORT", []string{"**交易方向**: ADD_SHORT", "交易方向: ADD_SHORT", "ACTION: ADD_SHORT", "决策: ADD_SHORT"}},
+		{"REDUCE_LONG", []string{"**交易方向**: REDUCE_LONG", "交易方向: REDUCE_LONG", "ACTION: REDUCE_LONG", "决策: REDUCE_LONG"}},
+		{"REDUCE_SHORT", []string{"**交易方向**: REDUCE_SHORT", "交易方向: REDUCE_SHORT", "ACTION: REDUCE_SHORT", "决策: REDUCE_SHORT"}},
 		{"BUY", []string{"**交易方向**: BUY", "交易方向: BUY", "ACTION: BUY", "决策: BUY", "建议.*?买入", "建议.*?做多", "开多"}},
 		{"SELL", []string{"**交易方向**: SELL", "交易方向: SELL", "ACTION: SELL", "决策: SELL", "建议.*?卖出", "建议.*?做空", "开空"}},
 		{"CLOSE_LONG", []string{"**交易方向**: CLOSE_LONG", "交易方向: CLOSE_LONG", "ACTION: CLOSE_LONG", "决策: CLOSE_LONG", "平多", "平掉多单"}},
@@ -458,6 +858,7 @@ func (s *Server) handleBalanceHistory(ctx context.Context, c *app.RequestContext
 	var totalAssets []float64 // 总资产 = 总余额 + 未实现盈亏 / Total Assets = Total Balance + Unrealized PnL
 	var availableBalances []float64
 	var unrealizedPnLs []float64
+	var marginRatios []float64 // 保证金率（%）/ Margin ratio (%)
 
 	// Determine time format based on data span
 	// 根据数据跨度决定时间格式
@@ -495,6 +896,7 @@ func (s *Server) handleBalanceHistory(ctx context.Context, c *app.RequestContext
 		totalAssets = append(totalAssets, totalAsset)
 		availableBalances = append(availableBalances, h.AvailableBalance)
 		unrealizedPnLs = append(unrealizedPnLs, h.UnrealizedPnL)
+		marginRatios = append(marginRatios, h.MarginRatio)
 	}
 
 	response := map[string]interface{}{
@@ -503,6 +905,7 @@ func (s *Server) handleBalanceHistory(ctx context.Context, c *app.RequestContext
 		"total_assets":      totalAssets, // 新增：总资产数据 / New: Total assets data
 		"available_balance": availableBalances,
 		"unrealized_pnl":    unrealizedPnLs,
+		"margin_ratio":      marginRatios,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -511,9 +914,14 @@ func (s *Server) handleBalanceHistory(ctx context.Context, c *app.RequestContext
 // handleCurrentBalance returns current real-time balance from Binance
 // handleCurrentBalance 返回从币安实时获取的当前余额
 func (s *Server) handleCurrentBalance(ctx context.Context, c *app.RequestContext) {
+	if s.config.IsAnalysisOnly() {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": analysisOnlyMessage})
+		return
+	}
+
 	// Create executor and portfolio manager for real-time balance query
 	// 创建执行器和投资组合管理器用于实时余额查询
-	executor := executors.NewBinanceExecutor(s.config, s.logger)
+	executor := s.newExecutor()
 	portfolioMgr := portfolio.NewPortfolioManager(s.config, executor, s.logger)
 
 	// Update balance from Binance
@@ -544,11 +952,15 @@ func (s *Server) handleCurrentBalance(ctx context.Context, c *app.RequestContext
 	// Return current balance data
 	// 返回当前余额数据
 	response := map[string]interface{}{
-		"timestamp":         time.Now().Format("2006-01-02 15:04:05"),
-		"total_balance":     portfolioMgr.GetTotalBalance(),
-		"available_balance": portfolioMgr.GetAvailableBalance(),
-		"unrealized_pnl":    portfolioMgr.GetTotalUnrealizedPnL(),
-		"positions":         portfolioMgr.GetPositionCount(),
+		"timestamp":          time.Now().Format("2006-01-02 15:04:05"),
+		"total_balance":      portfolioMgr.GetTotalBalance(),
+		"available_balance":  portfolioMgr.GetAvailableBalance(),
+		"unrealized_pnl":     portfolioMgr.GetTotalUnrealizedPnL(),
+		"positions":          portfolioMgr.GetPositionCount(),
+		"margin_balance":     portfolioMgr.GetAccountRisk().MarginBalance,
+		"maintenance_margin": portfolioMgr.GetAccountRisk().MaintenanceMargin,
+		"margin_ratio":       portfolioMgr.GetAccountRisk().MarginRatio,
+		"assets":             portfolioMgr.GetAccountRisk().Assets,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -720,9 +1132,33 @@ func (s *Server) handleSaveConfig(ctx context.Context, c *app.RequestContext) {
 
 	s.logger.Info(fmt.Sprintf("Trading interval saved to .env (trading_interval=%s)", currentInterval))
 
+	if err := s.notifier.Notify(ctx, notify.Message{
+		Title: "配置已保存",
+		Text:  fmt.Sprintf("运行间隔已保存为 %s", currentInterval),
+		Level: notify.LevelInfo,
+	}); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+
 	c.JSON(http.StatusOK, utils.H{
 		"status":           "success",
 		"message":          "Configuration saved to .env file",
 		"trading_interval": currentInterval,
 	})
 }
+
+// handleTestNotify sends a test message to every configured notification sink
+// handleTestNotify 向所有已配置的通知渠道发送测试消息
+func (s *Server) handleTestNotify(ctx context.Context, c *app.RequestContext) {
+	err := s.notifier.Notify(ctx, notify.Message{
+		Title: "测试通知",
+		Text:  fmt.Sprintf("来自交易机器人的测试消息 (%s)", time.Now().Format("2006-01-02 15:04:05")),
+		Level: notify.LevelInfo,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.H{"status": "success"})
+}
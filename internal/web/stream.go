@@ -0,0 +1,74 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+	"github.com/oak/crypto-trading-bot/internal/agents"
+)
+
+// streamHeartbeat is how often an idle decision stream sends a comment to keep proxies from closing it
+// streamHeartbeat 是空闲的决策流发送注释行的间隔，避免被代理断开
+const streamHeartbeat = 15 * time.Second
+
+// SetDecisionStream enables the live decision endpoint, fed by the trading graph
+// SetDecisionStream 启用实时决策接口，数据来自交易图
+func (s *Server) SetDecisionStream(stream *agents.DecisionStream) {
+	s.decisionStream = stream
+}
+
+// handleDecisionStream pushes streamed decision output to the browser as server-sent events
+// handleDecisionStream 以 SSE（server-sent events）将流式决策输出推送到浏览器
+func (s *Server) handleDecisionStream(ctx context.Context, c *app.RequestContext) {
+	if s.decisionStream == nil || !s.config.LLMStreaming {
+		c.JSON(http.StatusNotFound, utils.H{"error": "流式决策未启用（LLM_STREAMING=false）"})
+		return
+	}
+
+	events, unsubscribe := s.decisionStream.Subscribe()
+	defer unsubscribe()
+
+	c.SetStatusCode(http.StatusOK)
+	c.Response.Header.Set("Content-Type", "text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var frame []byte
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			data, err := sonic.Marshal(event)
+			if err != nil {
+				s.logger.Warning(fmt.Sprintf("⚠️  序列化流式决策事件失败: %v", err))
+				continue
+			}
+			frame = append(append([]byte("data: "), data...), '\n', '\n')
+		case <-heartbeat.C:
+			if s.stopping.Load() {
+				return
+			}
+			frame = []byte(": ping\n\n")
+		}
+
+		// A failed write means the browser went away
+		// 写入失败说明浏览器已断开
+		if _, err := c.Write(frame); err != nil {
+			return
+		}
+		if err := c.Flush(); err != nil {
+			return
+		}
+	}
+}
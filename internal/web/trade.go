@@ -0,0 +1,503 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/agents"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+)
+
+// manualStopLossPercent is the default stop distance for manual entries without a stop price
+// manualStopLossPercent 是未指定止损价的手动开仓默认止损距离
+const manualStopLossPercent = 2.5
+
+// SetTradeCoordinator enables the manual trading endpoints, using the trading loop's coordinator
+// SetTradeCoordinator 启用手动交易接口，使用交易循环的协调器
+func (s *Server) SetTradeCoordinator(coordinator *executors.TradeCoordinator) {
+	s.coordinator = coordinator
+}
+
+// manualSymbol resolves a path or body symbol to a configured trading pair
+// manualSymbol 将路径或请求体中的交易对解析为已配置的交易对
+func (s *Server) manualSymbol(raw string) (string, bool) {
+	binanceSymbol := s.config.GetBinanceSymbolFor(strings.ToUpper(strings.TrimSpace(raw)))
+	for _, symbol := range s.config.CryptoSymbols {
+		if s.config.GetBinanceSymbolFor(symbol) == binanceSymbol {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+// tradingReady reports whether the manual trading endpoints are wired, writing a 503 otherwise
+// tradingReady 判断手动交易接口是否可用，不可用时返回 503
+func (s *Server) tradingReady(c *app.RequestContext) bool {
+	if s.coordinator == nil || s.stopLossManager == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Manual trading is not enabled on this server"})
+		return false
+	}
+	return true
+}
+
+// notifyManual sends a notification for an operator override
+// notifyManual 发送人工干预通知
+func (s *Server) notifyManual(ctx context.Context, title, text string) {
+	if err := s.notifier.Notify(ctx, notify.Message{Title: title, Text: text, Level: notify.LevelWarning}); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  发送通知失败: %v", err))
+	}
+}
+
+// handleManualTrade executes an operator trade (POST /api/trade)
+// handleManualTrade 执行人工下单（POST /api/trade）
+//
+// Opening trades pass the same gates as LLM decisions (kill switch, freeze window, daily loss halt, global
+// risk limits), are registered with the stop-loss manager and protected by an initial stop
+// (stop_loss, or 2.5% from the fill when omitted) bracketed with take_profit when given.
+// 开仓与 LLM 决策经过相同的检查（急停开关、冻结观察期、单日亏损熔断、全局风控限制），注册到止损管理器并下初始止损单
+// （使用 stop_loss，未指定时为成交价 2.5%），指定 take_profit 时同时挂止盈单组成括号单。
+func (s *Server) handleManualTrade(ctx context.Context, c *app.RequestContext) {
+	if !s.tradingReady(c) {
+		return
+	}
+
+	var req struct {
+		Symbol              string  `json:"symbol"`
+		Action              string  `json:"action"`
+		Leverage            int     `json:"leverage"`
+		PositionSizePercent float64 `json:"position_size_percent"`
+		StopLoss            float64 `json:"stop_loss"`
+		TakeProfit          float64 `json:"take_profit"`
+		Reason              string  `json:"reason"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
+	}
+
+	symbol, ok := s.manualSymbol(req.Symbol)
+	if !ok {
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("Unknown symbol %q", req.Symbol)})
+		return
+	}
+	action := executors.TradeAction(strings.ToUpper(req.Action))
+	switch action {
+	case executors.ActionBuy, executors.ActionSell, executors.ActionCloseLong, executors.ActionCloseShort:
+	default:
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Action must be BUY, SELL, CLOSE_LONG or CLOSE_SHORT"})
+		return
+	}
+	opening := action == executors.ActionBuy || action == executors.ActionSell
+	if opening && (req.PositionSizePercent <= 0 || req.PositionSizePercent > 100) {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "position_size_percent must be greater than 0 and at most 100"})
+		return
+	}
+	if req.StopLoss < 0 {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "stop_loss must be a positive price"})
+		return
+	}
+	if req.TakeProfit < 0 {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "take_profit must be a positive price"})
+		return
+	}
+	if opening && s.killSwitch.Engaged() {
+		c.JSON(http.StatusConflict, utils.H{"error": "Kill switch engaged: entries are halted until POST /api/killswitch/rearm"})
+		return
+	}
+
+	reason := "人工下单"
+	if req.Reason != "" {
+		reason = fmt.Sprintf("人工下单: %s", req.Reason)
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	if !opening {
+		s.closeManual(ctx, c, symbol, action, reason)
+		return
+	}
+
+	leverage := req.Leverage
+	if leverage > 0 {
+		leverage = agents.ValidateLeverage(leverage, s.config.BinanceLeverageMin, s.config.BinanceLeverageMax, s.config.BinanceLeverageDynamic)
+	}
+
+	if status, err := s.checkManualEntry(ctx, symbol, action, leverage, req.PositionSizePercent, req.StopLoss, req.TakeProfit); err != nil {
+		s.logger.Warning(fmt.Sprintf("🛑 人工下单被拒绝: %s %s: %v", symbol, action, err))
+		c.JSON(status, utils.H{"error": err.Error()})
+		return
+	}
+
+	s.logger.Warning(fmt.Sprintf("🧑 人工下单: %s %s (%s)", symbol, action, reason))
+	result, err := s.coordinator.ExecuteDecisionWithParams(ctx, symbol, action, reason, leverage, req.PositionSizePercent, req.StopLoss, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	// A below-minimum order is a sizing problem of the request, not an exchange failure
+	// 订单价值不足属于请求的仓位问题，而不是交易所故障
+	if result.Skipped {
+		c.JSON(http.StatusBadRequest, utils.H{"error": result.Message, "result": result})
+		return
+	}
+	if !result.Success {
+		c.JSON(http.StatusBadGateway, utils.H{"error": result.Message, "result": result})
+		return
+	}
+
+	if leverage == 0 {
+		leverage = s.config.BinanceLeverage
+	}
+	stopLoss := req.StopLoss
+	if result.StopLoss > 0 {
+		stopLoss = result.StopLoss // 插件或外部风控调整后的止损 / Adjusted by a plugin or the risk oracle
+	}
+	if stopLoss == 0 {
+		if action == executors.ActionBuy {
+			stopLoss = result.Price * (1 - manualStopLossPercent/100)
+		} else {
+			stopLoss = result.Price * (1 + manualStopLossPercent/100)
+		}
+	}
+	side := "long"
+	if action == executors.ActionSell {
+		side = "short"
+	}
+
+	position := &executors.Position{
+		ID:              fmt.Sprintf("%s-%d", symbol, time.Now().Unix()),
+		Symbol:          symbol,
+		Side:            side,
+		EntryPrice:      result.Price,
+		EntryTime:       time.Now(),
+		Quantity:        result.Amount,
+		Leverage:        leverage,
+		InitialStopLoss: stopLoss,
+		CurrentStopLoss: stopLoss,
+		StopLossType:    "fixed",
+		OpenReason:      reason,
+		TakeProfitPrice: req.TakeProfit,
+	}
+	s.stopLossManager.RegisterPosition(position)
+
+	if err := s.storage.SavePosition(position.Record()); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  保存持仓到数据库失败: %v", err))
+	}
+
+	stopErr := s.stopLossManager.PlaceInitialStopLoss(ctx, position)
+	if stopErr != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", stopErr))
+	}
+
+	s.notifyManual(ctx, "人工下单", fmt.Sprintf("%s %s %.4f @ %.2f，止损 %.2f", symbol, action, result.Amount, result.Price, stopLoss))
+
+	response := utils.H{
+		"status":    "success",
+		"result":    result,
+		"stop_loss": stopLoss,
+	}
+	if position.TakeProfitPrice > 0 {
+		response["take_profit"] = position.TakeProfitPrice
+	}
+	if stopErr != nil {
+		response["warning"] = fmt.Sprintf("Position opened but the initial stop-loss failed: %v", stopErr)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// checkManualEntry runs an opening order through the gates of the trading loop: the strategy
+// freeze window, the stop side, the daily loss halt and the global risk limits
+// checkManualEntry 让人工开仓经过交易循环的各项检查：策略冻结观察期、止损方向、单日亏损熔断和全局风控限制
+//
+// Returns the HTTP status to answer with when the order is refused. A failed freeze check
+// refuses the order, since the trading loop then only runs shadow cycles.
+// 订单被拒绝时返回应答的 HTTP 状态码。冻结检查失败时拒绝下单，因为此时交易循环也只运行影子周期。
+func (s *Server) checkManualEntry(ctx context.Context, symbol string, action executors.TradeAction, leverage int, positionSizePercent, stopLoss, takeProfit float64) (int, error) {
+	freeze, err := risk.CheckFreeze(s.storage, s.config)
+	if err != nil {
+		return http.StatusServiceUnavailable, fmt.Errorf("strategy version check failed: %w", err)
+	}
+	if freeze.Active() {
+		return http.StatusForbidden, fmt.Errorf("strategy freeze window active (shadow cycle %d/%d), opening is disabled",
+			freeze.Cycle(), freeze.Version.FreezeCycles)
+	}
+
+	executor := s.newExecutor()
+	price, err := executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to get price for %s: %w", symbol, err)
+	}
+	if err := validateManualStop(action, price, stopLoss); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := validateManualTakeProfit(action, price, takeProfit); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	portfolioMgr := portfolio.NewPortfolioManager(s.config, executor, s.logger.WithComponent("portfolio"))
+	if err := portfolioMgr.UpdateBalance(ctx); err != nil {
+		return http.StatusBadGateway, fmt.Errorf("failed to get balance: %w", err)
+	}
+	for _, configured := range s.config.CryptoSymbols {
+		if err := portfolioMgr.UpdatePosition(ctx, configured); err != nil {
+			return http.StatusBadGateway, fmt.Errorf("failed to get position for %s: %w", configured, err)
+		}
+	}
+
+	// Same setup as the trading loop: the daily loss is replayed from today's balance history
+	// 与交易循环相同：单日亏损根据当日余额历史回放计算
+	riskManager := risk.NewManager(risk.LimitsFromConfig(s.config))
+	if history, err := s.storage.GetBalanceHistory(24); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  获取余额历史失败，单日亏损从当前权益开始统计: %v", err))
+	} else {
+		withdrawals, err := s.storage.GetWithdrawalsSince(time.Now().Add(-24 * time.Hour))
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  获取利润提取记录失败，单日亏损可能包含已提取的利润: %v", err))
+		}
+		riskManager.LoadHistory(history, withdrawals)
+	}
+	riskManager.ObserveEquity(portfolioMgr.GetTotalBalance()+portfolioMgr.GetTotalUnrealizedPnL(), time.Now())
+
+	order, err := portfolioMgr.ProposedOrder(ctx, symbol, action, positionSizePercent, leverage, stopLoss, 0)
+	if err != nil {
+		return http.StatusBadGateway, err
+	}
+	if err := riskManager.CheckOrder(portfolioMgr.RiskSnapshot(s.stopLossManager), order, executor.IsHedgeMode(ctx)); err != nil {
+		return http.StatusForbidden, fmt.Errorf("refused by risk limits: %w", err)
+	}
+	return http.StatusOK, nil
+}
+
+// validateManualStop rejects a stop-loss on the wrong side of the entry price (0 means the default stop)
+// validateManualStop 拒绝位于入场价错误一侧的止损价（0 表示使用默认止损）
+func validateManualStop(action executors.TradeAction, price, stopLoss float64) error {
+	if stopLoss == 0 {
+		return nil
+	}
+	if action == executors.ActionBuy && stopLoss >= price {
+		return fmt.Errorf("stop_loss %.2f must be below the current price %.2f for a long", stopLoss, price)
+	}
+	if action == executors.ActionSell && stopLoss <= price {
+		return fmt.Errorf("stop_loss %.2f must be above the current price %.2f for a short", stopLoss, price)
+	}
+	return nil
+}
+
+// validateManualTakeProfit rejects a take-profit on the wrong side of the entry price (0 means none)
+// validateManualTakeProfit 拒绝位于入场价错误一侧的止盈价（0 表示不挂止盈单）
+func validateManualTakeProfit(action executors.TradeAction, price, takeProfit float64) error {
+	if takeProfit == 0 {
+		return nil
+	}
+	if action == executors.ActionBuy && takeProfit <= price {
+		return fmt.Errorf("take_profit %.2f must be above the current price %.2f for a long", takeProfit, price)
+	}
+	if action == executors.ActionSell && takeProfit >= price {
+		return fmt.Errorf("take_profit %.2f must be below the current price %.2f for a short", takeProfit, price)
+	}
+	return nil
+}
+
+// handleManualClose closes the whole position of a symbol (POST /api/close/:symbol)
+// handleManualClose 平掉交易对的全部持仓（POST /api/close/:symbol）
+func (s *Server) handleManualClose(ctx context.Context, c *app.RequestContext) {
+	if !s.tradingReady(c) {
+		return
+	}
+
+	symbol, ok := s.manualSymbol(c.Param("symbol"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("Unknown symbol %q", c.Param("symbol"))})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.BindJSON(&req) // 请求体可选 / Body is optional
+	reason := "人工平仓"
+	if req.Reason != "" {
+		reason = fmt.Sprintf("人工平仓: %s", req.Reason)
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	position, err := s.newExecutor().GetCurrentPosition(ctx, symbol)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, utils.H{"error": err.Error()})
+		return
+	}
+	if position == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("No open position for %s", symbol)})
+		return
+	}
+
+	action := executors.ActionCloseLong
+	if position.Side == "short" {
+		action = executors.ActionCloseShort
+	}
+	s.closeManual(ctx, c, symbol, action, reason)
+}
+
+// handleCloseAll closes every open position of the account (POST /api/close-all)
+// handleCloseAll 平掉账户的所有持仓（POST /api/close-all）
+//
+// Pending stop entries and the stop-loss/take-profit orders are cancelled too. Responds 502 with the
+// partial report when some position could not be closed.
+// 同时撤销条件入场单和止损/止盈单。部分持仓未能平仓时返回 502 和部分结果。
+func (s *Server) handleCloseAll(ctx context.Context, c *app.RequestContext) {
+	if !s.tradingReady(c) {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.BindJSON(&req) // 请求体可选 / Body is optional
+	reason := "人工一键平仓"
+	if req.Reason != "" {
+		reason = fmt.Sprintf("人工一键平仓: %s", req.Reason)
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	s.logger.Warning(fmt.Sprintf("🧑 人工一键平仓 (%s)", reason))
+	report, err := s.coordinator.CloseAllPositions(ctx, reason)
+
+	text := strings.Join(report.Lines(), "\n")
+	if text == "" {
+		text = "暂无持仓"
+	}
+	s.notifyManual(ctx, "人工一键平仓", text)
+
+	if err != nil {
+		c.JSON(http.StatusBadGateway, utils.H{"error": err.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"status": "success", "report": report})
+}
+
+// closeManual closes a position through the coordinator and removes it from stop-loss management
+// closeManual 通过协调器平仓，并将持仓移出止损管理
+//
+// Callers must hold tradeMu.
+// 调用方必须持有 tradeMu。
+func (s *Server) closeManual(ctx context.Context, c *app.RequestContext, symbol string, action executors.TradeAction, reason string) {
+	side := executors.ActionSide(action)
+	current, err := s.newExecutor().GetCurrentPositionSide(ctx, symbol, side)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  获取 %s 当前持仓失败: %v", symbol, err))
+	}
+
+	s.logger.Warning(fmt.Sprintf("🧑 人工平仓: %s %s (%s)", symbol, action, reason))
+	result, err := s.coordinator.ExecuteDecisionWithParams(ctx, symbol, action, reason, 0, 0, 0, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	if !result.Success {
+		c.JSON(http.StatusBadGateway, utils.H{"error": result.Message, "result": result})
+		return
+	}
+
+	realizedPnL := 0.0
+	if current != nil {
+		realizedPnL = current.RealizedPnLAt(result.Price, closedQuantity(current, result))
+	}
+	if err := s.stopLossManager.ClosePositionSide(ctx, symbol, side, result.Price, reason, realizedPnL); err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  关闭 %s 持仓失败: %v", symbol, err))
+	}
+
+	s.notifyManual(ctx, "人工平仓", fmt.Sprintf("%s %s @ %.2f，盈亏 %+.2f USDT", symbol, action, result.Price, realizedPnL))
+
+	c.JSON(http.StatusOK, utils.H{
+		"status":       "success",
+		"result":       result,
+		"realized_pnl": realizedPnL,
+	})
+}
+
+// closedQuantity returns the quantity a close order filled, falling back to the position size
+// closedQuantity 返回平仓订单的成交数量，无成交数量时使用持仓大小
+func closedQuantity(position *executors.Position, result *executors.TradeResult) float64 {
+	if result.Filled > 0 {
+		return result.Filled
+	}
+	if result.Amount > 0 {
+		return result.Amount
+	}
+	return position.Size
+}
+
+// handleManualStopLoss moves the stop-loss of a managed position (POST /api/stoploss/:symbol)
+// handleManualStopLoss 调整受管持仓的止损价（POST /api/stoploss/:symbol）
+//
+// The usual rules apply: the stop only moves in the favorable direction.
+// 遵循相同规则：止损只能朝有利方向移动。
+func (s *Server) handleManualStopLoss(ctx context.Context, c *app.RequestContext) {
+	if !s.tradingReady(c) {
+		return
+	}
+
+	symbol, ok := s.manualSymbol(c.Param("symbol"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("Unknown symbol %q", c.Param("symbol"))})
+		return
+	}
+
+	var req struct {
+		StopLoss float64 `json:"stop_loss"`
+		Reason   string  `json:"reason"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.StopLoss <= 0 {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "stop_loss must be a positive price"})
+		return
+	}
+	reason := "人工调整止损"
+	if req.Reason != "" {
+		reason = fmt.Sprintf("人工调整止损: %s", req.Reason)
+	}
+
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	position := s.stopLossManager.GetPosition(symbol)
+	if position == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("No managed position for %s", symbol)})
+		return
+	}
+	oldStop := position.CurrentStopLoss
+
+	if err := s.stopLossManager.UpdateStopLoss(ctx, symbol, req.StopLoss, reason); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+
+	// Small moves below STOPLOSS_SCOPE_THRESHOLD are skipped, so report the effective stop
+	// 低于 STOPLOSS_SCOPE_THRESHOLD 的小幅调整会被跳过，因此返回实际生效的止损价
+	newStop := req.StopLoss
+	if updated := s.stopLossManager.GetPosition(symbol); updated != nil {
+		newStop = updated.CurrentStopLoss
+	}
+	if newStop != oldStop {
+		s.notifyManual(ctx, "人工调整止损", fmt.Sprintf("%s 止损 %.2f → %.2f", symbol, oldStop, newStop))
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"status":    "success",
+		"symbol":    symbol,
+		"old_stop":  oldStop,
+		"stop_loss": newStop,
+	})
+}
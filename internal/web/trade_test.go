@@ -0,0 +1,226 @@
+package web
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/risk"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// TestManualSymbol tests that manual trades only accept configured trading pairs in any notation
+// TestManualSymbol 测试人工交易只接受已配置的交易对（支持多种写法）
+func TestManualSymbol(t *testing.T) {
+	s := &Server{config: &config.Config{CryptoSymbols: []string{"BTC/USDT", "ETH/USDT"}}}
+
+	for _, raw := range []string{"BTC/USDT", "BTCUSDT", "btcusdt", " btc/usdt "} {
+		if symbol, ok := s.manualSymbol(raw); !ok || symbol != "BTC/USDT" {
+			t.Errorf("manualSymbol(%q) = %q, %v; want BTC/USDT", raw, symbol, ok)
+		}
+	}
+	if _, ok := s.manualSymbol("DOGEUSDT"); ok {
+		t.Error("Unconfigured symbol should be rejected")
+	}
+}
+
+// newTradeTestServer creates a paper-trading server with the manual trading endpoints enabled
+// newTradeTestServer 创建启用人工交易接口的模拟盘测试服务器
+func newTradeTestServer(cfg *config.Config, db *storage.Storage) (*Server, *server.Hertz) {
+	log := logger.NewColorLogger(false)
+	executor := executors.NewBinanceExecutor(cfg, log)
+	stopLossManager := executors.NewStopLossManager(cfg, executor, log, db)
+	s := &Server{
+		config:          cfg,
+		logger:          log,
+		storage:         db,
+		stopLossManager: stopLossManager,
+		notifier:        notify.NewMultiNotifier(),
+		coordinator:     executors.NewTradeCoordinator(cfg, executor, log, stopLossManager),
+	}
+
+	h := server.Default()
+	h.POST("/api/trade", s.handleManualTrade)
+	return s, h
+}
+
+// postTrade sends a manual trade request and returns the status code
+// postTrade 发送人工下单请求并返回状态码
+func postTrade(h *server.Hertz, body string) int {
+	return ut.PerformRequest(h.Engine, http.MethodPost, "/api/trade",
+		&ut.Body{Body: strings.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"}).Result().StatusCode()
+}
+
+// TestManualTradeValidation tests that invalid manual orders are rejected before anything is sent
+// TestManualTradeValidation 测试无效的人工订单在发送前即被拒绝
+func TestManualTradeValidation(t *testing.T) {
+	dbPath := "./test_manual_trade_validation.db"
+	defer os.Remove(dbPath)
+	db, err := storage.NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer db.Close()
+
+	_, h := newTradeTestServer(&config.Config{CryptoSymbols: []string{"BTC/USDT"}, PaperTrading: true}, db)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown symbol", `{"symbol":"DOGEUSDT","action":"BUY","position_size_percent":10}`},
+		{"unknown action", `{"symbol":"BTCUSDT","action":"HOLD","position_size_percent":10}`},
+		{"zero size", `{"symbol":"BTCUSDT","action":"BUY","position_size_percent":0}`},
+		{"size above 100", `{"symbol":"BTCUSDT","action":"SELL","position_size_percent":150}`},
+		{"negative stop", `{"symbol":"BTCUSDT","action":"BUY","position_size_percent":10,"stop_loss":-1}`},
+	}
+	for _, tt := range tests {
+		if status := postTrade(h, tt.body); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.name, status)
+		}
+	}
+}
+
+// TestManualTradeFreezeGate tests that manual entries are refused during the freeze window and when the check fails
+// TestManualTradeFreezeGate 测试冻结观察期内以及检查失败时人工开仓被拒绝
+func TestManualTradeFreezeGate(t *testing.T) {
+	dbPath := "./test_manual_trade_freeze.db"
+	defer os.Remove(dbPath)
+	db, err := storage.NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	// Record a baseline, then change the risk settings so the server's config opens a window
+	// 先记录基准版本，再修改风控配置，使服务器配置开启观察期
+	baseline := &config.Config{CryptoSymbols: []string{"BTC/USDT"}, PaperTrading: true, RiskMaxPositions: 1}
+	if _, err := risk.CheckFreeze(db, baseline); err != nil {
+		t.Fatalf("CheckFreeze failed: %v", err)
+	}
+	cfg := &config.Config{CryptoSymbols: []string{"BTC/USDT"}, PaperTrading: true, RiskMaxPositions: 2, FreezeCycles: 2}
+	s, h := newTradeTestServer(cfg, db)
+
+	body := `{"symbol":"BTCUSDT","action":"BUY","position_size_percent":10}`
+	if status := postTrade(h, body); status != http.StatusForbidden {
+		t.Errorf("frozen: status = %d, want 403", status)
+	}
+	if s.stopLossManager.GetPosition("BTCUSDT") != nil {
+		t.Error("No position should be registered during the freeze window")
+	}
+
+	// A failed freeze check refuses the entry instead of trading unchecked
+	// 冻结检查失败时拒绝开仓，而不是跳过检查直接交易
+	db.Close()
+	if status := postTrade(h, body); status != http.StatusServiceUnavailable {
+		t.Errorf("freeze check error: status = %d, want 503", status)
+	}
+}
+
+// TestManualTradeKillSwitch tests that manual entries are refused while the kill switch is engaged
+// TestManualTradeKillSwitch 测试急停开关启用期间人工开仓被拒绝
+func TestManualTradeKillSwitch(t *testing.T) {
+	dbPath := "./test_manual_trade_killswitch.db"
+	defer os.Remove(dbPath)
+	db, err := storage.NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{CryptoSymbols: []string{"BTC/USDT"}, PaperTrading: true}
+	s, h := newTradeTestServer(cfg, db)
+	s.killSwitch = executors.NewKillSwitch(db, cfg, s.logger)
+	if _, _, err := s.killSwitch.Engage("test", executors.KillSwitchSourceAPI, false); err != nil {
+		t.Fatalf("Engage failed: %v", err)
+	}
+
+	if status := postTrade(h, `{"symbol":"BTCUSDT","action":"BUY","position_size_percent":10}`); status != http.StatusConflict {
+		t.Errorf("kill switch: status = %d, want 409", status)
+	}
+	if s.stopLossManager.GetPosition("BTCUSDT") != nil {
+		t.Error("No position should be registered while the kill switch is engaged")
+	}
+}
+
+// TestValidateManualStop tests that the stop-loss must be on the losing side of the entry
+// TestValidateManualStop 测试止损价必须位于入场价的亏损一侧
+func TestValidateManualStop(t *testing.T) {
+	tests := []struct {
+		action executors.TradeAction
+		stop   float64
+		valid  bool
+	}{
+		{executors.ActionBuy, 0, true},
+		{executors.ActionBuy, 95, true},
+		{executors.ActionBuy, 100, false},
+		{executors.ActionBuy, 105, false},
+		{executors.ActionSell, 0, true},
+		{executors.ActionSell, 105, true},
+		{executors.ActionSell, 100, false},
+		{executors.ActionSell, 95, false},
+	}
+	for _, tt := range tests {
+		err := validateManualStop(tt.action, 100, tt.stop)
+		if (err == nil) != tt.valid {
+			t.Errorf("validateManualStop(%s, 100, %.0f) = %v, want valid %v", tt.action, tt.stop, err, tt.valid)
+		}
+	}
+}
+
+// TestValidateManualTakeProfit tests that the take-profit must be on the winning side of the entry
+// TestValidateManualTakeProfit 测试止盈价必须位于入场价的盈利一侧
+func TestValidateManualTakeProfit(t *testing.T) {
+	tests := []struct {
+		action     executors.TradeAction
+		takeProfit float64
+		valid      bool
+	}{
+		{executors.ActionBuy, 0, true},
+		{executors.ActionBuy, 110, true},
+		{executors.ActionBuy, 100, false},
+		{executors.ActionBuy, 95, false},
+		{executors.ActionSell, 0, true},
+		{executors.ActionSell, 90, true},
+		{executors.ActionSell, 100, false},
+		{executors.ActionSell, 105, false},
+	}
+	for _, tt := range tests {
+		err := validateManualTakeProfit(tt.action, 100, tt.takeProfit)
+		if (err == nil) != tt.valid {
+			t.Errorf("validateManualTakeProfit(%s, 100, %.0f) = %v, want valid %v", tt.action, tt.takeProfit, err, tt.valid)
+		}
+	}
+}
+
+// TestManualCloseRealizedPnL tests that the realized PnL of a manual close uses the executed price and quantity
+// TestManualCloseRealizedPnL 测试人工平仓的已实现盈亏使用实际成交价和成交数量
+func TestManualCloseRealizedPnL(t *testing.T) {
+	long := &executors.Position{Side: "long", EntryPrice: 100, Size: 2, UnrealizedPnL: 50}
+	short := &executors.Position{Side: "short", EntryPrice: 100, Size: 2, UnrealizedPnL: 50}
+
+	tests := []struct {
+		name     string
+		position *executors.Position
+		result   *executors.TradeResult
+		want     float64
+	}{
+		{"long filled", long, &executors.TradeResult{Price: 110, Filled: 2}, 20},
+		{"long partial fill", long, &executors.TradeResult{Price: 90, Filled: 1}, -10},
+		{"short amount only", short, &executors.TradeResult{Price: 95, Amount: 2}, 10},
+		{"short falls back to size", short, &executors.TradeResult{Price: 104}, -8},
+	}
+	for _, tt := range tests {
+		got := tt.position.RealizedPnLAt(tt.result.Price, closedQuantity(tt.position, tt.result))
+		if got != tt.want {
+			t.Errorf("%s: realized PnL = %.2f, want %.2f (not the stale unrealized PnL)", tt.name, got, tt.want)
+		}
+	}
+}